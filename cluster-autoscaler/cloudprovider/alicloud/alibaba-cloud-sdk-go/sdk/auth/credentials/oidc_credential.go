@@ -23,6 +23,9 @@ type OIDCCredential struct {
 	OIDCTokenFilePath     string
 	RoleSessionName       string
 	RoleSessionExpiration int
+	// STSEndpoint is the endpoint of the STS service the role is assumed with, the
+	// central sts.aliyuncs.com endpoint if empty.
+	STSEndpoint string
 }
 
 // NewOIDCRoleArnCredential returns OIDCCredential
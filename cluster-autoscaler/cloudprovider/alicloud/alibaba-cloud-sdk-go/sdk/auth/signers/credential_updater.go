@@ -49,7 +49,11 @@ func (updater *credentialUpdater) updateCredential() (err error) {
 	if err != nil {
 		return
 	}
+	// Only successful refreshes are recorded, so that failed ones are retried on the next request
+	// instead of once the previous credential expired.
+	if err = updater.responseCallBack(response); err != nil {
+		return
+	}
 	updater.lastUpdateTimestamp = time.Now().Unix()
-	err = updater.responseCallBack(response)
 	return
 }
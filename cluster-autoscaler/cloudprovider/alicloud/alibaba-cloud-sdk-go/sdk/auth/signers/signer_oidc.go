@@ -36,6 +36,7 @@ import (
 
 const (
 	defaultOIDCDurationSeconds = 3600
+	defaultSTSEndpoint         = "sts.aliyuncs.com"
 )
 
 // OIDCSigner is kind of signer
@@ -122,7 +123,10 @@ func (signer *OIDCSigner) Sign(stringToSign, secretSuffix string) string {
 }
 
 func (signer *OIDCSigner) buildCommonRequest() (request *requests.CommonRequest, err error) {
-	const endpoint = "sts.aliyuncs.com"
+	endpoint := defaultSTSEndpoint
+	if len(signer.credential.STSEndpoint) > 0 {
+		endpoint = signer.credential.STSEndpoint
+	}
 	const stsApiVersion = "2015-04-01"
 	const action = "AssumeRoleWithOIDC"
 	request = requests.NewCommonRequest()
@@ -225,11 +229,16 @@ func (signer *OIDCSigner) refreshCredential(response *responses.CommonResponse)
 		return
 	}
 
-	if accessKeyId == nil || accessKeySecret == nil || securityToken == nil {
+	if accessKeyId == nil || accessKeySecret == nil || securityToken == nil || expiration == nil {
+		err = errors.NewServerError(response.GetHttpStatus(), response.GetHttpContentString(), "refresh RRSA failed, credentials are missing")
 		return
 	}
 
 	expirationTime, err := time.Parse("2006-01-02T15:04:05Z", expiration.(string))
+	if err != nil {
+		klog.Errorf("refresh RRSA token err, fail to parse Expiration: %s", err)
+		return
+	}
 	signer.credentialExpiration = int(expirationTime.Unix() - time.Now().Unix())
 	signer.sessionCredential = &SessionCredential{
 		AccessKeyId:     accessKeyId.(string),
@@ -18,6 +18,7 @@ package alicloud
 
 import (
 	"fmt"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/sdk"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/sdk/requests"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/services/ess"
 	klog "k8s.io/klog/v2"
@@ -25,7 +26,6 @@ import (
 )
 
 const (
-	refreshClientInterval   = 60 * time.Minute
 	acsAutogenIncreaseRules = "acs-autogen-increase-rules"
 	defaultAdjustmentType   = "TotalCapacity"
 	defaultRequestPageSize  = 10
@@ -53,21 +53,6 @@ func newAutoScalingWrapper(cfg *cloudConfig) (*autoScalingWrapper, error) {
 	asw := &autoScalingWrapper{
 		cfg: cfg,
 	}
-	if cfg.STSEnabled {
-		go func(asw *autoScalingWrapper, cfg *cloudConfig) {
-			timer := time.NewTicker(refreshClientInterval)
-			defer timer.Stop()
-			for {
-				select {
-				case <-timer.C:
-					client, err := getEssClient(cfg)
-					if err == nil {
-						asw.autoScaling = client
-					}
-				}
-			}
-		}(asw, cfg)
-	}
 	client, err := getEssClient(cfg)
 	if err == nil {
 		asw.autoScaling = client
@@ -78,17 +63,18 @@ func newAutoScalingWrapper(cfg *cloudConfig) (*autoScalingWrapper, error) {
 func getEssClient(cfg *cloudConfig) (client *ess.Client, err error) {
 	region := cfg.getRegion()
 	if cfg.STSEnabled {
-		auth, err := cfg.getSTSToken()
+		roleName, err := cfg.getRoleName()
 		if err != nil {
-			klog.Errorf("Failed to get sts token from metadata,Because of %s", err.Error())
+			klog.Errorf("Failed to get the RAM role name from metadata, because of %s", err.Error())
 			return nil, err
 		}
-		client, err = ess.NewClientWithStsToken(region, auth.AccessKeyId, auth.AccessKeySecret, auth.SecurityToken)
+		// The sts token of the role is read from metadata and refreshed before it expires.
+		client, err = ess.NewClientWithEcsRamRole(region, roleName)
 		if err != nil {
-			klog.Errorf("Failed to create client with sts in metadata because of %s", err.Error())
+			klog.Errorf("Failed to create ess client with the RAM role of the instance, because of %s", err.Error())
 		}
 	} else if cfg.RRSAEnabled {
-		client, err = ess.NewClientWithRRSA(region, cfg.RoleARN, cfg.OIDCProviderARN, cfg.OIDCTokenFilePath, cfg.RoleSessionName)
+		client, err = ess.NewClientWithOptions(region, sdk.NewConfig(), cfg.getRRSACredential())
 		if err != nil {
 			klog.Errorf("Failed to create ess client with RRSA, because of %s", err.Error())
 		}
@@ -17,6 +17,8 @@ limitations under the License.
 package alicloud
 
 import (
+	"fmt"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/sdk/auth/credentials"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/metadata"
 	"k8s.io/klog/v2"
 	"os"
@@ -30,6 +32,10 @@ const (
 	roleARN           = "ALICLOUD_ROLE_ARN"
 	roleSessionName   = "ALICLOUD_SESSION_NAME"
 	regionId          = "REGION_ID"
+	// stsEndpoint is the STS endpoint the RRSA role is assumed with, e.g. a VPC endpoint.
+	stsEndpoint = "ALICLOUD_STS_ENDPOINT"
+	// stsRegionalEndpoint set to "true" makes the RRSA role assumed with the STS endpoint of the region.
+	stsRegionalEndpoint = "ALICLOUD_STS_REGIONAL_ENDPOINT"
 )
 
 type cloudConfig struct {
@@ -40,6 +46,8 @@ type cloudConfig struct {
 	OIDCTokenFilePath string
 	RoleARN           string
 	RoleSessionName   string
+	STSEndpoint       string
+	RegionalSTS       bool
 	RRSAEnabled       bool
 	STSEnabled        bool
 }
@@ -73,6 +81,14 @@ func (cc *cloudConfig) isValid() bool {
 		cc.RoleSessionName = os.Getenv(roleSessionName)
 	}
 
+	if cc.STSEndpoint == "" {
+		cc.STSEndpoint = os.Getenv(stsEndpoint)
+	}
+
+	if !cc.RegionalSTS {
+		cc.RegionalSTS = os.Getenv(stsRegionalEndpoint) == "true"
+	}
+
 	if cc.RegionId != "" && cc.AccessKeyID != "" && cc.AccessKeySecret != "" {
 		klog.V(2).Info("Using AccessKey authentication")
 		return true
@@ -95,8 +111,7 @@ func (cc *cloudConfig) isValid() bool {
 }
 
 func (cc *cloudConfig) validateSTSToken() bool {
-	m := metadata.NewMetaData(nil)
-	r, err := m.RoleName()
+	r, err := cc.getRoleName()
 	if err != nil || r == "" {
 		klog.Warningf("The role name %s is not valid and error is %v", r, err)
 		return false
@@ -104,17 +119,30 @@ func (cc *cloudConfig) validateSTSToken() bool {
 	return true
 }
 
-func (cc *cloudConfig) getSTSToken() (metadata.RoleAuth, error) {
+// getRoleName returns the name of the RAM role of the ECS instance, whose STS token
+// is read from metadata and refreshed by the client before it expires.
+func (cc *cloudConfig) getRoleName() (string, error) {
 	m := metadata.NewMetaData(nil)
-	r, err := m.RoleName()
-	if err != nil {
-		return metadata.RoleAuth{}, err
+	return m.RoleName()
+}
+
+// getRRSACredential returns the credential assuming the RAM role with the OIDC token of
+// the service account. The client refreshes it before it expires.
+func (cc *cloudConfig) getRRSACredential() *credentials.OIDCCredential {
+	credential := credentials.NewOIDCRoleArnCredential(cc.RoleARN, cc.OIDCProviderARN, cc.OIDCTokenFilePath, cc.RoleSessionName, 0)
+	credential.STSEndpoint = cc.getSTSEndpoint()
+	return credential
+}
+
+// getSTSEndpoint returns the STS endpoint the RRSA role is assumed with, empty for the central endpoint.
+func (cc *cloudConfig) getSTSEndpoint() string {
+	if cc.STSEndpoint != "" {
+		return cc.STSEndpoint
 	}
-	auth, err := m.RamRoleToken(r)
-	if err != nil {
-		return metadata.RoleAuth{}, err
+	if cc.RegionalSTS {
+		return fmt.Sprintf("sts.%s.aliyuncs.com", cc.getRegion())
 	}
-	return auth, nil
+	return ""
 }
 
 func (cc *cloudConfig) getRegion() string {
@@ -42,3 +42,28 @@ func TestRRSACloudConfigIsValid(t *testing.T) {
 	assert.True(t, cfg.isValid())
 	assert.True(t, cfg.RRSAEnabled)
 }
+
+func TestRRSACloudConfigSTSEndpoint(t *testing.T) {
+	t.Setenv(oidcProviderARN, "acs:ram::12345:oidc-provider/ack-rrsa-cb123")
+	t.Setenv(oidcTokenFilePath, "/var/run/secrets/tokens/oidc-token")
+	t.Setenv(roleARN, "acs:ram::12345:role/autoscaler-role")
+	t.Setenv(roleSessionName, "session")
+	t.Setenv(regionId, "cn-hangzhou")
+
+	cfg := &cloudConfig{}
+	assert.True(t, cfg.isValid())
+	assert.Equal(t, "", cfg.getRRSACredential().STSEndpoint)
+
+	t.Setenv(stsRegionalEndpoint, "true")
+	cfg = &cloudConfig{}
+	assert.True(t, cfg.isValid())
+	credential := cfg.getRRSACredential()
+	assert.Equal(t, "sts.cn-hangzhou.aliyuncs.com", credential.STSEndpoint)
+	assert.Equal(t, "acs:ram::12345:role/autoscaler-role", credential.RoleArn)
+	assert.Equal(t, "/var/run/secrets/tokens/oidc-token", credential.OIDCTokenFilePath)
+
+	t.Setenv(stsEndpoint, "sts-vpc.cn-hangzhou.aliyuncs.com")
+	cfg = &cloudConfig{}
+	assert.True(t, cfg.isValid())
+	assert.Equal(t, "sts-vpc.cn-hangzhou.aliyuncs.com", cfg.getRRSACredential().STSEndpoint)
+}
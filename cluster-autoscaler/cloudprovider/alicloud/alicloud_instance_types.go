@@ -18,10 +18,10 @@ package alicloud
 
 import (
 	"fmt"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/sdk"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/services/ecs"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/services/ess"
 	klog "k8s.io/klog/v2"
-	"time"
 )
 
 type ecsInstance interface {
@@ -107,21 +107,6 @@ func newInstanceWrapper(cfg *cloudConfig) (*instanceWrapper, error) {
 		return nil, fmt.Errorf("your cloud config is not valid")
 	}
 	iw := &instanceWrapper{}
-	if cfg.STSEnabled {
-		go func(iw *instanceWrapper, cfg *cloudConfig) {
-			timer := time.NewTicker(refreshClientInterval)
-			defer timer.Stop()
-			for {
-				select {
-				case <-timer.C:
-					client, err := getEcsClient(cfg)
-					if err == nil {
-						iw.ecsInstance = client
-					}
-				}
-			}
-		}(iw, cfg)
-	}
 	client, err := getEcsClient(cfg)
 	if err == nil {
 		iw.ecsInstance = client
@@ -131,18 +116,19 @@ func newInstanceWrapper(cfg *cloudConfig) (*instanceWrapper, error) {
 
 func getEcsClient(cfg *cloudConfig) (client *ecs.Client, err error) {
 	region := cfg.getRegion()
-	if cfg.STSEnabled == true {
-		auth, err := cfg.getSTSToken()
+	if cfg.STSEnabled {
+		roleName, err := cfg.getRoleName()
 		if err != nil {
-			klog.Errorf("failed to get sts token from metadata,because of %s", err.Error())
+			klog.Errorf("Failed to get the RAM role name from metadata, because of %s", err.Error())
 			return nil, err
 		}
-		client, err = ecs.NewClientWithStsToken(region, auth.AccessKeyId, auth.AccessKeySecret, auth.SecurityToken)
+		// The sts token of the role is read from metadata and refreshed before it expires.
+		client, err = ecs.NewClientWithEcsRamRole(region, roleName)
 		if err != nil {
-			klog.Errorf("failed to create client with sts in metadata,because of %s", err.Error())
+			klog.Errorf("Failed to create ecs client with the RAM role of the instance, because of %s", err.Error())
 		}
 	} else if cfg.RRSAEnabled {
-		client, err = ecs.NewClientWithRRSA(region, cfg.RoleARN, cfg.OIDCProviderARN, cfg.OIDCTokenFilePath, cfg.RoleSessionName)
+		client, err = ecs.NewClientWithOptions(region, sdk.NewConfig(), cfg.getRRSACredential())
 		if err != nil {
 			klog.Errorf("Failed to create ess client with RRSA, because of %s", err.Error())
 		}
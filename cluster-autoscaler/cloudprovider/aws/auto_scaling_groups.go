@@ -23,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/ec2"
@@ -50,6 +51,20 @@ type asgCache struct {
 	asgAutoDiscoverySpecs []asgAutoDiscoveryConfig
 	explicitlyConfigured  map[AwsRef]bool
 	autoscalingOptions    map[AwsRef]map[string]string
+
+	// describedGroups are the ASGs as described by the last refresh, used to detect
+	// the ASGs changed since then.
+	describedGroups map[AwsRef]*autoscaling.Group
+	// unfulfillableErrorKinds are the kinds of the errors which failed the last scaling
+	// activity of the ASGs which can't provision their placeholder instances.
+	unfulfillableErrorKinds map[AwsRef]cloudprovider.ErrorKind
+}
+
+// asgCacheDelta counts the ASGs added, updated and removed by a refresh.
+type asgCacheDelta struct {
+	added   int
+	updated int
+	removed int
 }
 
 type launchTemplate struct {
@@ -92,6 +107,7 @@ func newASGCache(awsService *awsWrapper, explicitSpecs []string, autoDiscoverySp
 		asgAutoDiscoverySpecs: autoDiscoverySpecs,
 		explicitlyConfigured:  make(map[AwsRef]bool),
 		autoscalingOptions:    make(map[AwsRef]map[string]string),
+		describedGroups:       make(map[AwsRef]*autoscaling.Group),
 	}
 
 	if err := registry.parseExplicitAsgs(explicitSpecs); err != nil {
@@ -243,6 +259,15 @@ func (m *asgCache) InstanceStatus(ref AwsInstanceRef) (*string, error) {
 	return nil, fmt.Errorf("could not find instance %v", ref)
 }
 
+// UnfulfillableErrorKind returns the kind of the error which prevents the ASG from provisioning
+// its placeholder instances.
+func (m *asgCache) UnfulfillableErrorKind(ref AwsRef) cloudprovider.ErrorKind {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.unfulfillableErrorKinds[ref]
+}
+
 func (m *asgCache) findInstanceLifecycle(ref AwsInstanceRef) (*string, error) {
 	if lifecycle, found := m.instanceLifecycle[ref]; found {
 		return lifecycle, nil
@@ -269,7 +294,7 @@ func (m *asgCache) setAsgSizeNoLock(asg *asg, size int) error {
 	_, err := m.awsService.SetDesiredCapacity(params)
 	observeAWSRequest("SetDesiredCapacity", err, start)
 	if err != nil {
-		return err
+		return classifyAWSError(err)
 	}
 
 	// Proactively set the ASG size so autoscaler makes better decisions
@@ -393,21 +418,13 @@ func (m *asgCache) regenerate() error {
 
 	// Fetch details of all ASGs
 	refreshNames := m.buildAsgNames()
-	klog.V(4).Infof("Regenerating instance to ASG map for ASG names: %v", refreshNames)
-	namedGroups, err := m.awsService.getAutoscalingGroupsByNames(refreshNames)
-	if err != nil {
-		return err
-	}
-
 	refreshTags := m.buildAsgTags()
-	klog.V(4).Infof("Regenerating instance to ASG map for ASG tags: %v", refreshTags)
-	taggedGroups, err := m.awsService.getAutoscalingGroupsByTags(refreshTags)
+	klog.V(4).Infof("Regenerating instance to ASG map for ASG names: %v and ASG tags: %v", refreshNames, refreshTags)
+	groups, err := m.awsService.getAutoscalingGroups(refreshNames, refreshTags)
 	if err != nil {
 		return err
 	}
 
-	groups := append(namedGroups, taggedGroups...)
-
 	// If currently any ASG has more Desired than running Instances, introduce placeholders
 	// for the instances to come up. This is required to track Desired instances that
 	// will never come up, like with Spot Request that can't be fulfilled
@@ -415,6 +432,8 @@ func (m *asgCache) regenerate() error {
 
 	// Register or update ASGs
 	exists := make(map[AwsRef]bool)
+	newDescribedGroups := make(map[AwsRef]*autoscaling.Group, len(groups))
+	delta := asgCacheDelta{}
 	for _, group := range groups {
 		asg, err := m.buildAsgFromAWS(group)
 		if err != nil {
@@ -422,6 +441,18 @@ func (m *asgCache) regenerate() error {
 		}
 		exists[asg.AwsRef] = true
 
+		previous, found := m.describedGroups[asg.AwsRef]
+		if !found {
+			delta.added++
+		} else if !reflect.DeepEqual(previous, group) {
+			delta.updated++
+			if launchSpecChanged(previous, group) {
+				// Don't wait for the TTL to pick up the instance type of the new launch specification.
+				_ = m.asgInstanceTypeCache.Delete(instanceTypeCachedObject{name: asg.AwsRef.Name})
+			}
+		}
+		newDescribedGroups[asg.AwsRef] = group
+
 		asg = m.register(asg)
 
 		newAsgToInstancesCache[asg.AwsRef] = make([]AwsInstanceRef, len(group.Instances))
@@ -462,10 +493,27 @@ func (m *asgCache) regenerate() error {
 	m.autoscalingOptions = newAutoscalingOptions
 	m.instanceStatus = newInstanceStatusMap
 	m.instanceLifecycle = newInstanceLifecycleMap
+
+	for ref := range m.describedGroups {
+		if _, found := newDescribedGroups[ref]; !found {
+			delta.removed++
+		}
+	}
+	m.describedGroups = newDescribedGroups
+	klog.V(4).Infof("Regenerated ASG cache: %d ASGs added, %d updated, %d removed", delta.added, delta.updated, delta.removed)
+	observeASGCacheDelta(delta)
 	return nil
 }
 
+// launchSpecChanged tells whether the launch configuration, template or mixed instances policy of an ASG changed.
+func launchSpecChanged(previous, current *autoscaling.Group) bool {
+	return aws.StringValue(previous.LaunchConfigurationName) != aws.StringValue(current.LaunchConfigurationName) ||
+		!reflect.DeepEqual(previous.LaunchTemplate, current.LaunchTemplate) ||
+		!reflect.DeepEqual(previous.MixedInstancesPolicy, current.MixedInstancesPolicy)
+}
+
 func (m *asgCache) createPlaceholdersForDesiredNonStartedInstances(groups []*autoscaling.Group) []*autoscaling.Group {
+	m.unfulfillableErrorKinds = make(map[AwsRef]cloudprovider.ErrorKind)
 	for _, g := range groups {
 		desired := *g.DesiredCapacity
 		realInstances := int64(len(g.Instances))
@@ -477,12 +525,13 @@ func (m *asgCache) createPlaceholdersForDesiredNonStartedInstances(groups []*aut
 			"Creating placeholder instances.", *g.AutoScalingGroupName, realInstances, desired)
 
 		healthStatus := ""
-		isAvailable, err := m.isNodeGroupAvailable(g)
+		isAvailable, errorKind, err := m.isNodeGroupAvailable(g)
 		if err != nil {
 			klog.V(4).Infof("Could not check instance availability, creating placeholder node anyways: %v", err)
 		} else if !isAvailable {
 			klog.Warningf("Instance group %s cannot provision any more nodes!", *g.AutoScalingGroupName)
 			healthStatus = placeholderUnfulfillableStatus
+			m.unfulfillableErrorKinds[AwsRef{Name: *g.AutoScalingGroupName}] = errorKind
 		}
 
 		for i := realInstances; i < desired; i++ {
@@ -497,7 +546,9 @@ func (m *asgCache) createPlaceholdersForDesiredNonStartedInstances(groups []*aut
 	return groups
 }
 
-func (m *asgCache) isNodeGroupAvailable(group *autoscaling.Group) (bool, error) {
+// isNodeGroupAvailable returns false if a scaling activity of the ASG failed since its last
+// update, together with the kind of the error which failed it.
+func (m *asgCache) isNodeGroupAvailable(group *autoscaling.Group) (bool, cloudprovider.ErrorKind, error) {
 	input := &autoscaling.DescribeScalingActivitiesInput{
 		AutoScalingGroupName: group.AutoScalingGroupName,
 	}
@@ -506,7 +557,7 @@ func (m *asgCache) isNodeGroupAvailable(group *autoscaling.Group) (bool, error)
 	response, err := m.awsService.DescribeScalingActivities(input)
 	observeAWSRequest("DescribeScalingActivities", err, start)
 	if err != nil {
-		return true, err // If we can't describe the scaling activities we assume the node group is available
+		return true, cloudprovider.UnknownErrorKind, err // If we can't describe the scaling activities we assume the node group is available
 	}
 
 	for _, activity := range response.Activities {
@@ -517,13 +568,13 @@ func (m *asgCache) isNodeGroupAvailable(group *autoscaling.Group) (bool, error)
 				break
 			} else if *activity.StatusCode == "Failed" {
 				klog.Warningf("ASG %s scaling failed with %s", asgRef.Name, *activity)
-				return false, nil
+				return false, scalingActivityErrorKind(activity), nil
 			}
 		} else {
 			klog.V(4).Infof("asg %v is not registered yet, skipping DescribeScalingActivities check", asgRef.Name)
 		}
 	}
-	return true, nil
+	return true, cloudprovider.UnknownErrorKind, nil
 }
 
 func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
@@ -577,9 +628,13 @@ func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 			instanceRequirementsOverrides: getInstanceTypeRequirements(g.MixedInstancesPolicy.LaunchTemplate.Overrides),
 		}
 
-		instanceRequirements, err := m.getInstanceRequirementsFromMixedInstancesPolicy(asg.MixedInstancesPolicy)
-		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve instance requirements from mixed instance policy, err: %v", err)
+		instanceRequirements, found := m.cachedInstanceRequirements(g)
+		if !found {
+			var err error
+			instanceRequirements, err = m.getInstanceRequirementsFromMixedInstancesPolicy(asg.MixedInstancesPolicy)
+			if err != nil {
+				return nil, fmt.Errorf("unable to retrieve instance requirements from mixed instance policy, err: %v", err)
+			}
 		}
 		asg.MixedInstancesPolicy.instanceRequirements = instanceRequirements
 
@@ -591,6 +646,33 @@ func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 	return asg, nil
 }
 
+// cachedInstanceRequirements returns the instance requirements of the registered ASG, if its mixed
+// instances policy didn't change since the last refresh. This saves describing the launch template
+// of every ASG at every refresh. Requirements read from the $Latest or $Default version of a launch
+// template are never cached, as these versions change without the ASG changing.
+func (m *asgCache) cachedInstanceRequirements(g *autoscaling.Group) (*ec2.InstanceRequirements, bool) {
+	ref := AwsRef{Name: aws.StringValue(g.AutoScalingGroupName)}
+	previous, found := m.describedGroups[ref]
+	if !found || !reflect.DeepEqual(previous.MixedInstancesPolicy, g.MixedInstancesPolicy) {
+		return nil, false
+	}
+	registered, found := m.registeredAsgs[ref]
+	if !found || registered.MixedInstancesPolicy == nil || registered.MixedInstancesPolicy.instanceRequirements == nil {
+		return nil, false
+	}
+	policy := registered.MixedInstancesPolicy
+	if policy.instanceRequirementsOverrides == nil && policy.launchTemplate != nil && isFloatingLaunchTemplateVersion(policy.launchTemplate.version) {
+		return nil, false
+	}
+	return policy.instanceRequirements, true
+}
+
+// isFloatingLaunchTemplateVersion returns whether the launch template version refers to a version
+// which may change, rather than to a fixed version number.
+func isFloatingLaunchTemplateVersion(version string) bool {
+	return version == "" || version == "$Latest" || version == "$Default"
+}
+
 func (m *asgCache) getInstanceRequirementsFromMixedInstancesPolicy(policy *mixedInstancesPolicy) (*ec2.InstanceRequirements, error) {
 	instanceRequirements := &ec2.InstanceRequirements{}
 	if policy.instanceRequirementsOverrides != nil {
@@ -22,8 +22,11 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/ec2"
 )
 
 func TestBuildAsg(t *testing.T) {
@@ -81,8 +84,9 @@ func TestCreatePlaceholders(t *testing.T) {
 			desiredCapacity: aws.Int64(1),
 			activities: []*autoscaling.Activity{
 				{
-					StatusCode: aws.String("Failed"),
-					StartTime:  aws.Time(time.Unix(10, 0)),
+					StatusCode:    aws.String("Failed"),
+					StatusMessage: aws.String("We currently do not have sufficient p3.2xlarge capacity in the Availability Zone you requested. Launching EC2 instance failed."),
+					StartTime:     aws.Time(time.Unix(10, 0)),
 				},
 			},
 			groupLastUpdateTime: time.Unix(9, 0),
@@ -159,6 +163,7 @@ func TestCreatePlaceholders(t *testing.T) {
 			assert.Equal(t, int64(len(groups[0].Instances)), *tc.desiredCapacity)
 			if tc.activities != nil && *tc.activities[0].StatusCode == "Failed" && tc.activities[0].StartTime.After(tc.groupLastUpdateTime) && asgName == registeredAsgName {
 				assert.Equal(t, *groups[0].Instances[0].HealthStatus, placeholderUnfulfillableStatus)
+				assert.Equal(t, cloudprovider.CapacityErrorKind, asgCache.UnfulfillableErrorKind(registeredAsgRef))
 			} else if len(groups[0].Instances) > 0 {
 				assert.Equal(t, *groups[0].Instances[0].HealthStatus, "")
 			}
@@ -166,3 +171,77 @@ func TestCreatePlaceholders(t *testing.T) {
 		})
 	}
 }
+
+func TestRegenerateDetectsChangedAsgs(t *testing.T) {
+	a := &autoScalingMock{}
+	e := &ec2Mock{}
+	describe := func(ltVersion string, instanceIds ...string) {
+		output := testNamedDescribeAutoScalingGroupsOutput("test-asg", int64(len(instanceIds)), instanceIds...)
+		output.AutoScalingGroups[0].MixedInstancesPolicy = &autoscaling.MixedInstancesPolicy{
+			LaunchTemplate: &autoscaling.LaunchTemplate{
+				LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateName: aws.String("lt"),
+					Version:            aws.String(ltVersion),
+				},
+				Overrides: []*autoscaling.LaunchTemplateOverrides{{InstanceType: aws.String("t3.large")}},
+			},
+		}
+		a.On("DescribeAutoScalingGroupsPages",
+			mock.Anything,
+			mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+		).Run(func(args mock.Arguments) {
+			fn := args.Get(1).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+			fn(output, false)
+		}).Return(nil).Once()
+	}
+	for _, ltVersion := range []string{"1", "2", "$Latest"} {
+		e.On("DescribeLaunchTemplateVersions", &ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateName: aws.String("lt"),
+			Versions:           []*string{aws.String(ltVersion)},
+		}).Return(&ec2.DescribeLaunchTemplateVersionsOutput{
+			LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{{LaunchTemplateData: &ec2.ResponseLaunchTemplateData{}}},
+		})
+	}
+
+	cache, err := newASGCache(&awsWrapper{a, e, nil}, []string{"1:5:test-asg"}, nil)
+	assert.NoError(t, err)
+	ref := AwsRef{Name: "test-asg"}
+
+	describe("1", "i-1")
+	assert.NoError(t, cache.regenerate())
+	assert.Equal(t, 1, cache.Get()[ref].curSize)
+	e.AssertNumberOfCalls(t, "DescribeLaunchTemplateVersions", 1)
+
+	// The launch template of unchanged ASGs isn't described again.
+	describe("1", "i-1")
+	assert.NoError(t, cache.regenerate())
+	describe("1", "i-1", "i-2")
+	assert.NoError(t, cache.regenerate())
+	assert.Equal(t, 2, cache.Get()[ref].curSize)
+	e.AssertNumberOfCalls(t, "DescribeLaunchTemplateVersions", 1)
+
+	describe("2", "i-1", "i-2")
+	assert.NoError(t, cache.regenerate())
+	assert.Equal(t, "2", cache.Get()[ref].MixedInstancesPolicy.launchTemplate.version)
+	e.AssertNumberOfCalls(t, "DescribeLaunchTemplateVersions", 2)
+
+	// The $Latest version may change without the ASG changing, so it's described at every refresh.
+	describe("$Latest", "i-1", "i-2")
+	assert.NoError(t, cache.regenerate())
+	describe("$Latest", "i-1", "i-2")
+	assert.NoError(t, cache.regenerate())
+	e.AssertNumberOfCalls(t, "DescribeLaunchTemplateVersions", 4)
+}
+
+func TestLaunchSpecChanged(t *testing.T) {
+	group := func(launchConfigurationName string, ltVersion string) *autoscaling.Group {
+		return &autoscaling.Group{
+			LaunchConfigurationName: aws.String(launchConfigurationName),
+			LaunchTemplate:          &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("lt"), Version: aws.String(ltVersion)},
+			DesiredCapacity:         aws.Int64(1),
+		}
+	}
+	assert.False(t, launchSpecChanged(group("lc", "1"), group("lc", "1")))
+	assert.True(t, launchSpecChanged(group("lc", "1"), group("lc-2", "1")))
+	assert.True(t, launchSpecChanged(group("lc", "1"), group("lc", "2")))
+}
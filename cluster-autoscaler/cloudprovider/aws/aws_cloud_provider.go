@@ -29,6 +29,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	"k8s.io/client-go/informers"
 	klog "k8s.io/klog/v2"
 	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
 )
@@ -277,7 +278,18 @@ func (ng *AwsNodeGroup) IncreaseSize(delta int) error {
 	if size+delta > ng.asg.maxSize {
 		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.asg.maxSize)
 	}
-	return ng.awsManager.SetAsgSize(ng.asg, size+delta)
+	if err := ng.awsManager.SetAsgSize(ng.asg, size+delta); err != nil {
+		return err
+	}
+	ng.awsManager.capacityReservations.consume(ng.asg, delta)
+	return nil
+}
+
+// AvailableReservedCapacity returns the number of instances which can still be launched in the
+// Capacity Reservations or Capacity Blocks for ML targeted by the ASG, and false if it doesn't
+// target any reservation.
+func (ng *AwsNodeGroup) AvailableReservedCapacity() (int, bool) {
+	return ng.awsManager.AvailableReservedCapacity(ng.asg)
 }
 
 // AtomicIncreaseSize is not implemented.
@@ -372,10 +384,16 @@ func (ng *AwsNodeGroup) Nodes() ([]cloudprovider.Instance, error) {
 		if err != nil {
 			klog.V(4).Infof("Could not get instance status, continuing anyways: %v", err)
 		} else if instanceStatusString != nil && *instanceStatusString == placeholderUnfulfillableStatus {
+			errorKind := ng.awsManager.GetUnfulfillableErrorKind(ng.asg.AwsRef)
+			errorClass := cloudprovider.OutOfResourcesErrorClass
+			if errorKind != cloudprovider.UnknownErrorKind {
+				errorClass = errorKind.ErrorClass()
+			}
 			status = &cloudprovider.InstanceStatus{
 				State: cloudprovider.InstanceCreating,
 				ErrorInfo: &cloudprovider.InstanceErrorInfo{
-					ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+					ErrorClass:   errorClass,
+					ErrorKind:    errorKind,
 					ErrorCode:    placeholderUnfulfillableStatus,
 					ErrorMessage: "AWS cannot provision any more instances for this node group",
 				},
@@ -407,7 +425,7 @@ func (ng *AwsNodeGroup) TemplateNodeInfo() (*schedulerframework.NodeInfo, error)
 }
 
 // BuildAWS builds AWS cloud provider, manager etc.
-func BuildAWS(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+func BuildAWS(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter, informerFactory informers.SharedInformerFactory) cloudprovider.CloudProvider {
 	var cfg io.ReadCloser
 	if opts.CloudConfig != "" {
 		var err error
@@ -459,6 +477,12 @@ func BuildAWS(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscover
 	if err != nil {
 		klog.Fatalf("Failed to create AWS Manager: %v", err)
 	}
+	if opts.AWSUseExemplarNodeTemplates {
+		manager.SetExemplarNodeLister(informerFactory.Core().V1().Nodes().Lister())
+	}
+	if opts.AWSUseENIMaxPods {
+		manager.SetENIMaxPods(opts.AWSVPCCNIPrefixDelegation)
+	}
 
 	provider, err := BuildAwsCloudProvider(manager, rl)
 	if err != nil {
@@ -55,6 +55,7 @@ func newTestAwsManagerWithMockServices(mockAutoScaling autoScalingI, mockEC2 ec2
 			awsService:            &awsService,
 			autoscalingOptions:    make(map[AwsRef]map[string]string),
 		},
+		capacityReservations: newCapacityReservationCache(&awsService),
 	}
 
 	if instanceStatus != nil {
@@ -139,7 +140,7 @@ func TestInstanceTypeFallback(t *testing.T) {
 	// This test ensures that no klog.Fatalf calls occur when constructing the AWS cloud provider.  Specifically it is
 	// intended to ensure that instance type fallback works correctly in the event of an error enumerating instance
 	// types.
-	_ = BuildAWS(opts, do, resourceLimiter)
+	_ = BuildAWS(opts, do, resourceLimiter, nil)
 }
 
 func TestName(t *testing.T) {
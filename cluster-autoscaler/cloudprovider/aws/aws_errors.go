@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws/awserr"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws/request"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
+)
+
+// awsErrorKinds maps the codes of AWS API errors to the kind of cloud provider errors.
+var awsErrorKinds = map[string]cloudprovider.ErrorKind{
+	"Throttling":                   cloudprovider.ThrottlingErrorKind,
+	"ThrottlingException":          cloudprovider.ThrottlingErrorKind,
+	"RequestLimitExceeded":         cloudprovider.ThrottlingErrorKind,
+	"TooManyRequestsException":     cloudprovider.ThrottlingErrorKind,
+	"AccessDenied":                 cloudprovider.PermissionErrorKind,
+	"AccessDeniedException":        cloudprovider.PermissionErrorKind,
+	"UnauthorizedOperation":        cloudprovider.PermissionErrorKind,
+	"InsufficientInstanceCapacity": cloudprovider.CapacityErrorKind,
+	"LimitExceeded":                cloudprovider.QuotaErrorKind,
+	"VcpuLimitExceeded":            cloudprovider.QuotaErrorKind,
+	"InstanceLimitExceeded":        cloudprovider.QuotaErrorKind,
+	"MaxSpotInstanceCountExceeded": cloudprovider.QuotaErrorKind,
+	"ServiceUnavailable":           cloudprovider.TransientNetworkErrorKind,
+	"InternalFailure":              cloudprovider.TransientNetworkErrorKind,
+	request.ErrCodeRequestError:    cloudprovider.TransientNetworkErrorKind,
+}
+
+// classifyAWSError wraps the errors returned by the AWS API in errors of the kind
+// corresponding to their code, or to their HTTP status code for unknown codes.
+func classifyAWSError(err error) error {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return err
+	}
+	kind, found := awsErrorKinds[awsErr.Code()]
+	if !found {
+		var reqErr awserr.RequestFailure
+		if !errors.As(err, &reqErr) {
+			return err
+		}
+		kind = cloudprovider.ErrorKindFromHTTPStatusCode(reqErr.StatusCode())
+	}
+	switch kind {
+	case cloudprovider.UnknownErrorKind:
+		return err
+	case cloudprovider.QuotaErrorKind:
+		return cloudprovider.NewQuotaExceededError(awsErr.Code(), time.Time{}, err)
+	default:
+		return cloudprovider.NewClassifiedError(kind, awsErr.Code(), err)
+	}
+}
+
+// scalingActivityErrorKinds map substrings of the status messages of failed scaling activities,
+// which quote the error of the failed EC2 request, to the kind of the error. They are checked in order.
+var scalingActivityErrorKinds = []struct {
+	substring string
+	kind      cloudprovider.ErrorKind
+}{
+	{"InsufficientInstanceCapacity", cloudprovider.CapacityErrorKind},
+	{"do not have sufficient", cloudprovider.CapacityErrorKind},
+	{"VcpuLimitExceeded", cloudprovider.QuotaErrorKind},
+	{"vCPU limit", cloudprovider.QuotaErrorKind},
+	{"InstanceLimitExceeded", cloudprovider.QuotaErrorKind},
+	{"MaxSpotInstanceCountExceeded", cloudprovider.QuotaErrorKind},
+	{"UnauthorizedOperation", cloudprovider.PermissionErrorKind},
+	{"not authorized", cloudprovider.PermissionErrorKind},
+	{"RequestLimitExceeded", cloudprovider.ThrottlingErrorKind},
+}
+
+// scalingActivityErrorKind returns the kind of the error which failed a scaling activity.
+func scalingActivityErrorKind(activity *autoscaling.Activity) cloudprovider.ErrorKind {
+	message := aws.StringValue(activity.StatusMessage)
+	for _, errorKind := range scalingActivityErrorKinds {
+		if strings.Contains(message, errorKind.substring) {
+			return errorKind.kind
+		}
+	}
+	return cloudprovider.UnknownErrorKind
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws/awserr"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
+)
+
+func TestClassifyAWSError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err   error
+		kind  cloudprovider.ErrorKind
+		quota string
+	}{
+		"not an AWS error": {err: errors.New("boom"), kind: cloudprovider.UnknownErrorKind},
+		"throttling":       {err: awserr.New("RequestLimitExceeded", "slow down", nil), kind: cloudprovider.ThrottlingErrorKind},
+		"permission":       {err: awserr.New("UnauthorizedOperation", "denied", nil), kind: cloudprovider.PermissionErrorKind},
+		"capacity":         {err: awserr.New("InsufficientInstanceCapacity", "stockout", nil), kind: cloudprovider.CapacityErrorKind},
+		"quota":            {err: awserr.New("VcpuLimitExceeded", "no more vcpus", nil), kind: cloudprovider.QuotaErrorKind, quota: "VcpuLimitExceeded"},
+		"unknown code with status": {
+			err:  awserr.NewRequestFailure(awserr.New("SomethingElse", "unavailable", nil), 503, "id"),
+			kind: cloudprovider.TransientNetworkErrorKind,
+		},
+		"unknown code": {err: awserr.New("ValidationError", "invalid", nil), kind: cloudprovider.UnknownErrorKind},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := classifyAWSError(tc.err)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.kind, cloudprovider.ErrorKindFromError(err))
+			if tc.quota != "" {
+				assert.Equal(t, tc.quota, cloudprovider.QuotaErrorInfoFromError(err).Quota)
+			}
+		})
+	}
+}
+
+func TestScalingActivityErrorKind(t *testing.T) {
+	for message, kind := range map[string]cloudprovider.ErrorKind{
+		"Could not launch On-Demand Instances. InsufficientInstanceCapacity - We currently do not have sufficient p3.2xlarge capacity.": cloudprovider.CapacityErrorKind,
+		"Could not launch On-Demand Instances. VcpuLimitExceeded - You have requested more vCPU capacity than your current vCPU limit.": cloudprovider.QuotaErrorKind,
+		"Could not launch Spot Instances. MaxSpotInstanceCountExceeded - Max spot instance count exceeded.":                             cloudprovider.QuotaErrorKind,
+		"You are not authorized to perform this operation.":                                                                             cloudprovider.PermissionErrorKind,
+		"The security group 'sg-1' does not exist.":                                                                                     cloudprovider.UnknownErrorKind,
+	} {
+		assert.Equal(t, kind, scalingActivityErrorKind(&autoscaling.Activity{StatusMessage: aws.String(message)}), message)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math/rand"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +31,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
@@ -37,6 +39,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/ec2"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/eks"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/extendedzones"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 )
@@ -51,6 +54,7 @@ const (
 	asgAutoDiscovererKeyTag = "tag"
 	optionsTagsPrefix       = "k8s.io/cluster-autoscaler/node-template/autoscaling-options/"
 	labelAwsCSITopologyZone = "topology.ebs.csi.aws.com/zone"
+	architectureTagKey      = "k8s.io/cluster-autoscaler/node-template/architecture"
 )
 
 // AwsManager is handles aws communication and data caching.
@@ -60,6 +64,10 @@ type AwsManager struct {
 	lastRefresh           time.Time
 	instanceTypes         map[string]*InstanceType
 	managedNodegroupCache *managedNodegroupCache
+	capacityReservations  *capacityReservationCache
+	exemplarNodeLister    v1lister.NodeLister
+	eniMaxPods            bool
+	prefixDelegation      bool
 }
 
 type asgTemplate struct {
@@ -100,6 +108,7 @@ func createAWSManagerInternal(
 		asgCache:              cache,
 		instanceTypes:         instanceTypes,
 		managedNodegroupCache: mngCache,
+		capacityReservations:  newCapacityReservationCache(awsService),
 	}
 
 	if err := manager.forceRefresh(); err != nil {
@@ -118,6 +127,7 @@ func CreateAwsManager(awsSDKProvider *awsSDKProvider, discoveryOpts cloudprovide
 // In particular the list of node groups returned by NodeGroups can change as a result of CloudProvider.Refresh().
 func (m *AwsManager) Refresh() error {
 	if m.lastRefresh.Add(refreshInterval).After(time.Now()) {
+		observeASGCacheStaleness(m.lastRefresh)
 		return nil
 	}
 	return m.forceRefresh()
@@ -126,9 +136,14 @@ func (m *AwsManager) Refresh() error {
 func (m *AwsManager) forceRefresh() error {
 	if err := m.asgCache.regenerate(); err != nil {
 		klog.Errorf("Failed to regenerate ASG cache: %v", err)
+		if !m.lastRefresh.IsZero() {
+			observeASGCacheStaleness(m.lastRefresh)
+		}
 		return err
 	}
+	m.capacityReservations.regenerate(m.asgCache.Get())
 	m.lastRefresh = time.Now()
+	observeASGCacheStaleness(m.lastRefresh)
 	klog.V(2).Infof("Refreshed ASG list, next refresh after %v", m.lastRefresh.Add(refreshInterval))
 	return nil
 }
@@ -156,6 +171,12 @@ func (m *AwsManager) SetAsgSize(asg *asg, size int) error {
 	return m.asgCache.SetAsgSize(asg, size)
 }
 
+// AvailableReservedCapacity returns the number of instances which can still be launched in the
+// capacity reservations targeted by the ASG, and false if it doesn't target any reservation.
+func (m *AwsManager) AvailableReservedCapacity(asg *asg) (int, bool) {
+	return m.capacityReservations.availableCapacity(asg)
+}
+
 // DeleteInstances deletes the given instances. All instances must be controlled by the same ASG.
 func (m *AwsManager) DeleteInstances(instances []*AwsInstanceRef) error {
 	if err := m.asgCache.DeleteInstances(instances); err != nil {
@@ -176,13 +197,21 @@ func (m *AwsManager) GetInstanceStatus(ref AwsInstanceRef) (*string, error) {
 	return m.asgCache.InstanceStatus(ref)
 }
 
+// GetUnfulfillableErrorKind returns the kind of the error which prevents the ASG from provisioning nodes.
+func (m *AwsManager) GetUnfulfillableErrorKind(ref AwsRef) cloudprovider.ErrorKind {
+	return m.asgCache.UnfulfillableErrorKind(ref)
+}
+
 func (m *AwsManager) getAsgTemplate(asg *asg) (*asgTemplate, error) {
 	if len(asg.AvailabilityZones) < 1 {
 		return nil, fmt.Errorf("unable to get first AvailabilityZone for ASG %q", asg.Name)
 	}
 
 	az := asg.AvailabilityZones[0]
-	region := az[0 : len(az)-1]
+	region, zoneType := extendedzones.ParseZone(az)
+	if zoneType != extendedzones.ZoneTypeAvailabilityZone {
+		klog.V(4).Infof("ASG %q is located in the %s %s of region %s", asg.Name, zoneType, az, region)
+	}
 
 	if len(asg.AvailabilityZones) > 1 {
 		klog.V(4).Infof("Found multiple availability zones for ASG %q; using %s for %s label\n", asg.Name, az, apiv1.LabelZoneFailureDomain)
@@ -194,6 +223,12 @@ func (m *AwsManager) getAsgTemplate(asg *asg) (*asgTemplate, error) {
 	}
 
 	if t, ok := m.instanceTypes[instanceTypeName]; ok {
+		if _, found := extractArchitectureFromAsg(asg.Tags); !found && asg.MixedInstancesPolicy != nil {
+			if architectures := m.getOverridesArchitectures(asg.MixedInstancesPolicy.instanceTypesOverrides); len(architectures) > 1 {
+				klog.Warningf("ASG %q mixes instance types of architectures %v; using %s for %s label, set the %s tag to override it",
+					asg.Name, architectures, t.Architecture, apiv1.LabelArchStable, architectureTagKey)
+			}
+		}
 		return &asgTemplate{
 			InstanceType: t,
 			Region:       region,
@@ -274,23 +309,29 @@ func (m *AwsManager) buildNodeFromTemplate(asg *asg, template *asgTemplate) (*ap
 		Capacity: apiv1.ResourceList{},
 	}
 
-	// TODO: get a real value.
-	node.Status.Capacity[apiv1.ResourcePods] = *resource.NewQuantity(110, resource.DecimalSI)
+	node.Status.Capacity[apiv1.ResourcePods] = *resource.NewQuantity(m.getMaxPods(template.InstanceType), resource.DecimalSI)
 	node.Status.Capacity[apiv1.ResourceCPU] = *resource.NewQuantity(template.InstanceType.VCPU, resource.DecimalSI)
 	node.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(template.InstanceType.GPU, resource.DecimalSI)
 	node.Status.Capacity[apiv1.ResourceMemory] = *resource.NewQuantity(template.InstanceType.MemoryMb*1024*1024, resource.DecimalSI)
 
 	m.updateCapacityWithRequirementsOverrides(&node.Status.Capacity, asg.MixedInstancesPolicy)
 
+	// Resources are taken from the instance type, then from a live exemplar node of the ASG
+	// if there is one, then from ASG tags and finally from EKS managed nodegroup tags.
+	// Allocatable resources not reported by an exemplar node default to capacity.
+	allocatable := apiv1.ResourceList{}
+	if exemplar := m.getExemplarNode(asg, template.InstanceType.InstanceType); exemplar != nil {
+		klog.V(4).Infof("Using node %s as exemplar for ASG %s template", exemplar.Name, asg.Name)
+		applyExemplarNodeResources(&node, allocatable, exemplar)
+	}
+
 	resourcesFromTags := extractAllocatableResourcesFromAsg(template.Tags)
 	klog.V(5).Infof("Extracted resources from ASG tags %v", resourcesFromTags)
 	for resourceName, val := range resourcesFromTags {
 		node.Status.Capacity[apiv1.ResourceName(resourceName)] = *val
+		delete(allocatable, apiv1.ResourceName(resourceName))
 	}
 
-	// TODO: use proper allocatable!!
-	node.Status.Allocatable = node.Status.Capacity
-
 	// GenericLabels
 	node.Labels = cloudprovider.JoinStringMaps(node.Labels, buildGenericLabels(template, nodeName))
 
@@ -328,10 +369,18 @@ func (m *AwsManager) buildNodeFromTemplate(asg *asg, template *asgTemplate) (*ap
 			// ManagedNodeGroup resource-indicating tags override conflicting tags on the ASG if they exist
 			for resourceName, val := range resourcesFromMngTags {
 				node.Status.Capacity[apiv1.ResourceName(resourceName)] = *val
+				delete(allocatable, apiv1.ResourceName(resourceName))
 			}
 		}
 	}
 
+	for resourceName, val := range node.Status.Capacity {
+		if _, found := allocatable[resourceName]; !found {
+			allocatable[resourceName] = val
+		}
+	}
+	node.Status.Allocatable = allocatable
+
 	node.Status.Conditions = cloudprovider.BuildReadyConditions()
 	return &node, nil
 }
@@ -385,6 +434,12 @@ func buildGenericLabels(template *asgTemplate, nodeName string) map[string]strin
 	result := make(map[string]string)
 
 	result[apiv1.LabelArchStable] = template.InstanceType.Architecture
+	if result[apiv1.LabelArchStable] == "" {
+		result[apiv1.LabelArchStable] = cloudprovider.DefaultArch
+	}
+	if architecture, found := extractArchitectureFromAsg(template.Tags); found {
+		result[apiv1.LabelArchStable] = architecture
+	}
 	result[apiv1.LabelOSStable] = cloudprovider.DefaultOS
 
 	result[apiv1.LabelInstanceTypeStable] = template.InstanceType.InstanceType
@@ -396,6 +451,41 @@ func buildGenericLabels(template *asgTemplate, nodeName string) map[string]strin
 	return result
 }
 
+// extractArchitectureFromAsg returns the architecture set by the architecture tag, for
+// ASGs whose AMI architecture can't be derived from their instance types.
+func extractArchitectureFromAsg(tags []*autoscaling.TagDescription) (string, bool) {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) != architectureTagKey {
+			continue
+		}
+		switch value := strings.ToLower(strings.TrimSpace(aws.StringValue(tag.Value))); value {
+		case "amd64", "x86_64":
+			return "amd64", true
+		case "arm64", "aarch64":
+			return "arm64", true
+		default:
+			klog.Warningf("Ignoring %s tag with unsupported architecture %q", architectureTagKey, value)
+		}
+	}
+	return "", false
+}
+
+// getOverridesArchitectures returns the sorted, distinct architectures of the given instance types.
+func (m *AwsManager) getOverridesArchitectures(instanceTypes []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, instanceType := range instanceTypes {
+		t, found := m.instanceTypes[instanceType]
+		if !found || t.Architecture == "" || seen[t.Architecture] {
+			continue
+		}
+		seen[t.Architecture] = true
+		result = append(result, t.Architecture)
+	}
+	sort.Strings(result)
+	return result
+}
+
 func extractLabelsFromAsg(tags []*autoscaling.TagDescription) map[string]string {
 	result := make(map[string]string)
 
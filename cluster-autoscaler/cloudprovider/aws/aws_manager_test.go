@@ -36,6 +36,9 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/ec2"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestJoinNodeLabelsChoosingUserValuesOverAPIValues(t *testing.T) {
@@ -76,6 +79,52 @@ func TestBuildGenericLabels(t *testing.T) {
 	assert.Equal(t, cloudprovider.DefaultOS, labels[apiv1.LabelOSStable])
 }
 
+func TestBuildGenericLabelsArchitecture(t *testing.T) {
+	tests := []struct {
+		description  string
+		architecture string
+		tags         []*autoscaling.TagDescription
+		expected     string
+	}{
+		{"architecture of the instance type", "arm64", nil, "arm64"},
+		{"unknown architecture of the instance type", "", nil, cloudprovider.DefaultArch},
+		{"architecture tag", "amd64", []*autoscaling.TagDescription{
+			{Key: aws.String(architectureTagKey), Value: aws.String("arm64")},
+		}, "arm64"},
+		{"EC2 name in architecture tag", "arm64", []*autoscaling.TagDescription{
+			{Key: aws.String(architectureTagKey), Value: aws.String("x86_64")},
+		}, "amd64"},
+		{"unsupported architecture tag", "arm64", []*autoscaling.TagDescription{
+			{Key: aws.String(architectureTagKey), Value: aws.String("sparc")},
+		}, "arm64"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			labels := buildGenericLabels(&asgTemplate{
+				InstanceType: &InstanceType{
+					InstanceType: "m6g.large",
+					Architecture: test.architecture,
+				},
+				Tags: test.tags,
+			}, "sillyname")
+			assert.Equal(t, test.expected, labels[apiv1.LabelArchStable])
+		})
+	}
+}
+
+func TestGetOverridesArchitectures(t *testing.T) {
+	m := &AwsManager{
+		instanceTypes: map[string]*InstanceType{
+			"m5.large":  {InstanceType: "m5.large", Architecture: "amd64"},
+			"c5.large":  {InstanceType: "c5.large", Architecture: "amd64"},
+			"m6g.large": {InstanceType: "m6g.large", Architecture: "arm64"},
+		},
+	}
+	assert.Equal(t, []string{"amd64"}, m.getOverridesArchitectures([]string{"m5.large", "c5.large"}))
+	assert.Equal(t, []string{"amd64", "arm64"}, m.getOverridesArchitectures([]string{"m6g.large", "m5.large", "unknown.large"}))
+	assert.Empty(t, m.getOverridesArchitectures(nil))
+}
+
 func TestExtractAllocatableResourcesFromAsg(t *testing.T) {
 	tags := []*autoscaling.TagDescription{
 		{
@@ -411,6 +460,111 @@ func TestBuildNodeFromTemplateWithManagedNodegroupNilLabelsOrTaints(t *testing.T
 	assert.Equal(t, len(observedNode.Spec.Taints), 0)
 }
 
+func TestBuildNodeFromTemplateWithExemplarNode(t *testing.T) {
+	testAsg := &asg{AwsRef: AwsRef{Name: "test-auto-scaling-group"}}
+	instance := AwsInstanceRef{ProviderID: "aws:///us-east-1a/i-exemplar", Name: "i-exemplar"}
+	awsManager := &AwsManager{
+		asgCache: &asgCache{
+			instanceToAsg: map[AwsInstanceRef]*asg{instance: testAsg},
+		},
+	}
+	c5Instance := &InstanceType{
+		InstanceType: "c5.xlarge",
+		VCPU:         4,
+		MemoryMb:     8192,
+		GPU:          0,
+	}
+	template := &asgTemplate{
+		InstanceType: c5Instance,
+		Tags: []*autoscaling.TagDescription{
+			{
+				Key:   aws.String("k8s.io/cluster-autoscaler/node-template/resources/ephemeral-storage"),
+				Value: aws.String("20"),
+			},
+		},
+	}
+
+	exemplar := BuildTestNode("exemplar", 4000, 8192*1024*1024)
+	exemplar.Spec.ProviderID = instance.ProviderID
+	exemplar.Labels[apiv1.LabelInstanceTypeStable] = "c5.xlarge"
+	exemplar.Status.Capacity[apiv1.ResourceEphemeralStorage] = *resource.NewQuantity(100, resource.DecimalSI)
+	exemplar.Status.Allocatable[apiv1.ResourceCPU] = *resource.NewMilliQuantity(3920, resource.DecimalSI)
+	exemplar.Status.Allocatable[apiv1.ResourceMemory] = *resource.NewQuantity(7000*1024*1024, resource.DecimalSI)
+	exemplar.Status.Allocatable[apiv1.ResourcePods] = *resource.NewQuantity(58, resource.DecimalSI)
+	SetNodeReadyState(exemplar, true, time.Now())
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NoError(t, indexer.Add(exemplar))
+
+	// Without exemplar node lister, resources are derived from the instance type.
+	observedNode, err := awsManager.buildNodeFromTemplate(testAsg, template)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), observedNode.Status.Allocatable.Cpu().MilliValue())
+
+	awsManager.SetExemplarNodeLister(v1lister.NewNodeLister(indexer))
+	observedNode, err = awsManager.buildNodeFromTemplate(testAsg, template)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), observedNode.Status.Capacity.Cpu().MilliValue())
+	assert.Equal(t, int64(3920), observedNode.Status.Allocatable.Cpu().MilliValue())
+	assert.Equal(t, int64(7000*1024*1024), observedNode.Status.Allocatable.Memory().Value())
+	assert.Equal(t, int64(58), observedNode.Status.Allocatable.Pods().Value())
+	// ASG tags take precedence over the exemplar node.
+	assert.Equal(t, int64(20), observedNode.Status.Capacity.StorageEphemeral().Value())
+	assert.Equal(t, int64(20), observedNode.Status.Allocatable.StorageEphemeral().Value())
+
+	// Exemplar nodes running a different instance type are ignored.
+	template.InstanceType = &InstanceType{InstanceType: "c5.2xlarge", VCPU: 8, MemoryMb: 16384}
+	observedNode, err = awsManager.buildNodeFromTemplate(testAsg, template)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8000), observedNode.Status.Allocatable.Cpu().MilliValue())
+}
+
+func TestBuildNodeFromTemplateWithENIMaxPods(t *testing.T) {
+	testAsg := &asg{AwsRef: AwsRef{Name: "test-auto-scaling-group"}}
+	m5Large := &InstanceType{InstanceType: "m5.large", VCPU: 2, MemoryMb: 8192, MaxNetworkInterfaces: 3, Ipv4AddressesPerInterface: 10}
+	m524xLarge := &InstanceType{InstanceType: "m5.24xlarge", VCPU: 96, MemoryMb: 393216, MaxNetworkInterfaces: 15, Ipv4AddressesPerInterface: 50}
+	unknownLimits := &InstanceType{InstanceType: "c5.xlarge", VCPU: 4, MemoryMb: 8192}
+
+	testCases := []struct {
+		name             string
+		eniMaxPods       bool
+		prefixDelegation bool
+		instanceType     *InstanceType
+		tags             []*autoscaling.TagDescription
+		expectedPods     int64
+	}{
+		{name: "disabled", instanceType: m5Large, expectedPods: 110},
+		{name: "secondary IPs", eniMaxPods: true, instanceType: m5Large, expectedPods: 29},
+		{name: "secondary IPs large instance", eniMaxPods: true, instanceType: m524xLarge, expectedPods: 737},
+		{name: "prefix delegation", eniMaxPods: true, prefixDelegation: true, instanceType: m5Large, expectedPods: 110},
+		{name: "prefix delegation large instance", eniMaxPods: true, prefixDelegation: true, instanceType: m524xLarge, expectedPods: 250},
+		{name: "unknown limits", eniMaxPods: true, instanceType: unknownLimits, expectedPods: 110},
+		{
+			name:         "ASG tag takes precedence",
+			eniMaxPods:   true,
+			instanceType: m5Large,
+			tags: []*autoscaling.TagDescription{
+				{
+					Key:   aws.String("k8s.io/cluster-autoscaler/node-template/resources/pods"),
+					Value: aws.String("17"),
+				},
+			},
+			expectedPods: 17,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			awsManager := &AwsManager{}
+			if tc.eniMaxPods {
+				awsManager.SetENIMaxPods(tc.prefixDelegation)
+			}
+			observedNode, err := awsManager.buildNodeFromTemplate(testAsg, &asgTemplate{InstanceType: tc.instanceType, Tags: tc.tags})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPods, observedNode.Status.Capacity.Pods().Value())
+			assert.Equal(t, tc.expectedPods, observedNode.Status.Allocatable.Pods().Value())
+		})
+	}
+}
+
 func TestBuildNodeFromTemplate(t *testing.T) {
 	awsManager := &AwsManager{}
 	asg := &asg{AwsRef: AwsRef{Name: "test-auto-scaling-group"}}
@@ -38,11 +38,28 @@ var (
 			Buckets:   []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0, 2.0, 5.0, 10.0, 20.0, 30.0, 60.0},
 		}, []string{"endpoint", "status"},
 	)
+
+	/**** Metrics related to the ASG cache ****/
+	asgCacheChanges = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "aws_asg_cache_changes_total",
+			Help:      "Number of ASGs added, updated or removed by the refreshes of the ASG cache",
+		}, []string{"change"},
+	)
+
+	asgCacheStaleness = k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "aws_asg_cache_staleness_seconds",
+			Help:      "Time since the last successful refresh of the ASG cache, in seconds",
+		},
+	)
 )
 
 // RegisterMetrics registers all AWS metrics.
 func RegisterMetrics() {
-	legacyregistry.MustRegister(requestSummary)
+	legacyregistry.MustRegister(requestSummary, asgCacheChanges, asgCacheStaleness)
 }
 
 // observeAWSRequest records AWS API calls counts and durations
@@ -57,3 +74,15 @@ func observeAWSRequest(endpoint string, err error, start time.Time) {
 	}
 	requestSummary.WithLabelValues(endpoint, status).Observe(duration)
 }
+
+// observeASGCacheDelta records the ASGs added, updated and removed by a refresh of the ASG cache
+func observeASGCacheDelta(delta asgCacheDelta) {
+	asgCacheChanges.WithLabelValues("added").Add(float64(delta.added))
+	asgCacheChanges.WithLabelValues("updated").Add(float64(delta.updated))
+	asgCacheChanges.WithLabelValues("removed").Add(float64(delta.removed))
+}
+
+// observeASGCacheStaleness records the time since the last successful refresh of the ASG cache
+func observeASGCacheStaleness(lastRefresh time.Time) {
+	asgCacheStaleness.Set(time.Since(lastRefresh).Seconds())
+}
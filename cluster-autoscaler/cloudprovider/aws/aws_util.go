@@ -74,6 +74,14 @@ func transformInstanceType(rawInstanceType *ec2.InstanceTypeInfo) *InstanceType
 	if rawInstanceType.ProcessorInfo != nil && len(rawInstanceType.ProcessorInfo.SupportedArchitectures) > 0 {
 		instanceType.Architecture = interpretEc2SupportedArchitecure(*rawInstanceType.ProcessorInfo.SupportedArchitectures[0])
 	}
+	if rawInstanceType.NetworkInfo != nil {
+		if rawInstanceType.NetworkInfo.MaximumNetworkInterfaces != nil {
+			instanceType.MaxNetworkInterfaces = *rawInstanceType.NetworkInfo.MaximumNetworkInterfaces
+		}
+		if rawInstanceType.NetworkInfo.Ipv4AddressesPerInterface != nil {
+			instanceType.Ipv4AddressesPerInterface = *rawInstanceType.NetworkInfo.Ipv4AddressesPerInterface
+		}
+	}
 	return instanceType
 }
 
@@ -44,6 +44,10 @@ func TestInstanceTypeTransform(t *testing.T) {
 		MemoryInfo: &ec2.MemoryInfo{
 			SizeInMiB: aws.Int64(7680),
 		},
+		NetworkInfo: &ec2.NetworkInfo{
+			MaximumNetworkInterfaces:  aws.Int64(4),
+			Ipv4AddressesPerInterface: aws.Int64(15),
+		},
 	}
 
 	instanceType := transformInstanceType(&rawInstanceType)
@@ -53,6 +57,8 @@ func TestInstanceTypeTransform(t *testing.T) {
 	assert.Equal(t, int64(7680), instanceType.MemoryMb)
 	assert.Equal(t, int64(0), instanceType.GPU)
 	assert.Equal(t, "amd64", instanceType.Architecture)
+	assert.Equal(t, int64(4), instanceType.MaxNetworkInterfaces)
+	assert.Equal(t, int64(15), instanceType.Ipv4AddressesPerInterface)
 }
 
 func TestInterpretEc2SupportedArchitecure(t *testing.T) {
@@ -40,6 +40,7 @@ type autoScalingI interface {
 
 // ec2I is the interface abstracting specific API calls of the EC2 service provided by AWS SDK for use in CA
 type ec2I interface {
+	DescribeCapacityReservationsPages(input *ec2.DescribeCapacityReservationsInput, fn func(*ec2.DescribeCapacityReservationsOutput, bool) bool) error
 	DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error)
 	DescribeLaunchTemplateVersions(input *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
 	GetInstanceTypesFromInstanceRequirementsPages(input *ec2.GetInstanceTypesFromInstanceRequirementsInput, fn func(*ec2.GetInstanceTypesFromInstanceRequirementsOutput, bool) bool) error
@@ -234,6 +235,33 @@ func (m *awsWrapper) getAutoscalingGroupsByTags(tags map[string]string) ([]*auto
 	return asgs, nil
 }
 
+// getAutoscalingGroups describes the ASGs matching the given tags, then the ASGs of the given names
+// which don't match them, so that each ASG is described once per refresh.
+func (m *awsWrapper) getAutoscalingGroups(names []string, tags map[string]string) ([]*autoscaling.Group, error) {
+	taggedGroups, err := m.getAutoscalingGroupsByTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	described := make(map[string]bool, len(taggedGroups))
+	for _, group := range taggedGroups {
+		described[aws.StringValue(group.AutoScalingGroupName)] = true
+	}
+	remainingNames := make([]string, 0, len(names))
+	for _, name := range names {
+		if !described[name] {
+			remainingNames = append(remainingNames, name)
+		}
+	}
+
+	namedGroups, err := m.getAutoscalingGroupsByNames(remainingNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(namedGroups, taggedGroups...), nil
+}
+
 func (m *awsWrapper) getInstanceTypeByLaunchTemplate(launchTemplate *launchTemplate) (string, error) {
 	templateData, err := m.getLaunchTemplateData(launchTemplate.name, launchTemplate.version)
 	if err != nil {
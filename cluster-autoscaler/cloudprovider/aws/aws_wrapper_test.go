@@ -64,6 +64,11 @@ type ec2Mock struct {
 	mock.Mock
 }
 
+func (e *ec2Mock) DescribeCapacityReservationsPages(input *ec2.DescribeCapacityReservationsInput, fn func(*ec2.DescribeCapacityReservationsOutput, bool) bool) error {
+	args := e.Called(input, fn)
+	return args.Error(0)
+}
+
 func (e *ec2Mock) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
 	args := e.Called(input)
 	return args.Get(0).(*ec2.DescribeImagesOutput), nil
@@ -785,3 +790,46 @@ func TestTaintEksTranslator(t *testing.T) {
 	_, err = taintEksTranslator(&taint4)
 	assert.Error(t, err)
 }
+
+func TestGetAutoscalingGroupsDescribesEachGroupOnce(t *testing.T) {
+	a := &autoScalingMock{}
+	awsWrapper := &awsWrapper{
+		autoScalingI: a,
+		ec2I:         nil,
+		eksI:         nil,
+	}
+
+	tags := map[string]string{"k8s.io/cluster-autoscaler/enabled": ""}
+	a.On("DescribeAutoScalingGroupsPages",
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			Filters: []*autoscaling.Filter{{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{"k8s.io/cluster-autoscaler/enabled"}),
+			}},
+			MaxRecords: aws.Int64(maxRecordsReturnedByAPI),
+		},
+		mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+	).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+		fn(testNamedDescribeAutoScalingGroupsOutput("asg-1", 1, "test-instance-id"), false)
+	}).Return(nil).Once()
+
+	// asg-1 was already described by its tags.
+	a.On("DescribeAutoScalingGroupsPages",
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice([]string{"asg-2"}),
+			MaxRecords:            aws.Int64(maxRecordsReturnedByAPI),
+		},
+		mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+	).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+		fn(testNamedDescribeAutoScalingGroupsOutput("asg-2", 1, "test-instance-id-2"), false)
+	}).Return(nil).Once()
+
+	asgs, err := awsWrapper.getAutoscalingGroups([]string{"asg-1", "asg-2"}, tags)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(asgs))
+	assert.Equal(t, "asg-2", *asgs[0].AutoScalingGroupName)
+	assert.Equal(t, "asg-1", *asgs[1].AutoScalingGroupName)
+	a.AssertExpectations(t)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/ec2"
+	klog "k8s.io/klog/v2"
+)
+
+// capacityReservationIdsTagKey is the tag of ASGs listing, comma separated, the ids of the
+// Capacity Reservations or Capacity Blocks for ML targeted by their launch template.
+const capacityReservationIdsTagKey = "k8s.io/cluster-autoscaler/capacity-reservation-ids"
+
+// capacityReservation is the state of a Capacity Reservation or Capacity Block for ML.
+type capacityReservation struct {
+	id string
+	// capacityBlock is true for Capacity Blocks for ML, which are only usable during their time window.
+	capacityBlock bool
+	// active is true if instances can be launched in the reservation.
+	active bool
+	// availableInstanceCount is the number of instances which can still be launched in the reservation.
+	availableInstanceCount int
+}
+
+// capacityReservationCache tracks the remaining capacity of the reservations targeted by ASGs.
+type capacityReservationCache struct {
+	sync.Mutex
+	awsService   *awsWrapper
+	reservations map[string]*capacityReservation
+}
+
+func newCapacityReservationCache(awsService *awsWrapper) *capacityReservationCache {
+	return &capacityReservationCache{
+		awsService:   awsService,
+		reservations: make(map[string]*capacityReservation),
+	}
+}
+
+// extractCapacityReservationIdsFromAsg returns the ids of the reservations targeted by the ASG.
+func extractCapacityReservationIdsFromAsg(tags []*autoscaling.TagDescription) []string {
+	var ids []string
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) != capacityReservationIdsTagKey {
+			continue
+		}
+		for _, id := range strings.Split(aws.StringValue(tag.Value), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// regenerate refreshes the state of the reservations targeted by the given ASGs. The
+// previous state is kept if the reservations can't be described.
+func (c *capacityReservationCache) regenerate(asgs map[AwsRef]*asg) {
+	idSet := make(map[string]bool)
+	for _, asg := range asgs {
+		for _, id := range extractCapacityReservationIdsFromAsg(asg.Tags) {
+			idSet[id] = true
+		}
+	}
+	reservations := make(map[string]*capacityReservation)
+	if len(idSet) > 0 {
+		ids := make([]string, 0, len(idSet))
+		for id := range idSet {
+			ids = append(ids, id)
+		}
+		var err error
+		reservations, err = c.awsService.getCapacityReservations(ids)
+		if err != nil {
+			klog.Errorf("Failed to describe capacity reservations %v: %v", ids, err)
+			return
+		}
+	}
+	for _, reservation := range reservations {
+		if reservation.capacityBlock && !reservation.active {
+			klog.V(4).Infof("Capacity Block for ML %s is outside of its time window, its capacity isn't available", reservation.id)
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.reservations = reservations
+}
+
+// availableCapacity returns the number of instances which can still be launched in the
+// reservations targeted by the ASG, and false if it doesn't target any reservation.
+func (c *capacityReservationCache) availableCapacity(asg *asg) (int, bool) {
+	ids := extractCapacityReservationIdsFromAsg(asg.Tags)
+	if len(ids) == 0 {
+		return 0, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	available := 0
+	for _, id := range ids {
+		if reservation, found := c.reservations[id]; found && reservation.active {
+			available += reservation.availableInstanceCount
+		}
+	}
+	return available, true
+}
+
+// consume records that the given number of instances is being launched in the reservations
+// targeted by the ASG, so that they aren't considered available until the next refresh.
+func (c *capacityReservationCache) consume(asg *asg, count int) {
+	ids := extractCapacityReservationIdsFromAsg(asg.Tags)
+
+	c.Lock()
+	defer c.Unlock()
+	for _, id := range ids {
+		reservation, found := c.reservations[id]
+		if !found || !reservation.active {
+			continue
+		}
+		consumed := count
+		if consumed > reservation.availableInstanceCount {
+			consumed = reservation.availableInstanceCount
+		}
+		reservation.availableInstanceCount -= consumed
+		count -= consumed
+		if count == 0 {
+			return
+		}
+	}
+}
+
+func (m *awsWrapper) getCapacityReservations(ids []string) (map[string]*capacityReservation, error) {
+	input := &ec2.DescribeCapacityReservationsInput{
+		CapacityReservationIds: aws.StringSlice(ids),
+	}
+	reservations := make(map[string]*capacityReservation)
+	start := time.Now()
+	err := m.DescribeCapacityReservationsPages(input, func(output *ec2.DescribeCapacityReservationsOutput, _ bool) bool {
+		for _, r := range output.CapacityReservations {
+			id := aws.StringValue(r.CapacityReservationId)
+			reservations[id] = &capacityReservation{
+				id:                     id,
+				capacityBlock:          aws.StringValue(r.ReservationType) == ec2.CapacityReservationTypeCapacityBlock,
+				active:                 aws.StringValue(r.State) == ec2.CapacityReservationStateActive,
+				availableInstanceCount: int(aws.Int64Value(r.AvailableInstanceCount)),
+			}
+		}
+		return true
+	})
+	observeAWSRequest("DescribeCapacityReservations", err, start)
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
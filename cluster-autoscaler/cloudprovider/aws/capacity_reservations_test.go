@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/aws"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/aws-sdk-go/service/ec2"
+)
+
+func TestCapacityReservationCache(t *testing.T) {
+	e := &ec2Mock{}
+	e.On("DescribeCapacityReservationsPages", mock.Anything,
+		mock.AnythingOfType("func(*ec2.DescribeCapacityReservationsOutput, bool) bool"),
+	).Run(func(args mock.Arguments) {
+		input := args.Get(0).(*ec2.DescribeCapacityReservationsInput)
+		assert.ElementsMatch(t, []string{"cr-1", "cr-2", "cr-3"}, aws.StringValueSlice(input.CapacityReservationIds))
+		fn := args.Get(1).(func(*ec2.DescribeCapacityReservationsOutput, bool) bool)
+		fn(&ec2.DescribeCapacityReservationsOutput{
+			CapacityReservations: []*ec2.CapacityReservation{
+				{CapacityReservationId: aws.String("cr-1"), State: aws.String(ec2.CapacityReservationStateActive), AvailableInstanceCount: aws.Int64(2)},
+				{CapacityReservationId: aws.String("cr-2"), State: aws.String(ec2.CapacityReservationStateActive), AvailableInstanceCount: aws.Int64(3),
+					ReservationType: aws.String(ec2.CapacityReservationTypeCapacityBlock)},
+				{CapacityReservationId: aws.String("cr-3"), State: aws.String(ec2.CapacityReservationStateScheduled), AvailableInstanceCount: aws.Int64(8),
+					ReservationType: aws.String(ec2.CapacityReservationTypeCapacityBlock)},
+			},
+		}, true)
+	}).Return(nil).Once()
+
+	withTags := func(name, ids string) *asg {
+		a := &asg{AwsRef: AwsRef{Name: name}}
+		if ids != "" {
+			a.Tags = []*autoscaling.TagDescription{{Key: aws.String(capacityReservationIdsTagKey), Value: aws.String(ids)}}
+		}
+		return a
+	}
+	reserved := withTags("reserved", "cr-1, cr-2")
+	scheduled := withTags("scheduled", "cr-3")
+	onDemand := withTags("on-demand", "")
+
+	c := newCapacityReservationCache(&awsWrapper{nil, e, nil})
+	c.regenerate(map[AwsRef]*asg{reserved.AwsRef: reserved, scheduled.AwsRef: scheduled, onDemand.AwsRef: onDemand})
+	e.AssertExpectations(t)
+
+	available, ok := c.availableCapacity(reserved)
+	assert.True(t, ok)
+	assert.Equal(t, 5, available)
+
+	// Capacity Blocks outside of their time window have no available capacity.
+	available, ok = c.availableCapacity(scheduled)
+	assert.True(t, ok)
+	assert.Equal(t, 0, available)
+
+	_, ok = c.availableCapacity(onDemand)
+	assert.False(t, ok)
+
+	// Launched instances consume the capacity until the next refresh.
+	c.consume(reserved, 3)
+	available, _ = c.availableCapacity(reserved)
+	assert.Equal(t, 2, available)
+	c.consume(reserved, 4)
+	available, _ = c.availableCapacity(reserved)
+	assert.Equal(t, 0, available)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+const (
+	// defaultMaxPods is the pod capacity of node templates when it isn't derived from ENI limits.
+	defaultMaxPods = 110
+	// maxPodsLargeInstance is the pod capacity recommended with prefix delegation
+	// for instance types with more than prefixDelegationLargeInstanceVCPU vCPUs.
+	maxPodsLargeInstance = 250
+	// prefixDelegationLargeInstanceVCPU is the number of vCPUs above which
+	// maxPodsLargeInstance is used with prefix delegation.
+	prefixDelegationLargeInstanceVCPU = 30
+	// ipv4PrefixSize is the number of addresses in an IPv4 prefix assigned to an ENI slot.
+	ipv4PrefixSize = 16
+	// hostNetworkPods accounts for the aws-node and kube-proxy pods, which use the node IP.
+	hostNetworkPods = 2
+)
+
+// SetENIMaxPods makes the manager derive the pod capacity of node templates from
+// the ENI and IPv4 address limits of the instance type, the way the Amazon VPC
+// CNI does. prefixDelegation tells if the CNI assigns IPv4 prefixes to ENIs.
+func (m *AwsManager) SetENIMaxPods(prefixDelegation bool) {
+	m.eniMaxPods = true
+	m.prefixDelegation = prefixDelegation
+}
+
+// getMaxPods returns the pod capacity of a node of the given instance type.
+func (m *AwsManager) getMaxPods(instanceType *InstanceType) int64 {
+	if !m.eniMaxPods {
+		return defaultMaxPods
+	}
+	return eniMaxPods(instanceType, m.prefixDelegation)
+}
+
+// eniMaxPods computes the number of pods the Amazon VPC CNI can assign IPs to on
+// the instance type, following the EKS max pods calculator. It falls back to
+// defaultMaxPods for instance types with unknown network limits.
+func eniMaxPods(instanceType *InstanceType, prefixDelegation bool) int64 {
+	if instanceType.MaxNetworkInterfaces <= 0 || instanceType.Ipv4AddressesPerInterface <= 0 {
+		return defaultMaxPods
+	}
+	// The primary address of every ENI can't be assigned to pods.
+	addressesPerInterface := instanceType.Ipv4AddressesPerInterface - 1
+	if !prefixDelegation {
+		return instanceType.MaxNetworkInterfaces*addressesPerInterface + hostNetworkPods
+	}
+	maxPods := instanceType.MaxNetworkInterfaces*addressesPerInterface*ipv4PrefixSize + hostNetworkPods
+	limit := int64(defaultMaxPods)
+	if instanceType.VCPU > prefixDelegationLargeInstanceVCPU {
+		limit = maxPodsLargeInstance
+	}
+	if maxPods > limit {
+		return limit
+	}
+	return maxPods
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+)
+
+// SetExemplarNodeLister makes the manager build node templates based on live
+// nodes of the ASG, whenever such nodes exist.
+func (m *AwsManager) SetExemplarNodeLister(lister v1lister.NodeLister) {
+	m.exemplarNodeLister = lister
+}
+
+// getExemplarNode returns a ready node of the given ASG running the given
+// instance type, or nil if there is no such node.
+func (m *AwsManager) getExemplarNode(asg *asg, instanceType string) *apiv1.Node {
+	if m.exemplarNodeLister == nil {
+		return nil
+	}
+	nodes, err := m.exemplarNodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Warningf("Failed to list nodes, not using exemplar node for ASG %s: %v", asg.Name, err)
+		return nil
+	}
+	for _, node := range nodes {
+		if node.DeletionTimestamp != nil || node.Labels[apiv1.LabelInstanceTypeStable] != instanceType {
+			continue
+		}
+		if ready, _, err := kube_util.GetReadinessState(node); err != nil || !ready {
+			continue
+		}
+		ref, err := AwsRefFromProviderId(node.Spec.ProviderID)
+		if err != nil {
+			continue
+		}
+		if nodeAsg := m.GetAsgForInstance(*ref); nodeAsg != nil && nodeAsg.AwsRef == asg.AwsRef {
+			return node
+		}
+	}
+	return nil
+}
+
+// applyExemplarNodeResources copies capacity and allocatable of the exemplar
+// node into the template node. Exemplar values reflect the actual kubelet
+// configuration (e.g. system reserved resources and eviction thresholds), so
+// they take precedence over values derived from the instance type.
+func applyExemplarNodeResources(node *apiv1.Node, allocatable apiv1.ResourceList, exemplar *apiv1.Node) {
+	for name, val := range exemplar.Status.Capacity {
+		node.Status.Capacity[name] = val.DeepCopy()
+	}
+	for name, val := range exemplar.Status.Allocatable {
+		allocatable[name] = val.DeepCopy()
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extendedzones
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
+	"k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// NodeGroupListProcessor only considers node groups located in AWS Local Zones or Wavelength Zones
+// for scale-up when some of the unschedulable pods explicitly require their zone. Those zones are typically more
+// expensive and offer a limited choice of instance types, so pods without topology requirements shouldn't trigger them.
+type NodeGroupListProcessor struct {
+	next nodegroups.NodeGroupListProcessor
+}
+
+// NewNodeGroupListProcessor returns a processor filtering out node groups in AWS extended zones,
+// before processing the node groups with the next processor.
+func NewNodeGroupListProcessor(next nodegroups.NodeGroupListProcessor) nodegroups.NodeGroupListProcessor {
+	return &NodeGroupListProcessor{next: next}
+}
+
+// Process filters out the node groups in AWS extended zones which aren't required by any of the unschedulable pods.
+func (p *NodeGroupListProcessor) Process(context *context.AutoscalingContext, nodeGroups []cloudprovider.NodeGroup, nodeInfos map[string]*schedulerframework.NodeInfo,
+	unschedulablePods []*apiv1.Pod) ([]cloudprovider.NodeGroup, map[string]*schedulerframework.NodeInfo, error) {
+	filtered := make([]cloudprovider.NodeGroup, 0, len(nodeGroups))
+	for _, nodeGroup := range nodeGroups {
+		nodeInfo, found := nodeInfos[nodeGroup.Id()]
+		if !found || nodeInfo.Node() == nil {
+			filtered = append(filtered, nodeGroup)
+			continue
+		}
+		zone := nodeInfo.Node().Labels[apiv1.LabelTopologyZone]
+		if !IsExtendedZone(zone) || anyPodRequiresZone(unschedulablePods, zone) {
+			filtered = append(filtered, nodeGroup)
+			continue
+		}
+		klog.V(4).Infof("Skipping node group %s in extended zone %s, no unschedulable pod requires the zone", nodeGroup.Id(), zone)
+	}
+	return p.next.Process(context, filtered, nodeInfos, unschedulablePods)
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *NodeGroupListProcessor) CleanUp() {
+	p.next.CleanUp()
+}
+
+func anyPodRequiresZone(pods []*apiv1.Pod, zone string) bool {
+	for _, pod := range pods {
+		if podRequiresZone(pod, zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// podRequiresZone returns true if the pod selects the zone with a node selector or a required node affinity.
+func podRequiresZone(pod *apiv1.Pod, zone string) bool {
+	for _, label := range []string{apiv1.LabelTopologyZone, apiv1.LabelFailureDomainBetaZone} {
+		if pod.Spec.NodeSelector[label] == zone {
+			return true
+		}
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil || pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return false
+	}
+	for _, term := range pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key != apiv1.LabelTopologyZone && expression.Key != apiv1.LabelFailureDomainBetaZone {
+				continue
+			}
+			if expression.Operator != apiv1.NodeSelectorOpIn {
+				continue
+			}
+			for _, value := range expression.Values {
+				if value == zone {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extendedzones
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestNodeGroupListProcessor(t *testing.T) {
+	zones := map[string]string{
+		"az":         "us-west-2a",
+		"local":      "us-west-2-lax-1a",
+		"wavelength": "us-east-1-wl1-bos-wlz-1",
+	}
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	nodeInfos := map[string]*schedulerframework.NodeInfo{}
+	for id, zone := range zones {
+		provider.AddNodeGroup(id, 0, 10, 0)
+		node := BuildTestNode(id+"-template", 1000, 1000)
+		node.Labels[apiv1.LabelTopologyZone] = zone
+		nodeInfo := schedulerframework.NewNodeInfo()
+		nodeInfo.SetNode(node)
+		nodeInfos[id] = nodeInfo
+	}
+
+	anyZonePod := BuildTestPod("any", 100, 100)
+	localZonePod := BuildTestPod("local", 100, 100)
+	localZonePod.Spec.NodeSelector = map[string]string{apiv1.LabelTopologyZone: "us-west-2-lax-1a"}
+	wavelengthZonePod := BuildTestPod("wavelength", 100, 100)
+	wavelengthZonePod.Spec.Affinity = &apiv1.Affinity{NodeAffinity: &apiv1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{NodeSelectorTerms: []apiv1.NodeSelectorTerm{{
+			MatchExpressions: []apiv1.NodeSelectorRequirement{{
+				Key:      apiv1.LabelTopologyZone,
+				Operator: apiv1.NodeSelectorOpIn,
+				Values:   []string{"us-east-1a", "us-east-1-wl1-bos-wlz-1"},
+			}},
+		}}},
+	}}
+
+	tests := []struct {
+		name     string
+		pods     []*apiv1.Pod
+		expected []string
+	}{
+		{"pods without zone requirements", []*apiv1.Pod{anyZonePod}, []string{"az"}},
+		{"pod with zone node selector", []*apiv1.Pod{anyZonePod, localZonePod}, []string{"az", "local"}},
+		{"pod with zone node affinity", []*apiv1.Pod{wavelengthZonePod}, []string{"az", "wavelength"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processor := NewNodeGroupListProcessor(nodegroups.NewDefaultNodeGroupListProcessor())
+			nodeGroups, _, err := processor.Process(nil, provider.NodeGroups(), nodeInfos, test.pods)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, nodeGroupIds(nodeGroups))
+		})
+	}
+}
+
+func nodeGroupIds(nodeGroups []cloudprovider.NodeGroup) []string {
+	ids := make([]string, 0, len(nodeGroups))
+	for _, nodeGroup := range nodeGroups {
+		ids = append(ids, nodeGroup.Id())
+	}
+	return ids
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extendedzones
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// ZoneTypeAvailabilityZone is the type of availability zones, e.g. us-west-2a.
+	ZoneTypeAvailabilityZone = "availability-zone"
+	// ZoneTypeLocalZone is the type of Local Zones, e.g. us-west-2-lax-1a.
+	ZoneTypeLocalZone = "local-zone"
+	// ZoneTypeWavelengthZone is the type of Wavelength Zones, e.g. us-east-1-wl1-bos-wlz-1.
+	ZoneTypeWavelengthZone = "wavelength-zone"
+)
+
+// zoneNameRegexp splits a zone name in its region and the suffix identifying the zone in the region,
+// e.g. "a" for us-west-2a, "-lax-1a" for the us-west-2-lax-1a Local Zone or "-wl1-bos-wlz-1" for the
+// us-east-1-wl1-bos-wlz-1 Wavelength Zone.
+var zoneNameRegexp = regexp.MustCompile(`^([a-z]{2}(?:-gov|-iso[a-z]?)?-[a-z]+-[0-9]+)(.+)$`)
+
+// ParseZone returns the region and the type of the given zone: an availability zone,
+// a Local Zone or a Wavelength Zone.
+func ParseZone(zone string) (region string, zoneType string) {
+	match := zoneNameRegexp.FindStringSubmatch(zone)
+	if match == nil {
+		if zone == "" {
+			return "", ZoneTypeAvailabilityZone
+		}
+		return zone[0 : len(zone)-1], ZoneTypeAvailabilityZone
+	}
+	region, suffix := match[1], match[2]
+	switch {
+	case len(suffix) == 1:
+		return region, ZoneTypeAvailabilityZone
+	case strings.HasPrefix(suffix, "-wl"):
+		return region, ZoneTypeWavelengthZone
+	default:
+		return region, ZoneTypeLocalZone
+	}
+}
+
+// IsExtendedZone returns whether the zone is a Local Zone or a Wavelength Zone, which extend
+// the region with a location, unlike availability zones.
+func IsExtendedZone(zone string) bool {
+	_, zoneType := ParseZone(zone)
+	return zoneType != ZoneTypeAvailabilityZone
+}
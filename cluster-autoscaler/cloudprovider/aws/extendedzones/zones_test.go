@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extendedzones
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseZone(t *testing.T) {
+	tests := []struct {
+		zone     string
+		region   string
+		zoneType string
+	}{
+		{"us-east-1a", "us-east-1", ZoneTypeAvailabilityZone},
+		{"us-gov-west-1b", "us-gov-west-1", ZoneTypeAvailabilityZone},
+		{"ap-northeast-1d", "ap-northeast-1", ZoneTypeAvailabilityZone},
+		{"us-west-2-lax-1a", "us-west-2", ZoneTypeLocalZone},
+		{"us-east-1-bos-1a", "us-east-1", ZoneTypeLocalZone},
+		{"us-east-1-wl1-bos-wlz-1", "us-east-1", ZoneTypeWavelengthZone},
+		{"eu-west-2-wl2-man-wlz-1", "eu-west-2", ZoneTypeWavelengthZone},
+		{"", "", ZoneTypeAvailabilityZone},
+	}
+	for _, test := range tests {
+		t.Run(test.zone, func(t *testing.T) {
+			region, zoneType := ParseZone(test.zone)
+			assert.Equal(t, test.region, region)
+			assert.Equal(t, test.zoneType, zoneType)
+			assert.Equal(t, test.zoneType != ZoneTypeAvailabilityZone, IsExtendedZone(test.zone))
+		})
+	}
+}
@@ -82,14 +82,14 @@ func (as *AgentPool) initialize() error {
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
 
-	template, err := as.manager.azClient.deploymentsClient.ExportTemplate(ctx, as.manager.config.ResourceGroup, as.manager.config.Deployment)
+	template, err := as.manager.getAzClient().deploymentsClient.ExportTemplate(ctx, as.manager.getConfig().ResourceGroup, as.manager.getConfig().Deployment)
 	if err != nil {
-		klog.Errorf("deploymentsClient.ExportTemplate(%s, %s) failed: %v", as.manager.config.ResourceGroup, as.manager.config.Deployment, err)
+		klog.Errorf("deploymentsClient.ExportTemplate(%s, %s) failed: %v", as.manager.getConfig().ResourceGroup, as.manager.getConfig().Deployment, err)
 		return err
 	}
 
 	as.template = template.Template.(map[string]interface{})
-	as.parameters = as.manager.config.DeploymentParameters
+	as.parameters = as.manager.getConfig().DeploymentParameters
 	return normalizeForK8sVMASScalingUp(as.template)
 }
 
@@ -216,7 +216,7 @@ func (as *AgentPool) getAllSucceededAndFailedDeployments() (succeededAndFailedDe
 	defer cancel()
 
 	deploymentsFilter := "provisioningState eq 'Succeeded' or provisioningState eq 'Failed'"
-	succeededAndFailedDeployments, err = as.manager.azClient.deploymentsClient.List(ctx, as.manager.config.ResourceGroup, deploymentsFilter, nil)
+	succeededAndFailedDeployments, err = as.manager.getAzClient().deploymentsClient.List(ctx, as.manager.getConfig().ResourceGroup, deploymentsFilter, nil)
 	if err != nil {
 		klog.Errorf("getAllSucceededAndFailedDeployments: failed to list succeeded or failed deployments with error: %v", err)
 		return nil, err
@@ -241,7 +241,7 @@ func (as *AgentPool) deleteOutdatedDeployments() (err error) {
 		}
 	}
 
-	if int64(len(deployments)) <= as.manager.config.MaxDeploymentsCount {
+	if int64(len(deployments)) <= as.manager.getConfig().MaxDeploymentsCount {
 		klog.V(4).Infof("deleteOutdatedDeployments: the number of deployments (%d) is under threshold, skip deleting", len(deployments))
 		return err
 	}
@@ -250,7 +250,7 @@ func (as *AgentPool) deleteOutdatedDeployments() (err error) {
 		return deployments[i].Properties.Timestamp.Time.After(deployments[j].Properties.Timestamp.Time)
 	})
 
-	toBeDeleted := deployments[as.manager.config.MaxDeploymentsCount:]
+	toBeDeleted := deployments[as.manager.getConfig().MaxDeploymentsCount:]
 
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
@@ -258,7 +258,7 @@ func (as *AgentPool) deleteOutdatedDeployments() (err error) {
 	errList := make([]error, 0)
 	for _, deployment := range toBeDeleted {
 		klog.V(4).Infof("deleteOutdatedDeployments: starts deleting outdated deployment (%s)", *deployment.Name)
-		_, err := as.manager.azClient.deploymentsClient.Delete(ctx, as.manager.config.ResourceGroup, *deployment.Name)
+		_, err := as.manager.getAzClient().deploymentsClient.Delete(ctx, as.manager.getConfig().ResourceGroup, *deployment.Name)
 		if err != nil {
 			errList = append(errList, err)
 		}
@@ -317,11 +317,11 @@ func (as *AgentPool) IncreaseSize(delta int) error {
 	}
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
-	klog.V(3).Infof("Waiting for deploymentsClient.CreateOrUpdate(%s, %s, %v)", as.manager.config.ResourceGroup, newDeploymentName, newDeployment)
-	resp, err := as.manager.azClient.deploymentsClient.CreateOrUpdate(ctx, as.manager.config.ResourceGroup, newDeploymentName, newDeployment)
+	klog.V(3).Infof("Waiting for deploymentsClient.CreateOrUpdate(%s, %s, %v)", as.manager.getConfig().ResourceGroup, newDeploymentName, newDeployment)
+	resp, err := as.manager.getAzClient().deploymentsClient.CreateOrUpdate(ctx, as.manager.getConfig().ResourceGroup, newDeploymentName, newDeployment)
 	isSuccess, realError := isSuccessHTTPResponse(resp, err)
 	if isSuccess {
-		klog.V(3).Infof("deploymentsClient.CreateOrUpdate(%s, %s, %v) success", as.manager.config.ResourceGroup, newDeploymentName, newDeployment)
+		klog.V(3).Infof("deploymentsClient.CreateOrUpdate(%s, %s, %v) success", as.manager.getConfig().ResourceGroup, newDeploymentName, newDeployment)
 
 		// Update cache after scale success.
 		as.curSize = int64(expectedSize)
@@ -503,7 +503,7 @@ func (as *AgentPool) deleteBlob(accountName, vhdContainer, vhdBlob string) error
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
 
-	storageKeysResult, rerr := as.manager.azClient.storageAccountsClient.ListKeys(ctx, as.manager.config.SubscriptionID, as.manager.config.ResourceGroup, accountName)
+	storageKeysResult, rerr := as.manager.getAzClient().storageAccountsClient.ListKeys(ctx, as.manager.getConfig().SubscriptionID, as.manager.getConfig().ResourceGroup, accountName)
 	if rerr != nil {
 		return rerr.Error()
 	}
@@ -526,21 +526,21 @@ func (as *AgentPool) deleteVirtualMachine(name string) error {
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
 
-	vm, rerr := as.manager.azClient.virtualMachinesClient.Get(ctx, as.manager.config.ResourceGroup, name, "")
+	vm, rerr := as.manager.getAzClient().virtualMachinesClient.Get(ctx, as.manager.getConfig().ResourceGroup, name, "")
 	if rerr != nil {
 		if exists, _ := checkResourceExistsFromRetryError(rerr); !exists {
-			klog.V(2).Infof("VirtualMachine %s/%s has already been removed", as.manager.config.ResourceGroup, name)
+			klog.V(2).Infof("VirtualMachine %s/%s has already been removed", as.manager.getConfig().ResourceGroup, name)
 			return nil
 		}
 
-		klog.Errorf("failed to get VM: %s/%s: %s", as.manager.config.ResourceGroup, name, rerr.Error())
+		klog.Errorf("failed to get VM: %s/%s: %s", as.manager.getConfig().ResourceGroup, name, rerr.Error())
 		return rerr.Error()
 	}
 
 	vhd := vm.VirtualMachineProperties.StorageProfile.OsDisk.Vhd
 	managedDisk := vm.VirtualMachineProperties.StorageProfile.OsDisk.ManagedDisk
 	if vhd == nil && managedDisk == nil {
-		klog.Errorf("failed to get a valid os disk URI for VM: %s/%s", as.manager.config.ResourceGroup, name)
+		klog.Errorf("failed to get a valid os disk URI for VM: %s/%s", as.manager.getConfig().ResourceGroup, name)
 		return fmt.Errorf("os disk does not have a VHD URI")
 	}
 
@@ -548,38 +548,38 @@ func (as *AgentPool) deleteVirtualMachine(name string) error {
 	var nicName string
 	nicID := (*vm.VirtualMachineProperties.NetworkProfile.NetworkInterfaces)[0].ID
 	if nicID == nil {
-		klog.Warningf("NIC ID is not set for VM (%s/%s)", as.manager.config.ResourceGroup, name)
+		klog.Warningf("NIC ID is not set for VM (%s/%s)", as.manager.getConfig().ResourceGroup, name)
 	} else {
 		nicName, err := resourceName(*nicID)
 		if err != nil {
 			return err
 		}
-		klog.Infof("found nic name for VM (%s/%s): %s", as.manager.config.ResourceGroup, name, nicName)
+		klog.Infof("found nic name for VM (%s/%s): %s", as.manager.getConfig().ResourceGroup, name, nicName)
 	}
 
-	klog.Infof("deleting VM: %s/%s", as.manager.config.ResourceGroup, name)
+	klog.Infof("deleting VM: %s/%s", as.manager.getConfig().ResourceGroup, name)
 	deleteCtx, deleteCancel := getContextWithCancel()
 	defer deleteCancel()
 
-	klog.Infof("waiting for VirtualMachine deletion: %s/%s", as.manager.config.ResourceGroup, name)
-	rerr = as.manager.azClient.virtualMachinesClient.Delete(deleteCtx, as.manager.config.ResourceGroup, name)
+	klog.Infof("waiting for VirtualMachine deletion: %s/%s", as.manager.getConfig().ResourceGroup, name)
+	rerr = as.manager.getAzClient().virtualMachinesClient.Delete(deleteCtx, as.manager.getConfig().ResourceGroup, name)
 	_, realErr := checkResourceExistsFromRetryError(rerr)
 	if realErr != nil {
 		return realErr
 	}
-	klog.V(2).Infof("VirtualMachine %s/%s removed", as.manager.config.ResourceGroup, name)
+	klog.V(2).Infof("VirtualMachine %s/%s removed", as.manager.getConfig().ResourceGroup, name)
 
 	if len(nicName) > 0 {
-		klog.Infof("deleting nic: %s/%s", as.manager.config.ResourceGroup, nicName)
+		klog.Infof("deleting nic: %s/%s", as.manager.getConfig().ResourceGroup, nicName)
 		interfaceCtx, interfaceCancel := getContextWithCancel()
 		defer interfaceCancel()
-		rerr := as.manager.azClient.interfacesClient.Delete(interfaceCtx, as.manager.config.ResourceGroup, nicName)
-		klog.Infof("waiting for nic deletion: %s/%s", as.manager.config.ResourceGroup, nicName)
+		rerr := as.manager.getAzClient().interfacesClient.Delete(interfaceCtx, as.manager.getConfig().ResourceGroup, nicName)
+		klog.Infof("waiting for nic deletion: %s/%s", as.manager.getConfig().ResourceGroup, nicName)
 		_, realErr := checkResourceExistsFromRetryError(rerr)
 		if realErr != nil {
 			return realErr
 		}
-		klog.V(2).Infof("interface %s/%s removed", as.manager.config.ResourceGroup, nicName)
+		klog.V(2).Infof("interface %s/%s removed", as.manager.getConfig().ResourceGroup, nicName)
 	}
 
 	if vhd != nil {
@@ -596,21 +596,21 @@ func (as *AgentPool) deleteVirtualMachine(name string) error {
 			if realErr != nil {
 				return realErr
 			}
-			klog.V(2).Infof("Blob %s/%s removed", as.manager.config.ResourceGroup, vhdBlob)
+			klog.V(2).Infof("Blob %s/%s removed", as.manager.getConfig().ResourceGroup, vhdBlob)
 		}
 	} else if managedDisk != nil {
 		if osDiskName == nil {
-			klog.Warningf("osDisk is not set for VM %s/%s", as.manager.config.ResourceGroup, name)
+			klog.Warningf("osDisk is not set for VM %s/%s", as.manager.getConfig().ResourceGroup, name)
 		} else {
-			klog.Infof("deleting managed disk: %s/%s", as.manager.config.ResourceGroup, *osDiskName)
+			klog.Infof("deleting managed disk: %s/%s", as.manager.getConfig().ResourceGroup, *osDiskName)
 			disksCtx, disksCancel := getContextWithCancel()
 			defer disksCancel()
-			rerr := as.manager.azClient.disksClient.Delete(disksCtx, as.manager.config.SubscriptionID, as.manager.config.ResourceGroup, *osDiskName)
+			rerr := as.manager.getAzClient().disksClient.Delete(disksCtx, as.manager.getConfig().SubscriptionID, as.manager.getConfig().ResourceGroup, *osDiskName)
 			_, realErr := checkResourceExistsFromRetryError(rerr)
 			if realErr != nil {
 				return realErr
 			}
-			klog.V(2).Infof("disk %s/%s removed", as.manager.config.ResourceGroup, *osDiskName)
+			klog.V(2).Infof("disk %s/%s removed", as.manager.getConfig().ResourceGroup, *osDiskName)
 		}
 	}
 
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	azurecore_policy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/go-autorest/tracing"
+)
+
+const (
+	// remainingRequestsHeaderPrefix prefixes the headers in which ARM reports the remaining requests budget
+	// of a scope, e.g. x-ms-ratelimit-remaining-subscription-reads. See
+	// https://learn.microsoft.com/en-us/azure/azure-resource-manager/management/request-limits-and-throttling
+	remainingRequestsHeaderPrefix = "X-Ms-Ratelimit-Remaining-"
+	// remainingResourceRequestsHeader is the header in which resource providers report the remaining requests
+	// budget of their throttling policies, e.g. Microsoft.Compute/HighCostGet3Min;107,Microsoft.Compute/HighCostGet30Min;775
+	remainingResourceRequestsHeader = "X-Ms-Ratelimit-Remaining-Resource"
+)
+
+var registerARMMetricsTracerOnce sync.Once
+
+// registerARMMetricsTracer instruments the transport of the autorest based Azure clients, including the
+// ones of cloud-provider-azure, with ARM request metrics. It has to be called before any client sends a
+// request, as the transport is created once with the first request.
+func registerARMMetricsTracer() {
+	registerARMMetricsTracerOnce.Do(func() {
+		if !tracing.IsEnabled() {
+			tracing.Register(armMetricsTracer{})
+		}
+	})
+}
+
+// armMetricsTracer is registered as the go-autorest tracer only to instrument the transport shared by
+// the autorest based Azure clients, it doesn't record any spans.
+type armMetricsTracer struct{}
+
+func (armMetricsTracer) NewTransport(base *http.Transport) http.RoundTripper {
+	return &armMetricsTransport{base: base}
+}
+
+func (armMetricsTracer) StartSpan(ctx context.Context, name string) context.Context {
+	return ctx
+}
+
+func (armMetricsTracer) EndSpan(ctx context.Context, httpStatusCode int, err error) {}
+
+// armMetricsTransport records the latency and the throttling of ARM requests, as well as the remaining
+// requests budget reported by the responses.
+type armMetricsTransport struct {
+	base http.RoundTripper
+}
+
+func (t *armMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return observeARMResponse(req, t.base.RoundTrip)
+}
+
+// armMetricsTransporter is the equivalent of armMetricsTransport for the clients of the track 2 SDK.
+type armMetricsTransporter struct {
+	base azurecore_policy.Transporter
+}
+
+func newARMMetricsTransporter(base azurecore_policy.Transporter) *armMetricsTransporter {
+	return &armMetricsTransporter{base: base}
+}
+
+func (t *armMetricsTransporter) Do(req *http.Request) (*http.Response, error) {
+	return observeARMResponse(req, t.base.Do)
+}
+
+func observeARMResponse(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	start := time.Now()
+	resp, err := send(req)
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		observeARMRemainingRequestsHeaders(resp.Header)
+	}
+	observeARMRequest(armOperation(req), statusCode, time.Since(start))
+	return resp, err
+}
+
+// armOperation returns the operation of an ARM request, made of its method, resource provider and resource
+// types, e.g. "GET Microsoft.Compute/virtualMachineScaleSets/virtualMachines" for listing the VMs of a scale
+// set. Resource names are left out to keep the number of operations bounded.
+func armOperation(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	start := 0
+	for i := len(segments) - 2; i >= 0; i-- {
+		if strings.EqualFold(segments[i], "providers") {
+			start = i + 1
+			break
+		}
+	}
+	var types []string
+	if start > 0 {
+		// The resource provider namespace isn't followed by a name.
+		types = append(types, segments[start])
+		start++
+	}
+	for i := start; i < len(segments); i += 2 {
+		types = append(types, segments[i])
+	}
+	return req.Method + " " + strings.Join(types, "/")
+}
+
+// observeARMRemainingRequestsHeaders records the remaining requests budgets reported in the response headers.
+// Malformed values are ignored.
+func observeARMRemainingRequestsHeaders(header http.Header) {
+	for key, values := range header {
+		if len(values) == 0 || !strings.HasPrefix(key, remainingRequestsHeaderPrefix) {
+			continue
+		}
+		if key == remainingResourceRequestsHeader {
+			for _, policy := range strings.Split(values[0], ",") {
+				budget, remaining, found := strings.Cut(strings.TrimSpace(policy), ";")
+				if n, err := strconv.Atoi(remaining); found && err == nil {
+					observeARMRemainingRequests("resource", budget, n)
+				}
+			}
+			continue
+		}
+		// E.g. Subscription-Reads or Subscription-Global-Writes.
+		scope, budget, found := strings.Cut(strings.ToLower(strings.TrimPrefix(key, remainingRequestsHeaderPrefix)), "-")
+		if n, err := strconv.Atoi(values[0]); found && err == nil {
+			observeARMRemainingRequests(scope, budget, n)
+		}
+	}
+}
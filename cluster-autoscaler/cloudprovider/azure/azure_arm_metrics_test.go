@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestARMOperation(t *testing.T) {
+	testCases := []struct {
+		method    string
+		path      string
+		operation string
+	}{
+		{
+			method:    http.MethodGet,
+			path:      "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets",
+			operation: "GET Microsoft.Compute/virtualMachineScaleSets",
+		},
+		{
+			method:    http.MethodGet,
+			path:      "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/vmss/virtualMachines",
+			operation: "GET Microsoft.Compute/virtualMachineScaleSets/virtualMachines",
+		},
+		{
+			method:    http.MethodPost,
+			path:      "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/vmss/delete",
+			operation: "POST Microsoft.Compute/virtualMachineScaleSets/delete",
+		},
+		{
+			method:    http.MethodGet,
+			path:      "/subscriptions/sub/providers/Microsoft.Compute/locations/eastus/operations/id",
+			operation: "GET Microsoft.Compute/locations/operations",
+		},
+		{
+			method:    http.MethodGet,
+			path:      "/subscriptions/sub/resourcegroups/rg",
+			operation: "GET subscriptions/resourcegroups",
+		},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "https://management.azure.com"+tc.path+"?api-version=2022-08-01", nil)
+		assert.Equal(t, tc.operation, armOperation(req), tc.path)
+	}
+}
+
+func TestARMMetricsTransport(t *testing.T) {
+	registry := k8smetrics.NewKubeRegistry()
+	registry.MustRegister(armRequestDuration, armThrottledRequests, armRemainingRequests)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ms-ratelimit-remaining-subscription-reads", "11999")
+		w.Header().Set("x-ms-ratelimit-remaining-tenant-writes", "not-a-number")
+		w.Header().Set("x-ms-ratelimit-remaining-resource", "Microsoft.Compute/HighCostGet3Min;107,Microsoft.Compute/HighCostGet30Min;775")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: armMetricsTracer{}.NewTransport(&http.Transport{})}
+	resp, err := client.Get(server.URL + "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	operation := "GET Microsoft.Compute/virtualMachineScaleSets"
+	throttled, err := testutil.GetCounterMetricValue(armThrottledRequests.WithLabelValues(operation))
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, throttled)
+	requests, err := testutil.GetHistogramMetricCount(armRequestDuration.WithLabelValues(operation, "429"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), requests)
+
+	for _, tc := range []struct {
+		scope, budget string
+		remaining     float64
+	}{
+		{"subscription", "reads", 11999},
+		{"resource", "Microsoft.Compute/HighCostGet3Min", 107},
+		{"resource", "Microsoft.Compute/HighCostGet30Min", 775},
+	} {
+		remaining, err := testutil.GetGaugeMetricValue(armRemainingRequests.WithLabelValues(tc.scope, tc.budget))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.remaining, remaining, tc.budget)
+	}
+	// Malformed budgets aren't recorded.
+	gathered, err := registry.Gather()
+	assert.NoError(t, err)
+	for _, family := range gathered {
+		if family.GetName() == "cluster_autoscaler_azure_arm_remaining_requests" {
+			assert.Len(t, family.GetMetric(), 3)
+		}
+	}
+}
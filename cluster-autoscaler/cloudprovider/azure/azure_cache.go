@@ -30,6 +30,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
 
 var (
@@ -59,9 +60,25 @@ type azureCache struct {
 	unownedInstances     map[azureRef]bool
 	autoscalingOptions   map[azureRef]map[string]string
 	skus                 map[string]*skewer.Cache
+
+	// lazyVMDiscovery makes the cache look up standalone VMs one by one, when
+	// they're seen as nodes, instead of listing all VMs of the resource group.
+	lazyVMDiscovery bool
+	// lazyVMs holds the VMs looked up in lazy VM discovery mode, by resource ID.
+	lazyVMs map[azureRef]lazyVM
+}
+
+// lazyVM is a standalone VM looked up in lazy VM discovery mode.
+type lazyVM struct {
+	// poolName is the name of the VMs pool of the VM, empty if it isn't part of one.
+	poolName string
+	vm       compute.VirtualMachine
+	// expires is when a VM which isn't part of a VMs pool is looked up again, as its
+	// tags may not have been set yet when it was first seen.
+	expires time.Time
 }
 
-func newAzureCache(client *azClient, cacheTTL time.Duration, resourceGroup, vmType string, enableDynamicInstanceList bool, defaultLocation string) (*azureCache, error) {
+func newAzureCache(client *azClient, cacheTTL time.Duration, resourceGroup, vmType string, enableDynamicInstanceList, lazyVMDiscovery bool, defaultLocation string) (*azureCache, error) {
 	cache := &azureCache{
 		interrupt:            make(chan struct{}),
 		azClient:             client,
@@ -76,6 +93,8 @@ func newAzureCache(client *azClient, cacheTTL time.Duration, resourceGroup, vmTy
 		unownedInstances:     make(map[azureRef]bool),
 		autoscalingOptions:   make(map[azureRef]map[string]string),
 		skus:                 make(map[string]*skewer.Cache),
+		lazyVMDiscovery:      lazyVMDiscovery,
+		lazyVMs:              make(map[azureRef]lazyVM),
 	}
 
 	if enableDynamicInstanceList {
@@ -89,6 +108,14 @@ func newAzureCache(client *azClient, cacheTTL time.Duration, resourceGroup, vmTy
 	return cache, nil
 }
 
+func (m *azureCache) setAzClient(client *azClient, cacheTTL time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.azClient = client
+	m.refreshInterval = cacheTTL
+}
+
 func (m *azureCache) getVMsPoolSet() map[string]struct{} {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -148,9 +175,19 @@ func (m *azureCache) regenerate() error {
 		newAutoscalingOptions[ref] = options
 	}
 
-	newSkuCache := make(map[string]*skewer.Cache)
+	// The SKUs are fetched without lock, with the clients and locations of the current config,
+	// as the clients are replaced when the config is reloaded.
+	m.mutex.Lock()
+	client := m.azClient
+	locations := make([]string, 0, len(m.skus))
 	for location := range m.skus {
-		cache, err := m.fetchSKUs(context.Background(), location)
+		locations = append(locations, location)
+	}
+	m.mutex.Unlock()
+
+	newSkuCache := make(map[string]*skewer.Cache)
+	for _, location := range locations {
+		cache, err := fetchSKUs(context.Background(), client, location)
 		if err != nil {
 			return err
 		}
@@ -183,7 +220,11 @@ func (m *azureCache) fetchAzureResources() error {
 		return err
 	}
 
-	vmResult, vmsPoolSet, err := m.fetchVirtualMachines()
+	fetchVirtualMachines := m.fetchVirtualMachines
+	if m.lazyVMDiscovery {
+		fetchVirtualMachines = m.refreshLazyVMs
+	}
+	vmResult, vmsPoolSet, err := fetchVirtualMachines()
 	if err == nil {
 		m.virtualMachines = vmResult
 		m.vmsPoolSet = vmsPoolSet
@@ -247,14 +288,113 @@ func (m *azureCache) fetchVirtualMachines() (map[string][]compute.VirtualMachine
 	return instances, vmsPoolSet, nil
 }
 
+// refreshLazyVMs refreshes the VMs pool members looked up in lazy VM discovery mode with
+// targeted GETs, forgetting deleted ones, and returns them like fetchVirtualMachines.
+// Should be called with lock.
+func (m *azureCache) refreshLazyVMs() (map[string][]compute.VirtualMachine, map[string]struct{}, error) {
+	now := time.Now()
+	for ref, known := range m.lazyVMs {
+		if known.poolName == "" {
+			if !now.Before(known.expires) {
+				delete(m.lazyVMs, ref)
+			}
+			continue
+		}
+		ctx, cancel := getContextWithCancel()
+		vm, rerr := m.azClient.virtualMachinesClient.Get(ctx, m.resourceGroup, to.String(known.vm.Name), "")
+		cancel()
+		if exists, err := checkResourceExistsFromRetryError(rerr); err != nil {
+			klog.Warningf("Failed to refresh VM %q, keeping the cached one: %v", ref.Name, err)
+		} else if !exists {
+			klog.V(4).Infof("VM %q of VMs pool %q was deleted", ref.Name, known.poolName)
+			delete(m.lazyVMs, ref)
+		} else {
+			m.lazyVMs[ref] = lazyVM{poolName: known.poolName, vm: vm}
+		}
+	}
+
+	instances := make(map[string][]compute.VirtualMachine)
+	vmsPoolSet := make(map[string]struct{})
+	for _, known := range m.lazyVMs {
+		if known.poolName == "" {
+			continue
+		}
+		instances[known.poolName] = append(instances[known.poolName], known.vm)
+		vmsPoolSet[known.poolName] = struct{}{}
+	}
+	return instances, vmsPoolSet, nil
+}
+
+// lookUpLazyVM returns the VMs pool name of the VM with the given resource ID, empty if
+// it isn't part of one, getting the VM if it wasn't looked up before. VMs outside of the
+// resource group are never part of a VMs pool, as in fetchVirtualMachines. VMs which
+// aren't part of one are looked up again after the cache refresh interval.
+// Should be called with lock.
+func (m *azureCache) lookUpLazyVM(ref azureRef) (string, error) {
+	now := time.Now()
+	if known, found := m.lazyVMs[ref]; found && (known.poolName != "" || now.Before(known.expires)) {
+		return known.poolName, nil
+	}
+	nameMatch := virtualMachineRE.FindStringSubmatch(ref.Name)
+	groupMatch := azureResourceGroupNameRE.FindStringSubmatch(ref.Name)
+	if len(nameMatch) != 2 || len(groupMatch) != 2 || !strings.EqualFold(groupMatch[1], m.resourceGroup) {
+		return "", nil
+	}
+
+	ctx, cancel := getContextWithCancel()
+	defer cancel()
+	vm, rerr := m.azClient.virtualMachinesClient.Get(ctx, m.resourceGroup, nameMatch[1], "")
+	if exists, err := checkResourceExistsFromRetryError(rerr); err != nil {
+		return "", err
+	} else if !exists {
+		m.lazyVMs[ref] = lazyVM{expires: now.Add(m.refreshInterval)}
+		return "", nil
+	}
+
+	var poolName string
+	if vmPoolName := vm.Tags[agentpoolNameTag]; vmPoolName != nil {
+		poolName = to.String(vmPoolName)
+	} else if vmPoolName := vm.Tags[legacyAgentpoolNameTag]; vmPoolName != nil {
+		poolName = to.String(vmPoolName)
+	}
+	if poolType := vm.Tags[agentpoolTypeTag]; poolName == "" || poolType == nil || !strings.EqualFold(to.String(poolType), vmsPoolType) {
+		poolName = ""
+	}
+	if poolName == "" {
+		m.lazyVMs[ref] = lazyVM{vm: vm, expires: now.Add(m.refreshInterval)}
+		return "", nil
+	}
+	m.lazyVMs[ref] = lazyVM{poolName: poolName, vm: vm}
+
+	// Replace the maps rather than updating them, as they're read without lock.
+	virtualMachines := make(map[string][]compute.VirtualMachine, len(m.virtualMachines)+1)
+	for name, vms := range m.virtualMachines {
+		virtualMachines[name] = vms
+	}
+	virtualMachines[poolName] = append(append([]compute.VirtualMachine{}, m.virtualMachines[poolName]...), vm)
+	vmsPoolSet := map[string]struct{}{poolName: {}}
+	for name := range m.vmsPoolSet {
+		vmsPoolSet[name] = struct{}{}
+	}
+	m.virtualMachines = virtualMachines
+	m.vmsPoolSet = vmsPoolSet
+	return poolName, nil
+}
+
 // fetchScaleSets returns the updated list of scale sets in the config resource group using the Azure API.
 func (m *azureCache) fetchScaleSets() (map[string]compute.VirtualMachineScaleSet, error) {
 	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
 	defer cancel()
 
-	result, err := m.azClient.virtualMachineScaleSetsClient.List(ctx, m.resourceGroup)
+	var result []compute.VirtualMachineScaleSet
+	var err *retry.Error
+	if m.azClient.resourceGraphScaleSetsLister != nil {
+		result, err = m.azClient.resourceGraphScaleSetsLister.ListScaleSets(ctx, []string{m.resourceGroup})
+	} else {
+		result, err = m.azClient.virtualMachineScaleSetsClient.List(ctx, m.resourceGroup)
+	}
 	if err != nil {
-		klog.Errorf("VirtualMachineScaleSetsClient.List in resource group %q failed: %v", m.resourceGroup, err)
+		klog.Errorf("Listing scale sets in resource group %q failed: %v", m.resourceGroup, err)
 		return nil, err.Error()
 	}
 
@@ -314,10 +454,10 @@ func (m *azureCache) Unregister(nodeGroup cloudprovider.NodeGroup) bool {
 	return changed
 }
 
-func (m *azureCache) fetchSKUs(ctx context.Context, location string) (*skewer.Cache, error) {
+func fetchSKUs(ctx context.Context, client *azClient, location string) (*skewer.Cache, error) {
 	return skewer.NewCache(ctx,
 		skewer.WithLocation(location),
-		skewer.WithResourceClient(m.azClient.skuClient),
+		skewer.WithResourceClient(client.skuClient),
 	)
 }
 
@@ -328,7 +468,7 @@ func (m *azureCache) GetSKU(ctx context.Context, skuName, location string) (skew
 	cache, ok := m.skus[location]
 	if !ok {
 		var err error
-		cache, err = m.fetchSKUs(ctx, location)
+		cache, err = fetchSKUs(ctx, m.azClient, location)
 		if err != nil {
 			klog.V(1).Infof("Failed to instantiate cache, err: %v", err)
 			return skewer.SKU{}, err
@@ -373,6 +513,27 @@ func (m *azureCache) FindForInstance(instance *azureRef, vmType string) (cloudpr
 		return nil, nil
 	}
 
+	// Look up standalone VMs one by one rather than relying on the list of all VMs.
+	if m.lazyVMDiscovery && virtualMachineRE.MatchString(inst.Name) {
+		poolName, err := m.lookUpLazyVM(inst)
+		if err != nil {
+			return nil, err
+		}
+		if poolName == "" {
+			klog.V(3).Infof("Instance %q is not part of a VMs pool, omit it in autoscaler", instance.Name)
+			m.unownedInstances[inst] = true
+			return nil, nil
+		}
+		for _, nodeGroup := range m.registeredNodeGroups {
+			if strings.EqualFold(nodeGroup.Id(), poolName) {
+				m.instanceToNodeGroup[inst] = nodeGroup
+				return nodeGroup, nil
+			}
+		}
+		klog.V(4).Infof("FindForInstance: VMs pool %q of instance %q is not registered", poolName, inst)
+		return nil, nil
+	}
+
 	// cluster with vmss pool only
 	if vmType == vmTypeVMSS && len(vmsPoolSet) == 0 {
 		if m.areAllScaleSetsUniform() {
@@ -17,10 +17,20 @@ limitations under the License.
 package azure
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	skucompute "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient/mockvmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient/mockvmssclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -70,3 +80,129 @@ func TestFindForInstance(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, ac.unownedInstances[inst])
 }
+
+func TestFindForInstanceLazyVMDiscovery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vmsPoolVM := compute.VirtualMachine{
+		Name: to.StringPtr("vms-pool-vm"),
+		ID:   to.StringPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vms-pool-vm"),
+		Tags: map[string]*string{
+			agentpoolTypeTag: to.StringPtr(vmsPoolType),
+			agentpoolNameTag: to.StringPtr("test-vms-pool"),
+		},
+	}
+	vmasVM := compute.VirtualMachine{
+		Name: to.StringPtr("vmas-vm"),
+		ID:   to.StringPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vmas-vm"),
+		Tags: map[string]*string{
+			legacyAgentpoolNameTag: to.StringPtr("vmas"),
+		},
+	}
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), "rg").Return([]compute.VirtualMachineScaleSet{}, nil).AnyTimes()
+	// VMs are never listed, only the ones seen as nodes are looked up, once.
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().Get(gomock.Any(), "rg", "vms-pool-vm", gomock.Any()).Return(vmsPoolVM, nil)
+	mockVMClient.EXPECT().Get(gomock.Any(), "rg", "vmas-vm", gomock.Any()).Return(vmasVM, nil)
+
+	provider := newTestProvider(t)
+	provider.azureManager.config.LazyVirtualMachineDiscovery = true
+	provider.azureManager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	provider.azureManager.azClient.virtualMachinesClient = mockVMClient
+	ac, err := newAzureCache(provider.azureManager.azClient, refreshInterval, "rg", vmTypeVMSS, false, true, "")
+	assert.NoError(t, err)
+	provider.azureManager.azureCache = ac
+	vmsPool := newTestVMsPool(provider.azureManager, "test-vms-pool")
+	ac.Register(vmsPool)
+
+	for i := 0; i < 2; i++ {
+		nodeGroup, err := ac.FindForInstance(&azureRef{Name: "azure://" + *vmsPoolVM.ID}, vmTypeVMSS)
+		assert.NoError(t, err)
+		assert.Equal(t, vmsPool, nodeGroup)
+
+		nodeGroup, err = ac.FindForInstance(&azureRef{Name: "azure://" + *vmasVM.ID}, vmTypeVMSS)
+		assert.NoError(t, err)
+		assert.Nil(t, nodeGroup)
+
+		nodeGroup, err = ac.FindForInstance(&azureRef{Name: "azure:///subscriptions/sub/resourceGroups/other-rg/providers/Microsoft.Compute/virtualMachines/vm"}, vmTypeVMSS)
+		assert.NoError(t, err)
+		assert.Nil(t, nodeGroup)
+	}
+	assert.Equal(t, map[string]struct{}{"test-vms-pool": {}}, ac.getVMsPoolSet())
+	assert.Equal(t, []compute.VirtualMachine{vmsPoolVM}, ac.getVirtualMachines()["test-vms-pool"])
+
+	// Deleted VMs are forgotten when the cache is regenerated.
+	mockVMClient.EXPECT().Get(gomock.Any(), "rg", "vms-pool-vm", gomock.Any()).Return(compute.VirtualMachine{}, &retry.Error{HTTPStatusCode: http.StatusNotFound})
+	assert.NoError(t, ac.regenerate())
+	assert.Empty(t, ac.getVMsPoolSet())
+	nodes, err := vmsPool.Nodes()
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+
+	// VMs which aren't part of a VMs pool are looked up again once their entry expires,
+	// as their tags may have been set since.
+	vmasRef := azureRef{Name: "azure://" + *vmasVM.ID}
+	ac.lazyVMs[vmasRef] = lazyVM{vm: vmasVM, expires: time.Now().Add(-time.Second)}
+	taggedVM := vmasVM
+	taggedVM.Tags = vmsPoolVM.Tags
+	mockVMClient.EXPECT().Get(gomock.Any(), "rg", "vmas-vm", gomock.Any()).Return(taggedVM, nil)
+	nodeGroup, err := ac.FindForInstance(&azureRef{Name: "azure://" + *vmasVM.ID}, vmTypeVMSS)
+	assert.NoError(t, err)
+	assert.Equal(t, vmsPool, nodeGroup)
+
+	// Expired entries of VMs which aren't part of a VMs pool are dropped on refreshes.
+	otherRef := azureRef{Name: "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/other-vm"}
+	ac.lazyVMs[otherRef] = lazyVM{expires: time.Now().Add(-time.Second)}
+	mockVMClient.EXPECT().Get(gomock.Any(), "rg", "vmas-vm", gomock.Any()).Return(taggedVM, nil)
+	assert.NoError(t, ac.regenerate())
+	assert.NotContains(t, ac.lazyVMs, otherRef)
+}
+
+func TestRegenerateWhileReloadingConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	skuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value": []}`))
+	}))
+	defer skuServer.Close()
+	newClient := func() *azClient {
+		mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+		mockVMSSClient.EXPECT().List(gomock.Any(), "rg").Return([]compute.VirtualMachineScaleSet{}, nil).AnyTimes()
+		mockVMClient := mockvmclient.NewMockInterface(ctrl)
+		mockVMClient.EXPECT().List(gomock.Any(), "rg").Return([]compute.VirtualMachine{}, nil).AnyTimes()
+		return &azClient{
+			virtualMachineScaleSetsClient: mockVMSSClient,
+			virtualMachinesClient:         mockVMClient,
+			skuClient:                     skucompute.NewResourceSkusClientWithBaseURI(skuServer.URL, "sub"),
+		}
+	}
+
+	ac, err := newAzureCache(newClient(), refreshInterval, "rg", vmTypeVMSS, true, false, "eastus")
+	assert.NoError(t, err)
+
+	// The clients are replaced by config reloads while the cache is regenerated, which is caught with -race.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				ac.setAzClient(newClient(), refreshInterval)
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, ac.regenerate())
+	}
+	close(stop)
+	<-done
+	_, found := ac.skus["eastus"]
+	assert.True(t, found)
+}
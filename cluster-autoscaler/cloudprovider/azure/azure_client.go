@@ -44,6 +44,7 @@ import (
 
 	klog "k8s.io/klog/v2"
 
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/diskclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/interfaceclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/storageaccountclient"
@@ -227,7 +228,7 @@ func newAgentpoolClientWithConfig(subscriptionID string, cred azcore.TokenCreden
 					},
 				},
 				Telemetry: azextensions.DefaultTelemetryOpts(getUserAgentExtension()),
-				Transport: azextensions.DefaultHTTPClient(),
+				Transport: newARMMetricsTransporter(azextensions.DefaultHTTPClient()),
 				Retry:     retryOptions,
 			},
 		})
@@ -266,14 +267,18 @@ type azAccountsClient struct {
 
 type azClient struct {
 	virtualMachineScaleSetsClient   vmssclient.Interface
+	virtualMachineScaleSetsUpdater  VirtualMachineScaleSetsUpdater
 	virtualMachineScaleSetVMsClient vmssvmclient.Interface
-	virtualMachinesClient           vmclient.Interface
-	deploymentsClient               DeploymentsClient
-	interfacesClient                interfaceclient.Interface
-	disksClient                     diskclient.Interface
-	storageAccountsClient           storageaccountclient.Interface
-	skuClient                       compute.ResourceSkusClient
-	agentPoolClient                 AgentPoolsClient
+	virtualMachineScaleSetVMsPager  VirtualMachineScaleSetVMsPager
+	// resourceGraphScaleSetsLister is only set if the scale sets are listed with Resource Graph queries.
+	resourceGraphScaleSetsLister ScaleSetsLister
+	virtualMachinesClient        vmclient.Interface
+	deploymentsClient            DeploymentsClient
+	interfacesClient             interfaceclient.Interface
+	disksClient                  diskclient.Interface
+	storageAccountsClient        storageaccountclient.Interface
+	skuClient                    compute.ResourceSkusClient
+	agentPoolClient              AgentPoolsClient
 }
 
 // newServicePrincipalTokenFromCredentials creates a new ServicePrincipalToken using values of the
@@ -360,6 +365,9 @@ func newAuthorizer(config *Config, env *azure.Environment) (autorest.Authorizer,
 }
 
 func newAzClient(cfg *Config, env *azure.Environment) (*azClient, error) {
+	// The transport of the clients is instrumented once created, so this has to precede them.
+	registerARMMetricsTracer()
+
 	authorizer, err := newAuthorizer(cfg, env)
 	if err != nil {
 		return nil, err
@@ -369,12 +377,32 @@ func newAzClient(cfg *Config, env *azure.Environment) (*azClient, error) {
 	azClientConfig.UserAgent = getUserAgentExtension()
 
 	vmssClientConfig := azClientConfig.WithRateLimiter(cfg.VirtualMachineScaleSetRateLimit)
-	scaleSetsClient := vmssclient.New(vmssClientConfig)
+	var scaleSetsClient vmssclient.Interface = vmssclient.New(vmssClientConfig)
 	klog.V(5).Infof("Created scale set client with authorizer: %v", scaleSetsClient)
+	// The scale sets client and updater share the write rate limit and Retry-After deadline.
+	scaleSetsWriteThrottle := newScaleSetsWriteThrottle(vmssClientConfig)
+	scaleSetsClient = &writeThrottledScaleSetsClient{Interface: scaleSetsClient, throttle: scaleSetsWriteThrottle}
+	scaleSetsUpdater := newAzScaleSetsUpdater(vmssClientConfig, scaleSetsWriteThrottle)
 
 	vmssVMClientConfig := azClientConfig.WithRateLimiter(cfg.VirtualMachineScaleSetRateLimit)
-	scaleSetVMsClient := vmssvmclient.New(vmssVMClientConfig)
+	var scaleSetVMsClient vmssvmclient.Interface = vmssvmclient.New(vmssVMClientConfig)
 	klog.V(5).Infof("Created scale set vm client with authorizer: %v", scaleSetVMsClient)
+	var scaleSetVMsPager VirtualMachineScaleSetVMsPager
+	if cfg.UseVmssVMsPager {
+		// The pages and the other reads of scale set VMs share the read rate limit.
+		rateLimiterReader, _ := azclients.NewRateLimiter(vmssVMClientConfig.RateLimitConfig)
+		scaleSetVMsPager = newAzScaleSetVMsPager(vmssVMClientConfig, rateLimiterReader, scaleSetVMsClient)
+		scaleSetVMsClient = &rateLimitedScaleSetVMsClient{Interface: scaleSetVMsClient, rateLimiterReader: rateLimiterReader}
+		klog.V(5).Infof("Created scale set vm pager")
+	}
+	var resourceGraphScaleSetsLister ScaleSetsLister
+	if cfg.UseResourceGraph {
+		// The queries and the other reads of scale sets share the read rate limit.
+		rateLimiterReader, _ := azclients.NewRateLimiter(vmssClientConfig.RateLimitConfig)
+		resourceGraphScaleSetsLister = newAzResourceGraphScaleSetsLister(vmssClientConfig, rateLimiterReader)
+		scaleSetsClient = &rateLimitedScaleSetsClient{Interface: scaleSetsClient, rateLimiterReader: rateLimiterReader}
+		klog.V(5).Infof("Created resource graph scale sets lister")
+	}
 
 	vmClientConfig := azClientConfig.WithRateLimiter(cfg.VirtualMachineRateLimit)
 	virtualMachinesClient := vmclient.New(vmClientConfig)
@@ -412,7 +440,10 @@ func newAzClient(cfg *Config, env *azure.Environment) (*azClient, error) {
 		disksClient:                     disksClient,
 		interfacesClient:                interfacesClient,
 		virtualMachineScaleSetsClient:   scaleSetsClient,
+		virtualMachineScaleSetsUpdater:  scaleSetsUpdater,
 		virtualMachineScaleSetVMsClient: scaleSetVMsClient,
+		virtualMachineScaleSetVMsPager:  scaleSetVMsPager,
+		resourceGraphScaleSetsLister:    resourceGraphScaleSetsLister,
 		deploymentsClient:               deploymentsClient,
 		virtualMachinesClient:           virtualMachinesClient,
 		storageAccountsClient:           storageAccountsClient,
@@ -121,8 +121,17 @@ func (azure *AzureCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovid
 	return azure.azureManager.GetNodeGroupForInstance(ref)
 }
 
-// HasInstance returns whether a given node has a corresponding instance in this cloud provider
-func (azure *AzureCloudProvider) HasInstance(*apiv1.Node) (bool, error) {
+// HasInstance returns whether a given node has a corresponding instance in this cloud provider.
+// Only instances of scale sets operations were denied on because they don't exist anymore are
+// known not to exist.
+func (azure *AzureCloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	nodeGroup, err := azure.NodeGroupForNode(node)
+	if err != nil || nodeGroup == nil {
+		return true, cloudprovider.ErrNotImplemented
+	}
+	if scaleSet, ok := nodeGroup.(*ScaleSet); ok && scaleSet.isInstanceNotFound(node.Spec.ProviderID) {
+		return false, nil
+	}
 	return true, cloudprovider.ErrNotImplemented
 }
 
@@ -187,9 +196,16 @@ func BuildAzure(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscov
 	if err != nil {
 		klog.Fatalf("Failed to create Azure Manager: %v", err)
 	}
+	if opts.CloudConfig != "" && manager.getConfig().EnableConfigReload {
+		manager.configReloader, err = newConfigReloader(opts.CloudConfig)
+		if err != nil {
+			klog.Fatalf("Failed to watch Azure cloud config: %v", err)
+		}
+	}
 	provider, err := BuildAzureCloudProvider(manager, rl)
 	if err != nil {
 		klog.Fatalf("Failed to create Azure cloud provider: %v", err)
 	}
+	RegisterMetrics()
 	return provider
 }
@@ -60,6 +60,7 @@ func newTestAzureManager(t *testing.T) *AzureManager {
 		},
 		azClient: &azClient{
 			virtualMachineScaleSetsClient:   mockVMSSClient,
+			virtualMachineScaleSetsUpdater:  &fakeScaleSetsUpdater{},
 			virtualMachineScaleSetVMsClient: mockVMSSVMClient,
 			virtualMachinesClient:           mockVMClient,
 			deploymentsClient: &DeploymentsClientMock{
@@ -82,7 +83,7 @@ func newTestAzureManager(t *testing.T) *AzureManager {
 		},
 	}
 
-	cache, error := newAzureCache(manager.azClient, refreshInterval, manager.config.ResourceGroup, vmTypeVMSS, false, "")
+	cache, error := newAzureCache(manager.azClient, refreshInterval, manager.config.ResourceGroup, vmTypeVMSS, false, false, "")
 	assert.NoError(t, error)
 
 	manager.azureCache = cache
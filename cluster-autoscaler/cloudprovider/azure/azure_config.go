@@ -63,6 +63,10 @@ const (
 	// toggle
 	dynamicInstanceListDefault = false
 	enableVmssFlexDefault      = false
+
+	// ghost instances
+	ghostInstanceTimeoutDefault = 20 * 60 // in seconds
+	ghostInstanceMinRefreshes   = 2
 )
 
 // CloudProviderRateLimitConfig indicates the rate limit config for each clients.
@@ -141,11 +145,47 @@ type Config struct {
 	// EnableForceDelete defines whether to enable force deletion on the APIs
 	EnableForceDelete bool `json:"enableForceDelete,omitempty" yaml:"enableForceDelete,omitempty"`
 
+	// ClearInstanceProtection defines whether to clear the protection of VMSS instances before deleting them,
+	// if the protection was set by the autoscaler, i.e. the instance is tagged with the instance protection tag.
+	// Otherwise protected instances are never deleted.
+	ClearInstanceProtection bool `json:"clearInstanceProtection,omitempty" yaml:"clearInstanceProtection,omitempty"`
+
+	// LazyVirtualMachineDiscovery defines whether to look up standalone VMs one by one when they're seen as nodes,
+	// instead of listing all VMs of the resource group on every cache refresh. Only supported by the vmss VM type.
+	LazyVirtualMachineDiscovery bool `json:"lazyVirtualMachineDiscovery,omitempty" yaml:"lazyVirtualMachineDiscovery,omitempty"`
+
+	// UseResourceGraph defines whether to list the scale sets with Azure Resource Graph queries
+	// instead of the Compute API.
+	UseResourceGraph bool `json:"useResourceGraph,omitempty" yaml:"useResourceGraph,omitempty"`
+
+	// UseVmssVMsPager defines whether to list the VMs of uniform scale sets page by page, keeping
+	// only the fields the instance cache needs, instead of holding all the VMs in memory.
+	UseVmssVMsPager bool `json:"useVmssVmsPager,omitempty" yaml:"useVmssVmsPager,omitempty"`
+
+	// DeleteGhostInstances defines whether to delete VMSS instances which failed provisioning and never became nodes,
+	// i.e. which have been in the failed provisioning state since they were first seen, for longer than GhostInstanceTimeout.
+	DeleteGhostInstances bool `json:"deleteGhostInstances,omitempty" yaml:"deleteGhostInstances,omitempty"`
+
+	// GhostInstanceTimeout is the time in seconds after which ghost VMSS instances are deleted, 20 minutes by default
+	GhostInstanceTimeout int64 `json:"ghostInstanceTimeout,omitempty" yaml:"ghostInstanceTimeout,omitempty"`
+
 	// EnableDynamicInstanceList defines whether to enable dynamic instance workflow for instance information check
 	EnableDynamicInstanceList bool `json:"enableDynamicInstanceList,omitempty" yaml:"enableDynamicInstanceList,omitempty"`
 
 	// EnableVmssFlex defines whether to enable Vmss Flex support or not
 	EnableVmssFlex bool `json:"enableVmssFlex,omitempty" yaml:"enableVmssFlex,omitempty"`
+
+	// NetworkPlugin is the network plugin of the cluster: kubenet (default), azure or none. It determines the
+	// max pods of the node templates, unless set by the pods resource tag of the scale set.
+	NetworkPlugin string `json:"networkPlugin,omitempty" yaml:"networkPlugin,omitempty"`
+
+	// NetworkPluginMode is the mode of the azure network plugin: empty for pod IPs allocated from a subnet,
+	// statically (traditional Azure CNI) or dynamically, or overlay.
+	NetworkPluginMode string `json:"networkPluginMode,omitempty" yaml:"networkPluginMode,omitempty"`
+
+	// EnableConfigReload defines whether to reload the cloud config file when it changes, e.g. when it is mounted
+	// from a Secret. Only credentials, rate limits, backoff and cache TTLs can be changed without a restart.
+	EnableConfigReload bool `json:"enableConfigReload,omitempty" yaml:"enableConfigReload,omitempty"`
 }
 
 // BuildAzureConfig returns a Config object for the Azure clients
@@ -327,6 +367,56 @@ func BuildAzureConfig(configReader io.Reader) (*Config, error) {
 		}
 	}
 
+	if clearInstanceProtection := os.Getenv("AZURE_CLEAR_INSTANCE_PROTECTION"); clearInstanceProtection != "" {
+		cfg.ClearInstanceProtection, err = strconv.ParseBool(clearInstanceProtection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AZURE_CLEAR_INSTANCE_PROTECTION: %q, %v", clearInstanceProtection, err)
+		}
+	}
+
+	if lazyVirtualMachineDiscovery := os.Getenv("AZURE_LAZY_VIRTUAL_MACHINE_DISCOVERY"); lazyVirtualMachineDiscovery != "" {
+		cfg.LazyVirtualMachineDiscovery, err = strconv.ParseBool(lazyVirtualMachineDiscovery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AZURE_LAZY_VIRTUAL_MACHINE_DISCOVERY: %q, %v", lazyVirtualMachineDiscovery, err)
+		}
+	}
+
+	if useResourceGraph := os.Getenv("AZURE_USE_RESOURCE_GRAPH"); useResourceGraph != "" {
+		cfg.UseResourceGraph, err = strconv.ParseBool(useResourceGraph)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AZURE_USE_RESOURCE_GRAPH: %q, %v", useResourceGraph, err)
+		}
+	}
+
+	if useVmssVMsPager := os.Getenv("AZURE_USE_VMSS_VMS_PAGER"); useVmssVMsPager != "" {
+		cfg.UseVmssVMsPager, err = strconv.ParseBool(useVmssVMsPager)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AZURE_USE_VMSS_VMS_PAGER: %q, %v", useVmssVMsPager, err)
+		}
+	}
+
+	if deleteGhostInstances := os.Getenv("AZURE_DELETE_GHOST_INSTANCES"); deleteGhostInstances != "" {
+		cfg.DeleteGhostInstances, err = strconv.ParseBool(deleteGhostInstances)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AZURE_DELETE_GHOST_INSTANCES: %q, %v", deleteGhostInstances, err)
+		}
+	}
+
+	if ghostInstanceTimeout := os.Getenv("AZURE_GHOST_INSTANCE_TIMEOUT"); ghostInstanceTimeout != "" {
+		cfg.GhostInstanceTimeout, err = strconv.ParseInt(ghostInstanceTimeout, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AZURE_GHOST_INSTANCE_TIMEOUT: %q, %v", ghostInstanceTimeout, err)
+		}
+	}
+
+	if networkPlugin := os.Getenv("AZURE_NETWORK_PLUGIN"); networkPlugin != "" {
+		cfg.NetworkPlugin = networkPlugin
+	}
+
+	if networkPluginMode := os.Getenv("AZURE_NETWORK_PLUGIN_MODE"); networkPluginMode != "" {
+		cfg.NetworkPluginMode = networkPluginMode
+	}
+
 	err = initializeCloudProviderRateLimitConfig(&cfg.CloudProviderRateLimitConfig)
 	if err != nil {
 		return nil, err
@@ -493,6 +583,8 @@ func (cfg *Config) TrimSpace() {
 	cfg.AADClientCertPath = strings.TrimSpace(cfg.AADClientCertPath)
 	cfg.AADClientCertPassword = strings.TrimSpace(cfg.AADClientCertPassword)
 	cfg.Deployment = strings.TrimSpace(cfg.Deployment)
+	cfg.NetworkPlugin = strings.TrimSpace(cfg.NetworkPlugin)
+	cfg.NetworkPluginMode = strings.TrimSpace(cfg.NetworkPluginMode)
 }
 
 func (cfg *Config) validate() error {
@@ -508,6 +600,31 @@ func (cfg *Config) validate() error {
 		if len(cfg.DeploymentParameters) == 0 {
 			return fmt.Errorf("deploymentParameters not set")
 		}
+
+		if cfg.LazyVirtualMachineDiscovery {
+			return fmt.Errorf("lazyVirtualMachineDiscovery is not supported by vmType %q", vmTypeStandard)
+		}
+	}
+
+	if cfg.ClearInstanceProtection && cfg.EnableVmssFlex {
+		// The protection of the VMs of Flexible scale sets isn't reported, so it can't be cleared.
+		return fmt.Errorf("clearInstanceProtection is not supported together with enableVmssFlex")
+	}
+
+	switch cfg.NetworkPlugin {
+	case "", networkPluginKubenet, networkPluginAzure, networkPluginNone:
+	default:
+		return fmt.Errorf("unsupported network plugin: %s", cfg.NetworkPlugin)
+	}
+
+	switch cfg.NetworkPluginMode {
+	case "":
+	case networkPluginModeOverlay:
+		if cfg.NetworkPlugin != networkPluginAzure {
+			return fmt.Errorf("network plugin mode %s requires network plugin %s", cfg.NetworkPluginMode, networkPluginAzure)
+		}
+	default:
+		return fmt.Errorf("unsupported network plugin mode: %s", cfg.NetworkPluginMode)
 	}
 
 	if cfg.SubscriptionID == "" {
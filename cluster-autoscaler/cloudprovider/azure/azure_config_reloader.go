@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	klog "k8s.io/klog/v2"
+)
+
+// configReloader detects changes of the cloud config file, e.g. azure.json
+// mounted from a Secret which is updated in place by the kubelet.
+type configReloader struct {
+	path        string
+	lastContent []byte
+}
+
+func newConfigReloader(path string) (*configReloader, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cloud config %s: %v", path, err)
+	}
+	return &configReloader{path: path, lastContent: content}, nil
+}
+
+// load returns the config if the file content changed since the last call.
+// Content which fails to parse is not retried until the file changes again.
+func (r *configReloader) load() (*Config, bool, error) {
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cloud config %s: %v", r.path, err)
+	}
+	if bytes.Equal(content, r.lastContent) {
+		return nil, false, nil
+	}
+	r.lastContent = content
+	cfg, err := BuildAzureConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, true, err
+	}
+	return cfg, true, nil
+}
+
+// applyReloadableConfig copies the fields which can be changed without
+// restarting: credentials, rate limits, backoff and cache TTLs.
+func applyReloadableConfig(dst, src *Config) {
+	dst.CloudProviderRateLimitConfig = src.CloudProviderRateLimitConfig
+	dst.AuthMethod = src.AuthMethod
+	dst.AADClientID = src.AADClientID
+	dst.AADClientSecret = src.AADClientSecret
+	dst.AADClientCertPath = src.AADClientCertPath
+	dst.AADClientCertPassword = src.AADClientCertPassword
+	dst.AADFederatedTokenFile = src.AADFederatedTokenFile
+	dst.UseManagedIdentityExtension = src.UseManagedIdentityExtension
+	dst.UseWorkloadIdentityExtension = src.UseWorkloadIdentityExtension
+	dst.UserAssignedIdentityID = src.UserAssignedIdentityID
+	dst.VmssCacheTTL = src.VmssCacheTTL
+	dst.VmssVmsCacheTTL = src.VmssVmsCacheTTL
+	dst.CloudProviderBackoff = src.CloudProviderBackoff
+	dst.CloudProviderBackoffRetries = src.CloudProviderBackoffRetries
+	dst.CloudProviderBackoffExponent = src.CloudProviderBackoffExponent
+	dst.CloudProviderBackoffDuration = src.CloudProviderBackoffDuration
+	dst.CloudProviderBackoffJitter = src.CloudProviderBackoffJitter
+}
+
+// checkReloadableConfig returns an error if newCfg changes fields which
+// require a restart, e.g. the subscription or the resource group.
+func checkReloadableConfig(oldCfg, newCfg *Config) error {
+	expected := *oldCfg
+	applyReloadableConfig(&expected, newCfg)
+	if !reflect.DeepEqual(&expected, newCfg) {
+		return fmt.Errorf("only credentials, rate limits, backoff and cache TTLs can be changed without a restart")
+	}
+	return nil
+}
+
+func vmssCacheTTL(cfg *Config) time.Duration {
+	if cfg.VmssCacheTTL != 0 {
+		return time.Duration(cfg.VmssCacheTTL) * time.Second
+	}
+	return refreshInterval
+}
+
+func vmssVmsCacheTTL(cfg *Config) time.Duration {
+	if cfg.VmssVmsCacheTTL != 0 {
+		return time.Duration(cfg.VmssVmsCacheTTL) * time.Second
+	}
+	return defaultVmssInstancesRefreshPeriod
+}
+
+// reloadConfig rebuilds the Azure clients if the cloud config changed. The
+// previous config and clients are kept if the new config can't be applied.
+func (m *AzureManager) reloadConfig() error {
+	cfg, changed, err := m.configReloader.load()
+	if err != nil || !changed {
+		return err
+	}
+	oldCfg := m.getConfig()
+	if err := checkReloadableConfig(oldCfg, cfg); err != nil {
+		return err
+	}
+	azClient, err := newAzClient(cfg, &m.env)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild Azure clients: %v", err)
+	}
+
+	// The config is read concurrently, so it's replaced by a copy instead of being updated in place.
+	newCfg := *oldCfg
+	applyReloadableConfig(&newCfg, cfg)
+	m.configMutex.Lock()
+	m.config = &newCfg
+	m.configMutex.Unlock()
+	m.azClientMutex.Lock()
+	m.azClient = azClient
+	m.azClientMutex.Unlock()
+	m.azureCache.setAzClient(azClient, vmssCacheTTL(cfg))
+	for _, nodeGroup := range m.getNodeGroups() {
+		if scaleSet, ok := nodeGroup.(*ScaleSet); ok {
+			scaleSet.setRefreshPeriods(vmssCacheTTL(cfg), vmssVmsCacheTTL(cfg))
+		}
+	}
+	klog.Infof("Reloaded Azure cloud config from %s", m.configReloader.path)
+	return nil
+}
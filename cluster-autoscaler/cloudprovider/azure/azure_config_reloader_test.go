@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reloadTestConfig(secret string) []byte {
+	return []byte(`{"resourceGroup": "rg", "subscriptionId": "fakeId", "tenantId": "fakeId", "vmType": "vmss", "aadClientId": "fakeId", "aadClientSecret": "` + secret + `"}`)
+}
+
+func TestConfigReloaderLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "azure.json")
+	assert.NoError(t, os.WriteFile(path, reloadTestConfig("old"), 0600))
+
+	reloader, err := newConfigReloader(path)
+	assert.NoError(t, err)
+
+	cfg, changed, err := reloader.load()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Nil(t, cfg)
+
+	assert.NoError(t, os.WriteFile(path, reloadTestConfig("new"), 0600))
+	cfg, changed, err = reloader.load()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "new", cfg.AADClientSecret)
+
+	// Unparsable content is reported once.
+	assert.NoError(t, os.WriteFile(path, []byte(`{`), 0600))
+	_, changed, err = reloader.load()
+	assert.Error(t, err)
+	assert.True(t, changed)
+	_, changed, err = reloader.load()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestCheckReloadableConfig(t *testing.T) {
+	oldCfg := &Config{
+		ResourceGroup:   "rg",
+		VMType:          vmTypeVMSS,
+		AADClientSecret: "old",
+		VmssCacheTTL:    60,
+	}
+
+	newCfg := *oldCfg
+	newCfg.AADClientSecret = "new"
+	newCfg.VmssCacheTTL = 120
+	newCfg.CloudProviderRateLimitQPS = 10
+	assert.NoError(t, checkReloadableConfig(oldCfg, &newCfg))
+
+	applyReloadableConfig(oldCfg, &newCfg)
+	assert.Equal(t, "new", oldCfg.AADClientSecret)
+	assert.Equal(t, 120*time.Second, vmssCacheTTL(oldCfg))
+
+	newCfg.ResourceGroup = "other-rg"
+	assert.Error(t, checkReloadableConfig(oldCfg, &newCfg))
+}
+
+func TestReloadConfigReplacesConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "azure.json")
+	assert.NoError(t, os.WriteFile(path, reloadTestConfig("old"), 0600))
+	manager := newTestAzureManager(t)
+	oldCfg, err := BuildAzureConfig(bytes.NewReader(reloadTestConfig("old")))
+	assert.NoError(t, err)
+	manager.config = oldCfg
+	manager.configReloader, err = newConfigReloader(path)
+	assert.NoError(t, err)
+
+	// The config is read while it's reloaded.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = manager.getConfig().AADClientSecret
+			}
+		}
+	}()
+	assert.NoError(t, os.WriteFile(path, reloadTestConfig("new"), 0600))
+	assert.NoError(t, manager.reloadConfig())
+	close(stop)
+	wg.Wait()
+
+	assert.Equal(t, "new", manager.getConfig().AADClientSecret)
+	assert.Equal(t, "old", oldCfg.AADClientSecret)
+}
@@ -20,12 +20,142 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/skewer"
 	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+const (
+	// cpuArchitectureCapability is the SKU API capability holding the CPU architecture of a SKU, e.g. "x64" or "Arm64".
+	cpuArchitectureCapability = "CpuArchitectureType"
+	// trustedLaunchDisabledCapability is the SKU API capability set to "True" for SKUs not supporting trusted launch.
+	trustedLaunchDisabledCapability = "TrustedLaunchDisabled"
+	// confidentialComputingTypeCapability is the SKU API capability holding the confidential computing
+	// technology of SKUs supporting confidential VMs, e.g. "SNP" or "TDX".
+	confidentialComputingTypeCapability = "ConfidentialComputingType"
+	// minSkuNameVersionWithVCPUs is the oldest SKU version whose name holds the number of vCPUs.
+	minSkuNameVersionWithVCPUs = 5
+)
+
+var (
+	// skuNameRe matches the names of SKUs of single letter families, e.g. Standard_D4pds_v5,
+	// capturing the family, the number of vCPUs, the additive features and the version.
+	skuNameRe = regexp.MustCompile(`(?i)^standard_([a-z])([0-9]+)([a-z]*)_v([0-9]+)$`)
+	// armSkuNameRe matches the names of SKUs running on ARM64 processors, marked with
+	// the "p" additive feature, e.g. Standard_D4ps_v5 or Standard_E8pds_v5.
+	armSkuNameRe = regexp.MustCompile(`(?i)^standard_[a-z]+[0-9]+[a-z]*p[a-z]*_v[0-9]+$`)
 )
 
+// getArchitectureFromSkuName returns the CPU architecture of the SKU following
+// the Azure VM naming conventions.
+func getArchitectureFromSkuName(skuName string) string {
+	if armSkuNameRe.MatchString(skuName) {
+		return "arm64"
+	}
+	return cloudprovider.DefaultArch
+}
+
+// getArchitectureFromSku returns the CPU architecture of the SKU returned by the SKU API.
+func getArchitectureFromSku(sku skewer.SKU) string {
+	if sku.HasCapabilityWithSeparator(cpuArchitectureCapability, "Arm64") {
+		return "arm64"
+	}
+	if sku.HasCapabilityWithSeparator(cpuArchitectureCapability, "x64") {
+		return cloudprovider.DefaultArch
+	}
+	return getArchitectureFromSkuName(sku.GetName())
+}
+
+// skuSupportsSecurityType returns whether the SKU returned by the SKU API supports the security type.
+func skuSupportsSecurityType(sku skewer.SKU, securityType compute.SecurityTypes) bool {
+	switch securityType {
+	case compute.SecurityTypesTrustedLaunch:
+		return !sku.HasCapability(trustedLaunchDisabledCapability)
+	case compute.SecurityTypesConfidentialVM:
+		if sku.Capabilities == nil {
+			return false
+		}
+		for _, capability := range *sku.Capabilities {
+			if capability.Name != nil && strings.EqualFold(*capability.Name, confidentialComputingTypeCapability) {
+				return capability.Value != nil && *capability.Value != ""
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// checkSecurityTypeSupported returns an error if the SKU of the scale set doesn't support the
+// security type of its VMs. The SKU API is only called if the dynamic instance list is enabled,
+// otherwise as well as for SKUs which can't be fetched from it, the SKU is assumed to support it.
+func checkSecurityTypeSupported(template compute.VirtualMachineScaleSet, manager *AzureManager) error {
+	securityType := getSecurityType(template)
+	if securityType == "" || template.Sku == nil || template.Sku.Name == nil || template.Location == nil {
+		return nil
+	}
+	if !manager.getConfig().EnableDynamicInstanceList {
+		return nil
+	}
+	sku, err := manager.azureCache.GetSKU(context.Background(), *template.Sku.Name, *template.Location)
+	if err != nil {
+		klog.V(4).Infof("Can't check whether SKU %q supports security type %s: %v", *template.Sku.Name, securityType, err)
+		return nil
+	}
+	if !skuSupportsSecurityType(sku, securityType) {
+		return fmt.Errorf("SKU %q doesn't support security type %s", *template.Sku.Name, securityType)
+	}
+	return nil
+}
+
+// getVMSSTypeFromSkuName takes the vCPUs and memory of recent SKUs missing from the
+// static list from the same size of the latest previous version in the static list,
+// e.g. Standard_E96_v5 for Standard_E96_v6. Returns false if there is no such size.
+func getVMSSTypeFromSkuName(skuName string) (InstanceType, bool) {
+	match := skuNameRe.FindStringSubmatch(skuName)
+	if match == nil {
+		return InstanceType{}, false
+	}
+	vcpu, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil || vcpu <= 0 {
+		return InstanceType{}, false
+	}
+	version, err := strconv.Atoi(match[4])
+	if err != nil {
+		return InstanceType{}, false
+	}
+	for v := version - 1; v >= minSkuNameVersionWithVCPUs; v-- {
+		previous := lookUpStaticInstanceType(fmt.Sprintf("Standard_%s%s%s_v%d", match[1], match[2], match[3], v))
+		// Sizes of the same name have the same number of vCPUs in versions whose names hold it.
+		if previous == nil || previous.VCPU != vcpu {
+			continue
+		}
+		return InstanceType{
+			InstanceType: skuName,
+			VCPU:         previous.VCPU,
+			MemoryMb:     previous.MemoryMb,
+			GPU:          previous.GPU,
+			Architecture: getArchitectureFromSkuName(skuName),
+		}, true
+	}
+	return InstanceType{}, false
+}
+
+// lookUpStaticInstanceType returns the instance type of the given name from the static list, or nil.
+func lookUpStaticInstanceType(skuName string) *InstanceType {
+	for k := range InstanceTypes {
+		if strings.EqualFold(k, skuName) {
+			return InstanceTypes[k]
+		}
+	}
+	return nil
+}
+
 // GetVMSSTypeStatically uses static list of vmss generated at azure_instance_types.go to fetch vmss instance information.
 // It is declared as a variable for testing purpose.
 var GetVMSSTypeStatically = func(template compute.VirtualMachineScaleSet) (*InstanceType, error) {
@@ -96,6 +226,7 @@ var GetVMSSTypeDynamically = func(template compute.VirtualMachineScaleSet, azCac
 		return vmssType, err
 	}
 	vmssType.MemoryMb = int64(memoryGb) * 1024
+	vmssType.Architecture = getArchitectureFromSku(sku)
 
 	return vmssType, nil
 }
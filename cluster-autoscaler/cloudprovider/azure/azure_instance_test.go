@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"fmt"
+	"testing"
+
+	skucompute "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/Azure/skewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetArchitectureFromSkuName(t *testing.T) {
+	for skuName, expected := range map[string]string{
+		"Standard_D4ps_v5":   "arm64",
+		"Standard_E8pds_v5":  "arm64",
+		"Standard_D2plds_v6": "arm64",
+		"Standard_D4s_v5":    "amd64",
+		"Standard_NP10s":     "amd64",
+		"Standard_D4_v2":     "amd64",
+	} {
+		assert.Equal(t, expected, getArchitectureFromSkuName(skuName), skuName)
+	}
+}
+
+func TestGetArchitectureFromSku(t *testing.T) {
+	newSku := func(name, arch string) skewer.SKU {
+		return skewer.SKU{
+			Name: to.StringPtr(name),
+			Capabilities: &[]skucompute.ResourceSkuCapabilities{
+				{Name: to.StringPtr(cpuArchitectureCapability), Value: to.StringPtr(arch)},
+			},
+		}
+	}
+	assert.Equal(t, "arm64", getArchitectureFromSku(newSku("Standard_Custom", "Arm64")))
+	assert.Equal(t, "amd64", getArchitectureFromSku(newSku("Standard_D4ps_v5", "x64")))
+	// Without the capability the architecture is derived from the name.
+	assert.Equal(t, "arm64", getArchitectureFromSku(skewer.SKU{Name: to.StringPtr("Standard_D4ps_v5")}))
+}
+
+func TestGetVMSSTypeFromSkuName(t *testing.T) {
+	testCases := []struct {
+		skuName  string
+		expected *InstanceType
+	}{
+		{skuName: "Standard_D4s_v6", expected: &InstanceType{VCPU: 4, MemoryMb: 16384, Architecture: "amd64"}},
+		{skuName: "Standard_D16pls_v6", expected: &InstanceType{VCPU: 16, MemoryMb: 32768, Architecture: "arm64"}},
+		{skuName: "Standard_E8ads_v6", expected: &InstanceType{VCPU: 8, MemoryMb: 65536, Architecture: "amd64"}},
+		{skuName: "Standard_E96_v6", expected: &InstanceType{VCPU: 96, MemoryMb: 688128, Architecture: "amd64"}},
+		{skuName: "Standard_E96_v7", expected: &InstanceType{VCPU: 96, MemoryMb: 688128, Architecture: "amd64"}},
+		// Sizes without a previous version in the static list.
+		{skuName: "Standard_D4_v3"},
+		{skuName: "Standard_D5s_v6"},
+		{skuName: "Standard_E16-4s_v6"},
+		{skuName: "Standard_NC24ads_A100_v5"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.skuName, func(t *testing.T) {
+			vmssType, found := getVMSSTypeFromSkuName(tc.skuName)
+			if tc.expected == nil {
+				assert.False(t, found)
+				return
+			}
+			assert.True(t, found)
+			tc.expected.InstanceType = tc.skuName
+			assert.Equal(t, *tc.expected, vmssType)
+		})
+	}
+}
+
+func TestGetVMSSTypeFallbacks(t *testing.T) {
+	defer func(static func(compute.VirtualMachineScaleSet) (*InstanceType, error),
+		dynamic func(compute.VirtualMachineScaleSet, *azureCache) (InstanceType, error)) {
+		GetVMSSTypeStatically, GetVMSSTypeDynamically = static, dynamic
+	}(GetVMSSTypeStatically, GetVMSSTypeDynamically)
+
+	manager := &AzureManager{config: &Config{EnableDynamicInstanceList: true}}
+	template := compute.VirtualMachineScaleSet{Sku: &compute.Sku{Name: to.StringPtr("Standard_D4ps_v6")}}
+	GetVMSSTypeStatically = func(template compute.VirtualMachineScaleSet) (*InstanceType, error) {
+		return nil, fmt.Errorf("static error exists")
+	}
+
+	// SKUs are fetched from the SKU API if the dynamic instance list is enabled.
+	GetVMSSTypeDynamically = func(template compute.VirtualMachineScaleSet, azCache *azureCache) (InstanceType, error) {
+		return InstanceType{VCPU: 4, MemoryMb: 16384, Architecture: "arm64"}, nil
+	}
+	vmssType, err := getVMSSType(template, manager)
+	assert.NoError(t, err)
+	assert.Equal(t, InstanceType{VCPU: 4, MemoryMb: 16384, Architecture: "arm64"}, vmssType)
+
+	// Otherwise the SKU API isn't called and SKUs missing from the static list
+	// get the resources of their previous version.
+	manager.config.EnableDynamicInstanceList = false
+	GetVMSSTypeDynamically = func(template compute.VirtualMachineScaleSet, azCache *azureCache) (InstanceType, error) {
+		t.Fatalf("unexpected SKU API call for %s", *template.Sku.Name)
+		return InstanceType{}, nil
+	}
+	vmssType, err = getVMSSType(template, manager)
+	assert.NoError(t, err)
+	assert.Equal(t, InstanceType{InstanceType: "Standard_D4ps_v6", VCPU: 4, MemoryMb: 16384, Architecture: "arm64"}, vmssType)
+
+	template.Sku.Name = to.StringPtr("Standard_D4_v2")
+	_, err = getVMSSType(template, manager)
+	assert.Equal(t, fmt.Errorf("static error exists"), err)
+
+	// The architecture of SKUs from the static list is derived from their names.
+	GetVMSSTypeStatically = func(template compute.VirtualMachineScaleSet) (*InstanceType, error) {
+		return &InstanceType{InstanceType: "Standard_D4ps_v5", VCPU: 4, MemoryMb: 16384}, nil
+	}
+	template.Sku.Name = to.StringPtr("Standard_D4ps_v5")
+	vmssType, err = getVMSSType(template, manager)
+	assert.NoError(t, err)
+	assert.Equal(t, "arm64", vmssType.Architecture)
+}
+
+func TestCheckSecurityTypeSupported(t *testing.T) {
+	newSku := func(name string, capabilities map[string]string) skewer.SKU {
+		skuCapabilities := []skucompute.ResourceSkuCapabilities{}
+		for k, v := range capabilities {
+			skuCapabilities = append(skuCapabilities, skucompute.ResourceSkuCapabilities{Name: to.StringPtr(k), Value: to.StringPtr(v)})
+		}
+		return skewer.SKU{
+			Name:         to.StringPtr(name),
+			ResourceType: to.StringPtr(skewer.VirtualMachines),
+			Locations:    &[]string{"eastus"},
+			Capabilities: &skuCapabilities,
+		}
+	}
+	skuCache, err := skewer.NewStaticCache([]skewer.SKU{
+		newSku("Standard_D4s_v5", nil),
+		newSku("Standard_D4_v2", map[string]string{trustedLaunchDisabledCapability: "True"}),
+		newSku("Standard_DC4as_v5", map[string]string{confidentialComputingTypeCapability: "SNP"}),
+	})
+	assert.NoError(t, err)
+	manager := &AzureManager{
+		config:     &Config{EnableDynamicInstanceList: true},
+		azureCache: &azureCache{skus: map[string]*skewer.Cache{"eastus": skuCache}},
+	}
+
+	newTemplate := func(skuName string, securityType compute.SecurityTypes) compute.VirtualMachineScaleSet {
+		return compute.VirtualMachineScaleSet{
+			Sku:      &compute.Sku{Name: to.StringPtr(skuName)},
+			Location: to.StringPtr("eastus"),
+			VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+				VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{
+					SecurityProfile: &compute.SecurityProfile{SecurityType: securityType},
+				},
+			},
+		}
+	}
+	for name, tc := range map[string]struct {
+		template  compute.VirtualMachineScaleSet
+		supported bool
+	}{
+		"no security type":               {template: newTemplate("Standard_D4_v2", ""), supported: true},
+		"trusted launch":                 {template: newTemplate("Standard_D4s_v5", compute.SecurityTypesTrustedLaunch), supported: true},
+		"trusted launch disabled":        {template: newTemplate("Standard_D4_v2", compute.SecurityTypesTrustedLaunch), supported: false},
+		"confidential VM":                {template: newTemplate("Standard_DC4as_v5", compute.SecurityTypesConfidentialVM), supported: true},
+		"confidential VM not supported":  {template: newTemplate("Standard_D4s_v5", compute.SecurityTypesConfidentialVM), supported: false},
+		"unknown SKU assumed to support": {template: newTemplate("Standard_X1", compute.SecurityTypesConfidentialVM), supported: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := checkSecurityTypeSupported(tc.template, manager)
+			if tc.supported {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	// The SKU API isn't called without the dynamic instance list.
+	manager.config = &Config{}
+	manager.azureCache = &azureCache{}
+	assert.NoError(t, checkSecurityTypeSupported(newTemplate("Standard_D4_v2", compute.SecurityTypesTrustedLaunch), manager))
+}
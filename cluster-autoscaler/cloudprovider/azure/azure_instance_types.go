@@ -25,6 +25,7 @@ type InstanceType struct {
 	VCPU         int64
 	MemoryMb     int64
 	GPU          int64
+	Architecture string
 }
 
 // InstanceTypes is a map of azure resources
@@ -59,6 +59,7 @@ type InstanceType struct {
 	VCPU         int64
 	MemoryMb     int64
 	GPU          int64
+	Architecture string
 }
 
 // InstanceTypes is a map of azure resources
@@ -70,6 +71,7 @@ var InstanceTypes = map[string]*InstanceType{
 		VCPU:         {{ .VCPU }},
 		MemoryMb:     {{ .MemoryMb }},
 		GPU:          {{ .GPU }},
+		Architecture: "{{ .Architecture }}",
 	},
 {{- end }}
 }
@@ -135,6 +137,11 @@ func getAllAzureVirtualMachineTypes() (result map[string]*azure.InstanceType, er
 					if err != nil {
 						return nil, err
 					}
+				case "CpuArchitectureType":
+					virtualMachine.Architecture = "amd64"
+					if strings.EqualFold(capability.Value, "Arm64") {
+						virtualMachine.Architecture = "arm64"
+					}
 				}
 			}
 			virtualMachines[virtualMachine.InstanceType] = &virtualMachine
@@ -19,12 +19,17 @@ limitations under the License.
 package azure
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
@@ -46,9 +51,13 @@ const (
 
 // AzureManager handles Azure communication and data caching.
 type AzureManager struct {
-	config   *Config
-	azClient *azClient
-	env      azure.Environment
+	config        *Config
+	configMutex   sync.RWMutex
+	azClient      *azClient
+	azClientMutex sync.RWMutex
+	env           azure.Environment
+	// configReloader is set if the cloud config should be reloaded on change.
+	configReloader *configReloader
 
 	azureCache           *azureCache
 	lastRefresh          time.Time
@@ -89,11 +98,7 @@ func createAzureManagerInternal(configReader io.Reader, discoveryOpts cloudprovi
 		explicitlyConfigured: make(map[string]bool),
 	}
 
-	cacheTTL := refreshInterval
-	if cfg.VmssCacheTTL != 0 {
-		cacheTTL = time.Duration(cfg.VmssCacheTTL) * time.Second
-	}
-	cache, err := newAzureCache(azClient, cacheTTL, cfg.ResourceGroup, cfg.VMType, cfg.EnableDynamicInstanceList, cfg.Location)
+	cache, err := newAzureCache(azClient, vmssCacheTTL(cfg), cfg.ResourceGroup, cfg.VMType, cfg.EnableDynamicInstanceList, cfg.LazyVirtualMachineDiscovery, cfg.Location)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +158,7 @@ func (m *AzureManager) fetchExplicitNodeGroups(specs []string) error {
 
 func (m *AzureManager) buildNodeGroupFromSpec(spec string) (cloudprovider.NodeGroup, error) {
 	scaleToZeroSupported := scaleToZeroSupportedStandard
-	if strings.EqualFold(m.config.VMType, vmTypeVMSS) {
+	if strings.EqualFold(m.getConfig().VMType, vmTypeVMSS) {
 		scaleToZeroSupported = scaleToZeroSupportedVMSS
 	}
 	s, err := dynamic.SpecFromString(spec, scaleToZeroSupported)
@@ -165,31 +170,93 @@ func (m *AzureManager) buildNodeGroupFromSpec(spec string) (cloudprovider.NodeGr
 	if _, ok := vmsPoolSet[s.Name]; ok {
 		return NewVMsPool(s, m), nil
 	}
+	// Without the list of all VMs, ask the agent pool API whether node groups which aren't scale sets are VMs pools.
+	if m.getConfig().LazyVirtualMachineDiscovery {
+		if _, ok := m.azureCache.getScaleSets()[s.Name]; !ok {
+			isVMsPool, err := m.isVMsPoolAgentPool(s.Name)
+			if err != nil {
+				return nil, err
+			}
+			if isVMsPool {
+				return NewVMsPool(s, m), nil
+			}
+		}
+	}
 
-	switch m.config.VMType {
+	switch m.getConfig().VMType {
 	case vmTypeStandard:
 		return NewAgentPool(s, m)
 	case vmTypeVMSS:
+		if vmss, ok := m.azureCache.getScaleSets()[s.Name]; ok {
+			if err := checkSecurityTypeSupported(vmss, m); err != nil {
+				klog.Warningf("Scale-ups of explicitly configured vmss %q will fail: %v", s.Name, err)
+			}
+		}
 		return NewScaleSet(s, m, -1)
 	default:
-		return nil, fmt.Errorf("vmtype %s not supported", m.config.VMType)
+		return nil, fmt.Errorf("vmtype %s not supported", m.getConfig().VMType)
 	}
 }
 
+// isVMsPoolAgentPool returns whether the AKS agent pool with the given name is a VMs pool.
+func (m *AzureManager) isVMsPoolAgentPool(name string) (bool, error) {
+	client := m.getAzClient()
+	if client.agentPoolClient == nil {
+		return false, fmt.Errorf("failed to get agent pool %q: agent pool client is not configured", name)
+	}
+	config := m.getConfig()
+	ctx, cancel := getContextWithCancel()
+	defer cancel()
+	resp, err := client.agentPoolClient.Get(ctx, config.ClusterResourceGroup, config.ClusterName, name, nil)
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get agent pool %q: %v", name, err)
+	}
+	return resp.Properties != nil && resp.Properties.Type != nil &&
+		*resp.Properties.Type == armcontainerservice.AgentPoolTypeVirtualMachines, nil
+}
+
 // Refresh is called before every main loop and can be used to dynamically update cloud provider state.
 // In particular the list of node groups returned by NodeGroups can change as a result of CloudProvider.Refresh().
 func (m *AzureManager) Refresh() error {
+	if m.configReloader != nil {
+		if err := m.reloadConfig(); err != nil {
+			klog.Errorf("Failed to reload Azure cloud config, keeping the previous one: %v", err)
+		}
+	}
 	if m.lastRefresh.Add(m.azureCache.refreshInterval).After(time.Now()) {
+		observeCacheLookup(vmssCacheName, true)
 		return nil
 	}
+	observeCacheLookup(vmssCacheName, false)
 	return m.forceRefresh()
 }
 
+// getConfig returns the cloud config, which is replaced when it's reloaded. The returned
+// config must not be modified.
+func (m *AzureManager) getConfig() *Config {
+	m.configMutex.RLock()
+	defer m.configMutex.RUnlock()
+	return m.config
+}
+
+// getAzClient returns the clients, which are rebuilt when the cloud config is reloaded.
+func (m *AzureManager) getAzClient() *azClient {
+	m.azClientMutex.RLock()
+	defer m.azClientMutex.RUnlock()
+	return m.azClient
+}
+
 func (m *AzureManager) forceRefresh() error {
 	if err := m.fetchAutoNodeGroups(); err != nil {
 		klog.Errorf("Failed to fetch autodiscovered nodegroups: %v", err)
 	}
-	if err := m.azureCache.regenerate(); err != nil {
+	err := m.azureCache.regenerate()
+	observeCacheRefresh(vmssCacheName, err)
+	if err != nil {
 		klog.Errorf("Failed to regenerate Azure cache: %v", err)
 		return err
 	}
@@ -260,7 +327,7 @@ func (m *AzureManager) UnregisterNodeGroup(nodeGroup cloudprovider.NodeGroup) bo
 
 // GetNodeGroupForInstance returns the NodeGroup of the given Instance
 func (m *AzureManager) GetNodeGroupForInstance(instance *azureRef) (cloudprovider.NodeGroup, error) {
-	return m.azureCache.FindForInstance(instance, m.config.VMType)
+	return m.azureCache.FindForInstance(instance, m.getConfig().VMType)
 }
 
 // GetScaleSetOptions parse options extracted from VMSS tags and merges them with provided defaults
@@ -296,11 +363,11 @@ func (m *AzureManager) getFilteredNodeGroups(filter []labelAutoDiscoveryConfig)
 		return nil, nil
 	}
 
-	if m.config.VMType == vmTypeVMSS {
+	if m.getConfig().VMType == vmTypeVMSS {
 		return m.getFilteredScaleSets(filter)
 	}
 
-	return nil, fmt.Errorf("vmType %q does not support autodiscovery", m.config.VMType)
+	return nil, fmt.Errorf("vmType %q does not support autodiscovery", m.getConfig().VMType)
 }
 
 // getFilteredScaleSets gets a list of scale sets and instanceIDs.
@@ -356,6 +423,11 @@ func (m *AzureManager) getFilteredScaleSets(filter []labelAutoDiscoveryConfig) (
 			continue
 		}
 
+		if err := checkSecurityTypeSupported(scaleSet, m); err != nil {
+			klog.Warningf("ignoring vmss %q because its VMs can't be created: %v", *scaleSet.Name, err)
+			continue
+		}
+
 		curSize := int64(-1)
 		if scaleSet.Sku != nil && scaleSet.Sku.Capacity != nil {
 			curSize = *scaleSet.Sku.Capacity
@@ -17,7 +17,9 @@ limitations under the License.
 package azure
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
 	"testing"
@@ -25,6 +27,8 @@ import (
 
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient/mockvmclient"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2017-05-10/resources"
 	"github.com/Azure/go-autorest/autorest/date"
@@ -827,3 +831,42 @@ func TestGetScaleSetOptions(t *testing.T) {
 	opts = manager.GetScaleSetOptions("test3", defaultOptions)
 	assert.Equal(t, *opts, defaultOptions)
 }
+
+// fakeAgentPoolsClient returns the agent pools of its store by name, not found otherwise.
+type fakeAgentPoolsClient struct {
+	AgentPoolsClient
+	agentPools map[string]armcontainerservice.AgentPool
+}
+
+func (c *fakeAgentPoolsClient) Get(_ context.Context, _, _, agentPoolName string, _ *armcontainerservice.AgentPoolsClientGetOptions) (armcontainerservice.AgentPoolsClientGetResponse, error) {
+	agentPool, found := c.agentPools[agentPoolName]
+	if !found {
+		return armcontainerservice.AgentPoolsClientGetResponse{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return armcontainerservice.AgentPoolsClientGetResponse{AgentPool: agentPool}, nil
+}
+
+func TestBuildNodeGroupFromSpecLazyVMDiscovery(t *testing.T) {
+	manager := newTestAzureManager(t)
+	manager.config.LazyVirtualMachineDiscovery = true
+	vmsPoolType := armcontainerservice.AgentPoolTypeVirtualMachines
+	manager.azClient.agentPoolClient = &fakeAgentPoolsClient{agentPools: map[string]armcontainerservice.AgentPool{
+		"vms-pool": {Properties: &armcontainerservice.ManagedClusterAgentPoolProfileProperties{Type: &vmsPoolType}},
+	}}
+	ac, err := newAzureCache(manager.azClient, refreshInterval, "rg", vmTypeVMSS, false, true, "")
+	assert.NoError(t, err)
+	manager.azureCache = ac
+
+	// Only agent pools of the VirtualMachines type are VMs pools.
+	nodeGroup, err := manager.buildNodeGroupFromSpec("1:5:vms-pool")
+	assert.NoError(t, err)
+	assert.IsType(t, &VMsPool{}, nodeGroup)
+
+	nodeGroup, err = manager.buildNodeGroupFromSpec("1:5:test-vmss")
+	assert.NoError(t, err)
+	assert.IsType(t, &ScaleSet{}, nodeGroup)
+
+	nodeGroup, err = manager.buildNodeGroupFromSpec("1:5:unknown-pool")
+	assert.NoError(t, err)
+	assert.IsType(t, &ScaleSet{}, nodeGroup)
+}
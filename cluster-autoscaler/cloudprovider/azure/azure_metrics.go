@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	caNamespace = "cluster_autoscaler"
+
+	// vmssCacheName is the cache of VMSS and VM lists, refreshed every VmssCacheTTL.
+	vmssCacheName = "vmss"
+	// vmssSizeCacheName is the per-VMSS cache of the VMSS capacity.
+	vmssSizeCacheName = "vmss_size"
+	// vmssVmsCacheName is the per-VMSS cache of VMSS instances, refreshed every VmssVmsCacheTTL.
+	vmssVmsCacheName = "vmss_vms"
+)
+
+var (
+	/**** Metrics related to Azure caches ****/
+	cacheLookups = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "azure_cache_lookups_total",
+			Help:      "Number of Azure cache lookups, by cache and result (hit or miss)",
+		}, []string{"cache", "result"},
+	)
+	cacheRefreshes = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "azure_cache_refreshes_total",
+			Help:      "Number of Azure cache refreshes, by cache and status",
+		}, []string{"cache", "status"},
+	)
+
+	/**** Metrics related to Azure Resource Manager requests ****/
+	armRequestDuration = k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: caNamespace,
+			Name:      "azure_arm_request_duration_seconds",
+			Help:      "Latency of Azure Resource Manager requests, by operation and response code",
+			Buckets:   k8smetrics.ExponentialBuckets(0.05, 2, 10),
+		}, []string{"operation", "code"},
+	)
+	armThrottledRequests = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "azure_arm_throttled_requests_total",
+			Help:      "Number of Azure Resource Manager requests throttled with 429 Too Many Requests, by operation",
+		}, []string{"operation"},
+	)
+	armRemainingRequests = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "azure_arm_remaining_requests",
+			Help:      "Remaining Azure Resource Manager requests budget reported by the last response, by scope (subscription, tenant or resource) and budget",
+		}, []string{"scope", "budget"},
+	)
+)
+
+// RegisterMetrics registers all Azure metrics.
+func RegisterMetrics() {
+	legacyregistry.MustRegister(cacheLookups)
+	legacyregistry.MustRegister(cacheRefreshes)
+	legacyregistry.MustRegister(armRequestDuration)
+	legacyregistry.MustRegister(armThrottledRequests)
+	legacyregistry.MustRegister(armRemainingRequests)
+}
+
+// observeCacheLookup records whether a cache lookup could be served from the cache.
+func observeCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// observeCacheRefresh records a cache refresh and whether it succeeded.
+func observeCacheRefresh(cache string, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	cacheRefreshes.WithLabelValues(cache, status).Inc()
+}
+
+// observeARMRequest records the latency of an Azure Resource Manager request and whether it was throttled.
+// A request which failed without a response is recorded with the "error" code.
+func observeARMRequest(operation string, statusCode int, duration time.Duration) {
+	code := "error"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	armRequestDuration.WithLabelValues(operation, code).Observe(duration.Seconds())
+	if statusCode == http.StatusTooManyRequests {
+		armThrottledRequests.WithLabelValues(operation).Inc()
+	}
+}
+
+// observeARMRemainingRequests records the remaining requests budget of the given scope reported by a response.
+func observeARMRemainingRequests(scope, budget string, remaining int) {
+	armRemainingRequests.WithLabelValues(scope, budget).Set(float64(remaining))
+}
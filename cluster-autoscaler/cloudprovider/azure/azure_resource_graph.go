@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
+	"github.com/Azure/go-autorest/autorest/to"
+	"k8s.io/client-go/util/flowcontrol"
+	klog "k8s.io/klog/v2"
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// scaleSetsQuery lists the scale sets of resource groups, projected to the fields used by the autoscaler.
+// Resource Graph returns the resources in the ARM format, so the rows unmarshal to compute.VirtualMachineScaleSet.
+const scaleSetsQuery = `resources
+| where type =~ 'microsoft.compute/virtualmachinescalesets' and resourceGroup in~ (%s)
+| project id, name, type, location, tags, sku, zones, extendedLocation, identity, properties`
+
+// ScaleSetsLister lists the scale sets of resource groups.
+type ScaleSetsLister interface {
+	// ListScaleSets returns all scale sets of the resource groups with a single query. Resource Graph
+	// data can lag behind ARM, so the scale sets are only meant for discovery: their size isn't current.
+	ListScaleSets(ctx context.Context, resourceGroupNames []string) ([]compute.VirtualMachineScaleSet, *retry.Error)
+}
+
+// azResourceGraphScaleSetsLister lists the scale sets with Azure Resource Graph queries instead of the
+// Compute API, so that cache refreshes don't take from the Compute API read quota of the subscription.
+type azResourceGraphScaleSetsLister struct {
+	client         resourcegraph.BaseClient
+	subscriptionID string
+	// rateLimiterReader is shared with the reads of the scale sets client.
+	rateLimiterReader flowcontrol.RateLimiter
+}
+
+func newAzResourceGraphScaleSetsLister(config *azclients.ClientConfig, rateLimiterReader flowcontrol.RateLimiter) *azResourceGraphScaleSetsLister {
+	client := resourcegraph.NewWithBaseURI(config.ResourceManagerEndpoint)
+	client.Authorizer = config.Authorizer
+	configureUserAgent(&client.Client)
+
+	return &azResourceGraphScaleSetsLister{
+		client:            client,
+		subscriptionID:    config.SubscriptionID,
+		rateLimiterReader: rateLimiterReader,
+	}
+}
+
+func (az *azResourceGraphScaleSetsLister) ListScaleSets(ctx context.Context, resourceGroupNames []string) ([]compute.VirtualMachineScaleSet, *retry.Error) {
+	request := resourcegraph.QueryRequest{
+		Subscriptions: &[]string{az.subscriptionID},
+		Query:         to.StringPtr(fmt.Sprintf(scaleSetsQuery, resourceGraphStringList(resourceGroupNames))),
+		Options:       &resourcegraph.QueryRequestOptions{ResultFormat: resourcegraph.ResultFormatObjectArray},
+	}
+	var result []compute.VirtualMachineScaleSet
+	pages := 0
+	for {
+		// Pages wait for the shared read rate limiter rather than failing the whole listing.
+		if err := az.rateLimiterReader.Wait(ctx); err != nil {
+			return nil, retry.GetRateLimitError(false, "ResourceGraphListScaleSets")
+		}
+		response, err := az.client.Resources(ctx, request)
+		if err != nil {
+			return nil, requestError(err)
+		}
+		scaleSets, err := scaleSetsFromQueryResponse(response)
+		if err != nil {
+			return nil, retry.NewError(false, err)
+		}
+		result = append(result, scaleSets...)
+		pages++
+		if response.SkipToken == nil || *response.SkipToken == "" {
+			break
+		}
+		request.Options.SkipToken = response.SkipToken
+	}
+	klog.V(4).Infof("azResourceGraphScaleSetsLister.ListScaleSets(%v): listed %d scale sets in %d pages", resourceGroupNames, len(result), pages)
+	return result, nil
+}
+
+// rateLimitedScaleSetsClient takes a token of a read rate limiter shared with
+// the Resource Graph scale sets lister for every read, so that both stay within a single read budget.
+type rateLimitedScaleSetsClient struct {
+	vmssclient.Interface
+	rateLimiterReader flowcontrol.RateLimiter
+}
+
+// Get gets a VirtualMachineScaleSet.
+func (c *rateLimitedScaleSetsClient) Get(ctx context.Context, resourceGroupName string, VMScaleSetName string) (compute.VirtualMachineScaleSet, *retry.Error) {
+	if !c.rateLimiterReader.TryAccept() {
+		return compute.VirtualMachineScaleSet{}, retry.GetRateLimitError(false, "VMSSGet")
+	}
+	return c.Interface.Get(ctx, resourceGroupName, VMScaleSetName)
+}
+
+// List gets a list of VirtualMachineScaleSets in the resource group.
+func (c *rateLimitedScaleSetsClient) List(ctx context.Context, resourceGroupName string) ([]compute.VirtualMachineScaleSet, *retry.Error) {
+	if !c.rateLimiterReader.TryAccept() {
+		return nil, retry.GetRateLimitError(false, "VMSSList")
+	}
+	return c.Interface.List(ctx, resourceGroupName)
+}
+
+// scaleSetsFromQueryResponse converts the rows of an object array query response to scale sets.
+func scaleSetsFromQueryResponse(response resourcegraph.QueryResponse) ([]compute.VirtualMachineScaleSet, error) {
+	if response.Data == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(response.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Resource Graph query data: %v", err)
+	}
+	var scaleSets []compute.VirtualMachineScaleSet
+	if err := json.Unmarshal(data, &scaleSets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scale sets from Resource Graph query data: %v", err)
+	}
+	return scaleSets, nil
+}
+
+// resourceGraphStringList formats values as a comma separated list of single quoted strings of a query.
+func resourceGraphStringList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, "'"+escapeResourceGraphString(value)+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// escapeResourceGraphString escapes a value used in a single quoted string of a query.
+func escapeResourceGraphString(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
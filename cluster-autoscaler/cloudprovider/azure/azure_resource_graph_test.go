@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient/mockvmssclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+type fakeScaleSetsLister struct {
+	scaleSets      []compute.VirtualMachineScaleSet
+	resourceGroups []string
+}
+
+func (f *fakeScaleSetsLister) ListScaleSets(_ context.Context, resourceGroupNames []string) ([]compute.VirtualMachineScaleSet, *retry.Error) {
+	f.resourceGroups = append(f.resourceGroups, resourceGroupNames...)
+	return f.scaleSets, nil
+}
+
+func TestScaleSetsFromQueryResponse(t *testing.T) {
+	response := resourcegraph.QueryResponse{
+		Data: []interface{}{
+			map[string]interface{}{
+				"id":       "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/vmss1",
+				"name":     "vmss1",
+				"location": "eastus",
+				"tags":     map[string]interface{}{"k8s.io_cluster-autoscaler_node-template_label_foo": "bar"},
+				"sku":      map[string]interface{}{"name": "Standard_D4s_v3", "capacity": float64(3)},
+				"zones":    []interface{}{"1", "2"},
+				"properties": map[string]interface{}{
+					"provisioningState": "Succeeded",
+					"orchestrationMode": "Uniform",
+				},
+			},
+		},
+	}
+
+	scaleSets, err := scaleSetsFromQueryResponse(response)
+	assert.NoError(t, err)
+	assert.Len(t, scaleSets, 1)
+	vmss := scaleSets[0]
+	assert.Equal(t, "vmss1", *vmss.Name)
+	assert.Equal(t, "eastus", *vmss.Location)
+	assert.Equal(t, "bar", *vmss.Tags["k8s.io_cluster-autoscaler_node-template_label_foo"])
+	assert.Equal(t, "Standard_D4s_v3", *vmss.Sku.Name)
+	assert.Equal(t, int64(3), *vmss.Sku.Capacity)
+	assert.Equal(t, []string{"1", "2"}, *vmss.Zones)
+	assert.Equal(t, "Succeeded", *vmss.ProvisioningState)
+	assert.Equal(t, compute.Uniform, vmss.OrchestrationMode)
+
+	scaleSets, err = scaleSetsFromQueryResponse(resourcegraph.QueryResponse{})
+	assert.NoError(t, err)
+	assert.Empty(t, scaleSets)
+}
+
+func TestEscapeResourceGraphString(t *testing.T) {
+	assert.Equal(t, "rg", escapeResourceGraphString("rg"))
+	assert.Equal(t, `rg\' or 1==1 or \'`, escapeResourceGraphString(`rg' or 1==1 or '`))
+	assert.Equal(t, `rg\\`, escapeResourceGraphString(`rg\`))
+}
+
+func TestResourceGraphStringList(t *testing.T) {
+	assert.Equal(t, `'rg1', 'rg\'2'`, resourceGraphStringList([]string{"rg1", "rg'2"}))
+}
+
+func TestFetchScaleSetsWithResourceGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The Compute API isn't called when the scale sets are listed with Resource Graph.
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	lister := &fakeScaleSetsLister{scaleSets: []compute.VirtualMachineScaleSet{{Name: to.StringPtr("vmss1")}}}
+	cache := &azureCache{
+		azClient: &azClient{
+			virtualMachineScaleSetsClient: mockVMSSClient,
+			resourceGraphScaleSetsLister:  lister,
+		},
+		resourceGroup: "rg",
+	}
+
+	scaleSets, err := cache.fetchScaleSets()
+	assert.NoError(t, err)
+	assert.Contains(t, scaleSets, "vmss1")
+	assert.Equal(t, []string{"rg"}, lister.resourceGroups)
+}
+
+func TestGetCurSizeWithResourceGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The listed size lags behind ARM, the size is read from the Compute API.
+	manager := newTestAzureManager(t)
+	manager.azureCache.scaleSets = map[string]compute.VirtualMachineScaleSet{"test-vmss": newTestVMSSList(3, "test-vmss", "eastus", compute.Uniform)[0]}
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().Get(gomock.Any(), "rg", "test-vmss").Return(newTestVMSSList(5, "test-vmss", "eastus", compute.Uniform)[0], nil)
+	manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	manager.azClient.resourceGraphScaleSetsLister = &fakeScaleSetsLister{}
+
+	scaleSet := newTestScaleSet(manager, "test-vmss")
+	size, err := scaleSet.getCurSize()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package azure
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -33,12 +36,16 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
 	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
 )
 
 var (
 	defaultVmssInstancesRefreshPeriod = 5 * time.Minute
 	vmssContextTimeout                = 3 * time.Minute
 	vmssSizeMutex                     sync.Mutex
+
+	// errInstanceNotFound is returned by operations on instances which don't exist anymore.
+	errInstanceNotFound = errors.New("instance not found")
 )
 
 const (
@@ -50,6 +57,10 @@ const (
 	provisioningStateUpdating  string = "Updating"
 )
 
+// instanceProtectionTag marks VMSS instances whose protection from scale-in was set by
+// the autoscaler, e.g. as part of a drain workflow, so that it can be cleared on deletion.
+const instanceProtectionTag = "cluster-autoscaler-instance-protection"
+
 // ScaleSet implements NodeGroup interface.
 type ScaleSet struct {
 	azureRef
@@ -58,10 +69,18 @@ type ScaleSet struct {
 	minSize int
 	maxSize int
 
-	enableForceDelete bool
+	enableForceDelete       bool
+	clearInstanceProtection bool
 
 	sizeMutex sync.Mutex
 	curSize   int64
+	// capacity requested by the in-flight capacity update, reported as the size
+	// until the update finishes, so that a stale capacity from ARM doesn't make
+	// the autoscaler request the same scale-up again.
+	inFlightCapacity *int64
+	// incremented with every capacity update, so that only the latest finished
+	// update clears inFlightCapacity.
+	capacityUpdateGeneration uint64
 
 	enableDynamicInstanceList bool
 
@@ -74,6 +93,28 @@ type ScaleSet struct {
 	instanceMutex       sync.Mutex
 	instanceCache       []cloudprovider.Instance
 	lastInstanceRefresh time.Time
+	// protection of instances from scale-in by provider ID, only for uniform scale sets.
+	instanceProtection map[string]instanceProtection
+	// instances which never became nodes, i.e. which have been in the failed
+	// provisioning state since they were first seen, by provider ID.
+	ghostInstances map[string]ghostInstance
+	// provider IDs, lowercased, of the instances operations were denied on because they don't
+	// exist anymore, until they disappear from the instance cache.
+	notFoundInstances map[string]bool
+}
+
+// ghostInstance tracks an instance which has been in the failed provisioning state since it was first seen.
+type ghostInstance struct {
+	// since is the time the instance was first seen.
+	since time.Time
+	// refreshes is the number of instance cache refreshes the instance was seen failed in.
+	refreshes int
+}
+
+// instanceProtection describes the protection policy of a VMSS instance.
+type instanceProtection struct {
+	// setByAutoscaler is true if the instance is tagged with instanceProtectionTag.
+	setByAutoscaler bool
 }
 
 // NewScaleSet creates a new NewScaleSet.
@@ -87,20 +128,38 @@ func NewScaleSet(spec *dynamic.NodeGroupSpec, az *AzureManager, curSize int64) (
 		manager:                   az,
 		curSize:                   curSize,
 		sizeRefreshPeriod:         az.azureCache.refreshInterval,
-		enableDynamicInstanceList: az.config.EnableDynamicInstanceList,
-		instancesRefreshJitter:    az.config.VmssVmsCacheJitter,
-		enableForceDelete:         az.config.EnableForceDelete,
-	}
-
-	if az.config.VmssVmsCacheTTL != 0 {
-		scaleSet.instancesRefreshPeriod = time.Duration(az.config.VmssVmsCacheTTL) * time.Second
-	} else {
-		scaleSet.instancesRefreshPeriod = defaultVmssInstancesRefreshPeriod
+		enableDynamicInstanceList: az.getConfig().EnableDynamicInstanceList,
+		instancesRefreshJitter:    az.getConfig().VmssVmsCacheJitter,
+		enableForceDelete:         az.getConfig().EnableForceDelete,
+		clearInstanceProtection:   az.getConfig().ClearInstanceProtection,
+		instancesRefreshPeriod:    vmssVmsCacheTTL(az.getConfig()),
 	}
 
 	return scaleSet, nil
 }
 
+// setRefreshPeriods updates the size and instances cache TTLs, e.g. after the cloud config was reloaded.
+func (scaleSet *ScaleSet) setRefreshPeriods(sizeRefreshPeriod, instancesRefreshPeriod time.Duration) {
+	scaleSet.sizeMutex.Lock()
+	scaleSet.sizeRefreshPeriod = sizeRefreshPeriod
+	scaleSet.sizeMutex.Unlock()
+
+	scaleSet.instanceMutex.Lock()
+	scaleSet.instancesRefreshPeriod = instancesRefreshPeriod
+	scaleSet.instanceMutex.Unlock()
+}
+
+// getInstancesRefreshPeriodOverride returns the instances cache TTL set with the
+// vmssVmsCacheTTLOption tag of the VMSS, if any.
+func (scaleSet *ScaleSet) getInstancesRefreshPeriodOverride() (time.Duration, bool) {
+	options := scaleSet.manager.azureCache.getAutoscalingOptions(scaleSet.azureRef)
+	ttl, ok := getDurationOption(options, scaleSet.Name, vmssVmsCacheTTLOption)
+	if !ok || ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
 // MinSize returns minimum size of the node group.
 func (scaleSet *ScaleSet) MinSize() int {
 	return scaleSet.minSize
@@ -153,16 +212,35 @@ func (scaleSet *ScaleSet) getVMSSFromCache() (compute.VirtualMachineScaleSet, er
 	return allVMSS[scaleSet.Name], nil
 }
 
+// getVMSSForSize returns the scale set to read the current size from. Scale sets listed with
+// Resource Graph queries can lag behind ARM, so their size is read from the Compute API instead.
+func (scaleSet *ScaleSet) getVMSSForSize() (compute.VirtualMachineScaleSet, error) {
+	azClient := scaleSet.manager.getAzClient()
+	if azClient.resourceGraphScaleSetsLister == nil {
+		return scaleSet.getVMSSFromCache()
+	}
+	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
+	defer cancel()
+	set, rerr := azClient.virtualMachineScaleSetsClient.Get(ctx, scaleSet.manager.getConfig().ResourceGroup, scaleSet.Name)
+	if rerr != nil {
+		return compute.VirtualMachineScaleSet{}, rerr.Error()
+	}
+	return set, nil
+}
+
 func (scaleSet *ScaleSet) getCurSize() (int64, error) {
 	scaleSet.sizeMutex.Lock()
 	defer scaleSet.sizeMutex.Unlock()
 
 	if scaleSet.lastSizeRefresh.Add(scaleSet.sizeRefreshPeriod).After(time.Now()) {
 		klog.V(3).Infof("VMSS: %s, returning in-memory size: %d", scaleSet.Name, scaleSet.curSize)
+		observeCacheLookup(vmssSizeCacheName, true)
 		return scaleSet.curSize, nil
 	}
+	observeCacheLookup(vmssSizeCacheName, false)
 
-	set, err := scaleSet.getVMSSFromCache()
+	set, err := scaleSet.getVMSSForSize()
+	observeCacheRefresh(vmssSizeCacheName, err)
 	if err != nil {
 		klog.Errorf("failed to get information for VMSS: %s, error: %v", scaleSet.Name, err)
 		return -1, err
@@ -172,6 +250,11 @@ func (scaleSet *ScaleSet) getCurSize() (int64, error) {
 	curSize := *set.Sku.Capacity
 	vmssSizeMutex.Unlock()
 
+	if scaleSet.inFlightCapacity != nil && *scaleSet.inFlightCapacity != curSize {
+		klog.V(3).Infof("VMSS: %s, capacity update to %d in flight, ignoring reported size %d", scaleSet.Name, *scaleSet.inFlightCapacity, curSize)
+		curSize = *scaleSet.inFlightCapacity
+	}
+
 	if scaleSet.curSize != curSize {
 		// Invalidate the instance cache if the capacity has changed.
 		klog.V(5).Infof("VMSS %q size changed from: %d to %d, invalidating instance cache", scaleSet.Name, scaleSet.curSize, curSize)
@@ -194,7 +277,7 @@ func (scaleSet *ScaleSet) waitForDeleteInstances(future *azure.Future, requiredI
 	defer cancel()
 
 	klog.V(3).Infof("Calling virtualMachineScaleSetsClient.WaitForDeleteInstancesResult(%v) for %s", requiredIds.InstanceIds, scaleSet.Name)
-	httpResponse, err := scaleSet.manager.azClient.virtualMachineScaleSetsClient.WaitForDeleteInstancesResult(ctx, future, scaleSet.manager.config.ResourceGroup)
+	httpResponse, err := scaleSet.manager.getAzClient().virtualMachineScaleSetsClient.WaitForDeleteInstancesResult(ctx, future, scaleSet.manager.getConfig().ResourceGroup)
 	isSuccess, err := isSuccessHTTPResponse(httpResponse, err)
 	if isSuccess {
 		klog.V(3).Infof("virtualMachineScaleSetsClient.WaitForDeleteInstancesResult(%v) for %s success", requiredIds.InstanceIds, scaleSet.Name)
@@ -204,9 +287,10 @@ func (scaleSet *ScaleSet) waitForDeleteInstances(future *azure.Future, requiredI
 }
 
 // updateVMSSCapacity invokes virtualMachineScaleSetsClient to update the capacity for VMSS.
-func (scaleSet *ScaleSet) updateVMSSCapacity(future *azure.Future) {
+func (scaleSet *ScaleSet) updateVMSSCapacity(future *azure.Future, generation uint64) {
 	var err error
 
+	defer scaleSet.finishCapacityUpdate(generation)
 	defer func() {
 		if err != nil {
 			klog.Errorf("Failed to update the capacity for vmss %s with error %v, invalidate the cache so as to get the real size from API", scaleSet.Name, err)
@@ -219,17 +303,82 @@ func (scaleSet *ScaleSet) updateVMSSCapacity(future *azure.Future) {
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
 
-	klog.V(3).Infof("Calling virtualMachineScaleSetsClient.WaitForCreateOrUpdateResult(%s)", scaleSet.Name)
-	httpResponse, err := scaleSet.manager.azClient.virtualMachineScaleSetsClient.WaitForCreateOrUpdateResult(ctx, future, scaleSet.manager.config.ResourceGroup)
+	klog.V(3).Infof("Calling virtualMachineScaleSetsUpdater.WaitForUpdateResult(%s)", scaleSet.Name)
+	httpResponse, err := scaleSet.manager.getAzClient().virtualMachineScaleSetsUpdater.WaitForUpdateResult(ctx, future, scaleSet.manager.getConfig().ResourceGroup)
 
 	isSuccess, err := isSuccessHTTPResponse(httpResponse, err)
 	if isSuccess {
-		klog.V(3).Infof("virtualMachineScaleSetsClient.WaitForCreateOrUpdateResult(%s) success", scaleSet.Name)
+		klog.V(3).Infof("virtualMachineScaleSetsUpdater.WaitForUpdateResult(%s) success", scaleSet.Name)
 		scaleSet.invalidateInstanceCache()
 		return
 	}
 
-	klog.Errorf("virtualMachineScaleSetsClient.WaitForCreateOrUpdateResult - updateVMSSCapacity for scale set %q failed: %v", scaleSet.Name, err)
+	klog.Errorf("virtualMachineScaleSetsUpdater.WaitForUpdateResult - updateVMSSCapacity for scale set %q failed: %v", scaleSet.Name, err)
+}
+
+// finishCapacityUpdate stops reporting the capacity of the given update as the size,
+// unless a newer capacity update is in flight.
+func (scaleSet *ScaleSet) finishCapacityUpdate(generation uint64) {
+	scaleSet.sizeMutex.Lock()
+	defer scaleSet.sizeMutex.Unlock()
+
+	if scaleSet.capacityUpdateGeneration == generation {
+		scaleSet.inFlightCapacity = nil
+	}
+}
+
+// quotaNameRegexp extracts the quota from messages like "Operation could not be completed
+// as it results in exceeding approved standardDSv3Family Cores quota.".
+var quotaNameRegexp = regexp.MustCompile(`exceeding approved (.+?) quota`)
+
+// capacityServiceErrorCodes are the codes of errors returned when Azure is out of
+// capacity for the scale set's VM size.
+var capacityServiceErrorCodes = map[string]bool{
+	"AllocationFailed":                      true,
+	"ZonalAllocationFailed":                 true,
+	"OverconstrainedAllocationRequest":      true,
+	"OverconstrainedZonalAllocationRequest": true,
+	"SkuNotAvailable":                       true,
+}
+
+// provisioningErrorKind returns the kind of the error with the given code which failed the provisioning of an instance.
+func provisioningErrorKind(code string) cloudprovider.ErrorKind {
+	switch {
+	case capacityServiceErrorCodes[code]:
+		return cloudprovider.CapacityErrorKind
+	case strings.Contains(code, retry.QuotaExceeded):
+		return cloudprovider.QuotaErrorKind
+	case code == "AuthorizationFailed" || code == "LinkedAuthorizationFailed":
+		return cloudprovider.PermissionErrorKind
+	default:
+		return cloudprovider.UnknownErrorKind
+	}
+}
+
+// scaleSetUpdateError returns the error of a failed update of a scale set in the given
+// location. Errors caused by exceeded subscription quotas carry the quota, which applies
+// to the location, so that the node groups exceeding it are backed off until the time the
+// quota may be available again. Other errors are classified by their service error code
+// or HTTP status code.
+func scaleSetUpdateError(rerr *retry.Error, location string) error {
+	code := rerr.ServiceErrorCode()
+	if code != retry.QuotaExceeded {
+		kind := cloudprovider.ErrorKindFromHTTPStatusCode(rerr.HTTPStatusCode)
+		if capacityServiceErrorCodes[code] {
+			kind = cloudprovider.CapacityErrorKind
+		} else if rerr.IsThrottled() {
+			kind = cloudprovider.ThrottlingErrorKind
+		}
+		if kind == cloudprovider.UnknownErrorKind {
+			return rerr.Error()
+		}
+		return cloudprovider.NewClassifiedError(kind, code, rerr.Error())
+	}
+	quota := retry.QuotaExceeded
+	if match := quotaNameRegexp.FindStringSubmatch(rerr.ServiceErrorMessage()); match != nil {
+		quota = match[1]
+	}
+	return cloudprovider.NewQuotaExceededError(quota, rerr.RetryAfter, rerr.Error()).WithScope(location)
 }
 
 // SetScaleSetSize sets ScaleSet size.
@@ -248,36 +397,29 @@ func (scaleSet *ScaleSet) SetScaleSetSize(size int64) error {
 	vmssInfo.Sku.Capacity = &size
 	vmssSizeMutex.Unlock()
 
-	// Compose a new VMSS for updating.
-	op := compute.VirtualMachineScaleSet{
-		Name:     vmssInfo.Name,
-		Sku:      vmssInfo.Sku,
-		Location: vmssInfo.Location,
-	}
-
-	if vmssInfo.ExtendedLocation != nil {
-		op.ExtendedLocation = &compute.ExtendedLocation{
-			Name: vmssInfo.ExtendedLocation.Name,
-			Type: vmssInfo.ExtendedLocation.Type,
-		}
-
-		klog.V(3).Infof("Passing ExtendedLocation information if it is not nil, with Edge Zone name:(%s)", *op.ExtendedLocation.Name)
+	// Only the capacity is sent, with a PATCH request, so that the tags and settings managed by others,
+	// e.g. assigned by Azure Policy, are kept as they are.
+	op := compute.VirtualMachineScaleSetUpdate{
+		Sku: vmssInfo.Sku,
 	}
 
 	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
 	defer cancel()
-	klog.V(3).Infof("Waiting for virtualMachineScaleSetsClient.CreateOrUpdateAsync(%s)", scaleSet.Name)
-	future, rerr := scaleSet.manager.azClient.virtualMachineScaleSetsClient.CreateOrUpdateAsync(ctx, scaleSet.manager.config.ResourceGroup, scaleSet.Name, op)
+	klog.V(3).Infof("Waiting for virtualMachineScaleSetsUpdater.UpdateAsync(%s)", scaleSet.Name)
+	future, rerr := scaleSet.manager.getAzClient().virtualMachineScaleSetsUpdater.UpdateAsync(ctx, scaleSet.manager.getConfig().ResourceGroup, scaleSet.Name, op)
 	if rerr != nil {
-		klog.Errorf("virtualMachineScaleSetsClient.CreateOrUpdate for scale set %q failed: %v", scaleSet.Name, rerr)
-		return rerr.Error()
+		klog.Errorf("virtualMachineScaleSetsUpdater.UpdateAsync for scale set %q failed: %v", scaleSet.Name, rerr)
+		return scaleSetUpdateError(rerr, to.String(vmssInfo.Location))
 	}
 
 	// Proactively set the VMSS size so autoscaler makes better decisions.
 	scaleSet.curSize = size
 	scaleSet.lastSizeRefresh = time.Now()
+	scaleSet.capacityUpdateGeneration++
+	inFlightCapacity := size
+	scaleSet.inFlightCapacity = &inFlightCapacity
 
-	go scaleSet.updateVMSSCapacity(future)
+	go scaleSet.updateVMSSCapacity(future, scaleSet.capacityUpdateGeneration)
 	return nil
 }
 
@@ -321,8 +463,8 @@ func (scaleSet *ScaleSet) GetScaleSetVms() ([]compute.VirtualMachineScaleSetVM,
 	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
 	defer cancel()
 
-	resourceGroup := scaleSet.manager.config.ResourceGroup
-	vmList, rerr := scaleSet.manager.azClient.virtualMachineScaleSetVMsClient.List(ctx, resourceGroup, scaleSet.Name, "instanceView")
+	resourceGroup := scaleSet.manager.getConfig().ResourceGroup
+	vmList, rerr := scaleSet.manager.getAzClient().virtualMachineScaleSetVMsClient.List(ctx, resourceGroup, scaleSet.Name, "instanceView")
 	klog.V(4).Infof("GetScaleSetVms: scaleSet.Name: %s, vmList: %v", scaleSet.Name, vmList)
 	if rerr != nil {
 		klog.Errorf("VirtualMachineScaleSetVMsClient.List failed for %s: %v", scaleSet.Name, rerr)
@@ -348,7 +490,7 @@ func (scaleSet *ScaleSet) GetFlexibleScaleSetVms() ([]compute.VirtualMachine, *r
 		}
 		return nil, rerr
 	}
-	vmList, rerr := scaleSet.manager.azClient.virtualMachinesClient.ListVmssFlexVMsWithoutInstanceView(ctx, *vmssInfo.ID)
+	vmList, rerr := scaleSet.manager.getAzClient().virtualMachinesClient.ListVmssFlexVMsWithoutInstanceView(ctx, *vmssInfo.ID)
 	if rerr != nil {
 		klog.Errorf("VirtualMachineScaleSetVMsClient.List failed for %s: %v", scaleSet.Name, rerr)
 		return nil, rerr
@@ -408,6 +550,10 @@ func (scaleSet *ScaleSet) DeleteInstances(instances []*azureRef, hasUnregistered
 	}
 
 	instancesToDelete := []*azureRef{}
+	instancesToUnprotect := []*azureRef{}
+	// instances which can't be deleted, e.g. because they are protected, by provider ID.
+	// The other instances are still deleted.
+	notDeleted := map[string]error{}
 	for _, instance := range instances {
 		asg, err := scaleSet.manager.GetNodeGroupForInstance(instance)
 		if err != nil {
@@ -422,13 +568,21 @@ func (scaleSet *ScaleSet) DeleteInstances(instances []*azureRef, hasUnregistered
 			klog.V(3).Infof("Skipping deleting instance %s as its current state is deleting", instance.Name)
 			continue
 		}
+		if protection, found := scaleSet.getInstanceProtection(instance.Name); found {
+			if !scaleSet.clearInstanceProtection || !protection.setByAutoscaler {
+				klog.Warningf("Not deleting instance %s of %s, it is protected from scale-in", instance.Name, scaleSet.Name)
+				notDeleted[instance.Name] = fmt.Errorf("instance is protected from scale-in")
+				continue
+			}
+			instancesToUnprotect = append(instancesToUnprotect, instance)
+		}
 		instancesToDelete = append(instancesToDelete, instance)
 	}
 
 	// nothing to delete
 	if len(instancesToDelete) == 0 {
 		klog.V(3).Infof("No new instances eligible for deletion, skipping")
-		return nil
+		return notDeletedError(notDeleted)
 	}
 
 	instanceIDs := []string{}
@@ -441,26 +595,66 @@ func (scaleSet *ScaleSet) DeleteInstances(instances []*azureRef, hasUnregistered
 		instanceIDs = append(instanceIDs, instanceID)
 	}
 
+	skipped := map[string]bool{}
+	for _, instance := range instancesToUnprotect {
+		if err := scaleSet.clearProtection(instance); err != nil {
+			if !errors.Is(err, errInstanceNotFound) {
+				notDeleted[instance.Name] = err
+			}
+			skipped[instance.Name] = true
+		}
+	}
+	if len(skipped) > 0 {
+		remaining := []*azureRef{}
+		instanceIDs = []string{}
+		for _, instance := range instancesToDelete {
+			if skipped[instance.Name] {
+				continue
+			}
+			remaining = append(remaining, instance)
+			instanceID, _ := getLastSegment(instance.Name)
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+		instancesToDelete = remaining
+		if len(instancesToDelete) == 0 {
+			klog.V(3).Infof("No instances left to delete, skipping")
+			return notDeletedError(notDeleted)
+		}
+	}
+
 	requiredIds := &compute.VirtualMachineScaleSetVMInstanceRequiredIDs{
 		InstanceIds: &instanceIDs,
 	}
 
 	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
 	defer cancel()
-	resourceGroup := scaleSet.manager.config.ResourceGroup
+	resourceGroup := scaleSet.manager.getConfig().ResourceGroup
 
 	scaleSet.instanceMutex.Lock()
 	klog.V(3).Infof("Calling virtualMachineScaleSetsClient.DeleteInstancesAsync(%v), force delete set to %v", requiredIds.InstanceIds, scaleSet.enableForceDelete)
-	future, rerr := scaleSet.manager.azClient.virtualMachineScaleSetsClient.DeleteInstancesAsync(ctx, resourceGroup, commonAsg.Id(), *requiredIds, scaleSet.enableForceDelete)
+	future, rerr := scaleSet.manager.getAzClient().virtualMachineScaleSetsClient.DeleteInstancesAsync(ctx, resourceGroup, commonAsg.Id(), *requiredIds, scaleSet.enableForceDelete)
 
 	if scaleSet.enableForceDelete && isOperationNotAllowed(rerr) {
 		klog.Infof("falling back to normal delete for instances %v for %s", requiredIds.InstanceIds, scaleSet.Name)
-		future, rerr = scaleSet.manager.azClient.virtualMachineScaleSetsClient.DeleteInstancesAsync(ctx, resourceGroup,
+		future, rerr = scaleSet.manager.getAzClient().virtualMachineScaleSetsClient.DeleteInstancesAsync(ctx, resourceGroup,
 			commonAsg.Id(), *requiredIds, false)
 	}
 
 	scaleSet.instanceMutex.Unlock()
 	if rerr != nil {
+		if rerr.HTTPStatusCode == http.StatusNotFound {
+			// The request fails if any of the instances doesn't exist, the others still have to be deleted.
+			klog.Warningf("virtualMachineScaleSetsClient.DeleteInstancesAsync for instances %v returned not found, checking which don't exist anymore", requiredIds.InstanceIds)
+			for _, instance := range instancesToDelete {
+				notFound, err := scaleSet.confirmInstanceNotFound(instance)
+				if err != nil {
+					notDeleted[instance.Name] = err
+				} else if !notFound {
+					notDeleted[instance.Name] = rerr.Error()
+				}
+			}
+			return notDeletedError(notDeleted)
+		}
 		klog.Errorf("virtualMachineScaleSetsClient.DeleteInstancesAsync for instances %v failed: %v", requiredIds.InstanceIds, rerr)
 		return rerr.Error()
 	}
@@ -472,6 +666,9 @@ func (scaleSet *ScaleSet) DeleteInstances(instances []*azureRef, hasUnregistered
 		scaleSet.sizeMutex.Lock()
 		scaleSet.curSize -= int64(len(instanceIDs))
 		scaleSet.lastSizeRefresh = time.Now()
+		if scaleSet.inFlightCapacity != nil {
+			*scaleSet.inFlightCapacity -= int64(len(instanceIDs))
+		}
 		scaleSet.sizeMutex.Unlock()
 	}
 
@@ -482,7 +679,16 @@ func (scaleSet *ScaleSet) DeleteInstances(instances []*azureRef, hasUnregistered
 
 	go scaleSet.waitForDeleteInstances(future, requiredIds)
 
-	return nil
+	return notDeletedError(notDeleted)
+}
+
+// notDeletedError returns a NodesNotDeletedError for the instances which couldn't be
+// deleted, by provider ID, or nil if all the instances are being deleted.
+func notDeletedError(notDeleted map[string]error) error {
+	if len(notDeleted) == 0 {
+		return nil
+	}
+	return &cloudprovider.NodesNotDeletedError{NodeErrors: notDeleted}
 }
 
 // DeleteNodes deletes the nodes from the group.
@@ -498,6 +704,7 @@ func (scaleSet *ScaleSet) DeleteNodes(nodes []*apiv1.Node) error {
 	}
 
 	refs := make([]*azureRef, 0, len(nodes))
+	nodeNames := make(map[string]string, len(nodes))
 	hasUnregisteredNodes := false
 	for _, node := range nodes {
 		belongs, err := scaleSet.Belongs(node)
@@ -516,9 +723,20 @@ func (scaleSet *ScaleSet) DeleteNodes(nodes []*apiv1.Node) error {
 			Name: node.Spec.ProviderID,
 		}
 		refs = append(refs, ref)
+		nodeNames[node.Spec.ProviderID] = node.Name
 	}
 
-	return scaleSet.DeleteInstances(refs, hasUnregisteredNodes)
+	err = scaleSet.DeleteInstances(refs, hasUnregisteredNodes)
+	// Report the instances which couldn't be deleted by the names of their nodes.
+	var notDeletedErr *cloudprovider.NodesNotDeletedError
+	if errors.As(err, &notDeletedErr) {
+		nodeErrors := make(map[string]error, len(notDeletedErr.NodeErrors))
+		for providerID, instanceErr := range notDeletedErr.NodeErrors {
+			nodeErrors[nodeNames[providerID]] = instanceErr
+		}
+		return &cloudprovider.NodesNotDeletedError{NodeErrors: nodeErrors}
+	}
+	return err
 }
 
 // Id returns ScaleSet id.
@@ -556,15 +774,22 @@ func (scaleSet *ScaleSet) Nodes() ([]cloudprovider.Instance, error) {
 		klog.Errorf("Failed to get current size for vmss %q: %v", scaleSet.Name, err)
 		return nil, err
 	}
+	ttlOverride, hasTTLOverride := scaleSet.getInstancesRefreshPeriodOverride()
 
 	scaleSet.instanceMutex.Lock()
 	defer scaleSet.instanceMutex.Unlock()
 
+	refreshPeriod := scaleSet.instancesRefreshPeriod
+	if hasTTLOverride {
+		refreshPeriod = ttlOverride
+	}
 	if int64(len(scaleSet.instanceCache)) == curSize &&
-		scaleSet.lastInstanceRefresh.Add(scaleSet.instancesRefreshPeriod).After(time.Now()) {
+		scaleSet.lastInstanceRefresh.Add(refreshPeriod).After(time.Now()) {
 		klog.V(4).Infof("Nodes: returns with curSize %d", curSize)
+		observeCacheLookup(vmssVmsCacheName, true)
 		return scaleSet.instanceCache, nil
 	}
+	observeCacheLookup(vmssVmsCacheName, false)
 
 	klog.V(4).Infof("Nodes: starts to get VMSS VMs")
 	splay := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(scaleSet.instancesRefreshJitter + 1)
@@ -580,13 +805,15 @@ func (scaleSet *ScaleSet) Nodes() ([]cloudprovider.Instance, error) {
 
 	if orchestrationMode == compute.Uniform {
 		err := scaleSet.buildScaleSetCache(lastRefresh)
+		observeCacheRefresh(vmssVmsCacheName, err)
 		if err != nil {
 			return nil, err
 		}
 
 	} else if orchestrationMode == compute.Flexible {
-		if scaleSet.manager.config.EnableVmssFlex {
+		if scaleSet.manager.getConfig().EnableVmssFlex {
 			err := scaleSet.buildScaleSetCacheForFlex(lastRefresh)
+			observeCacheRefresh(vmssVmsCacheName, err)
 			if err != nil {
 				return nil, err
 			}
@@ -603,25 +830,30 @@ func (scaleSet *ScaleSet) Nodes() ([]cloudprovider.Instance, error) {
 }
 
 func (scaleSet *ScaleSet) buildScaleSetCache(lastRefresh time.Time) error {
-	vms, rerr := scaleSet.GetScaleSetVms()
+	vms, rerr := scaleSet.listScaleSetVMs()
 	if rerr != nil {
 		if isAzureRequestsThrottled(rerr) {
 			// Log a warning and update the instance refresh time so that it would retry after cache expiration
-			klog.Warningf("GetScaleSetVms() is throttled with message %v, would return the cached instances", rerr)
+			klog.Warningf("listScaleSetVMs() is throttled with message %v, would return the cached instances", rerr)
 			scaleSet.lastInstanceRefresh = lastRefresh
 			return nil
 		}
 		return rerr.Error()
 	}
 
+	previousInstances := scaleSet.instanceCache
 	scaleSet.instanceCache = buildInstanceCache(vms)
+	scaleSet.instanceProtection = buildInstanceProtection(vms)
+	scaleSet.updateGhostInstances(previousInstances, failedProvisioningInstances(vms), time.Now())
+	scaleSet.reportGhostInstances(time.Now())
+	scaleSet.forgetNotFoundInstances()
 	scaleSet.lastInstanceRefresh = lastRefresh
 
 	return nil
 }
 
 func (scaleSet *ScaleSet) buildScaleSetCacheForFlex(lastRefresh time.Time) error {
-	vms, rerr := scaleSet.GetFlexibleScaleSetVms()
+	vmList, rerr := scaleSet.GetFlexibleScaleSetVms()
 	if rerr != nil {
 		if isAzureRequestsThrottled(rerr) {
 			// Log a warning and update the instance refresh time so that it would retry after cache expiration
@@ -632,46 +864,320 @@ func (scaleSet *ScaleSet) buildScaleSetCacheForFlex(lastRefresh time.Time) error
 		return rerr.Error()
 	}
 
+	vms := make([]scaleSetVMInfo, 0, len(vmList))
+	for _, vm := range vmList {
+		vms = append(vms, projectVM(vm))
+	}
+	previousInstances := scaleSet.instanceCache
 	scaleSet.instanceCache = buildInstanceCache(vms)
+	scaleSet.updateGhostInstances(previousInstances, failedProvisioningInstances(vms), time.Now())
+	scaleSet.reportGhostInstances(time.Now())
+	scaleSet.forgetNotFoundInstances()
 	scaleSet.lastInstanceRefresh = lastRefresh
 
 	return nil
 }
 
+// scaleSetVMInfo is the projection of a scale set VM to the fields the instance cache is built from.
+// The full VMs carry their whole model and instance view, so on scale sets with thousands of
+// instances only the projections are kept while the VMs list is read.
+type scaleSetVMInfo struct {
+	id                string
+	provisioningState *string
+	powerState        string
+	// provisioningErrorCode is the code of the error which failed the provisioning of the VM, if any.
+	provisioningErrorCode string
+	// protection is set for the instances protected from scale-in or from all scale set actions.
+	protection *instanceProtection
+}
+
+func projectScaleSetVM(vm compute.VirtualMachineScaleSetVM) scaleSetVMInfo {
+	info := scaleSetVMInfo{id: to.String(vm.ID), powerState: vmPowerStateRunning}
+	props := vm.VirtualMachineScaleSetVMProperties
+	if props == nil {
+		return info
+	}
+	info.provisioningState = props.ProvisioningState
+	if props.InstanceView != nil && props.InstanceView.Statuses != nil {
+		info.powerState = vmPowerStateFromStatuses(*props.InstanceView.Statuses)
+		info.provisioningErrorCode = vmProvisioningErrorCodeFromStatuses(*props.InstanceView.Statuses)
+	}
+	if policy := props.ProtectionPolicy; policy != nil && (to.Bool(policy.ProtectFromScaleIn) || to.Bool(policy.ProtectFromScaleSetActions)) {
+		_, setByAutoscaler := vm.Tags[instanceProtectionTag]
+		info.protection = &instanceProtection{setByAutoscaler: setByAutoscaler}
+	}
+	return info
+}
+
+func projectVM(vm compute.VirtualMachine) scaleSetVMInfo {
+	info := scaleSetVMInfo{id: to.String(vm.ID), powerState: vmPowerStateRunning}
+	props := vm.VirtualMachineProperties
+	if props == nil {
+		return info
+	}
+	info.provisioningState = props.ProvisioningState
+	if props.InstanceView != nil && props.InstanceView.Statuses != nil {
+		info.powerState = vmPowerStateFromStatuses(*props.InstanceView.Statuses)
+		info.provisioningErrorCode = vmProvisioningErrorCodeFromStatuses(*props.InstanceView.Statuses)
+	}
+	return info
+}
+
+// listScaleSetVMs returns the projections of the VMs of the uniform scale set. The VMs are
+// listed page by page when the pager is available, so that at most one page of full VMs
+// is held in memory.
+func (scaleSet *ScaleSet) listScaleSetVMs() ([]scaleSetVMInfo, *retry.Error) {
+	pager := scaleSet.manager.getAzClient().virtualMachineScaleSetVMsPager
+	if pager == nil {
+		vmList, rerr := scaleSet.GetScaleSetVms()
+		if rerr != nil {
+			return nil, rerr
+		}
+		vms := make([]scaleSetVMInfo, 0, len(vmList))
+		for _, vm := range vmList {
+			vms = append(vms, projectScaleSetVM(vm))
+		}
+		return vms, nil
+	}
+
+	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
+	defer cancel()
+
+	vms := make([]scaleSetVMInfo, 0, len(scaleSet.instanceCache))
+	rerr := pager.ListPages(ctx, scaleSet.manager.getConfig().ResourceGroup, scaleSet.Name, "instanceView", func(page []compute.VirtualMachineScaleSetVM) {
+		for _, vm := range page {
+			vms = append(vms, projectScaleSetVM(vm))
+		}
+	})
+	if rerr != nil {
+		klog.Errorf("VirtualMachineScaleSetVMsPager.ListPages failed for %s: %v", scaleSet.Name, rerr)
+		return nil, rerr
+	}
+	klog.V(4).Infof("listScaleSetVMs: scaleSet.Name: %s, listed %d VMs", scaleSet.Name, len(vms))
+	return vms, nil
+}
+
 // Note that the GetScaleSetVms() results is not used directly because for the List endpoint,
 // their resource ID format is not consistent with Get endpoint
-func buildInstanceCache(vmList interface{}) []cloudprovider.Instance {
+func buildInstanceCache(vms []scaleSetVMInfo) []cloudprovider.Instance {
 	instances := []cloudprovider.Instance{}
+	for _, vm := range vms {
+		addInstanceToCache(&instances, vm.id, vm.provisioningState, vm.powerState, vm.provisioningErrorCode)
+	}
+	return instances
+}
 
-	switch vms := vmList.(type) {
-	case []compute.VirtualMachineScaleSetVM:
-		for _, vm := range vms {
-			powerState := vmPowerStateRunning
-			if vm.InstanceView != nil && vm.InstanceView.Statuses != nil {
-				powerState = vmPowerStateFromStatuses(*vm.InstanceView.Statuses)
-			}
-			addInstanceToCache(&instances, vm.ID, vm.ProvisioningState, powerState)
+// buildInstanceProtection returns the protection of the instances protected from scale-in
+// or from all scale set actions, by their provider IDs.
+func buildInstanceProtection(vms []scaleSetVMInfo) map[string]instanceProtection {
+	result := map[string]instanceProtection{}
+	for _, vm := range vms {
+		if len(vm.id) == 0 || vm.protection == nil {
+			continue
+		}
+		resourceID, err := convertResourceGroupNameToLower(vm.id)
+		if err != nil {
+			klog.Warningf("buildInstanceProtection.convertResourceGroupNameToLower failed with error: %v", err)
+			continue
+		}
+		result["azure://"+resourceID] = *vm.protection
+	}
+	return result
+}
+
+// failedProvisioningInstances returns the provider IDs of the instances in the failed provisioning state.
+func failedProvisioningInstances(vms []scaleSetVMInfo) map[string]bool {
+	result := map[string]bool{}
+	for _, vm := range vms {
+		if len(vm.id) == 0 || vm.provisioningState == nil || *vm.provisioningState != provisioningStateFailed {
+			continue
+		}
+		resourceID, err := convertResourceGroupNameToLower(vm.id)
+		if err != nil {
+			klog.Warningf("failedProvisioningInstances.convertResourceGroupNameToLower failed with error: %v", err)
+			continue
+		}
+		result["azure://"+resourceID] = true
+	}
+	return result
+}
+
+// updateGhostInstances tracks the instances which have been in the failed provisioning state
+// since they were first seen, i.e. which never became nodes. Instances seen in another state
+// before failing aren't tracked, as they may have registered. Must be called with instanceMutex held.
+func (scaleSet *ScaleSet) updateGhostInstances(previousInstances []cloudprovider.Instance, failedInstances map[string]bool, now time.Time) {
+	known := map[string]bool{}
+	for _, instance := range previousInstances {
+		known[instance.Id] = true
+	}
+	ghostInstances := map[string]ghostInstance{}
+	for id := range failedInstances {
+		if ghost, found := scaleSet.ghostInstances[id]; found {
+			ghost.refreshes++
+			ghostInstances[id] = ghost
+		} else if !known[id] {
+			ghostInstances[id] = ghostInstance{since: now, refreshes: 1}
+		}
+	}
+	scaleSet.ghostInstances = ghostInstances
+}
+
+// reportGhostInstances reports the instances which failed provisioning and didn't become nodes
+// within the ghost instance timeout as failed to be created, so that they are deleted like the
+// other instances which failed to be created, through DeleteNodes, unless a node registered for
+// them. Instances are only reported once they were seen failed over several refreshes, as right
+// after a restart all the failed instances look like they never became nodes.
+// Must be called with instanceMutex held.
+func (scaleSet *ScaleSet) reportGhostInstances(now time.Time) {
+	if !scaleSet.manager.getConfig().DeleteGhostInstances {
+		return
+	}
+	timeout := time.Duration(scaleSet.manager.getConfig().GhostInstanceTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = ghostInstanceTimeoutDefault * time.Second
+	}
+	for i, instance := range scaleSet.instanceCache {
+		ghost, found := scaleSet.ghostInstances[instance.Id]
+		if !found || ghost.refreshes < ghostInstanceMinRefreshes || !ghost.since.Add(timeout).Before(now) {
+			continue
+		}
+		klog.V(4).Infof("Instance %s of %s failed provisioning and didn't become a node within %v", instance.Id, scaleSet.Name, timeout)
+		scaleSet.instanceCache[i].Status = &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OtherErrorClass,
+				ErrorCode:    "ghost-instance",
+				ErrorMessage: fmt.Sprintf("Azure instance failed provisioning and didn't become a node within %v", timeout),
+			},
+		}
+	}
+}
+
+func (scaleSet *ScaleSet) getInstanceProtection(providerID string) (instanceProtection, bool) {
+	scaleSet.instanceMutex.Lock()
+	defer scaleSet.instanceMutex.Unlock()
+	protection, found := scaleSet.instanceProtection[providerID]
+	return protection, found
+}
+
+// clearProtection clears the protection policy of the given instance, so that it can be deleted.
+// VMSS VMs can only be updated with PUT requests, which replace the whole VM, so the current
+// VM is read and sent back with only its protection policy changed.
+func (scaleSet *ScaleSet) clearProtection(instance *azureRef) error {
+	instanceID, err := getLastSegment(instance.Name)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
+	defer cancel()
+
+	klog.V(3).Infof("Clearing protection of instance %s set by the autoscaler", instance.Name)
+	vmsClient := scaleSet.manager.getAzClient().virtualMachineScaleSetVMsClient
+	resourceGroup := scaleSet.manager.getConfig().ResourceGroup
+	vm, rerr := vmsClient.Get(ctx, resourceGroup, scaleSet.Name, instanceID, "")
+	if rerr != nil && rerr.HTTPStatusCode == http.StatusNotFound {
+		klog.Warningf("Clearing protection of instance %s returned not found, it doesn't exist anymore", instance.Name)
+		scaleSet.markInstanceNotFound(instance.Name)
+		return errInstanceNotFound
+	}
+	if rerr == nil {
+		if vm.VirtualMachineScaleSetVMProperties == nil {
+			vm.VirtualMachineScaleSetVMProperties = &compute.VirtualMachineScaleSetVMProperties{}
+		}
+		vm.ProtectionPolicy = &compute.VirtualMachineScaleSetVMProtectionPolicy{
+			ProtectFromScaleIn:         to.BoolPtr(false),
+			ProtectFromScaleSetActions: to.BoolPtr(false),
 		}
-	case []compute.VirtualMachine:
-		for _, vm := range vms {
-			powerState := vmPowerStateRunning
-			if vm.InstanceView != nil && vm.InstanceView.Statuses != nil {
-				powerState = vmPowerStateFromStatuses(*vm.InstanceView.Statuses)
+		// The instance view is read-only and may be large, it isn't sent back.
+		vm.InstanceView = nil
+		_, rerr = vmsClient.Update(ctx, resourceGroup, scaleSet.Name, instanceID, vm, "cluster-autoscaler")
+	}
+	if rerr != nil {
+		if rerr.HTTPStatusCode == http.StatusNotFound {
+			if notFound, err := scaleSet.confirmInstanceNotFound(instance); err == nil && notFound {
+				return errInstanceNotFound
 			}
-			addInstanceToCache(&instances, vm.ID, vm.ProvisioningState, powerState)
 		}
+		klog.Errorf("Clearing protection of instance %s failed: %v", instance.Name, rerr)
+		return rerr.Error()
 	}
 
-	return instances
+	scaleSet.instanceMutex.Lock()
+	delete(scaleSet.instanceProtection, instance.Name)
+	scaleSet.instanceMutex.Unlock()
+	return nil
+}
+
+// confirmInstanceNotFound checks with a GET whether an instance an operation returned not found for
+// doesn't exist anymore, as the error may be about another instance of the request. Confirmed
+// instances are removed from the cache with markInstanceNotFound.
+func (scaleSet *ScaleSet) confirmInstanceNotFound(instance *azureRef) (bool, error) {
+	instanceID, err := getLastSegment(instance.Name)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := getContextWithTimeout(vmssContextTimeout)
+	defer cancel()
+
+	_, rerr := scaleSet.manager.getAzClient().virtualMachineScaleSetVMsClient.Get(ctx, scaleSet.manager.getConfig().ResourceGroup, scaleSet.Name, instanceID, "")
+	if rerr == nil {
+		return false, nil
+	}
+	if rerr.HTTPStatusCode != http.StatusNotFound {
+		klog.Errorf("Checking whether instance %s still exists failed: %v", instance.Name, rerr)
+		return false, rerr.Error()
+	}
+	klog.Warningf("Instance %s doesn't exist anymore", instance.Name)
+	scaleSet.markInstanceNotFound(instance.Name)
+	return true, nil
 }
 
-func addInstanceToCache(instances *[]cloudprovider.Instance, id *string, provisioningState *string, powerState string) {
+// markInstanceNotFound removes an instance an operation was denied on because it doesn't exist
+// anymore from the instance cache, without waiting for the cache to expire, and reports it as
+// deleted by isInstanceNotFound until the next refresh of the cache confirms it.
+func (scaleSet *ScaleSet) markInstanceNotFound(providerID string) {
+	scaleSet.instanceMutex.Lock()
+	instances := make([]cloudprovider.Instance, 0, len(scaleSet.instanceCache))
+	for _, instance := range scaleSet.instanceCache {
+		if !strings.EqualFold(instance.Id, providerID) {
+			instances = append(instances, instance)
+		}
+	}
+	scaleSet.instanceCache = instances
+	delete(scaleSet.instanceProtection, providerID)
+	if scaleSet.notFoundInstances == nil {
+		scaleSet.notFoundInstances = map[string]bool{}
+	}
+	scaleSet.notFoundInstances[strings.ToLower(providerID)] = true
+	scaleSet.instanceMutex.Unlock()
+
+	// The capacity of the scale set no longer counts the instance either.
+	scaleSet.invalidateLastSizeRefreshWithLock()
+	klog.V(2).Infof("Instance %s of %s doesn't exist anymore, removed it from the cache", providerID, scaleSet.Name)
+}
+
+// isInstanceNotFound returns true if an operation on the instance was denied because it doesn't exist anymore.
+func (scaleSet *ScaleSet) isInstanceNotFound(providerID string) bool {
+	scaleSet.instanceMutex.Lock()
+	defer scaleSet.instanceMutex.Unlock()
+	return scaleSet.notFoundInstances[strings.ToLower(providerID)]
+}
+
+// forgetNotFoundInstances forgets the instances which are listed again in the instance cache,
+// e.g. because the instance id was reused. Must be called with instanceMutex held.
+func (scaleSet *ScaleSet) forgetNotFoundInstances() {
+	for _, instance := range scaleSet.instanceCache {
+		delete(scaleSet.notFoundInstances, strings.ToLower(instance.Id))
+	}
+}
+
+func addInstanceToCache(instances *[]cloudprovider.Instance, id string, provisioningState *string, powerState string, provisioningErrorCode string) {
 	// The resource ID is empty string, which indicates the instance may be in deleting state.
-	if len(*id) == 0 {
+	if len(id) == 0 {
 		return
 	}
 
-	resourceID, err := convertResourceGroupNameToLower(*id)
+	resourceID, err := convertResourceGroupNameToLower(id)
 	if err != nil {
 		// This shouldn't happen. Log a warning message for tracking.
 		klog.Warningf("buildInstanceCache.convertResourceGroupNameToLower failed with error: %v", err)
@@ -680,7 +1186,7 @@ func addInstanceToCache(instances *[]cloudprovider.Instance, id *string, provisi
 
 	*instances = append(*instances, cloudprovider.Instance{
 		Id:     "azure://" + resourceID,
-		Status: instanceStatusFromProvisioningStateAndPowerState(resourceID, provisioningState, powerState),
+		Status: instanceStatusFromProvisioningStateAndPowerState(resourceID, provisioningState, powerState, provisioningErrorCode),
 	})
 }
 
@@ -707,8 +1213,9 @@ func (scaleSet *ScaleSet) setInstanceStatusByProviderID(providerID string, statu
 	scaleSet.lastInstanceRefresh = time.Now()
 }
 
-// instanceStatusFromProvisioningStateAndPowerState converts the VM provisioning state and power state to cloudprovider.InstanceStatus
-func instanceStatusFromProvisioningStateAndPowerState(resourceId string, provisioningState *string, powerState string) *cloudprovider.InstanceStatus {
+// instanceStatusFromProvisioningStateAndPowerState converts the VM provisioning state and power state to cloudprovider.InstanceStatus.
+// Failed provisioning is classified by the code of the error which failed it.
+func instanceStatusFromProvisioningStateAndPowerState(resourceId string, provisioningState *string, powerState string, provisioningErrorCode string) *cloudprovider.InstanceStatus {
 	if provisioningState == nil {
 		return nil
 	}
@@ -729,10 +1236,20 @@ func instanceStatusFromProvisioningStateAndPowerState(resourceId string, provisi
 		if !isRunningVmPowerState(powerState) {
 			klog.V(4).Infof("VM %s reports failed provisioning state with non-running power state: %s", resourceId, powerState)
 			status.State = cloudprovider.InstanceCreating
+			errorKind := provisioningErrorKind(provisioningErrorCode)
+			errorClass := cloudprovider.OutOfResourcesErrorClass
+			if errorKind != cloudprovider.UnknownErrorKind {
+				errorClass = errorKind.ErrorClass()
+			}
+			errorMessage := "Azure failed to provision a node for this node group"
+			if provisioningErrorCode != "" {
+				errorMessage = fmt.Sprintf("%s: %s", errorMessage, provisioningErrorCode)
+			}
 			status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
-				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+				ErrorClass:   errorClass,
+				ErrorKind:    errorKind,
 				ErrorCode:    "provisioning-state-failed",
-				ErrorMessage: "Azure failed to provision a node for this node group",
+				ErrorMessage: errorMessage,
 			}
 		} else {
 			klog.V(5).Infof("VM %s reports a failed provisioning state but is running (%s)", resourceId, powerState)
@@ -747,8 +1264,8 @@ func instanceStatusFromProvisioningStateAndPowerState(resourceId string, provisi
 
 func (scaleSet *ScaleSet) invalidateInstanceCache() {
 	scaleSet.instanceMutex.Lock()
-	// Set the instanceCache as outdated.
-	scaleSet.lastInstanceRefresh = time.Now().Add(-1 * scaleSet.instancesRefreshPeriod)
+	// Set the instanceCache as outdated, whichever TTL applies to the VMSS.
+	scaleSet.lastInstanceRefresh = time.Time{}
 	scaleSet.instanceMutex.Unlock()
 }
 
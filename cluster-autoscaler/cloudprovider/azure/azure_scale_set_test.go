@@ -17,11 +17,15 @@ limitations under the License.
 package azure
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +34,7 @@ import (
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmclient/mockvmclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient/mockvmssclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssvmclient/mockvmssvmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
 )
 
 const (
@@ -42,10 +47,11 @@ func newTestScaleSet(manager *AzureManager, name string) *ScaleSet {
 		azureRef: azureRef{
 			Name: name,
 		},
-		manager:           manager,
-		minSize:           1,
-		maxSize:           5,
-		enableForceDelete: manager.config.EnableForceDelete,
+		manager:                 manager,
+		minSize:                 1,
+		maxSize:                 5,
+		enableForceDelete:       manager.config.EnableForceDelete,
+		clearInstanceProtection: manager.config.ClearInstanceProtection,
 	}
 }
 
@@ -229,13 +235,9 @@ func TestIncreaseSize(t *testing.T) {
 
 		mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
 		mockVMSSClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return(expectedScaleSets, nil).AnyTimes()
-		mockVMSSClient.EXPECT().CreateOrUpdateAsync(gomock.Any(), provider.azureManager.config.ResourceGroup, testASG, gomock.Any()).Return(nil, nil)
-		// This should be Anytimes() because the parent function of this call - updateVMSSCapacity() is a goroutine
-		// and this test doesn't wait on goroutine, hence, it is difficult to write exact expected number (which is 3 here)
-		// before we return from this this.
-		// This is a future TODO: sync.WaitGroup should be used in actual code and make code easily testable
-		mockVMSSClient.EXPECT().WaitForCreateOrUpdateResult(gomock.Any(), gomock.Any(), provider.azureManager.config.ResourceGroup).Return(&http.Response{StatusCode: http.StatusOK}, nil).AnyTimes()
 		provider.azureManager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+		updater := &fakeScaleSetsUpdater{}
+		provider.azureManager.azClient.virtualMachineScaleSetsUpdater = updater
 		mockVMClient := mockvmclient.NewMockInterface(ctrl)
 		mockVMClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return([]compute.VirtualMachine{}, nil).AnyTimes()
 		provider.azureManager.azClient.virtualMachinesClient = mockVMClient
@@ -276,6 +278,13 @@ func TestIncreaseSize(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 5, targetSize)
 
+		// Only the capacity is updated, the tags and properties of the scale set are kept.
+		update, found := updater.lastUpdate(testASG)
+		assert.True(t, found)
+		assert.Equal(t, int64(5), *update.Sku.Capacity)
+		assert.Nil(t, update.Tags)
+		assert.Nil(t, update.VirtualMachineScaleSetUpdateProperties)
+
 		// Testing Edge Zone scenario. Scale from 3 to 5.
 		registeredForEdgeZone := provider.azureManager.RegisterNodeGroup(
 			newTestScaleSet(provider.azureManager, "edgezone-vmss"))
@@ -286,14 +295,15 @@ func TestIncreaseSize(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 3, targetSizeForEdgeZone)
 
-		mockVMSSClient.EXPECT().CreateOrUpdateAsync(gomock.Any(), provider.azureManager.config.ResourceGroup,
-			"edgezone-vmss", gomock.Any()).Return(nil, nil)
 		err = provider.NodeGroups()[1].IncreaseSize(2)
 		assert.NoError(t, err)
 
 		targetSizeForEdgeZone, err = provider.NodeGroups()[1].TargetSize()
 		assert.NoError(t, err)
 		assert.Equal(t, 5, targetSizeForEdgeZone)
+		update, found = updater.lastUpdate("edgezone-vmss")
+		assert.True(t, found)
+		assert.Equal(t, int64(5), *update.Sku.Capacity)
 
 		// Testing Edge Zone scenario scaleFromZero case. Scale from 0 to 2.
 		registeredForEdgeZoneMinZero := provider.azureManager.RegisterNodeGroup(
@@ -306,8 +316,6 @@ func TestIncreaseSize(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 0, targetSizeForEdgeZoneMinZero)
 
-		mockVMSSClient.EXPECT().CreateOrUpdateAsync(gomock.Any(), provider.azureManager.config.ResourceGroup,
-			"edgezone-minzero-vmss", gomock.Any()).Return(nil, nil)
 		err = provider.NodeGroups()[2].IncreaseSize(2)
 		assert.NoError(t, err)
 
@@ -315,7 +323,65 @@ func TestIncreaseSize(t *testing.T) {
 		targetSizeForEdgeZoneMinZero, err = provider.NodeGroups()[2].TargetSize()
 		assert.NoError(t, err)
 		assert.Equal(t, 2, targetSizeForEdgeZoneMinZero)
+		update, found = updater.lastUpdate("edgezone-minzero-vmss")
+		assert.True(t, found)
+		assert.Equal(t, int64(2), *update.Sku.Capacity)
+	}
+}
+
+func TestTargetSizeWithInFlightCapacityUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := newTestProvider(t)
+	// Return a fresh list on every call, so that updates of the cached capacity don't leak into ARM responses.
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).DoAndReturn(
+		func(_ interface{}, _ string) ([]compute.VirtualMachineScaleSet, error) {
+			return newTestVMSSList(3, testASG, testLocation, compute.Uniform), nil
+		}).AnyTimes()
+	provider.azureManager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	release := make(chan struct{})
+	provider.azureManager.azClient.virtualMachineScaleSetsUpdater = &fakeScaleSetsUpdater{
+		wait: func() (*http.Response, error) {
+			<-release
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		},
 	}
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return([]compute.VirtualMachine{}, nil).AnyTimes()
+	provider.azureManager.azClient.virtualMachinesClient = mockVMClient
+	mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
+	mockVMSSVMClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup, testASG, gomock.Any()).Return(newTestVMSSVMList(3), nil).AnyTimes()
+	provider.azureManager.azClient.virtualMachineScaleSetVMsClient = mockVMSSVMClient
+
+	err := provider.azureManager.forceRefresh()
+	assert.NoError(t, err)
+	scaleSet := newTestScaleSet(provider.azureManager, testASG)
+	assert.True(t, provider.azureManager.RegisterNodeGroup(scaleSet))
+
+	err = scaleSet.IncreaseSize(2)
+	assert.NoError(t, err)
+
+	// ARM still reports the old capacity while the update is in flight.
+	err = provider.azureManager.forceRefresh()
+	assert.NoError(t, err)
+	targetSize, err := scaleSet.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, targetSize)
+
+	// Once the update finished, the capacity reported by ARM is used again.
+	close(release)
+	assert.Eventually(t, func() bool {
+		scaleSet.sizeMutex.Lock()
+		defer scaleSet.sizeMutex.Unlock()
+		return scaleSet.inFlightCapacity == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	err = provider.azureManager.forceRefresh()
+	assert.NoError(t, err)
+	targetSize, err = scaleSet.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, targetSize)
 }
 
 func TestIncreaseSizeOnVMProvisioningFailed(t *testing.T) {
@@ -323,8 +389,19 @@ func TestIncreaseSizeOnVMProvisioningFailed(t *testing.T) {
 		expectInstanceRunning bool
 		isMissingInstanceView bool
 		statuses              []compute.InstanceViewStatus
+		expectedErrorClass    cloudprovider.InstanceErrorClass
+		expectedErrorKind     cloudprovider.ErrorKind
 	}{
 		"out of resources when no power state exists": {},
+		"capacity error when allocation failed": {
+			statuses:          []compute.InstanceViewStatus{{Code: to.StringPtr("ProvisioningState/failed/AllocationFailed")}},
+			expectedErrorKind: cloudprovider.CapacityErrorKind,
+		},
+		"permission error when authorization failed": {
+			statuses:           []compute.InstanceViewStatus{{Code: to.StringPtr("ProvisioningState/failed/AuthorizationFailed")}},
+			expectedErrorClass: cloudprovider.OtherErrorClass,
+			expectedErrorKind:  cloudprovider.PermissionErrorKind,
+		},
 		"out of resources when VM is stopped": {
 			statuses: []compute.InstanceViewStatus{{Code: to.StringPtr(vmPowerStateStopped)}},
 		},
@@ -358,8 +435,6 @@ func TestIncreaseSizeOnVMProvisioningFailed(t *testing.T) {
 
 			mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
 			mockVMSSClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(expectedScaleSets, nil)
-			mockVMSSClient.EXPECT().CreateOrUpdateAsync(gomock.Any(), manager.config.ResourceGroup, vmssName, gomock.Any()).Return(nil, nil)
-			mockVMSSClient.EXPECT().WaitForCreateOrUpdateResult(gomock.Any(), gomock.Any(), manager.config.ResourceGroup).Return(&http.Response{StatusCode: http.StatusOK}, nil).AnyTimes()
 			manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
 			mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
 			mockVMSSVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup, "vmss-failed-upscale", gomock.Any()).Return(expectedVMSSVMs, nil).AnyTimes()
@@ -389,8 +464,13 @@ func TestIncreaseSizeOnVMProvisioningFailed(t *testing.T) {
 			if testCase.expectInstanceRunning {
 				assert.Equal(t, cloudprovider.InstanceRunning, nodes[2].Status.State)
 			} else {
+				expectedErrorClass := testCase.expectedErrorClass
+				if expectedErrorClass == 0 {
+					expectedErrorClass = cloudprovider.OutOfResourcesErrorClass
+				}
 				assert.Equal(t, cloudprovider.InstanceCreating, nodes[2].Status.State)
-				assert.Equal(t, cloudprovider.OutOfResourcesErrorClass, nodes[2].Status.ErrorInfo.ErrorClass)
+				assert.Equal(t, expectedErrorClass, nodes[2].Status.ErrorInfo.ErrorClass)
+				assert.Equal(t, testCase.expectedErrorKind, nodes[2].Status.ErrorInfo.ErrorKind)
 			}
 		})
 	}
@@ -420,8 +500,6 @@ func TestIncreaseSizeOnVMSSUpdating(t *testing.T) {
 
 	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
 	mockVMSSClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(expectedScaleSets, nil)
-	mockVMSSClient.EXPECT().CreateOrUpdateAsync(gomock.Any(), manager.config.ResourceGroup, vmssName, gomock.Any()).Return(nil, nil)
-	mockVMSSClient.EXPECT().WaitForCreateOrUpdateResult(gomock.Any(), gomock.Any(), manager.config.ResourceGroup).Return(&http.Response{StatusCode: http.StatusOK}, nil).AnyTimes()
 	manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
 	mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
 	mockVMSSVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup, "vmss-updating", gomock.Any()).Return(expectedVMSSVMs, nil).AnyTimes()
@@ -441,6 +519,41 @@ func TestIncreaseSizeOnVMSSUpdating(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestScaleSetUpdateError(t *testing.T) {
+	retryAfter := time.Now().Add(time.Hour)
+	quotaErr := &retry.Error{
+		RawError:   fmt.Errorf("%s", `{"error":{"code":"OperationNotAllowed","message":"Operation could not be completed as it results in exceeding approved standardDSv3Family Cores quota. Submit a request for Quota increase."}}`),
+		RetryAfter: retryAfter,
+	}
+	err := scaleSetUpdateError(quotaErr, "eastus")
+	info := cloudprovider.QuotaErrorInfoFromError(err)
+	assert.NotNil(t, info)
+	assert.Equal(t, "standardDSv3Family Cores", info.Quota)
+	assert.Equal(t, "eastus", info.Scope)
+	assert.Equal(t, retryAfter, info.RetryAfter)
+
+	otherErr := &retry.Error{
+		RawError: fmt.Errorf("%s", `{"error":{"code":"OperationNotAllowed","message":"Another operation is in progress"}}`),
+	}
+	err = scaleSetUpdateError(otherErr, "eastus")
+	assert.Error(t, err)
+	assert.Nil(t, cloudprovider.QuotaErrorInfoFromError(err))
+	assert.Equal(t, cloudprovider.UnknownErrorKind, cloudprovider.ErrorKindFromError(err))
+
+	capacityErr := &retry.Error{
+		RawError: fmt.Errorf("%s", `{"error":{"code":"AllocationFailed","message":"Allocation failed. We do not have sufficient capacity for the requested VM size in this region."}}`),
+	}
+	err = scaleSetUpdateError(capacityErr, "eastus")
+	assert.Equal(t, cloudprovider.CapacityErrorKind, cloudprovider.ErrorKindFromError(err))
+
+	forbiddenErr := &retry.Error{
+		RawError:       fmt.Errorf("%s", `{"error":{"code":"AuthorizationFailed","message":"The client does not have authorization to perform action."}}`),
+		HTTPStatusCode: http.StatusForbidden,
+	}
+	err = scaleSetUpdateError(forbiddenErr, "eastus")
+	assert.Equal(t, cloudprovider.PermissionErrorKind, cloudprovider.ErrorKindFromError(err))
+}
+
 func TestBelongs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -629,6 +742,248 @@ func TestDeleteNodes(t *testing.T) {
 	}
 }
 
+func TestDeleteNodesProtectedInstances(t *testing.T) {
+	cases := []struct {
+		name                    string
+		setByAutoscaler         bool
+		clearInstanceProtection bool
+		expectDeleted           bool
+	}{
+		{
+			name: "protected instance isn't deleted",
+		},
+		{
+			name:            "protection set by autoscaler isn't cleared by default",
+			setByAutoscaler: true,
+		},
+		{
+			name:                    "protection not set by autoscaler isn't cleared",
+			clearInstanceProtection: true,
+		},
+		{
+			name:                    "protection set by autoscaler is cleared",
+			setByAutoscaler:         true,
+			clearInstanceProtection: true,
+			expectDeleted:           true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			expectedVMSSVMs := newTestVMSSVMList(3)
+			expectedVMSSVMs[0].ProtectionPolicy = &compute.VirtualMachineScaleSetVMProtectionPolicy{ProtectFromScaleIn: to.BoolPtr(true)}
+			if tc.setByAutoscaler {
+				expectedVMSSVMs[0].Tags = map[string]*string{instanceProtectionTag: to.StringPtr("true")}
+			}
+
+			manager := newTestAzureManager(t)
+			manager.config.ClearInstanceProtection = tc.clearInstanceProtection
+			mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+			mockVMSSClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMSSList(3, testASG, testLocation, compute.Uniform), nil).AnyTimes()
+			mockVMSSClient.EXPECT().WaitForDeleteInstancesResult(gomock.Any(), gomock.Any(), manager.config.ResourceGroup).Return(&http.Response{StatusCode: http.StatusOK}, nil).AnyTimes()
+			manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+			mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
+			mockVMSSVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup, testASG, gomock.Any()).Return(expectedVMSSVMs, nil).AnyTimes()
+			manager.azClient.virtualMachineScaleSetVMsClient = mockVMSSVMClient
+			mockVMClient := mockvmclient.NewMockInterface(ctrl)
+			mockVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMList(3), nil).AnyTimes()
+			manager.azClient.virtualMachinesClient = mockVMClient
+			// The unprotected instance is deleted in any case.
+			deletedIDs := []string{"1"}
+			if tc.expectDeleted {
+				deletedIDs = []string{"0", "1"}
+				mockVMSSVMClient.EXPECT().Get(gomock.Any(), manager.config.ResourceGroup, testASG, "0", gomock.Any()).Return(expectedVMSSVMs[0], nil)
+				mockVMSSVMClient.EXPECT().Update(gomock.Any(), manager.config.ResourceGroup, testASG, "0", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _, _, _ string, vm compute.VirtualMachineScaleSetVM, _ string) (*compute.VirtualMachineScaleSetVM, *retry.Error) {
+						// Only the protection policy of the current VM is changed.
+						assert.Equal(t, expectedVMSSVMs[0].Tags, vm.Tags)
+						assert.Equal(t, expectedVMSSVMs[0].OsProfile, vm.OsProfile)
+						assert.False(t, *vm.ProtectionPolicy.ProtectFromScaleIn)
+						return nil, nil
+					})
+			}
+			mockVMSSClient.EXPECT().DeleteInstancesAsync(gomock.Any(), manager.config.ResourceGroup, testASG,
+				compute.VirtualMachineScaleSetVMInstanceRequiredIDs{InstanceIds: &deletedIDs}, manager.config.EnableForceDelete).Return(nil, nil)
+
+			manager.RegisterNodeGroup(newTestScaleSet(manager, testASG))
+			manager.explicitlyConfigured[testASG] = true
+			assert.NoError(t, manager.forceRefresh())
+			scaleSet := manager.getNodeGroups()[0].(*ScaleSet)
+			_, err := scaleSet.Nodes()
+			assert.NoError(t, err)
+
+			protected, unprotected := newApiNode(compute.Uniform, 0), newApiNode(compute.Uniform, 1)
+			protected.Name, unprotected.Name = "protected", "unprotected"
+			err = scaleSet.DeleteNodes([]*apiv1.Node{protected, unprotected})
+			if tc.expectDeleted {
+				assert.NoError(t, err)
+			} else {
+				var notDeletedErr *cloudprovider.NodesNotDeletedError
+				assert.ErrorAs(t, err, &notDeletedErr)
+				assert.Len(t, notDeletedErr.NodeErrors, 1)
+				assert.Contains(t, notDeletedErr.NodeErrors, "protected")
+			}
+		})
+	}
+}
+
+func TestDeleteNodesNotFoundInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedVMSSVMs := newTestVMSSVMList(3)
+	expectedVMSSVMs[0].ProtectionPolicy = &compute.VirtualMachineScaleSetVMProtectionPolicy{ProtectFromScaleIn: to.BoolPtr(true)}
+	expectedVMSSVMs[0].Tags = map[string]*string{instanceProtectionTag: to.StringPtr("true")}
+
+	provider := newTestProvider(t)
+	manager := provider.azureManager
+	manager.config.ClearInstanceProtection = true
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMSSList(3, testASG, testLocation, compute.Uniform), nil).AnyTimes()
+	mockVMSSClient.EXPECT().WaitForDeleteInstancesResult(gomock.Any(), gomock.Any(), manager.config.ResourceGroup).Return(&http.Response{StatusCode: http.StatusOK}, nil).AnyTimes()
+	manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
+	mockVMSSVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup, testASG, gomock.Any()).Return(expectedVMSSVMs, nil).AnyTimes()
+	manager.azClient.virtualMachineScaleSetVMsClient = mockVMSSVMClient
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMList(3), nil).AnyTimes()
+	manager.azClient.virtualMachinesClient = mockVMClient
+
+	// The protected instance doesn't exist anymore, only the other one is deleted.
+	mockVMSSVMClient.EXPECT().Get(gomock.Any(), manager.config.ResourceGroup, testASG, "0", gomock.Any()).Return(compute.VirtualMachineScaleSetVM{}, &retry.Error{HTTPStatusCode: http.StatusNotFound})
+	mockVMSSClient.EXPECT().DeleteInstancesAsync(gomock.Any(), manager.config.ResourceGroup, testASG,
+		compute.VirtualMachineScaleSetVMInstanceRequiredIDs{InstanceIds: &[]string{"1"}}, manager.config.EnableForceDelete).Return(nil, nil)
+
+	manager.RegisterNodeGroup(newTestScaleSet(manager, testASG))
+	manager.explicitlyConfigured[testASG] = true
+	assert.NoError(t, manager.forceRefresh())
+	scaleSet := manager.getNodeGroups()[0].(*ScaleSet)
+	_, err := scaleSet.Nodes()
+	assert.NoError(t, err)
+
+	notFound, deleted := newApiNode(compute.Uniform, 0), newApiNode(compute.Uniform, 1)
+	assert.NoError(t, scaleSet.DeleteNodes([]*apiv1.Node{notFound, deleted}))
+
+	_, found := scaleSet.getInstanceByProviderID(notFound.Spec.ProviderID)
+	assert.False(t, found)
+	exists, err := provider.HasInstance(notFound)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	_, err = provider.HasInstance(deleted)
+	assert.ErrorIs(t, err, cloudprovider.ErrNotImplemented)
+}
+
+func TestDeleteNodesNotFoundResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := newTestProvider(t)
+	manager := provider.azureManager
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMSSList(3, testASG, testLocation, compute.Uniform), nil).AnyTimes()
+	manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
+	mockVMSSVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup, testASG, gomock.Any()).Return(newTestVMSSVMList(3), nil).AnyTimes()
+	manager.azClient.virtualMachineScaleSetVMsClient = mockVMSSVMClient
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMList(3), nil).AnyTimes()
+	manager.azClient.virtualMachinesClient = mockVMClient
+
+	// The deletion fails because one of the instances doesn't exist, the other one still does.
+	mockVMSSClient.EXPECT().DeleteInstancesAsync(gomock.Any(), manager.config.ResourceGroup, testASG,
+		compute.VirtualMachineScaleSetVMInstanceRequiredIDs{InstanceIds: &[]string{"0", "1"}}, manager.config.EnableForceDelete).Return(nil, &retry.Error{HTTPStatusCode: http.StatusNotFound})
+	mockVMSSVMClient.EXPECT().Get(gomock.Any(), manager.config.ResourceGroup, testASG, "0", gomock.Any()).Return(compute.VirtualMachineScaleSetVM{}, &retry.Error{HTTPStatusCode: http.StatusNotFound})
+	mockVMSSVMClient.EXPECT().Get(gomock.Any(), manager.config.ResourceGroup, testASG, "1", gomock.Any()).Return(compute.VirtualMachineScaleSetVM{}, nil)
+
+	manager.RegisterNodeGroup(newTestScaleSet(manager, testASG))
+	manager.explicitlyConfigured[testASG] = true
+	assert.NoError(t, manager.forceRefresh())
+	scaleSet := manager.getNodeGroups()[0].(*ScaleSet)
+	_, err := scaleSet.Nodes()
+	assert.NoError(t, err)
+
+	notFound, existing := newApiNode(compute.Uniform, 0), newApiNode(compute.Uniform, 1)
+	notFound.Name, existing.Name = "not-found", "existing"
+	err = scaleSet.DeleteNodes([]*apiv1.Node{notFound, existing})
+	var notDeletedErr *cloudprovider.NodesNotDeletedError
+	assert.ErrorAs(t, err, &notDeletedErr)
+	assert.Len(t, notDeletedErr.NodeErrors, 1)
+	assert.Contains(t, notDeletedErr.NodeErrors, "existing")
+
+	_, found := scaleSet.getInstanceByProviderID(notFound.Spec.ProviderID)
+	assert.False(t, found)
+	_, found = scaleSet.getInstanceByProviderID(existing.Spec.ProviderID)
+	assert.True(t, found)
+}
+
+func TestReportGhostInstances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedVMSSVMs := newTestVMSSVMList(3)
+	expectedVMSSVMs[0].ProvisioningState = to.StringPtr(provisioningStateFailed)
+
+	manager := newTestAzureManager(t)
+	manager.config.DeleteGhostInstances = true
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMSSList(3, testASG, testLocation, compute.Uniform), nil).AnyTimes()
+	manager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
+	mockVMSSVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup, testASG, gomock.Any()).Return(expectedVMSSVMs, nil).AnyTimes()
+	manager.azClient.virtualMachineScaleSetVMsClient = mockVMSSVMClient
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().List(gomock.Any(), manager.config.ResourceGroup).Return(newTestVMList(3), nil).AnyTimes()
+	manager.azClient.virtualMachinesClient = mockVMClient
+
+	manager.RegisterNodeGroup(newTestScaleSet(manager, testASG))
+	manager.explicitlyConfigured[testASG] = true
+	assert.NoError(t, manager.forceRefresh())
+	scaleSet := manager.getNodeGroups()[0].(*ScaleSet)
+	_, err := scaleSet.Nodes()
+	assert.NoError(t, err)
+
+	ghostID := "azure://" + fmt.Sprintf(fakeVirtualMachineScaleSetVMID, 0)
+	ghostStatus := func() *cloudprovider.InstanceStatus {
+		instance, found := scaleSet.getInstanceByProviderID(ghostID)
+		assert.True(t, found)
+		return instance.Status
+	}
+	assert.Contains(t, scaleSet.ghostInstances, ghostID)
+	since := scaleSet.ghostInstances[ghostID].since
+
+	// Ghost instances aren't reported before they were seen over several refreshes,
+	// e.g. right after a restart.
+	scaleSet.ghostInstances[ghostID] = ghostInstance{since: since, refreshes: 1}
+	scaleSet.reportGhostInstances(since.Add(2 * time.Hour))
+	assert.NotEqual(t, "ghost-instance", errorCode(ghostStatus()))
+
+	scaleSet.updateGhostInstances(scaleSet.instanceCache, map[string]bool{ghostID: true}, since.Add(time.Minute))
+	assert.Equal(t, 2, scaleSet.ghostInstances[ghostID].refreshes)
+	// Nor before the timeout.
+	scaleSet.reportGhostInstances(since.Add(time.Minute))
+	assert.NotEqual(t, "ghost-instance", errorCode(ghostStatus()))
+
+	// Then they are reported as failed to be created, to be deleted through DeleteNodes.
+	scaleSet.reportGhostInstances(since.Add(2 * time.Hour))
+	assert.Equal(t, cloudprovider.InstanceCreating, ghostStatus().State)
+	assert.Equal(t, "ghost-instance", errorCode(ghostStatus()))
+
+	// Instances seen in another state before failing may have become nodes.
+	healthyID := "azure://" + fmt.Sprintf(fakeVirtualMachineScaleSetVMID, 1)
+	scaleSet.updateGhostInstances(scaleSet.instanceCache, map[string]bool{ghostID: true, healthyID: true}, since.Add(3*time.Hour))
+	assert.Equal(t, map[string]ghostInstance{ghostID: {since: since, refreshes: 3}}, scaleSet.ghostInstances)
+}
+
+func errorCode(status *cloudprovider.InstanceStatus) string {
+	if status == nil || status.ErrorInfo == nil {
+		return ""
+	}
+	return status.ErrorInfo.ErrorCode
+}
+
 func TestDeleteNodeUnregistered(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -887,6 +1242,141 @@ func TestScaleSetNodes(t *testing.T) {
 
 }
 
+func TestScaleSetNodesCacheTTLOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedScaleSets := newTestVMSSList(3, "test-asg", "eastus", compute.Uniform)
+	provider := newTestProvider(t)
+
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return(expectedScaleSets, nil).AnyTimes()
+	provider.azureManager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return([]compute.VirtualMachine{}, nil).AnyTimes()
+	provider.azureManager.azClient.virtualMachinesClient = mockVMClient
+	// Listed once by the cache refresh and once more before the override is set.
+	mockVMSSVMClient := mockvmssvmclient.NewMockInterface(ctrl)
+	mockVMSSVMClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup, "test-asg", gomock.Any()).Return(newTestVMSSVMList(3), nil).Times(2)
+	provider.azureManager.azClient.virtualMachineScaleSetVMsClient = mockVMSSVMClient
+
+	scaleSet := newTestScaleSet(provider.azureManager, "test-asg")
+	provider.azureManager.RegisterNodeGroup(scaleSet)
+	provider.azureManager.explicitlyConfigured["test-asg"] = true
+	assert.NoError(t, provider.azureManager.Refresh())
+
+	scaleSet.setRefreshPeriods(time.Hour, 0)
+	instances, err := scaleSet.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(instances))
+
+	provider.azureManager.azureCache.autoscalingOptions[scaleSet.azureRef] = map[string]string{vmssVmsCacheTTLOption: "1h"}
+	instances, err = scaleSet.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(instances))
+}
+
+// fakeScaleSetVMsPager returns the VMs split into pages of the given size.
+type fakeScaleSetVMsPager struct {
+	vms      []compute.VirtualMachineScaleSetVM
+	pageSize int
+	pages    int
+}
+
+func (f *fakeScaleSetVMsPager) ListPages(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, expand string, handlePage func([]compute.VirtualMachineScaleSetVM)) *retry.Error {
+	for start := 0; start < len(f.vms); start += f.pageSize {
+		end := start + f.pageSize
+		if end > len(f.vms) {
+			end = len(f.vms)
+		}
+		handlePage(f.vms[start:end])
+		f.pages++
+	}
+	return nil
+}
+
+// fakeScaleSetsUpdater records the updates of scale sets, which complete once wait returns.
+type fakeScaleSetsUpdater struct {
+	mutex   sync.Mutex
+	updates map[string][]compute.VirtualMachineScaleSetUpdate
+	wait    func() (*http.Response, error)
+}
+
+func (f *fakeScaleSetsUpdater) UpdateAsync(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, parameters compute.VirtualMachineScaleSetUpdate) (*azure.Future, *retry.Error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.updates == nil {
+		f.updates = make(map[string][]compute.VirtualMachineScaleSetUpdate)
+	}
+	f.updates[virtualMachineScaleSetName] = append(f.updates[virtualMachineScaleSetName], parameters)
+	return &azure.Future{}, nil
+}
+
+func (f *fakeScaleSetsUpdater) WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error) {
+	if f.wait != nil {
+		return f.wait()
+	}
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func (f *fakeScaleSetsUpdater) lastUpdate(virtualMachineScaleSetName string) (compute.VirtualMachineScaleSetUpdate, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	updates := f.updates[virtualMachineScaleSetName]
+	if len(updates) == 0 {
+		return compute.VirtualMachineScaleSetUpdate{}, false
+	}
+	return updates[len(updates)-1], true
+}
+
+func TestScaleSetNodesPaged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := newTestProvider(t)
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	mockVMSSClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return(newTestVMSSList(5, testASG, testLocation, compute.Uniform), nil).AnyTimes()
+	provider.azureManager.azClient.virtualMachineScaleSetsClient = mockVMSSClient
+	mockVMClient := mockvmclient.NewMockInterface(ctrl)
+	mockVMClient.EXPECT().List(gomock.Any(), provider.azureManager.config.ResourceGroup).Return([]compute.VirtualMachine{}, nil).AnyTimes()
+	provider.azureManager.azClient.virtualMachinesClient = mockVMClient
+	// The full list isn't used when the pager is available.
+	provider.azureManager.azClient.virtualMachineScaleSetVMsClient = mockvmssvmclient.NewMockInterface(ctrl)
+
+	vms := newTestVMSSVMList(5)
+	vms[1].ProvisioningState = to.StringPtr(provisioningStateFailed)
+	vms[2].ProvisioningState = to.StringPtr(provisioningStateSucceeded)
+	vms[1].InstanceView = &compute.VirtualMachineScaleSetVMInstanceView{Statuses: &[]compute.InstanceViewStatus{{Code: to.StringPtr(vmPowerStateStopped)}}}
+	vms[3].ProtectionPolicy = &compute.VirtualMachineScaleSetVMProtectionPolicy{ProtectFromScaleIn: to.BoolPtr(true)}
+	vms[3].Tags = map[string]*string{instanceProtectionTag: to.StringPtr("")}
+	pager := &fakeScaleSetVMsPager{vms: vms, pageSize: 2}
+	provider.azureManager.azClient.virtualMachineScaleSetVMsPager = pager
+
+	scaleSet := newTestScaleSet(provider.azureManager, testASG)
+	provider.azureManager.RegisterNodeGroup(scaleSet)
+	provider.azureManager.explicitlyConfigured[testASG] = true
+	assert.NoError(t, provider.azureManager.Refresh())
+
+	pager.pages = 0
+	scaleSet.invalidateInstanceCache()
+	instances, err := scaleSet.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pager.pages)
+	assert.Equal(t, 5, len(instances))
+	for i, instance := range instances {
+		assert.Equal(t, "azure://"+fmt.Sprintf(fakeVirtualMachineScaleSetVMID, i), instance.Id)
+	}
+	assert.Equal(t, cloudprovider.InstanceCreating, instances[1].Status.State)
+	assert.Equal(t, cloudprovider.OutOfResourcesErrorClass, instances[1].Status.ErrorInfo.ErrorClass)
+	assert.Equal(t, cloudprovider.InstanceRunning, instances[2].Status.State)
+
+	protection, found := scaleSet.getInstanceProtection(instances[3].Id)
+	assert.True(t, found)
+	assert.True(t, protection.setByAutoscaler)
+	_, found = scaleSet.getInstanceProtection(instances[2].Id)
+	assert.False(t, found)
+}
+
 func TestEnableVmssFlexFlag(t *testing.T) {
 
 	// flag set to false
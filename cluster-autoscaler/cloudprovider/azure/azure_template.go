@@ -25,9 +25,11 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	cloudvolume "k8s.io/cloud-provider/volume"
@@ -36,6 +38,30 @@ import (
 
 const (
 	azureDiskTopologyKey string = "topology.disk.csi.azure.com/zone"
+
+	// securityTypeLabel holds the security type of the VMs of a scale set, TrustedLaunch or ConfidentialVM.
+	securityTypeLabel = "kubernetes.azure.com/security-type"
+	// secureBootLabel is set to "true" on nodes with secure boot enabled.
+	secureBootLabel = "kubernetes.azure.com/secure-boot"
+	// vTPMLabel is set to "true" on nodes with a virtual TPM enabled.
+	vTPMLabel = "kubernetes.azure.com/vtpm"
+	// userAssignedIdentityLabelPrefix prefixes the name of each user-assigned identity of a scale set, in labels
+	// set to "true" on its nodes.
+	userAssignedIdentityLabelPrefix = "identity.kubernetes.azure.com/"
+
+	networkPluginKubenet     = "kubenet"
+	networkPluginAzure       = "azure"
+	networkPluginNone        = "none"
+	networkPluginModeOverlay = "overlay"
+
+	// Default max pods of AKS nodes by network plugin, see
+	// https://learn.microsoft.com/en-us/azure/aks/azure-cni-overview
+	defaultKubenetMaxPods = 110
+	// defaultAzureCNIMaxPods is the default max pods with pod IPs statically allocated from the node subnet.
+	defaultAzureCNIMaxPods = 30
+	// defaultAzureCNIDynamicMaxPods is the default max pods with pod IPs dynamically allocated from a pod
+	// subnet or with overlay, where IPs don't limit the number of pods as much.
+	defaultAzureCNIDynamicMaxPods = 250
 )
 
 func buildInstanceOS(template compute.VirtualMachineScaleSet) string {
@@ -47,10 +73,13 @@ func buildInstanceOS(template compute.VirtualMachineScaleSet) string {
 	return instanceOS
 }
 
-func buildGenericLabels(template compute.VirtualMachineScaleSet, nodeName string) map[string]string {
+func buildGenericLabels(template compute.VirtualMachineScaleSet, nodeName, arch string) map[string]string {
 	result := make(map[string]string)
 
-	result[apiv1.LabelArchStable] = cloudprovider.DefaultArch
+	if arch == "" {
+		arch = cloudprovider.DefaultArch
+	}
+	result[apiv1.LabelArchStable] = arch
 	result[apiv1.LabelOSStable] = buildInstanceOS(template)
 
 	result[apiv1.LabelInstanceTypeStable] = *template.Sku.Name
@@ -73,6 +102,90 @@ func buildGenericLabels(template compute.VirtualMachineScaleSet, nodeName string
 	return result
 }
 
+// getSecurityType returns the security type of the VMs of the scale set, empty if not set.
+func getSecurityType(template compute.VirtualMachineScaleSet) compute.SecurityTypes {
+	if template.VirtualMachineScaleSetProperties == nil || template.VirtualMachineProfile == nil || template.VirtualMachineProfile.SecurityProfile == nil {
+		return ""
+	}
+	return template.VirtualMachineProfile.SecurityProfile.SecurityType
+}
+
+// buildSecurityProfileLabels returns the labels describing the security profile of the VMs of
+// the scale set, so that pods requiring trusted launch or confidential VMs can select them. Nodes
+// created from the scale set have to be labeled the same way by the kubelet.
+func buildSecurityProfileLabels(template compute.VirtualMachineScaleSet) map[string]string {
+	result := make(map[string]string)
+	securityType := getSecurityType(template)
+	if securityType == "" {
+		return result
+	}
+	result[securityTypeLabel] = string(securityType)
+	if uefi := template.VirtualMachineProfile.SecurityProfile.UefiSettings; uefi != nil {
+		if uefi.SecureBootEnabled != nil && *uefi.SecureBootEnabled {
+			result[secureBootLabel] = "true"
+		}
+		if uefi.VTpmEnabled != nil && *uefi.VTpmEnabled {
+			result[vTPMLabel] = "true"
+		}
+	}
+	return result
+}
+
+// buildUserAssignedIdentityLabels returns a label for each user-assigned managed identity of the scale set,
+// so that pods selecting nodes with a given identity trigger scale-ups of the scale sets having it. Identities
+// whose names aren't valid in label keys are skipped.
+func buildUserAssignedIdentityLabels(template compute.VirtualMachineScaleSet) map[string]string {
+	result := make(map[string]string)
+	if template.Identity == nil {
+		return result
+	}
+	for id := range template.Identity.UserAssignedIdentities {
+		name := id[strings.LastIndex(id, "/")+1:]
+		key := userAssignedIdentityLabelPrefix + name
+		if errs := validation.IsQualifiedName(key); name == "" || len(errs) > 0 {
+			klog.V(4).Infof("Not labeling template node of scale set %s with user-assigned identity %s: %v", to.String(template.Name), id, errs)
+			continue
+		}
+		result[key] = "true"
+	}
+	return result
+}
+
+// getVMSSType returns the instance type of the scale set template. It is fetched from
+// the SKU API if enableDynamicInstanceList is set, then looked up in the static list,
+// and finally taken from the previous version of the SKU in the static list for
+// recent SKUs missing from it.
+func getVMSSType(template compute.VirtualMachineScaleSet, manager *AzureManager) (InstanceType, error) {
+	skuName := *template.Sku.Name
+	if manager.getConfig().EnableDynamicInstanceList {
+		klog.V(1).Infof("Fetching instance information for SKU: %s from SKU API", skuName)
+		vmssType, err := GetVMSSTypeDynamically(template, manager.azureCache)
+		if err == nil {
+			return vmssType, nil
+		}
+		klog.Errorf("Dynamically fetching of instance information from SKU api failed with error: %v", err)
+	}
+
+	klog.V(1).Infof("Falling back to static SKU list for SKU: %s", skuName)
+	staticType, staticErr := GetVMSSTypeStatically(template)
+	if staticErr == nil {
+		vmssType := *staticType
+		if vmssType.Architecture == "" {
+			vmssType.Architecture = getArchitectureFromSkuName(skuName)
+		}
+		return vmssType, nil
+	}
+
+	if vmssType, found := getVMSSTypeFromSkuName(skuName); found {
+		klog.Warningf("Instance type %q not supported, using %d vCPUs and %d MiB of memory of its previous version", skuName, vmssType.VCPU, vmssType.MemoryMb)
+		return vmssType, nil
+	}
+
+	// return error if none of the workflows results with vmss data.
+	klog.V(1).Infof("Instance type %q not supported, err: %v", skuName, staticErr)
+	return InstanceType{}, staticErr
+}
+
 func buildNodeFromTemplate(scaleSetName string, template compute.VirtualMachineScaleSet, manager *AzureManager) (*apiv1.Node, error) {
 	node := apiv1.Node{}
 	nodeName := fmt.Sprintf("%s-asg-%d", scaleSetName, rand.Int63())
@@ -87,38 +200,13 @@ func buildNodeFromTemplate(scaleSetName string, template compute.VirtualMachineS
 		Capacity: apiv1.ResourceList{},
 	}
 
-	var vcpu, gpuCount, memoryMb int64
-
-	// Fetching SKU information from SKU API if enableDynamicInstanceList is true.
-	var dynamicErr error
-	if manager.config.EnableDynamicInstanceList {
-		var vmssTypeDynamic InstanceType
-		klog.V(1).Infof("Fetching instance information for SKU: %s from SKU API", *template.Sku.Name)
-		vmssTypeDynamic, dynamicErr = GetVMSSTypeDynamically(template, manager.azureCache)
-		if dynamicErr == nil {
-			vcpu = vmssTypeDynamic.VCPU
-			gpuCount = vmssTypeDynamic.GPU
-			memoryMb = vmssTypeDynamic.MemoryMb
-		} else {
-			klog.Errorf("Dynamically fetching of instance information from SKU api failed with error: %v", dynamicErr)
-		}
-	}
-	if !manager.config.EnableDynamicInstanceList || dynamicErr != nil {
-		klog.V(1).Infof("Falling back to static SKU list for SKU: %s", *template.Sku.Name)
-		// fall-back on static list of vmss if dynamic workflow fails.
-		vmssTypeStatic, staticErr := GetVMSSTypeStatically(template)
-		if staticErr == nil {
-			vcpu = vmssTypeStatic.VCPU
-			gpuCount = vmssTypeStatic.GPU
-			memoryMb = vmssTypeStatic.MemoryMb
-		} else {
-			// return error if neither of the workflows results with vmss data.
-			klog.V(1).Infof("Instance type %q not supported, err: %v", *template.Sku.Name, staticErr)
-			return nil, staticErr
-		}
+	vmssType, err := getVMSSType(template, manager)
+	if err != nil {
+		return nil, err
 	}
+	vcpu, gpuCount, memoryMb := vmssType.VCPU, vmssType.GPU, vmssType.MemoryMb
 
-	node.Status.Capacity[apiv1.ResourcePods] = *resource.NewQuantity(110, resource.DecimalSI)
+	node.Status.Capacity[apiv1.ResourcePods] = *resource.NewQuantity(buildMaxPods(template, manager.getConfig().NetworkPlugin, manager.getConfig().NetworkPluginMode), resource.DecimalSI)
 	node.Status.Capacity[apiv1.ResourceCPU] = *resource.NewQuantity(vcpu, resource.DecimalSI)
 	// isNPSeries returns if a SKU is an NP-series SKU
 	// SKU API reports GPUs for NP-series but it's actually FPGAs
@@ -149,7 +237,11 @@ func buildNodeFromTemplate(scaleSetName string, template compute.VirtualMachineS
 	}
 
 	// GenericLabels
-	node.Labels = cloudprovider.JoinStringMaps(node.Labels, buildGenericLabels(template, nodeName))
+	node.Labels = cloudprovider.JoinStringMaps(node.Labels, buildGenericLabels(template, nodeName, vmssType.Architecture))
+	// Security profile labels
+	node.Labels = cloudprovider.JoinStringMaps(node.Labels, buildSecurityProfileLabels(template))
+	// User-assigned identity labels
+	node.Labels = cloudprovider.JoinStringMaps(node.Labels, buildUserAssignedIdentityLabels(template))
 	// Labels from the Scale Set's Tags
 	node.Labels = cloudprovider.JoinStringMaps(node.Labels, extractLabelsFromScaleSet(template.Tags))
 
@@ -160,6 +252,54 @@ func buildNodeFromTemplate(scaleSetName string, template compute.VirtualMachineS
 	return &node, nil
 }
 
+// buildMaxPods returns the max pods of the nodes of a scale set for the network plugin of the cluster.
+// With pod IPs statically allocated from the node subnet by the azure network plugin (traditional Azure
+// CNI), the IPs of the pods are secondary IP configurations of the primary NIC of the node, so their
+// number is the max pods. A single IP configuration means pod IPs are dynamically allocated from a
+// pod subnet instead.
+func buildMaxPods(template compute.VirtualMachineScaleSet, networkPlugin, networkPluginMode string) int64 {
+	switch networkPlugin {
+	case networkPluginAzure:
+		if networkPluginMode == networkPluginModeOverlay {
+			return defaultAzureCNIDynamicMaxPods
+		}
+		ipConfigurations := primaryNICIPConfigurationsCount(template)
+		switch {
+		case ipConfigurations > 1:
+			return int64(ipConfigurations - 1)
+		case ipConfigurations == 1:
+			return defaultAzureCNIDynamicMaxPods
+		default:
+			return defaultAzureCNIMaxPods
+		}
+	case networkPluginNone:
+		return defaultAzureCNIDynamicMaxPods
+	default:
+		return defaultKubenetMaxPods
+	}
+}
+
+// primaryNICIPConfigurationsCount returns the number of IP configurations of the primary NIC of the
+// scale set template, or 0 if it doesn't have any.
+func primaryNICIPConfigurationsCount(template compute.VirtualMachineScaleSet) int {
+	if template.VirtualMachineScaleSetProperties == nil || template.VirtualMachineProfile == nil || template.VirtualMachineProfile.NetworkProfile == nil ||
+		template.VirtualMachineProfile.NetworkProfile.NetworkInterfaceConfigurations == nil {
+		return 0
+	}
+	nics := *template.VirtualMachineProfile.NetworkProfile.NetworkInterfaceConfigurations
+	for i, nic := range nics {
+		properties := nic.VirtualMachineScaleSetNetworkConfigurationProperties
+		if properties == nil || properties.IPConfigurations == nil {
+			continue
+		}
+		// The only NIC of a scale set doesn't have to be marked as primary.
+		if (properties.Primary != nil && *properties.Primary) || (len(nics) == 1 && i == 0) {
+			return len(*properties.IPConfigurations)
+		}
+	}
+	return 0
+}
+
 func extractLabelsFromScaleSet(tags map[string]*string) map[string]string {
 	result := make(map[string]string)
 
@@ -18,10 +18,12 @@ package azure
 
 import (
 	"fmt"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/stretchr/testify/assert"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"strings"
 	"testing"
 )
 
@@ -119,6 +121,78 @@ func TestExtractAllocatableResourcesFromScaleSet(t *testing.T) {
 	assert.Equal(t, (&exepectedCustomAllocatable).String(), labels["nvidia.com/Tesla-P100-PCIE"].String())
 }
 
+func TestBuildMaxPods(t *testing.T) {
+	templateWithIPConfigurations := func(count int) compute.VirtualMachineScaleSet {
+		ipConfigurations := make([]compute.VirtualMachineScaleSetIPConfiguration, count)
+		return compute.VirtualMachineScaleSet{
+			VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+				VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{
+					NetworkProfile: &compute.VirtualMachineScaleSetNetworkProfile{
+						NetworkInterfaceConfigurations: &[]compute.VirtualMachineScaleSetNetworkConfiguration{
+							{
+								VirtualMachineScaleSetNetworkConfigurationProperties: &compute.VirtualMachineScaleSetNetworkConfigurationProperties{
+									Primary:          to.BoolPtr(true),
+									IPConfigurations: &ipConfigurations,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		template          compute.VirtualMachineScaleSet
+		networkPlugin     string
+		networkPluginMode string
+		expectedMaxPods   int64
+	}{
+		{
+			name:            "kubenet by default",
+			template:        templateWithIPConfigurations(1),
+			expectedMaxPods: 110,
+		},
+		{
+			name:            "azure cni with static pod IPs",
+			template:        templateWithIPConfigurations(51),
+			networkPlugin:   "azure",
+			expectedMaxPods: 50,
+		},
+		{
+			name:            "azure cni with dynamic pod IPs",
+			template:        templateWithIPConfigurations(1),
+			networkPlugin:   "azure",
+			expectedMaxPods: 250,
+		},
+		{
+			name:            "azure cni without network profile",
+			template:        compute.VirtualMachineScaleSet{},
+			networkPlugin:   "azure",
+			expectedMaxPods: 30,
+		},
+		{
+			name:              "azure cni overlay",
+			template:          templateWithIPConfigurations(51),
+			networkPlugin:     "azure",
+			networkPluginMode: "overlay",
+			expectedMaxPods:   250,
+		},
+		{
+			name:            "bring your own cni",
+			template:        templateWithIPConfigurations(1),
+			networkPlugin:   "none",
+			expectedMaxPods: 250,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedMaxPods, buildMaxPods(tc.template, tc.networkPlugin, tc.networkPluginMode))
+		})
+	}
+}
+
 func makeTaintSet(taints []apiv1.Taint) map[apiv1.Taint]bool {
 	set := make(map[apiv1.Taint]bool)
 	for _, taint := range taints {
@@ -126,3 +200,51 @@ func makeTaintSet(taints []apiv1.Taint) map[apiv1.Taint]bool {
 	}
 	return set
 }
+
+func TestBuildSecurityProfileLabels(t *testing.T) {
+	template := compute.VirtualMachineScaleSet{
+		VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+			VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{},
+		},
+	}
+	assert.Empty(t, buildSecurityProfileLabels(template))
+
+	template.VirtualMachineProfile.SecurityProfile = &compute.SecurityProfile{
+		SecurityType: compute.SecurityTypesConfidentialVM,
+		UefiSettings: &compute.UefiSettings{
+			SecureBootEnabled: to.BoolPtr(true),
+			VTpmEnabled:       to.BoolPtr(true),
+		},
+	}
+	assert.Equal(t, map[string]string{
+		securityTypeLabel: "ConfidentialVM",
+		secureBootLabel:   "true",
+		vTPMLabel:         "true",
+	}, buildSecurityProfileLabels(template))
+
+	template.VirtualMachineProfile.SecurityProfile = &compute.SecurityProfile{
+		SecurityType: compute.SecurityTypesTrustedLaunch,
+		UefiSettings: &compute.UefiSettings{SecureBootEnabled: to.BoolPtr(false)},
+	}
+	assert.Equal(t, map[string]string{securityTypeLabel: "TrustedLaunch"}, buildSecurityProfileLabels(template))
+}
+
+func TestBuildUserAssignedIdentityLabels(t *testing.T) {
+	template := compute.VirtualMachineScaleSet{Name: to.StringPtr("vmss")}
+	assert.Empty(t, buildUserAssignedIdentityLabels(template))
+
+	idPrefix := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/"
+	template.Identity = &compute.VirtualMachineScaleSetIdentity{
+		Type: compute.ResourceIdentityTypeSystemAssignedUserAssigned,
+		UserAssignedIdentities: map[string]*compute.UserAssignedIdentitiesValue{
+			idPrefix + "payments-writer":                                 {},
+			idPrefix + "reports_reader":                                  {},
+			idPrefix + strings.Repeat("a", 64):                           {},
+			"/subscriptions/sub/resourceGroups/rg/providers/invalid/id/": {},
+		},
+	}
+	assert.Equal(t, map[string]string{
+		userAssignedIdentityLabelPrefix + "payments-writer": "true",
+		userAssignedIdentityLabelPrefix + "reports_reader":  "true",
+	}, buildUserAssignedIdentityLabels(template))
+}
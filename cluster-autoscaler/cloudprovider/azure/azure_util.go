@@ -83,6 +83,9 @@ const (
 	nodeResourcesTagName = "k8s.io_cluster-autoscaler_node-template_resources_"
 	nodeOptionsTagName   = "k8s.io_cluster-autoscaler_node-template_autoscaling-options_"
 
+	// vmssVmsCacheTTLOption is the autoscaling options tag overriding VmssVmsCacheTTL for a VMSS.
+	vmssVmsCacheTTLOption = "vmssvmscachettl"
+
 	// PowerStates reflect the operational state of a VM
 	// From https://learn.microsoft.com/en-us/java/api/com.microsoft.azure.management.compute.powerstate?view=azure-java-stable
 	vmPowerStateStarting     = "PowerState/starting"
@@ -92,6 +95,10 @@ const (
 	vmPowerStateDeallocating = "PowerState/deallocating"
 	vmPowerStateDeallocated  = "PowerState/deallocated"
 	vmPowerStateUnknown      = "PowerState/unknown"
+
+	// provisioningStateFailedStatusPrefix prefixes the code of the error in the instance view status
+	// of a VM whose provisioning failed.
+	provisioningStateFailedStatusPrefix = "ProvisioningState/failed/"
 )
 
 var (
@@ -113,7 +120,7 @@ func (util *AzUtil) DeleteBlob(accountName, vhdContainer, vhdBlob string) error
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
 
-	storageKeysResult, rerr := util.manager.azClient.storageAccountsClient.ListKeys(ctx, util.manager.config.SubscriptionID, util.manager.config.ResourceGroup, accountName)
+	storageKeysResult, rerr := util.manager.getAzClient().storageAccountsClient.ListKeys(ctx, util.manager.getConfig().SubscriptionID, util.manager.getConfig().ResourceGroup, accountName)
 	if rerr != nil {
 		return rerr.Error()
 	}
@@ -136,7 +143,7 @@ func (util *AzUtil) DeleteVirtualMachine(rg string, name string) error {
 	ctx, cancel := getContextWithCancel()
 	defer cancel()
 
-	vm, rerr := util.manager.azClient.virtualMachinesClient.Get(ctx, rg, name, "")
+	vm, rerr := util.manager.getAzClient().virtualMachinesClient.Get(ctx, rg, name, "")
 	if rerr != nil {
 		if exists, _ := checkResourceExistsFromRetryError(rerr); !exists {
 			klog.V(2).Infof("VirtualMachine %s/%s has already been removed", rg, name)
@@ -173,7 +180,7 @@ func (util *AzUtil) DeleteVirtualMachine(rg string, name string) error {
 	defer deleteCancel()
 
 	klog.Infof("waiting for VirtualMachine deletion: %s/%s", rg, name)
-	rerr = util.manager.azClient.virtualMachinesClient.Delete(deleteCtx, rg, name)
+	rerr = util.manager.getAzClient().virtualMachinesClient.Delete(deleteCtx, rg, name)
 	_, realErr := checkResourceExistsFromRetryError(rerr)
 	if realErr != nil {
 		return realErr
@@ -185,7 +192,7 @@ func (util *AzUtil) DeleteVirtualMachine(rg string, name string) error {
 		interfaceCtx, interfaceCancel := getContextWithCancel()
 		defer interfaceCancel()
 		klog.Infof("waiting for nic deletion: %s/%s", rg, nicName)
-		nicErr := util.manager.azClient.interfacesClient.Delete(interfaceCtx, rg, nicName)
+		nicErr := util.manager.getAzClient().interfacesClient.Delete(interfaceCtx, rg, nicName)
 		_, realErr := checkResourceExistsFromRetryError(nicErr)
 		if realErr != nil {
 			return realErr
@@ -216,7 +223,7 @@ func (util *AzUtil) DeleteVirtualMachine(rg string, name string) error {
 			klog.Infof("deleting managed disk: %s/%s", rg, *osDiskName)
 			disksCtx, disksCancel := getContextWithCancel()
 			defer disksCancel()
-			diskErr := util.manager.azClient.disksClient.Delete(disksCtx, util.manager.config.SubscriptionID, rg, *osDiskName)
+			diskErr := util.manager.getAzClient().disksClient.Delete(disksCtx, util.manager.getConfig().SubscriptionID, rg, *osDiskName)
 			_, realErr := checkResourceExistsFromRetryError(diskErr)
 			if realErr != nil {
 				return realErr
@@ -647,3 +654,17 @@ func vmPowerStateFromStatuses(statuses []compute.InstanceViewStatus) string {
 	// PowerState is not set if the VM is still creating (or has failed creation)
 	return vmPowerStateUnknown
 }
+
+// vmProvisioningErrorCodeFromStatuses returns the code of the error which failed the provisioning of a VM,
+// given by its ProvisioningState/failed/<code> instance view status, or an empty string.
+func vmProvisioningErrorCodeFromStatuses(statuses []compute.InstanceViewStatus) string {
+	for _, status := range statuses {
+		if status.Code == nil {
+			continue
+		}
+		if code, found := strings.CutPrefix(*status.Code, provisioningStateFailedStatusPrefix); found {
+			return code
+		}
+	}
+	return ""
+}
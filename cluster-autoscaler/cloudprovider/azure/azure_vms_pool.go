@@ -50,7 +50,7 @@ func NewVMsPool(spec *dynamic.NodeGroupSpec, am *AzureManager) *VMsPool {
 		},
 
 		manager:       am,
-		resourceGroup: am.config.ResourceGroup,
+		resourceGroup: am.getConfig().ResourceGroup,
 
 		curSize: -1,
 		minSize: spec.MinSize,
@@ -140,6 +140,10 @@ func (agentPool *VMsPool) getVMsFromCache() ([]compute.VirtualMachine, error) {
 	// vmsPoolMap is a map of agent pool name to the list of virtual machines
 	vmsPoolMap := agentPool.manager.azureCache.getVirtualMachines()
 	if _, ok := vmsPoolMap[agentPool.Name]; !ok {
+		// VMs are only known once they're seen as nodes in lazy VM discovery mode.
+		if agentPool.manager.getConfig().LazyVirtualMachineDiscovery {
+			return []compute.VirtualMachine{}, nil
+		}
 		return []compute.VirtualMachine{}, fmt.Errorf("vms pool %s not found in the cache", agentPool.Name)
 	}
 
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"k8s.io/client-go/util/flowcontrol"
+	klog "k8s.io/klog/v2"
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// VirtualMachineScaleSetsUpdater updates scale sets with PATCH requests. Unlike the PUT requests
+// of CreateOrUpdate, which replace the whole scale set with the composed one, they only change the
+// properties which are set, so that the tags and settings managed by others, e.g. assigned by Azure
+// Policy, are kept. The capacity update is the only write of a whole scale set done by the cluster
+// autoscaler, its other scale set writes act on instances.
+type VirtualMachineScaleSetsUpdater interface {
+	// UpdateAsync sends the update request and doesn't wait for it to complete.
+	UpdateAsync(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, parameters compute.VirtualMachineScaleSetUpdate) (*azure.Future, *retry.Error)
+	// WaitForUpdateResult waits for the update request to complete.
+	WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error)
+}
+
+// scaleSetsWriteThrottle is the write rate limiter and Retry-After deadline shared by the scale sets
+// client and updater, so that they stay within a single write budget and both stop writing while
+// either is throttled.
+type scaleSetsWriteThrottle struct {
+	rateLimiterWriter flowcontrol.RateLimiter
+
+	mu         sync.Mutex
+	retryAfter time.Time
+}
+
+func newScaleSetsWriteThrottle(config *azclients.ClientConfig) *scaleSetsWriteThrottle {
+	_, rateLimiterWriter := azclients.NewRateLimiter(config.RateLimitConfig)
+	return &scaleSetsWriteThrottle{rateLimiterWriter: rateLimiterWriter}
+}
+
+// accept returns an error if the write can't be sent, because the last throttled write asked
+// to retry later or the write budget is used up.
+func (t *scaleSetsWriteThrottle) accept(operation string) *retry.Error {
+	t.mu.Lock()
+	retryAfter := t.retryAfter
+	t.mu.Unlock()
+	if retryAfter.After(time.Now()) {
+		return retry.GetThrottlingError(operation, "client throttled", retryAfter)
+	}
+	if !t.rateLimiterWriter.TryAccept() {
+		return retry.GetRateLimitError(true, operation)
+	}
+	return nil
+}
+
+// observe records the Retry-After deadline of the error of a throttled write.
+func (t *scaleSetsWriteThrottle) observe(rerr *retry.Error) {
+	if rerr == nil || !rerr.IsThrottled() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rerr.RetryAfter.After(t.retryAfter) {
+		t.retryAfter = rerr.RetryAfter
+	}
+}
+
+// writeThrottledScaleSetsClient takes a token of the write rate limiter shared with the scale sets
+// updater for every write, and honors the Retry-After deadline of both.
+type writeThrottledScaleSetsClient struct {
+	vmssclient.Interface
+	throttle *scaleSetsWriteThrottle
+}
+
+// CreateOrUpdate creates or updates a VirtualMachineScaleSet.
+func (c *writeThrottledScaleSetsClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, VMScaleSetName string, parameters compute.VirtualMachineScaleSet) *retry.Error {
+	if rerr := c.throttle.accept("VMSSCreateOrUpdate"); rerr != nil {
+		return rerr
+	}
+	rerr := c.Interface.CreateOrUpdate(ctx, resourceGroupName, VMScaleSetName, parameters)
+	c.throttle.observe(rerr)
+	return rerr
+}
+
+// CreateOrUpdateAsync sends the request of creating or updating a VirtualMachineScaleSet.
+func (c *writeThrottledScaleSetsClient) CreateOrUpdateAsync(ctx context.Context, resourceGroupName string, VMScaleSetName string, parameters compute.VirtualMachineScaleSet) (*azure.Future, *retry.Error) {
+	if rerr := c.throttle.accept("VMSSCreateOrUpdateAsync"); rerr != nil {
+		return nil, rerr
+	}
+	future, rerr := c.Interface.CreateOrUpdateAsync(ctx, resourceGroupName, VMScaleSetName, parameters)
+	c.throttle.observe(rerr)
+	return future, rerr
+}
+
+// DeleteInstances deletes the instances of a VirtualMachineScaleSet.
+func (c *writeThrottledScaleSetsClient) DeleteInstances(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs) *retry.Error {
+	if rerr := c.throttle.accept("VMSSDeleteInstances"); rerr != nil {
+		return rerr
+	}
+	rerr := c.Interface.DeleteInstances(ctx, resourceGroupName, vmScaleSetName, vmInstanceIDs)
+	c.throttle.observe(rerr)
+	return rerr
+}
+
+// DeleteInstancesAsync sends the request of deleting the instances of a VirtualMachineScaleSet.
+func (c *writeThrottledScaleSetsClient) DeleteInstancesAsync(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs, forceDelete bool) (*azure.Future, *retry.Error) {
+	if rerr := c.throttle.accept("VMSSDeleteInstancesAsync"); rerr != nil {
+		return nil, rerr
+	}
+	future, rerr := c.Interface.DeleteInstancesAsync(ctx, resourceGroupName, vmScaleSetName, vmInstanceIDs, forceDelete)
+	c.throttle.observe(rerr)
+	return future, rerr
+}
+
+// DeallocateInstancesAsync sends the request of deallocating the instances of a VirtualMachineScaleSet.
+func (c *writeThrottledScaleSetsClient) DeallocateInstancesAsync(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs) (*azure.Future, *retry.Error) {
+	if rerr := c.throttle.accept("VMSSDeallocateInstancesAsync"); rerr != nil {
+		return nil, rerr
+	}
+	future, rerr := c.Interface.DeallocateInstancesAsync(ctx, resourceGroupName, vmScaleSetName, vmInstanceIDs)
+	c.throttle.observe(rerr)
+	return future, rerr
+}
+
+// StartInstancesAsync sends the request of starting the instances of a VirtualMachineScaleSet.
+func (c *writeThrottledScaleSetsClient) StartInstancesAsync(ctx context.Context, resourceGroupName string, vmScaleSetName string, vmInstanceIDs compute.VirtualMachineScaleSetVMInstanceRequiredIDs) (*azure.Future, *retry.Error) {
+	if rerr := c.throttle.accept("VMSSStartInstancesAsync"); rerr != nil {
+		return nil, rerr
+	}
+	future, rerr := c.Interface.StartInstancesAsync(ctx, resourceGroupName, vmScaleSetName, vmInstanceIDs)
+	c.throttle.observe(rerr)
+	return future, rerr
+}
+
+type azScaleSetsUpdater struct {
+	client   compute.VirtualMachineScaleSetsClient
+	throttle *scaleSetsWriteThrottle
+}
+
+func newAzScaleSetsUpdater(config *azclients.ClientConfig, throttle *scaleSetsWriteThrottle) *azScaleSetsUpdater {
+	client := compute.NewVirtualMachineScaleSetsClientWithBaseURI(config.ResourceManagerEndpoint, config.SubscriptionID)
+	client.Authorizer = config.Authorizer
+	configureUserAgent(&client.Client)
+
+	return &azScaleSetsUpdater{
+		client:   client,
+		throttle: throttle,
+	}
+}
+
+func (az *azScaleSetsUpdater) UpdateAsync(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, parameters compute.VirtualMachineScaleSetUpdate) (*azure.Future, *retry.Error) {
+	klog.V(10).Infof("azScaleSetsUpdater.UpdateAsync(%q,%q): start", resourceGroupName, virtualMachineScaleSetName)
+	defer func() {
+		klog.V(10).Infof("azScaleSetsUpdater.UpdateAsync(%q,%q): end", resourceGroupName, virtualMachineScaleSetName)
+	}()
+
+	if rerr := az.throttle.accept("VMSSUpdate"); rerr != nil {
+		return nil, rerr
+	}
+	future, err := az.client.Update(ctx, resourceGroupName, virtualMachineScaleSetName, parameters)
+	if err != nil {
+		rerr := requestError(err)
+		az.throttle.observe(rerr)
+		return nil, rerr
+	}
+	azFuture, ok := future.FutureAPI.(*azure.Future)
+	if !ok {
+		return nil, retry.NewError(false, fmt.Errorf("unexpected future type %T", future.FutureAPI))
+	}
+	return azFuture, nil
+}
+
+func (az *azScaleSetsUpdater) WaitForUpdateResult(ctx context.Context, future *azure.Future, resourceGroupName string) (*http.Response, error) {
+	if err := future.WaitForCompletionRef(ctx, az.client.Client); err != nil {
+		return future.Response(), err
+	}
+	return future.Response(), nil
+}
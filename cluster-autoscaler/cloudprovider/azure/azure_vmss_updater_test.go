@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssclient/mockvmssclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteThrottledScaleSetsClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ids := compute.VirtualMachineScaleSetVMInstanceRequiredIDs{}
+	mockVMSSClient := mockvmssclient.NewMockInterface(ctrl)
+	throttle := &scaleSetsWriteThrottle{rateLimiterWriter: flowcontrol.NewFakeAlwaysRateLimiter()}
+	client := &writeThrottledScaleSetsClient{Interface: mockVMSSClient, throttle: throttle}
+
+	// A throttled write sets the Retry-After deadline of the scale sets client and updater.
+	retryAfter := time.Now().Add(time.Minute)
+	mockVMSSClient.EXPECT().DeleteInstancesAsync(gomock.Any(), "rg", "ss", ids, false).Return(nil,
+		&retry.Error{HTTPStatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter}).Times(1)
+	_, rerr := client.DeleteInstancesAsync(context.Background(), "rg", "ss", ids, false)
+	assert.Equal(t, http.StatusTooManyRequests, rerr.HTTPStatusCode)
+
+	_, rerr = client.DeleteInstancesAsync(context.Background(), "rg", "ss", ids, false)
+	assert.True(t, rerr.IsThrottled())
+	assert.Equal(t, retryAfter, rerr.RetryAfter)
+	updater := &azScaleSetsUpdater{throttle: throttle}
+	_, rerr = updater.UpdateAsync(context.Background(), "rg", "ss", compute.VirtualMachineScaleSetUpdate{})
+	assert.True(t, rerr.IsThrottled())
+
+	// Once the write budget is used up, writes aren't sent.
+	throttle = &scaleSetsWriteThrottle{rateLimiterWriter: flowcontrol.NewFakeNeverRateLimiter()}
+	client = &writeThrottledScaleSetsClient{Interface: mockVMSSClient, throttle: throttle}
+	rerr = client.DeleteInstances(context.Background(), "rg", "ss", ids)
+	assert.True(t, rerr.Retriable)
+	assert.Contains(t, rerr.Error().Error(), "write")
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"k8s.io/client-go/util/flowcontrol"
+	klog "k8s.io/klog/v2"
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azureclients/vmssvmclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/retry"
+)
+
+// maxPageRetries is the number of times the request of a page is retried after it was throttled.
+const maxPageRetries = 3
+
+// VirtualMachineScaleSetVMsPager lists the VMs of a scale set page by page, so that
+// the callers don't need to hold the VMs of all pages in memory at the same time.
+type VirtualMachineScaleSetVMsPager interface {
+	// ListPages calls handlePage with the VMs of every page of the scale set VMs list, in order.
+	ListPages(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, expand string, handlePage func([]compute.VirtualMachineScaleSetVM)) *retry.Error
+}
+
+type azScaleSetVMsPager struct {
+	client compute.VirtualMachineScaleSetVMsClient
+	// rateLimiterReader is shared with the reads of the scale set VMs client.
+	rateLimiterReader flowcontrol.RateLimiter
+	// vmssVMClient is the scale set VMs client whose Retry-After of throttled reads
+	// is honored and updated by the pager. Nil if its type isn't known.
+	vmssVMClient *vmssvmclient.Client
+	retryAfter   time.Time
+}
+
+func newAzScaleSetVMsPager(config *azclients.ClientConfig, rateLimiterReader flowcontrol.RateLimiter, vmssVMClient vmssvmclient.Interface) *azScaleSetVMsPager {
+	client := compute.NewVirtualMachineScaleSetVMsClientWithBaseURI(config.ResourceManagerEndpoint, config.SubscriptionID)
+	client.Authorizer = config.Authorizer
+	configureUserAgent(&client.Client)
+	pager := &azScaleSetVMsPager{
+		client:            client,
+		rateLimiterReader: rateLimiterReader,
+	}
+	pager.vmssVMClient, _ = vmssVMClient.(*vmssvmclient.Client)
+	return pager
+}
+
+func (az *azScaleSetVMsPager) ListPages(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, expand string, handlePage func([]compute.VirtualMachineScaleSetVM)) *retry.Error {
+	klog.V(10).Infof("azScaleSetVMsPager.ListPages(%q,%q): start", resourceGroupName, virtualMachineScaleSetName)
+	defer func() {
+		klog.V(10).Infof("azScaleSetVMsPager.ListPages(%q,%q): end", resourceGroupName, virtualMachineScaleSetName)
+	}()
+
+	var page compute.VirtualMachineScaleSetVMListResultPage
+	rerr := az.fetchPage(ctx, func() error {
+		var err error
+		page, err = az.client.List(ctx, resourceGroupName, virtualMachineScaleSetName, "", "", expand)
+		return err
+	})
+	pages := 0
+	for {
+		if rerr != nil {
+			return rerr
+		}
+		if !page.NotDone() {
+			break
+		}
+		handlePage(page.Values())
+		pages++
+		rerr = az.fetchPage(ctx, func() error {
+			return page.NextWithContext(ctx)
+		})
+	}
+	klog.V(4).Infof("azScaleSetVMsPager.ListPages(%q,%q): listed %d pages", resourceGroupName, virtualMachineScaleSetName, pages)
+	return nil
+}
+
+// fetchPage sends the request of a page once both the read rate limiter and the
+// Retry-After of earlier throttled reads allow it, and retries it if it is throttled.
+// Waiting doesn't fail the listing of the pages fetched so far, unless it would
+// exceed the deadline of ctx.
+func (az *azScaleSetVMsPager) fetchPage(ctx context.Context, request func() error) *retry.Error {
+	for attempt := 0; ; attempt++ {
+		if retryAfter := az.getRetryAfter(); retryAfter.After(time.Now()) {
+			if deadline, found := ctx.Deadline(); found && retryAfter.After(deadline) {
+				return retry.GetThrottlingError("VMSSVMListPages", "client throttled", retryAfter)
+			}
+			select {
+			case <-time.After(time.Until(retryAfter)):
+			case <-ctx.Done():
+				return retry.NewError(true, ctx.Err())
+			}
+		}
+		if err := az.rateLimiterReader.Wait(ctx); err != nil {
+			return retry.GetRateLimitError(false, "VMSSVMListPages")
+		}
+		err := request()
+		if err == nil {
+			return nil
+		}
+		rerr := requestError(err)
+		if !rerr.IsThrottled() {
+			return rerr
+		}
+		az.setRetryAfter(rerr.RetryAfter)
+		if attempt >= maxPageRetries {
+			return rerr
+		}
+		klog.V(4).Infof("azScaleSetVMsPager: page request throttled, retrying after %v", rerr.RetryAfter)
+	}
+}
+
+func (az *azScaleSetVMsPager) getRetryAfter() time.Time {
+	if az.vmssVMClient != nil {
+		return az.vmssVMClient.RetryAfterReader
+	}
+	return az.retryAfter
+}
+
+func (az *azScaleSetVMsPager) setRetryAfter(retryAfter time.Time) {
+	if az.vmssVMClient != nil {
+		az.vmssVMClient.RetryAfterReader = retryAfter
+		return
+	}
+	az.retryAfter = retryAfter
+}
+
+// rateLimitedScaleSetVMsClient takes a token of a read rate limiter shared with
+// the VMSS VMs pager for every read, so that both stay within a single read budget.
+type rateLimitedScaleSetVMsClient struct {
+	vmssvmclient.Interface
+	rateLimiterReader flowcontrol.RateLimiter
+}
+
+// Get gets a VirtualMachineScaleSetVM.
+func (c *rateLimitedScaleSetVMsClient) Get(ctx context.Context, resourceGroupName string, VMScaleSetName string, instanceID string, expand compute.InstanceViewTypes) (compute.VirtualMachineScaleSetVM, *retry.Error) {
+	if !c.rateLimiterReader.TryAccept() {
+		return compute.VirtualMachineScaleSetVM{}, retry.GetRateLimitError(false, "VMSSVMGet")
+	}
+	return c.Interface.Get(ctx, resourceGroupName, VMScaleSetName, instanceID, expand)
+}
+
+// List gets a list of VirtualMachineScaleSetVMs in the virtualMachineScaleSet.
+func (c *rateLimitedScaleSetVMsClient) List(ctx context.Context, resourceGroupName string, virtualMachineScaleSetName string, expand string) ([]compute.VirtualMachineScaleSetVM, *retry.Error) {
+	if !c.rateLimiterReader.TryAccept() {
+		return nil, retry.GetRateLimitError(false, "VMSSVMList")
+	}
+	return c.Interface.List(ctx, resourceGroupName, virtualMachineScaleSetName, expand)
+}
+
+// requestError converts the error of a request sent with an SDK client to a retry.Error, keeping
+// the HTTP response details, e.g. whether the request was throttled.
+func requestError(err error) *retry.Error {
+	var detailedErr autorest.DetailedError
+	if errors.As(err, &detailedErr) && detailedErr.Response != nil {
+		return retry.GetError(detailedErr.Response, err)
+	}
+	return retry.NewError(false, err)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2022-08-01/compute"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/flowcontrol"
+	azclients "sigs.k8s.io/cloud-provider-azure/pkg/azureclients"
+)
+
+func TestScaleSetVMsPagerThrottling(t *testing.T) {
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		switch requests {
+		case 1:
+			fmt.Fprintf(w, `{"value": [{"id": "vm-0"}, {"id": "vm-1"}], "nextLink": "%s/next"}`, server.URL)
+		case 2:
+			// The second page is throttled once.
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": {"code": "TooManyRequests"}}`)
+		default:
+			fmt.Fprint(w, `{"value": [{"id": "vm-2"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	pager := newAzScaleSetVMsPager(&azclients.ClientConfig{ResourceManagerEndpoint: server.URL, SubscriptionID: "sub"},
+		flowcontrol.NewFakeAlwaysRateLimiter(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var ids []string
+	rerr := pager.ListPages(ctx, "rg", "vmss", "", func(page []compute.VirtualMachineScaleSetVM) {
+		for _, vm := range page {
+			ids = append(ids, *vm.ID)
+		}
+	})
+	assert.Nil(t, rerr)
+	assert.Equal(t, []string{"vm-0", "vm-1", "vm-2"}, ids)
+	assert.Equal(t, 3, requests)
+
+	// Pages aren't requested while the Retry-After is beyond the deadline.
+	pager.setRetryAfter(time.Now().Add(time.Hour))
+	rerr = pager.ListPages(ctx, "rg", "vmss", "", func([]compute.VirtualMachineScaleSetVM) {})
+	assert.NotNil(t, rerr)
+	assert.True(t, rerr.IsThrottled())
+	assert.Equal(t, 3, requests)
+}
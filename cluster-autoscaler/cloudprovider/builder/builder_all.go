@@ -100,7 +100,7 @@ func buildCloudProvider(opts config.AutoscalingOptions,
 	case cloudprovider.GceProviderName:
 		return gce.BuildGCE(opts, do, rl)
 	case cloudprovider.AwsProviderName:
-		return aws.BuildAWS(opts, do, rl)
+		return aws.BuildAWS(opts, do, rl, informerFactory)
 	case cloudprovider.AzureProviderName:
 		return azure.BuildAzure(opts, do, rl)
 	case cloudprovider.AlicloudProviderName:
@@ -195,7 +195,8 @@ type NodeGroup interface {
 
 	// DeleteNodes deletes nodes from this node group. Error is returned either on
 	// failure or if the given node doesn't belong to this node group. This function
-	// should wait until node group size is updated. Implementation required.
+	// should wait until node group size is updated. A NodesNotDeletedError is returned
+	// if only some of the nodes couldn't be deleted. Implementation required.
 	DeleteNodes([]*apiv1.Node) error
 
 	// DecreaseTargetSize decreases the target size of the node group. This function
@@ -247,6 +248,23 @@ type NodeGroup interface {
 	GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error)
 }
 
+// ReservedCapacityNodeGroup is optionally implemented by node groups which can create
+// nodes using cloud capacity reserved in advance, e.g. capacity reservations.
+type ReservedCapacityNodeGroup interface {
+	// AvailableReservedCapacity returns the number of nodes which can still be created
+	// using reserved capacity, and false if the node group doesn't use reserved capacity.
+	AvailableReservedCapacity() (int, bool)
+}
+
+// AvailableReservedCapacity returns the number of nodes which can still be added to the
+// node group using reserved capacity, and false if it doesn't use reserved capacity.
+func AvailableReservedCapacity(nodeGroup NodeGroup) (int, bool) {
+	if reserved, ok := nodeGroup.(ReservedCapacityNodeGroup); ok {
+		return reserved.AvailableReservedCapacity()
+	}
+	return 0, false
+}
+
 // Instance represents a cloud-provider node. The node does not necessarily map to k8s node
 // i.e it does not have to be registered in k8s cluster despite being returned by NodeGroup.Nodes()
 // method. Also it is sane to have Instance object for nodes which are being created or deleted.
@@ -282,10 +300,16 @@ const (
 type InstanceErrorInfo struct {
 	// ErrorClass tells what is class of error on instance
 	ErrorClass InstanceErrorClass
+	// ErrorKind is the provider-independent kind of the error, used to decide how to retry.
+	// Providers set it whenever the cause of the error is known, e.g. from the error code
+	// of a failed provisioning, and leave it unknown otherwise.
+	ErrorKind ErrorKind
 	// ErrorCode is cloud-provider specific error code for error condition
 	ErrorCode string
 	// ErrorMessage is human readable description of error condition
 	ErrorMessage string
+	// Quota describes the exceeded cloud quota if the error is caused by one, nil otherwise.
+	Quota *QuotaErrorInfo
 }
 
 // InstanceErrorClass defines class of error condition
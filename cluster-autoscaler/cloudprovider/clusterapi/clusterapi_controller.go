@@ -56,6 +56,7 @@ const (
 	resourceNameMachinePool       = "machinepools"
 	failedMachinePrefix           = "failed-machine-"
 	pendingMachinePrefix          = "pending-machine-"
+	pendingMachinePoolPrefix      = "pending-machinepool-"
 	machineTemplateKind           = "MachineTemplate"
 	machineDeploymentKind         = "MachineDeployment"
 	machineSetKind                = "MachineSet"
@@ -148,6 +149,10 @@ func (c *machineController) findMachine(id string) (*unstructured.Unstructured,
 	return c.findResourceByKey(c.machineInformer.Informer().GetStore(), id)
 }
 
+func (c *machineController) findMachinePool(id string) (*unstructured.Unstructured, error) {
+	return c.findResourceByKey(c.machinePoolInformer.Informer().GetStore(), id)
+}
+
 func (c *machineController) findMachineSet(id string) (*unstructured.Unstructured, error) {
 	return c.findResourceByKey(c.machineSetInformer.Informer().GetStore(), id)
 }
@@ -232,6 +237,9 @@ func (c *machineController) run() error {
 func (c *machineController) findScalableResourceByProviderID(providerID normalizedProviderID) (*unstructured.Unstructured, error) {
 	// Check for a MachinePool first to simplify the logic afterward.
 	if c.machinePoolsAvailable {
+		if isPendingMachinePoolProviderID(providerID) {
+			return c.findMachinePool(machinePoolKeyFromPendingProviderID(providerID))
+		}
 		machinePool, err := c.findMachinePoolByProviderID(providerID)
 		if err != nil {
 			return nil, err
@@ -345,6 +353,18 @@ func machineKeyFromPendingMachineProviderID(providerID normalizedProviderID) str
 	return strings.Replace(namespaceName, "_", "/", 1)
 }
 
+func isPendingMachinePoolProviderID(providerID normalizedProviderID) bool {
+	return strings.HasPrefix(string(providerID), pendingMachinePoolPrefix)
+}
+
+func machinePoolKeyFromPendingProviderID(providerID normalizedProviderID) string {
+	namespaceNameIndex := strings.TrimPrefix(string(providerID), pendingMachinePoolPrefix)
+	if i := strings.LastIndex(namespaceNameIndex, "_"); i >= 0 {
+		namespaceNameIndex = namespaceNameIndex[:i]
+	}
+	return strings.Replace(namespaceNameIndex, "_", "/", 1)
+}
+
 func isFailedMachineProviderID(providerID normalizedProviderID) bool {
 	return strings.HasPrefix(string(providerID), failedMachinePrefix)
 }
@@ -587,6 +607,19 @@ func (c *machineController) findMachinePoolProviderIDs(scalableResource *unstruc
 		klog.Warningf("Machine Pool %q has no providerIDList", scalableResource.GetName())
 	}
 
+	replicas, found, err := unstructured.NestedInt64(scalableResource.UnstructuredContent(), "spec", "replicas")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		// Provide fake IDs for replicas which don't have a providerID yet, so that the
+		// autoscaler tracks them as upcoming nodes and removes them after maxNodeProvisionTime.
+		// Fake IDs need to be recognised later and converted into a machine pool key.
+		for i := len(providerIDs); i < int(replicas); i++ {
+			providerIDs = append(providerIDs, fmt.Sprintf("%s%s_%s_%d", pendingMachinePoolPrefix, scalableResource.GetNamespace(), scalableResource.GetName(), i))
+		}
+	}
+
 	klog.V(4).Infof("nodegroup %s has %d nodes: %v", scalableResource.GetName(), len(providerIDs), providerIDs)
 	return providerIDs, nil
 }
@@ -95,6 +95,9 @@ func mustCreateTestController(t testing.TB, testConfigs ...*testConfig) (*machin
 		if config.machineDeployment != nil {
 			machineObjects = append(machineObjects, config.machineDeployment)
 		}
+		if config.machinePool != nil {
+			machineObjects = append(machineObjects, config.machinePool)
+		}
 
 		if config.machineTemplate != nil {
 			machineObjects = append(machineObjects, config.machineTemplate)
@@ -1700,6 +1703,19 @@ func TestMachineKeyFromFailedProviderID(t *testing.T) {
 	}
 }
 
+func TestMachinePoolKeyFromPendingProviderID(t *testing.T) {
+	providerID := normalizedProviderID(fmt.Sprintf("%stest-namespace_foo_3", pendingMachinePoolPrefix))
+	if !isPendingMachinePoolProviderID(providerID) {
+		t.Errorf("expected %q to be a pending machine pool providerID", providerID)
+	}
+	if isPendingMachineProviderID(providerID) {
+		t.Errorf("expected %q not to be a pending machine providerID", providerID)
+	}
+	if got := machinePoolKeyFromPendingProviderID(providerID); got != "test-namespace/foo" {
+		t.Errorf("expected: %q, got: %q", "test-namespace/foo", got)
+	}
+}
+
 const CharSet = "0123456789abcdefghijklmnopqrstuvwxyz"
 
 var rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -143,6 +143,15 @@ func (ng *nodegroup) DeleteNodes(nodes []*corev1.Node) error {
 		if err != nil {
 			return err
 		}
+		if machine == nil && ng.scalableResource.Kind() == machinePoolKind {
+			// MachinePools backed by cloud-native groups don't have Machines, the
+			// replica is removed from the MachinePool providerIDList instead.
+			if err := ng.scalableResource.RemoveMachinePoolReplica(node.Spec.ProviderID, replicas-1); err != nil {
+				return err
+			}
+			replicas--
+			continue
+		}
 		if machine == nil {
 			return fmt.Errorf("unknown machine for node %q", node.Spec.ProviderID)
 		}
@@ -1303,6 +1303,96 @@ func TestNodeGroupWithFailedMachine(t *testing.T) {
 	})
 }
 
+func TestNodeGroupMachinePoolWithoutMachines(t *testing.T) {
+	testConfig := createMachineSetTestConfig(RandomString(6), RandomString(6), RandomString(6), 0, nil, nil)
+	testConfig.machinePool = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       machinePoolKind,
+			"apiVersion": "cluster.x-k8s.io/v1alpha3",
+			"metadata": map[string]interface{}{
+				"name":      "pool",
+				"namespace": testConfig.namespace,
+				"uid":       "pool",
+			},
+			"spec": map[string]interface{}{
+				"clusterName":    testConfig.clusterName,
+				"replicas":       int64(3),
+				"providerIDList": []interface{}{"test:////pool-node-0", "test:////pool-node-1"},
+			},
+			"status": map[string]interface{}{},
+		},
+	}
+	testConfig.machinePool.SetAnnotations(map[string]string{
+		nodeGroupMinSizeAnnotationKey: "1",
+		nodeGroupMaxSizeAnnotationKey: "10",
+	})
+	for i := 0; i < 2; i++ {
+		testConfig.nodes = append(testConfig.nodes, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pool-node-%d", i)},
+			Spec:       corev1.NodeSpec{ProviderID: fmt.Sprintf("test:////pool-node-%d", i)},
+		})
+	}
+
+	controller, stop := mustCreateTestController(t, testConfig)
+	defer stop()
+
+	nodegroups, err := controller.nodeGroups()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := len(nodegroups); l != 1 {
+		t.Fatalf("expected 1 nodegroup, got %d", l)
+	}
+	ng := nodegroups[0]
+
+	instances, err := ng.Nodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pendingID := fmt.Sprintf("%s%s_pool_2", pendingMachinePoolPrefix, testConfig.namespace)
+	expectedIDs := []string{"test:////pool-node-0", "test:////pool-node-1", pendingID}
+	if len(instances) != len(expectedIDs) {
+		t.Fatalf("expected %d instances, got %d", len(expectedIDs), len(instances))
+	}
+	for i := range instances {
+		if instances[i].Id != expectedIDs[i] {
+			t.Errorf("expected instance %q, got %q", expectedIDs[i], instances[i].Id)
+		}
+	}
+
+	// A pending replica is removed by scaling down only.
+	pendingNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: pendingID},
+		Spec:       corev1.NodeSpec{ProviderID: pendingID},
+	}
+	if err := ng.DeleteNodes([]*corev1.Node{pendingNode}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A registered replica is also removed from the providerIDList.
+	if err := ng.DeleteNodes([]*corev1.Node{testConfig.nodes[0]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	machinePool, err := controller.managementClient.Resource(controller.machinePoolResource).Namespace(testConfig.namespace).Get(context.TODO(), "pool", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replicas, _, err := unstructured.NestedInt64(machinePool.Object, "spec", "replicas")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replicas != 1 {
+		t.Errorf("expected 1 replica, got %d", replicas)
+	}
+	providerIDList, _, err := unstructured.NestedStringSlice(machinePool.Object, "spec", "providerIDList")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(providerIDList) != 1 || providerIDList[0] != "test:////pool-node-1" {
+		t.Errorf("expected providerIDList [test:////pool-node-1], got %v", providerIDList)
+	}
+}
+
 func TestNodeGroupTemplateNodeInfo(t *testing.T) {
 	enableScaleAnnotations := map[string]string{
 		nodeGroupMinSizeAnnotationKey: "1",
@@ -136,6 +136,52 @@ func (r unstructuredScalableResource) SetSize(nreplicas int) error {
 	return updateErr
 }
 
+// RemoveMachinePoolReplica removes providerID from the providerIDList of a
+// MachinePool and scales it to nreplicas in a single update. Pending replicas,
+// which don't have a providerID yet, are removed by scaling only.
+func (r unstructuredScalableResource) RemoveMachinePoolReplica(providerID string, nreplicas int) error {
+	if nreplicas < r.minSize {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", nreplicas, r.minSize)
+	}
+
+	gvr, err := r.GroupVersionResource()
+	if err != nil {
+		return err
+	}
+
+	u, err := r.controller.managementClient.Resource(gvr).Namespace(r.Namespace()).Get(context.TODO(), r.Name(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	u = u.DeepCopy()
+
+	providerIDList, _, err := unstructured.NestedStringSlice(u.UnstructuredContent(), "spec", "providerIDList")
+	if err != nil {
+		return err
+	}
+	remainingProviderIDs := make([]string, 0, len(providerIDList))
+	for _, id := range providerIDList {
+		if normalizedProviderString(id) != normalizedProviderString(providerID) {
+			remainingProviderIDs = append(remainingProviderIDs, id)
+		}
+	}
+	if err := unstructured.SetNestedStringSlice(u.UnstructuredContent(), remainingProviderIDs, "spec", "providerIDList"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(u.UnstructuredContent(), int64(nreplicas), "spec", "replicas"); err != nil {
+		return err
+	}
+
+	_, updateErr := r.controller.managementClient.Resource(gvr).Namespace(r.Namespace()).Update(context.TODO(), u, metav1.UpdateOptions{})
+
+	if updateErr == nil {
+		updateErr = unstructured.SetNestedField(r.unstructured.UnstructuredContent(), int64(nreplicas), "spec", "replicas")
+	}
+
+	return updateErr
+}
+
 func (r unstructuredScalableResource) UnmarkMachineForDeletion(machine *unstructured.Unstructured) error {
 	u, err := r.controller.managementClient.Resource(r.controller.machineResource).Namespace(machine.GetNamespace()).Get(context.TODO(), machine.GetName(), metav1.GetOptions{})
 	if err != nil {
@@ -42,7 +42,7 @@ type NodeRef struct {
 type equinixMetalManager interface {
 	nodeGroupSize(nodegroup string) (int, error)
 	createNodes(nodegroup string, nodes int) error
-	getNodes(nodegroup string) ([]string, error)
+	getNodes(nodegroup string) ([]cloudprovider.Instance, error)
 	getNodeNames(nodegroup string) ([]string, error)
 	deleteNodes(nodegroup string, nodes []NodeRef, updatedNodeCount int) error
 	templateNodeInfo(nodegroup string) (*schedulerframework.NodeInfo, error)
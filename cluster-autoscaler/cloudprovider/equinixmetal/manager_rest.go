@@ -49,6 +49,8 @@ const (
 	expectedAPIContentTypePrefix = "application/json"
 	prefix                       = "equinixmetal://"
 	metalAuthTokenEnv            = "METAL_AUTH_TOKEN"
+	// spotInstanceReclaimedErrorCode is the error code of spot market devices reclaimed by Equinix Metal.
+	spotInstanceReclaimedErrorCode = "spot-instance-reclaimed"
 )
 
 type instanceType struct {
@@ -158,6 +160,8 @@ type equinixMetalManagerNodePool struct {
 	cloudinit         string
 	reservation       string
 	hostnamePattern   string
+	spotInstance      bool
+	spotPriceMax      float64
 }
 
 type equinixMetalManagerRest struct {
@@ -167,16 +171,18 @@ type equinixMetalManagerRest struct {
 
 // ConfigNodepool options only include the project-id for now
 type ConfigNodepool struct {
-	ClusterName       string `gcfg:"cluster-name"`
-	ProjectID         string `gcfg:"project-id"`
-	APIServerEndpoint string `gcfg:"api-server-endpoint"`
-	Metro             string `gcfg:"metro"`
-	Plan              string `gcfg:"plan"`
-	OS                string `gcfg:"os"`
-	Billing           string `gcfg:"billing"`
-	CloudInit         string `gcfg:"cloudinit"`
-	Reservation       string `gcfg:"reservation"`
-	HostnamePattern   string `gcfg:"hostname-pattern"`
+	ClusterName       string  `gcfg:"cluster-name"`
+	ProjectID         string  `gcfg:"project-id"`
+	APIServerEndpoint string  `gcfg:"api-server-endpoint"`
+	Metro             string  `gcfg:"metro"`
+	Plan              string  `gcfg:"plan"`
+	OS                string  `gcfg:"os"`
+	Billing           string  `gcfg:"billing"`
+	CloudInit         string  `gcfg:"cloudinit"`
+	Reservation       string  `gcfg:"reservation"`
+	HostnamePattern   string  `gcfg:"hostname-pattern"`
+	SpotInstance      bool    `gcfg:"spot-instance"`
+	SpotPriceMax      float64 `gcfg:"spot-price-max"`
 }
 
 // ConfigFile is used to read and store information from the cloud configuration file
@@ -193,6 +199,10 @@ type Device struct {
 	Description string   `json:"description"`
 	State       string   `json:"state"`
 	Tags        []string `json:"tags"`
+	// SpotInstance is true for devices provisioned from the spot market.
+	SpotInstance bool `json:"spot_instance"`
+	// TerminationTime is set when the device is scheduled for termination, e.g. when a spot market device is reclaimed.
+	TerminationTime *time.Time `json:"termination_time,omitempty"`
 }
 
 // Devices represents a list of an Equinix Metal devices
@@ -220,6 +230,8 @@ type DeviceCreateRequest struct {
 	CustomData            string                   `json:"customdata,omitempty"`
 	IPAddresses           []IPAddressCreateRequest `json:"ip_addresses,omitempty"`
 	HardwareReservationID string                   `json:"hardware_reservation_id,omitempty"`
+	SpotInstance          bool                     `json:"spot_instance,omitempty"`
+	SpotPriceMax          float64                  `json:"spot_price_max,omitempty"`
 }
 
 // CloudInitTemplateData represents the variables that can be used in cloudinit templates
@@ -330,6 +342,11 @@ func createEquinixMetalManagerRest(configReader io.Reader, discoverOpts cloudpro
 			cloudinit:         cfg.Nodegroupdef[nodepool].CloudInit,
 			reservation:       cfg.Nodegroupdef[nodepool].Reservation,
 			hostnamePattern:   cfg.Nodegroupdef[nodepool].HostnamePattern,
+			spotInstance:      cfg.Nodegroupdef[nodepool].SpotInstance,
+			spotPriceMax:      cfg.Nodegroupdef[nodepool].SpotPriceMax,
+		}
+		if cfg.Nodegroupdef[nodepool].SpotInstance && cfg.Nodegroupdef[nodepool].SpotPriceMax <= 0 {
+			klog.Fatalf("The spot-price-max parameter must be set to a positive value for spot instances in nodepool %s", nodepool)
 		}
 	}
 
@@ -536,13 +553,17 @@ func (mgr *equinixMetalManagerRest) createDevice(ctx context.Context, hostname,
 		Tags:                  []string{"k8s-cluster-" + mgr.getNodePoolDefinition(nodegroup).clusterName, "k8s-nodepool-" + nodegroup},
 		HardwareReservationID: reservation,
 	}
+	if reservation == "" {
+		mgr.setSpotMarketBid(cr, nodegroup)
+	}
 
 	if err := mgr.createDeviceRequest(ctx, cr, nodegroup); err != nil {
-		// If reservation is preferred but not available, retry provisioning as on-demand
+		// If reservation is preferred but not available, retry provisioning as on-demand or spot market bid
 		if reservation != "" && mgr.getNodePoolDefinition(nodegroup).reservation == "prefer" && isNoAvailableReservationsError(err) {
 			klog.Infof("Reservation preferred but not available. Provisioning on-demand node.")
 
 			cr.HardwareReservationID = ""
+			mgr.setSpotMarketBid(cr, nodegroup)
 			return mgr.createDeviceRequest(ctx, cr, nodegroup)
 		}
 
@@ -552,6 +573,16 @@ func (mgr *equinixMetalManagerRest) createDevice(ctx context.Context, hostname,
 	return nil
 }
 
+// setSpotMarketBid turns the request into a spot market bid, if the nodegroup is configured for spot instances.
+func (mgr *equinixMetalManagerRest) setSpotMarketBid(cr *DeviceCreateRequest, nodegroup string) {
+	if !mgr.getNodePoolDefinition(nodegroup).spotInstance {
+		return
+	}
+	klog.Infof("Bidding for a spot market node with max price %v", mgr.getNodePoolDefinition(nodegroup).spotPriceMax)
+	cr.SpotInstance = true
+	cr.SpotPriceMax = mgr.getNodePoolDefinition(nodegroup).spotPriceMax
+}
+
 // TODO: find a better way than parsing the error messages for this.
 func isNoAvailableReservationsError(err error) bool {
 	return strings.Contains(err.Error(), " no available hardware reservations ")
@@ -575,26 +606,56 @@ func (mgr *equinixMetalManagerRest) createDeviceRequest(ctx context.Context, cr
 	return nil
 }
 
-// getNodes should return ProviderIDs for all nodes in the node group,
+// getNodes should return instances with ProviderIDs for all nodes in the node group,
 // used to find any nodes which are unregistered in kubernetes.
-func (mgr *equinixMetalManagerRest) getNodes(nodegroup string) ([]string, error) {
+func (mgr *equinixMetalManagerRest) getNodes(nodegroup string) ([]cloudprovider.Instance, error) {
 	// Get node ProviderIDs by getting device IDs from the Equinix Metal API
 	devices, err := mgr.listMetalDevices(context.TODO())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list devices: %w", err)
 	}
 
-	nodes := []string{}
+	nodes := []cloudprovider.Instance{}
 
 	for _, d := range devices.Devices {
 		if Contains(d.Tags, "k8s-cluster-"+mgr.getNodePoolDefinition(nodegroup).clusterName) && Contains(d.Tags, "k8s-nodepool-"+nodegroup) {
-			nodes = append(nodes, fmt.Sprintf("%s%s", prefix, d.ID))
+			nodes = append(nodes, cloudprovider.Instance{Id: fmt.Sprintf("%s%s", prefix, d.ID), Status: deviceStatus(d)})
 		}
 	}
 
 	return nodes, nil
 }
 
+// deviceStatus returns the status of the instance of a device. Reclaimed spot market devices which
+// never came up are reported as failing to be created, so that the nodegroup backs off instead of
+// bidding again right away. Reclaimed devices which are already running are reported as being deleted,
+// as their nodes may have registered and must not be removed without being drained.
+func deviceStatus(d Device) *cloudprovider.InstanceStatus {
+	if d.SpotInstance && d.TerminationTime != nil {
+		if d.State == "active" {
+			return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting}
+		}
+		return &cloudprovider.InstanceStatus{
+			State: cloudprovider.InstanceCreating,
+			ErrorInfo: &cloudprovider.InstanceErrorInfo{
+				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+				ErrorKind:    cloudprovider.CapacityErrorKind,
+				ErrorCode:    spotInstanceReclaimedErrorCode,
+				ErrorMessage: fmt.Sprintf("spot market device %s reclaimed, terminating at %v", d.Hostname, d.TerminationTime),
+			},
+		}
+	}
+	switch d.State {
+	case "queued", "provisioning":
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}
+	case "deprovisioning":
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting}
+	case "active":
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning}
+	}
+	return nil
+}
+
 // getNodeNames should return Names for all nodes in the node group,
 // used to find any nodes which are unregistered in kubernetes.
 func (mgr *equinixMetalManagerRest) getNodeNames(nodegroup string) ([]string, error) {
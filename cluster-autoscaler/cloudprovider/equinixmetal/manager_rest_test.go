@@ -21,6 +21,7 @@ import (
 	"os"
 	"testing"
 
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 
 	"github.com/stretchr/testify/assert"
@@ -99,3 +100,49 @@ func TestListMetalDevices(t *testing.T) {
 
 	mock.AssertExpectationsForObjects(t, server)
 }
+
+const listMetalDevicesResponseSpotReclaimed = `
+{"devices":[{"id":"cace3b27-dff8-4930-943d-b2a63a775f03","short_id":"cace3b27","hostname":"k8s-cluster2-pool3-gndxdmmw","description":null,"state":"active","tags":["k8s-cluster-cluster2","k8s-nodepool-pool3"],"spot_instance":true,"termination_time":"2024-05-02T10:04:00Z"},{"id":"8fa90049-e715-4794-ba31-81c1c78cee84","short_id":"8fa90049","hostname":"k8s-cluster2-pool3-xpnrwgdf","description":null,"state":"provisioning","tags":["k8s-cluster-cluster2","k8s-nodepool-pool3"],"spot_instance":true,"termination_time":null},{"id":"1e2d0c6d-5d1b-4a8f-9b55-3c1a0f1b6a4e","short_id":"1e2d0c6d","hostname":"k8s-cluster2-pool3-qzkvtrbn","description":null,"state":"provisioning","tags":["k8s-cluster-cluster2","k8s-nodepool-pool3"],"spot_instance":true,"termination_time":"2024-05-02T10:04:00Z"}]}
+`
+
+func TestGetNodesSpotInstanceReclaimed(t *testing.T) {
+	server := NewHttpServerMock(MockFieldContentType, MockFieldResponse)
+	defer server.Close()
+	m := newTestMetalManagerRest(t, server.URL)
+	server.On("handle", "/projects/"+m.equinixMetalManagerNodePools["default"].projectID+"/devices").Return("application/json", listMetalDevicesResponseSpotReclaimed).Times(1)
+
+	instances, err := m.getNodes("pool3")
+	assert.NoError(t, err)
+	assert.Len(t, instances, 3)
+
+	// Running devices which are reclaimed may have registered, so they are only reported as being deleted.
+	assert.Equal(t, "equinixmetal://cace3b27-dff8-4930-943d-b2a63a775f03", instances[0].Id)
+	assert.Equal(t, &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting}, instances[0].Status)
+
+	assert.Equal(t, "equinixmetal://8fa90049-e715-4794-ba31-81c1c78cee84", instances[1].Id)
+	assert.Equal(t, &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}, instances[1].Status)
+
+	// Devices reclaimed before they came up failed to be created.
+	assert.Equal(t, "equinixmetal://1e2d0c6d-5d1b-4a8f-9b55-3c1a0f1b6a4e", instances[2].Id)
+	assert.Equal(t, cloudprovider.InstanceCreating, instances[2].Status.State)
+	assert.NotNil(t, instances[2].Status.ErrorInfo)
+	assert.Equal(t, cloudprovider.OutOfResourcesErrorClass, instances[2].Status.ErrorInfo.ErrorClass)
+	assert.Equal(t, spotInstanceReclaimedErrorCode, instances[2].Status.ErrorInfo.ErrorCode)
+
+	mock.AssertExpectationsForObjects(t, server)
+}
+
+func TestSetSpotMarketBid(t *testing.T) {
+	m := newTestMetalManagerRest(t, "")
+	m.equinixMetalManagerNodePools["pool2"].spotInstance = true
+	m.equinixMetalManagerNodePools["pool2"].spotPriceMax = 0.35
+
+	cr := &DeviceCreateRequest{}
+	m.setSpotMarketBid(cr, "default")
+	assert.False(t, cr.SpotInstance)
+	assert.Zero(t, cr.SpotPriceMax)
+
+	m.setSpotMarketBid(cr, "pool2")
+	assert.True(t, cr.SpotInstance)
+	assert.Equal(t, 0.35, cr.SpotPriceMax)
+}
@@ -248,14 +248,10 @@ func (ng *equinixMetalNodeGroup) Debug() string {
 
 // Nodes returns a list of nodes that belong to this node group.
 func (ng *equinixMetalNodeGroup) Nodes() ([]cloudprovider.Instance, error) {
-	nodes, err := ng.equinixMetalManager.getNodes(ng.id)
+	instances, err := ng.equinixMetalManager.getNodes(ng.id)
 	if err != nil {
 		return nil, fmt.Errorf("could not get nodes: %v", err)
 	}
-	var instances []cloudprovider.Instance
-	for _, node := range nodes {
-		instances = append(instances, cloudprovider.Instance{Id: node})
-	}
 	return instances, nil
 }
 
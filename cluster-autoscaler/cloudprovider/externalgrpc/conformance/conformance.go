@@ -0,0 +1,404 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance checks that an external gRPC cloud provider service behaves
+// the way the externalgrpc cloud provider of Cluster Autoscaler expects it to.
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
+)
+
+const (
+	// nonExistingNodeGroupID is the id of a node group no server should know.
+	nonExistingNodeGroupID = "conformance-non-existing-node-group"
+	// nonExistingProviderID is the provider id of a node no server should know.
+	nonExistingProviderID = "conformance://non-existing-node"
+)
+
+// Config configures the conformance suite.
+type Config struct {
+	// CallTimeout is the timeout of a single call.
+	CallTimeout time.Duration
+	// MaxLatency is the maximum average latency of the calls Cluster Autoscaler makes
+	// in every loop, i.e. listing node groups, their sizes and instances.
+	MaxLatency time.Duration
+	// LatencySamples is the number of calls the average latency is measured over.
+	LatencySamples int
+	// Mutating enables the checks scaling a node group up and down. They create and
+	// delete an instance, so they should only be enabled against test environments.
+	Mutating bool
+	// NodeGroupID is the id of the node group the mutating checks scale. If empty,
+	// the first node group with room for one more instance is used.
+	NodeGroupID string
+	// ScaleUpTimeout is how long the mutating checks wait for a new instance to be listed.
+	ScaleUpTimeout time.Duration
+	// PollInterval is how often the mutating checks list instances while waiting.
+	PollInterval time.Duration
+}
+
+// DefaultConfig returns the config used by the suite when none is passed.
+func DefaultConfig() Config {
+	return Config{
+		CallTimeout:    5 * time.Second,
+		MaxLatency:     500 * time.Millisecond,
+		LatencySamples: 10,
+		ScaleUpTimeout: 10 * time.Minute,
+		PollInterval:   10 * time.Second,
+	}
+}
+
+type suite struct {
+	client protos.CloudProviderClient
+	config Config
+}
+
+// Run runs the conformance suite against the server the client is connected to,
+// every group of checks as a subtest of t.
+func Run(t *testing.T, client protos.CloudProviderClient, config Config) {
+	s := &suite{client: client, config: config}
+	t.Run("NodeGroups", s.testNodeGroups)
+	t.Run("NodeGroupNodes", s.testNodeGroupNodes)
+	t.Run("NodeGroupForNode", s.testNodeGroupForNode)
+	t.Run("NodeGroupTargetSize", s.testNodeGroupTargetSize)
+	t.Run("GPU", s.testGPU)
+	t.Run("Refresh", s.testRefresh)
+	t.Run("OptionalRPCs", s.testOptionalRPCs)
+	t.Run("UnknownNodeGroup", s.testUnknownNodeGroup)
+	t.Run("InvalidArguments", s.testInvalidArguments)
+	t.Run("Latency", s.testLatency)
+	if config.Mutating {
+		t.Run("Scaling", s.testScaling)
+		t.Run("Cleanup", s.testCleanup)
+	}
+}
+
+func (s *suite) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.config.CallTimeout)
+}
+
+func (s *suite) nodeGroups(t *testing.T) []*protos.NodeGroup {
+	t.Helper()
+	ctx, cancel := s.context()
+	defer cancel()
+	res, err := s.client.NodeGroups(ctx, &protos.NodeGroupsRequest{})
+	require.NoError(t, err)
+	return res.GetNodeGroups()
+}
+
+func (s *suite) instances(t *testing.T, id string) []*protos.Instance {
+	t.Helper()
+	ctx, cancel := s.context()
+	defer cancel()
+	res, err := s.client.NodeGroupNodes(ctx, &protos.NodeGroupNodesRequest{Id: id})
+	require.NoError(t, err)
+	return res.GetInstances()
+}
+
+func (s *suite) targetSize(t *testing.T, id string) int32 {
+	t.Helper()
+	ctx, cancel := s.context()
+	defer cancel()
+	res, err := s.client.NodeGroupTargetSize(ctx, &protos.NodeGroupTargetSizeRequest{Id: id})
+	require.NoError(t, err)
+	return res.GetTargetSize()
+}
+
+func (s *suite) nodeGroupForNode(t *testing.T, providerID string) string {
+	t.Helper()
+	ctx, cancel := s.context()
+	defer cancel()
+	res, err := s.client.NodeGroupForNode(ctx, &protos.NodeGroupForNodeRequest{Node: externalGrpcNode(providerID)})
+	require.NoError(t, err)
+	return res.GetNodeGroup().GetId()
+}
+
+func externalGrpcNode(providerID string) *protos.ExternalGrpcNode {
+	return &protos.ExternalGrpcNode{ProviderID: providerID, Name: providerID}
+}
+
+// testNodeGroups checks the node groups are valid: ids are unique and sizes are consistent.
+func (s *suite) testNodeGroups(t *testing.T) {
+	nodeGroups := s.nodeGroups(t)
+	require.NotEmpty(t, nodeGroups, "the server must have at least one node group")
+	ids := make(map[string]bool)
+	for _, ng := range nodeGroups {
+		assert.NotEmpty(t, ng.GetId(), "node group ids must not be empty")
+		assert.False(t, ids[ng.GetId()], "node group id %q is not unique", ng.GetId())
+		ids[ng.GetId()] = true
+		assert.GreaterOrEqual(t, ng.GetMinSize(), int32(0), "node group %q: min size must not be negative", ng.GetId())
+		assert.LessOrEqual(t, ng.GetMinSize(), ng.GetMaxSize(), "node group %q: min size must not exceed max size", ng.GetId())
+	}
+}
+
+// testNodeGroupNodes checks the instances of every node group have unique, non-empty ids.
+func (s *suite) testNodeGroupNodes(t *testing.T) {
+	ids := make(map[string]string)
+	for _, ng := range s.nodeGroups(t) {
+		for _, instance := range s.instances(t, ng.GetId()) {
+			assert.NotEmpty(t, instance.GetId(), "node group %q: instance ids must not be empty", ng.GetId())
+			if other, found := ids[instance.GetId()]; found {
+				assert.Fail(t, "instance ids must be unique", "instance %q is listed in node groups %q and %q", instance.GetId(), other, ng.GetId())
+			}
+			ids[instance.GetId()] = ng.GetId()
+		}
+	}
+}
+
+// testNodeGroupForNode checks every listed instance maps back to its node group, and
+// nodes unknown to the server map to the empty node group instead of an error.
+func (s *suite) testNodeGroupForNode(t *testing.T) {
+	for _, ng := range s.nodeGroups(t) {
+		for _, instance := range s.instances(t, ng.GetId()) {
+			assert.Equal(t, ng.GetId(), s.nodeGroupForNode(t, instance.GetId()), "instance %q", instance.GetId())
+		}
+	}
+	assert.Empty(t, s.nodeGroupForNode(t, nonExistingProviderID), "nodes not managed by the server must map to the node group with an empty id")
+}
+
+// testNodeGroupTargetSize checks target sizes aren't negative.
+func (s *suite) testNodeGroupTargetSize(t *testing.T) {
+	for _, ng := range s.nodeGroups(t) {
+		assert.GreaterOrEqual(t, s.targetSize(t, ng.GetId()), int32(0), "node group %q", ng.GetId())
+	}
+}
+
+// testGPU checks the GPU RPCs, which aren't optional, succeed.
+func (s *suite) testGPU(t *testing.T) {
+	ctx, cancel := s.context()
+	defer cancel()
+	_, err := s.client.GPULabel(ctx, &protos.GPULabelRequest{})
+	assert.NoError(t, err)
+	_, err = s.client.GetAvailableGPUTypes(ctx, &protos.GetAvailableGPUTypesRequest{})
+	assert.NoError(t, err)
+}
+
+// testRefresh checks Refresh succeeds and doesn't change the node groups.
+func (s *suite) testRefresh(t *testing.T) {
+	before := s.nodeGroups(t)
+	ctx, cancel := s.context()
+	defer cancel()
+	_, err := s.client.Refresh(ctx, &protos.RefreshRequest{})
+	require.NoError(t, err)
+	after := s.nodeGroups(t)
+	assert.Equal(t, len(before), len(after))
+}
+
+// testOptionalRPCs checks the optional RPCs either succeed or return Unimplemented.
+func (s *suite) testOptionalRPCs(t *testing.T) {
+	nodeGroups := s.nodeGroups(t)
+	require.NotEmpty(t, nodeGroups)
+	ng := nodeGroups[0]
+	now := metav1.Now()
+	later := metav1.NewTime(now.Add(time.Hour))
+	ctx, cancel := s.context()
+	defer cancel()
+
+	_, err := s.client.PricingNodePrice(ctx, &protos.PricingNodePriceRequest{Node: externalGrpcNode(nonExistingProviderID), StartTime: &now, EndTime: &later})
+	assertOptional(t, err, "PricingNodePrice")
+	_, err = s.client.PricingPodPrice(ctx, &protos.PricingPodPriceRequest{Pod: &apiv1.Pod{}, StartTime: &now, EndTime: &later})
+	assertOptional(t, err, "PricingPodPrice")
+
+	template, err := s.client.NodeGroupTemplateNodeInfo(ctx, &protos.NodeGroupTemplateNodeInfoRequest{Id: ng.GetId()})
+	if assertOptional(t, err, "NodeGroupTemplateNodeInfo") {
+		assert.NotNil(t, template.GetNodeInfo(), "NodeGroupTemplateNodeInfo must return a node")
+	}
+
+	options, err := s.client.NodeGroupGetOptions(ctx, &protos.NodeGroupAutoscalingOptionsRequest{
+		Id: ng.GetId(),
+		Defaults: &protos.NodeGroupAutoscalingOptions{
+			ScaleDownUtilizationThreshold:    0.5,
+			ScaleDownGpuUtilizationThreshold: 0.5,
+			ScaleDownUnneededTime:            &metav1.Duration{Duration: 10 * time.Minute},
+			ScaleDownUnreadyTime:             &metav1.Duration{Duration: 20 * time.Minute},
+			MaxNodeProvisionTime:             &metav1.Duration{Duration: 15 * time.Minute},
+		},
+	})
+	if assertOptional(t, err, "NodeGroupGetOptions") {
+		assert.NotNil(t, options.GetNodeGroupAutoscalingOptions(), "NodeGroupGetOptions must return options")
+	}
+}
+
+// assertOptional checks an optional RPC either succeeded or returned Unimplemented,
+// and returns whether it succeeded.
+func assertOptional(t *testing.T, err error, rpc string) bool {
+	t.Helper()
+	if err == nil {
+		return true
+	}
+	assert.Equal(t, codes.Unimplemented, status.Code(err), "%s must succeed or return Unimplemented, got: %v", rpc, err)
+	return false
+}
+
+// testUnknownNodeGroup checks the node group RPCs return NotFound for unknown node groups.
+func (s *suite) testUnknownNodeGroup(t *testing.T) {
+	id := nonExistingNodeGroupID
+	ctx, cancel := s.context()
+	defer cancel()
+	calls := map[string]func() error{
+		"NodeGroupTargetSize": func() error {
+			_, err := s.client.NodeGroupTargetSize(ctx, &protos.NodeGroupTargetSizeRequest{Id: id})
+			return err
+		},
+		"NodeGroupIncreaseSize": func() error {
+			_, err := s.client.NodeGroupIncreaseSize(ctx, &protos.NodeGroupIncreaseSizeRequest{Id: id, Delta: 1})
+			return err
+		},
+		"NodeGroupDeleteNodes": func() error {
+			_, err := s.client.NodeGroupDeleteNodes(ctx, &protos.NodeGroupDeleteNodesRequest{Id: id, Nodes: []*protos.ExternalGrpcNode{externalGrpcNode(nonExistingProviderID)}})
+			return err
+		},
+		"NodeGroupDecreaseTargetSize": func() error {
+			_, err := s.client.NodeGroupDecreaseTargetSize(ctx, &protos.NodeGroupDecreaseTargetSizeRequest{Id: id, Delta: -1})
+			return err
+		},
+		"NodeGroupNodes": func() error {
+			_, err := s.client.NodeGroupNodes(ctx, &protos.NodeGroupNodesRequest{Id: id})
+			return err
+		},
+		"NodeGroupTemplateNodeInfo": func() error {
+			_, err := s.client.NodeGroupTemplateNodeInfo(ctx, &protos.NodeGroupTemplateNodeInfoRequest{Id: id})
+			return err
+		},
+		"NodeGroupGetOptions": func() error {
+			_, err := s.client.NodeGroupGetOptions(ctx, &protos.NodeGroupAutoscalingOptionsRequest{Id: id, Defaults: &protos.NodeGroupAutoscalingOptions{}})
+			return err
+		},
+	}
+	for rpc, call := range calls {
+		err := call()
+		if status.Code(err) == codes.Unimplemented && (rpc == "NodeGroupTemplateNodeInfo" || rpc == "NodeGroupGetOptions") {
+			continue
+		}
+		assert.Equal(t, codes.NotFound, status.Code(err), "%s must return NotFound for unknown node groups, got: %v", rpc, err)
+	}
+}
+
+// testInvalidArguments checks requests with missing mandatory fields are rejected.
+func (s *suite) testInvalidArguments(t *testing.T) {
+	ctx, cancel := s.context()
+	defer cancel()
+	_, err := s.client.NodeGroupForNode(ctx, &protos.NodeGroupForNodeRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "NodeGroupForNode without node must return InvalidArgument, got: %v", err)
+}
+
+// testLatency checks the RPCs called in every Cluster Autoscaler loop are fast enough.
+func (s *suite) testLatency(t *testing.T) {
+	nodeGroups := s.nodeGroups(t)
+	require.NotEmpty(t, nodeGroups)
+	id := nodeGroups[0].GetId()
+	calls := map[string]func(){
+		"NodeGroups":          func() { s.nodeGroups(t) },
+		"NodeGroupTargetSize": func() { s.targetSize(t, id) },
+		"NodeGroupNodes":      func() { s.instances(t, id) },
+		"NodeGroupForNode":    func() { s.nodeGroupForNode(t, nonExistingProviderID) },
+	}
+	for rpc, call := range calls {
+		start := time.Now()
+		for i := 0; i < s.config.LatencySamples; i++ {
+			call()
+		}
+		average := time.Since(start) / time.Duration(s.config.LatencySamples)
+		assert.LessOrEqual(t, average, s.config.MaxLatency, "average latency of %s is too high", rpc)
+	}
+}
+
+// testScaling scales a node group up by one instance and deletes the new instance.
+func (s *suite) testScaling(t *testing.T) {
+	ng := s.scalableNodeGroup(t)
+	id := ng.GetId()
+	before := s.targetSize(t, id)
+	existing := make(map[string]bool)
+	for _, instance := range s.instances(t, id) {
+		existing[instance.GetId()] = true
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+	_, err := s.client.NodeGroupIncreaseSize(ctx, &protos.NodeGroupIncreaseSizeRequest{Id: id, Delta: ng.GetMaxSize() - before + 1})
+	assert.Error(t, err, "increasing the size over max size must fail")
+	_, err = s.client.NodeGroupDecreaseTargetSize(ctx, &protos.NodeGroupDecreaseTargetSizeRequest{Id: id, Delta: 1})
+	assert.Error(t, err, "decreasing the target size with a positive delta must fail")
+	_, err = s.client.NodeGroupDeleteNodes(ctx, &protos.NodeGroupDeleteNodesRequest{Id: id, Nodes: []*protos.ExternalGrpcNode{externalGrpcNode(nonExistingProviderID)}})
+	assert.Error(t, err, "deleting nodes not belonging to the node group must fail")
+	require.Equal(t, before, s.targetSize(t, id), "failed calls must not change the target size")
+
+	_, err = s.client.NodeGroupIncreaseSize(ctx, &protos.NodeGroupIncreaseSizeRequest{Id: id, Delta: 1})
+	require.NoError(t, err)
+	require.Equal(t, before+1, s.targetSize(t, id), "the target size must be updated when NodeGroupIncreaseSize returns")
+
+	created := s.waitForNewInstance(t, id, existing)
+	assert.Equal(t, id, s.nodeGroupForNode(t, created), "the new instance must belong to the scaled node group")
+
+	ctx, cancel = s.context()
+	defer cancel()
+	_, err = s.client.NodeGroupDeleteNodes(ctx, &protos.NodeGroupDeleteNodesRequest{Id: id, Nodes: []*protos.ExternalGrpcNode{externalGrpcNode(created)}})
+	require.NoError(t, err)
+	assert.Equal(t, before, s.targetSize(t, id), "the target size must be updated when NodeGroupDeleteNodes returns")
+}
+
+// scalableNodeGroup returns the configured node group, or the first one with room for one more instance.
+func (s *suite) scalableNodeGroup(t *testing.T) *protos.NodeGroup {
+	t.Helper()
+	for _, ng := range s.nodeGroups(t) {
+		if s.config.NodeGroupID != "" {
+			if ng.GetId() == s.config.NodeGroupID {
+				return ng
+			}
+			continue
+		}
+		if size := s.targetSize(t, ng.GetId()); size < ng.GetMaxSize() && size >= ng.GetMinSize() {
+			return ng
+		}
+	}
+	require.FailNow(t, "no node group to scale", "node group id: %q", s.config.NodeGroupID)
+	return nil
+}
+
+// waitForNewInstance waits until the node group lists an instance which isn't in existing, and returns its id.
+func (s *suite) waitForNewInstance(t *testing.T, id string, existing map[string]bool) string {
+	t.Helper()
+	deadline := time.Now().Add(s.config.ScaleUpTimeout)
+	for {
+		for _, instance := range s.instances(t, id) {
+			if !existing[instance.GetId()] {
+				return instance.GetId()
+			}
+		}
+		if time.Now().After(deadline) {
+			require.FailNow(t, "new instance not listed", "node group %q didn't list a new instance within %v", id, s.config.ScaleUpTimeout)
+		}
+		time.Sleep(s.config.PollInterval)
+	}
+}
+
+// testCleanup checks Cleanup succeeds. It runs last, as the server may release its resources.
+func (s *suite) testCleanup(t *testing.T) {
+	ctx, cancel := s.context()
+	defer cancel()
+	_, err := s.client.Cleanup(ctx, &protos.CleanupRequest{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"flag"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/examples/external-grpc-cloud-provider-service/inmemory"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/examples/external-grpc-cloud-provider-service/wrapper"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
+)
+
+var (
+	address   = flag.String("conformance-address", "", "Address of the external gRPC cloud provider service to test. If empty, the suite runs against the in-memory reference server.")
+	mutating  = flag.Bool("conformance-mutating", false, "Run the checks scaling a node group up and down. Always enabled for the in-memory reference server.")
+	nodeGroup = flag.String("conformance-node-group", "", "Id of the node group scaled by the mutating checks.")
+)
+
+func TestConformance(t *testing.T) {
+	config := DefaultConfig()
+	config.Mutating = *mutating
+	config.NodeGroupID = *nodeGroup
+	target := *address
+	if target == "" {
+		target = startReferenceServer(t)
+		config.Mutating = true
+	}
+
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	Run(t, protos.NewCloudProviderClient(conn), config)
+}
+
+// startReferenceServer serves the in-memory backend with the reference server and returns its address.
+func startReferenceServer(t *testing.T) string {
+	t.Helper()
+	provider, err := inmemory.NewCloudProvider(&inmemory.Config{NodeGroups: []inmemory.NodeGroupConfig{
+		{ID: "ng-1", MinSize: 0, MaxSize: 3, TargetSize: 1, CPU: "4", Memory: "16Gi", Pods: "110"},
+		{ID: "ng-2", MinSize: 1, MaxSize: 1, TargetSize: 1, CPU: "8", Memory: "32Gi", Pods: "110"},
+	}})
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	protos.RegisterCloudProviderServer(server, wrapper.NewCloudProviderGrpcWrapper(provider))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
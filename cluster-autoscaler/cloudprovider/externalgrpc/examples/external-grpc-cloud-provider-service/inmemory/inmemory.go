@@ -0,0 +1,373 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// ProviderName is the name of the in-memory cloud provider.
+	ProviderName = "in-memory"
+	// providerIDPrefix is the prefix of the provider ids of the in-memory instances.
+	providerIDPrefix = "in-memory://"
+)
+
+// Config is the configuration of the in-memory cloud provider.
+type Config struct {
+	NodeGroups []NodeGroupConfig `yaml:"nodeGroups"`
+}
+
+// NodeGroupConfig is the configuration of a single in-memory node group.
+type NodeGroupConfig struct {
+	ID         string `yaml:"id"`
+	MinSize    int    `yaml:"minSize"`
+	MaxSize    int    `yaml:"maxSize"`
+	TargetSize int    `yaml:"targetSize"`
+	// CPU, Memory and Pods are the capacity of the nodes of the node group, in resource.Quantity format.
+	CPU    string            `yaml:"cpu"`
+	Memory string            `yaml:"memory"`
+	Pods   string            `yaml:"pods"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// LoadConfig reads the configuration of the in-memory cloud provider from a yaml file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read in-memory cloud provider config: %v", err)
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse in-memory cloud provider config: %v", err)
+	}
+	return cfg, nil
+}
+
+// CloudProvider is a cloud provider keeping its node groups and instances in memory.
+// Instances are created and deleted immediately. It is meant for developing and testing
+// external gRPC cloud provider clients and servers, not for running real clusters.
+type CloudProvider struct {
+	sync.Mutex
+	nodeGroups []*NodeGroup
+}
+
+// NewCloudProvider builds an in-memory cloud provider with the node groups of the config.
+func NewCloudProvider(cfg *Config) (*CloudProvider, error) {
+	provider := &CloudProvider{}
+	ids := make(map[string]bool)
+	for _, ngConfig := range cfg.NodeGroups {
+		if ngConfig.ID == "" {
+			return nil, fmt.Errorf("node group id can't be empty")
+		}
+		if ids[ngConfig.ID] {
+			return nil, fmt.Errorf("duplicate node group id %q", ngConfig.ID)
+		}
+		ids[ngConfig.ID] = true
+		if ngConfig.MinSize < 0 || ngConfig.MinSize > ngConfig.MaxSize {
+			return nil, fmt.Errorf("node group %q: invalid size range [%d, %d]", ngConfig.ID, ngConfig.MinSize, ngConfig.MaxSize)
+		}
+		if ngConfig.TargetSize < ngConfig.MinSize || ngConfig.TargetSize > ngConfig.MaxSize {
+			return nil, fmt.Errorf("node group %q: target size %d out of range [%d, %d]", ngConfig.ID, ngConfig.TargetSize, ngConfig.MinSize, ngConfig.MaxSize)
+		}
+		template, err := buildNodeTemplate(ngConfig)
+		if err != nil {
+			return nil, err
+		}
+		ng := &NodeGroup{
+			provider: provider,
+			id:       ngConfig.ID,
+			minSize:  ngConfig.MinSize,
+			maxSize:  ngConfig.MaxSize,
+			template: template,
+		}
+		ng.createInstances(ngConfig.TargetSize)
+		provider.nodeGroups = append(provider.nodeGroups, ng)
+	}
+	return provider, nil
+}
+
+func buildNodeTemplate(ngConfig NodeGroupConfig) (*apiv1.Node, error) {
+	capacity := apiv1.ResourceList{}
+	for name, value := range map[apiv1.ResourceName]string{
+		apiv1.ResourceCPU:    ngConfig.CPU,
+		apiv1.ResourceMemory: ngConfig.Memory,
+		apiv1.ResourcePods:   ngConfig.Pods,
+	} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("node group %q: invalid %s %q: %v", ngConfig.ID, name, value, err)
+		}
+		capacity[name] = quantity
+	}
+	labels := map[string]string{apiv1.LabelOSStable: "linux"}
+	for key, value := range ngConfig.Labels {
+		labels[key] = value
+	}
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("%s-template", ngConfig.ID),
+			Labels: labels,
+		},
+		Status: apiv1.NodeStatus{
+			Capacity:    capacity,
+			Allocatable: capacity,
+			Conditions:  cloudprovider.BuildReadyConditions(),
+		},
+	}, nil
+}
+
+// Name returns name of the cloud provider.
+func (p *CloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (p *CloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	result := make([]cloudprovider.NodeGroup, 0, len(p.nodeGroups))
+	for _, ng := range p.nodeGroups {
+		result = append(result, ng)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node, nil if the node
+// doesn't belong to any node group.
+func (p *CloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	p.Lock()
+	defer p.Unlock()
+	for _, ng := range p.nodeGroups {
+		if _, found := ng.instances[node.Spec.ProviderID]; found {
+			return ng, nil
+		}
+	}
+	return nil, nil
+}
+
+// HasInstance returns whether the node has corresponding instance in cloud provider.
+func (p *CloudProvider) HasInstance(node *apiv1.Node) (bool, error) {
+	ng, err := p.NodeGroupForNode(node)
+	return ng != nil, err
+}
+
+// Pricing is not implemented.
+func (p *CloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes is not implemented.
+func (p *CloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup is not implemented.
+func (p *CloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns a resource limiter without limits.
+func (p *CloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return cloudprovider.NewResourceLimiter(nil, nil), nil
+}
+
+// GPULabel returns the label added to nodes with GPU resource.
+func (p *CloudProvider) GPULabel() string {
+	return ""
+}
+
+// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+func (p *CloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	return nil
+}
+
+// GetNodeGpuConfig returns the label, type and resource name for the GPU added to node.
+func (p *CloudProvider) GetNodeGpuConfig(node *apiv1.Node) *cloudprovider.GpuConfig {
+	return gpu.GetNodeGPUFromCloudProvider(p, node)
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (p *CloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh is called before every main loop. The in-memory state is always up to date.
+func (p *CloudProvider) Refresh() error {
+	return nil
+}
+
+// NodeGroup is an in-memory node group. All its methods lock the cloud provider.
+type NodeGroup struct {
+	provider *CloudProvider
+	id       string
+	minSize  int
+	maxSize  int
+	template *apiv1.Node
+	// instances are indexed by provider id.
+	instances map[string]cloudprovider.Instance
+	nextIndex int
+}
+
+// createInstances creates count running instances.
+func (ng *NodeGroup) createInstances(count int) {
+	if ng.instances == nil {
+		ng.instances = make(map[string]cloudprovider.Instance)
+	}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("%s%s-%d", providerIDPrefix, ng.id, ng.nextIndex)
+		ng.nextIndex++
+		ng.instances[id] = cloudprovider.Instance{
+			Id:     id,
+			Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning},
+		}
+	}
+}
+
+// MaxSize returns maximum size of the node group.
+func (ng *NodeGroup) MaxSize() int {
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *NodeGroup) MinSize() int {
+	return ng.minSize
+}
+
+// TargetSize returns the current target size of the node group. Instances are
+// created and deleted immediately, so it's always the number of instances.
+func (ng *NodeGroup) TargetSize() (int, error) {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	return len(ng.instances), nil
+}
+
+// IncreaseSize creates delta new instances.
+func (ng *NodeGroup) IncreaseSize(delta int) error {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive, got %d", delta)
+	}
+	if len(ng.instances)+delta > ng.maxSize {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", len(ng.instances)+delta, ng.maxSize)
+	}
+	ng.createInstances(delta)
+	return nil
+}
+
+// AtomicIncreaseSize is not implemented.
+func (ng *NodeGroup) AtomicIncreaseSize(delta int) error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// DeleteNodes deletes the instances of the nodes. An error is returned, and no
+// instance is deleted, if any of the nodes doesn't belong to the node group.
+func (ng *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	for _, node := range nodes {
+		if _, found := ng.instances[node.Spec.ProviderID]; !found {
+			return fmt.Errorf("node %q doesn't belong to node group %q", node.Name, ng.id)
+		}
+	}
+	if len(ng.instances)-len(nodes) < ng.minSize {
+		return fmt.Errorf("size decrease too large - desired:%d min:%d", len(ng.instances)-len(nodes), ng.minSize)
+	}
+	for _, node := range nodes {
+		delete(ng.instances, node.Spec.ProviderID)
+	}
+	return nil
+}
+
+// DecreaseTargetSize decreases the target size of the node group. Instances are
+// created immediately, so there are never requests for new nodes to cancel.
+func (ng *NodeGroup) DecreaseTargetSize(delta int) error {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative, got %d", delta)
+	}
+	return fmt.Errorf("attempt to delete existing nodes, target size: %d, delta: %d", len(ng.instances), delta)
+}
+
+// Id returns an unique identifier of the node group.
+func (ng *NodeGroup) Id() string {
+	return ng.id
+}
+
+// Debug returns a string containing all information regarding this node group.
+func (ng *NodeGroup) Debug() string {
+	return fmt.Sprintf("%s (%d:%d)", ng.id, ng.minSize, ng.maxSize)
+}
+
+// Nodes returns a list of all nodes that belong to this node group.
+func (ng *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	ng.provider.Lock()
+	defer ng.provider.Unlock()
+	instances := make([]cloudprovider.Instance, 0, len(ng.instances))
+	for _, instance := range ng.instances {
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template for this node group.
+func (ng *NodeGroup) TemplateNodeInfo() (*schedulerframework.NodeInfo, error) {
+	nodeInfo := schedulerframework.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.id))
+	nodeInfo.SetNode(ng.template.DeepCopy())
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (ng *NodeGroup) Exist() bool {
+	return true
+}
+
+// Create is not implemented.
+func (ng *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete is not implemented.
+func (ng *NodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *NodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// GetOptions is not implemented, the defaults are used for all node groups.
+func (ng *NodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
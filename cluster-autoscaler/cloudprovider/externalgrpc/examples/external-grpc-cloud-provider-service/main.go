@@ -22,11 +22,14 @@ import (
 	"flag"
 	"io/ioutil"
 	"net"
+	"sort"
 	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/examples/external-grpc-cloud-provider-service/inmemory"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/examples/external-grpc-cloud-provider-service/wrapper"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/gce/localssdsize"
@@ -62,6 +65,11 @@ var (
 	cert    = flag.String("cert", "", "The path to the certificate file. Empty string for insecure communication.")
 	cacert  = flag.String("ca-cert", "", "The path to the ca certificate file. Empty string for insecure communication.")
 
+	// backend serving the cloud provider calls
+	backendFlag = flag.String("backend", cloudProviderBackend,
+		"Backend serving the cloud provider calls. Available values: ["+strings.Join(availableBackends(), ",")+"]")
+	inMemoryConfig = flag.String("in-memory-config", "", "The path to the node groups configuration file of the in-memory backend.")
+
 	// flags needed by the specific cloud provider
 	cloudProviderFlag = flag.String("cloud-provider", cloudBuilder.DefaultCloudProvider,
 		"Cloud provider type. Available values: ["+strings.Join(cloudBuilder.AvailableCloudProviders, ",")+"]")
@@ -79,6 +87,50 @@ var (
 			"Can be used multiple times.")
 )
 
+const (
+	// cloudProviderBackend serves the calls with one of the in-tree cloud providers.
+	cloudProviderBackend = "cloud-provider"
+)
+
+// backends build the cloud provider serving the calls, by backend name.
+var backends = map[string]func() (cloudprovider.CloudProvider, error){
+	cloudProviderBackend:  buildInTreeCloudProvider,
+	inmemory.ProviderName: buildInMemoryCloudProvider,
+}
+
+func availableBackends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func buildInTreeCloudProvider() (cloudprovider.CloudProvider, error) {
+	autoscalingOptions := config.AutoscalingOptions{
+		CloudProviderName:      *cloudProviderFlag,
+		CloudConfig:            *cloudConfig,
+		NodeGroupAutoDiscovery: *nodeGroupAutoDiscoveryFlag,
+		NodeGroups:             *nodeGroupsFlag,
+		ClusterName:            *clusterName,
+		GCEOptions: config.GCEOptions{
+			ConcurrentRefreshes:      1,
+			LocalSSDDiskSizeProvider: localssdsize.NewSimpleLocalSSDProvider(),
+		},
+		UserAgent: "user-agent",
+	}
+	return cloudBuilder.NewCloudProvider(autoscalingOptions, nil), nil
+}
+
+func buildInMemoryCloudProvider() (cloudprovider.CloudProvider, error) {
+	cfg, err := inmemory.LoadConfig(*inMemoryConfig)
+	if err != nil {
+		return nil, err
+	}
+	return inmemory.NewCloudProvider(cfg)
+}
+
 func main() {
 	klog.InitFlags(nil)
 	kube_flag.InitFlags()
@@ -114,20 +166,15 @@ func main() {
 		s = grpc.NewServer(serverOpt)
 	}
 
-	//cloud provider config
-	autoscalingOptions := config.AutoscalingOptions{
-		CloudProviderName:      *cloudProviderFlag,
-		CloudConfig:            *cloudConfig,
-		NodeGroupAutoDiscovery: *nodeGroupAutoDiscoveryFlag,
-		NodeGroups:             *nodeGroupsFlag,
-		ClusterName:            *clusterName,
-		GCEOptions: config.GCEOptions{
-			ConcurrentRefreshes:      1,
-			LocalSSDDiskSizeProvider: localssdsize.NewSimpleLocalSSDProvider(),
-		},
-		UserAgent: "user-agent",
+	// cloud provider backend
+	buildBackend, found := backends[*backendFlag]
+	if !found {
+		klog.Fatalf("unknown backend %q, available backends: %v", *backendFlag, availableBackends())
+	}
+	cloudProvider, err := buildBackend()
+	if err != nil {
+		klog.Fatalf("failed to build %s backend: %v", *backendFlag, err)
 	}
-	cloudProvider := cloudBuilder.NewCloudProvider(autoscalingOptions, nil)
 	srv := wrapper.NewCloudProviderGrpcWrapper(cloudProvider)
 
 	// listen
@@ -92,7 +92,7 @@ func (w *Wrapper) NodeGroupForNode(_ context.Context, req *protos.NodeGroupForNo
 
 	pbNode := req.GetNode()
 	if pbNode == nil {
-		return nil, fmt.Errorf("request fields were nil")
+		return nil, status.Error(codes.InvalidArgument, "request fields were nil")
 	}
 	node := apiv1Node(pbNode)
 	ng, err := w.provider.NodeGroupForNode(node)
@@ -124,7 +124,7 @@ func (w *Wrapper) PricingNodePrice(_ context.Context, req *protos.PricingNodePri
 	reqStartTime := req.GetStartTime()
 	reqEndTime := req.GetEndTime()
 	if reqNode == nil || reqStartTime == nil || reqEndTime == nil {
-		return nil, fmt.Errorf("request fields were nil")
+		return nil, status.Error(codes.InvalidArgument, "request fields were nil")
 	}
 	price, nodePriceErr := model.NodePrice(apiv1Node(reqNode), reqStartTime.Time, reqEndTime.Time)
 	if nodePriceErr != nil {
@@ -150,7 +150,7 @@ func (w *Wrapper) PricingPodPrice(_ context.Context, req *protos.PricingPodPrice
 	reqStartTime := req.GetStartTime()
 	reqEndTime := req.GetEndTime()
 	if reqPod == nil || reqStartTime == nil || reqEndTime == nil {
-		return nil, fmt.Errorf("request fields were nil")
+		return nil, status.Error(codes.InvalidArgument, "request fields were nil")
 	}
 	price, podPriceErr := model.PodPrice(reqPod, reqStartTime.Time, reqEndTime.Time)
 	if podPriceErr != nil {
@@ -218,7 +218,7 @@ func (w *Wrapper) NodeGroupTargetSize(_ context.Context, req *protos.NodeGroupTa
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	size, err := ng.TargetSize()
 	if err != nil {
@@ -236,7 +236,7 @@ func (w *Wrapper) NodeGroupIncreaseSize(_ context.Context, req *protos.NodeGroup
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	err := ng.IncreaseSize(int(req.GetDelta()))
 	if err != nil {
@@ -252,7 +252,7 @@ func (w *Wrapper) NodeGroupDeleteNodes(_ context.Context, req *protos.NodeGroupD
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	nodes := make([]*apiv1.Node, 0)
 	for _, n := range req.GetNodes() {
@@ -272,7 +272,7 @@ func (w *Wrapper) NodeGroupDecreaseTargetSize(_ context.Context, req *protos.Nod
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	err := ng.DecreaseTargetSize(int(req.GetDelta()))
 	if err != nil {
@@ -288,7 +288,7 @@ func (w *Wrapper) NodeGroupNodes(_ context.Context, req *protos.NodeGroupNodesRe
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	instances, err := ng.Nodes()
 	if err != nil {
@@ -330,7 +330,7 @@ func (w *Wrapper) NodeGroupTemplateNodeInfo(_ context.Context, req *protos.NodeG
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	info, err := ng.TemplateNodeInfo()
 	if err != nil {
@@ -351,11 +351,11 @@ func (w *Wrapper) NodeGroupGetOptions(_ context.Context, req *protos.NodeGroupAu
 	id := req.GetId()
 	ng := w.getNodeGroup(id)
 	if ng == nil {
-		return nil, fmt.Errorf("NodeGroup %q, not found", id)
+		return nil, status.Errorf(codes.NotFound, "NodeGroup %q, not found", id)
 	}
 	pbDefaults := req.GetDefaults()
 	if pbDefaults == nil {
-		return nil, fmt.Errorf("request fields were nil")
+		return nil, status.Error(codes.InvalidArgument, "request fields were nil")
 	}
 	defaults := config.NodeGroupAutoscalingOptions{
 		ScaleDownUtilizationThreshold:    pbDefaults.GetScaleDownGpuUtilizationThreshold(),
@@ -372,7 +372,7 @@ func (w *Wrapper) NodeGroupGetOptions(_ context.Context, req *protos.NodeGroupAu
 		return nil, err
 	}
 	if opts == nil {
-		return nil, fmt.Errorf("GetOptions not implemented") //make this explicitly so that grpc response is discarded
+		return nil, status.Error(codes.Unimplemented, "GetOptions not implemented") //make this explicitly so that grpc response is discarded
 	}
 	return &protos.NodeGroupAutoscalingOptionsResponse{
 		NodeGroupAutoscalingOptions: &protos.NodeGroupAutoscalingOptions{
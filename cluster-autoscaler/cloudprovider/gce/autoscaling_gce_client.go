@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -91,6 +92,8 @@ var (
 		regexp.MustCompile("Zone does not currently have sufficient capacity for the requested resources"),
 		regexp.MustCompile("Reservation (.*) does not have sufficient capacity for the requested resources."),
 	}
+	quotaNameRegexp  = regexp.MustCompile(`Quota '([^']+)' exceeded`)
+	quotaScopeRegexp = regexp.MustCompile(`Limit: [0-9.]+ (?:in region ([a-z0-9-]+)|(globally))`)
 )
 
 // GceInstance extends cloudprovider.Instance with GCE specific numeric id.
@@ -276,7 +279,7 @@ func (client *autoscalingGceClientV1) ResizeMig(migRef GceRef, size int64) error
 	defer cancel()
 	op, err := client.gceService.InstanceGroupManagers.Resize(migRef.Project, migRef.Zone, migRef.Name, size).Context(ctx).Do()
 	if err != nil {
-		return err
+		return classifyGceError(err)
 	}
 	return client.WaitForOperation(op.Name, op.OperationType, migRef.Project, migRef.Zone)
 }
@@ -296,7 +299,7 @@ func (client *autoscalingGceClientV1) CreateInstances(migRef GceRef, baseName st
 
 	op, err := client.gceService.InstanceGroupManagers.CreateInstances(migRef.Project, migRef.Zone, migRef.Name, &req).Context(ctx).Do()
 	if err != nil {
-		return err
+		return classifyGceError(err)
 	}
 	return client.WaitForOperation(op.Name, op.OperationType, migRef.Project, migRef.Zone)
 }
@@ -533,31 +536,38 @@ func GetErrorInfo(errorCode, errorMessage, instanceStatus string, previousErrorI
 	if isResourcePoolExhaustedErrorCode(errorCode) {
 		return &cloudprovider.InstanceErrorInfo{
 			ErrorClass: cloudprovider.OutOfResourcesErrorClass,
+			ErrorKind:  cloudprovider.CapacityErrorKind,
 			ErrorCode:  ErrorCodeResourcePoolExhausted,
 		}
 	} else if isQuotaExceededErrorCode(errorCode) {
 		return &cloudprovider.InstanceErrorInfo{
 			ErrorClass: cloudprovider.OutOfResourcesErrorClass,
+			ErrorKind:  cloudprovider.QuotaErrorKind,
 			ErrorCode:  ErrorCodeQuotaExceeded,
+			Quota:      &cloudprovider.QuotaErrorInfo{Quota: quotaName(errorCode, errorMessage), Scope: quotaScope(errorMessage)},
 		}
 	} else if isIPSpaceExhaustedErrorCode(errorCode) {
 		return &cloudprovider.InstanceErrorInfo{
 			ErrorClass: cloudprovider.OtherErrorClass,
+			ErrorKind:  cloudprovider.CapacityErrorKind,
 			ErrorCode:  ErrorIPSpaceExhausted,
 		}
 	} else if isPermissionsError(errorCode) {
 		return &cloudprovider.InstanceErrorInfo{
 			ErrorClass: cloudprovider.OtherErrorClass,
+			ErrorKind:  cloudprovider.PermissionErrorKind,
 			ErrorCode:  ErrorCodePermissions,
 		}
 	} else if isVmExternalIpAccessPolicyConstraintError(errorCode, errorMessage) {
 		return &cloudprovider.InstanceErrorInfo{
 			ErrorClass: cloudprovider.OtherErrorClass,
+			ErrorKind:  cloudprovider.PermissionErrorKind,
 			ErrorCode:  ErrorCodeVmExternalIpAccessPolicyConstraint,
 		}
 	} else if isReservationNotReady(errorCode, errorMessage) {
 		return &cloudprovider.InstanceErrorInfo{
 			ErrorClass: cloudprovider.OtherErrorClass,
+			ErrorKind:  cloudprovider.CapacityErrorKind,
 			ErrorCode:  ErrorReservationNotReady,
 		}
 	} else if isInvalidReservationError(errorCode, errorMessage) {
@@ -607,6 +617,30 @@ func getLastAttemptErrors(instance *gce.ManagedInstance) []*gce.ManagedInstanceL
 	return nil
 }
 
+// classifyGceError wraps the errors returned by the GCE API in errors of the kind
+// corresponding to their reason, or to their HTTP status code for other reasons.
+func classifyGceError(err error) error {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return err
+	}
+	kind := cloudprovider.ErrorKindFromHTTPStatusCode(apiErr.Code)
+	reason := ""
+	for _, item := range apiErr.Errors {
+		reason = item.Reason
+		switch item.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded":
+			kind = cloudprovider.ThrottlingErrorKind
+		case "quotaExceeded":
+			return cloudprovider.NewQuotaExceededError(quotaName(item.Reason, item.Message), retryAfter(apiErr, time.Now()), err).WithScope(quotaScope(item.Message))
+		}
+	}
+	if kind == cloudprovider.UnknownErrorKind {
+		return err
+	}
+	return cloudprovider.NewClassifiedError(kind, reason, err)
+}
+
 func isResourcePoolExhaustedErrorCode(errorCode string) bool {
 	return errorCode == "RESOURCE_POOL_EXHAUSTED" || errorCode == "ZONE_RESOURCE_POOL_EXHAUSTED" || errorCode == "ZONE_RESOURCE_POOL_EXHAUSTED_WITH_DETAILS"
 }
@@ -615,6 +649,51 @@ func isQuotaExceededErrorCode(errorCode string) bool {
 	return strings.Contains(errorCode, "QUOTA")
 }
 
+// quotaName returns the name of the exceeded quota from messages like
+// "Quota 'CPUS' exceeded. Limit: 24.0 in region us-central1.", or the error code
+// if the message doesn't name the quota.
+func quotaName(errorCode, errorMessage string) string {
+	if match := quotaNameRegexp.FindStringSubmatch(errorMessage); match != nil {
+		return match[1]
+	}
+	return errorCode
+}
+
+// quotaScope returns the scope of the exceeded quota from messages like
+// "Quota 'CPUS' exceeded. Limit: 24.0 in region us-central1.", i.e. the region,
+// or "global" for global quotas. Empty if the message doesn't tell.
+func quotaScope(errorMessage string) string {
+	match := quotaScopeRegexp.FindStringSubmatch(errorMessage)
+	if match == nil {
+		return ""
+	}
+	if match[2] != "" {
+		return "global"
+	}
+	return match[1]
+}
+
+// retryAfter returns the time after which the request may be retried, from the
+// google.rpc.RetryInfo details or the Retry-After header of the error. Zero if
+// the error doesn't tell.
+func retryAfter(apiErr *googleapi.Error, now time.Time) time.Time {
+	for _, detail := range apiErr.Details {
+		fields, ok := detail.(map[string]interface{})
+		if !ok || !strings.HasSuffix(fmt.Sprint(fields["@type"]), "google.rpc.RetryInfo") {
+			continue
+		}
+		if delay, ok := fields["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(delay); err == nil {
+				return now.Add(d)
+			}
+		}
+	}
+	if seconds, err := strconv.Atoi(apiErr.Header.Get("Retry-After")); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	return time.Time{}
+}
+
 func isIPSpaceExhaustedErrorCode(errorCode string) bool {
 	return strings.Contains(errorCode, "IP_SPACE_EXHAUSTED")
 }
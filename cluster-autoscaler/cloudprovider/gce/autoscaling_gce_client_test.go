@@ -34,6 +34,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	gce_api "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 func newTestAutoscalingGceClient(t *testing.T, projectId, url, userAgent string) *autoscalingGceClientV1 {
@@ -1157,3 +1158,64 @@ func TestExternalToInternalInstance(t *testing.T) {
 		})
 	}
 }
+
+func TestGetErrorInfoQuota(t *testing.T) {
+	errorInfo := GetErrorInfo("QUOTA_EXCEEDED", "Instance 'myinst' creation failed: Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1.", "", nil)
+	assert.Equal(t, &cloudprovider.QuotaErrorInfo{Quota: "CPUS", Scope: "us-central1"}, errorInfo.Quota)
+
+	errorInfo = GetErrorInfo("QUOTA_EXCEEDED", "Quota 'GPUS_ALL_REGIONS' exceeded.  Limit: 8.0 globally.", "", nil)
+	assert.Equal(t, &cloudprovider.QuotaErrorInfo{Quota: "GPUS_ALL_REGIONS", Scope: "global"}, errorInfo.Quota)
+
+	errorInfo = GetErrorInfo("ZONE_QUOTA_EXCEEDED", "We run out of quota!", "", nil)
+	assert.Equal(t, &cloudprovider.QuotaErrorInfo{Quota: "ZONE_QUOTA_EXCEEDED"}, errorInfo.Quota)
+
+	errorInfo = GetErrorInfo("RESOURCE_POOL_EXHAUSTED", "", "", nil)
+	assert.Nil(t, errorInfo.Quota)
+}
+
+func TestClassifyGceError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		kind cloudprovider.ErrorKind
+	}{
+		"not an API error": {err: fmt.Errorf("boom"), kind: cloudprovider.UnknownErrorKind},
+		"rate limited": {
+			err:  &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}},
+			kind: cloudprovider.ThrottlingErrorKind,
+		},
+		"quota": {
+			err:  &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded", Message: "Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1."}}},
+			kind: cloudprovider.QuotaErrorKind,
+		},
+		"forbidden":   {err: &googleapi.Error{Code: http.StatusForbidden}, kind: cloudprovider.PermissionErrorKind},
+		"unavailable": {err: &googleapi.Error{Code: http.StatusServiceUnavailable}, kind: cloudprovider.TransientNetworkErrorKind},
+		"bad request": {err: &googleapi.Error{Code: http.StatusBadRequest}, kind: cloudprovider.UnknownErrorKind},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := classifyGceError(tc.err)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.kind, cloudprovider.ErrorKindFromError(err))
+		})
+	}
+	err := classifyGceError(&googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded", Message: "Quota 'CPUS' exceeded."}}})
+	assert.Equal(t, "CPUS", cloudprovider.QuotaErrorInfoFromError(err).Quota)
+	assert.True(t, cloudprovider.QuotaErrorInfoFromError(err).RetryAfter.IsZero())
+
+	before := time.Now()
+	err = classifyGceError(&googleapi.Error{
+		Code:    http.StatusForbidden,
+		Errors:  []googleapi.ErrorItem{{Reason: "quotaExceeded", Message: "Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1."}},
+		Details: []interface{}{map[string]interface{}{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "30s"}},
+	})
+	info := cloudprovider.QuotaErrorInfoFromError(err)
+	assert.Equal(t, "us-central1", info.Scope)
+	assert.False(t, info.RetryAfter.Before(before.Add(30*time.Second)))
+	assert.True(t, info.RetryAfter.Before(time.Now().Add(31*time.Second)))
+
+	err = classifyGceError(&googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded", Message: "Quota 'CPUS' exceeded."}},
+		Header: http.Header{"Retry-After": []string{"60"}},
+	})
+	assert.False(t, cloudprovider.QuotaErrorInfoFromError(err).RetryAfter.Before(before.Add(time.Minute)))
+}
@@ -17,13 +17,16 @@ limitations under the License.
 package gce
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
@@ -388,7 +391,11 @@ func BuildGCE(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscover
 		klog.Fatalf("Failed to create GCE Manager: %v", err)
 	}
 
-	pricingModel := NewGcePriceModel(NewGcePriceInfo(), opts.GCEOptions.LocalSSDDiskSizeProvider)
+	var priceInfo PriceInfo = NewGcePriceInfo()
+	if opts.GCEOptions.PricingCatalogRefreshInterval > 0 {
+		priceInfo = buildCatalogPriceInfo(manager, opts.UserAgent, opts.GCEOptions.PricingCatalogRefreshInterval)
+	}
+	pricingModel := NewGcePriceModel(priceInfo, opts.GCEOptions.LocalSSDDiskSizeProvider)
 	provider, err := BuildGceCloudProvider(manager, rl, pricingModel)
 	if err != nil {
 		klog.Fatalf("Failed to create GCE cloud provider: %v", err)
@@ -397,3 +404,21 @@ func BuildGCE(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscover
 	RegisterMetrics()
 	return provider
 }
+
+// buildCatalogPriceInfo returns a price info refreshed from the Cloud Billing Catalog,
+// or the static one if the catalog can't be used.
+func buildCatalogPriceInfo(manager GceManager, userAgent string, refreshInterval time.Duration) PriceInfo {
+	region, err := manager.GetRegion()
+	if err != nil {
+		klog.Errorf("Failed to get GCE region, using static prices: %v", err)
+		return NewGcePriceInfo()
+	}
+	skuLister, err := NewCloudBillingSkuLister(context.Background(), userAgent)
+	if err != nil {
+		klog.Errorf("Failed to create Cloud Billing Catalog client, using static prices: %v", err)
+		return NewGcePriceInfo()
+	}
+	priceInfo := NewCatalogPriceInfo(skuLister, region)
+	go priceInfo.Run(refreshInterval, wait.NeverStop)
+	return priceInfo
+}
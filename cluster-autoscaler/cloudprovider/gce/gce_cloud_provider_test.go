@@ -98,6 +98,11 @@ func (m *gceManagerMock) GetMigTemplateNode(mig Mig) (*apiv1.Node, error) {
 	return args.Get(0).(*apiv1.Node), args.Error(1)
 }
 
+func (m *gceManagerMock) GetRegion() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
 func (m *gceManagerMock) CreateInstances(mig Mig, delta int64) error {
 	args := m.Called(mig, delta)
 	return args.Error(0)
@@ -92,6 +92,8 @@ type GceManager interface {
 	GetResourceLimiter() (*cloudprovider.ResourceLimiter, error)
 	// GetMigSize gets MIG size.
 	GetMigSize(mig Mig) (int64, error)
+	// GetRegion returns the region of the cluster.
+	GetRegion() (string, error)
 	// GetMigOptions returns MIG's NodeGroupAutoscalingOptions
 	GetMigOptions(mig Mig, defaults config.NodeGroupAutoscalingOptions) *config.NodeGroupAutoscalingOptions
 
@@ -280,6 +282,14 @@ func (m *gceManagerImpl) DeleteInstances(instances []GceRef) error {
 	return m.GceService.DeleteInstances(commonMig.GceRef(), instances)
 }
 
+// GetRegion returns the region of the cluster.
+func (m *gceManagerImpl) GetRegion() (string, error) {
+	if m.regional {
+		return m.location, nil
+	}
+	return provider_gce.GetGCERegion(m.location)
+}
+
 // GetMigs returns list of registered MIGs.
 func (m *gceManagerImpl) GetMigs() []Mig {
 	return m.migLister.GetMigs()
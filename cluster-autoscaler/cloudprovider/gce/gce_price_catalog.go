@@ -0,0 +1,371 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/cloudbilling/v1"
+	"google.golang.org/api/option"
+	"k8s.io/apimachinery/pkg/util/wait"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// computeEngineService is the name of the Compute Engine service in the Cloud Billing Catalog API.
+	computeEngineService = "services/6F81-5844-456A"
+	catalogCurrencyCode  = "USD"
+	catalogFetchTimeout  = 2 * time.Minute
+)
+
+var (
+	// instanceSkuRegexp matches descriptions of vCPU and memory SKUs, e.g. "N2 Custom Instance Core running in Americas",
+	// "Spot Preemptible C2D AMD Instance Ram running in Belgium" or "Custom Instance Core running in Iowa" for N1.
+	instanceSkuRegexp = regexp.MustCompile(`^(?:Spot Preemptible |Preemptible )?(?:([A-Z][0-9][A-Z]?)(?: AMD| Arm)? )?(Custom |Predefined )?Instance (Core|Ram) running in `)
+	// gpuSkuRegexp matches descriptions of GPU SKUs, e.g. "Nvidia Tesla T4 GPU running in Americas".
+	gpuSkuRegexp = regexp.MustCompile(`^(?:Spot Preemptible |Preemptible )?(Nvidia [A-Za-z0-9 ]+?) GPU running in `)
+	// sharedCoreMachineTypes are billed for a fraction of their vCPUs, so their prices can't be
+	// derived from the vCPU prices.
+	sharedCoreMachineTypes = map[string]bool{
+		"e2-micro":  true,
+		"e2-small":  true,
+		"e2-medium": true,
+		"f1-micro":  true,
+		"g1-small":  true,
+	}
+)
+
+// SkuLister lists the SKUs of Compute Engine.
+type SkuLister interface {
+	ListSkus(ctx context.Context) ([]*cloudbilling.Sku, error)
+}
+
+type cloudBillingSkuLister struct {
+	service *cloudbilling.APIService
+}
+
+// NewCloudBillingSkuLister returns a SkuLister using the Cloud Billing Catalog API.
+func NewCloudBillingSkuLister(ctx context.Context, userAgent string) (SkuLister, error) {
+	service, err := cloudbilling.NewService(ctx, option.WithScopes(cloudbilling.CloudBillingReadonlyScope), option.WithUserAgent(userAgent))
+	if err != nil {
+		return nil, err
+	}
+	return &cloudBillingSkuLister{service: service}, nil
+}
+
+func (l *cloudBillingSkuLister) ListSkus(ctx context.Context) ([]*cloudbilling.Sku, error) {
+	var skus []*cloudbilling.Sku
+	err := l.service.Services.Skus.List(computeEngineService).CurrencyCode(catalogCurrencyCode).Pages(ctx, func(page *cloudbilling.ListSkusResponse) error {
+		skus = append(skus, page.Skus...)
+		return nil
+	})
+	return skus, err
+}
+
+// CatalogPriceInfo is a PriceInfo periodically refreshed from the Cloud Billing Catalog API.
+// Prices of the region of the cluster found in the catalog override the static ones, the
+// static prices are used for anything else and until the first successful refresh.
+type CatalogPriceInfo struct {
+	static    *GcePriceInfo
+	region    string
+	skuLister SkuLister
+
+	mutex   sync.RWMutex
+	current *GcePriceInfo
+}
+
+// NewCatalogPriceInfo returns a new instance of the CatalogPriceInfo using prices of the given region.
+func NewCatalogPriceInfo(skuLister SkuLister, region string) *CatalogPriceInfo {
+	static := NewGcePriceInfo()
+	return &CatalogPriceInfo{
+		static:    static,
+		region:    region,
+		skuLister: skuLister,
+		current:   static,
+	}
+}
+
+// Run refreshes the prices every refreshInterval until stopCh is closed.
+func (c *CatalogPriceInfo) Run(refreshInterval time.Duration, stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if err := c.Refresh(); err != nil {
+			klog.Errorf("Failed to refresh GCE prices from the Cloud Billing Catalog, using previous prices: %v", err)
+		}
+	}, refreshInterval, stopCh)
+}
+
+// Refresh fetches the prices from the catalog. On error, the previous prices are kept.
+func (c *CatalogPriceInfo) Refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), catalogFetchTimeout)
+	defer cancel()
+	skus, err := c.skuLister.ListSkus(ctx)
+	if err != nil {
+		return err
+	}
+	prices := c.pricesFromSkus(skus)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.current = prices
+	return nil
+}
+
+func (c *CatalogPriceInfo) get() *GcePriceInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.current
+}
+
+// skuPrices holds the prices of a single resource found in the catalog.
+type skuPrices struct {
+	onDemand float64
+	spot     float64
+}
+
+func (c *CatalogPriceInfo) pricesFromSkus(skus []*cloudbilling.Sku) *GcePriceInfo {
+	predefinedCpu := map[string]*skuPrices{}
+	predefinedMemory := map[string]*skuPrices{}
+	customCpu := map[string]*skuPrices{}
+	customMemory := map[string]*skuPrices{}
+	gpus := map[string]*skuPrices{}
+
+	for _, sku := range skus {
+		if sku.Category == nil || !c.inRegion(sku) {
+			continue
+		}
+		spot := sku.Category.UsageType == "Preemptible"
+		if !spot && sku.Category.UsageType != "OnDemand" {
+			continue
+		}
+		price, found := getSkuPrice(sku)
+		if !found {
+			continue
+		}
+		var target map[string]*skuPrices
+		var key string
+		if match := instanceSkuRegexp.FindStringSubmatch(sku.Description); match != nil {
+			key = strings.ToLower(match[1])
+			if key == "" {
+				key = "n1"
+			}
+			custom := match[2] == "Custom "
+			switch {
+			case match[3] == "Core" && custom:
+				target = customCpu
+			case match[3] == "Core":
+				target = predefinedCpu
+			case custom:
+				target = customMemory
+			default:
+				target = predefinedMemory
+			}
+		} else if match := gpuSkuRegexp.FindStringSubmatch(sku.Description); match != nil {
+			key = strings.ToLower(strings.ReplaceAll(match[1], " ", "-"))
+			target = gpus
+		} else {
+			continue
+		}
+		if target[key] == nil {
+			target[key] = &skuPrices{}
+		}
+		if spot {
+			target[key].spot = price
+		} else {
+			target[key].onDemand = price
+		}
+	}
+
+	prices := copyGcePriceInfo(c.static)
+	updatedFamilies := map[string]bool{}
+	for family, cpu := range predefinedCpu {
+		memory, found := predefinedMemory[family]
+		if cpu.onDemand == 0 || !found || memory.onDemand == 0 {
+			continue
+		}
+		prices.predefinedCpuPricePerHour[family] = cpu.onDemand
+		prices.predefinedMemoryPricePerHourPerGb[family] = memory.onDemand
+		if cpu.spot != 0 {
+			prices.predefinedPreemptibleDiscount[family] = cpu.spot / cpu.onDemand
+		}
+		updatedFamilies[family] = true
+	}
+	for family, cpu := range customCpu {
+		memory, found := customMemory[family]
+		if cpu.onDemand == 0 || !found || memory.onDemand == 0 {
+			continue
+		}
+		prices.customCpuPricePerHour[family] = cpu.onDemand
+		prices.customMemoryPricePerHourPerGb[family] = memory.onDemand
+		if cpu.spot != 0 {
+			prices.customPreemptibleDiscount[family] = cpu.spot / cpu.onDemand
+		}
+	}
+	for gpuType, gpu := range gpus {
+		if gpu.onDemand != 0 {
+			prices.gpuPrices[gpuType] = gpu.onDemand
+		}
+		if gpu.spot != 0 {
+			prices.preemptibleGpuPrices[gpuType] = gpu.spot
+		}
+	}
+	// Static prices of machine types take precedence over the prices of vCPUs and memory,
+	// drop them for families with current prices.
+	for _, instancePrices := range []map[string]float64{prices.instancePrices, prices.preemptibleInstancePrices} {
+		for machineType := range instancePrices {
+			family, err := GetMachineFamily(machineType)
+			if err == nil && updatedFamilies[family] && !sharedCoreMachineTypes[machineType] {
+				delete(instancePrices, machineType)
+			}
+		}
+	}
+	klog.V(2).Infof("Refreshed GCE prices of %d machine families and %d GPU types in %s from the Cloud Billing Catalog", len(updatedFamilies), len(gpus), c.region)
+	return prices
+}
+
+func (c *CatalogPriceInfo) inRegion(sku *cloudbilling.Sku) bool {
+	for _, region := range sku.ServiceRegions {
+		if region == c.region {
+			return true
+		}
+	}
+	return false
+}
+
+// getSkuPrice returns the current price per unit of the SKU, taken from its last pricing tier.
+func getSkuPrice(sku *cloudbilling.Sku) (float64, bool) {
+	if len(sku.PricingInfo) == 0 || sku.PricingInfo[0].PricingExpression == nil {
+		return 0, false
+	}
+	rates := sku.PricingInfo[0].PricingExpression.TieredRates
+	if len(rates) == 0 || rates[len(rates)-1].UnitPrice == nil {
+		return 0, false
+	}
+	unitPrice := rates[len(rates)-1].UnitPrice
+	return float64(unitPrice.Units) + float64(unitPrice.Nanos)/1e9, true
+}
+
+func copyGcePriceInfo(info *GcePriceInfo) *GcePriceInfo {
+	result := *info
+	result.predefinedCpuPricePerHour = copyPrices(info.predefinedCpuPricePerHour)
+	result.predefinedMemoryPricePerHourPerGb = copyPrices(info.predefinedMemoryPricePerHourPerGb)
+	result.predefinedPreemptibleDiscount = copyPrices(info.predefinedPreemptibleDiscount)
+	result.customCpuPricePerHour = copyPrices(info.customCpuPricePerHour)
+	result.customMemoryPricePerHourPerGb = copyPrices(info.customMemoryPricePerHourPerGb)
+	result.customPreemptibleDiscount = copyPrices(info.customPreemptibleDiscount)
+	result.instancePrices = copyPrices(info.instancePrices)
+	result.preemptibleInstancePrices = copyPrices(info.preemptibleInstancePrices)
+	result.gpuPrices = copyPrices(info.gpuPrices)
+	result.preemptibleGpuPrices = copyPrices(info.preemptibleGpuPrices)
+	result.bootDiskPricePerHour = copyPrices(info.bootDiskPricePerHour)
+	return &result
+}
+
+func copyPrices(prices map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(prices))
+	for k, v := range prices {
+		result[k] = v
+	}
+	return result
+}
+
+// BaseCpuPricePerHour gets the base cpu price per hour
+func (c *CatalogPriceInfo) BaseCpuPricePerHour() float64 {
+	return c.get().BaseCpuPricePerHour()
+}
+
+// BaseMemoryPricePerHourPerGb gets the base memory price per hour per Gb
+func (c *CatalogPriceInfo) BaseMemoryPricePerHourPerGb() float64 {
+	return c.get().BaseMemoryPricePerHourPerGb()
+}
+
+// BasePreemptibleDiscount gets the base preemptible discount applicable
+func (c *CatalogPriceInfo) BasePreemptibleDiscount() float64 {
+	return c.get().BasePreemptibleDiscount()
+}
+
+// BaseGpuPricePerHour gets the base gpu price per hour
+func (c *CatalogPriceInfo) BaseGpuPricePerHour() float64 {
+	return c.get().BaseGpuPricePerHour()
+}
+
+// PredefinedCpuPricePerHour gets the predefined cpu price per hour for machine family
+func (c *CatalogPriceInfo) PredefinedCpuPricePerHour() map[string]float64 {
+	return c.get().PredefinedCpuPricePerHour()
+}
+
+// PredefinedMemoryPricePerHourPerGb gets the predefined memory price per hour per Gb for machine family
+func (c *CatalogPriceInfo) PredefinedMemoryPricePerHourPerGb() map[string]float64 {
+	return c.get().PredefinedMemoryPricePerHourPerGb()
+}
+
+// PredefinedPreemptibleDiscount gets the predefined preemptible discount for machine family
+func (c *CatalogPriceInfo) PredefinedPreemptibleDiscount() map[string]float64 {
+	return c.get().PredefinedPreemptibleDiscount()
+}
+
+// CustomCpuPricePerHour gets the cpu price per hour for custom machine of a machine family
+func (c *CatalogPriceInfo) CustomCpuPricePerHour() map[string]float64 {
+	return c.get().CustomCpuPricePerHour()
+}
+
+// CustomMemoryPricePerHourPerGb gets the memory price per hour per Gb for custom machine of a machine family
+func (c *CatalogPriceInfo) CustomMemoryPricePerHourPerGb() map[string]float64 {
+	return c.get().CustomMemoryPricePerHourPerGb()
+}
+
+// CustomPreemptibleDiscount gets the preemptible discount of a machine family
+func (c *CatalogPriceInfo) CustomPreemptibleDiscount() map[string]float64 {
+	return c.get().CustomPreemptibleDiscount()
+}
+
+// InstancePrices gets the prices for standard machine types
+func (c *CatalogPriceInfo) InstancePrices() map[string]float64 {
+	return c.get().InstancePrices()
+}
+
+// PreemptibleInstancePrices gets the preemptible prices for standard machine types
+func (c *CatalogPriceInfo) PreemptibleInstancePrices() map[string]float64 {
+	return c.get().PreemptibleInstancePrices()
+}
+
+// GpuPrices gets the price of GPUs
+func (c *CatalogPriceInfo) GpuPrices() map[string]float64 {
+	return c.get().GpuPrices()
+}
+
+// PreemptibleGpuPrices gets the price of preemptible GPUs
+func (c *CatalogPriceInfo) PreemptibleGpuPrices() map[string]float64 {
+	return c.get().PreemptibleGpuPrices()
+}
+
+// BootDiskPricePerHour gets the price of boot disk.
+func (c *CatalogPriceInfo) BootDiskPricePerHour() map[string]float64 {
+	return c.get().BootDiskPricePerHour()
+}
+
+// LocalSsdPricePerHour gets the price of local SSD.
+func (c *CatalogPriceInfo) LocalSsdPricePerHour() float64 {
+	return c.get().LocalSsdPricePerHour()
+}
+
+// SpotLocalSsdPricePerHour gets the price of local SSD for Spot VMs.
+func (c *CatalogPriceInfo) SpotLocalSsdPricePerHour() float64 {
+	return c.get().SpotLocalSsdPricePerHour()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/cloudbilling/v1"
+)
+
+type fakeSkuLister struct {
+	skus []*cloudbilling.Sku
+	err  error
+}
+
+func (f *fakeSkuLister) ListSkus(_ context.Context) ([]*cloudbilling.Sku, error) {
+	return f.skus, f.err
+}
+
+func testSku(description, usageType, region string, units, nanos int64) *cloudbilling.Sku {
+	return &cloudbilling.Sku{
+		Description:    description,
+		Category:       &cloudbilling.Category{ResourceFamily: "Compute", UsageType: usageType},
+		ServiceRegions: []string{region},
+		PricingInfo: []*cloudbilling.PricingInfo{{
+			PricingExpression: &cloudbilling.PricingExpression{
+				TieredRates: []*cloudbilling.TierRate{{UnitPrice: &cloudbilling.Money{Units: units, Nanos: nanos}}},
+			},
+		}},
+	}
+}
+
+func TestCatalogPriceInfo(t *testing.T) {
+	lister := &fakeSkuLister{skus: []*cloudbilling.Sku{
+		testSku("N2 Instance Core running in Americas", "OnDemand", "us-central1", 0, 40000000),
+		testSku("N2 Instance Ram running in Americas", "OnDemand", "us-central1", 0, 5000000),
+		testSku("Spot Preemptible N2 Instance Core running in Americas", "Preemptible", "us-central1", 0, 10000000),
+		testSku("N2 Custom Instance Core running in Americas", "OnDemand", "us-central1", 0, 50000000),
+		testSku("N2 Custom Instance Ram running in Americas", "OnDemand", "us-central1", 0, 6000000),
+		testSku("C2D AMD Instance Core running in Americas", "OnDemand", "us-central1", 0, 30000000),
+		testSku("C2D AMD Instance Ram running in Americas", "OnDemand", "us-central1", 0, 4000000),
+		testSku("Custom Instance Core running in Americas", "OnDemand", "us-central1", 0, 35000000),
+		testSku("Custom Instance Ram running in Americas", "OnDemand", "us-central1", 0, 4500000),
+		testSku("Nvidia Tesla T4 GPU running in Americas", "OnDemand", "us-central1", 0, 400000000),
+		testSku("Spot Preemptible Nvidia Tesla T4 GPU running in Americas", "Preemptible", "us-central1", 0, 100000000),
+		testSku("Nvidia L4 GPU running in Americas", "OnDemand", "us-central1", 1, 0),
+		// Ignored SKUs.
+		testSku("E2 Instance Core running in Belgium", "OnDemand", "europe-west1", 1, 0),
+		testSku("E2 Instance Ram running in Belgium", "OnDemand", "europe-west1", 1, 0),
+		testSku("Commitment v1: N2D AMD Cpu in Americas for 1 Year", "Commit1Yr", "us-central1", 1, 0),
+		testSku("Network Inter Zone Egress", "OnDemand", "us-central1", 1, 0),
+	}}
+	priceInfo := NewCatalogPriceInfo(lister, "us-central1")
+	static := NewGcePriceInfo()
+
+	// Static prices are used until the first refresh.
+	assert.Equal(t, static.PredefinedCpuPricePerHour(), priceInfo.PredefinedCpuPricePerHour())
+	assert.Contains(t, priceInfo.InstancePrices(), "n2-standard-2")
+
+	assert.NoError(t, priceInfo.Refresh())
+	assert.Equal(t, 0.04, priceInfo.PredefinedCpuPricePerHour()["n2"])
+	assert.Equal(t, 0.005, priceInfo.PredefinedMemoryPricePerHourPerGb()["n2"])
+	assert.InDelta(t, 0.25, priceInfo.PredefinedPreemptibleDiscount()["n2"], 1e-9)
+	assert.Equal(t, 0.05, priceInfo.CustomCpuPricePerHour()["n2"])
+	assert.Equal(t, 0.006, priceInfo.CustomMemoryPricePerHourPerGb()["n2"])
+	assert.Equal(t, 0.03, priceInfo.PredefinedCpuPricePerHour()["c2d"])
+	assert.Equal(t, 0.035, priceInfo.CustomCpuPricePerHour()["n1"])
+	assert.Equal(t, 0.4, priceInfo.GpuPrices()["nvidia-tesla-t4"])
+	assert.Equal(t, 0.1, priceInfo.PreemptibleGpuPrices()["nvidia-tesla-t4"])
+	assert.Equal(t, 1.0, priceInfo.GpuPrices()["nvidia-l4"])
+	// Families missing in the catalog keep their static prices.
+	assert.Equal(t, static.PredefinedCpuPricePerHour()["e2"], priceInfo.PredefinedCpuPricePerHour()["e2"])
+	// Machine types of refreshed families are priced by their vCPUs and memory.
+	assert.NotContains(t, priceInfo.InstancePrices(), "n2-standard-2")
+	assert.NotContains(t, priceInfo.PreemptibleInstancePrices(), "n2-standard-2")
+	assert.Contains(t, priceInfo.InstancePrices(), "e2-standard-2")
+	// The static price list isn't modified.
+	assert.Contains(t, NewGcePriceInfo().InstancePrices(), "n2-standard-2")
+	assert.NotEqual(t, 0.04, NewGcePriceInfo().PredefinedCpuPricePerHour()["n2"])
+
+	// Failed refreshes keep the previous prices.
+	lister.err = fmt.Errorf("catalog unavailable")
+	assert.Error(t, priceInfo.Refresh())
+	assert.Equal(t, 0.04, priceInfo.PredefinedCpuPricePerHour()["n2"])
+}
@@ -51,6 +51,35 @@ const (
 	EphemeralStorageLocalSsdAnnotation = "cluster-autoscaler/gce/ephemeral-storage-local-ssd"
 )
 
+const (
+	// ResourceTPU is the name of the TPU resource of GKE nodes.
+	ResourceTPU apiv1.ResourceName = "google.com/tpu"
+	// TPUAcceleratorLabel is the label holding the TPU type of GKE nodes with TPUs.
+	TPUAcceleratorLabel = "cloud.google.com/gke-tpu-accelerator"
+	// TPUTopologyLabel is the label holding the topology of the TPU slice of GKE nodes with TPUs.
+	TPUTopologyLabel = "cloud.google.com/gke-tpu-topology"
+	// TPUTaintKey is the key of the taint GKE adds to nodes with TPUs, tolerated by pods requesting TPUs.
+	TPUTaintKey = "google.com/tpu"
+)
+
+// tpuSingleHostTopologies maps the TPU types to the topologies of their single-host slices, by number of chips.
+var tpuSingleHostTopologies = map[string]map[int64]string{
+	"tpu-v4-podslice":      {4: "2x2x1"},
+	"tpu-v5-lite-device":   {1: "1x1", 4: "2x2", 8: "2x4"},
+	"tpu-v5-lite-podslice": {1: "1x1", 4: "2x2", 8: "2x4"},
+	"tpu-v5p-slice":        {4: "2x2x1"},
+	"tpu-v6e-slice":        {1: "1x1", 4: "2x2", 8: "2x4"},
+}
+
+// tpuMachineFamilies maps the families of machine types with TPUs attached to their TPU type.
+var tpuMachineFamilies = map[string]string{
+	"ct4p":  "tpu-v4-podslice",
+	"ct5l":  "tpu-v5-lite-device",
+	"ct5lp": "tpu-v5-lite-podslice",
+	"ct5p":  "tpu-v5p-slice",
+	"ct6e":  "tpu-v6e-slice",
+}
+
 // TODO: This should be imported from sigs.k8s.io/gcp-compute-persistent-disk-csi-driver/pkg/common/constants.go
 // This key is applicable to both GCE and GKE
 const gceCSITopologyKeyZone = "topology.gke.io/zone"
@@ -65,6 +94,44 @@ func (t *GceTemplateBuilder) getAcceleratorCount(accelerators []*gce.Accelerator
 	return count
 }
 
+// getTPUs returns the TPU type and the number of TPU chips of instances with the given machine type
+// and guest accelerators. TPU machine types, e.g. ct5lp-hightpu-4t, end with their number of chips.
+func (t *GceTemplateBuilder) getTPUs(machineType string, accelerators []*gce.AcceleratorConfig) (string, int64) {
+	tpuType := ""
+	count := int64(0)
+	for _, accelerator := range accelerators {
+		if strings.HasPrefix(accelerator.AcceleratorType, "tpu-") {
+			tpuType = accelerator.AcceleratorType
+			count += accelerator.AcceleratorCount
+		}
+	}
+	if count > 0 {
+		return tpuType, count
+	}
+	parts := strings.Split(machineType, "-")
+	family, found := tpuMachineFamilies[parts[0]]
+	if !found || len(parts) < 3 {
+		return "", 0
+	}
+	chips, err := strconv.ParseInt(strings.TrimSuffix(parts[len(parts)-1], "t"), 10, 64)
+	if err != nil || chips <= 0 {
+		klog.Warningf("could not get the number of TPU chips of machine type %q", machineType)
+		return "", 0
+	}
+	return family, chips
+}
+
+// getTPUTopology returns the topology of the TPU slice of instances with the given TPU type and number of
+// chips. Multi-host slices are created with a compact placement policy, whose topology spans several
+// instances and can't be derived from a single one, so an empty topology is returned for instances with
+// resource policies. The topology of such slices has to be set in the labels of kube-env.
+func (t *GceTemplateBuilder) getTPUTopology(tpuType string, chips int64, resourcePolicies []string) string {
+	if len(resourcePolicies) > 0 {
+		return ""
+	}
+	return tpuSingleHostTopologies[tpuType][chips]
+}
+
 // BuildCapacity builds a list of resource capacities given list of hardware.
 func (t *GceTemplateBuilder) BuildCapacity(m MigOsInfo, cpu int64, mem int64, accelerators []*gce.AcceleratorConfig,
 	ephemeralStorage int64, ephemeralStorageLocalSSDCount int64, pods *int64, r OsReservedCalculator, extendedResources apiv1.ResourceList) (apiv1.ResourceList, error) {
@@ -79,8 +146,8 @@ func (t *GceTemplateBuilder) BuildCapacity(m MigOsInfo, cpu int64, mem int64, ac
 	memTotal := mem - r.CalculateKernelReserved(m, mem)
 	capacity[apiv1.ResourceMemory] = *resource.NewQuantity(memTotal, resource.DecimalSI)
 
-	if accelerators != nil && len(accelerators) > 0 {
-		capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(t.getAcceleratorCount(accelerators), resource.DecimalSI)
+	if gpuCount := t.getAcceleratorCount(accelerators); gpuCount > 0 {
+		capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(gpuCount, resource.DecimalSI)
 	}
 
 	if ephemeralStorage > 0 {
@@ -206,6 +273,18 @@ func (t *GceTemplateBuilder) BuildNodeFromTemplate(mig Mig, migOsInfo MigOsInfo,
 		return nil, err
 	}
 
+	// TPU capacity set in the extended resources of kube-env takes precedence, as do the TPU labels of kube-env.
+	tpuType, tpuCount := t.getTPUs(template.Properties.MachineType, template.Properties.GuestAccelerators)
+	if tpuCount > 0 {
+		if _, found := capacity[ResourceTPU]; !found {
+			capacity[ResourceTPU] = *resource.NewQuantity(tpuCount, resource.DecimalSI)
+		}
+		node.Labels[TPUAcceleratorLabel] = tpuType
+		if topology := t.getTPUTopology(tpuType, tpuCount, template.Properties.ResourcePolicies); topology != "" {
+			node.Labels[TPUTopologyLabel] = topology
+		}
+	}
+
 	node.Status = apiv1.NodeStatus{
 		Capacity: capacity,
 	}
@@ -238,6 +317,11 @@ func (t *GceTemplateBuilder) BuildNodeFromTemplate(mig Mig, migOsInfo MigOsInfo,
 		}
 	}
 
+	// Only pods requesting TPUs tolerate the taint of TPU nodes, so other pods don't scale up TPU node groups.
+	if tpuCount > 0 && !hasTaint(node.Spec.Taints, TPUTaintKey) {
+		node.Spec.Taints = append(node.Spec.Taints, apiv1.Taint{Key: TPUTaintKey, Value: "present", Effect: apiv1.TaintEffectNoSchedule})
+	}
+
 	if nodeAllocatable == nil {
 		klog.Warningf("could not extract kube-reserved from kubeEnv for mig %q, setting allocatable to capacity.", mig.GceRef().Name)
 		node.Status.Allocatable = node.Status.Capacity
@@ -256,6 +340,15 @@ func (t *GceTemplateBuilder) BuildNodeFromTemplate(mig Mig, migOsInfo MigOsInfo,
 	return &node, nil
 }
 
+func hasTaint(taints []apiv1.Taint, key string) bool {
+	for _, taint := range taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
 func ephemeralStorageLocalSSDCount(kubeEnv KubeEnv) int64 {
 	v, found, err := extractAutoscalerVarFromKubeEnv(kubeEnv, "ephemeral_storage_local_ssd_count")
 	if err != nil {
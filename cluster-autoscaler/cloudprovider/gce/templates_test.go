@@ -1552,3 +1552,146 @@ func stringifyResourceList(resourceList apiv1.ResourceList) string {
 	}
 	return strings.Join(results, ", ")
 }
+
+func TestGetTPUs(t *testing.T) {
+	testCases := map[string]struct {
+		machineType  string
+		accelerators []*gce.AcceleratorConfig
+		wantType     string
+		wantCount    int64
+	}{
+		"no TPUs": {
+			machineType: "n1-standard-8",
+			accelerators: []*gce.AcceleratorConfig{
+				{AcceleratorType: "nvidia-tesla-k80", AcceleratorCount: 3},
+			},
+		},
+		"TPU machine type": {
+			machineType: "ct5lp-hightpu-4t",
+			wantType:    "tpu-v5-lite-podslice",
+			wantCount:   4,
+		},
+		"TPU machine type with a single chip": {
+			machineType: "ct6e-standard-1t",
+			wantType:    "tpu-v6e-slice",
+			wantCount:   1,
+		},
+		"TPU guest accelerators": {
+			machineType: "n2-standard-8",
+			accelerators: []*gce.AcceleratorConfig{
+				{AcceleratorType: "tpu-v4-podslice", AcceleratorCount: 4},
+			},
+			wantType:  "tpu-v4-podslice",
+			wantCount: 4,
+		},
+		"TPU machine type with invalid chip count": {
+			machineType: "ct5p-hightpu-xt",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tb := GceTemplateBuilder{}
+			gotType, gotCount := tb.getTPUs(tc.machineType, tc.accelerators)
+			assert.Equal(t, tc.wantType, gotType)
+			assert.Equal(t, tc.wantCount, gotCount)
+		})
+	}
+}
+
+func TestBuildNodeFromTemplateTPU(t *testing.T) {
+	tpuTaint := apiv1.Taint{Key: TPUTaintKey, Value: "present", Effect: apiv1.TaintEffectNoSchedule}
+	for tn, tc := range map[string]struct {
+		kubeEnv          string
+		machineType      string
+		resourcePolicies []string
+		wantTPU          int64
+		wantLabels       map[string]string
+		missingLabels    []string
+		wantTaints       []apiv1.Taint
+	}{
+		"TPU machine type": {
+			kubeEnv:     "AUTOSCALER_ENV_VARS: os_distribution=cos;arch=amd64;os=linux\n",
+			machineType: "ct5lp-hightpu-4t",
+			wantTPU:     4,
+			wantLabels: map[string]string{
+				TPUAcceleratorLabel: "tpu-v5-lite-podslice",
+				TPUTopologyLabel:    "2x2",
+			},
+			wantTaints: []apiv1.Taint{tpuTaint},
+		},
+		"3D TPU machine type": {
+			kubeEnv:     "AUTOSCALER_ENV_VARS: os_distribution=cos;arch=amd64;os=linux\n",
+			machineType: "ct5p-hightpu-4t",
+			wantTPU:     4,
+			wantLabels: map[string]string{
+				TPUAcceleratorLabel: "tpu-v5p-slice",
+				TPUTopologyLabel:    "2x2x1",
+			},
+			wantTaints: []apiv1.Taint{tpuTaint},
+		},
+		"multi-host TPU slice": {
+			kubeEnv:          "AUTOSCALER_ENV_VARS: os_distribution=cos;arch=amd64;os=linux\n",
+			machineType:      "ct5lp-hightpu-4t",
+			resourcePolicies: []string{"https://www.googleapis.com/compute/v1/projects/some-proj/regions/us-central1/resourcePolicies/tpu-placement"},
+			wantTPU:          4,
+			wantLabels:       map[string]string{TPUAcceleratorLabel: "tpu-v5-lite-podslice"},
+			missingLabels:    []string{TPUTopologyLabel},
+			wantTaints:       []apiv1.Taint{tpuTaint},
+		},
+		"multi-host TPU slice with topology from kube-env": {
+			kubeEnv:          "AUTOSCALER_ENV_VARS: os_distribution=cos;arch=amd64;os=linux;node_labels=cloud.google.com/gke-tpu-topology=4x4;node_taints=google.com/tpu=present:NoExecute\n",
+			machineType:      "ct5lp-hightpu-4t",
+			resourcePolicies: []string{"https://www.googleapis.com/compute/v1/projects/some-proj/regions/us-central1/resourcePolicies/tpu-placement"},
+			wantTPU:          4,
+			wantLabels: map[string]string{
+				TPUAcceleratorLabel: "tpu-v5-lite-podslice",
+				TPUTopologyLabel:    "4x4",
+			},
+			wantTaints: []apiv1.Taint{{Key: TPUTaintKey, Value: "present", Effect: apiv1.TaintEffectNoExecute}},
+		},
+		"TPU resource from kube-env takes precedence": {
+			kubeEnv:     "AUTOSCALER_ENV_VARS: os_distribution=cos;arch=amd64;os=linux;extended_resources=google.com/tpu=8\n",
+			machineType: "ct5p-hightpu-4t",
+			wantTPU:     8,
+			wantLabels:  map[string]string{TPUAcceleratorLabel: "tpu-v5p-slice"},
+			wantTaints:  []apiv1.Taint{tpuTaint},
+		},
+		"no TPUs": {
+			kubeEnv:       "AUTOSCALER_ENV_VARS: os_distribution=cos;arch=amd64;os=linux\n",
+			machineType:   "n1-standard-8",
+			missingLabels: []string{TPUAcceleratorLabel, TPUTopologyLabel},
+		},
+	} {
+		t.Run(tn, func(t *testing.T) {
+			mig := &gceMig{gceRef: GceRef{Name: "some-name", Project: "some-proj", Zone: "us-central1-b"}}
+			template := &gce.InstanceTemplate{
+				Name: "node-name",
+				Properties: &gce.InstanceProperties{
+					MachineType: tc.machineType,
+					Metadata: &gce.Metadata{
+						Items: []*gce.MetadataItems{{Key: "kube-env", Value: &tc.kubeEnv}},
+					},
+					Disks:            []*gce.AttachedDisk{},
+					ResourcePolicies: tc.resourcePolicies,
+				},
+			}
+			tb := &GceTemplateBuilder{}
+			kubeEnv, err := ExtractKubeEnv(template)
+			assert.NoError(t, err)
+			migOsInfo, err := tb.MigOsInfo(mig.Id(), kubeEnv)
+			assert.NoError(t, err)
+			node, err := tb.BuildNodeFromTemplate(mig, migOsInfo, template, kubeEnv, 16, 128, nil, &GceReserved{}, localssdsize.NewSimpleLocalSSDProvider())
+			assert.NoError(t, err)
+
+			tpu := node.Status.Allocatable[ResourceTPU]
+			assert.Equal(t, tc.wantTPU, tpu.Value())
+			for key, value := range tc.wantLabels {
+				assert.Equal(t, value, node.Labels[key])
+			}
+			for _, key := range tc.missingLabels {
+				assert.NotContains(t, node.Labels, key)
+			}
+			assert.ElementsMatch(t, tc.wantTaints, node.Spec.Taints)
+		})
+	}
+}
@@ -49,6 +49,7 @@ type hetznerManager struct {
 	clusterConfig    *ClusterConfig
 	sshKey           *hcloud.SSHKey
 	network          *hcloud.Network
+	nodePoolNetworks map[string]*hcloud.Network
 	firewall         *hcloud.Firewall
 	createTimeout    time.Duration
 	publicIPv4       bool
@@ -76,6 +77,16 @@ type NodeConfig struct {
 	CloudInit string
 	Taints    []apiv1.Taint
 	Labels    map[string]string
+	// MaxPods is the pods capacity of the nodes, matching the kubelet max-pods setting.
+	// Defaults to 110.
+	MaxPods int
+	// Network is the id or name of the network the servers are attached to instead of HCLOUD_NETWORK,
+	// e.g. a network with a vSwitch subnet connecting them to dedicated servers.
+	Network string
+	// PublicIPv4 overrides HCLOUD_PUBLIC_IPV4 for the servers. Disabling it creates IPv6-only servers.
+	PublicIPv4 *bool
+	// PublicIPv6 overrides HCLOUD_PUBLIC_IPV6 for the servers.
+	PublicIPv6 *bool
 }
 
 // LegacyConfig holds the configuration in the legacy format
@@ -176,6 +187,26 @@ func newManager() (*hetznerManager, error) {
 
 	}
 
+	nodePoolNetworks := make(map[string]*hcloud.Network)
+	for nodePool, nodeConfig := range clusterConfig.NodeConfigs {
+		if nodeConfig.MaxPods < 0 {
+			return nil, fmt.Errorf("invalid max pods %d of node pool %s", nodeConfig.MaxPods, nodePool)
+		}
+		if nodeConfig.Network != "" {
+			nodePoolNetworks[nodePool], _, err = client.Network.Get(ctx, nodeConfig.Network)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get network of node pool %s error: %s", nodePool, err)
+			}
+			if nodePoolNetworks[nodePool] == nil {
+				return nil, fmt.Errorf("network %s of node pool %s not found", nodeConfig.Network, nodePool)
+			}
+		}
+		enableIPv4, enableIPv6 := nodePoolPublicNet(nodeConfig, publicIPv4, publicIPv6)
+		if !enableIPv4 && !enableIPv6 && nodePoolNetworks[nodePool] == nil && network == nil {
+			return nil, fmt.Errorf("servers of node pool %s would have neither a public IP nor a network", nodePool)
+		}
+	}
+
 	createTimeout := serverCreateTimeoutDefault
 	v, err := strconv.Atoi(os.Getenv("HCLOUD_SERVER_CREATION_TIMEOUT"))
 	if err == nil && v != 0 {
@@ -196,6 +227,7 @@ func newManager() (*hetznerManager, error) {
 		nodeGroups:       make(map[string]*hetznerNodeGroup),
 		sshKey:           sshKey,
 		network:          network,
+		nodePoolNetworks: nodePoolNetworks,
 		firewall:         firewall,
 		createTimeout:    createTimeout,
 		apiCallContext:   ctx,
@@ -281,3 +313,17 @@ func (m *hetznerManager) serverForNode(node *apiv1.Node) (*hcloud.Server, error)
 	}
 	return server, nil
 }
+
+// nodePoolPublicNet returns whether the servers of the node pool get public IPv4 and IPv6 addresses.
+func nodePoolPublicNet(nodeConfig *NodeConfig, publicIPv4, publicIPv6 bool) (bool, bool) {
+	if nodeConfig == nil {
+		return publicIPv4, publicIPv6
+	}
+	if nodeConfig.PublicIPv4 != nil {
+		publicIPv4 = *nodeConfig.PublicIPv4
+	}
+	if nodeConfig.PublicIPv6 != nil {
+		publicIPv6 = *nodeConfig.PublicIPv6
+	}
+	return publicIPv4, publicIPv6
+}
@@ -241,6 +241,9 @@ func (n *hetznerNodeGroup) TemplateNodeInfo() (*schedulerframework.NodeInfo, err
 			Conditions: cloudprovider.BuildReadyConditions(),
 		},
 	}
+	if nodeConfig := n.nodeConfig(); nodeConfig != nil && nodeConfig.MaxPods > 0 {
+		node.Status.Capacity[apiv1.ResourcePods] = *resource.NewQuantity(int64(nodeConfig.MaxPods), resource.DecimalSI)
+	}
 	node.Status.Allocatable = node.Status.Capacity
 	node.Status.Conditions = cloudprovider.BuildReadyConditions()
 
@@ -250,8 +253,8 @@ func (n *hetznerNodeGroup) TemplateNodeInfo() (*schedulerframework.NodeInfo, err
 	}
 	node.Labels = cloudprovider.JoinStringMaps(node.Labels, nodeGroupLabels)
 
-	if n.manager.clusterConfig.IsUsingNewFormat && n.id != drainingNodePoolId {
-		for _, taint := range n.manager.clusterConfig.NodeConfigs[n.id].Taints {
+	if nodeConfig := n.nodeConfig(); nodeConfig != nil {
+		for _, taint := range nodeConfig.Taints {
 			node.Spec.Taints = append(node.Spec.Taints, apiv1.Taint{
 				Key:    taint.Key,
 				Value:  taint.Value,
@@ -335,6 +338,15 @@ func toInstanceStatus(status hcloud.ServerStatus) *cloudprovider.InstanceStatus
 	return st
 }
 
+// nodeConfig returns the configuration of the node group from HCLOUD_CLUSTER_CONFIG,
+// or nil if the legacy configuration is used.
+func (n *hetznerNodeGroup) nodeConfig() *NodeConfig {
+	if !n.manager.clusterConfig.IsUsingNewFormat || n.id == drainingNodePoolId {
+		return nil
+	}
+	return n.manager.clusterConfig.NodeConfigs[n.id]
+}
+
 func newNodeName(n *hetznerNodeGroup) string {
 	return fmt.Sprintf("%s-%x", n.id, rand.Int63())
 }
@@ -350,12 +362,13 @@ func buildNodeGroupLabels(n *hetznerNodeGroup) (map[string]string, error) {
 		apiv1.LabelInstanceType:      n.instanceType,
 		apiv1.LabelTopologyRegion:    n.region,
 		apiv1.LabelArchStable:        archLabel,
+		apiv1.LabelOSStable:          "linux",
 		"csi.hetzner.cloud/location": n.region,
 		nodeGroupLabel:               n.id,
 	}
 
-	if n.manager.clusterConfig.IsUsingNewFormat && n.id != drainingNodePoolId {
-		maps.Copy(labels, n.manager.clusterConfig.NodeConfigs[n.id].Labels)
+	if nodeConfig := n.nodeConfig(); nodeConfig != nil {
+		maps.Copy(labels, nodeConfig.Labels)
 	}
 
 	klog.V(4).Infof("%s nodegroup labels: %s", n.id, labels)
@@ -425,9 +438,11 @@ func createServer(n *hetznerNodeGroup) error {
 
 	cloudInit := n.manager.clusterConfig.LegacyConfig.CloudInit
 
-	if n.manager.clusterConfig.IsUsingNewFormat {
-		cloudInit = n.manager.clusterConfig.NodeConfigs[n.id].CloudInit
+	nodeConfig := n.nodeConfig()
+	if nodeConfig != nil {
+		cloudInit = nodeConfig.CloudInit
 	}
+	enableIPv4, enableIPv6 := nodePoolPublicNet(nodeConfig, n.manager.publicIPv4, n.manager.publicIPv6)
 
 	StartAfterCreate := true
 	opts := hcloud.ServerCreateOpts{
@@ -441,14 +456,16 @@ func createServer(n *hetznerNodeGroup) error {
 			nodeGroupLabel: n.id,
 		},
 		PublicNet: &hcloud.ServerCreatePublicNet{
-			EnableIPv4: n.manager.publicIPv4,
-			EnableIPv6: n.manager.publicIPv6,
+			EnableIPv4: enableIPv4,
+			EnableIPv6: enableIPv6,
 		},
 	}
 	if n.manager.sshKey != nil {
 		opts.SSHKeys = []*hcloud.SSHKey{n.manager.sshKey}
 	}
-	if n.manager.network != nil {
+	if network, found := n.manager.nodePoolNetworks[n.id]; found {
+		opts.Networks = []*hcloud.Network{network}
+	} else if n.manager.network != nil {
 		opts.Networks = []*hcloud.Network{n.manager.network}
 	}
 	if n.manager.firewall != nil {
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/hetzner/hcloud-go/hcloud"
+)
+
+func TestTemplateNodeInfo(t *testing.T) {
+	serverTypes := newServerTypeCache(context.Background(), nil)
+	require.NoError(t, serverTypes.Add(serverTypeCachedObject{
+		name: serverTypeCacheKey,
+		serverTypes: []*hcloud.ServerType{
+			{Name: "cax11", Cores: 2, Memory: 4, Disk: 40, Architecture: hcloud.ArchitectureARM},
+			{Name: "cx22", Cores: 2, Memory: 4, Disk: 40, Architecture: hcloud.ArchitectureX86},
+		},
+	}))
+	manager := &hetznerManager{
+		cachedServerType: serverTypes,
+		clusterConfig: &ClusterConfig{
+			IsUsingNewFormat: true,
+			NodeConfigs: map[string]*NodeConfig{
+				"arm":   {MaxPods: 250},
+				"amd64": {},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		instanceType string
+		wantArch     string
+		wantPods     int64
+	}{
+		{name: "arm", instanceType: "cax11", wantArch: "arm64", wantPods: 250},
+		{name: "amd64", instanceType: "cx22", wantArch: "amd64", wantPods: defaultPodAmountsLimit},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodeGroup := &hetznerNodeGroup{id: tc.name, manager: manager, instanceType: tc.instanceType, region: "fsn1"}
+
+			nodeInfo, err := nodeGroup.TemplateNodeInfo()
+
+			require.NoError(t, err)
+			node := nodeInfo.Node()
+			assert.Equal(t, tc.wantArch, node.Labels[apiv1.LabelArchStable])
+			assert.Equal(t, "linux", node.Labels[apiv1.LabelOSStable])
+			assert.Equal(t, tc.wantPods, node.Status.Allocatable.Pods().Value())
+		})
+	}
+}
+
+func TestNodePoolPublicNet(t *testing.T) {
+	disabled := false
+	enabled := true
+
+	testCases := []struct {
+		name       string
+		nodeConfig *NodeConfig
+		wantIPv4   bool
+		wantIPv6   bool
+	}{
+		{name: "legacy config", wantIPv4: true, wantIPv6: true},
+		{name: "no override", nodeConfig: &NodeConfig{}, wantIPv4: true, wantIPv6: true},
+		{name: "IPv6-only", nodeConfig: &NodeConfig{PublicIPv4: &disabled, PublicIPv6: &enabled}, wantIPv4: false, wantIPv6: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enableIPv4, enableIPv6 := nodePoolPublicNet(tc.nodeConfig, true, true)
+			assert.Equal(t, tc.wantIPv4, enableIPv4)
+			assert.Equal(t, tc.wantIPv6, enableIPv6)
+		})
+	}
+}
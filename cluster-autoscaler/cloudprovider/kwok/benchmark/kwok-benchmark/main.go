@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kwok-benchmark runs a benchmark scenario against a cluster autoscaled by CA
+// with the kwok provider, and writes a report of the CA loop time and the
+// quality of its decisions.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/kwok/benchmark"
+)
+
+var (
+	scenarioFile      = flag.String("scenario", "", "Path of the scenario file.")
+	kubeconfig        = flag.String("kubeconfig", "", "Path of the kubeconfig of the cluster. Uses the default loading rules if empty.")
+	providerNamespace = flag.String("provider-namespace", "kube-system", "Namespace of the kwok provider configmap.")
+	providerConfigMap = flag.String("provider-configmap", "kwok-provider-config", "Name of the kwok provider configmap, in which provider latency is set.")
+	metricsURL        = flag.String("metrics-url", "http://localhost:8085/metrics", "URL of the CA metrics, from which loop time is scraped. Loop time isn't reported if empty.")
+	output            = flag.String("output", "", "Path of the report file. The report is written to stdout if empty.")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	f, err := os.Open(*scenarioFile)
+	if err != nil {
+		klog.Fatalf("Failed to open scenario: %v", err)
+	}
+	scenario, err := benchmark.LoadScenario(f)
+	f.Close()
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = *kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		klog.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+	client := kube_client.NewForConfigOrDie(restConfig)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	runner := benchmark.NewRunner(client, *providerNamespace, *providerConfigMap, *metricsURL)
+	report, err := runner.Run(ctx, scenario)
+	if err != nil {
+		klog.Fatalf("Scenario %s failed: %v", scenario.Name, err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			klog.Fatalf("Failed to create report file: %v", err)
+		}
+		defer out.Close()
+	}
+	if err := report.Write(out); err != nil {
+		klog.Fatalf("Failed to write report: %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	mainLoopDurationSum   = `cluster_autoscaler_function_duration_seconds_sum{function="main"}`
+	mainLoopDurationCount = `cluster_autoscaler_function_duration_seconds_count{function="main"}`
+)
+
+// Report summarizes the CA loop time and the quality of its decisions over a scenario.
+type Report struct {
+	Scenario string          `json:"scenario"`
+	Duration metav1.Duration `json:"duration"`
+	// PodsCreated is the number of pods created by the scenario.
+	PodsCreated int `json:"podsCreated"`
+	// PodsScheduled is the number of pods observed scheduled.
+	PodsScheduled int `json:"podsScheduled"`
+	// PodsPending is the number of pods of the scenario still pending at its end.
+	PodsPending int `json:"podsPending"`
+	// SchedulingLatency are percentiles of the time from the creation of pods until they were scheduled.
+	SchedulingLatency LatencyPercentiles `json:"schedulingLatency"`
+	// PeakNodes and FinalNodes are the maximum and final numbers of kwok nodes observed.
+	PeakNodes  int `json:"peakNodes"`
+	FinalNodes int `json:"finalNodes"`
+	// NodeHours is the number of kwok nodes integrated over the scenario, a proxy for its cost.
+	NodeHours float64 `json:"nodeHours"`
+	// MainLoops and AverageLoopTime describe the CA main loops run during the scenario,
+	// they are only set when CA metrics are scraped.
+	MainLoops       int             `json:"mainLoops,omitempty"`
+	AverageLoopTime metav1.Duration `json:"averageLoopTime,omitempty"`
+}
+
+// LatencyPercentiles are percentiles of a latency distribution.
+type LatencyPercentiles struct {
+	P50 metav1.Duration `json:"p50"`
+	P90 metav1.Duration `json:"p90"`
+	P99 metav1.Duration `json:"p99"`
+	Max metav1.Duration `json:"max"`
+}
+
+// Write writes the report as indented JSON.
+func (r *Report) Write(w io.Writer) error {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+func (r *Report) setLoopTime(before, after loopMetrics) {
+	loops := after.count - before.count
+	if loops <= 0 {
+		return
+	}
+	r.MainLoops = int(loops)
+	seconds := (after.sum - before.sum) / loops
+	r.AverageLoopTime = metav1.Duration{Duration: time.Duration(seconds * float64(time.Second))}
+}
+
+// observation accumulates samples of the cluster taken while a scenario runs.
+type observation struct {
+	scenario    string
+	podsCreated int
+	// schedulingLatency holds the scheduling latency of pods observed scheduled, by namespace/name.
+	schedulingLatency map[string]time.Duration
+	pending           int
+	peakNodes         int
+	lastNodes         int
+	lastSample        time.Time
+	nodeSeconds       float64
+}
+
+func newObservation(scenario string) *observation {
+	return &observation{scenario: scenario, schedulingLatency: make(map[string]time.Duration)}
+}
+
+func (o *observation) observe(pods []apiv1.Pod, nodes int, now time.Time) {
+	o.pending = 0
+	for _, pod := range pods {
+		key := pod.Namespace + "/" + pod.Name
+		if _, found := o.schedulingLatency[key]; found {
+			continue
+		}
+		scheduled := false
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == apiv1.PodScheduled && condition.Status == apiv1.ConditionTrue {
+				o.schedulingLatency[key] = condition.LastTransitionTime.Sub(pod.CreationTimestamp.Time)
+				scheduled = true
+			}
+		}
+		if !scheduled && pod.DeletionTimestamp == nil {
+			o.pending++
+		}
+	}
+	if !o.lastSample.IsZero() {
+		o.nodeSeconds += float64(o.lastNodes) * now.Sub(o.lastSample).Seconds()
+	}
+	o.lastSample = now
+	o.lastNodes = nodes
+	if nodes > o.peakNodes {
+		o.peakNodes = nodes
+	}
+}
+
+func (o *observation) report(duration time.Duration) *Report {
+	latencies := make([]time.Duration, 0, len(o.schedulingLatency))
+	for _, latency := range o.schedulingLatency {
+		latencies = append(latencies, latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return &Report{
+		Scenario:      o.scenario,
+		Duration:      metav1.Duration{Duration: duration},
+		PodsCreated:   o.podsCreated,
+		PodsScheduled: len(latencies),
+		PodsPending:   o.pending,
+		SchedulingLatency: LatencyPercentiles{
+			P50: percentile(latencies, 0.5),
+			P90: percentile(latencies, 0.9),
+			P99: percentile(latencies, 0.99),
+			Max: percentile(latencies, 1),
+		},
+		PeakNodes:  o.peakNodes,
+		FinalNodes: o.lastNodes,
+		NodeHours:  o.nodeSeconds / time.Hour.Seconds(),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) metav1.Duration {
+	if len(sorted) == 0 {
+		return metav1.Duration{}
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return metav1.Duration{Duration: sorted[rank]}
+}
+
+// loopMetrics are the cumulative duration and number of CA main loops.
+type loopMetrics struct {
+	sum   float64
+	count float64
+}
+
+// loopMetricsScraper scrapes the main loop metrics from the CA metrics endpoint.
+type loopMetricsScraper struct {
+	url    string
+	client *http.Client
+}
+
+func newLoopMetricsScraper(url string) *loopMetricsScraper {
+	return &loopMetricsScraper{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *loopMetricsScraper) scrape(ctx context.Context) (loopMetrics, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return loopMetrics{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return loopMetrics{}, fmt.Errorf("failed to scrape CA metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return loopMetrics{}, fmt.Errorf("failed to scrape CA metrics: %s", resp.Status)
+	}
+	return parseLoopMetrics(resp.Body)
+}
+
+// parseLoopMetrics parses the main loop duration histogram out of metrics in the Prometheus text format.
+func parseLoopMetrics(r io.Reader) (loopMetrics, error) {
+	var m loopMetrics
+	var foundSum, foundCount bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var value *float64
+		var name string
+		switch {
+		case strings.HasPrefix(line, mainLoopDurationSum+" "):
+			value, name, foundSum = &m.sum, mainLoopDurationSum, true
+		case strings.HasPrefix(line, mainLoopDurationCount+" "):
+			value, name, foundCount = &m.count, mainLoopDurationCount, true
+		default:
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.Fields(strings.TrimPrefix(line, name))[0], 64)
+		if err != nil {
+			return loopMetrics{}, fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		*value = parsed
+	}
+	if err := scanner.Err(); err != nil {
+		return loopMetrics{}, err
+	}
+	if !foundSum || !foundCount {
+		return loopMetrics{}, fmt.Errorf("CA main loop duration metrics not found")
+	}
+	return m, nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kube_client "k8s.io/client-go/kubernetes"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/kwok"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
+)
+
+const (
+	// ScenarioLabel is the label of pods created by a scenario, holding the scenario name.
+	ScenarioLabel = "kwok-benchmark.cluster-autoscaler/scenario"
+	// WaveLabel is the label of pods created by a scenario, holding the name of their wave.
+	WaveLabel = "kwok-benchmark.cluster-autoscaler/wave"
+
+	failedNodeReason = "KwokBenchmarkFailure"
+)
+
+// Runner runs scenarios against a cluster autoscaled by CA with the kwok provider.
+type Runner struct {
+	client kube_client.Interface
+	// providerNamespace and providerConfigMap locate the kwok provider configmap.
+	providerNamespace string
+	providerConfigMap string
+	// metrics scrapes the CA main loop metrics, nil if CA metrics aren't scraped.
+	metrics *loopMetricsScraper
+}
+
+// NewRunner returns a Runner. The provider latency is changed in the given kwok
+// provider configmap, and loop times are scraped from the CA metrics URL, unless it is empty.
+func NewRunner(client kube_client.Interface, providerNamespace, providerConfigMap, metricsURL string) *Runner {
+	r := &Runner{client: client, providerNamespace: providerNamespace, providerConfigMap: providerConfigMap}
+	if metricsURL != "" {
+		r.metrics = newLoopMetricsScraper(metricsURL)
+	}
+	return r
+}
+
+// Run runs the scenario and returns the report of the cluster observed while it ran.
+func (r *Runner) Run(ctx context.Context, s *Scenario) (*Report, error) {
+	var loopsBefore loopMetrics
+	if r.metrics != nil {
+		var err error
+		if loopsBefore, err = r.metrics.scrape(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	end := start.Add(s.duration())
+	o := newObservation(s.Name)
+	next := 0
+	for {
+		now := time.Now()
+		for next < len(s.Steps) && !now.Before(start.Add(s.Steps[next].At.Duration)) {
+			if err := r.runStep(ctx, s, s.Steps[next], o); err != nil {
+				return nil, fmt.Errorf("step %d failed: %v", next, err)
+			}
+			next++
+		}
+		if err := r.sample(ctx, s, o, now); err != nil {
+			return nil, err
+		}
+		if !now.Before(end) {
+			break
+		}
+		wait := s.SampleInterval.Duration
+		if untilEnd := end.Sub(now); untilEnd < wait {
+			wait = untilEnd
+		}
+		if next < len(s.Steps) {
+			if untilStep := start.Add(s.Steps[next].At.Duration).Sub(now); untilStep < wait {
+				wait = untilStep
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	report := o.report(time.Since(start))
+	if r.metrics != nil {
+		loopsAfter, err := r.metrics.scrape(ctx)
+		if err != nil {
+			return nil, err
+		}
+		report.setLoopTime(loopsBefore, loopsAfter)
+	}
+	return report, nil
+}
+
+func (r *Runner) runStep(ctx context.Context, s *Scenario, step Step, o *observation) error {
+	switch {
+	case step.CreatePods != nil:
+		return r.createPods(ctx, s, step.CreatePods, o)
+	case step.DeletePods != nil:
+		return r.deletePods(ctx, s, step.DeletePods)
+	case step.FailNodes != nil:
+		return r.failNodes(ctx, step.FailNodes)
+	case step.ProviderLatency != nil:
+		return r.setProviderLatency(ctx, step.ProviderLatency)
+	}
+	return nil
+}
+
+func (r *Runner) createPods(ctx context.Context, s *Scenario, c *CreatePods, o *observation) error {
+	klog.V(1).Infof("Creating %d pods of wave %s", c.Count, c.Wave)
+	for i := 0; i < c.Count; i++ {
+		pod := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-%d", c.Wave, i),
+				Namespace:   c.Namespace,
+				Labels:      map[string]string{ScenarioLabel: s.Name, WaveLabel: c.Wave},
+				Annotations: map[string]string{drain.PodSafeToEvictKey: "true"},
+			},
+			Spec: apiv1.PodSpec{
+				Containers: []apiv1.Container{{
+					Name:  "benchmark",
+					Image: "registry.k8s.io/pause:3.9",
+					Resources: apiv1.ResourceRequirements{
+						Requests: apiv1.ResourceList{apiv1.ResourceCPU: c.CPU, apiv1.ResourceMemory: c.Memory},
+					},
+				}},
+				NodeSelector: c.NodeSelector,
+				Tolerations: []apiv1.Toleration{
+					{Key: kwok.ProviderTaintKey, Operator: apiv1.TolerationOpExists},
+					{Key: kwok.KwokManagedAnnotation, Operator: apiv1.TolerationOpExists},
+				},
+			},
+		}
+		if _, err := r.client.CoreV1().Pods(c.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		o.podsCreated++
+	}
+	return nil
+}
+
+func (r *Runner) deletePods(ctx context.Context, s *Scenario, d *DeletePods) error {
+	selector := labels.SelectorFromSet(labels.Set{ScenarioLabel: s.Name, WaveLabel: d.Wave}).String()
+	pods, err := r.client.CoreV1().Pods(d.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	count := d.Count
+	if count == 0 || count > len(pods.Items) {
+		count = len(pods.Items)
+	}
+	klog.V(1).Infof("Deleting %d pods of wave %s", count, d.Wave)
+	for _, pod := range pods.Items[:count] {
+		if err := r.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) failNodes(ctx context.Context, f *FailNodes) error {
+	nodes, err := r.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var candidates []apiv1.Node
+	for _, node := range nodes.Items {
+		if node.Annotations[kwok.NGNameAnnotation] == f.NodeGroup && !isFailed(&node) {
+			candidates = append(candidates, node)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	if len(candidates) > f.Count {
+		candidates = candidates[:f.Count]
+	}
+	klog.V(1).Infof("Failing %d nodes of node group %s", len(candidates), f.NodeGroup)
+	for i := range candidates {
+		node := &candidates[i]
+		setNotReady(node, time.Now())
+		if _, err := r.client.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to fail node %s: %v", node.Name, err)
+		}
+	}
+	return nil
+}
+
+func isFailed(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Reason == failedNodeReason
+		}
+	}
+	return false
+}
+
+func setNotReady(node *apiv1.Node, now time.Time) {
+	notReady := apiv1.NodeCondition{
+		Type:               apiv1.NodeReady,
+		Status:             apiv1.ConditionFalse,
+		Reason:             failedNodeReason,
+		Message:            "Node failure injected by kwok benchmark",
+		LastHeartbeatTime:  metav1.NewTime(now),
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	for i, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			node.Status.Conditions[i] = notReady
+			return
+		}
+	}
+	node.Status.Conditions = append(node.Status.Conditions, notReady)
+}
+
+func (r *Runner) setProviderLatency(ctx context.Context, latency *kwok.LatencyConfig) error {
+	// JSON is valid YAML, which the provider decodes.
+	value, err := json.Marshal(latency)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{kwok.LatencyConfigKey: string(value)},
+	})
+	if err != nil {
+		return err
+	}
+	klog.V(1).Infof("Setting kwok provider latency to %s", value)
+	_, err = r.client.CoreV1().ConfigMaps(r.providerNamespace).Patch(ctx, r.providerConfigMap, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func (r *Runner) sample(ctx context.Context, s *Scenario, o *observation, now time.Time) error {
+	selector := labels.SelectorFromSet(labels.Set{ScenarioLabel: s.Name}).String()
+	pods, err := r.client.CoreV1().Pods(apiv1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	nodes, err := r.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	kwokNodes := 0
+	for _, node := range nodes.Items {
+		if _, found := node.Annotations[kwok.NGNameAnnotation]; found {
+			kwokNodes++
+		}
+	}
+	o.observe(pods.Items, kwokNodes, now)
+	return nil
+}
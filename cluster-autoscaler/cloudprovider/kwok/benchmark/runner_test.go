@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/kwok"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestRun(t *testing.T) {
+	kwokNode := func(name string) *apiv1.Node {
+		node := BuildTestNode(name, 1000, 1000)
+		node.Annotations = map[string]string{kwok.NGNameAnnotation: "ng1"}
+		SetNodeReadyState(node, true, time.Now())
+		return node
+	}
+	n1, n2, n3 := kwokNode("n1"), kwokNode("n2"), kwokNode("n3")
+	configMap := &apiv1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kwok-provider-config", Namespace: "kube-system"}}
+	client := fake.NewSimpleClientset(n1, n2, n3, configMap)
+
+	loops := 0
+	metricsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# TYPE cluster_autoscaler_function_duration_seconds histogram\n")
+		fmt.Fprintf(w, "%s %d\n%s %d\n", mainLoopDurationSum, loops*2, mainLoopDurationCount, loops*4)
+		loops += 10
+	}))
+	defer metricsServer.Close()
+
+	s := &Scenario{
+		Name:           "test",
+		SettleTime:     metav1.Duration{Duration: 20 * time.Millisecond},
+		SampleInterval: metav1.Duration{Duration: 5 * time.Millisecond},
+		Steps: []Step{
+			{CreatePods: &CreatePods{Namespace: "default", Wave: "w", Count: 3, CPU: resource.MustParse("100m"), Memory: resource.MustParse("1Mi")}},
+			{At: metav1.Duration{Duration: 10 * time.Millisecond}, DeletePods: &DeletePods{Namespace: "default", Wave: "w", Count: 1}},
+			{At: metav1.Duration{Duration: 10 * time.Millisecond}, FailNodes: &FailNodes{NodeGroup: "ng1", Count: 2}},
+			{At: metav1.Duration{Duration: 15 * time.Millisecond}, ProviderLatency: &kwok.LatencyConfig{IncreaseSize: metav1.Duration{Duration: time.Second}}},
+		},
+	}
+	r := NewRunner(client, "kube-system", "kwok-provider-config", metricsServer.URL)
+	report, err := r.Run(context.Background(), s)
+	assert.NoError(t, err)
+
+	pods, err := client.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 2)
+	for _, pod := range pods.Items {
+		assert.Equal(t, "test", pod.Labels[ScenarioLabel])
+		assert.NotEqual(t, "w-0", pod.Name)
+	}
+
+	for name, failed := range map[string]bool{"n1": true, "n2": true, "n3": false} {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, failed, isFailed(node), name)
+	}
+
+	configMap, err = client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "kwok-provider-config", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"increaseSize":"1s","deleteNodes":"0s"}`, configMap.Data[kwok.LatencyConfigKey])
+
+	assert.Equal(t, "test", report.Scenario)
+	assert.Equal(t, 3, report.PodsCreated)
+	assert.Equal(t, 0, report.PodsScheduled)
+	assert.Equal(t, 2, report.PodsPending)
+	assert.Equal(t, 3, report.PeakNodes)
+	assert.Equal(t, 3, report.FinalNodes)
+	assert.Greater(t, report.NodeHours, 0.0)
+	assert.Equal(t, 40, report.MainLoops)
+	assert.Equal(t, 500*time.Millisecond, report.AverageLoopTime.Duration)
+}
+
+func TestObservationReport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	scheduledPod := func(name string, latency time.Duration) apiv1.Pod {
+		pod := BuildTestPod(name, 100, 100)
+		pod.CreationTimestamp = metav1.NewTime(start)
+		pod.Status.Conditions = []apiv1.PodCondition{{
+			Type:               apiv1.PodScheduled,
+			Status:             apiv1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(start.Add(latency)),
+		}}
+		return *pod
+	}
+	pending := *BuildTestPod("pending", 100, 100)
+
+	o := newObservation("test")
+	o.observe([]apiv1.Pod{scheduledPod("p1", time.Second), pending}, 2, start)
+	o.observe([]apiv1.Pod{scheduledPod("p1", time.Second), scheduledPod("p2", 3*time.Second), scheduledPod("p3", 2*time.Second), pending}, 4, start.Add(30*time.Minute))
+	o.observe([]apiv1.Pod{scheduledPod("p2", 3*time.Second)}, 1, start.Add(time.Hour))
+	report := o.report(time.Hour)
+
+	assert.Equal(t, 3, report.PodsScheduled)
+	assert.Equal(t, 0, report.PodsPending)
+	assert.Equal(t, 2*time.Second, report.SchedulingLatency.P50.Duration)
+	assert.Equal(t, 3*time.Second, report.SchedulingLatency.P99.Duration)
+	assert.Equal(t, 3*time.Second, report.SchedulingLatency.Max.Duration)
+	assert.Equal(t, 4, report.PeakNodes)
+	assert.Equal(t, 1, report.FinalNodes)
+	assert.Equal(t, 3.0, report.NodeHours)
+
+	out := &strings.Builder{}
+	assert.NoError(t, report.Write(out))
+	assert.Contains(t, out.String(), `"nodeHours": 3`)
+}
+
+func TestParseLoopMetrics(t *testing.T) {
+	m, err := parseLoopMetrics(strings.NewReader(strings.Join([]string{
+		`cluster_autoscaler_function_duration_seconds_bucket{function="main",le="0.01"} 1`,
+		`cluster_autoscaler_function_duration_seconds_sum{function="main"} 12.5`,
+		`cluster_autoscaler_function_duration_seconds_count{function="main"} 5`,
+		`cluster_autoscaler_function_duration_seconds_sum{function="scaleUp"} 3`,
+	}, "\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, loopMetrics{sum: 12.5, count: 5}, m)
+
+	_, err = parseLoopMetrics(strings.NewReader("cluster_autoscaler_nodes_count 3\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/kwok"
+)
+
+const defaultSampleInterval = 10 * time.Second
+
+// Scenario is a script of timed steps applied to a cluster autoscaled by CA
+// with the kwok provider, to benchmark CA reproducibly.
+type Scenario struct {
+	// Name identifies the scenario in reports and labels of the pods it creates.
+	Name string `json:"name"`
+	// SettleTime is how long the cluster is observed after the last step.
+	SettleTime metav1.Duration `json:"settleTime"`
+	// SampleInterval is the interval between observations of the cluster, 10s by default.
+	SampleInterval metav1.Duration `json:"sampleInterval"`
+	// Steps are run in order, each at its offset from the start of the scenario.
+	Steps []Step `json:"steps"`
+}
+
+// Step is a single action of a scenario. Exactly one of its actions must be set.
+type Step struct {
+	// At is the offset from the start of the scenario at which the step is run.
+	At metav1.Duration `json:"at"`
+	// CreatePods creates a wave of pods.
+	CreatePods *CreatePods `json:"createPods,omitempty"`
+	// DeletePods deletes pods of a wave.
+	DeletePods *DeletePods `json:"deletePods,omitempty"`
+	// FailNodes marks nodes of a node group as not ready.
+	FailNodes *FailNodes `json:"failNodes,omitempty"`
+	// ProviderLatency sets the artificial latency of kwok provider operations.
+	ProviderLatency *kwok.LatencyConfig `json:"providerLatency,omitempty"`
+}
+
+// CreatePods is a step creating a wave of identical pods.
+type CreatePods struct {
+	Namespace string `json:"namespace"`
+	// Wave names the pods, which can be deleted by a later DeletePods step of the same wave.
+	Wave         string            `json:"wave"`
+	Count        int               `json:"count"`
+	CPU          resource.Quantity `json:"cpu"`
+	Memory       resource.Quantity `json:"memory"`
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+// DeletePods is a step deleting pods of a wave.
+type DeletePods struct {
+	Namespace string `json:"namespace"`
+	Wave      string `json:"wave"`
+	// Count is the number of pods deleted, all pods of the wave if 0.
+	Count int `json:"count"`
+}
+
+// FailNodes is a step marking nodes of a node group as not ready, to inject node failures.
+type FailNodes struct {
+	NodeGroup string `json:"nodeGroup"`
+	Count     int    `json:"count"`
+}
+
+// LoadScenario decodes a YAML or JSON scenario and validates it.
+func LoadScenario(r io.Reader) (*Scenario, error) {
+	s := &Scenario{}
+	if err := yaml.NewYAMLOrJSONDecoder(r, 4096).Decode(s); err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %v", err)
+	}
+	if s.SampleInterval.Duration == 0 {
+		s.SampleInterval.Duration = defaultSampleInterval
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Scenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario name is empty")
+	}
+	if s.SampleInterval.Duration < 0 || s.SettleTime.Duration < 0 {
+		return fmt.Errorf("sample interval and settle time can't be negative")
+	}
+	var previous time.Duration
+	for i, step := range s.Steps {
+		if step.At.Duration < previous {
+			return fmt.Errorf("step %d at %v is before the previous step at %v", i, step.At.Duration, previous)
+		}
+		previous = step.At.Duration
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("invalid step %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (s Step) validate() error {
+	actions := 0
+	if s.CreatePods != nil {
+		actions++
+		if s.CreatePods.Wave == "" || s.CreatePods.Namespace == "" {
+			return fmt.Errorf("createPods needs a namespace and a wave")
+		}
+		if s.CreatePods.Count <= 0 {
+			return fmt.Errorf("createPods count must be positive, got %d", s.CreatePods.Count)
+		}
+	}
+	if s.DeletePods != nil {
+		actions++
+		if s.DeletePods.Wave == "" || s.DeletePods.Namespace == "" {
+			return fmt.Errorf("deletePods needs a namespace and a wave")
+		}
+		if s.DeletePods.Count < 0 {
+			return fmt.Errorf("deletePods count can't be negative, got %d", s.DeletePods.Count)
+		}
+	}
+	if s.FailNodes != nil {
+		actions++
+		if s.FailNodes.NodeGroup == "" || s.FailNodes.Count <= 0 {
+			return fmt.Errorf("failNodes needs a node group and a positive count")
+		}
+	}
+	if s.ProviderLatency != nil {
+		actions++
+	}
+	if actions != 1 {
+		return fmt.Errorf("exactly one action must be set, got %d", actions)
+	}
+	return nil
+}
+
+// duration returns the time from the start of the scenario to the end of its observation.
+func (s *Scenario) duration() time.Duration {
+	var last time.Duration
+	if len(s.Steps) > 0 {
+		last = s.Steps[len(s.Steps)-1].At.Duration
+	}
+	return last + s.SettleTime.Duration
+}
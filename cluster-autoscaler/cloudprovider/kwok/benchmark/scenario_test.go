@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const testScenario = `
+name: burst
+settleTime: 5m
+steps:
+- at: 0s
+  createPods:
+    namespace: default
+    wave: first
+    count: 100
+    cpu: 500m
+    memory: 256Mi
+- at: 1m
+  providerLatency:
+    increaseSize: 10s
+- at: 2m
+  failNodes:
+    nodeGroup: ng1
+    count: 2
+- at: 3m
+  deletePods:
+    namespace: default
+    wave: first
+`
+
+func TestLoadScenario(t *testing.T) {
+	s, err := LoadScenario(strings.NewReader(testScenario))
+	assert.NoError(t, err)
+	assert.Equal(t, "burst", s.Name)
+	assert.Equal(t, defaultSampleInterval, s.SampleInterval.Duration)
+	assert.Equal(t, 8*time.Minute, s.duration())
+	assert.Len(t, s.Steps, 4)
+	assert.Equal(t, 100, s.Steps[0].CreatePods.Count)
+	assert.Equal(t, resource.MustParse("500m"), s.Steps[0].CreatePods.CPU)
+	assert.Equal(t, 10*time.Second, s.Steps[1].ProviderLatency.IncreaseSize.Duration)
+	assert.Equal(t, "ng1", s.Steps[2].FailNodes.NodeGroup)
+	assert.Equal(t, 0, s.Steps[3].DeletePods.Count)
+
+	for name, scenario := range map[string]string{
+		"no name":            "steps: []",
+		"steps out of order": "name: s\nsteps:\n- at: 2m\n  providerLatency: {}\n- at: 1m\n  providerLatency: {}\n",
+		"no action":          "name: s\nsteps:\n- at: 1m\n",
+		"two actions":        "name: s\nsteps:\n- at: 1m\n  providerLatency: {}\n  failNodes: {nodeGroup: ng1, count: 1}\n",
+		"no pods":            "name: s\nsteps:\n- createPods: {namespace: default, wave: w, count: 0}\n",
+		"unknown duration":   "name: s\nsettleTime: soon\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadScenario(strings.NewReader(scenario))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	kubeclient "k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
 	klog "k8s.io/klog/v2"
 )
 
@@ -88,6 +89,11 @@ func LoadConfigFile(kubeClient kubeclient.Interface) (*KwokProviderConfig, error
 	if kwokConfig.status == nil {
 		kwokConfig.status = &GroupingConfig{}
 	}
+	kwokConfig.configMapNamespace = currentNamespace
+	kwokConfig.configMapName = configMapName
+	if kwokConfig.latency, err = parseLatencyConfig(c.Data[LatencyConfigKey]); err != nil {
+		return nil, err
+	}
 
 	switch kwokConfig.ReadNodesFrom {
 	case nodeTemplatesFromConfigMap:
@@ -151,3 +157,26 @@ func LoadConfigFile(kubeClient kubeclient.Interface) (*KwokProviderConfig, error
 
 	return &kwokConfig, nil
 }
+
+// parseLatencyConfig parses the latency config of the provider configmap. An
+// empty config means no artificial latency.
+func parseLatencyConfig(data string) (*LatencyConfig, error) {
+	latency := &LatencyConfig{}
+	if strings.TrimSpace(data) == "" {
+		return latency, nil
+	}
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(data), 4096)
+	if err := decoder.Decode(latency); err != nil {
+		return nil, fmt.Errorf("failed to decode kwok latency config: %v", err)
+	}
+	return latency, nil
+}
+
+// loadLatencyConfig reloads the latency config from the provider configmap in the lister's cache.
+func loadLatencyConfig(configMapLister listersv1.ConfigMapLister, namespace, name string) (*LatencyConfig, error) {
+	c, err := configMapLister.ConfigMaps(namespace).Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap '%s': %v", name, err)
+	}
+	return parseLatencyConfig(c.Data[LatencyConfigKey])
+}
@@ -18,15 +18,19 @@ package kwok
 
 import (
 	"testing"
+	"time"
 
 	"os"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
+
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 )
 
 var testConfigs = map[string]string{
@@ -283,3 +287,28 @@ func TestLoadConfigFile(t *testing.T) {
 	assert.NotNil(t, kwokConfig.status)
 	assert.NotEmpty(t, kwokConfig.status.gpuLabel)
 }
+
+func TestLoadLatencyConfig(t *testing.T) {
+	latency := map[string]string{}
+	lister, err := kube_util.NewTestConfigMapLister([]*v1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: defaultConfigName}, Data: latency},
+	})
+	assert.NoError(t, err)
+
+	l, err := loadLatencyConfig(lister, "kube-system", defaultConfigName)
+	assert.NoError(t, err)
+	assert.Equal(t, &LatencyConfig{}, l)
+
+	latency[LatencyConfigKey] = "increaseSize: 5s\ndeleteNodes: 1m\n"
+	l, err = loadLatencyConfig(lister, "kube-system", defaultConfigName)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, l.IncreaseSize.Duration)
+	assert.Equal(t, time.Minute, l.DeleteNodes.Duration)
+
+	latency[LatencyConfigKey] = "increaseSize: soon"
+	_, err = loadLatencyConfig(lister, "kube-system", defaultConfigName)
+	assert.Error(t, err)
+
+	_, err = loadLatencyConfig(lister, "kube-system", "missing")
+	assert.Error(t, err)
+}
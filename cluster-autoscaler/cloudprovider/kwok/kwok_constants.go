@@ -34,6 +34,14 @@ const (
 	// a node it sees in the cluster
 	KwokManagedAnnotation = "kwok.x-k8s.io/node"
 
+	// ProviderTaintKey is the key of the taint kwok provider adds to template nodes
+	// (unless nodes.skipTaint is set), so that only pods tolerating it land on kwok nodes
+	ProviderTaintKey = "kwok-provider"
+
+	// LatencyConfigKey is the optional key of the provider configmap holding the LatencyConfig.
+	// It is reloaded on every refresh, so that latency can be changed while CA is running.
+	LatencyConfigKey = "latency"
+
 	groupNodesByAnnotation = "annotation"
 	groupNodesByLabel      = "label"
 
@@ -19,6 +19,7 @@ package kwok
 import (
 	"context"
 	"fmt"
+	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -67,6 +68,7 @@ func (nodeGroup *NodeGroup) IncreaseSize(delta int) error {
 	}
 
 	klog.V(5).Infof("increasing size of nodegroup '%s' to %v (old size: %v, delta: %v)", nodeGroup.name, newSize, size, delta)
+	nodeGroup.injectLatency(func(l *LatencyConfig) time.Duration { return l.IncreaseSize.Duration })
 
 	schedNode, err := nodeGroup.TemplateNodeInfo()
 	if err != nil {
@@ -103,6 +105,8 @@ func (nodeGroup *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
 		return fmt.Errorf(belowMinSizeErr)
 	}
 
+	nodeGroup.injectLatency(func(l *LatencyConfig) time.Duration { return l.DeleteNodes.Duration })
+
 	for _, node := range nodes {
 		// TODO(vadasambar): check if there's a better way than returning an error here
 		if node.GetAnnotations()[KwokManagedAnnotation] != "fake" {
@@ -143,6 +147,21 @@ func (nodeGroup *NodeGroup) DecreaseTargetSize(delta int) error {
 	return nil
 }
 
+// injectLatency sleeps for the artificial latency of an operation, if any is configured
+func (nodeGroup *NodeGroup) injectLatency(operationLatency func(*LatencyConfig) time.Duration) {
+	if nodeGroup.latency == nil {
+		return
+	}
+	latency := nodeGroup.latency.Load()
+	if latency == nil {
+		return
+	}
+	if d := operationLatency(latency); d > 0 {
+		klog.V(5).Infof("injecting %v latency into an operation of nodegroup '%s'", d, nodeGroup.name)
+		time.Sleep(d)
+	}
+}
+
 // getNodeNamesForNodeGroup returns list of nodes belonging to the nodegroup
 func (nodeGroup *NodeGroup) getNodeNamesForNodeGroup() ([]string, error) {
 	names := []string{}
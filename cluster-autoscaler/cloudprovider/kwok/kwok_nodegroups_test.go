@@ -18,6 +18,7 @@ package kwok
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -87,6 +88,16 @@ func TestIncreaseSize(t *testing.T) {
 	assert.Contains(t, err.Error(), maxSizeReachedErr)
 	assert.Len(t, nodes, 0)
 
+	// injected latency
+	nodes = []*apiv1.Node{}
+	ng.targetSize = 2
+	ng.latency = &atomic.Pointer[LatencyConfig]{}
+	ng.latency.Store(&LatencyConfig{IncreaseSize: metav1.Duration{Duration: 20 * time.Millisecond}})
+	start := time.Now()
+	err = ng.IncreaseSize(1)
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 1)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
 }
 
 func TestDeleteNodes(t *testing.T) {
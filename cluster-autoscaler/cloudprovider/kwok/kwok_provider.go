@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
@@ -148,9 +149,25 @@ func (kwok *KwokCloudProvider) Refresh() error {
 		ng.targetSize = targetSizeInCluster[ng.Id()]
 	}
 
+	kwok.refreshLatency()
+
 	return nil
 }
 
+// refreshLatency reloads the artificial latency of node group operations from
+// the informer cache of the provider configmap, keeping the previous latency if it can't be loaded
+func (kwok *KwokCloudProvider) refreshLatency() {
+	if kwok.latency == nil || kwok.configMapLister == nil || kwok.config == nil || kwok.config.configMapName == "" {
+		return
+	}
+	latency, err := loadLatencyConfig(kwok.configMapLister, kwok.config.configMapNamespace, kwok.config.configMapName)
+	if err != nil {
+		klog.Warningf("failed to reload kwok latency config, keeping the previous one: %v", err)
+		return
+	}
+	kwok.latency.Store(latency)
+}
+
 // Cleanup cleans up all resources before the cloud provider is removed
 func (kwok *KwokCloudProvider) Cleanup() error {
 	for _, ng := range kwok.nodeGroups {
@@ -208,7 +225,8 @@ func BuildKwok(opts config.AutoscalingOptions,
 		discoveryOpts:   &do,
 		resourceLimiter: rl,
 		ngNodeListerFn:  kube_util.NewNodeLister,
-		allNodesLister:  informerFactory.Core().V1().Nodes().Lister()})
+		allNodesLister:  informerFactory.Core().V1().Nodes().Lister(),
+		configMapLister: informerFactory.Core().V1().ConfigMaps().Lister()})
 
 	if err != nil {
 		klog.Fatal(err)
@@ -246,18 +264,26 @@ func BuildKwokProvider(ko *kwokOptions) (*KwokCloudProvider, error) {
 
 	nodegroups = createNodegroups(nodeTemplates, ko.kubeClient, kwokConfig, ko.ngNodeListerFn, ko.allNodesLister)
 
+	latency := &atomic.Pointer[LatencyConfig]{}
+	latency.Store(kwokConfig.latency)
+	for _, ng := range nodegroups {
+		ng.latency = latency
+	}
+
 	return &KwokCloudProvider{
 		nodeGroups:      nodegroups,
 		kubeClient:      ko.kubeClient,
 		resourceLimiter: ko.resourceLimiter,
 		config:          kwokConfig,
 		allNodesLister:  ko.allNodesLister,
+		latency:         latency,
+		configMapLister: ko.configMapLister,
 	}, nil
 }
 
 func kwokProviderTaint() apiv1.Taint {
 	return apiv1.Taint{
-		Key:    "kwok-provider",
+		Key:    ProviderTaintKey,
 		Value:  "true",
 		Effect: apiv1.TaintEffectNoSchedule,
 	}
@@ -17,7 +17,10 @@ limitations under the License.
 package kwok
 
 import (
+	"sync/atomic"
+
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 
@@ -35,6 +38,11 @@ type KwokCloudProvider struct {
 	kubeClient kubernetes.Interface
 	//allNodesLister is a lister to list all nodes in cluster
 	allNodesLister listersv1.NodeLister
+	// latency is the artificial latency of node group operations,
+	// shared with the node groups and reloaded on every refresh
+	latency *atomic.Pointer[LatencyConfig]
+	// configMapLister lists the configmaps the latency is reloaded from
+	configMapLister listersv1.ConfigMapLister
 }
 
 type kwokOptions struct {
@@ -48,6 +56,8 @@ type kwokOptions struct {
 	allNodesLister listersv1.NodeLister
 	// nodeLister lists all nodes managed by kwok for a specific nodegroup
 	ngNodeListerFn listerFn
+	// configMapLister lists the configmaps the latency is reloaded from, if set
+	configMapLister listersv1.ConfigMapLister
 }
 
 // NodeGroup implements NodeGroup interface.
@@ -59,6 +69,7 @@ type NodeGroup struct {
 	minSize      int
 	targetSize   int
 	maxSize      int
+	latency      *atomic.Pointer[LatencyConfig]
 }
 
 // NodegroupsConfig defines options for creating nodegroups
@@ -85,6 +96,13 @@ type GPUConfig struct {
 	AvailableGPUTypes map[string]struct{} `json:"availableGPUTypes" yaml:"availableGPUTypes"`
 }
 
+// LatencyConfig defines artificial latencies of node group operations,
+// to simulate slow cloud provider APIs when benchmarking CA
+type LatencyConfig struct {
+	IncreaseSize metav1.Duration `json:"increaseSize" yaml:"increaseSize"`
+	DeleteNodes  metav1.Duration `json:"deleteNodes" yaml:"deleteNodes"`
+}
+
 // KwokConfig is the struct to define kwok specific config
 // (needs to be implemented; currently empty)
 type KwokConfig struct {
@@ -99,6 +117,11 @@ type KwokProviderConfig struct {
 	ConfigMap     *ConfigMapConfig  `json:"configmap" yaml:"configmap"`
 	Kwok          *KwokConfig       `json:"kwok" yaml:"kwok"`
 	status        *GroupingConfig
+	// configMapNamespace and configMapName locate the configmap
+	// the config was loaded from, to reload the latency config
+	configMapNamespace string
+	configMapName      string
+	latency            *LatencyConfig
 }
 
 // GroupingConfig defines different
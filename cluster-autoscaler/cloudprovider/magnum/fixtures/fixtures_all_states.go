@@ -105,11 +105,11 @@ var ExpectedInstances = []cloudprovider.Instance{
 	// Failed nodes
 	{f(AllNodes[10].Index), &cloudprovider.InstanceStatus{
 		cloudprovider.InstanceCreating, &cloudprovider.InstanceErrorInfo{
-			cloudprovider.OutOfResourcesErrorClass, "", "out of quota"}},
+			ErrorClass: cloudprovider.OutOfResourcesErrorClass, ErrorKind: cloudprovider.QuotaErrorKind, ErrorMessage: "out of quota"}},
 	},
 	{f(AllNodes[11].Index), &cloudprovider.InstanceStatus{
 		cloudprovider.InstanceCreating, &cloudprovider.InstanceErrorInfo{
-			cloudprovider.OtherErrorClass, "", "other error"}},
+			ErrorClass: cloudprovider.OtherErrorClass, ErrorMessage: "other error"}},
 	},
 	// node 12 is not reported
 }
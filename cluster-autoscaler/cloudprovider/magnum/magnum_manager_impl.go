@@ -339,14 +339,17 @@ func (mgr *magnumManagerImpl) getNodes(nodegroup string) ([]cloudprovider.Instan
 			instance.Status.State = cloudprovider.InstanceCreating
 
 			errorClass := cloudprovider.OtherErrorClass
+			errorKind := cloudprovider.UnknownErrorKind
 
 			// Check if the error message is for exceeding the project quota.
 			if strings.Contains(strings.ToLower(minion.StatusReason), "quota") {
 				errorClass = cloudprovider.OutOfResourcesErrorClass
+				errorKind = cloudprovider.QuotaErrorKind
 			}
 
 			instance.Status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
 				ErrorClass:   errorClass,
+				ErrorKind:    errorKind,
 				ErrorMessage: minion.StatusReason,
 			}
 
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ipconsts "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/instancepools/consts"
+	npconsts "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/nodepools/consts"
+)
+
+const (
+	// MinSizeTag is the default freeform tag key overriding the min size of a discovered pool.
+	MinSizeTag = "cluster-autoscaler/min-size"
+	// MaxSizeTag is the default freeform tag key overriding the max size of a discovered pool.
+	MaxSizeTag = "cluster-autoscaler/max-size"
+)
+
+// AutoDiscoverySpec is a parsed --node-group-auto-discovery spec of the form
+// `<nodepool|instancepool>:compartmentId=<ocid>,clusterId=<ocid>,tag=<key>=<value>,min=<min>,max=<max>`.
+// compartmentId and tag can be repeated, a pool must match all the tags and be in one of the compartments.
+// Tag keys of the form `<namespace>.<key>` match defined tags, other keys freeform tags. A tag without
+// value matches any value. clusterId only applies to node pools.
+type AutoDiscoverySpec struct {
+	// PoolType is the type of the discovered pools, nodepool or instancepool.
+	PoolType string
+	// CompartmentIDs are the compartments the pools are discovered in. The compartment
+	// of the cloud config is used if empty.
+	CompartmentIDs []string
+	// ClusterID restricts the discovered node pools to a single cluster, if set.
+	ClusterID string
+	// Tags are the tags the pools must have, by key.
+	Tags map[string]string
+	// MinSize and MaxSize are the sizes of the discovered pools without size tags.
+	MinSize int
+	MaxSize int
+	// MinSizeTag and MaxSizeTag are the keys of the tags overriding the sizes of the pools.
+	MinSizeTag string
+	MaxSizeTag string
+}
+
+// ParseAutoDiscoverySpecs parses the given --node-group-auto-discovery specs.
+func ParseAutoDiscoverySpecs(specs []string) ([]AutoDiscoverySpec, error) {
+	var result []AutoDiscoverySpec
+	for _, spec := range specs {
+		parsed, err := ParseAutoDiscoverySpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+// ParseAutoDiscoverySpec parses a single --node-group-auto-discovery spec.
+func ParseAutoDiscoverySpec(spec string) (AutoDiscoverySpec, error) {
+	result := AutoDiscoverySpec{
+		Tags:       map[string]string{},
+		MinSize:    -1,
+		MaxSize:    -1,
+		MinSizeTag: MinSizeTag,
+		MaxSizeTag: MaxSizeTag,
+	}
+	tokens := strings.SplitN(spec, ":", 2)
+	if len(tokens) != 2 {
+		return result, fmt.Errorf("invalid auto-discovery spec %q, expected <nodepool|instancepool>:<key>=<value>,...", spec)
+	}
+	result.PoolType = tokens[0]
+	if result.PoolType != npconsts.OciNodePoolResourceIdent && result.PoolType != ipconsts.OciInstancePoolResourceIdent {
+		return result, fmt.Errorf("unsupported pool type %q in auto-discovery spec %q", result.PoolType, spec)
+	}
+	for _, option := range strings.Split(tokens[1], ",") {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return result, fmt.Errorf("invalid option %q in auto-discovery spec %q", option, spec)
+		}
+		var err error
+		switch kv[0] {
+		case "compartmentId":
+			result.CompartmentIDs = append(result.CompartmentIDs, kv[1])
+		case "clusterId":
+			result.ClusterID = kv[1]
+		case "tag":
+			tag := strings.SplitN(kv[1], "=", 2)
+			if len(tag) == 1 {
+				result.Tags[tag[0]] = ""
+			} else {
+				result.Tags[tag[0]] = tag[1]
+			}
+		case "min":
+			result.MinSize, err = strconv.Atoi(kv[1])
+		case "max":
+			result.MaxSize, err = strconv.Atoi(kv[1])
+		case "minSizeTag":
+			result.MinSizeTag = kv[1]
+		case "maxSizeTag":
+			result.MaxSizeTag = kv[1]
+		default:
+			return result, fmt.Errorf("unsupported option %q in auto-discovery spec %q", kv[0], spec)
+		}
+		if err != nil {
+			return result, fmt.Errorf("invalid %s in auto-discovery spec %q: %v", kv[0], spec, err)
+		}
+	}
+	if len(result.Tags) == 0 {
+		return result, fmt.Errorf("auto-discovery spec %q must have at least one tag", spec)
+	}
+	if result.ClusterID != "" && result.PoolType != npconsts.OciNodePoolResourceIdent {
+		return result, fmt.Errorf("clusterId only applies to node pools in auto-discovery spec %q", spec)
+	}
+	if result.MinSize < 0 || result.MaxSize < result.MinSize {
+		return result, fmt.Errorf("auto-discovery spec %q must have min and max sizes, with min <= max", spec)
+	}
+	return result, nil
+}
+
+// Matches returns true if a pool with the given tags has all the tags of the spec.
+func (s AutoDiscoverySpec) Matches(freeformTags map[string]string, definedTags map[string]map[string]interface{}) bool {
+	for key, value := range s.Tags {
+		tagValue, found := lookupTag(key, freeformTags, definedTags)
+		if !found || (value != "" && tagValue != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sizes returns the min and max sizes of a pool with the given tags, the size tags taking
+// precedence over the sizes of the spec.
+func (s AutoDiscoverySpec) Sizes(freeformTags map[string]string, definedTags map[string]map[string]interface{}) (int, int, error) {
+	minSize, maxSize := s.MinSize, s.MaxSize
+	if value, found := lookupTag(s.MinSizeTag, freeformTags, definedTags); found {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid min size tag %s=%q: %v", s.MinSizeTag, value, err)
+		}
+		minSize = size
+	}
+	if value, found := lookupTag(s.MaxSizeTag, freeformTags, definedTags); found {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid max size tag %s=%q: %v", s.MaxSizeTag, value, err)
+		}
+		maxSize = size
+	}
+	if minSize < 0 || maxSize < minSize {
+		return 0, 0, fmt.Errorf("invalid sizes, min %d and max %d", minSize, maxSize)
+	}
+	return minSize, maxSize, nil
+}
+
+// lookupTag returns the value of the freeform tag with the given key or, if the key is of the form
+// `<namespace>.<key>`, of the defined tag.
+func lookupTag(key string, freeformTags map[string]string, definedTags map[string]map[string]interface{}) (string, bool) {
+	if value, found := freeformTags[key]; found {
+		return value, true
+	}
+	tokens := strings.SplitN(key, ".", 2)
+	if len(tokens) != 2 {
+		return "", false
+	}
+	value, found := definedTags[tokens[0]][tokens[1]]
+	if !found {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// GetPoolTypeWithAutoDiscovery returns the resource type of the pools given by the node group specs
+// and the auto-discovery specs, or an error if they aren't all of the same type.
+func GetPoolTypeWithAutoDiscovery(groups []string, autoDiscoverySpecs []string) (string, error) {
+	poolType, err := GetAllPoolTypes(groups)
+	if err != nil {
+		return "", err
+	}
+	for _, spec := range autoDiscoverySpecs {
+		specType := strings.SplitN(spec, ":", 2)[0]
+		if poolType == "" {
+			poolType = specType
+		} else if specType != poolType {
+			return "", fmt.Errorf("found multiple pool types, but cluster autoscaler currently only supports either instance pools OR node pools: %s and %s", poolType, specType)
+		}
+	}
+	return poolType, nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAutoDiscoverySpec(t *testing.T) {
+	testCases := map[string]struct {
+		spec      string
+		expected  AutoDiscoverySpec
+		expectErr bool
+	}{
+		"node pools": {
+			spec: "nodepool:compartmentId=ocid1.compartment.oc1..aaa,compartmentId=ocid1.compartment.oc1..bbb,clusterId=ocid1.cluster.oc1..aaa,tag=ca-managed=true,tag=ns.team,min=1,max=5",
+			expected: AutoDiscoverySpec{
+				PoolType:       "nodepool",
+				CompartmentIDs: []string{"ocid1.compartment.oc1..aaa", "ocid1.compartment.oc1..bbb"},
+				ClusterID:      "ocid1.cluster.oc1..aaa",
+				Tags:           map[string]string{"ca-managed": "true", "ns.team": ""},
+				MinSize:        1,
+				MaxSize:        5,
+				MinSizeTag:     MinSizeTag,
+				MaxSizeTag:     MaxSizeTag,
+			},
+		},
+		"instance pools with size tags": {
+			spec: "instancepool:tag=ns.ca-managed=true,min=0,max=3,minSizeTag=ns.min,maxSizeTag=ns.max",
+			expected: AutoDiscoverySpec{
+				PoolType:   "instancepool",
+				Tags:       map[string]string{"ns.ca-managed": "true"},
+				MinSize:    0,
+				MaxSize:    3,
+				MinSizeTag: "ns.min",
+				MaxSizeTag: "ns.max",
+			},
+		},
+		"unsupported pool type": {
+			spec:      "asg:tag=ca-managed,min=1,max=5",
+			expectErr: true,
+		},
+		"missing options": {
+			spec:      "nodepool",
+			expectErr: true,
+		},
+		"unsupported option": {
+			spec:      "nodepool:tag=ca-managed,min=1,max=5,foo=bar",
+			expectErr: true,
+		},
+		"no tag": {
+			spec:      "nodepool:min=1,max=5",
+			expectErr: true,
+		},
+		"no sizes": {
+			spec:      "nodepool:tag=ca-managed",
+			expectErr: true,
+		},
+		"min greater than max": {
+			spec:      "nodepool:tag=ca-managed,min=5,max=1",
+			expectErr: true,
+		},
+		"invalid min": {
+			spec:      "nodepool:tag=ca-managed,min=one,max=5",
+			expectErr: true,
+		},
+		"cluster id of instance pools": {
+			spec:      "instancepool:clusterId=ocid1.cluster.oc1..aaa,tag=ca-managed,min=1,max=5",
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec, err := ParseAutoDiscoverySpec(tc.spec)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error parsing %q", tc.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(spec, tc.expected) {
+				t.Errorf("got %+v, expected %+v", spec, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAutoDiscoverySpecMatchesAndSizes(t *testing.T) {
+	spec, err := ParseAutoDiscoverySpec("nodepool:tag=ca-managed=true,tag=ns.team,min=1,max=5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		freeformTags  map[string]string
+		definedTags   map[string]map[string]interface{}
+		expectMatch   bool
+		expectMinSize int
+		expectMaxSize int
+		expectErr     bool
+	}{
+		"all tags": {
+			freeformTags:  map[string]string{"ca-managed": "true"},
+			definedTags:   map[string]map[string]interface{}{"ns": {"team": "a"}},
+			expectMatch:   true,
+			expectMinSize: 1,
+			expectMaxSize: 5,
+		},
+		"size tags": {
+			freeformTags:  map[string]string{"ca-managed": "true", MaxSizeTag: "10"},
+			definedTags:   map[string]map[string]interface{}{"ns": {"team": "a"}},
+			expectMatch:   true,
+			expectMinSize: 1,
+			expectMaxSize: 10,
+		},
+		"invalid size tag": {
+			freeformTags: map[string]string{"ca-managed": "true", MinSizeTag: "6"},
+			definedTags:  map[string]map[string]interface{}{"ns": {"team": "a"}},
+			expectMatch:  true,
+			expectErr:    true,
+		},
+		"other tag value": {
+			freeformTags: map[string]string{"ca-managed": "false"},
+			definedTags:  map[string]map[string]interface{}{"ns": {"team": "a"}},
+		},
+		"missing defined tag": {
+			freeformTags: map[string]string{"ca-managed": "true"},
+			definedTags:  map[string]map[string]interface{}{"other": {"team": "a"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if match := spec.Matches(tc.freeformTags, tc.definedTags); match != tc.expectMatch {
+				t.Fatalf("got match %v, expected %v", match, tc.expectMatch)
+			}
+			if !tc.expectMatch {
+				return
+			}
+			minSize, maxSize, err := spec.Sizes(tc.freeformTags, tc.definedTags)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if minSize != tc.expectMinSize || maxSize != tc.expectMaxSize {
+				t.Errorf("got sizes %d-%d, expected %d-%d", minSize, maxSize, tc.expectMinSize, tc.expectMaxSize)
+			}
+		})
+	}
+}
+
+func TestGetPoolTypeWithAutoDiscovery(t *testing.T) {
+	poolType, err := GetPoolTypeWithAutoDiscovery(nil, []string{"nodepool:tag=ca-managed,min=1,max=5"})
+	if err != nil || poolType != "nodepool" {
+		t.Errorf("got %q, %v, expected nodepool", poolType, err)
+	}
+	poolType, err = GetPoolTypeWithAutoDiscovery([]string{"1:5:ocid1.instancepool.oc1..aaa"}, []string{"instancepool:tag=ca-managed,min=1,max=5"})
+	if err != nil || poolType != "instancepool" {
+		t.Errorf("got %q, %v, expected instancepool", poolType, err)
+	}
+	if _, err := GetPoolTypeWithAutoDiscovery([]string{"1:5:ocid1.instancepool.oc1..aaa"}, []string{"nodepool:tag=ca-managed,min=1,max=5"}); err == nil {
+		t.Error("expected an error mixing pool types")
+	}
+}
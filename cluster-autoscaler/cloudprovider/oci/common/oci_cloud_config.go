@@ -6,6 +6,7 @@ package common
 
 import (
 	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -24,6 +25,8 @@ type CloudConfig struct {
 		Region                 string        `gcfg:"region"`
 		UseInstancePrinciples  bool          `gcfg:"use-instance-principals"`
 		UseNonMemberAnnotation bool          `gcfg:"use-non-member-annotation"`
+		VirtualNodePools       []string      `gcfg:"virtual-node-pool"`
+		VirtualNodePoolPolicy  string        `gcfg:"virtual-node-pool-policy"`
 	}
 }
 
@@ -74,6 +77,17 @@ func CreateCloudConfig(cloudConfigPath string, configProvider common.Configurati
 		cloudConfig.Global.UseNonMemberAnnotation = true
 	}
 
+	// virtual node pools are only relevant for node pools
+	if len(cloudConfig.Global.VirtualNodePools) == 0 && os.Getenv(npconsts.OciVirtualNodePoolsEnvVar) != "" {
+		cloudConfig.Global.VirtualNodePools = strings.Split(os.Getenv(npconsts.OciVirtualNodePoolsEnvVar), ",")
+	}
+	if cloudConfig.Global.VirtualNodePoolPolicy == "" {
+		cloudConfig.Global.VirtualNodePoolPolicy = os.Getenv(npconsts.OciVirtualNodePoolPolicyEnvVar)
+	}
+	if cloudConfig.Global.VirtualNodePoolPolicy == "" {
+		cloudConfig.Global.VirtualNodePoolPolicy = npconsts.VirtualNodePoolPolicyDisabled
+	}
+
 	cloudConfig.Global.CompartmentID = os.Getenv(ipconsts.OciCompartmentEnvVar)
 
 	// Not passed by --cloud-config or environment variable, attempt to use the tenancy ID as the compartment ID
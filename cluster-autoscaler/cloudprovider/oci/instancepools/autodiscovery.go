@@ -0,0 +1,83 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package instancepools
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/core"
+)
+
+type instancePoolLister interface {
+	ListInstancePools(context.Context, core.ListInstancePoolsRequest) (core.ListInstancePoolsResponse, error)
+}
+
+// instancePoolSizes holds the sizes of a discovered instance pool.
+type instancePoolSizes struct {
+	minSize int
+	maxSize int
+}
+
+// discoverInstancePools returns the sizes of the instance pools matching the auto-discovery specs, by
+// instance pool id. An instance pool matching several specs gets the sizes of the first one.
+func discoverInstancePools(client instancePoolLister, specs []ocicommon.AutoDiscoverySpec, defaultCompartmentID string) (map[string]instancePoolSizes, error) {
+	result := map[string]instancePoolSizes{}
+	for _, spec := range specs {
+		compartmentIDs := spec.CompartmentIDs
+		if len(compartmentIDs) == 0 {
+			compartmentIDs = []string{defaultCompartmentID}
+		}
+		for _, compartmentID := range compartmentIDs {
+			summaries, err := listInstancePools(client, compartmentID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to list instance pools of compartment %s", compartmentID)
+			}
+			for _, summary := range summaries {
+				if summary.Id == nil || !spec.Matches(summary.FreeformTags, summary.DefinedTags) {
+					continue
+				}
+				if _, found := result[*summary.Id]; found {
+					continue
+				}
+				minSize, maxSize, err := spec.Sizes(summary.FreeformTags, summary.DefinedTags)
+				if err != nil {
+					klog.Warningf("Ignoring discovered instance pool %s: %v", *summary.Id, err)
+					continue
+				}
+				result[*summary.Id] = instancePoolSizes{minSize: minSize, maxSize: maxSize}
+			}
+		}
+	}
+	return result, nil
+}
+
+// listInstancePools returns the instance pools of the compartment which aren't terminating or terminated.
+func listInstancePools(client instancePoolLister, compartmentID string) ([]core.InstancePoolSummary, error) {
+	request := core.ListInstancePoolsRequest{
+		CompartmentId: &compartmentID,
+	}
+	var summaries []core.InstancePoolSummary
+	for {
+		resp, err := client.ListInstancePools(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range resp.Items {
+			if summary.LifecycleState == core.InstancePoolSummaryLifecycleStateTerminating ||
+				summary.LifecycleState == core.InstancePoolSummaryLifecycleStateTerminated {
+				continue
+			}
+			summaries = append(summaries, summary)
+		}
+		if resp.OpcNextPage == nil {
+			return summaries, nil
+		}
+		request.Page = resp.OpcNextPage
+	}
+}
@@ -130,7 +130,7 @@ func (ocp *OciCloudProvider) Refresh() error {
 
 // BuildOCI constructs the OciCloudProvider object that implements the could provider interface (InstancePoolManager).
 func BuildOCI(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
-	ocidType, err := ocicommon.GetAllPoolTypes(opts.NodeGroups)
+	ocidType, err := ocicommon.GetPoolTypeWithAutoDiscovery(opts.NodeGroups, do.NodeGroupAutoDiscoverySpecs)
 	if err != nil {
 		klog.Fatalf("Failed to get pool type: %v", err)
 	}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/instancepools/consts"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
@@ -53,6 +54,9 @@ type instancePoolCache struct {
 	poolCache            map[string]*core.InstancePool
 	instanceSummaryCache map[string]*[]core.InstanceSummary
 	unownedInstances     map[ocicommon.OciRef]bool
+	// unfulfilledErrorKinds holds, per instance pool, the kind of the unrecoverable error
+	// behind its placeholder instances.
+	unfulfilledErrorKinds map[string]cloudprovider.ErrorKind
 
 	computeManagementClient ComputeMgmtClient
 	computeClient           ComputeClient
@@ -65,6 +69,7 @@ func newInstancePoolCache(computeManagementClient ComputeMgmtClient, computeClie
 		poolCache:               map[string]*core.InstancePool{},
 		instanceSummaryCache:    map[string]*[]core.InstanceSummary{},
 		unownedInstances:        map[ocicommon.OciRef]bool{},
+		unfulfilledErrorKinds:   map[string]cloudprovider.ErrorKind{},
 		computeManagementClient: computeManagementClient,
 		computeClient:           computeClient,
 		virtualNetworkClient:    virtualNetworkClient,
@@ -81,8 +86,6 @@ func (c *instancePoolCache) InstancePools() map[string]*core.InstancePool {
 }
 
 func (c *instancePoolCache) rebuild(staticInstancePools map[string]*InstancePoolNodeGroup, cfg ocicommon.CloudConfig) error {
-	// Since we only support static instance-pools we don't need to worry about pruning.
-
 	for id := range staticInstancePools {
 		getInstancePoolResp, err := c.computeManagementClient.GetInstancePool(context.Background(), core.GetInstancePoolRequest{
 			InstancePoolId: common.String(id),
@@ -95,13 +98,18 @@ func (c *instancePoolCache) rebuild(staticInstancePools map[string]*InstancePool
 
 		c.setInstancePool(&getInstancePoolResp.InstancePool)
 
+		// Discovered instance pools may be in another compartment than the one of the cloud config.
+		compartmentID := cfg.Global.CompartmentID
+		if getInstancePoolResp.InstancePool.CompartmentId != nil {
+			compartmentID = *getInstancePoolResp.InstancePool.CompartmentId
+		}
 		var instanceSummaries []core.InstanceSummary
 		var page *string
 		for {
 			// OCI instance-pools do not contain individual instance objects so they must be fetched separately.
 			listInstancePoolInstances, err := c.computeManagementClient.ListInstancePoolInstances(context.Background(), core.ListInstancePoolInstancesRequest{
 				InstancePoolId: common.String(id),
-				CompartmentId:  common.String(cfg.Global.CompartmentID),
+				CompartmentId:  common.String(compartmentID),
 				Page:           page,
 			})
 			if err != nil {
@@ -115,6 +123,7 @@ func (c *instancePoolCache) rebuild(staticInstancePools map[string]*InstancePool
 			}
 		}
 		c.setInstanceSummaries(id, &instanceSummaries)
+		c.setUnfulfilledErrorKind(id, cloudprovider.UnknownErrorKind)
 		// Compare instance pool's size with the latest number of InstanceSummaries. If found, look for unrecoverable
 		// errors such as quota or capacity issues in scaling pool.
 		if len(*c.instanceSummaryCache[id]) < *c.poolCache[id].Size {
@@ -131,6 +140,7 @@ func (c *instancePoolCache) rebuild(staticInstancePools map[string]*InstancePool
 					unrecoverableErrorMsg := c.firstUnrecoverableErrorForWorkRequest(*lastWorkRequest.Id)
 					if unrecoverableErrorMsg != "" {
 						klog.V(4).Infof("Creating placeholder instances for %s.", *getInstancePoolResp.InstancePool.DisplayName)
+						c.setUnfulfilledErrorKind(id, unrecoverableErrorKind(unrecoverableErrorMsg))
 						for i := len(*c.instanceSummaryCache[id]); i < *c.poolCache[id].Size; i++ {
 							c.addUnfulfilledInstanceToCache(id, fmt.Sprintf("%s%s-%d", consts.InstanceIDUnfulfilled,
 								*getInstancePoolResp.InstancePool.Id, i), *getInstancePoolResp.InstancePool.CompartmentId,
@@ -157,6 +167,39 @@ func (c *instancePoolCache) addUnfulfilledInstanceToCache(instancePoolID, instan
 	})
 }
 
+// unrecoverableErrorKind classifies an unrecoverable work request error message returned by
+// firstUnrecoverableErrorForWorkRequest.
+func unrecoverableErrorKind(msg string) cloudprovider.ErrorKind {
+	msg = strings.ToLower(msg)
+	switch {
+	case strings.Contains(msg, strings.ToLower("QuotaExceeded")), strings.Contains(msg, strings.ToLower("LimitExceeded")):
+		return cloudprovider.QuotaErrorKind
+	case strings.Contains(msg, strings.ToLower("OutOfCapacity")):
+		return cloudprovider.CapacityErrorKind
+	}
+	return cloudprovider.UnknownErrorKind
+}
+
+func (c *instancePoolCache) setUnfulfilledErrorKind(instancePoolID string, kind cloudprovider.ErrorKind) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if kind == cloudprovider.UnknownErrorKind {
+		delete(c.unfulfilledErrorKinds, instancePoolID)
+		return
+	}
+	c.unfulfilledErrorKinds[instancePoolID] = kind
+}
+
+// getUnfulfilledErrorKind returns the kind of the error behind the placeholder instances of
+// the specified instance pool, or UnknownErrorKind if it couldn't be determined.
+func (c *instancePoolCache) getUnfulfilledErrorKind(instancePoolID string) cloudprovider.ErrorKind {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.unfulfilledErrorKinds[instancePoolID]
+}
+
 // removeInstance tries to remove the instance from the specified instance pool. If the instance isn't in the array,
 // then it won't do anything removeInstance returns true if it actually removed the instance and reduced the size of
 // the instance pool.
@@ -327,6 +370,19 @@ func (c *instancePoolCache) getInstancePoolWithoutLock(id string) (*core.Instanc
 	return instancePool, nil
 }
 
+// prune removes the instance pools which aren't registered anymore from the cache.
+func (c *instancePoolCache) prune(instancePools map[string]*InstancePoolNodeGroup) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id := range c.poolCache {
+		if _, found := instancePools[id]; !found {
+			delete(c.poolCache, id)
+			delete(c.instanceSummaryCache, id)
+		}
+	}
+}
+
 func (c *instancePoolCache) setInstancePool(np *core.InstancePool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
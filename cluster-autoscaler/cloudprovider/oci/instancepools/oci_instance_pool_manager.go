@@ -60,10 +60,15 @@ type InstancePoolManager interface {
 
 // InstancePoolManagerImpl is the implementation of an instance-pool based autoscaler on OCI.
 type InstancePoolManagerImpl struct {
-	cfg                 *ocicommon.CloudConfig
-	ShapeGetter         ocicommon.ShapeGetter
+	cfg         *ocicommon.CloudConfig
+	ShapeGetter ocicommon.ShapeGetter
+	// staticInstancePools holds the instance pools given by --nodes and the ones discovered by the auto-discovery specs.
 	staticInstancePools map[string]*InstancePoolNodeGroup
-	lastRefresh         time.Time
+	// argInstancePools holds the instance pools given by --nodes, which take precedence over the discovered ones.
+	argInstancePools   map[string]*InstancePoolNodeGroup
+	discoverySpecs     []ocicommon.AutoDiscoverySpec
+	instancePoolLister instancePoolLister
+	lastRefresh        time.Time
 	// caches the instance pool and instance summary objects received from OCI.
 	// All interactions with OCI's API should go through the poolCache.
 	instancePoolCache *instancePoolCache
@@ -131,9 +136,17 @@ func CreateInstancePoolManager(cloudConfigPath string, discoveryOpts cloudprovid
 	}
 	workRequestClient.SetCustomClientConfiguration(clientConfig)
 
+	discoverySpecs, err := ocicommon.ParseAutoDiscoverySpecs(discoveryOpts.NodeGroupAutoDiscoverySpecs)
+	if err != nil {
+		return nil, err
+	}
+
 	ipManager := &InstancePoolManagerImpl{
 		cfg:                 cloudConfig,
 		staticInstancePools: map[string]*InstancePoolNodeGroup{},
+		argInstancePools:    map[string]*InstancePoolNodeGroup{},
+		discoverySpecs:      discoverySpecs,
+		instancePoolLister:  &computeMgmtClient,
 		ShapeGetter:         ocicommon.CreateShapeGetter(ocicommon.ShapeClientImpl{ComputeMgmtClient: computeMgmtClient, ComputeClient: computeClient}),
 		instancePoolCache:   newInstancePoolCache(&computeMgmtClient, &computeClient, &networkClient, &workRequestClient),
 		kubeClient:          kubeClient,
@@ -150,6 +163,7 @@ func CreateInstancePoolManager(cloudConfigPath string, discoveryOpts cloudprovid
 		ip.kubeClient = kubeClient
 
 		ipManager.staticInstancePools[ip.Id()] = ip
+		ipManager.argInstancePools[ip.Id()] = ip
 	}
 
 	// wait until we have an initial full poolCache.
@@ -217,6 +231,12 @@ func (m *InstancePoolManagerImpl) forceRefresh() error {
 		return errors.New("instance pool manager does have a required config")
 	}
 	m.ShapeGetter.Refresh()
+	if len(m.discoverySpecs) > 0 {
+		if err := m.discoverInstancePools(); err != nil {
+			return err
+		}
+		m.instancePoolCache.prune(m.staticInstancePools)
+	}
 	err := m.instancePoolCache.rebuild(m.staticInstancePools, *m.cfg)
 	if err != nil {
 		return err
@@ -227,6 +247,43 @@ func (m *InstancePoolManagerImpl) forceRefresh() error {
 	return nil
 }
 
+// discoverInstancePools registers the instance pools matching the auto-discovery specs, in addition to the
+// ones given by --nodes, and unregisters the ones which don't match anymore.
+func (m *InstancePoolManagerImpl) discoverInstancePools() error {
+	discovered, err := discoverInstancePools(m.instancePoolLister, m.discoverySpecs, m.cfg.Global.CompartmentID)
+	if err != nil {
+		return err
+	}
+	instancePools := make(map[string]*InstancePoolNodeGroup, len(m.argInstancePools)+len(discovered))
+	for id, ip := range m.argInstancePools {
+		instancePools[id] = ip
+	}
+	for id, sizes := range discovered {
+		if _, found := instancePools[id]; found {
+			continue
+		}
+		if existing, found := m.staticInstancePools[id]; found && existing.minSize == sizes.minSize && existing.maxSize == sizes.maxSize {
+			instancePools[id] = existing
+			continue
+		}
+		klog.Infof("Discovered instance pool %s, min size %d, max size %d", id, sizes.minSize, sizes.maxSize)
+		instancePools[id] = &InstancePoolNodeGroup{
+			manager:    m,
+			kubeClient: m.kubeClient,
+			id:         id,
+			minSize:    sizes.minSize,
+			maxSize:    sizes.maxSize,
+		}
+	}
+	for id := range m.staticInstancePools {
+		if _, found := instancePools[id]; !found {
+			klog.Infof("Instance pool %s doesn't match the auto-discovery specs anymore", id)
+		}
+	}
+	m.staticInstancePools = instancePools
+	return nil
+}
+
 func (m *InstancePoolManagerImpl) forceRefreshInstancePool(instancePoolID string) error {
 
 	if m.cfg == nil {
@@ -287,6 +344,7 @@ func (m *InstancePoolManagerImpl) GetInstancePoolNodes(ip InstancePoolNodeGroup)
 			status.State = cloudprovider.InstanceCreating
 			status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
 				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+				ErrorKind:    m.instancePoolCache.getUnfulfilledErrorKind(ip.Id()),
 				ErrorCode:    consts.InstanceStateUnfulfilled,
 				ErrorMessage: "OCI cannot provision additional instances for this instance pool. Review quota and/or capacity.",
 			}
@@ -8,6 +8,7 @@ import (
 	"context"
 	apiv1 "k8s.io/api/core/v1"
 	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/instancepools/consts"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/core"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/workrequests"
 	kubeletapis "k8s.io/kubelet/pkg/apis"
@@ -371,8 +372,12 @@ func TestGetInstancePoolNodes(t *testing.T) {
 		Id:                 common.String("ocid1.instance.oc1.phx.aaa2"),
 		AvailabilityDomain: common.String("PHX-AD-1"),
 		State:              common.String(string(core.InstanceLifecycleStateTerminating)),
+	}, {
+		Id:    common.String(consts.InstanceIDUnfulfilled + "ocid1.instancepool.oc1.phx.aaaaaaaa1-2"),
+		State: common.String(consts.InstanceStateUnfulfilled),
 	},
 	}
+	nodePoolCache.setUnfulfilledErrorKind("ocid1.instancepool.oc1.phx.aaaaaaaa1", unrecoverableErrorKind("Instance launch failed: OutOfCapacity"))
 
 	expected := []cloudprovider.Instance{
 		{
@@ -387,6 +392,18 @@ func TestGetInstancePoolNodes(t *testing.T) {
 				State: cloudprovider.InstanceDeleting,
 			},
 		},
+		{
+			Id: consts.InstanceIDUnfulfilled + "ocid1.instancepool.oc1.phx.aaaaaaaa1-2",
+			Status: &cloudprovider.InstanceStatus{
+				State: cloudprovider.InstanceCreating,
+				ErrorInfo: &cloudprovider.InstanceErrorInfo{
+					ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+					ErrorKind:    cloudprovider.CapacityErrorKind,
+					ErrorCode:    consts.InstanceStateUnfulfilled,
+					ErrorMessage: "OCI cannot provision additional instances for this instance pool. Review quota and/or capacity.",
+				},
+			},
+		},
 	}
 
 	manager := &InstancePoolManagerImpl{instancePoolCache: nodePoolCache, cfg: &ocicommon.CloudConfig{}}
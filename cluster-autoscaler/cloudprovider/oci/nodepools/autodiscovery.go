@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package nodepools
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	klog "k8s.io/klog/v2"
+
+	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
+	oke "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/containerengine"
+)
+
+type nodePoolLister interface {
+	ListNodePools(context.Context, oke.ListNodePoolsRequest) (oke.ListNodePoolsResponse, error)
+}
+
+// nodePoolSizes holds the sizes of a discovered node pool.
+type nodePoolSizes struct {
+	minSize int
+	maxSize int
+}
+
+// discoverNodePools returns the sizes of the node pools matching the auto-discovery specs, by node
+// pool id. A node pool matching several specs gets the sizes of the first one.
+func discoverNodePools(client nodePoolLister, specs []ocicommon.AutoDiscoverySpec, defaultCompartmentID string) (map[string]nodePoolSizes, error) {
+	result := map[string]nodePoolSizes{}
+	for _, spec := range specs {
+		compartmentIDs := spec.CompartmentIDs
+		if len(compartmentIDs) == 0 {
+			compartmentIDs = []string{defaultCompartmentID}
+		}
+		for _, compartmentID := range compartmentIDs {
+			summaries, err := listNodePools(client, compartmentID, spec.ClusterID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to list node pools of compartment %s", compartmentID)
+			}
+			for _, summary := range summaries {
+				if summary.Id == nil || !spec.Matches(summary.FreeformTags, summary.DefinedTags) {
+					continue
+				}
+				if _, found := result[*summary.Id]; found {
+					continue
+				}
+				minSize, maxSize, err := spec.Sizes(summary.FreeformTags, summary.DefinedTags)
+				if err != nil {
+					klog.Warningf("Ignoring discovered node pool %s: %v", *summary.Id, err)
+					continue
+				}
+				result[*summary.Id] = nodePoolSizes{minSize: minSize, maxSize: maxSize}
+			}
+		}
+	}
+	return result, nil
+}
+
+func listNodePools(client nodePoolLister, compartmentID, clusterID string) ([]oke.NodePoolSummary, error) {
+	request := oke.ListNodePoolsRequest{
+		CompartmentId:  &compartmentID,
+		LifecycleState: []oke.NodePoolLifecycleStateEnum{oke.NodePoolLifecycleStateActive, oke.NodePoolLifecycleStateUpdating},
+	}
+	if clusterID != "" {
+		request.ClusterId = &clusterID
+	}
+	var summaries []oke.NodePoolSummary
+	for {
+		resp, err := client.ListNodePools(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return summaries, nil
+		}
+		request.Page = resp.OpcNextPage
+	}
+}
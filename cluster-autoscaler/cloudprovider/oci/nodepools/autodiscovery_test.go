@@ -0,0 +1,77 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package nodepools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
+	oke "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/containerengine"
+)
+
+type mockNodePoolLister struct {
+	// pages holds the listed node pools by compartment, one slice per page.
+	pages map[string][][]oke.NodePoolSummary
+}
+
+func (l mockNodePoolLister) ListNodePools(_ context.Context, req oke.ListNodePoolsRequest) (oke.ListNodePoolsResponse, error) {
+	pages := l.pages[*req.CompartmentId]
+	page := 0
+	if req.Page != nil {
+		page = int((*req.Page)[0] - '0')
+	}
+	resp := oke.ListNodePoolsResponse{}
+	if page < len(pages) {
+		resp.Items = pages[page]
+	}
+	if page+1 < len(pages) {
+		resp.OpcNextPage = common.String(string(rune('0' + page + 1)))
+	}
+	return resp, nil
+}
+
+func TestDiscoverNodePools(t *testing.T) {
+	managed := map[string]string{"ca-managed": "true"}
+	lister := mockNodePoolLister{pages: map[string][][]oke.NodePoolSummary{
+		"default": {
+			{
+				{Id: common.String("np-1"), FreeformTags: managed},
+				{Id: common.String("np-2"), FreeformTags: map[string]string{"ca-managed": "false"}},
+			},
+			{
+				{Id: common.String("np-3"), FreeformTags: map[string]string{"ca-managed": "true", ocicommon.MaxSizeTag: "10"}},
+				{Id: common.String("np-4"), FreeformTags: map[string]string{"ca-managed": "true", ocicommon.MaxSizeTag: "ten"}},
+			},
+		},
+		"other": {
+			{
+				{Id: common.String("np-5"), DefinedTags: map[string]map[string]interface{}{"ns": {"ca-managed": "true"}}},
+			},
+		},
+	}}
+	specs, err := ocicommon.ParseAutoDiscoverySpecs([]string{
+		"nodepool:tag=ca-managed=true,min=1,max=5",
+		"nodepool:compartmentId=other,tag=ns.ca-managed=true,min=0,max=3",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	discovered, err := discoverNodePools(lister, specs, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]nodePoolSizes{
+		"np-1": {minSize: 1, maxSize: 5},
+		"np-3": {minSize: 1, maxSize: 10},
+		"np-5": {minSize: 0, maxSize: 3},
+	}
+	if !reflect.DeepEqual(discovered, expected) {
+		t.Errorf("got %+v, expected %+v", discovered, expected)
+	}
+}
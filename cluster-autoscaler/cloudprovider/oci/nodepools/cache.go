@@ -42,6 +42,15 @@ func (c *nodePoolCache) nodePools() map[string]*oke.NodePool {
 
 func (c *nodePoolCache) rebuild(staticNodePools map[string]NodePool, maxGetNodepoolRetries int) (httpStatusCode int, err error) {
 	klog.Infof("rebuilding cache")
+	// drop the node pools which aren't registered anymore, e.g. discovered ones whose tags changed.
+	c.mu.Lock()
+	for id := range c.cache {
+		if _, found := staticNodePools[id]; !found {
+			delete(c.cache, id)
+			delete(c.targetSize, id)
+		}
+	}
+	c.mu.Unlock()
 	var resp oke.GetNodePoolResponse
 	var statusCode int
 	for id := range staticNodePools {
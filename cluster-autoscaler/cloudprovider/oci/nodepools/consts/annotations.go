@@ -28,4 +28,17 @@ const (
 
 	// EphemeralStorageSize is the freeform tag key that would be used to determine the ephemeral-storage size of the node
 	EphemeralStorageSize = "cluster-autoscaler/node-ephemeral-storage"
+
+	// OciVirtualNodePoolsEnvVar is a comma separated list of virtual node pool ocids pending pods can be routed to
+	OciVirtualNodePoolsEnvVar = "OCI_VIRTUAL_NODE_POOLS"
+	// OciVirtualNodePoolPolicyEnvVar controls when pending pods are routed to virtual node pools
+	OciVirtualNodePoolPolicyEnvVar = "OCI_VIRTUAL_NODE_POOL_POLICY"
+
+	// VirtualNodePoolPolicyDisabled never routes pending pods to virtual node pools
+	VirtualNodePoolPolicyDisabled = "disabled"
+	// VirtualNodePoolPolicyFallback routes pending pods to virtual node pools only when VM-based node pools
+	// can't be scaled up, i.e. they are at their max size or backed off
+	VirtualNodePoolPolicyFallback = "fallback"
+	// VirtualNodePoolPolicyPrefer routes pending pods to virtual node pools whenever they fit
+	VirtualNodePoolPolicyPrefer = "prefer"
 )
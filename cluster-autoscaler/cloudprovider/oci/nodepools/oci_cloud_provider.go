@@ -63,6 +63,13 @@ func (ocp *OciCloudProvider) NodeGroupForNode(n *apiv1.Node) (cloudprovider.Node
 	return ng, err
 }
 
+// VirtualNodePoolForPod returns the OKE virtual node pool the pending pod can run on instead of scaling up
+// a node pool, and whether virtual nodes are preferred over node pools that can still be scaled up.
+// An empty pool means that the pod can't be routed to virtual nodes.
+func (ocp *OciCloudProvider) VirtualNodePoolForPod(pod *apiv1.Pod) (string, bool) {
+	return ocp.manager.GetVirtualNodePoolForPod(pod)
+}
+
 // GetNodeGpuConfig returns the label, type and resource name for the GPU added to node. If node doesn't have
 // any GPUs, it returns nil.
 func (ocp *OciCloudProvider) GetNodeGpuConfig(node *apiv1.Node) *cloudprovider.GpuConfig {
@@ -69,10 +69,14 @@ type NodePoolManager interface {
 	InvalidateAndRefreshCache() error
 	// Taint with ToBeDeletedByClusterAutoscaler to avoid unexpected CA restarts scheduling pods on a node intended to be deleted before restart
 	TaintToPreventFurtherSchedulingOnRestart(nodes []*apiv1.Node, client kubernetes.Interface) error
+	// GetVirtualNodePoolForPod returns the virtual node pool the pod can be routed to, if any, and whether
+	// virtual nodes are preferred over scaling up node pools.
+	GetVirtualNodePoolForPod(pod *apiv1.Pod) (string, bool)
 }
 
 type okeClient interface {
 	GetNodePool(context.Context, oke.GetNodePoolRequest) (oke.GetNodePoolResponse, error)
+	ListNodePools(context.Context, oke.ListNodePoolsRequest) (oke.ListNodePoolsResponse, error)
 	UpdateNodePool(context.Context, oke.UpdateNodePoolRequest) (oke.UpdateNodePoolResponse, error)
 	DeleteNode(context.Context, oke.DeleteNodeRequest) (oke.DeleteNodeResponse, error)
 }
@@ -140,15 +144,30 @@ func CreateNodePoolManager(cloudConfigPath string, discoveryOpts cloudprovider.N
 
 	registeredTaintsGetter := CreateRegisteredTaintsGetter()
 
+	virtualNodePools, err := newVirtualNodePools(&okeClient, cloudConfig.Global.VirtualNodePools, cloudConfig.Global.VirtualNodePoolPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	discoverySpecs, err := ocicommon.ParseAutoDiscoverySpecs(discoveryOpts.NodeGroupAutoDiscoverySpecs)
+	if err != nil {
+		return nil, err
+	}
+
 	manager := &ociManagerImpl{
 		cfg:                    cloudConfig,
 		okeClient:              &okeClient,
 		computeClient:          &computeClient,
 		staticNodePools:        map[string]NodePool{},
+		argNodePools:           map[string]NodePool{},
+		discoverySpecs:         discoverySpecs,
+		nodePoolLister:         &okeClient,
+		kubeClient:             kubeClient,
 		ociShapeGetter:         ociShapeGetter,
 		ociTagsGetter:          ociTagsGetter,
 		registeredTaintsGetter: registeredTaintsGetter,
 		nodePoolCache:          newNodePoolCache(&okeClient),
+		virtualNodePools:       virtualNodePools,
 	}
 
 	// Contains all the specs from the args that give us the pools.
@@ -162,6 +181,7 @@ func CreateNodePoolManager(cloudConfigPath string, discoveryOpts cloudprovider.N
 		np.kubeClient = kubeClient
 
 		manager.staticNodePools[np.Id()] = np
+		manager.argNodePools[np.Id()] = np
 	}
 
 	// wait until we have an initial full cache.
@@ -214,13 +234,22 @@ type ociManagerImpl struct {
 	ociShapeGetter         ocicommon.ShapeGetter
 	ociTagsGetter          ocicommon.TagsGetter
 	registeredTaintsGetter RegisteredTaintsGetter
-	staticNodePools        map[string]NodePool
+	// staticNodePools holds the node pools given by --nodes and the ones discovered by the auto-discovery specs.
+	staticNodePools map[string]NodePool
+	// argNodePools holds the node pools given by --nodes, which take precedence over the discovered ones.
+	argNodePools   map[string]NodePool
+	discoverySpecs []ocicommon.AutoDiscoverySpec
+	nodePoolLister nodePoolLister
+	kubeClient     kubernetes.Interface
 
 	lastRefresh time.Time
 
 	// caches the node pool objects received from OKE.
 	// All interactions with OKE's API should go through the cache.
 	nodePoolCache *nodePoolCache
+
+	// virtual node pools pending pods can be routed to instead of scaling up node pools.
+	virtualNodePools *virtualNodePools
 }
 
 // Refresh triggers refresh of cached resources.
@@ -253,6 +282,11 @@ func (m *ociManagerImpl) TaintToPreventFurtherSchedulingOnRestart(nodes []*apiv1
 }
 
 func (m *ociManagerImpl) forceRefresh() error {
+	if len(m.discoverySpecs) > 0 {
+		if err := m.discoverNodePools(); err != nil {
+			return err
+		}
+	}
 	httpStatusCode, err := m.nodePoolCache.rebuild(m.staticNodePools, maxGetNodepoolRetries)
 	if err != nil {
 		if httpStatusCode == 404 {
@@ -262,11 +296,52 @@ func (m *ociManagerImpl) forceRefresh() error {
 		}
 		return err
 	}
+	// virtual node pools are only a fallback, keep using the previous state if they can't be fetched.
+	if err := m.virtualNodePools.rebuild(); err != nil {
+		klog.Warningf("Failed to refresh virtual node pools: %v", err)
+	}
 	m.lastRefresh = time.Now()
 	klog.Infof("Refreshed NodePool list, next refresh after %v", m.lastRefresh.Add(m.cfg.Global.RefreshInterval))
 	return nil
 }
 
+// discoverNodePools registers the node pools matching the auto-discovery specs, in addition to the ones
+// given by --nodes, and unregisters the ones which don't match anymore.
+func (m *ociManagerImpl) discoverNodePools() error {
+	discovered, err := discoverNodePools(m.nodePoolLister, m.discoverySpecs, m.cfg.Global.CompartmentID)
+	if err != nil {
+		return err
+	}
+	nodePools := make(map[string]NodePool, len(m.argNodePools)+len(discovered))
+	for id, np := range m.argNodePools {
+		nodePools[id] = np
+	}
+	for id, sizes := range discovered {
+		if _, found := nodePools[id]; found {
+			continue
+		}
+		if existing, ok := m.staticNodePools[id].(*nodePool); ok && existing.minSize == sizes.minSize && existing.maxSize == sizes.maxSize {
+			nodePools[id] = existing
+			continue
+		}
+		klog.Infof("Discovered node pool %s, min size %d, max size %d", id, sizes.minSize, sizes.maxSize)
+		nodePools[id] = &nodePool{
+			manager:    m,
+			kubeClient: m.kubeClient,
+			id:         id,
+			minSize:    sizes.minSize,
+			maxSize:    sizes.maxSize,
+		}
+	}
+	for id := range m.staticNodePools {
+		if _, found := nodePools[id]; !found {
+			klog.Infof("Node pool %s doesn't match the auto-discovery specs anymore", id)
+		}
+	}
+	m.staticNodePools = nodePools
+	return nil
+}
+
 // Cleanup cleans up open resources before the cloud provider is destroyed, i.e. go routines etc.
 func (m *ociManagerImpl) Cleanup() error {
 	return nil
@@ -381,10 +456,17 @@ func (m *ociManagerImpl) GetNodePoolNodes(np NodePool) ([]cloudprovider.Instance
 		if node.NodeError != nil {
 
 			errorClass := cloudprovider.OtherErrorClass
+			errorKind := cloudprovider.UnknownErrorKind
 			if *node.NodeError.Code == "LimitExceeded" ||
 				(*node.NodeError.Code == "InternalServerError" &&
 					strings.Contains(*node.NodeError.Message, "quota")) {
 				errorClass = cloudprovider.OutOfResourcesErrorClass
+				errorKind = cloudprovider.QuotaErrorKind
+			} else if strings.Contains(strings.ToLower(*node.NodeError.Message), "out of host capacity") {
+				errorClass = cloudprovider.OutOfResourcesErrorClass
+				errorKind = cloudprovider.CapacityErrorKind
+			} else if *node.NodeError.Code == "NotAuthorizedOrNotFound" {
+				errorKind = cloudprovider.PermissionErrorKind
 			}
 
 			instances = append(instances, cloudprovider.Instance{
@@ -392,6 +474,7 @@ func (m *ociManagerImpl) GetNodePoolNodes(np NodePool) ([]cloudprovider.Instance
 				Status: &cloudprovider.InstanceStatus{
 					ErrorInfo: &cloudprovider.InstanceErrorInfo{
 						ErrorClass:   errorClass,
+						ErrorKind:    errorKind,
 						ErrorCode:    *node.NodeError.Code,
 						ErrorMessage: *node.NodeError.Message,
 					},
@@ -472,6 +555,12 @@ func (m *ociManagerImpl) GetNodePoolTemplateNode(np NodePool) (*apiv1.Node, erro
 	return node, nil
 }
 
+// GetVirtualNodePoolForPod returns the virtual node pool the pod can be routed to, if any, and whether
+// virtual nodes are preferred over scaling up node pools.
+func (m *ociManagerImpl) GetVirtualNodePoolForPod(pod *apiv1.Pod) (string, bool) {
+	return m.virtualNodePools.poolForPod(pod)
+}
+
 // GetNodePoolSize gets NodePool size.
 func (m *ociManagerImpl) GetNodePoolSize(np NodePool) (int, error) {
 	return m.nodePoolCache.getSize(np.Id())
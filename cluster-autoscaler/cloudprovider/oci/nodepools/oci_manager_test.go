@@ -166,6 +166,7 @@ func TestGetNodePoolNodes(t *testing.T) {
 			Status: &cloudprovider.InstanceStatus{
 				ErrorInfo: &cloudprovider.InstanceErrorInfo{
 					ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+					ErrorKind:    cloudprovider.QuotaErrorKind,
 					ErrorCode:    "LimitExceeded",
 					ErrorMessage: "message",
 				},
@@ -176,6 +177,7 @@ func TestGetNodePoolNodes(t *testing.T) {
 			Status: &cloudprovider.InstanceStatus{
 				ErrorInfo: &cloudprovider.InstanceErrorInfo{
 					ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+					ErrorKind:    cloudprovider.QuotaErrorKind,
 					ErrorCode:    "InternalServerError",
 					ErrorMessage: "blah blah quota exceeded blah blah",
 				},
@@ -321,6 +323,9 @@ type mockOKEClient struct{}
 func (c mockOKEClient) GetNodePool(context.Context, oke.GetNodePoolRequest) (oke.GetNodePoolResponse, error) {
 	return oke.GetNodePoolResponse{}, nil
 }
+func (c mockOKEClient) ListNodePools(context.Context, oke.ListNodePoolsRequest) (oke.ListNodePoolsResponse, error) {
+	return oke.ListNodePoolsResponse{}, nil
+}
 func (c mockOKEClient) UpdateNodePool(context.Context, oke.UpdateNodePoolRequest) (oke.UpdateNodePoolResponse, error) {
 	return oke.UpdateNodePoolResponse{}, nil
 }
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package nodepools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	klog "k8s.io/klog/v2"
+
+	npconsts "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/nodepools/consts"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
+	oke "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/containerengine"
+)
+
+type virtualNodePoolClient interface {
+	GetVirtualNodePool(context.Context, oke.GetVirtualNodePoolRequest) (oke.GetVirtualNodePoolResponse, error)
+}
+
+// virtualNodePool is the scheduling relevant part of an OKE virtual node pool. Virtual node pools are
+// serverless and are never scaled by the autoscaler, pending pods that fit them are left for OKE instead.
+type virtualNodePool struct {
+	id     string
+	active bool
+	// node with the labels and taints virtual nodes of the pool register with
+	node *apiv1.Node
+}
+
+func newVirtualNodePool(vnp *oke.VirtualNodePool) *virtualNodePool {
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   *vnp.Id,
+			Labels: map[string]string{},
+		},
+	}
+	for _, kv := range vnp.InitialVirtualNodeLabels {
+		if kv.Key != nil && kv.Value != nil {
+			node.Labels[*kv.Key] = *kv.Value
+		}
+	}
+	for _, taint := range vnp.Taints {
+		if taint.Key == nil || taint.Effect == nil {
+			continue
+		}
+		t := apiv1.Taint{Key: *taint.Key, Effect: apiv1.TaintEffect(*taint.Effect)}
+		if taint.Value != nil {
+			t.Value = *taint.Value
+		}
+		node.Spec.Taints = append(node.Spec.Taints, t)
+	}
+	return &virtualNodePool{
+		id:     *vnp.Id,
+		active: vnp.LifecycleState == oke.VirtualNodePoolLifecycleStateActive,
+		node:   node,
+	}
+}
+
+// fits returns true if the pod can run on virtual nodes of the pool.
+func (p *virtualNodePool) fits(pod *apiv1.Pod) bool {
+	if !p.active {
+		return false
+	}
+	// virtual nodes don't support host networking nor host path volumes
+	if pod.Spec.HostNetwork {
+		return false
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			return false
+		}
+	}
+	for _, taint := range p.node.Spec.Taints {
+		if taint.Effect == apiv1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !corev1helpers.TolerationsTolerateTaint(pod.Spec.Tolerations, &taint) {
+			return false
+		}
+	}
+	match, err := nodeaffinity.GetRequiredNodeAffinity(pod).Match(p.node)
+	return err == nil && match
+}
+
+// virtualNodePools keeps track of the virtual node pools pending pods can be routed to.
+type virtualNodePools struct {
+	mu     sync.Mutex
+	client virtualNodePoolClient
+	policy string
+	ids    []string
+	pools  map[string]*virtualNodePool
+}
+
+func newVirtualNodePools(client virtualNodePoolClient, ids []string, policy string) (*virtualNodePools, error) {
+	switch policy {
+	case npconsts.VirtualNodePoolPolicyDisabled, npconsts.VirtualNodePoolPolicyFallback, npconsts.VirtualNodePoolPolicyPrefer:
+	default:
+		return nil, fmt.Errorf("unknown virtual node pool policy %q, expected one of %s, %s, %s", policy,
+			npconsts.VirtualNodePoolPolicyDisabled, npconsts.VirtualNodePoolPolicyFallback, npconsts.VirtualNodePoolPolicyPrefer)
+	}
+	v := &virtualNodePools{
+		client: client,
+		policy: policy,
+		pools:  map[string]*virtualNodePool{},
+	}
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			v.ids = append(v.ids, id)
+		}
+	}
+	return v, nil
+}
+
+func (v *virtualNodePools) enabled() bool {
+	return v != nil && v.policy != npconsts.VirtualNodePoolPolicyDisabled && len(v.ids) > 0
+}
+
+// rebuild fetches the configured virtual node pools. Pools that could not be fetched keep their previous state.
+func (v *virtualNodePools) rebuild() error {
+	if !v.enabled() {
+		return nil
+	}
+	var errs []string
+	for _, id := range v.ids {
+		resp, err := v.client.GetVirtualNodePool(context.Background(), oke.GetVirtualNodePoolRequest{
+			VirtualNodePoolId: common.String(id),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		pool := newVirtualNodePool(&resp.VirtualNodePool)
+		v.mu.Lock()
+		v.pools[id] = pool
+		v.mu.Unlock()
+		klog.V(4).Infof("virtual node pool %q active: %v", id, pool.active)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to fetch virtual node pools: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// poolForPod returns the first configured virtual node pool the pod fits in, and whether
+// virtual nodes are preferred over scaling up VM-based node pools.
+func (v *virtualNodePools) poolForPod(pod *apiv1.Pod) (string, bool) {
+	if !v.enabled() {
+		return "", false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, id := range v.ids {
+		if pool, found := v.pools[id]; found && pool.fits(pod) {
+			return id, v.policy == npconsts.VirtualNodePoolPolicyPrefer
+		}
+	}
+	return "", false
+}
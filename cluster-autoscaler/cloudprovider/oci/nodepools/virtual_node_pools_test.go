@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Oracle and/or its affiliates.
+*/
+
+package nodepools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/nodepools/consts"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
+	oke "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/containerengine"
+)
+
+type mockVirtualNodePoolClient struct {
+	pools map[string]oke.VirtualNodePool
+}
+
+func (c mockVirtualNodePoolClient) GetVirtualNodePool(_ context.Context, req oke.GetVirtualNodePoolRequest) (oke.GetVirtualNodePoolResponse, error) {
+	pool, found := c.pools[*req.VirtualNodePoolId]
+	if !found {
+		return oke.GetVirtualNodePoolResponse{}, errors.New("not found")
+	}
+	return oke.GetVirtualNodePoolResponse{VirtualNodePool: pool}, nil
+}
+
+func TestVirtualNodePoolForPod(t *testing.T) {
+	client := mockVirtualNodePoolClient{pools: map[string]oke.VirtualNodePool{
+		"vnp-tainted": {
+			Id:             common.String("vnp-tainted"),
+			LifecycleState: oke.VirtualNodePoolLifecycleStateActive,
+			InitialVirtualNodeLabels: []oke.InitialVirtualNodeLabel{
+				{Key: common.String("tier"), Value: common.String("serverless")},
+			},
+			Taints: []oke.Taint{
+				{Key: common.String("virtual"), Value: common.String("true"), Effect: common.String("NoSchedule")},
+			},
+		},
+		"vnp-inactive": {
+			Id:             common.String("vnp-inactive"),
+			LifecycleState: oke.VirtualNodePoolLifecycleStateUpdating,
+		},
+		"vnp": {
+			Id:             common.String("vnp"),
+			LifecycleState: oke.VirtualNodePoolLifecycleStateActive,
+		},
+	}}
+
+	tolerating := &apiv1.Pod{Spec: apiv1.PodSpec{
+		Tolerations: []apiv1.Toleration{{Key: "virtual", Operator: apiv1.TolerationOpExists}},
+	}}
+	selecting := &apiv1.Pod{Spec: apiv1.PodSpec{
+		NodeSelector: map[string]string{"tier": "serverless"},
+	}}
+	hostNetwork := &apiv1.Pod{Spec: apiv1.PodSpec{HostNetwork: true}}
+
+	testCases := []struct {
+		name          string
+		ids           []string
+		policy        string
+		pod           *apiv1.Pod
+		wantPool      string
+		wantPreferred bool
+	}{
+		{
+			name:   "disabled",
+			ids:    []string{"vnp"},
+			policy: consts.VirtualNodePoolPolicyDisabled,
+			pod:    tolerating,
+		},
+		{
+			name:     "first fitting pool",
+			ids:      []string{"vnp-tainted", "vnp"},
+			policy:   consts.VirtualNodePoolPolicyFallback,
+			pod:      tolerating,
+			wantPool: "vnp-tainted",
+		},
+		{
+			name:     "taint not tolerated and labels not selected",
+			ids:      []string{"vnp-tainted", "vnp"},
+			policy:   consts.VirtualNodePoolPolicyFallback,
+			pod:      selecting,
+			wantPool: "",
+		},
+		{
+			name:     "inactive and missing pools are skipped",
+			ids:      []string{"vnp-inactive", "vnp-missing", "vnp"},
+			policy:   consts.VirtualNodePoolPolicyFallback,
+			pod:      tolerating,
+			wantPool: "vnp",
+		},
+		{
+			name:          "preferred",
+			ids:           []string{"vnp-inactive", "vnp"},
+			policy:        consts.VirtualNodePoolPolicyPrefer,
+			pod:           tolerating,
+			wantPool:      "vnp",
+			wantPreferred: true,
+		},
+		{
+			name:   "host network not supported",
+			ids:    []string{"vnp"},
+			policy: consts.VirtualNodePoolPolicyPrefer,
+			pod:    hostNetwork,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pools, err := newVirtualNodePools(client, tc.ids, tc.policy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// missing pools are reported, but don't prevent using the others
+			_ = pools.rebuild()
+			pool, preferred := pools.poolForPod(tc.pod)
+			if pool != tc.wantPool || preferred != tc.wantPreferred {
+				t.Errorf("got (%q, %v); wanted (%q, %v)", pool, preferred, tc.wantPool, tc.wantPreferred)
+			}
+		})
+	}
+
+	if _, err := newVirtualNodePools(client, nil, "sometimes"); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
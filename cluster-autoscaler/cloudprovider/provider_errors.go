@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind is the provider-independent kind of an error returned by a cloud provider.
+// Cloud providers map their own error codes to these kinds, so that the core can
+// decide how to retry without knowing anything about the provider.
+type ErrorKind string
+
+const (
+	// UnknownErrorKind means the error wasn't classified by the cloud provider.
+	UnknownErrorKind ErrorKind = ""
+	// QuotaErrorKind means a cloud quota prevents the operation.
+	QuotaErrorKind ErrorKind = "quota"
+	// CapacityErrorKind means the cloud provider is out of capacity (e.g. stockout).
+	CapacityErrorKind ErrorKind = "capacity"
+	// PermissionErrorKind means the credentials used lack permissions for the operation.
+	PermissionErrorKind ErrorKind = "permission"
+	// ThrottlingErrorKind means the request was rate limited by the cloud provider API.
+	ThrottlingErrorKind ErrorKind = "throttling"
+	// TransientNetworkErrorKind means the cloud provider API couldn't be reached or
+	// failed with a transient server-side error.
+	TransientNetworkErrorKind ErrorKind = "transientNetwork"
+)
+
+// ErrorClass returns the instance error class corresponding to the error kind.
+func (k ErrorKind) ErrorClass() InstanceErrorClass {
+	switch k {
+	case QuotaErrorKind, CapacityErrorKind:
+		return OutOfResourcesErrorClass
+	default:
+		return OtherErrorClass
+	}
+}
+
+func (k ErrorKind) String() string {
+	if k == UnknownErrorKind {
+		return "unknown"
+	}
+	return string(k)
+}
+
+// ClassifiedError is an error returned by a cloud provider, together with its kind.
+type ClassifiedError struct {
+	// Kind is the provider-independent kind of the error.
+	Kind ErrorKind
+	// Code is the cloud-provider specific error code, if any.
+	Code string
+	// Err is the error returned by the cloud provider.
+	Err error
+}
+
+// NewClassifiedError returns a ClassifiedError of the given kind. It returns
+// nil if err is nil, so that it can wrap the result of cloud provider calls.
+func NewClassifiedError(kind ErrorKind, code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{Kind: kind, Code: code, Err: err}
+}
+
+// Error implements the error interface.
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error returned by the cloud provider.
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindFromError returns the kind of err. Errors wrapping a ClassifiedError or a
+// QuotaExceededError have the kind given by the cloud provider, network errors
+// are transient and all other errors are of unknown kind.
+func ErrorKindFromError(err error) ErrorKind {
+	if err == nil {
+		return UnknownErrorKind
+	}
+	var classifiedErr *ClassifiedError
+	if errors.As(err, &classifiedErr) {
+		return classifiedErr.Kind
+	}
+	var quotaErr *QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return QuotaErrorKind
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return TransientNetworkErrorKind
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return TransientNetworkErrorKind
+	}
+	return UnknownErrorKind
+}
+
+// ErrorKindFromHTTPStatusCode returns the kind of an error returned by a cloud
+// provider API with the given HTTP status code.
+func ErrorKindFromHTTPStatusCode(statusCode int) ErrorKind {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return ThrottlingErrorKind
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return PermissionErrorKind
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return TransientNetworkErrorKind
+	default:
+		return UnknownErrorKind
+	}
+}
+
+// NodesNotDeletedError is returned by NodeGroup.DeleteNodes when only some of the nodes
+// couldn't be deleted. The deletion of the other nodes was started as usual.
+type NodesNotDeletedError struct {
+	// NodeErrors holds the errors of the nodes which couldn't be deleted, by node name.
+	NodeErrors map[string]error
+}
+
+// Error implements the error interface.
+func (e *NodesNotDeletedError) Error() string {
+	names := make([]string, 0, len(e.NodeErrors))
+	for name := range e.NodeErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, e.NodeErrors[name]))
+	}
+	return fmt.Sprintf("failed to delete nodes: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKindFromError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		kind ErrorKind
+	}{
+		"nil":        {err: nil, kind: UnknownErrorKind},
+		"plain":      {err: errors.New("boom"), kind: UnknownErrorKind},
+		"classified": {err: NewClassifiedError(ThrottlingErrorKind, "RequestLimitExceeded", errors.New("slow down")), kind: ThrottlingErrorKind},
+		"wrapped classified": {
+			err:  fmt.Errorf("failed to scale up: %w", NewClassifiedError(CapacityErrorKind, "", errors.New("stockout"))),
+			kind: CapacityErrorKind,
+		},
+		"quota":              {err: NewQuotaExceededError("CPUS", time.Time{}, errors.New("no more cpus")), kind: QuotaErrorKind},
+		"network timeout":    {err: &net.OpError{Op: "dial", Err: timeoutError{}}, kind: TransientNetworkErrorKind},
+		"connection refused": {err: fmt.Errorf("request failed: %w", syscall.ECONNREFUSED), kind: TransientNetworkErrorKind},
+	} {
+		t.Run(name, func(t *testing.T) {
+			kind := ErrorKindFromError(tc.err)
+			assert.Equal(t, tc.kind, kind)
+		})
+	}
+	assert.Nil(t, NewClassifiedError(QuotaErrorKind, "", nil))
+}
+
+func TestErrorKindFromHTTPStatusCode(t *testing.T) {
+	assert.Equal(t, ThrottlingErrorKind, ErrorKindFromHTTPStatusCode(429))
+	assert.Equal(t, PermissionErrorKind, ErrorKindFromHTTPStatusCode(403))
+	assert.Equal(t, TransientNetworkErrorKind, ErrorKindFromHTTPStatusCode(503))
+	assert.Equal(t, UnknownErrorKind, ErrorKindFromHTTPStatusCode(404))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QuotaErrorInfo describes a cloud quota which prevented creating instances.
+type QuotaErrorInfo struct {
+	// Quota is the cloud-provider specific name of the exceeded quota, e.g. "CPUS".
+	Quota string
+	// Scope is the cloud-provider specific scope the quota applies to, e.g. a region.
+	// Node groups which exceeded the same quota in the same scope are backed off together.
+	// Empty if unknown.
+	Scope string
+	// RetryAfter is the time after which the quota is expected to be available
+	// again, as reported by the cloud provider. Zero if unknown.
+	RetryAfter time.Time
+}
+
+// QuotaExceededError is returned by node groups when instances can't be created
+// because of a cloud quota. Node groups are backed off until RetryAfter if it's set.
+type QuotaExceededError struct {
+	QuotaErrorInfo
+	// Err is the error returned by the cloud provider.
+	Err error
+}
+
+// NewQuotaExceededError returns a QuotaExceededError for the given quota.
+func NewQuotaExceededError(quota string, retryAfter time.Time, err error) *QuotaExceededError {
+	return &QuotaExceededError{
+		QuotaErrorInfo: QuotaErrorInfo{Quota: quota, RetryAfter: retryAfter},
+		Err:            err,
+	}
+}
+
+// WithScope sets the scope the exceeded quota applies to.
+func (e *QuotaExceededError) WithScope(scope string) *QuotaExceededError {
+	e.Scope = scope
+	return e
+}
+
+// Key returns the key identifying the exceeded quota in its scope.
+func (i *QuotaErrorInfo) Key() string {
+	return i.Scope + "/" + i.Quota
+}
+
+// Error implements the error interface.
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota %s exceeded: %v", e.Quota, e.Err)
+}
+
+// Unwrap returns the error returned by the cloud provider.
+func (e *QuotaExceededError) Unwrap() error {
+	return e.Err
+}
+
+// QuotaErrorInfoFromError returns the description of the exceeded quota
+// if err is or wraps a QuotaExceededError, nil otherwise.
+func QuotaErrorInfoFromError(err error) *QuotaErrorInfo {
+	var quotaErr *QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		info := quotaErr.QuotaErrorInfo
+		return &info
+	}
+	return nil
+}
@@ -333,6 +333,8 @@ type TestNodeGroup struct {
 	labels          map[string]string
 	taints          []apiv1.Taint
 	opts            *config.NodeGroupAutoscalingOptions
+	// reservedCapacity is the available reserved capacity, nil if the node group doesn't use any.
+	reservedCapacity *int
 }
 
 // NewTestNodeGroup creates a TestNodeGroup without setting up the realted TestCloudProvider.
@@ -534,6 +536,19 @@ func (tng *TestNodeGroup) SetOptions(opts *config.NodeGroupAutoscalingOptions) {
 	tng.opts = opts
 }
 
+// AvailableReservedCapacity returns the available reserved capacity set with SetAvailableReservedCapacity.
+func (tng *TestNodeGroup) AvailableReservedCapacity() (int, bool) {
+	if tng.reservedCapacity == nil {
+		return 0, false
+	}
+	return *tng.reservedCapacity, true
+}
+
+// SetAvailableReservedCapacity makes the node group use reserved capacity, with the given available capacity.
+func (tng *TestNodeGroup) SetAvailableReservedCapacity(available int) {
+	tng.reservedCapacity = &available
+}
+
 // Labels returns labels passed to the test node group when it was created.
 func (tng *TestNodeGroup) Labels() map[string]string {
 	return tng.labels
@@ -159,6 +159,18 @@ type ScaleDownCondition struct {
 	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty" yaml:"lastProbeTime,omitempty"`
 	// LastTransitionTime is the time since when the condition was in the given state.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" yaml:"lastTransitionTime,omitempty"`
+	// UnneededTimes contains the effective scale-down unneeded times of candidates, by node name,
+	// for candidates whose unneeded time is extended by their pods. Only reported for node groups.
+	UnneededTimes map[string]metav1.Duration `json:"unneededTimes,omitempty" yaml:"unneededTimes,omitempty"`
+}
+
+// DefragmentationCondition contains information about how many nodes of a node group or the
+// whole cluster could be freed by repacking their pods onto other nodes.
+type DefragmentationCondition struct {
+	// FreeableNodes is the number of nodes which could be freed by repacking pods.
+	FreeableNodes int `json:"freeableNodes" yaml:"freeableNodes"`
+	// LastProbeTime is the last time we probed the condition.
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty" yaml:"lastProbeTime,omitempty"`
 }
 
 // ClusterWideStatus contains status that apply to the whole cluster.
@@ -169,6 +181,9 @@ type ClusterWideStatus struct {
 	ScaleUp ClusterScaleUpCondition `json:"scaleUp,omitempty" yaml:"scaleUp,omitempty"`
 	// ScaleDown contains information about scale down condition of the node group.
 	ScaleDown ScaleDownCondition `json:"scaleDown,omitempty" yaml:"scaleDown,omitempty"`
+	// Defragmentation contains information about achievable consolidation of the cluster.
+	// Only reported if the defragmentation report is enabled.
+	Defragmentation *DefragmentationCondition `json:"defragmentation,omitempty" yaml:"defragmentation,omitempty"`
 }
 
 // NodeGroupStatus contains status of an individual node group on which CA works..
@@ -181,6 +196,9 @@ type NodeGroupStatus struct {
 	ScaleUp NodeGroupScaleUpCondition `json:"scaleUp,omitempty" yaml:"scaleUp,omitempty"`
 	// ScaleDown contains information about scale down condition of the node group.
 	ScaleDown ScaleDownCondition `json:"scaleDown,omitempty" yaml:"scaleDown,omitempty"`
+	// Defragmentation contains information about achievable consolidation of the node group.
+	// Only reported if the defragmentation report is enabled.
+	Defragmentation *DefragmentationCondition `json:"defragmentation,omitempty" yaml:"defragmentation,omitempty"`
 }
 
 // ClusterAutoscalerStatus contains ClusterAutoscaler status.
@@ -196,3 +214,53 @@ type ClusterAutoscalerStatus struct {
 	// NodeGroups contains status information of individual node groups on which CA works.
 	NodeGroups []NodeGroupStatus `json:"nodeGroups,omitempty" yaml:"nodeGroups,omitempty"`
 }
+
+// AcceptableRange contains the number of ready nodes expected in a node group.
+type AcceptableRange struct {
+	// MinNodes is the minimum number of ready nodes expected in the node group.
+	MinNodes int `json:"minNodes" yaml:"minNodes"`
+	// MaxNodes is the maximum number of ready nodes expected in the node group.
+	MaxNodes int `json:"maxNodes" yaml:"maxNodes"`
+	// CurrentTarget is the target size of the node group.
+	CurrentTarget int `json:"currentTarget" yaml:"currentTarget"`
+}
+
+// IncorrectNodeGroupSize contains information about a node group whose number of
+// registered nodes differs from its target size.
+type IncorrectNodeGroupSize struct {
+	// ExpectedSize is the size of the node group measured on the cloud provider side.
+	ExpectedSize int `json:"expectedSize" yaml:"expectedSize"`
+	// CurrentSize is the size of the node group measured on the kubernetes side.
+	CurrentSize int `json:"currentSize" yaml:"currentSize"`
+	// FirstObserved is the time when the difference was first observed.
+	FirstObserved metav1.Time `json:"firstObserved,omitempty" yaml:"firstObserved,omitempty"`
+}
+
+// NodeGroupStateSnapshot contains the state of a node group as seen by CA.
+type NodeGroupStateSnapshot struct {
+	// Name of the node group.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Healthy tells if CA considered the node group healthy.
+	Healthy bool `json:"healthy" yaml:"healthy"`
+	// Readiness contains number of nodes that satisfy different criteria in the node group.
+	Readiness NodeCount `json:"readiness,omitempty" yaml:"readiness,omitempty"`
+	// AcceptableRange contains the number of ready nodes expected in the node group.
+	AcceptableRange AcceptableRange `json:"acceptableRange,omitempty" yaml:"acceptableRange,omitempty"`
+	// IncorrectSize is set if the number of registered nodes differs from the target size.
+	IncorrectSize *IncorrectNodeGroupSize `json:"incorrectSize,omitempty" yaml:"incorrectSize,omitempty"`
+}
+
+// ClusterStateSnapshot contains the state of the cluster as seen by CA at a given time.
+// Snapshots are persisted for post-mortem analysis of CA decisions.
+type ClusterStateSnapshot struct {
+	// Time of the snapshot.
+	Time string `json:"time,omitempty" yaml:"time,omitempty"`
+	// Healthy tells if CA considered the cluster healthy.
+	Healthy bool `json:"healthy" yaml:"healthy"`
+	// Readiness contains number of nodes that satisfy different criteria in the cluster.
+	Readiness NodeCount `json:"readiness,omitempty" yaml:"readiness,omitempty"`
+	// NodeGroups contains the state of individual node groups.
+	NodeGroups []NodeGroupStateSnapshot `json:"nodeGroups,omitempty" yaml:"nodeGroups,omitempty"`
+	// TruncatedNodeGroups is the number of healthy node groups left out to keep the snapshot within the size limit.
+	TruncatedNodeGroups int `json:"truncatedNodeGroups,omitempty" yaml:"truncatedNodeGroups,omitempty"`
+}
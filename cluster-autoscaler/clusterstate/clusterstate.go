@@ -132,9 +132,12 @@ type ClusterStateRegistry struct {
 	unregisteredNodes                  map[string]UnregisteredNode
 	deletedNodes                       map[string]struct{}
 	candidatesForScaleDown             map[string][]string
+	extendedUnneededTimes              map[string]time.Duration
 	backoff                            backoff.Backoff
 	lastStatus                         *api.ClusterAutoscalerStatus
 	lastScaleDownUpdateTime            time.Time
+	freeableNodes                      map[string]int
+	lastDefragmentationUpdateTime      time.Time
 	logRecorder                        *utils.LogEventRecorder
 	cloudProviderNodeInstances         map[string][]cloudprovider.Instance
 	previousCloudProviderNodeInstances map[string][]cloudprovider.Instance
@@ -145,8 +148,22 @@ type ClusterStateRegistry struct {
 	// scaleUpFailures contains information about scale-up failures for each node group. It should be
 	// cleared periodically to avoid unnecessary accumulation.
 	scaleUpFailures map[string][]ScaleUpFailure
+	// quotaNodeGroups contains the node groups which failed to scale up because of each cloud quota,
+	// by quota key, with the time of their last failure. They're backed off together when the quota
+	// is exceeded again.
+	quotaNodeGroups map[string]map[string]quotaNodeGroup
 }
 
+// quotaNodeGroup is a node group which failed to scale up because of a cloud quota.
+type quotaNodeGroup struct {
+	nodeGroup   cloudprovider.NodeGroup
+	lastFailure time.Time
+}
+
+// quotaNodeGroupsTTL is how long node groups which exceeded a cloud quota are backed off
+// together with the other node groups exceeding it, since their last failure.
+const quotaNodeGroupsTTL = 30 * time.Minute
+
 // NodeGroupScalingSafety contains information about the safety of the node group to scale up/down.
 type NodeGroupScalingSafety struct {
 	SafeToScale   bool
@@ -174,6 +191,7 @@ func NewClusterStateRegistry(cloudProvider cloudprovider.CloudProvider, config C
 		cloudProviderNodeInstancesCache: utils.NewCloudProviderNodeInstancesCache(cloudProvider),
 		interrupt:                       make(chan struct{}),
 		scaleUpFailures:                 make(map[string][]ScaleUpFailure),
+		quotaNodeGroups:                 make(map[string]map[string]quotaNodeGroup),
 		nodeGroupConfigProcessor:        nodeGroupConfigProcessor,
 	}
 }
@@ -265,12 +283,18 @@ func (csr *ClusterStateRegistry) updateScaleRequests(currentTime time.Time) {
 			delete(csr.scaleUpRequests, nodeGroupName)
 			klog.V(4).Infof("Scale up in group %v finished successfully in %v",
 				nodeGroupName, currentTime.Sub(scaleUpRequest.Time))
+			if observer, ok := csr.nodeGroupConfigProcessor.(nodegroupconfig.NodeProvisionTimeObserver); ok {
+				observer.ObserveNodeProvisionTime(scaleUpRequest.NodeGroup, currentTime.Sub(scaleUpRequest.Time))
+			}
 			continue
 		}
 
 		if scaleUpRequest.ExpectedAddTime.Before(currentTime) {
 			klog.Warningf("Scale-up timed out for node group %v after %v",
 				nodeGroupName, currentTime.Sub(scaleUpRequest.Time))
+			if observer, ok := csr.nodeGroupConfigProcessor.(nodegroupconfig.NodeProvisionTimeObserver); ok {
+				observer.ObserveNodeProvisionTimeout(scaleUpRequest.NodeGroup, currentTime.Sub(scaleUpRequest.Time))
+			}
 			csr.logRecorder.Eventf(apiv1.EventTypeWarning, "ScaleUpTimedOut",
 				"Nodes added to group %s failed to register within %v",
 				scaleUpRequest.NodeGroup.Id(), currentTime.Sub(scaleUpRequest.Time))
@@ -304,7 +328,38 @@ func (csr *ClusterStateRegistry) updateScaleRequests(currentTime time.Time) {
 func (csr *ClusterStateRegistry) backoffNodeGroup(nodeGroup cloudprovider.NodeGroup, errorInfo cloudprovider.InstanceErrorInfo, currentTime time.Time) {
 	nodeGroupInfo := csr.nodeInfosForGroups[nodeGroup.Id()]
 	backoffUntil := csr.backoff.Backoff(nodeGroup, nodeGroupInfo, errorInfo, currentTime)
-	klog.Warningf("Disabling scale-up for node group %v until %v; errorClass=%v; errorCode=%v", nodeGroup.Id(), backoffUntil, errorInfo.ErrorClass, errorInfo.ErrorCode)
+	if errorInfo.Quota != nil {
+		klog.Warningf("Disabling scale-up for node group %v until %v; errorClass=%v; errorKind=%v; errorCode=%v; quota=%v; retryAfter=%v", nodeGroup.Id(), backoffUntil, errorInfo.ErrorClass, errorInfo.ErrorKind, errorInfo.ErrorCode, errorInfo.Quota.Quota, errorInfo.Quota.RetryAfter)
+		return
+	}
+	klog.Warningf("Disabling scale-up for node group %v until %v; errorClass=%v; errorKind=%v; errorCode=%v", nodeGroup.Id(), backoffUntil, errorInfo.ErrorClass, errorInfo.ErrorKind, errorInfo.ErrorCode)
+}
+
+// backoffQuotaNodeGroups backs off the node groups which recently exceeded the same cloud quota
+// as the given node group, unless they're already backed off, as they would fail on it too.
+// To be executed under a lock.
+func (csr *ClusterStateRegistry) backoffQuotaNodeGroups(nodeGroup cloudprovider.NodeGroup, errorInfo cloudprovider.InstanceErrorInfo, currentTime time.Time) {
+	key := errorInfo.Quota.Key()
+	nodeGroups := csr.quotaNodeGroups[key]
+	if nodeGroups == nil {
+		nodeGroups = make(map[string]quotaNodeGroup)
+		csr.quotaNodeGroups[key] = nodeGroups
+	}
+	nodeGroups[nodeGroup.Id()] = quotaNodeGroup{nodeGroup: nodeGroup, lastFailure: currentTime}
+	for id, other := range nodeGroups {
+		if id == nodeGroup.Id() {
+			continue
+		}
+		if other.lastFailure.Add(quotaNodeGroupsTTL).Before(currentTime) {
+			delete(nodeGroups, id)
+			continue
+		}
+		if csr.backoff.BackoffStatus(other.nodeGroup, csr.nodeInfosForGroups[id], currentTime).IsBackedOff {
+			continue
+		}
+		backoffUntil := csr.backoff.Backoff(other.nodeGroup, csr.nodeInfosForGroups[id], errorInfo, currentTime)
+		klog.Warningf("Disabling scale-up for node group %v until %v; quota %v exceeded by node group %v", id, backoffUntil, key, nodeGroup.Id())
+	}
 }
 
 // RegisterFailedScaleUp should be called after getting error from cloudprovider
@@ -320,6 +375,15 @@ func (csr *ClusterStateRegistry) RegisterFailedScaleUp(nodeGroup cloudprovider.N
 	}, gpuResourceName, gpuType, currentTime)
 }
 
+// RegisterFailedScaleUpWithErrorInfo is like RegisterFailedScaleUp, with the details of the
+// error returned by the cloudprovider, e.g. the exceeded cloud quota, used to back off the
+// node group. errorInfo.ErrorCode is the reason of the failure.
+func (csr *ClusterStateRegistry) RegisterFailedScaleUpWithErrorInfo(nodeGroup cloudprovider.NodeGroup, errorInfo cloudprovider.InstanceErrorInfo, gpuResourceName, gpuType string, currentTime time.Time) {
+	csr.Lock()
+	defer csr.Unlock()
+	csr.registerFailedScaleUpNoLock(nodeGroup, metrics.FailedScaleUpReason(errorInfo.ErrorCode), errorInfo, gpuResourceName, gpuType, currentTime)
+}
+
 // RegisterFailedScaleDown records failed scale-down for a nodegroup.
 // We don't need to implement this function for cluster state registry
 func (csr *ClusterStateRegistry) RegisterFailedScaleDown(_ cloudprovider.NodeGroup, _ string, _ time.Time) {
@@ -328,7 +392,14 @@ func (csr *ClusterStateRegistry) RegisterFailedScaleDown(_ cloudprovider.NodeGro
 func (csr *ClusterStateRegistry) registerFailedScaleUpNoLock(nodeGroup cloudprovider.NodeGroup, reason metrics.FailedScaleUpReason, errorInfo cloudprovider.InstanceErrorInfo, gpuResourceName, gpuType string, currentTime time.Time) {
 	csr.scaleUpFailures[nodeGroup.Id()] = append(csr.scaleUpFailures[nodeGroup.Id()], ScaleUpFailure{NodeGroup: nodeGroup, Reason: reason, Time: currentTime})
 	metrics.RegisterFailedScaleUp(reason, gpuResourceName, gpuType)
+	if errorInfo.Quota != nil {
+		metrics.RegisterQuotaExceededScaleUp(errorInfo.Quota.Quota)
+	}
+	metrics.RegisterFailedScaleUpErrorKind(errorInfo.ErrorKind.String())
 	csr.backoffNodeGroup(nodeGroup, errorInfo, currentTime)
+	if errorInfo.Quota != nil {
+		csr.backoffQuotaNodeGroups(nodeGroup, errorInfo, currentTime)
+	}
 }
 
 // UpdateNodes updates the state of the nodes in the ClusterStateRegistry and recalculates the stats
@@ -763,6 +834,17 @@ func (csr *ClusterStateRegistry) UpdateScaleDownCandidates(nodes []*apiv1.Node,
 	csr.lastScaleDownUpdateTime = now
 }
 
+// UpdateExtendedUnneededTimes updates scale-down unneeded times of candidates extended by their pods, by node name.
+func (csr *ClusterStateRegistry) UpdateExtendedUnneededTimes(extendedUnneededTimes map[string]time.Duration) {
+	csr.extendedUnneededTimes = extendedUnneededTimes
+}
+
+// UpdateFreeableNodes updates the number of nodes of each node group which could be freed by repacking pods.
+func (csr *ClusterStateRegistry) UpdateFreeableNodes(freeableNodes map[string]int, now time.Time) {
+	csr.freeableNodes = freeableNodes
+	csr.lastDefragmentationUpdateTime = now
+}
+
 // GetStatus returns ClusterAutoscalerStatus with the current cluster autoscaler status.
 func (csr *ClusterStateRegistry) GetStatus(now time.Time) *api.ClusterAutoscalerStatus {
 	result := &api.ClusterAutoscalerStatus{
@@ -795,6 +877,15 @@ func (csr *ClusterStateRegistry) GetStatus(now time.Time) *api.ClusterAutoscaler
 		// Scale down.
 		nodeGroupStatus.ScaleDown = buildScaleDownStatusNodeGroup(
 			csr.candidatesForScaleDown[nodeGroup.Id()], csr.lastScaleDownUpdateTime, nodeGroupLastStatus.ScaleDown)
+		nodeGroupStatus.ScaleDown.UnneededTimes = csr.buildUnneededTimes(nodeGroup)
+
+		// Defragmentation.
+		if !csr.lastDefragmentationUpdateTime.IsZero() {
+			nodeGroupStatus.Defragmentation = &api.DefragmentationCondition{
+				FreeableNodes: csr.freeableNodes[nodeGroup.Id()],
+				LastProbeTime: metav1.Time{Time: csr.lastDefragmentationUpdateTime},
+			}
+		}
 
 		result.NodeGroups = append(result.NodeGroups, nodeGroupStatus)
 	}
@@ -804,11 +895,54 @@ func (csr *ClusterStateRegistry) GetStatus(now time.Time) *api.ClusterAutoscaler
 		buildScaleUpStatusClusterwide(result.NodeGroups, csr.totalReadiness, csr.lastStatus.ClusterWide.ScaleUp)
 	result.ClusterWide.ScaleDown =
 		buildScaleDownStatusClusterwide(csr.candidatesForScaleDown, csr.lastScaleDownUpdateTime, csr.lastStatus.ClusterWide.ScaleDown)
+	if !csr.lastDefragmentationUpdateTime.IsZero() {
+		totalFreeable := 0
+		for _, freeable := range csr.freeableNodes {
+			totalFreeable += freeable
+		}
+		result.ClusterWide.Defragmentation = &api.DefragmentationCondition{
+			FreeableNodes: totalFreeable,
+			LastProbeTime: metav1.Time{Time: csr.lastDefragmentationUpdateTime},
+		}
+	}
 
 	csr.lastStatus = result
 	return result
 }
 
+// GetStateSnapshot returns the current readiness, acceptable ranges and incorrect
+// sizes of the node groups, to be persisted for post-mortem analysis.
+func (csr *ClusterStateRegistry) GetStateSnapshot(now time.Time) *api.ClusterStateSnapshot {
+	result := &api.ClusterStateSnapshot{
+		Time:       now.Format(utils.ConfigMapLastUpdateFormat),
+		Healthy:    csr.IsClusterHealthy(),
+		Readiness:  buildNodeCount(csr.totalReadiness),
+		NodeGroups: make([]api.NodeGroupStateSnapshot, 0),
+	}
+	for _, nodeGroup := range csr.cloudProvider.NodeGroups() {
+		acceptable := csr.acceptableRanges[nodeGroup.Id()]
+		nodeGroupSnapshot := api.NodeGroupStateSnapshot{
+			Name:      nodeGroup.Id(),
+			Healthy:   csr.IsNodeGroupHealthy(nodeGroup.Id()),
+			Readiness: buildNodeCount(csr.perNodeGroupReadiness[nodeGroup.Id()]),
+			AcceptableRange: api.AcceptableRange{
+				MinNodes:      acceptable.MinNodes,
+				MaxNodes:      acceptable.MaxNodes,
+				CurrentTarget: acceptable.CurrentTarget,
+			},
+		}
+		if incorrect, found := csr.incorrectNodeGroupSizes[nodeGroup.Id()]; found {
+			nodeGroupSnapshot.IncorrectSize = &api.IncorrectNodeGroupSize{
+				ExpectedSize:  incorrect.ExpectedSize,
+				CurrentSize:   incorrect.CurrentSize,
+				FirstObserved: metav1.Time{Time: incorrect.FirstObserved},
+			}
+		}
+		result.NodeGroups = append(result.NodeGroups, nodeGroupSnapshot)
+	}
+	return result
+}
+
 // GetClusterReadiness returns current readiness stats of cluster
 func (csr *ClusterStateRegistry) GetClusterReadiness() Readiness {
 	return csr.totalReadiness
@@ -897,6 +1031,29 @@ func buildScaleDownStatusNodeGroup(candidates []string, lastProbed time.Time, la
 	return condition
 }
 
+// buildUnneededTimes returns the effective unneeded times of scale-down candidates of the node group,
+// whose unneeded time is extended by their pods beyond the one of the node group.
+func (csr *ClusterStateRegistry) buildUnneededTimes(nodeGroup cloudprovider.NodeGroup) map[string]metav1.Duration {
+	if len(csr.extendedUnneededTimes) == 0 {
+		return nil
+	}
+	unneededTime, err := csr.nodeGroupConfigProcessor.GetScaleDownUnneededTime(nodeGroup)
+	if err != nil {
+		klog.Warningf("Failed to get ScaleDownUnneededTime for node group %s: %v", nodeGroup.Id(), err)
+		return nil
+	}
+	var result map[string]metav1.Duration
+	for _, nodeName := range csr.candidatesForScaleDown[nodeGroup.Id()] {
+		if extended := csr.extendedUnneededTimes[nodeName]; extended > unneededTime {
+			if result == nil {
+				result = make(map[string]metav1.Duration)
+			}
+			result[nodeName] = metav1.Duration{Duration: extended}
+		}
+	}
+	return result
+}
+
 func buildHealthStatusClusterwide(isHealthy bool, readiness Readiness, lastStatus api.ClusterHealthCondition) api.ClusterHealthCondition {
 	condition := api.ClusterHealthCondition{
 		NodeCounts:    buildNodeCount(readiness),
@@ -1138,6 +1295,7 @@ func (csr *ClusterStateRegistry) handleInstanceCreationErrorsForNodeGroup(
 
 			csr.registerFailedScaleUpNoLock(nodeGroup, metrics.FailedScaleUpReason(errorCode.code), cloudprovider.InstanceErrorInfo{
 				ErrorClass:   errorCode.class,
+				ErrorKind:    errorCode.kind,
 				ErrorCode:    errorCode.code,
 				ErrorMessage: csr.buildErrorMessageEventString(currentUniqueErrorMessagesForErrorCode[errorCode]),
 			}, gpuResource, gpuType, currentTime)
@@ -1164,6 +1322,7 @@ func (csr *ClusterStateRegistry) buildErrorMessageEventString(uniqErrorMessages
 type errorCode struct {
 	code  string
 	class cloudprovider.InstanceErrorClass
+	kind  cloudprovider.ErrorKind
 }
 
 func (c errorCode) String() string {
@@ -1179,7 +1338,7 @@ func (csr *ClusterStateRegistry) buildInstanceToErrorCodeMappings(instances []cl
 	for _, instance := range instances {
 		if instance.Status != nil && instance.Status.State == cloudprovider.InstanceCreating && instance.Status.ErrorInfo != nil {
 			errorInfo := instance.Status.ErrorInfo
-			errorCode := errorCode{errorInfo.ErrorCode, errorInfo.ErrorClass}
+			errorCode := errorCode{errorInfo.ErrorCode, errorInfo.ErrorClass, errorInfo.ErrorKind}
 
 			if _, found := uniqErrorMessagesForErrorCodeTmp[errorCode]; !found {
 				uniqErrorMessagesForErrorCodeTmp[errorCode] = make(map[string]bool)
@@ -1200,15 +1359,24 @@ func (csr *ClusterStateRegistry) buildInstanceToErrorCodeMappings(instances []cl
 }
 
 // GetCreatedNodesWithErrors returns list of nodes being created which reported create error.
+// Instances a node registered for are skipped, as deleting them would skip draining the node.
 func (csr *ClusterStateRegistry) GetCreatedNodesWithErrors() []*apiv1.Node {
 	csr.Lock()
 	defer csr.Unlock()
 
+	registered := make(map[string]bool, len(csr.nodes))
+	for _, node := range csr.nodes {
+		registered[node.Spec.ProviderID] = true
+	}
 	nodesWithCreateErrors := make([]*apiv1.Node, 0, 0)
 	for _, nodeGroupInstances := range csr.cloudProviderNodeInstances {
 		_, _, instancesByErrorCode := csr.buildInstanceToErrorCodeMappings(nodeGroupInstances)
 		for _, instances := range instancesByErrorCode {
 			for _, instance := range instances {
+				if registered[instance.Id] {
+					klog.V(2).Infof("Instance %s reported a create error but registered as a node, not deleting it", instance.Id)
+					continue
+				}
 				nodesWithCreateErrors = append(nodesWithCreateErrors, FakeNode(instance, cloudprovider.FakeNodeCreateError))
 			}
 		}
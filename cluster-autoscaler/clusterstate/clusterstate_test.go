@@ -54,6 +54,29 @@ func GetCloudProviderDeletedNodeNames(csr *ClusterStateRegistry) []string {
 	return result
 }
 
+func TestDefragmentationStatus(t *testing.T) {
+	now := time.Now()
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{}, fakeLogRecorder, newBackoff(),
+		nodegroupconfig.NewDefaultNodeGroupConfigProcessor(config.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: time.Minute}))
+
+	status := clusterstate.GetStatus(now)
+	assert.Nil(t, status.ClusterWide.Defragmentation)
+
+	clusterstate.UpdateFreeableNodes(map[string]int{"ng1": 2}, now)
+	status = clusterstate.GetStatus(now)
+	assert.Equal(t, 2, status.ClusterWide.Defragmentation.FreeableNodes)
+	for _, nodeGroupStatus := range status.NodeGroups {
+		want := map[string]int{"ng1": 2, "ng2": 0}[nodeGroupStatus.Name]
+		assert.Equal(t, want, nodeGroupStatus.Defragmentation.FreeableNodes, nodeGroupStatus.Name)
+	}
+}
+
 func TestOKWithScaleUp(t *testing.T) {
 	now := time.Now()
 
@@ -101,6 +124,47 @@ func TestOKWithScaleUp(t *testing.T) {
 	assert.True(t, ng2Checked)
 }
 
+func TestGetStateSnapshot(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+	ng2_1 := BuildTestNode("ng2-1", 1000, 1000)
+	SetNodeReadyState(ng2_1, false, now.Add(-time.Minute))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng2", ng2_1)
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+	}, fakeLogRecorder, newBackoff(), nodegroupconfig.NewDefaultNodeGroupConfigProcessor(config.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: time.Minute}))
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng2_1}, nil, now)
+	assert.NoError(t, err)
+
+	snapshot := clusterstate.GetStateSnapshot(now)
+	assert.True(t, snapshot.Healthy)
+	assert.Equal(t, 2, snapshot.Readiness.Registered.Total)
+	assert.Equal(t, 1, snapshot.Readiness.Registered.Unready.Total)
+	assert.Len(t, snapshot.NodeGroups, 2)
+	nodeGroups := make(map[string]api.NodeGroupStateSnapshot)
+	for _, nodeGroup := range snapshot.NodeGroups {
+		nodeGroups[nodeGroup.Name] = nodeGroup
+	}
+	assert.Equal(t, api.AcceptableRange{MinNodes: 3, MaxNodes: 3, CurrentTarget: 3}, nodeGroups["ng1"].AcceptableRange)
+	if assert.NotNil(t, nodeGroups["ng1"].IncorrectSize) {
+		assert.Equal(t, 3, nodeGroups["ng1"].IncorrectSize.ExpectedSize)
+		assert.Equal(t, 1, nodeGroups["ng1"].IncorrectSize.CurrentSize)
+	}
+	assert.Equal(t, 1, nodeGroups["ng2"].Readiness.Registered.Unready.Total)
+	assert.Nil(t, nodeGroups["ng2"].IncorrectSize)
+}
+
 func TestEmptyOK(t *testing.T) {
 	now := time.Now()
 
@@ -238,6 +302,42 @@ func TestNodeWithoutNodeGroupDontCrash(t *testing.T) {
 	clusterstate.UpdateScaleDownCandidates([]*apiv1.Node{noNgNode}, now)
 }
 
+func TestScaleDownCandidatesExtendedUnneededTimes(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+	ng1_2 := BuildTestNode("ng1-2", 1000, 1000)
+	SetNodeReadyState(ng1_2, true, now.Add(-time.Minute))
+	ng1_3 := BuildTestNode("ng1-3", 1000, 1000)
+	SetNodeReadyState(ng1_3, true, now.Add(-time.Minute))
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng1", ng1_2)
+	provider.AddNode("ng1", ng1_3)
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{}, fakeLogRecorder, newBackoff(),
+		nodegroupconfig.NewDefaultNodeGroupConfigProcessor(config.NodeGroupAutoscalingOptions{ScaleDownUnneededTime: 10 * time.Minute}))
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2, ng1_3}, nil, now)
+	assert.NoError(t, err)
+	clusterstate.UpdateScaleDownCandidates([]*apiv1.Node{ng1_1, ng1_2}, now)
+	clusterstate.UpdateExtendedUnneededTimes(map[string]time.Duration{
+		"ng1-1": 30 * time.Minute,
+		// Shorter than the unneeded time of the node group.
+		"ng1-2": 5 * time.Minute,
+		// Not a candidate anymore.
+		"ng1-3": 30 * time.Minute,
+	})
+
+	status := clusterstate.GetStatus(now)
+	assert.Equal(t, 1, len(status.NodeGroups))
+	assert.Equal(t, map[string]metav1.Duration{"ng1-1": {Duration: 30 * time.Minute}}, status.NodeGroups[0].ScaleDown.UnneededTimes)
+	assert.Nil(t, status.ClusterWide.ScaleDown.UnneededTimes)
+}
+
 func TestOKOneUnreadyNodeWithScaleDownCandidate(t *testing.T) {
 	now := time.Now()
 
@@ -1019,6 +1119,50 @@ func TestScaleUpFailures(t *testing.T) {
 	assert.Empty(t, clusterstate.GetScaleUpFailures())
 }
 
+func TestQuotaScaleUpFailures(t *testing.T) {
+	now := time.Now()
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 0)
+	provider.AddNodeGroup("ng2", 0, 10, 0)
+	provider.AddNodeGroup("ng3", 0, 10, 0)
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{}, fakeLogRecorder, newBackoff(), nodegroupconfig.NewDefaultNodeGroupConfigProcessor(config.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: 15 * time.Minute}))
+	quotaError := func(scope string) cloudprovider.InstanceErrorInfo {
+		return cloudprovider.InstanceErrorInfo{
+			ErrorClass: cloudprovider.OutOfResourcesErrorClass,
+			ErrorCode:  string(metrics.CloudProviderError),
+			Quota:      &cloudprovider.QuotaErrorInfo{Quota: "CPUS", Scope: scope},
+		}
+	}
+	isBackedOff := func(id string, at time.Time) bool {
+		return clusterstate.BackoffStatusForNodeGroup(provider.GetNodeGroup(id), at).IsBackedOff
+	}
+
+	clusterstate.RegisterFailedScaleUpWithErrorInfo(provider.GetNodeGroup("ng1"), quotaError("us-central1"), "", "", now)
+	clusterstate.RegisterFailedScaleUpWithErrorInfo(provider.GetNodeGroup("ng3"), quotaError("europe-west1"), "", "", now)
+	assert.True(t, isBackedOff("ng1", now))
+	assert.False(t, isBackedOff("ng2", now))
+
+	// Once ng1's backoff expired, ng2 exceeding the same quota in the same scope backs off ng1 too,
+	// but not ng3, which exceeded it in another scope.
+	later := now.Add(10 * time.Minute)
+	assert.False(t, isBackedOff("ng1", later))
+	assert.False(t, isBackedOff("ng3", later))
+	clusterstate.RegisterFailedScaleUpWithErrorInfo(provider.GetNodeGroup("ng2"), quotaError("us-central1"), "", "", later)
+	assert.True(t, isBackedOff("ng1", later))
+	assert.True(t, isBackedOff("ng2", later))
+	assert.False(t, isBackedOff("ng3", later))
+
+	// Node groups are forgotten when they haven't exceeded the quota for a while.
+	muchLater := now.Add(2 * quotaNodeGroupsTTL)
+	clusterstate.RegisterFailedScaleUpWithErrorInfo(provider.GetNodeGroup("ng2"), quotaError("us-central1"), "", "", muchLater)
+	assert.False(t, isBackedOff("ng1", muchLater))
+	assert.NotContains(t, clusterstate.quotaNodeGroups["us-central1/CPUS"], "ng1")
+}
+
 func newBackoff() backoff.Backoff {
 	return backoff.NewIdBasedExponentialBackoff(5*time.Minute, /*InitialNodeGroupBackoffDuration*/
 		30*time.Minute /*MaxNodeGroupBackoffDuration*/, 3*time.Hour /*NodeGroupBackoffResetTimeout*/)
@@ -1380,3 +1524,30 @@ func TestTruncateIfExceedMaxSize(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCreatedNodesWithErrorsSkipsRegisteredNodes(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{}, fakeLogRecorder, newBackoff(),
+		nodegroupconfig.NewDefaultNodeGroupConfigProcessor(config.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: time.Minute}))
+
+	registered := BuildTestNode("ng1-1", 1000, 1000)
+	registered.Spec.ProviderID = "ng1-1"
+	failedStatus := &cloudprovider.InstanceStatus{
+		State: cloudprovider.InstanceCreating,
+		ErrorInfo: &cloudprovider.InstanceErrorInfo{
+			ErrorClass: cloudprovider.OtherErrorClass,
+			ErrorCode:  "failed",
+		},
+	}
+	clusterstate.nodes = []*apiv1.Node{registered}
+	clusterstate.cloudProviderNodeInstances = map[string][]cloudprovider.Instance{
+		"ng1": {{Id: "ng1-1", Status: failedStatus}, {Id: "ng1-2", Status: failedStatus}},
+	}
+
+	nodes := clusterstate.GetCreatedNodesWithErrors()
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, "ng1-2", nodes[0].Spec.ProviderID)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	kube_client "k8s.io/client-go/kubernetes"
+
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// stateSnapshotKeyFormat is the format of the keys of the persisted snapshots.
+	// The keys sort in the order the snapshots were taken.
+	stateSnapshotKeyFormat = "20060102-150405.000000000"
+	// stateSnapshotFilePrefix is the prefix of the names of the snapshot files.
+	stateSnapshotFilePrefix = "cluster-state-"
+	// stateSnapshotFileSuffix is the suffix of the names of the snapshot files.
+	stateSnapshotFileSuffix = ".yaml"
+	// maxStateSnapshotsConfigMapSize is the maximum total size of the snapshots kept in the ConfigMap,
+	// leaving headroom for its metadata under the 1MiB size limit of ConfigMaps.
+	maxStateSnapshotsConfigMapSize = 900 * 1024
+)
+
+// StateSnapshotWriter persists the cluster state snapshots taken every loop,
+// keeping only a limited number of the most recent ones.
+type StateSnapshotWriter interface {
+	// Write persists the snapshot taken at the given time and removes the oldest
+	// snapshots exceeding the retention.
+	Write(snapshot *api.ClusterStateSnapshot, now time.Time) error
+}
+
+// NewStateSnapshotWriter returns a StateSnapshotWriter writing snapshots to files in dir,
+// or to a ConfigMap if dir is empty. Snapshots are only persisted when the state changed,
+// or when the interval passed since the last persisted one.
+func NewStateSnapshotWriter(kubeClient kube_client.Interface, namespace, configMapName, dir string, retention int, interval time.Duration) StateSnapshotWriter {
+	var writer StateSnapshotWriter
+	if dir != "" {
+		writer = &fileStateSnapshotWriter{dir: dir, retention: retention}
+	} else {
+		writer = &configMapStateSnapshotWriter{kubeClient: kubeClient, namespace: namespace, name: configMapName, retention: retention}
+	}
+	return &changedStateSnapshotWriter{writer: writer, interval: interval}
+}
+
+func stateSnapshotKey(now time.Time) string {
+	return now.UTC().Format(stateSnapshotKeyFormat)
+}
+
+// changedStateSnapshotWriter persists a snapshot only if the state changed since the last
+// persisted snapshot, or if the interval passed since then.
+type changedStateSnapshotWriter struct {
+	writer   StateSnapshotWriter
+	interval time.Duration
+	// lastState is the last persisted snapshot, without its time.
+	lastState api.ClusterStateSnapshot
+	lastWrite time.Time
+}
+
+// Write persists the snapshot if the state changed or the interval passed.
+func (w *changedStateSnapshotWriter) Write(snapshot *api.ClusterStateSnapshot, now time.Time) error {
+	state := *snapshot
+	state.Time = ""
+	if !w.lastWrite.IsZero() && now.Sub(w.lastWrite) < w.interval && reflect.DeepEqual(state, w.lastState) {
+		return nil
+	}
+	if err := w.writer.Write(snapshot, now); err != nil {
+		return err
+	}
+	w.lastState = state
+	w.lastWrite = now
+	return nil
+}
+
+// configMapStateSnapshotWriter keeps the snapshots in a ConfigMap, one per key. The ConfigMap
+// is only read when the writer starts or when it was changed by someone else.
+type configMapStateSnapshotWriter struct {
+	kubeClient kube_client.Interface
+	namespace  string
+	name       string
+	retention  int
+	// configMap is the last version of the ConfigMap written, nil if it must be read.
+	configMap *apiv1.ConfigMap
+}
+
+// Write persists the snapshot in the ConfigMap, creating it if it doesn't exist. The oldest
+// snapshots are removed to keep the ConfigMap within its size limit.
+func (w *configMapStateSnapshotWriter) Write(snapshot *api.ClusterStateSnapshot, now time.Time) error {
+	snapshotYaml, err := marshalStateSnapshot(snapshot, maxStateSnapshotsConfigMapSize)
+	if err != nil {
+		return err
+	}
+	updateTime := now.Format(ConfigMapLastUpdateFormat)
+	maps := w.kubeClient.CoreV1().ConfigMaps(w.namespace)
+	configMap := w.configMap
+	if configMap == nil {
+		configMap, err = maps.Get(context.TODO(), w.name, metav1.GetOptions{})
+		if kube_errors.IsNotFound(err) {
+			configMap = &apiv1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   w.namespace,
+					Name:        w.name,
+					Annotations: map[string]string{ConfigMapLastUpdatedKey: updateTime},
+				},
+				Data: map[string]string{stateSnapshotKey(now): string(snapshotYaml)},
+			}
+			if w.configMap, err = maps.Create(context.TODO(), configMap, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create cluster state snapshots configmap: %v", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retrieve cluster state snapshots configmap: %v", err)
+		}
+	}
+	configMap = configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[stateSnapshotKey(now)] = string(snapshotYaml)
+	for _, key := range expiredStateSnapshots(keys(configMap.Data), w.retention) {
+		delete(configMap.Data, key)
+	}
+	for _, key := range oversizedStateSnapshots(configMap.Data, maxStateSnapshotsConfigMapSize) {
+		delete(configMap.Data, key)
+	}
+	if configMap.ObjectMeta.Annotations == nil {
+		configMap.ObjectMeta.Annotations = make(map[string]string)
+	}
+	configMap.ObjectMeta.Annotations[ConfigMapLastUpdatedKey] = updateTime
+	if w.configMap, err = maps.Update(context.TODO(), configMap, metav1.UpdateOptions{}); err != nil {
+		// The ConfigMap may have been changed or removed, read it again next time.
+		w.configMap = nil
+		return fmt.Errorf("failed to update cluster state snapshots configmap: %v", err)
+	}
+	return nil
+}
+
+// marshalStateSnapshot returns the YAML of the snapshot. If it's larger than maxSize, healthy node
+// groups without an incorrect size are left out, the last ones first, until it fits.
+func marshalStateSnapshot(snapshot *api.ClusterStateSnapshot, maxSize int) ([]byte, error) {
+	snapshotYaml, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cluster state snapshot: %v", err)
+	}
+	if len(snapshotYaml) <= maxSize {
+		return snapshotYaml, nil
+	}
+	var kept, droppable []api.NodeGroupStateSnapshot
+	for _, nodeGroup := range snapshot.NodeGroups {
+		if nodeGroup.Healthy && nodeGroup.IncorrectSize == nil {
+			droppable = append(droppable, nodeGroup)
+		} else {
+			kept = append(kept, nodeGroup)
+		}
+	}
+	truncate := func(dropped int) ([]byte, error) {
+		truncated := *snapshot
+		truncated.NodeGroups = append(append([]api.NodeGroupStateSnapshot{}, kept...), droppable[:len(droppable)-dropped]...)
+		truncated.TruncatedNodeGroups = dropped
+		return yaml.Marshal(&truncated)
+	}
+	// Leave out as few node groups as possible. If the snapshot doesn't fit even
+	// without any droppable node group, it's persisted without them anyway.
+	dropped := sort.Search(len(droppable), func(dropped int) bool {
+		truncatedYaml, err := truncate(dropped)
+		return err != nil || len(truncatedYaml) <= maxSize
+	})
+	klog.Warningf("Cluster state snapshot exceeds %d bytes, leaving out %d healthy node groups", maxSize, dropped)
+	if snapshotYaml, err = truncate(dropped); err != nil {
+		return nil, fmt.Errorf("failed to marshal cluster state snapshot: %v", err)
+	}
+	return snapshotYaml, nil
+}
+
+// fileStateSnapshotWriter keeps the snapshots in files in a directory, e.g. a mounted volume.
+type fileStateSnapshotWriter struct {
+	dir       string
+	retention int
+}
+
+// Write persists the snapshot in a new file and removes the files of the oldest snapshots.
+func (w *fileStateSnapshotWriter) Write(snapshot *api.ClusterStateSnapshot, now time.Time) error {
+	snapshotYaml, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster state snapshot: %v", err)
+	}
+	fileName := stateSnapshotFilePrefix + stateSnapshotKey(now) + stateSnapshotFileSuffix
+	if err := os.WriteFile(filepath.Join(w.dir, fileName), snapshotYaml, 0644); err != nil {
+		return fmt.Errorf("failed to write cluster state snapshot: %v", err)
+	}
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list cluster state snapshots: %v", err)
+	}
+	var fileNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), stateSnapshotFilePrefix) && strings.HasSuffix(entry.Name(), stateSnapshotFileSuffix) {
+			fileNames = append(fileNames, entry.Name())
+		}
+	}
+	for _, name := range expiredStateSnapshots(fileNames, w.retention) {
+		if err := os.Remove(filepath.Join(w.dir, name)); err != nil {
+			klog.Warningf("Failed to remove expired cluster state snapshot %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// expiredStateSnapshots returns the names of the oldest snapshots exceeding the retention.
+func expiredStateSnapshots(names []string, retention int) []string {
+	if len(names) <= retention {
+		return nil
+	}
+	sort.Strings(names)
+	return names[:len(names)-retention]
+}
+
+// oversizedStateSnapshots returns the keys of the oldest snapshots to remove to keep their total size
+// within maxSize. The most recent snapshot is always kept.
+func oversizedStateSnapshots(snapshots map[string]string, maxSize int) []string {
+	names := keys(snapshots)
+	sort.Strings(names)
+	size := 0
+	for i := len(names) - 1; i >= 0; i-- {
+		size += len(names[i]) + len(snapshots[names[i]])
+		if size > maxSize && i < len(names)-1 {
+			return names[:i+1]
+		}
+	}
+	return nil
+}
+
+func keys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMapStateSnapshotWriter(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	writer := NewStateSnapshotWriter(client, "kube-system", "snapshots", "", 2, time.Hour)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		snapshot := &api.ClusterStateSnapshot{
+			Healthy:    true,
+			NodeGroups: []api.NodeGroupStateSnapshot{{Name: "ng1", AcceptableRange: api.AcceptableRange{CurrentTarget: i}}},
+		}
+		assert.NoError(t, writer.Write(snapshot, start.Add(time.Duration(i)*time.Minute)))
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "snapshots", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, configMap.Data, 2)
+	assert.NotContains(t, configMap.Data, "20240101-000000.000000000")
+	var snapshot api.ClusterStateSnapshot
+	assert.NoError(t, yaml.Unmarshal([]byte(configMap.Data["20240101-000200.000000000"]), &snapshot))
+	assert.Equal(t, 2, snapshot.NodeGroups[0].AcceptableRange.CurrentTarget)
+	assert.Contains(t, configMap.Annotations, ConfigMapLastUpdatedKey)
+
+	// The ConfigMap is only read before the first write.
+	gets := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "get" {
+			gets++
+		}
+	}
+	assert.Equal(t, 2, gets, "one read by the writer, one by the test")
+}
+
+func TestChangedStateSnapshotWriter(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	writer := NewStateSnapshotWriter(client, "kube-system", "snapshots", "", 10, 10*time.Minute)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	healthy := &api.ClusterStateSnapshot{Healthy: true}
+
+	assert.NoError(t, writer.Write(healthy, start))
+	assert.NoError(t, writer.Write(healthy, start.Add(time.Minute)))
+	assert.NoError(t, writer.Write(&api.ClusterStateSnapshot{Healthy: false}, start.Add(2*time.Minute)))
+	assert.NoError(t, writer.Write(&api.ClusterStateSnapshot{Healthy: false}, start.Add(3*time.Minute)))
+	assert.NoError(t, writer.Write(&api.ClusterStateSnapshot{Healthy: false}, start.Add(13*time.Minute)))
+
+	configMap, err := client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "snapshots", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"20240101-000000.000000000",
+		"20240101-000200.000000000",
+		"20240101-001300.000000000",
+	}, keys(configMap.Data))
+}
+
+func TestMarshalStateSnapshot(t *testing.T) {
+	snapshot := &api.ClusterStateSnapshot{Healthy: true}
+	for i := 0; i < 100; i++ {
+		snapshot.NodeGroups = append(snapshot.NodeGroups, api.NodeGroupStateSnapshot{Name: fmt.Sprintf("ng-%d", i), Healthy: i != 99})
+	}
+	snapshotYaml, err := marshalStateSnapshot(snapshot, 1000)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(snapshotYaml), 1000)
+	var truncated api.ClusterStateSnapshot
+	assert.NoError(t, yaml.Unmarshal(snapshotYaml, &truncated))
+	assert.Equal(t, 100, len(truncated.NodeGroups)+truncated.TruncatedNodeGroups)
+	assert.Equal(t, "ng-99", truncated.NodeGroups[0].Name, "unhealthy node group kept")
+	assert.Equal(t, "ng-0", truncated.NodeGroups[1].Name)
+
+	// Snapshots fitting in the size limit aren't truncated.
+	snapshotYaml, err = marshalStateSnapshot(snapshot, 1<<20)
+	assert.NoError(t, err)
+	var full api.ClusterStateSnapshot
+	assert.NoError(t, yaml.Unmarshal(snapshotYaml, &full))
+	assert.Len(t, full.NodeGroups, 100)
+	assert.Zero(t, full.TruncatedNodeGroups)
+}
+
+func TestOversizedStateSnapshots(t *testing.T) {
+	snapshots := map[string]string{"1": "aaaa", "2": "bbbb", "3": "cccc"}
+	assert.Empty(t, oversizedStateSnapshots(snapshots, 15))
+	assert.Equal(t, []string{"1"}, oversizedStateSnapshots(snapshots, 10))
+	assert.Equal(t, []string{"1", "2"}, oversizedStateSnapshots(snapshots, 5))
+	assert.Equal(t, []string{"1", "2"}, oversizedStateSnapshots(snapshots, 1), "the most recent snapshot is kept")
+}
+
+func TestFileStateSnapshotWriter(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(dir+"/other.yaml", []byte{}, 0644))
+	writer := NewStateSnapshotWriter(nil, "", "", dir, 2, 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, writer.Write(&api.ClusterStateSnapshot{Healthy: true}, start.Add(time.Duration(i)*time.Second)))
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"cluster-state-20240101-000001.000000000.yaml",
+		"cluster-state-20240101-000002.000000000.yaml",
+		"other.yaml",
+	}, names)
+}
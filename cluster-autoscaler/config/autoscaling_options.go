@@ -34,6 +34,15 @@ type GpuLimits struct {
 	Max int64
 }
 
+// DomainNodeLimits define upper bound on number of nodes in each domain of a node label,
+// e.g. in each zone
+type DomainNodeLimits struct {
+	// Label whose values define the domains (e.g. topology.kubernetes.io/zone)
+	Label string
+	// Upper bound on number of nodes in each domain
+	Max int
+}
+
 // NodeGroupAutoscalingOptions contain various options to customize how autoscaling of
 // a given NodeGroup works. Different options can be used for each NodeGroup.
 type NodeGroupAutoscalingOptions struct {
@@ -66,6 +75,9 @@ type GCEOptions struct {
 	DomainUrl string
 	// LocalSSDDiskSizeProvider provides local ssd disk size based on machine type
 	LocalSSDDiskSizeProvider gce_localssdsize.LocalSSDSizeProvider
+	// PricingCatalogRefreshInterval is how often prices are refreshed from the Cloud Billing Catalog API.
+	// If 0, the static price list is used.
+	PricingCatalogRefreshInterval time.Duration
 }
 
 const (
@@ -96,6 +108,48 @@ func NewDefaultNodeGroupDifferenceRatios() NodeGroupDifferenceRatios {
 	}
 }
 
+// ProportionalWorkload is a deployment whose replicas are scaled proportionally to the size of the
+// cluster, e.g. by cluster-proportional-autoscaler in linear mode. Removing a node shrinks it to
+// max(ceil(cores / CoresPerReplica), ceil(nodes / NodesPerReplica), Min) replicas.
+type ProportionalWorkload struct {
+	// Namespace of the deployment.
+	Namespace string
+	// Deployment is the name of the deployment.
+	Deployment string
+	// NodesPerReplica is the number of nodes per replica, zero if not scaled by nodes.
+	NodesPerReplica float64
+	// CoresPerReplica is the number of cores per replica, zero if not scaled by cores.
+	CoresPerReplica float64
+	// Min is the minimum number of replicas.
+	Min int
+}
+
+// NodeDeletionVetoWebhookConfig configures the webhook called before nodes are deleted by scale-down,
+// which can veto the deletion of a node.
+type NodeDeletionVetoWebhookConfig struct {
+	// URL of the webhook. Empty if no webhook is called.
+	URL string
+	// Timeout of a webhook call, including reading its response.
+	Timeout time.Duration
+	// CacheTTL is how long the verdict of the webhook on a node is reused, as long as the deletion
+	// of the node doesn't change. Zero disables the cache.
+	CacheTTL time.Duration
+	// IgnoreFailures allows deleting a node if the webhook call fails, otherwise the deletion is vetoed.
+	IgnoreFailures bool
+}
+
+// GracefulTerminationOverride overrides the maximum number of seconds scale down waits for
+// the pods of a namespace or of a priority class to terminate. Exactly one of Namespace and
+// PriorityClassName is set.
+type GracefulTerminationOverride struct {
+	// Namespace of the pods the override applies to.
+	Namespace string
+	// PriorityClassName of the pods the override applies to.
+	PriorityClassName string
+	// MaxGracefulTerminationSec is the maximum number of seconds the pods are given to terminate.
+	MaxGracefulTerminationSec int
+}
+
 // AutoscalingOptions contain various options to customize how autoscaling works
 type AutoscalingOptions struct {
 	// NodeGroupDefaults are default values for per NodeGroup options.
@@ -105,6 +159,9 @@ type AutoscalingOptions struct {
 	MaxEmptyBulkDelete int
 	// MaxNodesTotal sets the maximum number of nodes in the whole cluster
 	MaxNodesTotal int
+	// MaxNodesPerLabelDomain sets the maximum number of nodes in each domain of node labels,
+	// e.g. in each zone. Scale-ups in domains which reached their limit are skipped.
+	MaxNodesPerLabelDomain []DomainNodeLimits
 	// MaxCoresTotal sets the maximum number of cores in the whole cluster
 	MaxCoresTotal int64
 	// MinCoresTotal sets the minimum number of cores in the whole cluster
@@ -125,6 +182,9 @@ type AutoscalingOptions struct {
 	GRPCExpanderCert string
 	// GRPCExpanderURL is the url of the gRPC server when using the gRPC expander
 	GRPCExpanderURL string
+	// ExpanderPodPreferencePolicy is how the expander honors the preferred node groups annotations of pending pods:
+	// none, bonus or exclusive.
+	ExpanderPodPreferencePolicy string
 	// IgnoreMirrorPodsUtilization is whether CA will ignore Mirror pods when calculating resource utilization for scaling down
 	IgnoreMirrorPodsUtilization bool
 	// MaxGracefulTerminationSec is maximum number of seconds scale down waits for pods to terminate before
@@ -135,12 +195,24 @@ type AutoscalingOptions struct {
 	// This field is optional and could be nil.
 	// DrainPriorityConfig takes higher precedence and MaxGracefulTerminationSec will not be applicable when the DrainPriorityConfig is set.
 	DrainPriorityConfig []kubelet_config.ShutdownGracePeriodByPodPriority
+	// MaxGracefulTerminationOverrides override the maximum graceful termination, from MaxGracefulTerminationSec
+	// or DrainPriorityConfig, for the pods of some namespaces or priority classes. Namespace overrides take
+	// precedence over priority class overrides.
+	MaxGracefulTerminationOverrides []GracefulTerminationOverride
+	// ProportionalWorkloads are deployments scaled proportionally to the cluster size. Scale-down anticipates
+	// their shrink, so that their surplus replicas don't need to fit on the remaining nodes.
+	ProportionalWorkloads []ProportionalWorkload
+	// NodeDeletionVetoWebhook is the webhook which can veto node deletions of scale-down.
+	NodeDeletionVetoWebhook NodeDeletionVetoWebhookConfig
 	// MaxTotalUnreadyPercentage is the maximum percentage of unready nodes after which CA halts operations
 	MaxTotalUnreadyPercentage float64
 	// OkTotalUnreadyCount is the number of allowed unready nodes, irrespective of max-total-unready-percentage
 	OkTotalUnreadyCount int
 	// ScaleUpFromZero defines if CA should scale up when there 0 ready nodes.
 	ScaleUpFromZero bool
+	// APIServerDegradedMode defines whether CA suspends actuation while the API server is degraded,
+	// until its informers have resynced, and keeps running when it loses its leader lease.
+	APIServerDegradedMode bool
 	// ParallelScaleUp defines whether CA can scale up node groups in parallel.
 	ParallelScaleUp bool
 	// CloudConfig is the path to the cloud provider configuration file. Empty string for no configuration file.
@@ -161,9 +233,57 @@ type AutoscalingOptions struct {
 	ScaleDownDelayAfterDelete time.Duration
 	// ScaleDownDelayAfterFailure sets the duration before the next scale down attempt if scale down results in an error
 	ScaleDownDelayAfterFailure time.Duration
+	// MaxPodScaleDownUnneededTime is the maximum unneeded time pods can request for their nodes
+	// through the scale-down-unneeded-time annotation. 0 disables the annotation.
+	MaxPodScaleDownUnneededTime time.Duration
 	// ScaleDownDelayTypeLocal sets if the --scale-down-delay-after-* flags should be applied locally per nodegroup
 	// or globally across all nodegroups
 	ScaleDownDelayTypeLocal bool
+	// ScaleDownCoordination sets if unneeded nodes should be published in node annotations and
+	// the remaining pods should be evicted from nearly empty unneeded nodes.
+	ScaleDownCoordination bool
+	// ScaleDownCompactionMaxNodes is the maximum number of unneeded nodes pods are evicted from in a loop.
+	ScaleDownCompactionMaxNodes int
+	// ScaleDownCompactionMaxPods is the maximum number of pods on an unneeded node for it to have its pods evicted.
+	ScaleDownCompactionMaxPods int
+	// ScaleDownCompactionDelay is how long a node should be unneeded before pods are evicted from it.
+	ScaleDownCompactionDelay time.Duration
+	// ScaleDownGpuBusyPodAnnotation is the pod annotation which, set to "true", marks a GPU job in progress.
+	// GPU nodes running such pods are not scaled down. Empty disables the check.
+	ScaleDownGpuBusyPodAnnotation string
+	// ScaleDownGpuBusyNodeCondition is the node condition type which, when true, marks a GPU job in progress
+	// on the node. GPU nodes with such condition are not scaled down. Empty disables the check.
+	ScaleDownGpuBusyNodeCondition string
+	// ScaleDownWaitForMigReconfig sets if GPU nodes whose NVIDIA MIG configuration is being changed
+	// by the MIG manager should be kept from scale down.
+	ScaleDownWaitForMigReconfig bool
+	// ScaleDownUpgradeNodeAnnotations are node annotations, in the form of <key> or <key>=<value>,
+	// marking nodes being upgraded, which are kept from scale down.
+	ScaleDownUpgradeNodeAnnotations []string
+	// ScaleDownUpgradeNodeConditions are node condition types which, when true, mark nodes being
+	// upgraded, which are kept from scale down.
+	ScaleDownUpgradeNodeConditions []string
+	// ScaleDownUpgradeWindow is how long after an upgrade started a node is kept from scale down.
+	// 0 keeps it for as long as it is marked as being upgraded.
+	ScaleDownUpgradeWindow time.Duration
+	// ScaleDownMakeRoom sets if node groups should be scaled up by a few nodes when the pods of underutilized
+	// nodes have no place to be moved to, if fewer new nodes than these underutilized nodes can take their pods.
+	ScaleDownMakeRoom bool
+	// ScaleDownMakeRoomMaxNodes is the maximum number of nodes added at once to make room for pods.
+	ScaleDownMakeRoomMaxNodes int
+	// LearnNodeProvisionTime sets if MaxNodeProvisionTime of node groups not overriding it
+	// should be learned from their recent scale-ups.
+	LearnNodeProvisionTime bool
+	// MinLearnedNodeProvisionTime is the lower bound of a learned MaxNodeProvisionTime.
+	MinLearnedNodeProvisionTime time.Duration
+	// MaxLearnedNodeProvisionTime is the upper bound of a learned MaxNodeProvisionTime.
+	MaxLearnedNodeProvisionTime time.Duration
+	// DefragmentationReportInterval is how often the number of nodes which could be freed by
+	// repacking pods is reported. 0 disables the report.
+	DefragmentationReportInterval time.Duration
+	// LintNodeGroupTemplates enables linting of node group templates, reporting misconfigured
+	// node groups in metrics and events.
+	LintNodeGroupTemplates bool
 	// ScaleDownNonEmptyCandidatesCount is the maximum number of non empty nodes
 	// considered at once as candidates for scale down.
 	ScaleDownNonEmptyCandidatesCount int
@@ -180,6 +300,10 @@ type AutoscalingOptions struct {
 	// ScaleDownSimulationTimeout defines the maximum time that can be
 	// spent on scale down simulation.
 	ScaleDownSimulationTimeout time.Duration
+	// ScaleDownSimulationWorkers is the number of workers checking concurrently
+	// whether the pods of scale down candidates can be moved elsewhere. 1 or less
+	// means the candidates are only checked sequentially.
+	ScaleDownSimulationWorkers int
 	// SchedulerConfig allows changing configuration of in-tree
 	// scheduler plugins acting on PreFilter and Filter extension points
 	SchedulerConfig *scheduler_config.KubeSchedulerConfiguration
@@ -189,6 +313,20 @@ type AutoscalingOptions struct {
 	WriteStatusConfigMap bool
 	// StaticConfigMapName
 	StatusConfigMapName string
+	// ClusterStateSnapshotRetention is the number of the most recent cluster state snapshots
+	// persisted for post-mortem analysis. 0 disables the snapshots.
+	ClusterStateSnapshotRetention int
+	// ClusterStateSnapshotInterval is the maximum time between two persisted cluster state snapshots.
+	// Snapshots are persisted more often only when the cluster state changes.
+	ClusterStateSnapshotInterval time.Duration
+	// ClusterStateSnapshotConfigMap is the name of the ConfigMap holding the cluster state snapshots.
+	ClusterStateSnapshotConfigMap string
+	// ClusterStateSnapshotDir is the directory the cluster state snapshots are written to instead of the ConfigMap, if set.
+	ClusterStateSnapshotDir string
+	// NotTriggerScaleUpEventWindow is the minimum time between two NotTriggerScaleUp events emitted for the
+	// pods of the same controller, e.g. a ReplicaSet or a Job, which are aggregated in a single event on the controller.
+	// Zero emits an event for each pod.
+	NotTriggerScaleUpEventWindow time.Duration
 	// BalanceSimilarNodeGroups enables logic that identifies node groups with similar machines and tries to balance node count between them.
 	BalanceSimilarNodeGroups bool
 	// ConfigNamespace is the namespace cluster-autoscaler is running in and all related configmaps live in
@@ -201,9 +339,33 @@ type AutoscalingOptions struct {
 	MaxAutoprovisionedNodeGroupCount int
 	// UnremovableNodeRecheckTimeout is the timeout before we check again a node that couldn't be removed before
 	UnremovableNodeRecheckTimeout time.Duration
+	// UninitializedNodeRemovalTime is the time after which a node still tainted by the cloud provider as uninitialized
+	// is deleted together with its backing instance. Zero disables the removal of uninitialized nodes.
+	UninitializedNodeRemovalTime time.Duration
+	// MaxUninitializedNodesRemoved is the maximum number of uninitialized nodes removed from a single
+	// node group in one loop.
+	MaxUninitializedNodesRemoved int
+	// BrokenNodeReplacementTime is the time after which an unready node of a node group at its min size is
+	// replaced: a new node is requested first, and the unready node is deleted once the new one is ready.
+	// Zero disables the replacement of unready nodes.
+	BrokenNodeReplacementTime time.Duration
 	// Pods with priority below cutoff are expendable. They can be killed without any consideration during scale down and they don't cause scale-up.
 	// Pods with null priority (PodPriority disabled) are non-expendable.
 	ExpendablePodsPriorityCutoff int
+	// BalloonPodPriorityClass is the priority class of balloon pods, placeholder pods keeping
+	// headroom in the cluster. Balloon pods never block scale-down and only trigger scale-up
+	// up to BalloonHeadroomPods. Empty if balloon pods aren't recognized.
+	BalloonPodPriorityClass string
+	// BalloonHeadroomPods is the number of balloon pods the cluster should have room for.
+	BalloonHeadroomPods int
+	// BalloonHeadroomController tells if CA manages a deployment of BalloonHeadroomPods balloon pods.
+	BalloonHeadroomController bool
+	// BalloonPodNamespace is the namespace of the balloon pods deployment managed by CA.
+	BalloonPodNamespace string
+	// BalloonPodCPU is the CPU request of balloon pods managed by CA, e.g. "500m".
+	BalloonPodCPU string
+	// BalloonPodMemory is the memory request of balloon pods managed by CA, e.g. "1Gi".
+	BalloonPodMemory string
 	// Regional tells whether the cluster is regional.
 	Regional bool
 	// Pods newer than this will not be considered as unschedulable for scale-up.
@@ -213,8 +375,37 @@ type AutoscalingOptions struct {
 	MaxBulkSoftTaintCount int
 	// MaxBulkSoftTaintTime sets the maximum duration of single run of PreferNoSchedule tainting.
 	MaxBulkSoftTaintTime time.Duration
+	// DeletionCandidateTaintTTL is the age after which DeletionCandidate taints left by a previous run of CA are removed on startup.
+	// Value of 0 keeps such taints.
+	DeletionCandidateTaintTTL time.Duration
+	// StartupTaintCleanupParallelism is the number of nodes taints left by a previous run of CA are removed from
+	// at the same time on startup.
+	StartupTaintCleanupParallelism int
+	// StartupTaintCleanupQPS is the maximum number of nodes per second taints left by a previous run of CA are
+	// removed from on startup. Value of 0 turns off the limit.
+	StartupTaintCleanupQPS float64
+	// CleanSoftTaintsOnScaleDownAbort removes PreferNoSchedule taints in bulk, within the MaxBulkSoftTaint limits,
+	// when scale-down is abandoned in a loop, e.g. because pending pods triggered a scale-up.
+	CleanSoftTaintsOnScaleDownAbort bool
+	// ScaleUpQuotas limit nodes provisioned for pods from a namespace or matching a label selector,
+	// see scaleupquota.ParseQuota for the format.
+	ScaleUpQuotas []string
+	// ScaleUpQuotaWindow is the sliding window in which usage of ScaleUpQuotas is counted.
+	ScaleUpQuotaWindow time.Duration
+	// PackingHintsMaxPods is the maximum number of pods annotated with the node group each was
+	// planned onto after each scale-up. Zero disables packing hints.
+	PackingHintsMaxPods int
+	// EstimationFeedbackEnabled makes CA evaluate how many of the nodes added by scale-ups were actually
+	// needed and correct the node counts estimated for each node group accordingly.
+	EstimationFeedbackEnabled bool
+	// EstimationFeedbackSettleTime is the time after a scale-up at which the nodes it actually needed are counted.
+	EstimationFeedbackSettleTime time.Duration
 	// MaxPodEvictionTime sets the maximum time CA tries to evict a pod before giving up.
 	MaxPodEvictionTime time.Duration
+	// UnreadyNodePdbOverrideTimeout is the time after which PDBs with zero disruptions allowed
+	// stop blocking scale-down of unready nodes. Pods blocked by such PDBs are then deleted
+	// instead of evicted. Zero means PDBs are always respected.
+	UnreadyNodePdbOverrideTimeout time.Duration
 	// StartupTaints is a list of taints CA considers to reflect transient node
 	// status that should be removed when creating a node template for scheduling.
 	// startup taints are expected to appear during node startup.
@@ -231,6 +422,17 @@ type AutoscalingOptions struct {
 	BalancingLabels []string
 	// AWSUseStaticInstanceList tells if AWS cloud provider use static instance type list or dynamically fetch from remote APIs.
 	AWSUseStaticInstanceList bool
+	// AWSUseExemplarNodeTemplates tells if AWS cloud provider builds node templates based on live nodes of the ASG when available.
+	AWSUseExemplarNodeTemplates bool
+	// AWSUseENIMaxPods tells if AWS cloud provider derives the pod capacity of node templates from
+	// the ENI and IPv4 address limits of the instance type, as the Amazon VPC CNI does.
+	AWSUseENIMaxPods bool
+	// AWSVPCCNIPrefixDelegation tells if the Amazon VPC CNI runs with prefix delegation enabled.
+	// Only used together with AWSUseENIMaxPods.
+	AWSVPCCNIPrefixDelegation bool
+	// AWSExtendedZonesRequireZoneSelection tells if ASGs in Local Zones or Wavelength Zones are only scaled up
+	// for pods explicitly requiring their zone.
+	AWSExtendedZonesRequireZoneSelection bool
 	// GCEOptions contain autoscaling options specific to GCE cloud provider.
 	GCEOptions GCEOptions
 	// KubeClientOpts specify options for kube client
@@ -292,6 +494,17 @@ type AutoscalingOptions struct {
 	BypassedSchedulers map[string]bool
 	// ProvisioningRequestEnabled tells if CA processes ProvisioningRequest.
 	ProvisioningRequestEnabled bool
+	// KueueIntegrationEnabled tells if CA provisions capacity for Kueue Workloads waiting for admission
+	// with ProvisioningRequests. Requires ProvisioningRequestEnabled.
+	KueueIntegrationEnabled bool
+	// KueueProvisioningClassName is the class of the ProvisioningRequests created for Kueue Workloads.
+	KueueProvisioningClassName string
+	// KueueAdmissionCheckName is the name of the Kueue admission check managed by CA. Once the capacity
+	// for a Workload is provisioned, the check annotates its pods with the ProvisioningRequest they consume.
+	KueueAdmissionCheckName string
+	// ReserveNodeGroups is a list of regular expressions matching ids of node groups used as reserve capacity.
+	// Reserve node groups are scaled up only if no other node group can accommodate pending pods and are excluded from scale-down.
+	ReserveNodeGroups []string
 }
 
 // KubeClientOptions specify options for kube client
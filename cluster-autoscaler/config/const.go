@@ -43,6 +43,8 @@ const (
 
 	// DefaultScaleDownUnneededTime is the default time duration for which CA waits before deleting an unneeded node
 	DefaultScaleDownUnneededTime = 10 * time.Minute
+	// DefaultMaxPodScaleDownUnneededTime is the default maximum unneeded time pods can request for their nodes
+	DefaultMaxPodScaleDownUnneededTime = 24 * time.Hour
 	// DefaultScaleDownUnreadyTime identifies ScaleDownUnreadyTime autoscaling option
 	DefaultScaleDownUnreadyTime = 20 * time.Minute
 	// DefaultScaleDownUtilizationThreshold identifies ScaleDownUtilizationThreshold autoscaling option
@@ -24,7 +24,6 @@ import (
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup"
 	"k8s.io/autoscaler/cluster-autoscaler/debuggingsnapshot"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
@@ -38,6 +37,8 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/predicatechecker"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/client-go/informers"
 	kube_client "k8s.io/client-go/kubernetes"
 )
@@ -46,13 +47,16 @@ import (
 type AutoscalerOptions struct {
 	config.AutoscalingOptions
 	KubeClient             kube_client.Interface
+	APIServerHealth        *kube_util.APIServerHealth
 	InformerFactory        informers.SharedInformerFactory
 	AutoscalingKubeClients *context.AutoscalingKubeClients
 	CloudProvider          cloudprovider.CloudProvider
 	PredicateChecker       predicatechecker.PredicateChecker
 	ClusterSnapshot        clustersnapshot.ClusterSnapshot
 	ExpanderStrategy       expander.Strategy
+	ExpanderPreFilters     []expander.Filter
 	EstimatorBuilder       estimator.EstimatorBuilder
+	EstimationCorrection   estimator.CorrectionFactors
 	Processors             *ca_processors.AutoscalingProcessors
 	LoopStartNotifier      *loopstart.ObserversList
 	Backoff                backoff.Backoff
@@ -113,6 +117,7 @@ func initializeDefaultOptions(opts *AutoscalerOptions, informerFactory informers
 	}
 	if opts.AutoscalingKubeClients == nil {
 		opts.AutoscalingKubeClients = context.NewAutoscalingKubeClients(opts.AutoscalingOptions, opts.KubeClient, opts.InformerFactory)
+		opts.AutoscalingKubeClients.APIServerHealth = opts.APIServerHealth
 	}
 	if opts.ClusterSnapshot == nil {
 		opts.ClusterSnapshot = clustersnapshot.NewBasicClusterSnapshot()
@@ -126,7 +131,10 @@ func initializeDefaultOptions(opts *AutoscalerOptions, informerFactory informers
 	if opts.ExpanderStrategy == nil {
 		expanderFactory := factory.NewFactory()
 		expanderFactory.RegisterDefaultExpanders(opts.CloudProvider, opts.AutoscalingKubeClients, opts.KubeClient, opts.ConfigNamespace, opts.GRPCExpanderCert, opts.GRPCExpanderURL)
-		expanderStrategy, err := expanderFactory.Build(strings.Split(opts.ExpanderNames, ","))
+		for _, filter := range opts.ExpanderPreFilters {
+			expanderFactory.AddPreFilter(filter)
+		}
+		expanderStrategy, err := expanderFactory.BuildWithPodPreference(strings.Split(opts.ExpanderNames, ","), opts.ExpanderPodPreferencePolicy)
 		if err != nil {
 			return err
 		}
@@ -148,10 +156,14 @@ func initializeDefaultOptions(opts *AutoscalerOptions, informerFactory informers
 			return err
 		}
 		opts.EstimatorBuilder = estimatorBuilder
+		if opts.EstimationCorrection != nil {
+			opts.EstimatorBuilder = estimator.NewCorrectedEstimatorBuilder(opts.EstimatorBuilder, opts.EstimationCorrection)
+		}
 	}
 	if opts.Backoff == nil {
 		opts.Backoff =
-			backoff.NewIdBasedExponentialBackoff(opts.InitialNodeGroupBackoffDuration, opts.MaxNodeGroupBackoffDuration, opts.NodeGroupBackoffResetTimeout)
+			backoff.NewIdBasedExponentialBackoffWithErrorKindPolicies(opts.InitialNodeGroupBackoffDuration, opts.MaxNodeGroupBackoffDuration, opts.NodeGroupBackoffResetTimeout,
+				backoff.DefaultErrorKindPolicies(opts.InitialNodeGroupBackoffDuration))
 	}
 	if opts.DrainabilityRules == nil {
 		opts.DrainabilityRules = rules.Default(opts.DeleteOptions)
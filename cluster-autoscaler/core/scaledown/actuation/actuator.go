@@ -26,7 +26,6 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/budgets"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/deletiontracker"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/status"
 	"k8s.io/autoscaler/cluster-autoscaler/core/utils"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
@@ -39,6 +38,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/expiring"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
 	"k8s.io/klog/v2"
 )
@@ -76,9 +76,9 @@ func NewActuator(ctx *context.AutoscalingContext, scaleStateNotifier nodegroupch
 	legacyFlagDrainConfig := SingleRuleDrainConfig(ctx.MaxGracefulTerminationSec)
 	var evictor Evictor
 	if len(ctx.DrainPriorityConfig) > 0 {
-		evictor = NewEvictor(ndt, ctx.DrainPriorityConfig, true)
+		evictor = NewEvictor(ndt, ctx.DrainPriorityConfig, true, ctx.MaxGracefulTerminationOverrides)
 	} else {
-		evictor = NewEvictor(ndt, legacyFlagDrainConfig, false)
+		evictor = NewEvictor(ndt, legacyFlagDrainConfig, false, ctx.MaxGracefulTerminationOverrides)
 	}
 	return &Actuator{
 		ctx:                       ctx,
@@ -167,7 +167,7 @@ func (a *Actuator) deleteAsyncEmpty(NodeGroupViews []*budgets.NodeGroupView, nod
 	}
 
 	for _, bucket := range NodeGroupViews {
-		go a.deleteNodesAsync(bucket.Nodes, bucket.Group, false, bucket.BatchSize, nodeDeleteDelayAfterTaint)
+		go a.deleteNodesAsync(bucket.Nodes, bucket.Group, false, bucket.BatchSize, nodeDeleteDelayAfterTaint, nil)
 	}
 
 	return reportedSDNodes
@@ -221,9 +221,11 @@ func (a *Actuator) taintNodesSync(NodeGroupViews []*budgets.NodeGroupView) (time
 // deleteAsyncDrain asynchronously starts deletions with drain for all provided nodes. scaledDownNodes return value contains all nodes for which
 // deletion successfully started.
 func (a *Actuator) deleteAsyncDrain(NodeGroupViews []*budgets.NodeGroupView, nodeDeleteDelayAfterTaint time.Duration) (reportedSDNodes []*status.ScaleDownNode) {
+	surplusPods := a.proportionalSurplusPods(NodeGroupViews)
 	for _, bucket := range NodeGroupViews {
 		for _, drainNode := range bucket.Nodes {
 			if sdNode, err := a.scaleDownNodeToReport(drainNode, true); err == nil {
+				sdNode.EvictedPods = filterOutPods(sdNode.EvictedPods, surplusPods)
 				klog.V(0).Infof("Scale-down: removing node %s, utilization: %v, pods to reschedule: %s", drainNode.Name, sdNode.UtilInfo, joinPodNames(sdNode.EvictedPods))
 				a.ctx.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaleDown", "Scale-down: removing node %s, utilization: %v, pods to reschedule: %s", drainNode.Name, sdNode.UtilInfo, joinPodNames(sdNode.EvictedPods))
 				reportedSDNodes = append(reportedSDNodes, sdNode)
@@ -236,13 +238,33 @@ func (a *Actuator) deleteAsyncDrain(NodeGroupViews []*budgets.NodeGroupView, nod
 	}
 
 	for _, bucket := range NodeGroupViews {
-		go a.deleteNodesAsync(bucket.Nodes, bucket.Group, true, bucket.BatchSize, nodeDeleteDelayAfterTaint)
+		go a.deleteNodesAsync(bucket.Nodes, bucket.Group, true, bucket.BatchSize, nodeDeleteDelayAfterTaint, surplusPods)
 	}
 
 	return reportedSDNodes
 }
 
-func (a *Actuator) deleteNodesAsync(nodes []*apiv1.Node, nodeGroup cloudprovider.NodeGroup, drain bool, batchSize int, nodeDeleteDelayAfterTaint time.Duration) {
+// proportionalSurplusPods returns the replicas of proportional workloads, running on the nodes to drain, which are
+// beyond the share of their workloads for the remaining cluster, keyed by namespace and name. They aren't evicted,
+// but go away along with the nodes, so that draining disrupts the workloads only by their share.
+func (a *Actuator) proportionalSurplusPods(NodeGroupViews []*budgets.NodeGroupView) map[string]bool {
+	var nodes []*apiv1.Node
+	for _, bucket := range NodeGroupViews {
+		nodes = append(nodes, bucket.Nodes...)
+	}
+	pods, err := simulator.ProportionalSurplusPods(a.ctx.ClusterSnapshot, a.deleteOptions.ProportionalWorkloads, nodes)
+	if err != nil {
+		klog.Errorf("Scale-down: couldn't find surplus replicas of proportional workloads, evicting them, err: %v", err)
+		return nil
+	}
+	surplusPods := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		surplusPods[podKey(pod)] = true
+	}
+	return surplusPods
+}
+
+func (a *Actuator) deleteNodesAsync(nodes []*apiv1.Node, nodeGroup cloudprovider.NodeGroup, drain bool, batchSize int, nodeDeleteDelayAfterTaint time.Duration, skippedPods map[string]bool) {
 	var remainingPdbTracker pdb.RemainingPdbTracker
 	var registry kube_util.ListerRegistry
 
@@ -255,7 +277,7 @@ func (a *Actuator) deleteNodesAsync(nodes []*apiv1.Node, nodeGroup cloudprovider
 		time.Sleep(nodeDeleteDelayAfterTaint)
 	}
 
-	clusterSnapshot, err := a.createSnapshot(nodes)
+	clusterSnapshot, err := a.createSnapshot(nodes, skippedPods)
 	if err != nil {
 		klog.Errorf("Scale-down: couldn't create delete snapshot, err: %v", err)
 		nodeDeleteResult := status.NodeDeleteResult{ResultType: status.NodeDeleteErrorInternal, Err: errors.NewAutoscalerError(errors.InternalError, "createSnapshot returned error %v", err)}
@@ -355,7 +377,8 @@ func (a *Actuator) taintNode(node *apiv1.Node) error {
 	return nil
 }
 
-func (a *Actuator) createSnapshot(nodes []*apiv1.Node) (clustersnapshot.ClusterSnapshot, error) {
+// createSnapshot returns a snapshot of the nodes and the pods running on them, except for skippedPods, which aren't drained.
+func (a *Actuator) createSnapshot(nodes []*apiv1.Node, skippedPods map[string]bool) (clustersnapshot.ClusterSnapshot, error) {
 	knownNodes := make(map[string]bool)
 	snapshot := clustersnapshot.NewBasicClusterSnapshot()
 	pods, err := a.ctx.AllPodLister().List()
@@ -375,7 +398,7 @@ func (a *Actuator) createSnapshot(nodes []*apiv1.Node) (clustersnapshot.ClusterS
 	}
 
 	for _, pod := range nonExpendableScheduledPods {
-		if knownNodes[pod.Spec.NodeName] {
+		if knownNodes[pod.Spec.NodeName] && !skippedPods[podKey(pod)] {
 			if err := snapshot.AddPod(pod, pod.Spec.NodeName); err != nil {
 				return nil, err
 			}
@@ -385,6 +408,23 @@ func (a *Actuator) createSnapshot(nodes []*apiv1.Node) (clustersnapshot.ClusterS
 	return snapshot, nil
 }
 
+func filterOutPods(pods []*apiv1.Pod, skippedPods map[string]bool) []*apiv1.Pod {
+	if len(skippedPods) == 0 {
+		return pods
+	}
+	var result []*apiv1.Pod
+	for _, pod := range pods {
+		if !skippedPods[podKey(pod)] {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
+func podKey(pod *apiv1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
 func joinPodNames(pods []*apiv1.Pod) string {
 	var names []string
 	for _, pod := range pods {
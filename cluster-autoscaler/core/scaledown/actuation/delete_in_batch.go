@@ -17,6 +17,7 @@ limitations under the License.
 package actuation
 
 import (
+	goerrors "errors"
 	"fmt"
 	"reflect"
 	"sync"
@@ -88,7 +89,7 @@ func (d *NodeDeletionBatcher) AddNodes(nodes []*apiv1.Node, nodeGroup cloudprovi
 func (d *NodeDeletionBatcher) deleteNodesAndRegisterStatus(nodes []*apiv1.Node, nodeGroupId string, drain bool) {
 	nodeGroup, err := deleteNodesFromCloudProvider(d.ctx, d.scaleStateNotifier, nodes)
 	for _, node := range nodes {
-		if err != nil {
+		if err := nodeDeleteError(err, node); err != nil {
 			result := status.NodeDeleteResult{ResultType: status.NodeDeleteErrorFailedToDelete, Err: err}
 			CleanUpAndRecordFailedScaleDownEvent(d.ctx, node, nodeGroupId, drain, d.nodeDeletionTracker, "", result)
 		} else {
@@ -133,7 +134,7 @@ func (d *NodeDeletionBatcher) remove(nodeGroupId string) error {
 		nodeGroup, err := deleteNodesFromCloudProvider(d.ctx, d.scaleStateNotifier, nodes)
 		for _, node := range nodes {
 			drain := drainedNodeDeletions[node.Name]
-			if err != nil {
+			if err := nodeDeleteError(err, node); err != nil {
 				result = status.NodeDeleteResult{ResultType: status.NodeDeleteErrorFailedToDelete, Err: err}
 				CleanUpAndRecordFailedScaleDownEvent(d.ctx, node, nodeGroupId, drain, d.nodeDeletionTracker, "", result)
 			} else {
@@ -154,15 +155,46 @@ func deleteNodesFromCloudProvider(ctx *context.AutoscalingContext, scaleStateNot
 	if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
 		return nil, errors.NewAutoscalerError(errors.InternalError, "picked node that doesn't belong to a node group: %s", nodes[0].Name)
 	}
+	if err := actuationSuspendedError(ctx); err != nil {
+		return nodeGroup, err
+	}
 	if err := nodeGroup.DeleteNodes(nodes); err != nil {
 		scaleStateNotifier.RegisterFailedScaleDown(nodeGroup,
 			string(errors.CloudProviderError),
 			time.Now())
+		var notDeletedErr *cloudprovider.NodesNotDeletedError
+		if goerrors.As(err, &notDeletedErr) {
+			nodeErrors := make(map[string]error, len(notDeletedErr.NodeErrors))
+			for name, nodeErr := range notDeletedErr.NodeErrors {
+				nodeErrors[name] = errors.NewAutoscalerError(errors.CloudProviderError, "failed to delete node %s from group %s: %v", name, nodeGroup.Id(), nodeErr)
+			}
+			return nodeGroup, &cloudprovider.NodesNotDeletedError{NodeErrors: nodeErrors}
+		}
 		return nodeGroup, errors.NewAutoscalerError(errors.CloudProviderError, "failed to delete nodes from group %s: %v", nodeGroup.Id(), err)
 	}
 	return nodeGroup, nil
 }
 
+// actuationSuspendedError returns an error if actuation is suspended because the API server is degraded,
+// nil otherwise. Deletions started before the API server was found degraded stop at the next step.
+func actuationSuspendedError(ctx *context.AutoscalingContext) errors.AutoscalerError {
+	if degraded, reason := ctx.APIServerHealth.Degraded(); degraded {
+		return errors.NewAutoscalerError(errors.TransientError, "actuation suspended while the API server is degraded: %s", reason)
+	}
+	return nil
+}
+
+// nodeDeleteError returns the error of deleting the node, given the error of deleting
+// the nodes it was deleted together with. Nodes missing from a NodesNotDeletedError
+// were deleted.
+func nodeDeleteError(err error, node *apiv1.Node) error {
+	var notDeletedErr *cloudprovider.NodesNotDeletedError
+	if goerrors.As(err, &notDeletedErr) {
+		return notDeletedErr.NodeErrors[node.Name]
+	}
+	return err
+}
+
 func nodeScaleDownReason(node *apiv1.Node, drain bool) metrics.NodeScaleDownReason {
 	readiness, err := kubernetes.GetNodeReadiness(node)
 	if err != nil {
@@ -18,19 +18,24 @@ package actuation
 
 import (
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/deletiontracker"
 	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
 	"k8s.io/autoscaler/cluster-autoscaler/observers/nodegroupchange"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAddNodeToBucket(t *testing.T) {
@@ -231,3 +236,54 @@ func TestRemove(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeDeleteError(t *testing.T) {
+	deleted, notDeleted := &apiv1.Node{}, &apiv1.Node{}
+	deleted.Name, notDeleted.Name = "deleted", "not-deleted"
+	nodeErr := fmt.Errorf("protected")
+
+	assert.NoError(t, nodeDeleteError(nil, deleted))
+	err := fmt.Errorf("failed")
+	assert.Equal(t, err, nodeDeleteError(err, deleted))
+
+	// Only the nodes which couldn't be deleted get an error.
+	err = &cloudprovider.NodesNotDeletedError{NodeErrors: map[string]error{notDeleted.Name: nodeErr}}
+	assert.NoError(t, nodeDeleteError(err, deleted))
+	assert.Equal(t, nodeErr, nodeDeleteError(err, notDeleted))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDeleteNodesFromCloudProviderSuspended(t *testing.T) {
+	deletedNodes := 0
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		deletedNodes++
+		return nil
+	})
+	provider.AddNodeGroup("ng", 0, 10, 1)
+	nodes := generateNodes(0, 1, "ng")
+	provider.AddNode("ng", nodes[0])
+	ctx, err := NewScaleTestAutoscalingContext(config.AutoscalingOptions{}, &fake.Clientset{}, nil, provider, nil, nil)
+	assert.NoError(t, err)
+
+	// The nodes watch fails, so the API server is degraded.
+	ctx.APIServerHealth = kube_util.NewAPIServerHealth()
+	req, err := http.NewRequest(http.MethodGet, "https://api/api/v1/nodes?watch=true", nil)
+	assert.NoError(t, err)
+	_, _ = ctx.APIServerHealth.WrapTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("connection refused")
+	})).RoundTrip(req)
+
+	_, err = deleteNodesFromCloudProvider(&ctx, nodegroupchange.NewNodeGroupChangeObserversList(), nodes)
+	assert.Error(t, err)
+	assert.Equal(t, 0, deletedNodes)
+
+	ctx.APIServerHealth = nil
+	_, err = deleteNodesFromCloudProvider(&ctx, nodegroupchange.NewNodeGroupChangeObserversList(), nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deletedNodes)
+}
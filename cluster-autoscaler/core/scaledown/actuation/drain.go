@@ -30,10 +30,12 @@ import (
 	"k8s.io/klog/v2"
 	kubelet_config "k8s.io/kubernetes/pkg/kubelet/apis/config"
 
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	acontext "k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/status"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/daemonset"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	pod_util "k8s.io/autoscaler/cluster-autoscaler/utils/pod"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 )
@@ -57,10 +59,11 @@ type Evictor struct {
 	evictionRegister                 evictionRegister
 	shutdownGracePeriodByPodPriority []kubelet_config.ShutdownGracePeriodByPodPriority
 	fullDsEviction                   bool
+	gracefulTerminationOverrides     []config.GracefulTerminationOverride
 }
 
 // NewEvictor returns an instance of Evictor.
-func NewEvictor(evictionRegister evictionRegister, shutdownGracePeriodByPodPriority []kubelet_config.ShutdownGracePeriodByPodPriority, fullDsEviction bool) Evictor {
+func NewEvictor(evictionRegister evictionRegister, shutdownGracePeriodByPodPriority []kubelet_config.ShutdownGracePeriodByPodPriority, fullDsEviction bool, gracefulTerminationOverrides []config.GracefulTerminationOverride) Evictor {
 	sort.Slice(shutdownGracePeriodByPodPriority, func(i, j int) bool {
 		return shutdownGracePeriodByPodPriority[i].Priority < shutdownGracePeriodByPodPriority[j].Priority
 	})
@@ -71,6 +74,7 @@ func NewEvictor(evictionRegister evictionRegister, shutdownGracePeriodByPodPrior
 		evictionRegister:                 evictionRegister,
 		shutdownGracePeriodByPodPriority: shutdownGracePeriodByPodPriority,
 		fullDsEviction:                   fullDsEviction,
+		gracefulTerminationOverrides:     gracefulTerminationOverrides,
 	}
 }
 
@@ -122,8 +126,9 @@ func (e Evictor) drainNodeWithPodsBasedOnPodPriority(ctx *acontext.AutoscalingCo
 			return evictionResults, err
 		}
 
-		// Evictions created successfully, wait ShutdownGracePeriodSeconds + podEvictionHeadroom to see if fullEviction pods really disappeared.
-		evictionResults, err = e.waitPodsToDisappear(ctx, node, group.FullEvictionPods, evictionResults, group.ShutdownGracePeriodSeconds)
+		// Evictions created successfully, wait ShutdownGracePeriodSeconds (or the longest override of the pods) + podEvictionHeadroom
+		// to see if fullEviction pods really disappeared.
+		evictionResults, err = e.waitPodsToDisappear(ctx, node, group.FullEvictionPods, evictionResults, e.groupMaxTermination(group.FullEvictionPods, group.ShutdownGracePeriodSeconds))
 		if err != nil {
 			return evictionResults, err
 		}
@@ -173,19 +178,20 @@ func (e Evictor) initiateEviction(ctx *acontext.AutoscalingContext, node *apiv1.
 	maxTermination int64) (map[string]status.PodEvictionResult, error) {
 
 	retryUntil := time.Now().Add(ctx.MaxPodEvictionTime)
+	overridePdbs := pdb.OverridesZeroDisruptions(node, ctx.UnreadyNodePdbOverrideTimeout, time.Now())
 	fullEvictionConfirmations := make(chan status.PodEvictionResult, len(fullEvictionPods))
 	bestEffortEvictionConfirmations := make(chan status.PodEvictionResult, len(bestEffortEvictionPods))
 
 	for _, pod := range fullEvictionPods {
 		evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: true, Err: nil}
 		go func(pod *apiv1.Pod) {
-			fullEvictionConfirmations <- e.evictPod(ctx, pod, retryUntil, maxTermination, true)
+			fullEvictionConfirmations <- e.evictPod(ctx, pod, retryUntil, e.maxTermination(pod, maxTermination), true, overridePdbs)
 		}(pod)
 	}
 
 	for _, pod := range bestEffortEvictionPods {
 		go func(pod *apiv1.Pod) {
-			bestEffortEvictionConfirmations <- e.evictPod(ctx, pod, retryUntil, maxTermination, false)
+			bestEffortEvictionConfirmations <- e.evictPod(ctx, pod, retryUntil, e.maxTermination(pod, maxTermination), false, overridePdbs)
 		}(pod)
 	}
 
@@ -215,7 +221,36 @@ func (e Evictor) initiateEviction(ctx *acontext.AutoscalingContext, node *apiv1.
 	return evictionResults, nil
 }
 
-func (e Evictor) evictPod(ctx *acontext.AutoscalingContext, podToEvict *apiv1.Pod, retryUntil time.Time, maxTermination int64, fullEvictionPod bool) status.PodEvictionResult {
+// maxTermination returns the maximum number of seconds the pod is given to terminate: the override
+// for its namespace or, if there is none, for its priority class, groupMaxTermination otherwise.
+func (e Evictor) maxTermination(pod *apiv1.Pod, groupMaxTermination int64) int64 {
+	for _, override := range e.gracefulTerminationOverrides {
+		if override.Namespace != "" && override.Namespace == pod.Namespace {
+			return int64(override.MaxGracefulTerminationSec)
+		}
+	}
+	for _, override := range e.gracefulTerminationOverrides {
+		if override.PriorityClassName != "" && override.PriorityClassName == pod.Spec.PriorityClassName {
+			return int64(override.MaxGracefulTerminationSec)
+		}
+	}
+	return groupMaxTermination
+}
+
+// groupMaxTermination returns the longest maximum termination of the pods of a group.
+func (e Evictor) groupMaxTermination(pods []*apiv1.Pod, groupMaxTermination int64) int64 {
+	result := groupMaxTermination
+	for _, pod := range pods {
+		if termination := e.maxTermination(pod, groupMaxTermination); termination > result {
+			result = termination
+		}
+	}
+	return result
+}
+
+// evictPod evicts the pod, retrying until retryUntil. If overridePdbs is set, pods whose
+// eviction is refused because of a PDB are deleted instead.
+func (e Evictor) evictPod(ctx *acontext.AutoscalingContext, podToEvict *apiv1.Pod, retryUntil time.Time, maxTermination int64, fullEvictionPod, overridePdbs bool) status.PodEvictionResult {
 	ctx.Recorder.Eventf(podToEvict, apiv1.EventTypeNormal, "ScaleDown", "deleting pod for node scale down")
 
 	termination := int64(apiv1.DefaultTerminationGracePeriodSeconds)
@@ -229,6 +264,10 @@ func (e Evictor) evictPod(ctx *acontext.AutoscalingContext, podToEvict *apiv1.Po
 	var lastError error
 	for first := true; first || time.Now().Before(retryUntil); time.Sleep(e.EvictionRetryTime) {
 		first = false
+		if err := actuationSuspendedError(ctx); err != nil {
+			lastError = err
+			break
+		}
 		eviction := &policyv1beta1.Eviction{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: podToEvict.Namespace,
@@ -239,6 +278,9 @@ func (e Evictor) evictPod(ctx *acontext.AutoscalingContext, podToEvict *apiv1.Po
 			},
 		}
 		lastError = ctx.ClientSet.CoreV1().Pods(podToEvict.Namespace).Evict(context.TODO(), eviction)
+		if overridePdbs && kube_errors.IsTooManyRequests(lastError) {
+			lastError = deletePodOverridingPdb(ctx, podToEvict, termination)
+		}
 		if lastError == nil || kube_errors.IsNotFound(lastError) {
 			if e.evictionRegister != nil {
 				e.evictionRegister.RegisterEviction(podToEvict)
@@ -253,6 +295,17 @@ func (e Evictor) evictPod(ctx *acontext.AutoscalingContext, podToEvict *apiv1.Po
 	return status.PodEvictionResult{Pod: podToEvict, TimedOut: true, Err: fmt.Errorf("failed to evict pod %s/%s within allowed timeout (last error: %v)", podToEvict.Namespace, podToEvict.Name, lastError)}
 }
 
+// deletePodOverridingPdb deletes the pod, bypassing PDBs which refused its eviction, and
+// records events about it on the pod and in the status ConfigMap.
+func deletePodOverridingPdb(ctx *acontext.AutoscalingContext, pod *apiv1.Pod, termination int64) error {
+	klog.Warningf("Deleting pod %s/%s from unready node %s despite PodDisruptionBudget refusing its eviction", pod.Namespace, pod.Name, pod.Spec.NodeName)
+	ctx.Recorder.Eventf(pod, apiv1.EventTypeWarning, "ScaleDownPdbOverridden", "deleting pod despite PodDisruptionBudget, node %s has been unready for over %v", pod.Spec.NodeName, ctx.UnreadyNodePdbOverrideTimeout)
+	if ctx.LogRecorder != nil {
+		ctx.LogRecorder.Eventf(apiv1.EventTypeWarning, "ScaleDownPdbOverridden", "Deleting pod %s/%s despite PodDisruptionBudget, node %s has been unready for over %v", pod.Namespace, pod.Name, pod.Spec.NodeName, ctx.UnreadyNodePdbOverrideTimeout)
+	}
+	return ctx.ClientSet.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &termination})
+}
+
 func podsToEvict(nodeInfo *framework.NodeInfo, evictDsByDefault bool) (dsPods, nonDsPods []*apiv1.Pod) {
 	for _, podInfo := range nodeInfo.Pods {
 		if pod_util.IsMirrorPod(podInfo.Pod) {
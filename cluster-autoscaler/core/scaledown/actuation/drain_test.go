@@ -564,6 +564,130 @@ func TestDrainNodeWithPodsEvictionFailure(t *testing.T) {
 	assert.Contains(t, r.pods, p1, p3)
 }
 
+func TestDrainNodeWithPodsOverridingPdb(t *testing.T) {
+	for desc, tc := range map[string]struct {
+		unreadySince    time.Duration
+		overrideTimeout time.Duration
+		wantDeleted     bool
+	}{
+		"node unready for longer than the timeout": {
+			unreadySince:    time.Hour,
+			overrideTimeout: 10 * time.Minute,
+			wantDeleted:     true,
+		},
+		"node unready for shorter than the timeout": {
+			unreadySince:    time.Minute,
+			overrideTimeout: 10 * time.Minute,
+		},
+		"override disabled": {
+			unreadySince: time.Hour,
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			fakeClient := &fake.Clientset{}
+			n1 := BuildTestNode("n1", 1000, 1000)
+			p1 := BuildTestPod("p1", 100, 0, WithNodeName(n1.Name))
+			p2 := BuildTestPod("p2", 100, 0, WithNodeName(n1.Name))
+			SetNodeReadyState(n1, false, time.Now().Add(-tc.unreadySince))
+
+			var deletedPods []string
+			fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+			})
+			fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				eviction := action.(core.CreateAction).GetObject().(*policyv1beta1.Eviction)
+				if eviction.Name == "p2" {
+					return true, nil, errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+				}
+				return true, nil, nil
+			})
+			fakeClient.Fake.AddReactor("delete", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				deletedPods = append(deletedPods, action.(core.DeleteAction).GetName())
+				return true, nil, nil
+			})
+
+			options := config.AutoscalingOptions{
+				MaxGracefulTerminationSec:     20,
+				MaxPodEvictionTime:            0 * time.Second,
+				UnreadyNodePdbOverrideTimeout: tc.overrideTimeout,
+			}
+			ctx, err := NewScaleTestAutoscalingContext(options, fakeClient, nil, nil, nil, nil)
+			assert.NoError(t, err)
+			evictor := Evictor{
+				EvictionRetryTime:                0,
+				PodEvictionHeadroom:              DefaultPodEvictionHeadroom,
+				shutdownGracePeriodByPodPriority: SingleRuleDrainConfig(ctx.MaxGracefulTerminationSec),
+			}
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, ctx.ClusterSnapshot, []*apiv1.Node{n1}, []*apiv1.Pod{p1, p2})
+			nodeInfo, err := ctx.ClusterSnapshot.NodeInfos().Get(n1.Name)
+			assert.NoError(t, err)
+			evictionResults, err := evictor.DrainNode(&ctx, nodeInfo)
+			assert.True(t, evictionResults["p1"].WasEvictionSuccessful())
+			if tc.wantDeleted {
+				assert.NoError(t, err)
+				assert.True(t, evictionResults["p2"].WasEvictionSuccessful())
+				assert.Equal(t, []string{"p2"}, deletedPods)
+			} else {
+				assert.Error(t, err)
+				assert.False(t, evictionResults["p2"].WasEvictionSuccessful())
+				assert.Empty(t, deletedPods)
+			}
+		})
+	}
+}
+
+func TestDrainNodeWithPodsGracefulTerminationOverrides(t *testing.T) {
+	fakeClient := &fake.Clientset{}
+	n1 := BuildTestNode("n1", 1000, 1000)
+	db := BuildTestPod("db", 100, 0, WithNodeName(n1.Name))
+	db.Namespace = "databases"
+	db.Spec.PriorityClassName = "batch"
+	batch := BuildTestPod("batch", 100, 0, WithNodeName(n1.Name))
+	batch.Spec.PriorityClassName = "batch"
+	other := BuildTestPod("other", 100, 0, WithNodeName(n1.Name))
+	short := BuildTestPod("short", 100, 0, WithNodeName(n1.Name))
+	short.Namespace = "databases"
+	for _, pod := range []*apiv1.Pod{db, batch, other, short} {
+		termination := int64(3600)
+		pod.Spec.TerminationGracePeriodSeconds = &termination
+	}
+	shortTermination := int64(5)
+	short.Spec.TerminationGracePeriodSeconds = &shortTermination
+
+	var mutex sync.Mutex
+	gracePeriods := make(map[string]int64)
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		eviction := action.(core.CreateAction).GetObject().(*policyv1beta1.Eviction)
+		mutex.Lock()
+		defer mutex.Unlock()
+		gracePeriods[eviction.Name] = *eviction.DeleteOptions.GracePeriodSeconds
+		return true, nil, nil
+	})
+
+	options := config.AutoscalingOptions{
+		MaxGracefulTerminationSec: 600,
+		MaxPodEvictionTime:        0 * time.Second,
+	}
+	ctx, err := NewScaleTestAutoscalingContext(options, fakeClient, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	evictor := NewEvictor(nil, SingleRuleDrainConfig(ctx.MaxGracefulTerminationSec), false, []config.GracefulTerminationOverride{
+		{PriorityClassName: "batch", MaxGracefulTerminationSec: 120},
+		{Namespace: "databases", MaxGracefulTerminationSec: 1800},
+	})
+	evictor.EvictionRetryTime = 0
+	clustersnapshot.InitializeClusterSnapshotOrDie(t, ctx.ClusterSnapshot, []*apiv1.Node{n1}, []*apiv1.Pod{db, batch, other, short})
+	nodeInfo, err := ctx.ClusterSnapshot.NodeInfos().Get(n1.Name)
+	assert.NoError(t, err)
+	_, err = evictor.DrainNode(&ctx, nodeInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"db": 1800, "batch": 120, "other": 600, "short": 5}, gracePeriods)
+	assert.Equal(t, int64(1800), evictor.groupMaxTermination([]*apiv1.Pod{batch, db, other}, 600))
+	assert.Equal(t, int64(600), evictor.groupMaxTermination([]*apiv1.Pod{batch, other}, 600))
+}
+
 func TestDrainWithPodsNodeDisappearanceFailure(t *testing.T) {
 	fakeClient := &fake.Clientset{}
 
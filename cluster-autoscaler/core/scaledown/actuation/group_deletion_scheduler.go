@@ -87,6 +87,12 @@ func (ds *GroupDeletionScheduler) ScheduleDeletion(nodeInfo *framework.NodeInfo,
 		opts = &config.NodeGroupAutoscalingOptions{}
 	}
 
+	if err := actuationSuspendedError(ds.ctx); err != nil {
+		nodeDeleteResult := status.NodeDeleteResult{ResultType: status.NodeDeleteErrorInternal, Err: err}
+		ds.AbortNodeDeletion(nodeInfo.Node(), nodeGroup.Id(), drain, "actuation suspended", nodeDeleteResult)
+		return
+	}
+
 	nodeDeleteResult := ds.prepareNodeForDeletion(nodeInfo, drain)
 	if nodeDeleteResult.Err != nil {
 		ds.AbortNodeDeletion(nodeInfo.Node(), nodeGroup.Id(), drain, "prepareNodeForDeletion failed", nodeDeleteResult)
@@ -17,12 +17,14 @@ limitations under the License.
 package actuation
 
 import (
+	"fmt"
 	"math"
 	"sort"
 	"strconv"
 	"strings"
 
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/klog/v2"
 	kubelet_config "k8s.io/kubernetes/pkg/kubelet/apis/config"
 )
@@ -106,6 +108,45 @@ func ParseShutdownGracePeriodsAndPriorities(priorityGracePeriodStr string) []kub
 	return priorityGracePeriodMap
 }
 
+const (
+	namespaceOverridePrefix     = "namespace/"
+	priorityClassOverridePrefix = "priorityclass/"
+)
+
+// ParseMaxGracefulTerminationOverrides parses a list of ',' separated overrides of the form
+// namespace/<name>:<seconds> or priorityclass/<name>:<seconds>.
+func ParseMaxGracefulTerminationOverrides(overridesStr string) ([]config.GracefulTerminationOverride, error) {
+	var overrides []config.GracefulTerminationOverride
+	if overridesStr == "" {
+		return overrides, nil
+	}
+	for _, item := range strings.Split(overridesStr, ",") {
+		targetAndPeriod := strings.Split(item, ":")
+		if len(targetAndPeriod) != 2 {
+			return nil, fmt.Errorf("%q is not a target and grace period couple separated by ':'", item)
+		}
+		seconds, err := strconv.Atoi(targetAndPeriod[1])
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid grace period %q of %q", targetAndPeriod[1], item)
+		}
+		override := config.GracefulTerminationOverride{MaxGracefulTerminationSec: seconds}
+		target := targetAndPeriod[0]
+		switch {
+		case strings.HasPrefix(target, namespaceOverridePrefix):
+			override.Namespace = strings.TrimPrefix(target, namespaceOverridePrefix)
+		case strings.HasPrefix(target, priorityClassOverridePrefix):
+			override.PriorityClassName = strings.TrimPrefix(target, priorityClassOverridePrefix)
+		default:
+			return nil, fmt.Errorf("target %q of %q is neither %s<name> nor %s<name>", target, item, namespaceOverridePrefix, priorityClassOverridePrefix)
+		}
+		if override.Namespace == "" && override.PriorityClassName == "" {
+			return nil, fmt.Errorf("empty name in %q", item)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
 // SingleRuleDrainConfig returns an array of ShutdownGracePeriodByPodPriority with a single ShutdownGracePeriodByPodPriority
 func SingleRuleDrainConfig(shutdownGracePeriodSeconds int) []kubelet_config.ShutdownGracePeriodByPodPriority {
 	return []kubelet_config.ShutdownGracePeriodByPodPriority{
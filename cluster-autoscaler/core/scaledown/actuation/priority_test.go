@@ -237,3 +237,57 @@ func TestParseShutdownGracePeriodsAndPriorities(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMaxGracefulTerminationOverrides(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    []config.GracefulTerminationOverride
+		wantErr bool
+	}{
+		{
+			name:  "empty input",
+			input: "",
+		},
+		{
+			name:  "namespace and priority class",
+			input: "namespace/databases:1800,priorityclass/batch:120",
+			want: []config.GracefulTerminationOverride{
+				{Namespace: "databases", MaxGracefulTerminationSec: 1800},
+				{PriorityClassName: "batch", MaxGracefulTerminationSec: 120},
+			},
+		},
+		{
+			name:    "unknown target",
+			input:   "deployment/db:1800",
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			input:   "namespace/:1800",
+			wantErr: true,
+		},
+		{
+			name:    "missing grace period",
+			input:   "namespace/databases",
+			wantErr: true,
+		},
+		{
+			name:    "negative grace period",
+			input:   "namespace/databases:-1",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			overrides, err := ParseMaxGracefulTerminationOverrides(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, overrides)
+		})
+	}
+}
@@ -71,6 +71,39 @@ func UpdateSoftDeletionTaints(context *context.AutoscalingContext, uneededNodes,
 	return
 }
 
+// CleanSoftDeletionTaints removes soft taints from the given nodes, e.g. after
+// scale-down was aborted and the nodes may be needed by pending pods. The same
+// API call and time budget as in UpdateSoftDeletionTaints applies, remaining
+// taints are removed in subsequent calls. Returns the number of removed taints.
+func CleanSoftDeletionTaints(context *context.AutoscalingContext, nodes []*apiv1.Node) (removed int, errors []error) {
+	defer metrics.UpdateDurationFromStart(metrics.ScaleDownSoftTaintUnneeded, time.Now())
+	b := &budgetTracker{
+		apiCallBudget: context.AutoscalingOptions.MaxBulkSoftTaintCount,
+		timeBudget:    context.AutoscalingOptions.MaxBulkSoftTaintTime,
+		startTime:     now(),
+	}
+	for _, node := range nodes {
+		if taints.HasToBeDeletedTaint(node) {
+			// Do not consider nodes that are scheduled to be deleted
+			continue
+		}
+		if !taints.HasDeletionCandidateTaint(node) {
+			continue
+		}
+		b.processWithinBudget(func() {
+			_, err := taints.CleanDeletionCandidate(node, context.ClientSet)
+			if err != nil {
+				errors = append(errors, err)
+				klog.Warningf("Soft taint on %s removal error %v", node.Name, err)
+				return
+			}
+			removed++
+		})
+	}
+	b.reportExceededLimits()
+	return
+}
+
 // Get current time. Proxy for unit tests.
 var now func() time.Time = time.Now
 
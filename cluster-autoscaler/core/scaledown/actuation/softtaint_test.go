@@ -195,6 +195,58 @@ func TestSoftTaintTimeLimit(t *testing.T) {
 	assert.Equal(t, 0, countDeletionCandidateTaints(t, fakeClient))
 }
 
+func TestCleanSoftDeletionTaints(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Time{})
+
+	fakeClient := fake.NewSimpleClientset()
+	ctx := context.Background()
+	for _, n := range []*apiv1.Node{n1, n2, n3} {
+		_, err := fakeClient.CoreV1().Nodes().Create(ctx, n, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+	provider.AddNode("ng1", n3)
+
+	options := config.AutoscalingOptions{
+		MaxBulkSoftTaintCount: 1,
+		MaxBulkSoftTaintTime:  3 * time.Second,
+	}
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	actx, err := test.NewScaleTestAutoscalingContext(options, fakeClient, registry, provider, nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, taints.MarkDeletionCandidate(n1, fakeClient))
+	assert.NoError(t, taints.MarkDeletionCandidate(n2, fakeClient))
+	assert.Equal(t, 2, countDeletionCandidateTaints(t, fakeClient))
+
+	// Nodes without the taint don't use the budget.
+	removed, errs := CleanSoftDeletionTaints(&actx, []*apiv1.Node{getNode(t, fakeClient, n3.Name), getNode(t, fakeClient, n1.Name)})
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, removed)
+	assert.False(t, hasDeletionCandidateTaint(t, fakeClient, n1.Name))
+
+	// The API call budget applies.
+	assert.NoError(t, taints.MarkDeletionCandidate(getNode(t, fakeClient, n1.Name), fakeClient))
+	removed, errs = CleanSoftDeletionTaints(&actx, getAllNodes(t, fakeClient))
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, countDeletionCandidateTaints(t, fakeClient))
+	removed, errs = CleanSoftDeletionTaints(&actx, getAllNodes(t, fakeClient))
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 0, countDeletionCandidateTaints(t, fakeClient))
+}
+
 func countDeletionCandidateTaints(t *testing.T, client kubernetes.Interface) (total int) {
 	t.Helper()
 	for _, node := range getAllNodes(t, client) {
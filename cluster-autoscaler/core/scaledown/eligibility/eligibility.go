@@ -120,6 +120,11 @@ func (c *Checker) unremovableReasonAndNodeUtilization(context *context.Autoscali
 		return simulator.ScaleDownDisabledAnnotation, nil
 	}
 
+	// Skip nodes being upgraded, which are only temporarily cordoned or unready.
+	if isUpgradeInProgress(context.AutoscalingOptions, node, timestamp) {
+		return simulator.UpgradeInProgress, nil
+	}
+
 	nodeGroup, err := context.CloudProvider.NodeGroupForNode(node)
 	if err != nil {
 		klog.Warningf("Node group not found for node %v: %v", node.Name, err)
@@ -132,6 +137,12 @@ func (c *Checker) unremovableReasonAndNodeUtilization(context *context.Autoscali
 		return simulator.NotAutoscaled, nil
 	}
 
+	// Skip nodes of reserve node groups, which are only scaled up as a last resort.
+	if isReserveNodeGroup(context.AutoscalingOptions, nodeGroup) {
+		klog.V(1).Infof("Skipping %s from delete consideration - the node belongs to reserve node group %s", node.Name, nodeGroup.Id())
+		return simulator.ReserveNodeGroup, nil
+	}
+
 	ignoreDaemonSetsUtilization, err := c.configGetter.GetIgnoreDaemonSetsUtilization(nodeGroup)
 	if err != nil {
 		klog.Warningf("Couldn't retrieve `IgnoreDaemonSetsUtilization` option for node %v: %v", node.Name, err)
@@ -139,6 +150,12 @@ func (c *Checker) unremovableReasonAndNodeUtilization(context *context.Autoscali
 	}
 
 	gpuConfig := context.CloudProvider.GetNodeGpuConfig(node)
+	if gpuConfig != nil {
+		if reason := gpuUnremovableReason(context.AutoscalingOptions, nodeInfo); reason != simulator.NoReason {
+			return reason, nil
+		}
+	}
+
 	utilInfo, err := utilization.Calculate(nodeInfo, ignoreDaemonSetsUtilization, context.IgnoreMirrorPodsUtilization, gpuConfig, timestamp)
 	if err != nil {
 		klog.Warningf("Failed to calculate utilization for %s: %v", node.Name, err)
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eligibility
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+)
+
+const (
+	// DefaultGpuJobInProgressAnnotation is the default pod annotation marking a GPU job
+	// which is in progress and shouldn't be interrupted by scale-down.
+	DefaultGpuJobInProgressAnnotation = "cluster-autoscaler.kubernetes.io/gpu-job-in-progress"
+	// MigConfigStateLabel is the node label the NVIDIA MIG manager sets to the state
+	// of the MIG reconfiguration of the node.
+	MigConfigStateLabel = "nvidia.com/mig.config.state"
+)
+
+// migReconfigurationStates are the MigConfigStateLabel values set while the MIG
+// manager is reconfiguring the node.
+var migReconfigurationStates = map[string]bool{
+	"pending":   true,
+	"rebooting": true,
+}
+
+// gpuUnremovableReason returns the reason why a GPU node can't be removed because
+// of a GPU job in progress on it or an ongoing MIG reconfiguration, or NoReason.
+func gpuUnremovableReason(options config.AutoscalingOptions, nodeInfo *schedulerframework.NodeInfo) simulator.UnremovableReason {
+	node := nodeInfo.Node()
+	if options.ScaleDownWaitForMigReconfig {
+		if state := node.Labels[MigConfigStateLabel]; migReconfigurationStates[state] {
+			klog.V(1).Infof("Skipping %s from delete consideration - MIG reconfiguration is in progress (%s=%s)", node.Name, MigConfigStateLabel, state)
+			return simulator.MigReconfigurationInProgress
+		}
+	}
+	if options.ScaleDownGpuBusyNodeCondition != "" {
+		for _, condition := range node.Status.Conditions {
+			if string(condition.Type) == options.ScaleDownGpuBusyNodeCondition && condition.Status == apiv1.ConditionTrue {
+				klog.V(1).Infof("Skipping %s from delete consideration - node condition %s reports a GPU job in progress", node.Name, condition.Type)
+				return simulator.GpuJobInProgress
+			}
+		}
+	}
+	if options.ScaleDownGpuBusyPodAnnotation != "" {
+		for _, podInfo := range nodeInfo.Pods {
+			pod := podInfo.Pod
+			if pod.Annotations[options.ScaleDownGpuBusyPodAnnotation] == "true" && pod.Status.Phase != apiv1.PodSucceeded && pod.Status.Phase != apiv1.PodFailed {
+				klog.V(1).Infof("Skipping %s from delete consideration - pod %s/%s has a GPU job in progress", node.Name, pod.Namespace, pod.Name)
+				return simulator.GpuJobInProgress
+			}
+		}
+	}
+	return simulator.NoReason
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eligibility
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/unremovable"
+	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupconfig"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+const testGpuBusyCondition = "GPUJobInProgress"
+
+func buildTestGpuNode(name string) *apiv1.Node {
+	node := BuildTestNode(name, 1000, 10)
+	AddGpusToNode(node, 1)
+	node.Labels["TestGPULabel/accelerator"] = "nvidia-tesla-k80"
+	SetNodeReadyState(node, true, time.Time{})
+	return node
+}
+
+func TestFilterOutUnremovableGpuNodes(t *testing.T) {
+	now := time.Now()
+
+	idleNode := buildTestGpuNode("idle")
+
+	jobNode := buildTestGpuNode("job")
+	jobPod := BuildTestPod("jobPod", 0, 0)
+	jobPod.Spec.NodeName = "job"
+	jobPod.Annotations = map[string]string{DefaultGpuJobInProgressAnnotation: "true"}
+
+	finishedJobNode := buildTestGpuNode("finishedJob")
+	finishedJobPod := BuildTestPod("finishedJobPod", 0, 0)
+	finishedJobPod.Spec.NodeName = "finishedJob"
+	finishedJobPod.Annotations = map[string]string{DefaultGpuJobInProgressAnnotation: "true"}
+	finishedJobPod.Status.Phase = apiv1.PodSucceeded
+
+	busyConditionNode := buildTestGpuNode("busyCondition")
+	busyConditionNode.Status.Conditions = append(busyConditionNode.Status.Conditions,
+		apiv1.NodeCondition{Type: testGpuBusyCondition, Status: apiv1.ConditionTrue})
+
+	idleConditionNode := buildTestGpuNode("idleCondition")
+	idleConditionNode.Status.Conditions = append(idleConditionNode.Status.Conditions,
+		apiv1.NodeCondition{Type: testGpuBusyCondition, Status: apiv1.ConditionFalse})
+
+	migPendingNode := buildTestGpuNode("migPending")
+	migPendingNode.Labels[MigConfigStateLabel] = "pending"
+
+	migDoneNode := buildTestGpuNode("migDone")
+	migDoneNode.Labels[MigConfigStateLabel] = "success"
+
+	nodes := []*apiv1.Node{idleNode, jobNode, finishedJobNode, busyConditionNode, idleConditionNode, migPendingNode, migDoneNode}
+	pods := []*apiv1.Pod{jobPod, finishedJobPod}
+
+	testCases := []struct {
+		desc        string
+		options     config.AutoscalingOptions
+		want        []string
+		wantReasons map[string]simulator.UnremovableReason
+	}{
+		{
+			desc: "GPU checks disabled",
+			want: []string{"idle", "job", "finishedJob", "busyCondition", "idleCondition", "migPending", "migDone"},
+		},
+		{
+			desc: "GPU checks enabled",
+			options: config.AutoscalingOptions{
+				ScaleDownGpuBusyPodAnnotation: DefaultGpuJobInProgressAnnotation,
+				ScaleDownGpuBusyNodeCondition: testGpuBusyCondition,
+				ScaleDownWaitForMigReconfig:   true,
+			},
+			want: []string{"idle", "finishedJob", "idleCondition", "migDone"},
+			wantReasons: map[string]simulator.UnremovableReason{
+				"job":           simulator.GpuJobInProgress,
+				"busyCondition": simulator.GpuJobInProgress,
+				"migPending":    simulator.MigReconfigurationInProgress,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			options := tc.options
+			options.UnremovableNodeRecheckTimeout = 5 * time.Minute
+			options.NodeGroupDefaults = config.NodeGroupAutoscalingOptions{
+				ScaleDownUtilizationThreshold:    config.DefaultScaleDownUtilizationThreshold,
+				ScaleDownGpuUtilizationThreshold: config.DefaultScaleDownGpuUtilizationThreshold,
+			}
+			c := NewChecker(nodegroupconfig.NewDefaultNodeGroupConfigProcessor(options.NodeGroupDefaults))
+			provider := testprovider.NewTestCloudProvider(nil, nil)
+			provider.AddNodeGroup("ng1", 1, 10, len(nodes))
+			for _, n := range nodes {
+				provider.AddNode("ng1", n)
+			}
+			context, err := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, nil, nil)
+			assert.NoError(t, err)
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, context.ClusterSnapshot, nodes, pods)
+
+			got, _, unremovableNodes := c.FilterOutUnremovable(&context, nodes, now, unremovable.NewNodes())
+			assert.ElementsMatch(t, tc.want, got)
+			gotReasons := map[string]simulator.UnremovableReason{}
+			for _, n := range unremovableNodes {
+				gotReasons[n.Node.Name] = n.Reason
+			}
+			if tc.wantReasons == nil {
+				tc.wantReasons = map[string]simulator.UnremovableReason{}
+			}
+			assert.Equal(t, tc.wantReasons, gotReasons)
+		})
+	}
+}
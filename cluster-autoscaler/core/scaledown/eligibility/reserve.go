@@ -0,0 +1,35 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eligibility
+
+import (
+	"regexp"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+// isReserveNodeGroup checks if the node group id matches one of the configured
+// reserve node group patterns. Invalid patterns are rejected at startup.
+func isReserveNodeGroup(options config.AutoscalingOptions, nodeGroup cloudprovider.NodeGroup) bool {
+	for _, pattern := range options.ReserveNodeGroups {
+		if matched, err := regexp.MatchString(pattern, nodeGroup.Id()); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eligibility
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/unremovable"
+	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupconfig"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestFilterOutUnremovableReserveNodes(t *testing.T) {
+	now := time.Now()
+	nodeGroups := map[string]string{
+		"regular":  "ng1",
+		"reserve1": "reserve-ng1",
+		"reserve2": "reserve-ng2",
+	}
+	var nodes []*apiv1.Node
+	for name := range nodeGroups {
+		node := BuildTestNode(name, 1000, 10)
+		SetNodeReadyState(node, true, time.Time{})
+		nodes = append(nodes, node)
+	}
+
+	testCases := []struct {
+		desc              string
+		reserveNodeGroups []string
+		want              []string
+		wantReasons       map[string]simulator.UnremovableReason
+	}{
+		{
+			desc: "no reserve node groups",
+			want: []string{"regular", "reserve1", "reserve2"},
+		},
+		{
+			desc:              "reserve node groups",
+			reserveNodeGroups: []string{"^reserve-.*"},
+			want:              []string{"regular"},
+			wantReasons: map[string]simulator.UnremovableReason{
+				"reserve1": simulator.ReserveNodeGroup,
+				"reserve2": simulator.ReserveNodeGroup,
+			},
+		},
+		{
+			desc:              "one of multiple patterns matches",
+			reserveNodeGroups: []string{"^other$", "^reserve-ng2$"},
+			want:              []string{"regular", "reserve1"},
+			wantReasons: map[string]simulator.UnremovableReason{
+				"reserve2": simulator.ReserveNodeGroup,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			options := config.AutoscalingOptions{
+				ReserveNodeGroups:             tc.reserveNodeGroups,
+				UnremovableNodeRecheckTimeout: 5 * time.Minute,
+				NodeGroupDefaults: config.NodeGroupAutoscalingOptions{
+					ScaleDownUtilizationThreshold: config.DefaultScaleDownUtilizationThreshold,
+				},
+			}
+			c := NewChecker(nodegroupconfig.NewDefaultNodeGroupConfigProcessor(options.NodeGroupDefaults))
+			provider := testprovider.NewTestCloudProvider(nil, nil)
+			for _, ng := range []string{"ng1", "reserve-ng1", "reserve-ng2"} {
+				provider.AddNodeGroup(ng, 0, 10, 1)
+			}
+			for _, n := range nodes {
+				provider.AddNode(nodeGroups[n.Name], n)
+			}
+			context, err := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, nil, nil)
+			assert.NoError(t, err)
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, context.ClusterSnapshot, nodes, nil)
+
+			got, _, unremovableNodes := c.FilterOutUnremovable(&context, nodes, now, unremovable.NewNodes())
+			assert.ElementsMatch(t, tc.want, got)
+			gotReasons := map[string]simulator.UnremovableReason{}
+			for _, n := range unremovableNodes {
+				gotReasons[n.Node.Name] = n.Reason
+			}
+			if tc.wantReasons == nil {
+				tc.wantReasons = map[string]simulator.UnremovableReason{}
+			}
+			assert.Equal(t, tc.wantReasons, gotReasons)
+		})
+	}
+}
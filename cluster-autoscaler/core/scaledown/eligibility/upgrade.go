@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eligibility
+
+import (
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+// KuredRebootInProgressAnnotation is the node annotation kured sets, to the time
+// the reboot started, while it drains and reboots the node.
+const KuredRebootInProgressAnnotation = "weave.works/kured-reboot-in-progress"
+
+// isUpgradeInProgress checks if the node is marked as being upgraded by one of the
+// configured node annotations or conditions, for less than the upgrade window.
+func isUpgradeInProgress(options config.AutoscalingOptions, node *apiv1.Node, timestamp time.Time) bool {
+	for _, annotation := range options.ScaleDownUpgradeNodeAnnotations {
+		key, value, hasValue := strings.Cut(annotation, "=")
+		current, found := node.Annotations[key]
+		if !found || (hasValue && current != value) {
+			continue
+		}
+		// kured and some upgrade controllers set the annotation to the time the upgrade started.
+		since, err := time.Parse(time.RFC3339, current)
+		if err == nil && !withinUpgradeWindow(options, since, timestamp) {
+			klog.V(4).Infof("Node %s has upgrade annotation %s since %v, longer than the upgrade window", node.Name, key, since)
+			continue
+		}
+		klog.V(1).Infof("Skipping %s from delete consideration - the node is being upgraded (annotation %s)", node.Name, key)
+		return true
+	}
+	for _, conditionType := range options.ScaleDownUpgradeNodeConditions {
+		for _, condition := range node.Status.Conditions {
+			if string(condition.Type) != conditionType || condition.Status != apiv1.ConditionTrue {
+				continue
+			}
+			if !withinUpgradeWindow(options, condition.LastTransitionTime.Time, timestamp) {
+				klog.V(4).Infof("Node %s has upgrade condition %s since %v, longer than the upgrade window", node.Name, condition.Type, condition.LastTransitionTime.Time)
+				continue
+			}
+			klog.V(1).Infof("Skipping %s from delete consideration - the node is being upgraded (condition %s)", node.Name, condition.Type)
+			return true
+		}
+	}
+	return false
+}
+
+func withinUpgradeWindow(options config.AutoscalingOptions, since, timestamp time.Time) bool {
+	return options.ScaleDownUpgradeWindow == 0 || since.IsZero() || timestamp.Sub(since) < options.ScaleDownUpgradeWindow
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eligibility
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/unremovable"
+	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupconfig"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+const testUpgradeCondition = "OSUpgradeInProgress"
+
+func TestFilterOutUnremovableUpgradingNodes(t *testing.T) {
+	now := time.Now()
+	buildNode := func(name string) *apiv1.Node {
+		node := BuildTestNode(name, 1000, 10)
+		SetNodeReadyState(node, true, time.Time{})
+		return node
+	}
+
+	idleNode := buildNode("idle")
+
+	rebootingNode := buildNode("rebooting")
+	rebootingNode.Annotations = map[string]string{KuredRebootInProgressAnnotation: now.Add(-10 * time.Minute).Format(time.RFC3339)}
+
+	staleRebootNode := buildNode("staleReboot")
+	staleRebootNode.Annotations = map[string]string{KuredRebootInProgressAnnotation: now.Add(-2 * time.Hour).Format(time.RFC3339)}
+
+	upgradingNode := buildNode("upgrading")
+	upgradingNode.Annotations = map[string]string{"upgrade.example.com/state": "in-progress"}
+
+	upgradedNode := buildNode("upgraded")
+	upgradedNode.Annotations = map[string]string{"upgrade.example.com/state": "done"}
+
+	conditionNode := buildNode("condition")
+	conditionNode.Status.Conditions = append(conditionNode.Status.Conditions,
+		apiv1.NodeCondition{Type: testUpgradeCondition, Status: apiv1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute))})
+
+	staleConditionNode := buildNode("staleCondition")
+	staleConditionNode.Status.Conditions = append(staleConditionNode.Status.Conditions,
+		apiv1.NodeCondition{Type: testUpgradeCondition, Status: apiv1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Hour))})
+
+	nodes := []*apiv1.Node{idleNode, rebootingNode, staleRebootNode, upgradingNode, upgradedNode, conditionNode, staleConditionNode}
+
+	testCases := []struct {
+		desc        string
+		options     config.AutoscalingOptions
+		want        []string
+		wantReasons map[string]simulator.UnremovableReason
+	}{
+		{
+			desc: "upgrade checks disabled",
+			want: []string{"idle", "rebooting", "staleReboot", "upgrading", "upgraded", "condition", "staleCondition"},
+		},
+		{
+			desc: "upgrade checks enabled",
+			options: config.AutoscalingOptions{
+				ScaleDownUpgradeNodeAnnotations: []string{KuredRebootInProgressAnnotation, "upgrade.example.com/state=in-progress"},
+				ScaleDownUpgradeNodeConditions:  []string{testUpgradeCondition},
+				ScaleDownUpgradeWindow:          time.Hour,
+			},
+			want: []string{"idle", "staleReboot", "upgraded", "staleCondition"},
+			wantReasons: map[string]simulator.UnremovableReason{
+				"rebooting": simulator.UpgradeInProgress,
+				"upgrading": simulator.UpgradeInProgress,
+				"condition": simulator.UpgradeInProgress,
+			},
+		},
+		{
+			desc: "upgrade checks enabled without window",
+			options: config.AutoscalingOptions{
+				ScaleDownUpgradeNodeAnnotations: []string{KuredRebootInProgressAnnotation},
+				ScaleDownUpgradeNodeConditions:  []string{testUpgradeCondition},
+			},
+			want: []string{"idle", "upgrading", "upgraded"},
+			wantReasons: map[string]simulator.UnremovableReason{
+				"rebooting":      simulator.UpgradeInProgress,
+				"staleReboot":    simulator.UpgradeInProgress,
+				"condition":      simulator.UpgradeInProgress,
+				"staleCondition": simulator.UpgradeInProgress,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			options := tc.options
+			options.UnremovableNodeRecheckTimeout = 5 * time.Minute
+			options.NodeGroupDefaults = config.NodeGroupAutoscalingOptions{
+				ScaleDownUtilizationThreshold: config.DefaultScaleDownUtilizationThreshold,
+			}
+			c := NewChecker(nodegroupconfig.NewDefaultNodeGroupConfigProcessor(options.NodeGroupDefaults))
+			provider := testprovider.NewTestCloudProvider(nil, nil)
+			provider.AddNodeGroup("ng1", 1, 10, len(nodes))
+			for _, n := range nodes {
+				provider.AddNode("ng1", n)
+			}
+			context, err := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, nil, nil)
+			assert.NoError(t, err)
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, context.ClusterSnapshot, nodes, nil)
+
+			got, _, unremovableNodes := c.FilterOutUnremovable(&context, nodes, now, unremovable.NewNodes())
+			assert.ElementsMatch(t, tc.want, got)
+			gotReasons := map[string]simulator.UnremovableReason{}
+			for _, n := range unremovableNodes {
+				gotReasons[n.Node.Name] = n.Reason
+			}
+			if tc.wantReasons == nil {
+				tc.wantReasons = map[string]simulator.UnremovableReason{}
+			}
+			assert.Equal(t, tc.wantReasons, gotReasons)
+		})
+	}
+}
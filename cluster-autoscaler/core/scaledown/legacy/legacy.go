@@ -66,7 +66,7 @@ func NewScaleDown(context *context.AutoscalingContext, processors *processors.Au
 		context:              context,
 		processors:           processors,
 		unremovableNodes:     unremovableNodes,
-		unneededNodes:        unneeded.NewNodes(processors.NodeGroupConfigProcessor, resourceLimitsFinder),
+		unneededNodes:        unneeded.NewNodes(processors.NodeGroupConfigProcessor, resourceLimitsFinder, context.MaxPodScaleDownUnneededTime),
 		nodeUtilizationMap:   make(map[string]utilization.Info),
 		usageTracker:         usageTracker,
 		nodeDeletionTracker:  ndt,
@@ -93,6 +93,11 @@ func (sd *ScaleDown) UnneededNodes() []*apiv1.Node {
 	return sd.unneededNodes.AsList()
 }
 
+// ExtendedUnneededTimes returns the unneeded times of unneeded nodes extended by their pods.
+func (sd *ScaleDown) ExtendedUnneededTimes() map[string]time.Duration {
+	return sd.unneededNodes.ExtendedUnneededTimes()
+}
+
 // UpdateUnneededNodes calculates which nodes are not needed, i.e. all pods can be scheduled somewhere else,
 // and updates unneededNodes accordingly. It also computes information where pods can be rescheduled and
 // node utilization level. The computations are made only for the nodes managed by CA.
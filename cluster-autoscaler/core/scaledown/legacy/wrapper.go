@@ -62,6 +62,11 @@ func (p *ScaleDownWrapper) UnneededNodes() []*apiv1.Node {
 	return p.sd.UnneededNodes()
 }
 
+// ExtendedUnneededTimes returns the unneeded times of unneeded nodes extended by their pods.
+func (p *ScaleDownWrapper) ExtendedUnneededTimes() map[string]time.Duration {
+	return p.sd.ExtendedUnneededTimes()
+}
+
 // UnremovableNodes returns a list of nodes that cannot be removed.
 func (p *ScaleDownWrapper) UnremovableNodes() []*simulator.UnremovableNode {
 	return p.sd.UnremovableNodes()
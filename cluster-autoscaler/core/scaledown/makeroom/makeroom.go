@@ -0,0 +1,419 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package makeroom
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/estimator"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/customresources"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/options"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
+	caerrors "k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// RequestedAtAnnotation is the node annotation under which the time a node group was scaled up
+	// to make room for the pods of the node is recorded, so that the added nodes stay protected from
+	// scale-down across restarts until the node is removed.
+	RequestedAtAnnotation = "cluster-autoscaler.kubernetes.io/make-room-requested-at"
+)
+
+// Orchestrator makes room to move pods when scale-down is blocked only because the pods of
+// underutilized nodes have no place to go. It scales a node group up by a few nodes if their
+// pods fit on fewer new nodes than the nodes they run on, so that scale-down can then move
+// them and remove more nodes than were added.
+type Orchestrator struct {
+	context              *context.AutoscalingContext
+	clusterStateRegistry *clusterstate.ClusterStateRegistry
+	estimatorBuilder     estimator.EstimatorBuilder
+	deleteOptions        options.NodeDeleteOptions
+	drainabilityRules    rules.Rules
+	resourceManager      *resource.Manager
+	// backoff keeps node groups whose nodes weren't consolidated after making room from being
+	// scaled up to make room again right away.
+	backoff backoff.Backoff
+	// requests are the pending scale-ups done to make room, by node group id.
+	requests map[string]*request
+}
+
+// request is a scale-up done to make room for the pods of nodes to consolidate.
+type request struct {
+	nodeGroup cloudprovider.NodeGroup
+	// nodes are the names of the nodes whose pods are to be moved to the added nodes.
+	nodes map[string]bool
+	time  time.Time
+}
+
+// NewOrchestrator returns an Orchestrator.
+func NewOrchestrator(context *context.AutoscalingContext, clusterStateRegistry *clusterstate.ClusterStateRegistry,
+	estimatorBuilder estimator.EstimatorBuilder, deleteOptions options.NodeDeleteOptions, drainabilityRules rules.Rules,
+	customResourcesProcessor customresources.CustomResourcesProcessor) *Orchestrator {
+	// A node group whose nodes weren't consolidated is backed off for at least as long as it was given to consolidate them.
+	initialBackoffDuration := requestTimeout(context)
+	maxBackoffDuration := context.MaxNodeGroupBackoffDuration
+	if maxBackoffDuration < initialBackoffDuration {
+		maxBackoffDuration = initialBackoffDuration
+	}
+	return &Orchestrator{
+		context:              context,
+		clusterStateRegistry: clusterStateRegistry,
+		estimatorBuilder:     estimatorBuilder,
+		deleteOptions:        deleteOptions,
+		drainabilityRules:    drainabilityRules,
+		resourceManager:      resource.NewManager(customResourcesProcessor),
+		backoff:              backoff.NewIdBasedExponentialBackoff(initialBackoffDuration, maxBackoffDuration, context.NodeGroupBackoffResetTimeout),
+		requests:             make(map[string]*request),
+	}
+}
+
+// requestTimeout returns how long the added nodes have to come up and the nodes to consolidate to
+// become unneeded and be removed.
+func requestTimeout(context *context.AutoscalingContext) time.Duration {
+	return context.NodeGroupDefaults.MaxNodeProvisionTime + context.ScaleDownDelayAfterAdd + context.NodeGroupDefaults.ScaleDownUnneededTime
+}
+
+// FilterOutAddedNodes returns the scale-down candidates without the nodes added to make room, so
+// that they aren't removed instead of the nodes they were added for. Requests end once none of
+// their nodes is a scale-down candidate anymore, or once the added nodes had enough time to come
+// up and the nodes to consolidate to become unneeded, in which case the node group is backed off.
+// Requests recorded in annotations of the candidates are restored, e.g. after a restart.
+func (o *Orchestrator) FilterOutAddedNodes(candidates []*apiv1.Node, now time.Time) []*apiv1.Node {
+	o.restoreRequests(candidates)
+	candidateNames := make(map[string]bool, len(candidates))
+	for _, node := range candidates {
+		candidateNames[node.Name] = true
+	}
+	timeout := requestTimeout(o.context)
+	for nodeGroupId, r := range o.requests {
+		pending := false
+		for nodeName := range r.nodes {
+			if candidateNames[nodeName] {
+				pending = true
+			}
+		}
+		if !pending {
+			o.endRequest(nodeGroupId, r)
+		} else if now.Sub(r.time) > timeout {
+			klog.V(1).Infof("Pods of %v nodes of node group %s weren't moved %v after making room for them, backing the node group off",
+				len(r.nodes), nodeGroupId, timeout)
+			o.backoff.Backoff(r.nodeGroup, nil, cloudprovider.InstanceErrorInfo{
+				ErrorClass: cloudprovider.OtherErrorClass,
+				ErrorCode:  "makeRoomNotConsolidated",
+			}, now)
+			o.endRequest(nodeGroupId, r)
+		}
+	}
+	o.backoff.RemoveStaleBackoffData(now)
+	if len(o.requests) == 0 {
+		return candidates
+	}
+
+	filtered := make([]*apiv1.Node, 0, len(candidates))
+	for _, node := range candidates {
+		nodeGroup, err := o.context.CloudProvider.NodeGroupForNode(node)
+		if err == nil && nodeGroup != nil && !reflect.ValueOf(nodeGroup).IsNil() {
+			if r, found := o.requests[nodeGroup.Id()]; found && !node.CreationTimestamp.Time.Before(r.time) {
+				klog.V(4).Infof("Skipping node %s from scale down, it was added to make room for pods of other nodes", node.Name)
+				continue
+			}
+		}
+		filtered = append(filtered, node)
+	}
+	return filtered
+}
+
+// restoreRequests restores the requests of node groups recorded in the annotations of the scale-down
+// candidates which aren't known yet.
+func (o *Orchestrator) restoreRequests(candidates []*apiv1.Node) {
+	for _, node := range candidates {
+		value, found := node.Annotations[RequestedAtAnnotation]
+		if !found {
+			continue
+		}
+		requestTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation of node %s: %v", RequestedAtAnnotation, node.Name, err)
+			continue
+		}
+		nodeGroup, err := o.context.CloudProvider.NodeGroupForNode(node)
+		if err != nil || nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		r, found := o.requests[nodeGroup.Id()]
+		if !found {
+			klog.V(4).Infof("Restoring request to make room in node group %s at %v", nodeGroup.Id(), requestTime)
+			r = &request{nodeGroup: nodeGroup, nodes: make(map[string]bool), time: requestTime}
+			o.requests[nodeGroup.Id()] = r
+		}
+		if r.time.Equal(requestTime) {
+			r.nodes[node.Name] = true
+		}
+	}
+}
+
+// endRequest forgets the request and removes its annotation from its nodes which are still there.
+func (o *Orchestrator) endRequest(nodeGroupId string, r *request) {
+	delete(o.requests, nodeGroupId)
+	for nodeName := range r.nodes {
+		if _, err := o.context.ClusterSnapshot.NodeInfos().Get(nodeName); err != nil {
+			continue
+		}
+		o.patchAnnotation(nodeName, nil)
+	}
+}
+
+// patchAnnotation sets the RequestedAtAnnotation of the node, or removes it if value is nil.
+func (o *Orchestrator) patchAnnotation(nodeName string, value *string) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{RequestedAtAnnotation: value},
+		},
+	})
+	if err != nil {
+		klog.Errorf("Failed to build annotation patch for node %s: %v", nodeName, err)
+		return
+	}
+	if _, err := o.context.ClientSet.CoreV1().Nodes().Patch(stdcontext.TODO(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.Warningf("Failed to update %s annotation of node %s: %v", RequestedAtAnnotation, nodeName, err)
+	}
+}
+
+// ScaleUp scales up at most one node group to make room for the pods of its nodes which are unremovable
+// only because there's no place to move their pods to. The nodes are only consolidated if PodDisruptionBudgets
+// allow moving all their pods, if their pods fit on at most ScaleDownMakeRoomMaxNodes new nodes, fewer than
+// the consolidated nodes, if the new nodes are within the cluster resource limits, and, if the cloud provider
+// supports pricing, if the new nodes cost less than the consolidated ones. Node groups whose nodes weren't
+// consolidated the last time room was made for them are backed off. Returns true if a node group was scaled up.
+func (o *Orchestrator) ScaleUp(unremovable []*simulator.UnremovableNode, nodeInfosForGroups map[string]*schedulerframework.NodeInfo, now time.Time) (bool, caerrors.AutoscalerError) {
+	nodeGroups := make(map[string]cloudprovider.NodeGroup)
+	nodesByNodeGroupId := make(map[string][]*apiv1.Node)
+	for _, unremovableNode := range unremovable {
+		if unremovableNode.Reason != simulator.NoPlaceToMovePods {
+			continue
+		}
+		node := unremovableNode.Node
+		nodeGroup, err := o.context.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			klog.Warningf("Failed to get node group for %s: %v", node.Name, err)
+			continue
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		nodeGroups[nodeGroup.Id()] = nodeGroup
+		nodesByNodeGroupId[nodeGroup.Id()] = append(nodesByNodeGroupId[nodeGroup.Id()], node)
+	}
+	if len(nodesByNodeGroupId) == 0 {
+		return false, nil
+	}
+
+	nodeInfos, err := o.context.ClusterSnapshot.NodeInfos().List()
+	if err != nil {
+		return false, caerrors.ToAutoscalerError(caerrors.InternalError, err)
+	}
+	nodeCount := len(nodeInfos)
+	upcomingCounts, _ := o.clusterStateRegistry.GetUpcomingNodes()
+	allNodes := make([]*apiv1.Node, 0, len(nodeInfos))
+	for _, nodeInfo := range nodeInfos {
+		allNodes = append(allNodes, nodeInfo.Node())
+	}
+	resourcesLeft, aErr := o.resourceManager.ResourcesLeft(o.context, nodeInfosForGroups, allNodes)
+	if aErr != nil {
+		return false, aErr.AddPrefix("could not compute total resources: ")
+	}
+
+	nodeGroupIds := make([]string, 0, len(nodesByNodeGroupId))
+	for nodeGroupId := range nodesByNodeGroupId {
+		nodeGroupIds = append(nodeGroupIds, nodeGroupId)
+	}
+	sort.Strings(nodeGroupIds)
+	for _, nodeGroupId := range nodeGroupIds {
+		nodeGroup := nodeGroups[nodeGroupId]
+		if _, found := o.requests[nodeGroupId]; found || upcomingCounts[nodeGroupId] > 0 {
+			continue
+		}
+		nodeTemplate, found := nodeInfosForGroups[nodeGroupId]
+		if !found {
+			continue
+		}
+		if o.backoff.BackoffStatus(nodeGroup, nil, now).IsBackedOff {
+			klog.V(4).Infof("Node group %s is backed off, not making room for pods of its nodes", nodeGroupId)
+			continue
+		}
+		if !o.clusterStateRegistry.NodeGroupScaleUpSafety(nodeGroup, now).SafeToScale {
+			klog.V(4).Infof("Node group %s is not safe to scale up, not making room for pods of its nodes", nodeGroupId)
+			continue
+		}
+
+		// Each node consolidated uses up PodDisruptionBudgets of the pods it moves.
+		remainingPdbTracker := pdb.NewBasicRemainingPdbTracker()
+		if err := remainingPdbTracker.SetPdbs(o.context.RemainingPdbTracker.GetPdbs()); err != nil {
+			return false, caerrors.ToAutoscalerError(caerrors.InternalError, err)
+		}
+		nodes, pods := o.movableNodes(nodesByNodeGroupId[nodeGroupId], remainingPdbTracker, now)
+		increase, ok := o.estimate(nodeGroup, nodeTemplate, nodes, pods, nodeCount)
+		if !ok || increase == 0 || increase >= len(nodes) || increase > o.context.ScaleDownMakeRoomMaxNodes {
+			continue
+		}
+		size, err := nodeGroup.TargetSize()
+		if err != nil {
+			klog.Warningf("Failed to get node group size; nodeGroup=%v; err=%v", nodeGroupId, err)
+			continue
+		}
+		if size+increase > nodeGroup.MaxSize() || (o.context.MaxNodesTotal > 0 && nodeCount+increase > o.context.MaxNodesTotal) {
+			klog.V(4).Infof("Max size reached, not making room for pods of %v nodes in node group %s", len(nodes), nodeGroupId)
+			continue
+		}
+		if !o.withinResourceLimits(nodeGroup, nodeTemplate, increase, resourcesLeft) {
+			klog.V(4).Infof("Resource limits reached, not making room for pods of %v nodes in node group %s", len(nodes), nodeGroupId)
+			continue
+		}
+		if !o.costsLess(nodeTemplate.Node(), increase, nodes, now) {
+			klog.V(4).Infof("Not making room for pods of %v nodes in node group %s, %v new nodes would cost more", len(nodes), nodeGroupId, increase)
+			continue
+		}
+
+		klog.V(0).Infof("Scaling up node group %v by %v to make room for pods of %v nodes", nodeGroupId, increase, len(nodes))
+		if err := nodeGroup.IncreaseSize(increase); err != nil {
+			o.context.LogRecorder.Eventf(apiv1.EventTypeWarning, "MakeRoomFailed",
+				"Failed to scale up group %s to make room for pods of %v nodes: %v", nodeGroupId, len(nodes), err)
+			return false, caerrors.ToAutoscalerError(caerrors.CloudProviderError, err)
+		}
+		o.clusterStateRegistry.RegisterScaleUp(nodeGroup, increase, now)
+		r := &request{nodeGroup: nodeGroup, nodes: make(map[string]bool, len(nodes)), time: now}
+		requestedAt := now.Format(time.RFC3339)
+		for _, node := range nodes {
+			r.nodes[node.Name] = true
+			o.patchAnnotation(node.Name, &requestedAt)
+		}
+		o.requests[nodeGroupId] = r
+		o.context.LogRecorder.Eventf(apiv1.EventTypeNormal, "MakeRoom",
+			"Scale-up: group %s size set to %d to make room for pods of %v nodes", nodeGroupId, size+increase, len(nodes))
+		return true, nil
+	}
+	return false, nil
+}
+
+// movableNodes returns the nodes whose pods can be moved without violating PodDisruptionBudgets,
+// along with these pods, and updates the remaining PodDisruptionBudgets accordingly.
+func (o *Orchestrator) movableNodes(candidates []*apiv1.Node, remainingPdbTracker pdb.RemainingPdbTracker, now time.Time) ([]*apiv1.Node, []*apiv1.Pod) {
+	var nodes []*apiv1.Node
+	var pods []*apiv1.Pod
+	for _, node := range candidates {
+		nodeInfo, err := o.context.ClusterSnapshot.NodeInfos().Get(node.Name)
+		if err != nil {
+			klog.Errorf("Can't retrieve node %s from snapshot, err: %v", node.Name, err)
+			continue
+		}
+		podsToMove, _, _, err := simulator.GetPodsToMove(nodeInfo, o.deleteOptions, o.drainabilityRules, o.context.ListerRegistry, remainingPdbTracker, now)
+		if err != nil {
+			continue
+		}
+		if canRemove, _, _ := remainingPdbTracker.CanRemovePods(podsToMove); !canRemove {
+			continue
+		}
+		remainingPdbTracker.RemovePods(podsToMove)
+		nodes = append(nodes, node)
+		pods = append(pods, podsToMove...)
+	}
+	return nodes, pods
+}
+
+// estimate returns how many new nodes of the node group are needed for the pods of nodes, once
+// these nodes are removed, and whether all the pods fit on them.
+func (o *Orchestrator) estimate(nodeGroup cloudprovider.NodeGroup, nodeTemplate *schedulerframework.NodeInfo, nodes []*apiv1.Node, pods []*apiv1.Pod, nodeCount int) (int, bool) {
+	if len(nodes) < 2 {
+		return 0, false
+	}
+	snapshot := o.context.ClusterSnapshot
+	snapshot.Fork()
+	defer snapshot.Revert()
+	for _, node := range nodes {
+		if err := snapshot.RemoveNode(node.Name); err != nil {
+			klog.Errorf("Failed to remove node %s from snapshot: %v", node.Name, err)
+			return 0, false
+		}
+	}
+
+	podEquivalenceGroups := make([]estimator.PodEquivalenceGroup, 0, len(pods))
+	for _, pod := range pods {
+		pendingPod := pod.DeepCopy()
+		pendingPod.Spec.NodeName = ""
+		podEquivalenceGroups = append(podEquivalenceGroups, estimator.PodEquivalenceGroup{Pods: []*apiv1.Pod{pendingPod}})
+	}
+	estimationContext := estimator.NewEstimationContext(o.context.MaxNodesTotal, nil, nodeCount-len(nodes))
+	e := o.estimatorBuilder(o.context.PredicateChecker, snapshot, estimationContext)
+	increase, scheduledPods := e.Estimate(podEquivalenceGroups, nodeTemplate, nodeGroup)
+	return increase, len(scheduledPods) == len(pods)
+}
+
+// withinResourceLimits returns whether increase nodes built from the template fit in the resources
+// left below the cluster resource limits.
+func (o *Orchestrator) withinResourceLimits(nodeGroup cloudprovider.NodeGroup, nodeTemplate *schedulerframework.NodeInfo, increase int, resourcesLeft resource.Limits) bool {
+	delta, err := o.resourceManager.DeltaForNode(o.context, nodeTemplate, nodeGroup)
+	if err != nil {
+		klog.Warningf("Failed to get resources of node group %s: %v", nodeGroup.Id(), err)
+		return false
+	}
+	for resourceName, resourceDelta := range delta {
+		delta[resourceName] = resourceDelta * int64(increase)
+	}
+	return !resource.CheckDeltaWithinLimits(resourcesLeft, delta).Exceeded
+}
+
+// costsLess returns whether increase nodes built from the template cost less than nodes. It returns
+// true if the cloud provider doesn't support pricing, as consolidation reduces the node count anyway.
+func (o *Orchestrator) costsLess(template *apiv1.Node, increase int, nodes []*apiv1.Node, now time.Time) bool {
+	pricingModel, pricingErr := o.context.CloudProvider.Pricing()
+	if pricingErr != nil {
+		return true
+	}
+	end := now.Add(time.Hour)
+	price, err := pricingModel.NodePrice(template, now, end)
+	if err != nil {
+		klog.Warningf("Failed to get price of node template %s: %v", template.Name, err)
+		return false
+	}
+	newCost := price * float64(increase)
+	oldCost := 0.0
+	for _, node := range nodes {
+		price, err := pricingModel.NodePrice(node, now, end)
+		if err != nil {
+			klog.Warningf("Failed to get price of node %s: %v", node.Name, err)
+			return false
+		}
+		oldCost += price
+	}
+	return newCost < oldCost
+}
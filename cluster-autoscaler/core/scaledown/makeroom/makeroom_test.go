@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package makeroom
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
+	"k8s.io/autoscaler/cluster-autoscaler/estimator"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/customresources"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupconfig"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/options"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+type testPricingModel struct {
+	nodePrice map[string]float64
+}
+
+func (tpm *testPricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	if price, found := tpm.nodePrice[node.Name]; found {
+		return price, nil
+	}
+	return 0.0, fmt.Errorf("price for node %v not found", node.Name)
+}
+
+func (tpm *testPricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	return 0.0, nil
+}
+
+func TestScaleUp(t *testing.T) {
+	now := time.Now()
+	var nodes []*apiv1.Node
+	var pods []*apiv1.Pod
+	for i := 1; i <= 3; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 1000)
+		SetNodeReadyState(node, true, now.Add(-time.Hour))
+		nodes = append(nodes, node)
+		pod := BuildTestPod(fmt.Sprintf("p%d", i), 600, 100)
+		pod.Spec.NodeName = node.Name
+		pod.Labels = map[string]string{"app": "a"}
+		pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "apps/v1", "rs-uid")
+		pods = append(pods, pod)
+	}
+	noPlaceToMovePods := func(reason simulator.UnremovableReason) []*simulator.UnremovableNode {
+		var unremovable []*simulator.UnremovableNode
+		for _, node := range nodes {
+			unremovable = append(unremovable, &simulator.UnremovableNode{Node: node, Reason: reason})
+		}
+		return unremovable
+	}
+	pdb := func(disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "default"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: disruptionsAllowed},
+		}
+	}
+
+	testCases := map[string]struct {
+		unremovable  []*simulator.UnremovableNode
+		templateSize int64
+		maxNodes     int
+		pdbs         []*policyv1.PodDisruptionBudget
+		prices       map[string]float64
+		coresLimit   int64
+		wantIncrease int
+	}{
+		"pods of three nodes fit on one new node": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			pdbs:         []*policyv1.PodDisruptionBudget{pdb(3)},
+			wantIncrease: 1,
+		},
+		"nodes unremovable for other reasons": {
+			unremovable:  noPlaceToMovePods(simulator.BlockedByPod),
+			templateSize: 2000,
+			maxNodes:     1,
+		},
+		"as many new nodes as consolidated nodes": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 1000,
+			maxNodes:     3,
+		},
+		"more new nodes than allowed": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 1500,
+			maxNodes:     1,
+		},
+		"pod disruption budget allows moving pods of a single node": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			pdbs:         []*policyv1.PodDisruptionBudget{pdb(1)},
+		},
+		"pod disruption budget allows moving pods of two nodes": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			pdbs:         []*policyv1.PodDisruptionBudget{pdb(2)},
+			wantIncrease: 1,
+		},
+		"new node cheaper": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			prices:       map[string]float64{"template": 2, "n1": 1, "n2": 1, "n3": 1},
+			wantIncrease: 1,
+		},
+		"new node more expensive": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			prices:       map[string]float64{"template": 4, "n1": 1, "n2": 1, "n3": 1},
+		},
+		"new node within cores limit": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			coresLimit:   8,
+			wantIncrease: 1,
+		},
+		"new node over cores limit": {
+			unremovable:  noPlaceToMovePods(simulator.NoPlaceToMovePods),
+			templateSize: 2000,
+			maxNodes:     1,
+			coresLimit:   7,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			increases := make(map[string]int)
+			provider := testprovider.NewTestCloudProvider(func(nodeGroup string, increase int) error {
+				increases[nodeGroup] += increase
+				return nil
+			}, nil)
+			provider.AddNodeGroup("ng1", 0, 10, 3)
+			for _, node := range nodes {
+				provider.AddNode("ng1", node)
+			}
+			if tc.prices != nil {
+				provider.SetPricingModel(&testPricingModel{nodePrice: tc.prices})
+			}
+			if tc.coresLimit > 0 {
+				provider.SetResourceLimiter(cloudprovider.NewResourceLimiter(nil, map[string]int64{cloudprovider.ResourceNameCores: tc.coresLimit}))
+			}
+
+			opts := config.AutoscalingOptions{
+				EstimatorName:             estimator.BinpackingEstimatorName,
+				ScaleDownMakeRoom:         true,
+				ScaleDownMakeRoomMaxNodes: tc.maxNodes,
+				NodeGroupDefaults:         config.NodeGroupAutoscalingOptions{MaxNodeProvisionTime: 15 * time.Minute},
+			}
+			listers := kube_util.NewListerRegistry(nil, nil, kube_util.NewTestPodLister(pods), nil, nil, nil, nil, nil, nil)
+			var objects []runtime.Object
+			for _, node := range nodes {
+				objects = append(objects, node)
+			}
+			client := fake.NewSimpleClientset(objects...)
+			ctx, err := NewScaleTestAutoscalingContext(opts, client, listers, provider, nil, nil)
+			assert.NoError(t, err)
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, ctx.ClusterSnapshot, nodes, pods)
+			assert.NoError(t, ctx.RemainingPdbTracker.SetPdbs(tc.pdbs))
+
+			template := schedulerframework.NewNodeInfo()
+			template.SetNode(BuildTestNode("template", tc.templateSize, 1000))
+			nodeInfosForGroups := map[string]*schedulerframework.NodeInfo{"ng1": template}
+			estimatorBuilder, err := estimator.NewEstimatorBuilder(estimator.BinpackingEstimatorName,
+				estimator.NewThresholdBasedEstimationLimiter(nil), estimator.NewDecreasingPodOrderer(), nil)
+			assert.NoError(t, err)
+			newOrchestrator := func() *Orchestrator {
+				clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, ctx.LogRecorder, NewBackoff(),
+					nodegroupconfig.NewDefaultNodeGroupConfigProcessor(opts.NodeGroupDefaults))
+				assert.NoError(t, clusterState.UpdateNodes(nodes, nodeInfosForGroups, now))
+				return NewOrchestrator(&ctx, clusterState, estimatorBuilder, options.NodeDeleteOptions{}, nil,
+					customresources.NewDefaultCustomResourcesProcessor())
+			}
+			o := newOrchestrator()
+
+			scaledUp, scaleUpErr := o.ScaleUp(tc.unremovable, nodeInfosForGroups, now)
+			assert.NoError(t, scaleUpErr)
+			assert.Equal(t, tc.wantIncrease > 0, scaledUp)
+			assert.Equal(t, tc.wantIncrease, increases["ng1"])
+			if !scaledUp {
+				return
+			}
+
+			// Another scale-up isn't requested while the first one is pending.
+			scaledUp, scaleUpErr = o.ScaleUp(tc.unremovable, nodeInfosForGroups, now.Add(time.Minute))
+			assert.NoError(t, scaleUpErr)
+			assert.False(t, scaledUp)
+
+			// The added node isn't scaled down until the consolidated nodes are gone.
+			added := BuildTestNode("n4", 2000, 1000)
+			added.CreationTimestamp = metav1.NewTime(now.Add(5 * time.Minute))
+			provider.AddNode("ng1", added)
+			candidates := append([]*apiv1.Node{added}, nodes...)
+			assert.Equal(t, nodes, o.FilterOutAddedNodes(candidates, now.Add(10*time.Minute)))
+
+			// The request is recorded in annotations of the consolidated nodes and restored after a restart.
+			getNodes := func() []*apiv1.Node {
+				var result []*apiv1.Node
+				for _, node := range nodes {
+					n, err := client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+					assert.NoError(t, err)
+					result = append(result, n)
+				}
+				return result
+			}
+			annotated := getNodes()
+			for _, node := range annotated {
+				if o.requests["ng1"].nodes[node.Name] {
+					assert.Equal(t, now.Format(time.RFC3339), node.Annotations[RequestedAtAnnotation])
+				} else {
+					assert.NotContains(t, node.Annotations, RequestedAtAnnotation)
+				}
+			}
+			restarted := newOrchestrator()
+			assert.Equal(t, annotated, restarted.FilterOutAddedNodes(append([]*apiv1.Node{added}, annotated...), now.Add(10*time.Minute)))
+
+			// If the consolidated nodes aren't removed in time, the annotations are removed and the node group is backed off.
+			candidates = append([]*apiv1.Node{added}, annotated...)
+			assert.Equal(t, candidates, restarted.FilterOutAddedNodes(candidates, now.Add(20*time.Minute)))
+			for _, node := range getNodes() {
+				assert.NotContains(t, node.Annotations, RequestedAtAnnotation)
+			}
+			scaledUp, scaleUpErr = restarted.ScaleUp(tc.unremovable, nodeInfosForGroups, now.Add(20*time.Minute))
+			assert.NoError(t, scaleUpErr)
+			assert.False(t, scaledUp)
+
+			// Once the consolidated nodes are gone, the added node can be scaled down.
+			assert.Equal(t, []*apiv1.Node{added}, o.FilterOutAddedNodes([]*apiv1.Node{added}, now.Add(15*time.Minute)))
+		})
+	}
+}
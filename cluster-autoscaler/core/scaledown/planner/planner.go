@@ -27,7 +27,6 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/eligibility"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/resource"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/unneeded"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/unremovable"
@@ -40,6 +39,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/scheduling"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/utilization"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	pod_util "k8s.io/autoscaler/cluster-autoscaler/utils/pod"
 	klog "k8s.io/klog/v2"
 )
@@ -51,8 +51,13 @@ type eligibilityChecker interface {
 type removalSimulator interface {
 	DropOldHints()
 	SimulateNodeRemoval(node string, podDestinations map[string]bool, timestamp time.Time, remainingPdbTracker pdb.RemainingPdbTracker) (*simulator.NodeToBeRemoved, *simulator.UnremovableNode)
+	FindUnremovableNodes(candidates []string, podDestinations map[string]bool, timestamp time.Time, remainingPdbTracker pdb.RemainingPdbTracker, workers int) map[string]*simulator.UnremovableNode
 }
 
+// candidatesPerSimulationWorker is the number of scale down candidates checked concurrently
+// per worker before the candidates which may be removable are simulated sequentially.
+const candidatesPerSimulationWorker = 4
+
 // controllerReplicasCalculator calculates a number of target and expected replicas for a given controller.
 type controllerReplicasCalculator interface {
 	getReplicas(metav1.OwnerReference, string) (*replicasInfo, error)
@@ -89,7 +94,7 @@ func New(context *context.AutoscalingContext, processors *processors.Autoscaling
 	return &Planner{
 		context:               context,
 		unremovableNodes:      unremovable.NewNodes(),
-		unneededNodes:         unneeded.NewNodes(processors.NodeGroupConfigProcessor, resourceLimitsFinder),
+		unneededNodes:         unneeded.NewNodes(processors.NodeGroupConfigProcessor, resourceLimitsFinder, context.MaxPodScaleDownUnneededTime),
 		rs:                    simulator.NewRemovalSimulator(context.ListerRegistry, context.ClusterSnapshot, context.PredicateChecker, simulator.NewUsageTracker(), deleteOptions, drainabilityRules, true),
 		actuationInjector:     scheduling.NewHintingSimulator(context.PredicateChecker),
 		eligibilityChecker:    eligibility.NewChecker(processors.NodeGroupConfigProcessor),
@@ -192,6 +197,11 @@ func (p *Planner) UnneededNodes() []*apiv1.Node {
 	return p.unneededNodes.AsList()
 }
 
+// ExtendedUnneededTimes returns the unneeded times of unneeded nodes extended by their pods.
+func (p *Planner) ExtendedUnneededTimes() map[string]time.Duration {
+	return p.unneededNodes.ExtendedUnneededTimes()
+}
+
 // UnremovableNodes returns a list of nodes currently considered as unremovable.
 func (p *Planner) UnremovableNodes() []*simulator.UnremovableNode {
 	return p.unremovableNodes.AsList()
@@ -270,6 +280,8 @@ func (p *Planner) categorizeNodes(podDestinations map[string]bool, scaleDownCand
 	}
 	p.nodeUtilizationMap = utilizationMap
 	timer := time.NewTimer(p.context.ScaleDownSimulationTimeout)
+	workers := p.context.ScaleDownSimulationWorkers
+	var prechecked map[string]*simulator.UnremovableNode
 
 	for i, node := range currentlyUnneededNodeNames {
 		if timedOut(timer) {
@@ -280,7 +292,17 @@ func (p *Planner) categorizeNodes(podDestinations map[string]bool, scaleDownCand
 			klog.V(4).Infof("%d out of %d nodes skipped in scale down simulation: there are already %d unneeded nodes so no point in looking for more. Total atomic scale down nodes: %d", len(currentlyUnneededNodeNames)-i, len(currentlyUnneededNodeNames), len(removableList), atomicScaleDownNodesCount)
 			break
 		}
-		removable, unremovable := p.rs.SimulateNodeRemoval(node, podDestinations, p.latestUpdate, p.context.RemainingPdbTracker)
+		if workers > 1 && i%(workers*candidatesPerSimulationWorker) == 0 {
+			// Nodes found unremovable concurrently stay unremovable once the nodes before them are removed,
+			// only the other ones have to be simulated sequentially.
+			batch := currentlyUnneededNodeNames[i:min(i+workers*candidatesPerSimulationWorker, len(currentlyUnneededNodeNames))]
+			prechecked = p.rs.FindUnremovableNodes(batch, podDestinations, p.latestUpdate, p.context.RemainingPdbTracker, workers)
+		}
+		var removable *simulator.NodeToBeRemoved
+		unremovable, found := prechecked[node]
+		if !found {
+			removable, unremovable = p.rs.SimulateNodeRemoval(node, podDestinations, p.latestUpdate, p.context.RemainingPdbTracker)
+		}
 		if removable != nil {
 			_, inParallel, _ := p.context.RemainingPdbTracker.CanRemovePods(removable.PodsToReschedule)
 			if !inParallel {
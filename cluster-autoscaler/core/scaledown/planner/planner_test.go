@@ -31,7 +31,6 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/deletiontracker"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/status"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/unremovable"
 	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
@@ -40,6 +39,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/options"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/utilization"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/client-go/kubernetes/fake"
@@ -477,44 +477,49 @@ func TestUpdateClusterState(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			if tc.replicasSets == nil {
-				tc.replicasSets = generateReplicaSets("rs", 5)
-			}
-			rsLister, err := kube_util.NewTestReplicaSetLister(tc.replicasSets)
-			assert.NoError(t, err)
-			registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, rsLister, nil)
-			provider := testprovider.NewTestCloudProvider(nil, nil)
-			provider.AddNodeGroup("ng1", 0, 0, 0)
-			for _, node := range tc.nodes {
-				provider.AddNode("ng1", node)
-			}
-			context, err := NewScaleTestAutoscalingContext(config.AutoscalingOptions{
-				NodeGroupDefaults: config.NodeGroupAutoscalingOptions{
-					ScaleDownUnneededTime: 10 * time.Minute,
-				},
-				ScaleDownSimulationTimeout: 1 * time.Second,
-				MaxScaleDownParallelism:    10,
-			}, &fake.Clientset{}, registry, provider, nil, nil)
-			assert.NoError(t, err)
-			clustersnapshot.InitializeClusterSnapshotOrDie(t, context.ClusterSnapshot, tc.nodes, tc.pods)
-			deleteOptions := options.NodeDeleteOptions{}
-			p := New(&context, NewTestProcessors(&context), deleteOptions, nil)
-			p.eligibilityChecker = &fakeEligibilityChecker{eligible: asMap(tc.eligible)}
-			if tc.isSimulationTimeout {
-				context.AutoscalingOptions.ScaleDownSimulationTimeout = 1 * time.Second
-				rs := &fakeRemovalSimulator{
-					nodes: tc.nodes,
-					sleep: 2 * time.Second,
-				}
-				p.rs = rs
-			}
-			// TODO(x13n): test subsets of nodes passed as podDestinations/scaleDownCandidates.
-			assert.NoError(t, p.UpdateClusterState(tc.nodes, tc.nodes, tc.actuationStatus, time.Now()))
-			wantUnneeded := asMap(tc.wantUnneeded)
-			wantUnremovable := asMap(tc.wantUnremovable)
-			for _, n := range tc.nodes {
-				assert.Equal(t, wantUnneeded[n.Name], p.unneededNodes.Contains(n.Name), []string{n.Name, "unneeded"})
-				assert.Equal(t, wantUnremovable[n.Name], p.unremovableNodes.Contains(n.Name), []string{n.Name, "unremovable"})
+			for _, workers := range []int{1, 4} {
+				t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+					if tc.replicasSets == nil {
+						tc.replicasSets = generateReplicaSets("rs", 5)
+					}
+					rsLister, err := kube_util.NewTestReplicaSetLister(tc.replicasSets)
+					assert.NoError(t, err)
+					registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, rsLister, nil)
+					provider := testprovider.NewTestCloudProvider(nil, nil)
+					provider.AddNodeGroup("ng1", 0, 0, 0)
+					for _, node := range tc.nodes {
+						provider.AddNode("ng1", node)
+					}
+					context, err := NewScaleTestAutoscalingContext(config.AutoscalingOptions{
+						NodeGroupDefaults: config.NodeGroupAutoscalingOptions{
+							ScaleDownUnneededTime: 10 * time.Minute,
+						},
+						ScaleDownSimulationTimeout: 1 * time.Second,
+						ScaleDownSimulationWorkers: workers,
+						MaxScaleDownParallelism:    10,
+					}, &fake.Clientset{}, registry, provider, nil, nil)
+					assert.NoError(t, err)
+					clustersnapshot.InitializeClusterSnapshotOrDie(t, context.ClusterSnapshot, tc.nodes, tc.pods)
+					deleteOptions := options.NodeDeleteOptions{}
+					p := New(&context, NewTestProcessors(&context), deleteOptions, nil)
+					p.eligibilityChecker = &fakeEligibilityChecker{eligible: asMap(tc.eligible)}
+					if tc.isSimulationTimeout {
+						context.AutoscalingOptions.ScaleDownSimulationTimeout = 1 * time.Second
+						rs := &fakeRemovalSimulator{
+							nodes: tc.nodes,
+							sleep: 2 * time.Second,
+						}
+						p.rs = rs
+					}
+					// TODO(x13n): test subsets of nodes passed as podDestinations/scaleDownCandidates.
+					assert.NoError(t, p.UpdateClusterState(tc.nodes, tc.nodes, tc.actuationStatus, time.Now()))
+					wantUnneeded := asMap(tc.wantUnneeded)
+					wantUnremovable := asMap(tc.wantUnremovable)
+					for _, n := range tc.nodes {
+						assert.Equal(t, wantUnneeded[n.Name], p.unneededNodes.Contains(n.Name), []string{n.Name, "unneeded"})
+						assert.Equal(t, wantUnremovable[n.Name], p.unremovableNodes.Contains(n.Name), []string{n.Name, "unremovable"})
+					}
+				})
 			}
 		})
 	}
@@ -983,6 +988,10 @@ type fakeRemovalSimulator struct {
 
 func (r *fakeRemovalSimulator) DropOldHints() {}
 
+func (r *fakeRemovalSimulator) FindUnremovableNodes(_ []string, _ map[string]bool, _ time.Time, _ pdb.RemainingPdbTracker, _ int) map[string]*simulator.UnremovableNode {
+	return nil
+}
+
 func (r *fakeRemovalSimulator) SimulateNodeRemoval(name string, _ map[string]bool, _ time.Time, _ pdb.RemainingPdbTracker) (*simulator.NodeToBeRemoved, *simulator.UnremovableNode) {
 	time.Sleep(r.sleep)
 	node := &apiv1.Node{}
@@ -40,6 +40,9 @@ type Planner interface {
 	// right now or in a near future, assuming nothing will change in the
 	// cluster.
 	UnneededNodes() []*apiv1.Node
+	// ExtendedUnneededTimes returns the scale-down unneeded times of unneeded
+	// nodes extended by annotations of their pods, by node name.
+	ExtendedUnneededTimes() map[string]time.Duration
 	// UnremovableNodes returns a list of nodes that cannot be removed.
 	// TODO(x13n): Add a guarantee that each node is either unneeded or
 	// unremovable. This is not guaranteed by the current implementation.
@@ -34,17 +34,26 @@ import (
 	klog "k8s.io/klog/v2"
 )
 
+// ScaleDownUnneededTimeAnnotationKey is the annotation of pods which extends the scale-down
+// unneeded time of nodes running them, e.g. to keep warm nodes between waves of batch jobs.
+const ScaleDownUnneededTimeAnnotationKey = "cluster-autoscaler.kubernetes.io/scale-down-unneeded-time"
+
 // Nodes tracks the state of cluster nodes that are not needed.
 type Nodes struct {
 	sdtg         scaleDownTimeGetter
 	limitsFinder *resource.LimitsFinder
-	cachedList   []*apiv1.Node
-	byName       map[string]*node
+	// maxPodsUnneededTime caps the unneeded time requested by pods.
+	maxPodsUnneededTime time.Duration
+	cachedList          []*apiv1.Node
+	byName              map[string]*node
 }
 
 type node struct {
 	ntbr  simulator.NodeToBeRemoved
 	since time.Time
+	// podsUnneededTime is the longest unneeded time requested by pods seen on
+	// the node since it became unneeded.
+	podsUnneededTime time.Duration
 }
 
 type scaleDownTimeGetter interface {
@@ -54,11 +63,13 @@ type scaleDownTimeGetter interface {
 	GetScaleDownUnreadyTime(nodeGroup cloudprovider.NodeGroup) (time.Duration, error)
 }
 
-// NewNodes returns a new initialized Nodes object.
-func NewNodes(sdtg scaleDownTimeGetter, limitsFinder *resource.LimitsFinder) *Nodes {
+// NewNodes returns a new initialized Nodes object. Unneeded times requested by pods
+// are capped at maxPodsUnneededTime.
+func NewNodes(sdtg scaleDownTimeGetter, limitsFinder *resource.LimitsFinder, maxPodsUnneededTime time.Duration) *Nodes {
 	return &Nodes{
-		sdtg:         sdtg,
-		limitsFinder: limitsFinder,
+		sdtg:                sdtg,
+		limitsFinder:        limitsFinder,
+		maxPodsUnneededTime: maxPodsUnneededTime,
 	}
 }
 
@@ -69,10 +80,14 @@ func (n *Nodes) Update(nodes []simulator.NodeToBeRemoved, ts time.Time) {
 	for _, nn := range nodes {
 		name := nn.Node.Name
 		updated[name] = &node{
-			ntbr: nn,
+			ntbr:             nn,
+			podsUnneededTime: podsUnneededTime(nn, n.maxPodsUnneededTime),
 		}
 		if val, found := n.byName[name]; found {
 			updated[name].since = val.since
+			if val.podsUnneededTime > updated[name].podsUnneededTime {
+				updated[name].podsUnneededTime = val.podsUnneededTime
+			}
 		} else {
 			updated[name].since = ts
 		}
@@ -86,6 +101,45 @@ func (n *Nodes) Update(nodes []simulator.NodeToBeRemoved, ts time.Time) {
 	}
 }
 
+// podsUnneededTime returns the longest unneeded time requested by pods on the node,
+// with the ScaleDownUnneededTimeAnnotationKey annotation, capped at maxUnneededTime.
+func podsUnneededTime(nn simulator.NodeToBeRemoved, maxUnneededTime time.Duration) time.Duration {
+	var result time.Duration
+	for _, pods := range [][]*apiv1.Pod{nn.PodsToReschedule, nn.DaemonSetPods} {
+		for _, pod := range pods {
+			value, found := pod.Annotations[ScaleDownUnneededTimeAnnotationKey]
+			if !found {
+				continue
+			}
+			unneededTime, err := time.ParseDuration(value)
+			if err != nil || unneededTime < 0 {
+				klog.Warningf("Ignoring invalid %s annotation of pod %s/%s: %q", ScaleDownUnneededTimeAnnotationKey, pod.Namespace, pod.Name, value)
+				continue
+			}
+			if unneededTime > maxUnneededTime {
+				klog.V(4).Infof("Lowering %s annotation of pod %s/%s from %v to %v", ScaleDownUnneededTimeAnnotationKey, pod.Namespace, pod.Name, unneededTime, maxUnneededTime)
+				unneededTime = maxUnneededTime
+			}
+			if unneededTime > result {
+				result = unneededTime
+			}
+		}
+	}
+	return result
+}
+
+// ExtendedUnneededTimes returns the unneeded times requested by pods of unneeded nodes,
+// by node name. Nodes without such pods are skipped.
+func (n *Nodes) ExtendedUnneededTimes() map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for name, v := range n.byName {
+		if v.podsUnneededTime > 0 {
+			result[name] = v.podsUnneededTime
+		}
+	}
+	return result
+}
+
 // Clear resets the internal state, dropping information about all tracked nodes.
 func (n *Nodes) Clear() {
 	n.Update(nil, time.Time{})
@@ -167,6 +221,10 @@ func (n *Nodes) unremovableReason(context *context.AutoscalingContext, v *node,
 			klog.Errorf("Error trying to get ScaleDownUnneededTime for node %s (in group: %s)", node.Name, nodeGroup.Id())
 			return simulator.UnexpectedError
 		}
+		// Pods may keep their nodes for longer than the node group would.
+		if v.podsUnneededTime > 0 && v.podsUnneededTime > unneededTime {
+			unneededTime = v.podsUnneededTime
+		}
 		if !v.since.Add(unneededTime).Before(ts) {
 			return simulator.NotUnneededLongEnough
 		}
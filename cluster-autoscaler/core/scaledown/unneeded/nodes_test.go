@@ -98,7 +98,7 @@ func TestUpdate(t *testing.T) {
 		tc := tc
 		t.Run(tc.desc, func(t *testing.T) {
 			t.Parallel()
-			nodes := NewNodes(nil, nil)
+			nodes := NewNodes(nil, nil, time.Hour)
 			nodes.Update(tc.initialNodes, initialTimestamp)
 			nodes.Update(tc.finalNodes, finalTimestamp)
 			wantNodes := len(tc.wantTimestamps)
@@ -201,7 +201,7 @@ func TestRemovableAt(t *testing.T) {
 			ctx, err := NewScaleTestAutoscalingContext(config.AutoscalingOptions{ScaleDownSimulationTimeout: 5 * time.Minute}, &fake.Clientset{}, registry, provider, nil, nil)
 			assert.NoError(t, err)
 
-			n := NewNodes(&fakeScaleDownTimeGetter{}, &resource.LimitsFinder{})
+			n := NewNodes(&fakeScaleDownTimeGetter{}, &resource.LimitsFinder{}, time.Hour)
 			n.Update(nodes, time.Now())
 			gotEmptyToRemove, gotDrainToRemove, _ := n.RemovableAt(&ctx, time.Now(), resource.Limits{}, []string{}, as)
 			if len(gotDrainToRemove) != tc.numDrainToRemove || len(gotEmptyToRemove) != tc.numEmptyToRemove {
@@ -211,6 +211,48 @@ func TestRemovableAt(t *testing.T) {
 	}
 }
 
+func TestRemovableAtExtendedUnneededTime(t *testing.T) {
+	now := time.Now()
+	ng := testprovider.NewTestNodeGroup("ng", 100, 0, 10, true, false, "", nil, nil)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.InsertNodeGroup(ng)
+	node := BuildTestNode("n", 10, 100)
+	SetNodeReadyState(node, true, now.Add(-time.Hour))
+	provider.AddNode("ng", node)
+
+	rsLister, err := kube_util.NewTestReplicaSetLister(nil)
+	assert.NoError(t, err)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, rsLister, nil)
+	ctx, err := NewScaleTestAutoscalingContext(config.AutoscalingOptions{ScaleDownSimulationTimeout: 5 * time.Minute}, &fake.Clientset{}, registry, provider, nil, nil)
+	assert.NoError(t, err)
+
+	pod := BuildTestPod("p", 1, 1)
+	pod.Annotations = map[string]string{ScaleDownUnneededTimeAnnotationKey: "10m"}
+	invalid := BuildTestPod("invalid", 1, 1)
+	invalid.Annotations = map[string]string{ScaleDownUnneededTimeAnnotationKey: "soon"}
+
+	n := NewNodes(&fakeScaleDownTimeGetter{}, &resource.LimitsFinder{}, time.Hour)
+	n.Update([]simulator.NodeToBeRemoved{{Node: node, PodsToReschedule: []*apiv1.Pod{pod, invalid}}}, now)
+	assert.Equal(t, map[string]time.Duration{"n": 10 * time.Minute}, n.ExtendedUnneededTimes())
+	_, _, unremovable := n.RemovableAt(&ctx, now.Add(5*time.Minute), resource.Limits{}, []string{}, &fakeActuationStatus{})
+	assert.Len(t, unremovable, 1)
+	assert.Equal(t, simulator.NotUnneededLongEnough, unremovable[0].Reason)
+
+	// The unneeded time is kept after the pod is gone, as long as the node is unneeded.
+	n.Update([]simulator.NodeToBeRemoved{{Node: node}}, now.Add(5*time.Minute))
+	empty, _, _ := n.RemovableAt(&ctx, now.Add(11*time.Minute), resource.Limits{}, []string{}, &fakeActuationStatus{})
+	assert.Len(t, empty, 1)
+
+	n.Update(nil, now.Add(12*time.Minute))
+	n.Update([]simulator.NodeToBeRemoved{{Node: node}}, now.Add(13*time.Minute))
+	assert.Empty(t, n.ExtendedUnneededTimes())
+
+	// Requested unneeded times are capped.
+	pod.Annotations[ScaleDownUnneededTimeAnnotationKey] = "720h"
+	n.Update([]simulator.NodeToBeRemoved{{Node: node, PodsToReschedule: []*apiv1.Pod{pod}}}, now.Add(14*time.Minute))
+	assert.Equal(t, map[string]time.Duration{"n": time.Hour}, n.ExtendedUnneededTimes())
+}
+
 type fakeActuationStatus struct {
 	recentEvictions []*apiv1.Pod
 	deletionCount   map[string]int
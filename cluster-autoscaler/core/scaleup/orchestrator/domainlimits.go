@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// domain is a value of a node label, e.g. a single zone.
+type domain struct {
+	label string
+	value string
+}
+
+// domainNodeLimits enforces the limits on the number of nodes in each domain
+// of node labels, e.g. in each zone or instance family. Nodes without the label
+// of a limit aren't limited by it.
+type domainNodeLimits struct {
+	limits []config.DomainNodeLimits
+	// nodeCounts holds the number of existing, upcoming and planned nodes in each domain.
+	nodeCounts map[domain]int
+}
+
+func newDomainNodeLimits(limits []config.DomainNodeLimits, nodes []*apiv1.Node, upcomingNodes []*schedulerframework.NodeInfo) *domainNodeLimits {
+	d := &domainNodeLimits{limits: limits, nodeCounts: map[domain]int{}}
+	if len(limits) == 0 {
+		return d
+	}
+	for _, node := range nodes {
+		d.add(node, 1)
+	}
+	for _, nodeInfo := range upcomingNodes {
+		d.add(nodeInfo.Node(), 1)
+	}
+	return d
+}
+
+func (d *domainNodeLimits) add(node *apiv1.Node, count int) {
+	for _, dom := range d.domains(node) {
+		d.nodeCounts[dom] += count
+	}
+}
+
+func (d *domainNodeLimits) domains(node *apiv1.Node) []domain {
+	if node == nil {
+		return nil
+	}
+	var result []domain
+	for _, limit := range d.limits {
+		if value, found := node.Labels[limit.Label]; found {
+			result = append(result, domain{label: limit.Label, value: value})
+		}
+	}
+	return result
+}
+
+// headroom returns the number of nodes which can still be added to the domains
+// of the node group, or -1 if it isn't limited. If no node can be added, it also
+// returns the skip reason.
+func (d *domainNodeLimits) headroom(nodeInfo *schedulerframework.NodeInfo) (int, *MaxDomainNodesReached) {
+	if len(d.limits) == 0 || nodeInfo == nil {
+		return -1, nil
+	}
+	result := -1
+	var reason *MaxDomainNodesReached
+	for _, limit := range d.limits {
+		value, found := nodeInfo.Node().Labels[limit.Label]
+		if !found {
+			continue
+		}
+		left := limit.Max - d.nodeCounts[domain{label: limit.Label, value: value}]
+		if left < 0 {
+			left = 0
+		}
+		if result == -1 || left < result {
+			result = left
+		}
+		if left == 0 && reason == nil {
+			reason = NewMaxDomainNodesReached(fmt.Sprintf("max nodes in %s=%s reached", limit.Label, value))
+		}
+	}
+	return result, reason
+}
+
+// DomainNodesLeft returns the number of nodes which can still be added to the domains of the
+// node group built from nodeInfo, given the existing and upcoming nodes, or -1 if it isn't limited.
+func DomainNodesLeft(limits []config.DomainNodeLimits, nodes []*apiv1.Node, upcomingNodes []*schedulerframework.NodeInfo, nodeInfo *schedulerframework.NodeInfo) int {
+	left, _ := newDomainNodeLimits(limits, nodes, upcomingNodes).headroom(nodeInfo)
+	return left
+}
+
+// FilterOptions drops expansion options targeting node groups whose domains are
+// full, as well as such similar node groups, and records why the node groups were skipped.
+func (d *domainNodeLimits) FilterOptions(options []expander.Option, nodeInfos map[string]*schedulerframework.NodeInfo,
+	nodeGroupDefaults config.NodeGroupAutoscalingOptions, skippedNodeGroups map[string]status.Reasons) []expander.Option {
+	if len(d.limits) == 0 {
+		return options
+	}
+	var result []expander.Option
+	for _, option := range options {
+		left, reason := d.headroom(nodeInfos[option.NodeGroup.Id()])
+		if reason == nil && left != -1 && left < option.NodeCount && isAtomic(option.NodeGroup, nodeGroupDefaults) {
+			reason = NewMaxDomainNodesReached("atomic scale-up exceeds max nodes in domain")
+		}
+		if reason != nil {
+			klog.V(4).Infof("Skipping node group %s - %s", option.NodeGroup.Id(), reason.Reasons()[0])
+			skippedNodeGroups[option.NodeGroup.Id()] = reason
+			continue
+		}
+		option.SimilarNodeGroups = d.FilterNodeGroups(option.SimilarNodeGroups, nodeInfos)
+		result = append(result, option)
+	}
+	return result
+}
+
+// FilterNodeGroups returns the node groups whose domains aren't full.
+func (d *domainNodeLimits) FilterNodeGroups(nodeGroups []cloudprovider.NodeGroup, nodeInfos map[string]*schedulerframework.NodeInfo) []cloudprovider.NodeGroup {
+	if len(d.limits) == 0 {
+		return nodeGroups
+	}
+	var result []cloudprovider.NodeGroup
+	for _, ng := range nodeGroups {
+		if left, _ := d.headroom(nodeInfos[ng.Id()]); left != 0 {
+			result = append(result, ng)
+		}
+	}
+	return result
+}
+
+// CapScaleUpInfos caps the scale-ups so that the number of nodes in each domain
+// stays within its limit, dropping the ones which can't add any node. It returns
+// the domains which limited the scale-ups.
+func (d *domainNodeLimits) CapScaleUpInfos(scaleUpInfos []nodegroupset.ScaleUpInfo, nodeInfos map[string]*schedulerframework.NodeInfo) ([]nodegroupset.ScaleUpInfo, []string) {
+	if len(d.limits) == 0 {
+		return scaleUpInfos, nil
+	}
+	var result []nodegroupset.ScaleUpInfo
+	var reasons []string
+	for _, info := range scaleUpInfos {
+		nodeInfo := nodeInfos[info.Group.Id()]
+		delta := info.NewSize - info.CurrentSize
+		left, reason := d.headroom(nodeInfo)
+		if left != -1 && left < delta {
+			klog.V(1).Infof("Capping scale-up of %s to %d nodes due to max nodes per domain", info.Group.Id(), left)
+			if reason == nil {
+				reason = NewMaxDomainNodesReached(fmt.Sprintf("max nodes in domain allow only %d new nodes", left))
+			}
+			reasons = append(reasons, fmt.Sprintf("%s: %s", info.Group.Id(), reason.Reasons()[0]))
+			delta = left
+		}
+		if delta <= 0 {
+			continue
+		}
+		info.NewSize = info.CurrentSize + delta
+		d.add(nodeInfo.Node(), delta)
+		result = append(result, info)
+	}
+	return result, reasons
+}
+
+func isAtomic(nodeGroup cloudprovider.NodeGroup, nodeGroupDefaults config.NodeGroupAutoscalingOptions) bool {
+	autoscalingOptions, err := nodeGroup.GetOptions(nodeGroupDefaults)
+	if err != nil && err != cloudprovider.ErrNotImplemented {
+		klog.Errorf("Couldn't get autoscaling options for ng: %v", nodeGroup.Id())
+	}
+	return autoscalingOptions != nil && autoscalingOptions.ZeroOrMaxNodeScaling
+}
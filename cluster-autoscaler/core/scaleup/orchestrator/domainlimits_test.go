@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const zoneLabel = "topology.kubernetes.io/zone"
+
+func buildZonalNode(name, zone string) *apiv1.Node {
+	node := BuildTestNode(name, 1000, 1000)
+	if zone != "" {
+		node.Labels[zoneLabel] = zone
+	}
+	return node
+}
+
+func TestDomainNodeLimits(t *testing.T) {
+	limits := []config.DomainNodeLimits{{Label: zoneLabel, Max: 2}}
+	nodes := []*apiv1.Node{
+		buildZonalNode("n1", "a"),
+		buildZonalNode("n2", "a"),
+		buildZonalNode("n3", "b"),
+		buildZonalNode("n4", ""),
+	}
+	ngA := testprovider.NewTestNodeGroup("ng-a", 10, 0, 2, true, false, "", nil, nil)
+	ngB := testprovider.NewTestNodeGroup("ng-b", 10, 0, 1, true, false, "", nil, nil)
+	ngB2 := testprovider.NewTestNodeGroup("ng-b2", 10, 0, 0, true, false, "", nil, nil)
+	ngNoZone := testprovider.NewTestNodeGroup("ng-no-zone", 10, 0, 1, true, false, "", nil, nil)
+	ngAtomic := testprovider.NewTestNodeGroup("ng-atomic", 10, 0, 0, true, false, "", nil, nil)
+	ngAtomic.SetOptions(&config.NodeGroupAutoscalingOptions{ZeroOrMaxNodeScaling: true})
+	nodeInfos := map[string]*schedulerframework.NodeInfo{}
+	for id, zone := range map[string]string{"ng-a": "a", "ng-b": "b", "ng-b2": "b", "ng-no-zone": "", "ng-atomic": "c"} {
+		nodeInfos[id] = schedulerframework.NewNodeInfo()
+		nodeInfos[id].SetNode(buildZonalNode(id+"-template", zone))
+	}
+
+	t.Run("filters options in full domains", func(t *testing.T) {
+		d := newDomainNodeLimits(limits, nodes, nil)
+		skipped := map[string]status.Reasons{}
+		options := d.FilterOptions([]expander.Option{
+			{NodeGroup: ngA, NodeCount: 1},
+			{NodeGroup: ngB, NodeCount: 3, SimilarNodeGroups: []cloudprovider.NodeGroup{ngA, ngB2}},
+			{NodeGroup: ngNoZone, NodeCount: 5},
+			{NodeGroup: ngAtomic, NodeCount: 3},
+		}, nodeInfos, config.NodeGroupAutoscalingOptions{}, skipped)
+
+		if assert.Len(t, options, 2) {
+			assert.Equal(t, ngB, options[0].NodeGroup)
+			assert.Equal(t, []cloudprovider.NodeGroup{ngB2}, options[0].SimilarNodeGroups)
+			assert.Equal(t, ngNoZone, options[1].NodeGroup)
+		}
+		assert.Equal(t, []string{"max nodes in topology.kubernetes.io/zone=a reached"}, skipped["ng-a"].Reasons())
+		assert.Equal(t, []string{"atomic scale-up exceeds max nodes in domain"}, skipped["ng-atomic"].Reasons())
+	})
+
+	t.Run("counts upcoming nodes", func(t *testing.T) {
+		d := newDomainNodeLimits(limits, nodes, []*schedulerframework.NodeInfo{nodeInfos["ng-b"]})
+		left, reason := d.headroom(nodeInfos["ng-b"])
+		assert.Equal(t, 0, left)
+		assert.NotNil(t, reason)
+	})
+
+	t.Run("caps scale-ups across node groups in the same domain", func(t *testing.T) {
+		d := newDomainNodeLimits(limits, nodes, nil)
+		infos, capped := d.CapScaleUpInfos([]nodegroupset.ScaleUpInfo{
+			{Group: ngA, CurrentSize: 2, NewSize: 4, MaxSize: 10},
+			{Group: ngB, CurrentSize: 1, NewSize: 2, MaxSize: 10},
+			{Group: ngB2, CurrentSize: 0, NewSize: 2, MaxSize: 10},
+			{Group: ngNoZone, CurrentSize: 1, NewSize: 5, MaxSize: 10},
+		}, nodeInfos)
+
+		assert.Equal(t, []nodegroupset.ScaleUpInfo{
+			{Group: ngB, CurrentSize: 1, NewSize: 2, MaxSize: 10},
+			{Group: ngNoZone, CurrentSize: 1, NewSize: 5, MaxSize: 10},
+		}, infos)
+		assert.Len(t, capped, 2)
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		d := newDomainNodeLimits(nil, nodes, nil)
+		options := []expander.Option{{NodeGroup: ngA, NodeCount: 5}}
+		assert.Equal(t, options, d.FilterOptions(options, nodeInfos, config.NodeGroupAutoscalingOptions{}, map[string]status.Reasons{}))
+		left, reason := d.headroom(nodeInfos["ng-a"])
+		assert.Equal(t, -1, left)
+		assert.Nil(t, reason)
+	})
+}
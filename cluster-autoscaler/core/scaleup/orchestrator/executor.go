@@ -163,10 +163,19 @@ func (e *scaleUpExecutor) executeScaleUp(
 	e.autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaledUpGroup",
 		"Scale-up: setting group %s size to %d instead of %d (max: %d)", info.Group.Id(), info.NewSize, info.CurrentSize, info.MaxSize)
 	increase := info.NewSize - info.CurrentSize
+	reservedCapacity, usesReservedCapacity := cloudprovider.AvailableReservedCapacity(info.Group)
 	if err := e.increaseSize(info.Group, increase, atomic); err != nil {
 		e.autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeWarning, "FailedToScaleUpGroup", "Scale-up failed for group %s: %v", info.Group.Id(), err)
 		aerr := errors.ToAutoscalerError(errors.CloudProviderError, err).AddPrefix("failed to increase node group size: ")
-		e.scaleStateNotifier.RegisterFailedScaleUp(info.Group, string(aerr.Type()), aerr.Error(), gpuResourceName, gpuType, now)
+		errorKind := cloudprovider.ErrorKindFromError(err)
+		errorInfo := cloudprovider.InstanceErrorInfo{
+			ErrorClass:   errorKind.ErrorClass(),
+			ErrorKind:    errorKind,
+			ErrorCode:    string(aerr.Type()),
+			ErrorMessage: aerr.Error(),
+			Quota:        cloudprovider.QuotaErrorInfoFromError(err),
+		}
+		nodegroupchange.RegisterFailedScaleUpWithErrorInfo(e.scaleStateNotifier, info.Group, errorInfo, gpuResourceName, gpuType, now)
 		return aerr
 	}
 	if increase < 0 {
@@ -174,6 +183,10 @@ func (e *scaleUpExecutor) executeScaleUp(
 	}
 	e.scaleStateNotifier.RegisterScaleUp(info.Group, increase, time.Now())
 	metrics.RegisterScaleUp(increase, gpuResourceName, gpuType)
+	if usesReservedCapacity && increase > reservedCapacity {
+		e.autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeWarning, "ReservedCapacityExhausted",
+			"Scale-up: reserved capacity of group %s is exhausted, %d of %d new nodes use on-demand capacity", info.Group.Id(), increase-reservedCapacity, increase)
+	}
 	e.autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaledUpGroup",
 		"Scale-up: group %s size set to %d instead of %d (max: %d)", info.Group.Id(), info.NewSize, info.CurrentSize, info.MaxSize)
 	return nil
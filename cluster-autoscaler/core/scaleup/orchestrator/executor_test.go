@@ -18,8 +18,18 @@ package orchestrator
 
 import (
 	"testing"
+	"time"
 
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/observers/nodegroupchange"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	kube_record "k8s.io/client-go/tools/record"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -125,3 +135,35 @@ func TestCombinedConcurrentScaleUpErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteScaleUpReservedCapacityExhausted(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(func(string, int) error { return nil }, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	nodeGroup := provider.GetNodeGroup("ng1")
+	nodeGroup.(*testprovider.TestNodeGroup).SetAvailableReservedCapacity(2)
+	nodeInfo := schedulerframework.NewNodeInfo()
+	nodeInfo.SetNode(BuildTestNode("n1", 1000, 1000))
+
+	recorder := kube_record.NewFakeRecorder(10)
+	logRecorder, err := utils.NewStatusMapRecorder(fake.NewSimpleClientset(), "kube-system", recorder, true, "my-cool-configmap")
+	assert.NoError(t, err)
+	e := newScaleUpExecutor(&context.AutoscalingContext{CloudProvider: provider, AutoscalingKubeClients: context.AutoscalingKubeClients{LogRecorder: logRecorder}}, nodegroupchange.NewNodeGroupChangeObserversList())
+
+	scaleUp := func(newSize int) []string {
+		info := nodegroupset.ScaleUpInfo{Group: nodeGroup, CurrentSize: 1, NewSize: newSize, MaxSize: 10}
+		assert.NoError(t, e.executeScaleUp(info, nodeInfo, nil, time.Now(), false))
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+
+	// The reserved capacity is enough for the scale-up.
+	for _, event := range scaleUp(3) {
+		assert.NotContains(t, event, "ReservedCapacityExhausted")
+	}
+
+	// The scale-up spills over to on-demand capacity.
+	assert.Contains(t, scaleUp(4), "Warning ReservedCapacityExhausted Scale-up: reserved capacity of group ng1 is exhausted, 1 of 3 new nodes use on-demand capacity")
+}
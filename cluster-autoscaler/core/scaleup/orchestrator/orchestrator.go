@@ -22,6 +22,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	"k8s.io/klog/v2"
 	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
@@ -52,6 +53,7 @@ type ScaleUpOrchestrator struct {
 	scaleUpExecutor      *scaleUpExecutor
 	estimatorBuilder     estimator.EstimatorBuilder
 	taintConfig          taints.TaintConfig
+	reserveNodeGroups    *reserveNodeGroups
 	initialized          bool
 }
 
@@ -77,6 +79,7 @@ func (o *ScaleUpOrchestrator) Initialize(
 	o.taintConfig = taintConfig
 	o.resourceManager = resource.NewManager(processors.CustomResourcesProcessor)
 	o.scaleUpExecutor = newScaleUpExecutor(autoscalingContext, processors.ScaleStateNotifier)
+	o.reserveNodeGroups = newReserveNodeGroups(autoscalingContext.ReserveNodeGroups)
 	o.initialized = true
 }
 
@@ -165,6 +168,13 @@ func (o *ScaleUpOrchestrator) ScaleUp(
 	// Finalize binpacking limiter.
 	o.processors.BinpackingLimiter.FinalizeBinpacking(o.autoscalingContext, options)
 
+	// Node groups in domains which reached their max nodes can't be used.
+	domainLimits := newDomainNodeLimits(o.autoscalingContext.MaxNodesPerLabelDomain, nodes, upcomingNodes)
+	options = domainLimits.FilterOptions(options, nodeInfos, o.autoscalingContext.NodeGroupDefaults, skippedNodeGroups)
+
+	// Reserve node groups are only considered if nothing else can help.
+	options = o.reserveNodeGroups.FilterOptions(options)
+
 	if len(options) == 0 {
 		klog.V(1).Info("No expansion options")
 		return &status.ScaleUpStatus{
@@ -242,6 +252,7 @@ func (o *ScaleUpOrchestrator) ScaleUp(
 
 	// Recompute similar node groups in case they need to be updated
 	bestOption.SimilarNodeGroups = o.ComputeSimilarNodeGroups(bestOption.NodeGroup, nodeInfos, schedulablePodGroups, now)
+	bestOption.SimilarNodeGroups = domainLimits.FilterNodeGroups(bestOption.SimilarNodeGroups, nodeInfos)
 	if bestOption.SimilarNodeGroups != nil {
 		// if similar node groups are found, log about them
 		similarNodeGroupIds := make([]string, 0)
@@ -275,6 +286,20 @@ func (o *ScaleUpOrchestrator) ScaleUp(
 			aErr)
 	}
 
+	scaleUpInfos, cappedDomains := domainLimits.CapScaleUpInfos(scaleUpInfos, nodeInfos)
+	if len(cappedDomains) > 0 {
+		o.autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeWarning, "MaxNodesPerDomainReached", "Scale-up capped by max nodes per domain: %s", strings.Join(cappedDomains, ", "))
+	}
+	if len(scaleUpInfos) == 0 {
+		klog.V(1).Info("No nodes can be added due to max nodes per domain")
+		return &status.ScaleUpStatus{
+			Result:                  status.ScaleUpNoOptionsAvailable,
+			PodsRemainUnschedulable: GetRemainingPods(podEquivalenceGroups, skippedNodeGroups),
+			ConsideredNodeGroups:    nodeGroups,
+			CreateNodeGroupResults:  createNodeGroupResults,
+		}, nil
+	}
+
 	// Last check before scale-up. Node group capacity (both due to max size limits & current size) is only checked when balancing.
 	totalCapacity := 0
 	for _, sui := range scaleUpInfos {
@@ -304,6 +329,13 @@ func (o *ScaleUpOrchestrator) ScaleUp(
 		)
 	}
 
+	if o.reserveNodeGroups.Contains(bestOption.NodeGroup) {
+		klog.V(1).Infof("Scaling up reserve node group %s, no other node group can accommodate the pods", bestOption.NodeGroup.Id())
+		metrics.RegisterReserveNodeGroupActivation(bestOption.NodeGroup.Id())
+		o.autoscalingContext.LogRecorder.Eventf(apiv1.EventTypeNormal, "ReserveNodeGroupActivated",
+			"Scale-up: using reserve node group %s, no other node group can accommodate the pods", bestOption.NodeGroup.Id())
+	}
+
 	o.clusterStateRegistry.Recalculate()
 	return &status.ScaleUpStatus{
 		Result:                  status.ScaleUpSuccessful,
@@ -312,10 +344,30 @@ func (o *ScaleUpOrchestrator) ScaleUp(
 		ConsideredNodeGroups:    nodeGroups,
 		CreateNodeGroupResults:  createNodeGroupResults,
 		PodsTriggeredScaleUp:    bestOption.Pods,
+		PodsPlannedNodeGroups:   planPodsOntoNodeGroups(bestOption.Pods, scaleUpInfos),
 		PodsAwaitEvaluation:     GetPodsAwaitingEvaluation(podEquivalenceGroups, bestOption.NodeGroup.Id()),
 	}, nil
 }
 
+// planPodsOntoNodeGroups spreads the pods which triggered a scale-up over the scaled up node groups, in
+// proportion to the nodes added to each, and returns the id of the node group planned for each pod.
+func planPodsOntoNodeGroups(pods []*apiv1.Pod, scaleUpInfos []nodegroupset.ScaleUpInfo) map[types.UID]string {
+	var newNodeGroupIds []string
+	for _, info := range scaleUpInfos {
+		for i := info.CurrentSize; i < info.NewSize; i++ {
+			newNodeGroupIds = append(newNodeGroupIds, info.Group.Id())
+		}
+	}
+	if len(newNodeGroupIds) == 0 {
+		return nil
+	}
+	planned := make(map[types.UID]string, len(pods))
+	for i, pod := range pods {
+		planned[pod.UID] = newNodeGroupIds[i*len(newNodeGroupIds)/len(pods)]
+	}
+	return planned
+}
+
 // ScaleUpToNodeGroupMinSize tries to scale up node groups that have less nodes
 // than the configured min size. The source of truth for the current node group
 // size is the TargetSize queried directly from cloud providers. Returns
@@ -730,6 +782,9 @@ func (o *ScaleUpOrchestrator) ComputeSimilarNodeGroups(
 		return nil
 	}
 
+	// Never balance between reserve and regular node groups.
+	similarNodeGroups = o.reserveNodeGroups.filterNodeGroups(similarNodeGroups, o.reserveNodeGroups.Contains(nodeGroup))
+
 	var validSimilarNodeGroups []cloudprovider.NodeGroup
 	for _, ng := range similarNodeGroups {
 		// Non-existing node groups are created later so skip check for them.
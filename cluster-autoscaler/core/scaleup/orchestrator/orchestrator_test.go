@@ -53,6 +53,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
 
@@ -126,6 +127,72 @@ func TestMixedScaleUp(t *testing.T) {
 	simpleScaleUpTest(t, config, expectedResults)
 }
 
+func TestScaleUpSkipsReserveNodeGroup(t *testing.T) {
+	options := defaultOptions
+	options.ReserveNodeGroups = []string{"^ng2$"}
+	config := &ScaleUpTestConfig{
+		Nodes: []NodeConfig{
+			{Name: "n1", Cpu: 1000, Memory: 1000, Gpu: 0, Ready: true, Group: "ng1"},
+			{Name: "n2", Cpu: 1000, Memory: 1000, Gpu: 0, Ready: true, Group: "ng2"},
+		},
+		Pods: []PodConfig{
+			{Name: "p1", Cpu: 800, Memory: 0, Gpu: 0, Node: "n1", ToleratesGpu: false},
+			{Name: "p2", Cpu: 800, Memory: 0, Gpu: 0, Node: "n2", ToleratesGpu: false},
+		},
+		ExtraPods: []PodConfig{
+			{Name: "p-new", Cpu: 500, Memory: 0, Gpu: 0, Node: "", ToleratesGpu: false},
+		},
+		ExpansionOptionToChoose: &GroupSizeChange{GroupName: "ng1", SizeChange: 1},
+		Options:                 &options,
+	}
+	expectedResults := &ScaleTestResults{
+		FinalOption: GroupSizeChange{GroupName: "ng1", SizeChange: 1},
+		ExpansionOptions: []GroupSizeChange{
+			{GroupName: "ng1", SizeChange: 1},
+		},
+		ScaleUpStatus: ScaleUpStatusInfo{
+			PodsTriggeredScaleUp: []string{"p-new"},
+		},
+	}
+
+	simpleScaleUpTest(t, config, expectedResults)
+}
+
+func TestScaleUpUsesReserveNodeGroupWhenOthersAtMax(t *testing.T) {
+	options := defaultOptions
+	options.ReserveNodeGroups = []string{"^ng2$"}
+	config := &ScaleUpTestConfig{
+		Groups: []NodeGroupConfig{
+			{Name: "ng1", MinSize: 1, MaxSize: 1},
+			{Name: "ng2", MinSize: 1, MaxSize: 10},
+		},
+		Nodes: []NodeConfig{
+			{Name: "n1", Cpu: 1000, Memory: 1000, Gpu: 0, Ready: true, Group: "ng1"},
+			{Name: "n2", Cpu: 1000, Memory: 1000, Gpu: 0, Ready: true, Group: "ng2"},
+		},
+		Pods: []PodConfig{
+			{Name: "p1", Cpu: 800, Memory: 0, Gpu: 0, Node: "n1", ToleratesGpu: false},
+			{Name: "p2", Cpu: 800, Memory: 0, Gpu: 0, Node: "n2", ToleratesGpu: false},
+		},
+		ExtraPods: []PodConfig{
+			{Name: "p-new", Cpu: 500, Memory: 0, Gpu: 0, Node: "", ToleratesGpu: false},
+		},
+		ExpansionOptionToChoose: &GroupSizeChange{GroupName: "ng2", SizeChange: 1},
+		Options:                 &options,
+	}
+	expectedResults := &ScaleTestResults{
+		FinalOption: GroupSizeChange{GroupName: "ng2", SizeChange: 1},
+		ExpansionOptions: []GroupSizeChange{
+			{GroupName: "ng2", SizeChange: 1},
+		},
+		ScaleUpStatus: ScaleUpStatusInfo{
+			PodsTriggeredScaleUp: []string{"p-new"},
+		},
+	}
+
+	simpleScaleUpTest(t, config, expectedResults)
+}
+
 func TestZeroOrMaxNodeScaling(t *testing.T) {
 	options := defaultOptions
 	options.NodeGroupDefaults.ZeroOrMaxNodeScaling = true
@@ -1664,6 +1731,26 @@ func TestScaleUpToMeetNodeGroupMinSize(t *testing.T) {
 	assert.Equal(t, "ng1", scaleUpStatus.ScaleUpInfos[0].Group.Id())
 }
 
+func TestPlanPodsOntoNodeGroups(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+	provider.AddNodeGroup("ng3", 0, 10, 1)
+	scaleUpInfos := []nodegroupset.ScaleUpInfo{
+		{Group: provider.GetNodeGroup("ng1"), CurrentSize: 1, NewSize: 3},
+		{Group: provider.GetNodeGroup("ng2"), CurrentSize: 1, NewSize: 2},
+		{Group: provider.GetNodeGroup("ng3"), CurrentSize: 1, NewSize: 1},
+	}
+	var pods []*apiv1.Pod
+	for i := 0; i < 6; i++ {
+		pods = append(pods, BuildTestPod(fmt.Sprintf("p%d", i), 100, 100))
+	}
+
+	planned := planPodsOntoNodeGroups(pods, scaleUpInfos)
+	assert.Equal(t, map[types.UID]string{"p0": "ng1", "p1": "ng1", "p2": "ng1", "p3": "ng1", "p4": "ng2", "p5": "ng2"}, planned)
+	assert.Nil(t, planPodsOntoNodeGroups(pods, scaleUpInfos[2:]))
+}
+
 func TestCheckDeltaWithinLimits(t *testing.T) {
 	type testcase struct {
 		limits            resource.Limits
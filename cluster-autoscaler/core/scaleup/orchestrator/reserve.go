@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"regexp"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/klog/v2"
+)
+
+// reserveNodeGroups recognizes node groups configured as reserve capacity.
+// Reserve node groups are only used for scale-up when no other node group
+// can accommodate the pending pods, e.g. because all of them are in backoff
+// or at their max size. Their nodes are excluded from scale-down by the
+// eligibility checker.
+type reserveNodeGroups struct {
+	patterns []*regexp.Regexp
+}
+
+func newReserveNodeGroups(patterns []string) *reserveNodeGroups {
+	r := &reserveNodeGroups{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			klog.Errorf("Ignoring invalid reserve node group pattern %q: %v", pattern, err)
+			continue
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r
+}
+
+// Contains returns true if the given node group is a reserve node group.
+func (r *reserveNodeGroups) Contains(nodeGroup cloudprovider.NodeGroup) bool {
+	if r == nil {
+		return false
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(nodeGroup.Id()) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterOptions drops expansion options targeting reserve node groups, unless
+// they are the only options available.
+func (r *reserveNodeGroups) FilterOptions(options []expander.Option) []expander.Option {
+	if r == nil || len(r.patterns) == 0 {
+		return options
+	}
+	var regular []expander.Option
+	for _, option := range options {
+		if !r.Contains(option.NodeGroup) {
+			regular = append(regular, option)
+		}
+	}
+	if len(regular) == 0 {
+		return options
+	}
+	for i := range regular {
+		regular[i].SimilarNodeGroups = r.filterNodeGroups(regular[i].SimilarNodeGroups, false)
+	}
+	return regular
+}
+
+// filterNodeGroups returns the node groups for which being a reserve node group
+// matches the reserve argument.
+func (r *reserveNodeGroups) filterNodeGroups(nodeGroups []cloudprovider.NodeGroup, reserve bool) []cloudprovider.NodeGroup {
+	var result []cloudprovider.NodeGroup
+	for _, ng := range nodeGroups {
+		if r.Contains(ng) == reserve {
+			result = append(result, ng)
+		}
+	}
+	return result
+}
@@ -61,6 +61,22 @@ func (sr *MaxResourceLimitReached) Resources() []string {
 	return sr.resources
 }
 
+// MaxDomainNodesReached contains information why given node group was skipped because
+// of the limit on the number of nodes in one of its domains, e.g. its zone.
+type MaxDomainNodesReached struct {
+	messages []string
+}
+
+// Reasons returns a slice of reasons why the node group was not considered for scale up.
+func (sr *MaxDomainNodesReached) Reasons() []string {
+	return sr.messages
+}
+
+// NewMaxDomainNodesReached returns a reason describing which limit on the number of nodes in a domain was reached.
+func NewMaxDomainNodesReached(m string) *MaxDomainNodesReached {
+	return &MaxDomainNodesReached{[]string{m}}
+}
+
 // NewMaxResourceLimitReached returns a reason describing which cluster wide resource limits were reached.
 func NewMaxResourceLimitReached(resources []string) *MaxResourceLimitReached {
 	return &MaxResourceLimitReached{
@@ -24,14 +24,18 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/planner"
 	scaledownstatus "k8s.io/autoscaler/cluster-autoscaler/core/scaledown/status"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup"
 	orchestrator "k8s.io/autoscaler/cluster-autoscaler/core/scaleup/orchestrator"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup/resource"
 	"k8s.io/autoscaler/cluster-autoscaler/debuggingsnapshot"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
+	"k8s.io/client-go/util/flowcontrol"
+	cloudproviderapi "k8s.io/cloud-provider/api"
 	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
@@ -43,6 +47,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/actuation"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/deletiontracker"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/legacy"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/makeroom"
 	core_utils "k8s.io/autoscaler/cluster-autoscaler/core/utils"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
@@ -95,6 +100,14 @@ type StaticAutoscaler struct {
 	processorCallbacks      *staticAutoscalerProcessorCallbacks
 	initialized             bool
 	taintConfig             taints.TaintConfig
+	// stateSnapshotWriter persists cluster state snapshots, if enabled.
+	stateSnapshotWriter utils.StateSnapshotWriter
+	// brokenNodeReplacements are the unready nodes for which a replacement node was requested.
+	brokenNodeReplacements map[string]bool
+	// resourceManager checks the cluster resource limits for the replacements of broken nodes.
+	resourceManager *resource.Manager
+	// makeRoomOrchestrator scales up to make room for pods of underutilized nodes, if enabled.
+	makeRoomOrchestrator *makeroom.Orchestrator
 }
 
 type staticAutoscalerProcessorCallbacks struct {
@@ -196,6 +209,18 @@ func NewStaticAutoscaler(
 	}
 	scaleUpOrchestrator.Initialize(autoscalingContext, processors, clusterStateRegistry, estimatorBuilder, taintConfig)
 
+	var makeRoomOrchestrator *makeroom.Orchestrator
+	if opts.ScaleDownMakeRoom {
+		makeRoomOrchestrator = makeroom.NewOrchestrator(autoscalingContext, clusterStateRegistry, estimatorBuilder, deleteOptions, drainabilityRules,
+			processors.CustomResourcesProcessor)
+	}
+
+	var stateSnapshotWriter utils.StateSnapshotWriter
+	if opts.ClusterStateSnapshotRetention > 0 {
+		stateSnapshotWriter = utils.NewStateSnapshotWriter(autoscalingKubeClients.ClientSet, opts.ConfigNamespace,
+			opts.ClusterStateSnapshotConfigMap, opts.ClusterStateSnapshotDir, opts.ClusterStateSnapshotRetention, opts.ClusterStateSnapshotInterval)
+	}
+
 	// Set the initial scale times to be less than the start time so as to
 	// not start in cooldown mode.
 	initialScaleTime := time.Now().Add(-time.Hour)
@@ -212,6 +237,10 @@ func NewStaticAutoscaler(
 		processorCallbacks:      processorCallbacks,
 		clusterStateRegistry:    clusterStateRegistry,
 		taintConfig:             taintConfig,
+		stateSnapshotWriter:     stateSnapshotWriter,
+		brokenNodeReplacements:  make(map[string]bool),
+		resourceManager:         resource.NewManager(processors.CustomResourcesProcessor),
+		makeRoomOrchestrator:    makeRoomOrchestrator,
 	}
 }
 
@@ -242,19 +271,47 @@ func (a *StaticAutoscaler) cleanUpIfRequired() {
 	if allNodes, err := a.AllNodeLister().List(); err != nil {
 		klog.Errorf("Failed to list ready nodes, not cleaning up taints: %v", err)
 	} else {
+		limits := taints.CleanupLimits{Parallelism: a.AutoscalingContext.AutoscalingOptions.StartupTaintCleanupParallelism}
+		if qps := a.AutoscalingContext.AutoscalingOptions.StartupTaintCleanupQPS; qps > 0 {
+			limits.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(float32(qps), 1)
+		}
 		// Make sure we are only cleaning taints from selected node groups.
 		selectedNodes := filterNodesFromSelectedGroups(a.CloudProvider, allNodes...)
-		taints.CleanAllToBeDeleted(selectedNodes,
-			a.AutoscalingContext.ClientSet, a.Recorder, a.CordonNodeBeforeTerminate)
+		toBeDeletedResult := a.cleanOrphanedTaints(taints.ToBeDeletedTaint, selectedNodes, limits)
+		var deletionCandidateResult taints.CleanupResult
 		if a.AutoscalingContext.AutoscalingOptions.MaxBulkSoftTaintCount == 0 {
 			// Clean old taints if soft taints handling is disabled
-			taints.CleanAllDeletionCandidates(allNodes,
-				a.AutoscalingContext.ClientSet, a.Recorder)
+			deletionCandidateResult = a.cleanOrphanedTaints(taints.DeletionCandidateTaint, allNodes, limits)
+		} else if ttl := a.AutoscalingContext.AutoscalingOptions.DeletionCandidateTaintTTL; ttl > 0 {
+			// Clean soft taints which are too old to be still relevant, e.g. left by a crashed run of CA.
+			staleNodes := taints.GetStaleDeletionCandidates(allNodes, ttl, time.Now())
+			deletionCandidateResult = a.cleanOrphanedTaints(taints.DeletionCandidateTaint, staleNodes, limits)
+		}
+		cleaned := toBeDeletedResult.Cleaned + deletionCandidateResult.Cleaned
+		failed := toBeDeletedResult.Failed + deletionCandidateResult.Failed
+		if failed > 0 {
+			a.AutoscalingContext.LogRecorder.Eventf(apiv1.EventTypeWarning, "OrphanedTaintsCleanupFailed",
+				"Removed taints left by a previous run from %v nodes, failed to remove them from %v nodes", cleaned, failed)
+		} else if cleaned > 0 {
+			a.AutoscalingContext.LogRecorder.Eventf(apiv1.EventTypeNormal, "OrphanedTaintsCleanup",
+				"Removed taints left by a previous run from %v nodes", cleaned)
 		}
 	}
 	a.initialized = true
 }
 
+// cleanOrphanedTaints removes the taints of given type left by a previous run of CA from nodes and records the result.
+func (a *StaticAutoscaler) cleanOrphanedTaints(taintKey string, nodes []*apiv1.Node, limits taints.CleanupLimits) taints.CleanupResult {
+	metrics.ObserveOrphanedTaintsCount(taintKey, countNodesWithTaint(nodes, taintKey))
+	cordonNode := taintKey == taints.ToBeDeletedTaint && a.CordonNodeBeforeTerminate
+	result := taints.CleanAllTaintsInParallel(nodes, a.AutoscalingContext.ClientSet, a.Recorder, []string{taintKey}, cordonNode, limits)
+	metrics.ObserveOrphanedTaintsCleanup(taintKey, result.Cleaned, result.Failed)
+	if result.Cleaned > 0 || result.Failed > 0 {
+		klog.V(1).Infof("Removed %s taints left by a previous run from %v nodes, failed on %v nodes", taintKey, result.Cleaned, result.Failed)
+	}
+	return result
+}
+
 func (a *StaticAutoscaler) initializeClusterSnapshot(nodes []*apiv1.Node, scheduledPods []*apiv1.Pod) caerrors.AutoscalerError {
 	a.ClusterSnapshot.Clear()
 
@@ -400,6 +457,11 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 			utils.WriteStatusConfigMap(autoscalingContext.ClientSet, autoscalingContext.ConfigNamespace,
 				*status, a.AutoscalingContext.LogRecorder, a.AutoscalingContext.StatusConfigMapName, currentTime)
 		}
+		if a.stateSnapshotWriter != nil {
+			if err := a.stateSnapshotWriter.Write(a.clusterStateRegistry.GetStateSnapshot(currentTime), currentTime); err != nil {
+				klog.Warningf("Failed to persist cluster state snapshot: %v", err)
+			}
+		}
 
 		// This deferred processor execution allows the processors to handle a situation when a scale-(up|down)
 		// wasn't even attempted because e.g. the iteration exited earlier.
@@ -441,6 +503,32 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 		}
 	}
 
+	// Check if there are any nodes that registered, but the cloud provider never
+	// initialized them.
+	if a.UninitializedNodeRemovalTime > 0 {
+		removedAny, err := a.removeOldUninitializedNodes(allNodes, currentTime)
+		// There was a problem with removing uninitialized nodes. Retry in the next loop.
+		if err != nil {
+			klog.Warningf("Failed to remove uninitialized nodes: %v", err)
+		}
+		if removedAny {
+			klog.V(0).Infof("Some uninitialized nodes were removed")
+		}
+	}
+
+	// Check if there are any unready nodes that scale-down can't remove without going
+	// below the min size of their node group.
+	if a.BrokenNodeReplacementTime > 0 {
+		replacedAny, err := a.replaceBrokenNodes(allNodes, nodeInfosForGroups, currentTime)
+		// There was a problem with replacing broken nodes. Retry in the next loop.
+		if err != nil {
+			klog.Warningf("Failed to replace broken nodes: %v", err)
+		}
+		if replacedAny {
+			klog.V(0).Infof("Some broken nodes are being replaced")
+		}
+	}
+
 	if !a.clusterStateRegistry.IsClusterHealthy() {
 		klog.Warning("Cluster is not ready for autoscaling")
 		a.scaleDownPlanner.CleanUpUnneededNodes()
@@ -532,6 +620,12 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 
 	// finally, filter out pods that are too "young" to safely be considered for a scale-up (delay is configurable)
 	unschedulablePodsToHelp = a.filterOutYoungPods(unschedulablePodsToHelp, currentTime)
+	podsAwaitingNodeRegistration := countPodsOnUpcomingNodes(a.ClusterSnapshot, unschedulablePods)
+	pendingPodsTriaged := false
+	triagePending := func() {
+		metrics.UpdatePendingPodsByCategory(triagePendingPods(scaleUpStatus, unschedulablePodsToHelp, podsAwaitingNodeRegistration))
+		pendingPodsTriaged = true
+	}
 
 	preScaleUp := func() time.Time {
 		scaleUpStart := time.Now()
@@ -546,6 +640,7 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 			a.processors.ScaleUpStatusProcessor.Process(autoscalingContext, scaleUpStatus)
 			scaleUpStatusProcessorAlreadyCalled = true
 		}
+		triagePending()
 
 		if typedErr != nil {
 			klog.Errorf("Failed to scale up: %v", typedErr)
@@ -574,13 +669,20 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 		a.processorCallbacks.DisableScaleDownForLoop()
 		scaleUpStatus.Result = status.ScaleUpInCooldown
 		klog.V(1).Info("Unschedulable pods are very new, waiting one iteration for more")
+		a.handleScaleDownAbort(allNodes, metrics.ScaleDownAbortedNewPendingPods)
 	} else {
 		scaleUpStart := preScaleUp()
 		scaleUpStatus, typedErr = a.scaleUpOrchestrator.ScaleUp(unschedulablePodsToHelp, readyNodes, daemonsets, nodeInfosForGroups, false)
+		if typedErr == nil && scaleUpStatus.Result == status.ScaleUpSuccessful {
+			a.handleScaleDownAbort(allNodes, metrics.ScaleDownAbortedScaleUp)
+		}
 		if exit, err := postScaleUp(scaleUpStart); exit {
 			return err
 		}
 	}
+	if !pendingPodsTriaged {
+		triagePending()
+	}
 
 	if a.ScaleDownEnabled {
 		unneededStart := time.Now()
@@ -614,14 +716,20 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 			}
 		}
 
+		if a.makeRoomOrchestrator != nil {
+			scaleDownCandidates = a.makeRoomOrchestrator.FilterOutAddedNodes(scaleDownCandidates, currentTime)
+		}
+
 		typedErr := a.scaleDownPlanner.UpdateClusterState(podDestinations, scaleDownCandidates, scaleDownActuationStatus, currentTime)
 		// Update clusterStateRegistry and metrics regardless of whether ScaleDown was successful or not.
 		unneededNodes := a.scaleDownPlanner.UnneededNodes()
 		a.processors.ScaleDownCandidatesNotifier.Update(unneededNodes, currentTime)
+		a.clusterStateRegistry.UpdateExtendedUnneededTimes(a.scaleDownPlanner.ExtendedUnneededTimes())
 		metrics.UpdateUnneededNodesCount(len(unneededNodes))
 		if typedErr != nil {
 			scaleDownStatus.Result = scaledownstatus.ScaleDownError
 			klog.Errorf("Failed to scale down: %v", typedErr)
+			a.handleScaleDownAbort(allNodes, metrics.ScaleDownAbortedError)
 			return typedErr
 		}
 
@@ -633,6 +741,7 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 			a.lastScaleUpTime, a.lastScaleDownDeleteTime, a.lastScaleDownFailTime,
 			a.processorCallbacks.disableScaleDownForLoop, scaleDownInCooldown)
 		metrics.UpdateScaleDownInCooldown(scaleDownInCooldown)
+		a.processors.ScaleDownCandidatesNotifier.UpdateScaleDownInCooldown(scaleDownInCooldown, currentTime)
 		// We want to delete unneeded Node Groups only if here is no current delete
 		// in progress.
 		_, drained := scaleDownActuationStatus.DeletionsInProgress()
@@ -687,6 +796,18 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 				a.lastScaleDownFailTime = currentTime
 				return typedErr
 			}
+
+			// Nothing could be removed, check whether adding a few nodes would let more nodes be removed.
+			if a.makeRoomOrchestrator != nil && (scaleDownStatus.Result == scaledownstatus.ScaleDownNoNodeDeleted ||
+				scaleDownStatus.Result == scaledownstatus.ScaleDownNoUnneeded) {
+				scaledUp, err := a.makeRoomOrchestrator.ScaleUp(a.scaleDownPlanner.UnremovableNodes(), nodeInfosForGroups, currentTime)
+				if err != nil {
+					klog.Warningf("Failed to make room for pods of underutilized nodes: %v", err)
+				}
+				if scaledUp {
+					a.lastScaleUpTime = currentTime
+				}
+			}
 		}
 	}
 
@@ -701,6 +822,24 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) caerrors.AutoscalerErr
 	return nil
 }
 
+// handleScaleDownAbort records that scale-down was abandoned in this loop and,
+// if enabled, removes soft taints so that they don't skew scheduling of pending
+// pods onto nodes which are no longer going to be removed.
+func (a *StaticAutoscaler) handleScaleDownAbort(allNodes []*apiv1.Node, cause metrics.ScaleDownAbortCause) {
+	if !a.ScaleDownEnabled {
+		return
+	}
+	removed := 0
+	options := a.AutoscalingContext.AutoscalingOptions
+	if options.CleanSoftTaintsOnScaleDownAbort && options.MaxBulkSoftTaintCount != 0 {
+		// Make sure we are only cleaning taints from selected node groups.
+		selectedNodes := filterNodesFromSelectedGroups(a.CloudProvider, allNodes...)
+		removed, _ = actuation.CleanSoftDeletionTaints(a.AutoscalingContext, selectedNodes)
+	}
+	klog.V(4).Infof("Scale down aborted, cause: %s, removed %d soft taints", cause, removed)
+	metrics.RegisterScaleDownAbort(cause, removed)
+}
+
 func (a *StaticAutoscaler) isScaleDownInCooldown(currentTime time.Time, scaleDownCandidates []*apiv1.Node) bool {
 	scaleDownInCooldown := a.processorCallbacks.disableScaleDownForLoop || len(scaleDownCandidates) == 0
 
@@ -837,6 +976,288 @@ func toNodes(unregisteredNodes []clusterstate.UnregisteredNode) []*apiv1.Node {
 	return nodes
 }
 
+// Removes nodes which joined the cluster, but have been carrying the cloud provider's uninitialized
+// taint for longer than UninitializedNodeRemovalTime. Deleting the backing instance lets the node group
+// scale up again with a fresh one instead of counting the node as unready forever. Returns true if
+// anything was removed and error if such occurred.
+func (a *StaticAutoscaler) removeOldUninitializedNodes(allNodes []*apiv1.Node, currentTime time.Time) (bool, error) {
+	nodeGroups := a.nodeGroupsById()
+	nodesToBeDeletedByNodeGroupId := make(map[string][]*apiv1.Node)
+	for _, node := range allNodes {
+		if !taints.HasTaint(node, cloudproviderapi.TaintExternalCloudProvider) {
+			continue
+		}
+		if node.CreationTimestamp.Add(a.UninitializedNodeRemovalTime).After(currentTime) {
+			continue
+		}
+		nodeGroup, err := a.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			klog.Warningf("Failed to get node group for %s: %v", node.Name, err)
+			continue
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			klog.Warningf("No node group for node %s, skipping", node.Name)
+			continue
+		}
+		klog.V(0).Infof("Marking uninitialized node %v for removal", node.Name)
+		nodesToBeDeletedByNodeGroupId[nodeGroup.Id()] = append(nodesToBeDeletedByNodeGroupId[nodeGroup.Id()], node)
+	}
+
+	removedAny := false
+	for nodeGroupId, nodesToDelete := range nodesToBeDeletedByNodeGroupId {
+		nodeGroup := nodeGroups[nodeGroupId]
+
+		size, err := nodeGroup.TargetSize()
+		if err != nil {
+			klog.Warningf("Failed to get node group size; nodeGroup=%v; err=%v", nodeGroup.Id(), err)
+			continue
+		}
+		possibleToDelete := size - nodeGroup.MinSize()
+		if possibleToDelete <= 0 {
+			klog.Warningf("Node group %s min size reached, skipping removal of %v uninitialized nodes", nodeGroupId, len(nodesToDelete))
+			continue
+		}
+		if a.MaxUninitializedNodesRemoved > 0 && possibleToDelete > a.MaxUninitializedNodesRemoved {
+			possibleToDelete = a.MaxUninitializedNodesRemoved
+		}
+		if len(nodesToDelete) > possibleToDelete {
+			klog.V(1).Infof("Capping node group %s uninitialized node removal to %d out of %d nodes", nodeGroupId, possibleToDelete, len(nodesToDelete))
+			nodesToDelete = nodesToDelete[:possibleToDelete]
+		}
+
+		klog.V(0).Infof("Removing %v uninitialized nodes for node group %v", len(nodesToDelete), nodeGroupId)
+		err = nodeGroup.DeleteNodes(nodesToDelete)
+		a.clusterStateRegistry.InvalidateNodeInstancesCacheEntry(nodeGroup)
+		if err != nil {
+			klog.Warningf("Failed to remove %v uninitialized nodes from node group %s: %v", len(nodesToDelete), nodeGroupId, err)
+			for _, node := range nodesToDelete {
+				a.LogRecorder.Eventf(apiv1.EventTypeWarning, "DeleteUninitializedFailed",
+					"Failed to remove node %s: %v", node.Name, err)
+			}
+			return removedAny, err
+		}
+		for _, node := range nodesToDelete {
+			a.LogRecorder.Eventf(apiv1.EventTypeNormal, "DeleteUninitialized",
+				"Removed node %v uninitialized for more than %v", node.Name, a.UninitializedNodeRemovalTime)
+		}
+		metrics.RegisterOldUninitializedNodesRemoved(len(nodesToDelete))
+		removedAny = true
+	}
+	return removedAny, nil
+}
+
+// Replaces nodes which have been unready for longer than BrokenNodeReplacementTime in node groups at
+// their min size, which scale-down can't shrink. The node group is scaled up above its min size first,
+// and the broken nodes are deleted once their replacements are ready, so that it keeps its intended
+// capacity during the replacement. The scale-ups are capped by max-nodes-total, the cluster resource limits
+// and the per-domain node limits like any other scale-up. Returns true if anything was scaled up or removed
+// and error if such occurred.
+func (a *StaticAutoscaler) replaceBrokenNodes(allNodes []*apiv1.Node, nodeInfosForGroups map[string]*schedulerframework.NodeInfo, currentTime time.Time) (bool, error) {
+	nodeGroups := a.nodeGroupsById()
+	upcomingCounts, registeredUpcoming := a.clusterStateRegistry.GetUpcomingNodes()
+	upcomingNodes := make(map[string]bool)
+	for _, nodeNames := range registeredUpcoming {
+		for _, nodeName := range nodeNames {
+			upcomingNodes[nodeName] = true
+		}
+	}
+
+	brokenNodes := make(map[string]bool)
+	brokenNodesByNodeGroupId := make(map[string][]*apiv1.Node)
+	for _, node := range allNodes {
+		// Nodes which never became ready are handled as upcoming or uninitialized nodes.
+		if upcomingNodes[node.Name] || taints.HasTaint(node, cloudproviderapi.TaintExternalCloudProvider) || taints.HasToBeDeletedTaint(node) {
+			continue
+		}
+		ready, lastTransitionTime, err := kube_util.GetReadinessState(node)
+		if err != nil || ready || lastTransitionTime.Add(a.BrokenNodeReplacementTime).After(currentTime) {
+			continue
+		}
+		nodeGroup, err := a.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			klog.Warningf("Failed to get node group for %s: %v", node.Name, err)
+			continue
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		brokenNodes[node.Name] = true
+		brokenNodesByNodeGroupId[nodeGroup.Id()] = append(brokenNodesByNodeGroupId[nodeGroup.Id()], node)
+	}
+	// Forget the nodes which recovered or are gone. The node they were replaced with is then
+	// removed by scale-down like any other unneeded node.
+	for nodeName := range a.brokenNodeReplacements {
+		if !brokenNodes[nodeName] {
+			delete(a.brokenNodeReplacements, nodeName)
+		}
+	}
+
+	var readyCounts map[string]int
+	if len(a.brokenNodeReplacements) > 0 {
+		readyCounts = a.readyNodeCounts(allNodes, upcomingNodes)
+	}
+	var upcomingNodeInfos []*schedulerframework.NodeInfo
+	var resourcesLeft resource.Limits
+	if len(brokenNodesByNodeGroupId) > 0 {
+		upcomingNodeInfos = getUpcomingNodeInfos(upcomingCounts, nodeInfosForGroups)
+		var aErr caerrors.AutoscalerError
+		resourcesLeft, aErr = a.resourceManager.ResourcesLeft(a.AutoscalingContext, nodeInfosForGroups, allNodes)
+		if aErr != nil {
+			return false, aErr.AddPrefix("could not compute total resources: ")
+		}
+	}
+
+	replacedAny := false
+	for nodeGroupId, nodes := range brokenNodesByNodeGroupId {
+		nodeGroup := nodeGroups[nodeGroupId]
+		var replaced, unreplaced []*apiv1.Node
+		for _, node := range nodes {
+			if a.brokenNodeReplacements[node.Name] {
+				replaced = append(replaced, node)
+			} else {
+				unreplaced = append(unreplaced, node)
+			}
+		}
+
+		size, err := nodeGroup.TargetSize()
+		if err != nil {
+			klog.Warningf("Failed to get node group size; nodeGroup=%v; err=%v", nodeGroupId, err)
+			continue
+		}
+		if len(replaced) > 0 && size-len(replaced) < nodeGroup.MinSize() {
+			// The replacement scale-up was reverted, e.g. after failing, request it again.
+			for _, node := range replaced {
+				delete(a.brokenNodeReplacements, node.Name)
+			}
+			unreplaced = append(unreplaced, replaced...)
+			replaced = nil
+		}
+
+		// Delete the broken nodes once their replacements are ready, not merely registered, unready or
+		// failing to register, which all stop counting as upcoming nodes.
+		if len(replaced) > 0 && readyCounts[nodeGroupId] >= size-len(replaced) {
+			klog.V(0).Infof("Removing %v broken nodes replaced in node group %v", len(replaced), nodeGroupId)
+			err = nodeGroup.DeleteNodes(replaced)
+			a.clusterStateRegistry.InvalidateNodeInstancesCacheEntry(nodeGroup)
+			if err != nil {
+				for _, node := range replaced {
+					a.LogRecorder.Eventf(apiv1.EventTypeWarning, "DeleteBrokenFailed",
+						"Failed to remove node %s: %v", node.Name, err)
+				}
+				return replacedAny, err
+			}
+			for _, node := range replaced {
+				delete(a.brokenNodeReplacements, node.Name)
+				a.LogRecorder.Eventf(apiv1.EventTypeNormal, "DeleteBroken",
+					"Removed node %v unready for more than %v after its replacement became ready", node.Name, a.BrokenNodeReplacementTime)
+			}
+			metrics.RegisterBrokenNodesReplaced(len(replaced))
+			replacedAny = true
+		}
+
+		// Node groups above their min size are shrunk by scale-down instead.
+		if len(unreplaced) == 0 || size-len(replaced) > nodeGroup.MinSize() {
+			continue
+		}
+		if !a.clusterStateRegistry.NodeGroupScaleUpSafety(nodeGroup, currentTime).SafeToScale {
+			klog.V(1).Infof("Node group %s is not safe to scale up, skipping replacement of %v broken nodes", nodeGroupId, len(unreplaced))
+			continue
+		}
+		increase := len(unreplaced)
+		if size+increase > nodeGroup.MaxSize() {
+			increase = nodeGroup.MaxSize() - size
+		}
+		if increase <= 0 {
+			klog.Warningf("Node group %s max size reached, skipping replacement of %v broken nodes", nodeGroupId, len(unreplaced))
+			continue
+		}
+		nodeTemplate, found := nodeInfosForGroups[nodeGroupId]
+		if !found {
+			klog.Warningf("No node info for node group %s, skipping replacement of %v broken nodes", nodeGroupId, len(unreplaced))
+			continue
+		}
+		increase, delta := a.capBrokenNodeReplacement(nodeGroup, nodeTemplate, increase, allNodes, upcomingNodeInfos, resourcesLeft)
+		if increase <= 0 {
+			klog.Warningf("Node group %s can't be scaled up within the cluster limits, skipping replacement of %v broken nodes", nodeGroupId, len(unreplaced))
+			continue
+		}
+		klog.V(0).Infof("Scaling up node group %v by %v to replace broken nodes", nodeGroupId, increase)
+		if err := nodeGroup.IncreaseSize(increase); err != nil {
+			a.LogRecorder.Eventf(apiv1.EventTypeWarning, "ReplaceBrokenFailed",
+				"Failed to scale up group %s to replace broken nodes: %v", nodeGroupId, err)
+			return replacedAny, err
+		}
+		a.clusterStateRegistry.RegisterScaleUp(nodeGroup, increase, currentTime)
+		// The replacements are upcoming nodes for the limits of the following scale-ups.
+		for i := 0; i < increase; i++ {
+			upcomingNodeInfos = append(upcomingNodeInfos, nodeTemplate)
+		}
+		for resourceName, resourceDelta := range delta {
+			if left, found := resourcesLeft[resourceName]; found && left != resource.LimitUnknown {
+				resourcesLeft[resourceName] = left - resourceDelta*int64(increase)
+			}
+		}
+		for _, node := range unreplaced[:increase] {
+			a.brokenNodeReplacements[node.Name] = true
+			a.LogRecorder.Eventf(apiv1.EventTypeNormal, "ReplaceBroken",
+				"Scale-up: group %s size set to %d to replace node %v unready for more than %v", nodeGroupId, size+increase, node.Name, a.BrokenNodeReplacementTime)
+		}
+		replacedAny = true
+	}
+	return replacedAny, nil
+}
+
+// readyNodeCounts returns the number of ready nodes per node group, leaving out upcoming nodes and nodes being deleted.
+func (a *StaticAutoscaler) readyNodeCounts(allNodes []*apiv1.Node, upcomingNodes map[string]bool) map[string]int {
+	readyCounts := make(map[string]int)
+	for _, node := range allNodes {
+		if upcomingNodes[node.Name] || taints.HasToBeDeletedTaint(node) {
+			continue
+		}
+		if ready, _, err := kube_util.GetReadinessState(node); err != nil || !ready {
+			continue
+		}
+		nodeGroup, err := a.CloudProvider.NodeGroupForNode(node)
+		if err != nil || nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		readyCounts[nodeGroup.Id()]++
+	}
+	return readyCounts
+}
+
+// capBrokenNodeReplacement caps the number of nodes added to the node group to replace broken nodes, so
+// that the existing and upcoming nodes stay within max-nodes-total, the per-domain node limits and the
+// cluster resource limits. It returns the capped number along with the resources of a single node.
+func (a *StaticAutoscaler) capBrokenNodeReplacement(nodeGroup cloudprovider.NodeGroup, nodeTemplate *schedulerframework.NodeInfo, increase int,
+	allNodes []*apiv1.Node, upcomingNodes []*schedulerframework.NodeInfo, resourcesLeft resource.Limits) (int, resource.Delta) {
+	if nodeCount := len(allNodes) + len(upcomingNodes); a.MaxNodesTotal > 0 && nodeCount+increase > a.MaxNodesTotal {
+		klog.V(1).Infof("Capping replacement of broken nodes to max cluster total size (%d)", a.MaxNodesTotal)
+		increase = a.MaxNodesTotal - nodeCount
+	}
+	if left := orchestrator.DomainNodesLeft(a.MaxNodesPerLabelDomain, allNodes, upcomingNodes, nodeTemplate); left >= 0 && increase > left {
+		klog.V(1).Infof("Capping replacement of broken nodes to max nodes per domain (%d)", left)
+		increase = left
+	}
+	if increase <= 0 {
+		return 0, nil
+	}
+	delta, err := a.resourceManager.DeltaForNode(a.AutoscalingContext, nodeTemplate, nodeGroup)
+	if err != nil {
+		klog.Warningf("Failed to get resources of node group %s: %v", nodeGroup.Id(), err)
+		return 0, nil
+	}
+	if resource.CheckDeltaWithinLimits(resourcesLeft, delta).Exceeded {
+		return 0, nil
+	}
+	increase, err = a.resourceManager.ApplyLimits(a.AutoscalingContext, increase, resourcesLeft, nodeTemplate, nodeGroup)
+	if err != nil {
+		klog.Warningf("Failed to apply resource limits to node group %s: %v", nodeGroup.Id(), err)
+		return 0, nil
+	}
+	return increase, delta
+}
+
 func (a *StaticAutoscaler) deleteCreatedNodesWithErrors() (bool, error) {
 	// We always schedule deleting of incoming errornous nodes
 	// TODO[lukaszos] Consider adding logic to not retry delete every loop iteration
@@ -1001,6 +1422,16 @@ func filterNodesFromSelectedGroups(cp cloudprovider.CloudProvider, nodes ...*api
 	return filtered
 }
 
+func countNodesWithTaint(nodes []*apiv1.Node, taintKey string) int {
+	count := 0
+	for _, n := range nodes {
+		if taints.HasTaint(n, taintKey) {
+			count++
+		}
+	}
+	return count
+}
+
 func (a *StaticAutoscaler) updateClusterState(allNodes []*apiv1.Node, nodeInfosForGroups map[string]*schedulerframework.NodeInfo, currentTime time.Time) caerrors.AutoscalerError {
 	err := a.clusterStateRegistry.UpdateNodes(allNodes, nodeInfosForGroups, currentTime)
 	if err != nil {
@@ -1052,6 +1483,89 @@ func getUpcomingNodeInfos(upcomingCounts map[string]int, nodeInfos map[string]*s
 	return upcomingNodes
 }
 
+// countPodsOnUpcomingNodes returns the number of the pods which are placed on upcoming nodes in the snapshot.
+func countPodsOnUpcomingNodes(snapshot clustersnapshot.ClusterSnapshot, pods []*apiv1.Pod) int {
+	nodeInfos, err := snapshot.NodeInfos().List()
+	if err != nil {
+		klog.Errorf("Failed to list nodes of cluster snapshot: %v", err)
+		return 0
+	}
+	podsOnUpcomingNodes := make(map[types.UID]bool)
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node().Annotations[NodeUpcomingAnnotation] != "true" {
+			continue
+		}
+		for _, podInfo := range nodeInfo.Pods {
+			podsOnUpcomingNodes[podInfo.Pod.UID] = true
+		}
+	}
+	count := 0
+	for _, pod := range pods {
+		if podsOnUpcomingNodes[pod.UID] {
+			count++
+		}
+	}
+	return count
+}
+
+// triagePendingPods splits the pending pods considered for scale-up into categories
+// telling whether and why they can be helped by scale-up.
+func triagePendingPods(scaleUpStatus *status.ScaleUpStatus, unschedulablePodsToHelp []*apiv1.Pod, podsAwaitingNodeRegistration int) map[metrics.PendingPodCategory]int {
+	counts := map[metrics.PendingPodCategory]int{
+		metrics.PendingPodsAwaitingNodeRegistration: podsAwaitingNodeRegistration,
+	}
+	switch scaleUpStatus.Result {
+	case status.ScaleUpInCooldown:
+		counts[metrics.PendingPodsAwaitingScaleUp] += len(unschedulablePodsToHelp)
+		return counts
+	case status.ScaleUpNoOptionsAvailable:
+		if len(scaleUpStatus.PodsRemainUnschedulable) == 0 {
+			// Scale-up wasn't attempted, because the cluster reached max nodes total.
+			counts[metrics.PendingPodsBlockedByQuota] += len(unschedulablePodsToHelp)
+			return counts
+		}
+	}
+	counts[metrics.PendingPodsAwaitingScaleUp] += len(scaleUpStatus.PodsTriggeredScaleUp) + len(scaleUpStatus.PodsAwaitEvaluation)
+	for _, noScaleUpInfo := range scaleUpStatus.PodsRemainUnschedulable {
+		counts[pendingPodCategory(noScaleUpInfo)]++
+	}
+	return counts
+}
+
+// pendingPodCategory returns the category of a pod which didn't trigger scale-up, based on
+// the reasons of skipping node groups. Pods are unmatchable if they were rejected by all
+// node groups which weren't skipped.
+func pendingPodCategory(noScaleUpInfo status.NoScaleUpInfo) metrics.PendingPodCategory {
+	maxSize, quota, domainLimit, backoff := false, false, false, false
+	for _, reasons := range noScaleUpInfo.SkippedNodeGroups {
+		switch reasons {
+		case orchestrator.MaxLimitReachedReason:
+			maxSize = true
+		case orchestrator.BackoffReason, orchestrator.NotReadyReason:
+			backoff = true
+		default:
+			switch reasons.(type) {
+			case *orchestrator.MaxResourceLimitReached:
+				quota = true
+			case *orchestrator.MaxDomainNodesReached:
+				domainLimit = true
+			}
+		}
+	}
+	switch {
+	case maxSize:
+		return metrics.PendingPodsBlockedByMaxSize
+	case quota:
+		return metrics.PendingPodsBlockedByQuota
+	case domainLimit:
+		return metrics.PendingPodsBlockedByDomainLimit
+	case backoff:
+		return metrics.PendingPodsBlockedByBackoff
+	default:
+		return metrics.PendingPodsUnmatchable
+	}
+}
+
 func calculateCoresMemoryTotal(nodes []*apiv1.Node, timestamp time.Time) (int64, int64) {
 	// this function is essentially similar to the calculateScaleDownCoresMemoryTotal
 	// we want to check all nodes, aside from those deleting, to sum the cluster resource usage.
@@ -40,14 +40,18 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/legacy"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/status"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup/orchestrator"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup/resource"
 	. "k8s.io/autoscaler/cluster-autoscaler/core/test"
 	core_utils "k8s.io/autoscaler/cluster-autoscaler/core/utils"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	"k8s.io/autoscaler/cluster-autoscaler/observers/loopstart"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/callbacks"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/customresources"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupconfig"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/scaledowncandidates"
+	scaleupstatus "k8s.io/autoscaler/cluster-autoscaler/processors/status"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules"
@@ -69,6 +73,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/fake"
 	v1appslister "k8s.io/client-go/listers/apps/v1"
+	cloudproviderapi "k8s.io/cloud-provider/api"
 	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
 
 	"github.com/google/go-cmp/cmp"
@@ -1940,6 +1945,10 @@ func (f *candidateTrackingFakePlanner) UnneededNodes() []*apiv1.Node {
 	return nil
 }
 
+func (f *candidateTrackingFakePlanner) ExtendedUnneededTimes() map[string]time.Duration {
+	return nil
+}
+
 func (f *candidateTrackingFakePlanner) UnremovableNodes() []*simulator.UnremovableNode {
 	return nil
 }
@@ -2150,6 +2159,60 @@ func TestRemoveFixNodeTargetSize(t *testing.T) {
 	assert.Equal(t, "ng1/-2", change)
 }
 
+func TestHandleScaleDownAbort(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n3 := BuildTestNode("n3", 1000, 1000)
+	fakeClient := fake.NewSimpleClientset()
+	for _, n := range []*apiv1.Node{n1, n2, n3} {
+		_, err := fakeClient.CoreV1().Nodes().Create(stdcontext.Background(), n, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, taints.MarkDeletionCandidate(n, fakeClient))
+	}
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	getNodes := func() []*apiv1.Node {
+		var nodes []*apiv1.Node
+		for _, name := range []string{"n1", "n2", "n3"} {
+			node, err := fakeClient.CoreV1().Nodes().Get(stdcontext.Background(), name, metav1.GetOptions{})
+			assert.NoError(t, err)
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext: &context.AutoscalingContext{
+			AutoscalingOptions: config.AutoscalingOptions{
+				ScaleDownEnabled:      true,
+				MaxBulkSoftTaintCount: 1,
+				MaxBulkSoftTaintTime:  3 * time.Second,
+			},
+			AutoscalingKubeClients: context.AutoscalingKubeClients{
+				ClientSet: fakeClient,
+			},
+			CloudProvider: provider,
+		},
+	}
+
+	// Soft taints are kept unless their removal is enabled.
+	autoscaler.handleScaleDownAbort(getNodes(), metrics.ScaleDownAbortedScaleUp)
+	for _, node := range getNodes() {
+		assert.True(t, taints.HasDeletionCandidateTaint(node))
+	}
+
+	// Soft taints are removed within the bulk limit and only from selected node groups.
+	autoscaler.CleanSoftTaintsOnScaleDownAbort = true
+	autoscaler.handleScaleDownAbort(getNodes(), metrics.ScaleDownAbortedScaleUp)
+	autoscaler.handleScaleDownAbort(getNodes(), metrics.ScaleDownAbortedNewPendingPods)
+	nodes := getNodes()
+	assert.False(t, taints.HasDeletionCandidateTaint(nodes[0]))
+	assert.False(t, taints.HasDeletionCandidateTaint(nodes[1]))
+	assert.True(t, taints.HasDeletionCandidateTaint(nodes[2]))
+}
+
 func TestRemoveOldUnregisteredNodes(t *testing.T) {
 	deletedNodes := make(chan string, 10)
 
@@ -2271,6 +2334,299 @@ func TestRemoveOldUnregisteredNodesAtomic(t *testing.T) {
 	assert.ElementsMatch(t, wantNames, deletedNames)
 }
 
+func TestRemoveOldUninitializedNodes(t *testing.T) {
+	deletedNodes := make(chan string, 10)
+
+	now := time.Now()
+	uninitializedTaint := apiv1.Taint{Key: cloudproviderapi.TaintExternalCloudProvider, Value: "true", Effect: apiv1.TaintEffectNoSchedule}
+
+	provider := testprovider.NewTestCloudProvider(nil, func(nodegroup string, node string) error {
+		deletedNodes <- fmt.Sprintf("%s/%s", nodegroup, node)
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 4)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	var nodes []*apiv1.Node
+	for _, n := range []struct {
+		name      string
+		nodeGroup string
+		age       time.Duration
+		tainted   bool
+	}{
+		{name: "ng1-old-uninitialized-1", nodeGroup: "ng1", age: time.Hour, tainted: true},
+		{name: "ng1-old-uninitialized-2", nodeGroup: "ng1", age: time.Hour, tainted: true},
+		{name: "ng1-new-uninitialized", nodeGroup: "ng1", age: 10 * time.Minute, tainted: true},
+		{name: "ng1-old-initialized", nodeGroup: "ng1", age: time.Hour},
+		// Removing the node would violate the node group min size.
+		{name: "ng2-old-uninitialized", nodeGroup: "ng2", age: time.Hour, tainted: true},
+	} {
+		node := BuildTestNode(n.name, 1000, 1000)
+		node.Spec.ProviderID = n.name
+		node.CreationTimestamp = metav1.NewTime(now.Add(-n.age))
+		if n.tainted {
+			node.Spec.Taints = []apiv1.Taint{uninitializedTaint}
+		}
+		provider.AddNode(n.nodeGroup, node)
+		nodes = append(nodes, node)
+	}
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := clusterstate_utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+
+	context := &context.AutoscalingContext{
+		AutoscalingOptions: config.AutoscalingOptions{
+			UninitializedNodeRemovalTime: 30 * time.Minute,
+			MaxUninitializedNodesRemoved: 1,
+		},
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			LogRecorder: fakeLogRecorder,
+		},
+		CloudProvider: provider,
+	}
+	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+	}, fakeLogRecorder, NewBackoff(), nodegroupconfig.NewDefaultNodeGroupConfigProcessor(context.AutoscalingOptions.NodeGroupDefaults))
+
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext:   context,
+		clusterStateRegistry: clusterState,
+	}
+
+	// Nothing should be removed. None of the uninitialized nodes is old enough.
+	removed, err := autoscaler.removeOldUninitializedNodes(nodes, now.Add(-45*time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	// Only one of the old uninitialized nodes of ng1 should be removed, due to the per node group cap.
+	removed, err = autoscaler.removeOldUninitializedNodes(nodes, now)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, "ng1/ng1-old-uninitialized-1", core_utils.GetStringFromChan(deletedNodes))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(deletedNodes))
+}
+
+func TestReplaceBrokenNodes(t *testing.T) {
+	scaledUpGroups := make(chan string, 10)
+	deletedNodes := make(chan string, 10)
+
+	now := time.Now()
+	provider := testprovider.NewTestCloudProvider(func(nodegroup string, delta int) error {
+		scaledUpGroups <- fmt.Sprintf("%s/%d", nodegroup, delta)
+		return nil
+	}, func(nodegroup string, node string) error {
+		deletedNodes <- fmt.Sprintf("%s/%s", nodegroup, node)
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 2, 10, 2)
+	// Above its min size, scale-down removes unready nodes instead.
+	provider.AddNodeGroup("ng2", 1, 10, 2)
+	var nodes []*apiv1.Node
+	addNode := func(name, nodeGroup string, ready bool, unreadyFor time.Duration) {
+		node := BuildTestNode(name, 1000, 1000)
+		node.Spec.ProviderID = name
+		node.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+		SetNodeReadyState(node, ready, now.Add(-unreadyFor))
+		provider.AddNode(nodeGroup, node)
+		nodes = append(nodes, node)
+	}
+	addNode("ng1-ready", "ng1", true, time.Hour)
+	addNode("ng1-broken", "ng1", false, time.Hour)
+	addNode("ng2-ready", "ng2", true, time.Hour)
+	addNode("ng2-broken", "ng2", false, time.Hour)
+	nodeInfos := map[string]*schedulerframework.NodeInfo{}
+	for _, nodeGroup := range []string{"ng1", "ng2"} {
+		nodeInfos[nodeGroup] = schedulerframework.NewNodeInfo()
+		nodeInfos[nodeGroup].SetNode(BuildTestNode(nodeGroup+"-template", 1000, 1000))
+	}
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := clusterstate_utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+
+	context := &context.AutoscalingContext{
+		AutoscalingOptions: config.AutoscalingOptions{
+			BrokenNodeReplacementTime: 30 * time.Minute,
+		},
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			LogRecorder: fakeLogRecorder,
+		},
+		CloudProvider: provider,
+	}
+	clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+	}, fakeLogRecorder, NewBackoff(), nodegroupconfig.NewDefaultNodeGroupConfigProcessor(context.AutoscalingOptions.NodeGroupDefaults))
+
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext:     context,
+		clusterStateRegistry:   clusterState,
+		brokenNodeReplacements: make(map[string]bool),
+		resourceManager:        resource.NewManager(customresources.NewDefaultCustomResourcesProcessor()),
+	}
+
+	// Nothing should be replaced. The broken node isn't unready for long enough.
+	assert.NoError(t, clusterState.UpdateNodes(nodes, nil, now))
+	replaced, err := autoscaler.replaceBrokenNodes(nodes, nodeInfos, now.Add(-45*time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+
+	// A replacement of the broken node of ng1 should be requested first.
+	replaced, err = autoscaler.replaceBrokenNodes(nodes, nodeInfos, now)
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+	assert.Equal(t, "ng1/1", core_utils.GetStringFromChan(scaledUpGroups))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(scaledUpGroups))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(deletedNodes))
+
+	// The broken node is kept while its replacement is upcoming.
+	assert.NoError(t, clusterState.UpdateNodes(nodes, nil, now))
+	replaced, err = autoscaler.replaceBrokenNodes(nodes, nodeInfos, now)
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(scaledUpGroups))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(deletedNodes))
+
+	// The broken node is kept while its replacement fails to register.
+	replacement := BuildTestNode("ng1-replacement", 1000, 1000)
+	replacement.Spec.ProviderID = replacement.Name
+	replacement.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+	SetNodeReadyState(replacement, false, now.Add(-time.Minute))
+	provider.AddNode("ng1", replacement)
+	assert.NoError(t, clusterState.UpdateNodes(nodes, nil, now))
+	later := now.Add(time.Hour)
+	assert.NoError(t, clusterState.UpdateNodes(nodes, nil, later))
+	upcomingCounts, _ := clusterState.GetUpcomingNodes()
+	assert.Zero(t, upcomingCounts["ng1"])
+	replaced, err = autoscaler.replaceBrokenNodes(nodes, nodeInfos, later)
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(scaledUpGroups))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(deletedNodes))
+
+	// The broken node is kept while its replacement is registered but unready.
+	nodes = append(nodes, replacement)
+	assert.NoError(t, clusterState.UpdateNodes(nodes, nil, later))
+	upcomingCounts, _ = clusterState.GetUpcomingNodes()
+	assert.Zero(t, upcomingCounts["ng1"])
+	replaced, err = autoscaler.replaceBrokenNodes(nodes, nodeInfos, later)
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(scaledUpGroups))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(deletedNodes))
+
+	// The broken node is deleted once its replacement is ready.
+	SetNodeReadyState(replacement, true, later.Add(-time.Minute))
+	RemoveNodeNotReadyTaint(replacement)
+	assert.NoError(t, clusterState.UpdateNodes(nodes, nil, later))
+	replaced, err = autoscaler.replaceBrokenNodes(nodes, nodeInfos, later)
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+	assert.Equal(t, "ng1/ng1-broken", core_utils.GetStringFromChan(deletedNodes))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(deletedNodes))
+	assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(scaledUpGroups))
+	assert.Empty(t, autoscaler.brokenNodeReplacements)
+}
+
+func TestReplaceBrokenNodesWithinLimits(t *testing.T) {
+	testCases := []struct {
+		name             string
+		maxNodesTotal    int
+		maxCores         int64
+		domainLimits     []config.DomainNodeLimits
+		expectedScaleUps []string
+	}{
+		{
+			name:             "no limits",
+			expectedScaleUps: []string{"ng1/2"},
+		},
+		{
+			name:             "capped by max nodes total",
+			maxNodesTotal:    4,
+			expectedScaleUps: []string{"ng1/1"},
+		},
+		{
+			name:          "max nodes total reached",
+			maxNodesTotal: 3,
+		},
+		{
+			name:             "capped by resource limits",
+			maxCores:         4,
+			expectedScaleUps: []string{"ng1/1"},
+		},
+		{
+			name:     "resource limits reached",
+			maxCores: 3,
+		},
+		{
+			name:         "max nodes per domain reached",
+			domainLimits: []config.DomainNodeLimits{{Label: "zone", Max: 3}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaledUpGroups := make(chan string, 10)
+			now := time.Now()
+			provider := testprovider.NewTestCloudProvider(func(nodegroup string, delta int) error {
+				scaledUpGroups <- fmt.Sprintf("%s/%d", nodegroup, delta)
+				return nil
+			}, nil)
+			if tc.maxCores > 0 {
+				provider.SetResourceLimiter(cloudprovider.NewResourceLimiter(
+					map[string]int64{cloudprovider.ResourceNameCores: 0, cloudprovider.ResourceNameMemory: 0},
+					map[string]int64{cloudprovider.ResourceNameCores: tc.maxCores, cloudprovider.ResourceNameMemory: 1000000}))
+			}
+			provider.AddNodeGroup("ng1", 3, 10, 3)
+			var nodes []*apiv1.Node
+			for i, ready := range []bool{true, false, false} {
+				node := BuildTestNode(fmt.Sprintf("ng1-%d", i), 1000, 1000)
+				node.Spec.ProviderID = node.Name
+				node.Labels["zone"] = "a"
+				node.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+				SetNodeReadyState(node, ready, now.Add(-time.Hour))
+				provider.AddNode("ng1", node)
+				nodes = append(nodes, node)
+			}
+			template := BuildTestNode("ng1-template", 1000, 1000)
+			template.Labels["zone"] = "a"
+			nodeInfo := schedulerframework.NewNodeInfo()
+			nodeInfo.SetNode(template)
+			nodeInfos := map[string]*schedulerframework.NodeInfo{"ng1": nodeInfo}
+
+			fakeLogRecorder, _ := clusterstate_utils.NewStatusMapRecorder(&fake.Clientset{}, "kube-system", kube_record.NewFakeRecorder(5), false, "my-cool-configmap")
+			context := &context.AutoscalingContext{
+				AutoscalingOptions: config.AutoscalingOptions{
+					BrokenNodeReplacementTime: 30 * time.Minute,
+					MaxNodesTotal:             tc.maxNodesTotal,
+					MaxNodesPerLabelDomain:    tc.domainLimits,
+				},
+				AutoscalingKubeClients: context.AutoscalingKubeClients{
+					LogRecorder: fakeLogRecorder,
+				},
+				CloudProvider: provider,
+			}
+			clusterState := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{
+				MaxTotalUnreadyPercentage: 100,
+				OkTotalUnreadyCount:       10,
+			}, fakeLogRecorder, NewBackoff(), nodegroupconfig.NewDefaultNodeGroupConfigProcessor(context.AutoscalingOptions.NodeGroupDefaults))
+			assert.NoError(t, clusterState.UpdateNodes(nodes, nil, now))
+
+			autoscaler := &StaticAutoscaler{
+				AutoscalingContext:     context,
+				clusterStateRegistry:   clusterState,
+				brokenNodeReplacements: make(map[string]bool),
+				resourceManager:        resource.NewManager(customresources.NewDefaultCustomResourcesProcessor()),
+			}
+			replaced, err := autoscaler.replaceBrokenNodes(nodes, nodeInfos, now)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.expectedScaleUps) > 0, replaced)
+			for _, scaleUp := range tc.expectedScaleUps {
+				assert.Equal(t, scaleUp, core_utils.GetStringFromChan(scaledUpGroups))
+			}
+			assert.Equal(t, core_utils.NothingReturned, core_utils.GetStringFromChanImmediately(scaledUpGroups))
+		})
+	}
+}
+
 func TestSubtractNodes(t *testing.T) {
 	ns := make([]*apiv1.Node, 5)
 	for i := 0; i < len(ns); i++ {
@@ -2316,6 +2672,96 @@ func TestSubtractNodes(t *testing.T) {
 	}
 }
 
+func TestCountPodsOnUpcomingNodes(t *testing.T) {
+	snapshot := clustersnapshot.NewBasicClusterSnapshot()
+	node := BuildTestNode("n1", 1000, 1000)
+	upcomingNode := BuildTestNode("upcoming", 1000, 1000)
+	upcomingNode.Annotations = map[string]string{NodeUpcomingAnnotation: "true"}
+	onNode := BuildTestPod("on-node", 100, 100)
+	onUpcomingNode := BuildTestPod("on-upcoming-node", 100, 100)
+	unscheduled := BuildTestPod("unscheduled", 100, 100)
+	assert.NoError(t, snapshot.AddNodeWithPods(node, []*apiv1.Pod{onNode}))
+	assert.NoError(t, snapshot.AddNodeWithPods(upcomingNode, []*apiv1.Pod{onUpcomingNode}))
+
+	assert.Equal(t, 1, countPodsOnUpcomingNodes(snapshot, []*apiv1.Pod{onNode, onUpcomingNode, unscheduled}))
+}
+
+func TestTriagePendingPods(t *testing.T) {
+	p1 := BuildTestPod("p1", 100, 100)
+	p2 := BuildTestPod("p2", 100, 100)
+	p3 := BuildTestPod("p3", 100, 100)
+	p4 := BuildTestPod("p4", 100, 100)
+	rejected := map[string]scaleupstatus.Reasons{"ng1": orchestrator.NewRejectedReasons("node(s) had untolerated taint")}
+
+	testCases := []struct {
+		name          string
+		scaleUpStatus *scaleupstatus.ScaleUpStatus
+		podsToHelp    []*apiv1.Pod
+		want          map[metrics.PendingPodCategory]int
+	}{
+		{
+			name:          "no pending pods",
+			scaleUpStatus: &scaleupstatus.ScaleUpStatus{Result: scaleupstatus.ScaleUpNotNeeded},
+			want:          map[metrics.PendingPodCategory]int{metrics.PendingPodsAwaitingNodeRegistration: 2, metrics.PendingPodsAwaitingScaleUp: 0},
+		},
+		{
+			name:          "pods too new",
+			scaleUpStatus: &scaleupstatus.ScaleUpStatus{Result: scaleupstatus.ScaleUpInCooldown},
+			podsToHelp:    []*apiv1.Pod{p1, p2},
+			want:          map[metrics.PendingPodCategory]int{metrics.PendingPodsAwaitingNodeRegistration: 2, metrics.PendingPodsAwaitingScaleUp: 2},
+		},
+		{
+			name:          "max nodes total reached",
+			scaleUpStatus: &scaleupstatus.ScaleUpStatus{Result: scaleupstatus.ScaleUpNoOptionsAvailable},
+			podsToHelp:    []*apiv1.Pod{p1},
+			want:          map[metrics.PendingPodCategory]int{metrics.PendingPodsAwaitingNodeRegistration: 2, metrics.PendingPodsBlockedByQuota: 1},
+		},
+		{
+			name: "scale-up",
+			scaleUpStatus: &scaleupstatus.ScaleUpStatus{
+				Result:               scaleupstatus.ScaleUpSuccessful,
+				PodsTriggeredScaleUp: []*apiv1.Pod{p1},
+				PodsAwaitEvaluation:  []*apiv1.Pod{p2},
+				PodsRemainUnschedulable: []scaleupstatus.NoScaleUpInfo{
+					{Pod: p3, RejectedNodeGroups: rejected},
+				},
+			},
+			podsToHelp: []*apiv1.Pod{p1, p2, p3},
+			want: map[metrics.PendingPodCategory]int{
+				metrics.PendingPodsAwaitingNodeRegistration: 2,
+				metrics.PendingPodsAwaitingScaleUp:          2,
+				metrics.PendingPodsUnmatchable:              1,
+			},
+		},
+		{
+			name: "blocked pods",
+			scaleUpStatus: &scaleupstatus.ScaleUpStatus{
+				Result: scaleupstatus.ScaleUpNoOptionsAvailable,
+				PodsRemainUnschedulable: []scaleupstatus.NoScaleUpInfo{
+					{Pod: p1, RejectedNodeGroups: rejected, SkippedNodeGroups: map[string]scaleupstatus.Reasons{"ng2": orchestrator.MaxLimitReachedReason}},
+					{Pod: p2, RejectedNodeGroups: rejected, SkippedNodeGroups: map[string]scaleupstatus.Reasons{"ng2": orchestrator.NewMaxResourceLimitReached([]string{"cpu"})}},
+					{Pod: p3, RejectedNodeGroups: rejected, SkippedNodeGroups: map[string]scaleupstatus.Reasons{"ng2": orchestrator.BackoffReason}},
+					{Pod: p4, RejectedNodeGroups: rejected, SkippedNodeGroups: map[string]scaleupstatus.Reasons{"ng2": orchestrator.NewMaxDomainNodesReached("max nodes in topology.kubernetes.io/zone=a reached")}},
+				},
+			},
+			podsToHelp: []*apiv1.Pod{p1, p2, p3, p4},
+			want: map[metrics.PendingPodCategory]int{
+				metrics.PendingPodsAwaitingNodeRegistration: 2,
+				metrics.PendingPodsAwaitingScaleUp:          0,
+				metrics.PendingPodsBlockedByMaxSize:         1,
+				metrics.PendingPodsBlockedByQuota:           1,
+				metrics.PendingPodsBlockedByDomainLimit:     1,
+				metrics.PendingPodsBlockedByBackoff:         1,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, triagePendingPods(tc.scaleUpStatus, tc.podsToHelp, 2))
+		})
+	}
+}
+
 func TestFilterOutYoungPods(t *testing.T) {
 	now := time.Now()
 	klog.InitFlags(nil)
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimator
+
+import (
+	"math"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/predicatechecker"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// CorrectionFactors provides the factor the estimated node count of a node group is multiplied by,
+// e.g. learned from the nodes previous scale-ups of the node group actually needed.
+type CorrectionFactors interface {
+	// CorrectionFactor returns the correction factor of the node group, 1 if there is none.
+	CorrectionFactor(nodeGroupId string) float64
+}
+
+// NewCorrectedEstimatorBuilder returns an EstimatorBuilder building estimators which correct the node
+// count estimated by the estimators of the given builder with the factors of the node groups.
+func NewCorrectedEstimatorBuilder(builder EstimatorBuilder, factors CorrectionFactors) EstimatorBuilder {
+	return func(
+		predicateChecker predicatechecker.PredicateChecker,
+		clusterSnapshot clustersnapshot.ClusterSnapshot,
+		context EstimationContext) Estimator {
+		return &correctedEstimator{
+			estimator: builder(predicateChecker, clusterSnapshot, context),
+			factors:   factors,
+		}
+	}
+}
+
+type correctedEstimator struct {
+	estimator Estimator
+	factors   CorrectionFactors
+}
+
+// Estimate runs the wrapped estimator and multiplies the node count by the correction factor of the node
+// group, rounding up. A scale-up is never corrected down to zero nodes. When the node count is corrected
+// up, the pods are the ones scheduled by the wrapped estimator. When it's corrected down, the pods are the
+// ones the wrapped estimator schedules on at most the corrected node count, so that the scale-up doesn't
+// claim to help pods the nodes can't fit.
+func (e *correctedEstimator) Estimate(podsEquivalenceGroups []PodEquivalenceGroup, nodeTemplate *schedulerframework.NodeInfo, nodeGroup cloudprovider.NodeGroup) (int, []*apiv1.Pod) {
+	nodeCount, scheduledPods := e.estimator.Estimate(podsEquivalenceGroups, nodeTemplate, nodeGroup)
+	if nodeCount == 0 || nodeGroup == nil {
+		return nodeCount, scheduledPods
+	}
+	corrected := correctNodeCount(nodeCount, e.factors.CorrectionFactor(nodeGroup.Id()))
+	if corrected >= nodeCount {
+		return corrected, scheduledPods
+	}
+	return e.estimateWithin(podsEquivalenceGroups, nodeTemplate, nodeGroup, corrected)
+}
+
+// estimateWithin returns the estimation of the wrapped estimator for the longest prefix of the pods
+// which needs at most maxNodes nodes, found by bisection.
+func (e *correctedEstimator) estimateWithin(podsEquivalenceGroups []PodEquivalenceGroup, nodeTemplate *schedulerframework.NodeInfo, nodeGroup cloudprovider.NodeGroup, maxNodes int) (int, []*apiv1.Pod) {
+	total := 0
+	for _, group := range podsEquivalenceGroups {
+		total += len(group.Pods)
+	}
+	bestCount, bestPods := 0, []*apiv1.Pod{}
+	low, high := 1, total
+	for low <= high {
+		podCount := low + (high-low)/2
+		nodeCount, scheduledPods := e.estimator.Estimate(podsPrefix(podsEquivalenceGroups, podCount), nodeTemplate, nodeGroup)
+		if nodeCount <= maxNodes {
+			bestCount, bestPods = nodeCount, scheduledPods
+			low = podCount + 1
+		} else {
+			high = podCount - 1
+		}
+	}
+	return bestCount, bestPods
+}
+
+// podsPrefix returns the pod equivalence groups truncated to their first podCount pods.
+func podsPrefix(podsEquivalenceGroups []PodEquivalenceGroup, podCount int) []PodEquivalenceGroup {
+	prefix := make([]PodEquivalenceGroup, 0, len(podsEquivalenceGroups))
+	for _, group := range podsEquivalenceGroups {
+		if podCount <= 0 {
+			break
+		}
+		pods := group.Pods
+		if len(pods) > podCount {
+			pods = pods[:podCount]
+		}
+		prefix = append(prefix, PodEquivalenceGroup{Pods: pods})
+		podCount -= len(pods)
+	}
+	return prefix
+}
+
+// correctNodeCount multiplies a positive node count by the correction factor, rounding up, and returns
+// at least one node.
+func correctNodeCount(nodeCount int, factor float64) int {
+	corrected := int(math.Ceil(float64(nodeCount)*factor - 1e-9))
+	if corrected < 1 {
+		return 1
+	}
+	return corrected
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimator
+
+import (
+	"fmt"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// podsPerNodeEstimator schedules all the pods, on one node per podsPerNode pods.
+type podsPerNodeEstimator struct {
+	podsPerNode int
+}
+
+func (e *podsPerNodeEstimator) Estimate(podsEquivalenceGroups []PodEquivalenceGroup, _ *schedulerframework.NodeInfo, _ cloudprovider.NodeGroup) (int, []*apiv1.Pod) {
+	var pods []*apiv1.Pod
+	for _, group := range podsEquivalenceGroups {
+		pods = append(pods, group.Pods...)
+	}
+	return (len(pods) + e.podsPerNode - 1) / e.podsPerNode, pods
+}
+
+type fixedCorrectionFactors float64
+
+func (f fixedCorrectionFactors) CorrectionFactor(string) float64 {
+	return float64(f)
+}
+
+func TestCorrectedEstimator(t *testing.T) {
+	var pods []*apiv1.Pod
+	for i := 0; i < 10; i++ {
+		pods = append(pods, BuildTestPod(fmt.Sprintf("p%d", i), 100, 0))
+	}
+	groups := []PodEquivalenceGroup{{Pods: pods[:4]}, {Pods: pods[4:]}}
+	nodeGroup := testprovider.NewTestNodeGroup("ng", 10, 0, 0, true, false, "", nil, nil)
+
+	// Corrected up, all the pods are still scheduled.
+	e := &correctedEstimator{estimator: &podsPerNodeEstimator{podsPerNode: 2}, factors: fixedCorrectionFactors(1.4)}
+	nodeCount, scheduledPods := e.Estimate(groups, nil, nodeGroup)
+	assert.Equal(t, 7, nodeCount)
+	assert.Equal(t, pods, scheduledPods)
+
+	// Corrected down, only the pods fitting the corrected node count are scheduled.
+	e = &correctedEstimator{estimator: &podsPerNodeEstimator{podsPerNode: 2}, factors: fixedCorrectionFactors(0.6)}
+	nodeCount, scheduledPods = e.Estimate(groups, nil, nodeGroup)
+	assert.Equal(t, 3, nodeCount)
+	assert.Equal(t, pods[:6], scheduledPods)
+}
+
+func TestCorrectNodeCount(t *testing.T) {
+	cases := map[string]struct {
+		nodeCount int
+		factor    float64
+		want      int
+	}{
+		"no correction": {
+			nodeCount: 4,
+			factor:    1,
+			want:      4,
+		},
+		"corrected up": {
+			nodeCount: 4,
+			factor:    1.3,
+			want:      6,
+		},
+		"corrected down": {
+			nodeCount: 10,
+			factor:    0.7,
+			want:      7,
+		},
+		"at least one node": {
+			nodeCount: 1,
+			factor:    0.5,
+			want:      1,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, correctNodeCount(tc.nodeCount, tc.factor))
+		})
+	}
+}
@@ -30,10 +30,12 @@ import (
 const (
 	// BinpackingEstimatorName is the name of binpacking estimator.
 	BinpackingEstimatorName = "binpacking"
+	// FFDEstimatorName is the name of multi-dimensional First-Fit Decreasing estimator.
+	FFDEstimatorName = "ffd"
 )
 
 // AvailableEstimators is a list of available estimators.
-var AvailableEstimators = []string{BinpackingEstimatorName}
+var AvailableEstimators = []string{BinpackingEstimatorName, FFDEstimatorName}
 
 // PodEquivalenceGroup represents a group of pods, which have the same scheduling
 // requirements and are managed by the same controller.
@@ -73,6 +75,13 @@ func NewEstimatorBuilder(name string, limiter EstimationLimiter, orderer Estimat
 			context EstimationContext) Estimator {
 			return NewBinpackingNodeEstimator(predicateChecker, clusterSnapshot, limiter, orderer, context, estimationAnalyserFunc)
 		}, nil
+	case FFDEstimatorName:
+		return func(
+			predicateChecker predicatechecker.PredicateChecker,
+			clusterSnapshot clustersnapshot.ClusterSnapshot,
+			context EstimationContext) Estimator {
+			return NewFFDNodeEstimator(predicateChecker, clusterSnapshot, limiter, context, estimationAnalyserFunc)
+		}, nil
 	}
 	return nil, fmt.Errorf("unknown estimator: %s", name)
 }
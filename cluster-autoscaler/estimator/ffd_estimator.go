@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimator
+
+import (
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/predicatechecker"
+	klog "k8s.io/klog/v2"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// FFDNodeEstimator estimates the number of needed nodes with multi-dimensional
+// First-Fit Decreasing bin-packing. Unlike BinpackingNodeEstimator, which packs
+// whole equivalence groups one after another, it orders individual pods by their
+// dominant share of any resource of the node, e.g. CPU, memory or GPUs, and places
+// each pod on the first node it fits, so that small pods of any group fill the
+// space left by big ones. This improves estimates for heterogeneous pod sets at
+// the cost of checking more nodes per pod.
+type FFDNodeEstimator struct {
+	*BinpackingNodeEstimator
+}
+
+// ffdPod is a pod to place along with the index of its equivalence group and its dominant share.
+type ffdPod struct {
+	pod   *apiv1.Pod
+	group int
+	share float64
+}
+
+// NewFFDNodeEstimator builds a new FFDNodeEstimator.
+func NewFFDNodeEstimator(
+	predicateChecker predicatechecker.PredicateChecker,
+	clusterSnapshot clustersnapshot.ClusterSnapshot,
+	limiter EstimationLimiter,
+	context EstimationContext,
+	estimationAnalyserFunc EstimationAnalyserFunc,
+) *FFDNodeEstimator {
+	return &FFDNodeEstimator{
+		BinpackingNodeEstimator: NewBinpackingNodeEstimator(predicateChecker, clusterSnapshot, limiter, nil, context, estimationAnalyserFunc),
+	}
+}
+
+// Estimate implements multi-dimensional First-Fit Decreasing bin-packing.
+// Pods which can't be placed on an empty node, e.g. because of topology spread
+// constraints, are skipped together with the rest of their equivalence group,
+// and packing continues with pods of other groups.
+// Returns the number of nodes needed to accommodate the pods and the pods placed.
+func (e *FFDNodeEstimator) Estimate(
+	podsEquivalenceGroups []PodEquivalenceGroup,
+	nodeTemplate *schedulerframework.NodeInfo,
+	nodeGroup cloudprovider.NodeGroup,
+) (int, []*apiv1.Pod) {
+	e.limiter.StartEstimation(podsEquivalenceGroups, nodeGroup, e.context)
+	defer e.limiter.EndEstimation()
+
+	e.clusterSnapshot.Fork()
+	defer func() {
+		e.clusterSnapshot.Revert()
+	}()
+
+	estimationState := newEstimationState()
+	var newNodeNames []string
+	unschedulableGroups := make(map[int]bool)
+	canAddNodes := true
+	for _, p := range orderByDominantShare(podsEquivalenceGroups, nodeTemplate) {
+		if unschedulableGroups[p.group] {
+			continue
+		}
+		nodeName := ""
+		for _, name := range newNodeNames {
+			if err := e.predicateChecker.CheckPredicates(e.clusterSnapshot, p.pod, name); err == nil {
+				nodeName = name
+				break
+			}
+		}
+		if nodeName == "" {
+			// If the pod doesn't fit the last node while it is empty, it wouldn't fit a new node either.
+			if estimationState.lastNodeName != "" && !estimationState.newNodesWithPods[estimationState.lastNodeName] {
+				unschedulableGroups[p.group] = true
+				continue
+			}
+			// Smaller pods may still fit the nodes added so far once the limit is reached.
+			if !canAddNodes || !e.limiter.PermissionToAddNode() {
+				canAddNodes = false
+				continue
+			}
+			if err := e.addNewNodeToSnapshot(estimationState, nodeTemplate); err != nil {
+				klog.Errorf("Error while adding new node for template to ClusterSnapshot; %v", err)
+				return 0, nil
+			}
+			newNodeNames = append(newNodeNames, estimationState.lastNodeName)
+			if err := e.predicateChecker.CheckPredicates(e.clusterSnapshot, p.pod, estimationState.lastNodeName); err != nil {
+				unschedulableGroups[p.group] = true
+				continue
+			}
+			nodeName = estimationState.lastNodeName
+		}
+		if err := e.tryToAddNode(estimationState, p.pod, nodeName); err != nil {
+			klog.Errorf(err.Error())
+			return 0, nil
+		}
+	}
+
+	if e.estimationAnalyserFunc != nil {
+		e.estimationAnalyserFunc(e.clusterSnapshot, nodeGroup, estimationState.newNodesWithPods)
+	}
+	return len(estimationState.newNodesWithPods), estimationState.scheduledPods
+}
+
+// orderByDominantShare returns the pods of all equivalence groups ordered by their
+// dominant share of the node template allocatable, biggest first.
+func orderByDominantShare(podsEquivalenceGroups []PodEquivalenceGroup, nodeTemplate *schedulerframework.NodeInfo) []ffdPod {
+	var pods []ffdPod
+	for i, podsEquivalenceGroup := range podsEquivalenceGroups {
+		exemplar := podsEquivalenceGroup.Exemplar()
+		if exemplar == nil {
+			continue
+		}
+		share := dominantShare(exemplar, nodeTemplate.Node().Status.Allocatable)
+		for _, pod := range podsEquivalenceGroup.Pods {
+			pods = append(pods, ffdPod{pod: pod, group: i, share: share})
+		}
+	}
+	sort.SliceStable(pods, func(i, j int) bool { return pods[i].share > pods[j].share })
+	return pods
+}
+
+// dominantShare returns the highest fraction of any allocatable resource requested by the pod.
+func dominantShare(pod *apiv1.Pod, allocatable apiv1.ResourceList) float64 {
+	share := 0.0
+	for name, request := range resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{}) {
+		capacity, found := allocatable[name]
+		if !found || capacity.MilliValue() <= 0 {
+			continue
+		}
+		if s := float64(request.MilliValue()) / float64(capacity.MilliValue()); s > share {
+			share = s
+		}
+	}
+	return share
+}
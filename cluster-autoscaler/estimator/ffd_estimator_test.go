@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/predicatechecker"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestFFDEstimate(t *testing.T) {
+	buildPod := func(name string, cpu, mem int64, options ...func(*apiv1.Pod)) *apiv1.Pod {
+		options = append(options, WithNamespace("universe"), WithLabels(map[string]string{"app": name}))
+		return BuildTestPod(name, cpu, mem, options...)
+	}
+	testCases := []struct {
+		name                  string
+		millicores            int64
+		memory                int64
+		maxNodes              int
+		podsEquivalenceGroups []PodEquivalenceGroup
+		expectNodeCount       int
+		expectPodCount        int
+	}{
+		{
+			name:       "simple resource-based packing",
+			millicores: 350*3 - 50,
+			memory:     2 * 1000,
+			podsEquivalenceGroups: []PodEquivalenceGroup{
+				makePodEquivalenceGroup(buildPod("estimatee", 350, 1000), 10),
+			},
+			expectNodeCount: 5,
+			expectPodCount:  10,
+		},
+		{
+			name:       "small pods fill the space left by big pods",
+			millicores: 1000,
+			memory:     5000,
+			podsEquivalenceGroups: []PodEquivalenceGroup{
+				makePodEquivalenceGroup(buildPod("small", 300, 100), 3),
+				makePodEquivalenceGroup(buildPod("big", 700, 100), 3),
+			},
+			expectNodeCount: 3,
+			expectPodCount:  6,
+		},
+		{
+			name:       "memory is the dominant resource",
+			millicores: 1000,
+			memory:     1000,
+			podsEquivalenceGroups: []PodEquivalenceGroup{
+				makePodEquivalenceGroup(buildPod("cpu", 400, 100*units.MiB), 2),
+				makePodEquivalenceGroup(buildPod("memory", 100, 600*units.MiB), 2),
+			},
+			expectNodeCount: 2,
+			expectPodCount:  4,
+		},
+		{
+			name:       "limiter cuts packing",
+			millicores: 1000,
+			memory:     5000,
+			podsEquivalenceGroups: []PodEquivalenceGroup{
+				makePodEquivalenceGroup(buildPod("estimatee", 500, 1000), 20),
+			},
+			maxNodes:        5,
+			expectNodeCount: 5,
+			expectPodCount:  10,
+		},
+		{
+			name:       "unschedulable group doesn't stop packing of other groups",
+			millicores: 1000,
+			memory:     5000,
+			podsEquivalenceGroups: []PodEquivalenceGroup{
+				makePodEquivalenceGroup(buildPod("estimatee", 500, 100, WithMaxSkew(2, "topology.kubernetes.io/zone")), 8),
+				makePodEquivalenceGroup(buildPod("other", 250, 100), 4),
+			},
+			expectNodeCount: 2,
+			expectPodCount:  6,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clusterSnapshot := clustersnapshot.NewBasicClusterSnapshot()
+			clusterSnapshot.AddNode(makeNode(100, 100, 10, "oldnode", "zone-jupiter"))
+
+			predicateChecker, err := predicatechecker.NewTestPredicateChecker()
+			assert.NoError(t, err)
+			limiter := NewThresholdBasedEstimationLimiter([]Threshold{NewStaticThreshold(tc.maxNodes, time.Duration(0))})
+			estimator := NewFFDNodeEstimator(predicateChecker, clusterSnapshot, limiter, nil /* EstimationContext */, nil /* EstimationAnalyserFunc */)
+			nodeInfo := schedulerframework.NewNodeInfo()
+			nodeInfo.SetNode(makeNode(tc.millicores, tc.memory, 10, "template", "zone-mars"))
+
+			estimatedNodes, estimatedPods := estimator.Estimate(tc.podsEquivalenceGroups, nodeInfo, nil)
+			assert.Equal(t, tc.expectNodeCount, estimatedNodes)
+			assert.Equal(t, tc.expectPodCount, len(estimatedPods))
+		})
+	}
+}
+
+func TestOrderByDominantShare(t *testing.T) {
+	node := makeNode(4000, 4000, 10, "template", "zone-mars")
+	AddGpusToNode(node, 4)
+	nodeInfo := schedulerframework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	cpuPod := BuildTestPod("cpu", 1500, 100)
+	gpuPod := BuildTestPod("gpu", 100, 100)
+	RequestGpuForPod(gpuPod, 2)
+	memoryPod := BuildTestPod("memory", 100, 1000*units.MiB)
+
+	ordered := orderByDominantShare([]PodEquivalenceGroup{
+		makePodEquivalenceGroup(memoryPod, 1),
+		makePodEquivalenceGroup(cpuPod, 1),
+		makePodEquivalenceGroup(gpuPod, 1),
+	}, nodeInfo)
+	var names []string
+	for _, p := range ordered {
+		names = append(names, p.pod.Name)
+	}
+	assert.Equal(t, []string{"gpu", "cpu", "memory"}, names)
+}
@@ -24,7 +24,7 @@ import (
 
 var (
 	// AvailableExpanders is a list of available expander options
-	AvailableExpanders = []string{RandomExpanderName, MostPodsExpanderName, LeastWasteExpanderName, PriceBasedExpanderName, PriorityBasedExpanderName, GRPCExpanderName}
+	AvailableExpanders = []string{RandomExpanderName, MostPodsExpanderName, LeastWasteExpanderName, PriceBasedExpanderName, PriorityBasedExpanderName, GRPCExpanderName, WeightedRandomExpanderName, WeightedPriorityExpanderName}
 	// RandomExpanderName selects a node group at random
 	RandomExpanderName = "random"
 	// MostPodsExpanderName selects a node group that fits the most pods
@@ -38,6 +38,11 @@ var (
 	PriceBasedExpanderName = "price"
 	// PriorityBasedExpanderName selects a node group based on a user-configured priorities assigned to group names
 	PriorityBasedExpanderName = "priority"
+	// WeightedRandomExpanderName selects a node group at random, weighted by user-configured weights assigned to group names
+	WeightedRandomExpanderName = "weighted-random"
+	// WeightedPriorityExpanderName selects node groups with the highest sum of user-configured weights assigned to group names,
+	// breaking ties by price
+	WeightedPriorityExpanderName = "weighted-priority"
 	// GRPCExpanderName uses the gRPC client expander to call to an external gRPC server to select a node group for scale up
 	GRPCExpanderName = "grpc"
 )
@@ -23,10 +23,13 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/expander/grpcplugin"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/leastnodes"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/mostpods"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/podpreference"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/price"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/priority"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/random"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/waste"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/weightedpriority"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/weightedrandom"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 
@@ -37,6 +40,7 @@ import (
 // Factory can create expander.Strategy based on provided expander names.
 type Factory struct {
 	createFunc map[string]func() expander.Filter
+	preFilters []expander.Filter
 }
 
 // NewFactory returns a new Factory.
@@ -51,8 +55,22 @@ func (f *Factory) RegisterFilter(name string, createFunc func() expander.Filter)
 	f.createFunc[name] = createFunc
 }
 
+// AddPreFilter adds a filter applied to expansion options before the expanders
+// selected by name, e.g. to drop or trim options regardless of the expanders.
+func (f *Factory) AddPreFilter(filter expander.Filter) {
+	f.preFilters = append(f.preFilters, filter)
+}
+
 // Build creates a new expander.Strategy based on a list of expander.Filter names.
 func (f *Factory) Build(names []string) (expander.Strategy, errors.AutoscalerError) {
+	return f.BuildWithPodPreference(names, podpreference.NonePolicy)
+}
+
+// BuildWithPodPreference creates a new expander.Strategy based on a list of expander.Filter names,
+// honoring the preferred node groups annotations of pods according to the given policy. With the
+// exclusive policy only the preferred node groups are passed to the filters, with the bonus policy
+// the preferences break the ties left by the filters.
+func (f *Factory) BuildWithPodPreference(names []string, podPreferencePolicy string) (expander.Strategy, errors.AutoscalerError) {
 	var filters []expander.Filter
 	seenExpanders := map[string]struct{}{}
 	strategySeen := false
@@ -75,6 +93,21 @@ func (f *Factory) Build(names []string) (expander.Strategy, errors.AutoscalerErr
 			strategySeen = true
 		}
 	}
+	switch podPreferencePolicy {
+	case podpreference.NonePolicy, "":
+	case podpreference.ExclusivePolicy:
+		filters = append([]expander.Filter{podpreference.NewFilter()}, filters...)
+	case podpreference.BonusPolicy:
+		// The preference must be applied before a filter always returning a single option.
+		position := len(filters)
+		if strategySeen {
+			position--
+		}
+		filters = append(filters[:position], append([]expander.Filter{podpreference.NewFilter()}, filters[position:]...)...)
+	default:
+		return nil, errors.NewAutoscalerError(errors.InternalError, "Pod preference policy %s not supported", podPreferencePolicy)
+	}
+	filters = append(append([]expander.Filter{}, f.preFilters...), filters...)
 	return newChainStrategy(filters, random.NewStrategy()), nil
 }
 
@@ -97,5 +130,15 @@ func (f *Factory) RegisterDefaultExpanders(cloudProvider cloudprovider.CloudProv
 		lister := kubernetes.NewConfigMapListerForNamespace(kubeClient, stopChannel, configNamespace)
 		return priority.NewFilter(lister.ConfigMaps(configNamespace), autoscalingKubeClients.Recorder)
 	})
+	f.RegisterFilter(expander.WeightedRandomExpanderName, func() expander.Filter {
+		stopChannel := make(chan struct{})
+		lister := kubernetes.NewConfigMapListerForNamespace(kubeClient, stopChannel, configNamespace)
+		return weightedrandom.NewFilter(lister.ConfigMaps(configNamespace), autoscalingKubeClients.Recorder)
+	})
+	f.RegisterFilter(expander.WeightedPriorityExpanderName, func() expander.Filter {
+		stopChannel := make(chan struct{})
+		lister := kubernetes.NewConfigMapListerForNamespace(kubeClient, stopChannel, configNamespace)
+		return weightedpriority.NewFilter(lister.ConfigMaps(configNamespace), autoscalingKubeClients.Recorder, cloudProvider)
+	})
 	f.RegisterFilter(expander.GRPCExpanderName, func() expander.Filter { return grpcplugin.NewFilter(GRPCExpanderCert, GRPCExpanderURL) })
 }
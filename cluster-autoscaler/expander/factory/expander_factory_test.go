@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/mostpods"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/podpreference"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/random"
+)
+
+func TestBuildWithPodPreference(t *testing.T) {
+	f := NewFactory()
+	f.RegisterFilter(expander.MostPodsExpanderName, mostpods.NewFilter)
+	f.RegisterFilter(expander.RandomExpanderName, random.NewFilter)
+
+	for name, tc := range map[string]struct {
+		names     []string
+		policy    string
+		expected  []expander.Filter
+		expectErr bool
+	}{
+		"none": {
+			names:    []string{expander.MostPodsExpanderName},
+			policy:   podpreference.NonePolicy,
+			expected: []expander.Filter{mostpods.NewFilter()},
+		},
+		"exclusive": {
+			names:    []string{expander.MostPodsExpanderName, expander.RandomExpanderName},
+			policy:   podpreference.ExclusivePolicy,
+			expected: []expander.Filter{podpreference.NewFilter(), mostpods.NewFilter(), random.NewFilter()},
+		},
+		"bonus": {
+			names:    []string{expander.MostPodsExpanderName},
+			policy:   podpreference.BonusPolicy,
+			expected: []expander.Filter{mostpods.NewFilter(), podpreference.NewFilter()},
+		},
+		"bonus before a strategy": {
+			names:    []string{expander.MostPodsExpanderName, expander.RandomExpanderName},
+			policy:   podpreference.BonusPolicy,
+			expected: []expander.Filter{mostpods.NewFilter(), podpreference.NewFilter(), random.NewFilter()},
+		},
+		"unsupported policy": {
+			names:     []string{expander.MostPodsExpanderName},
+			policy:    "always",
+			expectErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := f.BuildWithPodPreference(tc.names, tc.policy)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, &chainStrategy{}, strategy)
+			filters := strategy.(*chainStrategy).filters
+			assert.Len(t, filters, len(tc.expected))
+			for i := range tc.expected {
+				assert.IsType(t, tc.expected[i], filters[i])
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podpreference
+
+import (
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// PreferredNodeGroupsAnnotation is the annotation of pending pods listing, comma separated,
+	// the ids of the node groups they prefer to be scheduled on.
+	PreferredNodeGroupsAnnotation = "cluster-autoscaler.kubernetes.io/preferred-node-groups"
+	// PreferredNodeGroupSelectorAnnotation is the annotation of pending pods holding a label
+	// selector over the labels of the template nodes of the node groups they prefer.
+	PreferredNodeGroupSelectorAnnotation = "cluster-autoscaler.kubernetes.io/preferred-node-group-selector"
+
+	// NonePolicy ignores the preferences of pods.
+	NonePolicy = "none"
+	// BonusPolicy breaks the ties left by the configured expanders in favor of preferred node groups.
+	BonusPolicy = "bonus"
+	// ExclusivePolicy only considers preferred node groups, if any of them can be scaled up.
+	ExclusivePolicy = "exclusive"
+)
+
+// AvailablePolicies is the list of supported pod preference policies.
+var AvailablePolicies = []string{NonePolicy, BonusPolicy, ExclusivePolicy}
+
+type podPreference struct {
+}
+
+// NewFilter returns a scale up filter that picks the node groups preferred by the most pods,
+// according to their preferred node groups annotations. All options are kept if no pod prefers
+// any of them, so that preferences never block scale-up.
+func NewFilter() expander.Filter {
+	return &podPreference{}
+}
+
+// BestOptions selects the expansion options preferred by the most pods.
+func (p *podPreference) BestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulerframework.NodeInfo) []expander.Option {
+	var maxScore int
+	var maxOptions []expander.Option
+
+	for _, option := range expansionOptions {
+		score := 0
+		for _, pod := range option.Pods {
+			if prefers(pod, option, nodeInfo) {
+				score++
+			}
+		}
+		if score == maxScore {
+			maxOptions = append(maxOptions, option)
+			continue
+		}
+		if score > maxScore {
+			maxScore = score
+			maxOptions = []expander.Option{option}
+		}
+	}
+
+	if maxScore == 0 {
+		return expansionOptions
+	}
+	return maxOptions
+}
+
+// prefers returns true if the pod prefers the node group of the option, either by id or by
+// the labels of its template node.
+func prefers(pod *apiv1.Pod, option expander.Option, nodeInfo map[string]*schedulerframework.NodeInfo) bool {
+	if ids, found := pod.Annotations[PreferredNodeGroupsAnnotation]; found {
+		for _, id := range strings.Split(ids, ",") {
+			if strings.TrimSpace(id) == option.NodeGroup.Id() {
+				return true
+			}
+		}
+	}
+	if value, found := pod.Annotations[PreferredNodeGroupSelectorAnnotation]; found {
+		selector, err := labels.Parse(value)
+		if err != nil {
+			klog.Warningf("Invalid %s annotation of pod %s/%s: %v", PreferredNodeGroupSelectorAnnotation, pod.Namespace, pod.Name, err)
+			return false
+		}
+		info, found := nodeInfo[option.NodeGroup.Id()]
+		if !found || info.Node() == nil {
+			return false
+		}
+		return selector.Matches(labels.Set(info.Node().Labels))
+	}
+	return false
+}
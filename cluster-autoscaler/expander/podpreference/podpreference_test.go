@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podpreference
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestPodPreference(t *testing.T) {
+	provider := test.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNodeGroup("ng3", 1, 10, 1)
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n2.Labels["pool"] = "spot"
+	n3 := BuildTestNode("n3", 1000, 1000)
+	nodeInfos := map[string]*schedulerframework.NodeInfo{}
+	for id, node := range map[string]*apiv1.Node{"ng1": n1, "ng2": n2, "ng3": n3} {
+		nodeInfos[id] = schedulerframework.NewNodeInfo()
+		nodeInfos[id].SetNode(node)
+	}
+
+	noPreference := BuildTestPod("p1", 100, 100)
+	byID := BuildTestPod("p2", 100, 100)
+	byID.Annotations = map[string]string{PreferredNodeGroupsAnnotation: "ng0, ng3"}
+	bySelector := BuildTestPod("p3", 100, 100)
+	bySelector.Annotations = map[string]string{PreferredNodeGroupSelectorAnnotation: "pool=spot"}
+	invalidSelector := BuildTestPod("p4", 100, 100)
+	invalidSelector.Annotations = map[string]string{PreferredNodeGroupSelectorAnnotation: "pool in"}
+
+	newOption := func(id string, pods ...*apiv1.Pod) expander.Option {
+		return expander.Option{NodeGroup: provider.GetNodeGroup(id), Debug: id, Pods: pods}
+	}
+	e := NewFilter()
+
+	// No pod prefers any node group, all options are kept.
+	options := []expander.Option{newOption("ng1", noPreference), newOption("ng2", noPreference, invalidSelector)}
+	assert.Equal(t, options, e.BestOptions(options, nodeInfos))
+
+	// The options preferred by the most pods are selected.
+	ng1 := newOption("ng1", noPreference, byID, bySelector)
+	ng2 := newOption("ng2", noPreference, byID, bySelector)
+	ng3 := newOption("ng3", noPreference, byID, bySelector)
+	assert.Equal(t, []expander.Option{ng2, ng3}, e.BestOptions([]expander.Option{ng1, ng2, ng3}, nodeInfos))
+
+	ng2 = newOption("ng2", noPreference)
+	assert.Equal(t, []expander.Option{ng3}, e.BestOptions([]expander.Option{ng1, ng2, ng3}, nodeInfos))
+}
@@ -122,7 +122,15 @@ nextoption:
 			klog.Warningf("Failed to calculate node price for %s: %v", option.NodeGroup.Id(), err)
 			continue
 		}
-		totalNodePrice := nodePrice * float64(option.NodeCount)
+		// Nodes created using reserved capacity are already paid for.
+		paidNodeCount := option.NodeCount
+		if reserved, ok := cloudprovider.AvailableReservedCapacity(option.NodeGroup); ok {
+			paidNodeCount -= reserved
+			if paidNodeCount < 0 {
+				paidNodeCount = 0
+			}
+		}
+		totalNodePrice := nodePrice * float64(paidNodeCount)
 		totalPodPrice := 0.0
 		for _, pod := range option.Pods {
 			podPrice, err := pricingModel.PodPrice(pod, now, then)
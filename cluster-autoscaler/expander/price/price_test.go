@@ -336,3 +336,47 @@ func TestPriceExpander(t *testing.T) {
 		SimpleNodeUnfitness,
 	).BestOptions(options3, nodeInfosForGroups)), []string{"ng3"})
 }
+
+func TestPriceExpanderReservedCapacity(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	p1 := BuildTestPod("p1", 1000, 0)
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng2", n2)
+	ng1, _ := provider.NodeGroupForNode(n1)
+	ng2, _ := provider.NodeGroupForNode(n2)
+
+	ni1 := schedulerframework.NewNodeInfo()
+	ni1.SetNode(n1)
+	ni2 := schedulerframework.NewNodeInfo()
+	ni2.SetNode(n2)
+	nodeInfosForGroups := map[string]*schedulerframework.NodeInfo{
+		"ng1": ni1, "ng2": ni2,
+	}
+	options := []expander.Option{
+		{NodeGroup: ng1, NodeCount: 2, Pods: []*apiv1.Pod{p1}, Debug: "ng1"},
+		{NodeGroup: ng2, NodeCount: 2, Pods: []*apiv1.Pod{p1}, Debug: "ng2"},
+	}
+	provider.SetPricingModel(&testPricingModel{
+		podPrice: map[string]float64{
+			"p1":        20.0,
+			"stabilize": 10,
+		},
+		nodePrice: map[string]float64{
+			"n1": 20.0,
+			"n2": 30.0,
+		},
+	})
+	filter := NewFilter(provider, &testPreferredNodeProvider{preferred: buildNode(1000, units.GiB)}, SimpleNodeUnfitness)
+
+	// First node group is cheaper.
+	assert.Equal(t, []string{"ng1"}, optionsToDebug(filter.BestOptions(options, nodeInfosForGroups)))
+
+	// Nodes created using the reserved capacity of the second node group are already paid for.
+	ng2.(*testprovider.TestNodeGroup).SetAvailableReservedCapacity(1)
+	assert.Equal(t, []string{"ng2"}, optionsToDebug(filter.BestOptions(options, nodeInfosForGroups)))
+}
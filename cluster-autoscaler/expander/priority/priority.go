@@ -17,15 +17,12 @@ limitations under the License.
 package priority
 
 import (
-	"errors"
 	"fmt"
-	"regexp"
-
-	"gopkg.in/yaml.v2"
 
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/priorityconfig"
 
-	apiv1 "k8s.io/api/core/v1"
 	v1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/record"
 	klog "k8s.io/klog/v2"
@@ -39,89 +36,31 @@ const (
 	ConfigMapKey = "priorities"
 )
 
-type priorities map[int][]*regexp.Regexp
-
 type priority struct {
-	logRecorder      record.EventRecorder
-	okConfigUpdates  int
-	badConfigUpdates int
-	configMapLister  v1lister.ConfigMapNamespaceLister
+	loader *priorityconfig.Loader[int]
 }
 
 // NewFilter returns an expansion filter that picks node groups based on user-defined priorities
 func NewFilter(configMapLister v1lister.ConfigMapNamespaceLister,
 	logRecorder record.EventRecorder) expander.Filter {
 	res := &priority{
-		logRecorder:     logRecorder,
-		configMapLister: configMapLister,
+		loader: priorityconfig.NewLoader(configMapLister, logRecorder, priorityconfig.Config[int]{
+			ConfigMapName:       PriorityConfigMapName,
+			ConfigMapKey:        ConfigMapKey,
+			ExpanderName:        "priority expander",
+			ValueName:           "priority",
+			InvalidConfigReason: "PriorityConfigMapInvalid",
+		}),
 	}
 	return res
 }
 
-func (p *priority) reloadConfigMap() (priorities, *apiv1.ConfigMap, error) {
-	cm, err := p.configMapLister.Get(PriorityConfigMapName)
-	if err != nil {
-		return nil, nil, fmt.Errorf("Priority expander config map %s not found: %v", PriorityConfigMapName, err)
-	}
-
-	prioString, found := cm.Data[ConfigMapKey]
-	if !found {
-		msg := fmt.Sprintf("Wrong configmap for priority expander, doesn't contain %s key. Ignoring update.",
-			ConfigMapKey)
-		p.logConfigWarning(cm, "PriorityConfigMapInvalid", msg)
-		return nil, cm, errors.New(msg)
-	}
-
-	newPriorities, err := p.parsePrioritiesYAMLString(prioString)
-	if err != nil {
-		msg := fmt.Sprintf("Wrong configuration for priority expander: %v. Ignoring update.", err)
-		p.logConfigWarning(cm, "PriorityConfigMapInvalid", msg)
-		return nil, cm, err
-	}
-
-	return newPriorities, cm, nil
-}
-
-func (p *priority) logConfigWarning(cm *apiv1.ConfigMap, reason, msg string) {
-	p.logRecorder.Event(cm, apiv1.EventTypeWarning, reason, msg)
-	klog.Warning(msg)
-	p.badConfigUpdates++
-}
-
-func (p *priority) parsePrioritiesYAMLString(prioritiesYAML string) (priorities, error) {
-	if prioritiesYAML == "" {
-		return nil, fmt.Errorf("priority configuration in %s configmap is empty; please provide valid configuration",
-			PriorityConfigMapName)
-	}
-	var config map[int][]string
-	if err := yaml.Unmarshal([]byte(prioritiesYAML), &config); err != nil {
-		return nil, fmt.Errorf("Can't parse YAML with priorities in the configmap: %v", err)
-	}
-
-	newPriorities := make(map[int][]*regexp.Regexp)
-	for prio, reList := range config {
-		for _, re := range reList {
-			regexp, err := regexp.Compile(re)
-			if err != nil {
-				return nil, fmt.Errorf("Can't compile regexp rule for priority %d and rule %s: %v", prio, re, err)
-			}
-			newPriorities[prio] = append(newPriorities[prio], regexp)
-		}
-	}
-
-	p.okConfigUpdates++
-	msg := "Successfully loaded priority configuration from configmap."
-	klog.V(4).Info(msg)
-
-	return newPriorities, nil
-}
-
 func (p *priority) BestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulerframework.NodeInfo) []expander.Option {
 	if len(expansionOptions) <= 0 {
 		return nil
 	}
 
-	priorities, cm, err := p.reloadConfigMap()
+	priorities, cm, err := p.loader.Load()
 	if err != nil {
 		return expansionOptions
 	}
@@ -132,7 +71,7 @@ func (p *priority) BestOptions(expansionOptions []expander.Option, nodeInfo map[
 		id := option.NodeGroup.Id()
 		found := false
 		for prio, nameRegexpList := range priorities {
-			if !p.groupIDMatchesList(id, nameRegexpList) {
+			if !priorityconfig.Matches(id, nameRegexpList) {
 				continue
 			}
 			found = true
@@ -149,27 +88,35 @@ func (p *priority) BestOptions(expansionOptions []expander.Option, nodeInfo map[
 		if !found {
 			msg := fmt.Sprintf("Priority expander: node group %s not found in priority expander configuration. "+
 				"The group won't be used.", id)
-			p.logConfigWarning(cm, "PriorityConfigMapNotMatchedGroup", msg)
+			p.loader.Warn(cm, "PriorityConfigMapNotMatchedGroup", msg)
 		}
 	}
 
 	if len(best) == 0 {
 		msg := "Priority expander: no priorities info found for any of the expansion options. No options filtered."
-		p.logConfigWarning(cm, "PriorityConfigMapNoGroupMatched", msg)
+		p.loader.Warn(cm, "PriorityConfigMapNoGroupMatched", msg)
 		return expansionOptions
 	}
 
+	best = preferReservedCapacity(best)
 	for _, opt := range best {
 		klog.V(2).Infof("priority expander: %s chosen as the highest available", opt.NodeGroup.Id())
 	}
 	return best
 }
 
-func (p *priority) groupIDMatchesList(id string, nameRegexpList []*regexp.Regexp) bool {
-	for _, re := range nameRegexpList {
-		if re.FindStringIndex(id) != nil {
-			return true
+// preferReservedCapacity returns the options whose node groups have reserved capacity
+// available, if any, so that reserved capacity is used before on-demand capacity of
+// node groups with the same priority.
+func preferReservedCapacity(options []expander.Option) []expander.Option {
+	var reserved []expander.Option
+	for _, option := range options {
+		if available, ok := cloudprovider.AvailableReservedCapacity(option.NodeGroup); ok && available > 0 {
+			reserved = append(reserved, option)
 		}
 	}
-	return false
+	if len(reserved) == 0 {
+		return options
+	}
+	return reserved
 }
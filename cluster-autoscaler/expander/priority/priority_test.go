@@ -149,21 +149,43 @@ func TestPriorityExpanderCorrecltyHandlesConfigUpdate(t *testing.T) {
 	ret = s.BestOptions([]expander.Option{eoT2Large, eoT3Large, eoM44XLarge}, nil)
 
 	priority := s.(*priority)
-	assert.Equal(t, 2, priority.okConfigUpdates)
+	assert.Equal(t, 2, priority.loader.OkConfigUpdates())
 	assert.Equal(t, ret, []expander.Option{eoM44XLarge})
 }
 
 func TestPriorityExpanderCorrecltySkipsBadChangeConfig(t *testing.T) {
 	s, r, cm := getFilterInstance(t, oneEntryConfig)
 	priority := s.(*priority)
-	assert.Equal(t, 0, priority.okConfigUpdates)
+	assert.Equal(t, 0, priority.loader.OkConfigUpdates())
 
 	cm.Data[ConfigMapKey] = ""
 	ret := s.BestOptions([]expander.Option{eoT2Large, eoT3Large, eoM44XLarge}, nil)
 
-	assert.Equal(t, 1, priority.badConfigUpdates)
+	assert.Equal(t, 1, priority.loader.BadConfigUpdates())
 
 	event := <-r.Events
 	assert.EqualValues(t, configWarnConfigMapEmpty, event)
 	assert.Equal(t, ret, []expander.Option{eoT2Large, eoT3Large, eoM44XLarge})
 }
+
+func TestPriorityExpanderPrefersReservedCapacity(t *testing.T) {
+	s, _, _ := getFilterInstance(t, config)
+	reserved := test.NewTestNodeGroup("my-asg.t3.large", 10, 1, 1, true, false, "t3.large", nil, nil)
+	reserved.SetAvailableReservedCapacity(2)
+	exhausted := test.NewTestNodeGroup("my-asg.t2.large", 10, 1, 1, true, false, "t2.large", nil, nil)
+	exhausted.SetAvailableReservedCapacity(0)
+	eoReserved := expander.Option{Debug: "t3.large", NodeGroup: reserved}
+	eoExhausted := expander.Option{Debug: "t2.large", NodeGroup: exhausted}
+
+	// Reserved capacity is preferred among the options with the highest priority.
+	ret := s.BestOptions([]expander.Option{eoExhausted, eoReserved, eoT2Micro}, nil)
+	assert.Equal(t, []expander.Option{eoReserved}, ret)
+
+	// But it doesn't override priorities.
+	ret = s.BestOptions([]expander.Option{eoReserved, eoM44XLarge}, nil)
+	assert.Equal(t, []expander.Option{eoM44XLarge}, ret)
+
+	// Without available reserved capacity, all the options with the highest priority are kept.
+	ret = s.BestOptions([]expander.Option{eoExhausted, eoT3Large}, nil)
+	assert.Equal(t, []expander.Option{eoExhausted, eoT3Large}, ret)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorityconfig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	apiv1 "k8s.io/api/core/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	klog "k8s.io/klog/v2"
+)
+
+// Rules map values, e.g. priorities or weights, to regexps matching ids of node groups.
+type Rules[V int | float64] map[V][]*regexp.Regexp
+
+// Config describes the ConfigMap an expander is configured with.
+type Config[V int | float64] struct {
+	// ConfigMapName is the name of the ConfigMap.
+	ConfigMapName string
+	// ConfigMapKey is the key of the ConfigMap holding the rules, e.g. "priorities".
+	ConfigMapKey string
+	// ExpanderName is the name of the expander used in messages, e.g. "priority expander".
+	ExpanderName string
+	// ValueName is the name of the configured values used in messages, e.g. "priority".
+	ValueName string
+	// InvalidConfigReason is the reason of the events reporting an invalid ConfigMap.
+	InvalidConfigReason string
+	// Validate, if set, returns an error for values which can't be configured.
+	Validate func(value V) error
+}
+
+// Loader loads rules of an expander from a ConfigMap. The ConfigMap holds, under a
+// single key, a YAML map from values to lists of regexps matching ids of node groups.
+type Loader[V int | float64] struct {
+	config           Config[V]
+	logRecorder      record.EventRecorder
+	configMapLister  v1lister.ConfigMapNamespaceLister
+	okConfigUpdates  int
+	badConfigUpdates int
+}
+
+// NewLoader returns a Loader of rules from the ConfigMap described by config.
+func NewLoader[V int | float64](configMapLister v1lister.ConfigMapNamespaceLister, logRecorder record.EventRecorder, config Config[V]) *Loader[V] {
+	return &Loader[V]{
+		config:          config,
+		logRecorder:     logRecorder,
+		configMapLister: configMapLister,
+	}
+}
+
+// Load returns the rules from the current version of the ConfigMap, along with the ConfigMap,
+// which is nil if it doesn't exist. Invalid configurations are reported with events on the ConfigMap.
+func (l *Loader[V]) Load() (Rules[V], *apiv1.ConfigMap, error) {
+	cm, err := l.configMapLister.Get(l.config.ConfigMapName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s config map %s not found: %v", capitalize(l.config.ExpanderName), l.config.ConfigMapName, err)
+	}
+
+	rulesString, found := cm.Data[l.config.ConfigMapKey]
+	if !found {
+		msg := fmt.Sprintf("Wrong configmap for %s, doesn't contain %s key. Ignoring update.",
+			l.config.ExpanderName, l.config.ConfigMapKey)
+		l.Warn(cm, l.config.InvalidConfigReason, msg)
+		return nil, cm, errors.New(msg)
+	}
+
+	rules, err := l.Parse(rulesString)
+	if err != nil {
+		msg := fmt.Sprintf("Wrong configuration for %s: %v. Ignoring update.", l.config.ExpanderName, err)
+		l.Warn(cm, l.config.InvalidConfigReason, msg)
+		return nil, cm, err
+	}
+
+	return rules, cm, nil
+}
+
+// Warn reports a problem with the configuration with an event on the ConfigMap.
+func (l *Loader[V]) Warn(cm *apiv1.ConfigMap, reason, msg string) {
+	l.logRecorder.Event(cm, apiv1.EventTypeWarning, reason, msg)
+	klog.Warning(msg)
+	l.badConfigUpdates++
+}
+
+// Parse returns the rules from their YAML representation.
+func (l *Loader[V]) Parse(rulesYAML string) (Rules[V], error) {
+	if rulesYAML == "" {
+		return nil, fmt.Errorf("%s configuration in %s configmap is empty; please provide valid configuration",
+			l.config.ValueName, l.config.ConfigMapName)
+	}
+	var config map[V][]string
+	if err := yaml.Unmarshal([]byte(rulesYAML), &config); err != nil {
+		return nil, fmt.Errorf("Can't parse YAML with %s in the configmap: %v", l.config.ConfigMapKey, err)
+	}
+
+	rules := make(Rules[V])
+	for value, reList := range config {
+		if l.config.Validate != nil {
+			if err := l.config.Validate(value); err != nil {
+				return nil, err
+			}
+		}
+		for _, re := range reList {
+			regexp, err := regexp.Compile(re)
+			if err != nil {
+				return nil, fmt.Errorf("Can't compile regexp rule for %s %v and rule %s: %v", l.config.ValueName, value, re, err)
+			}
+			rules[value] = append(rules[value], regexp)
+		}
+	}
+
+	l.okConfigUpdates++
+	klog.V(4).Infof("Successfully loaded %s configuration from configmap.", l.config.ExpanderName)
+
+	return rules, nil
+}
+
+// OkConfigUpdates returns the number of configurations loaded successfully.
+func (l *Loader[V]) OkConfigUpdates() int {
+	return l.okConfigUpdates
+}
+
+// BadConfigUpdates returns the number of problems with the configuration reported.
+func (l *Loader[V]) BadConfigUpdates() int {
+	return l.badConfigUpdates
+}
+
+// Matches checks if any of the regexps matches the node group id.
+func Matches(id string, nameRegexpList []*regexp.Regexp) bool {
+	for _, re := range nameRegexpList {
+		if re.FindStringIndex(id) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
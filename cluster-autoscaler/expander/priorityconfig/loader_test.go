@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorityconfig
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+)
+
+func TestLoader(t *testing.T) {
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test-expander",
+		},
+		Data: map[string]string{
+			"values": "10:\n  - \"^a$\"\n  - \"^b$\"\n20:\n  - \"^c$\"\n",
+		},
+	}
+	lister, err := kubernetes.NewTestConfigMapLister([]*apiv1.ConfigMap{cm})
+	assert.NoError(t, err)
+	r := record.NewFakeRecorder(10)
+	loader := NewLoader(lister.ConfigMaps("default"), r, Config[int]{
+		ConfigMapName:       "test-expander",
+		ConfigMapKey:        "values",
+		ExpanderName:        "test expander",
+		ValueName:           "value",
+		InvalidConfigReason: "TestConfigMapInvalid",
+		Validate: func(value int) error {
+			if value > 100 {
+				return errors.New("too high")
+			}
+			return nil
+		},
+	})
+
+	rules, loaded, err := loader.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, cm, loaded)
+	assert.Len(t, rules[10], 2)
+	assert.True(t, Matches("b", rules[10]))
+	assert.False(t, Matches("c", rules[10]))
+	assert.Equal(t, 1, loader.OkConfigUpdates())
+
+	for _, invalid := range []string{"", "not yaml", "10:\n  - \"(\"\n", "1000:\n  - \"^a$\"\n"} {
+		cm.Data["values"] = invalid
+		_, _, err = loader.Load()
+		assert.Error(t, err)
+		assert.Contains(t, <-r.Events, "TestConfigMapInvalid")
+	}
+	delete(cm.Data, "values")
+	_, _, err = loader.Load()
+	assert.Error(t, err)
+	assert.Equal(t, 5, loader.BadConfigUpdates())
+}
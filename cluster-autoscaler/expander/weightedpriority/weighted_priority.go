@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package weightedpriority
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/priorityconfig"
+
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// WeightsConfigMapName defines a name of the ConfigMap used to store weighted priority expander configuration
+	WeightsConfigMapName = "cluster-autoscaler-weighted-priority-expander"
+	// ConfigMapKey defines the key used in the ConfigMap to configure weights
+	ConfigMapKey = "weights"
+
+	// scoreEpsilon is the difference below which scores are considered equal.
+	scoreEpsilon = 1e-9
+)
+
+type weightedPriority struct {
+	loader        *priorityconfig.Loader[float64]
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewFilter returns an expansion filter that keeps the options with the highest score, the sum of
+// the user-defined weights of the rules matching their node groups, and breaks ties between them
+// by the price of the nodes they add, if the cloud provider supports pricing.
+func NewFilter(configMapLister v1lister.ConfigMapNamespaceLister,
+	logRecorder record.EventRecorder, cloudProvider cloudprovider.CloudProvider) expander.Filter {
+	return &weightedPriority{
+		loader: priorityconfig.NewLoader(configMapLister, logRecorder, priorityconfig.Config[float64]{
+			ConfigMapName:       WeightsConfigMapName,
+			ConfigMapKey:        ConfigMapKey,
+			ExpanderName:        "weighted priority expander",
+			ValueName:           "weight",
+			InvalidConfigReason: "WeightedPriorityConfigMapInvalid",
+			Validate:            validateWeight,
+		}),
+		cloudProvider: cloudProvider,
+	}
+}
+
+func validateWeight(weight float64) error {
+	if math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return fmt.Errorf("weight %v is not a finite number", weight)
+	}
+	return nil
+}
+
+// groupScore returns the sum of the weights of the rules matching the node group id, and whether
+// any rule matched.
+func groupScore(id string, rules priorityconfig.Rules[float64]) (float64, bool) {
+	score, found := 0.0, false
+	for weight, nameRegexpList := range rules {
+		for _, re := range nameRegexpList {
+			if re.FindStringIndex(id) != nil {
+				score += weight
+				found = true
+			}
+		}
+	}
+	return score, found
+}
+
+// BestOptions selects the options with the highest score. Node groups not matching any rule have
+// a score of 0. Ties are broken by the price of the nodes added by the options, options which
+// can't be priced are kept among the cheapest ones. If the ConfigMap is missing or invalid, all
+// the options are returned.
+func (w *weightedPriority) BestOptions(expansionOptions []expander.Option, nodeInfos map[string]*schedulerframework.NodeInfo) []expander.Option {
+	if len(expansionOptions) <= 0 {
+		return nil
+	}
+
+	rules, cm, err := w.loader.Load()
+	if err != nil {
+		return expansionOptions
+	}
+
+	var best []expander.Option
+	bestScore := 0.0
+	for _, option := range expansionOptions {
+		id := option.NodeGroup.Id()
+		score, found := groupScore(id, rules)
+		if !found {
+			msg := fmt.Sprintf("Weighted priority expander: node group %s not found in weighted priority expander configuration. "+
+				"The group has a score of 0.", id)
+			w.loader.Warn(cm, "WeightedPriorityConfigMapNotMatchedGroup", msg)
+		}
+		if len(best) == 0 || score > bestScore+scoreEpsilon {
+			best = []expander.Option{option}
+			bestScore = score
+		} else if math.Abs(score-bestScore) <= scoreEpsilon {
+			best = append(best, option)
+		}
+	}
+	for _, option := range best {
+		klog.V(2).Infof("weighted priority expander: %s chosen as the highest available with score %v", option.NodeGroup.Id(), bestScore)
+	}
+	if len(best) <= 1 {
+		return best
+	}
+	return w.cheapestOptions(best, nodeInfos)
+}
+
+// cheapestOptions returns the options adding the cheapest nodes, or all the options if the cloud
+// provider doesn't support pricing.
+func (w *weightedPriority) cheapestOptions(expansionOptions []expander.Option, nodeInfos map[string]*schedulerframework.NodeInfo) []expander.Option {
+	pricingModel, err := w.cloudProvider.Pricing()
+	if err != nil {
+		klog.V(4).Infof("Weighted priority expander: pricing not available, ties aren't broken by price: %v", err)
+		return expansionOptions
+	}
+	now := time.Now()
+	then := now.Add(time.Hour)
+
+	var cheapest, unpriced []expander.Option
+	cheapestPrice := 0.0
+	for _, option := range expansionOptions {
+		nodeInfo, found := nodeInfos[option.NodeGroup.Id()]
+		if !found {
+			unpriced = append(unpriced, option)
+			continue
+		}
+		nodePrice, err := pricingModel.NodePrice(nodeInfo.Node(), now, then)
+		if err != nil {
+			klog.Warningf("Weighted priority expander: failed to calculate node price for %s: %v", option.NodeGroup.Id(), err)
+			unpriced = append(unpriced, option)
+			continue
+		}
+		price := nodePrice * float64(option.NodeCount)
+		if len(cheapest) == 0 || price < cheapestPrice-scoreEpsilon {
+			cheapest = []expander.Option{option}
+			cheapestPrice = price
+		} else if math.Abs(price-cheapestPrice) <= scoreEpsilon {
+			cheapest = append(cheapest, option)
+		}
+	}
+	return append(cheapest, unpriced...)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package weightedpriority
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+const (
+	testNamespace                  = "default"
+	configWarnGroupNotFoundMessage = "Warning WeightedPriorityConfigMapNotMatchedGroup Weighted priority expander: node group " +
+		"%s not found in weighted priority expander configuration. The group has a score of 0."
+)
+
+var (
+	config = `
+10:
+  - ".*spot.*"
+2.5:
+  - ".*zone-a.*"
+-7.5:
+  - ".*legacy.*"
+`
+
+	eoSpotZoneA = expander.Option{
+		Debug:     "spot-zone-a",
+		NodeGroup: test.NewTestNodeGroup("spot-zone-a", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 2,
+	}
+	eoSpotZoneB = expander.Option{
+		Debug:     "spot-zone-b",
+		NodeGroup: test.NewTestNodeGroup("spot-zone-b", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 2,
+	}
+	eoSpotLegacy = expander.Option{
+		Debug:     "spot-legacy",
+		NodeGroup: test.NewTestNodeGroup("spot-legacy", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 1,
+	}
+	eoOnDemandZoneA = expander.Option{
+		Debug:     "on-demand-zone-a",
+		NodeGroup: test.NewTestNodeGroup("on-demand-zone-a", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 1,
+	}
+	eoLegacy = expander.Option{
+		Debug:     "legacy",
+		NodeGroup: test.NewTestNodeGroup("legacy", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 1,
+	}
+	eoOther = expander.Option{
+		Debug:     "other",
+		NodeGroup: test.NewTestNodeGroup("other", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 1,
+	}
+)
+
+type testPricingModel struct {
+	nodePrice map[string]float64
+}
+
+func (tpm *testPricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	if price, found := tpm.nodePrice[node.Name]; found {
+		return price, nil
+	}
+	return 0, fmt.Errorf("price for node %s not found", node.Name)
+}
+
+func (tpm *testPricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	return 0, nil
+}
+
+func getFilterInstance(t *testing.T, config string, pricing map[string]float64) (*weightedPriority, *record.FakeRecorder) {
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      WeightsConfigMapName,
+		},
+		Data: map[string]string{
+			ConfigMapKey: config,
+		},
+	}
+	lister, err := kubernetes.NewTestConfigMapLister([]*apiv1.ConfigMap{cm})
+	assert.Nil(t, err)
+	provider := test.NewTestCloudProvider(nil, nil)
+	if pricing != nil {
+		provider.SetPricingModel(&testPricingModel{nodePrice: pricing})
+	}
+	r := record.NewFakeRecorder(1000)
+	return NewFilter(lister.ConfigMaps(testNamespace), r, provider).(*weightedPriority), r
+}
+
+func nodeInfos(names ...string) map[string]*schedulerframework.NodeInfo {
+	result := make(map[string]*schedulerframework.NodeInfo)
+	for _, name := range names {
+		nodeInfo := schedulerframework.NewNodeInfo()
+		nodeInfo.SetNode(BuildTestNode(name, 1000, 1000))
+		result[name] = nodeInfo
+	}
+	return result
+}
+
+func debugs(options []expander.Option) []string {
+	var result []string
+	for _, option := range options {
+		result = append(result, option.Debug)
+	}
+	return result
+}
+
+func TestWeightedPriorityExpanderSumsWeights(t *testing.T) {
+	s, _ := getFilterInstance(t, config, nil)
+	best := s.BestOptions([]expander.Option{eoSpotZoneB, eoSpotZoneA, eoSpotLegacy, eoOnDemandZoneA}, nil)
+	assert.Equal(t, []string{"spot-zone-a"}, debugs(best))
+
+	best = s.BestOptions([]expander.Option{eoSpotLegacy, eoOnDemandZoneA}, nil)
+	assert.Equal(t, []string{"spot-legacy", "on-demand-zone-a"}, debugs(best))
+}
+
+func TestWeightedPriorityExpanderScoresUnmatchedGroupsZero(t *testing.T) {
+	s, r := getFilterInstance(t, config, nil)
+	best := s.BestOptions([]expander.Option{eoOther, eoOnDemandZoneA}, nil)
+	assert.Equal(t, []string{"on-demand-zone-a"}, debugs(best))
+	assert.EqualValues(t, fmt.Sprintf(configWarnGroupNotFoundMessage, eoOther.NodeGroup.Id()), <-r.Events)
+
+	// Negative weights rank node groups below unmatched ones.
+	best = s.BestOptions([]expander.Option{eoLegacy, eoOther}, nil)
+	assert.Equal(t, []string{"other"}, debugs(best))
+}
+
+func TestWeightedPriorityExpanderBreaksTiesByPrice(t *testing.T) {
+	s, _ := getFilterInstance(t, config, map[string]float64{
+		"spot-legacy":      3,
+		"on-demand-zone-a": 1,
+	})
+	infos := nodeInfos("spot-legacy", "on-demand-zone-a", "other")
+	best := s.BestOptions([]expander.Option{eoSpotLegacy, eoOnDemandZoneA}, infos)
+	assert.Equal(t, []string{"on-demand-zone-a"}, debugs(best))
+
+	// Options which can't be priced are kept.
+	eoUnpriced := expander.Option{
+		Debug:     "unpriced",
+		NodeGroup: test.NewTestNodeGroup("unpriced-zone-a", 10, 1, 1, true, false, "m5.large", nil, nil),
+		NodeCount: 1,
+	}
+	best = s.BestOptions([]expander.Option{eoSpotLegacy, eoOnDemandZoneA, eoUnpriced}, infos)
+	assert.Equal(t, []string{"on-demand-zone-a", "unpriced"}, debugs(best))
+
+	// The price is the price of all the nodes added.
+	eoExpensive := eoOnDemandZoneA
+	eoExpensive.NodeCount = 4
+	best = s.BestOptions([]expander.Option{eoSpotLegacy, eoExpensive}, infos)
+	assert.Equal(t, []string{"spot-legacy"}, debugs(best))
+}
+
+func TestWeightedPriorityExpanderInvalidConfig(t *testing.T) {
+	options := []expander.Option{eoSpotZoneA, eoOther}
+	for name, config := range map[string]string{
+		"empty":          "",
+		"invalid yaml":   "10: [",
+		"invalid regexp": "10:\n  - \"[\"",
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, r := getFilterInstance(t, config, nil)
+			assert.Equal(t, options, s.BestOptions(options, nil))
+			assert.Equal(t, 1, s.loader.BadConfigUpdates())
+			assert.Contains(t, <-r.Events, "WeightedPriorityConfigMapInvalid")
+		})
+	}
+}
+
+func TestWeightedPriorityExpanderReloadsConfig(t *testing.T) {
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      WeightsConfigMapName,
+		},
+		Data: map[string]string{
+			ConfigMapKey: config,
+		},
+	}
+	lister, err := kubernetes.NewTestConfigMapLister([]*apiv1.ConfigMap{cm})
+	assert.Nil(t, err)
+	s := NewFilter(lister.ConfigMaps(testNamespace), record.NewFakeRecorder(1000), test.NewTestCloudProvider(nil, nil)).(*weightedPriority)
+	assert.Equal(t, []string{"spot-zone-a"}, debugs(s.BestOptions([]expander.Option{eoSpotZoneA, eoOther}, nil)))
+
+	cm.Data[ConfigMapKey] = "100:\n  - \"^other$\"\n"
+	assert.Equal(t, []string{"other"}, debugs(s.BestOptions([]expander.Option{eoSpotZoneA, eoOther}, nil)))
+	assert.Equal(t, 2, s.loader.OkConfigUpdates())
+}
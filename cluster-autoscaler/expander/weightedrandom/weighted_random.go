@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package weightedrandom
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/priorityconfig"
+
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// WeightsConfigMapName defines a name of the ConfigMap used to store weighted random expander configuration
+	WeightsConfigMapName = "cluster-autoscaler-weighted-random-expander"
+	// ConfigMapKey defines the key used in the ConfigMap to configure weights
+	ConfigMapKey = "weights"
+)
+
+type weightedRandom struct {
+	loader *priorityconfig.Loader[int]
+	rand   *rand.Rand
+}
+
+// NewFilter returns an expansion filter that picks a single node group at random,
+// with probabilities proportional to user-defined weights of the node groups
+func NewFilter(configMapLister v1lister.ConfigMapNamespaceLister,
+	logRecorder record.EventRecorder) expander.Filter {
+	return &weightedRandom{
+		loader: priorityconfig.NewLoader(configMapLister, logRecorder, priorityconfig.Config[int]{
+			ConfigMapName:       WeightsConfigMapName,
+			ConfigMapKey:        ConfigMapKey,
+			ExpanderName:        "weighted random expander",
+			ValueName:           "weight",
+			InvalidConfigReason: "WeightedRandomConfigMapInvalid",
+			Validate:            validateWeight,
+		}),
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func validateWeight(weight int) error {
+	if weight < 0 {
+		return fmt.Errorf("weight %d is negative, weights must not be lower than 0", weight)
+	}
+	return nil
+}
+
+// groupWeight returns the highest weight with a regexp matching the node group id, and
+// whether any regexp matched.
+func (w *weightedRandom) groupWeight(id string, weights priorityconfig.Rules[int]) (int, bool) {
+	best, found := 0, false
+	for weight, nameRegexpList := range weights {
+		if priorityconfig.Matches(id, nameRegexpList) && (!found || weight > best) {
+			best, found = weight, true
+		}
+	}
+	return best, found
+}
+
+// BestOptions selects a single option at random, weighted by the configured node group weights
+func (w *weightedRandom) BestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulerframework.NodeInfo) []expander.Option {
+	best := w.BestOption(expansionOptions, nodeInfo)
+	if best == nil {
+		return nil
+	}
+	return []expander.Option{*best}
+}
+
+// BestOption selects an option at random, weighted by the configured node group weights. Node groups not
+// matching any weight are not used. If none of the options has a positive weight, an option is selected
+// uniformly at random.
+func (w *weightedRandom) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulerframework.NodeInfo) *expander.Option {
+	if len(expansionOptions) <= 0 {
+		return nil
+	}
+
+	weights, cm, err := w.loader.Load()
+	if err != nil {
+		return &expansionOptions[w.rand.Intn(len(expansionOptions))]
+	}
+
+	total := 0
+	optionWeights := make([]int, len(expansionOptions))
+	for i, option := range expansionOptions {
+		id := option.NodeGroup.Id()
+		weight, found := w.groupWeight(id, weights)
+		if !found {
+			msg := fmt.Sprintf("Weighted random expander: node group %s not found in weighted random expander configuration. "+
+				"The group won't be used.", id)
+			w.loader.Warn(cm, "WeightedRandomConfigMapNotMatchedGroup", msg)
+		}
+		optionWeights[i] = weight
+		total += weight
+	}
+
+	if total == 0 {
+		msg := "Weighted random expander: no positive weights found for any of the expansion options. Selecting an option at random."
+		w.loader.Warn(cm, "WeightedRandomConfigMapNoGroupMatched", msg)
+		return &expansionOptions[w.rand.Intn(len(expansionOptions))]
+	}
+
+	pick := w.rand.Intn(total)
+	for i, weight := range optionWeights {
+		if pick < weight {
+			klog.V(2).Infof("weighted random expander: %s chosen with weight %d out of %d", expansionOptions[i].NodeGroup.Id(), weight, total)
+			return &expansionOptions[i]
+		}
+		pick -= weight
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package weightedrandom
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+)
+
+const (
+	testNamespace                  = "default"
+	configWarnGroupNotFoundMessage = "Warning WeightedRandomConfigMapNotMatchedGroup Weighted random expander: node group " +
+		"%s not found in weighted random expander configuration. The group won't be used."
+)
+
+var (
+	config = `
+70:
+  - ".*spot.*"
+30:
+  - ".*on-demand.*"
+`
+	spotOnlyConfig = `
+1:
+  - ".*spot.*"
+`
+	zeroConfig = `
+0:
+  - ".*"
+`
+
+	eoSpot = expander.Option{
+		Debug:     "spot",
+		NodeGroup: test.NewTestNodeGroup("my-asg.spot", 10, 1, 1, true, false, "m5.large", nil, nil),
+	}
+	eoOnDemand = expander.Option{
+		Debug:     "on-demand",
+		NodeGroup: test.NewTestNodeGroup("my-asg.on-demand", 10, 1, 1, true, false, "m5.large", nil, nil),
+	}
+	eoOther = expander.Option{
+		Debug:     "other",
+		NodeGroup: test.NewTestNodeGroup("my-asg.other", 10, 1, 1, true, false, "m5.large", nil, nil),
+	}
+)
+
+func getFilterInstance(t *testing.T, config string) (*weightedRandom, *record.FakeRecorder, *apiv1.ConfigMap) {
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      WeightsConfigMapName,
+		},
+		Data: map[string]string{
+			ConfigMapKey: config,
+		},
+	}
+	lister, err := kubernetes.NewTestConfigMapLister([]*apiv1.ConfigMap{cm})
+	assert.Nil(t, err)
+	r := record.NewFakeRecorder(1000)
+	s := NewFilter(lister.ConfigMaps(testNamespace), r).(*weightedRandom)
+	s.rand = rand.New(rand.NewSource(42))
+	return s, r, cm
+}
+
+func countChoices(s *weightedRandom, options []expander.Option, runs int) map[string]int {
+	counts := map[string]int{}
+	for i := 0; i < runs; i++ {
+		best := s.BestOptions(options, nil)
+		if len(best) == 1 {
+			counts[best[0].Debug]++
+		}
+	}
+	return counts
+}
+
+func TestWeightedRandomExpanderIsStrategy(t *testing.T) {
+	s, _, _ := getFilterInstance(t, config)
+	var filter expander.Filter = s
+	_, ok := filter.(expander.Strategy)
+	assert.True(t, ok)
+	assert.Nil(t, s.BestOption(nil, nil))
+}
+
+func TestWeightedRandomExpanderFollowsWeights(t *testing.T) {
+	s, _, _ := getFilterInstance(t, config)
+	counts := countChoices(s, []expander.Option{eoSpot, eoOnDemand}, 1000)
+	assert.Equal(t, 1000, counts["spot"]+counts["on-demand"])
+	assert.InDelta(t, 700, counts["spot"], 50)
+	assert.InDelta(t, 300, counts["on-demand"], 50)
+}
+
+func TestWeightedRandomExpanderSkipsUnmatchedGroups(t *testing.T) {
+	s, r, _ := getFilterInstance(t, config)
+	counts := countChoices(s, []expander.Option{eoSpot, eoOther}, 100)
+	assert.Equal(t, map[string]int{"spot": 100}, counts)
+	assert.EqualValues(t, fmt.Sprintf(configWarnGroupNotFoundMessage, eoOther.NodeGroup.Id()), <-r.Events)
+}
+
+func TestWeightedRandomExpanderFallsBackToRandom(t *testing.T) {
+	s, _, _ := getFilterInstance(t, zeroConfig)
+	counts := countChoices(s, []expander.Option{eoSpot, eoOnDemand}, 1000)
+	assert.InDelta(t, 500, counts["spot"], 60)
+	assert.InDelta(t, 500, counts["on-demand"], 60)
+
+	s, r, _ := getFilterInstance(t, "")
+	counts = countChoices(s, []expander.Option{eoSpot, eoOnDemand}, 1000)
+	assert.Equal(t, 1000, counts["spot"]+counts["on-demand"])
+	assert.Equal(t, 1000, s.loader.BadConfigUpdates())
+	assert.Contains(t, <-r.Events, "WeightedRandomConfigMapInvalid")
+}
+
+func TestWeightedRandomExpanderHandlesConfigUpdate(t *testing.T) {
+	s, _, cm := getFilterInstance(t, spotOnlyConfig)
+	counts := countChoices(s, []expander.Option{eoSpot, eoOnDemand}, 100)
+	assert.Equal(t, map[string]int{"spot": 100}, counts)
+
+	cm.Data[ConfigMapKey] = config
+	counts = countChoices(s, []expander.Option{eoSpot, eoOnDemand}, 1000)
+	assert.Greater(t, counts["on-demand"], 0)
+	assert.Equal(t, 1100, s.loader.OkConfigUpdates())
+}
+
+func TestWeightedRandomExpanderRejectsNegativeWeights(t *testing.T) {
+	s, _, _ := getFilterInstance(t, config)
+	_, err := s.loader.Parse("-1:\n  - \".*\"\n")
+	assert.Error(t, err)
+}
@@ -23,12 +23,16 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/actuation"
+	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/eligibility"
 	"k8s.io/autoscaler/cluster-autoscaler/core/scaleup/orchestrator"
 	"k8s.io/autoscaler/cluster-autoscaler/debuggingsnapshot"
 	"k8s.io/autoscaler/cluster-autoscaler/loop"
@@ -45,7 +49,9 @@ import (
 	"k8s.io/apiserver/pkg/server/mux"
 	"k8s.io/apiserver/pkg/server/routes"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/autoscaler/cluster-autoscaler/apis/provisioningrequest/autoscaling.x-k8s.io/v1beta1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws/extendedzones"
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/gce/localssdsize"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
@@ -53,16 +59,29 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/core/podlistprocessor"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/podpreference"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	"k8s.io/autoscaler/cluster-autoscaler/observers/loopstart"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/actionablecluster"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/balloon"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/compaction"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/defragmentation"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/estimationfeedback"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupconfig"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodeinfosprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/packinghints"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/provreq"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/provreq/kueue"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/scaledowncandidates"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/scaledowncandidates/emptycandidates"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/scaledowncandidates/previouscandidates"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scaleupquota"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/virtualnodes"
 	provreqorchestrator "k8s.io/autoscaler/cluster-autoscaler/provisioningrequest/orchestrator"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/options"
@@ -70,7 +89,9 @@ import (
 	scheduler_util "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
 	"k8s.io/autoscaler/cluster-autoscaler/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
+	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -122,12 +143,38 @@ var (
 		"How long after scale up that scale down evaluation resumes")
 	scaleDownDelayTypeLocal = flag.Bool("scale-down-delay-type-local", false,
 		"Should --scale-down-delay-after-* flags be applied locally per nodegroup or globally across all nodegroups")
+	scaleDownCoordination = flag.Bool("scale-down-coordination", false,
+		"Should CA publish unneeded nodes in the cluster-autoscaler.kubernetes.io/unneeded-since node annotation and evict the remaining pods from nearly empty unneeded nodes")
+	scaleDownCompactionMaxNodes = flag.Int("scale-down-compaction-max-nodes", 1,
+		"Maximum number of unneeded nodes pods are evicted from in a loop when --scale-down-coordination is enabled. 0 only publishes unneeded nodes")
+	scaleDownCompactionMaxPods = flag.Int("scale-down-compaction-max-pods", 2,
+		"Maximum number of pods on an unneeded node for it to be considered nearly empty and have its pods evicted")
+	scaleDownCompactionDelay = flag.Duration("scale-down-compaction-delay", 2*time.Minute,
+		"How long a node should be unneeded before pods are evicted from it")
+	scaleDownGpuBusyPodAnnotation = flag.String("scale-down-gpu-busy-pod-annotation", eligibility.DefaultGpuJobInProgressAnnotation,
+		"Pod annotation which, set to \"true\", marks a GPU job in progress. GPU nodes running such pods are not scaled down. Empty disables the check")
+	scaleDownGpuBusyNodeCondition = flag.String("scale-down-gpu-busy-node-condition", "",
+		"Node condition type which, when true, marks a GPU job in progress on the node. GPU nodes with such condition are not scaled down. Empty disables the check")
+	scaleDownWaitForMigReconfig = flag.Bool("scale-down-wait-for-mig-reconfiguration", true,
+		"Should CA keep GPU nodes from scale down while the NVIDIA MIG manager is changing their MIG configuration")
+	scaleDownUpgradeNodeAnnotations = flag.String("scale-down-upgrade-node-annotations", eligibility.KuredRebootInProgressAnnotation,
+		"Comma separated list of node annotations, in the form of <key> or <key>=<value>, marking nodes being upgraded. Such nodes are not scaled down")
+	scaleDownUpgradeNodeConditions = flag.String("scale-down-upgrade-node-conditions", "",
+		"Comma separated list of node condition types which, when true, mark nodes being upgraded. Such nodes are not scaled down")
+	scaleDownUpgradeWindow = flag.Duration("scale-down-upgrade-window", time.Hour,
+		"How long after an upgrade started, as reported by the upgrade node condition or an RFC3339 annotation value, a node is kept from scale down. 0 keeps it for as long as it is marked as being upgraded")
+	scaleDownMakeRoom = flag.Bool("scale-down-make-room", false,
+		"Should CA scale node groups up by a few nodes when pods of underutilized nodes have no place to be moved to, if fewer new nodes than the underutilized ones can take their pods without violating PodDisruptionBudgets, so that more nodes are then scaled down")
+	scaleDownMakeRoomMaxNodes = flag.Int("scale-down-make-room-max-nodes", 1,
+		"Maximum number of nodes added at once to make room for pods of underutilized nodes")
 	scaleDownDelayAfterDelete = flag.Duration("scale-down-delay-after-delete", 0,
 		"How long after node deletion that scale down evaluation resumes, defaults to scanInterval")
 	scaleDownDelayAfterFailure = flag.Duration("scale-down-delay-after-failure", config.DefaultScaleDownDelayAfterFailure,
 		"How long after scale down failure that scale down evaluation resumes")
 	scaleDownUnneededTime = flag.Duration("scale-down-unneeded-time", config.DefaultScaleDownUnneededTime,
 		"How long a node should be unneeded before it is eligible for scale down")
+	maxPodScaleDownUnneededTime = flag.Duration("max-pod-scale-down-unneeded-time", config.DefaultMaxPodScaleDownUnneededTime,
+		"Maximum unneeded time pods can request for their nodes through the cluster-autoscaler.kubernetes.io/scale-down-unneeded-time annotation")
 	scaleDownUnreadyTime = flag.Duration("scale-down-unready-time", config.DefaultScaleDownUnreadyTime,
 		"How long an unready node should be unneeded before it is eligible for scale down")
 	scaleDownUtilizationThreshold = flag.Float64("scale-down-utilization-threshold", config.DefaultScaleDownUtilizationThreshold,
@@ -158,20 +205,37 @@ var (
 	maxNodesTotal               = flag.Int("max-nodes-total", 0, "Maximum number of nodes in all node groups. Cluster autoscaler will not grow the cluster beyond this number.")
 	coresTotal                  = flag.String("cores-total", minMaxFlagString(0, config.DefaultMaxClusterCores), "Minimum and maximum number of cores in cluster, in the format <min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers.")
 	memoryTotal                 = flag.String("memory-total", minMaxFlagString(0, config.DefaultMaxClusterMemory), "Minimum and maximum number of gigabytes of memory in cluster, in the format <min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers.")
+	maxNodesPerLabelDomain      = multiStringFlag("max-nodes-per-label-domain", "Maximum number of nodes in each domain of a node label, in the format <label>:<max>, e.g. topology.kubernetes.io/zone:50 for at most 50 nodes in each zone. Nodes without the label aren't limited. Can be passed multiple times.")
 	gpuTotal                    = multiStringFlag("gpu-total", "Minimum and maximum number of different GPUs in cluster, in the format <gpu_type>:<min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers. Can be passed multiple times. CURRENTLY THIS FLAG ONLY WORKS ON GKE.")
 	cloudProviderFlag           = flag.String("cloud-provider", cloudBuilder.DefaultCloudProvider,
 		"Cloud provider type. Available values: ["+strings.Join(cloudBuilder.AvailableCloudProviders, ",")+"]")
 	maxBulkSoftTaintCount      = flag.Int("max-bulk-soft-taint-count", 10, "Maximum number of nodes that can be tainted/untainted PreferNoSchedule at the same time. Set to 0 to turn off such tainting.")
 	maxBulkSoftTaintTime       = flag.Duration("max-bulk-soft-taint-time", 3*time.Second, "Maximum duration of tainting/untainting nodes as PreferNoSchedule at the same time.")
+	scaleUpQuotas              = multiStringFlag("scale-up-quota", "Limit of nodes provisioned for pods from a namespace or matching a label selector, in the form of <namespace|label>:<value>:<limits>, e.g. namespace:team-a:nodes=10,cpu=64,memory=256Gi or label:tenant=blue:nodes=5. Pods matching a used up quota don't trigger scale-up and scale-ups are trimmed to the nodes their pods' quotas still allow. Can be used multiple times.")
+	scaleUpQuotaWindow         = flag.Duration("scale-up-quota-window", time.Hour, "Sliding window in which nodes provisioned against scale-up quotas are counted.")
+	packingHintsMaxPods        = flag.Int("packing-hints-max-pods", 0, "Maximum number of pods annotated after each scale-up with the node group each was planned onto, so that a scheduler plugin can prefer its upcoming nodes. 0 disables packing hints.")
+	estimationFeedback         = flag.Bool("estimation-feedback", false, "Should CA evaluate how many of the nodes added by each scale-up were actually needed once pods scheduled, and correct the node counts estimated for each node group accordingly.")
+	estimationFeedbackSettle   = flag.Duration("estimation-feedback-settle-time", 15*time.Minute, "Time after a scale-up at which the nodes it actually needed are counted, if estimation-feedback is enabled. Should be longer than nodes take to start, and shorter than scale-down-delay-after-add plus scale-down-unneeded-time.")
+	cleanSoftTaintsOnAbort     = flag.Bool("clean-soft-taints-on-scale-down-abort", false, "Should CA remove PreferNoSchedule taints from unneeded nodes when scale-down is abandoned, e.g. because pending pods triggered a scale-up. Removal is limited by max-bulk-soft-taint-count and max-bulk-soft-taint-time per loop.")
+	taintCleanupParallelism    = flag.Int("startup-taint-cleanup-parallelism", 10, "Number of nodes taints left by a previous run of cluster autoscaler are removed from at the same time on startup.")
+	startupTaintCleanupQPS     = flag.Float64("startup-taint-cleanup-qps", 20, "Maximum number of nodes per second taints left by a previous run of cluster autoscaler are removed from on startup. Set to 0 to turn off the limit.")
+	deletionCandidateTaintTTL  = flag.Duration("deletion-candidate-taint-ttl", 0, "Age after which DeletionCandidate taints left by a previous run of cluster autoscaler are removed on startup. Set to 0 to keep such taints.")
 	maxEmptyBulkDeleteFlag     = flag.Int("max-empty-bulk-delete", 10, "Maximum number of empty nodes that can be deleted at the same time.")
 	maxGracefulTerminationFlag = flag.Int("max-graceful-termination-sec", 10*60, "Maximum number of seconds CA waits for pod termination when trying to scale down a node. "+
 		"This flag is mutually exclusion with drain-priority-config flag which allows more configuration options.")
 	maxTotalUnreadyPercentage = flag.Float64("max-total-unready-percentage", 45, "Maximum percentage of unready nodes in the cluster.  After this is exceeded, CA halts operations")
 	okTotalUnreadyCount       = flag.Int("ok-total-unready-count", 3, "Number of allowed unready nodes, irrespective of max-total-unready-percentage")
 	scaleUpFromZero           = flag.Bool("scale-up-from-zero", true, "Should CA scale up when there are 0 ready nodes.")
+	apiServerDegradedMode     = flag.Bool("api-server-degraded-mode", false, "Should CA suspend actuation while the API server is degraded, resuming once its informers have resynced, and wait to be elected again instead of restarting when it loses its leader lease.")
 	parallelScaleUp           = flag.Bool("parallel-scale-up", false, "Whether to allow parallel node groups scale up. Experimental: may not work on some cloud providers, enable at your own risk.")
 	maxNodeProvisionTime      = flag.Duration("max-node-provision-time", 15*time.Minute, "The default maximum time CA waits for node to be provisioned - the value can be overridden per node group")
+	learnNodeProvisionTime    = flag.Bool("learn-node-provision-time", false, "Should CA learn the maximum time it waits for node to be provisioned for each node group from its recent scale-ups. Node groups overriding max-node-provision-time keep their value.")
+	minLearnedProvisionTime   = flag.Duration("min-learned-node-provision-time", 5*time.Minute, "Lower bound of the learned maximum node provision time.")
+	maxLearnedProvisionTime   = flag.Duration("max-learned-node-provision-time", time.Hour, "Upper bound of the learned maximum node provision time.")
+	defragmentationInterval   = flag.Duration("defragmentation-report-interval", 0, "How often CA computes how many nodes could be freed by repacking pods and reports it in metrics and the status ConfigMap, without acting on it. 0 disables the report.")
+	lintNodeGroupTemplates    = flag.Bool("lint-node-group-templates", false, "Should CA lint node group templates for zero allocatable, conflicting taints and labels missing for pending pods, and report issues in metrics and events.")
 	maxPodEvictionTime        = flag.Duration("max-pod-eviction-time", 2*time.Minute, "Maximum time CA tries to evict a pod before giving up")
+	unreadyNodePdbTimeout     = flag.Duration("unready-node-pdb-override-timeout", 0, "Time after which PodDisruptionBudgets with zero disruptions allowed stop blocking scale-down of unready nodes; their pods are then deleted instead of evicted. 0 means PodDisruptionBudgets are always respected.")
 	nodeGroupsFlag            = multiStringFlag(
 		"nodes",
 		"sets min,max size and other configuration data for a node group in a format accepted by cloud provider. Can be used multiple times. Format: <min>:<max>:<other...>")
@@ -181,6 +245,7 @@ var (
 			"A definition is expressed `<name of discoverer>:[<key>[=<value>]]`. "+
 			"The `aws` and `gce` cloud providers are currently supported. AWS matches by ASG tags, e.g. `asg:tag=tagKey,anotherTagKey`. "+
 			"GCE matches by IG name prefix, and requires you to specify min and max nodes per IG, e.g. `mig:namePrefix=pfx,min=0,max=10` "+
+			"OCI matches node pools or instance pools by tags within compartments, e.g. `nodepool:compartmentId=<ocid>,tag=ns.key=value,min=0,max=10`. "+
 			"Can be used multiple times.")
 
 	estimatorFlag = flag.String("estimator", estimator.BinpackingEstimatorName,
@@ -191,6 +256,11 @@ var (
 	grpcExpanderCert = flag.String("grpc-expander-cert", "", "Path to cert used by gRPC server over TLS")
 	grpcExpanderURL  = flag.String("grpc-expander-url", "", "URL to reach gRPC expander server.")
 
+	expanderPodPreferencePolicy = flag.String("expander-pod-preference-policy", podpreference.NonePolicy,
+		"How the expander honors the "+podpreference.PreferredNodeGroupsAnnotation+" and "+podpreference.PreferredNodeGroupSelectorAnnotation+" annotations of pending pods. "+
+			"Available values: ["+strings.Join(podpreference.AvailablePolicies, ",")+"]. "+
+			"bonus breaks the ties left by the expanders in favor of the node groups preferred by the most pods, exclusive only considers these node groups if any of them can be scaled up.")
+
 	ignoreDaemonSetsUtilization = flag.Bool("ignore-daemonsets-utilization", false,
 		"Should CA ignore DaemonSet pods when calculating resource utilization for scaling down")
 	ignoreMirrorPodsUtilization = flag.Bool("ignore-mirror-pods-utilization", false,
@@ -198,6 +268,11 @@ var (
 
 	writeStatusConfigMapFlag         = flag.Bool("write-status-configmap", true, "Should CA write status information to a configmap")
 	statusConfigMapName              = flag.String("status-config-map-name", "cluster-autoscaler-status", "Status configmap name")
+	clusterStateSnapshotRetention    = flag.Int("cluster-state-snapshot-retention", 0, "Number of the most recent cluster state snapshots (readiness, acceptable ranges and incorrect sizes of node groups) persisted for post-mortem analysis. 0 disables the snapshots.")
+	clusterStateSnapshotInterval     = flag.Duration("cluster-state-snapshot-interval", 10*time.Minute, "Maximum time between two persisted cluster state snapshots. Snapshots are persisted more often only when the cluster state changes.")
+	clusterStateSnapshotConfigMap    = flag.String("cluster-state-snapshot-config-map-name", "cluster-autoscaler-state-snapshots", "Name of the configmap holding the cluster state snapshots")
+	clusterStateSnapshotDir          = flag.String("cluster-state-snapshot-dir", "", "Directory, e.g. a mounted volume, to write the cluster state snapshots to instead of the configmap")
+	notTriggerScaleUpEventWindow     = flag.Duration("not-trigger-scale-up-event-window", 0, "Minimum time between two NotTriggerScaleUp events for the pods of the same controller, e.g. a ReplicaSet or a Job. If set, such pods are reported by a single event on their controller, with their count and the reasons of all of them. 0 emits an event for each pod in every loop.")
 	maxInactivityTimeFlag            = flag.Duration("max-inactivity", 10*time.Minute, "Maximum time from last recorded autoscaler activity before automatic restart")
 	maxBinpackingTimeFlag            = flag.Duration("max-binpacking-time", 5*time.Minute, "Maximum time spend on binpacking for a single scale-up. If binpacking is limited by this, scale-up will continue with the already calculated scale-up options.")
 	maxFailingTimeFlag               = flag.Duration("max-failing-time", 15*time.Minute, "Maximum time from last recorded successful autoscaler run before automatic restart")
@@ -206,20 +281,34 @@ var (
 	maxAutoprovisionedNodeGroupCount = flag.Int("max-autoprovisioned-node-group-count", 15, "The maximum number of autoprovisioned groups in the cluster.This flag is deprecated and will be removed in future releases.")
 
 	unremovableNodeRecheckTimeout = flag.Duration("unremovable-node-recheck-timeout", 5*time.Minute, "The timeout before we check again a node that couldn't be removed before")
+	uninitializedNodeRemovalTime  = flag.Duration("uninitialized-node-removal-time", 0, "Time after which a node still tainted with node.cloudprovider.kubernetes.io/uninitialized is deleted together with its backing instance. 0 disables the removal.")
+	maxUninitializedNodesRemoved  = flag.Int("max-uninitialized-nodes-removed-per-node-group", 1, "Maximum number of uninitialized nodes removed from a single node group in one loop.")
+	brokenNodeReplacementTime     = flag.Duration("broken-node-replacement-time", 0, "Time after which an unready node of a node group at its min size is replaced: the node group is scaled up above its min size first, and the unready node is deleted once the new node is ready. 0 disables the replacement.")
 	expendablePodsPriorityCutoff  = flag.Int("expendable-pods-priority-cutoff", -10, "Pods with priority below cutoff will be expendable. They can be killed without any consideration during scale down and they don't cause scale up. Pods with null priority (PodPriority disabled) are non expendable.")
+	balloonPodPriorityClass       = flag.String("balloon-pod-priority-class", "", "Pods with this priority class are balloon pods, placeholder pods keeping headroom in the cluster. They never block scale-down and only trigger scale-up up to --balloon-headroom-pods. Empty disables balloon pods.")
+	balloonHeadroomPods           = flag.Int("balloon-headroom-pods", 0, "Number of balloon pods, scheduled or not, the cluster should have room for. Unschedulable balloon pods beyond it don't trigger scale-up.")
+	balloonHeadroomController     = flag.Bool("balloon-headroom-controller", false, "Should CA manage a deployment of --balloon-headroom-pods balloon pods with the --balloon-pod-priority-class priority class.")
+	balloonPodNamespace           = flag.String("balloon-pod-namespace", "kube-system", "Namespace of the balloon pods deployment managed by CA.")
+	balloonPodCPU                 = flag.String("balloon-pod-cpu", "", "CPU request of balloon pods managed by CA, e.g. 500m.")
+	balloonPodMemory              = flag.String("balloon-pod-memory", "", "Memory request of balloon pods managed by CA, e.g. 1Gi.")
 	regional                      = flag.Bool("regional", false, "Cluster is regional.")
 	newPodScaleUpDelay            = flag.Duration("new-pod-scale-up-delay", 0*time.Second, "Pods less than this old will not be considered for scale-up. Can be increased for individual pods through annotation 'cluster-autoscaler.kubernetes.io/pod-scale-up-delay'.")
 
-	ignoreTaintsFlag          = multiStringFlag("ignore-taint", "Specifies a taint to ignore in node templates when considering to scale a node group (Deprecated, use startup-taints instead)")
-	startupTaintsFlag         = multiStringFlag("startup-taint", "Specifies a taint to ignore in node templates when considering to scale a node group (Equivalent to ignore-taint)")
-	statusTaintsFlag          = multiStringFlag("status-taint", "Specifies a taint to ignore in node templates when considering to scale a node group but nodes will not be treated as unready")
-	balancingIgnoreLabelsFlag = multiStringFlag("balancing-ignore-label", "Specifies a label to ignore in addition to the basic and cloud-provider set of labels when comparing if two node groups are similar")
-	balancingLabelsFlag       = multiStringFlag("balancing-label", "Specifies a label to use for comparing if two node groups are similar, rather than the built in heuristics. Setting this flag disables all other comparison logic, and cannot be combined with --balancing-ignore-label.")
-	awsUseStaticInstanceList  = flag.Bool("aws-use-static-instance-list", false, "Should CA fetch instance types in runtime or use a static list. AWS only")
+	ignoreTaintsFlag                     = multiStringFlag("ignore-taint", "Specifies a taint to ignore in node templates when considering to scale a node group (Deprecated, use startup-taints instead)")
+	startupTaintsFlag                    = multiStringFlag("startup-taint", "Specifies a taint to ignore in node templates when considering to scale a node group (Equivalent to ignore-taint)")
+	statusTaintsFlag                     = multiStringFlag("status-taint", "Specifies a taint to ignore in node templates when considering to scale a node group but nodes will not be treated as unready")
+	balancingIgnoreLabelsFlag            = multiStringFlag("balancing-ignore-label", "Specifies a label to ignore in addition to the basic and cloud-provider set of labels when comparing if two node groups are similar")
+	balancingLabelsFlag                  = multiStringFlag("balancing-label", "Specifies a label to use for comparing if two node groups are similar, rather than the built in heuristics. Setting this flag disables all other comparison logic, and cannot be combined with --balancing-ignore-label.")
+	awsUseStaticInstanceList             = flag.Bool("aws-use-static-instance-list", false, "Should CA fetch instance types in runtime or use a static list. AWS only")
+	awsUseExemplarNodeTemplates          = flag.Bool("aws-use-exemplar-node-templates", false, "Should CA build node templates for ASGs based on their live nodes when available, with resources from ASG tags taking precedence. AWS only")
+	awsUseEniMaxPods                     = flag.Bool("aws-use-eni-max-pods", false, "Should CA derive the pod capacity of ASG node templates from the ENI and IPv4 address limits of the instance type, as the Amazon VPC CNI does, rather than use 110. AWS only")
+	awsVpcCniPrefixDelegation            = flag.Bool("aws-vpc-cni-prefix-delegation", false, "Whether the Amazon VPC CNI assigns IPv4 prefixes to ENIs, used together with --aws-use-eni-max-pods. AWS only")
+	awsExtendedZonesRequireZoneSelection = flag.Bool("aws-extended-zones-require-zone-selection", false, "Should CA only scale up ASGs in Local Zones or Wavelength Zones for pods selecting their zone with a node selector or a required node affinity. AWS only")
 
 	// GCE specific flags
 	concurrentGceRefreshes            = flag.Int("gce-concurrent-refreshes", 1, "Maximum number of concurrent refreshes per cloud object type.")
 	gceMigInstancesMinRefreshWaitTime = flag.Duration("gce-mig-instances-min-refresh-wait-time", 5*time.Second, "The minimum time which needs to pass before GCE MIG instances from a given MIG can be refreshed.")
+	gcePricingCatalogRefreshInterval  = flag.Duration("gce-pricing-catalog-refresh-interval", 0, "How often GCE prices used by the price expander are refreshed from the Cloud Billing Catalog API. If 0, the static price list is used.")
 	_                                 = flag.Bool("gce-expander-ephemeral-storage-support", true, "Whether scale-up takes ephemeral storage resources into account for GCE cloud provider (Deprecated, to be removed in 1.30+)")
 
 	enableProfiling                    = flag.Bool("profiling", false, "Is debug/pprof endpoint enabled")
@@ -249,6 +338,7 @@ var (
 	minReplicaCount                         = flag.Int("min-replica-count", 0, "Minimum number or replicas that a replica set or replication controller should have to allow their pods deletion in scale down")
 	nodeDeleteDelayAfterTaint               = flag.Duration("node-delete-delay-after-taint", 5*time.Second, "How long to wait before deleting a node after tainting it")
 	scaleDownSimulationTimeout              = flag.Duration("scale-down-simulation-timeout", 30*time.Second, "How long should we run scale down simulation.")
+	scaleDownSimulationWorkers              = flag.Int("scale-down-simulation-workers", 1, "Number of workers concurrently checking whether the pods of scale down candidates can be moved elsewhere. 1 means the candidates are checked sequentially.")
 	parallelDrain                           = flag.Bool("parallel-drain", true, "Whether to allow parallel drain of nodes. This flag is deprecated and will be removed in future releases.")
 	maxCapacityMemoryDifferenceRatio        = flag.Float64("memory-difference-ratio", config.DefaultMaxCapacityMemoryDifferenceRatio, "Maximum difference in memory capacity between two similar node groups to be considered for balancing. Value is a ratio of the smaller node group's memory capacity.")
 	maxFreeDifferenceRatio                  = flag.Float64("max-free-difference-ratio", config.DefaultMaxFreeDifferenceRatio, "Maximum difference in free resources between two similar node groups to be considered for balancing. Value is a ratio of the smaller node group's free resource.")
@@ -256,13 +346,26 @@ var (
 	forceDaemonSets                         = flag.Bool("force-ds", false, "Blocks scale-up of node groups too small for all suitable Daemon Sets pods.")
 	dynamicNodeDeleteDelayAfterTaintEnabled = flag.Bool("dynamic-node-delete-delay-after-taint-enabled", false, "Enables dynamic adjustment of NodeDeleteDelayAfterTaint based of the latency between CA and api-server")
 	bypassedSchedulers                      = pflag.StringSlice("bypassed-scheduler-names", []string{}, fmt.Sprintf("Names of schedulers to bypass. If set to non-empty value, CA will not wait for pods to reach a certain age before triggering a scale-up."))
-	drainPriorityConfig                     = flag.String("drain-priority-config", "",
+	maxGracefulTerminationOverrides         = flag.String("max-graceful-termination-overrides", "",
+		"List of ',' separated overrides of the maximum number of seconds CA waits for pod termination, from --max-graceful-termination-sec or --drain-priority-config, "+
+			"for the pods of a namespace (namespace/<name>:<seconds>) or a priority class (priorityclass/<name>:<seconds>). Namespace overrides take precedence. "+
+			"Eg. flag usage: 'namespace/databases:1800,priorityclass/batch:120'")
+	drainPriorityConfig = flag.String("drain-priority-config", "",
 		"List of ',' separated pairs (priority:terminationGracePeriodSeconds) of integers separated by ':' enables priority evictor. Priority evictor groups pods into priority groups based on pod priority and evict pods in the ascending order of group priorities"+
 			"--max-graceful-termination-sec flag should not be set when this flag is set. Not setting this flag will use unordered evictor by default."+
 			"Priority evictor reuses the concepts of drain logic in kubelet(https://github.com/kubernetes/enhancements/tree/master/keps/sig-node/2712-pod-priority-based-graceful-node-shutdown#migration-from-the-node-graceful-shutdown-feature)."+
 			"Eg. flag usage:  '10000:20,1000:100,0:60'")
 	provisioningRequestsEnabled = flag.Bool("enable-provisioning-requests", false, "Whether the clusterautoscaler will be handling the ProvisioningRequest CRs.")
+	kueueIntegrationEnabled     = flag.Bool("enable-kueue-integration", false, "Whether the clusterautoscaler will provision capacity with ProvisioningRequests for Kueue Workloads waiting for admission. Requires --enable-provisioning-requests.")
+	kueueProvisioningClassName  = flag.String("kueue-provisioning-class-name", v1beta1.ProvisioningClassBestEffortAtomicScaleUp, "ProvisioningClass of the ProvisioningRequests created for Kueue Workloads.")
+	kueueAdmissionCheckName     = flag.String("kueue-admission-check-name", "cluster-autoscaler", "Name of the Kueue admission check reporting the state of the capacity provisioned for Kueue Workloads and annotating their pods with the ProvisioningRequest they consume.")
 	frequentLoopsEnabled        = flag.Bool("frequent-loops-enabled", false, "Whether clusterautoscaler triggers new iterations more frequently when it's needed")
+	deletionVetoWebhookURL      = flag.String("node-deletion-veto-webhook-url", "", "URL of a webhook called before a node is deleted by scale-down, with the node, its pods and the reason of the deletion. The webhook can veto the deletion, which is retried in the following loops.")
+	deletionVetoWebhookTimeout  = flag.Duration("node-deletion-veto-webhook-timeout", 5*time.Second, "Timeout of a call to the node deletion veto webhook, which reviews all the nodes selected for deletion in a loop.")
+	deletionVetoWebhookCacheTTL = flag.Duration("node-deletion-veto-webhook-cache-ttl", time.Minute, "How long a verdict of the node deletion veto webhook is reused, as long as the pods of the node don't change. Set to 0 to call the webhook in every loop.")
+	deletionVetoFailurePolicy   = flag.String("node-deletion-veto-webhook-failure-policy", "Fail", "What happens to a node deletion if the node deletion veto webhook call fails: Fail vetoes the deletion, Ignore allows it.")
+	reserveNodeGroupsFlag       = multiStringFlag("reserve-node-group", "Regular expression matching ids of node groups used as reserve capacity. Reserve node groups are only scaled up when no other node group can accommodate pending pods, e.g. because all of them are in backoff or at max size, and their nodes are never scaled down. Can be passed multiple times.")
+	proportionalWorkloadsFlag   = multiStringFlag("proportional-workload", "Deployment scaled proportionally to the cluster size, e.g. by cluster-proportional-autoscaler in linear mode, in the format <namespace>/<name>:nodesPerReplica=<n>,coresPerReplica=<n>,min=<n>. Scale-down anticipates its shrink, so that its surplus replicas don't need to fit on the remaining nodes and aren't evicted. Can be passed multiple times.")
 )
 
 func isFlagPassed(name string) bool {
@@ -292,6 +395,10 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 	if err != nil {
 		klog.Fatalf("Failed to parse flags: %v", err)
 	}
+	parsedMaxNodesPerLabelDomain, err := parseMultipleDomainNodeLimits(*maxNodesPerLabelDomain)
+	if err != nil {
+		klog.Fatalf("Failed to parse flags: %v", err)
+	}
 	if *maxDrainParallelismFlag > 1 && !*parallelDrain {
 		klog.Fatalf("Invalid configuration, could not use --max-drain-parallelism > 1 if --parallel-drain is false")
 	}
@@ -327,6 +434,39 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		klog.Fatalf("Invalid configuration, could not use --drain-priority-config together with --max-graceful-termination-sec")
 	}
 
+	gracefulTerminationOverrides, err := actuation.ParseMaxGracefulTerminationOverrides(*maxGracefulTerminationOverrides)
+	if err != nil {
+		klog.Fatalf("Invalid configuration, could not parse --max-graceful-termination-overrides: %v", err)
+	}
+
+	for _, pattern := range *reserveNodeGroupsFlag {
+		if _, err := regexp.Compile(pattern); err != nil {
+			klog.Fatalf("Invalid configuration, could not parse --reserve-node-group %q: %v", pattern, err)
+		}
+	}
+
+	var proportionalWorkloads []config.ProportionalWorkload
+	for _, workloadStr := range *proportionalWorkloadsFlag {
+		workload, err := simulator.ParseProportionalWorkload(workloadStr)
+		if err != nil {
+			klog.Fatalf("Invalid configuration, could not parse --proportional-workload: %v", err)
+		}
+		proportionalWorkloads = append(proportionalWorkloads, workload)
+	}
+
+	deletionVetoWebhook := config.NodeDeletionVetoWebhookConfig{
+		URL:      *deletionVetoWebhookURL,
+		Timeout:  *deletionVetoWebhookTimeout,
+		CacheTTL: *deletionVetoWebhookCacheTTL,
+	}
+	switch *deletionVetoFailurePolicy {
+	case "Fail":
+	case "Ignore":
+		deletionVetoWebhook.IgnoreFailures = true
+	default:
+		klog.Fatalf("Invalid configuration, --node-deletion-veto-webhook-failure-policy must be Fail or Ignore, got %q", *deletionVetoFailurePolicy)
+	}
+
 	var drainPriorityConfigMap []kubelet_config.ShutdownGracePeriodByPodPriority
 	if isFlagPassed("drain-priority-config") {
 		drainPriorityConfigMap = actuation.ParseShutdownGracePeriodsAndPriorities(*drainPriorityConfig)
@@ -350,18 +490,31 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		MaxTotalUnreadyPercentage:        *maxTotalUnreadyPercentage,
 		OkTotalUnreadyCount:              *okTotalUnreadyCount,
 		ScaleUpFromZero:                  *scaleUpFromZero,
+		APIServerDegradedMode:            *apiServerDegradedMode,
 		ParallelScaleUp:                  *parallelScaleUp,
 		EstimatorName:                    *estimatorFlag,
 		ExpanderNames:                    *expanderFlag,
 		GRPCExpanderCert:                 *grpcExpanderCert,
 		GRPCExpanderURL:                  *grpcExpanderURL,
+		ExpanderPodPreferencePolicy:      *expanderPodPreferencePolicy,
 		IgnoreMirrorPodsUtilization:      *ignoreMirrorPodsUtilization,
 		MaxBulkSoftTaintCount:            *maxBulkSoftTaintCount,
 		MaxBulkSoftTaintTime:             *maxBulkSoftTaintTime,
+		DeletionCandidateTaintTTL:        *deletionCandidateTaintTTL,
+		StartupTaintCleanupParallelism:   *taintCleanupParallelism,
+		StartupTaintCleanupQPS:           *startupTaintCleanupQPS,
+		CleanSoftTaintsOnScaleDownAbort:  *cleanSoftTaintsOnAbort,
+		ScaleUpQuotas:                    *scaleUpQuotas,
+		ScaleUpQuotaWindow:               *scaleUpQuotaWindow,
+		PackingHintsMaxPods:              *packingHintsMaxPods,
+		EstimationFeedbackEnabled:        *estimationFeedback,
+		EstimationFeedbackSettleTime:     *estimationFeedbackSettle,
 		MaxEmptyBulkDelete:               *maxEmptyBulkDeleteFlag,
 		MaxGracefulTerminationSec:        *maxGracefulTerminationFlag,
 		MaxPodEvictionTime:               *maxPodEvictionTime,
+		UnreadyNodePdbOverrideTimeout:    *unreadyNodePdbTimeout,
 		MaxNodesTotal:                    *maxNodesTotal,
+		MaxNodesPerLabelDomain:           parsedMaxNodesPerLabelDomain,
 		MaxCoresTotal:                    maxCoresTotal,
 		MinCoresTotal:                    minCoresTotal,
 		MaxMemoryTotal:                   maxMemoryTotal,
@@ -371,24 +524,59 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		EnforceNodeGroupMinSize:          *enforceNodeGroupMinSize,
 		ScaleDownDelayAfterAdd:           *scaleDownDelayAfterAdd,
 		ScaleDownDelayTypeLocal:          *scaleDownDelayTypeLocal,
+		ScaleDownCoordination:            *scaleDownCoordination,
+		ScaleDownCompactionMaxNodes:      *scaleDownCompactionMaxNodes,
+		ScaleDownCompactionMaxPods:       *scaleDownCompactionMaxPods,
+		ScaleDownCompactionDelay:         *scaleDownCompactionDelay,
+		ScaleDownGpuBusyPodAnnotation:    *scaleDownGpuBusyPodAnnotation,
+		ScaleDownGpuBusyNodeCondition:    *scaleDownGpuBusyNodeCondition,
+		ScaleDownWaitForMigReconfig:      *scaleDownWaitForMigReconfig,
+		ScaleDownUpgradeNodeAnnotations:  splitNonEmpty(*scaleDownUpgradeNodeAnnotations),
+		ScaleDownUpgradeNodeConditions:   splitNonEmpty(*scaleDownUpgradeNodeConditions),
+		ScaleDownUpgradeWindow:           *scaleDownUpgradeWindow,
+		ScaleDownMakeRoom:                *scaleDownMakeRoom,
+		ScaleDownMakeRoomMaxNodes:        *scaleDownMakeRoomMaxNodes,
+		LearnNodeProvisionTime:           *learnNodeProvisionTime,
+		MinLearnedNodeProvisionTime:      *minLearnedProvisionTime,
+		MaxLearnedNodeProvisionTime:      *maxLearnedProvisionTime,
+		DefragmentationReportInterval:    *defragmentationInterval,
+		LintNodeGroupTemplates:           *lintNodeGroupTemplates,
 		ScaleDownDelayAfterDelete:        *scaleDownDelayAfterDelete,
 		ScaleDownDelayAfterFailure:       *scaleDownDelayAfterFailure,
+		MaxPodScaleDownUnneededTime:      *maxPodScaleDownUnneededTime,
 		ScaleDownEnabled:                 *scaleDownEnabled,
 		ScaleDownUnreadyEnabled:          *scaleDownUnreadyEnabled,
 		ScaleDownNonEmptyCandidatesCount: *scaleDownNonEmptyCandidatesCount,
 		ScaleDownCandidatesPoolRatio:     *scaleDownCandidatesPoolRatio,
 		ScaleDownCandidatesPoolMinCount:  *scaleDownCandidatesPoolMinCount,
 		DrainPriorityConfig:              drainPriorityConfigMap,
+		MaxGracefulTerminationOverrides:  gracefulTerminationOverrides,
+		ProportionalWorkloads:            proportionalWorkloads,
+		NodeDeletionVetoWebhook:          deletionVetoWebhook,
 		SchedulerConfig:                  parsedSchedConfig,
 		WriteStatusConfigMap:             *writeStatusConfigMapFlag,
 		StatusConfigMapName:              *statusConfigMapName,
+		ClusterStateSnapshotRetention:    *clusterStateSnapshotRetention,
+		ClusterStateSnapshotInterval:     *clusterStateSnapshotInterval,
+		ClusterStateSnapshotConfigMap:    *clusterStateSnapshotConfigMap,
+		ClusterStateSnapshotDir:          *clusterStateSnapshotDir,
+		NotTriggerScaleUpEventWindow:     *notTriggerScaleUpEventWindow,
 		BalanceSimilarNodeGroups:         *balanceSimilarNodeGroupsFlag,
 		ConfigNamespace:                  *namespace,
 		ClusterName:                      *clusterName,
 		NodeAutoprovisioningEnabled:      *nodeAutoprovisioningEnabled,
 		MaxAutoprovisionedNodeGroupCount: *maxAutoprovisionedNodeGroupCount,
 		UnremovableNodeRecheckTimeout:    *unremovableNodeRecheckTimeout,
+		UninitializedNodeRemovalTime:     *uninitializedNodeRemovalTime,
+		MaxUninitializedNodesRemoved:     *maxUninitializedNodesRemoved,
+		BrokenNodeReplacementTime:        *brokenNodeReplacementTime,
 		ExpendablePodsPriorityCutoff:     *expendablePodsPriorityCutoff,
+		BalloonPodPriorityClass:          *balloonPodPriorityClass,
+		BalloonHeadroomPods:              *balloonHeadroomPods,
+		BalloonHeadroomController:        *balloonHeadroomController,
+		BalloonPodNamespace:              *balloonPodNamespace,
+		BalloonPodCPU:                    *balloonPodCPU,
+		BalloonPodMemory:                 *balloonPodMemory,
 		Regional:                         *regional,
 		NewPodScaleUpDelay:               *newPodScaleUpDelay,
 		StartupTaints:                    append(*ignoreTaintsFlag, *startupTaintsFlag...),
@@ -400,12 +588,17 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 			KubeConfigPath: *kubeConfigFile,
 			APIContentType: *kubeAPIContentType,
 		},
-		NodeDeletionDelayTimeout: *nodeDeletionDelayTimeout,
-		AWSUseStaticInstanceList: *awsUseStaticInstanceList,
+		NodeDeletionDelayTimeout:             *nodeDeletionDelayTimeout,
+		AWSUseStaticInstanceList:             *awsUseStaticInstanceList,
+		AWSUseExemplarNodeTemplates:          *awsUseExemplarNodeTemplates,
+		AWSUseENIMaxPods:                     *awsUseEniMaxPods,
+		AWSVPCCNIPrefixDelegation:            *awsVpcCniPrefixDelegation,
+		AWSExtendedZonesRequireZoneSelection: *awsExtendedZonesRequireZoneSelection,
 		GCEOptions: config.GCEOptions{
 			ConcurrentRefreshes:            *concurrentGceRefreshes,
 			MigInstancesMinRefreshWaitTime: *gceMigInstancesMinRefreshWaitTime,
 			LocalSSDDiskSizeProvider:       localssdsize.NewSimpleLocalSSDProvider(),
+			PricingCatalogRefreshInterval:  *gcePricingCatalogRefreshInterval,
 		},
 		ClusterAPICloudConfigAuthoritative: *clusterAPICloudConfigAuthoritative,
 		CordonNodeBeforeTerminate:          *cordonNodeBeforeTerminate,
@@ -427,6 +620,7 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		MinReplicaCount:                    *minReplicaCount,
 		NodeDeleteDelayAfterTaint:          *nodeDeleteDelayAfterTaint,
 		ScaleDownSimulationTimeout:         *scaleDownSimulationTimeout,
+		ScaleDownSimulationWorkers:         *scaleDownSimulationWorkers,
 		ParallelDrain:                      *parallelDrain,
 		SkipNodesWithCustomControllerPods:  *skipNodesWithCustomControllerPods,
 		NodeGroupSetRatios: config.NodeGroupDifferenceRatios{
@@ -437,6 +631,10 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		DynamicNodeDeleteDelayAfterTaintEnabled: *dynamicNodeDeleteDelayAfterTaintEnabled,
 		BypassedSchedulers:                      scheduler_util.GetBypassedSchedulersMap(*bypassedSchedulers),
 		ProvisioningRequestEnabled:              *provisioningRequestsEnabled,
+		KueueIntegrationEnabled:                 *kueueIntegrationEnabled,
+		KueueProvisioningClassName:              *kueueProvisioningClassName,
+		KueueAdmissionCheckName:                 *kueueAdmissionCheckName,
+		ReserveNodeGroups:                       *reserveNodeGroupsFlag,
 	}
 }
 
@@ -461,7 +659,14 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 
 	autoscalingOptions.KubeClientOpts.KubeClientBurst = int(*kubeClientBurst)
 	autoscalingOptions.KubeClientOpts.KubeClientQPS = float32(*kubeClientQPS)
-	kubeClient := kube_util.CreateKubeClient(autoscalingOptions.KubeClientOpts)
+	kubeConfig := kube_util.GetKubeConfig(autoscalingOptions.KubeClientOpts)
+	var apiServerHealth *kube_util.APIServerHealth
+	if autoscalingOptions.APIServerDegradedMode {
+		// Track the health of the API server from the requests of the autoscaler, informers included.
+		apiServerHealth = kube_util.NewAPIServerHealth()
+		kubeConfig.Wrap(apiServerHealth.WrapTransport)
+	}
+	kubeClient := kube_client.NewForConfigOrDie(kubeConfig)
 
 	// Informer transform to trim ManagedFields for memory efficiency.
 	trim := func(obj interface{}) (interface{}, error) {
@@ -483,6 +688,7 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 		AutoscalingOptions:   autoscalingOptions,
 		ClusterSnapshot:      clustersnapshot.NewDeltaClusterSnapshot(),
 		KubeClient:           kubeClient,
+		APIServerHealth:      apiServerHealth,
 		InformerFactory:      informerFactory,
 		DebuggingSnapshotter: debuggingSnapshotter,
 		PredicateChecker:     predicateChecker,
@@ -492,6 +698,19 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 	}
 
 	opts.Processors = ca_processors.DefaultProcessors(autoscalingOptions)
+	if autoscalingOptions.LearnNodeProvisionTime {
+		opts.Processors.NodeGroupConfigProcessor = nodegroupconfig.NewLearningNodeGroupConfigProcessor(opts.Processors.NodeGroupConfigProcessor,
+			autoscalingOptions.NodeGroupDefaults, autoscalingOptions.MinLearnedNodeProvisionTime, autoscalingOptions.MaxLearnedNodeProvisionTime)
+	}
+	if autoscalingOptions.DefragmentationReportInterval > 0 {
+		opts.Processors.AutoscalingStatusProcessor = defragmentation.NewReportProcessor(opts.Processors.AutoscalingStatusProcessor, autoscalingOptions.DefragmentationReportInterval)
+	}
+	if autoscalingOptions.AWSExtendedZonesRequireZoneSelection {
+		opts.Processors.NodeGroupListProcessor = extendedzones.NewNodeGroupListProcessor(opts.Processors.NodeGroupListProcessor)
+	}
+	if autoscalingOptions.APIServerDegradedMode {
+		opts.Processors.ActionableClusterProcessor = actionablecluster.NewAPIServerDegradedModeProcessor(opts.Processors.ActionableClusterProcessor)
+	}
 	opts.Processors.TemplateNodeInfoProvider = nodeinfosprovider.NewDefaultTemplateNodeInfoProvider(nodeInfoCacheExpireTime, *forceDaemonSets)
 	podListProcessor := podlistprocessor.NewDefaultPodListProcessor(opts.PredicateChecker)
 
@@ -514,13 +733,68 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 		if err != nil {
 			return nil, err
 		}
-		opts.LoopStartNotifier = loopstart.NewObserversList([]loopstart.Observer{provreqProcesor})
+		observers := []loopstart.Observer{provreqProcesor}
+		if autoscalingOptions.KueueIntegrationEnabled {
+			dynamicClient, err := dynamic.NewForConfig(restConfig)
+			if err != nil {
+				return nil, err
+			}
+			workloadProcessor, err := kueue.NewWorkloadProcessor(dynamicClient, client, autoscalingOptions.KueueProvisioningClassName, autoscalingOptions.KueueAdmissionCheckName)
+			if err != nil {
+				return nil, err
+			}
+			observers = append(observers, workloadProcessor)
+		}
+		opts.LoopStartNotifier = loopstart.NewObserversList(observers)
 		injector, err := provreq.NewProvisioningRequestPodsInjector(restConfig)
 		if err != nil {
 			return nil, err
 		}
 		podListProcessor.AddProcessor(injector)
 	}
+	if autoscalingOptions.CloudProviderName == cloudprovider.OracleCloudProviderName {
+		// OKE virtual node pools are serverless, pods are left pending for them according to the cloud config policy.
+		podListProcessor.AddProcessor(virtualnodes.NewPodListProcessor())
+	}
+	if len(autoscalingOptions.ScaleUpQuotas) > 0 {
+		quotas, err := scaleupquota.ParseQuotas(autoscalingOptions.ScaleUpQuotas)
+		if err != nil {
+			return nil, err
+		}
+		quotaTracker := scaleupquota.NewTracker(quotas, autoscalingOptions.ScaleUpQuotaWindow)
+		podListProcessor.AddProcessor(scaleupquota.NewPodListProcessor(quotaTracker))
+		opts.ExpanderPreFilters = append(opts.ExpanderPreFilters, scaleupquota.NewExpanderFilter(quotaTracker))
+		opts.Processors.ScaleUpStatusProcessor = status.NewCombinedScaleUpStatusProcessor([]status.ScaleUpStatusProcessor{
+			opts.Processors.ScaleUpStatusProcessor,
+			scaleupquota.NewScaleUpStatusProcessor(quotaTracker),
+		})
+	}
+	if autoscalingOptions.PackingHintsMaxPods > 0 {
+		opts.Processors.ScaleUpStatusProcessor = status.NewCombinedScaleUpStatusProcessor([]status.ScaleUpStatusProcessor{
+			opts.Processors.ScaleUpStatusProcessor,
+			packinghints.NewScaleUpStatusProcessor(kubeClient, autoscalingOptions.PackingHintsMaxPods),
+		})
+	}
+	if autoscalingOptions.EstimationFeedbackEnabled {
+		feedbackTracker := estimationfeedback.NewTracker(autoscalingOptions.EstimationFeedbackSettleTime)
+		opts.EstimationCorrection = feedbackTracker
+		opts.Processors.ScaleUpStatusProcessor = status.NewCombinedScaleUpStatusProcessor([]status.ScaleUpStatusProcessor{
+			opts.Processors.ScaleUpStatusProcessor,
+			estimationfeedback.NewScaleUpStatusProcessor(feedbackTracker),
+		})
+		opts.Processors.AutoscalingStatusProcessor = estimationfeedback.NewAutoscalingStatusProcessor(opts.Processors.AutoscalingStatusProcessor, feedbackTracker)
+	}
+	if autoscalingOptions.BalloonPodPriorityClass != "" {
+		podListProcessor.AddProcessor(balloon.NewPodListProcessor(autoscalingOptions.BalloonPodPriorityClass, autoscalingOptions.BalloonHeadroomPods))
+		if autoscalingOptions.BalloonHeadroomController {
+			headroomController, err := balloon.NewHeadroomController(opts.Processors.AutoscalingStatusProcessor, autoscalingOptions.BalloonPodNamespace,
+				autoscalingOptions.BalloonPodPriorityClass, autoscalingOptions.BalloonHeadroomPods, autoscalingOptions.BalloonPodCPU, autoscalingOptions.BalloonPodMemory)
+			if err != nil {
+				return nil, err
+			}
+			opts.Processors.AutoscalingStatusProcessor = headroomController
+		}
+	}
 	opts.Processors.PodListProcessor = podListProcessor
 	scaleDownCandidatesComparers := []scaledowncandidates.CandidatesComparer{}
 	if autoscalingOptions.ParallelDrain {
@@ -541,6 +815,11 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 		opts.Processors.ScaleStateNotifier.Register(sdp)
 
 	}
+	if autoscalingOptions.ScaleDownCoordination {
+		podLister := kube_util.NewAllPodLister(informerFactory.Core().V1().Pods().Lister())
+		opts.Processors.ScaleDownCandidatesNotifier.Register(compaction.NewCompactor(kubeClient, podLister,
+			autoscalingOptions.ScaleDownCompactionMaxNodes, autoscalingOptions.ScaleDownCompactionMaxPods, autoscalingOptions.ScaleDownCompactionDelay))
+	}
 	opts.Processors.ScaleDownNodeProcessor = cp
 
 	var nodeInfoComparator nodegroupset.NodeInfoComparator
@@ -560,6 +839,10 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 		nodeInfoComparator = nodeInfoComparatorBuilder(autoscalingOptions.BalancingExtraIgnoredLabels, autoscalingOptions.NodeGroupSetRatios)
 	}
 
+	if autoscalingOptions.LintNodeGroupTemplates {
+		opts.Processors.TemplateNodeInfoProvider = nodeinfosprovider.NewLintingTemplateNodeInfoProvider(opts.Processors.TemplateNodeInfoProvider)
+	}
+
 	opts.Processors.NodeGroupSetProcessor = &nodegroupset.BalancingNodeGroupSetProcessor{
 		Comparator: nodeInfoComparator,
 	}
@@ -583,7 +866,8 @@ func buildAutoscaler(debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter
 	return autoscaler, nil
 }
 
-func run(healthCheck *metrics.HealthCheck, debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter) {
+// startAutoscaler builds the autoscaler and starts its background components.
+func startAutoscaler(healthCheck *metrics.HealthCheck, debuggingSnapshotter debuggingsnapshot.DebuggingSnapshotter) core.Autoscaler {
 	metrics.RegisterAll(*emitPerNodeGroupMetrics)
 
 	autoscaler, err := buildAutoscaler(debuggingSnapshotter)
@@ -601,22 +885,30 @@ func run(healthCheck *metrics.HealthCheck, debuggingSnapshotter debuggingsnapsho
 	if err := autoscaler.Start(); err != nil {
 		klog.Fatalf("Failed to autoscaler background components: %v", err)
 	}
+	return autoscaler
+}
 
-	// Autoscale ad infinitum.
-	context, cancel := ctx.WithCancel(ctx.Background())
-	defer cancel()
+// run autoscales until the context is done.
+func run(context ctx.Context, autoscaler core.Autoscaler, healthCheck *metrics.HealthCheck) {
 	if *frequentLoopsEnabled {
 		podObserver := loop.StartPodObserver(context, kube_util.CreateKubeClient(createAutoscalingOptions().KubeClientOpts))
 		trigger := loop.NewLoopTrigger(podObserver, autoscaler, *scanInterval)
 		lastRun := time.Now()
 		for {
 			trigger.Wait(lastRun)
+			if context.Err() != nil {
+				return
+			}
 			lastRun = time.Now()
 			loop.RunAutoscalerOnce(autoscaler, healthCheck, lastRun)
 		}
 	} else {
 		for {
-			time.Sleep(*scanInterval)
+			select {
+			case <-context.Done():
+				return
+			case <-time.After(*scanInterval):
+			}
 			loop.RunAutoscalerOnce(autoscaler, healthCheck, time.Now())
 		}
 	}
@@ -669,7 +961,8 @@ func main() {
 	}()
 
 	if !leaderElection.LeaderElect {
-		run(healthCheck, debuggingSnapshotter)
+		// Autoscale ad infinitum.
+		run(ctx.Background(), startAutoscaler(healthCheck, debuggingSnapshotter), healthCheck)
 	} else {
 		id, err := os.Hostname()
 		if err != nil {
@@ -699,23 +992,51 @@ func main() {
 			klog.Fatalf("Unable to create leader election lock: %v", err)
 		}
 
-		leaderelection.RunOrDie(ctx.TODO(), leaderelection.LeaderElectionConfig{
-			Lock:            lock,
-			LeaseDuration:   leaderElection.LeaseDuration.Duration,
-			RenewDeadline:   leaderElection.RenewDeadline.Duration,
-			RetryPeriod:     leaderElection.RetryPeriod.Duration,
-			ReleaseOnCancel: true,
-			Callbacks: leaderelection.LeaderCallbacks{
-				OnStartedLeading: func(_ ctx.Context) {
-					// Since we are committing a suicide after losing
-					// mastership, we can safely ignore the argument.
-					run(healthCheck, debuggingSnapshotter)
-				},
-				OnStoppedLeading: func() {
-					klog.Fatalf("lost master")
+		// In API server degraded mode, losing the lease doesn't kill CA: the lease is usually lost because
+		// the API server is degraded, and a restart would only drop the warm informer caches. Autoscaling
+		// stops until CA is elected again, keeping the autoscaler it built.
+		var runMutex sync.Mutex
+		var autoscaler core.Autoscaler
+		var leading atomic.Bool
+		if *apiServerDegradedMode {
+			// Waiting to be elected again doesn't make CA unhealthy.
+			go func() {
+				for now := range time.Tick(*scanInterval) {
+					if !leading.Load() {
+						healthCheck.UpdateLastSuccessfulRun(now)
+					}
+				}
+			}()
+		}
+		for {
+			leaderelection.RunOrDie(ctx.TODO(), leaderelection.LeaderElectionConfig{
+				Lock:            lock,
+				LeaseDuration:   leaderElection.LeaseDuration.Duration,
+				RenewDeadline:   leaderElection.RenewDeadline.Duration,
+				RetryPeriod:     leaderElection.RetryPeriod.Duration,
+				ReleaseOnCancel: true,
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStartedLeading: func(leaderContext ctx.Context) {
+						// The previous run may still be finishing its loop.
+						runMutex.Lock()
+						defer runMutex.Unlock()
+						leading.Store(true)
+						if autoscaler == nil {
+							autoscaler = startAutoscaler(healthCheck, debuggingSnapshotter)
+						}
+						// The context is done once the lease is lost.
+						run(leaderContext, autoscaler, healthCheck)
+					},
+					OnStoppedLeading: func() {
+						if !*apiServerDegradedMode {
+							klog.Fatalf("lost master")
+						}
+						leading.Store(false)
+						klog.Warningf("lost master, autoscaling stopped until elected again")
+					},
 				},
-			},
-		})
+			})
+		}
 	}
 }
 
@@ -774,6 +1095,17 @@ func minMaxFlagString(min, max int64) string {
 	return fmt.Sprintf("%v:%v", min, max)
 }
 
+// splitNonEmpty splits a comma separated flag value, dropping empty elements.
+func splitNonEmpty(value string) []string {
+	var result []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
 func parseMultipleGpuLimits(flags MultiStringFlag) ([]config.GpuLimits, error) {
 	parsedFlags := make([]config.GpuLimits, 0, len(flags))
 	for _, flag := range flags {
@@ -786,6 +1118,25 @@ func parseMultipleGpuLimits(flags MultiStringFlag) ([]config.GpuLimits, error) {
 	return parsedFlags, nil
 }
 
+func parseMultipleDomainNodeLimits(flags MultiStringFlag) ([]config.DomainNodeLimits, error) {
+	parsedFlags := make([]config.DomainNodeLimits, 0, len(flags))
+	for _, flag := range flags {
+		parts := strings.Split(flag, ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("incorrect max nodes per label domain specification: %v", flag)
+		}
+		maxVal, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("incorrect max nodes per label domain - max is not integer: %v", flag)
+		}
+		if maxVal < 0 {
+			return nil, fmt.Errorf("incorrect max nodes per label domain - max is less than 0: %v", flag)
+		}
+		parsedFlags = append(parsedFlags, config.DomainNodeLimits{Label: parts[0], Max: maxVal})
+	}
+	return parsedFlags, nil
+}
+
 func parseSingleGpuLimit(limits string) (config.GpuLimits, error) {
 	parts := strings.Split(limits, ":")
 	if len(parts) != 3 {
@@ -91,3 +91,17 @@ func TestParseSingleGpuLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMultipleDomainNodeLimits(t *testing.T) {
+	limits, err := parseMultipleDomainNodeLimits(MultiStringFlag{"topology.kubernetes.io/zone:50", "example.com/instance-family:10"})
+	assert.NoError(t, err)
+	assert.Equal(t, []config.DomainNodeLimits{
+		{Label: "topology.kubernetes.io/zone", Max: 50},
+		{Label: "example.com/instance-family", Max: 10},
+	}, limits)
+
+	for _, flag := range []string{"topology.kubernetes.io/zone", ":50", "topology.kubernetes.io/zone:x", "topology.kubernetes.io/zone:-1", "a:1:2"} {
+		_, err := parseMultipleDomainNodeLimits(MultiStringFlag{flag})
+		assert.Error(t, err, flag)
+	}
+}
@@ -47,6 +47,15 @@ type NodeGroupType string
 // PodEvictionResult describes result of the pod eviction attempt
 type PodEvictionResult string
 
+// ScaleDownAbortCause describes why scale-down was abandoned in a loop
+type ScaleDownAbortCause string
+
+// PendingPodCategory describes whether and why pending pods can be helped by scale-up
+type PendingPodCategory string
+
+// ScaleUpEstimationResult describes how the node count of a scale-up compares to the nodes it actually needed
+type ScaleUpEstimationResult string
+
 const (
 	caNamespace           = "cluster_autoscaler"
 	readyLabel            = "ready"
@@ -94,8 +103,48 @@ const (
 	PodEvictionSucceed PodEvictionResult = "succeeded"
 	// PodEvictionFailed means creation of the pod eviction object failed
 	PodEvictionFailed PodEvictionResult = "failed"
+
+	// ScaleDownAbortedScaleUp means scale-down was skipped because pending pods triggered a scale-up
+	ScaleDownAbortedScaleUp ScaleDownAbortCause = "scaleUp"
+	// ScaleDownAbortedNewPendingPods means scale-down was skipped because of very new pending pods
+	ScaleDownAbortedNewPendingPods ScaleDownAbortCause = "newPendingPods"
+	// ScaleDownAbortedError means scale-down was abandoned because of an error while finding unneeded nodes
+	ScaleDownAbortedError ScaleDownAbortCause = "error"
+
+	// PendingPodsAwaitingScaleUp are pending pods which triggered scale-up or will be considered for it in the next loops
+	PendingPodsAwaitingScaleUp PendingPodCategory = "awaitingScaleUp"
+	// PendingPodsAwaitingNodeRegistration are pending pods which will fit on nodes which are being created
+	PendingPodsAwaitingNodeRegistration PendingPodCategory = "awaitingNodeRegistration"
+	// PendingPodsBlockedByMaxSize are pending pods which can't be helped because node groups reached their max size
+	PendingPodsBlockedByMaxSize PendingPodCategory = "blockedByMaxSize"
+	// PendingPodsBlockedByQuota are pending pods which can't be helped because cluster-wide resource limits were reached
+	PendingPodsBlockedByQuota PendingPodCategory = "blockedByQuota"
+	// PendingPodsBlockedByDomainLimit are pending pods which can't be helped because the domains of node groups, e.g. zones, reached their max nodes
+	PendingPodsBlockedByDomainLimit PendingPodCategory = "blockedByDomainLimit"
+	// PendingPodsBlockedByBackoff are pending pods which can't be helped because node groups are backed off or not ready
+	PendingPodsBlockedByBackoff PendingPodCategory = "blockedByBackoff"
+	// PendingPodsUnmatchable are pending pods which don't fit any node group, e.g. because of taints or affinity
+	PendingPodsUnmatchable PendingPodCategory = "unmatchable"
+
+	// ScaleUpEstimationAccurate means the scale-up added as many nodes as it needed
+	ScaleUpEstimationAccurate ScaleUpEstimationResult = "accurate"
+	// ScaleUpEstimationOver means the scale-up added more nodes than it needed
+	ScaleUpEstimationOver ScaleUpEstimationResult = "overestimated"
+	// ScaleUpEstimationUnder means the scale-up added fewer nodes than it needed
+	ScaleUpEstimationUnder ScaleUpEstimationResult = "underestimated"
 )
 
+// PendingPodCategories lists all categories of pending pods.
+var PendingPodCategories = []PendingPodCategory{
+	PendingPodsAwaitingScaleUp,
+	PendingPodsAwaitingNodeRegistration,
+	PendingPodsBlockedByMaxSize,
+	PendingPodsBlockedByQuota,
+	PendingPodsBlockedByDomainLimit,
+	PendingPodsBlockedByBackoff,
+	PendingPodsUnmatchable,
+}
+
 // Names of Cluster Autoscaler operations
 const (
 	ScaleDown                  FunctionLabel = "scaleDown"
@@ -232,6 +281,38 @@ var (
 		}, []string{"node_group", "reason"},
 	)
 
+	nodeGroupFreeableNodes = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "node_group_freeable_nodes",
+			Help:      "Number of nodes in the node group which could be freed by repacking pods, as computed by the defragmentation report.",
+		}, []string{"node_group"},
+	)
+
+	nodeGroupTemplateIssues = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "node_group_template_issues",
+			Help:      "Number of issues of the given kind found by linting the template of the node group.",
+		}, []string{"node_group", "issue"},
+	)
+
+	nodeGroupEstimationAccuracy = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "node_group_estimation_accuracy",
+			Help:      "Ratio of the nodes the last evaluated scale-up of the node group needed to the nodes it added.",
+		}, []string{"node_group"},
+	)
+
+	nodeGroupEstimationCorrectionFactor = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "node_group_estimation_correction_factor",
+			Help:      "Factor the estimated node count of scale-ups of the node group is multiplied by.",
+		}, []string{"node_group"},
+	)
+
 	/**** Metrics related to autoscaler execution ****/
 	lastActivity = k8smetrics.NewGaugeVec(
 		&k8smetrics.GaugeOpts{
@@ -300,6 +381,30 @@ var (
 		}, []string{"reason"},
 	)
 
+	scaleUpEstimationsCount = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "scale_up_estimations_total",
+			Help:      "Number of evaluated scale-ups, by how their node count compares to the nodes they actually needed.",
+		}, []string{"result"},
+	)
+
+	quotaExceededScaleUpCount = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "quota_exceeded_failed_scale_ups_total",
+			Help:      "Number of times scale-up operation has failed because of a cloud quota, by quota.",
+		}, []string{"quota"},
+	)
+
+	failedScaleUpErrorKindCount = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "failed_scale_ups_by_error_kind_total",
+			Help:      "Number of times scale-up operation has failed, by provider-independent kind of the cloud provider error.",
+		}, []string{"error_kind"},
+	)
+
 	failedGPUScaleUpCount = k8smetrics.NewCounterVec(
 		&k8smetrics.CounterOpts{
 			Namespace: caNamespace,
@@ -365,6 +470,22 @@ var (
 		},
 	)
 
+	oldUninitializedNodesRemovedCount = k8smetrics.NewCounter(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "old_uninitialized_nodes_removed_count",
+			Help:      "Number of nodes removed by CA for remaining uninitialized by the cloud provider for too long.",
+		},
+	)
+
+	brokenNodesReplacedCount = k8smetrics.NewCounter(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "broken_nodes_replaced_count",
+			Help:      "Number of unready nodes of node groups at their min size removed by CA after a replacement node came up.",
+		},
+	)
+
 	overflowingControllersCount = k8smetrics.NewGauge(
 		&k8smetrics.GaugeOpts{
 			Namespace: caNamespace,
@@ -417,6 +538,75 @@ var (
 		},
 		[]string{"type"},
 	)
+
+	orphanedTaintsCount = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "orphaned_taints_count",
+			Help:      "Number of taints per type left by a previous run of cluster autoscaler and found on startup.",
+		},
+		[]string{"type"},
+	)
+
+	orphanedTaintsCleanupCount = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "orphaned_taints_cleanup_count",
+			Help:      "Number of nodes per taint type and result the taints left by a previous run of cluster autoscaler were removed from on startup.",
+		},
+		[]string{"type", "result"},
+	)
+
+	reserveNodeGroupActivationsCount = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "reserve_node_group_activations_total",
+			Help:      "Number of scale-ups which used a reserve node group because no other node group could accommodate pending pods.",
+		},
+		[]string{"node_group"},
+	)
+
+	scaleDownAbortsCount = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "scale_down_aborts_total",
+			Help:      "Number of loops in which scale-down was abandoned, by cause.",
+		},
+		[]string{"cause"},
+	)
+
+	pendingPodsByCategory = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "pending_pods_by_category",
+			Help:      "Number of pending pods considered in the last loop, by whether and why they can be helped by scale-up.",
+		}, []string{"category"},
+	)
+
+	softTaintsRemovedOnAbortCount = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "soft_taints_removed_on_scale_down_abort_total",
+			Help:      "Number of DeletionCandidate taints removed because scale-down was abandoned, by abort cause.",
+		},
+		[]string{"cause"},
+	)
+
+	apiServerDegraded = k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "api_server_degraded",
+			Help:      "Whether actuation is suspended because the API server is degraded or the informers haven't resynced yet. 1 if it is, 0 otherwise.",
+		},
+	)
+
+	apiServerDegradedSeconds = k8smetrics.NewCounter(
+		&k8smetrics.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "api_server_degraded_seconds_total",
+			Help:      "Time spent with actuation suspended because the API server was degraded or the informers hadn't resynced.",
+		},
+	)
 )
 
 // RegisterAll registers all metrics.
@@ -437,7 +627,10 @@ func RegisterAll(emitPerNodeGroupMetrics bool) {
 	legacyregistry.MustRegister(scaleUpCount)
 	legacyregistry.MustRegister(gpuScaleUpCount)
 	legacyregistry.MustRegister(failedScaleUpCount)
+	legacyregistry.MustRegister(failedScaleUpErrorKindCount)
 	legacyregistry.MustRegister(failedGPUScaleUpCount)
+	legacyregistry.MustRegister(quotaExceededScaleUpCount)
+	legacyregistry.MustRegister(scaleUpEstimationsCount)
 	legacyregistry.MustRegister(scaleDownCount)
 	legacyregistry.MustRegister(gpuScaleDownCount)
 	legacyregistry.MustRegister(evictionsCount)
@@ -445,6 +638,8 @@ func RegisterAll(emitPerNodeGroupMetrics bool) {
 	legacyregistry.MustRegister(unremovableNodesCount)
 	legacyregistry.MustRegister(scaleDownInCooldown)
 	legacyregistry.MustRegister(oldUnregisteredNodesRemovedCount)
+	legacyregistry.MustRegister(oldUninitializedNodesRemovedCount)
+	legacyregistry.MustRegister(brokenNodesReplacedCount)
 	legacyregistry.MustRegister(overflowingControllersCount)
 	legacyregistry.MustRegister(skippedScaleEventsCount)
 	legacyregistry.MustRegister(napEnabled)
@@ -452,6 +647,14 @@ func RegisterAll(emitPerNodeGroupMetrics bool) {
 	legacyregistry.MustRegister(nodeGroupDeletionCount)
 	legacyregistry.MustRegister(pendingNodeDeletions)
 	legacyregistry.MustRegister(nodeTaintsCount)
+	legacyregistry.MustRegister(orphanedTaintsCount)
+	legacyregistry.MustRegister(orphanedTaintsCleanupCount)
+	legacyregistry.MustRegister(reserveNodeGroupActivationsCount)
+	legacyregistry.MustRegister(scaleDownAbortsCount)
+	legacyregistry.MustRegister(softTaintsRemovedOnAbortCount)
+	legacyregistry.MustRegister(pendingPodsByCategory)
+	legacyregistry.MustRegister(apiServerDegraded)
+	legacyregistry.MustRegister(apiServerDegradedSeconds)
 
 	if emitPerNodeGroupMetrics {
 		legacyregistry.MustRegister(nodesGroupMinNodes)
@@ -459,6 +662,10 @@ func RegisterAll(emitPerNodeGroupMetrics bool) {
 		legacyregistry.MustRegister(nodesGroupTargetSize)
 		legacyregistry.MustRegister(nodesGroupHealthiness)
 		legacyregistry.MustRegister(nodeGroupBackOffStatus)
+		legacyregistry.MustRegister(nodeGroupFreeableNodes)
+		legacyregistry.MustRegister(nodeGroupTemplateIssues)
+		legacyregistry.MustRegister(nodeGroupEstimationAccuracy)
+		legacyregistry.MustRegister(nodeGroupEstimationCorrectionFactor)
 	}
 }
 
@@ -573,6 +780,16 @@ func UpdateNodeGroupHealthStatus(nodeGroup string, healthy bool) {
 	}
 }
 
+// UpdateNodeGroupFreeableNodes records the number of nodes in the node group which could be freed by repacking pods
+func UpdateNodeGroupFreeableNodes(nodeGroup string, freeableNodes int) {
+	nodeGroupFreeableNodes.WithLabelValues(nodeGroup).Set(float64(freeableNodes))
+}
+
+// UpdateNodeGroupTemplateIssues records the number of issues of the given kind found in the template of the node group
+func UpdateNodeGroupTemplateIssues(nodeGroup, issue string, count int) {
+	nodeGroupTemplateIssues.WithLabelValues(nodeGroup, issue).Set(float64(count))
+}
+
 // UpdateNodeGroupBackOffStatus records if node group is backoff for not autoscaling
 func UpdateNodeGroupBackOffStatus(nodeGroup string, backoffReasonStatus map[string]bool) {
 	if len(backoffReasonStatus) == 0 {
@@ -610,6 +827,24 @@ func RegisterFailedScaleUp(reason FailedScaleUpReason, gpuResourceName, gpuType
 	}
 }
 
+// RegisterFailedScaleUpErrorKind records a failed scale-up caused by a cloud provider error of the given kind.
+func RegisterFailedScaleUpErrorKind(errorKind string) {
+	failedScaleUpErrorKindCount.WithLabelValues(errorKind).Inc()
+}
+
+// RegisterQuotaExceededScaleUp records a failed scale-up caused by the given cloud quota.
+func RegisterQuotaExceededScaleUp(quota string) {
+	quotaExceededScaleUpCount.WithLabelValues(quota).Inc()
+}
+
+// RegisterScaleUpEstimation records the accuracy of an evaluated scale-up of the node group, i.e. the ratio of
+// the nodes it needed to the nodes it added, and the resulting correction factor of the node group.
+func RegisterScaleUpEstimation(nodeGroup string, result ScaleUpEstimationResult, accuracy, correctionFactor float64) {
+	scaleUpEstimationsCount.WithLabelValues(string(result)).Inc()
+	nodeGroupEstimationAccuracy.WithLabelValues(nodeGroup).Set(accuracy)
+	nodeGroupEstimationCorrectionFactor.WithLabelValues(nodeGroup).Set(correctionFactor)
+}
+
 // RegisterScaleDown records number of nodes removed by scale down
 func RegisterScaleDown(nodesCount int, gpuResourceName, gpuType string, reason NodeScaleDownReason) {
 	scaleDownCount.WithLabelValues(string(reason)).Add(float64(nodesCount))
@@ -680,6 +915,18 @@ func RegisterOldUnregisteredNodesRemoved(nodesCount int) {
 	oldUnregisteredNodesRemovedCount.Add(float64(nodesCount))
 }
 
+// RegisterOldUninitializedNodesRemoved records number of nodes that have been
+// removed by the cluster autoscaler for remaining uninitialized for too long
+func RegisterOldUninitializedNodesRemoved(nodesCount int) {
+	oldUninitializedNodesRemovedCount.Add(float64(nodesCount))
+}
+
+// RegisterBrokenNodesReplaced records number of unready nodes that have been
+// removed by the cluster autoscaler after a replacement node came up
+func RegisterBrokenNodesReplaced(nodesCount int) {
+	brokenNodesReplacedCount.Add(float64(nodesCount))
+}
+
 // UpdateOverflowingControllers sets the number of controllers that could not
 // have their pods cached.
 func UpdateOverflowingControllers(count int) {
@@ -715,3 +962,46 @@ func ObservePendingNodeDeletions(value int) {
 func ObserveNodeTaintsCount(taintType string, count float64) {
 	nodeTaintsCount.WithLabelValues(taintType).Set(count)
 }
+
+// ObserveOrphanedTaintsCount records the number of taints of given type left by a previous run of CA.
+func ObserveOrphanedTaintsCount(taintType string, count int) {
+	orphanedTaintsCount.WithLabelValues(taintType).Set(float64(count))
+}
+
+// ObserveOrphanedTaintsCleanup records the number of nodes taints of given type left by a previous
+// run of CA were removed from, and failed to be removed from.
+func ObserveOrphanedTaintsCleanup(taintType string, cleaned, failed int) {
+	orphanedTaintsCleanupCount.WithLabelValues(taintType, "cleaned").Set(float64(cleaned))
+	orphanedTaintsCleanupCount.WithLabelValues(taintType, "failed").Set(float64(failed))
+}
+
+// RegisterReserveNodeGroupActivation records a scale-up of a reserve node group.
+func RegisterReserveNodeGroupActivation(nodeGroup string) {
+	reserveNodeGroupActivationsCount.WithLabelValues(nodeGroup).Add(1.0)
+}
+
+// RegisterScaleDownAbort records a scale-down abandoned for the given cause
+// and the number of soft taints removed as a result.
+func RegisterScaleDownAbort(cause ScaleDownAbortCause, removedSoftTaints int) {
+	scaleDownAbortsCount.WithLabelValues(string(cause)).Inc()
+	softTaintsRemovedOnAbortCount.WithLabelValues(string(cause)).Add(float64(removedSoftTaints))
+}
+
+// UpdatePendingPodsByCategory records the number of pending pods in each category,
+// resetting categories missing from the counts to zero.
+func UpdatePendingPodsByCategory(counts map[PendingPodCategory]int) {
+	for _, category := range PendingPodCategories {
+		pendingPodsByCategory.WithLabelValues(string(category)).Set(float64(counts[category]))
+	}
+}
+
+// UpdateAPIServerDegraded records whether actuation is suspended because of the API server
+// and adds the time spent degraded since the previous update.
+func UpdateAPIServerDegraded(degraded bool, degradedDuration time.Duration) {
+	if degraded {
+		apiServerDegraded.Set(1)
+	} else {
+		apiServerDegraded.Set(0)
+	}
+	apiServerDegradedSeconds.Add(degradedDuration.Seconds())
+}
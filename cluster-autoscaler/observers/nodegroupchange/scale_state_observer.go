@@ -41,6 +41,26 @@ type NodeGroupChangeObserver interface {
 	RegisterFailedScaleDown(nodeGroup cloudprovider.NodeGroup, reason string, currentTime time.Time)
 }
 
+// FailedScaleUpErrorInfoObserver is implemented by NodeGroupChangeObservers which
+// handle the details of failed scale-ups, e.g. the exceeded cloud quota.
+type FailedScaleUpErrorInfoObserver interface {
+	// RegisterFailedScaleUpWithErrorInfo records failed scale-up for a nodegroup, with the
+	// details of the error. It's called instead of RegisterFailedScaleUp.
+	RegisterFailedScaleUpWithErrorInfo(nodeGroup cloudprovider.NodeGroup, errorInfo cloudprovider.InstanceErrorInfo, gpuResourceName, gpuType string, currentTime time.Time)
+}
+
+// RegisterFailedScaleUpWithErrorInfo records failed scale-up for a nodegroup with the observer,
+// with the details of the error if the observer handles them, or with errorInfo.ErrorCode as
+// the reason and errorInfo.ErrorMessage as the error message otherwise.
+func RegisterFailedScaleUpWithErrorInfo(observer NodeGroupChangeObserver, nodeGroup cloudprovider.NodeGroup,
+	errorInfo cloudprovider.InstanceErrorInfo, gpuResourceName, gpuType string, currentTime time.Time) {
+	if o, ok := observer.(FailedScaleUpErrorInfoObserver); ok {
+		o.RegisterFailedScaleUpWithErrorInfo(nodeGroup, errorInfo, gpuResourceName, gpuType, currentTime)
+		return
+	}
+	observer.RegisterFailedScaleUp(nodeGroup, errorInfo.ErrorCode, errorInfo.ErrorMessage, gpuResourceName, gpuType, currentTime)
+}
+
 // NodeGroupChangeObserversList is a slice of observers
 // of state of scale up/down in the cluster
 type NodeGroupChangeObserversList struct {
@@ -84,6 +104,17 @@ func (l *NodeGroupChangeObserversList) RegisterFailedScaleUp(nodeGroup cloudprov
 	}
 }
 
+// RegisterFailedScaleUpWithErrorInfo calls RegisterFailedScaleUpWithErrorInfo for each observer
+// handling the details of failed scale-ups, and RegisterFailedScaleUp for the others.
+func (l *NodeGroupChangeObserversList) RegisterFailedScaleUpWithErrorInfo(nodeGroup cloudprovider.NodeGroup,
+	errorInfo cloudprovider.InstanceErrorInfo, gpuResourceName, gpuType string, currentTime time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, observer := range l.observers {
+		RegisterFailedScaleUpWithErrorInfo(observer, nodeGroup, errorInfo, gpuResourceName, gpuType, currentTime)
+	}
+}
+
 // RegisterFailedScaleDown records failed scale-down for a nodegroup.
 func (l *NodeGroupChangeObserversList) RegisterFailedScaleDown(nodeGroup cloudprovider.NodeGroup,
 	reason string, currentTime time.Time) {
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionablecluster
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog/v2"
+)
+
+// APIServerDegradedModeProcessor suspends actuation while the API server is degraded. Listers keep serving
+// their last state during an outage, so acting on them could e.g. remove nodes whose pods were rescheduled
+// in the meantime. The health of the API server is tracked by the APIServerHealth of the context from the
+// requests the autoscaler sends anyway, so a degraded API server gets no extra load. Informers keep retrying
+// in the background, and actuation resumes once they have resynced nodes and pods.
+type APIServerDegradedModeProcessor struct {
+	next ActionableClusterProcessor
+	// degradedSince is the time the API server was found degraded, zero when not degraded.
+	degradedSince time.Time
+	// lastDegradedUpdate is the last time the degraded time was recorded.
+	lastDegradedUpdate time.Time
+}
+
+// NewAPIServerDegradedModeProcessor returns a processor suspending actuation while the API server is degraded,
+// before checking the cluster with the next processor.
+func NewAPIServerDegradedModeProcessor(next ActionableClusterProcessor) *APIServerDegradedModeProcessor {
+	return &APIServerDegradedModeProcessor{next: next}
+}
+
+// ShouldAbort aborts the loop while the API server is degraded or the informers haven't resynced yet.
+func (p *APIServerDegradedModeProcessor) ShouldAbort(context *context.AutoscalingContext, allNodes []*apiv1.Node, readyNodes []*apiv1.Node, currentTime time.Time) (bool, errors.AutoscalerError) {
+	if degraded, reason := context.APIServerHealth.Degraded(); degraded {
+		if p.degradedSince.IsZero() {
+			klog.Warningf("API server degraded, suspending actuation until it recovers: %s", reason)
+			// Scale-down timers were measured on data which may be stale by now.
+			context.ProcessorCallbacks.ResetUnneededNodes()
+		}
+		p.recordDegraded(currentTime)
+		return true, nil
+	}
+	if !p.degradedSince.IsZero() {
+		klog.Infof("API server recovered and informers resynced, resuming actuation after %v", currentTime.Sub(p.degradedSince))
+		metrics.UpdateAPIServerDegraded(false, currentTime.Sub(p.lastDegradedUpdate))
+		p.degradedSince = time.Time{}
+		p.lastDegradedUpdate = time.Time{}
+	}
+	return p.next.ShouldAbort(context, allNodes, readyNodes, currentTime)
+}
+
+func (p *APIServerDegradedModeProcessor) recordDegraded(currentTime time.Time) {
+	var degradedDuration time.Duration
+	if p.degradedSince.IsZero() {
+		p.degradedSince = currentTime
+	} else {
+		degradedDuration = currentTime.Sub(p.lastDegradedUpdate)
+	}
+	p.lastDegradedUpdate = currentTime
+	metrics.UpdateAPIServerDegraded(true, degradedDuration)
+}
+
+// CleanUp cleans up the Processor
+func (p *APIServerDegradedModeProcessor) CleanUp() {
+	p.next.CleanUp()
+}
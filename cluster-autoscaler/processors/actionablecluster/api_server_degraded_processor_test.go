@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionablecluster
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/callbacks"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAPIServerDegradedModeProcessor(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	unavailable := false
+	health := kube_util.NewAPIServerHealth()
+	rt := health.WrapTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		if unavailable {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+	watch := func(resource string) {
+		req, err := http.NewRequest(http.MethodGet, "https://api/api/v1/"+resource+"?watch=true", nil)
+		assert.NoError(t, err)
+		_, _ = rt.RoundTrip(req)
+	}
+	autoscalingContext := &context.AutoscalingContext{
+		AutoscalingOptions: config.AutoscalingOptions{ScaleUpFromZero: true},
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			APIServerHealth: health,
+		},
+		ProcessorCallbacks: callbacks.NewTestProcessorCallbacks(),
+	}
+	processor := NewAPIServerDegradedModeProcessor(NewDefaultActionableClusterProcessor())
+	now := time.Now()
+
+	abort, err := processor.ShouldAbort(autoscalingContext, []*apiv1.Node{n1}, []*apiv1.Node{n1}, now)
+	assert.NoError(t, err)
+	assert.False(t, abort, "API server available")
+
+	unavailable = true
+	watch("nodes")
+	abort, err = processor.ShouldAbort(autoscalingContext, []*apiv1.Node{n1}, []*apiv1.Node{n1}, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, abort, "API server unavailable")
+	assert.Equal(t, now.Add(time.Minute), processor.degradedSince)
+
+	// The API server is back, but the nodes informer hasn't resynced yet.
+	unavailable = false
+	watch("pods")
+	abort, err = processor.ShouldAbort(autoscalingContext, []*apiv1.Node{n1}, []*apiv1.Node{n1}, now.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, abort, "nodes informer not resynced")
+
+	watch("nodes")
+	abort, err = processor.ShouldAbort(autoscalingContext, []*apiv1.Node{n1}, []*apiv1.Node{n1}, now.Add(3*time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, abort, "informers resynced")
+	assert.True(t, processor.degradedSince.IsZero())
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package balloon
+
+import (
+	"fmt"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+)
+
+// IsBalloonPod tells if the pod is a balloon pod, i.e. a placeholder pod keeping
+// headroom in the cluster, identified by its priority class.
+func IsBalloonPod(pod *apiv1.Pod, priorityClassName string) bool {
+	return priorityClassName != "" && pod.Spec.PriorityClassName == priorityClassName
+}
+
+// PodListProcessor filters out unschedulable balloon pods beyond the headroom
+// target, so that balloon pods don't trigger scale-up by themselves beyond it.
+type PodListProcessor struct {
+	priorityClassName string
+	headroomPods      int
+}
+
+// NewPodListProcessor returns a PodListProcessor letting at most headroomPods
+// balloon pods, scheduled or not, trigger scale-up.
+func NewPodListProcessor(priorityClassName string, headroomPods int) *PodListProcessor {
+	return &PodListProcessor{
+		priorityClassName: priorityClassName,
+		headroomPods:      headroomPods,
+	}
+}
+
+// Process filters out unschedulable balloon pods which don't fit in the headroom
+// left after counting the scheduled balloon pods.
+func (p *PodListProcessor) Process(context *context.AutoscalingContext, unschedulablePods []*apiv1.Pod) ([]*apiv1.Pod, error) {
+	allPods, err := context.AllPodLister().List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list all pods while filtering balloon pods: %v", err)
+	}
+	remaining := p.headroomPods
+	for _, pod := range allPods {
+		if pod.Spec.NodeName != "" && IsBalloonPod(pod, p.priorityClassName) {
+			remaining--
+		}
+	}
+
+	var balloonPods []*apiv1.Pod
+	result := make([]*apiv1.Pod, 0, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		if IsBalloonPod(pod, p.priorityClassName) {
+			balloonPods = append(balloonPods, pod)
+		} else {
+			result = append(result, pod)
+		}
+	}
+	// Prefer the oldest balloon pods, so that the same ones keep triggering scale-up.
+	sort.SliceStable(balloonPods, func(i, j int) bool {
+		return balloonPods[i].CreationTimestamp.Before(&balloonPods[j].CreationTimestamp)
+	})
+	for _, pod := range balloonPods {
+		if remaining <= 0 {
+			klog.V(4).Infof("Balloon pod %s/%s is beyond the headroom of %d pods. Ignoring in scale up.", pod.Namespace, pod.Name, p.headroomPods)
+			continue
+		}
+		result = append(result, pod)
+		remaining--
+	}
+	return result, nil
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *PodListProcessor) CleanUp() {
+}
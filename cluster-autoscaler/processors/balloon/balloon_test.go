@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package balloon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+const testPriorityClass = "balloon"
+
+func buildTestBalloonPod(name, nodeName string, created time.Time) *apiv1.Pod {
+	pod := BuildTestPod(name, 500, 0)
+	pod.Spec.PriorityClassName = testPriorityClass
+	pod.Spec.NodeName = nodeName
+	pod.CreationTimestamp = metav1.NewTime(created)
+	return pod
+}
+
+func TestPodListProcessor(t *testing.T) {
+	now := time.Now()
+	scheduled := buildTestBalloonPod("scheduled", "node", now)
+	oldest := buildTestBalloonPod("oldest", "", now.Add(-2*time.Minute))
+	older := buildTestBalloonPod("older", "", now.Add(-time.Minute))
+	newest := buildTestBalloonPod("newest", "", now)
+	regular := BuildTestPod("regular", 500, 0)
+	unschedulable := []*apiv1.Pod{newest, regular, older, oldest}
+
+	testCases := []struct {
+		desc         string
+		headroomPods int
+		want         []*apiv1.Pod
+	}{
+		{
+			desc: "no headroom",
+			want: []*apiv1.Pod{regular},
+		},
+		{
+			desc:         "headroom taken by scheduled balloon pod",
+			headroomPods: 1,
+			want:         []*apiv1.Pod{regular},
+		},
+		{
+			desc:         "headroom left for oldest balloon pods",
+			headroomPods: 3,
+			want:         []*apiv1.Pod{regular, oldest, older},
+		},
+		{
+			desc:         "headroom left for all balloon pods",
+			headroomPods: 10,
+			want:         []*apiv1.Pod{regular, oldest, older, newest},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			podLister := kube_util.NewTestPodLister(append([]*apiv1.Pod{scheduled}, unschedulable...))
+			ctx := &context.AutoscalingContext{
+				AutoscalingKubeClients: context.AutoscalingKubeClients{
+					ListerRegistry: kube_util.NewListerRegistry(nil, nil, podLister, nil, nil, nil, nil, nil, nil),
+				},
+			}
+			got, err := NewPodListProcessor(testPriorityClass, tc.headroomPods).Process(ctx, unschedulable)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package balloon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_client "k8s.io/client-go/kubernetes"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	acontext "k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+)
+
+const (
+	// DeploymentName is the name of the balloon pods deployment managed by the HeadroomController.
+	DeploymentName = "cluster-autoscaler-balloon"
+	// PodImage is the image of balloon pods managed by the HeadroomController.
+	PodImage = "registry.k8s.io/pause:3.9"
+)
+
+// HeadroomController wraps an AutoscalingStatusProcessor and keeps a deployment of
+// balloon pods with the configured priority class, number of replicas and
+// requests up to date.
+type HeadroomController struct {
+	status.AutoscalingStatusProcessor
+	namespace         string
+	priorityClassName string
+	replicas          int32
+	requests          apiv1.ResourceList
+}
+
+// NewHeadroomController returns a HeadroomController managing replicas balloon pods
+// requesting the given amounts of CPU and memory. Empty amounts aren't requested.
+func NewHeadroomController(processor status.AutoscalingStatusProcessor, namespace, priorityClassName string, replicas int, cpu, memory string) (*HeadroomController, error) {
+	requests := apiv1.ResourceList{}
+	for name, value := range map[apiv1.ResourceName]string{apiv1.ResourceCPU: cpu, apiv1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid balloon pod %s request %q: %v", name, value, err)
+		}
+		requests[name] = quantity
+	}
+	return &HeadroomController{
+		AutoscalingStatusProcessor: processor,
+		namespace:                  namespace,
+		priorityClassName:          priorityClassName,
+		replicas:                   int32(replicas),
+		requests:                   requests,
+	}, nil
+}
+
+// Process runs the wrapped processor and reconciles the balloon pods deployment.
+func (c *HeadroomController) Process(context *acontext.AutoscalingContext, csr *clusterstate.ClusterStateRegistry, now time.Time) error {
+	err := c.AutoscalingStatusProcessor.Process(context, csr, now)
+	if reconcileErr := c.reconcile(context.ClientSet); reconcileErr != nil {
+		klog.Warningf("Failed to reconcile balloon pods deployment %s/%s: %v", c.namespace, DeploymentName, reconcileErr)
+	}
+	return err
+}
+
+func (c *HeadroomController) reconcile(client kube_client.Interface) error {
+	deployments := client.AppsV1().Deployments(c.namespace)
+	current, err := deployments.Get(context.TODO(), DeploymentName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		klog.V(1).Infof("Creating balloon pods deployment %s/%s with %d replicas", c.namespace, DeploymentName, c.replicas)
+		_, err = deployments.Create(context.TODO(), c.deployment(), metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if c.upToDate(current) {
+		return nil
+	}
+	klog.V(1).Infof("Updating balloon pods deployment %s/%s to %d replicas", c.namespace, DeploymentName, c.replicas)
+	desired := c.deployment()
+	current.Spec.Replicas = desired.Spec.Replicas
+	current.Spec.Template = desired.Spec.Template
+	_, err = deployments.Update(context.TODO(), current, metav1.UpdateOptions{})
+	return err
+}
+
+// upToDate tells if the deployment has the desired replicas, priority class and requests.
+func (c *HeadroomController) upToDate(deployment *appsv1.Deployment) bool {
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != c.replicas {
+		return false
+	}
+	podSpec := deployment.Spec.Template.Spec
+	if podSpec.PriorityClassName != c.priorityClassName || len(podSpec.Containers) != 1 {
+		return false
+	}
+	requests := podSpec.Containers[0].Resources.Requests
+	if len(requests) != len(c.requests) {
+		return false
+	}
+	for name, quantity := range c.requests {
+		if current, found := requests[name]; !found || current.Cmp(quantity) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *HeadroomController) deployment() *appsv1.Deployment {
+	labels := map[string]string{"app": DeploymentName}
+	replicas := c.replicas
+	terminationGracePeriod := int64(0)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DeploymentName,
+			Namespace: c.namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: apiv1.PodSpec{
+					PriorityClassName:             c.priorityClassName,
+					TerminationGracePeriodSeconds: &terminationGracePeriod,
+					Containers: []apiv1.Container{{
+						Name:      "balloon",
+						Image:     PodImage,
+						Resources: apiv1.ResourceRequirements{Requests: c.requests.DeepCopy()},
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package balloon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	acontext "k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+)
+
+func TestHeadroomController(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := &acontext.AutoscalingContext{
+		AutoscalingKubeClients: acontext.AutoscalingKubeClients{ClientSet: client},
+	}
+	getDeployment := func() (int32, apiv1.PodSpec) {
+		deployment, err := client.AppsV1().Deployments("kube-system").Get(context.TODO(), DeploymentName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return *deployment.Spec.Replicas, deployment.Spec.Template.Spec
+	}
+
+	controller, err := NewHeadroomController(&status.NoOpAutoscalingStatusProcessor{}, "kube-system", testPriorityClass, 2, "500m", "")
+	assert.NoError(t, err)
+	assert.NoError(t, controller.Process(ctx, nil, time.Now()))
+	replicas, podSpec := getDeployment()
+	assert.Equal(t, int32(2), replicas)
+	assert.Equal(t, testPriorityClass, podSpec.PriorityClassName)
+	assert.Equal(t, apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("500m")}, podSpec.Containers[0].Resources.Requests)
+
+	// The deployment is only updated when it differs.
+	client.ClearActions()
+	assert.NoError(t, controller.Process(ctx, nil, time.Now()))
+	for _, action := range client.Actions() {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+
+	controller, err = NewHeadroomController(&status.NoOpAutoscalingStatusProcessor{}, "kube-system", testPriorityClass, 3, "500m", "1Gi")
+	assert.NoError(t, err)
+	assert.NoError(t, controller.Process(ctx, nil, time.Now()))
+	replicas, podSpec = getDeployment()
+	assert.Equal(t, int32(3), replicas)
+	assert.Equal(t, resource.MustParse("1Gi"), podSpec.Containers[0].Resources.Requests[apiv1.ResourceMemory])
+
+	_, err = NewHeadroomController(&status.NoOpAutoscalingStatusProcessor{}, "kube-system", testPriorityClass, 1, "lots", "")
+	assert.Error(t, err)
+}
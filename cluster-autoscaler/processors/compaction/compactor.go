@@ -0,0 +1,305 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kube_client "k8s.io/client-go/kubernetes"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scaledowncandidates"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	pod_util "k8s.io/autoscaler/cluster-autoscaler/utils/pod"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
+)
+
+const (
+	// UnneededSinceAnnotation is the node annotation under which the time since which
+	// the node is unneeded is published, so that other components can coordinate with scale-down.
+	UnneededSinceAnnotation = "cluster-autoscaler.kubernetes.io/unneeded-since"
+	// CompactionStartedAnnotation is the node annotation under which the time the pods
+	// started to be evicted from the node is published, until the node is empty.
+	CompactionStartedAnnotation = "cluster-autoscaler.kubernetes.io/compaction-started"
+)
+
+// Compactor publishes the set of unneeded nodes as node annotations and proactively
+// evicts the few remaining pods from nearly empty unneeded nodes, so that they
+// become empty and are removed sooner. Before the pods are evicted, the node is
+// tainted with the DeletionCandidate taint, so that they aren't scheduled back.
+// Pods are only evicted while scale down isn't in cooldown, since the nodes won't
+// be removed before.
+type Compactor struct {
+	client        kube_client.Interface
+	podLister     kube_util.PodLister
+	maxNodes      int
+	maxPods       int
+	delay         time.Duration
+	unneededSince map[string]time.Time
+	compactions   map[string]*compaction
+	// unneeded are the unneeded nodes of the current loop.
+	unneeded []*apiv1.Node
+}
+
+// compaction is the state of the compaction of a node.
+type compaction struct {
+	// addedTaint is true if the node was tainted for the compaction, rather than
+	// before it, so the taint has to be removed if the compaction is aborted.
+	addedTaint bool
+	// done is true once the node is empty and the annotation was removed.
+	done bool
+}
+
+var _ scaledowncandidates.CooldownObserver = &Compactor{}
+
+// NewCompactor returns a Compactor. In each loop it evicts pods from at most maxNodes
+// nodes which have been unneeded for at least delay and run at most maxPods pods
+// that would have to be moved. With maxNodes of 0 unneeded nodes are only published.
+func NewCompactor(client kube_client.Interface, podLister kube_util.PodLister, maxNodes, maxPods int, delay time.Duration) *Compactor {
+	return &Compactor{
+		client:        client,
+		podLister:     podLister,
+		maxNodes:      maxNodes,
+		maxPods:       maxPods,
+		delay:         delay,
+		unneededSince: make(map[string]time.Time),
+		compactions:   make(map[string]*compaction),
+	}
+}
+
+// UpdateScaleDownCandidates publishes the unneeded nodes.
+func (c *Compactor) UpdateScaleDownCandidates(nodes []*apiv1.Node, now time.Time) {
+	c.publish(nodes, now)
+	c.abortCompactions(nodes)
+	c.unneeded = nodes
+}
+
+// UpdateScaleDownInCooldown compacts some of the unneeded nodes, unless scale down is in cooldown.
+func (c *Compactor) UpdateScaleDownInCooldown(inCooldown bool, now time.Time) {
+	if inCooldown {
+		klog.V(4).Infof("Scale down is in cooldown, skipping compaction of %d unneeded nodes", len(c.unneeded))
+		return
+	}
+	if c.maxNodes > 0 {
+		c.compact(c.unneeded, now)
+	}
+}
+
+// abortCompactions removes the taint and the annotation added for the compaction
+// of nodes which aren't unneeded anymore, e.g. because they are needed again.
+// Nodes which were deleted are simply forgotten.
+func (c *Compactor) abortCompactions(nodes []*apiv1.Node) {
+	unneeded := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		unneeded[node.Name] = true
+	}
+	for name, state := range c.compactions {
+		if unneeded[name] {
+			continue
+		}
+		// The compaction is forgotten only once it's cleaned up, otherwise it's retried in the next loop.
+		node, err := c.client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if kube_errors.IsNotFound(err) {
+			delete(c.compactions, name)
+			continue
+		}
+		if err != nil {
+			klog.Warningf("Failed to get node %s to abort its compaction: %v", name, err)
+			continue
+		}
+		if state.addedTaint {
+			if _, err := taints.CleanDeletionCandidate(node, c.client); err != nil {
+				klog.Warningf("Failed to remove compaction taint from node %s: %v", name, err)
+				continue
+			}
+		}
+		if _, found := node.Annotations[CompactionStartedAnnotation]; found {
+			if err := c.patchAnnotation(name, CompactionStartedAnnotation, nil); err != nil {
+				continue
+			}
+		}
+		delete(c.compactions, name)
+		klog.V(2).Infof("Compaction of node %s aborted, the node isn't unneeded anymore", name)
+	}
+}
+
+func (c *Compactor) publish(nodes []*apiv1.Node, now time.Time) {
+	unneededSince := make(map[string]time.Time, len(nodes))
+	for _, node := range nodes {
+		since, found := c.unneededSince[node.Name]
+		if !found {
+			since = now
+			// Keep the time published before a restart.
+			if published, err := time.Parse(time.RFC3339, node.Annotations[UnneededSinceAnnotation]); err == nil && published.Before(now) {
+				since = published
+			}
+		}
+		unneededSince[node.Name] = since
+		value := since.UTC().Format(time.RFC3339)
+		if node.Annotations[UnneededSinceAnnotation] != value {
+			c.patchAnnotation(node.Name, UnneededSinceAnnotation, &value)
+		}
+	}
+	for name := range c.unneededSince {
+		if _, found := unneededSince[name]; !found {
+			c.patchAnnotation(name, UnneededSinceAnnotation, nil)
+		}
+	}
+	c.unneededSince = unneededSince
+}
+
+// patchAnnotation sets the annotation of the node, or removes it if value is nil.
+func (c *Compactor) patchAnnotation(nodeName, key string, value *string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{key: value},
+		},
+	})
+	if err != nil {
+		klog.Errorf("Failed to build annotation patch for node %s: %v", nodeName, err)
+		return err
+	}
+	if _, err := c.client.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.Warningf("Failed to update %s annotation of node %s: %v", key, nodeName, err)
+		return err
+	}
+	return nil
+}
+
+func (c *Compactor) compact(nodes []*apiv1.Node, now time.Time) {
+	allPods, err := c.podLister.List()
+	if err != nil {
+		klog.Errorf("Failed to list pods, skipping compaction: %v", err)
+		return
+	}
+	podsByNode := make(map[string][]*apiv1.Pod)
+	for _, pod := range allPods {
+		if pod.Spec.NodeName != "" {
+			podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+		}
+	}
+
+	candidates := make([]*apiv1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if now.Sub(c.unneededSince[node.Name]) >= c.delay {
+			candidates = append(candidates, node)
+		}
+	}
+	// Compact nodes unneeded for the longest time first.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return c.unneededSince[candidates[i].Name].Before(c.unneededSince[candidates[j].Name])
+	})
+
+	compacted := 0
+	for _, node := range candidates {
+		pods, ok := c.podsToMove(podsByNode[node.Name])
+		if state, found := c.compactions[node.Name]; found && ok && len(pods) == 0 && !state.done {
+			// The node is empty, it's left to scale-down. The taint is kept until it's
+			// deleted, or removed if the node becomes needed again in the meantime.
+			if err := c.patchAnnotation(node.Name, CompactionStartedAnnotation, nil); err == nil {
+				state.done = true
+				klog.V(2).Infof("Compaction of node %s completed", node.Name)
+			}
+			continue
+		}
+		if compacted >= c.maxNodes || !ok || len(pods) == 0 {
+			continue
+		}
+		if err := c.startCompaction(node, now); err != nil {
+			klog.Warningf("Not compacting unneeded node %s: %v", node.Name, err)
+			continue
+		}
+		klog.V(1).Infof("Compacting unneeded node %s, evicting %d pods", node.Name, len(pods))
+		for _, pod := range pods {
+			c.evict(pod)
+		}
+		compacted++
+	}
+}
+
+// startCompaction taints the node, so that the evicted pods aren't scheduled back on it,
+// and publishes the time the compaction started. The DeletionCandidate taint is used with
+// the NoSchedule effect, since unlike the ToBeDeleted taint it doesn't prevent scale-down.
+func (c *Compactor) startCompaction(node *apiv1.Node, now time.Time) error {
+	state, found := c.compactions[node.Name]
+	if !found {
+		// A node annotated with the compaction start time was tainted for
+		// the compaction before a restart.
+		_, restarted := node.Annotations[CompactionStartedAnnotation]
+		state = &compaction{addedTaint: restarted}
+		if !taints.HasDeletionCandidateTaint(node) {
+			taint := apiv1.Taint{
+				Key:    taints.DeletionCandidateTaint,
+				Value:  fmt.Sprint(now.Unix()),
+				Effect: apiv1.TaintEffectNoSchedule,
+			}
+			if err := taints.AddTaints(node, c.client, []apiv1.Taint{taint}, false); err != nil {
+				return err
+			}
+			state.addedTaint = true
+		}
+		c.compactions[node.Name] = state
+	}
+	if _, found := node.Annotations[CompactionStartedAnnotation]; !found || state.done {
+		value := now.UTC().Format(time.RFC3339)
+		if err := c.patchAnnotation(node.Name, CompactionStartedAnnotation, &value); err != nil {
+			return err
+		}
+	}
+	state.done = false
+	return nil
+}
+
+// podsToMove returns the pods that have to be moved for the node to be empty, and
+// whether the node is nearly empty, i.e. runs at most maxPods such pods, all of them
+// managed by a controller which will recreate them elsewhere.
+func (c *Compactor) podsToMove(pods []*apiv1.Pod) ([]*apiv1.Pod, bool) {
+	var toMove []*apiv1.Pod
+	for _, pod := range pods {
+		if pod_util.IsMirrorPod(pod) || pod_util.IsDaemonSetPod(pod) || pod.DeletionTimestamp != nil ||
+			pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+			continue
+		}
+		if metav1.GetControllerOf(pod) == nil {
+			return nil, false
+		}
+		toMove = append(toMove, pod)
+	}
+	return toMove, len(toMove) <= c.maxPods
+}
+
+// evict evicts the pod through the eviction API, so that PodDisruptionBudgets are respected.
+func (c *Compactor) evict(pod *apiv1.Pod) {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+		},
+	}
+	if err := c.client.CoreV1().Pods(pod.Namespace).Evict(context.TODO(), eviction); err != nil {
+		klog.Warningf("Failed to evict pod %s/%s for compaction: %v", pod.Namespace, pod.Name, err)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compaction
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scaledowncandidates"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestPublishUnneededNodes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	restarted := now.Add(-time.Hour)
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n2.Annotations = map[string]string{UnneededSinceAnnotation: restarted.Format(time.RFC3339)}
+	client := fake.NewSimpleClientset(n1, n2)
+	c := NewCompactor(client, kube_util.NewTestPodLister(nil), 0, 2, time.Minute)
+
+	c.UpdateScaleDownCandidates([]*apiv1.Node{n1, n2}, now)
+	assert.Equal(t, now.Format(time.RFC3339), annotation(t, client, "n1"))
+	assert.Equal(t, restarted.Format(time.RFC3339), annotation(t, client, "n2"))
+
+	c.UpdateScaleDownCandidates([]*apiv1.Node{n2}, now.Add(time.Minute))
+	assert.Equal(t, "", annotation(t, client, "n1"))
+	assert.Equal(t, restarted.Format(time.RFC3339), annotation(t, client, "n2"))
+}
+
+func TestCompactNearlyEmptyNodes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rsPod := func(name, node string) *apiv1.Pod {
+		pod := BuildTestPod(name, 100, 100)
+		pod.Spec.NodeName = node
+		pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "apps/v1", "rs-uid")
+		return pod
+	}
+	dsPod := rsPod("ds", "old")
+	dsPod.OwnerReferences = GenerateOwnerReferences("ds", "DaemonSet", "apps/v1", "ds-uid")
+	bare := BuildTestPod("bare", 100, 100)
+	bare.Spec.NodeName = "bare"
+
+	cases := map[string]struct {
+		maxNodes    int
+		delay       time.Duration
+		inCooldown  bool
+		wantEvicted []string
+	}{
+		"oldest nearly empty node is compacted": {
+			maxNodes:    1,
+			delay:       time.Minute,
+			wantEvicted: []string{"old-1", "old-2"},
+		},
+		"several nodes are compacted": {
+			maxNodes:    2,
+			delay:       time.Minute,
+			wantEvicted: []string{"old-1", "old-2", "new-1"},
+		},
+		"nodes unneeded for too short are skipped": {
+			maxNodes:    2,
+			delay:       30 * time.Minute,
+			wantEvicted: []string{"old-1", "old-2"},
+		},
+		"nothing is compacted while scale down is in cooldown": {
+			maxNodes:   2,
+			delay:      time.Minute,
+			inCooldown: true,
+		},
+	}
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			pods := []*apiv1.Pod{
+				rsPod("old-1", "old"), rsPod("old-2", "old"), dsPod,
+				rsPod("new-1", "new"),
+				rsPod("full-1", "full"), rsPod("full-2", "full"), rsPod("full-3", "full"),
+				bare,
+			}
+			podNodes := map[string]string{}
+			for _, pod := range pods {
+				podNodes[pod.Name] = pod.Spec.NodeName
+			}
+			nodes := []*apiv1.Node{
+				BuildTestNode("full", 1000, 1000),
+				BuildTestNode("bare", 1000, 1000),
+				BuildTestNode("new", 1000, 1000),
+				BuildTestNode("old", 1000, 1000),
+			}
+			for _, node := range nodes[:3] {
+				node.Annotations = map[string]string{UnneededSinceAnnotation: now.Add(-2 * time.Hour).Format(time.RFC3339)}
+			}
+			nodes[2].Annotations[UnneededSinceAnnotation] = now.Add(-10 * time.Minute).Format(time.RFC3339)
+			nodes[3].Annotations = map[string]string{UnneededSinceAnnotation: now.Add(-time.Hour).Format(time.RFC3339)}
+
+			client := fake.NewSimpleClientset(nodes[0], nodes[1], nodes[2], nodes[3])
+			var evicted []string
+			client.Fake.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				eviction := action.(core.CreateAction).GetObject().(*policyv1beta1.Eviction)
+				// Pods are only evicted from tainted nodes.
+				node, err := client.Tracker().Get(apiv1.SchemeGroupVersion.WithResource("nodes"), "", podNodes[eviction.Name])
+				assert.NoError(t, err)
+				assert.True(t, taints.HasDeletionCandidateTaint(node.(*apiv1.Node)))
+				evicted = append(evicted, eviction.Name)
+				return true, nil, nil
+			})
+			c := NewCompactor(client, kube_util.NewTestPodLister(pods), tc.maxNodes, 2, tc.delay)
+			observers := scaledowncandidates.NewObserversList()
+			observers.Register(c)
+			observers.Update(nodes, now)
+			assert.Empty(t, evicted)
+			observers.UpdateScaleDownInCooldown(tc.inCooldown, now)
+			assert.Equal(t, tc.wantEvicted, evicted)
+			if tc.inCooldown {
+				assert.False(t, taints.HasDeletionCandidateTaint(getNode(t, client, "old")))
+			}
+		})
+	}
+}
+
+func TestCompactionCleanUp(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := BuildTestPod("p", 100, 100)
+	pod.Spec.NodeName = "n"
+	pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "apps/v1", "rs-uid")
+
+	cases := map[string]struct {
+		// podsAfterEviction are the pods running on the node in the second loop.
+		podsAfterEviction []*apiv1.Pod
+		// unneeded tells whether the node is still unneeded in the second loop.
+		unneeded        bool
+		wantTaint       bool
+		wantStartedTime bool
+	}{
+		"compaction in progress": {
+			podsAfterEviction: []*apiv1.Pod{pod},
+			unneeded:          true,
+			wantTaint:         true,
+			wantStartedTime:   true,
+		},
+		"compaction completed": {
+			unneeded:  true,
+			wantTaint: true,
+		},
+		"compaction aborted": {
+			podsAfterEviction: []*apiv1.Pod{pod},
+		},
+	}
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			node := BuildTestNode("n", 1000, 1000)
+			client := fake.NewSimpleClientset(node)
+			client.Fake.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				return true, nil, nil
+			})
+			podLister := kube_util.NewTestPodLister([]*apiv1.Pod{pod})
+			c := NewCompactor(client, podLister, 1, 2, 0)
+
+			c.UpdateScaleDownCandidates([]*apiv1.Node{node}, now)
+			c.UpdateScaleDownInCooldown(false, now)
+			node = getNode(t, client, "n")
+			assert.True(t, taints.HasDeletionCandidateTaint(node))
+			assert.Equal(t, now.Format(time.RFC3339), node.Annotations[CompactionStartedAnnotation])
+
+			c.podLister = kube_util.NewTestPodLister(tc.podsAfterEviction)
+			var unneeded []*apiv1.Node
+			if tc.unneeded {
+				unneeded = []*apiv1.Node{node}
+			}
+			c.UpdateScaleDownCandidates(unneeded, now.Add(time.Minute))
+			c.UpdateScaleDownInCooldown(false, now.Add(time.Minute))
+			node = getNode(t, client, "n")
+			assert.Equal(t, tc.wantTaint, taints.HasDeletionCandidateTaint(node))
+			_, found := node.Annotations[CompactionStartedAnnotation]
+			assert.Equal(t, tc.wantStartedTime, found)
+		})
+	}
+}
+
+func getNode(t *testing.T, client *fake.Clientset, name string) *apiv1.Node {
+	node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return node
+}
+
+func annotation(t *testing.T, client *fake.Clientset, name string) string {
+	return getNode(t, client, name).Annotations[UnneededSinceAnnotation]
+}
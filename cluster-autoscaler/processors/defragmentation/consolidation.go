@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defragmentation
+
+import (
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	pod_util "k8s.io/autoscaler/cluster-autoscaler/utils/pod"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// capacity is the amount of CPU (in millicores), memory (in bytes) and pods.
+type capacity struct {
+	milliCPU int64
+	memory   int64
+	pods     int64
+}
+
+func (c capacity) fits(request capacity) bool {
+	return request.milliCPU <= c.milliCPU && request.memory <= c.memory && request.pods <= c.pods
+}
+
+func (c *capacity) add(request capacity) {
+	c.milliCPU += request.milliCPU
+	c.memory += request.memory
+	c.pods += request.pods
+}
+
+func (c *capacity) sub(request capacity) {
+	c.milliCPU -= request.milliCPU
+	c.memory -= request.memory
+	c.pods -= request.pods
+}
+
+type packedNode struct {
+	free      capacity
+	requested capacity
+	movable   []capacity
+	removable bool
+}
+
+// FreeableNodes returns how many of the nodes could be freed by moving their pods onto
+// the remaining nodes. Nodes are emptied greedily, least requested first, and their pods
+// are placed first fit, largest first. Only CPU, memory and pod count are taken into
+// account, scheduling constraints are not. DaemonSet and mirror pods don't need to be
+// moved, and nodes running pods without a controller can't be freed.
+func FreeableNodes(nodeInfos []*schedulerframework.NodeInfo) int {
+	nodes := make([]*packedNode, 0, len(nodeInfos))
+	for _, nodeInfo := range nodeInfos {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		nodes = append(nodes, newPackedNode(nodeInfo))
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].requested.milliCPU+nodes[i].requested.memory/(1024*1024) <
+			nodes[j].requested.milliCPU+nodes[j].requested.memory/(1024*1024)
+	})
+
+	freed := make(map[*packedNode]bool)
+	for _, candidate := range nodes {
+		if !candidate.removable {
+			continue
+		}
+		if placement, ok := repack(candidate, nodes, freed); ok {
+			// Pods moved to a node have to be moved again if it is freed later.
+			for target, pods := range placement {
+				for _, pod := range pods {
+					target.free.sub(pod)
+					target.requested.add(pod)
+				}
+				target.movable = append(target.movable, pods...)
+			}
+			freed[candidate] = true
+		}
+	}
+	return len(freed)
+}
+
+// repack places the movable pods of the candidate on nodes which aren't freed, and
+// returns the pods placed on each node if all of them fit.
+func repack(candidate *packedNode, nodes []*packedNode, freed map[*packedNode]bool) (map[*packedNode][]capacity, bool) {
+	pods := append([]capacity{}, candidate.movable...)
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].milliCPU > pods[j].milliCPU || (pods[i].milliCPU == pods[j].milliCPU && pods[i].memory > pods[j].memory)
+	})
+	free := make(map[*packedNode]capacity)
+	placement := make(map[*packedNode][]capacity)
+	for _, pod := range pods {
+		placed := false
+		for _, target := range nodes {
+			if target == candidate || freed[target] {
+				continue
+			}
+			targetFree, found := free[target]
+			if !found {
+				targetFree = target.free
+			}
+			if !targetFree.fits(pod) {
+				continue
+			}
+			targetFree.sub(pod)
+			free[target] = targetFree
+			placement[target] = append(placement[target], pod)
+			placed = true
+			break
+		}
+		if !placed {
+			return nil, false
+		}
+	}
+	return placement, true
+}
+
+func newPackedNode(nodeInfo *schedulerframework.NodeInfo) *packedNode {
+	allocatable := nodeInfo.Node().Status.Allocatable
+	node := &packedNode{
+		free: capacity{
+			milliCPU: allocatable.Cpu().MilliValue(),
+			memory:   allocatable.Memory().Value(),
+			pods:     allocatable.Pods().Value(),
+		},
+		removable: true,
+	}
+	for _, podInfo := range nodeInfo.Pods {
+		request := podRequest(podInfo.Pod)
+		node.free.sub(request)
+		if pod_util.IsDaemonSetPod(podInfo.Pod) || pod_util.IsMirrorPod(podInfo.Pod) {
+			continue
+		}
+		if metav1.GetControllerOf(podInfo.Pod) == nil {
+			node.removable = false
+		}
+		node.requested.add(request)
+		node.movable = append(node.movable, request)
+	}
+	return node
+}
+
+func podRequest(pod *apiv1.Pod) capacity {
+	requests := resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{})
+	return capacity{
+		milliCPU: requests.Cpu().MilliValue(),
+		memory:   requests.Memory().Value(),
+		pods:     1,
+	}
+}
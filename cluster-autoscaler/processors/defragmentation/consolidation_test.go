@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defragmentation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestFreeableNodes(t *testing.T) {
+	rsPod := func(name string, cpu int64) *apiv1.Pod {
+		pod := BuildTestPod(name, cpu, 0)
+		pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "apps/v1", "rs-uid")
+		return pod
+	}
+	dsPod := func(name string) *apiv1.Pod {
+		pod := BuildTestPod(name, 500, 0)
+		pod.OwnerReferences = GenerateOwnerReferences("ds", "DaemonSet", "apps/v1", "ds-uid")
+		return pod
+	}
+	nodeInfo := func(name string, pods ...*apiv1.Pod) *schedulerframework.NodeInfo {
+		ni := schedulerframework.NewNodeInfo(pods...)
+		ni.SetNode(BuildTestNode(name, 4000, 1000))
+		return ni
+	}
+
+	cases := map[string]struct {
+		nodeInfos []*schedulerframework.NodeInfo
+		want      int
+	}{
+		"no nodes": {
+			want: 0,
+		},
+		"pods of nearly empty nodes repacked": {
+			nodeInfos: []*schedulerframework.NodeInfo{
+				nodeInfo("n1", rsPod("p1", 1000), dsPod("d1")),
+				nodeInfo("n2", rsPod("p2", 1000), dsPod("d2")),
+				nodeInfo("n3", rsPod("p3", 1000), dsPod("d3")),
+			},
+			want: 2,
+		},
+		"full nodes can't be freed": {
+			nodeInfos: []*schedulerframework.NodeInfo{
+				nodeInfo("n1", rsPod("p1", 3000)),
+				nodeInfo("n2", rsPod("p2", 3000)),
+			},
+			want: 0,
+		},
+		"pods moved to a freed node are moved again": {
+			nodeInfos: []*schedulerframework.NodeInfo{
+				nodeInfo("n1", rsPod("p1", 500)),
+				nodeInfo("n2", rsPod("p2", 600)),
+				nodeInfo("n3", rsPod("p3", 2500)),
+			},
+			want: 2,
+		},
+		"nodes with pods without controller can't be freed": {
+			nodeInfos: []*schedulerframework.NodeInfo{
+				nodeInfo("n1", BuildTestPod("bare", 100, 0)),
+				nodeInfo("n2", rsPod("p2", 1000)),
+			},
+			want: 1,
+		},
+	}
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.want, FreeableNodes(tc.nodeInfos))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defragmentation
+
+import (
+	"reflect"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// ReportProcessor wraps an AutoscalingStatusProcessor and periodically reports how many
+// nodes of each node group could be freed by repacking pods, in metrics and in the
+// status ConfigMap. It doesn't act on the report.
+type ReportProcessor struct {
+	status.AutoscalingStatusProcessor
+	interval   time.Duration
+	lastReport time.Time
+}
+
+// NewReportProcessor returns a ReportProcessor computing the report at most once per interval.
+func NewReportProcessor(processor status.AutoscalingStatusProcessor, interval time.Duration) *ReportProcessor {
+	return &ReportProcessor{
+		AutoscalingStatusProcessor: processor,
+		interval:                   interval,
+	}
+}
+
+// Process runs the wrapped processor and updates the defragmentation report if it is due.
+func (p *ReportProcessor) Process(context *context.AutoscalingContext, csr *clusterstate.ClusterStateRegistry, now time.Time) error {
+	err := p.AutoscalingStatusProcessor.Process(context, csr, now)
+	if now.Sub(p.lastReport) < p.interval || context.ClusterSnapshot == nil {
+		return err
+	}
+	nodeInfos, listErr := context.ClusterSnapshot.NodeInfos().List()
+	if listErr != nil {
+		klog.Errorf("Failed to list nodes, skipping defragmentation report: %v", listErr)
+		return err
+	}
+
+	nodeInfosByGroup := make(map[string][]*schedulerframework.NodeInfo)
+	for _, nodeInfo := range nodeInfos {
+		nodeGroup, ngErr := context.CloudProvider.NodeGroupForNode(nodeInfo.Node())
+		if ngErr != nil || nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		nodeInfosByGroup[nodeGroup.Id()] = append(nodeInfosByGroup[nodeGroup.Id()], nodeInfo)
+	}
+	freeableNodes := make(map[string]int)
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		freeable := FreeableNodes(nodeInfosByGroup[nodeGroup.Id()])
+		freeableNodes[nodeGroup.Id()] = freeable
+		metrics.UpdateNodeGroupFreeableNodes(nodeGroup.Id(), freeable)
+	}
+	csr.UpdateFreeableNodes(freeableNodes, now)
+	p.lastReport = now
+	return err
+}
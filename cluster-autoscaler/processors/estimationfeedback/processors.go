@@ -0,0 +1,227 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimationfeedback
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	pod_util "k8s.io/autoscaler/cluster-autoscaler/utils/pod"
+	klog "k8s.io/klog/v2"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// ScaleUpStatusProcessor records successful scale-ups in the tracker.
+type ScaleUpStatusProcessor struct {
+	tracker *Tracker
+	now     func() time.Time
+}
+
+// NewScaleUpStatusProcessor returns a ScaleUpStatusProcessor recording scale-ups in the tracker.
+func NewScaleUpStatusProcessor(tracker *Tracker) *ScaleUpStatusProcessor {
+	return &ScaleUpStatusProcessor{tracker: tracker, now: time.Now}
+}
+
+// Process records the node groups scaled up by a successful scale-up, with the pods which triggered
+// it. Scale-ups to the max size of a node group aren't recorded, as they may have added fewer nodes
+// than estimated.
+func (p *ScaleUpStatusProcessor) Process(context *context.AutoscalingContext, scaleUpStatus *status.ScaleUpStatus) {
+	if !scaleUpStatus.WasSuccessful() {
+		return
+	}
+	now := p.now()
+	for _, info := range scaleUpStatus.ScaleUpInfos {
+		delta := info.NewSize - info.CurrentSize
+		if delta <= 0 || info.NewSize >= info.MaxSize {
+			continue
+		}
+		p.tracker.RecordScaleUp(info.Group.Id(), delta, scaleUpStatus.PodsTriggeredScaleUp, now)
+	}
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *ScaleUpStatusProcessor) CleanUp() {
+}
+
+// AutoscalingStatusProcessor wraps an AutoscalingStatusProcessor and evaluates the settled scale-ups
+// of the tracker, binpacking the pods each of them was for onto the nodes they landed on.
+type AutoscalingStatusProcessor struct {
+	status.AutoscalingStatusProcessor
+	tracker *Tracker
+}
+
+// NewAutoscalingStatusProcessor returns an AutoscalingStatusProcessor evaluating scale-ups of the tracker.
+func NewAutoscalingStatusProcessor(processor status.AutoscalingStatusProcessor, tracker *Tracker) *AutoscalingStatusProcessor {
+	return &AutoscalingStatusProcessor{
+		AutoscalingStatusProcessor: processor,
+		tracker:                    tracker,
+	}
+}
+
+// Process runs the wrapped processor and evaluates the scale-ups which settled. The nodes of a scale-up
+// are the first nodes of the node group created since it, up to the number of nodes it requested. The
+// nodes it needed are the nodes the pods it was for fit on when binpacked, wherever they landed on nodes
+// of the node group created since the scale-up, including nodes of later scale-ups.
+func (p *AutoscalingStatusProcessor) Process(context *context.AutoscalingContext, csr *clusterstate.ClusterStateRegistry, now time.Time) error {
+	err := p.AutoscalingStatusProcessor.Process(context, csr, now)
+	nodeGroupIds := make(map[string]bool)
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		nodeGroupIds[nodeGroup.Id()] = true
+	}
+	p.tracker.Forget(nodeGroupIds)
+
+	settled := p.tracker.SettledScaleUps(now)
+	if len(settled) == 0 || context.ClusterSnapshot == nil {
+		return err
+	}
+	nodeInfos, listErr := context.ClusterSnapshot.NodeInfos().List()
+	if listErr != nil {
+		klog.Errorf("Failed to list nodes, skipping evaluation of scale-ups: %v", listErr)
+		return err
+	}
+
+	createdNodes := make(map[string][]*schedulerframework.NodeInfo)
+	for _, nodeInfo := range nodeInfos {
+		node := nodeInfo.Node()
+		if node == nil || node.CreationTimestamp.IsZero() {
+			continue
+		}
+		nodeGroup, ngErr := context.CloudProvider.NodeGroupForNode(node)
+		if ngErr != nil || nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		scaleUp, found := settled[nodeGroup.Id()]
+		if !found || node.CreationTimestamp.Time.Before(scaleUp.Time) {
+			continue
+		}
+		createdNodes[nodeGroup.Id()] = append(createdNodes[nodeGroup.Id()], nodeInfo)
+	}
+	for nodeGroupId, scaleUp := range settled {
+		nodes := createdNodes[nodeGroupId]
+		var pods []*apiv1.Pod
+		for _, nodeInfo := range nodes {
+			for _, podInfo := range nodeInfo.Pods {
+				if scaleUp.Pods[podInfo.Pod.UID] {
+					pods = append(pods, podInfo.Pod)
+				}
+			}
+		}
+		created := len(nodes)
+		if created > scaleUp.Requested {
+			created = scaleUp.Requested
+		}
+		needed := 0
+		if len(pods) > 0 {
+			needed = binpackedNodeCount(pods, workloadCapacity(firstCreated(nodes)))
+		}
+		klog.V(4).Infof("Scale-up of node group %s settled: %d of %d nodes created, %d of its pods needing %d nodes", nodeGroupId, created, scaleUp.Requested, len(pods), needed)
+		p.tracker.Evaluate(nodeGroupId, created, needed)
+	}
+	return err
+}
+
+// firstCreated returns the node created first.
+func firstCreated(nodeInfos []*schedulerframework.NodeInfo) *schedulerframework.NodeInfo {
+	first := nodeInfos[0]
+	for _, nodeInfo := range nodeInfos[1:] {
+		if nodeInfo.Node().CreationTimestamp.Before(&first.Node().CreationTimestamp) {
+			first = nodeInfo
+		}
+	}
+	return first
+}
+
+// capacity holds the resources binpacking accounts for.
+type capacity struct {
+	milliCPU int64
+	memory   int64
+	pods     int64
+}
+
+func (c capacity) fits(request capacity) bool {
+	return request.milliCPU <= c.milliCPU && request.memory <= c.memory && request.pods <= c.pods
+}
+
+func (c *capacity) sub(request capacity) {
+	c.milliCPU -= request.milliCPU
+	c.memory -= request.memory
+	c.pods -= request.pods
+}
+
+func podRequest(pod *apiv1.Pod) capacity {
+	requests := resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{})
+	return capacity{
+		milliCPU: requests.Cpu().MilliValue(),
+		memory:   requests.Memory().Value(),
+		pods:     1,
+	}
+}
+
+// workloadCapacity returns the allocatable resources of the node left to workload pods, after
+// DaemonSet and mirror pods.
+func workloadCapacity(nodeInfo *schedulerframework.NodeInfo) capacity {
+	allocatable := nodeInfo.Node().Status.Allocatable
+	free := capacity{
+		milliCPU: allocatable.Cpu().MilliValue(),
+		memory:   allocatable.Memory().Value(),
+		pods:     allocatable.Pods().Value(),
+	}
+	for _, podInfo := range nodeInfo.Pods {
+		if pod_util.IsDaemonSetPod(podInfo.Pod) || pod_util.IsMirrorPod(podInfo.Pod) {
+			free.sub(podRequest(podInfo.Pod))
+		}
+	}
+	return free
+}
+
+// binpackedNodeCount returns the number of nodes of the given capacity the pods fit on, packed first fit
+// in decreasing order of CPU and memory requests. Pods which don't fit on an empty node take one each.
+func binpackedNodeCount(pods []*apiv1.Pod, nodeCapacity capacity) int {
+	requests := make([]capacity, 0, len(pods))
+	for _, pod := range pods {
+		requests = append(requests, podRequest(pod))
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].milliCPU != requests[j].milliCPU {
+			return requests[i].milliCPU > requests[j].milliCPU
+		}
+		return requests[i].memory > requests[j].memory
+	})
+	var nodes []capacity
+	for _, request := range requests {
+		placed := false
+		for i := range nodes {
+			if nodes[i].fits(request) {
+				nodes[i].sub(request)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			node := nodeCapacity
+			node.sub(request)
+			nodes = append(nodes, node)
+		}
+	}
+	return len(nodes)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimationfeedback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestProcessors(t *testing.T) {
+	now := time.Now()
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	provider.AddNodeGroup("ng2", 0, 3, 1)
+	snapshot := clustersnapshot.NewBasicClusterSnapshot()
+
+	addNode := func(name, nodeGroupId string, created time.Time, pods ...*apiv1.Pod) {
+		node := BuildTestNode(name, 1000, 1000)
+		node.CreationTimestamp = metav1.NewTime(created)
+		provider.AddNode(nodeGroupId, node)
+		assert.NoError(t, snapshot.AddNodeWithPods(node, pods))
+	}
+	rsPod := func(name string, milliCPU int64) *apiv1.Pod {
+		pod := BuildTestPod(name, milliCPU, 0)
+		pod.OwnerReferences = GenerateOwnerReferences("rs", "ReplicaSet", "apps/v1", "rs-uid")
+		return pod
+	}
+	dsPod := func(name string) *apiv1.Pod {
+		pod := BuildTestPod(name, 100, 0)
+		pod.OwnerReferences = GenerateOwnerReferences("ds", "DaemonSet", "apps/v1", "ds-uid")
+		return pod
+	}
+
+	// ng1 was scaled up by 4 nodes for p1 and p2, which fit on a single node.
+	p1, p2 := rsPod("p1", 300), rsPod("p2", 300)
+	addNode("old", "ng1", now.Add(-time.Hour), rsPod("p0", 100))
+	addNode("new1", "ng1", now.Add(time.Minute), p1, dsPod("d1"))
+	addNode("new2", "ng1", now.Add(time.Minute), p2)
+	// Pods the scale-up wasn't for don't count.
+	addNode("new3", "ng1", now.Add(time.Minute), dsPod("d3"), rsPod("other", 300))
+	addNode("new4", "ng1", now.Add(time.Minute))
+
+	// ng3 was scaled up by 1 node for q1, q2 and q3, which need a node each. Nodes of a later
+	// scale-up count for the pods of the first one which landed on them.
+	q1, q2, q3 := rsPod("q1", 600), rsPod("q2", 600), rsPod("q3", 600)
+	provider.AddNodeGroup("ng3", 0, 10, 1)
+	addNode("ng3-new1", "ng3", now.Add(time.Minute), q1)
+	addNode("ng3-new2", "ng3", now.Add(3*time.Minute), q2)
+	addNode("ng3-new3", "ng3", now.Add(3*time.Minute), q3)
+
+	tracker := NewTracker(10 * time.Minute)
+	scaleUpProcessor := NewScaleUpStatusProcessor(tracker)
+	scaleUpProcessor.now = func() time.Time { return now }
+	ng1 := provider.GetNodeGroup("ng1")
+	ng2 := provider.GetNodeGroup("ng2")
+	ng3 := provider.GetNodeGroup("ng3")
+	scaleUpProcessor.Process(nil, &status.ScaleUpStatus{
+		Result: status.ScaleUpSuccessful,
+		ScaleUpInfos: []nodegroupset.ScaleUpInfo{
+			{Group: ng1, CurrentSize: 1, NewSize: 5, MaxSize: 10},
+			// Scale-ups to the max size aren't evaluated.
+			{Group: ng2, CurrentSize: 1, NewSize: 3, MaxSize: 3},
+		},
+		PodsTriggeredScaleUp: []*apiv1.Pod{p1, p2},
+	})
+	scaleUpProcessor.Process(nil, &status.ScaleUpStatus{
+		Result:               status.ScaleUpSuccessful,
+		ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{Group: ng3, CurrentSize: 0, NewSize: 1, MaxSize: 10}},
+		PodsTriggeredScaleUp: []*apiv1.Pod{q1, q2, q3},
+	})
+
+	ctx := &context.AutoscalingContext{
+		CloudProvider:   provider,
+		ClusterSnapshot: snapshot,
+	}
+	statusProcessor := NewAutoscalingStatusProcessor(&status.NoOpAutoscalingStatusProcessor{}, tracker)
+	assert.NoError(t, statusProcessor.Process(ctx, nil, now.Add(5*time.Minute)))
+	assert.Equal(t, 1.0, tracker.CorrectionFactor("ng1"))
+
+	assert.NoError(t, statusProcessor.Process(ctx, nil, now.Add(10*time.Minute)))
+	// 1 of the 4 nodes was needed.
+	assert.InDelta(t, 0.775, tracker.CorrectionFactor("ng1"), 1e-9)
+	// 3 nodes were needed instead of 1.
+	assert.InDelta(t, 1.6, tracker.CorrectionFactor("ng3"), 1e-9)
+	assert.Equal(t, 1.0, tracker.CorrectionFactor("ng2"))
+	assert.Empty(t, tracker.SettledScaleUps(now.Add(time.Hour)))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimationfeedback
+
+import (
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+)
+
+const (
+	// smoothing is the weight of the last evaluated scale-up in the correction factor.
+	smoothing = 0.3
+	// minCorrectionFactor and maxCorrectionFactor bound the correction factor, so that a few
+	// misleading scale-ups can't make the estimations useless.
+	minCorrectionFactor = 0.5
+	maxCorrectionFactor = 2.0
+)
+
+// ScaleUp is a scale-up of a node group, with the pods which triggered it.
+type ScaleUp struct {
+	Time      time.Time
+	Requested int
+	Pods      map[types.UID]bool
+}
+
+// Tracker remembers scale-ups until they settle, and learns from the nodes they actually needed
+// a correction factor of the node count estimated for each node group.
+type Tracker struct {
+	lock       sync.Mutex
+	settleTime time.Duration
+	pending    map[string]ScaleUp
+	factors    map[string]float64
+}
+
+// NewTracker returns a tracker evaluating scale-ups once the given time passed since they happened.
+func NewTracker(settleTime time.Duration) *Tracker {
+	return &Tracker{
+		settleTime: settleTime,
+		pending:    make(map[string]ScaleUp),
+		factors:    make(map[string]float64),
+	}
+}
+
+// CorrectionFactor returns the correction factor of the node group, 1 until one of its scale-ups
+// was evaluated.
+func (t *Tracker) CorrectionFactor(nodeGroupId string) float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if factor, found := t.factors[nodeGroupId]; found {
+		return factor
+	}
+	return 1
+}
+
+// RecordScaleUp records a scale-up of the node group by the given number of nodes for the given pods.
+// Further scale-ups of the node group before the first one settles aren't recorded. Only the pods of
+// the first one are evaluated, wherever they landed in the node group, so that the nodes added for
+// other pods don't count as needed by it.
+func (t *Tracker) RecordScaleUp(nodeGroupId string, requested int, pods []*apiv1.Pod, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _, found := t.pending[nodeGroupId]; found {
+		return
+	}
+	podUIDs := make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		podUIDs[pod.UID] = true
+	}
+	t.pending[nodeGroupId] = ScaleUp{Time: now, Requested: requested, Pods: podUIDs}
+}
+
+// SettledScaleUps returns the scale-ups which settled, by node group.
+func (t *Tracker) SettledScaleUps(now time.Time) map[string]ScaleUp {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	settled := make(map[string]ScaleUp)
+	for nodeGroupId, scaleUp := range t.pending {
+		if now.Sub(scaleUp.Time) >= t.settleTime {
+			settled[nodeGroupId] = scaleUp
+		}
+	}
+	return settled
+}
+
+// Evaluate evaluates the settled scale-up of the node group, given how many of its nodes were created
+// and how many nodes the pods it was for needed, and updates the correction factor of the node group.
+// Scale-ups whose nodes weren't all created, or were already removed, are dropped without updating the
+// correction factor, as the nodes they needed can't be told.
+func (t *Tracker) Evaluate(nodeGroupId string, createdNodes, neededNodes int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	scaleUp, found := t.pending[nodeGroupId]
+	if !found {
+		return
+	}
+	delete(t.pending, nodeGroupId)
+	if scaleUp.Requested <= 0 || createdNodes < scaleUp.Requested {
+		return
+	}
+
+	accuracy := float64(neededNodes) / float64(scaleUp.Requested)
+	factor, found := t.factors[nodeGroupId]
+	if !found {
+		factor = 1
+	}
+	// The node count was already corrected by the factor, so the factor would have been accurate
+	// multiplied by the accuracy.
+	factor = (1-smoothing)*factor + smoothing*factor*accuracy
+	if factor < minCorrectionFactor {
+		factor = minCorrectionFactor
+	}
+	if factor > maxCorrectionFactor {
+		factor = maxCorrectionFactor
+	}
+	t.factors[nodeGroupId] = factor
+
+	result := metrics.ScaleUpEstimationAccurate
+	if neededNodes < scaleUp.Requested {
+		result = metrics.ScaleUpEstimationOver
+	} else if neededNodes > scaleUp.Requested {
+		result = metrics.ScaleUpEstimationUnder
+	}
+	metrics.RegisterScaleUpEstimation(nodeGroupId, result, accuracy, factor)
+}
+
+// Forget forgets the scale-ups and the correction factors of node groups other than the given ones.
+func (t *Tracker) Forget(nodeGroupIds map[string]bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for nodeGroupId := range t.pending {
+		if !nodeGroupIds[nodeGroupId] {
+			delete(t.pending, nodeGroupId)
+		}
+	}
+	for nodeGroupId := range t.factors {
+		if !nodeGroupIds[nodeGroupId] {
+			delete(t.factors, nodeGroupId)
+		}
+	}
+}
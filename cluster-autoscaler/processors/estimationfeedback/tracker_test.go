@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package estimationfeedback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestTrackerEvaluate(t *testing.T) {
+	now := time.Now()
+	cases := map[string]struct {
+		requested  int
+		created    int
+		needed     int
+		wantFactor float64
+	}{
+		"accurate": {
+			requested:  4,
+			created:    4,
+			needed:     4,
+			wantFactor: 1,
+		},
+		"overestimated": {
+			requested:  4,
+			created:    4,
+			needed:     2,
+			wantFactor: 0.85,
+		},
+		"underestimated": {
+			requested:  2,
+			created:    4,
+			needed:     4,
+			wantFactor: 1.3,
+		},
+		"nodes not created yet": {
+			requested:  4,
+			created:    3,
+			needed:     3,
+			wantFactor: 1,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewTracker(10 * time.Minute)
+			tracker.RecordScaleUp("ng1", tc.requested, nil, now)
+			assert.Empty(t, tracker.SettledScaleUps(now.Add(5*time.Minute)))
+			assert.Equal(t, map[string]ScaleUp{"ng1": {Time: now, Requested: tc.requested, Pods: map[types.UID]bool{}}}, tracker.SettledScaleUps(now.Add(10*time.Minute)))
+
+			tracker.Evaluate("ng1", tc.created, tc.needed)
+			assert.InDelta(t, tc.wantFactor, tracker.CorrectionFactor("ng1"), 1e-9)
+			assert.Equal(t, 1.0, tracker.CorrectionFactor("ng2"))
+			assert.Empty(t, tracker.SettledScaleUps(now.Add(10*time.Minute)))
+		})
+	}
+}
+
+func TestTrackerCorrectionFactorBounds(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(time.Minute)
+	for i := 0; i < 20; i++ {
+		tracker.RecordScaleUp("over", 10, nil, now)
+		tracker.Evaluate("over", 10, 0)
+		tracker.RecordScaleUp("under", 1, nil, now)
+		tracker.Evaluate("under", 10, 10)
+	}
+	assert.Equal(t, minCorrectionFactor, tracker.CorrectionFactor("over"))
+	assert.Equal(t, maxCorrectionFactor, tracker.CorrectionFactor("under"))
+}
+
+func TestTrackerRecordsFirstScaleUp(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(10 * time.Minute)
+	tracker.RecordScaleUp("ng1", 2, []*apiv1.Pod{BuildTestPod("p1", 100, 0)}, now)
+	tracker.RecordScaleUp("ng1", 3, []*apiv1.Pod{BuildTestPod("p2", 100, 0)}, now.Add(time.Minute))
+	assert.Equal(t, map[string]ScaleUp{"ng1": {Time: now, Requested: 2, Pods: map[types.UID]bool{"p1": true}}}, tracker.SettledScaleUps(now.Add(10*time.Minute)))
+
+	tracker.Evaluate("ng1", 5, 5)
+	assert.InDelta(t, 1.45, tracker.CorrectionFactor("ng1"), 1e-9)
+
+	tracker.Forget(map[string]bool{"ng2": true})
+	assert.Equal(t, 1.0, tracker.CorrectionFactor("ng1"))
+}
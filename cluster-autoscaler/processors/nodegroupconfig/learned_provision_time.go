@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupconfig
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// provisionTimeSamples is the number of most recent provision times remembered per node group.
+	provisionTimeSamples = 10
+	// minProvisionTimeSamples is the number of provision times needed before a learned value is used.
+	minProvisionTimeSamples = 3
+	// provisionTimeHeadroom is the factor applied to the slowest remembered provision time.
+	provisionTimeHeadroom = 1.5
+)
+
+// NodeProvisionTimeObserver is notified about how long it took for nodes added to
+// a node group to register.
+type NodeProvisionTimeObserver interface {
+	// ObserveNodeProvisionTime records the time a scale-up of the node group took.
+	ObserveNodeProvisionTime(nodeGroup cloudprovider.NodeGroup, provisionTime time.Duration)
+	// ObserveNodeProvisionTimeout records that a scale-up of the node group timed out
+	// after waitTime, i.e. that it would have taken longer than waitTime.
+	ObserveNodeProvisionTimeout(nodeGroup cloudprovider.NodeGroup, waitTime time.Duration)
+}
+
+// LearningNodeGroupConfigProcessor wraps a NodeGroupConfigProcessor and learns
+// MaxNodeProvisionTime for each NodeGroup from its recent scale-ups, including the
+// ones which timed out. Node groups with MaxNodeProvisionTime configured explicitly
+// keep the configured value.
+type LearningNodeGroupConfigProcessor struct {
+	NodeGroupConfigProcessor
+	nodeGroupDefaults config.NodeGroupAutoscalingOptions
+	floor             time.Duration
+	ceiling           time.Duration
+
+	sync.Mutex
+	provisionTimes map[string][]time.Duration
+}
+
+// NewLearningNodeGroupConfigProcessor returns a LearningNodeGroupConfigProcessor.
+// Learned provision times are kept between floor and ceiling.
+func NewLearningNodeGroupConfigProcessor(processor NodeGroupConfigProcessor, nodeGroupDefaults config.NodeGroupAutoscalingOptions, floor, ceiling time.Duration) *LearningNodeGroupConfigProcessor {
+	return &LearningNodeGroupConfigProcessor{
+		NodeGroupConfigProcessor: processor,
+		nodeGroupDefaults:        nodeGroupDefaults,
+		floor:                    floor,
+		ceiling:                  ceiling,
+		provisionTimes:           make(map[string][]time.Duration),
+	}
+}
+
+// ObserveNodeProvisionTime records the time a scale-up of the node group took.
+func (p *LearningNodeGroupConfigProcessor) ObserveNodeProvisionTime(nodeGroup cloudprovider.NodeGroup, provisionTime time.Duration) {
+	p.observe(nodeGroup.Id(), provisionTime)
+}
+
+// ObserveNodeProvisionTimeout records that a scale-up of the node group timed out after
+// waitTime. The actual provision time is unknown but longer than waitTime, so waitTime
+// is remembered as a lower bound on it. Since the learned value is the slowest
+// remembered time with headroom, every timeout raises it up to the ceiling.
+func (p *LearningNodeGroupConfigProcessor) ObserveNodeProvisionTimeout(nodeGroup cloudprovider.NodeGroup, waitTime time.Duration) {
+	p.observe(nodeGroup.Id(), waitTime)
+}
+
+func (p *LearningNodeGroupConfigProcessor) observe(nodeGroupId string, provisionTime time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	times := append(p.provisionTimes[nodeGroupId], provisionTime)
+	if len(times) > provisionTimeSamples {
+		times = times[len(times)-provisionTimeSamples:]
+	}
+	p.provisionTimes[nodeGroupId] = times
+}
+
+// GetMaxNodeProvisionTime returns MaxNodeProvisionTime learned for a given NodeGroup,
+// or the configured value if it was set explicitly or too few scale-ups were observed.
+func (p *LearningNodeGroupConfigProcessor) GetMaxNodeProvisionTime(nodeGroup cloudprovider.NodeGroup) (time.Duration, error) {
+	configured, err := p.NodeGroupConfigProcessor.GetMaxNodeProvisionTime(nodeGroup)
+	if err != nil {
+		return configured, err
+	}
+	overridden, err := p.overridesMaxNodeProvisionTime(nodeGroup)
+	if err != nil || overridden {
+		return configured, err
+	}
+	learned, found := p.learnedProvisionTime(nodeGroup.Id())
+	if !found {
+		return configured, nil
+	}
+	klog.V(5).Infof("Using learned MaxNodeProvisionTime %v for node group %s", learned, nodeGroup.Id())
+	return learned, nil
+}
+
+// overridesMaxNodeProvisionTime checks if the NodeGroup sets MaxNodeProvisionTime itself.
+// Cloud providers fill options the NodeGroup doesn't set from the defaults passed to
+// GetOptions, so an override can't be told apart from the default by its value. Instead,
+// the options are requested with MaxNodeProvisionTime unset in the defaults, so that it
+// is only set in the result if the NodeGroup sets it, even to the default value.
+func (p *LearningNodeGroupConfigProcessor) overridesMaxNodeProvisionTime(nodeGroup cloudprovider.NodeGroup) (bool, error) {
+	defaults := p.nodeGroupDefaults
+	defaults.MaxNodeProvisionTime = 0
+	ngConfig, err := nodeGroup.GetOptions(defaults)
+	if err == cloudprovider.ErrNotImplemented {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ngConfig != nil && ngConfig.MaxNodeProvisionTime != 0, nil
+}
+
+func (p *LearningNodeGroupConfigProcessor) learnedProvisionTime(nodeGroupId string) (time.Duration, bool) {
+	p.Lock()
+	defer p.Unlock()
+	times := p.provisionTimes[nodeGroupId]
+	if len(times) < minProvisionTimeSamples {
+		return 0, false
+	}
+	slowest := time.Duration(0)
+	for _, t := range times {
+		if t > slowest {
+			slowest = t
+		}
+	}
+	learned := time.Duration(float64(slowest) * provisionTimeHeadroom)
+	if learned < p.floor {
+		learned = p.floor
+	}
+	if learned > p.ceiling {
+		learned = p.ceiling
+	}
+	return learned, true
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/mocks"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+func TestLearningNodeGroupConfigProcessor(t *testing.T) {
+	globalOpts := config.NodeGroupAutoscalingOptions{
+		MaxNodeProvisionTime: 15 * time.Minute,
+	}
+
+	cases := map[string]struct {
+		ngProvisionTime time.Duration
+		provisionTimes  []time.Duration
+		timeouts        []time.Duration
+		want            time.Duration
+	}{
+		"too few scale-ups observed": {
+			provisionTimes: []time.Duration{20 * time.Minute, 20 * time.Minute},
+			want:           15 * time.Minute,
+		},
+		"learned from slowest scale-up": {
+			provisionTimes: []time.Duration{10 * time.Minute, 20 * time.Minute, 12 * time.Minute},
+			want:           30 * time.Minute,
+		},
+		"old scale-ups are forgotten": {
+			provisionTimes: []time.Duration{50 * time.Minute, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute,
+				10 * time.Minute, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+			want: 15 * time.Minute,
+		},
+		"learned value raised to floor": {
+			provisionTimes: []time.Duration{time.Minute, time.Minute, time.Minute},
+			want:           5 * time.Minute,
+		},
+		"learned value lowered to ceiling": {
+			provisionTimes: []time.Duration{time.Hour, time.Hour, time.Hour},
+			want:           time.Hour,
+		},
+		"timed out scale-ups raise learned value": {
+			provisionTimes: []time.Duration{10 * time.Minute, 10 * time.Minute},
+			timeouts:       []time.Duration{15 * time.Minute, 22 * time.Minute},
+			want:           33 * time.Minute,
+		},
+		"NodeGroup option is kept": {
+			ngProvisionTime: 60 * time.Minute,
+			provisionTimes:  []time.Duration{time.Minute, time.Minute, time.Minute},
+			want:            60 * time.Minute,
+		},
+		"NodeGroup option equal to the default is kept": {
+			ngProvisionTime: 15 * time.Minute,
+			provisionTimes:  []time.Duration{time.Hour, time.Hour, time.Hour},
+			want:            15 * time.Minute,
+		},
+	}
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			ng := &mocks.NodeGroup{}
+			ng.On("Id").Return("ng")
+			// Like cloud providers, fill options not set by the NodeGroup from the defaults.
+			ng.On("GetOptions", mock.Anything).Return(func(defaults config.NodeGroupAutoscalingOptions) *config.NodeGroupAutoscalingOptions {
+				if tc.ngProvisionTime != 0 {
+					defaults.MaxNodeProvisionTime = tc.ngProvisionTime
+				}
+				return &defaults
+			}, nil)
+			p := NewLearningNodeGroupConfigProcessor(NewDefaultNodeGroupConfigProcessor(globalOpts), globalOpts, 5*time.Minute, time.Hour)
+			for _, provisionTime := range tc.provisionTimes {
+				p.ObserveNodeProvisionTime(ng, provisionTime)
+			}
+			for _, waitTime := range tc.timeouts {
+				p.ObserveNodeProvisionTimeout(ng, waitTime)
+			}
+			res, err := p.GetMaxNodeProvisionTime(ng)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeinfosprovider
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// TemplateIssue is a kind of misconfiguration of a node group template.
+type TemplateIssue string
+
+const (
+	// ZeroAllocatableIssue - the template has no allocatable CPU or memory.
+	ZeroAllocatableIssue TemplateIssue = "zero_allocatable"
+	// ConflictingTaintsIssue - the template has taints with the same key and effect, but different values.
+	ConflictingTaintsIssue TemplateIssue = "conflicting_taints"
+	// MissingLabelsIssue - the template misses labels required by the node selector of pending pods,
+	// which no template provides. It's reported for the templates which miss the fewest labels.
+	MissingLabelsIssue TemplateIssue = "missing_labels"
+)
+
+// TemplateIssues lists all kinds of template issues.
+var TemplateIssues = []TemplateIssue{ZeroAllocatableIssue, ConflictingTaintsIssue, MissingLabelsIssue}
+
+// maxIssueDetailsReported is the number of details, e.g. pods missing labels, listed in
+// the message reporting an issue.
+const maxIssueDetailsReported = 5
+
+// LintingTemplateNodeInfoProvider wraps a TemplateNodeInfoProvider and lints the node infos
+// it returns, so that misconfigured node groups are visible before pods get stuck. Templates
+// are linted only when a template or the pending pods with a node selector change. Issues are
+// reported with metrics and, when they change, with events on the status ConfigMap.
+type LintingTemplateNodeInfoProvider struct {
+	provider TemplateNodeInfoProvider
+	// reported holds messages of issues reported by node group and issue kind.
+	reported map[string]map[TemplateIssue]string
+	// lintedTemplates holds keys of the templates last linted by node group.
+	lintedTemplates map[string]string
+	// lintedPods holds the key of the pending pods with a node selector last linted.
+	lintedPods string
+}
+
+// NewLintingTemplateNodeInfoProvider returns a LintingTemplateNodeInfoProvider wrapping the given provider.
+func NewLintingTemplateNodeInfoProvider(provider TemplateNodeInfoProvider) *LintingTemplateNodeInfoProvider {
+	return &LintingTemplateNodeInfoProvider{
+		provider: provider,
+		reported: map[string]map[TemplateIssue]string{},
+	}
+}
+
+// Process returns the node infos of the wrapped provider, after linting them if they changed.
+func (p *LintingTemplateNodeInfoProvider) Process(ctx *context.AutoscalingContext, nodes []*apiv1.Node, daemonsets []*appsv1.DaemonSet, taintConfig taints.TaintConfig, currentTime time.Time) (map[string]*schedulerframework.NodeInfo, errors.AutoscalerError) {
+	nodeInfos, err := p.provider.Process(ctx, nodes, daemonsets, taintConfig, currentTime)
+	if err != nil {
+		return nil, err
+	}
+	var pendingPods []*apiv1.Pod
+	if pods, listErr := ctx.AllPodLister().List(); listErr != nil {
+		klog.Warningf("Failed to list pods for linting node group templates: %v", listErr)
+	} else {
+		for _, pod := range kube_util.UnschedulablePods(pods) {
+			if len(pod.Spec.NodeSelector) > 0 {
+				pendingPods = append(pendingPods, pod)
+			}
+		}
+	}
+	templates := make(map[string]string, len(nodeInfos))
+	for nodeGroup, nodeInfo := range nodeInfos {
+		templates[nodeGroup] = templateKey(nodeInfo.Node())
+	}
+	podsKey := podsKey(pendingPods)
+	if p.lintedTemplates != nil && reflect.DeepEqual(templates, p.lintedTemplates) && podsKey == p.lintedPods {
+		return nodeInfos, nil
+	}
+	p.report(ctx, LintTemplates(nodeInfos, pendingPods))
+	p.lintedTemplates = templates
+	p.lintedPods = podsKey
+	return nodeInfos, nil
+}
+
+// CleanUp cleans up processor's internal structures.
+func (p *LintingTemplateNodeInfoProvider) CleanUp() {
+	p.provider.CleanUp()
+}
+
+func (p *LintingTemplateNodeInfoProvider) report(ctx *context.AutoscalingContext, issues map[string]map[TemplateIssue][]string) {
+	reported := make(map[string]map[TemplateIssue]string, len(issues))
+	for nodeGroup, groupIssues := range issues {
+		counts := make(map[TemplateIssue]int, len(TemplateIssues))
+		reported[nodeGroup] = make(map[TemplateIssue]string)
+		for issue, details := range groupIssues {
+			counts[issue] = len(details)
+			if len(details) == 0 {
+				continue
+			}
+			message := fmt.Sprintf("Template of node group %s has %s issues: %s", nodeGroup, issue, joinDetails(details))
+			reported[nodeGroup][issue] = message
+			if p.reported[nodeGroup][issue] != message {
+				klog.Warning(message)
+				if ctx.LogRecorder != nil {
+					ctx.LogRecorder.Event(apiv1.EventTypeWarning, "NodeGroupTemplateIssue", message)
+				}
+			}
+		}
+		for _, issue := range TemplateIssues {
+			metrics.UpdateNodeGroupTemplateIssues(nodeGroup, string(issue), counts[issue])
+		}
+	}
+	p.reported = reported
+}
+
+// LintTemplates returns issues of the templates of node groups, by node group and issue
+// kind. Each issue is described by a detail, e.g. the offending resource or taint.
+func LintTemplates(nodeInfos map[string]*schedulerframework.NodeInfo, pendingPods []*apiv1.Pod) map[string]map[TemplateIssue][]string {
+	result := make(map[string]map[TemplateIssue][]string, len(nodeInfos))
+	for nodeGroup, nodeInfo := range nodeInfos {
+		result[nodeGroup] = map[TemplateIssue][]string{}
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		for _, resource := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+			if allocatable, found := node.Status.Allocatable[resource]; !found || allocatable.IsZero() {
+				result[nodeGroup][ZeroAllocatableIssue] = append(result[nodeGroup][ZeroAllocatableIssue], string(resource))
+			}
+		}
+		result[nodeGroup][ConflictingTaintsIssue] = conflictingTaints(node.Spec.Taints)
+	}
+
+	for _, pod := range pendingPods {
+		if len(pod.Spec.NodeSelector) == 0 {
+			continue
+		}
+		missingByGroup := make(map[string][]string, len(nodeInfos))
+		fewestMissing := -1
+		for nodeGroup, nodeInfo := range nodeInfos {
+			if nodeInfo.Node() == nil {
+				continue
+			}
+			missing := missingLabels(nodeInfo.Node().Labels, pod.Spec.NodeSelector)
+			if len(missing) == 0 {
+				fewestMissing = 0
+				break
+			}
+			missingByGroup[nodeGroup] = missing
+			if fewestMissing < 0 || len(missing) < fewestMissing {
+				fewestMissing = len(missing)
+			}
+		}
+		if fewestMissing <= 0 {
+			continue
+		}
+		for nodeGroup, missing := range missingByGroup {
+			if len(missing) == fewestMissing {
+				detail := fmt.Sprintf("pod %s/%s requires %s", pod.Namespace, pod.Name, strings.Join(missing, ","))
+				result[nodeGroup][MissingLabelsIssue] = append(result[nodeGroup][MissingLabelsIssue], detail)
+			}
+		}
+	}
+	return result
+}
+
+// joinDetails joins up to maxIssueDetailsReported details, followed by the number of the
+// remaining ones.
+func joinDetails(details []string) string {
+	if len(details) <= maxIssueDetailsReported {
+		return strings.Join(details, "; ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(details[:maxIssueDetailsReported], "; "), len(details)-maxIssueDetailsReported)
+}
+
+// templateKey returns a key of the parts of the node which are linted: allocatable,
+// labels and taints.
+func templateKey(node *apiv1.Node) string {
+	if node == nil {
+		return ""
+	}
+	var parts []string
+	for resource, allocatable := range node.Status.Allocatable {
+		parts = append(parts, fmt.Sprintf("allocatable:%s=%s", resource, allocatable.String()))
+	}
+	for key, value := range node.Labels {
+		parts = append(parts, fmt.Sprintf("label:%s=%s", key, value))
+	}
+	for _, taint := range node.Spec.Taints {
+		parts = append(parts, fmt.Sprintf("taint:%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// podsKey returns a key of the given pods.
+func podsKey(pods []*apiv1.Pod) string {
+	uids := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		uids = append(uids, string(pod.UID))
+	}
+	sort.Strings(uids)
+	return strings.Join(uids, ",")
+}
+
+// conflictingTaints returns key:effect of taints which are set with different values.
+func conflictingTaints(nodeTaints []apiv1.Taint) []string {
+	values := make(map[string]string, len(nodeTaints))
+	conflicting := map[string]bool{}
+	for _, taint := range nodeTaints {
+		key := fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+		if value, found := values[key]; found && value != taint.Value {
+			conflicting[key] = true
+		}
+		values[key] = taint.Value
+	}
+	var result []string
+	for key := range conflicting {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// missingLabels returns key=value of the node selector which the labels don't match.
+func missingLabels(labels, nodeSelector map[string]string) []string {
+	var result []string
+	for key, value := range nodeSelector {
+		if labels[key] != value {
+			result = append(result, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeinfosprovider
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/taints"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+type staticTemplateNodeInfoProvider struct {
+	nodeInfos map[string]*schedulerframework.NodeInfo
+}
+
+func (p *staticTemplateNodeInfoProvider) Process(*context.AutoscalingContext, []*apiv1.Node, []*appsv1.DaemonSet, taints.TaintConfig, time.Time) (map[string]*schedulerframework.NodeInfo, errors.AutoscalerError) {
+	return p.nodeInfos, nil
+}
+
+func (p *staticTemplateNodeInfoProvider) CleanUp() {}
+
+func TestLintingTemplateNodeInfoProviderLintsChanges(t *testing.T) {
+	node := BuildTestNode("ng", 1000, 0)
+	nodeInfo := schedulerframework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+	provider := &staticTemplateNodeInfoProvider{nodeInfos: map[string]*schedulerframework.NodeInfo{"ng": nodeInfo}}
+	var pods []*apiv1.Pod
+	for i := 0; i < 7; i++ {
+		pod := BuildTestPod(fmt.Sprintf("p%d", i), 100, 100, MarkUnschedulable())
+		pod.UID = types.UID(pod.Name)
+		pod.Spec.NodeSelector = map[string]string{"pool": "gpu"}
+		pods = append(pods, pod)
+	}
+	ctx := &context.AutoscalingContext{
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			ListerRegistry: kube_util.NewListerRegistry(nil, nil, kube_util.NewTestPodLister(pods[:1]), nil, nil, nil, nil, nil, nil),
+		},
+	}
+	p := NewLintingTemplateNodeInfoProvider(provider)
+	process := func() {
+		_, err := p.Process(ctx, nil, nil, taints.TaintConfig{}, time.Now())
+		assert.NoError(t, err)
+	}
+
+	process()
+	assert.Contains(t, p.reported["ng"], ZeroAllocatableIssue)
+	assert.Contains(t, p.reported["ng"], MissingLabelsIssue)
+
+	// Nothing changed, templates aren't linted again.
+	p.reported = map[string]map[TemplateIssue]string{}
+	process()
+	assert.Empty(t, p.reported)
+
+	// The template changed.
+	fixed := BuildTestNode("ng", 1000, 1000)
+	fixed.Labels["pool"] = "gpu"
+	fixedNodeInfo := schedulerframework.NewNodeInfo()
+	fixedNodeInfo.SetNode(fixed)
+	provider.nodeInfos = map[string]*schedulerframework.NodeInfo{"ng": fixedNodeInfo}
+	process()
+	assert.Empty(t, p.reported["ng"])
+
+	// Both changed, only the first of the pods missing labels are listed.
+	fixed.Labels["pool"] = "cpu"
+	provider.nodeInfos = map[string]*schedulerframework.NodeInfo{"ng": fixedNodeInfo}
+	ctx.ListerRegistry = kube_util.NewListerRegistry(nil, nil, kube_util.NewTestPodLister(pods), nil, nil, nil, nil, nil, nil)
+	process()
+	assert.Contains(t, p.reported["ng"][MissingLabelsIssue], "pod default/p4 requires pool=gpu and 2 more")
+}
+
+func TestLintTemplates(t *testing.T) {
+	healthy := BuildTestNode("healthy", 1000, 1000)
+	healthy.Labels["pool"] = "gpu"
+	healthy.Labels["zone"] = "a"
+	healthy.Spec.Taints = []apiv1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "ml", Effect: apiv1.TaintEffectNoExecute},
+	}
+	zeroMemory := BuildTestNode("zero-memory", 1000, 0)
+	zeroMemory.Labels["pool"] = "gpu"
+	conflicting := BuildTestNode("conflicting", 1000, 1000)
+	conflicting.Spec.Taints = []apiv1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "ml", Effect: apiv1.TaintEffectNoSchedule},
+	}
+	nodeInfos := map[string]*schedulerframework.NodeInfo{}
+	for _, node := range []*apiv1.Node{healthy, zeroMemory, conflicting} {
+		nodeInfo := schedulerframework.NewNodeInfo()
+		nodeInfo.SetNode(node)
+		nodeInfos[node.Name] = nodeInfo
+	}
+
+	satisfied := BuildTestPod("satisfied", 100, 100)
+	satisfied.Spec.NodeSelector = map[string]string{"pool": "gpu", "zone": "a"}
+	unsatisfied := BuildTestPod("unsatisfied", 100, 100)
+	unsatisfied.Spec.NodeSelector = map[string]string{"pool": "gpu", "zone": "b"}
+	noSelector := BuildTestPod("no-selector", 100, 100)
+
+	issues := LintTemplates(nodeInfos, []*apiv1.Pod{satisfied, unsatisfied, noSelector})
+	assert.Equal(t, map[string]map[TemplateIssue][]string{
+		"healthy": {
+			ConflictingTaintsIssue: nil,
+			MissingLabelsIssue:     {"pod default/unsatisfied requires zone=b"},
+		},
+		"zero-memory": {
+			ZeroAllocatableIssue:   {"memory"},
+			ConflictingTaintsIssue: nil,
+			MissingLabelsIssue:     {"pod default/unsatisfied requires zone=b"},
+		},
+		"conflicting": {
+			ConflictingTaintsIssue: {"dedicated:NoSchedule"},
+		},
+	}, issues)
+}
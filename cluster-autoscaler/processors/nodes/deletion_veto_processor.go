@@ -0,0 +1,229 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// NodeDeletionReasonEmpty is the reason of the deletion of a node without pods to reschedule.
+	NodeDeletionReasonEmpty = "Empty"
+	// NodeDeletionReasonDrain is the reason of the deletion of a node whose pods are rescheduled elsewhere.
+	NodeDeletionReasonDrain = "Drain"
+
+	// maxDeletionReviewResponseSize is the maximum size of a webhook response body read.
+	maxDeletionReviewResponseSize = 1 << 20
+)
+
+// NodeDeletionReviews is sent by POST to the node deletion veto webhook before nodes are deleted,
+// with all the nodes selected for deletion in a loop whose verdict isn't cached.
+type NodeDeletionReviews struct {
+	// Nodes are the reviews of the nodes to be deleted.
+	Nodes []NodeDeletionReview `json:"nodes"`
+}
+
+// NodeDeletionReview describes the deletion of a single node.
+type NodeDeletionReview struct {
+	// Node is the name of the node to be deleted.
+	Node string `json:"node"`
+	// NodeGroup is the id of the node group of the node.
+	NodeGroup string `json:"nodeGroup,omitempty"`
+	// Reason is the reason of the deletion, Empty or Drain.
+	Reason string `json:"reason"`
+	// Pods are the pods rescheduled elsewhere if the node is deleted.
+	Pods []NodeDeletionReviewPod `json:"pods,omitempty"`
+}
+
+// NodeDeletionReviewPod identifies a pod rescheduled by a node deletion.
+type NodeDeletionReviewPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// NodeDeletionReviewsResponse is the response of the node deletion veto webhook.
+type NodeDeletionReviewsResponse struct {
+	// Nodes are the verdicts of the reviewed nodes. Nodes without a verdict are handled
+	// like failed webhook calls.
+	Nodes []NodeDeletionReviewResponse `json:"nodes"`
+}
+
+// NodeDeletionReviewResponse is the verdict of the webhook on the deletion of a single node.
+type NodeDeletionReviewResponse struct {
+	// Node is the name of the reviewed node.
+	Node string `json:"node"`
+	// Allowed is false if the webhook vetoes the deletion of the node.
+	Allowed bool `json:"allowed"`
+	// Reason explains why the deletion is vetoed.
+	Reason string `json:"reason,omitempty"`
+}
+
+// DeletionVetoProcessor asks a webhook whether the nodes selected for deletion can be deleted, and
+// removes the vetoed nodes from the selection. Vetoed nodes are considered again in the following loops.
+// All the nodes of a loop are reviewed in a single call, and the verdicts are cached for the configured
+// TTL, as long as the deletion of the node, i.e. its reason and pods, doesn't change.
+// NOTE! It has to be chained before AtomicResizeFilteringProcessor, so that vetoing a single node
+// prevents the scale-down of the whole node group.
+type DeletionVetoProcessor struct {
+	webhook  config.NodeDeletionVetoWebhookConfig
+	client   *http.Client
+	verdicts map[string]cachedVerdict
+	now      func() time.Time
+}
+
+type cachedVerdict struct {
+	// review is the review the verdict was given for.
+	review   NodeDeletionReview
+	response NodeDeletionReviewResponse
+	expires  time.Time
+}
+
+// NewDeletionVetoProcessor returns a new DeletionVetoProcessor calling the given webhook.
+func NewDeletionVetoProcessor(webhook config.NodeDeletionVetoWebhookConfig) *DeletionVetoProcessor {
+	return &DeletionVetoProcessor{
+		webhook:  webhook,
+		client:   &http.Client{},
+		verdicts: make(map[string]cachedVerdict),
+		now:      time.Now,
+	}
+}
+
+// GetNodesToRemove selects the candidates whose deletion isn't vetoed by the webhook.
+func (p *DeletionVetoProcessor) GetNodesToRemove(ctx *context.AutoscalingContext, candidates []simulator.NodeToBeRemoved, maxCount int) []simulator.NodeToBeRemoved {
+	now := p.now()
+	for name, verdict := range p.verdicts {
+		if !now.Before(verdict.expires) {
+			delete(p.verdicts, name)
+		}
+	}
+
+	responses := make(map[string]NodeDeletionReviewResponse, len(candidates))
+	reviews := NodeDeletionReviews{}
+	for _, candidate := range candidates {
+		review := newNodeDeletionReview(ctx, candidate)
+		if verdict, found := p.verdicts[review.Node]; found && reflect.DeepEqual(verdict.review, review) {
+			responses[review.Node] = verdict.response
+			continue
+		}
+		reviews.Nodes = append(reviews.Nodes, review)
+	}
+
+	var err error
+	if len(reviews.Nodes) > 0 {
+		var response *NodeDeletionReviewsResponse
+		response, err = p.review(reviews)
+		if err == nil {
+			for _, nodeResponse := range response.Nodes {
+				responses[nodeResponse.Node] = nodeResponse
+			}
+			for _, review := range reviews.Nodes {
+				if nodeResponse, found := responses[review.Node]; found && p.webhook.CacheTTL > 0 {
+					p.verdicts[review.Node] = cachedVerdict{review: review, response: nodeResponse, expires: now.Add(p.webhook.CacheTTL)}
+				}
+			}
+		}
+	}
+
+	result := []simulator.NodeToBeRemoved{}
+	for _, candidate := range candidates {
+		response, found := responses[candidate.Node.Name]
+		if !found {
+			failure := err
+			if failure == nil {
+				failure = fmt.Errorf("no verdict for the node in the response")
+			}
+			if p.webhook.IgnoreFailures {
+				klog.Warningf("Node deletion veto webhook failed for node %s, ignoring: %v", candidate.Node.Name, failure)
+				result = append(result, candidate)
+				continue
+			}
+			klog.Errorf("Node %s will not scale down, node deletion veto webhook failed: %v", candidate.Node.Name, failure)
+			continue
+		}
+		if !response.Allowed {
+			klog.V(1).Infof("Node %s will not scale down, deletion vetoed by webhook: %s", candidate.Node.Name, response.Reason)
+			ctx.Recorder.Eventf(candidate.Node, apiv1.EventTypeNormal, "ScaleDownVetoed", "scale-down vetoed by webhook: %s", response.Reason)
+			continue
+		}
+		result = append(result, candidate)
+	}
+	return result
+}
+
+func newNodeDeletionReview(ctx *context.AutoscalingContext, candidate simulator.NodeToBeRemoved) NodeDeletionReview {
+	review := NodeDeletionReview{
+		Node:   candidate.Node.Name,
+		Reason: NodeDeletionReasonEmpty,
+	}
+	if len(candidate.PodsToReschedule) > 0 {
+		review.Reason = NodeDeletionReasonDrain
+	}
+	for _, pod := range candidate.PodsToReschedule {
+		review.Pods = append(review.Pods, NodeDeletionReviewPod{Namespace: pod.Namespace, Name: pod.Name})
+	}
+	nodeGroup, err := ctx.CloudProvider.NodeGroupForNode(candidate.Node)
+	if err == nil && nodeGroup != nil && !reflect.ValueOf(nodeGroup).IsNil() {
+		review.NodeGroup = nodeGroup.Id()
+	}
+	return review
+}
+
+// review calls the webhook with the reviews of all nodes. The timeout is a deadline for the whole
+// call, including reading the response, so that a slow webhook can't stall the loop for longer.
+func (p *DeletionVetoProcessor) review(reviews NodeDeletionReviews) (*NodeDeletionReviewsResponse, error) {
+	body, err := json.Marshal(reviews)
+	if err != nil {
+		return nil, err
+	}
+	reqCtx, cancel := stdcontext.WithTimeout(stdcontext.Background(), p.webhook.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, p.webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	response := &NodeDeletionReviewsResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDeletionReviewResponseSize)).Decode(response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return response, nil
+}
+
+// CleanUp is called at CA termination
+func (p *DeletionVetoProcessor) CleanUp() {
+}
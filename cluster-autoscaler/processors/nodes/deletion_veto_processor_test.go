@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	kube_record "k8s.io/client-go/tools/record"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestDeletionVetoProcessor(t *testing.T) {
+	empty := BuildTestNode("empty", 1000, 1000)
+	drained := BuildTestNode("drained", 1000, 1000)
+	protected := BuildTestNode("protected", 1000, 1000)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNode("ng1", empty)
+	provider.AddNode("ng1", drained)
+	provider.AddNode("ng1", protected)
+	pod := BuildTestPod("p1", 100, 100)
+	candidates := []simulator.NodeToBeRemoved{
+		{Node: empty},
+		{Node: drained, PodsToReschedule: []*apiv1.Pod{pod}},
+		{Node: protected},
+	}
+
+	var calls []NodeDeletionReviews
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reviews NodeDeletionReviews
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&reviews))
+		calls = append(calls, reviews)
+		response := NodeDeletionReviewsResponse{}
+		for _, review := range reviews.Nodes {
+			if review.Node == "protected" {
+				response.Nodes = append(response.Nodes, NodeDeletionReviewResponse{Node: review.Node, Allowed: false, Reason: "rebalancing"})
+			} else {
+				response.Nodes = append(response.Nodes, NodeDeletionReviewResponse{Node: review.Node, Allowed: true})
+			}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	fakeRecorder := kube_record.NewFakeRecorder(10)
+	ctx := &context.AutoscalingContext{
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			Recorder: fakeRecorder,
+		},
+		CloudProvider: provider,
+	}
+
+	now := time.Now()
+	processor := NewDeletionVetoProcessor(config.NodeDeletionVetoWebhookConfig{URL: server.URL, Timeout: time.Second, CacheTTL: time.Minute})
+	processor.now = func() time.Time { return now }
+	result := processor.GetNodesToRemove(ctx, candidates, len(candidates))
+	assert.Equal(t, candidates[:2], result)
+	assert.Equal(t, []NodeDeletionReviews{{Nodes: []NodeDeletionReview{
+		{Node: "empty", NodeGroup: "ng1", Reason: NodeDeletionReasonEmpty},
+		{Node: "drained", NodeGroup: "ng1", Reason: NodeDeletionReasonDrain, Pods: []NodeDeletionReviewPod{{Namespace: pod.Namespace, Name: "p1"}}},
+		{Node: "protected", NodeGroup: "ng1", Reason: NodeDeletionReasonEmpty},
+	}}}, calls)
+	assert.Equal(t, "Normal ScaleDownVetoed scale-down vetoed by webhook: rebalancing", <-fakeRecorder.Events)
+
+	// Cached verdicts are reused, only the node whose deletion changed is reviewed again.
+	calls = nil
+	candidates[0].PodsToReschedule = []*apiv1.Pod{pod}
+	result = processor.GetNodesToRemove(ctx, candidates, len(candidates))
+	assert.Equal(t, candidates[:2], result)
+	assert.Equal(t, []NodeDeletionReviews{{Nodes: []NodeDeletionReview{
+		{Node: "empty", NodeGroup: "ng1", Reason: NodeDeletionReasonDrain, Pods: []NodeDeletionReviewPod{{Namespace: pod.Namespace, Name: "p1"}}},
+	}}}, calls)
+
+	// Expired verdicts are reviewed again.
+	calls = nil
+	now = now.Add(time.Minute)
+	processor.GetNodesToRemove(ctx, candidates, len(candidates))
+	assert.Len(t, calls, 1)
+	assert.Len(t, calls[0].Nodes, 3)
+}
+
+func TestDeletionVetoProcessorMissingVerdict(t *testing.T) {
+	node1 := BuildTestNode("n1", 1000, 1000)
+	node2 := BuildTestNode("n2", 1000, 1000)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	candidates := []simulator.NodeToBeRemoved{{Node: node1}, {Node: node2}}
+	ctx := &context.AutoscalingContext{CloudProvider: provider}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := NodeDeletionReviewsResponse{Nodes: []NodeDeletionReviewResponse{{Node: "n1", Allowed: true}}}
+		assert.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	processor := NewDeletionVetoProcessor(config.NodeDeletionVetoWebhookConfig{URL: server.URL, Timeout: time.Second})
+	assert.Equal(t, candidates[:1], processor.GetNodesToRemove(ctx, candidates, len(candidates)))
+}
+
+func TestDeletionVetoProcessorFailurePolicy(t *testing.T) {
+	node := BuildTestNode("n1", 1000, 1000)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	candidates := []simulator.NodeToBeRemoved{{Node: node}}
+	ctx := &context.AutoscalingContext{CloudProvider: provider}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	processor := NewDeletionVetoProcessor(config.NodeDeletionVetoWebhookConfig{URL: server.URL, Timeout: time.Second})
+	assert.Empty(t, processor.GetNodesToRemove(ctx, candidates, len(candidates)))
+
+	processor = NewDeletionVetoProcessor(config.NodeDeletionVetoWebhookConfig{URL: server.URL, Timeout: time.Second, IgnoreFailures: true})
+	assert.Equal(t, candidates, processor.GetNodesToRemove(ctx, candidates, len(candidates)))
+}
+
+func TestDeletionVetoProcessorDeadline(t *testing.T) {
+	node := BuildTestNode("n1", 1000, 1000)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	candidates := []simulator.NodeToBeRemoved{{Node: node}}
+	ctx := &context.AutoscalingContext{CloudProvider: provider}
+
+	// The headers are sent in time, but the body isn't complete before the deadline.
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	processor := NewDeletionVetoProcessor(config.NodeDeletionVetoWebhookConfig{URL: server.URL, Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	assert.Empty(t, processor.GetNodesToRemove(ctx, candidates, len(candidates)))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packinghints
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kube_client "k8s.io/client-go/kubernetes"
+	klog "k8s.io/klog/v2"
+
+	ca_context "k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+)
+
+const (
+	// PlannedNodeGroupAnnotation is the pod annotation under which the id of the node group the pod
+	// was planned onto by a scale-up is exported, so that a scheduler plugin can prefer its upcoming
+	// nodes when placing the pod.
+	PlannedNodeGroupAnnotation = "cluster-autoscaler.kubernetes.io/planned-node-group"
+	// PlannedAtAnnotation is the pod annotation under which the time of the scale-up planning the
+	// pod onto PlannedNodeGroupAnnotation is exported, so that stale hints can be ignored.
+	PlannedAtAnnotation = "cluster-autoscaler.kubernetes.io/planned-at"
+)
+
+// ScaleUpStatusProcessor exports packing hints after successful scale-ups: the
+// pods which triggered a scale-up are annotated with the node group they were
+// planned onto, reducing the mismatch between the scale-up simulation and the
+// eventual placement by the scheduler. The pods are patched in the background,
+// so that the main loop doesn't wait for the API server.
+type ScaleUpStatusProcessor struct {
+	client  kube_client.Interface
+	maxPods int
+	now     func() time.Time
+	// exporting is set while the hints of a scale-up are exported in the background.
+	exporting atomic.Bool
+	wg        sync.WaitGroup
+}
+
+var _ status.ScaleUpStatusProcessor = &ScaleUpStatusProcessor{}
+
+// packingHint is the node group a pod was planned onto.
+type packingHint struct {
+	pod       *apiv1.Pod
+	nodeGroup string
+}
+
+// NewScaleUpStatusProcessor returns a ScaleUpStatusProcessor annotating at most
+// maxPods pods after each scale-up.
+func NewScaleUpStatusProcessor(client kube_client.Interface, maxPods int) *ScaleUpStatusProcessor {
+	return &ScaleUpStatusProcessor{client: client, maxPods: maxPods, now: time.Now}
+}
+
+// Process annotates the pods which triggered a successful scale-up with the node group each was
+// planned onto. The hints of a scale-up done while the previous ones are still exported are dropped.
+func (p *ScaleUpStatusProcessor) Process(context *ca_context.AutoscalingContext, scaleUpStatus *status.ScaleUpStatus) {
+	if !scaleUpStatus.WasSuccessful() || len(scaleUpStatus.PodsPlannedNodeGroups) == 0 {
+		return
+	}
+	var hints []packingHint
+	for _, pod := range scaleUpStatus.PodsTriggeredScaleUp {
+		nodeGroup, found := scaleUpStatus.PodsPlannedNodeGroups[pod.UID]
+		if !found || pod.Annotations[PlannedNodeGroupAnnotation] == nodeGroup {
+			continue
+		}
+		if len(hints) >= p.maxPods {
+			klog.V(4).Infof("Exporting packing hints of %d pods, skipping the remaining ones", len(hints))
+			break
+		}
+		hints = append(hints, packingHint{pod: pod, nodeGroup: nodeGroup})
+	}
+	if len(hints) == 0 {
+		return
+	}
+	if !p.exporting.CompareAndSwap(false, true) {
+		klog.V(4).Infof("Still exporting packing hints of a previous scale-up, dropping those of %d pods", len(hints))
+		return
+	}
+	plannedAt := p.now().UTC().Format(time.RFC3339)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.exporting.Store(false)
+		for _, hint := range hints {
+			p.patchAnnotations(hint.pod, hint.nodeGroup, plannedAt)
+		}
+	}()
+}
+
+// CleanUp waits for the packing hints being exported.
+func (p *ScaleUpStatusProcessor) CleanUp() {
+	p.wg.Wait()
+}
+
+func (p *ScaleUpStatusProcessor) patchAnnotations(pod *apiv1.Pod, nodeGroup, plannedAt string) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				PlannedNodeGroupAnnotation: nodeGroup,
+				PlannedAtAnnotation:        plannedAt,
+			},
+		},
+	})
+	if err != nil {
+		klog.Errorf("Failed to build packing hints patch for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	if _, err := p.client.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.Warningf("Failed to export packing hints of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packinghints
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestExportPackingHints(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	provider.AddNodeGroup("ng2", 0, 10, 1)
+	provider.AddNodeGroup("ng3", 0, 10, 1)
+	scaleUpInfos := []nodegroupset.ScaleUpInfo{
+		{Group: provider.GetNodeGroup("ng1"), CurrentSize: 1, NewSize: 3},
+		{Group: provider.GetNodeGroup("ng2"), CurrentSize: 1, NewSize: 2},
+		{Group: provider.GetNodeGroup("ng3"), CurrentSize: 1, NewSize: 1},
+	}
+
+	p1 := BuildTestPod("p1", 100, 100)
+	p2 := BuildTestPod("p2", 100, 100)
+	p3 := BuildTestPod("p3", 100, 100)
+	p3.Annotations = map[string]string{PlannedNodeGroupAnnotation: "ng2"}
+	p4 := BuildTestPod("p4", 100, 100)
+	planned := map[types.UID]string{p1.UID: "ng1", p2.UID: "ng1", p3.UID: "ng2"}
+
+	for name, tc := range map[string]struct {
+		status   *status.ScaleUpStatus
+		maxPods  int
+		expected map[string]string
+	}{
+		"successful scale-up": {
+			status: &status.ScaleUpStatus{
+				Result:                status.ScaleUpSuccessful,
+				ScaleUpInfos:          scaleUpInfos,
+				PodsTriggeredScaleUp:  []*apiv1.Pod{p1, p2, p3},
+				PodsPlannedNodeGroups: planned,
+			},
+			maxPods:  10,
+			expected: map[string]string{"p1": "ng1", "p2": "ng1"},
+		},
+		"at most max pods are annotated": {
+			status: &status.ScaleUpStatus{
+				Result:                status.ScaleUpSuccessful,
+				ScaleUpInfos:          scaleUpInfos,
+				PodsTriggeredScaleUp:  []*apiv1.Pod{p1, p3, p2},
+				PodsPlannedNodeGroups: planned,
+			},
+			maxPods:  1,
+			expected: map[string]string{"p1": "ng1"},
+		},
+		"failed scale-up": {
+			status: &status.ScaleUpStatus{
+				Result:                status.ScaleUpError,
+				ScaleUpInfos:          scaleUpInfos,
+				PodsTriggeredScaleUp:  []*apiv1.Pod{p1, p2},
+				PodsPlannedNodeGroups: planned,
+			},
+			maxPods:  10,
+			expected: map[string]string{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(p1, p2, p3, p4)
+			p := NewScaleUpStatusProcessor(client, tc.maxPods)
+			p.now = func() time.Time { return now }
+			p.Process(nil, tc.status)
+			p.CleanUp()
+
+			for _, name := range []string{"p1", "p2", "p4"} {
+				pod, err := client.CoreV1().Pods(p1.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected[name], pod.Annotations[PlannedNodeGroupAnnotation], name)
+				if _, found := tc.expected[name]; found {
+					assert.Equal(t, now.Format(time.RFC3339), pod.Annotations[PlannedAtAnnotation], name)
+				}
+			}
+			for _, action := range client.Actions() {
+				if patch, ok := action.(core.PatchAction); ok {
+					assert.NotEqual(t, "p3", patch.GetName())
+				}
+			}
+		})
+	}
+}
@@ -84,14 +84,9 @@ func DefaultProcessors(options config.AutoscalingOptions) *AutoscalingProcessors
 			MaxCapacityMemoryDifferenceRatio: config.DefaultMaxCapacityMemoryDifferenceRatio,
 			MaxFreeDifferenceRatio:           config.DefaultMaxFreeDifferenceRatio,
 		}),
-		ScaleUpStatusProcessor: status.NewDefaultScaleUpStatusProcessor(),
-		ScaleDownNodeProcessor: nodes.NewPreFilteringScaleDownNodeProcessor(),
-		ScaleDownSetProcessor: nodes.NewCompositeScaleDownSetProcessor(
-			[]nodes.ScaleDownSetProcessor{
-				nodes.NewMaxNodesProcessor(),
-				nodes.NewAtomicResizeFilteringProcessor(),
-			},
-		),
+		ScaleUpStatusProcessor:      status.NewEventingScaleUpStatusProcessor(options.NotTriggerScaleUpEventWindow),
+		ScaleDownNodeProcessor:      nodes.NewPreFilteringScaleDownNodeProcessor(),
+		ScaleDownSetProcessor:       nodes.NewCompositeScaleDownSetProcessor(scaleDownSetProcessors(options)),
 		ScaleDownStatusProcessor:    status.NewDefaultScaleDownStatusProcessor(),
 		AutoscalingStatusProcessor:  status.NewDefaultAutoscalingStatusProcessor(),
 		NodeGroupManager:            nodegroups.NewDefaultNodeGroupManager(),
@@ -104,6 +99,15 @@ func DefaultProcessors(options config.AutoscalingOptions) *AutoscalingProcessors
 	}
 }
 
+func scaleDownSetProcessors(options config.AutoscalingOptions) []nodes.ScaleDownSetProcessor {
+	processors := []nodes.ScaleDownSetProcessor{nodes.NewMaxNodesProcessor()}
+	if options.NodeDeletionVetoWebhook.URL != "" {
+		processors = append(processors, nodes.NewDeletionVetoProcessor(options.NodeDeletionVetoWebhook))
+	}
+	// AtomicResizeFilteringProcessor has to be the last one.
+	return append(processors, nodes.NewAtomicResizeFilteringProcessor())
+}
+
 // CleanUp cleans up the processors' internal structures.
 func (ap *AutoscalingProcessors) CleanUp() {
 	ap.PodListProcessor.CleanUp()
@@ -0,0 +1,382 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/autoscaler/cluster-autoscaler/apis/provisioningrequest/autoscaling.x-k8s.io/v1beta1"
+	provreqpods "k8s.io/autoscaler/cluster-autoscaler/provisioningrequest/pods"
+	"k8s.io/autoscaler/cluster-autoscaler/provisioningrequest/provreqclient"
+	"k8s.io/autoscaler/cluster-autoscaler/provisioningrequest/provreqwrapper"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/dynamic/dynamiclister"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CapacityReady is the condition type set on Kueue Workloads reporting
+	// whether the capacity provisioned for them is available.
+	CapacityReady = "CapacityReady"
+	// CapacityProvisionedReason is the reason of the CapacityReady=True condition.
+	CapacityProvisionedReason = "CapacityProvisioned"
+	// CapacityPendingReason is the reason of the CapacityReady=False condition
+	// while the capacity is being provisioned.
+	CapacityPendingReason = "CapacityPending"
+	// CapacityProvisioningFailedReason is the reason of the CapacityReady=False
+	// condition when the capacity couldn't be provisioned.
+	CapacityProvisioningFailedReason = "CapacityProvisioningFailed"
+
+	// provReqNamePrefix is the prefix of the ProvisioningRequests created for Workloads.
+	provReqNamePrefix = "kueue-"
+	// defaultMaxUpdated is a limit for Workloads to create ProvisioningRequests for
+	// or update conditions of in one ClusterAutoscaler loop.
+	defaultMaxUpdated      = 20
+	kueueClientCallTimeout = 4 * time.Second
+
+	// Kueue Workload condition types.
+	quotaReserved = "QuotaReserved"
+	admitted      = "Admitted"
+	finished      = "Finished"
+
+	// Kueue admission check states.
+	checkStatePending  = "Pending"
+	checkStateReady    = "Ready"
+	checkStateRejected = "Rejected"
+)
+
+// WorkloadGroupVersionResource identifies the Kueue Workload resource.
+var WorkloadGroupVersionResource = schema.GroupVersionResource{Group: "kueue.x-k8s.io", Version: "v1beta1", Resource: "workloads"}
+
+// workload is the subset of the Kueue Workload API used by the processor.
+type workload struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              workloadSpec   `json:"spec,omitempty"`
+	Status            workloadStatus `json:"status,omitempty"`
+}
+
+type workloadSpec struct {
+	PodSets []workloadPodSet `json:"podSets,omitempty"`
+}
+
+type workloadPodSet struct {
+	Name     string                `json:"name"`
+	Count    int32                 `json:"count"`
+	Template apiv1.PodTemplateSpec `json:"template"`
+}
+
+type workloadStatus struct {
+	Conditions      []metav1.Condition    `json:"conditions,omitempty"`
+	AdmissionChecks []admissionCheckState `json:"admissionChecks,omitempty"`
+}
+
+type admissionCheckState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// WorkloadProcessor provisions capacity for Kueue Workloads waiting for admission
+// with ProvisioningRequests, before Kueue admits them and their pods are created.
+// The state of the ProvisioningRequests is reported back in the CapacityReady
+// condition of the Workloads and in their admission check, if they have one
+// managed by the processor. Once the capacity is provisioned, the admission
+// check asks Kueue to annotate the pods of the Workload, so they consume the
+// ProvisioningRequest instead of triggering another scale-up.
+type WorkloadProcessor struct {
+	now                   func() time.Time
+	maxUpdated            int
+	client                dynamic.Interface
+	lister                dynamiclister.Lister
+	provReqClient         *provreqclient.ProvisioningRequestClient
+	provisioningClassName string
+	admissionCheckName    string
+}
+
+// NewWorkloadProcessor returns a WorkloadProcessor creating ProvisioningRequests of the given class
+// and managing the admission check of the given name.
+func NewWorkloadProcessor(client dynamic.Interface, provReqClient *provreqclient.ProvisioningRequestClient, provisioningClassName, admissionCheckName string) (*WorkloadProcessor, error) {
+	stopChannel := make(chan struct{})
+	lister, err := newWorkloadsLister(client, stopChannel)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkloadProcessor{
+		now:                   time.Now,
+		maxUpdated:            defaultMaxUpdated,
+		client:                client,
+		lister:                lister,
+		provReqClient:         provReqClient,
+		provisioningClassName: provisioningClassName,
+		admissionCheckName:    admissionCheckName,
+	}, nil
+}
+
+// newWorkloadsLister creates a lister for the Kueue Workloads in the cluster.
+func newWorkloadsLister(client dynamic.Interface, stopChannel <-chan struct{}) (dynamiclister.Lister, error) {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 1*time.Hour)
+	informer := factory.ForResource(WorkloadGroupVersionResource).Informer()
+	factory.Start(stopChannel)
+	informersSynced := factory.WaitForCacheSync(stopChannel)
+	for _, synced := range informersSynced {
+		if !synced {
+			return nil, fmt.Errorf("can't create Kueue Workload lister")
+		}
+	}
+	klog.V(2).Info("Successful initial Kueue Workload sync")
+	return dynamiclister.New(informer.GetIndexer(), WorkloadGroupVersionResource), nil
+}
+
+// Refresh implements loop.Observer interface and will be run at the start
+// of every iteration of the main loop. It creates ProvisioningRequests for
+// Workloads waiting for admission and updates the CapacityReady condition
+// of Workloads whose ProvisioningRequests changed their state.
+func (p *WorkloadProcessor) Refresh() {
+	objs, err := p.lister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to get Kueue Workloads list, err: %v", err)
+		return
+	}
+	updated := 0
+	for _, obj := range objs {
+		if updated >= p.maxUpdated {
+			break
+		}
+		wl := &workload{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, wl); err != nil {
+			klog.Errorf("Failed to parse Kueue Workload %s/%s, err: %v", obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		if !admissionPending(wl) {
+			continue
+		}
+		changed, err := p.processWorkload(obj, wl)
+		if err != nil {
+			klog.Errorf("Failed to provision capacity for Kueue Workload %s/%s, err: %v", wl.Namespace, wl.Name, err)
+			continue
+		}
+		if changed {
+			updated++
+		}
+	}
+}
+
+// CleanUp cleans up internal state.
+func (p *WorkloadProcessor) CleanUp() {}
+
+// processWorkload creates the ProvisioningRequest for the Workload if it doesn't exist yet,
+// or reports its state otherwise. It returns whether any object was created or updated.
+func (p *WorkloadProcessor) processWorkload(obj *unstructured.Unstructured, wl *workload) (bool, error) {
+	provReq, err := p.provReqClient.ProvisioningRequest(wl.Namespace, provReqName(wl))
+	if errors.IsNotFound(err) {
+		err = p.provReqClient.CreateProvisioningRequest(p.provisioningRequestForWorkload(wl))
+		if errors.IsAlreadyExists(err) {
+			// The lister didn't catch up with the ProvisioningRequest created in the previous loop yet.
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, p.setCapacityReady(obj, wl, metav1.ConditionFalse, CapacityPendingReason, "Waiting for capacity to be provisioned", provReqName(wl))
+	}
+	if err != nil {
+		return false, err
+	}
+	status, reason, message := capacityReadyCondition(provReq)
+	current := apimeta.FindStatusCondition(wl.Status.Conditions, CapacityReady)
+	if current != nil && current.Status == status && current.Reason == reason && p.admissionCheckUpToDate(wl, status, reason) {
+		return false, nil
+	}
+	return true, p.setCapacityReady(obj, wl, status, reason, message, provReq.Name)
+}
+
+// admissionCheckUpToDate tells whether the admission check of the Workload managed
+// by the processor, if any, is in the state matching the CapacityReady condition.
+func (p *WorkloadProcessor) admissionCheckUpToDate(wl *workload, status metav1.ConditionStatus, reason string) bool {
+	for _, check := range wl.Status.AdmissionChecks {
+		if p.admissionCheckName != "" && check.Name == p.admissionCheckName {
+			return check.State == admissionCheckStateFor(status, reason)
+		}
+	}
+	return true
+}
+
+// setCapacityReady sets the CapacityReady condition in the status of the Workload,
+// together with the state of the admission check managed by the processor.
+func (p *WorkloadProcessor) setCapacityReady(obj *unstructured.Unstructured, wl *workload, status metav1.ConditionStatus, reason, message, provReqName string) error {
+	apimeta.SetStatusCondition(&wl.Status.Conditions, metav1.Condition{
+		Type:               CapacityReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: wl.Generation,
+		LastTransitionTime: metav1.NewTime(p.now()),
+	})
+	conditions := make([]interface{}, 0, len(wl.Status.Conditions))
+	for i := range wl.Status.Conditions {
+		condition, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&wl.Status.Conditions[i])
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, condition)
+	}
+	obj = obj.DeepCopy()
+	if err := unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions"); err != nil {
+		return err
+	}
+	if err := p.setAdmissionCheck(obj, wl, admissionCheckStateFor(status, reason), message, provReqName); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kueueClientCallTimeout)
+	defer cancel()
+	if _, err := p.client.Resource(WorkloadGroupVersionResource).Namespace(wl.Namespace).UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update %s condition of Kueue Workload %s/%s: %v", CapacityReady, wl.Namespace, wl.Name, err)
+	}
+	klog.V(4).Infof("Updated %s condition of Kueue Workload %s/%s to %s, reason: %s", CapacityReady, wl.Namespace, wl.Name, status, reason)
+	return nil
+}
+
+// setAdmissionCheck sets the state of the admission check managed by the processor
+// in the Workload object. Once the check is ready, it carries pod set updates
+// annotating the pods of the Workload with the ProvisioningRequest they consume,
+// which Kueue applies when it creates the pods. Other admission checks, and
+// fields of the check not managed by the processor, are left intact.
+func (p *WorkloadProcessor) setAdmissionCheck(obj *unstructured.Unstructured, wl *workload, state, message, provReqName string) error {
+	if p.admissionCheckName == "" {
+		return nil
+	}
+	checks, found, err := unstructured.NestedSlice(obj.Object, "status", "admissionChecks")
+	if err != nil || !found {
+		return err
+	}
+	for i := range checks {
+		check, ok := checks[i].(map[string]interface{})
+		if !ok || check["name"] != p.admissionCheckName {
+			continue
+		}
+		if check["state"] != state {
+			check["lastTransitionTime"] = p.now().UTC().Format(time.RFC3339)
+		}
+		check["state"] = state
+		check["message"] = message
+		if state == checkStateReady {
+			podSetUpdates := make([]interface{}, 0, len(wl.Spec.PodSets))
+			for _, podSet := range wl.Spec.PodSets {
+				podSetUpdates = append(podSetUpdates, map[string]interface{}{
+					"name": podSet.Name,
+					"annotations": map[string]interface{}{
+						provreqpods.ProvisioningRequestPodAnnotationKey: provReqName,
+						provreqpods.ProvisioningClassPodAnnotationKey:   p.provisioningClassName,
+					},
+				})
+			}
+			check["podSetUpdates"] = podSetUpdates
+		} else {
+			delete(check, "podSetUpdates")
+		}
+		checks[i] = check
+	}
+	return unstructured.SetNestedSlice(obj.Object, checks, "status", "admissionChecks")
+}
+
+// provisioningRequestForWorkload builds the ProvisioningRequest asking for capacity
+// for all pod sets of the Workload. The ProvisioningRequest and its PodTemplates
+// are owned by the Workload, so they are garbage collected together with it.
+func (p *WorkloadProcessor) provisioningRequestForWorkload(wl *workload) *provreqwrapper.ProvisioningRequest {
+	owner := metav1.OwnerReference{
+		APIVersion: WorkloadGroupVersionResource.GroupVersion().String(),
+		Kind:       "Workload",
+		Name:       wl.Name,
+		UID:        wl.UID,
+	}
+	name := provReqName(wl)
+	podSets := make([]v1beta1.PodSet, 0, len(wl.Spec.PodSets))
+	podTemplates := make([]*apiv1.PodTemplate, 0, len(wl.Spec.PodSets))
+	for _, podSet := range wl.Spec.PodSets {
+		podTemplate := &apiv1.PodTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("%s-%s", name, podSet.Name),
+				Namespace:       wl.Namespace,
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Template: podSet.Template,
+		}
+		podTemplates = append(podTemplates, podTemplate)
+		podSets = append(podSets, v1beta1.PodSet{
+			PodTemplateRef: v1beta1.Reference{Name: podTemplate.Name},
+			Count:          podSet.Count,
+		})
+	}
+	return provreqwrapper.NewProvisioningRequest(&v1beta1.ProvisioningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       wl.Namespace,
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: v1beta1.ProvisioningRequestSpec{
+			ProvisioningClassName: p.provisioningClassName,
+			PodSets:               podSets,
+		},
+	}, podTemplates)
+}
+
+// capacityReadyCondition maps the state of the ProvisioningRequest to the CapacityReady condition.
+func capacityReadyCondition(provReq *provreqwrapper.ProvisioningRequest) (metav1.ConditionStatus, string, string) {
+	conditions := provReq.Status.Conditions
+	if failed := apimeta.FindStatusCondition(conditions, v1beta1.Failed); failed != nil && failed.Status == metav1.ConditionTrue {
+		return metav1.ConditionFalse, CapacityProvisioningFailedReason, failed.Message
+	}
+	if provisioned := apimeta.FindStatusCondition(conditions, v1beta1.Provisioned); provisioned != nil && provisioned.Status == metav1.ConditionTrue {
+		return metav1.ConditionTrue, CapacityProvisionedReason, fmt.Sprintf("Capacity provisioned by ProvisioningRequest %s", provReq.Name)
+	}
+	return metav1.ConditionFalse, CapacityPendingReason, "Waiting for capacity to be provisioned"
+}
+
+// admissionCheckStateFor maps the CapacityReady condition to the state of the admission check.
+func admissionCheckStateFor(status metav1.ConditionStatus, reason string) string {
+	if status == metav1.ConditionTrue {
+		return checkStateReady
+	}
+	if reason == CapacityProvisioningFailedReason {
+		return checkStateRejected
+	}
+	return checkStatePending
+}
+
+// admissionPending tells whether the Workload has its quota reserved
+// but isn't admitted yet, i.e. its pods weren't created yet.
+func admissionPending(wl *workload) bool {
+	conditions := wl.Status.Conditions
+	return apimeta.IsStatusConditionTrue(conditions, quotaReserved) &&
+		!apimeta.IsStatusConditionTrue(conditions, admitted) &&
+		!apimeta.IsStatusConditionTrue(conditions, finished)
+}
+
+func provReqName(wl *workload) string {
+	return provReqNamePrefix + wl.Name
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kueue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/apis/provisioningrequest/autoscaling.x-k8s.io/v1beta1"
+	"k8s.io/autoscaler/cluster-autoscaler/provisioningrequest/provreqclient"
+	dynamic_fake "k8s.io/client-go/dynamic/fake"
+)
+
+const testAdmissionCheckName = "cluster-autoscaler"
+
+func buildTestWorkload(t *testing.T, name string, conditionTypes ...string) *unstructured.Unstructured {
+	t.Helper()
+	wl := &workload{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "kueue.x-k8s.io/v1beta1", Kind: "Workload"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+		Spec: workloadSpec{PodSets: []workloadPodSet{{
+			Name:  "main",
+			Count: 4,
+			Template: apiv1.PodTemplateSpec{Spec: apiv1.PodSpec{
+				Containers: []apiv1.Container{{Name: "test-container", Image: "test-image"}},
+			}},
+		}}},
+	}
+	for _, conditionType := range conditionTypes {
+		wl.Status.Conditions = append(wl.Status.Conditions, metav1.Condition{Type: conditionType, Status: metav1.ConditionTrue, Reason: "Test"})
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(wl)
+	assert.NoError(t, err)
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func newFakeDynamicClient(objects ...runtime.Object) *dynamic_fake.FakeDynamicClient {
+	return dynamic_fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{WorkloadGroupVersionResource: "WorkloadList"}, objects...)
+}
+
+func getCapacityReady(t *testing.T, client *dynamic_fake.FakeDynamicClient, name string) *metav1.Condition {
+	t.Helper()
+	obj, err := client.Resource(WorkloadGroupVersionResource).Namespace("default").Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	wl := &workload{}
+	assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, wl))
+	return apimeta.FindStatusCondition(wl.Status.Conditions, CapacityReady)
+}
+
+func TestRefreshCreatesProvisioningRequests(t *testing.T) {
+	client := newFakeDynamicClient(
+		buildTestWorkload(t, "pending", quotaReserved),
+		buildTestWorkload(t, "no-quota"),
+		buildTestWorkload(t, "admitted", quotaReserved, admitted),
+		buildTestWorkload(t, "finished", quotaReserved, finished),
+	)
+	provReqClient := provreqclient.NewFakeProvisioningRequestClient(context.Background(), t)
+	processor, err := NewWorkloadProcessor(client, provReqClient, v1beta1.ProvisioningClassBestEffortAtomicScaleUp, testAdmissionCheckName)
+	assert.NoError(t, err)
+
+	processor.Refresh()
+
+	provReq, err := provReqClient.ProvisioningRequestNoCache("default", "kueue-pending")
+	assert.NoError(t, err)
+	assert.Equal(t, v1beta1.ProvisioningClassBestEffortAtomicScaleUp, provReq.Spec.ProvisioningClassName)
+	assert.Equal(t, "Workload", provReq.OwnerReferences[0].Kind)
+	assert.Equal(t, []v1beta1.PodSet{{PodTemplateRef: v1beta1.Reference{Name: "kueue-pending-main"}, Count: 4}}, provReq.Spec.PodSets)
+	condition := getCapacityReady(t, client, "pending")
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, CapacityPendingReason, condition.Reason)
+
+	for _, name := range []string{"no-quota", "admitted", "finished"} {
+		_, err := provReqClient.ProvisioningRequestNoCache("default", "kueue-"+name)
+		assert.Error(t, err, name)
+		assert.Nil(t, getCapacityReady(t, client, name), name)
+	}
+}
+
+func TestRefreshReportsCapacityReady(t *testing.T) {
+	testCases := []struct {
+		name          string
+		provReqStatus []metav1.Condition
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name:       "provisioning in progress",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: CapacityPendingReason,
+		},
+		{
+			name:          "provisioned",
+			provReqStatus: []metav1.Condition{{Type: v1beta1.Provisioned, Status: metav1.ConditionTrue}},
+			wantStatus:    metav1.ConditionTrue,
+			wantReason:    CapacityProvisionedReason,
+		},
+		{
+			name:          "failed",
+			provReqStatus: []metav1.Condition{{Type: v1beta1.Failed, Status: metav1.ConditionTrue, Message: "out of stock"}},
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    CapacityProvisioningFailedReason,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeDynamicClient(buildTestWorkload(t, "wl", quotaReserved))
+			provReq := provreqclient.ProvisioningRequestWrapperForTesting("default", "kueue-wl")
+			provReq.Status.Conditions = tc.provReqStatus
+			provReqClient := provreqclient.NewFakeProvisioningRequestClient(context.Background(), t, provReq)
+			processor, err := NewWorkloadProcessor(client, provReqClient, v1beta1.ProvisioningClassBestEffortAtomicScaleUp, testAdmissionCheckName)
+			assert.NoError(t, err)
+
+			processor.Refresh()
+
+			condition := getCapacityReady(t, client, "wl")
+			assert.NotNil(t, condition)
+			assert.Equal(t, tc.wantStatus, condition.Status)
+			assert.Equal(t, tc.wantReason, condition.Reason)
+		})
+	}
+}
+
+func TestRefreshUpdatesAdmissionCheck(t *testing.T) {
+	testCases := []struct {
+		name              string
+		provReqStatus     []metav1.Condition
+		wantState         string
+		wantPodSetUpdates []interface{}
+	}{
+		{
+			name:      "provisioning in progress",
+			wantState: checkStatePending,
+		},
+		{
+			name:          "provisioned",
+			provReqStatus: []metav1.Condition{{Type: v1beta1.Provisioned, Status: metav1.ConditionTrue}},
+			wantState:     checkStateReady,
+			wantPodSetUpdates: []interface{}{map[string]interface{}{
+				"name": "main",
+				"annotations": map[string]interface{}{
+					"cluster-autoscaler.kubernetes.io/consume-provisioning-request": "kueue-wl",
+					"cluster-autoscaler.kubernetes.io/provisioning-class-name":      v1beta1.ProvisioningClassBestEffortAtomicScaleUp,
+				},
+			}},
+		},
+		{
+			name:          "failed",
+			provReqStatus: []metav1.Condition{{Type: v1beta1.Failed, Status: metav1.ConditionTrue, Message: "out of stock"}},
+			wantState:     checkStateRejected,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := buildTestWorkload(t, "wl", quotaReserved)
+			checks := []interface{}{
+				map[string]interface{}{"name": testAdmissionCheckName, "state": checkStatePending, "message": ""},
+				map[string]interface{}{"name": "other", "state": checkStatePending, "message": "other check"},
+			}
+			assert.NoError(t, unstructured.SetNestedSlice(obj.Object, checks, "status", "admissionChecks"))
+			client := newFakeDynamicClient(obj)
+			provReq := provreqclient.ProvisioningRequestWrapperForTesting("default", "kueue-wl")
+			provReq.Status.Conditions = tc.provReqStatus
+			provReqClient := provreqclient.NewFakeProvisioningRequestClient(context.Background(), t, provReq)
+			processor, err := NewWorkloadProcessor(client, provReqClient, v1beta1.ProvisioningClassBestEffortAtomicScaleUp, testAdmissionCheckName)
+			assert.NoError(t, err)
+
+			processor.Refresh()
+
+			updated, err := client.Resource(WorkloadGroupVersionResource).Namespace("default").Get(context.TODO(), "wl", metav1.GetOptions{})
+			assert.NoError(t, err)
+			checks, _, err = unstructured.NestedSlice(updated.Object, "status", "admissionChecks")
+			assert.NoError(t, err)
+			assert.Len(t, checks, 2)
+			check := checks[0].(map[string]interface{})
+			assert.Equal(t, tc.wantState, check["state"])
+			podSetUpdates, _, err := unstructured.NestedSlice(check, "podSetUpdates")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantPodSetUpdates, podSetUpdates)
+			assert.Equal(t, map[string]interface{}{"name": "other", "state": checkStatePending, "message": "other check"}, checks[1])
+		})
+	}
+}
@@ -28,6 +28,14 @@ type Observer interface {
 	UpdateScaleDownCandidates([]*apiv1.Node, time.Time)
 }
 
+// CooldownObserver is an Observer which also acts on the scale down candidates, and so
+// is notified whether scale down is in cooldown once the candidates are updated.
+type CooldownObserver interface {
+	Observer
+	// UpdateScaleDownInCooldown is called after UpdateScaleDownCandidates, with whether scale down is in cooldown.
+	UpdateScaleDownInCooldown(inCooldown bool, now time.Time)
+}
+
 // ObserversList is a slice of observers of scale down candidates
 type ObserversList struct {
 	observers []Observer
@@ -45,6 +53,15 @@ func (l *ObserversList) Update(nodes []*apiv1.Node, now time.Time) {
 	}
 }
 
+// UpdateScaleDownInCooldown notifies the observers implementing CooldownObserver whether scale down is in cooldown.
+func (l *ObserversList) UpdateScaleDownInCooldown(inCooldown bool, now time.Time) {
+	for _, observer := range l.observers {
+		if cooldownObserver, ok := observer.(CooldownObserver); ok {
+			cooldownObserver.UpdateScaleDownInCooldown(inCooldown, now)
+		}
+	}
+}
+
 // NewObserversList return empty list of observers.
 func NewObserversList() *ObserversList {
 	return &ObserversList{}
@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"time"
+
+	klog "k8s.io/klog/v2"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+)
+
+// ExpanderFilter trims expansion options to the number of nodes the scale-up
+// quotas of their pods still allow, so that a single scale-up can't overshoot
+// a quota which isn't used up yet. Options which can't add any node are dropped.
+type ExpanderFilter struct {
+	tracker *Tracker
+	now     func() time.Time
+}
+
+// NewExpanderFilter returns an expander filter enforcing quotas of the tracker.
+func NewExpanderFilter(tracker *Tracker) *ExpanderFilter {
+	return &ExpanderFilter{tracker: tracker, now: time.Now}
+}
+
+// BestOptions returns the options trimmed to the remaining scale-up quotas.
+func (f *ExpanderFilter) BestOptions(options []expander.Option, nodeInfos map[string]*schedulerframework.NodeInfo) []expander.Option {
+	now := f.now()
+	result := make([]expander.Option, 0, len(options))
+	for _, option := range options {
+		perNode := usage{nodes: 1}
+		if nodeInfo, found := nodeInfos[option.NodeGroup.Id()]; found && nodeInfo.Node() != nil {
+			capacity := nodeInfo.Node().Status.Capacity
+			perNode.milliCPU = float64(capacity.Cpu().MilliValue())
+			perNode.memory = float64(capacity.Memory().Value())
+		}
+		allowed := f.tracker.AllowedNodes(option.Pods, perNode, now)
+		if allowed < option.NodeCount {
+			klog.V(2).Infof("Scale-up of %s trimmed from %d to %d nodes by scale-up quotas", option.NodeGroup.Id(), option.NodeCount, allowed)
+			option.NodeCount = allowed
+		}
+		if option.NodeCount > 0 {
+			result = append(result, option)
+		}
+	}
+	return result
+}
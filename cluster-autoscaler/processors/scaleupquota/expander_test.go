@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestExpanderFilter(t *testing.T) {
+	quotas, err := ParseQuotas([]string{"namespace:team-a:nodes=3", "label:tenant=blue:cpu=5"})
+	assert.NoError(t, err)
+	tracker := NewTracker(quotas, time.Hour)
+	now := time.Now()
+	filter := NewExpanderFilter(tracker)
+	filter.now = func() time.Time { return now }
+
+	template := schedulerframework.NewNodeInfo()
+	template.SetNode(BuildTestNode("template", 2000, 1000))
+	nodeInfos := map[string]*schedulerframework.NodeInfo{"ng1": template, "ng2": template}
+	provider := testprovider.NewTestAutoprovisioningCloudProvider(nil, nil, nil, nil, nil, nodeInfos)
+	provider.AddNodeGroup("ng1", 0, 10, 0)
+	provider.AddNodeGroup("ng2", 0, 10, 0)
+	ng1 := provider.GetNodeGroup("ng1")
+	ng2 := provider.GetNodeGroup("ng2")
+
+	teamA := BuildTestPod("team-a", 100, 100)
+	teamA.Namespace = "team-a"
+	blue := BuildTestPod("blue", 100, 100)
+	blue.Labels = map[string]string{"tenant": "blue"}
+	other := BuildTestPod("other", 100, 100)
+
+	// One node already provisioned for team-a.
+	tracker.Record(quotas[0], now, usage{nodes: 1, milliCPU: 2000})
+
+	for desc, tc := range map[string]struct {
+		options []expander.Option
+		want    []int
+	}{
+		"pods without quota": {
+			options: []expander.Option{{NodeGroup: ng1, NodeCount: 8, Pods: []*apiv1.Pod{other}}},
+			want:    []int{8},
+		},
+		"trimmed to remaining nodes": {
+			options: []expander.Option{{NodeGroup: ng1, NodeCount: 5, Pods: []*apiv1.Pod{teamA}}},
+			want:    []int{2},
+		},
+		"nodes attributed proportionally": {
+			options: []expander.Option{{NodeGroup: ng1, NodeCount: 5, Pods: []*apiv1.Pod{teamA, other}}},
+			want:    []int{4},
+		},
+		"trimmed to remaining cpu": {
+			options: []expander.Option{
+				{NodeGroup: ng1, NodeCount: 1, Pods: []*apiv1.Pod{blue}},
+				{NodeGroup: ng2, NodeCount: 4, Pods: []*apiv1.Pod{blue}},
+			},
+			want: []int{1, 2},
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			var got []int
+			for _, option := range filter.BestOptions(tc.options, nodeInfos) {
+				got = append(got, option.NodeCount)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	// Options for used up quotas are dropped.
+	tracker.Record(quotas[0], now, usage{nodes: 2})
+	assert.Empty(t, filter.BestOptions([]expander.Option{{NodeGroup: ng1, NodeCount: 1, Pods: []*apiv1.Pod{teamA}}}, nodeInfos))
+}
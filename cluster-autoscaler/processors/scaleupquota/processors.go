@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+)
+
+// PodListProcessor filters out unschedulable pods matching a used up
+// scale-up quota, so that they don't trigger scale-up.
+type PodListProcessor struct {
+	tracker *Tracker
+	now     func() time.Time
+}
+
+// NewPodListProcessor returns a PodListProcessor enforcing quotas of the tracker.
+func NewPodListProcessor(tracker *Tracker) *PodListProcessor {
+	return &PodListProcessor{tracker: tracker, now: time.Now}
+}
+
+// Process filters out pods matching a used up quota and emits an event for them.
+func (p *PodListProcessor) Process(context *context.AutoscalingContext, unschedulablePods []*apiv1.Pod) ([]*apiv1.Pod, error) {
+	now := p.now()
+	result := make([]*apiv1.Pod, 0, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		quota, usage := p.tracker.ExceededQuota(pod, now)
+		if quota == nil {
+			result = append(result, pod)
+			continue
+		}
+		klog.V(4).Infof("Pod %s/%s is not considered for scale-up, scale-up quota %s exceeded: %s", pod.Namespace, pod.Name, quota.Name, usage)
+		context.Recorder.Eventf(pod, apiv1.EventTypeWarning, "ScaleUpQuotaExceeded",
+			"pod didn't trigger scale-up: scale-up quota %s exceeded, %s", quota.Name, usage)
+	}
+	return result, nil
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *PodListProcessor) CleanUp() {
+}
+
+// ScaleUpStatusProcessor attributes nodes added by successful scale-ups to
+// quotas matching the pods which triggered them.
+type ScaleUpStatusProcessor struct {
+	tracker *Tracker
+	now     func() time.Time
+}
+
+// NewScaleUpStatusProcessor returns a ScaleUpStatusProcessor recording usage of quotas of the tracker.
+func NewScaleUpStatusProcessor(tracker *Tracker) *ScaleUpStatusProcessor {
+	return &ScaleUpStatusProcessor{tracker: tracker, now: time.Now}
+}
+
+// Process records usage of quotas by a successful scale-up.
+func (p *ScaleUpStatusProcessor) Process(context *context.AutoscalingContext, scaleUpStatus *status.ScaleUpStatus) {
+	if !scaleUpStatus.WasSuccessful() || len(scaleUpStatus.PodsTriggeredScaleUp) == 0 {
+		return
+	}
+	var added usage
+	for _, info := range scaleUpStatus.ScaleUpInfos {
+		delta := float64(info.NewSize - info.CurrentSize)
+		if delta <= 0 {
+			continue
+		}
+		added.nodes += delta
+		nodeInfo, err := info.Group.TemplateNodeInfo()
+		if err != nil {
+			klog.Warningf("Cannot get template of node group %s, only nodes are counted against scale-up quotas: %v", info.Group.Id(), err)
+			continue
+		}
+		capacity := nodeInfo.Node().Status.Capacity
+		added.milliCPU += delta * float64(capacity.Cpu().MilliValue())
+		added.memory += delta * float64(capacity.Memory().Value())
+	}
+
+	now := p.now()
+	total := float64(len(scaleUpStatus.PodsTriggeredScaleUp))
+	for _, quota := range p.tracker.quotas {
+		matching := 0
+		for _, pod := range scaleUpStatus.PodsTriggeredScaleUp {
+			if quota.Matches(pod) {
+				matching++
+			}
+		}
+		if matching == 0 {
+			continue
+		}
+		share := float64(matching) / total
+		p.tracker.Record(quota, now, usage{
+			nodes:    added.nodes * share,
+			milliCPU: added.milliCPU * share,
+			memory:   added.memory * share,
+		})
+	}
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *ScaleUpStatusProcessor) CleanUp() {
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	kube_record "k8s.io/client-go/tools/record"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestScaleUpQuotas(t *testing.T) {
+	quotas, err := ParseQuotas([]string{"namespace:team-a:nodes=2", "label:tenant=blue:cpu=2"})
+	assert.NoError(t, err)
+	tracker := NewTracker(quotas, time.Hour)
+	now := time.Now()
+	podListProcessor := NewPodListProcessor(tracker)
+	podListProcessor.now = func() time.Time { return now }
+	statusProcessor := NewScaleUpStatusProcessor(tracker)
+	statusProcessor.now = func() time.Time { return now }
+
+	template := schedulerframework.NewNodeInfo()
+	template.SetNode(BuildTestNode("template", 2000, 1000))
+	provider := testprovider.NewTestAutoprovisioningCloudProvider(nil, nil, nil, nil, nil,
+		map[string]*schedulerframework.NodeInfo{"ng1": template})
+	provider.AddNodeGroup("ng1", 0, 10, 0)
+	ng1 := provider.GetNodeGroup("ng1")
+
+	teamA := BuildTestPod("team-a", 100, 100)
+	teamA.Namespace = "team-a"
+	blue := BuildTestPod("blue", 100, 100)
+	blue.Labels = map[string]string{"tenant": "blue"}
+	other := BuildTestPod("other", 100, 100)
+	pods := []*apiv1.Pod{teamA, blue, other}
+
+	fakeRecorder := kube_record.NewFakeRecorder(5)
+	ctx := &context.AutoscalingContext{
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			Recorder: fakeRecorder,
+		},
+	}
+
+	filtered, err := podListProcessor.Process(ctx, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, pods, filtered)
+
+	// Three nodes of 2 CPUs each, a third of them attributed to each quota.
+	statusProcessor.Process(ctx, &status.ScaleUpStatus{
+		Result:               status.ScaleUpSuccessful,
+		ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{Group: ng1, CurrentSize: 0, NewSize: 3, MaxSize: 10}},
+		PodsTriggeredScaleUp: []*apiv1.Pod{teamA, blue, other},
+	})
+	filtered, err = podListProcessor.Process(ctx, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apiv1.Pod{teamA, other}, filtered)
+	assert.Contains(t, <-fakeRecorder.Events, "ScaleUpQuotaExceeded")
+
+	// Unsuccessful scale-ups are not counted.
+	statusProcessor.Process(ctx, &status.ScaleUpStatus{
+		Result:               status.ScaleUpError,
+		ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{Group: ng1, CurrentSize: 3, NewSize: 5, MaxSize: 10}},
+		PodsTriggeredScaleUp: []*apiv1.Pod{teamA},
+	})
+	filtered, err = podListProcessor.Process(ctx, []*apiv1.Pod{teamA})
+	assert.NoError(t, err)
+	assert.Equal(t, []*apiv1.Pod{teamA}, filtered)
+
+	statusProcessor.Process(ctx, &status.ScaleUpStatus{
+		Result:               status.ScaleUpSuccessful,
+		ScaleUpInfos:         []nodegroupset.ScaleUpInfo{{Group: ng1, CurrentSize: 3, NewSize: 4, MaxSize: 10}},
+		PodsTriggeredScaleUp: []*apiv1.Pod{teamA},
+	})
+	filtered, err = podListProcessor.Process(ctx, []*apiv1.Pod{teamA})
+	assert.NoError(t, err)
+	assert.Empty(t, filtered)
+
+	// Usage expires after the window.
+	now = now.Add(time.Hour)
+	filtered, err = podListProcessor.Process(ctx, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, pods, filtered)
+}
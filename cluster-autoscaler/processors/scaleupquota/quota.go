@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	namespaceQuota = "namespace"
+	labelQuota     = "label"
+)
+
+// Limits caps the capacity of nodes provisioned for pods matching a quota.
+// Zero values are not limited.
+type Limits struct {
+	Nodes int64
+	// MilliCPU is the CPU capacity of provisioned nodes in millicores.
+	MilliCPU int64
+	// Memory is the memory capacity of provisioned nodes in bytes.
+	Memory int64
+}
+
+// Quota limits scale-ups attributable to pods from a namespace or matching a label selector.
+type Quota struct {
+	// Name identifies the quota in events and logs, e.g. "namespace:team-a".
+	Name      string
+	namespace string
+	selector  labels.Selector
+	Limits    Limits
+}
+
+// Matches tells whether the quota applies to the pod.
+func (q *Quota) Matches(pod *apiv1.Pod) bool {
+	if q.selector != nil {
+		return q.selector.Matches(labels.Set(pod.Labels))
+	}
+	return pod.Namespace == q.namespace
+}
+
+// ParseQuota parses a quota specification in the form of
+// namespace:<namespace>:<limits> or label:<selector>:<limits>, where limits
+// is a comma separated list of nodes=<count>, cpu=<quantity> and
+// memory=<quantity>, e.g. namespace:team-a:nodes=10,memory=256Gi.
+func ParseQuota(spec string) (*Quota, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid scale-up quota %q, expected <namespace|label>:<value>:<limits>", spec)
+	}
+	quota := &Quota{Name: parts[0] + ":" + parts[1]}
+	switch parts[0] {
+	case namespaceQuota:
+		quota.namespace = parts[1]
+	case labelQuota:
+		selector, err := labels.Parse(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector in scale-up quota %q: %v", spec, err)
+		}
+		quota.selector = selector
+	default:
+		return nil, fmt.Errorf("invalid scale-up quota %q, unknown kind %q", spec, parts[0])
+	}
+
+	for _, limit := range strings.Split(parts[2], ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(limit), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid limit %q in scale-up quota %q", limit, spec)
+		}
+		switch name {
+		case "nodes":
+			nodes, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || nodes <= 0 {
+				return nil, fmt.Errorf("invalid nodes limit %q in scale-up quota %q", value, spec)
+			}
+			quota.Limits.Nodes = nodes
+		case "cpu", "memory":
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil || quantity.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid %s limit %q in scale-up quota %q", name, value, spec)
+			}
+			if name == "cpu" {
+				quota.Limits.MilliCPU = quantity.MilliValue()
+			} else {
+				quota.Limits.Memory = quantity.Value()
+			}
+		default:
+			return nil, fmt.Errorf("unknown limit %q in scale-up quota %q", name, spec)
+		}
+	}
+	return quota, nil
+}
+
+// ParseQuotas parses a list of quota specifications.
+func ParseQuotas(specs []string) ([]*Quota, error) {
+	quotas := make([]*Quota, 0, len(specs))
+	for _, spec := range specs {
+		quota, err := ParseQuota(spec)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestParseQuota(t *testing.T) {
+	quota, err := ParseQuota("namespace:team-a:nodes=10,cpu=64,memory=1Gi")
+	assert.NoError(t, err)
+	assert.Equal(t, "namespace:team-a", quota.Name)
+	assert.Equal(t, Limits{Nodes: 10, MilliCPU: 64000, Memory: 1024 * 1024 * 1024}, quota.Limits)
+
+	pod := BuildTestPod("p1", 100, 100)
+	pod.Namespace = "team-a"
+	assert.True(t, quota.Matches(pod))
+	pod.Namespace = "team-b"
+	assert.False(t, quota.Matches(pod))
+
+	quota, err = ParseQuota("label:tenant in (blue,green):nodes=5")
+	assert.NoError(t, err)
+	assert.False(t, quota.Matches(pod))
+	pod.Labels = map[string]string{"tenant": "green"}
+	assert.True(t, quota.Matches(pod))
+
+	for _, spec := range []string{
+		"team-a:nodes=10",
+		"namespace::nodes=10",
+		"project:team-a:nodes=10",
+		"namespace:team-a:nodes=0",
+		"namespace:team-a:cpu=lots",
+		"namespace:team-a:gpus=1",
+		"label:tenant in (blue:nodes=1",
+	} {
+		_, err := ParseQuota(spec)
+		assert.Error(t, err, spec)
+	}
+}
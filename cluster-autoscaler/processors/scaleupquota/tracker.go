@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupquota
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// usage is the capacity of nodes provisioned for pods matching a quota.
+// Nodes are attributed to quotas proportionally to the number of pods which
+// triggered the scale-up, so the values can be fractional.
+type usage struct {
+	nodes    float64
+	milliCPU float64
+	memory   float64
+}
+
+type usageRecord struct {
+	time  time.Time
+	usage usage
+}
+
+// Tracker keeps track of the usage of scale-up quotas in a sliding window.
+type Tracker struct {
+	lock    sync.Mutex
+	quotas  []*Quota
+	window  time.Duration
+	records map[string][]usageRecord
+}
+
+// NewTracker returns a tracker of the given quotas.
+func NewTracker(quotas []*Quota, window time.Duration) *Tracker {
+	return &Tracker{
+		quotas:  quotas,
+		window:  window,
+		records: make(map[string][]usageRecord),
+	}
+}
+
+// Record attributes a scale-up of nodes with the given capacity to a quota.
+func (t *Tracker) Record(quota *Quota, now time.Time, u usage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.records[quota.Name] = append(t.records[quota.Name], usageRecord{time: now, usage: u})
+}
+
+// ExceededQuota returns the first quota matching the pod which is used up
+// within the window and a human readable description of its usage.
+func (t *Tracker) ExceededQuota(pod *apiv1.Pod, now time.Time) (*Quota, string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, quota := range t.quotas {
+		if !quota.Matches(pod) {
+			continue
+		}
+		u := t.usageLocked(quota, now)
+		if quota.Limits.Nodes > 0 && u.nodes >= float64(quota.Limits.Nodes) {
+			return quota, fmt.Sprintf("%.1f/%d nodes provisioned in the last %v", u.nodes, quota.Limits.Nodes, t.window)
+		}
+		if quota.Limits.MilliCPU > 0 && u.milliCPU >= float64(quota.Limits.MilliCPU) {
+			return quota, fmt.Sprintf("%dm/%dm CPU provisioned in the last %v", int64(u.milliCPU), quota.Limits.MilliCPU, t.window)
+		}
+		if quota.Limits.Memory > 0 && u.memory >= float64(quota.Limits.Memory) {
+			return quota, fmt.Sprintf("%d/%d bytes of memory provisioned in the last %v", int64(u.memory), quota.Limits.Memory, t.window)
+		}
+	}
+	return nil, ""
+}
+
+// AllowedNodes returns how many nodes of the given capacity can be added for
+// the given pods before any quota matching them is exceeded, attributing the
+// nodes to quotas the same way Record does. It returns math.MaxInt if none of
+// the quotas limits the pods.
+func (t *Tracker) AllowedNodes(pods []*apiv1.Pod, perNode usage, now time.Time) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	allowed := math.MaxInt
+	if len(pods) == 0 {
+		return allowed
+	}
+	for _, quota := range t.quotas {
+		matching := 0
+		for _, pod := range pods {
+			if quota.Matches(pod) {
+				matching++
+			}
+		}
+		if matching == 0 {
+			continue
+		}
+		share := float64(matching) / float64(len(pods))
+		u := t.usageLocked(quota, now)
+		allowed = minAllowedNodes(allowed, quota.Limits.Nodes, u.nodes, perNode.nodes*share)
+		allowed = minAllowedNodes(allowed, quota.Limits.MilliCPU, u.milliCPU, perNode.milliCPU*share)
+		allowed = minAllowedNodes(allowed, quota.Limits.Memory, u.memory, perNode.memory*share)
+	}
+	return allowed
+}
+
+// minAllowedNodes caps allowed to the number of nodes, each adding perNode to
+// used, that fit in limit. Zero limit or perNode mean no cap.
+func minAllowedNodes(allowed int, limit int64, used, perNode float64) int {
+	if limit <= 0 || perNode <= 0 {
+		return allowed
+	}
+	// Tolerate rounding errors of the fractional usage.
+	fit := math.Floor((float64(limit)-used)/perNode + 1e-9)
+	if fit <= 0 {
+		return 0
+	}
+	if fit < float64(allowed) {
+		return int(fit)
+	}
+	return allowed
+}
+
+// usageLocked sums up records within the window and drops older ones.
+func (t *Tracker) usageLocked(quota *Quota, now time.Time) usage {
+	var total usage
+	var kept []usageRecord
+	for _, record := range t.records[quota.Name] {
+		if now.Sub(record.time) >= t.window {
+			continue
+		}
+		kept = append(kept, record)
+		total.nodes += record.usage.nodes
+		total.milliCPU += record.usage.milliCPU
+		total.memory += record.usage.memory
+	}
+	t.records[quota.Name] = kept
+	return total
+}
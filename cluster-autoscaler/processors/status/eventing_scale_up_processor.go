@@ -19,10 +19,13 @@ package status
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	klog "k8s.io/klog/v2"
 
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
@@ -31,18 +34,69 @@ import (
 // EventingScaleUpStatusProcessor processes the state of the cluster after
 // a scale-up by emitting relevant events for pods depending on their post
 // scale-up status.
-type EventingScaleUpStatusProcessor struct{}
+// With a non-zero window, pods with a controller, e.g. a ReplicaSet or a Job,
+// which didn't trigger scale-up are reported by a single event on their
+// controller at most once per window, so that large pending Jobs don't emit
+// thousands of events per loop.
+type EventingScaleUpStatusProcessor struct {
+	window time.Duration
+	// lastOwnerEvents holds the time of the last NotTriggerScaleUp event per controller.
+	lastOwnerEvents map[types.UID]time.Time
+	now             func() time.Time
+}
+
+// NewEventingScaleUpStatusProcessor returns a new EventingScaleUpStatusProcessor emitting
+// NotTriggerScaleUp events for the pods of the same controller at most once per window.
+// A zero window emits a NotTriggerScaleUp event for each pod in every loop.
+func NewEventingScaleUpStatusProcessor(window time.Duration) *EventingScaleUpStatusProcessor {
+	return &EventingScaleUpStatusProcessor{
+		window:          window,
+		lastOwnerEvents: make(map[types.UID]time.Time),
+		now:             time.Now,
+	}
+}
+
+// ownerNoScaleUpInfo holds the pods of a single controller which didn't trigger scale-up.
+type ownerNoScaleUpInfo struct {
+	owner     *metav1.OwnerReference
+	namespace string
+	// reasons holds, per reason, the highest number of node groups rejected or skipped for a pod for that reason.
+	reasons map[string]int
+	count   int
+}
 
 // Process processes the state of the cluster after a scale-up by emitting
 // relevant events for pods depending on their post scale-up status.
 func (p *EventingScaleUpStatusProcessor) Process(context *context.AutoscalingContext, status *ScaleUpStatus) {
 	consideredNodeGroupsMap := nodeGroupListToMapById(status.ConsideredNodeGroups)
 	if status.Result != ScaleUpSuccessful && status.Result != ScaleUpError {
+		var owners []*ownerNoScaleUpInfo
+		ownersByUID := map[types.UID]*ownerNoScaleUpInfo{}
 		for _, noScaleUpInfo := range status.PodsRemainUnschedulable {
-			context.Recorder.Event(noScaleUpInfo.Pod, apiv1.EventTypeNormal, "NotTriggerScaleUp",
-				fmt.Sprintf("pod didn't trigger scale-up: %s",
-					ReasonsMessage(noScaleUpInfo, consideredNodeGroupsMap)))
+			var owner *metav1.OwnerReference
+			if p.window > 0 {
+				owner = metav1.GetControllerOf(noScaleUpInfo.Pod)
+			}
+			if owner == nil {
+				context.Recorder.Event(noScaleUpInfo.Pod, apiv1.EventTypeNormal, "NotTriggerScaleUp",
+					fmt.Sprintf("pod didn't trigger scale-up: %s",
+						ReasonsMessage(noScaleUpInfo, consideredNodeGroupsMap)))
+				continue
+			}
+			info, found := ownersByUID[owner.UID]
+			if !found {
+				info = &ownerNoScaleUpInfo{owner: owner, namespace: noScaleUpInfo.Pod.Namespace, reasons: map[string]int{}}
+				ownersByUID[owner.UID] = info
+				owners = append(owners, info)
+			}
+			info.count++
+			for reason, count := range reasonCounts(noScaleUpInfo, consideredNodeGroupsMap) {
+				if count > info.reasons[reason] {
+					info.reasons[reason] = count
+				}
+			}
 		}
+		p.emitOwnerEvents(context, owners, consideredNodeGroupsMap)
 	} else {
 		klog.V(4).Infof("Skipping event processing for unschedulable pods since there is a" +
 			" ScaleUp attempt this loop")
@@ -55,13 +109,50 @@ func (p *EventingScaleUpStatusProcessor) Process(context *context.AutoscalingCon
 	}
 }
 
+// emitOwnerEvents emits a NotTriggerScaleUp event on each controller whose last event is older than the window.
+func (p *EventingScaleUpStatusProcessor) emitOwnerEvents(context *context.AutoscalingContext, owners []*ownerNoScaleUpInfo, consideredNodeGroups map[string]cloudprovider.NodeGroup) {
+	if p.lastOwnerEvents == nil {
+		p.lastOwnerEvents = make(map[types.UID]time.Time)
+	}
+	if p.now == nil {
+		p.now = time.Now
+	}
+	now := p.now()
+	for uid, last := range p.lastOwnerEvents {
+		if now.Sub(last) >= p.window {
+			delete(p.lastOwnerEvents, uid)
+		}
+	}
+	for _, info := range owners {
+		if _, found := p.lastOwnerEvents[info.owner.UID]; found {
+			klog.V(4).Infof("Skipping NotTriggerScaleUp event for %d pods of %s %s/%s, already emitted in the last %v",
+				info.count, info.owner.Kind, info.namespace, info.owner.Name, p.window)
+			continue
+		}
+		ref := &apiv1.ObjectReference{
+			APIVersion: info.owner.APIVersion,
+			Kind:       info.owner.Kind,
+			Namespace:  info.namespace,
+			Name:       info.owner.Name,
+			UID:        info.owner.UID,
+		}
+		context.Recorder.Eventf(ref, apiv1.EventTypeNormal, "NotTriggerScaleUp",
+			"%d pod(s) didn't trigger scale-up: %s", info.count, reasonCountsMessage(info.reasons))
+		p.lastOwnerEvents[info.owner.UID] = now
+	}
+}
+
 // CleanUp cleans up the processor's internal structures.
 func (p *EventingScaleUpStatusProcessor) CleanUp() {
 }
 
 // ReasonsMessage aggregates reasons from NoScaleUpInfos.
 func ReasonsMessage(noScaleUpInfo NoScaleUpInfo, consideredNodeGroups map[string]cloudprovider.NodeGroup) string {
-	messages := []string{}
+	return reasonCountsMessage(reasonCounts(noScaleUpInfo, consideredNodeGroups))
+}
+
+// reasonCounts returns the number of existing considered node groups rejected or skipped for a pod, per reason.
+func reasonCounts(noScaleUpInfo NoScaleUpInfo, consideredNodeGroups map[string]cloudprovider.NodeGroup) map[string]int {
 	aggregated := map[string]int{}
 	for nodeGroupId, reasons := range noScaleUpInfo.RejectedNodeGroups {
 		if nodeGroup, present := consideredNodeGroups[nodeGroupId]; !present || !nodeGroup.Exist() {
@@ -83,6 +174,11 @@ func ReasonsMessage(noScaleUpInfo NoScaleUpInfo, consideredNodeGroups map[string
 		}
 	}
 
+	return aggregated
+}
+
+func reasonCountsMessage(aggregated map[string]int) string {
+	messages := []string{}
 	for msg, count := range aggregated {
 		messages = append(messages, fmt.Sprintf("%d %s", count, msg))
 	}
@@ -19,6 +19,7 @@ package status
 import (
 	"strings"
 	"testing"
+	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	kube_record "k8s.io/client-go/tools/record"
@@ -132,6 +133,67 @@ func TestEventingScaleUpStatusProcessor(t *testing.T) {
 	}
 }
 
+func TestEventingScaleUpStatusProcessorAggregatesPerOwner(t *testing.T) {
+	now := time.Now()
+	p := NewEventingScaleUpStatusProcessor(5 * time.Minute)
+	p.now = func() time.Time { return now }
+
+	jobPods := []*apiv1.Pod{BuildTestPod("job-1", 0, 0), BuildTestPod("job-2", 0, 0), BuildTestPod("job-3", 0, 0)}
+	for _, pod := range jobPods {
+		pod.OwnerReferences = GenerateOwnerReferences("job", "Job", "batch/v1", "job-uid")
+	}
+	standalone := BuildTestPod("standalone", 0, 0)
+	reasons := map[string]Reasons{"group 1": &testReason{"not schedulable"}}
+	otherReasons := map[string]Reasons{"group 1": &testReason{"not schedulable"}, "group 2": &testReason{"max limit reached"}}
+	considered := []cloudprovider.NodeGroup{
+		cp_test.NewTestNodeGroup("group 1", 1, 1, 1, true, false, "", nil, nil),
+		cp_test.NewTestNodeGroup("group 2", 1, 1, 1, true, false, "", nil, nil),
+	}
+	state := &ScaleUpStatus{
+		Result:               ScaleUpNoOptionsAvailable,
+		ConsideredNodeGroups: considered,
+		PodsRemainUnschedulable: []NoScaleUpInfo{
+			{jobPods[0], reasons, nil},
+			{standalone, reasons, nil},
+			{jobPods[1], nil, otherReasons},
+			{jobPods[2], reasons, nil},
+		},
+	}
+	assertJobEvent := func(event string) {
+		assert.True(t, strings.HasPrefix(event, "Normal NotTriggerScaleUp 3 pod(s) didn't trigger scale-up: "), event)
+		// The reasons of all pods are reported.
+		assert.Contains(t, event, "1 not schedulable")
+		assert.Contains(t, event, "1 max limit reached")
+	}
+
+	fakeRecorder := kube_record.NewFakeRecorder(10)
+	context := &context.AutoscalingContext{
+		AutoscalingKubeClients: context.AutoscalingKubeClients{
+			Recorder: fakeRecorder,
+		},
+	}
+	p.Process(context, state)
+	assert.Equal(t, "Normal NotTriggerScaleUp pod didn't trigger scale-up: 1 not schedulable", <-fakeRecorder.Events)
+	assertJobEvent(<-fakeRecorder.Events)
+	assert.Empty(t, fakeRecorder.Events)
+
+	// Within the window, only pods without a controller are reported.
+	now = now.Add(time.Minute)
+	p.Process(context, state)
+	assert.Equal(t, "Normal NotTriggerScaleUp pod didn't trigger scale-up: 1 not schedulable", <-fakeRecorder.Events)
+	assert.Empty(t, fakeRecorder.Events)
+
+	now = now.Add(5 * time.Minute)
+	p.Process(context, state)
+	<-fakeRecorder.Events
+	assertJobEvent(<-fakeRecorder.Events)
+
+	// Without a window, each pod is reported.
+	p = NewEventingScaleUpStatusProcessor(0)
+	p.Process(context, state)
+	assert.Len(t, fakeRecorder.Events, 4)
+}
+
 func TestReasonsMessage(t *testing.T) {
 	notSchedulableReason := &testReason{"not schedulable"}
 	alsoNotSchedulableReason := &testReason{"also not schedulable"}
@@ -18,6 +18,7 @@ package status
 
 import (
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
@@ -30,10 +31,12 @@ import (
 // on if scale-up happened, description of scale-up operation performed and
 // status of pods that took part in the scale-up evaluation.
 type ScaleUpStatus struct {
-	Result                   ScaleUpResult
-	ScaleUpError             *errors.AutoscalerError
-	ScaleUpInfos             []nodegroupset.ScaleUpInfo
-	PodsTriggeredScaleUp     []*apiv1.Pod
+	Result               ScaleUpResult
+	ScaleUpError         *errors.AutoscalerError
+	ScaleUpInfos         []nodegroupset.ScaleUpInfo
+	PodsTriggeredScaleUp []*apiv1.Pod
+	// PodsPlannedNodeGroups maps the UIDs of PodsTriggeredScaleUp to the id of the scaled up node group each pod was planned onto.
+	PodsPlannedNodeGroups    map[types.UID]string
 	PodsRemainUnschedulable  []NoScaleUpInfo
 	PodsAwaitEvaluation      []*apiv1.Pod
 	CreateNodeGroupResults   []nodegroups.CreateNodeGroupResult
@@ -86,7 +89,7 @@ type ScaleUpStatusProcessor interface {
 
 // NewDefaultScaleUpStatusProcessor creates a default instance of ScaleUpStatusProcessor.
 func NewDefaultScaleUpStatusProcessor() ScaleUpStatusProcessor {
-	return &EventingScaleUpStatusProcessor{}
+	return NewEventingScaleUpStatusProcessor(0)
 }
 
 // NoOpScaleUpStatusProcessor is a ScaleUpStatusProcessor implementations useful for testing.
@@ -100,6 +103,30 @@ func (p *NoOpScaleUpStatusProcessor) Process(context *context.AutoscalingContext
 func (p *NoOpScaleUpStatusProcessor) CleanUp() {
 }
 
+// CombinedScaleUpStatusProcessor is a list of ScaleUpStatusProcessors
+type CombinedScaleUpStatusProcessor struct {
+	processors []ScaleUpStatusProcessor
+}
+
+// NewCombinedScaleUpStatusProcessor construct CombinedScaleUpStatusProcessor.
+func NewCombinedScaleUpStatusProcessor(processors []ScaleUpStatusProcessor) *CombinedScaleUpStatusProcessor {
+	return &CombinedScaleUpStatusProcessor{processors}
+}
+
+// Process runs sub-processors sequentially
+func (p *CombinedScaleUpStatusProcessor) Process(context *context.AutoscalingContext, status *ScaleUpStatus) {
+	for _, processor := range p.processors {
+		processor.Process(context, status)
+	}
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *CombinedScaleUpStatusProcessor) CleanUp() {
+	for _, processor := range p.processors {
+		processor.CleanUp()
+	}
+}
+
 // UpdateScaleUpError updates ScaleUpStatus.
 func UpdateScaleUpError(s *ScaleUpStatus, err errors.AutoscalerError) (*ScaleUpStatus, errors.AutoscalerError) {
 	s.ScaleUpError = &err
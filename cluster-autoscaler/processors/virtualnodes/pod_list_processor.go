@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualnodes
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+)
+
+// VirtualNodeRouter is implemented by cloud providers which can run pods on
+// serverless virtual nodes that are not scaled by cluster autoscaler.
+type VirtualNodeRouter interface {
+	// VirtualNodePoolForPod returns the virtual node pool the pod can run on
+	// and whether virtual nodes are preferred over scaling up node groups.
+	// An empty pool means that the pod can't be routed to virtual nodes.
+	VirtualNodePoolForPod(pod *apiv1.Pod) (string, bool)
+}
+
+// PodListProcessor leaves unschedulable pods which can run on virtual nodes
+// pending, instead of provisioning nodes for them. Pods are routed to virtual
+// nodes if the cloud provider prefers it, or if none of the node groups can be
+// scaled up because they are at max size or backed off.
+type PodListProcessor struct {
+	now func() time.Time
+}
+
+// NewPodListProcessor returns a new PodListProcessor.
+func NewPodListProcessor() *PodListProcessor {
+	return &PodListProcessor{now: time.Now}
+}
+
+// Process filters out pods routed to virtual nodes and emits an event for them.
+func (p *PodListProcessor) Process(context *context.AutoscalingContext, unschedulablePods []*apiv1.Pod) ([]*apiv1.Pod, error) {
+	router, ok := context.CloudProvider.(VirtualNodeRouter)
+	if !ok {
+		return unschedulablePods, nil
+	}
+	var canScaleUp *bool
+	result := make([]*apiv1.Pod, 0, len(unschedulablePods))
+	for _, pod := range unschedulablePods {
+		pool, preferred := router.VirtualNodePoolForPod(pod)
+		if pool == "" {
+			result = append(result, pod)
+			continue
+		}
+		if !preferred {
+			if canScaleUp == nil {
+				scalable := p.canScaleUpNodeGroups(context)
+				canScaleUp = &scalable
+			}
+			if *canScaleUp {
+				result = append(result, pod)
+				continue
+			}
+		}
+		klog.V(4).Infof("Pod %s/%s is not considered for scale-up, it can run on virtual node pool %s", pod.Namespace, pod.Name, pool)
+		context.Recorder.Eventf(pod, apiv1.EventTypeNormal, "RoutedToVirtualNodes",
+			"pod didn't trigger scale-up: it can run on virtual node pool %s", pool)
+	}
+	return result, nil
+}
+
+// canScaleUpNodeGroups returns true if any node group is below its max size
+// and safe to scale up.
+func (p *PodListProcessor) canScaleUpNodeGroups(context *context.AutoscalingContext) bool {
+	now := p.now()
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		size, err := nodeGroup.TargetSize()
+		if err != nil {
+			klog.Warningf("Failed to get target size of node group %s: %v", nodeGroup.Id(), err)
+			continue
+		}
+		if size >= nodeGroup.MaxSize() {
+			continue
+		}
+		if context.ClusterStateRegistry != nil && !context.ClusterStateRegistry.NodeGroupScaleUpSafety(nodeGroup, now).SafeToScale {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *PodListProcessor) CleanUp() {
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualnodes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	kube_record "k8s.io/client-go/tools/record"
+
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+type virtualNodeCloudProvider struct {
+	*testprovider.TestCloudProvider
+	pools     map[string]string
+	preferred bool
+}
+
+func (p *virtualNodeCloudProvider) VirtualNodePoolForPod(pod *apiv1.Pod) (string, bool) {
+	pool := p.pools[pod.Name]
+	return pool, pool != "" && p.preferred
+}
+
+func TestPodListProcessor(t *testing.T) {
+	fits := BuildTestPod("fits", 100, 100)
+	doesNotFit := BuildTestPod("does-not-fit", 100, 100)
+	pods := []*apiv1.Pod{fits, doesNotFit}
+
+	testCases := []struct {
+		name      string
+		preferred bool
+		atMaxSize bool
+		router    bool
+		want      []*apiv1.Pod
+	}{
+		{
+			name:   "not a virtual node router",
+			router: false,
+			want:   pods,
+		},
+		{
+			name:   "fallback with node groups to scale up",
+			router: true,
+			want:   pods,
+		},
+		{
+			name:      "fallback with node groups at max size",
+			router:    true,
+			atMaxSize: true,
+			want:      []*apiv1.Pod{doesNotFit},
+		},
+		{
+			name:      "preferred",
+			router:    true,
+			preferred: true,
+			want:      []*apiv1.Pod{doesNotFit},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := testprovider.NewTestCloudProvider(nil, nil)
+			provider.AddNodeGroup("ng1", 0, 3, 3)
+			if tc.atMaxSize {
+				provider.AddNodeGroup("ng2", 0, 3, 3)
+			} else {
+				provider.AddNodeGroup("ng2", 0, 3, 2)
+			}
+			fakeRecorder := kube_record.NewFakeRecorder(5)
+			ctx := &context.AutoscalingContext{
+				CloudProvider: provider,
+				AutoscalingKubeClients: context.AutoscalingKubeClients{
+					Recorder: fakeRecorder,
+				},
+			}
+			if tc.router {
+				ctx.CloudProvider = &virtualNodeCloudProvider{
+					TestCloudProvider: provider,
+					pools:             map[string]string{"fits": "vnp"},
+					preferred:         tc.preferred,
+				}
+			}
+
+			got, err := NewPodListProcessor().Process(ctx, pods)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			if len(got) < len(pods) {
+				assert.Contains(t, <-fakeRecorder.Events, "RoutedToVirtualNodes")
+			} else {
+				assert.Empty(t, fakeRecorder.Events)
+			}
+		})
+	}
+}
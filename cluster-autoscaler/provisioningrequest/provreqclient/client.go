@@ -46,6 +46,7 @@ const (
 // ProvisioningRequestClient represents client for v1beta1 ProvReq CRD.
 type ProvisioningRequestClient struct {
 	client         versioned.Interface
+	kubeClient     kubernetes.Interface
 	provReqLister  listers.ProvisioningRequestLister
 	podTemplLister v1.PodTemplateLister
 }
@@ -74,6 +75,7 @@ func NewProvisioningRequestClient(kubeConfig *rest.Config) (*ProvisioningRequest
 
 	return &ProvisioningRequestClient{
 		client:         prClient,
+		kubeClient:     podTemplateClient,
 		provReqLister:  provReqLister,
 		podTemplLister: podTemplLister,
 	}, nil
@@ -216,3 +218,22 @@ func (c *ProvisioningRequestClient) DeleteProvisioningRequest(pr *v1beta1.Provis
 	klog.V(4).Infof("Deleted ProvisioningRequest %s/%s", pr.Namespace, pr.Name)
 	return nil
 }
+
+// CreateProvisioningRequest creates the PodTemplates referenced by the given ProvisioningRequest
+// and then the ProvisioningRequest CR itself. PodTemplates which already exist are left intact.
+func (c *ProvisioningRequestClient) CreateProvisioningRequest(pr *provreqwrapper.ProvisioningRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), provisioningRequestClientCallTimeout)
+	defer cancel()
+
+	for _, podTemplate := range pr.PodTemplates {
+		_, err := c.kubeClient.CoreV1().PodTemplates(pr.Namespace).Create(ctx, podTemplate, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error creating PodTemplate %s/%s: %w", pr.Namespace, podTemplate.Name, err)
+		}
+	}
+	if _, err := c.client.AutoscalingV1beta1().ProvisioningRequests(pr.Namespace).Create(ctx, pr.ProvisioningRequest, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("error creating ProvisioningRequest %s/%s: %w", pr.Namespace, pr.Name, err)
+	}
+	klog.V(4).Infof("Created ProvisioningRequest %s/%s", pr.Namespace, pr.Name)
+	return nil
+}
@@ -62,6 +62,7 @@ func NewFakeProvisioningRequestClient(ctx context.Context, t *testing.T, prs ...
 	}
 	return &ProvisioningRequestClient{
 		client:         provReqClient,
+		kubeClient:     podTemplClient,
 		provReqLister:  provReqLister,
 		podTemplLister: podTemplLister,
 	}
@@ -18,9 +18,9 @@ package simulator
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/options"
@@ -28,6 +28,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/scheduling"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/tpu"
 	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
 
@@ -89,6 +90,14 @@ const (
 	BlockedByPod
 	// UnexpectedError - node can't be removed because of an unexpected error.
 	UnexpectedError
+	// GpuJobInProgress - GPU node can't be removed because a GPU job is in progress on it.
+	GpuJobInProgress
+	// MigReconfigurationInProgress - GPU node can't be removed because its NVIDIA MIG configuration is being changed.
+	MigReconfigurationInProgress
+	// UpgradeInProgress - node can't be removed because it is being upgraded, e.g. rebooted by kured.
+	UpgradeInProgress
+	// ReserveNodeGroup - node can't be removed because it belongs to a reserve node group.
+	ReserveNodeGroup
 )
 
 // RemovalSimulator is a helper object for simulating node removal scenarios.
@@ -100,6 +109,9 @@ type RemovalSimulator struct {
 	deleteOptions       options.NodeDeleteOptions
 	drainabilityRules   rules.Rules
 	schedulingSimulator *scheduling.HintingSimulator
+	predicateChecker    predicatechecker.PredicateChecker
+	// workerSchedulingSimulators are used by concurrent simulations, one per worker.
+	workerSchedulingSimulators []*scheduling.HintingSimulator
 }
 
 // NewRemovalSimulator returns a new RemovalSimulator.
@@ -113,6 +125,7 @@ func NewRemovalSimulator(listers kube_util.ListerRegistry, clusterSnapshot clust
 		deleteOptions:       deleteOptions,
 		drainabilityRules:   drainabilityRules,
 		schedulingSimulator: scheduling.NewHintingSimulator(predicateChecker),
+		predicateChecker:    predicateChecker,
 	}
 }
 
@@ -179,6 +192,89 @@ func (r *RemovalSimulator) SimulateNodeRemoval(
 	}, nil
 }
 
+// FindUnremovableNodes simulates the removal of candidate nodes concurrently, using up to
+// workers goroutines, each with its own fork of the cluster snapshot. The simulations aren't
+// persisted and don't see each other, so only the nodes found unremovable are returned, keyed
+// by name: removing other nodes only takes away capacity, so these nodes stay unremovable
+// regardless of the order in which the candidates are processed. The removal of the remaining
+// candidates has to be simulated with SimulateNodeRemoval. Nothing is returned if the cluster
+// snapshot or the predicate checker don't support concurrent use.
+func (r *RemovalSimulator) FindUnremovableNodes(
+	candidates []string,
+	destinationMap map[string]bool,
+	timestamp time.Time,
+	remainingPdbTracker pdb.RemainingPdbTracker,
+	workers int,
+) map[string]*UnremovableNode {
+	if workers > len(candidates) {
+		workers = len(candidates)
+	}
+	snapshot, ok := r.clusterSnapshot.(clustersnapshot.ConcurrentForkingSnapshot)
+	if workers <= 1 || !ok {
+		return nil
+	}
+	simulators, err := r.workerSimulators(snapshot, workers)
+	if err != nil {
+		klog.Warningf("Can't simulate node removals concurrently: %v", err)
+		return nil
+	}
+
+	unremovable := make([]*UnremovableNode, len(candidates))
+	indices := make(chan int, len(candidates))
+	for i := range candidates {
+		indices <- i
+	}
+	close(indices)
+	var wg sync.WaitGroup
+	for _, simulator := range simulators {
+		wg.Add(1)
+		go func(simulator *RemovalSimulator) {
+			defer wg.Done()
+			for i := range indices {
+				_, unremovable[i] = simulator.SimulateNodeRemoval(candidates[i], destinationMap, timestamp, remainingPdbTracker)
+			}
+		}(simulator)
+	}
+	wg.Wait()
+
+	result := make(map[string]*UnremovableNode)
+	for i, urn := range unremovable {
+		if urn != nil {
+			result[candidates[i]] = urn
+		}
+	}
+	return result
+}
+
+// workerSimulators returns removal simulators for concurrent simulations, each using its own
+// fork of the snapshot and its own predicate checker. The scheduling simulators, with their
+// hints, are reused across calls.
+func (r *RemovalSimulator) workerSimulators(snapshot clustersnapshot.ConcurrentForkingSnapshot, workers int) ([]*RemovalSimulator, error) {
+	for len(r.workerSchedulingSimulators) < workers {
+		checker, ok := r.predicateChecker.(predicatechecker.ConcurrentPredicateChecker)
+		if !ok {
+			return nil, fmt.Errorf("predicate checker doesn't support concurrent use")
+		}
+		checkerCopy, err := checker.Copy()
+		if err != nil {
+			return nil, err
+		}
+		r.workerSchedulingSimulators = append(r.workerSchedulingSimulators, scheduling.NewHintingSimulator(checkerCopy))
+	}
+	simulators := make([]*RemovalSimulator, 0, workers)
+	for _, schedulingSimulator := range r.workerSchedulingSimulators[:workers] {
+		simulators = append(simulators, &RemovalSimulator{
+			listers:             r.listers,
+			clusterSnapshot:     snapshot.ConcurrentFork(),
+			usageTracker:        NewUsageTracker(),
+			deleteOptions:       r.deleteOptions,
+			drainabilityRules:   r.drainabilityRules,
+			schedulingSimulator: schedulingSimulator,
+		})
+	}
+	return simulators, nil
+}
+
 // FindEmptyNodesToRemove finds empty nodes that can be removed.
 func (r *RemovalSimulator) FindEmptyNodesToRemove(candidates []string, timestamp time.Time) []string {
 	result := make([]string, 0)
@@ -220,6 +316,12 @@ func (r *RemovalSimulator) findPlaceFor(removedNode string, pods []*apiv1.Pod, n
 
 	pods = tpu.ClearTPURequests(pods)
 
+	// proportional workloads shrink along with the cluster, their surplus replicas don't need a new place
+	surplus, err := proportionalSurplus(r.clusterSnapshot, r.deleteOptions.ProportionalWorkloads, map[string]bool{removedNode: true})
+	if err != nil {
+		return err
+	}
+
 	// remove pods from clusterSnapshot first
 	for _, pod := range pods {
 		if err := r.clusterSnapshot.RemovePod(pod.Namespace, pod.Name, removedNode); err != nil {
@@ -230,6 +332,11 @@ func (r *RemovalSimulator) findPlaceFor(removedNode string, pods []*apiv1.Pod, n
 
 	newpods := make([]*apiv1.Pod, 0, len(pods))
 	for _, podptr := range pods {
+		if i := proportionalWorkloadIndex(r.deleteOptions.ProportionalWorkloads, podptr); i >= 0 && surplus[i] > 0 {
+			klog.V(4).Infof("Not rescheduling %s/%s, surplus replica of a proportional workload", podptr.Namespace, podptr.Name)
+			surplus[i]--
+			continue
+		}
 		newpod := *podptr
 		newpod.Spec.NodeName = ""
 		newpods = append(newpods, &newpod)
@@ -252,4 +359,7 @@ func (r *RemovalSimulator) findPlaceFor(removedNode string, pods []*apiv1.Pod, n
 // DropOldHints drops old scheduling hints.
 func (r *RemovalSimulator) DropOldHints() {
 	r.schedulingSimulator.DropOldHints()
+	for _, schedulingSimulator := range r.workerSchedulingSimulators {
+		schedulingSimulator.DropOldHints()
+	}
 }
@@ -202,6 +202,88 @@ func TestFindNodesToRemove(t *testing.T) {
 	}
 }
 
+func TestFindUnremovableNodes(t *testing.T) {
+	replicas := int32(20)
+	rsLister, err := kube_util.NewTestReplicaSetLister([]*appsv1.ReplicaSet{{
+		ObjectMeta: metav1.ObjectMeta{Name: "rs", Namespace: "default", SelfLink: "api/v1/namespaces/default/replicasets/rs"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}})
+	assert.NoError(t, err)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, rsLister, nil)
+	ownerRefs := GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
+
+	var nodes []*apiv1.Node
+	var pods []*apiv1.Pod
+	var candidates []string
+	expected := map[string]UnremovableReason{}
+	for i := 0; i < 10; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 2000000)
+		SetNodeReadyState(node, true, time.Time{})
+		nodes = append(nodes, node)
+		candidates = append(candidates, node.Name)
+		switch {
+		case i < 6:
+			// two small pods backed by a ReplicaSet, which fit elsewhere
+			for j := 0; j < 2; j++ {
+				pod := BuildTestPod(fmt.Sprintf("p%d-%d", i, j), 100, 100000)
+				pod.OwnerReferences = ownerRefs
+				pod.Spec.NodeName = node.Name
+				pods = append(pods, pod)
+			}
+		case i < 8:
+			// one pod not backed by anything
+			pod := BuildTestPod(fmt.Sprintf("p%d", i), 100, 100000)
+			pod.Spec.NodeName = node.Name
+			pods = append(pods, pod)
+			expected[node.Name] = BlockedByPod
+		default:
+			// one very large pod
+			pod := BuildTestPod(fmt.Sprintf("p%d", i), 1000, 100000)
+			pod.OwnerReferences = ownerRefs
+			pod.Spec.NodeName = node.Name
+			pods = append(pods, pod)
+			expected[node.Name] = NoPlaceToMovePods
+		}
+	}
+	destinations := make(map[string]bool)
+	for _, node := range nodes {
+		destinations[node.Name] = true
+	}
+
+	for name, clusterSnapshot := range map[string]clustersnapshot.ClusterSnapshot{
+		"basic": clustersnapshot.NewBasicClusterSnapshot(),
+		"delta": clustersnapshot.NewDeltaClusterSnapshot(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, clusterSnapshot, nodes, pods)
+			predicateChecker, err := predicatechecker.NewTestPredicateChecker()
+			assert.NoError(t, err)
+			r := NewRemovalSimulator(registry, clusterSnapshot, predicateChecker, NewUsageTracker(), testDeleteOptions(), nil, true)
+
+			assert.Nil(t, r.FindUnremovableNodes(candidates, destinations, time.Now(), nil, 1))
+
+			unremovable := r.FindUnremovableNodes(candidates, destinations, time.Now(), nil, 4)
+			reasons := map[string]UnremovableReason{}
+			for nodeName, urn := range unremovable {
+				reasons[nodeName] = urn.Reason
+			}
+			assert.Equal(t, expected, reasons)
+
+			// The concurrent simulations aren't persisted.
+			nodeInfos, err := clusterSnapshot.NodeInfos().List()
+			assert.NoError(t, err)
+			podCount := 0
+			for _, nodeInfo := range nodeInfos {
+				for _, podInfo := range nodeInfo.Pods {
+					assert.Equal(t, nodeInfo.Node().Name, podInfo.Pod.Spec.NodeName)
+					podCount++
+				}
+			}
+			assert.Equal(t, len(pods), podCount)
+		})
+	}
+}
+
 func testDeleteOptions() options.NodeDeleteOptions {
 	return options.NodeDeleteOptions{
 		SkipNodesWithSystemPods:           true,
@@ -279,6 +279,11 @@ func (snapshot *BasicClusterSnapshot) Commit() error {
 	return nil
 }
 
+// ConcurrentFork returns a new snapshot with a copy of the current state of this snapshot.
+func (snapshot *BasicClusterSnapshot) ConcurrentFork() ClusterSnapshot {
+	return &BasicClusterSnapshot{data: []*internalBasicSnapshotData{snapshot.getInternalData().clone()}}
+}
+
 // Clear reset cluster snapshot to empty, unforked state
 func (snapshot *BasicClusterSnapshot) Clear() {
 	baseData := newInternalBasicSnapshotData()
@@ -54,6 +54,17 @@ type ClusterSnapshot interface {
 	Clear()
 }
 
+// ConcurrentForkingSnapshot is implemented by cluster snapshots which can be forked
+// into independent snapshots, so that simulations can run concurrently.
+type ConcurrentForkingSnapshot interface {
+	ClusterSnapshot
+	// ConcurrentFork returns a new snapshot starting with the current state of this snapshot.
+	// Changes done to the returned snapshot aren't visible in this snapshot. Snapshots returned
+	// by ConcurrentFork can be used concurrently with each other, as long as this snapshot
+	// isn't used meanwhile.
+	ConcurrentFork() ClusterSnapshot
+}
+
 // ErrNodeNotFound means that a node wasn't found in the snapshot.
 var ErrNodeNotFound = errors.New("node not found")
 
@@ -470,6 +470,15 @@ func (snapshot *DeltaClusterSnapshot) Commit() error {
 	return nil
 }
 
+// ConcurrentFork returns a new snapshot sharing the current state of this snapshot as
+// its read-only base. Modifications of the returned snapshot are stored in its own delta.
+// Time: O(n) on the first call after a modification of this snapshot, as the cached list
+// of node infos is built upfront to keep the shared state read-only, O(1) otherwise.
+func (snapshot *DeltaClusterSnapshot) ConcurrentFork() ClusterSnapshot {
+	snapshot.data.getNodeInfoList()
+	return &DeltaClusterSnapshot{data: snapshot.data.fork()}
+}
+
 // Clear reset cluster snapshot to empty, unforked state
 // Time: O(1)
 func (snapshot *DeltaClusterSnapshot) Clear() {
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package balloon
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Rule is a drainability rule on how to handle balloon pods, i.e. placeholder
+// pods keeping headroom in the cluster.
+type Rule struct {
+	priorityClassName string
+}
+
+// New creates a new Rule for balloon pods with the given priority class.
+func New(priorityClassName string) *Rule {
+	return &Rule{
+		priorityClassName: priorityClassName,
+	}
+}
+
+// Name returns the name of the rule.
+func (r *Rule) Name() string {
+	return "Balloon"
+}
+
+// Drainable decides what to do with balloon pods on node drain. Balloon pods
+// never block scale-down and aren't rescheduled elsewhere, they are simply
+// preempted by the pods they keep room for.
+func (r *Rule) Drainable(drainCtx *drainability.DrainContext, pod *apiv1.Pod, _ *framework.NodeInfo) drainability.Status {
+	if pod.Spec.PriorityClassName == r.priorityClassName {
+		return drainability.NewSkipStatus()
+	}
+	return drainability.NewUndefinedStatus()
+}
@@ -18,19 +18,27 @@ package pdb
 
 import (
 	"fmt"
+	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 )
 
 // Rule is a drainability rule on how to handle pods with pdbs.
-type Rule struct{}
+type Rule struct {
+	// overrideTimeout is the time after which PDBs with zero disruptions
+	// allowed stop blocking removal of unready nodes.
+	overrideTimeout time.Duration
+}
 
-// New creates a new Rule.
-func New() *Rule {
-	return &Rule{}
+// New creates a new Rule. PDBs with zero disruptions allowed don't block
+// removal of nodes unready for longer than overrideTimeout, unless it's zero.
+func New(overrideTimeout time.Duration) *Rule {
+	return &Rule{overrideTimeout: overrideTimeout}
 }
 
 // Name returns the name of the rule.
@@ -39,9 +47,13 @@ func (r *Rule) Name() string {
 }
 
 // Drainable decides how to handle pods with pdbs on node drain.
-func (Rule) Drainable(drainCtx *drainability.DrainContext, pod *apiv1.Pod, _ *framework.NodeInfo) drainability.Status {
-	for _, pdb := range drainCtx.RemainingPdbTracker.MatchingPdbs(pod) {
-		if pdb.Status.DisruptionsAllowed < 1 {
+func (r Rule) Drainable(drainCtx *drainability.DrainContext, pod *apiv1.Pod, nodeInfo *framework.NodeInfo) drainability.Status {
+	for _, podPdb := range drainCtx.RemainingPdbTracker.MatchingPdbs(pod) {
+		if podPdb.Status.DisruptionsAllowed < 1 {
+			if nodeInfo != nil && pdb.OverridesZeroDisruptions(nodeInfo.Node(), r.overrideTimeout, drainCtx.Timestamp) {
+				klog.V(2).Infof("Ignoring PDB %s/%s with zero disruptions allowed for pod %s/%s on unready node %s", podPdb.Namespace, podPdb.Name, pod.Namespace, pod.Name, nodeInfo.Node().Name)
+				continue
+			}
 			return drainability.NewBlockedStatus(drain.NotEnoughPdb, fmt.Errorf("not enough pod disruption budget to move %s/%s", pod.Namespace, pod.Name))
 		}
 	}
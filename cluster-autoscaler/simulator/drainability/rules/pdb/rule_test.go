@@ -18,26 +18,62 @@ package pdb
 
 import (
 	"testing"
+	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDrainable(t *testing.T) {
 	one := intstr.FromInt(1)
+	now := time.Now()
+	blockingPdbs := []*policyv1.PodDisruptionBudget{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "good",
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &one,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"label": "true",
+					},
+				},
+			},
+		},
+	}
+	blockedPod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sad",
+			Namespace: "good",
+			Labels: map[string]string{
+				"label": "true",
+			},
+		},
+	}
+	readyNode := BuildTestNode("ready", 1000, 1000)
+	SetNodeReadyState(readyNode, true, now.Add(-time.Hour))
+	recentlyUnreadyNode := BuildTestNode("recently-unready", 1000, 1000)
+	SetNodeReadyState(recentlyUnreadyNode, false, now.Add(-time.Minute))
+	longUnreadyNode := BuildTestNode("long-unready", 1000, 1000)
+	SetNodeReadyState(longUnreadyNode, false, now.Add(-time.Hour))
 
 	for desc, tc := range map[string]struct {
-		pod         *apiv1.Pod
-		pdbs        []*policyv1.PodDisruptionBudget
-		wantOutcome drainability.OutcomeType
-		wantReason  drain.BlockingPodReason
+		pod             *apiv1.Pod
+		pdbs            []*policyv1.PodDisruptionBudget
+		node            *apiv1.Node
+		overrideTimeout time.Duration
+		wantOutcome     drainability.OutcomeType
+		wantReason      drain.BlockingPodReason
 	}{
 		"no pdbs": {
 			pod: &apiv1.Pod{},
@@ -135,15 +171,50 @@ func TestDrainable(t *testing.T) {
 			wantOutcome: drainability.BlockDrain,
 			wantReason:  drain.NotEnoughPdb,
 		},
+		"pdb prevents scale-down of ready node despite override": {
+			pod:             blockedPod,
+			pdbs:            blockingPdbs,
+			node:            readyNode,
+			overrideTimeout: 10 * time.Minute,
+			wantOutcome:     drainability.BlockDrain,
+			wantReason:      drain.NotEnoughPdb,
+		},
+		"pdb prevents scale-down of node unready for shorter than override timeout": {
+			pod:             blockedPod,
+			pdbs:            blockingPdbs,
+			node:            recentlyUnreadyNode,
+			overrideTimeout: 10 * time.Minute,
+			wantOutcome:     drainability.BlockDrain,
+			wantReason:      drain.NotEnoughPdb,
+		},
+		"pdb prevents scale-down of long unready node without override": {
+			pod:         blockedPod,
+			pdbs:        blockingPdbs,
+			node:        longUnreadyNode,
+			wantOutcome: drainability.BlockDrain,
+			wantReason:  drain.NotEnoughPdb,
+		},
+		"pdb overridden for node unready for longer than override timeout": {
+			pod:             blockedPod,
+			pdbs:            blockingPdbs,
+			node:            longUnreadyNode,
+			overrideTimeout: 10 * time.Minute,
+		},
 	} {
 		t.Run(desc, func(t *testing.T) {
 			tracker := pdb.NewBasicRemainingPdbTracker()
 			tracker.SetPdbs(tc.pdbs)
 			drainCtx := &drainability.DrainContext{
 				RemainingPdbTracker: tracker,
+				Timestamp:           now,
+			}
+			var nodeInfo *framework.NodeInfo
+			if tc.node != nil {
+				nodeInfo = framework.NewNodeInfo()
+				nodeInfo.SetNode(tc.node)
 			}
 
-			got := New().Drainable(drainCtx, tc.pod, nil)
+			got := New(tc.overrideTimeout).Drainable(drainCtx, tc.pod, nodeInfo)
 			assert.Equal(t, tc.wantReason, got.BlockingReason)
 			assert.Equal(t, tc.wantOutcome, got.Outcome)
 		})
@@ -18,8 +18,8 @@ package rules
 
 import (
 	apiv1 "k8s.io/api/core/v1"
-	"k8s.io/autoscaler/cluster-autoscaler/core/scaledown/pdb"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules/balloon"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules/daemonset"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules/localstorage"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules/longterminating"
@@ -32,6 +32,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules/system"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/drainability/rules/terminal"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator/options"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pdb"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 )
@@ -56,6 +57,7 @@ func Default(deleteOptions options.NodeDeleteOptions) Rules {
 	}{
 		{rule: mirror.New()},
 		{rule: longterminating.New()},
+		{rule: balloon.New(deleteOptions.BalloonPodPriorityClass), skip: deleteOptions.BalloonPodPriorityClass == ""},
 		{rule: replicacount.New(deleteOptions.MinReplicaCount), skip: !deleteOptions.SkipNodesWithCustomControllerPods},
 
 		// Interrupting checks
@@ -68,7 +70,7 @@ func Default(deleteOptions options.NodeDeleteOptions) Rules {
 		{rule: system.New(), skip: !deleteOptions.SkipNodesWithSystemPods},
 		{rule: notsafetoevict.New()},
 		{rule: localstorage.New(), skip: !deleteOptions.SkipNodesWithLocalStorage},
-		{rule: pdbrule.New()},
+		{rule: pdbrule.New(deleteOptions.UnreadyNodePdbOverrideTimeout)},
 	} {
 		if !r.skip {
 			rules = append(rules, r.rule)
@@ -17,6 +17,8 @@ limitations under the License.
 package options
 
 import (
+	"time"
+
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
 
@@ -35,6 +37,15 @@ type NodeDeleteOptions struct {
 	// set or replication controller should have to allow pod deletion during
 	// scale down.
 	MinReplicaCount int
+	// BalloonPodPriorityClass is the priority class of balloon pods, which never
+	// block node deletion. Empty if balloon pods aren't recognized.
+	BalloonPodPriorityClass string
+	// UnreadyNodePdbOverrideTimeout is the time after which PDBs with zero
+	// disruptions allowed stop blocking removal of unready nodes. Zero means never.
+	UnreadyNodePdbOverrideTimeout time.Duration
+	// ProportionalWorkloads are deployments scaled proportionally to the cluster
+	// size, whose surplus replicas don't need to be rescheduled on node removal.
+	ProportionalWorkloads []config.ProportionalWorkload
 }
 
 // NewNodeDeleteOptions returns new node delete options extracted from autoscaling options.
@@ -44,5 +55,8 @@ func NewNodeDeleteOptions(opts config.AutoscalingOptions) NodeDeleteOptions {
 		SkipNodesWithLocalStorage:         opts.SkipNodesWithLocalStorage,
 		SkipNodesWithCustomControllerPods: opts.SkipNodesWithCustomControllerPods,
 		MinReplicaCount:                   opts.MinReplicaCount,
+		BalloonPodPriorityClass:           opts.BalloonPodPriorityClass,
+		UnreadyNodePdbOverrideTimeout:     opts.UnreadyNodePdbOverrideTimeout,
+		ProportionalWorkloads:             opts.ProportionalWorkloads,
 	}
 }
@@ -29,3 +29,12 @@ type PredicateChecker interface {
 	FitsAnyNodeMatching(clusterSnapshot clustersnapshot.ClusterSnapshot, pod *apiv1.Pod, nodeMatches func(*schedulerframework.NodeInfo) bool) (string, error)
 	CheckPredicates(clusterSnapshot clustersnapshot.ClusterSnapshot, pod *apiv1.Pod, nodeName string) *PredicateError
 }
+
+// ConcurrentPredicateChecker is implemented by predicate checkers which can create
+// independent copies of themselves, so that predicates can be checked concurrently.
+type ConcurrentPredicateChecker interface {
+	PredicateChecker
+	// Copy returns a new predicate checker with the same configuration, which can be
+	// used concurrently with this one.
+	Copy() (PredicateChecker, error)
+}
@@ -41,6 +41,8 @@ type SchedulerBasedPredicateChecker struct {
 	nodeLister             v1listers.NodeLister
 	podLister              v1listers.PodLister
 	lastIndex              int
+	informerFactory        informers.SharedInformerFactory
+	schedConfig            *config.KubeSchedulerConfiguration
 }
 
 // NewSchedulerBasedPredicateChecker builds scheduler based PredicateChecker.
@@ -73,11 +75,19 @@ func NewSchedulerBasedPredicateChecker(informerFactory informers.SharedInformerF
 	checker := &SchedulerBasedPredicateChecker{
 		framework:              framework,
 		delegatingSharedLister: sharedLister,
+		informerFactory:        informerFactory,
+		schedConfig:            schedConfig,
 	}
 
 	return checker, nil
 }
 
+// Copy returns a new SchedulerBasedPredicateChecker with its own scheduler framework, built
+// from the same informers and scheduler config.
+func (p *SchedulerBasedPredicateChecker) Copy() (PredicateChecker, error) {
+	return NewSchedulerBasedPredicateChecker(p.informerFactory, p.schedConfig)
+}
+
 // FitsAnyNode checks if the given pod can be placed on any of the given nodes.
 func (p *SchedulerBasedPredicateChecker) FitsAnyNode(clusterSnapshot clustersnapshot.ClusterSnapshot, pod *apiv1.Pod) (string, error) {
 	return p.FitsAnyNodeMatching(clusterSnapshot, pod, func(*schedulerframework.NodeInfo) bool {
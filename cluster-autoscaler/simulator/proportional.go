@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+)
+
+// ParseProportionalWorkload parses a proportional workload in the format
+// <namespace>/<deployment>:nodesPerReplica=<n>,coresPerReplica=<n>,min=<n>, e.g.
+// kube-system/coredns:nodesPerReplica=16,coresPerReplica=256,min=2. Parameters
+// mirror the linear mode of cluster-proportional-autoscaler and are all optional,
+// but at least one of nodesPerReplica and coresPerReplica has to be set.
+func ParseProportionalWorkload(workloadStr string) (config.ProportionalWorkload, error) {
+	var workload config.ProportionalWorkload
+	nameAndParams := strings.SplitN(workloadStr, ":", 2)
+	if len(nameAndParams) != 2 {
+		return workload, fmt.Errorf("%q is not a deployment and parameters separated by ':'", workloadStr)
+	}
+	namespaceAndName := strings.Split(nameAndParams[0], "/")
+	if len(namespaceAndName) != 2 || namespaceAndName[0] == "" || namespaceAndName[1] == "" {
+		return workload, fmt.Errorf("%q is not a deployment in the format <namespace>/<name>", nameAndParams[0])
+	}
+	workload.Namespace, workload.Deployment = namespaceAndName[0], namespaceAndName[1]
+	for _, param := range strings.Split(nameAndParams[1], ",") {
+		keyAndValue := strings.Split(param, "=")
+		if len(keyAndValue) != 2 {
+			return workload, fmt.Errorf("%q is not a parameter in the format <key>=<value>", param)
+		}
+		var err error
+		switch keyAndValue[0] {
+		case "nodesPerReplica":
+			workload.NodesPerReplica, err = parsePerReplica(keyAndValue[1])
+		case "coresPerReplica":
+			workload.CoresPerReplica, err = parsePerReplica(keyAndValue[1])
+		case "min":
+			workload.Min, err = strconv.Atoi(keyAndValue[1])
+			if err == nil && workload.Min < 0 {
+				err = fmt.Errorf("negative value %d", workload.Min)
+			}
+		default:
+			err = fmt.Errorf("unknown parameter")
+		}
+		if err != nil {
+			return workload, fmt.Errorf("invalid parameter %q of %q: %v", param, workloadStr, err)
+		}
+	}
+	if workload.NodesPerReplica == 0 && workload.CoresPerReplica == 0 {
+		return workload, fmt.Errorf("neither nodesPerReplica nor coresPerReplica set in %q", workloadStr)
+	}
+	return workload, nil
+}
+
+func parsePerReplica(value string) (float64, error) {
+	perReplica, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if perReplica <= 0 {
+		return 0, fmt.Errorf("non-positive value %v", perReplica)
+	}
+	return perReplica, nil
+}
+
+// ProportionalSurplusPods returns the pods of proportional workloads, running on removedNodes, which
+// are expected to go away once these nodes are deleted from the cluster, i.e. the replicas beyond the
+// share of the workload for the remaining cluster. They don't need to be evicted, since the workload
+// shrinks along with the cluster.
+func ProportionalSurplusPods(snapshot clustersnapshot.ClusterSnapshot, workloads []config.ProportionalWorkload, removedNodes []*apiv1.Node) ([]*apiv1.Pod, error) {
+	removed := make(map[string]bool, len(removedNodes))
+	for _, node := range removedNodes {
+		removed[node.Name] = true
+	}
+	surplus, err := proportionalSurplus(snapshot, workloads, removed)
+	if err != nil || len(surplus) == 0 {
+		return nil, err
+	}
+	var surplusPods []*apiv1.Pod
+	for _, node := range removedNodes {
+		nodeInfo, err := snapshot.NodeInfos().Get(node.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, podInfo := range nodeInfo.Pods {
+			if i := proportionalWorkloadIndex(workloads, podInfo.Pod); i >= 0 && surplus[i] > 0 {
+				surplusPods = append(surplusPods, podInfo.Pod)
+				surplus[i]--
+			}
+		}
+	}
+	return surplusPods, nil
+}
+
+// proportionalSurplus returns, for each proportional workload, the number of its replicas which
+// are expected to go away once removedNodes are deleted from the cluster. The current replica count
+// is taken from the snapshot, while the expected one follows the linear scaling of the workload
+// with the nodes and cores remaining after the removal.
+func proportionalSurplus(snapshot clustersnapshot.ClusterSnapshot, workloads []config.ProportionalWorkload, removedNodes map[string]bool) (map[int]int, error) {
+	if len(workloads) == 0 {
+		return nil, nil
+	}
+	nodeInfos, err := snapshot.NodeInfos().List()
+	if err != nil {
+		return nil, err
+	}
+	nodes := 0
+	var milliCores int64
+	replicas := make([]int, len(workloads))
+	for _, nodeInfo := range nodeInfos {
+		for _, podInfo := range nodeInfo.Pods {
+			if i := proportionalWorkloadIndex(workloads, podInfo.Pod); i >= 0 {
+				replicas[i]++
+			}
+		}
+		if removedNodes[nodeInfo.Node().Name] {
+			continue
+		}
+		nodes++
+		milliCores += nodeInfo.Node().Status.Allocatable.Cpu().MilliValue()
+	}
+	surplus := make(map[int]int)
+	for i, workload := range workloads {
+		expected := workload.Min
+		if expected < 1 {
+			expected = 1
+		}
+		if workload.NodesPerReplica > 0 {
+			expected = max(expected, int(math.Ceil(float64(nodes)/workload.NodesPerReplica)))
+		}
+		if workload.CoresPerReplica > 0 {
+			expected = max(expected, int(math.Ceil(float64(milliCores)/1000/workload.CoresPerReplica)))
+		}
+		if replicas[i] > expected {
+			surplus[i] = replicas[i] - expected
+		}
+	}
+	return surplus, nil
+}
+
+// proportionalWorkloadIndex returns the index of the proportional workload the pod belongs to, or -1.
+// Pods of a deployment are owned by its replica sets, named after the deployment and the pod template hash.
+func proportionalWorkloadIndex(workloads []config.ProportionalWorkload, pod *apiv1.Pod) int {
+	controllerRef := metav1.GetControllerOf(pod)
+	if controllerRef == nil || controllerRef.Kind != "ReplicaSet" {
+		return -1
+	}
+	hash, found := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	if !found {
+		return -1
+	}
+	for i, workload := range workloads {
+		if pod.Namespace == workload.Namespace && controllerRef.Name == workload.Deployment+"-"+hash {
+			return i
+		}
+	}
+	return -1
+}
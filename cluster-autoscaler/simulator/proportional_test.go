@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/clustersnapshot"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/predicatechecker"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestParseProportionalWorkload(t *testing.T) {
+	tests := []struct {
+		workload string
+		expected config.ProportionalWorkload
+		wantErr  bool
+	}{
+		{
+			workload: "kube-system/coredns:nodesPerReplica=16,coresPerReplica=256,min=2",
+			expected: config.ProportionalWorkload{Namespace: "kube-system", Deployment: "coredns", NodesPerReplica: 16, CoresPerReplica: 256, Min: 2},
+		},
+		{
+			workload: "kube-system/kube-dns:coresPerReplica=0.5",
+			expected: config.ProportionalWorkload{Namespace: "kube-system", Deployment: "kube-dns", CoresPerReplica: 0.5},
+		},
+		{workload: "coredns:nodesPerReplica=16", wantErr: true},
+		{workload: "kube-system/coredns", wantErr: true},
+		{workload: "kube-system/coredns:min=2", wantErr: true},
+		{workload: "kube-system/coredns:nodesPerReplica=0", wantErr: true},
+		{workload: "kube-system/coredns:nodesPerReplica=16,min=-1", wantErr: true},
+		{workload: "kube-system/coredns:replicas=3", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.workload, func(t *testing.T) {
+			workload, err := ParseProportionalWorkload(test.workload)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, workload)
+		})
+	}
+}
+
+func TestFindNodesToRemoveWithProportionalWorkloads(t *testing.T) {
+	replicaSets := []*appsv1.ReplicaSet{{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns-5d78c9869d", Namespace: "default"},
+	}}
+	rsLister, err := kube_util.NewTestReplicaSetLister(replicaSets)
+	assert.NoError(t, err)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, rsLister, nil)
+
+	// Each node runs a replica of the dns deployment, which can't share a node with another one.
+	var nodes []*apiv1.Node
+	var pods []*apiv1.Pod
+	for i := 0; i < 3; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 2000000)
+		SetNodeReadyState(node, true, time.Time{})
+		nodes = append(nodes, node)
+		pod := BuildTestPod(fmt.Sprintf("dns-%d", i), 600, 100000)
+		pod.OwnerReferences = GenerateOwnerReferences("dns-5d78c9869d", "ReplicaSet", "apps/v1", "")
+		pod.Labels = map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d78c9869d"}
+		pod.Spec.NodeName = node.Name
+		pods = append(pods, pod)
+	}
+	destinations := []string{"n0", "n1", "n2"}
+
+	tests := []struct {
+		name      string
+		workloads []config.ProportionalWorkload
+		removable bool
+	}{
+		{
+			name:      "no proportional workloads",
+			removable: false,
+		},
+		{
+			name:      "replicas shrink with the nodes",
+			workloads: []config.ProportionalWorkload{{Namespace: "default", Deployment: "dns", NodesPerReplica: 1}},
+			removable: true,
+		},
+		{
+			name:      "replicas kept by the minimum",
+			workloads: []config.ProportionalWorkload{{Namespace: "default", Deployment: "dns", NodesPerReplica: 1, Min: 3}},
+			removable: false,
+		},
+		{
+			name:      "replicas kept by the cores",
+			workloads: []config.ProportionalWorkload{{Namespace: "default", Deployment: "dns", NodesPerReplica: 1, CoresPerReplica: 0.5}},
+			removable: false,
+		},
+		{
+			name:      "other deployment",
+			workloads: []config.ProportionalWorkload{{Namespace: "default", Deployment: "coredns", NodesPerReplica: 1}},
+			removable: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clusterSnapshot := clustersnapshot.NewBasicClusterSnapshot()
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, clusterSnapshot, nodes, pods)
+			predicateChecker, err := predicatechecker.NewTestPredicateChecker()
+			assert.NoError(t, err)
+			deleteOptions := testDeleteOptions()
+			deleteOptions.ProportionalWorkloads = test.workloads
+			r := NewRemovalSimulator(registry, clusterSnapshot, predicateChecker, NewUsageTracker(), deleteOptions, nil, false)
+			toRemove, unremovable := r.FindNodesToRemove([]string{"n0"}, destinations, time.Now(), nil)
+			if test.removable {
+				assert.Len(t, toRemove, 1)
+				assert.Empty(t, unremovable)
+				// The surplus replica is only left out of the actuation, see ProportionalSurplusPods.
+				assert.Equal(t, []*apiv1.Pod{pods[0]}, toRemove[0].PodsToReschedule)
+			} else {
+				assert.Empty(t, toRemove)
+				assert.Equal(t, []*UnremovableNode{{Node: nodes[0], Reason: NoPlaceToMovePods}}, unremovable)
+			}
+		})
+	}
+}
+
+func TestProportionalSurplusPods(t *testing.T) {
+	// Each of the 4 nodes runs 2 replicas of the dns deployment, one replica is needed per 2 nodes.
+	var nodes []*apiv1.Node
+	var pods []*apiv1.Pod
+	for i := 0; i < 4; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 2000000)
+		nodes = append(nodes, node)
+		for j := 0; j < 2; j++ {
+			pod := BuildTestPod(fmt.Sprintf("dns-%d-%d", i, j), 100, 100000)
+			pod.OwnerReferences = GenerateOwnerReferences("dns-5d78c9869d", "ReplicaSet", "apps/v1", "")
+			pod.Labels = map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d78c9869d"}
+			pod.Spec.NodeName = node.Name
+			pods = append(pods, pod)
+		}
+	}
+	workloads := []config.ProportionalWorkload{{Namespace: "default", Deployment: "dns", NodesPerReplica: 2}}
+
+	tests := []struct {
+		name         string
+		workloads    []config.ProportionalWorkload
+		removedNodes []*apiv1.Node
+		expected     []*apiv1.Pod
+	}{
+		{
+			name:         "no proportional workloads",
+			removedNodes: nodes[:1],
+		},
+		{
+			name:         "all replicas of the removed node are surplus",
+			workloads:    workloads,
+			removedNodes: nodes[:1],
+			expected:     pods[:2],
+		},
+		{
+			name:         "replicas of all removed nodes are surplus",
+			workloads:    workloads,
+			removedNodes: nodes[:2],
+			expected:     pods[:4],
+		},
+		{
+			// 7 of the 8 replicas are kept, so the other replica of the removed node has to be evicted.
+			name:         "surplus used by the first replica",
+			workloads:    []config.ProportionalWorkload{{Namespace: "default", Deployment: "dns", NodesPerReplica: 2, Min: 7}},
+			removedNodes: nodes[:1],
+			expected:     pods[:1],
+		},
+		{
+			name:         "last replica kept",
+			workloads:    workloads,
+			removedNodes: nodes,
+			expected:     pods[:7],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clusterSnapshot := clustersnapshot.NewBasicClusterSnapshot()
+			clustersnapshot.InitializeClusterSnapshotOrDie(t, clusterSnapshot, nodes, pods)
+			surplusPods, err := ProportionalSurplusPods(clusterSnapshot, test.workloads, test.removedNodes)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, surplusPods)
+		})
+	}
+}
@@ -31,6 +31,28 @@ type exponentialBackoff struct {
 	backoffResetTimeout    time.Duration
 	backoffInfo            map[string]exponentialBackoffInfo
 	nodeGroupKey           func(nodeGroup cloudprovider.NodeGroup) string
+	errorKindPolicies      map[cloudprovider.ErrorKind]ErrorKindPolicy
+}
+
+// ErrorKindPolicy overrides the backoff durations for failures caused by a given
+// kind of cloud provider errors. Zero durations fall back to the default ones.
+type ErrorKindPolicy struct {
+	// InitialBackoffDuration is the duration of the first backoff.
+	InitialBackoffDuration time.Duration
+	// MaxBackoffDuration caps the exponentially increasing backoff duration.
+	MaxBackoffDuration time.Duration
+}
+
+// DefaultErrorKindPolicies returns the backoff policies used for kinds of cloud
+// provider errors that say little about the node group itself. Throttling and
+// transient network errors don't increase the backoff duration exponentially.
+// Other kinds, including permission errors, which may be fixed at any time,
+// get the regular exponential backoff.
+func DefaultErrorKindPolicies(initialBackoffDuration time.Duration) map[cloudprovider.ErrorKind]ErrorKindPolicy {
+	return map[cloudprovider.ErrorKind]ErrorKindPolicy{
+		cloudprovider.ThrottlingErrorKind:       {InitialBackoffDuration: initialBackoffDuration, MaxBackoffDuration: initialBackoffDuration},
+		cloudprovider.TransientNetworkErrorKind: {InitialBackoffDuration: initialBackoffDuration, MaxBackoffDuration: initialBackoffDuration},
+	}
 }
 
 type exponentialBackoffInfo struct {
@@ -38,6 +60,9 @@ type exponentialBackoffInfo struct {
 	backoffUntil        time.Time
 	lastFailedExecution time.Time
 	errorInfo           cloudprovider.InstanceErrorInfo
+	// untilQuotaRetryAfter is true if the backoff lasts until the time the exceeded
+	// quota is expected to be available, rather than for the exponential duration.
+	untilQuotaRetryAfter bool
 }
 
 // NewExponentialBackoff creates an instance of exponential backoff.
@@ -46,12 +71,24 @@ func NewExponentialBackoff(
 	maxBackoffDuration time.Duration,
 	backoffResetTimeout time.Duration,
 	nodeGroupKey func(nodeGroup cloudprovider.NodeGroup) string) Backoff {
+	return NewExponentialBackoffWithErrorKindPolicies(initialBackoffDuration, maxBackoffDuration, backoffResetTimeout, nodeGroupKey, nil)
+}
+
+// NewExponentialBackoffWithErrorKindPolicies creates an instance of exponential backoff
+// using the given policies for failures caused by the corresponding kinds of errors.
+func NewExponentialBackoffWithErrorKindPolicies(
+	initialBackoffDuration time.Duration,
+	maxBackoffDuration time.Duration,
+	backoffResetTimeout time.Duration,
+	nodeGroupKey func(nodeGroup cloudprovider.NodeGroup) string,
+	errorKindPolicies map[cloudprovider.ErrorKind]ErrorKindPolicy) Backoff {
 	return &exponentialBackoff{
 		maxBackoffDuration:     maxBackoffDuration,
 		initialBackoffDuration: initialBackoffDuration,
 		backoffResetTimeout:    backoffResetTimeout,
 		backoffInfo:            make(map[string]exponentialBackoffInfo),
 		nodeGroupKey:           nodeGroupKey,
+		errorKindPolicies:      errorKindPolicies,
 	}
 }
 
@@ -66,33 +103,76 @@ func NewIdBasedExponentialBackoff(initialBackoffDuration time.Duration, maxBacko
 		})
 }
 
+// NewIdBasedExponentialBackoffWithErrorKindPolicies creates an instance of exponential backoff with node
+// group Id used as a key, using the given policies for failures caused by the corresponding kinds of errors.
+func NewIdBasedExponentialBackoffWithErrorKindPolicies(initialBackoffDuration time.Duration, maxBackoffDuration time.Duration, backoffResetTimeout time.Duration, errorKindPolicies map[cloudprovider.ErrorKind]ErrorKindPolicy) Backoff {
+	return NewExponentialBackoffWithErrorKindPolicies(
+		initialBackoffDuration,
+		maxBackoffDuration,
+		backoffResetTimeout,
+		func(nodeGroup cloudprovider.NodeGroup) string {
+			return nodeGroup.Id()
+		},
+		errorKindPolicies)
+}
+
 // Backoff execution for the given node group. Returns time till execution is backed off.
 func (b *exponentialBackoff) Backoff(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulerframework.NodeInfo, errorInfo cloudprovider.InstanceErrorInfo, currentTime time.Time) time.Time {
-	duration := b.initialBackoffDuration
+	initialBackoffDuration, maxBackoffDuration := b.backoffDurations(errorInfo.ErrorKind)
+	duration := initialBackoffDuration
 	key := b.nodeGroupKey(nodeGroup)
 	if backoffInfo, found := b.backoffInfo[key]; found {
 		// Multiple concurrent scale-ups failing shouldn't cause
 		// backoff duration to increase exponentially
 		duration = backoffInfo.duration
-		if backoffInfo.backoffUntil.Before(currentTime) {
+		if backoffInfo.backoffUntil.Before(currentTime) && !backoffInfo.untilQuotaRetryAfter {
 			// NodeGroup is not currently in backoff, but was recently
 			// Increase backoff duration exponentially
 			duration = 2 * backoffInfo.duration
-			if duration > b.maxBackoffDuration {
-				duration = b.maxBackoffDuration
-			}
+		}
+		if duration < initialBackoffDuration {
+			duration = initialBackoffDuration
+		}
+		if duration > maxBackoffDuration {
+			duration = maxBackoffDuration
 		}
 	}
 	backoffUntil := currentTime.Add(duration)
+	retryAfter, untilQuotaRetryAfter := quotaRetryAfter(errorInfo, currentTime)
+	if untilQuotaRetryAfter {
+		// The cloud provider knows when the quota is available again, so there's no point in
+		// retrying earlier, nor waiting longer. The exponential duration isn't increased by
+		// the next failure, as this one doesn't tell anything about the node group itself.
+		backoffUntil = retryAfter
+		if maxBackoffUntil := currentTime.Add(maxBackoffDuration); backoffUntil.After(maxBackoffUntil) {
+			backoffUntil = maxBackoffUntil
+		}
+	}
 	b.backoffInfo[key] = exponentialBackoffInfo{
-		duration:            duration,
-		backoffUntil:        backoffUntil,
-		lastFailedExecution: currentTime,
-		errorInfo:           errorInfo,
+		duration:             duration,
+		backoffUntil:         backoffUntil,
+		lastFailedExecution:  currentTime,
+		errorInfo:            errorInfo,
+		untilQuotaRetryAfter: untilQuotaRetryAfter,
 	}
 	return backoffUntil
 }
 
+// backoffDurations returns the initial and maximum backoff durations for failures
+// caused by errors of the given kind.
+func (b *exponentialBackoff) backoffDurations(errorKind cloudprovider.ErrorKind) (time.Duration, time.Duration) {
+	initialBackoffDuration, maxBackoffDuration := b.initialBackoffDuration, b.maxBackoffDuration
+	if policy, found := b.errorKindPolicies[errorKind]; found {
+		if policy.InitialBackoffDuration > 0 {
+			initialBackoffDuration = policy.InitialBackoffDuration
+		}
+		if policy.MaxBackoffDuration > 0 {
+			maxBackoffDuration = policy.MaxBackoffDuration
+		}
+	}
+	return initialBackoffDuration, maxBackoffDuration
+}
+
 // BackoffStatus returns whether the execution is backed off for the given node group and error info when the node group is backed off.
 func (b *exponentialBackoff) BackoffStatus(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulerframework.NodeInfo, currentTime time.Time) Status {
 	backoffInfo, found := b.backoffInfo[b.nodeGroupKey(nodeGroup)]
@@ -118,3 +198,12 @@ func (b *exponentialBackoff) RemoveStaleBackoffData(currentTime time.Time) {
 		}
 	}
 }
+
+// quotaRetryAfter returns the time after which the exceeded quota is expected
+// to be available again, if the error is caused by a quota and the time is known.
+func quotaRetryAfter(errorInfo cloudprovider.InstanceErrorInfo, currentTime time.Time) (time.Time, bool) {
+	if errorInfo.Quota == nil || !errorInfo.Quota.RetryAfter.After(currentTime) {
+		return time.Time{}, false
+	}
+	return errorInfo.Quota.RetryAfter, true
+}
@@ -116,3 +116,46 @@ func TestIncreaseExistingBackoff(t *testing.T) {
 	assert.Equal(t, noBackOff, backoff.BackoffStatus(nodeGroup1, nil, currentTime))
 	// Result: existing backoff duration was scaled up beyond initial duration
 }
+
+func TestQuotaRetryAfterBackoff(t *testing.T) {
+	backoff := NewIdBasedExponentialBackoff(10*time.Minute, time.Hour, 3*time.Hour)
+	startTime := time.Now()
+	retryAfterError := func(retryAfter time.Time) cloudprovider.InstanceErrorInfo {
+		errorInfo := quotaError
+		errorInfo.Quota = &cloudprovider.QuotaErrorInfo{Quota: "CPUS", RetryAfter: retryAfter}
+		return errorInfo
+	}
+
+	// The node group is backed off until the quota is available again, rather than for the initial duration.
+	assert.Equal(t, startTime.Add(2*time.Minute), backoff.Backoff(nodeGroup1, nil, retryAfterError(startTime.Add(2*time.Minute)), startTime))
+	assert.True(t, backoff.BackoffStatus(nodeGroup1, nil, startTime.Add(time.Minute)).IsBackedOff)
+	assert.Equal(t, noBackOff, backoff.BackoffStatus(nodeGroup1, nil, startTime.Add(2*time.Minute+time.Millisecond)))
+
+	// Retry-After doesn't increase the duration of the following backoffs.
+	assert.Equal(t, startTime.Add(13*time.Minute), backoff.Backoff(nodeGroup1, nil, quotaError, startTime.Add(3*time.Minute)))
+
+	// Backoffs are limited to the max duration.
+	assert.Equal(t, startTime.Add(time.Hour), backoff.Backoff(nodeGroup2, nil, retryAfterError(startTime.Add(2*time.Hour)), startTime))
+
+	// Retry-After in the past falls back to the exponential backoff.
+	backoff.RemoveBackoff(nodeGroup2, nil)
+	assert.Equal(t, startTime.Add(10*time.Minute), backoff.Backoff(nodeGroup2, nil, retryAfterError(startTime.Add(-time.Minute)), startTime))
+}
+
+func TestErrorKindPolicies(t *testing.T) {
+	backoff := NewIdBasedExponentialBackoffWithErrorKindPolicies(time.Minute, time.Hour, 3*time.Hour, DefaultErrorKindPolicies(time.Minute))
+	startTime := time.Now()
+	throttlingError := cloudprovider.InstanceErrorInfo{ErrorClass: cloudprovider.OtherErrorClass, ErrorKind: cloudprovider.ThrottlingErrorKind, ErrorCode: "cloudProviderError"}
+	permissionError := cloudprovider.InstanceErrorInfo{ErrorClass: cloudprovider.OtherErrorClass, ErrorKind: cloudprovider.PermissionErrorKind, ErrorCode: "cloudProviderError"}
+
+	// Throttling doesn't increase the backoff duration exponentially.
+	assert.Equal(t, startTime.Add(time.Minute), backoff.Backoff(nodeGroup1, nil, throttlingError, startTime))
+	assert.Equal(t, startTime.Add(3*time.Minute), backoff.Backoff(nodeGroup1, nil, throttlingError, startTime.Add(2*time.Minute)))
+
+	// Other errors increase it from the last duration.
+	assert.Equal(t, startTime.Add(6*time.Minute), backoff.Backoff(nodeGroup1, nil, ipSpaceExhaustedError, startTime.Add(4*time.Minute)))
+
+	// Permission errors get the regular exponential backoff.
+	assert.Equal(t, startTime.Add(time.Minute), backoff.Backoff(nodeGroup2, nil, permissionError, startTime))
+	assert.Equal(t, startTime.Add(4*time.Minute), backoff.Backoff(nodeGroup2, nil, permissionError, startTime.Add(2*time.Minute)))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowAPIServerRequestThreshold is the latency above which a list counts as failed.
+const SlowAPIServerRequestThreshold = 10 * time.Second
+
+// trackedResources are the resources whose informers the autoscaler acts on.
+var trackedResources = []string{"nodes", "pods"}
+
+// APIServerHealth tracks the health of the API server from the requests sent by the clients whose transport
+// it wraps, without sending requests of its own. The API server is degraded from a failed or slow list or watch
+// of nodes or pods, or a network or server error of any other request for them, until they're listed or watched
+// again, i.e. until their informers have resynced. Throttling of requests other than lists and watches, e.g. an
+// eviction blocked by a PodDisruptionBudget, which is answered with 429, doesn't degrade the API server.
+type APIServerHealth struct {
+	mutex sync.Mutex
+	// lastFailure is the time of the last failed request, per resource.
+	lastFailure map[string]time.Time
+	// lastFailureReason is the reason of the last failed request, per resource.
+	lastFailureReason map[string]string
+	// lastResync is the start time of the last successful list or watch, per resource.
+	lastResync map[string]time.Time
+	now        func() time.Time
+}
+
+// NewAPIServerHealth returns an APIServerHealth with a healthy API server.
+func NewAPIServerHealth() *APIServerHealth {
+	return &APIServerHealth{
+		lastFailure:       make(map[string]time.Time),
+		lastFailureReason: make(map[string]string),
+		lastResync:        make(map[string]time.Time),
+		now:               time.Now,
+	}
+}
+
+// WrapTransport wraps the transport of a client, so that its requests are tracked.
+func (h *APIServerHealth) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &healthTrackingRoundTripper{health: h, rt: rt}
+}
+
+// Degraded returns whether the API server is degraded and, if it is, why. A nil APIServerHealth is never degraded.
+func (h *APIServerHealth) Degraded() (bool, string) {
+	if h == nil {
+		return false, ""
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, resource := range trackedResources {
+		if failure, found := h.lastFailure[resource]; found && !h.lastResync[resource].After(failure) {
+			return true, fmt.Sprintf("%s not resynced since %s", resource, h.lastFailureReason[resource])
+		}
+	}
+	return false, ""
+}
+
+func (h *APIServerHealth) observe(req *http.Request, resp *http.Response, err error, start time.Time) {
+	resource, collection := coreResource(req.URL.Path)
+	if resource == "" {
+		return
+	}
+	listOrWatch := collection && req.Method == http.MethodGet
+	watch := listOrWatch && req.URL.Query().Get("watch") == "true"
+	end := h.now()
+
+	var failure string
+	switch {
+	case err != nil:
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		failure = fmt.Sprintf("%s %s failed: %v", req.Method, req.URL.Path, err)
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented,
+		listOrWatch && resp.StatusCode == http.StatusTooManyRequests:
+		failure = fmt.Sprintf("%s %s returned %d", req.Method, req.URL.Path, resp.StatusCode)
+	case listOrWatch && !watch && end.Sub(start) > SlowAPIServerRequestThreshold:
+		failure = fmt.Sprintf("%s %s took %v", req.Method, req.URL.Path, end.Sub(start))
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if failure != "" {
+		h.lastFailure[resource] = end
+		h.lastFailureReason[resource] = failure
+		return
+	}
+	// Only a list or a watch started after the failure resyncs the informer.
+	if listOrWatch && start.After(h.lastResync[resource]) {
+		h.lastResync[resource] = start
+	}
+}
+
+// coreResource returns the tracked core resource a request path is for, and whether
+// the path is for the collection rather than a single object.
+func coreResource(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" || parts[1] != "v1" {
+		return "", false
+	}
+	parts = parts[2:]
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	for _, resource := range trackedResources {
+		if parts[0] == resource {
+			return resource, len(parts) == 1
+		}
+	}
+	return "", false
+}
+
+type healthTrackingRoundTripper struct {
+	health *APIServerHealth
+	rt     http.RoundTripper
+}
+
+// RoundTrip sends the request and records its result.
+func (t *healthTrackingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.health.now()
+	resp, err := t.rt.RoundTrip(req)
+	t.health.observe(req, resp, err, start)
+	return resp, err
+}
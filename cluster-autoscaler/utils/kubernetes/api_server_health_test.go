@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAPIServerHealth(t *testing.T) {
+	now := time.Now()
+	var latency time.Duration
+	var statusCode int
+	var requestErr error
+	health := NewAPIServerHealth()
+	health.now = func() time.Time { return now }
+	rt := health.WrapTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		now = now.Add(latency)
+		if requestErr != nil {
+			return nil, requestErr
+		}
+		return &http.Response{StatusCode: statusCode}, nil
+	}))
+	send := func(method, url string) {
+		req, err := http.NewRequest(method, url, nil)
+		assert.NoError(t, err)
+		_, _ = rt.RoundTrip(req)
+		now = now.Add(time.Second)
+	}
+
+	statusCode = http.StatusOK
+	send(http.MethodGet, "https://api/api/v1/nodes")
+	degraded, _ := health.Degraded()
+	assert.False(t, degraded, "healthy")
+
+	// Failures of other resources are ignored.
+	statusCode = http.StatusInternalServerError
+	send(http.MethodGet, "https://api/api/v1/namespaces/kube-system/configmaps/status")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "other resource failed")
+
+	requestErr = fmt.Errorf("connection refused")
+	send(http.MethodGet, "https://api/api/v1/pods?watch=true")
+	degraded, reason := health.Degraded()
+	assert.True(t, degraded, "pods watch failed")
+	assert.Contains(t, reason, "connection refused")
+
+	// Getting a single pod doesn't resync the informer.
+	requestErr = nil
+	statusCode = http.StatusOK
+	send(http.MethodGet, "https://api/api/v1/namespaces/default/pods/p1")
+	degraded, _ = health.Degraded()
+	assert.True(t, degraded, "single pod fetched")
+
+	send(http.MethodGet, "https://api/api/v1/pods?watch=true")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "pods watched again")
+
+	// An eviction blocked by a PodDisruptionBudget is answered with 429.
+	statusCode = http.StatusTooManyRequests
+	send(http.MethodPost, "https://api/api/v1/namespaces/default/pods/p1/eviction")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "eviction blocked by a PDB")
+
+	send(http.MethodPatch, "https://api/api/v1/nodes/n1")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "node patch throttled")
+
+	statusCode = http.StatusServiceUnavailable
+	send(http.MethodPatch, "https://api/api/v1/nodes/n1")
+	degraded, _ = health.Degraded()
+	assert.True(t, degraded, "node patch failed")
+
+	statusCode = http.StatusOK
+	send(http.MethodGet, "https://api/api/v1/nodes")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "nodes listed again")
+
+	// Only lists are slow.
+	latency = 2 * SlowAPIServerRequestThreshold
+	send(http.MethodGet, "https://api/api/v1/nodes/n1")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "node fetched slowly")
+
+	latency = 0
+	statusCode = http.StatusTooManyRequests
+	send(http.MethodGet, "https://api/api/v1/nodes")
+	degraded, _ = health.Degraded()
+	assert.True(t, degraded, "nodes list throttled")
+
+	statusCode = http.StatusOK
+	latency = 2 * SlowAPIServerRequestThreshold
+	send(http.MethodGet, "https://api/api/v1/nodes")
+	degraded, reason = health.Degraded()
+	assert.True(t, degraded, "nodes listed slowly")
+	assert.Contains(t, reason, "took")
+
+	// Watches are long-running, so their latency is ignored.
+	send(http.MethodGet, "https://api/api/v1/nodes?watch=true")
+	degraded, _ = health.Degraded()
+	assert.False(t, degraded, "nodes watched again")
+
+	var noHealth *APIServerHealth
+	degraded, _ = noHealth.Degraded()
+	assert.False(t, degraded, "not tracked")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdb
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+)
+
+// OverridesZeroDisruptions returns true if PDBs with zero disruptions allowed
+// shouldn't block removal of the node, because it has been unready for longer
+// than the given timeout. Zero timeout means PDBs are never overridden.
+func OverridesZeroDisruptions(node *apiv1.Node, timeout time.Duration, now time.Time) bool {
+	if timeout <= 0 || node == nil {
+		return false
+	}
+	ready, lastTransitionTime, err := kube_util.GetReadinessState(node)
+	if err != nil || ready {
+		return false
+	}
+	return now.Sub(lastTransitionTime) >= timeout
+}
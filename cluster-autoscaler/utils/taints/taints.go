@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -30,6 +31,8 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	kube_client "k8s.io/client-go/kubernetes"
 	kube_record "k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
 	cloudproviderapi "k8s.io/cloud-provider/api"
 
 	klog "k8s.io/klog/v2"
@@ -284,6 +287,22 @@ func GetTaintTime(node *apiv1.Node, taintKey string) (*time.Time, error) {
 	return nil, nil
 }
 
+// GetStaleDeletionCandidates returns nodes with DeletionCandidate taint older than ttl.
+// Taints without a valid timestamp are considered stale, as their age can't be determined.
+func GetStaleDeletionCandidates(nodes []*apiv1.Node, ttl time.Duration, now time.Time) []*apiv1.Node {
+	var result []*apiv1.Node
+	for _, node := range nodes {
+		if !HasDeletionCandidateTaint(node) {
+			continue
+		}
+		taintTime, err := GetDeletionCandidateTime(node)
+		if err != nil || taintTime == nil || now.Sub(*taintTime) > ttl {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
 // CleanToBeDeleted cleans CA's NoSchedule taint from a node.
 func CleanToBeDeleted(node *apiv1.Node, client kube_client.Interface, cordonNode bool) (bool, error) {
 	return CleanTaints(node, client, []string{ToBeDeletedTaint}, cordonNode)
@@ -364,25 +383,59 @@ func CleanAllDeletionCandidates(nodes []*apiv1.Node, client kube_client.Interfac
 	CleanAllTaints(nodes, client, recorder, []string{DeletionCandidateTaint}, false)
 }
 
+// CleanupLimits bounds the requests sent to clean taints from many nodes.
+type CleanupLimits struct {
+	// Parallelism is the number of nodes cleaned at the same time.
+	Parallelism int
+	// RateLimiter throttles the nodes cleaned, if not nil.
+	RateLimiter flowcontrol.RateLimiter
+}
+
+// CleanupResult counts the nodes taints were removed from, and the nodes they failed to be removed from.
+type CleanupResult struct {
+	Cleaned int
+	Failed  int
+}
+
 // CleanAllTaints cleans all specified taints from given nodes.
 func CleanAllTaints(nodes []*apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder, taintKeys []string, cordonNode bool) {
+	CleanAllTaintsInParallel(nodes, client, recorder, taintKeys, cordonNode, CleanupLimits{Parallelism: 1})
+}
+
+// CleanAllTaintsInParallel cleans all specified taints from given nodes, cleaning several nodes
+// at the same time within the limits, and returns how many nodes were cleaned or failed to be.
+func CleanAllTaintsInParallel(nodes []*apiv1.Node, client kube_client.Interface, recorder kube_record.EventRecorder, taintKeys []string, cordonNode bool, limits CleanupLimits) CleanupResult {
+	var taintedNodes []*apiv1.Node
 	for _, node := range nodes {
-		taintsPresent := false
 		for _, taintKey := range taintKeys {
-			taintsPresent = taintsPresent || HasTaint(node, taintKey)
+			if HasTaint(node, taintKey) {
+				taintedNodes = append(taintedNodes, node)
+				break
+			}
 		}
-		if !taintsPresent {
-			continue
+	}
+	parallelism := limits.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	var cleanedCount, failedCount int32
+	workqueue.ParallelizeUntil(context.Background(), parallelism, len(taintedNodes), func(piece int) {
+		node := taintedNodes[piece]
+		if limits.RateLimiter != nil {
+			limits.RateLimiter.Accept()
 		}
 		cleaned, err := CleanTaints(node, client, taintKeys, cordonNode)
 		if err != nil {
+			atomic.AddInt32(&failedCount, 1)
 			recorder.Eventf(node, apiv1.EventTypeWarning, "ClusterAutoscalerCleanup",
 				"failed to clean %v on node %v: %v", strings.Join(taintKeys, ","), node.Name, err)
 		} else if cleaned {
+			atomic.AddInt32(&cleanedCount, 1)
 			recorder.Eventf(node, apiv1.EventTypeNormal, "ClusterAutoscalerCleanup",
 				"removed %v taints from node %v", strings.Join(taintKeys, ","), node.Name)
 		}
-	}
+	})
+	return CleanupResult{Cleaned: int(cleanedCount), Failed: int(failedCount)}
 }
 
 func matchesAnyPrefix(prefixes []string, key string) bool {
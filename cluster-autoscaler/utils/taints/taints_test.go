@@ -35,6 +35,7 @@ import (
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/flowcontrol"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -111,6 +112,28 @@ func TestSoftCheckNodes(t *testing.T) {
 	assert.True(t, HasDeletionCandidateTaint(updatedNode))
 }
 
+func TestGetStaleDeletionCandidates(t *testing.T) {
+	now := time.Now()
+	buildNode := func(name, taintValue string) *apiv1.Node {
+		node := BuildTestNode(name, 1000, 1000)
+		if taintValue != "" {
+			addTaintsToSpec(node, []apiv1.Taint{{
+				Key:    DeletionCandidateTaint,
+				Value:  taintValue,
+				Effect: apiv1.TaintEffectPreferNoSchedule,
+			}}, false)
+		}
+		return node
+	}
+	fresh := buildNode("fresh", fmt.Sprint(now.Add(-time.Minute).Unix()))
+	stale := buildNode("stale", fmt.Sprint(now.Add(-2*time.Hour).Unix()))
+	invalid := buildNode("invalid", "not-a-timestamp")
+	untainted := buildNode("untainted", "")
+
+	result := GetStaleDeletionCandidates([]*apiv1.Node{fresh, stale, invalid, untainted}, time.Hour, now)
+	assert.ElementsMatch(t, []*apiv1.Node{stale, invalid}, result)
+}
+
 func TestQueryNodes(t *testing.T) {
 	defer setConflictRetryInterval(setConflictRetryInterval(time.Millisecond))
 	node := BuildTestNode("node", 1000, 1000)
@@ -309,6 +332,31 @@ func TestCleanAllDeletionCandidates(t *testing.T) {
 	assert.Equal(t, 0, len(getNode(t, fakeClient, "n2").Spec.Taints))
 }
 
+func TestCleanAllTaintsInParallel(t *testing.T) {
+	var nodes []*apiv1.Node
+	for i := 0; i < 20; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 10)
+		if i%2 == 0 {
+			node.Spec.Taints = []apiv1.Taint{{Key: ToBeDeletedTaint, Value: strconv.FormatInt(time.Now().Unix()-301, 10)}}
+		}
+		nodes = append(nodes, node)
+	}
+	fakeClient := buildFakeClient(t, nodes...)
+	fakeRecorder := kube_util.CreateEventRecorder(fakeClient, false)
+
+	// A node missing from the cluster fails to be cleaned.
+	missing := BuildTestNode("missing", 1000, 10)
+	missing.Spec.Taints = []apiv1.Taint{{Key: ToBeDeletedTaint, Value: strconv.FormatInt(time.Now().Unix()-301, 10)}}
+
+	limits := CleanupLimits{Parallelism: 4, RateLimiter: flowcontrol.NewTokenBucketRateLimiter(1000, 1)}
+	result := CleanAllTaintsInParallel(append(nodes, missing), fakeClient, fakeRecorder, []string{ToBeDeletedTaint}, false, limits)
+
+	assert.Equal(t, CleanupResult{Cleaned: 10, Failed: 1}, result)
+	for _, node := range nodes {
+		assert.False(t, HasToBeDeletedTaint(getNode(t, fakeClient, node.Name)))
+	}
+}
+
 func setConflictRetryInterval(interval time.Duration) time.Duration {
 	before := conflictRetryInterval
 	conflictRetryInterval = interval
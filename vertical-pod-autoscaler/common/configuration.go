@@ -0,0 +1,381 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfigurationAPIVersion is the API version of the configuration file.
+	ConfigurationAPIVersion = "config.vpa.autoscaling.k8s.io/v1alpha1"
+	// ConfigurationKind is the kind of the configuration file.
+	ConfigurationKind = "VerticalPodAutoscalerConfiguration"
+
+	// RecommenderComponent is the name of the recommender section of the configuration file.
+	RecommenderComponent = "recommender"
+	// UpdaterComponent is the name of the updater section of the configuration file.
+	UpdaterComponent = "updater"
+	// AdmissionControllerComponent is the name of the admission controller section of the configuration file.
+	AdmissionControllerComponent = "admissionController"
+
+	// configurationReloadInterval is how often the configuration file is checked for changes.
+	configurationReloadInterval = 30 * time.Second
+)
+
+// reloadableFlags are the flags whose changes in the configuration file are applied without
+// restarting the component. Changes of all the other settings are applied at the next restart.
+var reloadableFlags = map[string]bool{"v": true, "vmodule": true}
+
+// Default values of the typed settings, the same as the defaults of their flags.
+const (
+	defaultVerbosity    = 0
+	defaultKubeAPIQPS   = 5.0
+	defaultKubeAPIBurst = 10.0
+)
+
+// ConfigurationFlags holds values of flags by flag name. Values are strings, numbers, booleans,
+// or lists for the flags accepting multiple comma separated values.
+type ConfigurationFlags map[string]json.RawMessage
+
+// LoggingConfiguration configures logging. It's the only part of the configuration which is
+// reloaded at run time.
+type LoggingConfiguration struct {
+	// Verbosity is the log level verbosity, like the v flag.
+	Verbosity *int32 `json:"verbosity,omitempty"`
+	// VModule holds per file log verbosities, like the vmodule flag, e.g. "recommender*=4".
+	VModule []string `json:"vmodule,omitempty"`
+}
+
+// ClientConnectionConfiguration configures the connection to the API server.
+type ClientConnectionConfiguration struct {
+	// QPS is the QPS limit of requests to the API server, like the kube-api-qps flag.
+	QPS *float64 `json:"qps,omitempty"`
+	// Burst is the burst limit of requests to the API server, like the kube-api-burst flag.
+	Burst *float64 `json:"burst,omitempty"`
+}
+
+// ComponentConfiguration holds the settings of a component. Typed settings missing from the
+// section of a component are taken from the common section.
+type ComponentConfiguration struct {
+	// Logging configures logging.
+	Logging LoggingConfiguration `json:"logging,omitempty"`
+	// ClientConnection configures the connection to the API server.
+	ClientConnection ClientConnectionConfiguration `json:"clientConnection,omitempty"`
+	// Flags holds values of the other flags of the component by flag name. They're validated
+	// by the flags themselves when the configuration is applied.
+	Flags ConfigurationFlags `json:"flags,omitempty"`
+}
+
+// Configuration is the configuration file shared by the recommender, the updater and the
+// admission controller, as an alternative to their command line flags.
+type Configuration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Common holds the settings applying to all the components.
+	Common ComponentConfiguration `json:"common,omitempty"`
+	// Recommender holds the settings of the recommender.
+	Recommender ComponentConfiguration `json:"recommender,omitempty"`
+	// Updater holds the settings of the updater.
+	Updater ComponentConfiguration `json:"updater,omitempty"`
+	// AdmissionController holds the settings of the admission controller.
+	AdmissionController ComponentConfiguration `json:"admissionController,omitempty"`
+}
+
+// typedFlags are the flags set by the typed settings, which can't be set in Flags.
+var typedFlags = map[string]string{
+	"v":              "logging.verbosity",
+	"vmodule":        "logging.vmodule",
+	"kube-api-qps":   "clientConnection.qps",
+	"kube-api-burst": "clientConnection.burst",
+}
+
+// ParseConfiguration decodes a configuration file, sets the defaults of the settings missing
+// from it and validates it. Unknown fields are rejected.
+func ParseConfiguration(data []byte) (*Configuration, error) {
+	configuration := &Configuration{}
+	if err := yaml.UnmarshalStrict(data, configuration); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %v", err)
+	}
+	if configuration.APIVersion != ConfigurationAPIVersion {
+		return nil, fmt.Errorf("unsupported apiVersion %q, expected %q", configuration.APIVersion, ConfigurationAPIVersion)
+	}
+	if configuration.Kind != ConfigurationKind {
+		return nil, fmt.Errorf("unsupported kind %q, expected %q", configuration.Kind, ConfigurationKind)
+	}
+	SetConfigurationDefaults(configuration)
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+	return configuration, nil
+}
+
+// SetConfigurationDefaults sets the typed settings missing from the common section to their
+// defaults, so that settings removed from the file are reset when it's reloaded.
+func SetConfigurationDefaults(c *Configuration) {
+	if c.Common.Logging.Verbosity == nil {
+		verbosity := int32(defaultVerbosity)
+		c.Common.Logging.Verbosity = &verbosity
+	}
+	if c.Common.Logging.VModule == nil {
+		c.Common.Logging.VModule = []string{}
+	}
+	if c.Common.ClientConnection.QPS == nil {
+		qps := defaultKubeAPIQPS
+		c.Common.ClientConnection.QPS = &qps
+	}
+	if c.Common.ClientConnection.Burst == nil {
+		burst := defaultKubeAPIBurst
+		c.Common.ClientConnection.Burst = &burst
+	}
+}
+
+// Validate returns an error if any of the sections has invalid settings.
+func (c *Configuration) Validate() error {
+	for name, section := range map[string]*ComponentConfiguration{
+		"common":                     &c.Common,
+		RecommenderComponent:         &c.Recommender,
+		UpdaterComponent:             &c.Updater,
+		AdmissionControllerComponent: &c.AdmissionController,
+	} {
+		if err := section.validate(); err != nil {
+			return fmt.Errorf("invalid %s section: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (c *ComponentConfiguration) validate() error {
+	if v := c.Logging.Verbosity; v != nil && *v < 0 {
+		return fmt.Errorf("logging.verbosity must not be negative, got %d", *v)
+	}
+	for _, setting := range c.Logging.VModule {
+		pattern, level, found := strings.Cut(setting, "=")
+		if !found || pattern == "" {
+			return fmt.Errorf("logging.vmodule setting %q is not of the form pattern=N", setting)
+		}
+		if l, err := strconv.ParseInt(level, 10, 32); err != nil || l < 0 {
+			return fmt.Errorf("logging.vmodule setting %q has an invalid level", setting)
+		}
+	}
+	if qps := c.ClientConnection.QPS; qps != nil && *qps <= 0 {
+		return fmt.Errorf("clientConnection.qps must be positive, got %v", *qps)
+	}
+	if burst := c.ClientConnection.Burst; burst != nil && *burst <= 0 {
+		return fmt.Errorf("clientConnection.burst must be positive, got %v", *burst)
+	}
+	for name, raw := range c.Flags {
+		if setting, found := typedFlags[name]; found {
+			return fmt.Errorf("flag %s must be set with %s", name, setting)
+		}
+		if _, err := flagValue(raw); err != nil {
+			return fmt.Errorf("invalid value of flag %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Flags returns the values of the flags of the given component, the settings of its own section
+// taking precedence over the common ones.
+func (c *Configuration) Flags(component string) (map[string]string, error) {
+	var section ComponentConfiguration
+	switch component {
+	case RecommenderComponent:
+		section = c.Recommender
+	case UpdaterComponent:
+		section = c.Updater
+	case AdmissionControllerComponent:
+		section = c.AdmissionController
+	default:
+		return nil, fmt.Errorf("unknown component %q", component)
+	}
+	flags := map[string]string{}
+	for _, values := range []ConfigurationFlags{c.Common.Flags, section.Flags} {
+		for name, raw := range values {
+			value, err := flagValue(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of flag %s: %v", name, err)
+			}
+			flags[name] = value
+		}
+	}
+	for _, s := range []ComponentConfiguration{c.Common, section} {
+		if s.Logging.Verbosity != nil {
+			flags["v"] = strconv.Itoa(int(*s.Logging.Verbosity))
+		}
+		if s.Logging.VModule != nil {
+			flags["vmodule"] = strings.Join(s.Logging.VModule, ",")
+		}
+		if s.ClientConnection.QPS != nil {
+			flags["kube-api-qps"] = strconv.FormatFloat(*s.ClientConnection.QPS, 'g', -1, 64)
+		}
+		if s.ClientConnection.Burst != nil {
+			flags["kube-api-burst"] = strconv.FormatFloat(*s.ClientConnection.Burst, 'g', -1, 64)
+		}
+	}
+	return flags, nil
+}
+
+// flagValue converts a value of the configuration file to its command line representation.
+func flagValue(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			value, err := flagValue(item)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, ","), nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err == nil || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return "", fmt.Errorf("expected a string, a number, a boolean or a list, got %s", raw)
+	}
+	// Numbers and booleans.
+	return string(bytes.TrimSpace(raw)), nil
+}
+
+// ApplyConfiguration sets the flags of the given component from the configuration, except the
+// ones passed on the command line, which take precedence. The values are validated by the flags
+// themselves, and unknown flags are rejected.
+func ApplyConfiguration(configuration *Configuration, component string, flags *pflag.FlagSet) error {
+	values, err := configuration.Flags(component)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedFlagNames(values) {
+		if err := applyFlag(flags, name, values[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyFlag(flags *pflag.FlagSet, name, value string) error {
+	flag := flags.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("unknown flag %s", name)
+	}
+	if flag.Changed {
+		klog.V(4).Infof("Flag %s passed on the command line, ignoring its value in the configuration file", name)
+		return nil
+	}
+	if err := flag.Value.Set(value); err != nil {
+		return fmt.Errorf("invalid value %q of flag %s: %v", value, name, err)
+	}
+	return nil
+}
+
+func sortedFlagNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadConfigurationOrDie applies the configuration file at the given path, if any, to the flags of
+// the given component, parsed from the command line. Only changes of the logging settings are then
+// reloaded from the file, changes of other settings are logged and applied at the next restart.
+func LoadConfigurationOrDie(path string, component string) {
+	if path == "" {
+		return
+	}
+	klog.V(1).Infof("Using configuration file: %s", path)
+	configuration, err := readConfiguration(path)
+	if err != nil {
+		klog.Fatalf("Failed to load configuration file: %v", err)
+	}
+	if err := ApplyConfiguration(configuration, component, pflag.CommandLine); err != nil {
+		klog.Fatalf("Failed to apply configuration file: %v", err)
+	}
+	go wait.Forever(func() {
+		configuration, err = reloadConfiguration(path, component, configuration, pflag.CommandLine)
+		if err != nil {
+			klog.Errorf("Failed to reload configuration file: %v", err)
+		}
+	}, configurationReloadInterval)
+}
+
+func readConfiguration(path string) (*Configuration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfiguration(data)
+}
+
+// reloadConfiguration applies the reloadable flags, i.e. the logging settings, changed in the configuration
+// file since the given configuration, and returns the new configuration. Logging settings removed from the
+// file are reset to their defaults. Other changes are only logged, they are applied at the next restart.
+func reloadConfiguration(path string, component string, previous *Configuration, flags *pflag.FlagSet) (*Configuration, error) {
+	configuration, err := readConfiguration(path)
+	if err != nil {
+		return previous, err
+	}
+	if reflect.DeepEqual(configuration, previous) {
+		return previous, nil
+	}
+	previousValues, err := previous.Flags(component)
+	if err != nil {
+		return previous, err
+	}
+	values, err := configuration.Flags(component)
+	if err != nil {
+		return previous, err
+	}
+	for _, name := range sortedFlagNames(values) {
+		if previousValue, found := previousValues[name]; found && previousValue == values[name] {
+			continue
+		}
+		if !reloadableFlags[name] {
+			klog.Warningf("Flag %s changed in the configuration file, the change will be applied at the next restart", name)
+			continue
+		}
+		if err := applyFlag(flags, name, values[name]); err != nil {
+			return previous, err
+		}
+		klog.Infof("Reloaded flag %s from the configuration file: %s", name, values[name])
+	}
+	for name := range previousValues {
+		if _, found := values[name]; !found {
+			klog.Warningf("Flag %s removed from the configuration file, the change will be applied at the next restart", name)
+		}
+	}
+	return configuration, nil
+}
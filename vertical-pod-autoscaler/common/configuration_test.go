@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfiguration = `
+apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1
+kind: VerticalPodAutoscalerConfiguration
+common:
+  logging:
+    verbosity: 2
+  clientConnection:
+    qps: 20
+recommender:
+  clientConnection:
+    qps: 50
+  flags:
+    memory-aggregation-interval: 12h
+    enable-recommendation-api: true
+    pod-label-prefixes: [app, team]
+updater:
+  flags:
+    eviction-tolerance: 0.25
+`
+
+func newTestFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Float64("kube-api-qps", 5.0, "")
+	flags.Float64("kube-api-burst", 10.0, "")
+	flags.String("v", "0", "")
+	flags.String("vmodule", "", "")
+	flags.Duration("memory-aggregation-interval", 24*time.Hour, "")
+	flags.Bool("enable-recommendation-api", false, "")
+	flags.StringSlice("pod-label-prefixes", nil, "")
+	flags.String("address", ":8942", "")
+	return flags
+}
+
+func TestParseConfiguration(t *testing.T) {
+	configuration, err := ParseConfiguration([]byte(testConfiguration))
+	assert.NoError(t, err)
+	flags, err := configuration.Flags(RecommenderComponent)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"kube-api-qps":                "50",
+		"kube-api-burst":              "10",
+		"v":                           "2",
+		"vmodule":                     "",
+		"memory-aggregation-interval": "12h",
+		"enable-recommendation-api":   "true",
+		"pod-label-prefixes":          "app,team",
+	}, flags)
+
+	// Settings missing from the file get their defaults.
+	flags, err = configuration.Flags(AdmissionControllerComponent)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"kube-api-qps": "20", "kube-api-burst": "10", "v": "2", "vmodule": ""}, flags)
+
+	_, err = configuration.Flags("scheduler")
+	assert.Error(t, err)
+}
+
+func TestParseConfigurationErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown apiVersion": "apiVersion: v1\nkind: VerticalPodAutoscalerConfiguration\n",
+		"unknown kind":       "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: Config\n",
+		"unknown field":      "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: VerticalPodAutoscalerConfiguration\nscheduler: {}\n",
+		"negative verbosity": "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: VerticalPodAutoscalerConfiguration\ncommon:\n  logging:\n    verbosity: -1\n",
+		"invalid vmodule":    "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: VerticalPodAutoscalerConfiguration\ncommon:\n  logging:\n    vmodule: [recommender]\n",
+		"zero qps":           "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: VerticalPodAutoscalerConfiguration\nupdater:\n  clientConnection:\n    qps: 0\n",
+		"typed flag":         "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: VerticalPodAutoscalerConfiguration\nupdater:\n  flags:\n    kube-api-qps: 10\n",
+		"invalid flag value": "apiVersion: config.vpa.autoscaling.k8s.io/v1alpha1\nkind: VerticalPodAutoscalerConfiguration\nupdater:\n  flags:\n    eviction-tolerance: {value: 1}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfiguration([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApplyConfiguration(t *testing.T) {
+	configuration, err := ParseConfiguration([]byte(testConfiguration))
+	assert.NoError(t, err)
+
+	flags := newTestFlagSet()
+	assert.NoError(t, flags.Parse([]string{"--kube-api-qps=10"}))
+	assert.NoError(t, ApplyConfiguration(configuration, RecommenderComponent, flags))
+
+	// The command line takes precedence over the configuration file.
+	qps, _ := flags.GetFloat64("kube-api-qps")
+	assert.Equal(t, 10.0, qps)
+	interval, _ := flags.GetDuration("memory-aggregation-interval")
+	assert.Equal(t, 12*time.Hour, interval)
+	enabled, _ := flags.GetBool("enable-recommendation-api")
+	assert.True(t, enabled)
+	prefixes, _ := flags.GetStringSlice("pod-label-prefixes")
+	assert.Equal(t, []string{"app", "team"}, prefixes)
+	address, _ := flags.GetString("address")
+	assert.Equal(t, ":8942", address)
+
+	// Flags of other components are rejected.
+	err = ApplyConfiguration(configuration, UpdaterComponent, newTestFlagSet())
+	assert.Error(t, err)
+
+	// Values are validated by the flags.
+	configuration.Recommender.Flags["memory-aggregation-interval"] = []byte(`"one day"`)
+	err = ApplyConfiguration(configuration, RecommenderComponent, newTestFlagSet())
+	assert.Error(t, err)
+}
+
+func TestReloadConfiguration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(testConfiguration), 0644))
+	configuration, err := readConfiguration(path)
+	assert.NoError(t, err)
+	flags := newTestFlagSet()
+	assert.NoError(t, ApplyConfiguration(configuration, RecommenderComponent, flags))
+
+	// Unchanged configuration.
+	reloaded, err := reloadConfiguration(path, RecommenderComponent, configuration, flags)
+	assert.NoError(t, err)
+	assert.Same(t, configuration, reloaded)
+
+	updatedConfiguration := strings.Replace(testConfiguration, "  flags:\n    memory-aggregation-interval", "  logging:\n    verbosity: 4\n  flags:\n    address: \":9000\"\n    memory-aggregation-interval", 1)
+	assert.NoError(t, os.WriteFile(path, []byte(updatedConfiguration), 0644))
+	reloaded, err = reloadConfiguration(path, RecommenderComponent, configuration, flags)
+	assert.NoError(t, err)
+	assert.NotEqual(t, configuration, reloaded)
+	// Only the flags which can be updated at run time are applied.
+	verbosity, _ := flags.GetString("v")
+	assert.Equal(t, "4", verbosity)
+	address, _ := flags.GetString("address")
+	assert.Equal(t, ":8942", address)
+
+	// Logging settings removed from the file are reset to their defaults.
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Replace(testConfiguration, "  logging:\n    verbosity: 2\n", "", 1)), 0644))
+	_, err = reloadConfiguration(path, RecommenderComponent, reloaded, flags)
+	assert.NoError(t, err)
+	verbosity, _ = flags.GetString("v")
+	assert.Equal(t, "0", verbosity)
+}
@@ -0,0 +1,404 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package integration contains a harness running VPA scenarios against kind
+// clusters, with the three VPA components built from the local tree and
+// deployed under different combinations of feature gates and flags.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
+)
+
+const (
+	// InPlacePodVerticalScaling is the Kubernetes feature gate allowing to resize pods in place.
+	InPlacePodVerticalScaling = "InPlacePodVerticalScaling"
+
+	vpaNamespace          = "kube-system"
+	webhookService        = "vpa-webhook"
+	tlsCertsSecret        = "vpa-tls-certs"
+	metricsServerManifest = "https://github.com/kubernetes-sigs/metrics-server/releases/latest/download/components.yaml"
+	componentReadyTimeout = 5 * time.Minute
+	pollInterval          = 5 * time.Second
+)
+
+// Component is a VPA component deployed by the harness.
+type Component string
+
+const (
+	// Recommender is the VPA Recommender.
+	Recommender Component = "recommender"
+	// Updater is the VPA Updater.
+	Updater Component = "updater"
+	// AdmissionController is the VPA Admission Controller.
+	AdmissionController Component = "admission-controller"
+)
+
+// Components are all the VPA components, in the order they are deployed.
+var Components = []Component{Recommender, Updater, AdmissionController}
+
+// Environment is a combination of Kubernetes feature gates and VPA component
+// flags under which the scenarios are run.
+type Environment struct {
+	// Name identifies the environment in test names and kind cluster names.
+	Name string
+	// FeatureGates are the Kubernetes feature gates set on the kind cluster.
+	FeatureGates map[string]bool
+	// ComponentArgs are the additional flags passed to the VPA components.
+	ComponentArgs map[Component][]string
+}
+
+// FeatureGateEnabled returns whether the feature gate is explicitly enabled in the environment.
+func (e Environment) FeatureGateEnabled(gate string) bool {
+	return e.FeatureGates[gate]
+}
+
+// DefaultEnvironments returns the feature gate matrix run by default: the
+// default configuration, and in-place resizing of pods enabled both in
+// Kubernetes and in the VPA Updater.
+func DefaultEnvironments() []Environment {
+	return []Environment{
+		{
+			Name: "default",
+		},
+		{
+			Name:         "in-place",
+			FeatureGates: map[string]bool{InPlacePodVerticalScaling: true},
+			ComponentArgs: map[Component][]string{
+				Updater: {"--in-place-memory-limit-raise"},
+			},
+		},
+	}
+}
+
+// Config configures the harness.
+type Config struct {
+	// VpaRoot is the root of the vertical-pod-autoscaler tree the components are built from.
+	VpaRoot string
+	// DeployDir is the directory containing the VPA CRD and RBAC manifests.
+	DeployDir string
+	// Registry and Tag name the images of the components built by the harness.
+	Registry string
+	Tag      string
+	// NodeImage is the kind node image, which determines the Kubernetes version. Empty uses the kind default.
+	NodeImage string
+	// KeepClusters leaves the kind clusters running after the tests, for debugging.
+	KeepClusters bool
+	// SkipBuild reuses previously built images of the components.
+	SkipBuild bool
+}
+
+// ConfigFromEnv returns the harness configuration, read from VPA_INTEGRATION_* environment variables.
+func ConfigFromEnv() Config {
+	root := getEnv("VPA_INTEGRATION_ROOT", "..")
+	return Config{
+		VpaRoot:      root,
+		DeployDir:    getEnv("VPA_INTEGRATION_DEPLOY_DIR", filepath.Join(root, "deploy")),
+		Registry:     getEnv("VPA_INTEGRATION_REGISTRY", "localhost/vpa-integration"),
+		Tag:          getEnv("VPA_INTEGRATION_TAG", "dev"),
+		NodeImage:    os.Getenv("VPA_INTEGRATION_NODE_IMAGE"),
+		KeepClusters: os.Getenv("VPA_INTEGRATION_KEEP_CLUSTERS") == "true",
+		SkipBuild:    os.Getenv("VPA_INTEGRATION_SKIP_BUILD") == "true",
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, found := os.LookupEnv(key); found {
+		return value
+	}
+	return defaultValue
+}
+
+// Image returns the image of the component built by the harness.
+func (c Config) Image(component Component) string {
+	return fmt.Sprintf("%s/vpa-%s:%s", c.Registry, component, c.Tag)
+}
+
+// BuildImages builds the images of all the VPA components from the local tree.
+func BuildImages(ctx context.Context, config Config) error {
+	if config.SkipBuild {
+		return nil
+	}
+	for _, component := range Components {
+		dockerfile := filepath.Join("pkg", string(component), "Dockerfile")
+		if _, err := run(ctx, config.VpaRoot, nil, "docker", "build", "-t", config.Image(component), "-f", dockerfile, "."); err != nil {
+			return fmt.Errorf("failed to build %s image: %v", component, err)
+		}
+	}
+	return nil
+}
+
+// Cluster is a kind cluster running the VPA components in an environment.
+type Cluster struct {
+	// Name is the name of the kind cluster.
+	Name string
+	// Env is the environment the cluster was started in.
+	Env        Environment
+	KubeClient kubernetes.Interface
+	VpaClient  vpa_clientset.Interface
+
+	config     Config
+	dir        string
+	kubeconfig string
+}
+
+// StartCluster creates a kind cluster with the feature gates of the environment,
+// and deploys metrics-server and the VPA components built by BuildImages into it.
+func StartCluster(ctx context.Context, config Config, env Environment) (*Cluster, error) {
+	dir, err := os.MkdirTemp("", "vpa-integration-"+env.Name)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{
+		Name:       "vpa-" + env.Name,
+		Env:        env,
+		config:     config,
+		dir:        dir,
+		kubeconfig: filepath.Join(dir, "kubeconfig"),
+	}
+	if err := c.start(ctx); err != nil {
+		if stopErr := c.Stop(context.Background()); stopErr != nil {
+			return nil, fmt.Errorf("%v; stopping cluster also failed: %v", err, stopErr)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Cluster) start(ctx context.Context) error {
+	kindConfig := filepath.Join(c.dir, "kind.yaml")
+	if err := os.WriteFile(kindConfig, []byte(kindClusterConfig(c.Env.FeatureGates)), 0644); err != nil {
+		return err
+	}
+	args := []string{"create", "cluster", "--name", c.Name, "--config", kindConfig, "--kubeconfig", c.kubeconfig, "--wait", "5m"}
+	if c.config.NodeImage != "" {
+		args = append(args, "--image", c.config.NodeImage)
+	}
+	if _, err := run(ctx, "", nil, "kind", args...); err != nil {
+		return fmt.Errorf("failed to create kind cluster %s: %v", c.Name, err)
+	}
+	for _, component := range Components {
+		if _, err := run(ctx, "", nil, "kind", "load", "docker-image", c.config.Image(component), "--name", c.Name); err != nil {
+			return fmt.Errorf("failed to load %s image: %v", component, err)
+		}
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", c.kubeconfig)
+	if err != nil {
+		return err
+	}
+	if c.KubeClient, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return err
+	}
+	if c.VpaClient, err = vpa_clientset.NewForConfig(restConfig); err != nil {
+		return err
+	}
+
+	if err := c.installMetricsServer(ctx); err != nil {
+		return err
+	}
+	if err := c.Kubectl(ctx, "apply", "-f", filepath.Join(c.config.DeployDir, "vpa-v1-crd-gen.yaml"), "-f", filepath.Join(c.config.DeployDir, "vpa-rbac.yaml")); err != nil {
+		return fmt.Errorf("failed to install VPA CRDs and RBAC: %v", err)
+	}
+	gencerts := filepath.Join(c.config.VpaRoot, "pkg", "admission-controller", "gencerts.sh")
+	if _, err := run(ctx, "", []string{"KUBECONFIG=" + c.kubeconfig}, gencerts); err != nil {
+		return fmt.Errorf("failed to generate admission controller certificates: %v", err)
+	}
+	return c.deployComponents(ctx)
+}
+
+// Stop deletes the kind cluster, unless the harness is configured to keep it.
+func (c *Cluster) Stop(ctx context.Context) error {
+	defer os.RemoveAll(c.dir)
+	if c.config.KeepClusters {
+		return nil
+	}
+	_, err := run(ctx, "", nil, "kind", "delete", "cluster", "--name", c.Name)
+	return err
+}
+
+// Kubectl runs kubectl against the cluster.
+func (c *Cluster) Kubectl(ctx context.Context, args ...string) error {
+	_, err := run(ctx, "", nil, "kubectl", append([]string{"--kubeconfig", c.kubeconfig}, args...)...)
+	return err
+}
+
+// CreateNamespace creates a namespace with a generated name for a scenario.
+func (c *Cluster) CreateNamespace(ctx context.Context, prefix string) (string, error) {
+	ns, err := c.KubeClient.CoreV1().Namespaces().Create(ctx, &apiv1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: prefix + "-"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return ns.Name, nil
+}
+
+// DeleteNamespace deletes the namespace of a scenario.
+func (c *Cluster) DeleteNamespace(ctx context.Context, name string) error {
+	return c.KubeClient.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// WaitFor polls the condition until it is met or the timeout expires.
+func WaitFor(ctx context.Context, timeout time.Duration, condition wait.ConditionWithContextFunc) error {
+	return wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, condition)
+}
+
+func (c *Cluster) installMetricsServer(ctx context.Context) error {
+	if err := c.Kubectl(ctx, "apply", "-f", metricsServerManifest); err != nil {
+		return fmt.Errorf("failed to install metrics-server: %v", err)
+	}
+	// Kubelets of kind nodes serve self-signed certificates.
+	patch := `[{"op": "add", "path": "/spec/template/spec/containers/0/args/-", "value": "--kubelet-insecure-tls"}]`
+	if err := c.Kubectl(ctx, "patch", "deployment", "metrics-server", "-n", vpaNamespace, "--type=json", "-p", patch); err != nil {
+		return fmt.Errorf("failed to configure metrics-server: %v", err)
+	}
+	return c.waitForDeployment(ctx, "metrics-server")
+}
+
+func (c *Cluster) deployComponents(ctx context.Context) error {
+	_, err := c.KubeClient.CoreV1().Services(vpaNamespace).Create(ctx, &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: webhookService, Namespace: vpaNamespace},
+		Spec: apiv1.ServiceSpec{
+			Selector: map[string]string{"app": componentName(AdmissionController)},
+			Ports:    []apiv1.ServicePort{{Port: 443, TargetPort: intstr.FromInt(8000)}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create webhook service: %v", err)
+	}
+	for _, component := range Components {
+		deployment := componentDeployment(c.config.Image(component), component, c.Env.ComponentArgs[component])
+		if _, err := c.KubeClient.AppsV1().Deployments(vpaNamespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to deploy %s: %v", component, err)
+		}
+	}
+	for _, component := range Components {
+		if err := c.waitForDeployment(ctx, componentName(component)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) waitForDeployment(ctx context.Context, name string) error {
+	err := WaitFor(ctx, componentReadyTimeout, func(ctx context.Context) (bool, error) {
+		d, err := c.KubeClient.AppsV1().Deployments(vpaNamespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return d.Status.ObservedGeneration >= d.Generation && d.Status.AvailableReplicas >= 1, nil
+	})
+	if err != nil {
+		return fmt.Errorf("deployment %s/%s isn't available: %v", vpaNamespace, name, err)
+	}
+	return nil
+}
+
+func componentName(component Component) string {
+	return "vpa-" + string(component)
+}
+
+// componentDeployment returns the deployment of a VPA component, mirroring
+// the manifests of the VPA release with the image built by the harness.
+func componentDeployment(image string, component Component, extraArgs []string) *appsv1.Deployment {
+	name := componentName(component)
+	replicas := int32(1)
+	container := apiv1.Container{
+		Name:            string(component),
+		Image:           image,
+		ImagePullPolicy: apiv1.PullNever,
+		Args:            append([]string{"--v=4", "--stderrthreshold=info"}, extraArgs...),
+		Env: []apiv1.EnvVar{{
+			Name:      "NAMESPACE",
+			ValueFrom: &apiv1.EnvVarSource{FieldRef: &apiv1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+		}},
+	}
+	podSpec := apiv1.PodSpec{
+		ServiceAccountName: name,
+		Containers:         []apiv1.Container{container},
+	}
+	if component == AdmissionController {
+		podSpec.Containers[0].Ports = []apiv1.ContainerPort{{ContainerPort: 8000}}
+		podSpec.Containers[0].VolumeMounts = []apiv1.VolumeMount{{Name: "tls-certs", MountPath: "/etc/tls-certs", ReadOnly: true}}
+		podSpec.Volumes = []apiv1.Volume{{
+			Name:         "tls-certs",
+			VolumeSource: apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: tlsCertsSecret}},
+		}}
+	}
+	labels := map[string]string{"app": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: vpaNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// kindClusterConfig returns the kind configuration of a cluster with the given feature gates.
+func kindClusterConfig(featureGates map[string]bool) string {
+	var b strings.Builder
+	b.WriteString("kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\n")
+	if len(featureGates) > 0 {
+		gates := make([]string, 0, len(featureGates))
+		for gate := range featureGates {
+			gates = append(gates, gate)
+		}
+		sort.Strings(gates)
+		b.WriteString("featureGates:\n")
+		for _, gate := range gates {
+			fmt.Fprintf(&b, "  %s: %t\n", gate, featureGates[gate])
+		}
+	}
+	return b.String()
+}
+
+// run runs the command and returns its output, including the output in the error if the command fails.
+func run(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("%s %s: %v\n%s", name, strings.Join(args, " "), err, out.String())
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestKindClusterConfig(t *testing.T) {
+	expected := "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\n"
+	if config := kindClusterConfig(nil); config != expected {
+		t.Errorf("unexpected config without feature gates:\n%s", config)
+	}
+	expected += "featureGates:\n  InPlacePodVerticalScaling: true\n  SidecarContainers: false\n"
+	if config := kindClusterConfig(map[string]bool{"SidecarContainers": false, InPlacePodVerticalScaling: true}); config != expected {
+		t.Errorf("unexpected config with feature gates:\n%s", config)
+	}
+}
+
+func TestComponentDeployment(t *testing.T) {
+	d := componentDeployment("vpa-updater:dev", Updater, []string{"--in-place-memory-limit-raise"})
+	if d.Name != "vpa-updater" || d.Spec.Template.Spec.ServiceAccountName != "vpa-updater" {
+		t.Errorf("unexpected deployment or service account name: %s, %s", d.Name, d.Spec.Template.Spec.ServiceAccountName)
+	}
+	container := d.Spec.Template.Spec.Containers[0]
+	if container.ImagePullPolicy != apiv1.PullNever {
+		t.Errorf("images loaded into kind must not be pulled, got pull policy %s", container.ImagePullPolicy)
+	}
+	if args := container.Args; len(args) != 3 || args[2] != "--in-place-memory-limit-raise" {
+		t.Errorf("unexpected args %v", args)
+	}
+	if len(d.Spec.Template.Spec.Volumes) != 0 {
+		t.Errorf("only the admission controller mounts the certificates")
+	}
+
+	d = componentDeployment("vpa-admission-controller:dev", AdmissionController, nil)
+	if volumes := d.Spec.Template.Spec.Volumes; len(volumes) != 1 || volumes[0].Secret.SecretName != tlsCertsSecret {
+		t.Errorf("unexpected admission controller volumes %v", volumes)
+	}
+}
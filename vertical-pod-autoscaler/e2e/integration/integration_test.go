@@ -0,0 +1,274 @@
+//go:build integration
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+const (
+	hamster         = "hamster"
+	stressImage     = "gcr.io/google-containers/stress:v1"
+	sleepImage      = "registry.k8s.io/ubuntu-slim:0.1"
+	scenarioTimeout = 10 * time.Minute
+)
+
+var config Config
+
+func TestMain(m *testing.M) {
+	config = ConfigFromEnv()
+	if err := BuildImages(context.Background(), config); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// scenario is a test run in a fresh namespace of every environment enabling its feature gates.
+type scenario struct {
+	name         string
+	featureGates []string
+	run          func(ctx context.Context, t *testing.T, c *Cluster, ns string)
+}
+
+var scenarios = []scenario{
+	{name: "OOMBump", run: testOOMBump},
+	{name: "InPlaceMemoryLimitRaise", featureGates: []string{InPlacePodVerticalScaling}, run: testInPlaceMemoryLimitRaise},
+	{name: "AdmissionCaps", run: testAdmissionCaps},
+}
+
+func TestIntegration(t *testing.T) {
+	ctx := context.Background()
+	for _, env := range DefaultEnvironments() {
+		env := env
+		t.Run(env.Name, func(t *testing.T) {
+			c, err := StartCluster(ctx, config, env)
+			if err != nil {
+				t.Fatalf("failed to start cluster: %v", err)
+			}
+			defer func() {
+				if err := c.Stop(ctx); err != nil {
+					t.Errorf("failed to stop cluster: %v", err)
+				}
+			}()
+			for _, s := range scenarios {
+				s := s
+				t.Run(s.name, func(t *testing.T) {
+					for _, gate := range s.featureGates {
+						if !env.FeatureGateEnabled(gate) {
+							t.Skipf("feature gate %s isn't enabled", gate)
+						}
+					}
+					ns, err := c.CreateNamespace(ctx, "vpa-integration")
+					if err != nil {
+						t.Fatalf("failed to create namespace: %v", err)
+					}
+					defer c.DeleteNamespace(ctx, ns)
+					s.run(ctx, t, c, ns)
+				})
+			}
+		})
+	}
+}
+
+// testOOMBump checks that the memory requests of pods OOM killed right after
+// start are raised by the recommender, and applied through eviction by the
+// updater and the admission controller.
+func testOOMBump(ctx context.Context, t *testing.T, c *Cluster, ns string) {
+	memory := resource.MustParse("64Mi")
+	container := apiv1.Container{
+		Name:  hamster,
+		Image: stressImage,
+		// Allocates 80 MiB in a single chunk, above the limit.
+		Command: []string{"/stress", "--mem-total", "83886080", "--logtostderr", "--mem-alloc-size", "83886080"},
+		Resources: apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{apiv1.ResourceMemory: memory},
+			Limits:   apiv1.ResourceList{apiv1.ResourceMemory: memory},
+		},
+	}
+	createDeployment(ctx, t, c, ns, 2, container)
+	createVpa(ctx, t, c, test.VerticalPodAutoscaler().
+		WithNamespace(ns).
+		WithContainer(hamster).
+		WithUpdateMode(vpa_types.UpdateModeAuto))
+
+	waitForPods(ctx, t, c, ns, "memory requests above the OOM limit", func(pod apiv1.Pod) bool {
+		request := pod.Spec.Containers[0].Resources.Requests[apiv1.ResourceMemory]
+		return request.Cmp(memory) > 0
+	})
+}
+
+// testInPlaceMemoryLimitRaise checks that the updater raises in place the
+// memory limits of containers with controlled values MemoryLimitsOnly,
+// without recreating the pods.
+func testInPlaceMemoryLimitRaise(ctx context.Context, t *testing.T, c *Cluster, ns string) {
+	limit := resource.MustParse("48Mi")
+	container := apiv1.Container{
+		Name:  hamster,
+		Image: stressImage,
+		// Holds 40 MiB, under the limit but above it once the recommended margin is added.
+		Command: []string{"/stress", "--mem-total", "41943040", "--logtostderr", "--mem-alloc-size", "4194304"},
+		Resources: apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("40Mi")},
+			Limits:   apiv1.ResourceList{apiv1.ResourceMemory: limit},
+		},
+	}
+	createDeployment(ctx, t, c, ns, 2, container)
+	pods, err := c.KubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: "app=" + hamster})
+	if err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	uids := map[types.UID]bool{}
+	for _, pod := range pods.Items {
+		uids[pod.UID] = true
+	}
+	createVpa(ctx, t, c, test.VerticalPodAutoscaler().
+		WithNamespace(ns).
+		WithContainer(hamster).
+		WithUpdateMode(vpa_types.UpdateModeAuto).
+		WithControlledValues(hamster, vpa_types.ContainerControlledValuesMemoryLimitsOnly))
+
+	waitForPods(ctx, t, c, ns, "memory limits raised in place", func(pod apiv1.Pod) bool {
+		containerLimit := pod.Spec.Containers[0].Resources.Limits[apiv1.ResourceMemory]
+		return uids[pod.UID] && containerLimit.Cmp(limit) > 0
+	})
+}
+
+// testAdmissionCaps checks that the admission controller caps the requests of
+// new pods to the maximum allowed by the VPA resource policy.
+func testAdmissionCaps(ctx context.Context, t *testing.T, c *Cluster, ns string) {
+	maxCPU := resource.MustParse("100m")
+	maxMemory := resource.MustParse("100Mi")
+	vpa := createVpa(ctx, t, c, test.VerticalPodAutoscaler().
+		WithNamespace(ns).
+		WithContainer(hamster).
+		WithUpdateMode(vpa_types.UpdateModeInitial).
+		WithMaxAllowed(hamster, maxCPU.String(), maxMemory.String()))
+	// Recommend more than the maximum allowed. Recommendations of the recommender
+	// also exceed the maximum allowed memory, as they're above the 250Mi minimum.
+	vpa.Status.Recommendation = &vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			test.Recommendation().WithContainer(hamster).WithTarget("250m", "200Mi").GetContainerResources(),
+		},
+	}
+	if _, err := c.VpaClient.AutoscalingV1().VerticalPodAutoscalers(ns).UpdateStatus(ctx, vpa, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to set VPA recommendation: %v", err)
+	}
+
+	container := apiv1.Container{
+		Name:    hamster,
+		Image:   sleepImage,
+		Command: []string{"/bin/sh", "-c", "while true; do sleep 10; done"},
+		Resources: apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{
+				apiv1.ResourceCPU:    resource.MustParse("50m"),
+				apiv1.ResourceMemory: resource.MustParse("50Mi"),
+			},
+		},
+	}
+	createDeployment(ctx, t, c, ns, 2, container)
+
+	waitForPods(ctx, t, c, ns, "requests capped to the maximum allowed", func(pod apiv1.Pod) bool {
+		requests := pod.Spec.Containers[0].Resources.Requests
+		cpu, memory := requests[apiv1.ResourceCPU], requests[apiv1.ResourceMemory]
+		return memory.Cmp(maxMemory) == 0 && cpu.Cmp(maxCPU) <= 0
+	})
+}
+
+func createDeployment(ctx context.Context, t *testing.T, c *Cluster, ns string, replicas int32, container apiv1.Container) {
+	t.Helper()
+	labels := map[string]string{"app": hamster}
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: hamster, Namespace: ns},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       apiv1.PodSpec{Containers: []apiv1.Container{container}},
+			},
+		},
+	}
+	if _, err := c.KubeClient.AppsV1().Deployments(ns).Create(ctx, d, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	err := WaitFor(ctx, scenarioTimeout, func(ctx context.Context) (bool, error) {
+		pods, err := c.KubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: "app=" + hamster})
+		if err != nil {
+			return false, err
+		}
+		return len(pods.Items) == int(replicas), nil
+	})
+	if err != nil {
+		t.Fatalf("pods of deployment weren't created: %v", err)
+	}
+}
+
+func createVpa(ctx context.Context, t *testing.T, c *Cluster, builder test.VerticalPodAutoscalerBuilder) *vpa_types.VerticalPodAutoscaler {
+	t.Helper()
+	vpa := builder.
+		WithName(hamster + "-vpa").
+		WithTargetRef(&autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: hamster}).
+		Get()
+	vpa, err := c.VpaClient.AutoscalingV1().VerticalPodAutoscalers(vpa.Namespace).Create(ctx, vpa, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to create VPA: %v", err)
+	}
+	return vpa
+}
+
+// waitForPods waits until all the running pods of the deployment match the condition.
+func waitForPods(ctx context.Context, t *testing.T, c *Cluster, ns, description string, matches func(pod apiv1.Pod) bool) {
+	t.Helper()
+	err := WaitFor(ctx, scenarioTimeout, func(ctx context.Context) (bool, error) {
+		pods, err := c.KubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: "app=" + hamster,
+			FieldSelector: "status.phase!=" + string(apiv1.PodSucceeded) + ",status.phase!=" + string(apiv1.PodFailed),
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(pods.Items) == 0 {
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp != nil || !matches(pod) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("pods didn't get %s: %v", description, err)
+	}
+}
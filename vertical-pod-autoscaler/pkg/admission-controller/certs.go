@@ -17,11 +17,24 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
 	"os"
+	"sync"
 
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
+// caCertKey is the key of the CA bundle in kubernetes.io/tls Secrets issued by cert-manager.
+const caCertKey = "ca.crt"
+
 type certsContainer struct {
 	caCert, serverKey, serverCert []byte
 }
@@ -48,3 +61,118 @@ func initCerts(config certsConfig) certsContainer {
 	res.serverKey = readFile(*config.tlsPrivateKey)
 	return res
 }
+
+// certsProvider provides the webhook certificate and the CA bundle the webhook is registered with.
+type certsProvider interface {
+	getCertificate() *tls.Certificate
+	getCaCert() []byte
+}
+
+// staticCertsProvider serves the webhook certificate read from files on start up.
+type staticCertsProvider struct {
+	cert   *tls.Certificate
+	caCert []byte
+}
+
+func newStaticCertsProvider(certs certsContainer) *staticCertsProvider {
+	cert, err := tls.X509KeyPair(certs.serverCert, certs.serverKey)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	return &staticCertsProvider{cert: &cert, caCert: certs.caCert}
+}
+
+func (p *staticCertsProvider) getCertificate() *tls.Certificate {
+	return p.cert
+}
+
+func (p *staticCertsProvider) getCaCert() []byte {
+	return p.caCert
+}
+
+// secretCertsProvider serves the webhook certificate from a kubernetes.io/tls Secret, like the
+// ones issued by cert-manager, and reloads it whenever the Secret changes.
+type secretCertsProvider struct {
+	mutex  sync.RWMutex
+	cert   *tls.Certificate
+	caCert []byte
+	// onCaCertChange is called with the new CA bundle when it changes after the initial load.
+	onCaCertChange func(caCert []byte)
+}
+
+// watchCertsSecret loads the webhook certificate from the Secret and keeps it up to date
+// until stopCh is closed.
+func watchCertsSecret(kubeClient kubernetes.Interface, namespace, name string, stopCh <-chan struct{}) (*secretCertsProvider, error) {
+	p := &secretCertsProvider{}
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0, informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := factory.Core().V1().Secrets().Informer()
+	onUpdate := func(obj interface{}) {
+		secret, ok := obj.(*apiv1.Secret)
+		if !ok || secret.Name != name {
+			return
+		}
+		if err := p.update(secret); err != nil {
+			klog.Errorf("Failed to reload webhook certificate from secret %s/%s, keeping the previous one: %v", namespace, name, err)
+		}
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onUpdate,
+		UpdateFunc: func(_, obj interface{}) { onUpdate(obj) },
+	}); err != nil {
+		return nil, err
+	}
+	factory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		return nil, fmt.Errorf("failed to sync secret %s/%s", namespace, name)
+	}
+	if p.getCertificate() == nil {
+		return nil, fmt.Errorf("no valid webhook certificate found in secret %s/%s", namespace, name)
+	}
+	return p, nil
+}
+
+func (p *secretCertsProvider) update(secret *apiv1.Secret) error {
+	cert, err := tls.X509KeyPair(secret.Data[apiv1.TLSCertKey], secret.Data[apiv1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+	caCert := secret.Data[caCertKey]
+	if len(caCert) == 0 {
+		klog.Warningf("Secret %s/%s has no %s, the webhook is registered without a CA bundle", secret.Namespace, secret.Name, caCertKey)
+	}
+
+	p.mutex.Lock()
+	loaded := p.cert != nil
+	caCertChanged := !bytes.Equal(p.caCert, caCert)
+	p.cert = &cert
+	p.caCert = caCert
+	onCaCertChange := p.onCaCertChange
+	p.mutex.Unlock()
+
+	klog.V(3).Infof("Loaded webhook certificate from secret %s/%s", secret.Namespace, secret.Name)
+	if loaded && caCertChanged && onCaCertChange != nil {
+		onCaCertChange(caCert)
+	}
+	return nil
+}
+
+func (p *secretCertsProvider) getCertificate() *tls.Certificate {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.cert
+}
+
+func (p *secretCertsProvider) getCaCert() []byte {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.caCert
+}
+
+func (p *secretCertsProvider) setOnCaCertChange(onCaCertChange func(caCert []byte)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.onCaCertChange = onCaCertChange
+}
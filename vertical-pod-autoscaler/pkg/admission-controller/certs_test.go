@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// generateCert returns a self-signed certificate and its key in PEM format.
+func generateCert(t *testing.T, commonName string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func tlsSecret(t *testing.T, commonName string, caCert []byte) *apiv1.Secret {
+	cert, key := generateCert(t, commonName)
+	return &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpa-tls", Namespace: "kube-system"},
+		Type:       apiv1.SecretTypeTLS,
+		Data:       map[string][]byte{apiv1.TLSCertKey: cert, apiv1.TLSPrivateKeyKey: key, caCertKey: caCert},
+	}
+}
+
+func commonName(t *testing.T, p certsProvider) string {
+	cert := p.getCertificate()
+	if !assert.NotNil(t, cert) {
+		return ""
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return parsed.Subject.CommonName
+}
+
+func TestWatchCertsSecret(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	testClientSet := fake.NewSimpleClientset(tlsSecret(t, "first", []byte("ca1")))
+
+	p, err := watchCertsSecret(testClientSet, "kube-system", "vpa-tls", stopCh)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, p))
+	assert.Equal(t, []byte("ca1"), p.getCaCert())
+
+	caCerts := make(chan []byte, 1)
+	p.setOnCaCertChange(func(caCert []byte) { caCerts <- caCert })
+
+	_, err = testClientSet.CoreV1().Secrets("kube-system").Update(context.TODO(), tlsSecret(t, "second", []byte("ca2")), metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	select {
+	case caCert := <-caCerts:
+		assert.Equal(t, []byte("ca2"), caCert)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the CA bundle to change")
+	}
+	assert.Equal(t, "second", commonName(t, p))
+
+	// An invalid certificate is ignored.
+	invalid := tlsSecret(t, "third", []byte("ca3"))
+	invalid.Data[apiv1.TLSPrivateKeyKey] = []byte("invalid")
+	assert.Error(t, p.update(invalid))
+	assert.Equal(t, "second", commonName(t, p))
+	assert.Equal(t, []byte("ca2"), p.getCaCert())
+}
+
+func TestWatchCertsSecretInvalid(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	invalid := tlsSecret(t, "first", nil)
+	delete(invalid.Data, apiv1.TLSCertKey)
+	testClientSet := fake.NewSimpleClientset(invalid)
+
+	_, err := watchCertsSecret(testClientSet, "kube-system", "vpa-tls", stopCh)
+	assert.Error(t, err)
+}
+
+func TestSecretCertsProviderCaCertUnchanged(t *testing.T) {
+	p := &secretCertsProvider{}
+	changes := 0
+	p.setOnCaCertChange(func([]byte) { changes++ })
+
+	assert.NoError(t, p.update(tlsSecret(t, "first", []byte("ca"))))
+	assert.NoError(t, p.update(tlsSecret(t, "second", []byte("ca"))))
+	assert.Equal(t, 0, changes, "expected no CA bundle change")
+	assert.Equal(t, "second", commonName(t, p))
+}
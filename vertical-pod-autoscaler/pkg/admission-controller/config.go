@@ -33,14 +33,10 @@ const (
 	webhookConfigName = "vpa-webhook-config"
 )
 
-func configTLS(serverCert, serverKey []byte, minTlsVersion, ciphers string) *tls.Config {
+func configTLS(certs certsProvider, minTlsVersion, ciphers string) *tls.Config {
 	var tlsVersion uint16
 	var ciphersuites []uint16
 	reverseCipherMap := make(map[string]uint16)
-	sCert, err := tls.X509KeyPair(serverCert, serverKey)
-	if err != nil {
-		klog.Fatal(err)
-	}
 
 	for _, c := range tls.CipherSuites() {
 		reverseCipherMap[c.Name] = c.ID
@@ -67,8 +63,11 @@ func configTLS(serverCert, serverKey []byte, minTlsVersion, ciphers string) *tls
 	}
 
 	return &tls.Config{
-		MinVersion:   tlsVersion,
-		Certificates: []tls.Certificate{sCert},
+		MinVersion: tlsVersion,
+		// The certificate is read on every handshake, so that reloaded certificates are served.
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certs.getCertificate(), nil
+		},
 		CipherSuites: ciphersuites,
 	}
 }
@@ -135,3 +134,20 @@ func selfRegistration(clientset kubernetes.Interface, caCert []byte, namespace,
 		klog.V(3).Info("Self registration as MutatingWebhook succeeded.")
 	}
 }
+
+// updateCaBundle sets the CA bundle of the registered webhook, when the CA of the webhook certificate changes.
+func updateCaBundle(clientset kubernetes.Interface, caCert []byte) error {
+	client := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	webhookConfig, err := client.Get(context.TODO(), webhookConfigName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range webhookConfig.Webhooks {
+		webhookConfig.Webhooks[i].ClientConfig.CABundle = caCert
+	}
+	if _, err := client.Update(context.TODO(), webhookConfig, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.V(3).Info("Updated the CA bundle of the MutatingWebhook.")
+	return nil
+}
@@ -117,3 +117,23 @@ func TestSelfRegistrationWithOutURL(t *testing.T) {
 
 	assert.Nil(t, webhook.ClientConfig.URL, "expected URL to be set")
 }
+
+func TestUpdateCaBundle(t *testing.T) {
+	webhookConfig := &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: webhookConfigName},
+		Webhooks: []admissionregistration.MutatingWebhook{
+			{Name: "vpa.k8s.io", ClientConfig: admissionregistration.WebhookClientConfig{CABundle: []byte("old")}},
+		},
+	}
+	testClientSet := fake.NewSimpleClientset(webhookConfig)
+
+	err := updateCaBundle(testClientSet, []byte("new"))
+	assert.NoError(t, err)
+
+	webhookConfig, err = testClientSet.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(), webhookConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new"), webhookConfig.Webhooks[0].ClientConfig.CABundle, "expected CA bundle to be updated")
+
+	err = updateCaBundle(fake.NewSimpleClientset(), []byte("new"))
+	assert.Error(t, err, "expected an error when the webhook isn't registered")
+}
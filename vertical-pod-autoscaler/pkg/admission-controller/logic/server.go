@@ -44,9 +44,11 @@ func NewAdmissionServer(podPreProcessor pod.PreProcessor,
 	vpaPreProcessor vpa.PreProcessor,
 	limitsChecker limitrange.LimitRangeCalculator,
 	vpaMatcher vpa.Matcher,
-	patchCalculators []patch.Calculator) *AdmissionServer {
+	patchCalculators []patch.Calculator,
+	latencyBudget pod.LatencyBudget,
+	observeOnly pod.ObserveOnly) *AdmissionServer {
 	as := &AdmissionServer{limitsChecker, map[metav1.GroupResource]resource.Handler{}}
-	as.RegisterResourceHandler(pod.NewResourceHandler(podPreProcessor, vpaMatcher, patchCalculators))
+	as.RegisterResourceHandler(pod.NewObservingResourceHandler(podPreProcessor, vpaMatcher, patchCalculators, latencyBudget, observeOnly))
 	as.RegisterResourceHandler(vpa.NewResourceHandler(vpaPreProcessor))
 	return as
 }
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -62,8 +64,12 @@ var (
 	ciphers       = flag.String("tls-ciphers", "", "A comma-separated or colon-separated list of ciphers to accept.  Only works when min-tls-version is set to tls1_2.")
 	minTlsVersion = flag.String("min-tls-version", "tls1_2", "The minimum TLS version to accept.  Must be set to either tls1_2 (default) or tls1_3.")
 
+	certSecretName      = flag.String("cert-secret-name", "", "Name of a kubernetes.io/tls Secret, e.g. issued by cert-manager, from which the server certificate and the CA bundle (ca.crt) are read and reloaded when they change. If set, the certificate files flags are ignored.")
+	certSecretNamespace = flag.String("cert-secret-namespace", "", "Namespace of the Secret set by --cert-secret-name. Empty means the namespace of the admission controller.")
+
 	port               = flag.Int("port", 8000, "The port to listen on.")
 	address            = flag.String("address", ":8944", "The address to expose Prometheus metrics.")
+	configFile         = flag.String("config", "", "Path to an optional configuration file, whose values are overridden by the command line flags.")
 	kubeconfig         = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	kubeApiQps         = flag.Float64("kube-api-qps", 5.0, `QPS limit when making requests to Kubernetes apiserver`)
 	kubeApiBurst       = flag.Float64("kube-api-burst", 10.0, `QPS burst limit when making requests to Kubernetes apiserver`)
@@ -75,18 +81,26 @@ var (
 	registerWebhook    = flag.Bool("register-webhook", true, "If set to true, admission webhook object will be created on start up to register with the API server.")
 	registerByURL      = flag.Bool("register-by-url", false, "If set to true, admission webhook will be registered by URL (webhookAddress:webhookPort) instead of by service name")
 	vpaObjectNamespace = flag.String("vpa-object-namespace", apiv1.NamespaceAll, "Namespace to search for VPA objects. Empty means all namespaces will be used.")
+
+	admissionDeadline              = flag.Duration("admission-deadline", 0, "Time after which a Pod is admitted without applying a recommendation. Zero means no deadline.")
+	circuitBreakerFailureThreshold = flag.Int("circuit-breaker-failure-threshold", 0, "Number of consecutive admission failures after which Pods are admitted without applying recommendations for circuit-breaker-cool-down. Zero disables the circuit breaker.")
+	circuitBreakerCoolDown         = flag.Duration("circuit-breaker-cool-down", 30*time.Second, "Time for which Pods are admitted without applying recommendations once the circuit breaker opens.")
+	recommendationCacheTTL         = flag.Duration("recommendation-cache-ttl", 0, "Time for which the VPA matching Pods of a controller is cached by controller UID, so that Pods created in bulk (e.g. by Jobs) skip VPA matching. Zero disables the cache.")
+	recommendationCacheMaxStale    = flag.Duration("recommendation-cache-max-stale", 0, "Time after recommendation-cache-ttl for which a cached VPA match is still served while it's refreshed in the background.")
+	observeOnly                    = flag.Bool("observe-only", false, "If true, Pods are admitted in dry-run mode: the patches are recorded in the vpa.k8s.io/dry-run-patch annotation instead of being applied.")
+	observeOnlyNamespaces          = flag.String("observe-only-namespaces", "", "Comma separated list of namespaces whose Pods are admitted in dry-run mode, as with --observe-only.")
 )
 
 func main() {
 	klog.InitFlags(nil)
 	kube_flag.InitFlags()
+	common.LoadConfigurationOrDie(*configFile, common.AdmissionControllerComponent)
 	klog.V(1).Infof("Vertical Pod Autoscaler %s Admission Controller", common.VerticalPodAutoscalerVersion)
 
 	healthCheck := metrics.NewHealthCheck(time.Minute, false)
 	metrics.Initialize(*address, healthCheck)
 	metrics_admission.Register()
 
-	certs := initCerts(*certsConfiguration)
 	config := common.CreateKubeConfigOrDie(*kubeconfig, float32(*kubeApiQps), int(*kubeApiBurst))
 
 	vpaClient := vpa_clientset.NewForConfigOrDie(config)
@@ -105,6 +119,9 @@ func main() {
 	}
 	recommendationProvider := recommendation.NewProvider(limitRangeCalculator, vpa_api_util.NewCappingRecommendationProcessor(limitRangeCalculator))
 	vpaMatcher := vpa.NewMatcher(vpaLister, targetSelectorFetcher, controllerFetcher)
+	if *recommendationCacheTTL > 0 {
+		vpaMatcher = vpa.NewCachingMatcher(vpaMatcher, vpaLister, *recommendationCacheTTL, *recommendationCacheMaxStale)
+	}
 
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -125,20 +142,66 @@ func main() {
 	)
 	defer close(stopCh)
 
+	var certs certsProvider
+	if *certSecretName != "" {
+		secretNamespace := *certSecretNamespace
+		if secretNamespace == "" {
+			secretNamespace = namespace
+		}
+		secretCerts, err := watchCertsSecret(kubeClient, secretNamespace, *certSecretName, stopCh)
+		if err != nil {
+			klog.Fatalf("Failed to load the webhook certificate: %v", err)
+		}
+		if *registerWebhook {
+			secretCerts.setOnCaCertChange(func(caCert []byte) {
+				if err := updateCaBundle(kubeClient, caCert); err != nil {
+					klog.Errorf("Failed to update the CA bundle of the webhook: %v", err)
+				}
+			})
+		}
+		certs = secretCerts
+	} else {
+		certs = newStaticCertsProvider(initCerts(*certsConfiguration))
+	}
+
+	// Informers are started while the components using them are created, wait for them
+	// once, instead of checking them for every admitted Pod.
+	var cachesSynced atomic.Bool
+	go func() {
+		for informerType, synced := range factory.WaitForCacheSync(stopCh) {
+			if !synced {
+				klog.Warningf("Failed to sync cache of %v", informerType)
+				return
+			}
+		}
+		klog.V(2).Infof("Caches synced")
+		cachesSynced.Store(true)
+	}()
+
 	calculators := []patch.Calculator{patch.NewResourceUpdatesCalculator(recommendationProvider), patch.NewObservedContainersCalculator()}
-	as := logic.NewAdmissionServer(podPreprocessor, vpaPreprocessor, limitRangeCalculator, vpaMatcher, calculators)
+	latencyBudget := pod.LatencyBudget{
+		Deadline:         *admissionDeadline,
+		CacheSynced:      cachesSynced.Load,
+		FailureThreshold: *circuitBreakerFailureThreshold,
+		CoolDown:         *circuitBreakerCoolDown,
+	}
+	observeOnlyConfig := pod.ObserveOnly{All: *observeOnly}
+	if *observeOnlyNamespaces != "" {
+		observeOnlyConfig.Namespaces = strings.Split(*observeOnlyNamespaces, ",")
+	}
+	as := logic.NewAdmissionServer(podPreprocessor, vpaPreprocessor, limitRangeCalculator, vpaMatcher, calculators, latencyBudget, observeOnlyConfig)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		as.Serve(w, r)
 		healthCheck.UpdateLastActivity()
 	})
 	server := &http.Server{
 		Addr:      fmt.Sprintf(":%d", *port),
-		TLSConfig: configTLS(certs.serverCert, certs.serverKey, *minTlsVersion, *ciphers),
+		TLSConfig: configTLS(certs, *minTlsVersion, *ciphers),
 	}
 	url := fmt.Sprintf("%v:%v", *webhookAddress, *webhookPort)
 	go func() {
 		if *registerWebhook {
-			selfRegistration(kubeClient, certs.caCert, namespace, *serviceName, url, *registerByURL, int32(*webhookTimeout))
+			selfRegistration(kubeClient, certs.getCaCert(), namespace, *serviceName, url, *registerByURL, int32(*webhookTimeout))
 		}
 		// Start status updates after the webhook is initialized.
 		statusUpdater.Run(stopCh)
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"encoding/json"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/admission"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DryRunAnnotation is the Pod annotation which makes the admission controller
+	// preview its patch instead of applying it.
+	DryRunAnnotation = annotations.VpaDryRunAnnotation
+	// DryRunPatchAnnotation is the Pod annotation containing the JSON patch the
+	// admission controller would apply to a Pod in dry-run mode.
+	DryRunPatchAnnotation = annotations.VpaDryRunPatchAnnotation
+)
+
+// ObserveOnly selects the Pods which are admitted in dry-run mode regardless of
+// the DryRunAnnotation, so that the impact of VPA can be measured before Pods
+// are actually mutated.
+type ObserveOnly struct {
+	// All admits all Pods in dry-run mode.
+	All bool
+	// Namespaces admits the Pods of these namespaces in dry-run mode.
+	Namespaces []string
+}
+
+func (o ObserveOnly) selects(pod *v1.Pod) bool {
+	if o.All {
+		return true
+	}
+	for _, namespace := range o.Namespaces {
+		if pod.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *resourceHandler) isDryRun(pod *v1.Pod) bool {
+	return pod.Annotations[DryRunAnnotation] == "true" || h.observeOnly.selects(pod)
+}
+
+// getDryRunPatches returns a patch recording the given patches in the
+// DryRunPatchAnnotation of the Pod, leaving its resources unchanged.
+func getDryRunPatches(pod *v1.Pod, patches []resource_admission.PatchRecord) ([]resource_admission.PatchRecord, error) {
+	preview, err := json.Marshal(patches)
+	if err != nil {
+		return nil, err
+	}
+	klog.V(2).Infof("Dry-run for pod %s/%s, not applying patch: %s", pod.Namespace, pod.Name, preview)
+	admission.OnDryRunPod(changesResources(patches))
+	dryRunPatches := []resource_admission.PatchRecord{}
+	if pod.Annotations == nil {
+		dryRunPatches = append(dryRunPatches, patch.GetAddEmptyAnnotationsPatch())
+	}
+	return append(dryRunPatches, patch.GetAddAnnotationPatch(DryRunPatchAnnotation, string(preview))), nil
+}
+
+// changesResources returns true if any of the patches sets container resources.
+func changesResources(patches []resource_admission.PatchRecord) bool {
+	for _, p := range patches {
+		if strings.HasPrefix(p.Path, "/spec/containers/") && strings.Contains(p.Path, "/resources") {
+			return true
+		}
+	}
+	return false
+}
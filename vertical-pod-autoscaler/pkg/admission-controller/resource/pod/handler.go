@@ -19,6 +19,7 @@ package pod
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	v1 "k8s.io/api/core/v1"
@@ -26,6 +27,7 @@ import (
 	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/vpa"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/admission"
 	"k8s.io/klog/v2"
 )
@@ -35,14 +37,32 @@ type resourceHandler struct {
 	preProcessor     PreProcessor
 	vpaMatcher       vpa.Matcher
 	patchCalculators []patch.Calculator
+	latencyBudget    LatencyBudget
+	circuitBreaker   *circuitBreaker
+	observeOnly      ObserveOnly
 }
 
 // NewResourceHandler creates new instance of resourceHandler.
 func NewResourceHandler(preProcessor PreProcessor, vpaMatcher vpa.Matcher, patchCalculators []patch.Calculator) resource_admission.Handler {
+	return NewResourceHandlerWithLatencyBudget(preProcessor, vpaMatcher, patchCalculators, LatencyBudget{})
+}
+
+// NewResourceHandlerWithLatencyBudget creates new instance of resourceHandler which admits Pods
+// without changes whenever the given latency budget can't be met.
+func NewResourceHandlerWithLatencyBudget(preProcessor PreProcessor, vpaMatcher vpa.Matcher, patchCalculators []patch.Calculator, latencyBudget LatencyBudget) resource_admission.Handler {
+	return NewObservingResourceHandler(preProcessor, vpaMatcher, patchCalculators, latencyBudget, ObserveOnly{})
+}
+
+// NewObservingResourceHandler creates new instance of resourceHandler which admits the Pods
+// selected by observeOnly in dry-run mode, recording patches instead of applying them.
+func NewObservingResourceHandler(preProcessor PreProcessor, vpaMatcher vpa.Matcher, patchCalculators []patch.Calculator, latencyBudget LatencyBudget, observeOnly ObserveOnly) resource_admission.Handler {
 	return &resourceHandler{
 		preProcessor:     preProcessor,
 		vpaMatcher:       vpaMatcher,
 		patchCalculators: patchCalculators,
+		latencyBudget:    latencyBudget,
+		circuitBreaker:   newCircuitBreaker(latencyBudget.FailureThreshold, latencyBudget.CoolDown),
+		observeOnly:      observeOnly,
 	}
 }
 
@@ -64,9 +84,14 @@ func (h *resourceHandler) DisallowIncorrectObjects() bool {
 
 // GetPatches builds patches for Pod in given admission request.
 func (h *resourceHandler) GetPatches(ar *admissionv1.AdmissionRequest) ([]resource_admission.PatchRecord, error) {
+	start := time.Now()
 	if ar.Resource.Version != "v1" {
 		return nil, fmt.Errorf("only v1 Pods are supported")
 	}
+	if !h.circuitBreaker.allow() {
+		admission.OnShortCircuit(admission.CircuitOpen)
+		return []resource_admission.PatchRecord{}, nil
+	}
 	raw, namespace := ar.Object.Raw, ar.Namespace
 	pod := v1.Pod{}
 	if err := json.Unmarshal(raw, &pod); err != nil {
@@ -77,13 +102,33 @@ func (h *resourceHandler) GetPatches(ar *admissionv1.AdmissionRequest) ([]resour
 		pod.Namespace = namespace
 	}
 	klog.V(4).Infof("Admitting pod %v", pod.ObjectMeta)
+	if annotations.IsVpaDisabled(&pod) {
+		klog.V(4).Infof("VPA is disabled for pod %s/%s, admitting it without changes", pod.Namespace, pod.Name)
+		return []resource_admission.PatchRecord{}, nil
+	}
+	if h.latencyBudget.CacheSynced != nil && !h.latencyBudget.CacheSynced() {
+		klog.V(2).Infof("Caches not synced yet, admitting pod %s/%s without changes", pod.Namespace, pod.Name)
+		admission.OnShortCircuit(admission.CacheNotSynced)
+		return []resource_admission.PatchRecord{}, nil
+	}
+	if h.deadlineExceeded(start, &pod) {
+		return []resource_admission.PatchRecord{}, nil
+	}
+	phaseStart := time.Now()
 	controllingVpa := h.vpaMatcher.GetMatchingVPA(&pod)
+	admission.ObservePhaseLatency("match_vpa", time.Since(phaseStart))
 	if controllingVpa == nil {
 		klog.V(4).Infof("No matching VPA found for pod %s/%s", pod.Namespace, pod.Name)
 		return []resource_admission.PatchRecord{}, nil
 	}
+	if h.deadlineExceeded(start, &pod) {
+		return []resource_admission.PatchRecord{}, nil
+	}
+	phaseStart = time.Now()
 	pod, err := h.preProcessor.Process(pod)
+	admission.ObservePhaseLatency("pre_process", time.Since(phaseStart))
 	if err != nil {
+		h.circuitBreaker.recordFailure()
 		return nil, err
 	}
 
@@ -91,13 +136,35 @@ func (h *resourceHandler) GetPatches(ar *admissionv1.AdmissionRequest) ([]resour
 	if pod.Annotations == nil {
 		patches = append(patches, patch.GetAddEmptyAnnotationsPatch())
 	}
+	phaseStart = time.Now()
 	for _, c := range h.patchCalculators {
+		if h.deadlineExceeded(start, &pod) {
+			return []resource_admission.PatchRecord{}, nil
+		}
 		partialPatches, err := c.CalculatePatches(&pod, controllingVpa)
 		if err != nil {
+			h.circuitBreaker.recordFailure()
 			return []resource_admission.PatchRecord{}, err
 		}
 		patches = append(patches, partialPatches...)
 	}
+	admission.ObservePhaseLatency("calculate_patches", time.Since(phaseStart))
+	h.circuitBreaker.recordSuccess()
+
+	if h.isDryRun(&pod) {
+		return getDryRunPatches(&pod, patches)
+	}
 
 	return patches, nil
 }
+
+// deadlineExceeded returns true if the latency budget of admitting the pod, started
+// at start, is used up. The pod is then admitted without changes.
+func (h *resourceHandler) deadlineExceeded(start time.Time, pod *v1.Pod) bool {
+	if h.latencyBudget.Deadline <= 0 || time.Since(start) <= h.latencyBudget.Deadline {
+		return false
+	}
+	klog.V(2).Infof("Latency budget exceeded, admitting pod %s/%s without changes", pod.Namespace, pod.Name)
+	admission.OnShortCircuit(admission.DeadlineExceeded)
+	return true
+}
@@ -19,6 +19,7 @@ package pod
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	admissionv1 "k8s.io/api/admission/v1"
@@ -57,6 +58,18 @@ func (c *fakePatchCalculator) CalculatePatches(_ *apiv1.Pod, _ *vpa_types.Vertic
 	return c.patches, c.err
 }
 
+type slowPatchCalculator struct {
+	delay time.Duration
+	calls int
+}
+
+func (c *slowPatchCalculator) CalculatePatches(_ *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler) (
+	[]resource_admission.PatchRecord, error) {
+	c.calls++
+	time.Sleep(c.delay)
+	return []resource_admission.PatchRecord{{Op: "add", Path: "some/path", Value: "much"}}, nil
+}
+
 func TestGetPatches(t *testing.T) {
 	testVpa := test.VerticalPodAutoscaler().WithName("name").WithContainer("testy-container").Get()
 	testPatchRecord := resource_admission.PatchRecord{
@@ -202,3 +215,160 @@ func TestGetPatches(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPatchesDryRun(t *testing.T) {
+	testVpa := test.VerticalPodAutoscaler().WithName("name").WithContainer("testy-container").Get()
+	calculator := &fakePatchCalculator{[]resource_admission.PatchRecord{{Op: "add", Path: "some/path", Value: "much"}}, nil}
+	h := NewResourceHandler(&fakePodPreProcessor{}, &fakeVpaMatcher{vpa: testVpa}, []patch.Calculator{calculator})
+	request := &admissionv1.AdmissionRequest{
+		Resource: v1.GroupVersionResource{
+			Version: "v1",
+		},
+		Namespace: "test",
+		Object: runtime.RawExtension{
+			Raw: []byte(`{"metadata": {"annotations": {"vpa.k8s.io/dry-run": "true"}}}`),
+		},
+	}
+
+	patches, err := h.GetPatches(request)
+	assert.NoError(t, err)
+	assert.Equal(t, []resource_admission.PatchRecord{{
+		Op:    "add",
+		Path:  "/metadata/annotations/vpa.k8s.io~1dry-run-patch",
+		Value: `[{"op":"add","path":"some/path","value":"much"}]`,
+	}}, patches)
+}
+
+func TestGetPatchesVpaDisabled(t *testing.T) {
+	testVpa := test.VerticalPodAutoscaler().WithName("name").WithContainer("testy-container").Get()
+	calculator := &fakePatchCalculator{[]resource_admission.PatchRecord{{Op: "add", Path: "some/path", Value: "much"}}, nil}
+	h := NewResourceHandler(&fakePodPreProcessor{}, &fakeVpaMatcher{vpa: testVpa}, []patch.Calculator{calculator})
+	request := &admissionv1.AdmissionRequest{
+		Resource: v1.GroupVersionResource{
+			Version: "v1",
+		},
+		Namespace: "test",
+		Object: runtime.RawExtension{
+			Raw: []byte(`{"metadata": {"annotations": {"vpa.k8s.io/disable": "true"}}}`),
+		},
+	}
+
+	patches, err := h.GetPatches(request)
+	assert.NoError(t, err)
+	assert.Empty(t, patches)
+}
+
+func TestGetPatchesObserveOnly(t *testing.T) {
+	testVpa := test.VerticalPodAutoscaler().WithName("name").WithContainer("testy-container").Get()
+	calculator := &fakePatchCalculator{[]resource_admission.PatchRecord{{Op: "add", Path: "/spec/containers/0/resources", Value: "much"}}, nil}
+	h := NewObservingResourceHandler(&fakePodPreProcessor{}, &fakeVpaMatcher{vpa: testVpa}, []patch.Calculator{calculator},
+		LatencyBudget{}, ObserveOnly{Namespaces: []string{"observed"}})
+
+	for _, tc := range []struct {
+		namespace string
+		expected  []resource_admission.PatchRecord
+	}{
+		{
+			namespace: "observed",
+			expected: []resource_admission.PatchRecord{
+				{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}},
+				{
+					Op:    "add",
+					Path:  "/metadata/annotations/vpa.k8s.io~1dry-run-patch",
+					Value: `[{"op":"add","path":"/metadata/annotations","value":{}},{"op":"add","path":"/spec/containers/0/resources","value":"much"}]`,
+				},
+			},
+		},
+		{
+			namespace: "mutated",
+			expected: []resource_admission.PatchRecord{
+				{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}},
+				{Op: "add", Path: "/spec/containers/0/resources", Value: "much"},
+			},
+		},
+	} {
+		t.Run(tc.namespace, func(t *testing.T) {
+			request := &admissionv1.AdmissionRequest{
+				Resource: v1.GroupVersionResource{
+					Version: "v1",
+				},
+				Namespace: tc.namespace,
+				Object: runtime.RawExtension{
+					Raw: []byte(`{"metadata": {"generateName": "pod-"}}`),
+				},
+			}
+			patches, err := h.GetPatches(request)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, patches)
+		})
+	}
+}
+
+func TestGetPatchesWithLatencyBudget(t *testing.T) {
+	testVpa := test.VerticalPodAutoscaler().WithName("name").WithContainer("testy-container").Get()
+	request := &admissionv1.AdmissionRequest{
+		Resource: v1.GroupVersionResource{
+			Version: "v1",
+		},
+		Namespace: "test",
+		Object: runtime.RawExtension{
+			Raw: []byte("{}"),
+		},
+	}
+
+	t.Run("caches not synced", func(t *testing.T) {
+		calculator := &fakePatchCalculator{[]resource_admission.PatchRecord{{Op: "add", Path: "some/path", Value: "much"}}, nil}
+		h := NewResourceHandlerWithLatencyBudget(&fakePodPreProcessor{}, &fakeVpaMatcher{vpa: testVpa},
+			[]patch.Calculator{calculator}, LatencyBudget{CacheSynced: func() bool { return false }})
+		patches, err := h.GetPatches(request)
+		assert.NoError(t, err)
+		assert.Empty(t, patches)
+	})
+
+	t.Run("deadline checked before each patch calculator", func(t *testing.T) {
+		slow := &slowPatchCalculator{delay: 20 * time.Millisecond}
+		next := &slowPatchCalculator{}
+		h := NewResourceHandlerWithLatencyBudget(&fakePodPreProcessor{}, &fakeVpaMatcher{vpa: testVpa},
+			[]patch.Calculator{slow, next}, LatencyBudget{Deadline: 10 * time.Millisecond})
+		patches, err := h.GetPatches(request)
+		assert.NoError(t, err)
+		assert.Empty(t, patches)
+		assert.Equal(t, 1, slow.calls)
+		assert.Equal(t, 0, next.calls)
+	})
+
+	t.Run("circuit breaker opens after consecutive failures", func(t *testing.T) {
+		calculator := &fakePatchCalculator{nil, fmt.Errorf("recommendation unavailable")}
+		h := NewResourceHandlerWithLatencyBudget(&fakePodPreProcessor{}, &fakeVpaMatcher{vpa: testVpa},
+			[]patch.Calculator{calculator}, LatencyBudget{FailureThreshold: 2, CoolDown: time.Hour})
+		for i := 0; i < 2; i++ {
+			_, err := h.GetPatches(request)
+			assert.Error(t, err)
+		}
+		patches, err := h.GetPatches(request)
+		assert.NoError(t, err)
+		assert.Empty(t, patches)
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.recordFailure()
+	assert.True(t, b.allow())
+	b.recordFailure()
+	assert.False(t, b.allow())
+
+	// Requests are allowed after the cool down, and the next failure reopens the breaker.
+	now = now.Add(2 * time.Minute)
+	assert.True(t, b.allow())
+	b.recordFailure()
+	assert.False(t, b.allow())
+
+	now = now.Add(2 * time.Minute)
+	b.recordSuccess()
+	b.recordFailure()
+	assert.True(t, b.allow())
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/admission"
+	"k8s.io/klog/v2"
+)
+
+// LatencyBudget bounds the time spent on admitting a single Pod.
+// Whenever the budget can't be met the Pod is admitted without changes.
+type LatencyBudget struct {
+	// Deadline is the time after which recommendation lookup is skipped. It's checked before
+	// each step of the lookup, a step in progress isn't interrupted. Zero means no deadline.
+	Deadline time.Duration
+	// CacheSynced reports whether caches used for recommendation lookup are populated. It's
+	// called for every admitted Pod, so it must not block. Nil means caches are always
+	// considered synced.
+	CacheSynced func() bool
+	// FailureThreshold is the number of consecutive failures after which the circuit breaker
+	// opens and Pods are admitted without recommendation lookup. Zero disables the circuit breaker.
+	FailureThreshold int
+	// CoolDown is the time for which the circuit breaker stays open.
+	CoolDown time.Duration
+}
+
+// circuitBreaker stops recommendation lookups after repeated failures, so that
+// Pod creation isn't slowed down when the recommendation data source is down.
+type circuitBreaker struct {
+	mutex               sync.Mutex
+	failureThreshold    int
+	coolDown            time.Duration
+	consecutiveFailures int
+	openUntil           time.Time
+	now                 func() time.Time
+}
+
+func newCircuitBreaker(failureThreshold int, coolDown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		coolDown:         coolDown,
+		now:              time.Now,
+	}
+}
+
+// allow returns false if the circuit breaker is open.
+func (b *circuitBreaker) allow() bool {
+	if b.failureThreshold <= 0 {
+		return true
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return !b.now().Before(b.openUntil)
+}
+
+// recordFailure registers a failed recommendation lookup and opens the circuit breaker
+// once the number of consecutive failures reaches the threshold.
+func (b *circuitBreaker) recordFailure() {
+	if b.failureThreshold <= 0 {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.consecutiveFailures++
+	if b.consecutiveFailures >= b.failureThreshold {
+		klog.Warningf("%d consecutive admission failures, serving no-op patches for %v", b.consecutiveFailures, b.coolDown)
+		b.openUntil = b.now().Add(b.coolDown)
+		// Requests are let through again once the cool down passes, and the next
+		// failure reopens the circuit breaker.
+		b.consecutiveFailures = b.failureThreshold - 1
+		admission.SetCircuitBreakerOpen(true)
+	}
+}
+
+// recordSuccess registers a successful recommendation lookup and closes the circuit breaker.
+func (b *circuitBreaker) recordSuccess() {
+	if b.failureThreshold <= 0 {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.openUntil.IsZero() {
+		klog.V(2).Infof("Admission succeeded, closing circuit breaker")
+		admission.SetCircuitBreakerOpen(false)
+	}
+	b.consecutiveFailures = 0
+	b.openUntil = time.Time{}
+}
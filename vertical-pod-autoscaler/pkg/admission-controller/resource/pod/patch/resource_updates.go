@@ -25,6 +25,7 @@ import (
 	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/recommendation"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 )
 
@@ -69,9 +70,32 @@ func (c *resourcesUpdatesPatchCalculator) CalculatePatches(pod *core.Pod, vpa *v
 		vpaAnnotationValue := fmt.Sprintf("Pod resources updated by %s: %s", vpa.Name, strings.Join(updatesAnnotation, "; "))
 		result = append(result, GetAddAnnotationPatch(ResourceUpdatesAnnotation, vpaAnnotationValue))
 	}
+	if _, found := pod.Annotations[annotations.VpaOriginalRequestsAnnotation]; !found && hasRelativeBounds(vpa.Spec.ResourcePolicy) {
+		// Relative bounds are resolved against the requests of the controller template, which
+		// the updater can't tell from the updated requests of the Pod.
+		originalRequests, err := annotations.GetVpaOriginalRequestsValue(pod)
+		if err != nil {
+			return []resource_admission.PatchRecord{}, fmt.Errorf("Failed to record original requests of pod %v/%v: %v", pod.Namespace, pod.Name, err)
+		}
+		result = append(result, GetAddAnnotationPatch(annotations.VpaOriginalRequestsAnnotation, originalRequests))
+	}
 	return result, nil
 }
 
+// hasRelativeBounds returns true if any container policy bounds the recommendation
+// relative to the requests of the container.
+func hasRelativeBounds(policy *vpa_types.PodResourcePolicy) bool {
+	if policy == nil {
+		return false
+	}
+	for _, containerPolicy := range policy.ContainerPolicies {
+		if len(containerPolicy.MinAllowedPercentOfRequests) > 0 || len(containerPolicy.MaxAllowedPercentOfRequests) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func getContainerPatch(pod *core.Pod, i int, annotationsPerContainer vpa_api_util.ContainerToAnnotationsMap, containerResources vpa_api_util.ContainerResources) ([]resource_admission.PatchRecord, string) {
 	var patches []resource_admission.PatchRecord
 	// Add empty resources object if missing.
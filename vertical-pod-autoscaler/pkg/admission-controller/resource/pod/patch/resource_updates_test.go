@@ -23,8 +23,10 @@ import (
 
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 
@@ -309,3 +311,50 @@ func TestGetPatches_TwoReplacementResources(t *testing.T) {
 		AssertPatchOneOf(t, patches[2], []resource_admission.PatchRecord{cpuFirstUnobtaniumSecond, unobtaniumFirstCpuSecond})
 	}
 }
+
+func TestCalculatePatches_OriginalRequests(t *testing.T) {
+	recommendResources := []vpa_api_util.ContainerResources{
+		{
+			Requests: core.ResourceList{
+				cpu: resource.MustParse("2"),
+			},
+		},
+	}
+	pod := &core.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+		Spec: core.PodSpec{
+			Containers: []core.Container{{
+				Name: "test",
+				Resources: core.ResourceRequirements{
+					Requests: core.ResourceList{
+						cpu: resource.MustParse("1"),
+					},
+				},
+			}},
+		},
+	}
+	vpa := test.VerticalPodAutoscaler().WithName("name").WithContainer("test").Get()
+	frp := fakeRecommendationProvider{recommendResources, vpa_api_util.ContainerToAnnotationsMap{}, nil}
+	c := NewResourceUpdatesCalculator(&frp)
+
+	// Without relative bounds, the original requests aren't recorded.
+	patches, err := c.CalculatePatches(pod, vpa)
+	assert.NoError(t, err)
+	assert.Len(t, patches, 2)
+
+	vpa.Spec.ResourcePolicy = &vpa_types.PodResourcePolicy{ContainerPolicies: []vpa_types.ContainerResourcePolicy{{
+		ContainerName:               "test",
+		MaxAllowedPercentOfRequests: map[core.ResourceName]int32{cpu: 200},
+	}}}
+	patches, err = c.CalculatePatches(pod, vpa)
+	assert.NoError(t, err)
+	if assert.Len(t, patches, 3) {
+		assert.Equal(t, GetAddAnnotationPatch(annotations.VpaOriginalRequestsAnnotation, `{"test":{"cpu":"1"}}`), patches[2])
+	}
+
+	// Original requests already recorded are kept.
+	pod.Annotations[annotations.VpaOriginalRequestsAnnotation] = `{"test":{"cpu":"500m"}}`
+	patches, err = c.CalculatePatches(pod, vpa)
+	assert.NoError(t, err)
+	assert.Len(t, patches, 2)
+}
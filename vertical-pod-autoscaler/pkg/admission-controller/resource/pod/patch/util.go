@@ -18,6 +18,7 @@ package patch
 
 import (
 	"fmt"
+	"strings"
 
 	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
 )
@@ -31,11 +32,14 @@ func GetAddEmptyAnnotationsPatch() resource_admission.PatchRecord {
 	}
 }
 
+// annotationPathEscaper escapes annotation names for use in a JSON Pointer, see RFC 6901.
+var annotationPathEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 // GetAddAnnotationPatch returns a patch for an annotation.
 func GetAddAnnotationPatch(annotationName, annotationValue string) resource_admission.PatchRecord {
 	return resource_admission.PatchRecord{
 		Op:    "add",
-		Path:  fmt.Sprintf("/metadata/annotations/%s", annotationName),
+		Path:  fmt.Sprintf("/metadata/annotations/%s", annotationPathEscaper.Replace(annotationName)),
 		Value: annotationValue,
 	}
 }
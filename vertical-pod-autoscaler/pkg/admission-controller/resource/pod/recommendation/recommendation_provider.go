@@ -68,6 +68,13 @@ func GetContainersResources(pod *core.Pod, vpaResourcePolicy *vpa_types.PodResou
 			defaultLimit = limitRange.Default
 		}
 		containerControlledValues := vpa_api_util.GetContainerControlledValues(container.Name, vpaResourcePolicy)
+		if containerControlledValues == vpa_types.ContainerControlledValuesMemoryLimitsOnly {
+			// Requests are sized manually, only the memory limit is raised to prevent OOM kills.
+			resources[i].Requests = nil
+			if limit, raise := vpa_api_util.GetRaisedMemoryLimit(container, vpaResourcePolicy, recommendation); raise {
+				resources[i].Limits = core.ResourceList{core.ResourceMemory: limit}
+			}
+		}
 		if containerControlledValues == vpa_types.ContainerControlledValuesRequestsAndLimits {
 			proportionalLimits, limitAnnotations := vpa_api_util.GetProportionalLimit(container.Resources.Limits, container.Resources.Requests, resources[i].Requests, defaultLimit)
 			if proportionalLimits != nil {
@@ -92,16 +99,18 @@ func (p *recommendationProvider) GetContainersResourcesForPod(pod *core.Pod, vpa
 
 	var annotations vpa_api_util.ContainerToAnnotationsMap
 	recommendedPodResources := &vpa_types.RecommendedPodResources{}
+	limitsRangeCalculator, _ := vpa_api_util.ConstraintsLimitRangeCalculator(p.limitsRangeCalculator, vpa)
+	recommendationProcessor := vpa_api_util.NewConstraintsRecommendationProcessor(p.recommendationProcessor, p.limitsRangeCalculator, vpa)
 
-	if vpa.Status.Recommendation != nil {
+	if recommendation := vpa_api_util.GetRecommendationForPod(vpa, pod); recommendation != nil {
 		var err error
-		recommendedPodResources, annotations, err = p.recommendationProcessor.Apply(vpa.Status.Recommendation, vpa.Spec.ResourcePolicy, vpa.Status.Conditions, pod)
+		recommendedPodResources, annotations, err = recommendationProcessor.Apply(recommendation, vpa.Spec.ResourcePolicy, vpa.Status.Conditions, pod)
 		if err != nil {
 			klog.V(2).Infof("cannot process recommendation for pod %s", pod.Name)
 			return nil, annotations, err
 		}
 	}
-	containerLimitRange, err := p.limitsRangeCalculator.GetContainerLimitRangeItem(pod.Namespace)
+	containerLimitRange, err := limitsRangeCalculator.GetContainerLimitRangeItem(pod.Namespace)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error getting containerLimitRange: %s", err)
 	}
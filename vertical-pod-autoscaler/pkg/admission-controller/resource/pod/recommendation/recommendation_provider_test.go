@@ -107,6 +107,11 @@ func TestUpdateResourceRequests(t *testing.T) {
 	resourceRequestsOnlyVPAHighTarget := vpaBuilder.WithControlledValues(containerName, vpa_types.ContainerControlledValuesRequestsOnly).
 		WithTarget("3", "500Mi").WithMaxAllowed(containerName, "5", "1Gi").Get()
 
+	memoryLimitsOnlyVPA := vpaBuilder.WithControlledValues(containerName, vpa_types.ContainerControlledValuesMemoryLimitsOnly).Get()
+	memoryLimitsOnlyVPA.Status.Recommendation.ContainerRecommendations[0].MemoryLimit = mustParseResourcePointer("300Mi")
+	memoryLimitsOnlyVPALowLimit := vpaBuilder.WithControlledValues(containerName, vpa_types.ContainerControlledValuesMemoryLimitsOnly).Get()
+	memoryLimitsOnlyVPALowLimit.Status.Recommendation.ContainerRecommendations[0].MemoryLimit = mustParseResourcePointer("150Mi")
+
 	vpaWithEmptyRecommendation := vpaBuilder.Get()
 	vpaWithEmptyRecommendation.Status.Recommendation = &vpa_types.RecommendedPodResources{}
 	vpaWithNilRecommendation := vpaBuilder.Get()
@@ -250,6 +255,25 @@ func TestUpdateResourceRequests(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:             "memory limits only - limit raised, requests untouched",
+			pod:              podWithDoubleLimit,
+			vpa:              memoryLimitsOnlyVPA,
+			expectedAction:   true,
+			expectedMemLimit: mustParseResourcePointer("300Mi"),
+		},
+		{
+			name:           "memory limits only - limit not lowered",
+			pod:            podWithDoubleLimit,
+			vpa:            memoryLimitsOnlyVPALowLimit,
+			expectedAction: true,
+		},
+		{
+			name:           "memory limits only - limit not added",
+			pod:            initialized,
+			vpa:            memoryLimitsOnlyVPA,
+			expectedAction: true,
+		},
 		{
 			name:             "limit over int64",
 			pod:              podWithTenfoldLimit,
@@ -360,3 +384,60 @@ func TestUpdateResourceRequests(t *testing.T) {
 
 	}
 }
+
+type fakePresetLimitRangeCalculator struct {
+	fakeLimitRangeCalculator
+	presets map[string]*fakeLimitRangeCalculator
+}
+
+func (nlrc *fakePresetLimitRangeCalculator) WithPreset(namespace, name string) limitrange.LimitRangeCalculator {
+	if preset, found := nlrc.presets[namespace+"/"+name]; found {
+		return preset
+	}
+	return &nlrc.fakeLimitRangeCalculator
+}
+
+func TestUpdateResourceRequestsWithConstraintsRef(t *testing.T) {
+	containerName := "container1"
+	pod := test.Pod().WithName("test_initialized").
+		AddContainer(test.Container().WithName(containerName).
+			WithCPURequest(resource.MustParse("1")).WithCPULimit(resource.MustParse("1")).
+			WithMemRequest(resource.MustParse("100Mi")).WithMemLimit(resource.MustParse("100Mi")).Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithName("vpa1").WithNamespace("team-a").WithContainer(containerName).
+		WithTarget("2", "200Mi").Get()
+	vpa.Spec.ConstraintsRef = &vpa_types.ConstraintsReference{Namespace: "presets", Name: "small"}
+	vpaInOwnNamespace := vpa.DeepCopy()
+	vpaInOwnNamespace.Spec.ConstraintsRef.Namespace = ""
+
+	recommendationProvider := &recommendationProvider{
+		recommendationProcessor: vpa_api_util.NewCappingRecommendationProcessor(limitrange.NewNoopLimitsCalculator()),
+		limitsRangeCalculator: &fakePresetLimitRangeCalculator{
+			presets: map[string]*fakeLimitRangeCalculator{
+				"presets/small": {
+					containerLimitRange: &apiv1.LimitRangeItem{
+						Type: apiv1.LimitTypeContainer,
+						Max: apiv1.ResourceList{
+							apiv1.ResourceCPU:    resource.MustParse("1.5"),
+							apiv1.ResourceMemory: resource.MustParse("150Mi"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resources, annotations, err := recommendationProvider.GetContainersResourcesForPod(pod, vpa)
+	assert.NoError(t, err)
+	if assert.Len(t, resources, 1) {
+		assert.Equal(t, int64(1500), resources[0].Requests.Cpu().MilliValue())
+		assert.Equal(t, int64(150*1024*1024), resources[0].Requests.Memory().Value())
+	}
+	assert.Len(t, annotations[containerName], 2)
+
+	resources, _, err = recommendationProvider.GetContainersResourcesForPod(pod, vpaInOwnNamespace)
+	assert.NoError(t, err)
+	if assert.Len(t, resources, 1) {
+		assert.Equal(t, int64(2000), resources[0].Requests.Cpu().MilliValue())
+		assert.Equal(t, int64(200*1024*1024), resources[0].Requests.Memory().Value())
+	}
+}
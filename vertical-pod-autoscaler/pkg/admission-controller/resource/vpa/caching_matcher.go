@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vpa
+
+import (
+	"sync"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_lister "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/admission"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// cacheEntry holds the name of the VPA matching Pods of a controller, empty if there's none.
+type cacheEntry struct {
+	vpaName    string
+	matched    time.Time
+	refreshing bool
+}
+
+// cachingMatcher caches the VPA matching Pods by the UID of their controller, so that
+// Pods created in bulk by the same controller (e.g. Jobs) don't each go through
+// the full matching. Only the name of the matching VPA is cached, the VPA itself is
+// always read from the lister, so cached matches serve current recommendations.
+type cachingMatcher struct {
+	matcher   Matcher
+	vpaLister vpa_lister.VerticalPodAutoscalerLister
+	// ttl is the time for which a match is served from the cache.
+	ttl time.Duration
+	// maxStale is the time after ttl for which a match is still served while
+	// it's refreshed in the background.
+	maxStale  time.Duration
+	now       func() time.Time
+	mutex     sync.Mutex
+	entries   map[types.UID]*cacheEntry
+	lastPrune time.Time
+}
+
+// NewCachingMatcher returns a Matcher which caches matches of the given matcher by
+// controller UID for ttl, and serves them for up to maxStale more while refreshing them.
+// VPAs created for a controller with a cached match apply after the match expires.
+func NewCachingMatcher(matcher Matcher, vpaLister vpa_lister.VerticalPodAutoscalerLister, ttl, maxStale time.Duration) Matcher {
+	return &cachingMatcher{
+		matcher:   matcher,
+		vpaLister: vpaLister,
+		ttl:       ttl,
+		maxStale:  maxStale,
+		now:       time.Now,
+		entries:   map[types.UID]*cacheEntry{},
+	}
+}
+
+func (m *cachingMatcher) GetMatchingVPA(pod *core.Pod) *vpa_types.VerticalPodAutoscaler {
+	controller := meta.GetControllerOf(pod)
+	if controller == nil || controller.UID == "" {
+		return m.matcher.GetMatchingVPA(pod)
+	}
+
+	now := m.now()
+	m.mutex.Lock()
+	entry, found := m.entries[controller.UID]
+	result := admission.CacheMiss
+	if found {
+		age := now.Sub(entry.matched)
+		if age <= m.ttl {
+			result = admission.CacheHit
+		} else if age <= m.ttl+m.maxStale {
+			result = admission.CacheStale
+			if !entry.refreshing {
+				entry.refreshing = true
+				go m.refresh(controller.UID, pod.DeepCopy())
+			}
+		}
+	}
+	var vpaName string
+	if result != admission.CacheMiss {
+		vpaName = entry.vpaName
+	}
+	m.mutex.Unlock()
+
+	if result != admission.CacheMiss {
+		if vpa, ok := m.getVpa(pod.Namespace, vpaName); ok {
+			admission.OnRecommendationCacheLookup(controller.Kind, result)
+			return vpa
+		}
+		result = admission.CacheMiss
+	}
+	admission.OnRecommendationCacheLookup(controller.Kind, result)
+	vpa := m.matcher.GetMatchingVPA(pod)
+	m.store(controller.UID, vpa, now)
+	return vpa
+}
+
+// getVpa returns the cached VPA from the lister. It returns false if the VPA
+// no longer applies, in which case the match has to be looked up again.
+func (m *cachingMatcher) getVpa(namespace, name string) (*vpa_types.VerticalPodAutoscaler, bool) {
+	if name == "" {
+		return nil, true
+	}
+	vpa, err := m.vpaLister.VerticalPodAutoscalers(namespace).Get(name)
+	if err != nil {
+		klog.V(4).Infof("Cached VPA %s/%s not found: %v", namespace, name, err)
+		return nil, false
+	}
+	if vpa_api_util.GetUpdateMode(vpa) == vpa_types.UpdateModeOff {
+		return nil, false
+	}
+	return vpa, true
+}
+
+func (m *cachingMatcher) refresh(uid types.UID, pod *core.Pod) {
+	vpa := m.matcher.GetMatchingVPA(pod)
+	m.store(uid, vpa, m.now())
+}
+
+func (m *cachingMatcher) store(uid types.UID, vpa *vpa_types.VerticalPodAutoscaler, now time.Time) {
+	entry := &cacheEntry{matched: now}
+	if vpa != nil {
+		entry.vpaName = vpa.Name
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.entries[uid] = entry
+	if now.Sub(m.lastPrune) > m.ttl {
+		m.prune(now)
+	}
+}
+
+// prune removes entries which are too old to be served. Must be called with the mutex held.
+func (m *cachingMatcher) prune(now time.Time) {
+	for uid, entry := range m.entries {
+		if now.Sub(entry.matched) > m.ttl+m.maxStale {
+			delete(m.entries, uid)
+		}
+	}
+	m.lastPrune = now
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vpa
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_lister "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingMatcher struct {
+	mutex sync.Mutex
+	vpa   *vpa_types.VerticalPodAutoscaler
+	calls int
+}
+
+func (m *countingMatcher) GetMatchingVPA(_ *core.Pod) *vpa_types.VerticalPodAutoscaler {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls++
+	return m.vpa
+}
+
+func (m *countingMatcher) getCalls() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.calls
+}
+
+func TestCachingMatcher(t *testing.T) {
+	job := &batchv1.Job{
+		TypeMeta:   meta.TypeMeta{Kind: "Job", APIVersion: "batch/v1"},
+		ObjectMeta: meta.ObjectMeta{Name: "job", Namespace: "default", UID: "job-uid"},
+	}
+	jobPod := func(name string) *core.Pod {
+		return test.Pod().WithName(name).WithCreator(&job.ObjectMeta, &job.TypeMeta).Get()
+	}
+	vpa := test.VerticalPodAutoscaler().WithName("job-vpa").WithContainer("container").
+		WithUpdateMode(vpa_types.UpdateModeAuto).Get()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NoError(t, indexer.Add(vpa))
+	lister := vpa_lister.NewVerticalPodAutoscalerLister(indexer)
+
+	t.Run("serves fresh matches from the cache", func(t *testing.T) {
+		inner := &countingMatcher{vpa: vpa}
+		now := time.Now()
+		m := NewCachingMatcher(inner, lister, time.Minute, 0).(*cachingMatcher)
+		m.now = func() time.Time { return now }
+
+		assert.Equal(t, vpa, m.GetMatchingVPA(jobPod("pod-1")))
+		assert.Equal(t, vpa, m.GetMatchingVPA(jobPod("pod-2")))
+		assert.Equal(t, 1, inner.getCalls())
+
+		now = now.Add(2 * time.Minute)
+		assert.Equal(t, vpa, m.GetMatchingVPA(jobPod("pod-3")))
+		assert.Equal(t, 2, inner.getCalls())
+	})
+
+	t.Run("caches missing matches", func(t *testing.T) {
+		inner := &countingMatcher{}
+		m := NewCachingMatcher(inner, lister, time.Minute, 0)
+
+		assert.Nil(t, m.GetMatchingVPA(jobPod("pod-1")))
+		assert.Nil(t, m.GetMatchingVPA(jobPod("pod-2")))
+		assert.Equal(t, 1, inner.getCalls())
+	})
+
+	t.Run("doesn't cache Pods without a controller", func(t *testing.T) {
+		inner := &countingMatcher{vpa: vpa}
+		m := NewCachingMatcher(inner, lister, time.Minute, 0)
+
+		assert.Equal(t, vpa, m.GetMatchingVPA(test.Pod().WithName("pod-1").Get()))
+		assert.Equal(t, vpa, m.GetMatchingVPA(test.Pod().WithName("pod-1").Get()))
+		assert.Equal(t, 2, inner.getCalls())
+	})
+
+	t.Run("looks up the match again once the cached VPA is gone", func(t *testing.T) {
+		gone := test.VerticalPodAutoscaler().WithName("gone-vpa").WithContainer("container").Get()
+		inner := &countingMatcher{vpa: gone}
+		m := NewCachingMatcher(inner, lister, time.Minute, 0)
+
+		assert.Equal(t, gone, m.GetMatchingVPA(jobPod("pod-1")))
+		assert.Equal(t, gone, m.GetMatchingVPA(jobPod("pod-2")))
+		assert.Equal(t, 2, inner.getCalls())
+	})
+
+	t.Run("serves stale matches while refreshing them", func(t *testing.T) {
+		inner := &countingMatcher{vpa: vpa}
+		now := time.Now()
+		m := NewCachingMatcher(inner, lister, time.Minute, time.Minute).(*cachingMatcher)
+		m.now = func() time.Time { return now }
+
+		assert.Equal(t, vpa, m.GetMatchingVPA(jobPod("pod-1")))
+		now = now.Add(90 * time.Second)
+		assert.Equal(t, vpa, m.GetMatchingVPA(jobPod("pod-2")))
+		assert.Eventually(t, func() bool { return inner.getCalls() == 2 }, time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool {
+			m.mutex.Lock()
+			defer m.mutex.Unlock()
+			return m.entries[job.UID].matched.Equal(now)
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, vpa, m.GetMatchingVPA(jobPod("pod-3")))
+		assert.Equal(t, 2, inner.getCalls())
+	})
+}
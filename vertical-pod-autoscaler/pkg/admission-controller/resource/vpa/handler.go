@@ -28,6 +28,7 @@ import (
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/admission"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 	"k8s.io/klog/v2"
 )
 
@@ -123,6 +124,21 @@ func ValidateVPA(vpa *vpa_types.VerticalPodAutoscaler, isCreate bool) error {
 		if minReplicas := vpa.Spec.UpdatePolicy.MinReplicas; minReplicas != nil && *minReplicas <= 0 {
 			return fmt.Errorf("MinReplicas has to be positive, got %v", *minReplicas)
 		}
+
+		for _, window := range vpa.Spec.UpdatePolicy.MaintenanceWindows {
+			if err := vpa_api_util.ValidateMaintenanceWindow(window); err != nil {
+				return fmt.Errorf("invalid MaintenanceWindow: %v", err)
+			}
+		}
+
+		if budget := vpa.Spec.UpdatePolicy.DisruptionBudget; budget != nil {
+			if budget.MaxDisruptions <= 0 {
+				return fmt.Errorf("DisruptionBudget.MaxDisruptions has to be positive, got %v", budget.MaxDisruptions)
+			}
+			if budget.Window.Duration <= 0 {
+				return fmt.Errorf("DisruptionBudget.Window has to be positive, got %v", budget.Window.Duration)
+			}
+		}
 	}
 
 	if vpa.Spec.ResourcePolicy != nil {
@@ -151,6 +167,20 @@ func ValidateVPA(vpa *vpa_types.VerticalPodAutoscaler, isCreate bool) error {
 					return fmt.Errorf("MaxAllowed: %v", err)
 				}
 			}
+			for resource, min := range policy.MinAllowedPercentOfRequests {
+				if min <= 0 {
+					return fmt.Errorf("MinAllowedPercentOfRequests for %v has to be positive, got %v", resource, min)
+				}
+				max, found := policy.MaxAllowedPercentOfRequests[resource]
+				if found && max < min {
+					return fmt.Errorf("max percent of requests for %v is lower than min", resource)
+				}
+			}
+			for resource, max := range policy.MaxAllowedPercentOfRequests {
+				if max <= 0 {
+					return fmt.Errorf("MaxAllowedPercentOfRequests for %v has to be positive, got %v", resource, max)
+				}
+			}
 			ControlledValues := policy.ControlledValues
 			if mode != nil && ControlledValues != nil {
 				if *mode == vpa_types.ContainerScalingModeOff && *ControlledValues == vpa_types.ContainerControlledValuesRequestsAndLimits {
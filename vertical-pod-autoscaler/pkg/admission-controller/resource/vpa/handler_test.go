@@ -19,10 +19,12 @@ package vpa
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 )
 
@@ -89,6 +91,58 @@ func TestValidateVPA(t *testing.T) {
 			},
 			expectError: fmt.Errorf("MinReplicas has to be positive, got 0"),
 		},
+		{
+			name: "bad maintenance window schedule",
+			vpa: vpa_types.VerticalPodAutoscaler{
+				Spec: vpa_types.VerticalPodAutoscalerSpec{
+					UpdatePolicy: &vpa_types.PodUpdatePolicy{
+						UpdateMode: &validUpdateMode,
+						MaintenanceWindows: []vpa_types.MaintenanceWindow{
+							{Schedule: "0 2 * *", Duration: metav1.Duration{Duration: time.Hour}},
+						},
+					},
+				},
+			},
+			expectError: fmt.Errorf("invalid MaintenanceWindow: invalid schedule \"0 2 * *\": expected exactly 5 fields, found 4: [0 2 * *]"),
+		},
+		{
+			name: "zero maintenance window duration",
+			vpa: vpa_types.VerticalPodAutoscaler{
+				Spec: vpa_types.VerticalPodAutoscalerSpec{
+					UpdatePolicy: &vpa_types.PodUpdatePolicy{
+						UpdateMode: &validUpdateMode,
+						MaintenanceWindows: []vpa_types.MaintenanceWindow{
+							{Schedule: "0 2 * * 6"},
+						},
+					},
+				},
+			},
+			expectError: fmt.Errorf("invalid MaintenanceWindow: duration has to be positive, got 0s"),
+		},
+		{
+			name: "zero max disruptions",
+			vpa: vpa_types.VerticalPodAutoscaler{
+				Spec: vpa_types.VerticalPodAutoscalerSpec{
+					UpdatePolicy: &vpa_types.PodUpdatePolicy{
+						UpdateMode:       &validUpdateMode,
+						DisruptionBudget: &vpa_types.DisruptionBudget{Window: metav1.Duration{Duration: time.Hour}},
+					},
+				},
+			},
+			expectError: fmt.Errorf("DisruptionBudget.MaxDisruptions has to be positive, got 0"),
+		},
+		{
+			name: "zero disruption budget window",
+			vpa: vpa_types.VerticalPodAutoscaler{
+				Spec: vpa_types.VerticalPodAutoscalerSpec{
+					UpdatePolicy: &vpa_types.PodUpdatePolicy{
+						UpdateMode:       &validUpdateMode,
+						DisruptionBudget: &vpa_types.DisruptionBudget{MaxDisruptions: 2},
+					},
+				},
+			},
+			expectError: fmt.Errorf("DisruptionBudget.Window has to be positive, got 0s"),
+		},
 		{
 			name: "no policy name",
 			vpa: vpa_types.VerticalPodAutoscaler{
@@ -152,6 +206,39 @@ func TestValidateVPA(t *testing.T) {
 			},
 			expectError: fmt.Errorf("max resource for cpu is lower than min"),
 		},
+		{
+			name: "bad percent of requests limits",
+			vpa: vpa_types.VerticalPodAutoscaler{
+				Spec: vpa_types.VerticalPodAutoscalerSpec{
+					ResourcePolicy: &vpa_types.PodResourcePolicy{
+						ContainerPolicies: []vpa_types.ContainerResourcePolicy{
+							{
+								ContainerName:               "loot box",
+								MinAllowedPercentOfRequests: map[apiv1.ResourceName]int32{cpu: 150},
+								MaxAllowedPercentOfRequests: map[apiv1.ResourceName]int32{cpu: 120},
+							},
+						},
+					},
+				},
+			},
+			expectError: fmt.Errorf("max percent of requests for cpu is lower than min"),
+		},
+		{
+			name: "non-positive maxAllowed percent of requests",
+			vpa: vpa_types.VerticalPodAutoscaler{
+				Spec: vpa_types.VerticalPodAutoscalerSpec{
+					ResourcePolicy: &vpa_types.PodResourcePolicy{
+						ContainerPolicies: []vpa_types.ContainerResourcePolicy{
+							{
+								ContainerName:               "loot box",
+								MaxAllowedPercentOfRequests: map[apiv1.ResourceName]int32{cpu: 0},
+							},
+						},
+					},
+				},
+			},
+			expectError: fmt.Errorf("MaxAllowedPercentOfRequests for cpu has to be positive, got 0"),
+		},
 		{
 			name: "bad minAllowed cpu value",
 			vpa: vpa_types.VerticalPodAutoscaler{
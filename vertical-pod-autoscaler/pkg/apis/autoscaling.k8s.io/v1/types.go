@@ -20,6 +20,7 @@ package v1
 import (
 	autoscaling "k8s.io/api/autoscaling/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -107,6 +108,29 @@ type VerticalPodAutoscalerSpec struct {
 	// recommendation) or contain exactly one recommender.
 	// +optional
 	Recommenders []*VerticalPodAutoscalerRecommenderSelector `json:"recommenders,omitempty" protobuf:"bytes,4,opt,name=recommenders"`
+
+	// ConstraintsRef points to a preset LimitRange, possibly in another namespace,
+	// whose constraints are applied to the recommendations in addition to the
+	// LimitRanges in the namespace of the VerticalPodAutoscaler. It allows many
+	// teams to share the same constraints without copying them to each namespace.
+	// +optional
+	ConstraintsRef *ConstraintsReference `json:"constraintsRef,omitempty" protobuf:"bytes,5,opt,name=constraintsRef"`
+
+	// VariantLabels are keys of pod labels, e.g. "size" or "shard", which split
+	// the pods of the target into variants with different resource usage
+	// profiles. The pods with the same values of these labels get their own
+	// recommendation, in addition to the recommendation for all the pods.
+	// +optional
+	VariantLabels []string `json:"variantLabels,omitempty" protobuf:"bytes,6,rep,name=variantLabels"`
+}
+
+// ConstraintsReference points to a preset LimitRange.
+type ConstraintsReference struct {
+	// Namespace of the LimitRange. Defaults to the namespace of the VerticalPodAutoscaler.
+	// +optional
+	Namespace string `json:"namespace,omitempty" protobuf:"bytes,1,opt,name=namespace"`
+	// Name of the LimitRange.
+	Name string `json:"name" protobuf:"bytes,2,opt,name=name"`
 }
 
 // EvictionChangeRequirement refers to the relationship between the new target recommendation for a Pod and its current requests, what kind of change is necessary for the Pod to be evicted
@@ -148,6 +172,47 @@ type PodUpdatePolicy struct {
 	// EvictionRequirement is specified, all of them need to be fulfilled to allow eviction.
 	// +optional
 	EvictionRequirements []*EvictionRequirement `json:"evictionRequirements,omitempty" protobuf:"bytes,3,opt,name=evictionRequirements"`
+
+	// MaintenanceWindows is a list of time windows in which Updater is allowed
+	// to evict pods. Outside of them pods are not evicted, but Recommender keeps
+	// updating the recommendation. If empty, pods can be evicted at any time.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty" protobuf:"bytes,4,rep,name=maintenanceWindows"`
+
+	// DisruptionBudget limits how many pods Updater disrupts in a time window,
+	// either by evicting them or by resizing them in place in a way which
+	// restarts their containers. If not set, disruptions are only limited by
+	// the eviction rate limits of Updater.
+	// +optional
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget,omitempty" protobuf:"bytes,5,opt,name=disruptionBudget"`
+
+	// AllowRestartingResizes controls whether Updater resizes pods in place
+	// when the resizePolicy of a resized container requires restarting it.
+	// If false, such pods aren't resized in place. The default is true.
+	// +optional
+	AllowRestartingResizes *bool `json:"allowRestartingResizes,omitempty" protobuf:"varint,6,opt,name=allowRestartingResizes"`
+}
+
+// DisruptionBudget limits the pods disrupted by Updater in a sliding time window.
+type DisruptionBudget struct {
+	// MaxDisruptions is the maximum number of pods disrupted in the window.
+	// Has to be positive.
+	MaxDisruptions int32 `json:"maxDisruptions" protobuf:"varint,1,opt,name=maxDisruptions"`
+	// Window is the duration of the sliding window, e.g. "1h". Has to be positive.
+	Window metav1.Duration `json:"window" protobuf:"bytes,2,opt,name=window"`
+}
+
+// MaintenanceWindow defines a recurring time window in which pods can be evicted.
+type MaintenanceWindow struct {
+	// Schedule is a cron expression in the standard five field format, e.g.
+	// "0 2 * * 6", of the start of the window.
+	Schedule string `json:"schedule" protobuf:"bytes,1,opt,name=schedule"`
+	// Duration of the window. Has to be positive.
+	Duration metav1.Duration `json:"duration" protobuf:"bytes,2,opt,name=duration"`
+	// TimeZone is the name of the time zone of the schedule, e.g. "Europe/Berlin".
+	// The default is UTC.
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty" protobuf:"bytes,3,opt,name=timeZone"`
 }
 
 // UpdateMode controls when autoscaler applies changes to the pod resources.
@@ -213,6 +278,32 @@ type ContainerResourcePolicy struct {
 	// The default is "RequestsAndLimits".
 	// +optional
 	ControlledValues *ContainerControlledValues `json:"controlledValues,omitempty" protobuf:"bytes,6,rep,name=controlledValues"`
+
+	// Specifies the CPU utilization (in percent of the recommended request)
+	// that the container should run at during its observed usage peaks.
+	// When set, the CPU target recommendation is computed as the peak usage
+	// divided by this utilization instead of a percentile of usage plus
+	// safety margin, leaving headroom for latency-sensitive workloads.
+	// Allowed values are between 1 and 100.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty" protobuf:"varint,7,opt,name=targetCPUUtilizationPercentage"`
+
+	// Specifies the minimal amount of resources that will be recommended
+	// for the container, in percent of its requests in the pod, e.g. 50
+	// means at least half of the requests. It is resolved against the
+	// requests of the pod before VPA updated them, i.e. the requests of the
+	// controller template, and applies together with MinAllowed. Resources without requests aren't capped.
+	// +optional
+	MinAllowedPercentOfRequests map[v1.ResourceName]int32 `json:"minAllowedPercentOfRequests,omitempty" protobuf:"bytes,8,rep,name=minAllowedPercentOfRequests,castkey=ResourceName"`
+	// Specifies the maximum amount of resources that will be recommended
+	// for the container, in percent of its requests in the pod, e.g. 200
+	// means at most double the requests. It is resolved against the
+	// requests of the pod before VPA updated them, i.e. the requests of the
+	// controller template, and applies together with MaxAllowed. Resources without requests aren't capped.
+	// +optional
+	MaxAllowedPercentOfRequests map[v1.ResourceName]int32 `json:"maxAllowedPercentOfRequests,omitempty" protobuf:"bytes,9,rep,name=maxAllowedPercentOfRequests,castkey=ResourceName"`
 }
 
 const (
@@ -234,7 +325,7 @@ const (
 )
 
 // ContainerControlledValues controls which resource value should be autoscaled.
-// +kubebuilder:validation:Enum=RequestsAndLimits;RequestsOnly
+// +kubebuilder:validation:Enum=RequestsAndLimits;RequestsOnly;MemoryLimitsOnly
 type ContainerControlledValues string
 
 const (
@@ -243,6 +334,10 @@ const (
 	ContainerControlledValuesRequestsAndLimits ContainerControlledValues = "RequestsAndLimits"
 	// ContainerControlledValuesRequestsOnly means only requested resource is autoscaled.
 	ContainerControlledValuesRequestsOnly ContainerControlledValues = "RequestsOnly"
+	// ContainerControlledValuesMemoryLimitsOnly means only the memory limit is autoscaled,
+	// to prevent OOM kills. Requests are left untouched and memory limits are only raised,
+	// never lowered nor added to containers without one.
+	ContainerControlledValuesMemoryLimitsOnly ContainerControlledValues = "MemoryLimitsOnly"
 )
 
 // VerticalPodAutoscalerStatus describes the runtime state of the autoscaler.
@@ -267,6 +362,22 @@ type RecommendedPodResources struct {
 	// Resources recommended by the autoscaler for each container.
 	// +optional
 	ContainerRecommendations []RecommendedContainerResources `json:"containerRecommendations,omitempty" protobuf:"bytes,1,rep,name=containerRecommendations"`
+	// Resources recommended by the autoscaler for each variant of pods,
+	// if the VerticalPodAutoscaler has VariantLabels.
+	// +optional
+	Variants []RecommendedVariantResources `json:"variants,omitempty" protobuf:"bytes,2,rep,name=variants"`
+}
+
+// RecommendedVariantResources is the recommendation of resources computed by
+// autoscaler for the pods of a variant.
+type RecommendedVariantResources struct {
+	// Labels are the values of the variant labels of the pods of the variant.
+	// Variant labels which the pods don't have are left out.
+	Labels map[string]string `json:"labels" protobuf:"bytes,1,rep,name=labels"`
+	// Resources recommended by the autoscaler for each container of the pods
+	// of the variant.
+	// +optional
+	ContainerRecommendations []RecommendedContainerResources `json:"containerRecommendations,omitempty" protobuf:"bytes,2,rep,name=containerRecommendations"`
 }
 
 // RecommendedContainerResources is the recommendation of resources computed by
@@ -297,6 +408,26 @@ type RecommendedContainerResources struct {
 	// Used only as status indication, will not affect actual resource assignment.
 	// +optional
 	UncappedTarget v1.ResourceList `json:"uncappedTarget,omitempty" protobuf:"bytes,5,opt,name=uncappedTarget"`
+	// Recommended GPU resources, based on GPU memory usage of the controlled pods.
+	// Set only if the recommender is configured with a GPU memory usage source.
+	// Used only as status indication, will not affect actual resource assignment.
+	// +optional
+	GPU *RecommendedGPUResources `json:"gpu,omitempty" protobuf:"bytes,6,opt,name=gpu"`
+	// Recommended memory limit, preventing the container from being OOM killed.
+	// Set only for containers with ContainerControlledValues set to MemoryLimitsOnly.
+	// +optional
+	MemoryLimit *resource.Quantity `json:"memoryLimit,omitempty" protobuf:"bytes,7,opt,name=memoryLimit"`
+}
+
+// RecommendedGPUResources is the recommendation of GPU resources computed by
+// the autoscaler for a specific container.
+type RecommendedGPUResources struct {
+	// Recommended amount of GPU memory.
+	Memory resource.Quantity `json:"memory" protobuf:"bytes,1,name=memory"`
+	// Name of the smallest MIG (Multi-Instance GPU) profile providing the
+	// recommended amount of GPU memory. Empty if no known profile is large enough.
+	// +optional
+	MIGProfile string `json:"migProfile,omitempty" protobuf:"bytes,2,opt,name=migProfile"`
 }
 
 // VerticalPodAutoscalerConditionType are the valid conditions of
@@ -406,6 +537,32 @@ type VerticalPodAutoscalerCheckpointStatus struct {
 
 	// Total number of samples in the histograms.
 	TotalSamplesCount int `json:"totalSamplesCount,omitempty" protobuf:"bytes,7,opt,name=totalSamplesCount"`
+
+	// Checkpoints of the samples aggregated per calendar bucket, keyed by the
+	// bucket name. Empty if the samples aren't split by calendar buckets.
+	// +optional
+	CalendarBuckets map[string]CalendarBucketCheckpoint `json:"calendarBuckets,omitempty" protobuf:"bytes,8,rep,name=calendarBuckets"`
+}
+
+// CalendarBucketCheckpoint contains the checkpoint of samples aggregated in
+// a single calendar bucket.
+type CalendarBucketCheckpoint struct {
+	// Checkpoint of histogram for consumption of CPU.
+	CPUHistogram HistogramCheckpoint `json:"cpuHistogram,omitempty" protobuf:"bytes,1,rep,name=cpuHistograms"`
+
+	// Checkpoint of histogram for consumption of memory.
+	MemoryHistogram HistogramCheckpoint `json:"memoryHistogram,omitempty" protobuf:"bytes,2,rep,name=memoryHistogram"`
+
+	// Timestamp of the fist sample from the histograms.
+	// +nullable
+	FirstSampleStart metav1.Time `json:"firstSampleStart,omitempty" protobuf:"bytes,3,opt,name=firstSampleStart"`
+
+	// Timestamp of the last sample from the histograms.
+	// +nullable
+	LastSampleStart metav1.Time `json:"lastSampleStart,omitempty" protobuf:"bytes,4,opt,name=lastSampleStart"`
+
+	// Total number of samples in the histograms.
+	TotalSamplesCount int `json:"totalSamplesCount,omitempty" protobuf:"bytes,5,opt,name=totalSamplesCount"`
 }
 
 // HistogramCheckpoint contains data needed to reconstruct the histogram.
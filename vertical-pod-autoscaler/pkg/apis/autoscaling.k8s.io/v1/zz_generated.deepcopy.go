@@ -27,6 +27,26 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CalendarBucketCheckpoint) DeepCopyInto(out *CalendarBucketCheckpoint) {
+	*out = *in
+	in.CPUHistogram.DeepCopyInto(&out.CPUHistogram)
+	in.MemoryHistogram.DeepCopyInto(&out.MemoryHistogram)
+	in.FirstSampleStart.DeepCopyInto(&out.FirstSampleStart)
+	in.LastSampleStart.DeepCopyInto(&out.LastSampleStart)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CalendarBucketCheckpoint.
+func (in *CalendarBucketCheckpoint) DeepCopy() *CalendarBucketCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(CalendarBucketCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerResourcePolicy) DeepCopyInto(out *ContainerResourcePolicy) {
 	*out = *in
@@ -63,6 +83,25 @@ func (in *ContainerResourcePolicy) DeepCopyInto(out *ContainerResourcePolicy) {
 		*out = new(ContainerControlledValues)
 		**out = **in
 	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAllowedPercentOfRequests != nil {
+		in, out := &in.MinAllowedPercentOfRequests, &out.MinAllowedPercentOfRequests
+		*out = make(map[corev1.ResourceName]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MaxAllowedPercentOfRequests != nil {
+		in, out := &in.MaxAllowedPercentOfRequests, &out.MaxAllowedPercentOfRequests
+		*out = make(map[corev1.ResourceName]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -76,6 +115,39 @@ func (in *ContainerResourcePolicy) DeepCopy() *ContainerResourcePolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintsReference) DeepCopyInto(out *ConstraintsReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintsReference.
+func (in *ConstraintsReference) DeepCopy() *ConstraintsReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintsReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DisruptionBudget) DeepCopyInto(out *DisruptionBudget) {
+	*out = *in
+	out.Window = in.Window
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DisruptionBudget.
+func (in *DisruptionBudget) DeepCopy() *DisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(DisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HistogramCheckpoint) DeepCopyInto(out *HistogramCheckpoint) {
 	*out = *in
@@ -100,6 +172,28 @@ func (in *HistogramCheckpoint) DeepCopy() *HistogramCheckpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.TimeZone != nil {
+		in, out := &in.TimeZone, &out.TimeZone
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodResourcePolicy) DeepCopyInto(out *PodResourcePolicy) {
 	*out = *in
@@ -136,6 +230,23 @@ func (in *PodUpdatePolicy) DeepCopyInto(out *PodUpdatePolicy) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DisruptionBudget != nil {
+		in, out := &in.DisruptionBudget, &out.DisruptionBudget
+		*out = new(DisruptionBudget)
+		**out = **in
+	}
+	if in.AllowRestartingResizes != nil {
+		in, out := &in.AllowRestartingResizes, &out.AllowRestartingResizes
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -180,6 +291,16 @@ func (in *RecommendedContainerResources) DeepCopyInto(out *RecommendedContainerR
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.GPU != nil {
+		in, out := &in.GPU, &out.GPU
+		*out = new(RecommendedGPUResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemoryLimit != nil {
+		in, out := &in.MemoryLimit, &out.MemoryLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 	return
 }
 
@@ -193,6 +314,23 @@ func (in *RecommendedContainerResources) DeepCopy() *RecommendedContainerResourc
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommendedGPUResources) DeepCopyInto(out *RecommendedGPUResources) {
+	*out = *in
+	out.Memory = in.Memory.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendedGPUResources.
+func (in *RecommendedGPUResources) DeepCopy() *RecommendedGPUResources {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommendedGPUResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RecommendedPodResources) DeepCopyInto(out *RecommendedPodResources) {
 	*out = *in
@@ -203,6 +341,13 @@ func (in *RecommendedPodResources) DeepCopyInto(out *RecommendedPodResources) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Variants != nil {
+		in, out := &in.Variants, &out.Variants
+		*out = make([]RecommendedVariantResources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -216,6 +361,36 @@ func (in *RecommendedPodResources) DeepCopy() *RecommendedPodResources {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommendedVariantResources) DeepCopyInto(out *RecommendedVariantResources) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ContainerRecommendations != nil {
+		in, out := &in.ContainerRecommendations, &out.ContainerRecommendations
+		*out = make([]RecommendedContainerResources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendedVariantResources.
+func (in *RecommendedVariantResources) DeepCopy() *RecommendedVariantResources {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommendedVariantResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VerticalPodAutoscaler) DeepCopyInto(out *VerticalPodAutoscaler) {
 	*out = *in
@@ -329,6 +504,13 @@ func (in *VerticalPodAutoscalerCheckpointStatus) DeepCopyInto(out *VerticalPodAu
 	in.MemoryHistogram.DeepCopyInto(&out.MemoryHistogram)
 	in.FirstSampleStart.DeepCopyInto(&out.FirstSampleStart)
 	in.LastSampleStart.DeepCopyInto(&out.LastSampleStart)
+	if in.CalendarBuckets != nil {
+		in, out := &in.CalendarBuckets, &out.CalendarBuckets
+		*out = make(map[string]CalendarBucketCheckpoint, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -437,6 +619,16 @@ func (in *VerticalPodAutoscalerSpec) DeepCopyInto(out *VerticalPodAutoscalerSpec
 			}
 		}
 	}
+	if in.ConstraintsRef != nil {
+		in, out := &in.ConstraintsRef, &out.ConstraintsRef
+		*out = new(ConstraintsReference)
+		**out = **in
+	}
+	if in.VariantLabels != nil {
+		in, out := &in.VariantLabels, &out.VariantLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
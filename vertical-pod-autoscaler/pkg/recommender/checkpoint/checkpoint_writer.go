@@ -19,6 +19,7 @@ package checkpoint
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
@@ -39,16 +40,44 @@ type CheckpointWriter interface {
 	StoreCheckpoints(ctx context.Context, now time.Time, minCheckpoints int) error
 }
 
+// fieldManager is the field manager of checkpoints written with server-side apply.
+const fieldManager = "vpa-recommender"
+
 type checkpointWriter struct {
 	vpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointsGetter
 	cluster             *model.ClusterState
+	compaction          CompactionConfig
+	// spreadInterval is the interval over which writes of all checkpoints are
+	// spread. Zero if all checkpoints are written in every run.
+	spreadInterval time.Duration
+	lastRun        time.Time
+	// writtenSamples holds the number of samples of each VPA when its
+	// checkpoint was last written.
+	writtenSamples map[model.VpaID]int
 }
 
 // NewCheckpointWriter returns new instance of a CheckpointWriter
 func NewCheckpointWriter(cluster *model.ClusterState, vpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointsGetter) CheckpointWriter {
+	return NewCheckpointWriterWithCompaction(cluster, vpaCheckpointClient, CompactionConfig{})
+}
+
+// NewCheckpointWriterWithCompaction returns new instance of a CheckpointWriter
+// which downsamples histograms of old checkpoints before storing them.
+func NewCheckpointWriterWithCompaction(cluster *model.ClusterState, vpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointsGetter, compaction CompactionConfig) CheckpointWriter {
+	return NewSpreadingCheckpointWriter(cluster, vpaCheckpointClient, compaction, 0)
+}
+
+// NewSpreadingCheckpointWriter returns new instance of a CheckpointWriter which spreads
+// writes of checkpoints over the given interval. Each run writes the share of checkpoints
+// due since the previous run, those not written for the interval first and then those of
+// the VPAs whose models changed the most.
+func NewSpreadingCheckpointWriter(cluster *model.ClusterState, vpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointsGetter, compaction CompactionConfig, spreadInterval time.Duration) CheckpointWriter {
 	return &checkpointWriter{
 		vpaCheckpointClient: vpaCheckpointClient,
 		cluster:             cluster,
+		compaction:          compaction,
+		spreadInterval:      spreadInterval,
+		writtenSamples:      make(map[model.VpaID]int),
 	}
 }
 
@@ -75,9 +104,49 @@ func getVpasToCheckpoint(clusterVpas map[model.VpaID]*model.Vpa) []*model.Vpa {
 	return vpas
 }
 
+// prioritize orders VPAs whose checkpoints weren't written for the spread interval first,
+// oldest first, followed by the VPAs with the most samples added since their checkpoints
+// were written, and returns the number of VPAs to write in this run.
+func (writer *checkpointWriter) prioritize(vpas []*model.Vpa, now time.Time) ([]*model.Vpa, int) {
+	if writer.spreadInterval <= 0 || writer.lastRun.IsZero() {
+		return vpas, len(vpas)
+	}
+	changes := make(map[model.VpaID]int, len(vpas))
+	for _, vpa := range vpas {
+		change := vpa.TotalSamplesCount() - writer.writtenSamples[vpa.ID]
+		if change < 0 {
+			change = -change
+		}
+		changes[vpa.ID] = change
+	}
+	isDue := func(vpa *model.Vpa) bool {
+		return !vpa.CheckpointWritten.Add(writer.spreadInterval).After(now)
+	}
+	// vpas are sorted by the time of the last write, so due VPAs stay sorted that way.
+	sort.SliceStable(vpas, func(i, j int) bool {
+		if isDue(vpas[i]) || isDue(vpas[j]) {
+			return isDue(vpas[i]) && !isDue(vpas[j])
+		}
+		return changes[vpas[i].ID] > changes[vpas[j].ID]
+	})
+	share := float64(now.Sub(writer.lastRun)) / float64(writer.spreadInterval)
+	return vpas, int(math.Ceil(float64(len(vpas)) * math.Min(share, 1.0)))
+}
+
 func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.Time, minCheckpoints int) error {
-	vpas := getVpasToCheckpoint(writer.cluster.Vpas)
+	vpas, toWrite := writer.prioritize(getVpasToCheckpoint(writer.cluster.Vpas), now)
+	writer.lastRun = now
+	for vpaID := range writer.writtenSamples {
+		if _, found := writer.cluster.Vpas[vpaID]; !found {
+			delete(writer.writtenSamples, vpaID)
+		}
+	}
 	for _, vpa := range vpas {
+		if toWrite <= 0 && minCheckpoints <= 0 {
+			klog.V(3).Infof("Checkpoints of remaining VPAs are spread over the next runs")
+			return nil
+		}
+		toWrite--
 
 		// Draining ctx.Done() channel. ctx.Err() will be checked if timeout occurred, but minCheckpoints have
 		// to be written before return from this function.
@@ -97,6 +166,9 @@ func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.T
 				klog.Errorf("Cannot serialize checkpoint for vpa %v container %v. Reason: %+v", vpa.ID.VpaName, container, err)
 				continue
 			}
+			if compactCheckpoint(containerCheckpoint, writer.compaction, now) {
+				klog.V(4).Infof("Compacted VPA %s/%s checkpoint for %s", vpa.ID.Namespace, vpa.ID.VpaName, container)
+			}
 			checkpointName := fmt.Sprintf("%s-%s", vpa.ID.VpaName, container)
 			vpaCheckpoint := vpa_types.VerticalPodAutoscalerCheckpoint{
 				ObjectMeta: metav1.ObjectMeta{Name: checkpointName},
@@ -106,7 +178,7 @@ func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.T
 				},
 				Status: *containerCheckpoint,
 			}
-			err = api_util.CreateOrUpdateVpaCheckpoint(writer.vpaCheckpointClient.VerticalPodAutoscalerCheckpoints(vpa.ID.Namespace), &vpaCheckpoint)
+			err = api_util.ApplyVpaCheckpoint(writer.vpaCheckpointClient.VerticalPodAutoscalerCheckpoints(vpa.ID.Namespace), &vpaCheckpoint, fieldManager)
 			if err != nil {
 				klog.Errorf("Cannot save VPA %s/%s checkpoint for %s. Reason: %+v",
 					vpa.ID.Namespace, vpaCheckpoint.Spec.VPAObjectName, vpaCheckpoint.Spec.ContainerName, err)
@@ -114,6 +186,7 @@ func (writer *checkpointWriter) StoreCheckpoints(ctx context.Context, now time.T
 				klog.V(3).Infof("Saved VPA %s/%s checkpoint for %s",
 					vpa.ID.Namespace, vpaCheckpoint.Spec.VPAObjectName, vpaCheckpoint.Spec.ContainerName)
 				vpa.CheckpointWritten = now
+				writer.writtenSamples[vpa.ID] = vpa.TotalSamplesCount()
 			}
 			minCheckpoints--
 		}
@@ -17,14 +17,19 @@ limitations under the License.
 package checkpoint
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/fake"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+	core "k8s.io/client-go/testing"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -171,3 +176,60 @@ func TestGetVpasToCheckpointSorts(t *testing.T) {
 	assert.Equal(t, genVpaID(2), result[2].ID)
 
 }
+
+func TestStoreCheckpointsSpreadsWrites(t *testing.T) {
+	cluster := model.NewClusterState(testGcPeriod)
+	sampleTime := time.Unix(1, 0)
+	containers := make([]*model.ContainerState, 0, 3)
+	vpas := make([]*model.Vpa, 0, 3)
+	for i := 0; i < 3; i++ {
+		podID := model.PodID{Namespace: "namespace-1", PodName: fmt.Sprintf("pod-%d", i)}
+		cluster.AddOrUpdatePod(podID, map[string]string{"app": fmt.Sprintf("app-%d", i)}, v1.PodRunning)
+		containerID := model.ContainerID{PodID: podID, ContainerName: "container"}
+		assert.NoError(t, cluster.AddOrUpdateContainer(containerID, testRequest))
+		containers = append(containers, cluster.GetContainer(containerID))
+		vpas = append(vpas, addVpa(t, cluster, model.VpaID{Namespace: "namespace-1", VpaName: fmt.Sprintf("vpa-%d", i)}, fmt.Sprintf("app = app-%d", i)))
+	}
+	addSamples := func(container *model.ContainerState, count int) {
+		for i := 0; i < count; i++ {
+			sampleTime = sampleTime.Add(time.Minute)
+			container.AddSample(&model.ContainerUsageSample{
+				MeasureStart: sampleTime,
+				Usage:        model.CPUAmountFromCores(1),
+				Request:      testRequest[model.ResourceCPU],
+				Resource:     model.ResourceCPU,
+			})
+		}
+	}
+
+	var applied []string
+	client := &fake.Clientset{}
+	client.AddReactor("patch", "verticalpodautoscalercheckpoints", func(action core.Action) (bool, runtime.Object, error) {
+		patch := action.(core.PatchAction)
+		assert.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+		applied = append(applied, patch.GetName())
+		return true, nil, nil
+	})
+	writer := NewSpreadingCheckpointWriter(cluster, client.AutoscalingV1(), CompactionConfig{}, 10*time.Minute)
+
+	// All checkpoints are written in the first run.
+	now := time.Unix(10000, 0)
+	assert.NoError(t, writer.StoreCheckpoints(context.Background(), now, 0))
+	assert.ElementsMatch(t, []string{"vpa-0-container", "vpa-1-container", "vpa-2-container"}, applied)
+
+	// A third of the interval later, the checkpoint of the most changed VPA is written.
+	addSamples(containers[0], 1)
+	addSamples(containers[2], 3)
+	applied = nil
+	now = now.Add(200 * time.Second)
+	assert.NoError(t, writer.StoreCheckpoints(context.Background(), now, 0))
+	assert.Equal(t, []string{"vpa-2-container"}, applied)
+
+	// Checkpoints not written for the interval go first.
+	addSamples(containers[2], 5)
+	applied = nil
+	now = now.Add(400 * time.Second)
+	assert.NoError(t, writer.StoreCheckpoints(context.Background(), now, 0))
+	assert.ElementsMatch(t, []string{"vpa-0-container", "vpa-1-container"}, applied)
+	assert.Equal(t, now, vpas[0].CheckpointWritten)
+}
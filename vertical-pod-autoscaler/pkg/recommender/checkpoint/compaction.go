@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"sort"
+	"time"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
+)
+
+// lowWeightFraction is the fraction of the total weight of a histogram below
+// which a bucket is considered low-weight and can be merged.
+const lowWeightFraction = 0.01
+
+// CompactionConfig controls downsampling of histograms stored in checkpoints.
+type CompactionConfig struct {
+	// MinAge is the age of the first sample after which histograms of a
+	// checkpoint are downsampled. Zero disables compaction.
+	MinAge time.Duration
+	// BucketFactor is the number of adjacent buckets merged into one.
+	BucketFactor int
+	// TailPercentile is the percentile from which buckets are kept exact, so
+	// that the percentiles recommendations are computed from don't change.
+	TailPercentile float64
+}
+
+// Enabled tells whether checkpoints should be compacted.
+func (c CompactionConfig) Enabled() bool {
+	return c.MinAge > 0 && c.BucketFactor > 1
+}
+
+// compactCheckpoint downsamples histograms of checkpoints which collected
+// samples for longer than MinAge. Long-horizon trends are preserved while the
+// number of stored buckets, and so the size of the object, goes down.
+// Returns true if the checkpoint was compacted.
+func compactCheckpoint(status *vpa_types.VerticalPodAutoscalerCheckpointStatus, config CompactionConfig, now time.Time) bool {
+	if !config.Enabled() || status.FirstSampleStart.IsZero() {
+		return false
+	}
+	if now.Sub(status.FirstSampleStart.Time) < config.MinAge {
+		return false
+	}
+	downsampleHistogram(&status.CPUHistogram, config.BucketFactor, config.TailPercentile)
+	downsampleHistogram(&status.MemoryHistogram, config.BucketFactor, config.TailPercentile)
+	return true
+}
+
+// downsampleHistogram merges the low-weight buckets below the bucket holding
+// the tail percentile into the last bucket of their group of factor adjacent
+// buckets, without going past the tail. Percentiles are computed from bucket
+// ends, so moving weight up never lowers a recommendation, and the buckets of
+// the tail, which the recommendation percentiles depend on, are kept exact.
+func downsampleHistogram(histogram *vpa_types.HistogramCheckpoint, factor int, tailPercentile float64) {
+	if len(histogram.BucketWeights) == 0 {
+		return
+	}
+	buckets := make([]int, 0, len(histogram.BucketWeights))
+	total := uint64(0)
+	for bucket, weight := range histogram.BucketWeights {
+		buckets = append(buckets, bucket)
+		total += uint64(weight)
+	}
+	sort.Ints(buckets)
+	tailStart := buckets[len(buckets)-1]
+	cumulative := uint64(0)
+	for _, bucket := range buckets {
+		cumulative += uint64(histogram.BucketWeights[bucket])
+		if float64(cumulative) >= tailPercentile*float64(total) {
+			tailStart = bucket
+			break
+		}
+	}
+
+	merged := make(map[int]uint64, len(histogram.BucketWeights))
+	max := uint64(0)
+	for bucket, weight := range histogram.BucketWeights {
+		target := bucket
+		if bucket < tailStart && float64(weight) < lowWeightFraction*float64(total) {
+			target = bucket - bucket%factor + factor - 1
+			if target >= tailStart {
+				target = tailStart - 1
+			}
+		}
+		merged[target] += uint64(weight)
+		if merged[target] > max {
+			max = merged[target]
+		}
+	}
+	// Renormalize only if merged weights don't fit the range used by histograms,
+	// so that the weights of the tail stay exact otherwise.
+	ratio := 1.0
+	if max > uint64(util.MaxCheckpointWeight) {
+		ratio = float64(util.MaxCheckpointWeight) / float64(max)
+	}
+	result := make(map[int]uint32, len(merged))
+	for bucket, weight := range merged {
+		if newWeight := uint32(float64(weight)*ratio + 0.5); newWeight > 0 {
+			result[bucket] = newWeight
+		}
+	}
+	histogram.BucketWeights = result
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
+)
+
+func TestDownsampleHistogram(t *testing.T) {
+	histogram := vpa_types.HistogramCheckpoint{
+		TotalWeight:   10,
+		BucketWeights: map[int]uint32{0: 10, 1: 20, 2: 5, 3: 3000, 5: 10000, 6: 3000, 9: 1},
+	}
+	downsampleHistogram(&histogram, 2, 0.5)
+	// Bucket 3 isn't a low-weight bucket, bucket 9 is in the tail.
+	assert.Equal(t, map[int]uint32{1: 30, 3: 3005, 5: 10000, 6: 3000, 9: 1}, histogram.BucketWeights)
+	assert.Equal(t, 10.0, histogram.TotalWeight)
+
+	// Buckets aren't merged into the tail.
+	histogram.BucketWeights = map[int]uint32{0: 5, 3: 10000}
+	downsampleHistogram(&histogram, 4, 0.5)
+	assert.Equal(t, map[int]uint32{2: 5, 3: 10000}, histogram.BucketWeights)
+}
+
+func TestDownsampleHistogramKeepsTailPercentiles(t *testing.T) {
+	options := model.GetAggregationsConfig().CPUHistogramOptions
+	original := util.NewHistogram(options)
+	now := time.Now()
+	for i := 1; i <= 20; i++ {
+		original.AddSample(0.01*float64(i), 0.1, now)
+	}
+	for i, value := range []float64{0.5, 0.6, 0.8, 1.2} {
+		original.AddSample(value, float64(10*(i+1)), now)
+	}
+	checkpoint, err := original.SaveToChekpoint()
+	assert.NoError(t, err)
+	buckets := len(checkpoint.BucketWeights)
+	downsampleHistogram(checkpoint, 4, 0.5)
+	assert.Less(t, len(checkpoint.BucketWeights), buckets)
+
+	compacted := util.NewHistogram(options)
+	assert.NoError(t, compacted.LoadFromCheckpoint(checkpoint))
+	assert.GreaterOrEqual(t, compacted.Percentile(0.01), original.Percentile(0.01))
+	for _, percentile := range []float64{0.5, 0.9, 0.95, 1.0} {
+		assert.Equal(t, original.Percentile(percentile), compacted.Percentile(percentile))
+	}
+}
+
+func TestCompactCheckpoint(t *testing.T) {
+	now := time.Now()
+	config := CompactionConfig{MinAge: 7 * 24 * time.Hour, BucketFactor: 2, TailPercentile: 0.5}
+	newStatus := func(firstSample time.Time) *vpa_types.VerticalPodAutoscalerCheckpointStatus {
+		return &vpa_types.VerticalPodAutoscalerCheckpointStatus{
+			FirstSampleStart: metav1.NewTime(firstSample),
+			CPUHistogram:     vpa_types.HistogramCheckpoint{BucketWeights: map[int]uint32{0: 1, 4: 10000}},
+			MemoryHistogram:  vpa_types.HistogramCheckpoint{BucketWeights: map[int]uint32{2: 1, 7: 10000}},
+		}
+	}
+
+	recent := newStatus(now.Add(-24 * time.Hour))
+	assert.False(t, compactCheckpoint(recent, config, now))
+	assert.Equal(t, map[int]uint32{0: 1, 4: 10000}, recent.CPUHistogram.BucketWeights)
+
+	old := newStatus(now.Add(-8 * 24 * time.Hour))
+	assert.False(t, compactCheckpoint(old, CompactionConfig{}, now))
+	assert.True(t, compactCheckpoint(old, config, now))
+	assert.Equal(t, map[int]uint32{1: 1, 4: 10000}, old.CPUHistogram.BucketWeights)
+	assert.Equal(t, map[int]uint32{3: 1, 7: 10000}, old.MemoryHistogram.BucketWeights)
+}
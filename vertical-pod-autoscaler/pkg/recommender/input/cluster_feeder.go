@@ -22,6 +22,7 @@ import (
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -81,12 +82,19 @@ type ClusterStateFeederFactory struct {
 	MetricsClient       metrics.MetricsClient
 	VpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointsGetter
 	VpaLister           vpa_lister.VerticalPodAutoscalerLister
-	PodLister           v1lister.PodLister
-	OOMObserver         oom.Observer
-	SelectorFetcher     target.VpaTargetSelectorFetcher
-	MemorySaveMode      bool
-	ControllerFetcher   controllerfetcher.ControllerFetcher
-	RecommenderName     string
+	// VpaClient is used to check that VPAs not selected by VpaLabelSelector are gone
+	// before garbage collecting their checkpoints.
+	VpaClient         vpa_api.VerticalPodAutoscalersGetter
+	VpaLabelSelector  labels.Selector
+	PodLister         v1lister.PodLister
+	OOMObserver       oom.Observer
+	SelectorFetcher   target.VpaTargetSelectorFetcher
+	MemorySaveMode    bool
+	ControllerFetcher controllerfetcher.ControllerFetcher
+	RecommenderName   string
+	// CPUSampleWarmup is how long after a container (re)starts its CPU usage samples are skipped.
+	// Zero aggregates all samples.
+	CPUSampleWarmup time.Duration
 }
 
 // Make creates new ClusterStateFeeder with internal data providers, based on kube client.
@@ -103,6 +111,9 @@ func (m ClusterStateFeederFactory) Make() *clusterStateFeeder {
 		memorySaveMode:      m.MemorySaveMode,
 		controllerFetcher:   m.ControllerFetcher,
 		recommenderName:     m.RecommenderName,
+		vpaClient:           m.VpaClient,
+		vpaLabelSelector:    m.VpaLabelSelector,
+		cpuSampleWarmup:     m.CPUSampleWarmup,
 	}
 }
 
@@ -192,6 +203,9 @@ type clusterStateFeeder struct {
 	memorySaveMode      bool
 	controllerFetcher   controllerfetcher.ControllerFetcher
 	recommenderName     string
+	vpaClient           vpa_api.VerticalPodAutoscalersGetter
+	vpaLabelSelector    labels.Selector
+	cpuSampleWarmup     time.Duration
 }
 
 func (feeder *clusterStateFeeder) InitFromHistoryProvider(historyProvider history.HistoryProvider) {
@@ -257,6 +271,11 @@ func (feeder *clusterStateFeeder) InitFromCheckpoints() {
 			klog.Errorf("Cannot list VPA checkpoints from namespace %v. Reason: %+v", namespace, err)
 		}
 		for _, checkpoint := range checkpointList.Items {
+			vpaID := model.VpaID{Namespace: checkpoint.Namespace, VpaName: checkpoint.Spec.VPAObjectName}
+			if _, exists := feeder.clusterState.Vpas[vpaID]; !exists {
+				klog.V(4).Infof("Skipping checkpoint %s/%s of VPA %s not handled by this recommender", checkpoint.Namespace, checkpoint.Name, checkpoint.Spec.VPAObjectName)
+				continue
+			}
 
 			klog.V(3).Infof("Loading VPA %s/%s checkpoint for %s", checkpoint.ObjectMeta.Namespace, checkpoint.Spec.VPAObjectName, checkpoint.Spec.ContainerName)
 			err = feeder.setVpaCheckpoint(&checkpoint)
@@ -268,6 +287,28 @@ func (feeder *clusterStateFeeder) InitFromCheckpoints() {
 	}
 }
 
+// vpaExists returns true if the VPA exists, even if it's handled by other recommenders,
+// in which case its checkpoints must not be garbage collected.
+func (feeder *clusterStateFeeder) vpaExists(vpaID model.VpaID) bool {
+	_, err := feeder.vpaLister.VerticalPodAutoscalers(vpaID.Namespace).Get(vpaID.VpaName)
+	if err == nil {
+		return true
+	}
+	if feeder.vpaLabelSelector == nil || feeder.vpaLabelSelector.Empty() || feeder.vpaClient == nil {
+		return false
+	}
+	// VPAs not matching the label selector aren't in the lister.
+	_, err = feeder.vpaClient.VerticalPodAutoscalers(vpaID.Namespace).Get(context.TODO(), vpaID.VpaName, metav1.GetOptions{})
+	if err == nil {
+		return true
+	}
+	if !errors.IsNotFound(err) {
+		klog.Errorf("Cannot check if VPA %s/%s exists. Reason: %+v", vpaID.Namespace, vpaID.VpaName, err)
+		return true
+	}
+	return false
+}
+
 func (feeder *clusterStateFeeder) GarbageCollectCheckpoints() {
 	klog.V(3).Info("Starting garbage collection of checkpoints")
 	feeder.LoadVPAs()
@@ -287,7 +328,7 @@ func (feeder *clusterStateFeeder) GarbageCollectCheckpoints() {
 		for _, checkpoint := range checkpointList.Items {
 			vpaID := model.VpaID{Namespace: checkpoint.Namespace, VpaName: checkpoint.Spec.VPAObjectName}
 			_, exists := feeder.clusterState.Vpas[vpaID]
-			if !exists {
+			if !exists && !feeder.vpaExists(vpaID) {
 				err = feeder.vpaCheckpointClient.VerticalPodAutoscalerCheckpoints(namespace).Delete(context.TODO(), checkpoint.Name, metav1.DeleteOptions{})
 				if err == nil {
 					klog.V(3).Infof("Orphaned VPA checkpoint cleanup - deleting %v/%v.", namespace, checkpoint.Name)
@@ -407,10 +448,13 @@ func (feeder *clusterStateFeeder) LoadPods() {
 			continue
 		}
 		feeder.clusterState.AddOrUpdatePod(pod.ID, pod.PodLabels, pod.Phase)
+		feeder.clusterState.Pods[pod.ID].StaticCPU = pod.StaticCPU
 		for _, container := range pod.Containers {
 			if err = feeder.clusterState.AddOrUpdateContainer(container.ID, container.Request); err != nil {
 				klog.Warningf("Failed to add container %+v. Reason: %+v", container.ID, err)
+				continue
 			}
+			feeder.clusterState.Pods[pod.ID].Containers[container.ID.ContainerName].StartTime = container.StartTime
 		}
 	}
 }
@@ -423,8 +467,13 @@ func (feeder *clusterStateFeeder) LoadRealTimeMetrics() {
 
 	sampleCount := 0
 	droppedSampleCount := 0
+	skippedSampleCount := 0
 	for _, containerMetrics := range containersMetrics {
 		for _, sample := range newContainerUsageSamplesWithKey(containerMetrics) {
+			if feeder.inCPUWarmup(sample) {
+				skippedSampleCount++
+				continue
+			}
 			if err := feeder.clusterState.AddSample(sample); err != nil {
 				// Not all pod states are tracked in memory saver mode
 				if _, isKeyError := err.(model.KeyError); isKeyError && feeder.memorySaveMode {
@@ -437,7 +486,7 @@ func (feeder *clusterStateFeeder) LoadRealTimeMetrics() {
 			}
 		}
 	}
-	klog.V(3).Infof("ClusterSpec fed with #%v ContainerUsageSamples for #%v containers. Dropped #%v samples.", sampleCount, len(containersMetrics), droppedSampleCount)
+	klog.V(3).Infof("ClusterSpec fed with #%v ContainerUsageSamples for #%v containers. Dropped #%v samples, skipped #%v CPU samples of warming up containers.", sampleCount, len(containersMetrics), droppedSampleCount, skippedSampleCount)
 Loop:
 	for {
 		select {
@@ -453,6 +502,25 @@ Loop:
 	metrics_recommender.RecordAggregateContainerStatesCount(feeder.clusterState.StateMapSize())
 }
 
+// inCPUWarmup returns true for CPU samples measured within cpuSampleWarmup of the
+// latest start or restart of their container, which are dominated by startup CPU usage.
+// Samples measured later, including those of pods which were already running when the
+// recommender started, are aggregated.
+func (feeder *clusterStateFeeder) inCPUWarmup(sample *model.ContainerUsageSampleWithKey) bool {
+	if feeder.cpuSampleWarmup <= 0 || sample.Resource != model.ResourceCPU {
+		return false
+	}
+	pod, found := feeder.clusterState.Pods[sample.Container.PodID]
+	if !found {
+		return false
+	}
+	container, found := pod.Containers[sample.Container.ContainerName]
+	if !found || container.StartTime.IsZero() {
+		return false
+	}
+	return sample.MeasureStart.Sub(container.StartTime) < feeder.cpuSampleWarmup
+}
+
 func (feeder *clusterStateFeeder) matchesVPA(pod *spec.BasicPodSpec) bool {
 	for vpaKey, vpa := range feeder.clusterState.Vpas {
 		podLabels := labels.Set(pod.PodLabels)
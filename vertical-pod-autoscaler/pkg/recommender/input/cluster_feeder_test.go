@@ -27,9 +27,13 @@ import (
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/fake"
+	vpa_lister "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/history"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/metrics"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/spec"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
@@ -524,6 +528,50 @@ func TestClusterStateFeeder_InitFromHistoryProvider(t *testing.T) {
 	assert.Equal(t, memAmount, containerState.GetMaxMemoryPeak())
 }
 
+type fakeMetricsClient struct {
+	snapshots []*metrics.ContainerMetricsSnapshot
+}
+
+func (f *fakeMetricsClient) GetContainersMetrics() ([]*metrics.ContainerMetricsSnapshot, error) {
+	return f.snapshots, nil
+}
+
+func TestClusterStateFeeder_LoadRealTimeMetricsSkipsCPUInWarmup(t *testing.T) {
+	t0 := time.Date(2024, time.May, 6, 10, 0, 0, 0, time.UTC)
+	runningPod := model.PodID{Namespace: "ns", PodName: "running-pod"}
+	restartedPod := model.PodID{Namespace: "ns", PodName: "restarted-pod"}
+	unknownPod := model.PodID{Namespace: "ns", PodName: "unknown-pod"}
+	clusterState := model.NewClusterState(testGcPeriod)
+	snapshots := []*metrics.ContainerMetricsSnapshot{}
+	for podID, startTime := range map[model.PodID]time.Time{runningPod: t0.Add(-time.Hour), restartedPod: t0.Add(-30 * time.Second), unknownPod: {}} {
+		containerID := model.ContainerID{PodID: podID, ContainerName: "container"}
+		clusterState.AddOrUpdatePod(podID, map[string]string{}, "Running")
+		assert.NoError(t, clusterState.AddOrUpdateContainer(containerID, nil))
+		clusterState.Pods[podID].Containers["container"].StartTime = startTime
+		snapshots = append(snapshots, &metrics.ContainerMetricsSnapshot{
+			ID:           containerID,
+			SnapshotTime: t0,
+			Usage: model.Resources{
+				model.ResourceCPU:    model.CPUAmountFromCores(1),
+				model.ResourceMemory: model.MemoryAmountFromBytes(1024 * 1024 * 1024),
+			},
+		})
+	}
+
+	feeder := clusterStateFeeder{
+		clusterState:    clusterState,
+		metricsClient:   &fakeMetricsClient{snapshots: snapshots},
+		cpuSampleWarmup: time.Minute,
+	}
+	feeder.LoadRealTimeMetrics()
+
+	for podID, wantCPUSample := range map[model.PodID]bool{runningPod: true, restartedPod: false, unknownPod: true} {
+		container := clusterState.Pods[podID].Containers["container"]
+		assert.Equal(t, wantCPUSample, !container.LastCPUSampleStart.IsZero(), podID.PodName)
+		assert.Equal(t, model.MemoryAmountFromBytes(1024*1024*1024), container.GetMaxMemoryPeak(), podID.PodName)
+	}
+}
+
 func TestFilterVPAs(t *testing.T) {
 	recommenderName := "test-recommender"
 	defaultRecommenderName := "default-recommender"
@@ -568,3 +616,34 @@ func TestFilterVPAs(t *testing.T) {
 
 	assert.ElementsMatch(t, expectedResult, result)
 }
+
+func TestVpaExists(t *testing.T) {
+	handledVpa := test.VerticalPodAutoscaler().WithName("handled").WithNamespace(namespace).WithContainer("container").Get()
+	otherVpa := test.VerticalPodAutoscaler().WithName("other").WithNamespace(namespace).WithContainer("container").Get()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, indexer.Add(handledVpa))
+	vpaClient := fake.NewSimpleClientset(handledVpa, otherVpa)
+
+	testCases := []struct {
+		name     string
+		vpaName  string
+		selector labels.Selector
+		exists   bool
+	}{
+		{name: "VPA in the lister", vpaName: "handled", exists: true},
+		{name: "VPA missing from the lister without selector", vpaName: "other", exists: false},
+		{name: "VPA not selected by this recommender", vpaName: "other", selector: parseLabelSelector("recommender = other"), exists: true},
+		{name: "deleted VPA", vpaName: "deleted", selector: parseLabelSelector("recommender = other"), exists: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			feeder := clusterStateFeeder{
+				vpaLister:        vpa_lister.NewVerticalPodAutoscalerLister(indexer),
+				vpaClient:        vpaClient.AutoscalingV1(),
+				vpaLabelSelector: tc.selector,
+			}
+			assert.Equal(t, tc.exists, feeder.vpaExists(model.VpaID{Namespace: namespace, VpaName: tc.vpaName}))
+		})
+	}
+}
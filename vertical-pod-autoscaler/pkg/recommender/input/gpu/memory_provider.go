@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gpu
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	promapi "github.com/prometheus/client_golang/api"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/history"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+// MemoryProvider gives peak GPU memory usage of containers in a cluster.
+type MemoryProvider interface {
+	GetGPUMemoryPeaks() (map[model.ContainerID]model.ResourceAmount, error)
+}
+
+// PrometheusMemoryProviderConfig allows to select which metric should be
+// queried to get GPU memory usage, e.g. DCGM_FI_DEV_FB_USED exported by the
+// NVIDIA DCGM exporter.
+type PrometheusMemoryProviderConfig struct {
+	Address      string
+	QueryTimeout time.Duration
+	// MetricName is the name of the metric reporting used GPU memory.
+	MetricName string
+	// BytesPerUnit is the number of bytes in a unit of the metric, e.g. 1048576 for MiB.
+	BytesPerUnit float64
+	// Window is the length of the window over which peaks are computed, as a Prometheus duration.
+	Window                                           string
+	CtrNamespaceLabel, CtrPodNameLabel, CtrNameLabel string
+	Namespace                                        string
+	history.PrometheusBasicAuthTransport
+}
+
+type prometheusMemoryProvider struct {
+	prometheusClient prometheusv1.API
+	config           PrometheusMemoryProviderConfig
+}
+
+// NewPrometheusMemoryProvider constructs a GPU memory provider that gets data from Prometheus.
+func NewPrometheusMemoryProvider(config PrometheusMemoryProviderConfig) (MemoryProvider, error) {
+	promConfig := promapi.Config{
+		Address: config.Address,
+	}
+	if config.Username != "" && config.Password != "" {
+		promConfig.RoundTripper = &history.PrometheusBasicAuthTransport{
+			Username: config.Username,
+			Password: config.Password,
+		}
+	}
+	promClient, err := promapi.NewClient(promConfig)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := prommodel.ParseDuration(config.Window); err != nil {
+		return nil, fmt.Errorf("GPU memory window %s is not a valid Prometheus duration: %v", config.Window, err)
+	}
+	if config.BytesPerUnit <= 0 {
+		return nil, fmt.Errorf("GPU memory bytes per unit must be positive, got %v", config.BytesPerUnit)
+	}
+	return &prometheusMemoryProvider{
+		prometheusClient: prometheusv1.NewAPI(promClient),
+		config:           config,
+	}, nil
+}
+
+func (p *prometheusMemoryProvider) query() string {
+	selector := fmt.Sprintf("%s!=\"\", %s!=\"\"", p.config.CtrPodNameLabel, p.config.CtrNameLabel)
+	if p.config.Namespace != "" {
+		selector = fmt.Sprintf("%s, %s=\"%s\"", selector, p.config.CtrNamespaceLabel, p.config.Namespace)
+	}
+	return fmt.Sprintf("max by (%s, %s, %s) (max_over_time(%s{%s}[%s]))",
+		p.config.CtrNamespaceLabel, p.config.CtrPodNameLabel, p.config.CtrNameLabel,
+		p.config.MetricName, selector, p.config.Window)
+}
+
+// GetGPUMemoryPeaks returns peak GPU memory usage per container within the configured window.
+func (p *prometheusMemoryProvider) GetGPUMemoryPeaks() (map[model.ContainerID]model.ResourceAmount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.QueryTimeout)
+	defer cancel()
+
+	query := p.query()
+	klog.V(4).Infof("GPU memory usage query used: %s", query)
+	result, _, err := p.prometheusClient.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get GPU memory usage: %v", err)
+	}
+	vector, ok := result.(prommodel.Vector)
+	if !ok {
+		return nil, fmt.Errorf("expected query to return a vector; got result type %T", result)
+	}
+
+	peaks := make(map[model.ContainerID]model.ResourceAmount, len(vector))
+	for _, sample := range vector {
+		containerID := model.ContainerID{
+			PodID: model.PodID{
+				Namespace: string(sample.Metric[prommodel.LabelName(p.config.CtrNamespaceLabel)]),
+				PodName:   string(sample.Metric[prommodel.LabelName(p.config.CtrPodNameLabel)]),
+			},
+			ContainerName: string(sample.Metric[prommodel.LabelName(p.config.CtrNameLabel)]),
+		}
+		peaks[containerID] = model.MemoryAmountFromBytes(float64(sample.Value) * p.config.BytesPerUnit)
+	}
+	return peaks, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gpu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+type fakePrometheusAPI struct {
+	prometheusv1.API
+	query  string
+	result prommodel.Value
+}
+
+func (f *fakePrometheusAPI) Query(_ context.Context, query string, _ time.Time, _ ...prometheusv1.Option) (prommodel.Value, prometheusv1.Warnings, error) {
+	f.query = query
+	return f.result, nil, nil
+}
+
+func TestGetGPUMemoryPeaks(t *testing.T) {
+	api := &fakePrometheusAPI{
+		result: prommodel.Vector{
+			{
+				Metric: prommodel.Metric{"namespace": "default", "pod": "inference-1", "container": "server"},
+				Value:  1024,
+			},
+		},
+	}
+	provider := &prometheusMemoryProvider{
+		prometheusClient: api,
+		config: PrometheusMemoryProviderConfig{
+			MetricName:        "DCGM_FI_DEV_FB_USED",
+			BytesPerUnit:      1024 * 1024,
+			Window:            "1d",
+			CtrNamespaceLabel: "namespace",
+			CtrPodNameLabel:   "pod",
+			CtrNameLabel:      "container",
+			QueryTimeout:      time.Minute,
+		},
+	}
+
+	peaks, err := provider.GetGPUMemoryPeaks()
+	assert.NoError(t, err)
+	assert.Equal(t, "max by (namespace, pod, container) (max_over_time(DCGM_FI_DEV_FB_USED{pod!=\"\", container!=\"\"}[1d]))", api.query)
+	assert.Equal(t, map[model.ContainerID]model.ResourceAmount{
+		{PodID: model.PodID{Namespace: "default", PodName: "inference-1"}, ContainerName: "server"}: model.MemoryAmountFromBytes(1024 * 1024 * 1024),
+	}, peaks)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package spec
 
 import (
+	"time"
+
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
@@ -33,6 +35,9 @@ type BasicPodSpec struct {
 	Containers []BasicContainerSpec
 	// PodPhase describing current life cycle phase of the Pod.
 	Phase v1.PodPhase
+	// StaticCPU is true if the pod is in the Guaranteed QoS class and requests
+	// whole CPUs, so that it gets exclusive CPUs under the static CPU manager policy.
+	StaticCPU bool
 }
 
 // BasicContainerSpec contains basic information defining a container.
@@ -43,6 +48,8 @@ type BasicContainerSpec struct {
 	Image string
 	// Currently requested resources for this container.
 	Request model.Resources
+	// StartTime is the time the container was last (re)started, zero if it isn't running.
+	StartTime time.Time
 }
 
 // SpecClient provides information about pods and containers Specification
@@ -88,6 +95,7 @@ func newBasicPodSpec(pod *v1.Pod) *BasicPodSpec {
 		PodLabels:  pod.Labels,
 		Containers: containerSpecs,
 		Phase:      pod.Status.Phase,
+		StaticCPU:  isStaticCPUPod(pod),
 	}
 	return basicPodSpec
 }
@@ -97,12 +105,23 @@ func newContainerSpecs(podID model.PodID, pod *v1.Pod) []BasicContainerSpec {
 
 	for _, container := range pod.Spec.Containers {
 		containerSpec := newContainerSpec(podID, container)
+		containerSpec.StartTime = containerStartTime(pod, container.Name)
 		containerSpecs = append(containerSpecs, containerSpec)
 	}
 
 	return containerSpecs
 }
 
+// containerStartTime returns the time the container was last (re)started, zero if it isn't running.
+func containerStartTime(pod *v1.Pod, containerName string) time.Time {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName && status.State.Running != nil {
+			return status.State.Running.StartedAt.Time
+		}
+	}
+	return time.Time{}
+}
+
 func newContainerSpec(podID model.PodID, container v1.Container) BasicContainerSpec {
 	containerSpec := BasicContainerSpec{
 		ID: model.ContainerID{
@@ -115,6 +134,29 @@ func newContainerSpec(podID model.PodID, container v1.Container) BasicContainerS
 	return containerSpec
 }
 
+// isStaticCPUPod returns true if all containers of the pod have equal CPU and
+// memory requests and limits and at least one of them requests whole CPUs.
+// Such containers are pinned to exclusive CPUs by the static CPU manager policy.
+func isStaticCPUPod(pod *v1.Pod) bool {
+	integerCPU := false
+	for _, container := range pod.Spec.Containers {
+		for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			limit, found := container.Resources.Limits[resourceName]
+			if !found || limit.IsZero() {
+				return false
+			}
+			if request, found := container.Resources.Requests[resourceName]; found && request.Cmp(limit) != 0 {
+				return false
+			}
+		}
+		cpu := container.Resources.Limits[v1.ResourceCPU]
+		if cpu.MilliValue()%1000 == 0 {
+			integerCPU = true
+		}
+	}
+	return integerCPU
+}
+
 func calculateRequestedResources(container v1.Container) model.Resources {
 	cpuQuantity := container.Resources.Requests[v1.ResourceCPU]
 	cpuMillicores := cpuQuantity.MilliValue()
@@ -20,6 +20,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 )
 
 func TestGetPodSpecsReturnsNoResults(t *testing.T) {
@@ -50,3 +54,34 @@ func TestGetPodSpecsReturnsSpecs(t *testing.T) {
 		assert.Contains(t, tc.podSpecs, podSpec, "One of returned BasicPodSpcec is different than expected")
 	}
 }
+
+func TestIsStaticCPUPod(t *testing.T) {
+	guaranteed := func(cpu, memory string) v1.Container {
+		return test.Container().WithName("c").
+			WithCPURequest(resource.MustParse(cpu)).WithCPULimit(resource.MustParse(cpu)).
+			WithMemRequest(resource.MustParse(memory)).WithMemLimit(resource.MustParse(memory)).Get()
+	}
+	burstable := test.Container().WithName("c").
+		WithCPURequest(resource.MustParse("1")).WithCPULimit(resource.MustParse("2")).
+		WithMemRequest(resource.MustParse("1Gi")).WithMemLimit(resource.MustParse("1Gi")).Get()
+	limitsOnly := test.Container().WithName("c").
+		WithCPULimit(resource.MustParse("2")).WithMemLimit(resource.MustParse("1Gi")).Get()
+
+	for name, tc := range map[string]struct {
+		containers []v1.Container
+		expected   bool
+	}{
+		"guaranteed with whole CPUs":             {containers: []v1.Container{guaranteed("2", "1Gi")}, expected: true},
+		"guaranteed with limits only":            {containers: []v1.Container{limitsOnly}, expected: true},
+		"guaranteed with fractional CPUs":        {containers: []v1.Container{guaranteed("1500m", "1Gi")}, expected: false},
+		"guaranteed with a whole CPUs container": {containers: []v1.Container{guaranteed("1500m", "1Gi"), guaranteed("1", "1Gi")}, expected: true},
+		"burstable":                              {containers: []v1.Container{guaranteed("1", "1Gi"), burstable}, expected: false},
+		"best effort":                            {containers: []v1.Container{test.Container().WithName("c").Get()}, expected: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pod := test.Pod().WithName("pod").Get()
+			pod.Spec.Containers = tc.containers
+			assert.Equal(t, tc.expected, isStaticCPUPod(pod))
+		})
+	}
+}
@@ -55,6 +55,22 @@ type minResourcesEstimator struct {
 	baseEstimator ResourceEstimator
 }
 
+type targetUtilizationEstimator struct {
+	peakCPUPercentile float64
+	baseEstimator     ResourceEstimator
+}
+
+type oomRestartMarginEstimator struct {
+	marginFraction    float64
+	maxMarginFraction float64
+	halfLife          time.Duration
+	baseEstimator     ResourceEstimator
+}
+
+type baselineEstimator struct {
+	baseEstimator ResourceEstimator
+}
+
 type confidenceMultiplier struct {
 	multiplier    float64
 	exponent      float64
@@ -88,6 +104,29 @@ func WithConfidenceMultiplier(multiplier, exponent float64, baseEstimator Resour
 	return &confidenceMultiplier{multiplier, exponent, baseEstimator}
 }
 
+// WithTargetUtilization returns a given ResourceEstimator with the CPU
+// estimation replaced by peakCPUPercentile of CPU usage divided by the target
+// CPU utilization, for aggregations that have a target utilization configured.
+// Aggregations without a target utilization get the base estimation.
+func WithTargetUtilization(peakCPUPercentile float64, baseEstimator ResourceEstimator) ResourceEstimator {
+	return &targetUtilizationEstimator{peakCPUPercentile, baseEstimator}
+}
+
+// WithOOMRestartMargin returns a given ResourceEstimator with the memory
+// estimation increased by marginFraction for each recent OOM kill of the
+// containers. The weight of an OOM kill halves every halfLife, and the total
+// margin is capped at maxMarginFraction. Aggregations with an
+// OOMRestartMarginFraction use it instead of marginFraction.
+func WithOOMRestartMargin(marginFraction, maxMarginFraction float64, halfLife time.Duration, baseEstimator ResourceEstimator) ResourceEstimator {
+	return &oomRestartMarginEstimator{marginFraction, maxMarginFraction, halfLife, baseEstimator}
+}
+
+// WithBaseline returns a given ResourceEstimator with the resources raised to
+// the Baseline of the aggregation, for aggregations that have one.
+func WithBaseline(baseEstimator ResourceEstimator) ResourceEstimator {
+	return &baselineEstimator{baseEstimator}
+}
+
 // Returns a constant amount of resources.
 func (e *constEstimator) GetResourceEstimation(s *model.AggregateContainerState) model.Resources {
 	return e.resources
@@ -158,3 +197,70 @@ func (e *minResourcesEstimator) GetResourceEstimation(s *model.AggregateContaine
 	}
 	return newResources
 }
+
+func (e *targetUtilizationEstimator) GetResourceEstimation(s *model.AggregateContainerState) model.Resources {
+	originalResources := e.baseEstimator.GetResourceEstimation(s)
+	if s.TargetCPUUtilization == nil || *s.TargetCPUUtilization <= 0 {
+		return originalResources
+	}
+	newResources := make(model.Resources)
+	for resource, resourceAmount := range originalResources {
+		newResources[resource] = resourceAmount
+	}
+	peakCores := s.AggregateCPUUsage.Percentile(e.peakCPUPercentile)
+	newResources[model.ResourceCPU] = model.CPUAmountFromCores(peakCores / *s.TargetCPUUtilization)
+	return newResources
+}
+
+// Returns the number of recent OOM kills, each weighted by 2^(-age/halfLife).
+// The age is measured relative to the most recent sample or OOM kill, so that
+// the margin decays as the containers keep running without being OOM killed.
+func getOOMKillScore(s *model.AggregateContainerState, halfLife time.Duration) float64 {
+	if len(s.RecentOOMKills) == 0 {
+		return 0
+	}
+	now := s.RecentOOMKills[len(s.RecentOOMKills)-1]
+	if s.LastSampleStart.After(now) {
+		now = s.LastSampleStart
+	}
+	score := 0.0
+	for _, oomKill := range s.RecentOOMKills {
+		score += math.Exp2(-float64(now.Sub(oomKill)) / float64(halfLife))
+	}
+	return score
+}
+
+func (e *oomRestartMarginEstimator) GetResourceEstimation(s *model.AggregateContainerState) model.Resources {
+	originalResources := e.baseEstimator.GetResourceEstimation(s)
+	marginFraction := e.marginFraction
+	if s.OOMRestartMarginFraction != nil {
+		marginFraction = *s.OOMRestartMarginFraction
+	}
+	if marginFraction <= 0 || e.halfLife <= 0 || len(s.RecentOOMKills) == 0 {
+		return originalResources
+	}
+	margin := math.Min(marginFraction*getOOMKillScore(s, e.halfLife), e.maxMarginFraction)
+	newResources := make(model.Resources)
+	for resource, resourceAmount := range originalResources {
+		newResources[resource] = resourceAmount
+	}
+	if memory, found := originalResources[model.ResourceMemory]; found {
+		newResources[model.ResourceMemory] = memory + model.ScaleResource(memory, margin)
+	}
+	return newResources
+}
+
+func (e *baselineEstimator) GetResourceEstimation(s *model.AggregateContainerState) model.Resources {
+	originalResources := e.baseEstimator.GetResourceEstimation(s)
+	if s.Baseline == nil {
+		return originalResources
+	}
+	newResources := make(model.Resources)
+	for resource, resourceAmount := range originalResources {
+		if baseline, found := s.Baseline[resource]; found && resourceAmount < baseline {
+			resourceAmount = baseline
+		}
+		newResources[resource] = resourceAmount
+	}
+	return newResources
+}
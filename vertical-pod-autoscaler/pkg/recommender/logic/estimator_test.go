@@ -159,3 +159,74 @@ func TestMinResourcesEstimator(t *testing.T) {
 	// Original Memory is below min resources
 	assert.Equal(t, 4e8, model.BytesFromMemoryAmount(resourceEstimation[model.ResourceMemory]))
 }
+
+// Verifies that the targetUtilizationEstimator sizes CPU so that the usage
+// peak runs at the target utilization and leaves other resources intact.
+func TestTargetUtilizationEstimator(t *testing.T) {
+	baseEstimator := NewConstEstimator(testRequest)
+	testedEstimator := WithTargetUtilization(0.99, baseEstimator)
+
+	s := model.NewAggregateContainerState()
+	s.AddSample(&model.ContainerUsageSample{
+		MeasureStart: anyTime,
+		Usage:        model.CPUAmountFromCores(2.0),
+		Request:      model.CPUAmountFromCores(1.0),
+		Resource:     model.ResourceCPU,
+	})
+
+	// Without a target utilization the base estimation is returned.
+	assert.Equal(t, testRequest, testedEstimator.GetResourceEstimation(s))
+
+	utilization := 0.5
+	s.TargetCPUUtilization = &utilization
+	resourceEstimation := testedEstimator.GetResourceEstimation(s)
+	maxRelativeError := 0.05 // Allow 5% relative error to account for histogram rounding.
+	assert.InEpsilon(t, 4.0, model.CoresFromCPUAmount(resourceEstimation[model.ResourceCPU]), maxRelativeError)
+	assert.Equal(t, testRequest[model.ResourceMemory], resourceEstimation[model.ResourceMemory])
+}
+
+func TestOOMRestartMarginEstimator(t *testing.T) {
+	baseEstimator := NewConstEstimator(testRequest)
+	testedEstimator := WithOOMRestartMargin(0.1, 0.25, time.Hour, baseEstimator)
+
+	s := model.NewAggregateContainerState()
+	// Without OOM kills the base estimation is returned.
+	assert.Equal(t, testRequest, testedEstimator.GetResourceEstimation(s))
+
+	s.RecordOOMKill(anyTime)
+	s.RecordOOMKill(anyTime.Add(time.Hour))
+	s.LastSampleStart = anyTime.Add(2 * time.Hour)
+	// The OOM kills are 1h and 2h old, so the margin is 0.1 * (0.5 + 0.25).
+	resourceEstimation := testedEstimator.GetResourceEstimation(s)
+	assert.InEpsilon(t, 3.14e9*1.075, model.BytesFromMemoryAmount(resourceEstimation[model.ResourceMemory]), 0.001)
+	assert.Equal(t, testRequest[model.ResourceCPU], resourceEstimation[model.ResourceCPU])
+
+	// The margin is capped.
+	for i := 0; i < 5; i++ {
+		s.RecordOOMKill(anyTime.Add(2 * time.Hour))
+	}
+	resourceEstimation = testedEstimator.GetResourceEstimation(s)
+	assert.InEpsilon(t, 3.14e9*1.25, model.BytesFromMemoryAmount(resourceEstimation[model.ResourceMemory]), 0.001)
+
+	// The margin can be disabled per VPA.
+	disabled := 0.0
+	s.OOMRestartMarginFraction = &disabled
+	assert.Equal(t, testRequest, testedEstimator.GetResourceEstimation(s))
+}
+
+func TestBaselineEstimator(t *testing.T) {
+	baseEstimator := NewConstEstimator(testRequest)
+	testedEstimator := WithBaseline(baseEstimator)
+
+	s := model.NewAggregateContainerState()
+	// Without a baseline the base estimation is returned.
+	assert.Equal(t, testRequest, testedEstimator.GetResourceEstimation(s))
+
+	s.Baseline = model.Resources{
+		model.ResourceCPU:    model.CPUAmountFromCores(5.0),
+		model.ResourceMemory: model.MemoryAmountFromBytes(1e9),
+	}
+	resourceEstimation := testedEstimator.GetResourceEstimation(s)
+	assert.Equal(t, model.CPUAmountFromCores(5.0), resourceEstimation[model.ResourceCPU])
+	assert.Equal(t, testRequest[model.ResourceMemory], resourceEstimation[model.ResourceMemory])
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+// MIGProfile is a Multi-Instance GPU partition a container may be sized to.
+type MIGProfile struct {
+	Name   string
+	Memory model.ResourceAmount
+}
+
+// ParseMIGProfiles parses a comma separated list of <profile>=<memory> pairs,
+// e.g. "1g.5gb=5Gi,2g.10gb=10Gi", into profiles sorted by memory.
+func ParseMIGProfiles(profiles string) ([]MIGProfile, error) {
+	var result []MIGProfile
+	for _, entry := range strings.Split(profiles, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, memory, found := strings.Cut(entry, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid MIG profile %q, expected <profile>=<memory>", entry)
+		}
+		quantity, err := resource.ParseQuantity(memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory of MIG profile %q: %v", name, err)
+		}
+		result = append(result, MIGProfile{Name: name, Memory: model.ResourceAmount(quantity.Value())})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Memory < result[j].Memory })
+	return result, nil
+}
+
+// GPURecommender computes GPU recommendations from observed GPU memory peaks.
+type GPURecommender interface {
+	GetRecommendedGPUResources(peak model.ResourceAmount) *vpa_types.RecommendedGPUResources
+}
+
+type gpuRecommender struct {
+	marginFraction float64
+	migProfiles    []MIGProfile
+}
+
+// NewGPURecommender returns a GPURecommender adding marginFraction of the peak
+// as a safety margin and hinting the smallest of migProfiles that fits it.
+func NewGPURecommender(marginFraction float64, migProfiles []MIGProfile) GPURecommender {
+	return &gpuRecommender{
+		marginFraction: marginFraction,
+		migProfiles:    migProfiles,
+	}
+}
+
+// GetRecommendedGPUResources returns nil if no GPU memory usage was observed.
+func (r *gpuRecommender) GetRecommendedGPUResources(peak model.ResourceAmount) *vpa_types.RecommendedGPUResources {
+	if peak <= 0 {
+		return nil
+	}
+	memory := model.ScaleResource(peak, 1+r.marginFraction)
+	recommendation := &vpa_types.RecommendedGPUResources{
+		Memory: model.QuantityFromMemoryAmount(memory),
+	}
+	for _, profile := range r.migProfiles {
+		if profile.Memory >= memory {
+			recommendation.MIGProfile = profile.Name
+			break
+		}
+	}
+	return recommendation
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+func TestParseMIGProfiles(t *testing.T) {
+	profiles, err := ParseMIGProfiles("2g.10gb=10Gi, 1g.5gb=5Gi")
+	assert.NoError(t, err)
+	assert.Equal(t, []MIGProfile{
+		{Name: "1g.5gb", Memory: model.MemoryAmountFromBytes(5 * 1024 * 1024 * 1024)},
+		{Name: "2g.10gb", Memory: model.MemoryAmountFromBytes(10 * 1024 * 1024 * 1024)},
+	}, profiles)
+
+	_, err = ParseMIGProfiles("1g.5gb")
+	assert.Error(t, err)
+	_, err = ParseMIGProfiles("1g.5gb=lots")
+	assert.Error(t, err)
+}
+
+func TestGetRecommendedGPUResources(t *testing.T) {
+	profiles, err := ParseMIGProfiles("1g.5gb=5Gi,2g.10gb=10Gi")
+	assert.NoError(t, err)
+	recommender := NewGPURecommender(0.25, profiles)
+	gib := 1024.0 * 1024 * 1024
+
+	assert.Nil(t, recommender.GetRecommendedGPUResources(0))
+
+	recommendation := recommender.GetRecommendedGPUResources(model.MemoryAmountFromBytes(4 * gib))
+	assert.Equal(t, int64(5*gib), recommendation.Memory.Value())
+	assert.Equal(t, "1g.5gb", recommendation.MIGProfile)
+
+	recommendation = recommender.GetRecommendedGPUResources(model.MemoryAmountFromBytes(6 * gib))
+	assert.Equal(t, "2g.10gb", recommendation.MIGProfile)
+
+	recommendation = recommender.GetRecommendedGPUResources(model.MemoryAmountFromBytes(12 * gib))
+	assert.Equal(t, int64(15*gib), recommendation.Memory.Value())
+	assert.Empty(t, recommendation.MIGProfile)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+// MemoryLimitRecommender computes memory limit recommendations preventing OOM
+// kills, for containers with memory limits controlled by VPA.
+type MemoryLimitRecommender interface {
+	GetRecommendedMemoryLimit(s *model.AggregateContainerState) *resource.Quantity
+}
+
+type memoryLimitRecommender struct {
+	estimator ResourceEstimator
+}
+
+// NewMemoryLimitRecommender returns a MemoryLimitRecommender based on the given
+// memory estimator.
+func NewMemoryLimitRecommender(estimator ResourceEstimator) MemoryLimitRecommender {
+	return &memoryLimitRecommender{estimator: estimator}
+}
+
+// CreateMemoryLimitRecommender returns the memory limit recommender, sizing
+// limits to a high percentile of memory peaks with a safety margin. Recent OOM
+// kills raise the limit the same way they raise memory recommendations.
+func CreateMemoryLimitRecommender() MemoryLimitRecommender {
+	// Only the memory estimation is used.
+	estimator := NewPercentileEstimator(0, *memoryLimitPercentile)
+	estimator = WithMargin(*memoryLimitMarginFraction, estimator)
+	estimator = WithOOMRestartMargin(*oomRestartMarginFraction, *oomRestartMarginMax, *oomRestartMarginHalfLife, estimator)
+	return NewMemoryLimitRecommender(estimator)
+}
+
+// GetRecommendedMemoryLimit returns nil for containers whose memory limit isn't
+// controlled by VPA, or without memory usage observed yet.
+func (r *memoryLimitRecommender) GetRecommendedMemoryLimit(s *model.AggregateContainerState) *resource.Quantity {
+	if s.ControlledValues == nil || *s.ControlledValues != vpa_types.ContainerControlledValuesMemoryLimitsOnly {
+		return nil
+	}
+	memory := r.estimator.GetResourceEstimation(s)[model.ResourceMemory]
+	if memory <= 0 {
+		return nil
+	}
+	limit := model.QuantityFromMemoryAmount(memory)
+	return &limit
+}
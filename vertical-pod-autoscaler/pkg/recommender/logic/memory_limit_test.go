@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+func TestGetRecommendedMemoryLimit(t *testing.T) {
+	gib := 1024.0 * 1024 * 1024
+	recommender := NewMemoryLimitRecommender(NewConstEstimator(model.Resources{
+		model.ResourceCPU:    model.CPUAmountFromCores(1),
+		model.ResourceMemory: model.MemoryAmountFromBytes(2 * gib),
+	}))
+	memoryLimitsOnly := vpa_types.ContainerControlledValuesMemoryLimitsOnly
+	requestsAndLimits := vpa_types.ContainerControlledValuesRequestsAndLimits
+
+	s := model.NewAggregateContainerState()
+	assert.Nil(t, recommender.GetRecommendedMemoryLimit(s), "controlled values not configured")
+
+	s.ControlledValues = &requestsAndLimits
+	assert.Nil(t, recommender.GetRecommendedMemoryLimit(s), "memory limit not controlled")
+
+	s.ControlledValues = &memoryLimitsOnly
+	limit := recommender.GetRecommendedMemoryLimit(s)
+	if assert.NotNil(t, limit) {
+		assert.Equal(t, int64(2*gib), limit.Value())
+	}
+}
@@ -19,6 +19,7 @@ package logic
 import (
 	"flag"
 	"sort"
+	"time"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
@@ -34,6 +35,12 @@ var (
 	targetMemoryPercentile     = flag.Float64("target-memory-percentile", 0.9, "Memory usage percentile that will be used as a base for memory target recommendation. Doesn't affect memory lower bound nor memory upper bound.")
 	lowerBoundMemoryPercentile = flag.Float64("recommendation-lower-bound-memory-percentile", 0.5, `Memory usage percentile that will be used for the lower bound on memory recommendation.`)
 	upperBoundMemoryPercentile = flag.Float64("recommendation-upper-bound-memory-percentile", 0.95, `Memory usage percentile that will be used for the upper bound on memory recommendation.`)
+	peakCPUPercentile          = flag.Float64("target-cpu-utilization-peak-percentile", 0.99, `CPU usage percentile treated as the usage peak for containers with a target CPU utilization configured in their resource policy.`)
+	oomRestartMarginFraction   = flag.Float64("oom-restart-margin-fraction", 0, `Fraction of memory added to the memory recommendation for each recent OOM kill of the container, so that containers which keep being OOM killed stabilize faster. Can be overridden per VPA with the vpa-recommender.kubernetes.io/oom-restart-margin-fraction annotation. 0 disables the margin`)
+	oomRestartMarginMax        = flag.Float64("oom-restart-margin-max-fraction", 1.0, `Maximum fraction of memory added to the memory recommendation for recent OOM kills`)
+	oomRestartMarginHalfLife   = flag.Duration("oom-restart-margin-half-life", 6*time.Hour, `The amount of time it takes an OOM kill to lose half of its weight in the OOM restart margin`)
+	memoryLimitPercentile      = flag.Float64("memory-limit-percentile", 0.99, `Memory peaks percentile that will be used as a base for memory limit recommendations of containers with controlledValues MemoryLimitsOnly`)
+	memoryLimitMarginFraction  = flag.Float64("memory-limit-margin-fraction", 0.3, `Fraction of memory peaks added as the safety margin to the recommended memory limit`)
 )
 
 // PodResourceRecommender computes resource recommendation for a Vpa object.
@@ -115,6 +122,31 @@ func CreatePodResourceRecommender() PodResourceRecommender {
 	lowerBoundEstimator = WithMargin(*safetyMarginFraction, lowerBoundEstimator)
 	upperBoundEstimator = WithMargin(*safetyMarginFraction, upperBoundEstimator)
 
+	// Containers which were recently OOM killed get an additional memory
+	// margin growing with the number of OOM kills. It decays over time, so
+	// that a container flapping between OOM kills and restarts gets enough
+	// memory sooner than with the OOM bump up of its memory peaks alone.
+	targetEstimator = WithOOMRestartMargin(*oomRestartMarginFraction, *oomRestartMarginMax, *oomRestartMarginHalfLife, targetEstimator)
+	lowerBoundEstimator = WithOOMRestartMargin(*oomRestartMarginFraction, *oomRestartMarginMax, *oomRestartMarginHalfLife, lowerBoundEstimator)
+	upperBoundEstimator = WithOOMRestartMargin(*oomRestartMarginFraction, *oomRestartMarginMax, *oomRestartMarginHalfLife, upperBoundEstimator)
+
+	// Containers with a target CPU utilization get the CPU target sized so
+	// that their usage peaks run at that utilization. The target utilization
+	// already provides headroom, so it replaces the percentile with margin.
+	// The bounds are derived the same way, the upper bound from the peaks so
+	// that it never falls below the target, and the lower bound from its own
+	// percentile so that it never rises above the target.
+	targetEstimator = WithTargetUtilization(*peakCPUPercentile, targetEstimator)
+	lowerBoundEstimator = WithTargetUtilization(*lowerBoundCPUPercentile, lowerBoundEstimator)
+	upperBoundEstimator = WithTargetUtilization(*peakCPUPercentile, upperBoundEstimator)
+
+	// Containers under VPAs with a baseline percentile are never recommended
+	// less than the baseline usage remembered for the aggregation window, so
+	// that long idle periods don't scale down services that burst afterwards.
+	targetEstimator = WithBaseline(targetEstimator)
+	lowerBoundEstimator = WithBaseline(lowerBoundEstimator)
+	upperBoundEstimator = WithBaseline(upperBoundEstimator)
+
 	// Apply confidence multiplier to the upper bound estimator. This means
 	// that the updater will be less eager to evict pods with short history
 	// in order to reclaim unused resources.
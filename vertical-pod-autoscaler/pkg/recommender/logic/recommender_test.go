@@ -17,9 +17,11 @@ limitations under the License.
 package logic
 
 import (
+	"testing"
+	"time"
+
 	"github.com/stretchr/testify/assert"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
-	"testing"
 )
 
 func TestMinResourcesApplied(t *testing.T) {
@@ -165,3 +167,22 @@ func TestMapToListOfRecommendedContainerResources(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetUtilizationKeepsBoundsAroundTarget(t *testing.T) {
+	s := model.NewAggregateContainerState()
+	start := time.Unix(0, 0)
+	for i := 0; i < 24*60; i++ {
+		s.AddSample(&model.ContainerUsageSample{
+			MeasureStart: start.Add(time.Duration(i) * time.Minute),
+			Usage:        model.CPUAmountFromCores(1.0),
+			Request:      model.CPUAmountFromCores(1.0),
+			Resource:     model.ResourceCPU,
+		})
+	}
+	utilization := 1.0
+	s.TargetCPUUtilization = &utilization
+
+	recommended := CreatePodResourceRecommender().GetRecommendedPodResources(model.ContainerNameToAggregateStateMap{"container": s})["container"]
+	assert.LessOrEqual(t, recommended.LowerBound[model.ResourceCPU], recommended.Target[model.ResourceCPU])
+	assert.LessOrEqual(t, recommended.Target[model.ResourceCPU], recommended.UpperBound[model.ResourceCPU])
+}
@@ -18,12 +18,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	kube_client "k8s.io/client-go/kubernetes"
 	kube_flag "k8s.io/component-base/cli/flag"
@@ -33,11 +39,13 @@ import (
 	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/checkpoint"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/gpu"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/history"
 	input_metrics "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/metrics"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/logic"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/routines"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/server"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
@@ -53,6 +61,7 @@ var (
 	prometheusAddress      = flag.String("prometheus-address", "", `Where to reach for Prometheus metrics`)
 	prometheusJobName      = flag.String("prometheus-cadvisor-job-name", "kubernetes-cadvisor", `Name of the prometheus job name which scrapes the cAdvisor metrics`)
 	address                = flag.String("address", ":8942", "The address to expose Prometheus metrics.")
+	configFile             = flag.String("config", "", "Path to an optional configuration file, whose values are overridden by the command line flags.")
 	kubeconfig             = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	kubeApiQps             = flag.Float64("kube-api-qps", 5.0, `QPS limit when making requests to Kubernetes apiserver`)
 	kubeApiBurst           = flag.Float64("kube-api-burst", 10.0, `QPS burst limit when making requests to Kubernetes apiserver`)
@@ -70,9 +79,11 @@ var (
 	ctrPodNameLabel     = flag.String("container-pod-name-label", "pod_name", `Label name to look for container pod names`)
 	ctrNameLabel        = flag.String("container-name-label", "name", `Label name to look for container names`)
 	vpaObjectNamespace  = flag.String("vpa-object-namespace", apiv1.NamespaceAll, "Namespace to search for VPA objects and pod stats. Empty means all namespaces will be used.")
+	vpaLabelSelector    = flag.String("vpa-label-selector", "", "Label selector of the VPA objects handled by this recommender, e.g. to assign them to recommenders with different names. Other VPA objects aren't loaded. Empty means all VPA objects are loaded.")
 	username            = flag.String("username", "", "The username used in the prometheus server basic auth")
 	password            = flag.String("password", "", "The password used in the prometheus server basic auth")
 	memorySaver         = flag.Bool("memory-saver", false, `If true, only track pods which have an associated VPA`)
+	modelMemoryBudget   = flag.String("model-memory-budget", "", `Estimated memory, e.g. 2Gi, the usage histograms of containers are kept within. When over budget, the per calendar bucket histograms are dropped, then the histograms of the least recently updated containers which no longer run, the ones of the VPAs using more than an equal share of the budget first. The histograms of running containers are always kept. Empty disables the budget`)
 	// external metrics provider config
 	useExternalMetrics   = flag.Bool("use-external-metrics", false, "ALPHA.  Use an external metrics provider instead of metrics_server.")
 	externalCpuMetric    = flag.String("external-metrics-cpu-metric", "", "ALPHA.  Metric to use with external metrics provider for CPU usage.")
@@ -81,18 +92,54 @@ var (
 
 // Aggregation configuration flags
 var (
-	memoryAggregationInterval      = flag.Duration("memory-aggregation-interval", model.DefaultMemoryAggregationInterval, `The length of a single interval, for which the peak memory usage is computed. Memory usage peaks are aggregated in multiples of this interval. In other words there is one memory usage sample per interval (the maximum usage over that interval)`)
-	memoryAggregationIntervalCount = flag.Int64("memory-aggregation-interval-count", model.DefaultMemoryAggregationIntervalCount, `The number of consecutive memory-aggregation-intervals which make up the MemoryAggregationWindowLength which in turn is the period for memory usage aggregation by VPA. In other words, MemoryAggregationWindowLength = memory-aggregation-interval * memory-aggregation-interval-count.`)
-	memoryHistogramDecayHalfLife   = flag.Duration("memory-histogram-decay-half-life", model.DefaultMemoryHistogramDecayHalfLife, `The amount of time it takes a historical memory usage sample to lose half of its weight. In other words, a fresh usage sample is twice as 'important' as one with age equal to the half life period.`)
-	cpuHistogramDecayHalfLife      = flag.Duration("cpu-histogram-decay-half-life", model.DefaultCPUHistogramDecayHalfLife, `The amount of time it takes a historical CPU usage sample to lose half of its weight.`)
-	oomBumpUpRatio                 = flag.Float64("oom-bump-up-ratio", model.DefaultOOMBumpUpRatio, `The memory bump up ratio when OOM occurred, default is 1.2.`)
-	oomMinBumpUp                   = flag.Float64("oom-min-bump-up-bytes", model.DefaultOOMMinBumpUp, `The minimal increase of memory when OOM occurred in bytes, default is 100 * 1024 * 1024`)
+	memoryAggregationInterval            = flag.Duration("memory-aggregation-interval", model.DefaultMemoryAggregationInterval, `The length of a single interval, for which the peak memory usage is computed. Memory usage peaks are aggregated in multiples of this interval. In other words there is one memory usage sample per interval (the maximum usage over that interval)`)
+	memoryAggregationIntervalCount       = flag.Int64("memory-aggregation-interval-count", model.DefaultMemoryAggregationIntervalCount, `The number of consecutive memory-aggregation-intervals which make up the MemoryAggregationWindowLength which in turn is the period for memory usage aggregation by VPA. In other words, MemoryAggregationWindowLength = memory-aggregation-interval * memory-aggregation-interval-count.`)
+	memoryHistogramDecayHalfLife         = flag.Duration("memory-histogram-decay-half-life", model.DefaultMemoryHistogramDecayHalfLife, `The amount of time it takes a historical memory usage sample to lose half of its weight. In other words, a fresh usage sample is twice as 'important' as one with age equal to the half life period.`)
+	cpuHistogramDecayHalfLife            = flag.Duration("cpu-histogram-decay-half-life", model.DefaultCPUHistogramDecayHalfLife, `The amount of time it takes a historical CPU usage sample to lose half of its weight.`)
+	oomBumpUpRatio                       = flag.Float64("oom-bump-up-ratio", model.DefaultOOMBumpUpRatio, `The memory bump up ratio when OOM occurred, default is 1.2.`)
+	oomMinBumpUp                         = flag.Float64("oom-min-bump-up-bytes", model.DefaultOOMMinBumpUp, `The minimal increase of memory when OOM occurred in bytes, default is 100 * 1024 * 1024`)
+	cpuSampleWarmup                      = flag.Duration("cpu-sample-warmup", 0, `How long after a container starts or restarts its CPU usage samples are skipped, so that startup CPU usage doesn't skew CPU recommendations. Set to 0 to aggregate all CPU usage samples`)
+	minAdaptiveMemoryAggregationInterval = flag.Duration("min-adaptive-memory-aggregation-interval", 0, `The shortest memory aggregation interval used for containers with volatile usage, when the interval adapts to the usage volatility of each container. Set to 0 to use memory-aggregation-interval for all containers`)
+	maxAdaptiveMemoryAggregationInterval = flag.Duration("max-adaptive-memory-aggregation-interval", model.DefaultMemoryAggregationInterval, `The longest memory aggregation interval used for containers with stable usage, when the interval adapts to the usage volatility of each container`)
+)
+
+// Checkpoint compaction flags
+var (
+	checkpointCompactionMinAge         = flag.Duration("checkpoint-compaction-min-age", 0, `Age of the first sample after which histograms stored in checkpoints are downsampled to coarser buckets. Set to 0 to disable compaction`)
+	checkpointCompactionBucketFactor   = flag.Int("checkpoint-compaction-bucket-factor", 2, `Number of adjacent histogram buckets merged into one when a checkpoint is compacted`)
+	checkpointCompactionTailPercentile = flag.Float64("checkpoint-compaction-tail-percentile", 0.5, `Percentile from which histogram buckets are kept exact when a checkpoint is compacted. Should not be above the lowest percentile recommendations are computed from`)
+	checkpointsSpreadInterval          = flag.Duration("checkpoints-spread-interval", 0, `Interval over which writes of checkpoints are spread, writing checkpoints of the most changed VPAs first. Set to 0 to write all checkpoints in every run`)
 )
 
 // Post processors flags
 var (
 	// CPU as integer to benefit for CPU management Static Policy ( https://kubernetes.io/docs/tasks/administer-cluster/cpu-management-policies/#static-policy )
 	postProcessorCPUasInteger = flag.Bool("cpu-integer-post-processor-enabled", false, "Enable the cpu-integer recommendation post processor. The post processor will round up CPU recommendations to a whole CPU for pods which were opted in by setting an appropriate label on VPA object (experimental)")
+	// Whole CPUs for pods pinned to exclusive CPUs by the static CPU manager policy
+	postProcessorStaticCPU            = flag.Bool("static-cpu-post-processor-enabled", false, "Enable the static-cpu recommendation post processor. The post processor will round up CPU recommendations to whole CPUs for VPAs controlling pods in the Guaranteed QoS class which request whole CPUs (experimental)")
+	postProcessorStaticCPUPodSelector = flag.String("static-cpu-pod-label-selector", "", "Label selector limiting the static-cpu post processor to pods running on nodes with the static CPU manager policy. Empty matches all pods")
+)
+
+// GPU recommendation flags
+var (
+	gpuMemoryMetricSource = flag.String("gpu-memory-metric-source", "", `ALPHA. Source of GPU memory usage used to add GPU recommendations. Supported values: prometheus. Empty disables GPU recommendations`)
+	gpuMemoryMetricName   = flag.String("gpu-memory-metric-name", "DCGM_FI_DEV_FB_USED", `Prometheus metric reporting GPU memory used by a container, as exported by the DCGM exporter`)
+	gpuMemoryMetricUnit   = flag.String("gpu-memory-metric-unit", "1Mi", `Amount of memory represented by one unit of --gpu-memory-metric-name`)
+	gpuMemoryWindow       = flag.String("gpu-memory-window", "8d", `How much time back Prometheus is queried to find the GPU memory usage peak`)
+	gpuNamespaceLabel     = flag.String("gpu-memory-namespace-label", "namespace", `Label name to look for container namespaces in GPU memory metrics`)
+	gpuPodNameLabel       = flag.String("gpu-memory-pod-name-label", "pod", `Label name to look for container pod names in GPU memory metrics`)
+	gpuCtrNameLabel       = flag.String("gpu-memory-container-name-label", "container", `Label name to look for container names in GPU memory metrics`)
+	gpuMemoryMargin       = flag.Float64("gpu-memory-margin-fraction", 0.15, `Fraction of the GPU memory usage peak added as the safety margin to the GPU memory recommendation`)
+	gpuMIGProfiles        = flag.String("gpu-mig-profiles", "1g.5gb=5Gi,2g.10gb=10Gi,3g.20gb=20Gi,4g.20gb=20Gi,7g.40gb=40Gi", `Comma separated list of <profile>=<memory> Multi-Instance GPU profiles used for sizing hints`)
+)
+
+var (
+	enableRecommendationAPI = flag.Bool("enable-recommendation-api", false, `If true, the read-only API serving on-demand recommendations for workloads and pods, even without VPA objects, is exposed at `+server.RecommendationPath+` on --api-address`)
+	enableModelExport       = flag.Bool("enable-model-export", false, `If true, a snapshot of all the aggregations of the model is exported at `+server.ModelPath+` on --api-address, to be imported by the recommender of another cluster with --import-model-file`)
+	importModelFile         = flag.String("import-model-file", "", `Path of a model snapshot exported by the recommender of another cluster, imported on start up, e.g. to carry recommendations over during a cluster migration`)
+	apiAddress              = flag.String("api-address", ":8944", `The address to serve the recommendation API and the model export on. Clients are authenticated and authorized by the API server for the path as a non-resource URL`)
+	apiTLSCertFile          = flag.String("api-tls-cert-file", "", `Path to the TLS certificate served on --api-address. If empty, the API is served over plain HTTP`)
+	apiTLSKeyFile           = flag.String("api-tls-private-key", "", `Path to the TLS private key of --api-tls-cert-file`)
 )
 
 const (
@@ -108,6 +155,7 @@ const (
 func main() {
 	klog.InitFlags(nil)
 	kube_flag.InitFlags()
+	common.LoadConfigurationOrDie(*configFile, common.RecommenderComponent)
 	klog.V(1).Infof("Vertical Pod Autoscaler %s Recommender: %v", common.VerticalPodAutoscalerVersion, *recommenderName)
 
 	config := common.CreateKubeConfigOrDie(*kubeconfig, float32(*kubeApiQps), int(*kubeApiBurst))
@@ -117,7 +165,15 @@ func main() {
 	controllerFetcher := controllerfetcher.NewControllerFetcher(config, kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
 	podLister, oomObserver := input.NewPodListerAndOOMObserver(kubeClient, *vpaObjectNamespace)
 
-	model.InitializeAggregationsConfig(model.NewAggregationsConfig(*memoryAggregationInterval, *memoryAggregationIntervalCount, *memoryHistogramDecayHalfLife, *cpuHistogramDecayHalfLife, *oomBumpUpRatio, *oomMinBumpUp))
+	aggregationsConfig := model.NewAggregationsConfig(*memoryAggregationInterval, *memoryAggregationIntervalCount, *memoryHistogramDecayHalfLife, *cpuHistogramDecayHalfLife, *oomBumpUpRatio, *oomMinBumpUp)
+	if *minAdaptiveMemoryAggregationInterval > 0 {
+		if *maxAdaptiveMemoryAggregationInterval < *minAdaptiveMemoryAggregationInterval {
+			klog.Fatalf("--max-adaptive-memory-aggregation-interval (%v) must not be shorter than --min-adaptive-memory-aggregation-interval (%v)", *maxAdaptiveMemoryAggregationInterval, *minAdaptiveMemoryAggregationInterval)
+		}
+		aggregationsConfig.MinAdaptiveMemoryAggregationInterval = *minAdaptiveMemoryAggregationInterval
+		aggregationsConfig.MaxAdaptiveMemoryAggregationInterval = *maxAdaptiveMemoryAggregationInterval
+	}
+	model.InitializeAggregationsConfig(aggregationsConfig)
 
 	healthCheck := metrics.NewHealthCheck(*metricsFetcherInterval*5, true)
 	metrics.Initialize(*address, healthCheck)
@@ -130,6 +186,13 @@ func main() {
 	if *postProcessorCPUasInteger {
 		postProcessors = append(postProcessors, &routines.IntegerCPUPostProcessor{})
 	}
+	if *postProcessorStaticCPU {
+		staticCPUPodSelector, err := labels.Parse(*postProcessorStaticCPUPodSelector)
+		if err != nil {
+			klog.Fatalf("Could not parse --static-cpu-pod-label-selector: %v", err)
+		}
+		postProcessors = append(postProcessors, &routines.StaticCPUPostProcessor{ClusterState: clusterState, PodSelector: staticCPUPodSelector})
+	}
 
 	// CappingPostProcessor, should always come in the last position for post-processing
 	postProcessors = append(postProcessors, &routines.CappingPostProcessor{})
@@ -150,38 +213,112 @@ func main() {
 		source = input_metrics.NewPodMetricsesSource(resourceclient.NewForConfigOrDie(config))
 	}
 
+	vpaSelector, err := labels.Parse(*vpaLabelSelector)
+	if err != nil {
+		klog.Fatalf("Could not parse --vpa-label-selector: %v", err)
+	}
+	vpaClient := vpa_clientset.NewForConfigOrDie(config)
+	selectorFetcher := target.NewVpaTargetSelectorFetcher(config, kubeClient, factory)
 	clusterStateFeeder := input.ClusterStateFeederFactory{
 		PodLister:           podLister,
 		OOMObserver:         oomObserver,
 		KubeClient:          kubeClient,
 		MetricsClient:       input_metrics.NewMetricsClient(source, *vpaObjectNamespace, "default-metrics-client"),
 		VpaCheckpointClient: vpa_clientset.NewForConfigOrDie(config).AutoscalingV1(),
-		VpaLister:           vpa_api_util.NewVpasLister(vpa_clientset.NewForConfigOrDie(config), make(chan struct{}), *vpaObjectNamespace),
+		VpaLister:           vpa_api_util.NewVpasListerWithSelector(vpaClient, make(chan struct{}), *vpaObjectNamespace, vpaSelector),
+		VpaClient:           vpaClient.AutoscalingV1(),
+		VpaLabelSelector:    vpaSelector,
 		ClusterState:        clusterState,
-		SelectorFetcher:     target.NewVpaTargetSelectorFetcher(config, kubeClient, factory),
+		SelectorFetcher:     selectorFetcher,
 		MemorySaveMode:      *memorySaver,
 		ControllerFetcher:   controllerFetcher,
 		RecommenderName:     *recommenderName,
+		CPUSampleWarmup:     *cpuSampleWarmup,
 	}.Make()
 	controllerFetcher.Start(context.Background(), scaleCacheLoopPeriod)
 
+	promQueryTimeout, err := time.ParseDuration(*queryTimeout)
+	if err != nil {
+		klog.Fatalf("Could not parse --prometheus-query-timeout as a time.Duration: %v", err)
+	}
+
+	var gpuMemoryProvider gpu.MemoryProvider
+	var gpuRecommender logic.GPURecommender
+	switch *gpuMemoryMetricSource {
+	case "":
+	case "prometheus":
+		unit, err := resource.ParseQuantity(*gpuMemoryMetricUnit)
+		if err != nil {
+			klog.Fatalf("Could not parse --gpu-memory-metric-unit: %v", err)
+		}
+		gpuMemoryProvider, err = gpu.NewPrometheusMemoryProvider(gpu.PrometheusMemoryProviderConfig{
+			Address:           *prometheusAddress,
+			QueryTimeout:      promQueryTimeout,
+			MetricName:        *gpuMemoryMetricName,
+			BytesPerUnit:      float64(unit.Value()),
+			Window:            *gpuMemoryWindow,
+			CtrNamespaceLabel: *gpuNamespaceLabel,
+			CtrPodNameLabel:   *gpuPodNameLabel,
+			CtrNameLabel:      *gpuCtrNameLabel,
+			Namespace:         *vpaObjectNamespace,
+			PrometheusBasicAuthTransport: history.PrometheusBasicAuthTransport{
+				Username: *username,
+				Password: *password,
+			},
+		})
+		if err != nil {
+			klog.Fatalf("Could not initialize GPU memory provider: %v", err)
+		}
+		profiles, err := logic.ParseMIGProfiles(*gpuMIGProfiles)
+		if err != nil {
+			klog.Fatalf("Could not parse --gpu-mig-profiles: %v", err)
+		}
+		gpuRecommender = logic.NewGPURecommender(*gpuMemoryMargin, profiles)
+	default:
+		klog.Fatalf("Unsupported --gpu-memory-metric-source %q", *gpuMemoryMetricSource)
+	}
+
+	compactionConfig := checkpoint.CompactionConfig{
+		MinAge:         *checkpointCompactionMinAge,
+		BucketFactor:   *checkpointCompactionBucketFactor,
+		TailPercentile: *checkpointCompactionTailPercentile,
+	}
+	if compactionConfig.MinAge > 0 && compactionConfig.BucketFactor < 2 {
+		klog.Fatalf("--checkpoint-compaction-bucket-factor must be at least 2, got %d", compactionConfig.BucketFactor)
+	}
+	if compactionConfig.TailPercentile < 0 || compactionConfig.TailPercentile > 1 {
+		klog.Fatalf("--checkpoint-compaction-tail-percentile must be between 0 and 1, got %v", compactionConfig.TailPercentile)
+	}
+
+	var memoryBudget int64
+	if *modelMemoryBudget != "" {
+		budget, err := resource.ParseQuantity(*modelMemoryBudget)
+		if err != nil {
+			klog.Fatalf("Could not parse --model-memory-budget: %v", err)
+		}
+		memoryBudget = budget.Value()
+	}
+
+	podResourceRecommender := logic.CreatePodResourceRecommender()
+	// Held while the aggregations are updated, so that the recommender API reads a consistent state.
+	var clusterStateLock sync.RWMutex
 	recommender := routines.RecommenderFactory{
 		ClusterState:                 clusterState,
 		ClusterStateFeeder:           clusterStateFeeder,
 		ControllerFetcher:            controllerFetcher,
-		CheckpointWriter:             checkpoint.NewCheckpointWriter(clusterState, vpa_clientset.NewForConfigOrDie(config).AutoscalingV1()),
+		CheckpointWriter:             checkpoint.NewSpreadingCheckpointWriter(clusterState, vpa_clientset.NewForConfigOrDie(config).AutoscalingV1(), compactionConfig, *checkpointsSpreadInterval),
 		VpaClient:                    vpa_clientset.NewForConfigOrDie(config).AutoscalingV1(),
-		PodResourceRecommender:       logic.CreatePodResourceRecommender(),
+		PodResourceRecommender:       podResourceRecommender,
 		RecommendationPostProcessors: postProcessors,
+		GPUMemoryProvider:            gpuMemoryProvider,
+		GPURecommender:               gpuRecommender,
+		MemoryLimitRecommender:       logic.CreateMemoryLimitRecommender(),
 		CheckpointsGCInterval:        *checkpointsGCInterval,
 		UseCheckpoints:               useCheckpoints,
+		ModelMemoryBudget:            memoryBudget,
+		ClusterStateLock:             &clusterStateLock,
 	}.Make()
 
-	promQueryTimeout, err := time.ParseDuration(*queryTimeout)
-	if err != nil {
-		klog.Fatalf("Could not parse --prometheus-query-timeout as a time.Duration: %v", err)
-	}
-
 	if useCheckpoints {
 		recommender.GetClusterStateFeeder().InitFromCheckpoints()
 	} else {
@@ -211,9 +348,55 @@ func main() {
 		recommender.GetClusterStateFeeder().InitFromHistoryProvider(provider)
 	}
 
+	if *importModelFile != "" {
+		if err := importModel(clusterState, *importModelFile); err != nil {
+			klog.Fatalf("Could not import model from %s: %v", *importModelFile, err)
+		}
+	}
+
+	if *enableRecommendationAPI || *enableModelExport {
+		mux := http.NewServeMux()
+		if *enableRecommendationAPI {
+			mux.Handle(server.RecommendationPath, server.NewServer(clusterState, clusterStateLock.RLocker(), selectorFetcher, podResourceRecommender))
+		}
+		if *enableModelExport {
+			mux.Handle(server.ModelPath, server.NewModelExporter(clusterState, clusterStateLock.RLocker()))
+		}
+		go serveAPI(server.WithDelegatedAuth(mux, kubeClient))
+	}
+
 	ticker := time.Tick(*metricsFetcherInterval)
 	for range ticker {
 		recommender.RunOnce()
 		healthCheck.UpdateLastActivity()
 	}
 }
+
+// serveAPI serves the API of the recommender on --api-address.
+func serveAPI(handler http.Handler) {
+	var err error
+	if *apiTLSCertFile != "" {
+		err = http.ListenAndServeTLS(*apiAddress, *apiTLSCertFile, *apiTLSKeyFile, handler)
+	} else {
+		err = http.ListenAndServe(*apiAddress, handler)
+	}
+	klog.Fatalf("Failed to serve the recommender API: %v", err)
+}
+
+// importModel adds the aggregations of the model snapshot in the file to the cluster state.
+func importModel(clusterState *model.ClusterState, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	snapshot := &model.Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return err
+	}
+	imported, err := clusterState.ImportSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	klog.V(1).Infof("Imported %d aggregations of the model exported at %v", imported, snapshot.ExportTime.Time)
+	return nil
+}
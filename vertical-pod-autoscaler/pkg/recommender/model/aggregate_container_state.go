@@ -38,6 +38,7 @@ package model
 import (
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -56,6 +57,8 @@ const (
 	// version of the recommender binary can't initialize from the old checkpoint format or the
 	// previous version of the recommender binary can't initialize from the new checkpoint format.
 	SupportedCheckpointVersion = "v3"
+	// MaxRecentOOMKills is the number of most recent OOM kills remembered per aggregation.
+	MaxRecentOOMKills = 10
 )
 
 var (
@@ -81,6 +84,14 @@ type ContainerStateAggregator interface {
 	// GetUpdateMode returns the update mode of VPA controlling this aggregator,
 	// nil if aggregator is not autoscaled.
 	GetUpdateMode() *vpa_types.UpdateMode
+	// GetMemoryAggregationInterval returns the length of the interval for
+	// which a single memory usage peak is aggregated.
+	GetMemoryAggregationInterval() time.Duration
+	// RecordOOMKill records that a container was restarted after being OOM killed.
+	RecordOOMKill(timestamp time.Time)
+	// AddMemoryUsage records a memory usage sample of a container, which the
+	// adaptive memory aggregation interval follows.
+	AddMemoryUsage(usage ResourceAmount)
 }
 
 // AggregateContainerState holds input signals aggregated from a set of containers.
@@ -99,6 +110,10 @@ type AggregateContainerState struct {
 	LastSampleStart   time.Time
 	TotalSamplesCount int
 	CreationTime      time.Time
+	// GPUMemoryPeak is the peak GPU memory usage of all containers, as reported
+	// by the GPU memory usage source for its most recent observation window.
+	// It is not checkpointed.
+	GPUMemoryPeak ResourceAmount
 
 	// Following fields are needed to correctly report quality metrics
 	// for VPA. When we record a new sample in an AggregateContainerState
@@ -110,6 +125,37 @@ type AggregateContainerState struct {
 	UpdateMode          *vpa_types.UpdateMode
 	ScalingMode         *vpa_types.ContainerScalingMode
 	ControlledResources *[]ResourceName
+	// ControlledValues are the resource values controlled by VPA. Nil if not configured.
+	ControlledValues *vpa_types.ContainerControlledValues
+	// TargetCPUUtilization is the fraction of the CPU request that the
+	// container should use at its usage peaks. Nil if not configured.
+	TargetCPUUtilization *float64
+	// MemoryAggregationInterval overrides the global memory aggregation
+	// interval for this aggregation. Zero if not overridden.
+	MemoryAggregationInterval time.Duration
+	// MemoryUsageVolatility tracks the variation of the memory usage samples, which the
+	// memory aggregation interval adapts to, if enabled. It is not checkpointed.
+	MemoryUsageVolatility UsageVolatility
+	// RecentOOMKills holds the times of the most recent OOM kills of the
+	// containers, oldest first. It is not checkpointed.
+	RecentOOMKills []time.Time
+	// OOMRestartMarginFraction overrides the global memory margin added per
+	// recent OOM kill. Nil if not overridden.
+	OOMRestartMarginFraction *float64
+	// Baseline is the usage below which the containers are never recommended,
+	// even after long idle periods. Nil if the baseline floor is disabled.
+	// It is not checkpointed.
+	Baseline Resources
+	// CalendarBuckets splits the samples by days of the week, in addition to
+	// aggregating all of them. Nil if samples aren't split.
+	CalendarBuckets *CalendarBuckets
+	// BucketStates holds the samples aggregated per calendar bucket name.
+	// It is not checkpointed.
+	BucketStates map[string]*AggregateContainerState
+	// bucketsShed is true once the per calendar bucket aggregations were dropped
+	// to keep the model within its memory budget. No samples are split by
+	// calendar bucket afterwards.
+	bucketsShed bool
 }
 
 // GetLastRecommendation returns last recorded recommendation.
@@ -128,6 +174,35 @@ func (a *AggregateContainerState) GetUpdateMode() *vpa_types.UpdateMode {
 	return a.UpdateMode
 }
 
+// GetMemoryAggregationInterval returns the memory aggregation interval
+// requested by the VPA controlling this aggregator, the one adapted to the
+// volatility of the usage if enabled, or the global default.
+func (a *AggregateContainerState) GetMemoryAggregationInterval() time.Duration {
+	if a.MemoryAggregationInterval > 0 {
+		return a.MemoryAggregationInterval
+	}
+	config := GetAggregationsConfig()
+	if config.AdaptiveMemoryAggregationEnabled() && a.MemoryUsageVolatility.SamplesCount >= minAdaptiveSamplesCount {
+		return config.adaptiveMemoryAggregationInterval(a.MemoryUsageVolatility.CoefficientOfVariation())
+	}
+	return config.MemoryAggregationInterval
+}
+
+// RecordOOMKill records that a container was restarted after being OOM killed.
+// Only the MaxRecentOOMKills most recent OOM kills are kept.
+func (a *AggregateContainerState) RecordOOMKill(timestamp time.Time) {
+	a.RecentOOMKills = appendOOMKills(a.RecentOOMKills, timestamp)
+}
+
+func appendOOMKills(oomKills []time.Time, timestamps ...time.Time) []time.Time {
+	oomKills = append(oomKills, timestamps...)
+	sort.Slice(oomKills, func(i, j int) bool { return oomKills[i].Before(oomKills[j]) })
+	if len(oomKills) > MaxRecentOOMKills {
+		oomKills = oomKills[len(oomKills)-MaxRecentOOMKills:]
+	}
+	return oomKills
+}
+
 // GetScalingMode returns the container scaling mode of the container
 // represented byt his aggregator, nil if aggregator is not autoscaled.
 func (a *AggregateContainerState) GetScalingMode() *vpa_types.ContainerScalingMode {
@@ -151,6 +226,52 @@ func (a *AggregateContainerState) MarkNotAutoscaled() {
 	a.UpdateMode = nil
 	a.ScalingMode = nil
 	a.ControlledResources = nil
+	a.ControlledValues = nil
+	a.TargetCPUUtilization = nil
+	a.MemoryAggregationInterval = 0
+	a.SetCalendarBuckets(nil)
+}
+
+// SetCalendarBuckets updates the calendar buckets the samples are split by.
+// Samples aggregated per bucket so far are dropped if the buckets change.
+func (a *AggregateContainerState) SetCalendarBuckets(buckets *CalendarBuckets) {
+	if buckets.Equal(a.CalendarBuckets) {
+		return
+	}
+	a.CalendarBuckets = buckets
+	a.BucketStates = nil
+}
+
+// getOrCreateBucketState returns the aggregation of samples in the given calendar bucket.
+func (a *AggregateContainerState) getOrCreateBucketState(bucket string) *AggregateContainerState {
+	state, found := a.BucketStates[bucket]
+	if !found {
+		if a.BucketStates == nil {
+			a.BucketStates = make(map[string]*AggregateContainerState)
+		}
+		state = NewAggregateContainerState()
+		a.BucketStates[bucket] = state
+	}
+	return state
+}
+
+// ApplyCalendarBucket replaces the usage distributions with the ones aggregated
+// in the calendar bucket of the most recent sample, so that the recommendation
+// follows the usage expected in the current bucket. Nothing is replaced until
+// the bucket holds at least MinCalendarBucketSamples samples, the samples of
+// all buckets are used instead.
+func (a *AggregateContainerState) ApplyCalendarBucket(buckets *CalendarBuckets) {
+	if buckets == nil {
+		return
+	}
+	state, found := a.BucketStates[buckets.Bucket(a.LastSampleStart)]
+	if !found || state.TotalSamplesCount < MinCalendarBucketSamples {
+		return
+	}
+	a.AggregateCPUUsage = state.AggregateCPUUsage
+	a.AggregateMemoryPeaks = state.AggregateMemoryPeaks
+	a.FirstSampleStart = state.FirstSampleStart
+	a.TotalSamplesCount = state.TotalSamplesCount
 }
 
 // MergeContainerState merges two AggregateContainerStates.
@@ -166,6 +287,15 @@ func (a *AggregateContainerState) MergeContainerState(other *AggregateContainerS
 		a.LastSampleStart = other.LastSampleStart
 	}
 	a.TotalSamplesCount += other.TotalSamplesCount
+	if other.GPUMemoryPeak > a.GPUMemoryPeak {
+		a.GPUMemoryPeak = other.GPUMemoryPeak
+	}
+	if len(other.RecentOOMKills) > 0 {
+		a.RecentOOMKills = appendOOMKills(a.RecentOOMKills, other.RecentOOMKills...)
+	}
+	for bucket, state := range other.BucketStates {
+		a.getOrCreateBucketState(bucket).MergeContainerState(state)
+	}
 }
 
 // NewAggregateContainerState returns a new, empty AggregateContainerState.
@@ -188,6 +318,11 @@ func (a *AggregateContainerState) AddSample(sample *ContainerUsageSample) {
 	default:
 		panic(fmt.Sprintf("AddSample doesn't support resource '%s'", sample.Resource))
 	}
+	if a.CalendarBuckets != nil && !a.bucketsShed {
+		if bucket := a.CalendarBuckets.Bucket(sample.MeasureStart); bucket != "" {
+			a.getOrCreateBucketState(bucket).AddSample(sample)
+		}
+	}
 }
 
 // SubtractSample removes a single usage sample from an aggregation.
@@ -202,6 +337,11 @@ func (a *AggregateContainerState) SubtractSample(sample *ContainerUsageSample) {
 	default:
 		panic(fmt.Sprintf("SubtractSample doesn't support resource '%s'", sample.Resource))
 	}
+	if a.CalendarBuckets != nil {
+		if state, found := a.BucketStates[a.CalendarBuckets.Bucket(sample.MeasureStart)]; found {
+			state.SubtractSample(sample)
+		}
+	}
 }
 
 func (a *AggregateContainerState) addCPUSample(sample *ContainerUsageSample) {
@@ -221,6 +361,40 @@ func (a *AggregateContainerState) addCPUSample(sample *ContainerUsageSample) {
 	a.TotalSamplesCount++
 }
 
+// AddMemoryUsage records a memory usage sample in the memory usage volatility.
+func (a *AggregateContainerState) AddMemoryUsage(usage ResourceAmount) {
+	a.MemoryUsageVolatility.AddSample(float64(BytesFromMemoryAmount(usage)))
+}
+
+// UsageVolatility tracks the exponentially weighted mean and variance of usage samples.
+// Until maxVolatilitySamplesCount samples are added, all samples have the same weight.
+type UsageVolatility struct {
+	Mean         float64
+	Variance     float64
+	SamplesCount int
+}
+
+// maxVolatilitySamplesCount is the number of samples after which the weight of the
+// older samples used for the volatility starts decaying, about a day of samples.
+const maxVolatilitySamplesCount = 1440
+
+// AddSample adds a usage sample to the mean and variance.
+func (v *UsageVolatility) AddSample(usage float64) {
+	v.SamplesCount++
+	weight := 1.0 / float64(min(v.SamplesCount, maxVolatilitySamplesCount))
+	diff := usage - v.Mean
+	v.Mean += weight * diff
+	v.Variance = (1 - weight) * (v.Variance + weight*diff*diff)
+}
+
+// CoefficientOfVariation returns the standard deviation of the usage relative to its mean.
+func (v *UsageVolatility) CoefficientOfVariation() float64 {
+	if v.Mean <= 0 {
+		return 0
+	}
+	return math.Sqrt(v.Variance) / v.Mean
+}
+
 // SaveToCheckpoint serializes AggregateContainerState as VerticalPodAutoscalerCheckpointStatus.
 // The serialization may result in loss of precission of the histograms.
 func (a *AggregateContainerState) SaveToCheckpoint() (*vpa_types.VerticalPodAutoscalerCheckpointStatus, error) {
@@ -232,6 +406,30 @@ func (a *AggregateContainerState) SaveToCheckpoint() (*vpa_types.VerticalPodAuto
 	if err != nil {
 		return nil, err
 	}
+	var buckets map[string]vpa_types.CalendarBucketCheckpoint
+	for bucket, state := range a.BucketStates {
+		if state.isEmpty() {
+			continue
+		}
+		bucketMemory, err := state.AggregateMemoryPeaks.SaveToChekpoint()
+		if err != nil {
+			return nil, err
+		}
+		bucketCPU, err := state.AggregateCPUUsage.SaveToChekpoint()
+		if err != nil {
+			return nil, err
+		}
+		if buckets == nil {
+			buckets = make(map[string]vpa_types.CalendarBucketCheckpoint, len(a.BucketStates))
+		}
+		buckets[bucket] = vpa_types.CalendarBucketCheckpoint{
+			FirstSampleStart:  metav1.NewTime(state.FirstSampleStart),
+			LastSampleStart:   metav1.NewTime(state.LastSampleStart),
+			TotalSamplesCount: state.TotalSamplesCount,
+			MemoryHistogram:   *bucketMemory,
+			CPUHistogram:      *bucketCPU,
+		}
+	}
 	return &vpa_types.VerticalPodAutoscalerCheckpointStatus{
 		LastUpdateTime:    metav1.NewTime(time.Now()),
 		FirstSampleStart:  metav1.NewTime(a.FirstSampleStart),
@@ -239,6 +437,7 @@ func (a *AggregateContainerState) SaveToCheckpoint() (*vpa_types.VerticalPodAuto
 		TotalSamplesCount: a.TotalSamplesCount,
 		MemoryHistogram:   *memory,
 		CPUHistogram:      *cpu,
+		CalendarBuckets:   buckets,
 		Version:           SupportedCheckpointVersion,
 	}, nil
 }
@@ -260,6 +459,22 @@ func (a *AggregateContainerState) LoadFromCheckpoint(checkpoint *vpa_types.Verti
 	if err != nil {
 		return err
 	}
+	// Buckets are only restored if the samples are still split by them.
+	for bucket, bucketCheckpoint := range checkpoint.CalendarBuckets {
+		if a.CalendarBuckets == nil || !a.CalendarBuckets.Has(bucket) {
+			continue
+		}
+		state := a.getOrCreateBucketState(bucket)
+		state.TotalSamplesCount = bucketCheckpoint.TotalSamplesCount
+		state.FirstSampleStart = bucketCheckpoint.FirstSampleStart.Time
+		state.LastSampleStart = bucketCheckpoint.LastSampleStart.Time
+		if err := state.AggregateMemoryPeaks.LoadFromCheckpoint(&bucketCheckpoint.MemoryHistogram); err != nil {
+			return err
+		}
+		if err := state.AggregateCPUUsage.LoadFromCheckpoint(&bucketCheckpoint.CPUHistogram); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -287,6 +502,17 @@ func (a *AggregateContainerState) UpdateFromPolicy(resourcePolicy *vpa_types.Con
 	if resourcePolicy != nil && resourcePolicy.ControlledResources != nil {
 		a.ControlledResources = ResourceNamesApiToModel(*resourcePolicy.ControlledResources)
 	}
+	a.ControlledValues = nil
+	if resourcePolicy != nil {
+		a.ControlledValues = resourcePolicy.ControlledValues
+	}
+	a.TargetCPUUtilization = nil
+	if resourcePolicy != nil && resourcePolicy.TargetCPUUtilizationPercentage != nil {
+		utilization := float64(*resourcePolicy.TargetCPUUtilizationPercentage) / 100.0
+		if utilization > 0 && utilization <= 1 {
+			a.TargetCPUUtilization = &utilization
+		}
+	}
 }
 
 // AggregateStateByContainerName takes a set of AggregateContainerStates and merge them
@@ -350,6 +576,24 @@ func (p *ContainerStateAggregatorProxy) GetUpdateMode() *vpa_types.UpdateMode {
 	return aggregator.GetUpdateMode()
 }
 
+// GetMemoryAggregationInterval returns memory aggregation interval of the aggregator.
+func (p *ContainerStateAggregatorProxy) GetMemoryAggregationInterval() time.Duration {
+	aggregator := p.cluster.findOrCreateAggregateContainerState(p.containerID)
+	return aggregator.GetMemoryAggregationInterval()
+}
+
+// RecordOOMKill records an OOM kill in the aggregator.
+func (p *ContainerStateAggregatorProxy) RecordOOMKill(timestamp time.Time) {
+	aggregator := p.cluster.findOrCreateAggregateContainerState(p.containerID)
+	aggregator.RecordOOMKill(timestamp)
+}
+
+// AddMemoryUsage records a memory usage sample in the aggregator.
+func (p *ContainerStateAggregatorProxy) AddMemoryUsage(usage ResourceAmount) {
+	aggregator := p.cluster.findOrCreateAggregateContainerState(p.containerID)
+	aggregator.AddMemoryUsage(usage)
+}
+
 // GetScalingMode returns scaling mode of container represented by the aggregator.
 func (p *ContainerStateAggregatorProxy) GetScalingMode() *vpa_types.ContainerScalingMode {
 	aggregator := p.cluster.findOrCreateAggregateContainerState(p.containerID)
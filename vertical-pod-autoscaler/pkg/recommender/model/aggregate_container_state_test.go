@@ -294,3 +294,132 @@ func TestUpdateFromPolicyControlledResources(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateContainerStateCalendarBuckets(t *testing.T) {
+	buckets, err := ParseCalendarBuckets("weekday=Mon-Fri;weekend=Sat-Sun", time.UTC)
+	assert.NoError(t, err)
+	// 2024-01-05 is a Friday.
+	friday := time.Date(2024, time.January, 5, 12, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	cpuSample := func(cores float64, measureStart time.Time) *ContainerUsageSample {
+		return &ContainerUsageSample{MeasureStart: measureStart, Usage: CPUAmountFromCores(cores), Request: testRequest[ResourceCPU], Resource: ResourceCPU}
+	}
+
+	cs := NewAggregateContainerState()
+	cs.SetCalendarBuckets(buckets)
+	cs.AddSample(cpuSample(4.0, friday))
+	cs.AddSample(cpuSample(1.0, saturday))
+	memorySample := &ContainerUsageSample{MeasureStart: saturday, Usage: MemoryAmountFromBytes(1e9), Request: testRequest[ResourceMemory], Resource: ResourceMemory}
+	cs.AddSample(memorySample)
+	assert.Equal(t, 2, cs.TotalSamplesCount)
+	assert.Equal(t, 1, cs.BucketStates["weekday"].TotalSamplesCount)
+	assert.Equal(t, 1, cs.BucketStates["weekend"].TotalSamplesCount)
+	assert.False(t, cs.BucketStates["weekend"].AggregateMemoryPeaks.IsEmpty())
+	cs.SubtractSample(memorySample)
+	assert.True(t, cs.BucketStates["weekend"].AggregateMemoryPeaks.IsEmpty())
+
+	// Bucket states are merged together with the whole aggregation.
+	merged := NewAggregateContainerState()
+	merged.MergeContainerState(cs)
+	assert.Equal(t, 1, merged.BucketStates["weekday"].TotalSamplesCount)
+
+	// All samples are used until the bucket of the most recent sample has enough of them.
+	merged.ApplyCalendarBucket(buckets)
+	assert.Equal(t, 2, merged.TotalSamplesCount)
+	assert.Equal(t, friday, merged.FirstSampleStart)
+
+	for i := 1; i < MinCalendarBucketSamples; i++ {
+		cs.AddSample(cpuSample(1.0, saturday.Add(time.Duration(i)*time.Second)))
+	}
+	merged = NewAggregateContainerState()
+	merged.MergeContainerState(cs)
+	merged.ApplyCalendarBucket(buckets)
+	assert.Equal(t, MinCalendarBucketSamples, merged.TotalSamplesCount)
+	assert.Equal(t, saturday, merged.FirstSampleStart)
+	assert.InDelta(t, 1.0, merged.AggregateCPUUsage.Percentile(1.0), 0.1)
+
+	// Bucket states are checkpointed and restored while the buckets don't change.
+	checkpoint, err := cs.SaveToCheckpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, MinCalendarBucketSamples, checkpoint.CalendarBuckets["weekend"].TotalSamplesCount)
+	restored := NewAggregateContainerState()
+	restored.SetCalendarBuckets(buckets)
+	assert.NoError(t, restored.LoadFromCheckpoint(checkpoint))
+	assert.Equal(t, 1, restored.BucketStates["weekday"].TotalSamplesCount)
+	assert.Equal(t, MinCalendarBucketSamples, restored.BucketStates["weekend"].TotalSamplesCount)
+	assert.Equal(t, saturday, restored.BucketStates["weekend"].FirstSampleStart)
+	assert.False(t, restored.BucketStates["weekend"].AggregateCPUUsage.IsEmpty())
+	weekendOnly, err := ParseCalendarBuckets("weekend=Sat-Sun", time.UTC)
+	assert.NoError(t, err)
+	restored = NewAggregateContainerState()
+	restored.SetCalendarBuckets(weekendOnly)
+	assert.NoError(t, restored.LoadFromCheckpoint(checkpoint))
+	assert.NotContains(t, restored.BucketStates, "weekday")
+
+	// Changing the buckets drops the samples aggregated per bucket.
+	otherBuckets, err := ParseCalendarBuckets("weekend=Sat-Sun", time.UTC)
+	assert.NoError(t, err)
+	cs.SetCalendarBuckets(otherBuckets)
+	assert.Nil(t, cs.BucketStates)
+	cs.MarkNotAutoscaled()
+	assert.Nil(t, cs.CalendarBuckets)
+}
+
+func TestAggregateContainerStateAdaptiveMemoryAggregationInterval(t *testing.T) {
+	defaultConfig := GetAggregationsConfig()
+	config := *defaultConfig
+	config.MinAdaptiveMemoryAggregationInterval = time.Hour
+	config.MaxAdaptiveMemoryAggregationInterval = 48 * time.Hour
+	InitializeAggregationsConfig(&config)
+	defer InitializeAggregationsConfig(defaultConfig)
+
+	addSamples := func(cs *AggregateContainerState, gigabytes ...float64) {
+		for i := 0; i < minAdaptiveSamplesCount; i++ {
+			cs.AddMemoryUsage(MemoryAmountFromBytes(gigabytes[i%len(gigabytes)] * 1e9))
+		}
+	}
+
+	stable := NewAggregateContainerState()
+	stable.AddMemoryUsage(MemoryAmountFromBytes(1e9))
+	// Too few samples to adapt the interval.
+	assert.Equal(t, defaultConfig.MemoryAggregationInterval, stable.GetMemoryAggregationInterval())
+	addSamples(stable, 1.0, 1.05)
+	assert.Equal(t, 48*time.Hour, stable.GetMemoryAggregationInterval())
+
+	bursty := NewAggregateContainerState()
+	addSamples(bursty, 0.1, 0.1, 0.1, 4.0)
+	assert.Equal(t, time.Hour, bursty.GetMemoryAggregationInterval())
+
+	moderate := NewAggregateContainerState()
+	addSamples(moderate, 1.0, 2.0)
+	interval := moderate.GetMemoryAggregationInterval()
+	assert.Greater(t, interval, time.Hour)
+	assert.Less(t, interval, 48*time.Hour)
+
+	// CPU usage doesn't make the interval adapt.
+	cpuOnly := NewAggregateContainerState()
+	for i := 0; i < minAdaptiveSamplesCount; i++ {
+		cpuOnly.AddSample(&ContainerUsageSample{
+			MeasureStart: testTimestamp.Add(time.Duration(i) * time.Minute),
+			Usage:        CPUAmountFromCores([]float64{0.1, 4.0}[i%2]),
+			Request:      testRequest[ResourceCPU],
+			Resource:     ResourceCPU,
+		})
+	}
+	assert.Equal(t, defaultConfig.MemoryAggregationInterval, cpuOnly.GetMemoryAggregationInterval())
+
+	// An interval requested for the VPA takes precedence.
+	bursty.MemoryAggregationInterval = 2 * time.Hour
+	assert.Equal(t, 2*time.Hour, bursty.GetMemoryAggregationInterval())
+}
+
+func TestAdaptiveMemoryAggregationIntervalClamped(t *testing.T) {
+	config := &AggregationsConfig{
+		MinAdaptiveMemoryAggregationInterval: 90 * time.Second,
+		MaxAdaptiveMemoryAggregationInterval: 48 * time.Hour,
+	}
+	// Truncating to minutes doesn't take the interval below the minimum.
+	assert.Equal(t, 90*time.Second, config.adaptiveMemoryAggregationInterval(volatileUsageVariation))
+	assert.Equal(t, 90*time.Second, config.adaptiveMemoryAggregationInterval(volatileUsageVariation-0.001))
+	assert.Equal(t, 48*time.Hour, config.adaptiveMemoryAggregationInterval(stableUsageVariation))
+}
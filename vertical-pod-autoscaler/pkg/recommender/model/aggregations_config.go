@@ -17,6 +17,7 @@ limitations under the License.
 package model
 
 import (
+	"math"
 	"time"
 
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
@@ -55,6 +56,11 @@ type AggregationsConfig struct {
 	OOMBumpUpRatio float64
 	// OOMMinBumpUp specifies the minimal increase of memory when OOM occurred in bytes.
 	OOMMinBumpUp float64
+	// MinAdaptiveMemoryAggregationInterval and MaxAdaptiveMemoryAggregationInterval bound the
+	// memory aggregation interval adapted to the volatility of the usage of each aggregation:
+	// the more volatile the usage, the shorter the interval. Zero disables the adaptation.
+	MinAdaptiveMemoryAggregationInterval time.Duration
+	MaxAdaptiveMemoryAggregationInterval time.Duration
 }
 
 const (
@@ -79,6 +85,15 @@ const (
 	DefaultOOMBumpUpRatio float64 = 1.2 // Memory is increased by 20% after an OOMKill.
 	// DefaultOOMMinBumpUp is the default value for OOMMinBumpUp.
 	DefaultOOMMinBumpUp float64 = 100 * 1024 * 1024 // Memory is increased by at least 100MB after an OOMKill.
+	// stableUsageVariation is the coefficient of variation of usage samples at or below
+	// which the longest adaptive memory aggregation interval is used.
+	stableUsageVariation = 0.1
+	// volatileUsageVariation is the coefficient of variation of usage samples at or above
+	// which the shortest adaptive memory aggregation interval is used.
+	volatileUsageVariation = 1.0
+	// minAdaptiveSamplesCount is the number of memory samples an aggregation needs before its
+	// memory aggregation interval is adapted, about an hour of samples.
+	minAdaptiveSamplesCount = 60
 )
 
 // GetMemoryAggregationWindowLength returns the total length of the memory usage history aggregated by VPA.
@@ -86,6 +101,31 @@ func (a *AggregationsConfig) GetMemoryAggregationWindowLength() time.Duration {
 	return a.MemoryAggregationInterval * time.Duration(a.MemoryAggregationIntervalCount)
 }
 
+// AdaptiveMemoryAggregationEnabled returns true if memory aggregation intervals adapt to the usage volatility.
+func (a *AggregationsConfig) AdaptiveMemoryAggregationEnabled() bool {
+	return a.MinAdaptiveMemoryAggregationInterval > 0 && a.MaxAdaptiveMemoryAggregationInterval >= a.MinAdaptiveMemoryAggregationInterval
+}
+
+// adaptiveMemoryAggregationInterval returns the memory aggregation interval for usage with the given
+// coefficient of variation. Intervals between the bounds are interpolated geometrically, so that
+// every step of the variation shortens the interval by the same factor, and truncated to minutes
+// without getting below the shortest interval.
+func (a *AggregationsConfig) adaptiveMemoryAggregationInterval(variation float64) time.Duration {
+	minInterval, maxInterval := a.MinAdaptiveMemoryAggregationInterval, a.MaxAdaptiveMemoryAggregationInterval
+	if variation <= stableUsageVariation {
+		return maxInterval
+	}
+	if variation >= volatileUsageVariation {
+		return minInterval
+	}
+	fraction := (variation - stableUsageVariation) / (volatileUsageVariation - stableUsageVariation)
+	interval := time.Duration(float64(maxInterval) * math.Pow(float64(minInterval)/float64(maxInterval), fraction)).Truncate(time.Minute)
+	if interval < minInterval {
+		return minInterval
+	}
+	return interval
+}
+
 func (a *AggregationsConfig) cpuHistogramOptions() util.HistogramOptions {
 	// CPU histograms use exponential bucketing scheme with the smallest bucket
 	// size of 0.01 core, max of 1000.0 cores and the relative error of HistogramRelativeError.
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MinCalendarBucketSamples is the number of samples, one day worth of CPU
+// samples, a calendar bucket needs before recommendations are based on it.
+const MinCalendarBucketSamples = 24 * 60
+
+var weekdaysByName = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// CalendarBuckets assigns days of the week to named buckets, e.g. weekdays and
+// weekends, so that usage is aggregated separately for each bucket.
+type CalendarBuckets struct {
+	location *time.Location
+	// buckets holds the bucket name for each day of the week, empty if
+	// the day doesn't belong to any bucket.
+	buckets [7]string
+}
+
+// ParseCalendarBuckets parses calendar buckets from a spec of the form
+// "weekday=Mon-Fri;weekend=Sat,Sun". Days are given by their three letter
+// names, as lists or ranges, and can't belong to more than one bucket.
+// Days are determined in the given location.
+func ParseCalendarBuckets(spec string, location *time.Location) (*CalendarBuckets, error) {
+	result := &CalendarBuckets{location: location}
+	for _, bucketSpec := range strings.Split(spec, ";") {
+		name, days, found := strings.Cut(bucketSpec, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid bucket %q, expected <name>=<days>", bucketSpec)
+		}
+		for _, daysSpec := range strings.Split(days, ",") {
+			first, last, isRange := strings.Cut(daysSpec, "-")
+			firstDay, err := parseWeekday(first)
+			if err != nil {
+				return nil, err
+			}
+			lastDay := firstDay
+			if isRange {
+				if lastDay, err = parseWeekday(last); err != nil {
+					return nil, err
+				}
+			}
+			// Ranges can wrap around the end of the week, e.g. Fri-Mon.
+			for day := firstDay; ; day = (day + 1) % 7 {
+				if result.buckets[day] != "" {
+					return nil, fmt.Errorf("%v belongs to both %s and %s buckets", day, result.buckets[day], name)
+				}
+				result.buckets[day] = name
+				if day == lastDay {
+					break
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	day, found := weekdaysByName[strings.ToLower(strings.TrimSpace(name))]
+	if !found {
+		return 0, fmt.Errorf("unknown day of the week %q", name)
+	}
+	return day, nil
+}
+
+// Bucket returns the name of the bucket the given time belongs to, or an empty
+// string if its day doesn't belong to any bucket.
+func (c *CalendarBuckets) Bucket(t time.Time) string {
+	return c.buckets[t.In(c.location).Weekday()]
+}
+
+// Has returns true if some day of the week belongs to the bucket with the given name.
+func (c *CalendarBuckets) Has(bucket string) bool {
+	for _, name := range c.buckets {
+		if name != "" && name == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal returns true if both calendar buckets assign the days of the week the same way.
+func (c *CalendarBuckets) Equal(other *CalendarBuckets) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return c.buckets == other.buckets && c.location.String() == other.location.String()
+}
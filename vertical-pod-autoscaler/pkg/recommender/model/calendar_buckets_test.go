@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCalendarBuckets(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	monday := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		spec     string
+		expected [7]string
+	}{
+		{
+			spec:     "weekday=Mon-Fri;weekend=Sat-Sun",
+			expected: [7]string{"weekend", "weekday", "weekday", "weekday", "weekday", "weekday", "weekend"},
+		},
+		{
+			spec:     "weekend=sat,sun",
+			expected: [7]string{"weekend", "", "", "", "", "", "weekend"},
+		},
+		{
+			spec:     "long=Fri-Mon; short=Tue-Thu",
+			expected: [7]string{"long", "long", "short", "short", "short", "long", "long"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			buckets, err := ParseCalendarBuckets(tc.spec, time.UTC)
+			assert.NoError(t, err)
+			for day := 0; day < 7; day++ {
+				date := monday.AddDate(0, 0, day)
+				assert.Equal(t, tc.expected[date.Weekday()], buckets.Bucket(date), date.Weekday())
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "weekday", "=Mon", "weekday=Monday", "weekday=Mon-Fri;weekend=Fri-Sun"} {
+		_, err := ParseCalendarBuckets(spec, time.UTC)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCalendarBucketsTimeZone(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	buckets, err := ParseCalendarBuckets("weekday=Mon-Fri;weekend=Sat-Sun", location)
+	assert.NoError(t, err)
+	// Late Sunday in UTC is already Monday in Tokyo.
+	assert.Equal(t, "weekday", buckets.Bucket(time.Date(2024, time.January, 7, 20, 0, 0, 0, time.UTC)))
+
+	utcBuckets, err := ParseCalendarBuckets("weekday=Mon-Fri;weekend=Sat-Sun", time.UTC)
+	assert.NoError(t, err)
+	assert.False(t, buckets.Equal(utcBuckets))
+	sameBuckets, err := ParseCalendarBuckets("weekend=Sat,Sun;weekday=Mon-Fri", location)
+	assert.NoError(t, err)
+	assert.True(t, buckets.Equal(sameBuckets))
+}
@@ -94,6 +94,8 @@ type PodState struct {
 	Containers map[string]*ContainerState
 	// PodPhase describing current life cycle phase of the Pod.
 	Phase apiv1.PodPhase
+	// StaticCPU is true if the pod gets exclusive CPUs under the static CPU manager policy.
+	StaticCPU bool
 }
 
 // NewClusterState returns a new ClusterState with no pods.
@@ -224,6 +226,28 @@ func (cluster *ClusterState) AddSample(sample *ContainerUsageSampleWithKey) erro
 	return nil
 }
 
+// SetGPUMemoryPeaks replaces GPU memory usage peaks of all aggregations with
+// the maximum of the given per-container peaks. Containers unknown to the
+// ClusterState are ignored.
+func (cluster *ClusterState) SetGPUMemoryPeaks(peaks map[ContainerID]ResourceAmount) {
+	for _, aggregateContainerState := range cluster.aggregateStateMap {
+		aggregateContainerState.GPUMemoryPeak = 0
+	}
+	for containerID, peak := range peaks {
+		pod, podExists := cluster.Pods[containerID.PodID]
+		if !podExists {
+			continue
+		}
+		if _, containerExists := pod.Containers[containerID.ContainerName]; !containerExists {
+			continue
+		}
+		aggregateContainerState := cluster.findOrCreateAggregateContainerState(containerID)
+		if peak > aggregateContainerState.GPUMemoryPeak {
+			aggregateContainerState.GPUMemoryPeak = peak
+		}
+	}
+}
+
 // RecordOOM adds info regarding OOM event in the model as an artificial memory sample.
 func (cluster *ClusterState) RecordOOM(containerID ContainerID, timestamp time.Time, requestedMemory ResourceAmount) error {
 	pod, podExists := cluster.Pods[containerID.PodID]
@@ -248,6 +272,8 @@ func (cluster *ClusterState) RecordOOM(containerID ContainerID, timestamp time.T
 func (cluster *ClusterState) AddOrUpdateVpa(apiObject *vpa_types.VerticalPodAutoscaler, selector labels.Selector) error {
 	vpaID := VpaID{Namespace: apiObject.Namespace, VpaName: apiObject.Name}
 	annotationsMap := apiObject.Annotations
+	memoryAggregationInterval := vpaAnnotationsMap(annotationsMap).memoryAggregationInterval()
+	calendarBuckets := vpaAnnotationsMap(annotationsMap).calendarBuckets()
 	conditionsMap := make(vpaConditionsMap)
 	for _, condition := range apiObject.Status.Conditions {
 		conditionsMap[condition.Type] = condition
@@ -268,6 +294,8 @@ func (cluster *ClusterState) AddOrUpdateVpa(apiObject *vpa_types.VerticalPodAuto
 	}
 	if !vpaExists {
 		vpa = NewVpa(vpaID, selector, apiObject.CreationTimestamp.Time)
+		vpa.MemoryAggregationInterval = memoryAggregationInterval
+		vpa.CalendarBuckets = calendarBuckets
 		cluster.Vpas[vpaID] = vpa
 		for aggregationKey, aggregation := range cluster.aggregateStateMap {
 			vpa.UseAggregationIfMatching(aggregationKey, aggregation)
@@ -276,6 +304,11 @@ func (cluster *ClusterState) AddOrUpdateVpa(apiObject *vpa_types.VerticalPodAuto
 	}
 	vpa.TargetRef = apiObject.Spec.TargetRef
 	vpa.Annotations = annotationsMap
+	vpa.SetMemoryAggregationInterval(memoryAggregationInterval)
+	vpa.SetCalendarBuckets(calendarBuckets)
+	vpa.OOMRestartMarginFraction = vpaAnnotationsMap(annotationsMap).oomRestartMarginFraction()
+	vpa.BaselinePercentile = vpaAnnotationsMap(annotationsMap).baselinePercentile()
+	vpa.VariantLabels = apiObject.Spec.VariantLabels
 	vpa.Conditions = conditionsMap
 	vpa.Recommendation = currentRecommendation
 	vpa.SetUpdateMode(apiObject.Spec.UpdatePolicy)
@@ -452,6 +485,18 @@ func (cluster *ClusterState) GetMatchingPods(vpa *Vpa) []PodID {
 	return matchingPods
 }
 
+// HasStaticCPUPods returns true if any pod matching the VPA gets exclusive CPUs under
+// the static CPU manager policy and has labels matching the given selector.
+func (cluster *ClusterState) HasStaticCPUPods(vpa *Vpa, selector labels.Selector) bool {
+	for _, podID := range cluster.GetMatchingPods(vpa) {
+		pod := cluster.Pods[podID]
+		if pod.StaticCPU && selector.Matches(cluster.labelSetMap[pod.labelSetKey]) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetControllerForPodUnderVPA returns controller associated with given Pod. Returns nil if Pod is not controlled by a VPA object.
 func (cluster *ClusterState) GetControllerForPodUnderVPA(pod *PodState, controllerFetcher controllerfetcher.ControllerFetcher) *controllerfetcher.ControllerKeyWithAPIVersion {
 	controllingVPA := cluster.GetControllingVPA(pod)
@@ -481,6 +526,20 @@ func (cluster *ClusterState) GetControllingVPA(pod *PodState) *Vpa {
 	return nil
 }
 
+// AggregateStateByContainerNameMatching returns a map from container name to the aggregated
+// state of all containers with that name, belonging to pods in the given namespace matching
+// the selector. Unlike for VPAs, the aggregations aren't linked to anything, so the
+// recommendation can be computed for pods without a VPA.
+func (cluster *ClusterState) AggregateStateByContainerNameMatching(namespace string, selector labels.Selector) ContainerNameToAggregateStateMap {
+	matching := make(aggregateContainerStatesMap)
+	for key, state := range cluster.aggregateStateMap {
+		if key.Namespace() == namespace && selector.Matches(key.Labels()) {
+			matching[key] = state
+		}
+	}
+	return AggregateStateByContainerName(matching)
+}
+
 // Implementation of the AggregateStateKey interface. It can be used as a map key.
 type aggregateStateKey struct {
 	namespace     string
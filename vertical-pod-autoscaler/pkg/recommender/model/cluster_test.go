@@ -312,6 +312,70 @@ func TestClusterRecordOOM(t *testing.T) {
 	// Verify that OOM was aggregated into the aggregated stats.
 	aggregation := cluster.findOrCreateAggregateContainerState(testContainerID)
 	assert.NotEmpty(t, aggregation.AggregateMemoryPeaks)
+	assert.Equal(t, []time.Time{time.Unix(0, 0)}, aggregation.RecentOOMKills)
+}
+
+func TestOOMRestartMarginFractionAnnotation(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	cluster.AddOrUpdatePod(testPodID, testLabels, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(testContainerID, testRequest))
+	assert.NoError(t, cluster.RecordOOM(testContainerID, time.Unix(0, 0), ResourceAmount(10)))
+
+	vpa := addVpa(cluster, testVpaID, vpaAnnotationsMap{OOMRestartMarginFractionAnnotation: "0.25"}, testSelectorStr, testTargetRef)
+	state := vpa.AggregateStateByContainerName()[testContainerID.ContainerName]
+	assert.Equal(t, 0.25, *state.OOMRestartMarginFraction)
+	assert.Equal(t, []time.Time{time.Unix(0, 0)}, state.RecentOOMKills)
+
+	for _, value := range []string{"-1", "NaN", "many"} {
+		vpa = addVpa(cluster, testVpaID, vpaAnnotationsMap{OOMRestartMarginFractionAnnotation: value}, testSelectorStr, testTargetRef)
+		assert.Nil(t, vpa.AggregateStateByContainerName()[testContainerID.ContainerName].OOMRestartMarginFraction, value)
+	}
+}
+
+func TestBaselinePercentileAnnotation(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	cluster.AddOrUpdatePod(testPodID, testLabels, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(testContainerID, testRequest))
+	assert.NoError(t, cluster.AddSample(makeTestUsageSample()))
+
+	vpa := addVpa(cluster, testVpaID, vpaAnnotationsMap{}, testSelectorStr, testTargetRef)
+	assert.Nil(t, vpa.AggregateStateByContainerName()[testContainerID.ContainerName].Baseline)
+
+	vpa = addVpa(cluster, testVpaID, vpaAnnotationsMap{BaselinePercentileAnnotation: "0.1"}, testSelectorStr, testTargetRef)
+	assert.Equal(t, 0.1, *vpa.BaselinePercentile)
+	baseline := vpa.AggregateStateByContainerName()[testContainerID.ContainerName].Baseline
+	assert.NotZero(t, baseline[ResourceCPU])
+
+	for _, value := range []string{"-1", "1.5", "NaN", "low"} {
+		vpa = addVpa(cluster, testVpaID, vpaAnnotationsMap{BaselinePercentileAnnotation: value}, testSelectorStr, testTargetRef)
+		assert.Nil(t, vpa.BaselinePercentile, value)
+	}
+}
+
+func TestCalendarBucketsAnnotation(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	cluster.AddOrUpdatePod(testPodID, testLabels, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(testContainerID, testRequest))
+
+	vpa := addVpa(cluster, testVpaID, vpaAnnotationsMap{
+		CalendarBucketsAnnotation:  "weekday=Mon-Fri;weekend=Sat-Sun",
+		CalendarTimeZoneAnnotation: "America/New_York",
+	}, testSelectorStr, testTargetRef)
+	assert.NotNil(t, vpa.CalendarBuckets)
+	assert.NoError(t, cluster.AddSample(makeTestUsageSample()))
+	aggregation := cluster.findOrCreateAggregateContainerState(testContainerID)
+	assert.True(t, vpa.CalendarBuckets.Equal(aggregation.CalendarBuckets))
+	assert.Len(t, aggregation.BucketStates, 1)
+
+	for _, annotations := range []vpaAnnotationsMap{
+		{},
+		{CalendarBucketsAnnotation: "weekday=Mon-Someday"},
+		{CalendarBucketsAnnotation: "weekday=Mon-Fri", CalendarTimeZoneAnnotation: "Nowhere/Town"},
+	} {
+		vpa = addVpa(cluster, testVpaID, annotations, testSelectorStr, testTargetRef)
+		assert.Nil(t, vpa.CalendarBuckets, annotations)
+		assert.Nil(t, aggregation.CalendarBuckets, annotations)
+	}
 }
 
 // Verifies that AddSample and AddOrUpdateContainer methods return a proper
@@ -414,6 +478,38 @@ func TestUpdateAnnotations(t *testing.T) {
 	assert.Equal(t, vpa.Annotations, annotations)
 }
 
+// Creates a VPA and a matching pod and verifies that the memory aggregation
+// interval requested in the VPA annotations is propagated to the aggregation.
+func TestUpdateMemoryAggregationIntervalAnnotation(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	cluster.AddOrUpdatePod(testPodID, testLabels, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(testContainerID, testRequest))
+	aggregation := cluster.findOrCreateAggregateContainerState(testContainerID)
+	defaultInterval := GetAggregationsConfig().MemoryAggregationInterval
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "valid", value: "5m", expected: 5 * time.Minute},
+		{name: "unparsable", value: "fast", expected: defaultInterval},
+		{name: "below minimum", value: "10s", expected: defaultInterval},
+		{name: "not shorter than default", value: defaultInterval.String(), expected: defaultInterval},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			addVpa(cluster, testVpaID, vpaAnnotationsMap{MemoryAggregationIntervalAnnotation: tc.value}, testSelectorStr, testTargetRef)
+			assert.Equal(t, tc.expected, aggregation.GetMemoryAggregationInterval())
+		})
+	}
+
+	// Removing the annotation restores the default interval.
+	addVpa(cluster, testVpaID, vpaAnnotationsMap{MemoryAggregationIntervalAnnotation: "5m"}, testSelectorStr, testTargetRef)
+	addVpa(cluster, testVpaID, testAnnotations, testSelectorStr, testTargetRef)
+	assert.Equal(t, defaultInterval, aggregation.GetMemoryAggregationInterval())
+}
+
 // Creates a VPA and a matching pod, then change the VPA pod selector 3 times:
 // first such that it still matches the pod, then such that it no longer matches
 // the pod, finally such that it matches the pod again. Verifies that the links
@@ -926,3 +1022,21 @@ func TestVPAWithMatchingPods(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateStateByContainerNameMatching(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	addTestPod(cluster)
+	addTestContainer(t, cluster)
+	assert.NoError(t, cluster.AddSample(makeTestUsageSample()))
+	otherPodID := PodID{"namespace-2", "pod-1"}
+	cluster.AddOrUpdatePod(otherPodID, testLabels, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(ContainerID{otherPodID, "container-1"}, testRequest))
+
+	states := cluster.AggregateStateByContainerNameMatching("namespace-1", labels.SelectorFromSet(testLabels))
+	assert.Len(t, states, 1)
+	assert.Equal(t, 1, states["container-1"].TotalSamplesCount)
+	// The aggregation isn't linked to a VPA.
+	assert.False(t, cluster.findOrCreateAggregateContainerState(testContainerID).IsUnderVPA)
+
+	assert.Empty(t, cluster.AggregateStateByContainerNameMatching("namespace-1", labels.SelectorFromSet(map[string]string{"label-1": "other"})))
+}
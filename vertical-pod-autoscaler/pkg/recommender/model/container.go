@@ -47,6 +47,8 @@ type ContainerUsageSample struct {
 type ContainerState struct {
 	// Current request.
 	Request Resources
+	// StartTime is the time the container was last (re)started, zero if not known.
+	StartTime time.Time
 	// Start of the latest CPU usage sample that was aggregated.
 	LastCPUSampleStart time.Time
 	// Max memory usage observed in the current aggregation interval.
@@ -55,6 +57,9 @@ type ContainerState struct {
 	oomPeak ResourceAmount
 	// End time of the current memory aggregation interval (not inclusive).
 	WindowEnd time.Time
+	// Length of the current memory aggregation interval. It's fixed when the interval
+	// starts, so that an adaptive interval only changes at interval boundaries.
+	windowInterval time.Duration
 	// Start of the latest memory usage sample that was aggregated.
 	lastMemorySampleStart time.Time
 	// Aggregation to add usage samples to.
@@ -134,6 +139,9 @@ func (container *ContainerState) addMemorySample(sample *ContainerUsageSample, i
 		return false // Discard invalid or outdated samples.
 	}
 	container.lastMemorySampleStart = ts
+	if !isOOM {
+		container.aggregator.AddMemoryUsage(sample.Usage)
+	}
 	if container.WindowEnd.IsZero() { // This is the first sample.
 		container.WindowEnd = ts
 	}
@@ -158,9 +166,10 @@ func (container *ContainerState) addMemorySample(sample *ContainerUsageSample, i
 		}
 	} else {
 		// Shift the memory aggregation window to the next interval.
-		memoryAggregationInterval := GetAggregationsConfig().MemoryAggregationInterval
+		memoryAggregationInterval := container.aggregator.GetMemoryAggregationInterval()
 		shift := ts.Sub(container.WindowEnd).Truncate(memoryAggregationInterval) + memoryAggregationInterval
 		container.WindowEnd = container.WindowEnd.Add(shift)
+		container.windowInterval = memoryAggregationInterval
 		container.memoryPeak = 0
 		container.oomPeak = 0
 		addNewPeak = true
@@ -186,7 +195,7 @@ func (container *ContainerState) addMemorySample(sample *ContainerUsageSample, i
 // RecordOOM adds info regarding OOM event in the model as an artificial memory sample.
 func (container *ContainerState) RecordOOM(timestamp time.Time, requestedMemory ResourceAmount) error {
 	// Discard old OOM
-	if timestamp.Before(container.WindowEnd.Add(-1 * GetAggregationsConfig().MemoryAggregationInterval)) {
+	if timestamp.Before(container.WindowEnd.Add(-1 * container.windowInterval)) {
 		return fmt.Errorf("OOM event will be discarded - it is too old (%v)", timestamp)
 	}
 	// Get max of the request and the recent usage-based memory peak.
@@ -203,6 +212,7 @@ func (container *ContainerState) RecordOOM(timestamp time.Time, requestedMemory
 	if !container.addMemorySample(&oomMemorySample, true) {
 		return fmt.Errorf("adding OOM sample failed")
 	}
+	container.aggregator.RecordOOMKill(timestamp)
 	return nil
 }
 
@@ -194,3 +194,37 @@ func TestRecordOOMInNewWindow(t *testing.T) {
 	test.mockMemoryHistogram.On("AddSample", 2400.0*mb, 1.0, memoryAggregationWindowEnd)
 	assert.NoError(t, test.container.RecordOOM(testTimestamp.Add(2*memoryAggregationInterval), ResourceAmount(1000*mb)))
 }
+
+func TestMemoryAggregationIntervalOverride(t *testing.T) {
+	test := newContainerTest()
+	test.aggregateContainerState.MemoryAggregationInterval = time.Minute
+	memoryAggregationWindowEnd := testTimestamp.Add(time.Minute)
+
+	test.mockMemoryHistogram.On("AddSample", 1000.0*mb, 1.0, memoryAggregationWindowEnd)
+	assert.True(t, test.container.AddSample(newUsageSample(testTimestamp, 1000*mb, ResourceMemory)))
+
+	// A sample two minutes later starts a new peak instead of updating the current one.
+	memoryAggregationWindowEnd = memoryAggregationWindowEnd.Add(2 * time.Minute)
+	test.mockMemoryHistogram.On("AddSample", 500.0*mb, 1.0, memoryAggregationWindowEnd)
+	assert.True(t, test.container.AddSample(newUsageSample(testTimestamp.Add(2*time.Minute), 500*mb, ResourceMemory)))
+	test.mockMemoryHistogram.AssertExpectations(t)
+}
+
+func TestMemoryAggregationIntervalChangesAtWindowBoundary(t *testing.T) {
+	test := newContainerTest()
+	test.aggregateContainerState.MemoryAggregationInterval = time.Hour
+	memoryAggregationWindowEnd := testTimestamp.Add(time.Hour)
+
+	test.mockMemoryHistogram.On("AddSample", 1000.0*mb, 1.0, memoryAggregationWindowEnd)
+	assert.True(t, test.container.AddSample(newUsageSample(testTimestamp, 1000*mb, ResourceMemory)))
+
+	// The interval changes in the middle of the window: the current window keeps its length.
+	test.aggregateContainerState.MemoryAggregationInterval = 2 * time.Hour
+	assert.Error(t, test.container.RecordOOM(testTimestamp.Add(-30*time.Minute), ResourceAmount(1000*mb)))
+
+	// The next window uses the new interval.
+	memoryAggregationWindowEnd = memoryAggregationWindowEnd.Add(2 * time.Hour)
+	test.mockMemoryHistogram.On("AddSample", 500.0*mb, 1.0, memoryAggregationWindowEnd)
+	assert.True(t, test.container.AddSample(newUsageSample(testTimestamp.Add(time.Hour), 500*mb, ResourceMemory)))
+	test.mockMemoryHistogram.AssertExpectations(t)
+}
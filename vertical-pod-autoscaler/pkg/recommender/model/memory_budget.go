@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"sort"
+	"unsafe"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
+)
+
+// aggregateContainerStateKeyOverhead is the approximate memory used by the key of an
+// AggregateContainerState and its entries in the maps of the ClusterState and the VPAs.
+const aggregateContainerStateKeyOverhead = 256
+
+// MemoryBudgetResult summarizes the enforcement of the memory budget of the model.
+type MemoryBudgetResult struct {
+	// Footprint is the estimated memory used by the aggregations, in bytes,
+	// after enforcing the budget.
+	Footprint int64
+	// Downsampled is the number of aggregations whose per calendar bucket
+	// aggregations were dropped.
+	Downsampled int
+	// Shed is the number of aggregations dropped.
+	Shed int
+	// Live is the memory used by the aggregations of running containers, in bytes,
+	// if the footprint stays over the budget because they are never dropped.
+	Live int64
+}
+
+// footprint returns the memory used by the aggregation, in bytes, measured from the
+// histograms it holds.
+func (a *AggregateContainerState) footprint() int64 {
+	footprint := int64(unsafe.Sizeof(*a)) + aggregateContainerStateKeyOverhead +
+		util.MemoryFootprint(a.AggregateCPUUsage) + util.MemoryFootprint(a.AggregateMemoryPeaks)
+	for _, state := range a.BucketStates {
+		footprint += state.footprint()
+	}
+	return footprint
+}
+
+// lastUpdate returns the last time samples were added to the aggregation.
+func (a *AggregateContainerState) lastUpdate() int64 {
+	if a.LastSampleStart.IsZero() {
+		return a.CreationTime.UnixNano()
+	}
+	return a.LastSampleStart.UnixNano()
+}
+
+// budgetGroup holds the aggregations of a single VPA, or the ones not under any VPA,
+// least recently updated first.
+type budgetGroup struct {
+	entries   []budgetEntry
+	footprint int64
+	fairShare int64
+	next      int
+}
+
+type budgetEntry struct {
+	key   AggregateStateKey
+	state *AggregateContainerState
+}
+
+// EnforceMemoryBudget keeps the memory used by the aggregations within the given budget,
+// in bytes. Per calendar bucket aggregations are dropped first, and no longer collected
+// for these aggregations. Then whole aggregations without any running container are
+// dropped: the aggregations of running containers would be recreated empty by their next
+// sample, so they are kept even if the budget can't be met. Aggregations not under any VPA
+// go first, then the ones of the VPAs using more than an equal share of the budget, so that
+// VPAs matching many containers don't starve the others. Within a VPA, the least recently
+// updated aggregations go first. A budget of 0 disables enforcement.
+func (cluster *ClusterState) EnforceMemoryBudget(budget int64) MemoryBudgetResult {
+	groups := cluster.budgetGroups(budget)
+	result := MemoryBudgetResult{}
+	for _, group := range groups {
+		result.Footprint += group.footprint
+	}
+	if budget <= 0 || result.Footprint <= budget {
+		return result
+	}
+
+	for result.Footprint > budget {
+		group, entry := nextBudgetCandidate(groups, func(state *AggregateContainerState) bool {
+			return len(state.BucketStates) > 0
+		})
+		if group == nil {
+			break
+		}
+		state := entry.state
+		freed := state.footprint()
+		state.BucketStates = nil
+		state.bucketsShed = true
+		freed -= state.footprint()
+		group.footprint -= freed
+		result.Footprint -= freed
+		result.Downsampled++
+	}
+
+	for _, group := range groups {
+		group.next = 0
+	}
+	live := cluster.liveAggregateStates()
+	for result.Footprint > budget {
+		group, entry := nextBudgetCandidate(groups, func(state *AggregateContainerState) bool {
+			return !live[state]
+		})
+		if group == nil {
+			break
+		}
+		freed := entry.state.footprint()
+		delete(cluster.aggregateStateMap, entry.key)
+		for _, vpa := range cluster.Vpas {
+			vpa.DeleteAggregation(entry.key)
+		}
+		group.footprint -= freed
+		result.Footprint -= freed
+		result.Shed++
+	}
+	if result.Footprint > budget {
+		for state := range live {
+			result.Live += state.footprint()
+		}
+	}
+	return result
+}
+
+// liveAggregateStates returns the aggregations of the containers of running pods.
+func (cluster *ClusterState) liveAggregateStates() map[*AggregateContainerState]bool {
+	live := make(map[*AggregateContainerState]bool)
+	for _, pod := range cluster.Pods {
+		if pod.Phase == apiv1.PodSucceeded || pod.Phase == apiv1.PodFailed {
+			continue
+		}
+		for containerName := range pod.Containers {
+			if state, found := cluster.aggregateStateMap[cluster.MakeAggregateStateKey(pod, containerName)]; found {
+				live[state] = true
+			}
+		}
+	}
+	return live
+}
+
+// budgetGroups splits the aggregations by VPA. Aggregations matched by several VPAs
+// belong to the first one by ID. The first group holds the aggregations not under any VPA,
+// and has no share of the budget.
+func (cluster *ClusterState) budgetGroups(budget int64) []*budgetGroup {
+	owners := make(map[AggregateStateKey]VpaID)
+	for vpaID, vpa := range cluster.Vpas {
+		for key := range vpa.aggregateContainerStates {
+			if owner, found := owners[key]; !found || vpaIDLess(vpaID, owner) {
+				owners[key] = vpaID
+			}
+		}
+	}
+	unowned := &budgetGroup{}
+	groupsByVpa := make(map[VpaID]*budgetGroup)
+	for key, state := range cluster.aggregateStateMap {
+		group := unowned
+		if owner, found := owners[key]; found {
+			group = groupsByVpa[owner]
+			if group == nil {
+				group = &budgetGroup{}
+				groupsByVpa[owner] = group
+			}
+		}
+		group.entries = append(group.entries, budgetEntry{key: key, state: state})
+		group.footprint += state.footprint()
+	}
+
+	groups := []*budgetGroup{unowned}
+	for _, group := range groupsByVpa {
+		group.fairShare = budget / int64(len(groupsByVpa))
+		groups = append(groups, group)
+	}
+	for _, group := range groups {
+		entries := group.entries
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].state.lastUpdate() < entries[j].state.lastUpdate()
+		})
+	}
+	return groups
+}
+
+// nextBudgetCandidate returns the least recently updated aggregation accepted by the given
+// function in the group of the aggregations not under any VPA, the first one, or else in the
+// group using the most memory over its share of the budget.
+func nextBudgetCandidate(groups []*budgetGroup, accept func(*AggregateContainerState) bool) (*budgetGroup, budgetEntry) {
+	var selected *budgetGroup
+	for i, group := range groups {
+		for group.next < len(group.entries) && !accept(group.entries[group.next].state) {
+			group.next++
+		}
+		if group.next == len(group.entries) {
+			continue
+		}
+		if i == 0 {
+			selected = group
+			break
+		}
+		if selected == nil || group.footprint-group.fairShare > selected.footprint-selected.fairShare {
+			selected = group
+		}
+	}
+	if selected == nil {
+		return nil, budgetEntry{}
+	}
+	entry := selected.entries[selected.next]
+	selected.next++
+	return selected, entry
+}
+
+func vpaIDLess(a, b VpaID) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.VpaName < b.VpaName
+}
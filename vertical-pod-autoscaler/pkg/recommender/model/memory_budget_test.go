@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// addBudgetTestContainer adds a pod with a single container whose last sample was taken
+// at the given time, and returns the ID of the container.
+func addBudgetTestContainer(t *testing.T, cluster *ClusterState, app, name string, lastSample time.Time) ContainerID {
+	containerID := ContainerID{PodID{"namespace-1", name}, "container-1"}
+	cluster.AddOrUpdatePod(containerID.PodID, map[string]string{"app": app, "pod": name}, apiv1.PodRunning)
+	assert.NoError(t, cluster.AddOrUpdateContainer(containerID, testRequest))
+	assert.NoError(t, cluster.AddSample(&ContainerUsageSampleWithKey{ContainerUsageSample{
+		MeasureStart: lastSample,
+		Usage:        1.0,
+		Request:      testRequest[ResourceCPU],
+		Resource:     ResourceCPU},
+		containerID}))
+	return containerID
+}
+
+// finishBudgetTestPod marks the pod of the container added by addBudgetTestContainer as succeeded.
+func finishBudgetTestPod(cluster *ClusterState, containerID ContainerID, app string) {
+	cluster.AddOrUpdatePod(containerID.PodID, map[string]string{"app": app, "pod": containerID.PodID.PodName}, apiv1.PodSucceeded)
+}
+
+func hasAggregation(cluster *ClusterState, containerID ContainerID) bool {
+	_, found := cluster.aggregateStateMap[cluster.aggregateStateKeyForContainerID(containerID)]
+	return found
+}
+
+func TestEnforceMemoryBudgetSheds(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	vpaA := addVpa(cluster, VpaID{"namespace-1", "vpa-a"}, testAnnotations, "app = a", testTargetRef)
+	vpaB := addVpa(cluster, VpaID{"namespace-1", "vpa-b"}, testAnnotations, "app = b", testTargetRef)
+	a1 := addBudgetTestContainer(t, cluster, "a", "a1", testTimestamp)
+	a2 := addBudgetTestContainer(t, cluster, "a", "a2", testTimestamp.Add(time.Minute))
+	a3 := addBudgetTestContainer(t, cluster, "a", "a3", testTimestamp.Add(2*time.Minute))
+	b1 := addBudgetTestContainer(t, cluster, "b", "b1", testTimestamp.Add(-time.Hour))
+	c1 := addBudgetTestContainer(t, cluster, "c", "c1", testTimestamp.Add(3*time.Minute))
+	footprint := NewAggregateContainerState().footprint()
+	finishBudgetTestPod(cluster, a1, "a")
+	finishBudgetTestPod(cluster, a2, "a")
+	finishBudgetTestPod(cluster, b1, "b")
+	finishBudgetTestPod(cluster, c1, "c")
+
+	// Within the budget.
+	assert.Equal(t, MemoryBudgetResult{Footprint: 5 * footprint}, cluster.EnforceMemoryBudget(5*footprint))
+	assert.Equal(t, MemoryBudgetResult{Footprint: 5 * footprint}, cluster.EnforceMemoryBudget(0))
+
+	// The aggregation not under any VPA goes first, then the least recently updated
+	// one of the VPA using more than its share of the budget, even though the
+	// aggregation of the other VPA is older.
+	result := cluster.EnforceMemoryBudget(3 * footprint)
+	assert.Equal(t, MemoryBudgetResult{Footprint: 3 * footprint, Shed: 2}, result)
+	assert.False(t, hasAggregation(cluster, c1))
+	assert.False(t, hasAggregation(cluster, a1))
+	assert.True(t, hasAggregation(cluster, a2))
+	assert.True(t, hasAggregation(cluster, a3))
+	assert.True(t, hasAggregation(cluster, b1))
+	assert.Len(t, vpaA.aggregateContainerStates, 2)
+	assert.Len(t, vpaB.aggregateContainerStates, 1)
+
+	// The aggregations of running containers are kept even over the budget.
+	result = cluster.EnforceMemoryBudget(footprint)
+	assert.Equal(t, MemoryBudgetResult{Footprint: footprint, Shed: 2}, result)
+	assert.True(t, hasAggregation(cluster, a3))
+	result = cluster.EnforceMemoryBudget(footprint / 2)
+	assert.Equal(t, MemoryBudgetResult{Footprint: footprint, Live: footprint}, result)
+	assert.True(t, hasAggregation(cluster, a3))
+}
+
+func TestEnforceMemoryBudgetDownsamplesFirst(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	addVpa(cluster, testVpaID, vpaAnnotationsMap{CalendarBucketsAnnotation: "weekday=Mon-Fri;weekend=Sat-Sun"}, "app = a", testTargetRef)
+	a1 := addBudgetTestContainer(t, cluster, "a", "a1", testTimestamp)
+	a2 := addBudgetTestContainer(t, cluster, "a", "a2", testTimestamp.Add(time.Minute))
+	footprint := NewAggregateContainerState().footprint()
+	// Each aggregation holds the aggregation of its calendar bucket.
+	assert.Equal(t, MemoryBudgetResult{Footprint: 4 * footprint}, cluster.EnforceMemoryBudget(4*footprint))
+
+	result := cluster.EnforceMemoryBudget(3 * footprint)
+	assert.Equal(t, MemoryBudgetResult{Footprint: 3 * footprint, Downsampled: 1}, result)
+	assert.Empty(t, cluster.aggregateStateMap[cluster.aggregateStateKeyForContainerID(a1)].BucketStates)
+	assert.Len(t, cluster.aggregateStateMap[cluster.aggregateStateKeyForContainerID(a2)].BucketStates, 1)
+
+	// Samples are no longer split by calendar bucket once the buckets were dropped.
+	addBudgetTestContainer(t, cluster, "a", "a1", testTimestamp.Add(2*time.Minute))
+	assert.Empty(t, cluster.aggregateStateMap[cluster.aggregateStateKeyForContainerID(a1)].BucketStates)
+
+	finishBudgetTestPod(cluster, a1, "a")
+	result = cluster.EnforceMemoryBudget(footprint)
+	assert.Equal(t, MemoryBudgetResult{Footprint: footprint, Downsampled: 1, Shed: 1}, result)
+	assert.False(t, hasAggregation(cluster, a1))
+	assert.True(t, hasAggregation(cluster, a2))
+}
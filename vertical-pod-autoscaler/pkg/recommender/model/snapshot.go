@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// SupportedSnapshotVersion is the version of the model snapshots exported and imported by the recommender.
+const SupportedSnapshotVersion = "v1"
+
+// Snapshot is a portable dump of all the aggregations of the cluster state, used to
+// carry the model of a recommender over to the recommender of another cluster.
+type Snapshot struct {
+	// Version of the snapshot format.
+	Version string `json:"version"`
+	// ExportTime is the time the snapshot was taken.
+	ExportTime metav1.Time `json:"exportTime"`
+	// Aggregations are the non-empty aggregations of the cluster state.
+	Aggregations []AggregationSnapshot `json:"aggregations"`
+}
+
+// AggregationSnapshot is the state of the aggregation of the usage of the containers
+// of a given name, in the pods of a namespace with a given set of labels.
+type AggregationSnapshot struct {
+	Namespace     string            `json:"namespace"`
+	ContainerName string            `json:"containerName"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	// State holds the histograms of the aggregation, in the format of VPA checkpoints.
+	State vpa_types.VerticalPodAutoscalerCheckpointStatus `json:"state"`
+}
+
+// ExportSnapshot returns a snapshot of all non-empty aggregations of the cluster state.
+func (cluster *ClusterState) ExportSnapshot(now time.Time) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:      SupportedSnapshotVersion,
+		ExportTime:   metav1.NewTime(now),
+		Aggregations: []AggregationSnapshot{},
+	}
+	for key, aggregation := range cluster.aggregateStateMap {
+		if aggregation.isEmpty() {
+			continue
+		}
+		state, err := aggregation.SaveToCheckpoint()
+		if err != nil {
+			return nil, fmt.Errorf("cannot export the aggregation of container %s in namespace %s: %v", key.ContainerName(), key.Namespace(), err)
+		}
+		aggregationLabels := map[string]string{}
+		if labelSet, ok := key.Labels().(labels.Set); ok {
+			for name, value := range labelSet {
+				aggregationLabels[name] = value
+			}
+		}
+		snapshot.Aggregations = append(snapshot.Aggregations, AggregationSnapshot{
+			Namespace:     key.Namespace(),
+			ContainerName: key.ContainerName(),
+			Labels:        aggregationLabels,
+			State:         *state,
+		})
+	}
+	sort.Slice(snapshot.Aggregations, func(i, j int) bool {
+		a, b := snapshot.Aggregations[i], snapshot.Aggregations[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if la, lb := labels.Set(a.Labels).String(), labels.Set(b.Labels).String(); la != lb {
+			return la < lb
+		}
+		return a.ContainerName < b.ContainerName
+	})
+	return snapshot, nil
+}
+
+// ImportSnapshot adds the aggregations of the snapshot to the cluster state, merging them
+// into the existing aggregations of the same containers, and links them to the matching VPAs.
+// It returns the number of imported aggregations.
+func (cluster *ClusterState) ImportSnapshot(snapshot *Snapshot) (int, error) {
+	if snapshot.Version != SupportedSnapshotVersion {
+		return 0, fmt.Errorf("unsupported snapshot version %q", snapshot.Version)
+	}
+	imported := 0
+	for _, s := range snapshot.Aggregations {
+		aggregation := NewAggregateContainerState()
+		if err := aggregation.LoadFromCheckpoint(&s.State); err != nil {
+			return imported, fmt.Errorf("cannot import the aggregation of container %s in namespace %s: %v", s.ContainerName, s.Namespace, err)
+		}
+		key := aggregateStateKey{
+			namespace:     s.Namespace,
+			containerName: s.ContainerName,
+			labelSetKey:   cluster.getLabelSetKey(labels.Set(s.Labels)),
+			labelSetMap:   &cluster.labelSetMap,
+		}
+		if existing, found := cluster.aggregateStateMap[key]; found {
+			existing.MergeContainerState(aggregation)
+		} else {
+			cluster.aggregateStateMap[key] = aggregation
+			for _, vpa := range cluster.Vpas {
+				vpa.UseAggregationIfMatching(key, aggregation)
+			}
+		}
+		imported++
+	}
+	return imported, nil
+}
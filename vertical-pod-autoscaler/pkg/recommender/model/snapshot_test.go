@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestExportImportSnapshot(t *testing.T) {
+	source := NewClusterState(testGcPeriod)
+	addTestPod(source)
+	addTestContainer(t, source)
+	assert.NoError(t, source.AddSample(makeTestUsageSample()))
+	// Empty aggregations aren't exported.
+	source.AddOrUpdatePod(testPodID3, emptyLabels, apiv1.PodRunning)
+	assert.NoError(t, source.AddOrUpdateContainer(ContainerID{testPodID3, "container-1"}, testRequest))
+
+	snapshot, err := source.ExportSnapshot(testTimestamp)
+	assert.NoError(t, err)
+	assert.Equal(t, SupportedSnapshotVersion, snapshot.Version)
+	assert.Equal(t, testTimestamp, snapshot.ExportTime.Time)
+	if assert.Len(t, snapshot.Aggregations, 1) {
+		aggregation := snapshot.Aggregations[0]
+		assert.Equal(t, "namespace-1", aggregation.Namespace)
+		assert.Equal(t, "container-1", aggregation.ContainerName)
+		assert.Equal(t, testLabels, aggregation.Labels)
+		assert.Equal(t, 1, aggregation.State.TotalSamplesCount)
+	}
+
+	// The snapshot is carried over to another cluster as JSON.
+	data, err := json.Marshal(snapshot)
+	assert.NoError(t, err)
+	portable := &Snapshot{}
+	assert.NoError(t, json.Unmarshal(data, portable))
+
+	target := NewClusterState(testGcPeriod)
+	vpa := addTestVpa(target)
+	imported, err := target.ImportSnapshot(portable)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	assert.Equal(t, 1, target.StateMapSize())
+	states := vpa.AggregateStateByContainerName()
+	if assert.Contains(t, states, "container-1") {
+		assert.Equal(t, 1, states["container-1"].TotalSamplesCount)
+		assert.Equal(t, testTimestamp, states["container-1"].LastSampleStart.UTC())
+	}
+
+	// Pods of the target cluster with the same labels use the imported aggregation.
+	addTestPod(target)
+	addTestContainer(t, target)
+	assert.Equal(t, 1, target.StateMapSize())
+
+	// Importing again merges into the existing aggregation.
+	_, err = target.ImportSnapshot(portable)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, vpa.AggregateStateByContainerName()["container-1"].TotalSamplesCount)
+}
+
+func TestImportSnapshotUnsupportedVersion(t *testing.T) {
+	cluster := NewClusterState(testGcPeriod)
+	_, err := cluster.ImportSnapshot(&Snapshot{Version: "v0"})
+	assert.Error(t, err)
+
+	snapshot, err := cluster.ExportSnapshot(time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, snapshot.Aggregations)
+}
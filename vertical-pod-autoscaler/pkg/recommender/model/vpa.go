@@ -17,9 +17,13 @@ limitations under the License.
 package model
 
 import (
+	"math"
 	"sort"
+	"strconv"
 	"time"
 
+	"k8s.io/klog/v2"
+
 	autoscaling "k8s.io/api/autoscaling/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,6 +36,140 @@ import (
 // Map from VPA annotation key to value.
 type vpaAnnotationsMap map[string]string
 
+const (
+	// MemoryAggregationIntervalAnnotation is the VPA annotation requesting a shorter
+	// memory aggregation interval than the global one, e.g. "1m", for fast-changing workloads.
+	MemoryAggregationIntervalAnnotation = "vpa-recommender.kubernetes.io/memory-aggregation-interval"
+	// MinMemoryAggregationInterval is the shortest memory aggregation interval a VPA can request.
+	MinMemoryAggregationInterval = time.Minute
+	// OOMRestartMarginFractionAnnotation is the VPA annotation overriding the fraction
+	// of memory added to the recommendation per recent OOM kill, e.g. "0.1". "0" disables the margin.
+	OOMRestartMarginFractionAnnotation = "vpa-recommender.kubernetes.io/oom-restart-margin-fraction"
+	// BaselinePercentileAnnotation is the VPA annotation enabling a recommendation floor at
+	// the given percentile of usage, e.g. "0.1", remembered for the memory aggregation window.
+	BaselinePercentileAnnotation = "vpa-recommender.kubernetes.io/baseline-percentile"
+	// CalendarBucketsAnnotation is the VPA annotation enabling separate recommendations
+	// for buckets of days of the week, e.g. "weekday=Mon-Fri;weekend=Sat-Sun".
+	CalendarBucketsAnnotation = "vpa-recommender.kubernetes.io/calendar-buckets"
+	// CalendarTimeZoneAnnotation is the VPA annotation setting the time zone in which
+	// the days of the calendar buckets are determined, e.g. "Europe/Warsaw". Defaults to UTC.
+	CalendarTimeZoneAnnotation = "vpa-recommender.kubernetes.io/calendar-time-zone"
+)
+
+// memoryAggregationInterval returns the memory aggregation interval requested
+// in the annotations, or zero if none or an invalid one is requested.
+// Only intervals between MinMemoryAggregationInterval and the global interval are accepted.
+func (annotations vpaAnnotationsMap) memoryAggregationInterval() time.Duration {
+	value, found := annotations[MemoryAggregationIntervalAnnotation]
+	if !found {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		klog.Warningf("Ignoring invalid %s annotation %q: %v", MemoryAggregationIntervalAnnotation, value, err)
+		return 0
+	}
+	if interval < MinMemoryAggregationInterval || interval >= GetAggregationsConfig().MemoryAggregationInterval {
+		klog.Warningf("Ignoring %s annotation %q: interval must be at least %v and shorter than %v",
+			MemoryAggregationIntervalAnnotation, value, MinMemoryAggregationInterval, GetAggregationsConfig().MemoryAggregationInterval)
+		return 0
+	}
+	return interval
+}
+
+// oomRestartMarginFraction returns the memory margin fraction per recent OOM kill
+// requested in the annotations, or nil if none or an invalid one is requested.
+func (annotations vpaAnnotationsMap) oomRestartMarginFraction() *float64 {
+	value, found := annotations[OOMRestartMarginFractionAnnotation]
+	if !found {
+		return nil
+	}
+	fraction, err := strconv.ParseFloat(value, 64)
+	if err != nil || fraction < 0 || math.IsNaN(fraction) || math.IsInf(fraction, 0) {
+		klog.Warningf("Ignoring invalid %s annotation %q: must be a non-negative number", OOMRestartMarginFractionAnnotation, value)
+		return nil
+	}
+	return &fraction
+}
+
+// baselinePercentile returns the usage percentile used as the recommendation floor
+// requested in the annotations, or nil if none or an invalid one is requested.
+func (annotations vpaAnnotationsMap) baselinePercentile() *float64 {
+	value, found := annotations[BaselinePercentileAnnotation]
+	if !found {
+		return nil
+	}
+	percentile, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(percentile >= 0 && percentile <= 1) {
+		klog.Warningf("Ignoring invalid %s annotation %q: must be a number between 0 and 1", BaselinePercentileAnnotation, value)
+		return nil
+	}
+	return &percentile
+}
+
+// calendarBuckets returns the calendar buckets requested in the annotations,
+// or nil if none or invalid ones are requested.
+func (annotations vpaAnnotationsMap) calendarBuckets() *CalendarBuckets {
+	spec, found := annotations[CalendarBucketsAnnotation]
+	if !found {
+		return nil
+	}
+	location := time.UTC
+	if timeZone, found := annotations[CalendarTimeZoneAnnotation]; found {
+		var err error
+		if location, err = time.LoadLocation(timeZone); err != nil {
+			klog.Warningf("Ignoring invalid %s annotation %q: %v", CalendarTimeZoneAnnotation, timeZone, err)
+			return nil
+		}
+	}
+	buckets, err := ParseCalendarBuckets(spec, location)
+	if err != nil {
+		klog.Warningf("Ignoring invalid %s annotation %q: %v", CalendarBucketsAnnotation, spec, err)
+		return nil
+	}
+	return buckets
+}
+
+// baselineWindows remembers the highest baseline usage of a container in the
+// current and the previous window, so that each baseline is kept for at least
+// one full window.
+type baselineWindows struct {
+	start    time.Time
+	previous Resources
+	current  Resources
+}
+
+// update records the baseline observed at the given time and returns the
+// highest baseline remembered.
+func (b *baselineWindows) update(baseline Resources, now time.Time, window time.Duration) Resources {
+	if b.start.IsZero() {
+		b.start = now
+	}
+	if elapsed := now.Sub(b.start); elapsed >= window {
+		b.previous = b.current
+		if elapsed >= 2*window {
+			b.previous = nil
+		}
+		b.current = nil
+		b.start = now
+	}
+	b.current = maxResources(b.current, baseline)
+	return maxResources(b.previous, b.current)
+}
+
+func maxResources(a, b Resources) Resources {
+	result := make(Resources)
+	for resource, amount := range a {
+		result[resource] = amount
+	}
+	for resource, amount := range b {
+		if amount > result[resource] {
+			result[resource] = amount
+		}
+	}
+	return result
+}
+
 // Map from VPA condition type to condition.
 type vpaConditionsMap map[vpa_types.VerticalPodAutoscalerConditionType]vpa_types.VerticalPodAutoscalerCondition
 
@@ -110,6 +248,33 @@ type Vpa struct {
 	TargetRef *autoscaling.CrossVersionObjectReference
 	// PodCount contains number of live Pods matching a given VPA object.
 	PodCount int
+	// MemoryAggregationInterval overrides the global memory aggregation interval
+	// for aggregations under this VPA. Zero if not overridden.
+	MemoryAggregationInterval time.Duration
+	// OOMRestartMarginFraction overrides the global memory margin added per
+	// recent OOM kill for containers under this VPA. Nil if not overridden.
+	OOMRestartMarginFraction *float64
+	// BaselinePercentile is the usage percentile below which containers under
+	// this VPA are never recommended. Nil if the baseline floor is disabled.
+	BaselinePercentile *float64
+	// CalendarBuckets splits the aggregations under this VPA by days of the
+	// week. Nil if usage isn't aggregated per calendar bucket.
+	CalendarBuckets *CalendarBuckets
+	// VariantLabels are the keys of the pod labels splitting the pods matched
+	// by this VPA into variants recommended separately. Empty if not set.
+	VariantLabels []string
+	// baselines holds the baseline usage remembered per container name.
+	baselines map[string]*baselineWindows
+}
+
+// VariantAggregateStates is the aggregated state of the containers of the pods
+// of a variant.
+type VariantAggregateStates struct {
+	// Labels are the values of the variant labels of the pods of the variant.
+	Labels map[string]string
+	// AggregateStates maps container names to the aggregated state of the
+	// containers with that name.
+	AggregateStates ContainerNameToAggregateStateMap
 }
 
 // NewVpa returns a new Vpa with a given ID and pod selector. Doesn't set the
@@ -123,6 +288,7 @@ func NewVpa(id VpaID, selector labels.Selector, created time.Time) *Vpa {
 		Created:                         created,
 		Annotations:                     make(vpaAnnotationsMap),
 		Conditions:                      make(vpaConditionsMap),
+		baselines:                       make(map[string]*baselineWindows),
 		// APIVersion defaults to the version of the client used to read resources.
 		// If a new version is introduced that needs to be differentiated beyond the
 		// client conversion, this needs to be done based on the resource content.
@@ -155,6 +321,8 @@ func (vpa *Vpa) UseAggregationIfMatching(aggregationKey AggregateStateKey, aggre
 		vpa.aggregateContainerStates[aggregationKey] = aggregation
 		aggregation.IsUnderVPA = true
 		aggregation.UpdateMode = vpa.UpdateMode
+		aggregation.MemoryAggregationInterval = vpa.MemoryAggregationInterval
+		aggregation.SetCalendarBuckets(vpa.CalendarBuckets)
 		aggregation.UpdateFromPolicy(vpa_api_util.GetContainerResourcePolicy(aggregationKey.ContainerName(), vpa.ResourcePolicy))
 	}
 }
@@ -201,14 +369,89 @@ func (vpa *Vpa) MergeCheckpointedState(aggregateContainerStateMap ContainerNameT
 	}
 }
 
+// TotalSamplesCount returns the number of samples added to aggregations contributing to the VPA.
+func (vpa *Vpa) TotalSamplesCount() int {
+	count := 0
+	for _, state := range vpa.aggregateContainerStates {
+		count += state.TotalSamplesCount
+	}
+	return count
+}
+
 // AggregateStateByContainerName returns a map from container name to the aggregated state
 // of all containers with that name, belonging to pods matched by the VPA.
 func (vpa *Vpa) AggregateStateByContainerName() ContainerNameToAggregateStateMap {
 	containerNameToAggregateStateMap := AggregateStateByContainerName(vpa.aggregateContainerStates)
 	vpa.MergeCheckpointedState(containerNameToAggregateStateMap)
+	for _, aggregateContainerState := range containerNameToAggregateStateMap {
+		aggregateContainerState.OOMRestartMarginFraction = vpa.OOMRestartMarginFraction
+	}
+	vpa.updateBaselines(containerNameToAggregateStateMap)
 	return containerNameToAggregateStateMap
 }
 
+// AggregateStateByVariant returns the aggregated state of the containers of
+// each variant of the pods matched by the VPA, ordered by the labels of the
+// variants. Checkpoints and baselines are only kept for all the pods together,
+// so variants are only recommended from the usage observed since the
+// recommender started.
+func (vpa *Vpa) AggregateStateByVariant() []VariantAggregateStates {
+	if len(vpa.VariantLabels) == 0 {
+		return nil
+	}
+	variantAggregateContainerStates := make(map[string]aggregateContainerStatesMap)
+	variantLabels := make(map[string]map[string]string)
+	for aggregationKey, aggregation := range vpa.aggregateContainerStates {
+		labelValues := vpa_api_util.GetVariantLabels(vpa.VariantLabels, aggregationKey.Labels())
+		variant := labels.Set(labelValues).String()
+		if _, found := variantAggregateContainerStates[variant]; !found {
+			variantAggregateContainerStates[variant] = make(aggregateContainerStatesMap)
+			variantLabels[variant] = labelValues
+		}
+		variantAggregateContainerStates[variant][aggregationKey] = aggregation
+	}
+	variants := make([]string, 0, len(variantAggregateContainerStates))
+	for variant := range variantAggregateContainerStates {
+		variants = append(variants, variant)
+	}
+	sort.Strings(variants)
+	result := make([]VariantAggregateStates, 0, len(variants))
+	for _, variant := range variants {
+		containerNameToAggregateStateMap := AggregateStateByContainerName(variantAggregateContainerStates[variant])
+		for _, aggregateContainerState := range containerNameToAggregateStateMap {
+			aggregateContainerState.OOMRestartMarginFraction = vpa.OOMRestartMarginFraction
+		}
+		result = append(result, VariantAggregateStates{Labels: variantLabels[variant], AggregateStates: containerNameToAggregateStateMap})
+	}
+	return result
+}
+
+// updateBaselines records the baseline usage of each container and sets the
+// highest baseline remembered for the memory aggregation window as the
+// Baseline of its aggregation.
+func (vpa *Vpa) updateBaselines(containerNameToAggregateStateMap ContainerNameToAggregateStateMap) {
+	if vpa.BaselinePercentile == nil {
+		vpa.baselines = make(map[string]*baselineWindows)
+		return
+	}
+	window := GetAggregationsConfig().GetMemoryAggregationWindowLength()
+	for containerName, aggregateContainerState := range containerNameToAggregateStateMap {
+		if aggregateContainerState.TotalSamplesCount == 0 {
+			continue
+		}
+		baseline := Resources{
+			ResourceCPU:    CPUAmountFromCores(aggregateContainerState.AggregateCPUUsage.Percentile(*vpa.BaselinePercentile)),
+			ResourceMemory: MemoryAmountFromBytes(aggregateContainerState.AggregateMemoryPeaks.Percentile(*vpa.BaselinePercentile)),
+		}
+		windows, found := vpa.baselines[containerName]
+		if !found {
+			windows = &baselineWindows{}
+			vpa.baselines[containerName] = windows
+		}
+		aggregateContainerState.Baseline = windows.update(baseline, aggregateContainerState.LastSampleStart, window)
+	}
+}
+
 // HasRecommendation returns if the VPA object contains any recommendation
 func (vpa *Vpa) HasRecommendation() bool {
 	return (vpa.Recommendation != nil) && len(vpa.Recommendation.ContainerRecommendations) > 0
@@ -249,6 +492,29 @@ func (vpa *Vpa) SetUpdateMode(updatePolicy *vpa_types.PodUpdatePolicy) {
 	}
 }
 
+// SetMemoryAggregationInterval updates the memory aggregation interval of the VPA
+// and aggregators under this VPA. Zero restores the global interval.
+func (vpa *Vpa) SetMemoryAggregationInterval(interval time.Duration) {
+	if interval == vpa.MemoryAggregationInterval {
+		return
+	}
+	vpa.MemoryAggregationInterval = interval
+	for _, state := range vpa.aggregateContainerStates {
+		state.MemoryAggregationInterval = interval
+	}
+}
+
+// SetCalendarBuckets updates the calendar buckets of the VPA and aggregators under this VPA.
+func (vpa *Vpa) SetCalendarBuckets(buckets *CalendarBuckets) {
+	if buckets.Equal(vpa.CalendarBuckets) {
+		return
+	}
+	vpa.CalendarBuckets = buckets
+	for _, state := range vpa.aggregateContainerStates {
+		state.SetCalendarBuckets(buckets)
+	}
+}
+
 // UpdateConditions updates the conditions of VPA objects based on it's state.
 // PodsMatched is passed to indicate if there are currently active pods in the
 // cluster matching this VPA.
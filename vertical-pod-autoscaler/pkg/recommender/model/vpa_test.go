@@ -591,3 +591,42 @@ func (k mockAggregateStateKey) Labels() labels.Labels {
 	labels, _ := labels.ConvertSelectorToLabelsMap(k.labels)
 	return labels
 }
+
+func TestBaselineWindows(t *testing.T) {
+	window := 24 * time.Hour
+	high := Resources{ResourceCPU: 1000, ResourceMemory: 1000}
+	low := Resources{ResourceCPU: 100, ResourceMemory: 2000}
+	b := &baselineWindows{}
+
+	assert.Equal(t, high, b.update(high, anyTime, window))
+	// A lower baseline doesn't lower the remembered one, for each resource separately.
+	assert.Equal(t, Resources{ResourceCPU: 1000, ResourceMemory: 2000}, b.update(low, anyTime.Add(time.Hour), window))
+	// The baseline of the previous window is still remembered.
+	assert.Equal(t, Resources{ResourceCPU: 1000, ResourceMemory: 2000}, b.update(low, anyTime.Add(window+time.Hour), window))
+	// The previous window is forgotten after another window.
+	assert.Equal(t, low, b.update(low, anyTime.Add(2*window+2*time.Hour), window))
+	// Windows without any update are skipped.
+	assert.Equal(t, high, b.update(high, anyTime.Add(5*window), window))
+}
+
+func TestAggregateStateByVariant(t *testing.T) {
+	vpa := NewVpa(VpaID{Namespace: "ns", VpaName: "vpa"}, nil, anyTime)
+	assert.Nil(t, vpa.AggregateStateByVariant())
+
+	vpa.VariantLabels = []string{"size"}
+	for _, podLabels := range []string{"app=a,size=small,pod=1", "app=a,size=small,pod=2", "app=a,size=large", "app=a"} {
+		key, _ := testAggregation(vpa, "container", podLabels)
+		aggregation := NewAggregateContainerState()
+		aggregation.TotalSamplesCount = 1
+		vpa.aggregateContainerStates[key] = aggregation
+	}
+
+	variants := vpa.AggregateStateByVariant()
+	assert.Equal(t, 3, len(variants))
+	assert.Equal(t, map[string]string{}, variants[0].Labels)
+	assert.Equal(t, 1, variants[0].AggregateStates["container"].TotalSamplesCount)
+	assert.Equal(t, map[string]string{"size": "large"}, variants[1].Labels)
+	assert.Equal(t, 1, variants[1].AggregateStates["container"].TotalSamplesCount)
+	assert.Equal(t, map[string]string{"size": "small"}, variants[2].Labels)
+	assert.Equal(t, 2, variants[2].AggregateStates["container"].TotalSamplesCount)
+}
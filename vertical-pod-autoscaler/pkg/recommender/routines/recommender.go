@@ -19,13 +19,16 @@ package routines
 import (
 	"context"
 	"flag"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
 
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	vpa_api "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/typed/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/checkpoint"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/input/gpu"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/logic"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
@@ -66,6 +69,11 @@ type recommender struct {
 	useCheckpoints                bool
 	lastAggregateContainerStateGC time.Time
 	recommendationPostProcessor   []RecommendationPostProcessor
+	gpuMemoryProvider             gpu.MemoryProvider
+	gpuRecommender                logic.GPURecommender
+	memoryLimitRecommender        logic.MemoryLimitRecommender
+	modelMemoryBudget             int64
+	clusterStateLock              *sync.RWMutex
 }
 
 func (r *recommender) GetClusterState() *model.ClusterState {
@@ -91,13 +99,14 @@ func (r *recommender) UpdateVPAs() {
 		if !found {
 			continue
 		}
-		resources := r.podResourceRecommender.GetRecommendedPodResources(GetContainerNameToAggregateStateMap(vpa))
 		had := vpa.HasRecommendation()
-
-		listOfResourceRecommendation := logic.MapToListOfRecommendedContainerResources(resources)
-
-		for _, postProcessor := range r.recommendationPostProcessor {
-			listOfResourceRecommendation = postProcessor.Process(observedVpa, listOfResourceRecommendation)
+		listOfResourceRecommendation := r.recommend(observedVpa, GetContainerNameToAggregateStateMap(vpa))
+		for _, variant := range GetVariantAggregateStates(vpa) {
+			variantRecommendation := r.recommend(observedVpa, variant.AggregateStates)
+			listOfResourceRecommendation.Variants = append(listOfResourceRecommendation.Variants, vpa_types.RecommendedVariantResources{
+				Labels:                   variant.Labels,
+				ContainerRecommendations: variantRecommendation.ContainerRecommendations,
+			})
 		}
 
 		vpa.UpdateRecommendation(listOfResourceRecommendation)
@@ -131,6 +140,23 @@ func (r *recommender) UpdateVPAs() {
 	}
 }
 
+// recommend computes the recommendation for the containers with the given aggregated states.
+func (r *recommender) recommend(observedVpa *vpa_types.VerticalPodAutoscaler, aggregateStates model.ContainerNameToAggregateStateMap) *vpa_types.RecommendedPodResources {
+	resources := r.podResourceRecommender.GetRecommendedPodResources(aggregateStates)
+	listOfResourceRecommendation := logic.MapToListOfRecommendedContainerResources(resources)
+	if r.gpuRecommender != nil {
+		r.addGPURecommendations(listOfResourceRecommendation, aggregateStates)
+	}
+	if r.memoryLimitRecommender != nil {
+		r.addMemoryLimitRecommendations(listOfResourceRecommendation, aggregateStates)
+	}
+
+	for _, postProcessor := range r.recommendationPostProcessor {
+		listOfResourceRecommendation = postProcessor.Process(observedVpa, listOfResourceRecommendation)
+	}
+	return listOfResourceRecommendation
+}
+
 func (r *recommender) MaintainCheckpoints(ctx context.Context, minCheckpointsPerRun int) {
 	now := time.Now()
 	if r.useCheckpoints {
@@ -154,6 +180,7 @@ func (r *recommender) RunOnce() {
 
 	klog.V(3).Infof("Recommender Run")
 
+	r.lock()
 	r.clusterStateFeeder.LoadVPAs()
 	timer.ObserveStep("LoadVPAs")
 
@@ -164,15 +191,105 @@ func (r *recommender) RunOnce() {
 	timer.ObserveStep("LoadMetrics")
 	klog.V(3).Infof("ClusterState is tracking %v PodStates and %v VPAs", len(r.clusterState.Pods), len(r.clusterState.Vpas))
 
+	if r.gpuMemoryProvider != nil {
+		r.loadGPUMemoryPeaks()
+		timer.ObserveStep("LoadGPUMetrics")
+	}
+	r.unlock()
+
+	// Updating VPA objects and writing checkpoints only read the aggregations,
+	// so readers of the cluster state aren't blocked by the API calls.
+	r.rLock()
 	r.UpdateVPAs()
 	timer.ObserveStep("UpdateVPAs")
 
 	r.MaintainCheckpoints(ctx, *minCheckpointsPerRun)
 	timer.ObserveStep("MaintainCheckpoints")
+	r.rUnlock()
 
+	r.lock()
 	r.clusterState.RateLimitedGarbageCollectAggregateCollectionStates(time.Now(), r.controllerFetcher)
 	timer.ObserveStep("GarbageCollect")
+
+	if r.modelMemoryBudget > 0 {
+		r.enforceMemoryBudget()
+		timer.ObserveStep("EnforceMemoryBudget")
+	}
 	klog.V(3).Infof("ClusterState is tracking %d aggregated container states", r.clusterState.StateMapSize())
+	r.unlock()
+}
+
+// lock and unlock guard changes of the aggregations in the cluster state.
+func (r *recommender) lock() {
+	if r.clusterStateLock != nil {
+		r.clusterStateLock.Lock()
+	}
+}
+
+func (r *recommender) unlock() {
+	if r.clusterStateLock != nil {
+		r.clusterStateLock.Unlock()
+	}
+}
+
+// rLock and rUnlock guard reads of the aggregations in the cluster state.
+func (r *recommender) rLock() {
+	if r.clusterStateLock != nil {
+		r.clusterStateLock.RLock()
+	}
+}
+
+func (r *recommender) rUnlock() {
+	if r.clusterStateLock != nil {
+		r.clusterStateLock.RUnlock()
+	}
+}
+
+// enforceMemoryBudget sheds aggregate container states from the cluster state to keep
+// the memory used by the model within the budget.
+func (r *recommender) enforceMemoryBudget() {
+	result := r.clusterState.EnforceMemoryBudget(r.modelMemoryBudget)
+	metrics_recommender.RecordMemoryBudgetResult(result)
+	if result.Downsampled > 0 || result.Shed > 0 {
+		klog.Warningf("Model over its memory budget of %d bytes, downsampled %d and dropped %d aggregated container states, now using %d bytes",
+			r.modelMemoryBudget, result.Downsampled, result.Shed, result.Footprint)
+	}
+	if result.Footprint > r.modelMemoryBudget {
+		klog.Warningf("Model still over its memory budget of %d bytes, %d bytes are used by the aggregated container states of running containers",
+			r.modelMemoryBudget, result.Live)
+	}
+}
+
+// loadGPUMemoryPeaks feeds GPU memory peaks into the cluster state. On error
+// previously loaded peaks are kept.
+func (r *recommender) loadGPUMemoryPeaks() {
+	peaks, err := r.gpuMemoryProvider.GetGPUMemoryPeaks()
+	if err != nil {
+		klog.Errorf("Cannot get GPU memory usage. Reason: %+v", err)
+		return
+	}
+	r.clusterState.SetGPUMemoryPeaks(peaks)
+	klog.V(3).Infof("Loaded GPU memory peaks for %d containers", len(peaks))
+}
+
+func (r *recommender) addGPURecommendations(recommendation *vpa_types.RecommendedPodResources, aggregateStates model.ContainerNameToAggregateStateMap) {
+	for i, containerRecommendation := range recommendation.ContainerRecommendations {
+		state, found := aggregateStates[containerRecommendation.ContainerName]
+		if !found {
+			continue
+		}
+		recommendation.ContainerRecommendations[i].GPU = r.gpuRecommender.GetRecommendedGPUResources(state.GPUMemoryPeak)
+	}
+}
+
+func (r *recommender) addMemoryLimitRecommendations(recommendation *vpa_types.RecommendedPodResources, aggregateStates model.ContainerNameToAggregateStateMap) {
+	for i, containerRecommendation := range recommendation.ContainerRecommendations {
+		state, found := aggregateStates[containerRecommendation.ContainerName]
+		if !found {
+			continue
+		}
+		recommendation.ContainerRecommendations[i].MemoryLimit = r.memoryLimitRecommender.GetRecommendedMemoryLimit(state)
+	}
 }
 
 // RecommenderFactory makes instances of Recommender.
@@ -187,8 +304,26 @@ type RecommenderFactory struct {
 
 	RecommendationPostProcessors []RecommendationPostProcessor
 
+	// GPUMemoryProvider and GPURecommender are optional. When both are set
+	// recommendations include GPU memory sizing.
+	GPUMemoryProvider gpu.MemoryProvider
+	GPURecommender    logic.GPURecommender
+
+	// MemoryLimitRecommender is optional. When set recommendations include
+	// memory limits for containers with controlledValues MemoryLimitsOnly.
+	MemoryLimitRecommender logic.MemoryLimitRecommender
+
 	CheckpointsGCInterval time.Duration
 	UseCheckpoints        bool
+
+	// ModelMemoryBudget is the estimated memory, in bytes, the aggregate container
+	// states are kept within. Zero disables the budget.
+	ModelMemoryBudget int64
+
+	// ClusterStateLock is optional. When set it is held while the aggregations
+	// in ClusterState change, and read-locked while they are only read, so that
+	// other readers, like the recommendation API, see a consistent cluster state.
+	ClusterStateLock *sync.RWMutex
 }
 
 // Make creates a new recommender instance,
@@ -204,6 +339,11 @@ func (c RecommenderFactory) Make() Recommender {
 		vpaClient:                     c.VpaClient,
 		podResourceRecommender:        c.PodResourceRecommender,
 		recommendationPostProcessor:   c.RecommendationPostProcessors,
+		gpuMemoryProvider:             c.GPUMemoryProvider,
+		gpuRecommender:                c.GPURecommender,
+		memoryLimitRecommender:        c.MemoryLimitRecommender,
+		modelMemoryBudget:             c.ModelMemoryBudget,
+		clusterStateLock:              c.ClusterStateLock,
 		lastAggregateContainerStateGC: time.Now(),
 		lastCheckpointGC:              time.Now(),
 	}
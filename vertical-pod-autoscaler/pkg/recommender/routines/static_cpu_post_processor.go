@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routines
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+// StaticCPUPostProcessor rounds up CPU recommendations to whole CPUs for VPAs controlling pods
+// pinned to exclusive CPUs by the static CPU manager policy, i.e. pods in the Guaranteed QoS class
+// requesting whole CPUs. A fractional recommendation would move such pods to the shared CPU pool.
+// Requests and limits of these pods are equal, so they stay equal when limits are scaled
+// proportionally to the recommendation. A separate memory limit recommendation would make
+// the pod lose its Guaranteed QoS class, so it is dropped.
+type StaticCPUPostProcessor struct {
+	// ClusterState is used to find the pods controlled by the VPA.
+	ClusterState *model.ClusterState
+	// PodSelector limits the post processor to pods running on nodes with the static
+	// CPU manager policy, e.g. pods scheduled to a dedicated node pool.
+	PodSelector labels.Selector
+}
+
+var _ RecommendationPostProcessor = &StaticCPUPostProcessor{}
+
+// Process apply the static CPU post-processing to the recommendation.
+// For this post processor the CPU values of all containers are rounded up to an integer.
+func (p *StaticCPUPostProcessor) Process(vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources) *vpa_types.RecommendedPodResources {
+	if recommendation == nil {
+		return nil
+	}
+	vpaState, found := p.ClusterState.Vpas[model.VpaID{Namespace: vpa.Namespace, VpaName: vpa.Name}]
+	if !found || !p.ClusterState.HasStaticCPUPods(vpaState, p.PodSelector) {
+		return recommendation
+	}
+
+	amendedRecommendation := recommendation.DeepCopy()
+	for i, r := range amendedRecommendation.ContainerRecommendations {
+		amendedRecommendation.ContainerRecommendations[i].MemoryLimit = nil
+		setIntegerCPURecommendation(r.Target)
+		setIntegerCPURecommendation(r.LowerBound)
+		setIntegerCPURecommendation(r.UpperBound)
+		setIntegerCPURecommendation(r.UncappedTarget)
+	}
+	return amendedRecommendation
+}
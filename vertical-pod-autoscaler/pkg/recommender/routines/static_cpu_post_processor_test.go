@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routines
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestStaticCPUPostProcessor_Process(t *testing.T) {
+	recommendation := &vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			test.Recommendation().WithContainer("container1").WithTarget("1.2", "200Mi").WithLowerBound("0.5", "100Mi").GetContainerResources(),
+		},
+	}
+	memoryLimit := resource.MustParse("400Mi")
+	recommendation.ContainerRecommendations[0].MemoryLimit = &memoryLimit
+	rounded := &vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			test.Recommendation().WithContainer("container1").WithTarget("2", "200Mi").WithLowerBound("1", "100Mi").GetContainerResources(),
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		staticCPU   bool
+		podSelector string
+		want        *vpa_types.RecommendedPodResources
+	}{
+		"no static CPU pods": {
+			staticCPU: false,
+			want:      recommendation,
+		},
+		"static CPU pods": {
+			staticCPU: true,
+			want:      rounded,
+		},
+		"static CPU pods matching the selector": {
+			staticCPU:   true,
+			podSelector: "app=db",
+			want:        rounded,
+		},
+		"static CPU pods not matching the selector": {
+			staticCPU:   true,
+			podSelector: "cpu-manager-policy=static",
+			want:        recommendation,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			clusterState := model.NewClusterState(time.Minute)
+			vpa := &vpa_types.VerticalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vpa"}}
+			assert.NoError(t, clusterState.AddOrUpdateVpa(vpa, labels.SelectorFromSet(labels.Set{"app": "db"})))
+			podID := model.PodID{Namespace: "default", PodName: "db-0"}
+			clusterState.AddOrUpdatePod(podID, labels.Set{"app": "db"}, v1.PodRunning)
+			clusterState.Pods[podID].StaticCPU = tc.staticCPU
+			podSelector, err := labels.Parse(tc.podSelector)
+			assert.NoError(t, err)
+
+			p := StaticCPUPostProcessor{ClusterState: clusterState, PodSelector: podSelector}
+			got := p.Process(vpa, recommendation)
+			assert.True(t, equalRecommendedPodResources(tc.want, got), "Process(%v, %v)", vpa, recommendation)
+			if tc.want == rounded {
+				assert.Nil(t, got.ContainerRecommendations[0].MemoryLimit)
+			} else {
+				assert.Equal(t, &memoryLimit, got.ContainerRecommendations[0].MemoryLimit)
+			}
+		})
+	}
+}
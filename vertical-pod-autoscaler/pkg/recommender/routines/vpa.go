@@ -24,7 +24,22 @@ import (
 
 // GetContainerNameToAggregateStateMap returns ContainerNameToAggregateStateMap for pods.
 func GetContainerNameToAggregateStateMap(vpa *model.Vpa) model.ContainerNameToAggregateStateMap {
-	containerNameToAggregateStateMap := vpa.AggregateStateByContainerName()
+	return filterByResourcePolicy(vpa, vpa.AggregateStateByContainerName())
+}
+
+// GetVariantAggregateStates returns the VariantAggregateStates of each variant of pods,
+// if the VPA has variant labels.
+func GetVariantAggregateStates(vpa *model.Vpa) []model.VariantAggregateStates {
+	variants := vpa.AggregateStateByVariant()
+	for i := range variants {
+		variants[i].AggregateStates = filterByResourcePolicy(vpa, variants[i].AggregateStates)
+	}
+	return variants
+}
+
+// filterByResourcePolicy leaves out the containers with autoscaling disabled and applies
+// the resource policy of the VPA to the aggregated state of the other ones.
+func filterByResourcePolicy(vpa *model.Vpa, containerNameToAggregateStateMap model.ContainerNameToAggregateStateMap) model.ContainerNameToAggregateStateMap {
 	filteredContainerNameToAggregateStateMap := make(model.ContainerNameToAggregateStateMap)
 
 	for containerName, aggregatedContainerState := range containerNameToAggregateStateMap {
@@ -33,6 +48,7 @@ func GetContainerNameToAggregateStateMap(vpa *model.Vpa) model.ContainerNameToAg
 			*containerResourcePolicy.Mode == vpa_types.ContainerScalingModeOff
 		if !autoscalingDisabled {
 			aggregatedContainerState.UpdateFromPolicy(containerResourcePolicy)
+			aggregatedContainerState.ApplyCalendarBucket(vpa.CalendarBuckets)
 			filteredContainerNameToAggregateStateMap[containerName] = aggregatedContainerState
 		}
 	}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// delegatedAuth serves requests only to clients the API server allows to
+// access the request path, the way non-resource URLs like /metrics of the
+// API server are protected. Bearer tokens are authenticated with TokenReviews
+// and the access is checked with SubjectAccessReviews.
+type delegatedAuth struct {
+	handler http.Handler
+	client  kube_client.Interface
+}
+
+// WithDelegatedAuth wraps the handler with authentication and authorization
+// delegated to the API server. The caller needs RBAC permission for the
+// request path as a non-resource URL with the lowercase HTTP method as the verb,
+// e.g. "post" for RecommendationPath and "get" for ModelPath.
+func WithDelegatedAuth(handler http.Handler, client kube_client.Interface) http.Handler {
+	return &delegatedAuth{handler: handler, client: client}
+}
+
+// ServeHTTP authenticates and authorizes the request before serving it.
+func (d *delegatedAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		http.Error(w, "bearer token required", http.StatusUnauthorized)
+		return
+	}
+	tokenReview, err := d.client.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("Failed to authenticate request to %s: %v", r.URL.Path, err)
+		http.Error(w, "authentication failed", http.StatusInternalServerError)
+		return
+	}
+	if !tokenReview.Status.Authenticated {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	accessReview, err := d.client.AuthorizationV1().SubjectAccessReviews().Create(r.Context(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: r.URL.Path,
+				Verb: strings.ToLower(r.Method),
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("Failed to authorize request of %s to %s: %v", user.Username, r.URL.Path, err)
+		http.Error(w, "authorization failed", http.StatusInternalServerError)
+		return
+	}
+	if !accessReview.Status.Allowed {
+		klog.V(4).Infof("Denied %s request of %s to %s: %s", r.Method, user.Username, r.URL.Path, accessReview.Status.Reason)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	d.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestDelegatedAuth(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "valid" || review.Spec.Token == "other" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = review.Spec.Token + "-user"
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "valid-user" &&
+			review.Spec.NonResourceAttributes.Path == ModelPath &&
+			review.Spec.NonResourceAttributes.Verb == "get"
+		return true, review, nil
+	})
+	handler := WithDelegatedAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), client)
+
+	for desc, tc := range map[string]struct {
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		"no token":          {method: http.MethodGet, path: ModelPath, want: http.StatusUnauthorized},
+		"invalid token":     {method: http.MethodGet, path: ModelPath, token: "invalid", want: http.StatusUnauthorized},
+		"allowed":           {method: http.MethodGet, path: ModelPath, token: "valid", want: http.StatusOK},
+		"other user":        {method: http.MethodGet, path: ModelPath, token: "other", want: http.StatusForbidden},
+		"other path":        {method: http.MethodPost, path: RecommendationPath, token: "valid", want: http.StatusForbidden},
+		"other http method": {method: http.MethodPost, path: ModelPath, token: "valid", want: http.StatusForbidden},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(t, tc.want, recorder.Code)
+		})
+	}
+}
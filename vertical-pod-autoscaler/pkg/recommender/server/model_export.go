@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+// ModelPath is the path the snapshot of the model of the recommender is exported at.
+const ModelPath = "/apis/model"
+
+// ModelExporter serves snapshots of all the aggregations of the cluster state, to be
+// imported by the recommender of another cluster.
+type ModelExporter struct {
+	clusterState     *model.ClusterState
+	clusterStateLock sync.Locker
+}
+
+// NewModelExporter returns a ModelExporter reading the cluster state while holding
+// clusterStateLock, the read lock of the lock the recommender holds while it updates
+// the cluster state.
+func NewModelExporter(clusterState *model.ClusterState, clusterStateLock sync.Locker) *ModelExporter {
+	return &ModelExporter{
+		clusterState:     clusterState,
+		clusterStateLock: clusterStateLock,
+	}
+}
+
+// ServeHTTP serves GET requests with a model.Snapshot.
+func (e *ModelExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	e.clusterStateLock.Lock()
+	snapshot, err := e.clusterState.ExportSnapshot(time.Now())
+	e.clusterStateLock.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		klog.Errorf("Failed to write model snapshot: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+)
+
+func TestModelExporter(t *testing.T) {
+	exporter := NewModelExporter(newTestClusterState(t), &sync.Mutex{})
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ModelPath, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	snapshot := &model.Snapshot{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), snapshot))
+	assert.Equal(t, model.SupportedSnapshotVersion, snapshot.Version)
+	if assert.Len(t, snapshot.Aggregations, 2) {
+		assert.Equal(t, "app", snapshot.Aggregations[0].ContainerName)
+		assert.Equal(t, "sidecar", snapshot.Aggregations[1].ContainerName)
+		assert.Equal(t, testLabels, snapshot.Aggregations[0].Labels)
+	}
+
+	recorder = httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ModelPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
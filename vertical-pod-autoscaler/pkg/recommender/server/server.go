@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server implements the read-only API of the recommender serving
+// on-demand recommendations for pods and workloads, even without a VPA object,
+// and snapshots of its model.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	autoscaling "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/logic"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target"
+)
+
+// RecommendationPath is the path the recommendation API is served at.
+const RecommendationPath = "/apis/recommendation"
+
+// maxRequestBytes limits the size of the request bodies.
+const maxRequestBytes = 1 << 20
+
+// RecommendationRequest asks for the recommendation for the pods of a workload,
+// or for a pod. Exactly one of TargetRef and Pod must be set.
+type RecommendationRequest struct {
+	// Namespace of the workload or pod.
+	Namespace string `json:"namespace"`
+	// TargetRef refers to the workload, like the targetRef of a VPA object.
+	TargetRef *autoscaling.CrossVersionObjectReference `json:"targetRef,omitempty"`
+	// Pod is the pod spec, e.g. the pod template of a workload. The recommendation
+	// is computed from the usage of the containers of the pods matching its labels.
+	Pod *apiv1.PodTemplateSpec `json:"pod,omitempty"`
+}
+
+// RecommendationResponse is the recommendation computed from the model of the recommender.
+// The recommendation isn't capped, as no resource policy applies.
+type RecommendationResponse struct {
+	// Recommendation for the containers with usage samples. Empty if the usage
+	// of the containers isn't known to the recommender.
+	Recommendation *vpa_types.RecommendedPodResources `json:"recommendation"`
+}
+
+// Server serves recommendations computed from the cluster state of the recommender.
+type Server struct {
+	clusterState           *model.ClusterState
+	clusterStateLock       sync.Locker
+	selectorFetcher        target.VpaTargetSelectorFetcher
+	podResourceRecommender logic.PodResourceRecommender
+}
+
+// NewServer returns a Server reading the cluster state while holding clusterStateLock,
+// the read lock of the lock the recommender holds while it updates the cluster state.
+func NewServer(clusterState *model.ClusterState, clusterStateLock sync.Locker, selectorFetcher target.VpaTargetSelectorFetcher, podResourceRecommender logic.PodResourceRecommender) *Server {
+	return &Server{
+		clusterState:           clusterState,
+		clusterStateLock:       clusterStateLock,
+		selectorFetcher:        selectorFetcher,
+		podResourceRecommender: podResourceRecommender,
+	}
+}
+
+// ServeHTTP serves POST requests with a RecommendationRequest body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	var request RecommendationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	response, err := s.Recommend(&request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		klog.Errorf("Failed to write recommendation response: %v", err)
+	}
+}
+
+// Recommend computes the recommendation for the request.
+func (s *Server) Recommend(request *RecommendationRequest) (*RecommendationResponse, error) {
+	if request.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if (request.TargetRef == nil) == (request.Pod == nil) {
+		return nil, fmt.Errorf("exactly one of targetRef and pod is required")
+	}
+
+	var selector labels.Selector
+	containerNames := map[string]bool{}
+	if request.TargetRef != nil {
+		// The selector of a workload is fetched the same way as for a VPA object pointing at it.
+		vpa := &vpa_types.VerticalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: request.Namespace},
+			Spec:       vpa_types.VerticalPodAutoscalerSpec{TargetRef: request.TargetRef},
+		}
+		var err error
+		selector, err = s.selectorFetcher.Fetch(vpa)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get the pod selector of %s %s/%s: %v", request.TargetRef.Kind, request.Namespace, request.TargetRef.Name, err)
+		}
+	} else {
+		if len(request.Pod.Labels) == 0 {
+			return nil, fmt.Errorf("pod labels are required to match the pods the usage is known for")
+		}
+		selector = labels.SelectorFromSet(request.Pod.Labels)
+		for _, container := range request.Pod.Spec.Containers {
+			containerNames[container.Name] = true
+		}
+	}
+
+	s.clusterStateLock.Lock()
+	states := s.clusterState.AggregateStateByContainerNameMatching(request.Namespace, selector)
+	for containerName := range states {
+		if len(containerNames) > 0 && !containerNames[containerName] {
+			delete(states, containerName)
+		}
+	}
+	resources := s.podResourceRecommender.GetRecommendedPodResources(states)
+	s.clusterStateLock.Unlock()
+
+	return &RecommendationResponse{Recommendation: logic.MapToListOfRecommendedContainerResources(resources)}, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/logic"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+)
+
+var testLabels = map[string]string{"app": "web"}
+
+func newTestClusterState(t *testing.T) *model.ClusterState {
+	cluster := model.NewClusterState(time.Hour)
+	podID := model.PodID{Namespace: "default", PodName: "web-1"}
+	cluster.AddOrUpdatePod(podID, testLabels, apiv1.PodRunning)
+	for _, containerName := range []string{"app", "sidecar"} {
+		containerID := model.ContainerID{PodID: podID, ContainerName: containerName}
+		assert.NoError(t, cluster.AddOrUpdateContainer(containerID, model.Resources{model.ResourceCPU: model.CPUAmountFromCores(1)}))
+		assert.NoError(t, cluster.AddSample(&model.ContainerUsageSampleWithKey{
+			ContainerUsageSample: model.ContainerUsageSample{
+				MeasureStart: time.Now(),
+				Usage:        model.CPUAmountFromCores(0.5),
+				Request:      model.CPUAmountFromCores(1),
+				Resource:     model.ResourceCPU,
+			},
+			Container: containerID,
+		}))
+	}
+	return cluster
+}
+
+func TestRecommend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	targetRef := &autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}
+	selectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	selectorFetcher.EXPECT().Fetch(gomock.Any()).Return(labels.SelectorFromSet(testLabels), nil)
+	server := NewServer(newTestClusterState(t), &sync.Mutex{}, selectorFetcher, logic.CreatePodResourceRecommender())
+
+	testCases := []struct {
+		name           string
+		request        RecommendationRequest
+		wantContainers []string
+		wantErr        bool
+	}{
+		{
+			name:           "workload",
+			request:        RecommendationRequest{Namespace: "default", TargetRef: targetRef},
+			wantContainers: []string{"app", "sidecar"},
+		},
+		{
+			name: "pod",
+			request: RecommendationRequest{Namespace: "default", Pod: &apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: testLabels},
+				Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "app"}}},
+			}},
+			wantContainers: []string{"app"},
+		},
+		{
+			name: "pod in other namespace",
+			request: RecommendationRequest{Namespace: "other", Pod: &apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: testLabels},
+			}},
+			wantContainers: []string{},
+		},
+		{
+			name:    "missing namespace",
+			request: RecommendationRequest{TargetRef: targetRef},
+			wantErr: true,
+		},
+		{
+			name:    "missing target",
+			request: RecommendationRequest{Namespace: "default"},
+			wantErr: true,
+		},
+		{
+			name:    "pod without labels",
+			request: RecommendationRequest{Namespace: "default", Pod: &apiv1.PodTemplateSpec{}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := server.Recommend(&tc.request)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			containers := []string{}
+			for _, recommendation := range response.Recommendation.ContainerRecommendations {
+				containers = append(containers, recommendation.ContainerName)
+				assert.False(t, recommendation.Target.Cpu().IsZero())
+			}
+			assert.Equal(t, tc.wantContainers, containers)
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	server := NewServer(newTestClusterState(t), &sync.Mutex{}, nil, logic.CreatePodResourceRecommender())
+
+	request := httptest.NewRequest(http.MethodPost, RecommendationPath,
+		strings.NewReader(`{"namespace": "default", "pod": {"metadata": {"labels": {"app": "web"}}}}`))
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response RecommendationResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Len(t, response.Recommendation.ContainerRecommendations, 2)
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, RecommendationPath, strings.NewReader(`{`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, RecommendationPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unsafe"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 )
@@ -294,3 +295,15 @@ func (h *histogram) scale(factor float64) {
 	// Some buckets might become empty (weight < epsilon), so adjust min and max buckets.
 	h.updateMinAndMaxBucket()
 }
+
+// MemoryFootprint returns the memory used by the histogram, in bytes, measured from the
+// buckets it allocated. It returns 0 for implementations it doesn't know about.
+func MemoryFootprint(h Histogram) int64 {
+	switch h := h.(type) {
+	case *histogram:
+		return int64(unsafe.Sizeof(*h)) + int64(cap(h.bucketWeight))*int64(unsafe.Sizeof(float64(0)))
+	case *decayingHistogram:
+		return int64(unsafe.Sizeof(*h)) + int64(cap(h.bucketWeight))*int64(unsafe.Sizeof(float64(0)))
+	}
+	return 0
+}
@@ -25,6 +25,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
@@ -61,6 +62,18 @@ type singleGroupStats struct {
 	running           int
 	evictionTolerance int
 	evicted           int
+	// batch holds the state of batch eviction of pods of a Deployment or of a
+	// StatefulSet with ordered pod management. Nil if pods aren't evicted in batches.
+	batch *batchStats
+}
+
+// batchStats holds the state of a replica set owned by a Deployment, whose pods
+// are evicted in batches sized by the maxUnavailable of the Deployment, or of a
+// StatefulSet with ordered pod management, whose pods are evicted one at a time.
+type batchStats struct {
+	size       int
+	ready      int
+	rollingOut bool
 }
 
 // PodsEvictionRestrictionFactory creates PodsEvictionRestriction
@@ -76,6 +89,7 @@ type podsEvictionRestrictionFactoryImpl struct {
 	ssInformer                cache.SharedIndexInformer // informer for Stateful Sets
 	rsInformer                cache.SharedIndexInformer // informer for Replica Sets
 	dsInformer                cache.SharedIndexInformer // informer for Daemon Sets
+	deployInformer            cache.SharedIndexInformer // informer for Deployments, nil without batch eviction
 	minReplicas               int
 	evictionToleranceFraction float64
 }
@@ -88,6 +102,7 @@ const (
 	replicaSet            controllerKind = "ReplicaSet"
 	daemonSet             controllerKind = "DaemonSet"
 	job                   controllerKind = "Job"
+	deployment            controllerKind = "Deployment"
 )
 
 type podReplicaCreator struct {
@@ -104,6 +119,9 @@ func (e *podsEvictionRestrictionImpl) CanEvict(pod *apiv1.Pod) bool {
 		if pod.Status.Phase == apiv1.PodPending {
 			return true
 		}
+		if present && singleGroupStats.batch != nil {
+			return singleGroupStats.canEvictInBatch()
+		}
 		if present {
 			shouldBeAlive := singleGroupStats.configured - singleGroupStats.evictionTolerance
 			if singleGroupStats.running-singleGroupStats.evicted > shouldBeAlive {
@@ -120,6 +138,16 @@ func (e *podsEvictionRestrictionImpl) CanEvict(pod *apiv1.Pod) bool {
 	return false
 }
 
+// canEvictInBatch checks if a pod of a Deployment can be evicted in the current batch.
+// A batch is only started when the Deployment isn't being rolled out and all its
+// pods are ready, i.e. pods evicted in the previous batch have been replaced.
+func (s singleGroupStats) canEvictInBatch() bool {
+	if s.batch.rollingOut || s.batch.ready < s.configured {
+		return false
+	}
+	return s.evicted < s.batch.size
+}
+
 // Evict sends eviction instruction to api client. Returns error if pod cannot be evicted or if client returned error
 // Does not check if pod was actually evicted after eviction grace period.
 func (e *podsEvictionRestrictionImpl) Evict(podToEvict *apiv1.Pod, eventRecorder record.EventRecorder) error {
@@ -158,9 +186,10 @@ func (e *podsEvictionRestrictionImpl) Evict(podToEvict *apiv1.Pod, eventRecorder
 	return nil
 }
 
-// NewPodsEvictionRestrictionFactory creates PodsEvictionRestrictionFactory
+// NewPodsEvictionRestrictionFactory creates PodsEvictionRestrictionFactory. With batchEviction
+// pods of Deployments are evicted in batches sized by the maxUnavailable of the Deployment.
 func NewPodsEvictionRestrictionFactory(client kube_client.Interface, minReplicas int,
-	evictionToleranceFraction float64) (PodsEvictionRestrictionFactory, error) {
+	evictionToleranceFraction float64, batchEviction bool) (PodsEvictionRestrictionFactory, error) {
 	rcInformer, err := setUpInformer(client, replicationController)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create rcInformer: %v", err)
@@ -177,12 +206,20 @@ func NewPodsEvictionRestrictionFactory(client kube_client.Interface, minReplicas
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create dsInformer: %v", err)
 	}
+	var deployInformer cache.SharedIndexInformer
+	if batchEviction {
+		deployInformer, err = setUpInformer(client, deployment)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create deployInformer: %v", err)
+		}
+	}
 	return &podsEvictionRestrictionFactoryImpl{
 		client:                    client,
-		rcInformer:                rcInformer, // informer for Replication Controllers
-		ssInformer:                ssInformer, // informer for Replica Sets
-		rsInformer:                rsInformer, // informer for Stateful Sets
-		dsInformer:                dsInformer, // informer for Daemon Sets
+		rcInformer:                rcInformer,     // informer for Replication Controllers
+		ssInformer:                ssInformer,     // informer for Replica Sets
+		rsInformer:                rsInformer,     // informer for Stateful Sets
+		dsInformer:                dsInformer,     // informer for Daemon Sets
+		deployInformer:            deployInformer, // informer for Deployments
 		minReplicas:               minReplicas,
 		evictionToleranceFraction: evictionToleranceFraction}, nil
 }
@@ -252,6 +289,19 @@ func (f *podsEvictionRestrictionFactoryImpl) NewPodsEvictionRestriction(pods []*
 			}
 		}
 		singleGroup.running = len(replicas) - singleGroup.pending
+		if f.deployInformer != nil && creator.Kind == replicaSet {
+			singleGroup.batch = f.getDeploymentBatch(creator, configured)
+		}
+		if creator.Kind == statefulSet {
+			singleGroup.batch = f.getStatefulSetBatch(creator)
+		}
+		if singleGroup.batch != nil {
+			for _, pod := range replicas {
+				if isPodReady(pod) {
+					singleGroup.batch.ready++
+				}
+			}
+		}
 		creatorToSingleGroupStatsMap[creator] = singleGroup
 	}
 	return &podsEvictionRestrictionImpl{
@@ -260,6 +310,84 @@ func (f *podsEvictionRestrictionFactoryImpl) NewPodsEvictionRestriction(pods []*
 		creatorToSingleGroupStatsMap: creatorToSingleGroupStatsMap}
 }
 
+// getDeploymentBatch returns the batch eviction state of a replica set owned by a
+// Deployment with the rolling update strategy, or nil if it isn't owned by one.
+// The batch size is the maxUnavailable of the Deployment, at least one pod.
+func (f *podsEvictionRestrictionFactoryImpl) getDeploymentBatch(creator podReplicaCreator, configured int) *batchStats {
+	rsObj, exists, err := f.rsInformer.GetStore().GetByKey(creator.Namespace + "/" + creator.Name)
+	if err != nil || !exists {
+		return nil
+	}
+	rs, ok := rsObj.(*appsv1.ReplicaSet)
+	if !ok {
+		return nil
+	}
+	owner := metav1.GetControllerOf(rs)
+	if owner == nil || owner.Kind != string(deployment) {
+		return nil
+	}
+	deployObj, exists, err := f.deployInformer.GetStore().GetByKey(creator.Namespace + "/" + owner.Name)
+	if err != nil || !exists {
+		klog.V(4).Infof("deployment %s/%s owning replica set %s is not available, not evicting in batches", creator.Namespace, owner.Name, creator.Name)
+		return nil
+	}
+	d, ok := deployObj.(*appsv1.Deployment)
+	if !ok || d.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		return nil
+	}
+
+	maxUnavailable := intstr.FromString("25%")
+	if d.Spec.Strategy.RollingUpdate != nil && d.Spec.Strategy.RollingUpdate.MaxUnavailable != nil {
+		maxUnavailable = *d.Spec.Strategy.RollingUpdate.MaxUnavailable
+	}
+	size, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, configured, false)
+	if err != nil {
+		klog.Errorf("invalid maxUnavailable of deployment %s/%s: %v", d.Namespace, d.Name, err)
+		return nil
+	}
+	if size < 1 {
+		size = 1
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	rollingOut := d.Generation > d.Status.ObservedGeneration || d.Status.UpdatedReplicas < replicas || d.Status.Replicas > d.Status.UpdatedReplicas
+	return &batchStats{size: size, rollingOut: rollingOut}
+}
+
+// getStatefulSetBatch returns the batch eviction state of a StatefulSet with the OrderedReady
+// pod management policy, or nil if it has another policy. Pods of such StatefulSets are evicted
+// one at a time, waiting for all pods to be ready in between, as the StatefulSet controller
+// would during a rolling update.
+func (f *podsEvictionRestrictionFactoryImpl) getStatefulSetBatch(creator podReplicaCreator) *batchStats {
+	ssObj, exists, err := f.ssInformer.GetStore().GetByKey(creator.Namespace + "/" + creator.Name)
+	if err != nil || !exists {
+		return nil
+	}
+	ss, ok := ssObj.(*appsv1.StatefulSet)
+	if !ok || ss.Spec.PodManagementPolicy != appsv1.OrderedReadyPodManagement {
+		return nil
+	}
+	rollingOut := ss.Generation > ss.Status.ObservedGeneration
+	if ss.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType && ss.Status.CurrentRevision != ss.Status.UpdateRevision {
+		rollingOut = true
+	}
+	return &batchStats{size: 1, rollingOut: rollingOut}
+}
+
+func isPodReady(pod *apiv1.Pod) bool {
+	if pod.Status.Phase != apiv1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func getPodReplicaCreator(pod *apiv1.Pod) (*podReplicaCreator, error) {
 	creator := managingControllerRef(pod)
 	if creator == nil {
@@ -380,6 +508,9 @@ func setUpInformer(kubeClient kube_client.Interface, kind controllerKind) (cache
 	case daemonSet:
 		informer = appsinformer.NewDaemonSetInformer(kubeClient, apiv1.NamespaceAll,
 			resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	case deployment:
+		informer = appsinformer.NewDeploymentInformer(kubeClient, apiv1.NamespaceAll,
+			resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 	default:
 		return nil, fmt.Errorf("Unknown controller kind: %v", kind)
 	}
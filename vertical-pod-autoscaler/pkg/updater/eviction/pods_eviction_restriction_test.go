@@ -26,6 +26,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
@@ -370,6 +371,83 @@ func TestEvictReplicatedByStatefulSet(t *testing.T) {
 	}
 }
 
+func TestEvictReplicatedByOrderedStatefulSet(t *testing.T) {
+	replicas := int32(5)
+
+	newStatefulSet := func() *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "ss",
+				Namespace:  "default",
+				Generation: 1,
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind: "StatefulSet",
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:            &replicas,
+				PodManagementPolicy: appsv1.OrderedReadyPodManagement,
+				UpdateStrategy:      appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+			},
+			Status: appsv1.StatefulSetStatus{
+				ObservedGeneration: 1,
+				CurrentRevision:    "ss-1",
+				UpdateRevision:     "ss-1",
+			},
+		}
+	}
+	ss := newStatefulSet()
+	newPods := func(ready int) []*apiv1.Pod {
+		pods := make([]*apiv1.Pod, replicas)
+		for i := range pods {
+			pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&ss.ObjectMeta, &ss.TypeMeta).WithPhase(apiv1.PodRunning).Get()
+			if i < ready {
+				pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+			}
+		}
+		return pods
+	}
+
+	// All pods are ready, a single pod can be evicted.
+	factory, err := getEvictionRestrictionFactory(nil, nil, ss, nil, 2, 0.5)
+	assert.NoError(t, err)
+	pods := newPods(5)
+	eviction := factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	assert.NoError(t, eviction.Evict(pods[4], test.FakeEventRecorder()))
+	for _, pod := range pods[:4] {
+		assert.False(t, eviction.CanEvict(pod))
+	}
+
+	// The replacement of the evicted pod isn't ready yet.
+	pods = newPods(4)
+	eviction = factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods {
+		assert.False(t, eviction.CanEvict(pod))
+	}
+
+	// The StatefulSet is being rolled out.
+	rollingOut := newStatefulSet()
+	rollingOut.Status.UpdateRevision = "ss-2"
+	factory, err = getEvictionRestrictionFactory(nil, nil, rollingOut, nil, 2, 0.5)
+	assert.NoError(t, err)
+	pods = newPods(5)
+	eviction = factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods {
+		assert.False(t, eviction.CanEvict(pod))
+	}
+
+	// With parallel pod management eviction tolerance applies.
+	parallel := newStatefulSet()
+	parallel.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
+	factory, err = getEvictionRestrictionFactory(nil, nil, parallel, nil, 2, 0.5)
+	assert.NoError(t, err)
+	eviction = factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods[:2] {
+		assert.NoError(t, eviction.Evict(pod, test.FakeEventRecorder()))
+	}
+	assert.False(t, eviction.CanEvict(pods[2]))
+}
+
 func TestEvictReplicatedByDaemonSet(t *testing.T) {
 	livePods := int32(5)
 
@@ -557,6 +635,114 @@ func TestEvictAtLeastOne(t *testing.T) {
 	}
 }
 
+func TestEvictReplicatedByDeploymentInBatches(t *testing.T) {
+	replicas := int32(6)
+	maxUnavailable := intstr.FromInt(2)
+
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "deployment",
+				Namespace:  "default",
+				Generation: 1,
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Strategy: appsv1.DeploymentStrategy{
+					Type:          appsv1.RollingUpdateDeploymentStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+				},
+			},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 1,
+				Replicas:           replicas,
+				UpdatedReplicas:    replicas,
+			},
+		}
+	}
+	rs := appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rs",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: "deployment", Controller: &[]bool{true}[0]},
+			},
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicaSet",
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+		},
+	}
+	newPods := func(ready int) []*apiv1.Pod {
+		pods := make([]*apiv1.Pod, replicas)
+		for i := range pods {
+			pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rs.ObjectMeta, &rs.TypeMeta).WithPhase(apiv1.PodRunning).Get()
+			if i < ready {
+				pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+			}
+		}
+		return pods
+	}
+
+	// All pods are ready, a batch of maxUnavailable pods can be evicted.
+	factory, err := getBatchEvictionRestrictionFactory(&rs, newDeployment(), 0.5)
+	assert.NoError(t, err)
+	pods := newPods(6)
+	eviction := factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods[:2] {
+		assert.NoError(t, eviction.Evict(pod, test.FakeEventRecorder()))
+	}
+	for _, pod := range pods[2:] {
+		assert.False(t, eviction.CanEvict(pod))
+	}
+
+	// Replacements of the previous batch aren't ready yet.
+	pods = newPods(5)
+	eviction = factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods {
+		assert.False(t, eviction.CanEvict(pod))
+	}
+
+	// The deployment is being rolled out.
+	rollingOut := newDeployment()
+	rollingOut.Status.UpdatedReplicas = 3
+	factory, err = getBatchEvictionRestrictionFactory(&rs, rollingOut, 0.5)
+	assert.NoError(t, err)
+	pods = newPods(6)
+	eviction = factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods {
+		assert.False(t, eviction.CanEvict(pod))
+	}
+
+	// Without the deployment eviction tolerance applies.
+	factory, err = getBatchEvictionRestrictionFactory(&rs, nil, 0.5)
+	assert.NoError(t, err)
+	eviction = factory.NewPodsEvictionRestriction(pods, getBasicVpa())
+	for _, pod := range pods[:3] {
+		assert.NoError(t, eviction.Evict(pod, test.FakeEventRecorder()))
+	}
+	assert.False(t, eviction.CanEvict(pods[3]))
+}
+
+func getBatchEvictionRestrictionFactory(rs *appsv1.ReplicaSet, deployment *appsv1.Deployment,
+	evictionToleranceFraction float64) (PodsEvictionRestrictionFactory, error) {
+	factory, err := getEvictionRestrictionFactory(nil, rs, nil, nil, 2, evictionToleranceFraction)
+	if err != nil {
+		return nil, err
+	}
+	deployInformer := appsinformer.NewDeploymentInformer(&fake.Clientset{}, apiv1.NamespaceAll,
+		0*time.Second, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if deployment != nil {
+		if err := deployInformer.GetIndexer().Add(deployment); err != nil {
+			return nil, fmt.Errorf("Error adding object to cache: %v", err)
+		}
+	}
+	factory.(*podsEvictionRestrictionFactoryImpl).deployInformer = deployInformer
+	return factory, nil
+}
+
 func getEvictionRestrictionFactory(rc *apiv1.ReplicationController, rs *appsv1.ReplicaSet,
 	ss *appsv1.StatefulSet, ds *appsv1.DaemonSet, minReplicas int,
 	evictionToleranceFraction float64) (PodsEvictionRestrictionFactory, error) {
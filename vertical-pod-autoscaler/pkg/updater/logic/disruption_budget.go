@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// disruptionTracker remembers when pods of each VPA were disrupted, by evictions or by in-place
+// resizes restarting their containers, to enforce the DisruptionBudget of the VPA. Disruptions
+// are only remembered in memory, so the ones before a restart of Updater aren't counted.
+type disruptionTracker struct {
+	disruptions map[types.NamespacedName][]time.Time
+}
+
+// canDisrupt returns whether the DisruptionBudget of the VPA, if any, allows disrupting another
+// pod at the given time.
+func (d *disruptionTracker) canDisrupt(vpa *vpa_types.VerticalPodAutoscaler, now time.Time) bool {
+	budget := getDisruptionBudget(vpa)
+	if budget == nil {
+		return true
+	}
+	return len(d.recentDisruptions(vpa, budget, now)) < int(budget.MaxDisruptions)
+}
+
+// recordDisruption records a pod of the VPA disrupted at the given time.
+func (d *disruptionTracker) recordDisruption(vpa *vpa_types.VerticalPodAutoscaler, now time.Time) {
+	budget := getDisruptionBudget(vpa)
+	if budget == nil {
+		return
+	}
+	if d.disruptions == nil {
+		d.disruptions = make(map[types.NamespacedName][]time.Time)
+	}
+	key := types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}
+	d.disruptions[key] = append(d.recentDisruptions(vpa, budget, now), now)
+}
+
+// forgetOthers forgets the disruptions of VPAs other than the given ones.
+func (d *disruptionTracker) forgetOthers(vpas []*vpa_types.VerticalPodAutoscaler) {
+	keep := make(map[types.NamespacedName]bool, len(vpas))
+	for _, vpa := range vpas {
+		keep[types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}] = true
+	}
+	for key := range d.disruptions {
+		if !keep[key] {
+			delete(d.disruptions, key)
+		}
+	}
+}
+
+// recentDisruptions returns the disruptions of pods of the VPA within the window of its budget.
+func (d *disruptionTracker) recentDisruptions(vpa *vpa_types.VerticalPodAutoscaler, budget *vpa_types.DisruptionBudget, now time.Time) []time.Time {
+	key := types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}
+	var recent []time.Time
+	for _, disruption := range d.disruptions[key] {
+		if now.Sub(disruption) < budget.Window.Duration {
+			recent = append(recent, disruption)
+		}
+	}
+	return recent
+}
+
+func getDisruptionBudget(vpa *vpa_types.VerticalPodAutoscaler) *vpa_types.DisruptionBudget {
+	if vpa.Spec.UpdatePolicy == nil {
+		return nil
+	}
+	return vpa.Spec.UpdatePolicy.DisruptionBudget
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestDisruptionTracker(t *testing.T) {
+	now := time.Date(2024, 6, 1, 2, 30, 0, 0, time.UTC)
+	withoutBudget := test.VerticalPodAutoscaler().WithName("without-budget").WithContainer("container1").Get()
+	withBudget := test.VerticalPodAutoscaler().WithName("with-budget").WithContainer("container1").Get()
+	withBudget.Spec.UpdatePolicy = &vpa_types.PodUpdatePolicy{
+		DisruptionBudget: &vpa_types.DisruptionBudget{MaxDisruptions: 2, Window: metav1.Duration{Duration: time.Hour}},
+	}
+
+	d := disruptionTracker{}
+	for i := 0; i < 3; i++ {
+		assert.True(t, d.canDisrupt(withoutBudget, now))
+		d.recordDisruption(withoutBudget, now)
+	}
+	assert.Empty(t, d.disruptions)
+
+	assert.True(t, d.canDisrupt(withBudget, now))
+	d.recordDisruption(withBudget, now)
+	assert.True(t, d.canDisrupt(withBudget, now.Add(10*time.Minute)))
+	d.recordDisruption(withBudget, now.Add(10*time.Minute))
+	assert.False(t, d.canDisrupt(withBudget, now.Add(30*time.Minute)))
+	// The first disruption leaves the window.
+	assert.True(t, d.canDisrupt(withBudget, now.Add(time.Hour)))
+
+	d.forgetOthers([]*vpa_types.VerticalPodAutoscaler{withoutBudget})
+	assert.True(t, d.canDisrupt(withBudget, now.Add(30*time.Minute)))
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// raiseMemoryLimits raises in place the memory limits of containers with controlled values
+// MemoryLimitsOnly which are below the recommended memory limit, without restarting the pods.
+func (u *updater) raiseMemoryLimits(ctx context.Context, pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) {
+	if vpa.Status.Recommendation == nil {
+		return
+	}
+	for _, pod := range pods {
+		patch, err := getMemoryLimitsPatch(pod, vpa)
+		if err != nil {
+			klog.Errorf("cannot compute memory limits patch of pod %s: %v", klog.KObj(pod), err)
+			continue
+		}
+		if patch == nil {
+			continue
+		}
+		restarts := raisingMemoryLimitsRestarts(pod, vpa)
+		if restarts && !restartingResizesAllowed(vpa) {
+			klog.V(3).Infof("not raising memory limits of pod %s because it restarts containers, which VPA %s doesn't allow", klog.KObj(pod), klog.KObj(vpa))
+			continue
+		}
+		if restarts && !u.disruptions.canDisrupt(vpa, u.now()) {
+			klog.V(3).Infof("not raising memory limits of pod %s because it restarts containers and the disruption budget of VPA %s is used up", klog.KObj(pod), klog.KObj(vpa))
+			continue
+		}
+		if _, err := u.kubeClient.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			klog.Warningf("raising memory limits of pod %s failed: %v", klog.KObj(pod), err)
+			continue
+		}
+		klog.V(2).Infof("raised memory limits of pod %s", klog.KObj(pod))
+		if restarts {
+			u.disruptions.recordDisruption(vpa, u.now())
+		}
+		u.eventRecorder.Event(pod, apiv1.EventTypeNormal, "MemoryLimitRaised",
+			"Pod memory limits raised in place by VPA Updater to prevent OOM kills.")
+	}
+}
+
+// restartingResizesAllowed returns whether the VPA allows in-place resizes
+// restarting containers, which it does unless disabled in its update policy.
+func restartingResizesAllowed(vpa *vpa_types.VerticalPodAutoscaler) bool {
+	policy := vpa.Spec.UpdatePolicy
+	return policy == nil || policy.AllowRestartingResizes == nil || *policy.AllowRestartingResizes
+}
+
+// getMemoryLimitsPatch returns a strategic merge patch raising the memory limits of the pod
+// containers, or nil if none of them needs to be raised.
+func getMemoryLimitsPatch(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) ([]byte, error) {
+	var containers []map[string]interface{}
+	podRecommendation := vpa_api_util.GetRecommendationForPod(vpa, pod)
+	for _, container := range pod.Spec.Containers {
+		recommendation := vpa_api_util.GetRecommendationForContainer(container.Name, podRecommendation)
+		limit, raise := vpa_api_util.GetRaisedMemoryLimit(container, vpa.Spec.ResourcePolicy, recommendation)
+		if !raise {
+			continue
+		}
+		containers = append(containers, map[string]interface{}{
+			"name": container.Name,
+			"resources": map[string]interface{}{
+				"limits": apiv1.ResourceList{apiv1.ResourceMemory: limit},
+			},
+		})
+	}
+	if len(containers) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": containers,
+		},
+	})
+}
+
+// raisingMemoryLimitsRestarts returns whether raising the memory limits of the pod containers
+// restarts some of them, as required by their resize policy.
+func raisingMemoryLimitsRestarts(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) bool {
+	podRecommendation := vpa_api_util.GetRecommendationForPod(vpa, pod)
+	for _, container := range pod.Spec.Containers {
+		recommendation := vpa_api_util.GetRecommendationForContainer(container.Name, podRecommendation)
+		if _, raise := vpa_api_util.GetRaisedMemoryLimit(container, vpa.Spec.ResourcePolicy, recommendation); !raise {
+			continue
+		}
+		for _, policy := range container.ResizePolicy {
+			if policy.ResourceName == apiv1.ResourceMemory && policy.RestartPolicy == apiv1.RestartContainer {
+				return true
+			}
+		}
+	}
+	return false
+}
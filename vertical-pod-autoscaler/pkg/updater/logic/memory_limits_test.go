@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestRaiseMemoryLimits(t *testing.T) {
+	containerName := "container1"
+	newPod := func(name, memoryLimit string) *apiv1.Pod {
+		pod := test.Pod().WithName(name).AddContainer(test.Container().WithName(containerName).
+			WithMemRequest(resource.MustParse("100Mi")).WithMemLimit(resource.MustParse(memoryLimit)).Get()).Get()
+		pod.Namespace = "default"
+		return pod
+	}
+	lowLimitPod := newPod("low", "200Mi")
+	highLimitPod := newPod("high", "1Gi")
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer(containerName).WithTarget("1", "200Mi").
+		WithControlledValues(containerName, vpa_types.ContainerControlledValuesMemoryLimitsOnly).Get()
+	recommendedLimit := resource.MustParse("500Mi")
+	vpa.Status.Recommendation.ContainerRecommendations[0].MemoryLimit = &recommendedLimit
+
+	kubeClient := fake.NewSimpleClientset(lowLimitPod, highLimitPod)
+	u := &updater{
+		kubeClient:    kubeClient,
+		eventRecorder: record.NewFakeRecorder(10),
+	}
+	u.raiseMemoryLimits(context.Background(), []*apiv1.Pod{lowLimitPod, highLimitPod}, vpa)
+
+	for _, tc := range []struct {
+		name          string
+		expectedLimit string
+	}{
+		{name: "low", expectedLimit: "500Mi"},
+		{name: "high", expectedLimit: "1Gi"},
+	} {
+		pod, err := kubeClient.CoreV1().Pods("default").Get(context.Background(), tc.name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		limit := pod.Spec.Containers[0].Resources.Limits[apiv1.ResourceMemory]
+		assert.Zero(t, limit.Cmp(resource.MustParse(tc.expectedLimit)), "memory limit of pod %s is %s", tc.name, limit.String())
+		request := pod.Spec.Containers[0].Resources.Requests[apiv1.ResourceMemory]
+		assert.Zero(t, request.Cmp(resource.MustParse("100Mi")), "memory request of pod %s is %s", tc.name, request.String())
+	}
+}
+
+func TestRaiseMemoryLimitsDisruptionBudget(t *testing.T) {
+	containerName := "container1"
+	newPod := func(name string) *apiv1.Pod {
+		pod := test.Pod().WithName(name).AddContainer(test.Container().WithName(containerName).
+			WithMemRequest(resource.MustParse("100Mi")).WithMemLimit(resource.MustParse("200Mi")).Get()).Get()
+		pod.Namespace = "default"
+		pod.Spec.Containers[0].ResizePolicy = []apiv1.ContainerResizePolicy{
+			{ResourceName: apiv1.ResourceMemory, RestartPolicy: apiv1.RestartContainer},
+		}
+		return pod
+	}
+	pod1, pod2 := newPod("pod1"), newPod("pod2")
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer(containerName).WithTarget("1", "200Mi").
+		WithControlledValues(containerName, vpa_types.ContainerControlledValuesMemoryLimitsOnly).Get()
+	recommendedLimit := resource.MustParse("500Mi")
+	vpa.Status.Recommendation.ContainerRecommendations[0].MemoryLimit = &recommendedLimit
+	vpa.Spec.UpdatePolicy = &vpa_types.PodUpdatePolicy{
+		DisruptionBudget: &vpa_types.DisruptionBudget{MaxDisruptions: 1, Window: metav1.Duration{Duration: time.Hour}},
+	}
+
+	kubeClient := fake.NewSimpleClientset(pod1, pod2)
+	u := &updater{
+		kubeClient:    kubeClient,
+		eventRecorder: record.NewFakeRecorder(10),
+		now:           time.Now,
+	}
+	u.raiseMemoryLimits(context.Background(), []*apiv1.Pod{pod1, pod2}, vpa)
+
+	// Raising the limits restarts the containers, so only one pod is resized.
+	for _, tc := range []struct {
+		name          string
+		expectedLimit string
+	}{
+		{name: "pod1", expectedLimit: "500Mi"},
+		{name: "pod2", expectedLimit: "200Mi"},
+	} {
+		pod, err := kubeClient.CoreV1().Pods("default").Get(context.Background(), tc.name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		limit := pod.Spec.Containers[0].Resources.Limits[apiv1.ResourceMemory]
+		assert.Zero(t, limit.Cmp(resource.MustParse(tc.expectedLimit)), "memory limit of pod %s is %s", tc.name, limit.String())
+	}
+}
+
+func TestRaiseMemoryLimitsRestartingResizesNotAllowed(t *testing.T) {
+	newPod := func(name string, restartPolicy apiv1.ResourceResizeRestartPolicy) *apiv1.Pod {
+		pod := test.Pod().WithName(name).AddContainer(test.Container().WithName("container1").
+			WithMemRequest(resource.MustParse("100Mi")).WithMemLimit(resource.MustParse("200Mi")).Get()).Get()
+		pod.Namespace = "default"
+		pod.Spec.Containers[0].ResizePolicy = []apiv1.ContainerResizePolicy{
+			{ResourceName: apiv1.ResourceMemory, RestartPolicy: restartPolicy},
+		}
+		return pod
+	}
+	restarting, notRestarting := newPod("restarting", apiv1.RestartContainer), newPod("not-restarting", apiv1.NotRequired)
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("container1").WithTarget("1", "200Mi").
+		WithControlledValues("container1", vpa_types.ContainerControlledValuesMemoryLimitsOnly).Get()
+	recommendedLimit := resource.MustParse("500Mi")
+	vpa.Status.Recommendation.ContainerRecommendations[0].MemoryLimit = &recommendedLimit
+	allowRestartingResizes := false
+	vpa.Spec.UpdatePolicy = &vpa_types.PodUpdatePolicy{AllowRestartingResizes: &allowRestartingResizes}
+
+	kubeClient := fake.NewSimpleClientset(restarting, notRestarting)
+	u := &updater{
+		kubeClient:    kubeClient,
+		eventRecorder: record.NewFakeRecorder(10),
+		now:           time.Now,
+	}
+	u.raiseMemoryLimits(context.Background(), []*apiv1.Pod{restarting, notRestarting}, vpa)
+
+	// Only the pod whose containers aren't restarted by the resize is resized.
+	for _, tc := range []struct {
+		name          string
+		expectedLimit string
+	}{
+		{name: "restarting", expectedLimit: "200Mi"},
+		{name: "not-restarting", expectedLimit: "500Mi"},
+	} {
+		pod, err := kubeClient.CoreV1().Pods("default").Get(context.Background(), tc.name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		limit := pod.Spec.Containers[0].Resources.Limits[apiv1.ResourceMemory]
+		assert.Zero(t, limit.Cmp(resource.MustParse(tc.expectedLimit)), "memory limit of pod %s is %s", tc.name, limit.String())
+	}
+}
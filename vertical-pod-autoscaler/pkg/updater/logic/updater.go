@@ -19,11 +19,15 @@ package logic
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/time/rate"
 
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	kube_client "k8s.io/client-go/kubernetes"
@@ -42,6 +46,8 @@ import (
 	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/eviction"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
 	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/status"
 	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
@@ -59,6 +65,7 @@ type updater struct {
 	eventRecorder                record.EventRecorder
 	evictionFactory              eviction.PodsEvictionRestrictionFactory
 	recommendationProcessor      vpa_api_util.RecommendationProcessor
+	limitsRangeCalculator        limitrange.LimitRangeCalculator
 	evictionAdmission            priority.PodEvictionAdmission
 	priorityProcessor            priority.PriorityProcessor
 	evictionRateLimiter          *rate.Limiter
@@ -66,6 +73,10 @@ type updater struct {
 	useAdmissionControllerStatus bool
 	statusValidator              status.Validator
 	controllerFetcher            controllerfetcher.ControllerFetcher
+	kubeClient                   kube_client.Interface
+	inPlaceMemoryLimitRaise      bool
+	disruptions                  disruptionTracker
+	now                          func() time.Time
 }
 
 // NewUpdater creates Updater with given configuration
@@ -76,9 +87,12 @@ func NewUpdater(
 	evictionRateLimit float64,
 	evictionRateBurst int,
 	evictionToleranceFraction float64,
+	batchEviction bool,
+	inPlaceMemoryLimitRaise bool,
 	useAdmissionControllerStatus bool,
 	statusNamespace string,
 	recommendationProcessor vpa_api_util.RecommendationProcessor,
+	limitsRangeCalculator limitrange.LimitRangeCalculator,
 	evictionAdmission priority.PodEvictionAdmission,
 	selectorFetcher target.VpaTargetSelectorFetcher,
 	controllerFetcher controllerfetcher.ControllerFetcher,
@@ -86,7 +100,7 @@ func NewUpdater(
 	namespace string,
 ) (Updater, error) {
 	evictionRateLimiter := getRateLimiter(evictionRateLimit, evictionRateBurst)
-	factory, err := eviction.NewPodsEvictionRestrictionFactory(kubeClient, minReplicasForEvicition, evictionToleranceFraction)
+	factory, err := eviction.NewPodsEvictionRestrictionFactory(kubeClient, minReplicasForEvicition, evictionToleranceFraction, batchEviction)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create eviction restriction factory: %v", err)
 	}
@@ -96,11 +110,15 @@ func NewUpdater(
 		eventRecorder:                newEventRecorder(kubeClient),
 		evictionFactory:              factory,
 		recommendationProcessor:      recommendationProcessor,
+		limitsRangeCalculator:        limitsRangeCalculator,
 		evictionRateLimiter:          evictionRateLimiter,
 		evictionAdmission:            evictionAdmission,
 		priorityProcessor:            priorityProcessor,
 		selectorFetcher:              selectorFetcher,
 		controllerFetcher:            controllerFetcher,
+		kubeClient:                   kubeClient,
+		inPlaceMemoryLimitRaise:      inPlaceMemoryLimitRaise,
+		now:                          time.Now,
 		useAdmissionControllerStatus: useAdmissionControllerStatus,
 		statusValidator: status.NewValidator(
 			kubeClient,
@@ -133,6 +151,7 @@ func (u *updater) RunOnce(ctx context.Context) {
 		klog.Fatalf("failed get VPA list: %v", err)
 	}
 	timer.ObserveStep("ListVPAs")
+	u.disruptions.forgetOthers(vpaList)
 
 	vpas := make([]*vpa_api_util.VpaWithSelector, 0)
 
@@ -142,6 +161,10 @@ func (u *updater) RunOnce(ctx context.Context) {
 			klog.V(3).Infof("skipping VPA object %s because its mode is not \"Recreate\" or \"Auto\"", klog.KObj(vpa))
 			continue
 		}
+		if !vpa_api_util.InMaintenanceWindow(vpa, u.now()) {
+			klog.V(3).Infof("skipping VPA object %s because it is outside of its maintenance windows", klog.KObj(vpa))
+			continue
+		}
 		selector, err := u.selectorFetcher.Fetch(vpa)
 		if err != nil {
 			klog.V(3).Infof("skipping VPA object %s because we cannot fetch selector", klog.KObj(vpa))
@@ -199,10 +222,15 @@ func (u *updater) RunOnce(ctx context.Context) {
 	// NOTE: this loop assumes that controlledPods are filtered
 	// to contain only Pods controlled by a VPA in auto or recreate mode
 	for vpa, livePods := range controlledPods {
+		// Pods excluded from actuation still count as live replicas for the eviction restriction.
+		actuatedPods := filterDisabledPods(livePods)
+		if u.inPlaceMemoryLimitRaise {
+			u.raiseMemoryLimits(ctx, actuatedPods, vpa)
+		}
 		vpaSize := len(livePods)
 		controlledPodsCounter.Add(vpaSize, vpaSize)
 		evictionLimiter := u.evictionFactory.NewPodsEvictionRestriction(livePods, vpa)
-		podsForUpdate := u.getPodsUpdateOrder(filterNonEvictablePods(livePods, evictionLimiter), vpa)
+		podsForUpdate := u.getPodsUpdateOrder(filterNonEvictablePods(actuatedPods, evictionLimiter), vpa)
 		evictablePodsCounter.Add(vpaSize, len(podsForUpdate))
 
 		withEvictable := false
@@ -212,6 +240,10 @@ func (u *updater) RunOnce(ctx context.Context) {
 			if !evictionLimiter.CanEvict(pod) {
 				continue
 			}
+			if !u.disruptions.canDisrupt(vpa, u.now()) {
+				klog.V(3).Infof("not evicting more pods of VPA %s because its disruption budget is used up", klog.KObj(vpa))
+				break
+			}
 			err := u.evictionRateLimiter.Wait(ctx)
 			if err != nil {
 				klog.Warningf("evicting pod %s failed: %v", klog.KObj(pod), err)
@@ -223,6 +255,7 @@ func (u *updater) RunOnce(ctx context.Context) {
 				klog.Warningf("evicting pod %s failed: %v", klog.KObj(pod), evictErr)
 			} else {
 				withEvicted = true
+				u.disruptions.recordDisruption(vpa, u.now())
 				metrics_updater.AddEvictedPod(vpaSize)
 			}
 		}
@@ -252,17 +285,74 @@ func getRateLimiter(evictionRateLimit float64, evictionRateLimitBurst int) *rate
 
 // getPodsUpdateOrder returns list of pods that should be updated ordered by update priority
 func (u *updater) getPodsUpdateOrder(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) []*apiv1.Pod {
+	recommendationProcessor := u.recommendationProcessor
+	if u.limitsRangeCalculator != nil {
+		// Recommendations are capped to the constraints of the VPA as in the admission controller.
+		recommendationProcessor = vpa_api_util.NewConstraintsRecommendationProcessor(recommendationProcessor, u.limitsRangeCalculator, vpa)
+	}
 	priorityCalculator := priority.NewUpdatePriorityCalculator(
 		vpa,
 		nil,
-		u.recommendationProcessor,
+		recommendationProcessor,
 		u.priorityProcessor)
 
 	for _, pod := range pods {
 		priorityCalculator.AddPod(pod, time.Now())
 	}
 
-	return priorityCalculator.GetSortedPods(u.evictionAdmission)
+	return orderStatefulSetPods(priorityCalculator.GetSortedPods(u.evictionAdmission))
+}
+
+// orderStatefulSetPods reorders the pods of each StatefulSet by decreasing ordinal, keeping the
+// positions of the pods of the StatefulSet in the list, so that they are updated in the order the
+// StatefulSet controller would roll them out.
+func orderStatefulSetPods(pods []*apiv1.Pod) []*apiv1.Pod {
+	positions := make(map[string][]int)
+	for i, pod := range pods {
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != "StatefulSet" {
+			continue
+		}
+		if _, ok := statefulSetPodOrdinal(pod, owner.Name); !ok {
+			continue
+		}
+		key := pod.Namespace + "/" + owner.Name
+		positions[key] = append(positions[key], i)
+	}
+	if len(positions) == 0 {
+		return pods
+	}
+	result := make([]*apiv1.Pod, len(pods))
+	copy(result, pods)
+	for _, indexes := range positions {
+		ordered := make([]*apiv1.Pod, 0, len(indexes))
+		for _, i := range indexes {
+			ordered = append(ordered, pods[i])
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			owner := metav1.GetControllerOf(ordered[i]).Name
+			a, _ := statefulSetPodOrdinal(ordered[i], owner)
+			b, _ := statefulSetPodOrdinal(ordered[j], owner)
+			return a > b
+		})
+		for k, i := range indexes {
+			result[i] = ordered[k]
+		}
+	}
+	return result
+}
+
+// statefulSetPodOrdinal returns the ordinal of a pod of the given StatefulSet, named `<statefulset>-<ordinal>`.
+func statefulSetPodOrdinal(pod *apiv1.Pod, statefulSet string) (int, bool) {
+	suffix, found := strings.CutPrefix(pod.Name, statefulSet+"-")
+	if !found {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil {
+		return 0, false
+	}
+	return ordinal, true
 }
 
 func filterNonEvictablePods(pods []*apiv1.Pod, evictionRestriction eviction.PodsEvictionRestriction) []*apiv1.Pod {
@@ -275,6 +365,24 @@ func filterNonEvictablePods(pods []*apiv1.Pod, evictionRestriction eviction.Pods
 	return result
 }
 
+// filterDisabledPods returns the pods which aren't excluded from actuation by the VpaDisableAnnotation,
+// nor admitted in dry-run mode, which would never get the recommendation applied after an eviction.
+func filterDisabledPods(pods []*apiv1.Pod) []*apiv1.Pod {
+	result := make([]*apiv1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if annotations.IsVpaDisabled(pod) {
+			klog.V(4).Infof("skipping pod %s because VPA is disabled for it", klog.KObj(pod))
+			continue
+		}
+		if annotations.IsVpaDryRun(pod) {
+			klog.V(4).Infof("skipping pod %s because it was admitted in dry-run mode", klog.KObj(pod))
+			continue
+		}
+		result = append(result, pod)
+	}
+	return result
+}
+
 func filterDeletedPods(pods []*apiv1.Pod) []*apiv1.Pod {
 	result := make([]*apiv1.Pod, 0)
 	for _, pod := range pods {
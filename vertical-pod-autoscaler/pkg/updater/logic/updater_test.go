@@ -37,6 +37,7 @@ import (
 	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/eviction"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/status"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 )
@@ -79,6 +80,7 @@ func TestRunOnce_Mode(t *testing.T) {
 				t,
 				tc.updateMode,
 				newFakeValidator(true),
+				vpa_types.PodUpdatePolicy{},
 				tc.expectFetchCalls,
 				tc.expectedEvictionCount,
 			)
@@ -112,6 +114,7 @@ func TestRunOnce_Status(t *testing.T) {
 				t,
 				vpa_types.UpdateModeAuto,
 				tc.statusValidator,
+				vpa_types.PodUpdatePolicy{},
 				tc.expectFetchCalls,
 				tc.expectedEvictionCount,
 			)
@@ -119,10 +122,56 @@ func TestRunOnce_Status(t *testing.T) {
 	}
 }
 
+func TestRunOnce_MaintenanceWindows(t *testing.T) {
+	tests := []struct {
+		name                  string
+		maintenanceWindows    []vpa_types.MaintenanceWindow
+		expectFetchCalls      bool
+		expectedEvictionCount int
+	}{
+		{
+			name:                  "inside of maintenance window",
+			maintenanceWindows:    []vpa_types.MaintenanceWindow{{Schedule: "0 2 * * *", Duration: metav1.Duration{Duration: time.Hour}}},
+			expectFetchCalls:      true,
+			expectedEvictionCount: 5,
+		},
+		{
+			name:                  "outside of maintenance window",
+			maintenanceWindows:    []vpa_types.MaintenanceWindow{{Schedule: "0 4 * * *", Duration: metav1.Duration{Duration: time.Hour}}},
+			expectFetchCalls:      false,
+			expectedEvictionCount: 0,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			testRunOnceBase(
+				t,
+				vpa_types.UpdateModeAuto,
+				newFakeValidator(true),
+				vpa_types.PodUpdatePolicy{MaintenanceWindows: tc.maintenanceWindows},
+				tc.expectFetchCalls,
+				tc.expectedEvictionCount,
+			)
+		})
+	}
+}
+
+func TestRunOnce_DisruptionBudget(t *testing.T) {
+	testRunOnceBase(
+		t,
+		vpa_types.UpdateModeAuto,
+		newFakeValidator(true),
+		vpa_types.PodUpdatePolicy{DisruptionBudget: &vpa_types.DisruptionBudget{MaxDisruptions: 2, Window: metav1.Duration{Duration: time.Hour}}},
+		true,
+		2,
+	)
+}
+
 func testRunOnceBase(
 	t *testing.T,
 	updateMode vpa_types.UpdateMode,
 	statusValidator status.Validator,
+	updatePolicy vpa_types.PodUpdatePolicy,
 	expectFetchCalls bool,
 	expectedEvictionCount int,
 ) {
@@ -178,7 +227,8 @@ func testRunOnceBase(
 		WithMaxAllowed(containerName, "3", "1G").
 		WithTargetRef(targetRef).Get()
 
-	vpaObj.Spec.UpdatePolicy = &vpa_types.PodUpdatePolicy{UpdateMode: &updateMode}
+	updatePolicy.UpdateMode = &updateMode
+	vpaObj.Spec.UpdatePolicy = &updatePolicy
 	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil).Once()
 
 	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
@@ -195,6 +245,7 @@ func testRunOnceBase(
 		useAdmissionControllerStatus: true,
 		statusValidator:              statusValidator,
 		priorityProcessor:            priority.NewProcessor(),
+		now:                          func() time.Time { return time.Date(2024, 6, 1, 2, 30, 0, 0, time.UTC) },
 	}
 
 	if expectFetchCalls {
@@ -224,6 +275,33 @@ func TestRunOnceNotingToProcess(t *testing.T) {
 	updater.RunOnce(context.Background())
 }
 
+func TestFilterDisabledPods(t *testing.T) {
+	enabled := test.Pod().WithName("enabled").Get()
+	disabled := test.Pod().WithName("disabled").Get()
+	disabled.Annotations = map[string]string{annotations.VpaDisableAnnotation: "true"}
+	dryRun := test.Pod().WithName("dry-run").Get()
+	dryRun.Annotations = map[string]string{annotations.VpaDryRunAnnotation: "true"}
+	observed := test.Pod().WithName("observed").Get()
+	observed.Annotations = map[string]string{annotations.VpaDryRunPatchAnnotation: "[]"}
+
+	assert.Equal(t, []*apiv1.Pod{enabled}, filterDisabledPods([]*apiv1.Pod{enabled, disabled, dryRun, observed}))
+}
+
+func TestOrderStatefulSetPods(t *testing.T) {
+	ss := &metav1.ObjectMeta{Name: "ss", Namespace: "default"}
+	ssType := &metav1.TypeMeta{Kind: "StatefulSet"}
+	rs := &metav1.ObjectMeta{Name: "rs", Namespace: "default"}
+	rsType := &metav1.TypeMeta{Kind: "ReplicaSet"}
+	ss1 := test.Pod().WithName("ss-1").WithCreator(ss, ssType).Get()
+	ss2 := test.Pod().WithName("ss-2").WithCreator(ss, ssType).Get()
+	ss10 := test.Pod().WithName("ss-10").WithCreator(ss, ssType).Get()
+	rs1 := test.Pod().WithName("rs-1").WithCreator(rs, rsType).Get()
+	rs2 := test.Pod().WithName("rs-2").WithCreator(rs, rsType).Get()
+
+	ordered := orderStatefulSetPods([]*apiv1.Pod{ss1, rs1, ss10, rs2, ss2})
+	assert.Equal(t, []*apiv1.Pod{ss10, rs1, ss2, rs2, ss1}, ordered)
+}
+
 func TestGetRateLimiter(t *testing.T) {
 	cases := []struct {
 		rateLimit       float64
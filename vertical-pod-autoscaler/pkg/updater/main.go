@@ -25,6 +25,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/informers"
 	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	kube_flag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
 
@@ -51,6 +52,9 @@ var (
 	evictionToleranceFraction = flag.Float64("eviction-tolerance", 0.5,
 		`Fraction of replica count that can be evicted for update, if more than one pod can be evicted.`)
 
+	batchEviction = flag.Bool("batch-eviction-per-workload", false,
+		`If true, pods of Deployments are evicted in batches sized by the maxUnavailable of the Deployment, waiting for all pods to be ready between batches and not evicting during rollouts.`)
+
 	evictionRateLimit = flag.Float64("eviction-rate-limit", -1,
 		`Number of pods that can be evicted per seconds. A rate limit set to 0 or -1 will disable
 		the rate limiter.`)
@@ -58,6 +62,7 @@ var (
 	evictionRateBurst = flag.Int("eviction-rate-burst", 1, `Burst of pods that can be evicted.`)
 
 	address      = flag.String("address", ":8943", "The address to expose Prometheus metrics.")
+	configFile   = flag.String("config", "", "Path to an optional configuration file, whose values are overridden by the command line flags.")
 	kubeconfig   = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	kubeApiQps   = flag.Float64("kube-api-qps", 5.0, `QPS limit when making requests to Kubernetes apiserver`)
 	kubeApiBurst = flag.Float64("kube-api-burst", 10.0, `QPS burst limit when making requests to Kubernetes apiserver`)
@@ -65,6 +70,12 @@ var (
 	useAdmissionControllerStatus = flag.Bool("use-admission-controller-status", true,
 		"If true, updater will only evict pods when admission controller status is valid.")
 
+	inPlaceMemoryLimitRaise = flag.Bool("in-place-memory-limit-raise", false,
+		"If true, updater raises in place the memory limits of containers with controlledValues MemoryLimitsOnly which are below the recommended memory limit. Requires the InPlacePodVerticalScaling feature gate.")
+
+	evictOnlyIfFitsNode = flag.Bool("evict-only-if-fits-node", false,
+		"If true, updater will only evict pods whose recommended requests fit the allocatable resources of at least one node in the cluster.")
+
 	namespace          = os.Getenv("NAMESPACE")
 	vpaObjectNamespace = flag.String("vpa-object-namespace", apiv1.NamespaceAll, "Namespace to search for VPA objects. Empty means all namespaces will be used.")
 )
@@ -79,6 +90,7 @@ const (
 func main() {
 	klog.InitFlags(nil)
 	kube_flag.InitFlags()
+	common.LoadConfigurationOrDie(*configFile, common.UpdaterComponent)
 	klog.V(1).Infof("Vertical Pod Autoscaler %s Updater", common.VerticalPodAutoscalerVersion)
 
 	healthCheck := metrics.NewHealthCheck(*updaterInterval*5, true)
@@ -101,6 +113,19 @@ func main() {
 	if namespace != "" {
 		admissionControllerStatusNamespace = namespace
 	}
+	evictionAdmission := priority.NewScalingDirectionPodEvictionAdmission()
+	if *evictOnlyIfFitsNode {
+		nodeInformer := factory.Core().V1().Nodes().Informer()
+		stopCh := make(chan struct{})
+		go nodeInformer.Run(stopCh)
+		if !cache.WaitForCacheSync(stopCh, nodeInformer.HasSynced) {
+			klog.Fatalf("Could not sync cache for nodes")
+		}
+		evictionAdmission = priority.NewSequentialPodEvictionAdmission([]priority.PodEvictionAdmission{
+			evictionAdmission,
+			priority.NewNodeFitPodEvictionAdmission(factory.Core().V1().Nodes().Lister()),
+		})
+	}
 	// TODO: use SharedInformerFactory in updater
 	updater, err := updater.NewUpdater(
 		kubeClient,
@@ -109,10 +134,13 @@ func main() {
 		*evictionRateLimit,
 		*evictionRateBurst,
 		*evictionToleranceFraction,
+		*batchEviction,
+		*inPlaceMemoryLimitRaise,
 		*useAdmissionControllerStatus,
 		admissionControllerStatusNamespace,
 		vpa_api_util.NewCappingRecommendationProcessor(limitRangeCalculator),
-		priority.NewScalingDirectionPodEvictionAdmission(),
+		limitRangeCalculator,
+		evictionAdmission,
 		targetSelectorFetcher,
 		controllerFetcher,
 		priority.NewProcessor(),
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+	vpa_utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// NewNodeFitPodEvictionAdmission creates a PodEvictionAdmission object.
+// It admits Pods for eviction only if their requests after applying the recommendation
+// fit the allocatable resources of at least one node in the cluster, so that evicted
+// Pods don't become unschedulable.
+func NewNodeFitPodEvictionAdmission(nodeLister v1lister.NodeLister) PodEvictionAdmission {
+	return &nodeFitPodEvictionAdmission{nodeLister: nodeLister}
+}
+
+type nodeFitPodEvictionAdmission struct {
+	nodeLister v1lister.NodeLister
+	// allocatable resources of distinct node shapes in the cluster
+	nodeShapes []apiv1.ResourceList
+}
+
+// LoopInit collects the distinct allocatable resources of nodes in the cluster.
+func (n *nodeFitPodEvictionAdmission) LoopInit(_ []*apiv1.Pod, _ map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	n.nodeShapes = nil
+	nodes, err := n.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list nodes, admitting all pods for eviction: %v", err)
+		return
+	}
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		cpu := node.Status.Allocatable[apiv1.ResourceCPU]
+		memory := node.Status.Allocatable[apiv1.ResourceMemory]
+		key := cpu.String() + "/" + memory.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		n.nodeShapes = append(n.nodeShapes, apiv1.ResourceList{
+			apiv1.ResourceCPU:    cpu,
+			apiv1.ResourceMemory: memory,
+		})
+	}
+}
+
+// Admit admits a Pod for eviction if its recommended requests fit at least one node shape.
+// All Pods are admitted if nodes couldn't be listed.
+func (n *nodeFitPodEvictionAdmission) Admit(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) bool {
+	if len(n.nodeShapes) == 0 {
+		return true
+	}
+	requests := recommendedPodRequests(pod, recommendation)
+	for _, shape := range n.nodeShapes {
+		if fits(requests, shape) {
+			return true
+		}
+	}
+	cpu := requests[apiv1.ResourceCPU]
+	memory := requests[apiv1.ResourceMemory]
+	klog.V(2).Infof("not evicting pod %s, its recommended requests (cpu: %s, memory: %s) don't fit any node", klog.KObj(pod), cpu.String(), memory.String())
+	metrics_updater.AddPodNotFittingNodes()
+	return false
+}
+
+// CleanUp forgets the collected node shapes.
+func (n *nodeFitPodEvictionAdmission) CleanUp() {
+	n.nodeShapes = nil
+}
+
+// recommendedPodRequests returns the CPU and memory requests of the pod, with container requests
+// replaced by recommended targets. Like the scheduler, it accounts for init containers and pod overhead.
+func recommendedPodRequests(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) apiv1.ResourceList {
+	requests := apiv1.ResourceList{}
+	for _, resourceName := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+		total := resource.Quantity{}
+		for _, container := range pod.Spec.Containers {
+			request := container.Resources.Requests[resourceName]
+			if containerRecommendation := vpa_utils.GetRecommendationForContainer(container.Name, recommendation); containerRecommendation != nil {
+				if target, found := containerRecommendation.Target[resourceName]; found {
+					request = target
+				}
+			}
+			total.Add(request)
+		}
+		for _, container := range pod.Spec.InitContainers {
+			if request := container.Resources.Requests[resourceName]; request.Cmp(total) > 0 {
+				total = request.DeepCopy()
+			}
+		}
+		if overhead, found := pod.Spec.Overhead[resourceName]; found {
+			total.Add(overhead)
+		}
+		requests[resourceName] = total
+	}
+	return requests
+}
+
+func fits(requests, allocatable apiv1.ResourceList) bool {
+	for resourceName, request := range requests {
+		available := allocatable[resourceName]
+		if request.Cmp(available) > 0 {
+			return false
+		}
+	}
+	return true
+}
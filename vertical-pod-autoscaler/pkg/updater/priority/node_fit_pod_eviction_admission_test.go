@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func newNode(name, cpu, memory string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func newNodeLister(t *testing.T, nodes ...*corev1.Node) v1lister.NodeLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range nodes {
+		assert.NoError(t, indexer.Add(node))
+	}
+	return v1lister.NewNodeLister(indexer)
+}
+
+func TestNodeFitPodEvictionAdmission(t *testing.T) {
+	pod := test.Pod().WithName("test-pod").
+		AddContainer(test.Container().WithName("container-1").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()).
+		AddContainer(test.Container().WithName("container-2").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()).
+		Get()
+	nodes := []*corev1.Node{
+		newNode("small-1", "4", "4Gi"),
+		newNode("small-2", "4", "4Gi"),
+		newNode("highmem", "2", "16Gi"),
+	}
+
+	testCases := []struct {
+		name     string
+		nodes    []*corev1.Node
+		cpu      string
+		memory   string
+		expected bool
+	}{
+		{
+			name:     "fits small nodes",
+			nodes:    nodes,
+			cpu:      "2",
+			memory:   "2Gi",
+			expected: true,
+		},
+		{
+			name:     "fits only highmem node",
+			nodes:    nodes,
+			cpu:      "500m",
+			memory:   "10Gi",
+			expected: true,
+		},
+		{
+			name:     "needs more cpu and memory than any node has",
+			nodes:    nodes,
+			cpu:      "3",
+			memory:   "10Gi",
+			expected: false,
+		},
+		{
+			name:     "no nodes",
+			cpu:      "3",
+			memory:   "10Gi",
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			admission := NewNodeFitPodEvictionAdmission(newNodeLister(t, tc.nodes...))
+			admission.LoopInit(nil, nil)
+			// Only the first container has a recommendation, the second one keeps its requests.
+			recommendation := test.Recommendation().WithContainer("container-1").
+				WithTarget(tc.cpu, tc.memory).Get()
+			assert.Equal(t, tc.expected, admission.Admit(pod, recommendation))
+		})
+	}
+}
+
+func TestRecommendedPodRequests(t *testing.T) {
+	pod := test.Pod().WithName("test-pod").
+		AddContainer(test.Container().WithName("container-1").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()).
+		Get()
+	pod.Spec.InitContainers = []corev1.Container{
+		test.Container().WithName("init").WithCPURequest(resource.MustParse("3")).WithMemRequest(resource.MustParse("1Gi")).Get(),
+	}
+	pod.Spec.Overhead = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+
+	requests := recommendedPodRequests(pod, &vpa_types.RecommendedPodResources{})
+	cpu := requests[corev1.ResourceCPU]
+	memory := requests[corev1.ResourceMemory]
+	assert.Equal(t, int64(3000), cpu.MilliValue())
+	assert.Equal(t, int64(2*1024*1024*1024), memory.Value())
+}
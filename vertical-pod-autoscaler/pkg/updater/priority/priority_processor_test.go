@@ -207,6 +207,16 @@ func TestGetUpdatePriority(t *testing.T) {
 				ResourceDiff: 0.5 + 0.25,
 				ScaleUp:      true,
 			},
+		}, {
+			name: "memory limits only",
+			pod:  test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("2")).Get()).Get(),
+			vpa: test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("10", "").
+				WithControlledValues(containerName, vpa_types.ContainerControlledValuesMemoryLimitsOnly).Get(),
+			expectedPrio: PodPriority{
+				OutsideRecommendedRange: false,
+				ResourceDiff:            0.0,
+				ScaleUp:                 false,
+			},
 		},
 	}
 	for _, tc := range testCases {
@@ -82,7 +82,7 @@ func NewUpdatePriorityCalculator(vpa *vpa_types.VerticalPodAutoscaler,
 
 // AddPod adds pod to the UpdatePriorityCalculator.
 func (calc *UpdatePriorityCalculator) AddPod(pod *apiv1.Pod, now time.Time) {
-	processedRecommendation, _, err := calc.recommendationProcessor.Apply(calc.vpa.Status.Recommendation, calc.vpa.Spec.ResourcePolicy, calc.vpa.Status.Conditions, pod)
+	processedRecommendation, _, err := calc.recommendationProcessor.Apply(vpa_api_util.GetRecommendationForPod(calc.vpa, pod), calc.vpa.Spec.ResourcePolicy, calc.vpa.Status.Conditions, pod)
 	if err != nil {
 		klog.V(2).Infof("cannot process recommendation for pod %s: %v", klog.KObj(pod), err)
 		return
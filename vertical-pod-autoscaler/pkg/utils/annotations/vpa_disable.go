@@ -0,0 +1,31 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// VpaDisableAnnotation is the Pod annotation which, set to "true", excludes the Pod from
+// VPA actuation: the admission controller doesn't patch it and the updater neither evicts
+// it nor updates it in place. The Pod is still used to compute recommendations.
+const VpaDisableAnnotation = "vpa.k8s.io/disable"
+
+// IsVpaDisabled returns true if the Pod is excluded from VPA actuation by the VpaDisableAnnotation.
+func IsVpaDisabled(pod *v1.Pod) bool {
+	return pod.Annotations[VpaDisableAnnotation] == "true"
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestIsVpaDisabled(t *testing.T) {
+	pod := test.Pod().WithName("pod").Get()
+	assert.False(t, IsVpaDisabled(pod))
+
+	pod.Annotations = map[string]string{VpaDisableAnnotation: "false"}
+	assert.False(t, IsVpaDisabled(pod))
+
+	pod.Annotations[VpaDisableAnnotation] = "true"
+	assert.True(t, IsVpaDisabled(pod))
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// VpaDryRunAnnotation is the Pod annotation which, set to "true", makes the admission
+	// controller preview its patch instead of applying it.
+	VpaDryRunAnnotation = "vpa.k8s.io/dry-run"
+	// VpaDryRunPatchAnnotation is the Pod annotation containing the JSON patch the
+	// admission controller would have applied to a Pod admitted in dry-run mode.
+	VpaDryRunPatchAnnotation = "vpa.k8s.io/dry-run-patch"
+)
+
+// IsVpaDryRun returns true if the Pod was admitted in dry-run mode, either because it
+// requested it with the VpaDryRunAnnotation or because the admission controller observes
+// its namespace only. Such Pods never get the recommendation applied, so the updater must
+// not evict them.
+func IsVpaDryRun(pod *v1.Pod) bool {
+	if pod.Annotations[VpaDryRunAnnotation] == "true" {
+		return true
+	}
+	_, found := pod.Annotations[VpaDryRunPatchAnnotation]
+	return found
+}
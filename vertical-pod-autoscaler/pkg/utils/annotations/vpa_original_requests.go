@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"encoding/json"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// VpaOriginalRequestsAnnotation is the Pod annotation recording the requests of its containers
+// before the admission controller updated them, i.e. the requests of the controller template.
+const VpaOriginalRequestsAnnotation = "vpa.k8s.io/original-requests"
+
+// GetVpaOriginalRequestsValue creates an annotation value recording the current requests of
+// the containers of a given pod.
+func GetVpaOriginalRequestsValue(pod *v1.Pod) (string, error) {
+	requests := make(map[string]v1.ResourceList, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		if len(container.Resources.Requests) > 0 {
+			requests[container.Name] = container.Resources.Requests
+		}
+	}
+	value, err := json.Marshal(requests)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// GetOriginalRequests returns the requests of the container before the admission controller
+// updated them, as recorded by the VpaOriginalRequestsAnnotation, or its current requests if
+// the Pod doesn't have a valid annotation.
+func GetOriginalRequests(pod *v1.Pod, container v1.Container) v1.ResourceList {
+	value, found := pod.Annotations[VpaOriginalRequestsAnnotation]
+	if !found {
+		return container.Resources.Requests
+	}
+	requests := make(map[string]v1.ResourceList)
+	if err := json.Unmarshal([]byte(value), &requests); err != nil {
+		klog.V(4).Infof("ignoring invalid %s annotation of pod %s: %v", VpaOriginalRequestsAnnotation, klog.KObj(pod), err)
+		return container.Resources.Requests
+	}
+	return requests[container.Name]
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestGetOriginalRequests(t *testing.T) {
+	container := test.Container().WithName("container").WithCPURequest(resource.MustParse("1")).Get()
+	pod := test.Pod().WithName("pod").AddContainer(container).Get()
+	assert.Equal(t, container.Resources.Requests, GetOriginalRequests(pod, container))
+
+	value, err := GetVpaOriginalRequestsValue(pod)
+	assert.NoError(t, err)
+	pod.Annotations = map[string]string{VpaOriginalRequestsAnnotation: value}
+	updated := test.Container().WithName("container").WithCPURequest(resource.MustParse("2")).Get()
+	pod.Spec.Containers = []v1.Container{updated}
+	original := GetOriginalRequests(pod, updated)
+	assert.Equal(t, int64(1000), original.Cpu().MilliValue())
+
+	pod.Annotations[VpaOriginalRequestsAnnotation] = "invalid"
+	assert.Equal(t, updated.Resources.Requests, GetOriginalRequests(pod, updated))
+}
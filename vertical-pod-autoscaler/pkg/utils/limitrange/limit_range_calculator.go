@@ -20,11 +20,13 @@ import (
 	"fmt"
 
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
 )
 
 // LimitRangeCalculator calculates limit range items that has the same effect as all limit range items present in the cluster.
@@ -35,6 +37,15 @@ type LimitRangeCalculator interface {
 	GetPodLimitRangeItem(namespace string) (*core.LimitRangeItem, error)
 }
 
+// PresetLimitRangeCalculator is a LimitRangeCalculator which can also take into account
+// a preset LimitRange, shared by many namespaces, from any namespace in the cluster.
+type PresetLimitRangeCalculator interface {
+	LimitRangeCalculator
+	// WithPreset returns a LimitRangeCalculator which merges the limit range items of
+	// the LimitRange with the given namespace and name with the ones of each namespace.
+	WithPreset(namespace, name string) LimitRangeCalculator
+}
+
 type noopLimitsRangeCalculator struct{}
 
 func (lc *noopLimitsRangeCalculator) GetContainerLimitRangeItem(namespace string) (*core.LimitRangeItem, error) {
@@ -45,6 +56,10 @@ func (lc *noopLimitsRangeCalculator) GetPodLimitRangeItem(namespace string) (*co
 	return nil, nil
 }
 
+func (lc *noopLimitsRangeCalculator) WithPreset(namespace, name string) LimitRangeCalculator {
+	return lc
+}
+
 type limitsChecker struct {
 	limitRangeLister listers.LimitRangeLister
 }
@@ -78,12 +93,53 @@ func (lc *limitsChecker) GetPodLimitRangeItem(namespace string) (*core.LimitRang
 	return lc.getLimitRangeItem(namespace, core.LimitTypePod)
 }
 
+// WithPreset returns a LimitRangeCalculator which also takes into account the preset LimitRange.
+// A missing preset is ignored, so that Pods are still admitted with the limit ranges of their namespace.
+func (lc *limitsChecker) WithPreset(namespace, name string) LimitRangeCalculator {
+	return &presetLimitsChecker{limitsChecker: lc, presetNamespace: namespace, presetName: name}
+}
+
 func (lc *limitsChecker) getLimitRangeItem(namespace string, limitType core.LimitType) (*core.LimitRangeItem, error) {
 	limitRanges, err := lc.limitRangeLister.LimitRanges(namespace).List(labels.Everything())
 	if err != nil {
 		return nil, fmt.Errorf("error loading limit ranges: %s", err)
 	}
+	return mergeLimitRangeItems(limitRanges, limitType), nil
+}
+
+type presetLimitsChecker struct {
+	*limitsChecker
+	presetNamespace string
+	presetName      string
+}
+
+func (lc *presetLimitsChecker) GetContainerLimitRangeItem(namespace string) (*core.LimitRangeItem, error) {
+	return lc.getLimitRangeItem(namespace, core.LimitTypeContainer)
+}
+
+func (lc *presetLimitsChecker) GetPodLimitRangeItem(namespace string) (*core.LimitRangeItem, error) {
+	return lc.getLimitRangeItem(namespace, core.LimitTypePod)
+}
+
+func (lc *presetLimitsChecker) getLimitRangeItem(namespace string, limitType core.LimitType) (*core.LimitRangeItem, error) {
+	limitRanges, err := lc.limitRangeLister.LimitRanges(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("error loading limit ranges: %s", err)
+	}
+	preset, err := lc.limitRangeLister.LimitRanges(lc.presetNamespace).Get(lc.presetName)
+	if errors.IsNotFound(err) {
+		klog.Warningf("Preset LimitRange %s/%s not found, using limit ranges of namespace %s only", lc.presetNamespace, lc.presetName, namespace)
+	} else if err != nil {
+		return nil, fmt.Errorf("error loading preset limit range %s/%s: %s", lc.presetNamespace, lc.presetName, err)
+	} else if preset.Namespace != namespace {
+		limitRanges = append(limitRanges, preset)
+	}
+	return mergeLimitRangeItems(limitRanges, limitType), nil
+}
 
+// mergeLimitRangeItems returns a limit range item of the given type that has the same
+// effect as all the limit ranges, or nil if they don't limit that type.
+func mergeLimitRangeItems(limitRanges []*core.LimitRange, limitType core.LimitType) *core.LimitRangeItem {
 	updatedResult := func(result core.ResourceList, lrItem core.ResourceList,
 		resourceName core.ResourceName, picker func(q1, q2 resource.Quantity) resource.Quantity) core.ResourceList {
 		if lrItem == nil {
@@ -130,7 +186,7 @@ func (lc *limitsChecker) getLimitRangeItem(namespace string, limitType core.Limi
 		}
 	}
 	if result.Min != nil || result.Max != nil || result.Default != nil {
-		return result, nil
+		return result
 	}
-	return nil, nil
+	return nil
 }
@@ -150,3 +150,57 @@ func TestGetContainerLimitRangeItem(t *testing.T) {
 
 	}
 }
+
+func TestGetContainerLimitRangeItemWithPreset(t *testing.T) {
+	const presetNamespace = "presets"
+	preset := test.LimitRange().WithName("small").WithNamespace(presetNamespace).WithType(apiv1.LimitTypeContainer).
+		WithMax(test.Resources("1", "1")).WithMin(test.Resources("0.5", "0.5")).Get()
+	namespaceLimitRange := test.LimitRange().WithName("test-lr").WithNamespace(testNamespace).WithType(apiv1.LimitTypeContainer).
+		WithMax(test.Resources("2", "0.8")).Get()
+	testCases := []struct {
+		name           string
+		limitRanges    []runtime.Object
+		presetName     string
+		expectedLimits *apiv1.LimitRangeItem
+	}{
+		{
+			name:        "preset only",
+			limitRanges: []runtime.Object{preset},
+			presetName:  "small",
+			expectedLimits: &core.LimitRangeItem{
+				Type: core.LimitTypeContainer,
+				Min:  test.Resources("0.5", "0.5"),
+				Max:  test.Resources("1", "1"),
+			},
+		},
+		{
+			name:        "merges preset with namespace limit ranges",
+			limitRanges: []runtime.Object{preset, namespaceLimitRange},
+			presetName:  "small",
+			expectedLimits: &core.LimitRangeItem{
+				Type: core.LimitTypeContainer,
+				Min:  test.Resources("0.5", "0.5"),
+				Max:  test.Resources("1", "0.8"),
+			},
+		},
+		{
+			name:           "ignores missing preset",
+			limitRanges:    []runtime.Object{preset, namespaceLimitRange},
+			presetName:     "missing",
+			expectedLimits: &namespaceLimitRange.Spec.Limits[0],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := fake.NewSimpleClientset(tc.limitRanges...)
+			factory := informers.NewSharedInformerFactory(cs, 0)
+			lc, err := NewLimitsRangeCalculator(factory)
+			if assert.NoError(t, err) {
+				limitRange, err := lc.WithPreset(presetNamespace, tc.presetName).GetContainerLimitRangeItem(testNamespace)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedLimits, limitRange)
+			}
+		})
+	}
+}
@@ -42,6 +42,9 @@ type AdmissionStatus string
 // AdmissionResource describes the resource processed by Admission Control execution
 type AdmissionResource string
 
+// ShortCircuitReason describes why Admission Control execution was cut short
+type ShortCircuitReason string
+
 const (
 	// Error denotes a failed Admission Control execution
 	Error AdmissionStatus = "error"
@@ -60,6 +63,27 @@ const (
 	Vpa AdmissionResource = "VPA"
 )
 
+const (
+	// DeadlineExceeded means that the latency budget of a request was used up
+	DeadlineExceeded ShortCircuitReason = "deadline_exceeded"
+	// CacheNotSynced means that the caches used for recommendation lookup were not synced yet
+	CacheNotSynced ShortCircuitReason = "cache_not_synced"
+	// CircuitOpen means that the circuit breaker was open due to repeated failures
+	CircuitOpen ShortCircuitReason = "circuit_open"
+)
+
+// RecommendationCacheResult describes the result of a lookup in the recommendation cache
+type RecommendationCacheResult string
+
+const (
+	// CacheHit means that a fresh cache entry was used
+	CacheHit RecommendationCacheResult = "hit"
+	// CacheStale means that a stale cache entry was used while it was being refreshed
+	CacheStale RecommendationCacheResult = "stale"
+	// CacheMiss means that the matching VPA had to be looked up
+	CacheMiss RecommendationCacheResult = "miss"
+)
+
 var (
 	admissionCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -80,6 +104,47 @@ var (
 
 	functionLatency = metrics.CreateExecutionTimeMetric(metricsNamespace,
 		"Time spent in various parts of VPA admission controller")
+
+	phaseLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "admission_phase_latency_seconds",
+			Help:      "Time spent in phases of Pod admission in VPA Admission Controller.",
+			Buckets:   []float64{0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1.0, 2.0, 5.0, 10.0},
+		}, []string{"phase"},
+	)
+
+	shortCircuitCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "admission_short_circuits_total",
+			Help:      "Number of Pods admitted by VPA Admission Controller without a recommendation lookup.",
+		}, []string{"reason"},
+	)
+
+	recommendationCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "recommendation_cache_lookups_total",
+			Help:      "Number of lookups of the VPA matching a Pod in the recommendation cache of VPA Admission Controller.",
+		}, []string{"controller_kind", "result"},
+	)
+
+	dryRunCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "dry_run_pods_total",
+			Help:      "Number of Pods admitted in dry-run mode by VPA Admission Controller, by whether their resources would have been changed.",
+		}, []string{"resources_changed"},
+	)
+
+	circuitBreakerOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_open",
+			Help:      "Whether the VPA Admission Controller circuit breaker is open (1) or closed (0).",
+		},
+	)
 )
 
 // Register initializes all metrics for VPA Admission Controller
@@ -87,6 +152,11 @@ func Register() {
 	prometheus.MustRegister(admissionCount)
 	prometheus.MustRegister(admissionLatency)
 	prometheus.MustRegister(functionLatency)
+	prometheus.MustRegister(phaseLatency)
+	prometheus.MustRegister(shortCircuitCount)
+	prometheus.MustRegister(circuitBreakerOpen)
+	prometheus.MustRegister(recommendationCacheLookups)
+	prometheus.MustRegister(dryRunCount)
 }
 
 // OnAdmittedPod increases the counter of pods handled by VPA Admission Controller
@@ -94,6 +164,35 @@ func OnAdmittedPod(touched bool) {
 	admissionCount.WithLabelValues(fmt.Sprintf("%v", touched)).Add(1)
 }
 
+// OnShortCircuit increases the counter of pods admitted without a recommendation lookup
+func OnShortCircuit(reason ShortCircuitReason) {
+	shortCircuitCount.WithLabelValues(string(reason)).Add(1)
+}
+
+// OnDryRunPod increases the counter of pods admitted in dry-run mode
+func OnDryRunPod(resourcesChanged bool) {
+	dryRunCount.WithLabelValues(fmt.Sprintf("%v", resourcesChanged)).Add(1)
+}
+
+// ObservePhaseLatency records the time spent in the given phase of Pod admission
+func ObservePhaseLatency(phase string, duration time.Duration) {
+	phaseLatency.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// OnRecommendationCacheLookup increases the counter of recommendation cache lookups for Pods of the given controller kind
+func OnRecommendationCacheLookup(controllerKind string, result RecommendationCacheResult) {
+	recommendationCacheLookups.WithLabelValues(controllerKind, string(result)).Add(1)
+}
+
+// SetCircuitBreakerOpen records the state of the circuit breaker
+func SetCircuitBreakerOpen(open bool) {
+	if open {
+		circuitBreakerOpen.Set(1)
+	} else {
+		circuitBreakerOpen.Set(0)
+	}
+}
+
 // NewAdmissionLatency provides a timer for admission latency; call Observe() on it to measure
 func NewAdmissionLatency() *AdmissionLatency {
 	return &AdmissionLatency{
@@ -80,6 +80,22 @@ var (
 		},
 	)
 
+	modelMemoryFootprint = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "model_memory_footprint_bytes",
+			Help:      "Estimated memory used by the aggregate container states, after enforcing the memory budget.",
+		},
+	)
+
+	aggregateContainerStatesShed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "aggregate_container_states_shed_total",
+			Help:      "Number of aggregate container states downsampled or dropped to stay within the memory budget.",
+		}, []string{"action"},
+	)
+
 	metricServerResponses = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: metricsNamespace,
@@ -104,7 +120,7 @@ type ObjectCounter struct {
 
 // Register initializes all metrics for VPA Recommender
 func Register() {
-	prometheus.MustRegister(vpaObjectCount, recommendationLatency, functionLatency, aggregateContainerStatesCount, metricServerResponses)
+	prometheus.MustRegister(vpaObjectCount, recommendationLatency, functionLatency, aggregateContainerStatesCount, modelMemoryFootprint, aggregateContainerStatesShed, metricServerResponses)
 }
 
 // NewExecutionTimer provides a timer for Recommender's RunOnce execution
@@ -122,6 +138,14 @@ func RecordAggregateContainerStatesCount(statesCount int) {
 	aggregateContainerStatesCount.Set(float64(statesCount))
 }
 
+// RecordMemoryBudgetResult records the memory footprint of the model and the aggregate container states
+// downsampled or dropped to stay within the memory budget
+func RecordMemoryBudgetResult(result model.MemoryBudgetResult) {
+	modelMemoryFootprint.Set(float64(result.Footprint))
+	aggregateContainerStatesShed.WithLabelValues("downsampled").Add(float64(result.Downsampled))
+	aggregateContainerStatesShed.WithLabelValues("shed").Add(float64(result.Shed))
+}
+
 // RecordMetricsServerResponse records result of a query to metrics server
 func RecordMetricsServerResponse(err error, clientName string) {
 	metricServerResponses.WithLabelValues(strconv.FormatBool(err != nil), clientName).Inc()
@@ -59,6 +59,14 @@ var (
 		}, []string{"vpa_size_log2"},
 	)
 
+	notFittingNodesCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "pods_not_fitting_nodes_total",
+			Help:      "Number of times a Pod was not evicted because its recommended requests don't fit any node.",
+		},
+	)
+
 	vpasWithEvictablePodsCount = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: metricsNamespace,
@@ -81,7 +89,7 @@ var (
 
 // Register initializes all metrics for VPA Updater
 func Register() {
-	prometheus.MustRegister(controlledCount, evictableCount, evictedCount, vpasWithEvictablePodsCount, vpasWithEvictedPodsCount, functionLatency)
+	prometheus.MustRegister(controlledCount, evictableCount, evictedCount, notFittingNodesCount, vpasWithEvictablePodsCount, vpasWithEvictedPodsCount, functionLatency)
 }
 
 // NewExecutionTimer provides a timer for Updater's RunOnce execution
@@ -123,6 +131,11 @@ func AddEvictedPod(vpaSize int) {
 	evictedCount.WithLabelValues(strconv.Itoa(log2)).Inc()
 }
 
+// AddPodNotFittingNodes increases the counter of pods not evicted because their recommended requests don't fit any node
+func AddPodNotFittingNodes() {
+	notFittingNodesCount.Inc()
+}
+
 // Add increases the counter for the given VPA size
 func (g *SizeBasedGauge) Add(vpaSize int, value int) {
 	log2 := metrics.GetVpaSizeLog2(vpaSize)
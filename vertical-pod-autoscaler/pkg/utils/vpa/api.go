@@ -25,6 +25,7 @@ import (
 
 	core "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -80,7 +81,17 @@ func UpdateVpaStatusIfNeeded(vpaClient vpa_api.VerticalPodAutoscalerInterface, v
 // set namespace to k8sapiv1.NamespaceAll to select all namespaces.
 // The method blocks until vpaLister is initially populated.
 func NewVpasLister(vpaClient *vpa_clientset.Clientset, stopChannel <-chan struct{}, namespace string) vpa_lister.VerticalPodAutoscalerLister {
-	vpaListWatch := cache.NewListWatchFromClient(vpaClient.AutoscalingV1().RESTClient(), "verticalpodautoscalers", namespace, fields.Everything())
+	return NewVpasListerWithSelector(vpaClient, stopChannel, namespace, labels.Everything())
+}
+
+// NewVpasListerWithSelector returns VerticalPodAutoscalerLister configured to fetch only the VPA objects
+// from the namespace which match the label selector, so that others aren't even kept in memory.
+// The method blocks until vpaLister is initially populated.
+func NewVpasListerWithSelector(vpaClient *vpa_clientset.Clientset, stopChannel <-chan struct{}, namespace string, selector labels.Selector) vpa_lister.VerticalPodAutoscalerLister {
+	vpaListWatch := cache.NewFilteredListWatchFromClient(vpaClient.AutoscalingV1().RESTClient(), "verticalpodautoscalers", namespace, func(options *meta.ListOptions) {
+		options.FieldSelector = fields.Everything().String()
+		options.LabelSelector = selector.String()
+	})
 	indexer, controller := cache.NewIndexerInformer(vpaListWatch,
 		&vpa_types.VerticalPodAutoscaler{},
 		1*time.Hour,
@@ -212,6 +223,78 @@ func GetContainerControlledValues(name string, vpaResourcePolicy *vpa_types.PodR
 	return *containerPolicy.ControlledValues
 }
 
+// GetRaisedMemoryLimit returns the memory limit a container with controlled values MemoryLimitsOnly
+// should be raised to, and whether it should be raised. Memory limits are only raised to the
+// recommended memory limit, never lowered nor added to containers running without one.
+func GetRaisedMemoryLimit(container core.Container, vpaResourcePolicy *vpa_types.PodResourcePolicy, recommendation *vpa_types.RecommendedContainerResources) (resource.Quantity, bool) {
+	if GetContainerControlledValues(container.Name, vpaResourcePolicy) != vpa_types.ContainerControlledValuesMemoryLimitsOnly {
+		return resource.Quantity{}, false
+	}
+	if recommendation == nil || recommendation.MemoryLimit == nil {
+		return resource.Quantity{}, false
+	}
+	limit, found := container.Resources.Limits[core.ResourceMemory]
+	if !found || limit.Cmp(*recommendation.MemoryLimit) >= 0 {
+		return resource.Quantity{}, false
+	}
+	return recommendation.MemoryLimit.DeepCopy(), true
+}
+
+// GetVariantLabels returns the values of the variant labels of a pod with the given labels,
+// which identify its variant. Variant labels the pod doesn't have are left out.
+func GetVariantLabels(variantLabels []string, podLabels labels.Labels) map[string]string {
+	result := make(map[string]string, len(variantLabels))
+	for _, key := range variantLabels {
+		if podLabels.Has(key) {
+			result[key] = podLabels.Get(key)
+		}
+	}
+	return result
+}
+
+// GetRecommendationForPod returns the recommendation of the VPA for the variant of the pod,
+// or the recommendation for all the pods if the VPA has no variant labels or no recommendation
+// for the variant of the pod yet.
+func GetRecommendationForPod(vpa *vpa_types.VerticalPodAutoscaler, pod *core.Pod) *vpa_types.RecommendedPodResources {
+	recommendation := vpa.Status.Recommendation
+	if recommendation == nil || len(vpa.Spec.VariantLabels) == 0 {
+		return recommendation
+	}
+	variantLabels := GetVariantLabels(vpa.Spec.VariantLabels, labels.Set(pod.Labels))
+	for _, variant := range recommendation.Variants {
+		if labels.Equals(variant.Labels, variantLabels) {
+			return &vpa_types.RecommendedPodResources{ContainerRecommendations: variant.ContainerRecommendations}
+		}
+	}
+	return recommendation
+}
+
+// ApplyVpaCheckpoint creates or updates the VPA Checkpoint API object with server-side apply,
+// owning its spec and status as the given field manager.
+func ApplyVpaCheckpoint(vpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointInterface,
+	vpaCheckpoint *vpa_types.VerticalPodAutoscalerCheckpoint, fieldManager string) error {
+	applied := vpa_types.VerticalPodAutoscalerCheckpoint{
+		TypeMeta: meta.TypeMeta{
+			APIVersion: vpa_types.SchemeGroupVersion.String(),
+			Kind:       "VerticalPodAutoscalerCheckpoint",
+		},
+		ObjectMeta: meta.ObjectMeta{Name: vpaCheckpoint.Name},
+		Spec:       vpaCheckpoint.Spec,
+		Status:     vpaCheckpoint.Status,
+	}
+	bytes, err := json.Marshal(applied)
+	if err != nil {
+		return fmt.Errorf("Cannot marshal VPA checkpoint %+v. Reason: %+v", applied, err)
+	}
+	force := true
+	_, err = vpaCheckpointClient.Patch(context.TODO(), vpaCheckpoint.Name, types.ApplyPatchType, bytes,
+		meta.PatchOptions{FieldManager: fieldManager, Force: &force})
+	if err != nil {
+		return fmt.Errorf("Cannot apply checkpoint for vpa %v container %v. Reason: %+v", vpaCheckpoint.Spec.VPAObjectName, vpaCheckpoint.Spec.ContainerName, err)
+	}
+	return nil
+}
+
 // CreateOrUpdateVpaCheckpoint updates the status field of the VPA Checkpoint API object.
 // If object doesn't exits it is created.
 func CreateOrUpdateVpaCheckpoint(vpaCheckpointClient vpa_api.VerticalPodAutoscalerCheckpointInterface,
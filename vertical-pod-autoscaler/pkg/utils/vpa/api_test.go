@@ -302,3 +302,124 @@ func TestGetContainerControlledResources(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRaisedMemoryLimit(t *testing.T) {
+	memoryLimitsOnly := vpa_types.ContainerControlledValuesMemoryLimitsOnly
+	memoryLimitsOnlyPolicy := &vpa_types.PodResourcePolicy{
+		ContainerPolicies: []vpa_types.ContainerResourcePolicy{{
+			ContainerName:    containerName,
+			ControlledValues: &memoryLimitsOnly,
+		}},
+	}
+	recommendedLimit := resource.MustParse("2Gi")
+	recommendation := &vpa_types.RecommendedContainerResources{
+		ContainerName: containerName,
+		MemoryLimit:   &recommendedLimit,
+	}
+	containerWithLimit := func(limit string) core.Container {
+		container := core.Container{Name: containerName}
+		if limit != "" {
+			container.Resources.Limits = core.ResourceList{core.ResourceMemory: resource.MustParse(limit)}
+		}
+		return container
+	}
+	for _, tc := range []struct {
+		name           string
+		container      core.Container
+		policy         *vpa_types.PodResourcePolicy
+		recommendation *vpa_types.RecommendedContainerResources
+		expectedRaise  bool
+	}{
+		{
+			name:           "limit below the recommendation is raised",
+			container:      containerWithLimit("1Gi"),
+			policy:         memoryLimitsOnlyPolicy,
+			recommendation: recommendation,
+			expectedRaise:  true,
+		}, {
+			name:           "limit above the recommendation isn't lowered",
+			container:      containerWithLimit("3Gi"),
+			policy:         memoryLimitsOnlyPolicy,
+			recommendation: recommendation,
+		}, {
+			name:           "missing limit isn't added",
+			container:      containerWithLimit(""),
+			policy:         memoryLimitsOnlyPolicy,
+			recommendation: recommendation,
+		}, {
+			name:           "memory limit not controlled",
+			container:      containerWithLimit("1Gi"),
+			recommendation: recommendation,
+		}, {
+			name:           "no memory limit recommendation",
+			container:      containerWithLimit("1Gi"),
+			policy:         memoryLimitsOnlyPolicy,
+			recommendation: &vpa_types.RecommendedContainerResources{ContainerName: containerName},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limit, raise := GetRaisedMemoryLimit(tc.container, tc.policy, tc.recommendation)
+			assert.Equal(t, tc.expectedRaise, raise)
+			if tc.expectedRaise {
+				assert.Equal(t, recommendedLimit.Value(), limit.Value())
+			}
+		})
+	}
+}
+
+func TestGetRecommendationForPod(t *testing.T) {
+	recommendation := func(cpu string) []vpa_types.RecommendedContainerResources {
+		return []vpa_types.RecommendedContainerResources{{
+			ContainerName: containerName,
+			Target:        core.ResourceList{core.ResourceCPU: resource.MustParse(cpu)},
+		}}
+	}
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer(containerName).Get()
+	vpa.Status.Recommendation = &vpa_types.RecommendedPodResources{
+		ContainerRecommendations: recommendation("1"),
+		Variants: []vpa_types.RecommendedVariantResources{
+			{Labels: map[string]string{"size": "small"}, ContainerRecommendations: recommendation("100m")},
+			{Labels: map[string]string{"size": "large", "shard": "a"}, ContainerRecommendations: recommendation("4")},
+		},
+	}
+	withVariantLabels := vpa.DeepCopy()
+	withVariantLabels.Spec.VariantLabels = []string{"size", "shard"}
+	podWithLabels := func(podLabels map[string]string) *core.Pod {
+		pod := test.Pod().WithName("pod").AddContainer(test.Container().WithName(containerName).Get()).Get()
+		pod.Labels = podLabels
+		return pod
+	}
+
+	for _, tc := range []struct {
+		name     string
+		vpa      *vpa_types.VerticalPodAutoscaler
+		pod      *core.Pod
+		expected []vpa_types.RecommendedContainerResources
+	}{
+		{
+			name:     "no variant labels",
+			vpa:      vpa,
+			pod:      podWithLabels(map[string]string{"size": "small"}),
+			expected: recommendation("1"),
+		}, {
+			name:     "variant with a missing label",
+			vpa:      withVariantLabels,
+			pod:      podWithLabels(map[string]string{"app": "a", "size": "small"}),
+			expected: recommendation("100m"),
+		}, {
+			name:     "variant with all labels",
+			vpa:      withVariantLabels,
+			pod:      podWithLabels(map[string]string{"size": "large", "shard": "a"}),
+			expected: recommendation("4"),
+		}, {
+			name:     "variant without recommendation",
+			vpa:      withVariantLabels,
+			pod:      podWithLabels(map[string]string{"size": "large"}),
+			expected: recommendation("1"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetRecommendationForPod(tc.vpa, tc.pod).ContainerRecommendations)
+		})
+	}
+}
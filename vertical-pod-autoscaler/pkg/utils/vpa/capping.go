@@ -22,6 +22,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
 	"k8s.io/klog/v2"
 )
@@ -85,7 +86,7 @@ func (c *cappingRecommendationProcessor) Apply(
 			klog.Warningf("failed to fetch LimitRange for %v namespace", pod.Namespace)
 		}
 		updatedContainerResources, containerAnnotations, err := getCappedRecommendationForContainer(
-			*container, &containerRecommendation, policy, containerLimitRange)
+			pod, *container, &containerRecommendation, policy, containerLimitRange)
 
 		if len(containerAnnotations) != 0 {
 			containerToAnnotationsMap[containerRecommendation.ContainerName] = containerAnnotations
@@ -101,14 +102,14 @@ func (c *cappingRecommendationProcessor) Apply(
 
 // getCappedRecommendationForContainer returns a recommendation for the given container, adjusted to obey policy and limits.
 func getCappedRecommendationForContainer(
-	container apiv1.Container,
+	pod *apiv1.Pod, container apiv1.Container,
 	containerRecommendation *vpa_types.RecommendedContainerResources,
 	policy *vpa_types.PodResourcePolicy, limitRange *apiv1.LimitRangeItem) (*vpa_types.RecommendedContainerResources, []string, error) {
 	if containerRecommendation == nil {
 		return nil, nil, fmt.Errorf("no recommendation available for container name %v", container.Name)
 	}
 	// containerPolicy can be nil (user does not have to configure it).
-	containerPolicy := GetContainerResourcePolicy(container.Name, policy)
+	containerPolicy := resolveRelativeBounds(GetContainerResourcePolicy(container.Name, policy), annotations.GetOriginalRequests(pod, container))
 	containerControlledValues := GetContainerControlledValues(container.Name, policy)
 
 	cappedRecommendations := containerRecommendation.DeepCopy()
@@ -204,6 +205,56 @@ func applyVPAPolicyForContainer(containerName string,
 	return cappedRecommendations, nil
 }
 
+// resolveRelativeBounds returns the container policy with MinAllowed and MaxAllowed tightened
+// by the bounds relative to the original requests of the container, i.e. the requests of the
+// controller template, so that the bounds don't move each time the requests are updated.
+func resolveRelativeBounds(policy *vpa_types.ContainerResourcePolicy, requests apiv1.ResourceList) *vpa_types.ContainerResourcePolicy {
+	if policy == nil || (len(policy.MinAllowedPercentOfRequests) == 0 && len(policy.MaxAllowedPercentOfRequests) == 0) {
+		return policy
+	}
+	resolved := policy.DeepCopy()
+	for resourceName, percent := range policy.MinAllowedPercentOfRequests {
+		relativeMin, found := percentOfRequest(requests, resourceName, percent)
+		if !found {
+			continue
+		}
+		if min, found := resolved.MinAllowed[resourceName]; found && min.Cmp(relativeMin) >= 0 {
+			continue
+		}
+		if resolved.MinAllowed == nil {
+			resolved.MinAllowed = apiv1.ResourceList{}
+		}
+		resolved.MinAllowed[resourceName] = relativeMin
+	}
+	for resourceName, percent := range policy.MaxAllowedPercentOfRequests {
+		relativeMax, found := percentOfRequest(requests, resourceName, percent)
+		if !found {
+			continue
+		}
+		if max, found := resolved.MaxAllowed[resourceName]; found && !max.IsZero() && max.Cmp(relativeMax) <= 0 {
+			continue
+		}
+		if resolved.MaxAllowed == nil {
+			resolved.MaxAllowed = apiv1.ResourceList{}
+		}
+		resolved.MaxAllowed[resourceName] = relativeMax
+	}
+	return resolved
+}
+
+// percentOfRequest returns the given percent of the request of the resource,
+// or false if the resource isn't requested.
+func percentOfRequest(requests apiv1.ResourceList, resourceName apiv1.ResourceName, percent int32) (resource.Quantity, bool) {
+	request, found := requests[resourceName]
+	if !found || request.IsZero() {
+		return resource.Quantity{}, false
+	}
+	if resourceName == apiv1.ResourceCPU {
+		return *resource.NewMilliQuantity(request.MilliValue()*int64(percent)/100, request.Format), true
+	}
+	return *resource.NewQuantity(request.Value()*int64(percent)/100, request.Format), true
+}
+
 func maybeCapToPolicyMin(recommended resource.Quantity, resourceName apiv1.ResourceName,
 	containerPolicy *vpa_types.ContainerResourcePolicy) (resource.Quantity, bool) {
 	return maybeCapToMin(recommended, resourceName, containerPolicy.MinAllowed)
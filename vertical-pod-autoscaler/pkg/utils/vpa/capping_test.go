@@ -23,6 +23,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_annotations "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 )
 
@@ -201,6 +202,64 @@ func TestRecommendationCappedToMinMaxPolicy(t *testing.T) {
 	}, res.ContainerRecommendations[0].UpperBound)
 }
 
+func TestRecommendationCappedToPercentOfRequestsPolicy(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").
+		WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()).Get()
+	podRecommendation := vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			{
+				ContainerName: "ctr-name",
+				Target: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("3"),
+					apiv1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+			},
+		},
+	}
+	policy := vpa_types.PodResourcePolicy{
+		ContainerPolicies: []vpa_types.ContainerResourcePolicy{
+			{
+				ContainerName: "ctr-name",
+				MaxAllowed: apiv1.ResourceList{
+					apiv1.ResourceCPU: resource.MustParse("4"),
+				},
+				MinAllowed: apiv1.ResourceList{
+					apiv1.ResourceMemory: resource.MustParse("768Mi"),
+				},
+				MaxAllowedPercentOfRequests: map[apiv1.ResourceName]int32{apiv1.ResourceCPU: 200},
+				MinAllowedPercentOfRequests: map[apiv1.ResourceName]int32{apiv1.ResourceMemory: 50},
+			},
+		},
+	}
+
+	res, annotations, err := NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}).Apply(&podRecommendation, &policy, nil, pod)
+	assert.Nil(t, err)
+	target := res.ContainerRecommendations[0].Target
+	// The relative max is lower than MaxAllowed.
+	assert.Equal(t, int64(2000), target.Cpu().MilliValue())
+	// MinAllowed is higher than the relative min.
+	assert.Equal(t, int64(768*1024*1024), target.Memory().Value())
+	assert.Contains(t, annotations["ctr-name"], "cpu capped to maxAllowed")
+	assert.Contains(t, annotations["ctr-name"], "memory capped to minAllowed")
+	// The policy isn't modified.
+	assert.NotContains(t, policy.ContainerPolicies[0].MaxAllowed, apiv1.ResourceMemory)
+	assert.Equal(t, int64(4000), policy.ContainerPolicies[0].MaxAllowed.Cpu().MilliValue())
+
+	// Resources without requests aren't capped by relative bounds.
+	podWithoutRequests := test.Pod().WithName("pod2").AddContainer(test.Container().WithName("ctr-name").Get()).Get()
+	res, _, err = NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}).Apply(&podRecommendation, &policy, nil, podWithoutRequests)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3000), res.ContainerRecommendations[0].Target.Cpu().MilliValue())
+
+	// Once the requests were updated, the bounds stay relative to the original requests.
+	updatedPod := test.Pod().WithName("pod3").AddContainer(test.Container().WithName("ctr-name").
+		WithCPURequest(resource.MustParse("2")).WithMemRequest(resource.MustParse("1Gi")).Get()).Get()
+	updatedPod.Annotations = map[string]string{vpa_annotations.VpaOriginalRequestsAnnotation: `{"ctr-name":{"cpu":"1","memory":"1Gi"}}`}
+	res, _, err = NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}).Apply(&podRecommendation, &policy, nil, updatedPod)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2000), res.ContainerRecommendations[0].Target.Cpu().MilliValue())
+}
+
 var podRecommendation *vpa_types.RecommendedPodResources = &vpa_types.RecommendedPodResources{
 	ContainerRecommendations: []vpa_types.RecommendedContainerResources{
 		{
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	v1 "k8s.io/api/core/v1"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
+	"k8s.io/klog/v2"
+)
+
+// ConstraintsLimitRangeCalculator returns the calculator taking into account the preset
+// LimitRange the VPA refers to with ConstraintsRef, and whether there is one.
+func ConstraintsLimitRangeCalculator(calculator limitrange.LimitRangeCalculator, vpa *vpa_types.VerticalPodAutoscaler) (limitrange.LimitRangeCalculator, bool) {
+	ref := vpa.Spec.ConstraintsRef
+	if ref == nil || ref.Name == "" {
+		return calculator, false
+	}
+	presetCalculator, ok := calculator.(limitrange.PresetLimitRangeCalculator)
+	if !ok {
+		klog.V(4).Infof("Constraints of VPA %s/%s ignored, limit ranges can't be read", vpa.Namespace, vpa.Name)
+		return calculator, false
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = vpa.Namespace
+	}
+	return presetCalculator.WithPreset(namespace, ref.Name), true
+}
+
+// NewConstraintsRecommendationProcessor returns a RecommendationProcessor which applies the given
+// processor, then caps the recommendation to the preset LimitRange the VPA refers to with
+// ConstraintsRef, if any. Both the admission controller and the updater cap recommendations this
+// way, so that the updater doesn't evict Pods admitted with capped recommendations.
+func NewConstraintsRecommendationProcessor(processor RecommendationProcessor, calculator limitrange.LimitRangeCalculator, vpa *vpa_types.VerticalPodAutoscaler) RecommendationProcessor {
+	presetCalculator, withPreset := ConstraintsLimitRangeCalculator(calculator, vpa)
+	if !withPreset {
+		return processor
+	}
+	return &constraintsRecommendationProcessor{
+		processor: processor,
+		capping:   NewCappingRecommendationProcessor(presetCalculator),
+	}
+}
+
+type constraintsRecommendationProcessor struct {
+	processor RecommendationProcessor
+	capping   RecommendationProcessor
+}
+
+// Apply applies the processor, then caps its recommendation to the preset LimitRange. No
+// recommendation is capped if the processor doesn't return any.
+func (p *constraintsRecommendationProcessor) Apply(podRecommendation *vpa_types.RecommendedPodResources,
+	policy *vpa_types.PodResourcePolicy,
+	conditions []vpa_types.VerticalPodAutoscalerCondition,
+	pod *v1.Pod) (*vpa_types.RecommendedPodResources, ContainerToAnnotationsMap, error) {
+	recommendation, annotations, err := p.processor.Apply(podRecommendation, policy, conditions, pod)
+	if err != nil || recommendation == nil {
+		return recommendation, annotations, err
+	}
+	recommendation, presetAnnotations, err := p.capping.Apply(recommendation, policy, conditions, pod)
+	if len(presetAnnotations) > 0 {
+		if annotations == nil {
+			annotations = ContainerToAnnotationsMap{}
+		}
+		for container, containerAnnotations := range presetAnnotations {
+			annotations[container] = append(annotations[container], containerAnnotations...)
+		}
+	}
+	return recommendation, annotations, err
+}
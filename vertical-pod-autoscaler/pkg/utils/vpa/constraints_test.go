@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+type fakePresetLimitRangeCalculator struct {
+	fakeLimitRangeCalculator
+	presets map[string]*fakeLimitRangeCalculator
+}
+
+func (lc *fakePresetLimitRangeCalculator) WithPreset(namespace, name string) limitrange.LimitRangeCalculator {
+	if preset, found := lc.presets[namespace+"/"+name]; found {
+		return preset
+	}
+	return &lc.fakeLimitRangeCalculator
+}
+
+func TestConstraintsRecommendationProcessor(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").
+		WithCPURequest(resource.MustParse("1")).WithCPULimit(resource.MustParse("1")).Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithName("vpa1").WithNamespace("team-a").WithContainer("ctr-name").
+		WithTarget("2", "200Mi").Get()
+	calculator := &fakePresetLimitRangeCalculator{
+		presets: map[string]*fakeLimitRangeCalculator{
+			"presets/small": {
+				containerLimitRange: apiv1.LimitRangeItem{
+					Type: apiv1.LimitTypeContainer,
+					Max:  apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("1.5")},
+				},
+			},
+		},
+	}
+	processor := NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{})
+
+	// Without constraints, the processor is used as is.
+	assert.Equal(t, processor, NewConstraintsRecommendationProcessor(processor, calculator, vpa))
+
+	vpa.Spec.ConstraintsRef = &vpa_types.ConstraintsReference{Namespace: "presets", Name: "small"}
+	res, _, err := NewConstraintsRecommendationProcessor(processor, calculator, vpa).
+		Apply(vpa.Status.Recommendation, nil, nil, pod)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), res.ContainerRecommendations[0].Target.Cpu().MilliValue())
+
+	// Calculators which can't read preset LimitRanges ignore the constraints.
+	assert.Equal(t, processor, NewConstraintsRecommendationProcessor(processor, &fakeLimitRangeCalculator{}, vpa))
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// ValidateMaintenanceWindow checks that the schedule, duration and time zone of the window are valid.
+func ValidateMaintenanceWindow(window vpa_types.MaintenanceWindow) error {
+	_, _, err := parseMaintenanceWindow(window)
+	return err
+}
+
+// InMaintenanceWindow returns whether pods controlled by the VPA can be evicted at the given time,
+// i.e. the VPA has no maintenance windows or one of them is open. Invalid windows are never open.
+func InMaintenanceWindow(vpa *vpa_types.VerticalPodAutoscaler, now time.Time) bool {
+	if vpa.Spec.UpdatePolicy == nil || len(vpa.Spec.UpdatePolicy.MaintenanceWindows) == 0 {
+		return true
+	}
+	for _, window := range vpa.Spec.UpdatePolicy.MaintenanceWindows {
+		schedule, location, err := parseMaintenanceWindow(window)
+		if err != nil {
+			klog.Errorf("Invalid maintenance window of VPA %s: %v", klog.KObj(vpa), err)
+			continue
+		}
+		// The window is open if it started within the last window duration.
+		start := now.In(location).Add(-window.Duration.Duration)
+		if !schedule.Next(start).After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseMaintenanceWindow(window vpa_types.MaintenanceWindow) (cron.Schedule, *time.Location, error) {
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %v", window.Schedule, err)
+	}
+	if window.Duration.Duration <= 0 {
+		return nil, nil, fmt.Errorf("duration has to be positive, got %v", window.Duration.Duration)
+	}
+	location := time.UTC
+	if window.TimeZone != nil {
+		location, err = time.LoadLocation(*window.TimeZone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone %q: %v", *window.TimeZone, err)
+		}
+	}
+	return schedule, location, nil
+}
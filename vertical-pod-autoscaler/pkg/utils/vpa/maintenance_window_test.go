@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestInMaintenanceWindow(t *testing.T) {
+	berlin := "Europe/Berlin"
+	invalidTimeZone := "Mars/Olympus_Mons"
+	// Saturday.
+	now := time.Date(2024, 6, 1, 2, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		windows []vpa_types.MaintenanceWindow
+		want    bool
+	}{
+		{
+			name: "no windows",
+			want: true,
+		},
+		{
+			name:    "open window",
+			windows: []vpa_types.MaintenanceWindow{{Schedule: "0 2 * * 6", Duration: meta.Duration{Duration: time.Hour}}},
+			want:    true,
+		},
+		{
+			name:    "closed window",
+			windows: []vpa_types.MaintenanceWindow{{Schedule: "0 2 * * 6", Duration: meta.Duration{Duration: 20 * time.Minute}}},
+			want:    false,
+		},
+		{
+			name: "one of windows open",
+			windows: []vpa_types.MaintenanceWindow{
+				{Schedule: "0 3 * * *", Duration: meta.Duration{Duration: time.Hour}},
+				{Schedule: "0 22 * * 5", Duration: meta.Duration{Duration: 6 * time.Hour}},
+			},
+			want: true,
+		},
+		{
+			name:    "window in time zone",
+			windows: []vpa_types.MaintenanceWindow{{Schedule: "0 4 * * 6", Duration: meta.Duration{Duration: time.Hour}, TimeZone: &berlin}},
+			want:    true,
+		},
+		{
+			name:    "invalid window",
+			windows: []vpa_types.MaintenanceWindow{{Schedule: "0 2 * * 6", Duration: meta.Duration{Duration: time.Hour}, TimeZone: &invalidTimeZone}},
+			want:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpa := test.VerticalPodAutoscaler().WithContainer("container").Get()
+			vpa.Spec.UpdatePolicy = &vpa_types.PodUpdatePolicy{MaintenanceWindows: tc.windows}
+			assert.Equal(t, tc.want, InMaintenanceWindow(vpa, now))
+		})
+	}
+}